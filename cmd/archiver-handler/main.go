@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/archive"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+)
+
+// Handler manages the Archiver Lambda dependencies
+type Handler struct {
+	db      database.PaymentRepository
+	archive *archive.Client
+}
+
+// NewHandler creates a new archiver handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	if cfg.Retention.ArchiveBucket == "" {
+		return nil, fmt.Errorf("ARCHIVE_BUCKET is required")
+	}
+
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize S3 archive client
+	store, err := archive.NewClient(cfg.AWS.Region, cfg.Retention.ArchiveBucket, cfg.Retention.ArchivePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		db:      db,
+		archive: store,
+	}, nil
+}
+
+// HandleRequest exports terminal payments that have not been archived yet
+// Triggered nightly by an EventBridge schedule. Marking a payment archived starts its
+// retention clock, so nothing expires out of storage before it is in the archive.
+func (h *Handler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+	payments, err := h.db.ScanUnarchivedPayments(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Archiving terminal payments", logger.Fields{
+		"count": len(payments),
+	})
+
+	archived := 0
+	for _, payment := range payments {
+		if err := h.archive.ArchivePayment(ctx, payment); err != nil {
+			// Leave it unmarked so the next run retries
+			continue
+		}
+
+		if err := h.db.MarkPaymentArchived(ctx, payment.PaymentID, time.Now()); err != nil {
+			continue
+		}
+		archived++
+	}
+
+	logger.Info("Archive run complete", logger.Fields{
+		"archived": archived,
+		"failed":   len(payments) - archived,
+	})
+
+	if archived < len(payments) {
+		return fmt.Errorf("failed to archive %d of %d payments", len(payments)-archived, len(payments))
+	}
+
+	return nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.HandleRequest)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/outbox"
+)
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := outbox.NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda: DynamoDB streams the outbox table, other backends are swept on a schedule
+	if cfg.Storage.Backend == config.StorageDynamoDB {
+		lambda.Start(handler.HandleStream)
+		return
+	}
+	lambda.Start(handler.HandleSweep)
+}
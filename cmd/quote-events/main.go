@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/quotes"
+)
+
+// Handler manages the Quote Events Lambda dependencies
+type Handler struct {
+	queue *queue.Client
+	cfg   *config.Config
+}
+
+// NewHandler creates a new quote events handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	// Initialize queue client
+	q, err := queue.NewClient(cfg.AWS.Region, cfg.Queue.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		queue: q,
+		cfg:   cfg,
+	}, nil
+}
+
+// HandleStream turns quotes table changes into quote.expired and quote.consumed webhooks
+// (DynamoDB Streams trigger). TTL deletes can lag expiry by up to 48 hours, so integrators
+// should read expires_at from the event rather than its timestamp.
+func (h *Handler) HandleStream(ctx context.Context, event events.DynamoDBEvent) error {
+	for _, record := range event.Records {
+		webhook := quotes.StreamWebhookEvent(record)
+		if webhook == nil {
+			continue
+		}
+
+		// Returning an error retries the batch from this record
+		if err := h.queue.SendWebhookEvent(ctx, h.cfg.Queue.WebhookQueueURL, webhook); err != nil {
+			logger.Error("Failed to queue quote webhook", logger.Fields{
+				"error":      err.Error(),
+				"event_type": webhook.EventType,
+				"quote_id":   webhook.QuoteID,
+			})
+			return err
+		}
+
+		logger.Info("Quote webhook queued", logger.Fields{
+			"event_type": webhook.EventType,
+			"quote_id":   webhook.QuoteID,
+			"payment_id": webhook.PaymentID,
+		})
+	}
+
+	return nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda: only the DynamoDB quotes table has a stream to consume
+	lambda.Start(handler.HandleStream)
+}
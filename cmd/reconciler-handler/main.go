@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reconciliation"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// reconcileDetail is the optional event detail for re-running a specific day
+type reconcileDetail struct {
+	Date string `json:"date"` // YYYY-MM-DD
+}
+
+// Handler manages the Reconciler Lambda dependencies
+type Handler struct {
+	reconciler *reconciliation.Reconciler
+}
+
+// NewHandler creates a new reconciler handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	if !cfg.Reconciliation.Enabled {
+		return nil, fmt.Errorf("RECONCILIATION_ENABLED is required")
+	}
+	if cfg.Quotes.CircleAPIKey == "" {
+		return nil, fmt.Errorf("CIRCLE_API_KEY is required")
+	}
+
+	ctx := context.Background()
+
+	// Our side of each transfer comes from the payments that initiated it
+	payments, _, err := database.NewRepositories(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	breaks, err := database.NewReconciliationRepository(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := reconciliation.NewCircleStatement(cfg.Quotes.CircleAPIURL, cfg.Quotes.CircleAPIKey)
+	lookback := time.Duration(cfg.Reconciliation.LookbackDays) * 24 * time.Hour
+
+	return &Handler{
+		reconciler: reconciliation.NewReconciler(statement, payments, breaks, lookback),
+	}, nil
+}
+
+// HandleRequest reconciles the previous UTC day's Circle statement
+// Triggered nightly by an EventBridge schedule; an event with {"date": "YYYY-MM-DD"} in its detail re-runs that day
+func (h *Handler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+	day := time.Now().UTC().AddDate(0, 0, -1)
+
+	if len(event.Detail) > 0 {
+		var detail reconcileDetail
+		if err := json.Unmarshal(event.Detail, &detail); err != nil {
+			return fmt.Errorf("invalid event detail: %w", err)
+		}
+		if detail.Date != "" {
+			parsed, err := time.Parse(models.RevenueReportDateFormat, detail.Date)
+			if err != nil {
+				return fmt.Errorf("date must be YYYY-MM-DD: %w", err)
+			}
+			day = parsed
+		}
+	}
+
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	_, err := h.reconciler.Run(ctx, from, from.AddDate(0, 0, 1))
+	return err
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.HandleRequest)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reporting"
+)
+
+// reportDetail is the optional event detail for backfilling a specific day
+type reportDetail struct {
+	ReportDate string `json:"report_date"` // YYYY-MM-DD
+}
+
+// Handler manages the Reporter Lambda dependencies
+type Handler struct {
+	reporter *reporting.Reporter
+}
+
+// NewHandler creates a new reporter handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	if !cfg.FeeInvoices.Enabled {
+		return nil, fmt.Errorf("FEE_INVOICES_ENABLED is required")
+	}
+	if cfg.Reporting.Bucket == "" {
+		return nil, fmt.Errorf("REPORT_BUCKET is required")
+	}
+
+	ctx := context.Background()
+
+	// Revenue is built from the fee invoices issued on completion
+	invoices, err := database.NewFeeInvoiceRepository(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invoices only count while their payment is completed
+	payments, _, err := database.NewRepositories(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := database.NewRevenueReportRepository(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize S3 export
+	exporter, err := reporting.NewS3Exporter(cfg.AWS.Region, cfg.Reporting.Bucket, cfg.Reporting.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := reporting.NewReporter(invoices, payments, reports, exporter)
+
+	// Gas spend is read from the ledger's postings
+	if cfg.Ledger.Enabled {
+		ledger, err := database.NewLedgerRepository(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		reporter.EnableLedger(ledger)
+	}
+
+	return &Handler{
+		reporter: reporter,
+	}, nil
+}
+
+// HandleRequest reports the previous UTC day's revenue
+// Triggered nightly by an EventBridge schedule; an event with {"report_date": "YYYY-MM-DD"} in its detail re-runs that day
+func (h *Handler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+	day := time.Now().UTC().AddDate(0, 0, -1)
+
+	if len(event.Detail) > 0 {
+		var detail reportDetail
+		if err := json.Unmarshal(event.Detail, &detail); err != nil {
+			return fmt.Errorf("invalid event detail: %w", err)
+		}
+		if detail.ReportDate != "" {
+			parsed, err := time.Parse(models.RevenueReportDateFormat, detail.ReportDate)
+			if err != nil {
+				return fmt.Errorf("report_date must be YYYY-MM-DD: %w", err)
+			}
+			day = parsed
+		}
+	}
+
+	_, err := h.reporter.Run(ctx, day)
+	return err
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.HandleRequest)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/socket"
+	"crypto-conversion/internal/tracing"
+)
+
+// NewHandler creates the WebSocket API's connection handler and $connect authorizer
+func NewHandler(cfg *config.Config) (*socket.Connections, error) {
+	if cfg.Sockets.APIEndpoint == "" {
+		return nil, fmt.Errorf("SOCKET_API_ENDPOINT is required")
+	}
+
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := database.NewSocketSubscriptionRepository(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replies are posted back through the API's connection management endpoint
+	poster, err := socket.NewGatewayPoster(cfg.AWS.Region, cfg.Sockets.APIEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// The handler is also the $connect route's authorizer, checking the same keys and IP allowlists as the REST API
+	callers, err := apikeys.LoadCallers(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return socket.NewConnections(db, subs, poster, callers), nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Emit CloudWatch embedded metric format lines alongside the logs
+	metrics.SetDefault(metrics.New(os.Stdout, cfg.Metrics.Namespace, metrics.Dimensions{"Service": "socket-handler"}))
+
+	// Instrument AWS and HTTP clients before the handler constructs them
+	tracing.Configure(cfg.Tracing.Enabled)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.Handle)
+}
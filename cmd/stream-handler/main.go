@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambdaurl"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/stream"
+	"crypto-conversion/internal/tracing"
+)
+
+// NewHandler creates the payment status stream handler
+// It is served from a Lambda function URL in RESPONSE_STREAM mode, since API Gateway buffers responses.
+func NewHandler(cfg *config.Config) (*stream.Handler, error) {
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys stream.KeyAuthenticator
+	if cfg.Streams.RequireKey {
+		// Streams bypass API Gateway, so the handler checks the same keys and IP allowlists itself
+		callers, err := apikeys.LoadCallers(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		keys = callers
+	}
+
+	return stream.NewHandler(db, keys, stream.Config{
+		PollInterval: cfg.Streams.PollInterval,
+		MaxDuration:  cfg.Streams.MaxDuration,
+	}), nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Emit CloudWatch embedded metric format lines alongside the logs
+	metrics.SetDefault(metrics.New(os.Stdout, cfg.Metrics.Namespace, metrics.Dimensions{"Service": "stream-handler"}))
+
+	// Instrument AWS and HTTP clients before the handler constructs them
+	tracing.Configure(cfg.Tracing.Enabled)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambdaurl.Start(handler)
+}
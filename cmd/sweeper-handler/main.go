@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+)
+
+// Handler manages the Sweeper Lambda dependencies
+type Handler struct {
+	sweeper *payment.Sweeper
+}
+
+// NewHandler creates a new sweeper handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{sweeper: payment.NewSweeper(db, sweeperConfig(cfg))}, nil
+}
+
+// sweeperConfig builds the sweeper's settings from the stale-payment configuration
+func sweeperConfig(cfg *config.Config) payment.SweeperConfig {
+	sc := payment.SweeperConfig{
+		SLA:         cfg.StalePayments.SLA,
+		StatusSLAs:  make(map[models.PaymentStatus]time.Duration, len(cfg.StalePayments.StatusSLAs)),
+		MaxRequeues: cfg.StalePayments.MaxRequeues,
+	}
+	for status, sla := range cfg.StalePayments.StatusSLAs {
+		sc.StatusSLAs[models.PaymentStatus(status)] = sla
+	}
+
+	// A Step Functions execution is named by its payment ID and can't be started twice,
+	// so a stuck payment there is expired straight away rather than re-enqueued
+	if cfg.Orchestration.UseStepFunctions() {
+		sc.MaxRequeues = 0
+	}
+	return sc
+}
+
+// HandleRequest re-enqueues or expires payments stuck past their SLA
+// Triggered every few minutes by an EventBridge schedule.
+func (h *Handler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+	result, err := h.sweeper.Sweep(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Stale payment sweep complete", logger.Fields{
+		"requeued": result.Requeued,
+		"expired":  result.Expired,
+		"skipped":  result.Skipped,
+		"failed":   result.Failed,
+	})
+
+	if result.Failed > 0 {
+		return fmt.Errorf("failed to sweep %d stale payments", result.Failed)
+	}
+
+	return nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.HandleRequest)
+}
@@ -19,7 +19,12 @@ func main() {
 	}
 
 	// Create AI fee calculator
-	calc := fees.NewAIFeeCalculator(apiKey)
+	config := fees.DefaultLLMConfig()
+	llm, err := fees.NewLLMClient(config, apiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	calc := fees.NewAIFeeCalculator(llm, nil, nil, nil, config)
 
 	// Create test request for $1000 USD -> EUR
 	req := &fees.AIFeeRequest{
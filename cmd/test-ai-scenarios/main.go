@@ -27,7 +27,12 @@ func main() {
 	}
 
 	// Create AI fee calculator
-	calc := fees.NewAIFeeCalculator(apiKey)
+	config := fees.DefaultLLMConfig()
+	llm, err := fees.NewLLMClient(config, apiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	calc := fees.NewAIFeeCalculator(llm, nil, nil, nil, config)
 
 	// Define 5 different test scenarios
 	scenarios := []TestScenario{
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reporting"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// reportDetail is the optional event detail for re-exporting a specific month
+type reportDetail struct {
+	Period string `json:"period"` // YYYY-MM
+}
+
+// Handler manages the Usage Report Lambda dependencies
+type Handler struct {
+	reporter *reporting.UsageReporter
+}
+
+// NewHandler creates a new usage report handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	if cfg.Reporting.Bucket == "" {
+		return nil, fmt.Errorf("REPORT_BUCKET is required")
+	}
+
+	// Usage is metered by the API into the usage table
+	usage, err := database.NewUsageRepository(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize S3 export, which billing picks the month's CSV up from
+	exporter, err := reporting.NewS3Exporter(cfg.AWS.Region, cfg.Reporting.Bucket, cfg.Reporting.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		reporter: reporting.NewUsageReporter(usage, exporter),
+	}, nil
+}
+
+// HandleRequest exports the previous UTC month's usage
+// Triggered monthly by an EventBridge schedule; an event with {"period": "YYYY-MM"} in its detail re-runs that month
+func (h *Handler) HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	if len(event.Detail) > 0 {
+		var detail reportDetail
+		if err := json.Unmarshal(event.Detail, &detail); err != nil {
+			return fmt.Errorf("invalid event detail: %w", err)
+		}
+		if detail.Period != "" {
+			parsed, err := time.Parse(models.UsagePeriodFormat, detail.Period)
+			if err != nil {
+				return fmt.Errorf("period must be YYYY-MM: %w", err)
+			}
+			month = parsed
+		}
+	}
+
+	_, err := h.reporter.Run(ctx, month)
+	return err
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load configuration", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Initialize logger
+	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
+	logger.SetDefault(log)
+
+	// Create handler
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
+
+	// Start Lambda
+	lambda.Start(handler.HandleRequest)
+}
@@ -6,29 +6,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
 	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/schema"
+	"crypto-conversion/internal/tracing"
+	"crypto-conversion/internal/webhooks"
 )
 
 // Handler manages the Webhook Lambda dependencies
 type Handler struct {
-	httpClient *http.Client
-	cfg        *config.Config
+	httpClient    *http.Client
+	subscriptions *webhooks.Service     // nil unless webhook subscriptions are enabled
+	mtlsClients   *webhooks.ClientCache // nil unless webhook subscriptions are enabled
+	cfg           *config.Config
 }
 
 // NewHandler creates a new webhook handler
-func NewHandler(cfg *config.Config) *Handler {
-	return &Handler{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	h := &Handler{
+		httpClient: tracing.HTTPClient(&http.Client{
+			Timeout: webhooks.DeliveryTimeout,
+		}),
 		cfg: cfg,
 	}
+
+	// Customers' registered endpoints, some of which require a client certificate
+	if cfg.Webhooks.Enabled {
+		store, err := database.NewWebhookSubscriptionRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		deliveries, err := database.NewWebhookDeliveryRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		h.mtlsClients = webhooks.NewClientCache(cfg.AWS.Region, cfg.Webhooks.ClientCacheTTL)
+		h.subscriptions = webhooks.New(store, deliveries, h.mtlsClients)
+	}
+
+	return h, nil
 }
 
 // HandleRequest processes SQS messages containing webhook events
@@ -55,58 +79,179 @@ func (h *Handler) HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) e
 // processRecord processes a single webhook event
 func (h *Handler) processRecord(ctx context.Context, record events.SQSMessage) error {
 	// Parse webhook event from message body
-	var event models.WebhookEvent
-	if err := json.Unmarshal([]byte(record.Body), &event); err != nil {
+	decoded, err := schema.DecodeWebhookEvent([]byte(record.Body))
+	if err != nil {
 		logger.Error("Failed to unmarshal webhook event", logger.Fields{
 			"error": err.Error(),
 		})
 		return err
 	}
+	event := *decoded
 
 	logger.Info("Processing webhook event", logger.Fields{
+		"event_type": event.EventType,
 		"payment_id": event.PaymentID,
+		"quote_id":   event.QuoteID,
 		"status":     event.Status,
 	})
 
-	// In a real implementation, you would:
-	// 1. Fetch the webhook URL from the payment record or a separate configuration
-	// 2. Send the webhook with proper authentication/signing
-	// 3. Implement retry logic with exponential backoff
-	// 4. Track webhook delivery status
+	// In a real implementation, you would implement retry logic with exponential backoff
 
-	// For now, we'll simulate sending the webhook
-	if err := h.sendWebhook(ctx, event); err != nil {
-		logger.Error("Failed to send webhook", logger.Fields{
+	destinations, err := h.webhookDestinations(ctx, event)
+	if err != nil {
+		logger.Error("Failed to load webhook subscriptions", logger.Fields{
 			"error":      err.Error(),
 			"payment_id": event.PaymentID,
 		})
 		return err
 	}
 
-	logger.Info("Webhook sent successfully", logger.Fields{
-		"payment_id": event.PaymentID,
-		"status":     event.Status,
-	})
+	// Every destination is attempted even if an earlier one fails
+	var failed error
+	for _, dest := range destinations {
+		start := time.Now()
+		var statusCode int
+		err := tracing.Capture(ctx, "DeliverWebhook", func(ctx context.Context) error {
+			tracing.Annotate(ctx, "payment_id", event.PaymentID)
+			var err error
+			statusCode, err = h.sendWebhook(ctx, dest, event)
+			return err
+		})
+		elapsed := time.Since(start)
+		recordDelivery(elapsed, event, dest.kind, err)
+		h.trackDelivery(ctx, dest, event, elapsed, statusCode, err)
+		if err != nil {
+			logger.Error("Failed to send webhook", logger.Fields{
+				"error":           err.Error(),
+				"payment_id":      event.PaymentID,
+				"destination":     dest.kind,
+				"subscription_id": dest.subscriptionID,
+			})
+			failed = err
+			continue
+		}
 
-	return nil
+		logger.Info("Webhook sent successfully", logger.Fields{
+			"payment_id":      event.PaymentID,
+			"status":          event.Status,
+			"destination":     dest.kind,
+			"subscription_id": dest.subscriptionID,
+		})
+	}
+
+	return failed
+}
+
+// Webhook destination kinds
+const (
+	destinationSubscription = "subscription" // The customer's registered endpoint
+	destinationCallback     = "callback"     // The callback_url given when the payment was created
+)
+
+// subscriptionURL stands in for the customer's endpoint while webhook subscriptions are disabled
+const subscriptionURL = "https://example.com/webhook" // Placeholder
+
+// webhookDestination is an endpoint an event is delivered to
+type webhookDestination struct {
+	kind           string
+	url            string
+	subscriptionID string              // Set for registered endpoints
+	mtls           *models.WebhookMTLS // Client certificate the endpoint requires, if any
+	secrets        []string            // Secrets the delivery is signed with; registered endpoints only
+}
+
+// webhookDestinations returns where an event is delivered: the customer's subscriptions to its
+// type, and the payment's callback URL when it set one and no subscription already covers it
+func (h *Handler) webhookDestinations(ctx context.Context, event models.WebhookEvent) ([]webhookDestination, error) {
+	var destinations []webhookDestination
+	if h.subscriptions == nil {
+		destinations = append(destinations, webhookDestination{kind: destinationSubscription, url: subscriptionURL})
+	} else {
+		subs, err := h.subscriptions.Deliveries(ctx, event.CustomerID, event.EventType)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subs {
+			destinations = append(destinations, webhookDestination{
+				kind:           destinationSubscription,
+				url:            sub.URL,
+				subscriptionID: sub.SubscriptionID,
+				mtls:           sub.MTLS,
+				secrets:        sub.ActiveSecrets(time.Now()),
+			})
+		}
+	}
+
+	if event.CallbackURL == "" {
+		return destinations, nil
+	}
+	for _, dest := range destinations {
+		if dest.url == event.CallbackURL {
+			return destinations, nil
+		}
+	}
+	return append(destinations, webhookDestination{kind: destinationCallback, url: event.CallbackURL}), nil
+}
+
+// clientFor returns the HTTP client a destination is delivered with
+func (h *Handler) clientFor(ctx context.Context, dest webhookDestination) (*http.Client, error) {
+	if dest.mtls == nil || h.mtlsClients == nil {
+		return h.httpClient, nil
+	}
+	return h.mtlsClients.Client(ctx, dest.mtls)
 }
 
-// sendWebhook sends the webhook to the configured endpoint
-func (h *Handler) sendWebhook(ctx context.Context, event models.WebhookEvent) error {
-	// In production, fetch this from configuration or database
-	// For now, we'll just log the webhook payload
-	webhookURL := "https://example.com/webhook" // Placeholder
+// recordDelivery emits webhook delivery outcome and latency metrics
+func recordDelivery(elapsed time.Duration, event models.WebhookEvent, destination string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	dims := metrics.Dimensions{"Outcome": outcome, "EventType": event.EventType, "Destination": destination}
+	metrics.Count("WebhookDeliveries", dims)
+	metrics.Duration("WebhookDeliveryDuration", elapsed, dims)
+}
+
+// trackDelivery stores a delivery attempt to a subscription for its delivery statistics
+// Callback URLs aren't subscriptions, so their attempts only reach the metrics.
+func (h *Handler) trackDelivery(ctx context.Context, dest webhookDestination, event models.WebhookEvent, elapsed time.Duration, statusCode int, err error) {
+	if h.subscriptions == nil || dest.subscriptionID == "" {
+		return
+	}
+
+	delivery := models.NewWebhookDelivery(dest.subscriptionID, time.Now(), elapsed)
+	delivery.EventType = event.EventType
+	delivery.PaymentID = event.PaymentID
+	delivery.StatusCode = statusCode
+	delivery.Success = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	if err := h.subscriptions.RecordDelivery(ctx, delivery); err != nil {
+		logger.Warn("Failed to track webhook delivery", logger.Fields{
+			"error":           err.Error(),
+			"subscription_id": dest.subscriptionID,
+			"payment_id":      event.PaymentID,
+		})
+	}
+}
 
-	// Prepare webhook payload
+// sendWebhook sends the webhook to an endpoint, returning the status code it answered with
+func (h *Handler) sendWebhook(ctx context.Context, dest webhookDestination, event models.WebhookEvent) (int, error) {
+	// Prepare webhook payload; where and to whom it is delivered isn't part of it
+	event.CallbackURL = ""
+	event.CustomerID = ""
 	payload, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		return 0, fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
 	logger.Info("Sending webhook", logger.Fields{
-		"url":        webhookURL,
+		"url":        dest.url,
 		"payment_id": event.PaymentID,
 		"status":     event.Status,
+		"mtls":       dest.mtls != nil,
 	})
 
 	// In a real implementation, send the actual HTTP request
@@ -116,44 +261,46 @@ func (h *Handler) sendWebhook(ctx context.Context, event models.WebhookEvent) er
 	})
 
 	// Example of how to send in production:
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dest.url, bytes.NewBuffer(payload))
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.EventType)
 	req.Header.Set("X-Payment-ID", event.PaymentID)
 	req.Header.Set("X-Payment-Status", string(event.Status))
-	// Add signature header for webhook verification
-	// req.Header.Set("X-Webhook-Signature", generateSignature(payload))
-
-	// Uncomment in production to actually send the webhook:
-	// resp, err := h.httpClient.Do(req)
-	// if err != nil {
-	// 	return fmt.Errorf("failed to send webhook: %w", err)
-	// }
-	// defer resp.Body.Close()
-	//
-	// if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-	// 	return fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
-	// }
-
-	logger.Info("Webhook would be sent (mocked in development)", logger.Fields{
-		"payment_id": event.PaymentID,
-		"url":        webhookURL,
-	})
+	if event.QuoteID != "" {
+		req.Header.Set("X-Quote-ID", event.QuoteID)
+	}
+	if len(dest.secrets) > 0 {
+		req.Header.Set(webhooks.SignatureHeader, webhooks.Sign(dest.secrets, time.Now(), payload))
+	}
 
-	return nil
-}
+	// Without registered subscriptions there is no real endpoint to deliver to, so delivery is only logged
+	if h.subscriptions == nil {
+		logger.Info("Webhook would be sent (mocked in development)", logger.Fields{
+			"payment_id": event.PaymentID,
+			"url":        dest.url,
+		})
+		return 0, nil
+	}
+
+	client, err := h.clientFor(ctx, dest)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
 
-// generateSignature generates an HMAC signature for webhook verification
-// This is a placeholder - implement proper HMAC-SHA256 signing in production
-func generateSignature(payload []byte) string {
-	// Example:
-	// h := hmac.New(sha256.New, []byte(webhookSecret))
-	// h.Write(payload)
-	// return hex.EncodeToString(h.Sum(nil))
-	return "signature-placeholder"
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
 }
 
 func main() {
@@ -166,10 +313,24 @@ func main() {
 
 	// Initialize logger
 	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
 	logger.SetDefault(log)
 
+	// Emit CloudWatch embedded metric format lines alongside the logs
+	metrics.SetDefault(metrics.New(os.Stdout, cfg.Metrics.Namespace, metrics.Dimensions{"Service": "webhook-handler"}))
+	if cfg.Metrics.PrometheusAddr != "" {
+		metrics.ServePrometheus(cfg.Metrics.PrometheusAddr)
+	}
+
+	// Instrument AWS and HTTP clients before the handler constructs them
+	tracing.Configure(cfg.Tracing.Enabled)
+
 	// Create handler
-	handler := NewHandler(cfg)
+	handler, err := NewHandler(cfg)
+	if err != nil {
+		logger.Error("Failed to create handler", logger.Fields{"error": err.Error()})
+		panic(err)
+	}
 
 	// Start Lambda
 	lambda.Start(handler.HandleRequest)
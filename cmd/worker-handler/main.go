@@ -2,31 +2,43 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"time"
+	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"crypto-conversion/internal/audit"
+	"crypto-conversion/internal/chainwatch"
+	"crypto-conversion/internal/chaos"
+	"crypto-conversion/internal/compliance/sanctions"
 	"crypto-conversion/internal/config"
 	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/eventbus"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/ledger"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
 	"crypto-conversion/internal/models"
 	"crypto-conversion/internal/payment"
 	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/quotes"
+	"crypto-conversion/internal/schema"
+	"crypto-conversion/internal/socket"
+	"crypto-conversion/internal/tracing"
 )
 
 // Handler manages the Worker Lambda dependencies
 type Handler struct {
-	db           *database.Client
-	queue        *queue.Client
-	stateMachine *payment.StateMachine
-	cfg          *config.Config
+	db            database.PaymentRepository
+	audit         *audit.Logger
+	stateMachine  *payment.StateMachine
+	stepFunctions *payment.StepFunctionsOrchestrator
+	cfg           *config.Config
 }
 
 // NewHandler creates a new worker handler
 func NewHandler(cfg *config.Config) (*Handler, error) {
-	// Initialize database client
-	db, err := database.NewClient(cfg.AWS.Region, cfg.Database.TableName, cfg.Database.Endpoint)
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -41,18 +53,223 @@ func NewHandler(cfg *config.Config) (*Handler, error) {
 	queueAdapter := queue.NewQueueAdapter(q, cfg.Queue.PaymentQueueURL)
 
 	// Initialize stateful mock clients for async polling
-	onRamp := payment.NewStatefulOnRampClient()
-	offRamp := payment.NewStatefulOffRampClient()
+	var (
+		onRamp  payment.OnRampProvider  = payment.NewStatefulOnRampClient()
+		offRamp payment.OffRampProvider = payment.NewStatefulOffRampClient()
+		bridge  payment.BridgeProvider  = payment.NewStatefulBridgeClient() // CCTP, for off-ramps that redeem on another chain
+		wallet  payment.WalletProvider  = payment.NewStatefulWalletClient() // Treasury wallet, for wallet payouts
+	)
+	var sandbox payment.SandboxProviders = payment.NewSandbox() // Deterministic ramps for sandbox payments
+
+	// Exponential backoff for settlement polling (chain defaults, env overrides)
+	polling := payment.DefaultPollingConfig()
+	polling.InitialDelaySeconds = cfg.Polling.InitialDelaySeconds
+	polling.MaxDelaySeconds = cfg.Polling.MaxDelaySeconds
+	polling.Multiplier = cfg.Polling.Multiplier
+	polling.MaxPollAttempts = cfg.Polling.MaxPollAttempts
+	polling.MaxStageDuration = cfg.Polling.MaxStageDuration
+
+	// Lifecycle events go to EventBridge when a bus is configured
+	var events payment.EventPublisher
+	if cfg.Events.BusName != "" {
+		events, err = eventbus.NewClient(cfg.AWS.Region, cfg.Events.BusName, cfg.Events.Source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// State transitions are chained into the audit log when enabled
+	var auditLogger *audit.Logger
+	var auditLog payment.AuditRecorder
+	if cfg.Audit.Enabled {
+		auditRepo, err := database.NewAuditRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		auditLogger = audit.NewLogger(auditRepo)
+		auditLog = auditLogger
+	}
+
+	// Execution-time slippage check against the same rate providers that quote
+	registry, err := database.NewCorridorRegistry(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	rateProviders, err := quotes.NewRateProviders(cfg.Quotes.RateProviders, cfg.Quotes.CircleAPIURL, cfg.Quotes.CircleAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sandbox.Enabled {
+		rateProviders = nil // Sandbox rates are simulated
+	}
+	var rates payment.RateSource = quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), registry, rateProviders)
+	slippage := payment.SlippageConfig{
+		MaxSlippage: cfg.Slippage.MaxSlippage,
+		Action:      payment.SlippageAction(cfg.Slippage.Action),
+	}
+
+	// Completed payments count toward customer volume discounts when enabled
+	var volumes database.VolumeRepository
+	if cfg.VolumeDiscounts.Enabled {
+		volumes, err = database.NewVolumeRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Completed payments get an immutable fee invoice when enabled
+	var invoices database.FeeInvoiceRepository
+	if cfg.FeeInvoices.Enabled {
+		invoices, err = database.NewFeeInvoiceRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Payments move treasury balances, and wait for float before paying out, when enabled
+	var treasury database.TreasuryRepository
+	if cfg.Treasury.Enabled {
+		treasury, err = database.NewTreasuryRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	treasuryThresholds := models.TreasuryThresholds(cfg.Treasury.LowBalanceThresholds)
+
+	// Every leg that moves money is posted to the double-entry ledger when enabled
+	var paymentLedger *ledger.Ledger
+	if cfg.Ledger.Enabled {
+		store, err := database.NewLedgerRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		paymentLedger = ledger.New(store)
+	}
+	gasCosts := ledger.GasCosts(cfg.Ledger.GasCosts)
+
+	// Stages wait for their on-chain transactions to reach finality when enabled
+	var chainWatch payment.ConfirmationWatcher
+	if cfg.ChainWatch.Enabled {
+		chainWatch = chainwatch.NewFromConfig(cfg.ChainWatch.RPCURLs, cfg.ChainWatch.Confirmations)
+	}
+
+	// Payments are screened against sanctions lists again before funds leave for the beneficiary
+	var screener *sanctions.List
+	if cfg.Sanctions.Enabled {
+		screener, err = sanctions.Load(context.Background(), cfg.Sanctions.SDNURL, cfg.Sanctions.Countries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each status a payment enters is pushed to the WebSocket clients subscribed to it when enabled
+	var notifier *socket.Notifier
+	if cfg.Sockets.APIEndpoint != "" {
+		subs, err := database.NewSocketSubscriptionRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		poster, err := socket.NewGatewayPoster(cfg.AWS.Region, cfg.Sockets.APIEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		notifier = socket.NewNotifier(subs, poster)
+	}
+
+	// The state machine's own calls fail or stall on purpose when chaos testing is enabled
+	var stateDB payment.DatabaseClient = db
+	var stateQueue payment.QueueClient = queueAdapter
+	if cfg.Chaos.Enabled {
+		injector := chaos.NewFromConfig(cfg.Chaos)
+		stateDB = chaos.Database(db, injector)
+		stateQueue = chaos.Queue(queueAdapter, injector)
+		onRamp = chaos.OnRamp(onRamp, injector)
+		offRamp = chaos.OffRamp(offRamp, injector)
+		bridge = chaos.Bridge(bridge, injector)
+		wallet = chaos.Wallet(wallet, injector)
+		sandbox = chaos.Sandbox(sandbox, injector)
+		rates = chaos.RateSource(rates, injector)
+		if chainWatch != nil {
+			chainWatch = chaos.ConfirmationWatcher(chainWatch, injector)
+		}
+		logger.Warn("Chaos fault injection enabled", logger.Fields{
+			"failure_rates": cfg.Chaos.FailureRates,
+			"delay_rates":   cfg.Chaos.DelayRates,
+			"payment_ids":   cfg.Chaos.Payments,
+		})
+	}
 
 	// Create state machine orchestrator
-	stateMachine := payment.NewStateMachine(onRamp, offRamp, db, queueAdapter)
+	stateMachine := payment.NewStateMachine(onRamp, offRamp, stateDB, stateQueue, polling, events, auditLog, rates, slippage)
+	stateMachine.EnableBridging(bridge)
+	stateMachine.EnableWalletPayouts(wallet)
+	stateMachine.EnableSandbox(sandbox)
+	if chainWatch != nil {
+		stateMachine.EnableChainWatch(chainWatch)
+	}
+	if volumes != nil {
+		stateMachine.EnableVolumeTracking(volumes)
+	}
+	if invoices != nil {
+		stateMachine.EnableFeeInvoices(invoices)
+	}
+	if treasury != nil {
+		stateMachine.EnableTreasury(treasury, treasuryThresholds)
+	}
+	if paymentLedger != nil {
+		stateMachine.EnableLedger(paymentLedger, gasCosts)
+	}
+	if screener != nil {
+		stateMachine.EnableScreening(screener)
+	}
+	if notifier != nil {
+		stateMachine.EnableStatusNotifications(notifier)
+	}
 
-	return &Handler{
+	handler := &Handler{
 		db:           db,
-		queue:        q,
+		audit:        auditLogger,
 		stateMachine: stateMachine,
 		cfg:          cfg,
-	}, nil
+	}
+
+	// Step Functions mode runs the same state machine, capturing re-enqueues as waits
+	if cfg.Orchestration.UseStepFunctions() {
+		handler.stepFunctions, err = payment.NewStepFunctionsOrchestrator(cfg.AWS.Region, cfg.Orchestration.StateMachineARN, db,
+			func(queue payment.QueueClient) *payment.StateMachine {
+				sm := payment.NewStateMachine(onRamp, offRamp, stateDB, queue, polling, events, auditLog, rates, slippage)
+				sm.EnableBridging(bridge)
+				sm.EnableWalletPayouts(wallet)
+				sm.EnableSandbox(sandbox)
+				if chainWatch != nil {
+					sm.EnableChainWatch(chainWatch)
+				}
+				if volumes != nil {
+					sm.EnableVolumeTracking(volumes)
+				}
+				if invoices != nil {
+					sm.EnableFeeInvoices(invoices)
+				}
+				if treasury != nil {
+					sm.EnableTreasury(treasury, treasuryThresholds)
+				}
+				if paymentLedger != nil {
+					sm.EnableLedger(paymentLedger, gasCosts)
+				}
+				if screener != nil {
+					sm.EnableScreening(screener)
+				}
+				if notifier != nil {
+					sm.EnableStatusNotifications(notifier)
+				}
+				return sm
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return handler, nil
 }
 
 // HandleRequest processes SQS messages containing payment jobs
@@ -79,8 +296,8 @@ func (h *Handler) HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) e
 // processRecord processes a single SQS record
 func (h *Handler) processRecord(ctx context.Context, record events.SQSMessage) error {
 	// Parse payment job from message body
-	var job models.PaymentJob
-	if err := json.Unmarshal([]byte(record.Body), &job); err != nil {
+	job, err := schema.DecodePaymentJob([]byte(record.Body))
+	if err != nil {
 		logger.Error("Failed to unmarshal payment job", logger.Fields{
 			"error": err.Error(),
 		})
@@ -95,90 +312,45 @@ func (h *Handler) processRecord(ctx context.Context, record events.SQSMessage) e
 
 	// Process payment through state machine
 	// State machine handles state transitions, re-enqueuing, and error handling
-	if err := h.stateMachine.ProcessPayment(ctx, &job); err != nil {
+	err = tracing.Capture(ctx, "ProcessPayment", func(ctx context.Context) error {
+		tracing.Annotate(ctx, "payment_id", job.PaymentID)
+		return h.stateMachine.ProcessPayment(chaos.WithPaymentID(ctx, job.PaymentID), job)
+	})
+	if err != nil {
 		logger.Error("State machine processing failed", logger.Fields{
 			"error":      err.Error(),
 			"payment_id": job.PaymentID,
 		})
 
-		// Send webhook notification for failure if in terminal state
-		payment, _ := h.db.GetPaymentByID(ctx, job.PaymentID)
-		if payment != nil && payment.Status == models.StatusFailed {
-			h.sendWebhookNotification(ctx, job.PaymentID, models.StatusFailed, payment.OnRampTxID, payment.OffRampTxID, payment.ErrorMessage)
-		}
-
 		return err
 	}
 
-	// Check if payment reached terminal state and send webhook
-	payment, err := h.db.GetPaymentByID(ctx, job.PaymentID)
-	if err == nil {
-		if payment.Status == models.StatusCompleted {
-			h.sendWebhookNotification(ctx, job.PaymentID, models.StatusCompleted, payment.OnRampTxID, payment.OffRampTxID, "")
-			logger.Info("Payment completed successfully", logger.Fields{
-				"payment_id": job.PaymentID,
-				"onramp_polls": payment.OnRampPollCount,
-				"offramp_polls": payment.OffRampPollCount,
-			})
-		}
-	}
-
+	// Terminal webhooks are queued through the outbox with the final status update
 	return nil
 }
 
-// sendWebhookNotification sends a webhook event to the webhook queue
-func (h *Handler) sendWebhookNotification(ctx context.Context, paymentID string, status models.PaymentStatus, onRampTxID, offRampTxID, errorMsg string) {
-	// Fetch full payment details
-	payment, err := h.db.GetPaymentByID(ctx, paymentID)
+// HandleStepTask processes a Step Functions task invocation
+func (h *Handler) HandleStepTask(ctx context.Context, input payment.StepInput) (*payment.StepOutput, error) {
+	var output *payment.StepOutput
+	err := tracing.Capture(ctx, "StepTask", func(ctx context.Context) error {
+		tracing.Annotate(ctx, "action", input.Action)
+		if input.Job != nil {
+			tracing.Annotate(ctx, "payment_id", input.Job.PaymentID)
+			ctx = chaos.WithPaymentID(ctx, input.Job.PaymentID)
+		}
+		var err error
+		output, err = h.stepFunctions.HandleTask(ctx, &input)
+		return err
+	})
 	if err != nil {
-		logger.Error("Failed to fetch payment for webhook", logger.Fields{
-			"error":      err.Error(),
-			"payment_id": paymentID,
+		logger.Error("Step Functions task failed", logger.Fields{
+			"error":  err.Error(),
+			"action": input.Action,
 		})
-		return
-	}
-
-	// Determine event type
-	eventType := "payment.completed"
-	if status == models.StatusFailed {
-		eventType = "payment.failed"
-	}
-
-	// Create webhook event with fee information
-	event := &models.WebhookEvent{
-		EventType:   eventType,
-		PaymentID:   paymentID,
-		Status:      status,
-		Amount:      payment.Amount,
-		Currency:    payment.Currency,
-		OnRampTxID:  onRampTxID,
-		OffRampTxID: offRampTxID,
-		Error:       errorMsg,
-		Timestamp:   time.Now(),
-	}
-
-	// Include fee information if available
-	if payment.FeeAmount > 0 {
-		event.Fees = &models.FeeBreakdown{
-			Amount:   payment.FeeAmount,
-			Currency: payment.FeeCurrency,
-		}
+		return nil, err
 	}
 
-	// Send to webhook queue
-	if err := h.queue.SendWebhookEvent(ctx, h.cfg.Queue.WebhookQueueURL, event); err != nil {
-		logger.Error("Failed to send webhook event", logger.Fields{
-			"error":      err.Error(),
-			"payment_id": paymentID,
-		})
-		// We don't return error here as the payment is processed successfully
-		// Webhook delivery failure should be handled separately
-	} else {
-		logger.Info("Webhook event sent", logger.Fields{
-			"payment_id": paymentID,
-			"status":     status,
-		})
-	}
+	return output, nil
 }
 
 func main() {
@@ -191,8 +363,18 @@ func main() {
 
 	// Initialize logger
 	log := logger.NewFromString(cfg.Logging.Level)
+	log.MaskFields(cfg.Logging.MaskFields)
 	logger.SetDefault(log)
 
+	// Emit CloudWatch embedded metric format lines alongside the logs
+	metrics.SetDefault(metrics.New(os.Stdout, cfg.Metrics.Namespace, metrics.Dimensions{"Service": "worker-handler"}))
+	if cfg.Metrics.PrometheusAddr != "" {
+		metrics.ServePrometheus(cfg.Metrics.PrometheusAddr)
+	}
+
+	// Instrument AWS and HTTP clients before the handler constructs them
+	tracing.Configure(cfg.Tracing.Enabled)
+
 	// Create handler
 	handler, err := NewHandler(cfg)
 	if err != nil {
@@ -200,6 +382,17 @@ func main() {
 		panic(err)
 	}
 
+	// Record the configuration this instance started with
+	if handler.audit != nil {
+		if _, err := handler.audit.RecordConfigLoaded(context.Background(), "worker-handler", cfg.AuditSnapshot()); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"error": err.Error()})
+		}
+	}
+
 	// Start Lambda
+	if cfg.Orchestration.UseStepFunctions() {
+		lambda.Start(handler.HandleStepTask)
+		return
+	}
 	lambda.Start(handler.HandleRequest)
 }
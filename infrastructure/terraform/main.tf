@@ -46,6 +46,13 @@ resource "aws_dynamodb_table" "payments" {
     projection_type = "ALL"
   }
 
+  # TTL configuration - terminal payments expire after the retention period
+  # (archived to S3 first by the archiver Lambda)
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
   point_in_time_recovery {
     enabled = var.enable_point_in_time_recovery
   }
@@ -59,11 +66,681 @@ resource "aws_dynamodb_table" "payments" {
   }
 }
 
+# KMS key wrapping the data keys that encrypt payment account identifiers (used when FIELD_ENCRYPTION_KMS_KEY_ID is set)
+# Yearly automatic rotation keeps old key material, so values written before a rotation still decrypt
+resource "aws_kms_key" "payment_fields" {
+  description             = "${var.project_name} payment account identifiers (${var.environment})"
+  enable_key_rotation     = true
+  deletion_window_in_days = 30
+}
+
+resource "aws_kms_alias" "payment_fields" {
+  name          = "alias/${var.project_name}-payment-fields-${var.environment}"
+  target_key_id = aws_kms_key.payment_fields.key_id
+}
+
+# DynamoDB Table for the transactional outbox
+# Streamed to the outbox relay Lambda, which publishes to SQS and deletes delivered messages
+resource "aws_dynamodb_table" "outbox" {
+  name             = "${var.project_name}-outbox-${var.environment}"
+  billing_mode     = "PAY_PER_REQUEST"
+  hash_key         = "message_id"
+  stream_enabled   = true
+  stream_view_type = "NEW_IMAGE"
+
+  attribute {
+    name = "message_id"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-outbox-${var.environment}"
+  }
+}
+
+# DynamoDB Table for idempotency key claims
+# One item per API key and idempotency key; scoped_key is "<api_key_id>#<idempotency_key>"
+resource "aws_dynamodb_table" "idempotency_keys" {
+  name         = "${var.project_name}-idempotency-keys-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "scoped_key"
+
+  attribute {
+    name = "scoped_key"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-idempotency-keys-${var.environment}"
+  }
+}
+
+# DynamoDB Table for the audit log
+# All entries share one partition ("chain") ordered by sequence; each entry hashes its predecessor
+resource "aws_dynamodb_table" "audit_log" {
+  name         = "${var.project_name}-audit-log-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "chain"
+  range_key    = "sequence"
+
+  attribute {
+    name = "chain"
+    type = "S"
+  }
+
+  attribute {
+    name = "sequence"
+    type = "N"
+  }
+
+  point_in_time_recovery {
+    enabled = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-audit-log-${var.environment}"
+  }
+}
+
+# DynamoDB Table for corridor definitions (read by the API when CORRIDOR_TABLE is set)
+resource "aws_dynamodb_table" "corridors" {
+  name         = "${var.project_name}-corridors-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "corridor_id"
+
+  attribute {
+    name = "corridor_id"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-corridors-${var.environment}"
+  }
+}
+
+# DynamoDB Table for market data shared across Lambda instances (used when MARKET_DATA_CACHE_TABLE is set)
+# Items expire after the 2-minute cache duration
+resource "aws_dynamodb_table" "market_data_cache" {
+  name         = "${var.project_name}-market-data-cache-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "cache_key"
+
+  attribute {
+    name = "cache_key"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-market-data-cache-${var.environment}"
+  }
+}
+
+# DynamoDB Table for shadow-mode fee comparisons (used when FEE_SHADOW_ENABLED is set)
+# One item per payment: the static fee charged and the AI engine's fee for the same payment
+resource "aws_dynamodb_table" "fee_comparisons" {
+  name         = "${var.project_name}-fee-comparisons-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "payment_id"
+
+  attribute {
+    name = "payment_id"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-fee-comparisons-${var.environment}"
+  }
+}
+
+# DynamoDB Table for fee schedules (used when FEE_SCHEDULES_ENABLED is set)
+# Every effective-dated version of the default and per-corridor schedules, written by the admin API
+resource "aws_dynamodb_table" "fee_schedules" {
+  name         = "${var.project_name}-fee-schedules-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "schedule_id"
+  range_key    = "version"
+
+  attribute {
+    name = "schedule_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "version"
+    type = "N"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-fee-schedules-${var.environment}"
+  }
+}
+
+# DynamoDB Table for promo codes (used when PROMOS_ENABLED is set)
+# One item per code; payments increment uses with a conditional update against max_uses
+resource "aws_dynamodb_table" "promos" {
+  name         = "${var.project_name}-promos-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "code"
+
+  attribute {
+    name = "code"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-promos-${var.environment}"
+  }
+}
+
+# DynamoDB Table for customer volume (used when VOLUME_DISCOUNTS_ENABLED is set)
+# One item per completed payment, expired by TTL once it leaves the 30-day window
+resource "aws_dynamodb_table" "customer_volumes" {
+  name         = "${var.project_name}-customer-volumes-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "customer_id"
+  range_key    = "payment_id"
+
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "payment_id"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-customer-volumes-${var.environment}"
+  }
+}
+
+# DynamoDB Table for gas price history (used when GAS_HISTORY_ENABLED is set)
+# One item per chain and reading, expired by TTL after 7 days
+resource "aws_dynamodb_table" "gas_prices" {
+  name         = "${var.project_name}-gas-prices-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "chain"
+  range_key    = "recorded_at"
+
+  attribute {
+    name = "chain"
+    type = "S"
+  }
+
+  attribute {
+    name = "recorded_at"
+    type = "N"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-gas-prices-${var.environment}"
+  }
+}
+
+# DynamoDB Table for fee invoices (used when FEE_INVOICES_ENABLED is set)
+# One item per completed payment, written once and never updated
+resource "aws_dynamodb_table" "fee_invoices" {
+  name         = "${var.project_name}-fee-invoices-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "payment_id"
+
+  attribute {
+    name = "payment_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-fee-invoices-${var.environment}"
+  }
+}
+
+# DynamoDB Table for treasury balances (used when TREASURY_ENABLED is set)
+# One item per chain or provider account, plus one "movement#<id>" item per applied movement
+resource "aws_dynamodb_table" "treasury" {
+  name         = "${var.project_name}-treasury-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "account"
+
+  attribute {
+    name = "account"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-treasury-${var.environment}"
+  }
+}
+
+# DynamoDB Table for the double-entry ledger (used when LEDGER_ENABLED is set)
+# One item per payment leg, written once; entries are stored on the item
+resource "aws_dynamodb_table" "ledger" {
+  name         = "${var.project_name}-ledger-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "payment_id"
+  range_key    = "transaction_id"
+
+  attribute {
+    name = "payment_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "transaction_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-ledger-${var.environment}"
+  }
+}
+
+# DynamoDB Table for reconciliation breaks (written by the reconciler Lambda, resolved through the API)
+# One item per break; break_id is "<provider>:<tx_id>:<kind>"
+resource "aws_dynamodb_table" "reconciliation_breaks" {
+  name         = "${var.project_name}-reconciliation-breaks-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "break_id"
+
+  attribute {
+    name = "break_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-reconciliation-breaks-${var.environment}"
+  }
+}
+
+# DynamoDB Table for customer records (managed through the API's /internal/customers endpoints)
+# One item per customer; customer_id is the customer's API Gateway key ID
+resource "aws_dynamodb_table" "customers" {
+  name         = "${var.project_name}-customers-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "customer_id"
+
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-customers-${var.environment}"
+  }
+}
+
+# DynamoDB Table for API keys issued through the API's /api-keys endpoints
+# One item per key; key_hash is the SHA-256 of its secret, which is never stored
+resource "aws_dynamodb_table" "api_keys" {
+  name         = "${var.project_name}-api-keys-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "key_hash"
+
+  attribute {
+    name = "key_hash"
+    type = "S"
+  }
+
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  # Lists a customer's keys for rotation and revocation
+  global_secondary_index {
+    name            = "customer-id-index"
+    hash_key        = "customer_id"
+    projection_type = "ALL"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-api-keys-${var.environment}"
+  }
+}
+
+# DynamoDB Table for usage metering (used when USAGE_METERING_ENABLED is set)
+# One item per customer, month, metric and currency; usage_key is "<YYYY-MM>#<metric>[#<currency>]"
+resource "aws_dynamodb_table" "usage" {
+  name         = "${var.project_name}-usage-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "customer_id"
+  range_key    = "usage_key"
+
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "usage_key"
+    type = "S"
+  }
+
+  attribute {
+    name = "period"
+    type = "S"
+  }
+
+  # Lists every customer's usage for a month, for the billing export
+  global_secondary_index {
+    name            = "period-index"
+    hash_key        = "period"
+    projection_type = "ALL"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-usage-${var.environment}"
+  }
+}
+
+# DynamoDB Table for payment quotas (used when QUOTAS_ENABLED is set)
+# One item per customer and window; window_key is "daily#<YYYY-MM-DD>" or "monthly#<YYYY-MM>"
+resource "aws_dynamodb_table" "quotas" {
+  name         = "${var.project_name}-quotas-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "customer_id"
+  range_key    = "window_key"
+
+  attribute {
+    name = "customer_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "window_key"
+    type = "S"
+  }
+
+  # A window's counter is deleted a day after the window resets
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-quotas-${var.environment}"
+  }
+}
+
+# DynamoDB Table for AML monitoring alerts (raised by the API on payment creation, closed through the API)
+# One item per alert; alert_id is "<payment_id>:<rule>"
+resource "aws_dynamodb_table" "aml_alerts" {
+  name         = "${var.project_name}-aml-alerts-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "alert_id"
+
+  attribute {
+    name = "alert_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-aml-alerts-${var.environment}"
+  }
+}
+
+# DynamoDB Table for webhook subscriptions (registered through the API's /webhooks endpoints)
+# One item per subscription; customer_id is the owning API Gateway key ID
+resource "aws_dynamodb_table" "webhook_subscriptions" {
+  name         = "${var.project_name}-webhook-subscriptions-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "subscription_id"
+
+  attribute {
+    name = "subscription_id"
+    type = "S"
+  }
+
+  point_in_time_recovery {
+    enabled = var.enable_point_in_time_recovery
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-webhook-subscriptions-${var.environment}"
+  }
+}
+
+# DynamoDB Table for WebSocket subscriptions (written by the socket Lambda, read by the worker to push transitions)
+# One item per connection and payment; expires_at bounds it to the connection's two-hour lifetime
+resource "aws_dynamodb_table" "socket_subscriptions" {
+  name         = "${var.project_name}-socket-subscriptions-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "payment_id"
+  range_key    = "connection_id"
+
+  attribute {
+    name = "payment_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "connection_id"
+    type = "S"
+  }
+
+  # A closed connection's subscriptions are deleted together
+  global_secondary_index {
+    name            = "connection-id-index"
+    hash_key        = "connection_id"
+    projection_type = "KEYS_ONLY"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-socket-subscriptions-${var.environment}"
+  }
+}
+
+# DynamoDB Table for webhook delivery attempts (written by the webhook Lambda, summarized by GET /webhooks/{id}/stats)
+# One item per attempt; delivered_ns is the attempt time in Unix nanoseconds. Attempts expire after 7 days.
+resource "aws_dynamodb_table" "webhook_deliveries" {
+  name         = "${var.project_name}-webhook-deliveries-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "subscription_id"
+  range_key    = "delivered_ns"
+
+  attribute {
+    name = "subscription_id"
+    type = "S"
+  }
+
+  attribute {
+    name = "delivered_ns"
+    type = "N"
+  }
+
+  ttl {
+    attribute_name = "ttl"
+    enabled        = true
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-webhook-deliveries-${var.environment}"
+  }
+}
+
+# DynamoDB Table for the daily revenue report (written by the reporter Lambda)
+# One item per day, corridor and customer; report_key is "<corridor>#<customer_id>"
+resource "aws_dynamodb_table" "revenue_reports" {
+  name         = "${var.project_name}-revenue-reports-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "report_date"
+  range_key    = "report_key"
+
+  attribute {
+    name = "report_date"
+    type = "S"
+  }
+
+  attribute {
+    name = "report_key"
+    type = "S"
+  }
+
+  server_side_encryption {
+    enabled = true
+  }
+
+  tags = {
+    Name = "${var.project_name}-revenue-reports-${var.environment}"
+  }
+}
+
 # DynamoDB Table for Quotes
+# Streamed to the quote events Lambda, which emits quote.expired (TTL deletes) and quote.consumed webhooks
 resource "aws_dynamodb_table" "quotes" {
-  name           = "${var.project_name}-quotes-${var.environment}"
-  billing_mode   = "PAY_PER_REQUEST"
-  hash_key       = "quote_id"
+  name             = "${var.project_name}-quotes-${var.environment}"
+  billing_mode     = "PAY_PER_REQUEST"
+  hash_key         = "quote_id"
+  stream_enabled   = true
+  stream_view_type = "NEW_AND_OLD_IMAGES"
 
   attribute {
     name = "quote_id"
@@ -177,6 +854,11 @@ module "lambda_functions" {
   api_handler_log_group_arn     = aws_cloudwatch_log_group.api_handler.arn
   worker_handler_log_group_arn  = aws_cloudwatch_log_group.worker_handler.arn
   webhook_handler_log_group_arn = aws_cloudwatch_log_group.webhook_handler.arn
+  llm_provider                  = var.llm_provider
+  socket_table_name             = aws_dynamodb_table.socket_subscriptions.name
+  socket_table_arn              = aws_dynamodb_table.socket_subscriptions.arn
+  socket_api_endpoint           = "https://${aws_apigatewayv2_api.sockets.id}.execute-api.${var.aws_region}.amazonaws.com/${var.environment}"
+  socket_api_execution_arn      = aws_apigatewayv2_api.sockets.execution_arn
 }
 
 module "api_gateway" {
@@ -186,6 +868,111 @@ module "api_gateway" {
   environment             = var.environment
   api_handler_invoke_arn  = module.lambda_functions.api_handler_invoke_arn
   api_handler_function_name = module.lambda_functions.api_handler_function_name
+  socket_api_id             = aws_apigatewayv2_api.sockets.id
+  socket_stage_name         = aws_apigatewayv2_stage.sockets.name
+}
+
+# WebSocket API for real-time payment status notifications
+# Clients connect with their API key, then send {"action": "subscribe", "payment_id": ...}; the worker
+# pushes each status a subscribed payment enters through the connection management endpoint
+resource "aws_apigatewayv2_api" "sockets" {
+  name                       = "${var.project_name}-sockets-${var.environment}"
+  protocol_type              = "WEBSOCKET"
+  route_selection_expression = "$request.body.action"
+}
+
+resource "aws_apigatewayv2_integration" "sockets" {
+  api_id             = aws_apigatewayv2_api.sockets.id
+  integration_type   = "AWS_PROXY"
+  integration_uri    = module.lambda_functions.socket_handler_invoke_arn
+  integration_method = "POST"
+}
+
+# The socket handler authorizes $connect with the same keys and IP allowlists as the REST API; the caller it
+# allows is passed on every later message of the connection, so subscriptions are scoped to it
+resource "aws_apigatewayv2_authorizer" "sockets" {
+  api_id           = aws_apigatewayv2_api.sockets.id
+  name             = "${var.project_name}-sockets-${var.environment}"
+  authorizer_type  = "REQUEST"
+  authorizer_uri   = module.lambda_functions.socket_handler_invoke_arn
+  identity_sources = ["route.request.header.X-Api-Key"]
+}
+
+resource "aws_apigatewayv2_route" "sockets_connect" {
+  api_id             = aws_apigatewayv2_api.sockets.id
+  route_key          = "$connect"
+  authorization_type = "CUSTOM"
+  authorizer_id      = aws_apigatewayv2_authorizer.sockets.id
+  target             = "integrations/${aws_apigatewayv2_integration.sockets.id}"
+}
+
+resource "aws_apigatewayv2_route" "sockets" {
+  for_each = toset(["$disconnect", "$default", "subscribe", "unsubscribe"])
+
+  api_id    = aws_apigatewayv2_api.sockets.id
+  route_key = each.value
+  target    = "integrations/${aws_apigatewayv2_integration.sockets.id}"
+}
+
+resource "aws_apigatewayv2_stage" "sockets" {
+  api_id      = aws_apigatewayv2_api.sockets.id
+  name        = var.environment
+  auto_deploy = true
+}
+
+resource "aws_lambda_permission" "sockets" {
+  statement_id  = "AllowWebSocketAPIInvoke"
+  action        = "lambda:InvokeFunction"
+  function_name = module.lambda_functions.socket_handler_function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.sockets.execution_arn}/*/*"
+}
+
+# Step Functions state machine for ORCHESTRATION_MODE=stepfunctions
+# Loops the worker's advance task, waiting wait_seconds between steps
+resource "aws_iam_role" "payment_state_machine" {
+  count = var.enable_step_functions ? 1 : 0
+  name  = "${var.project_name}-payment-sfn-role-${var.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "states.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+resource "aws_iam_role_policy" "payment_state_machine" {
+  count = var.enable_step_functions ? 1 : 0
+  name  = "${var.project_name}-payment-sfn-policy-${var.environment}"
+  role  = aws_iam_role.payment_state_machine[0].id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = ["lambda:InvokeFunction"]
+        Resource = module.lambda_functions.worker_handler_arn
+      }
+    ]
+  })
+}
+
+resource "aws_sfn_state_machine" "payment" {
+  count    = var.enable_step_functions ? 1 : 0
+  name     = "${var.project_name}-payment-${var.environment}"
+  role_arn = aws_iam_role.payment_state_machine[0].arn
+
+  definition = templatefile("${path.module}/../stepfunctions/payment.asl.json", {
+    worker_function_arn = module.lambda_functions.worker_handler_arn
+  })
 }
 
 # Outputs
@@ -194,6 +981,16 @@ output "api_endpoint" {
   value       = module.api_gateway.api_endpoint
 }
 
+output "stream_endpoint" {
+  description = "Payment status stream URL (GET /payments/{payment_id}/stream)"
+  value       = module.lambda_functions.stream_handler_url
+}
+
+output "socket_endpoint" {
+  description = "WebSocket API URL for payment status notifications"
+  value       = aws_apigatewayv2_stage.sockets.invoke_url
+}
+
 output "dynamodb_table_name" {
   description = "DynamoDB payments table name"
   value       = aws_dynamodb_table.payments.name
@@ -213,3 +1010,8 @@ output "webhook_queue_url" {
   description = "Webhook SQS queue URL"
   value       = aws_sqs_queue.webhook_queue.url
 }
+
+output "payment_state_machine_arn" {
+  description = "Step Functions state machine ARN (STATE_MACHINE_ARN)"
+  value       = var.enable_step_functions ? aws_sfn_state_machine.payment[0].arn : ""
+}
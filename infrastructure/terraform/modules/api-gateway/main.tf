@@ -125,6 +125,891 @@ resource "aws_api_gateway_integration" "lambda_get_payment" {
   uri                     = var.api_handler_invoke_arn
 }
 
+# POST method on /payments/{payment_id}/review (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "payment_review" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.payment_id.id
+  path_part   = "review"
+}
+
+resource "aws_api_gateway_method" "post_payment_review" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.payment_review.id
+  http_method   = "POST"
+  authorization = "AWS_IAM"
+
+  request_parameters = {
+    "method.request.path.payment_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_payment_review" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.payment_review.id
+  http_method = aws_api_gateway_method.post_payment_review.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /payments/{payment_id}/fees (the payment's fee invoice)
+resource "aws_api_gateway_resource" "payment_fees" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.payment_id.id
+  path_part   = "fees"
+}
+
+resource "aws_api_gateway_method" "get_payment_fees" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.payment_fees.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.payment_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_get_payment_fees" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.payment_fees.id
+  http_method = aws_api_gateway_method.get_payment_fees.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /pricing (the caller's volume discount tier)
+resource "aws_api_gateway_resource" "pricing" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "pricing"
+}
+
+resource "aws_api_gateway_method" "get_pricing" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.pricing.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_pricing" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.pricing.id
+  http_method = aws_api_gateway_method.get_pricing.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /audit (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "audit" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "audit"
+}
+
+resource "aws_api_gateway_method" "get_audit" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.audit.id
+  http_method   = "GET"
+  authorization = "AWS_IAM"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_audit" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.audit.id
+  http_method = aws_api_gateway_method.get_audit.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /reports/ai-cost (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "reports" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "reports"
+}
+
+resource "aws_api_gateway_resource" "reports_ai_cost" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.reports.id
+  path_part   = "ai-cost"
+}
+
+resource "aws_api_gateway_method" "get_reports_ai_cost" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.reports_ai_cost.id
+  http_method   = "GET"
+  authorization = "AWS_IAM"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_reports_ai_cost" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.reports_ai_cost.id
+  http_method = aws_api_gateway_method.get_reports_ai_cost.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /reports/gas-trends (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "reports_gas_trends" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.reports.id
+  path_part   = "gas-trends"
+}
+
+resource "aws_api_gateway_method" "get_reports_gas_trends" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.reports_gas_trends.id
+  http_method   = "GET"
+  authorization = "AWS_IAM"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_reports_gas_trends" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.reports_gas_trends.id
+  http_method = aws_api_gateway_method.get_reports_gas_trends.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET/POST methods on /fee-schedules/{schedule_id} (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "fee_schedules" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "fee-schedules"
+}
+
+resource "aws_api_gateway_resource" "fee_schedule_id" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.fee_schedules.id
+  path_part   = "{schedule_id}"
+}
+
+resource "aws_api_gateway_method" "get_fee_schedule" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.fee_schedule_id.id
+  http_method   = "GET"
+  authorization = "AWS_IAM"
+
+  request_parameters = {
+    "method.request.path.schedule_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_get_fee_schedule" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.fee_schedule_id.id
+  http_method = aws_api_gateway_method.get_fee_schedule.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "post_fee_schedule" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.fee_schedule_id.id
+  http_method   = "POST"
+  authorization = "AWS_IAM"
+
+  request_parameters = {
+    "method.request.path.schedule_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_post_fee_schedule" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.fee_schedule_id.id
+  http_method = aws_api_gateway_method.post_fee_schedule.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# POST method on /payments/batch (up to 100 payments in one request)
+resource "aws_api_gateway_resource" "payments_batch" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.payments.id
+  path_part   = "batch"
+}
+
+resource "aws_api_gateway_method" "post_payments_batch" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.payments_batch.id
+  http_method   = "POST"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_post_payments_batch" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.payments_batch.id
+  http_method = aws_api_gateway_method.post_payments_batch.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /payments/{payment_id}/events (the payment's customer-facing timeline)
+resource "aws_api_gateway_resource" "payment_events" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.payment_id.id
+  path_part   = "events"
+}
+
+resource "aws_api_gateway_method" "get_payment_events" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.payment_events.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.payment_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_get_payment_events" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.payment_events.id
+  http_method = aws_api_gateway_method.get_payment_events.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET/POST methods on /webhooks and DELETE on /webhooks/{subscription_id} (the caller's webhook subscriptions)
+resource "aws_api_gateway_resource" "webhooks" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "webhooks"
+}
+
+resource "aws_api_gateway_resource" "webhook_id" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.webhooks.id
+  path_part   = "{subscription_id}"
+}
+
+resource "aws_api_gateway_method" "get_webhooks" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.webhooks.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_webhooks" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.webhooks.id
+  http_method = aws_api_gateway_method.get_webhooks.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "post_webhooks" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.webhooks.id
+  http_method   = "POST"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_post_webhooks" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.webhooks.id
+  http_method = aws_api_gateway_method.post_webhooks.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "delete_webhook" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.webhook_id.id
+  http_method   = "DELETE"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.subscription_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_delete_webhook" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.webhook_id.id
+  http_method = aws_api_gateway_method.delete_webhook.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /webhooks/{subscription_id}/stats (the subscription's delivery stats)
+resource "aws_api_gateway_resource" "webhook_stats" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.webhook_id.id
+  path_part   = "stats"
+}
+
+resource "aws_api_gateway_method" "get_webhook_stats" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.webhook_stats.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.subscription_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_get_webhook_stats" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.webhook_stats.id
+  http_method = aws_api_gateway_method.get_webhook_stats.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# POST method on /webhooks/{subscription_id}/rotate-secret (a new signing secret, overlapping the old for a day)
+resource "aws_api_gateway_resource" "webhook_rotate_secret" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.webhook_id.id
+  path_part   = "rotate-secret"
+}
+
+resource "aws_api_gateway_method" "post_webhook_rotate_secret" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.webhook_rotate_secret.id
+  http_method   = "POST"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.subscription_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_post_webhook_rotate_secret" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.webhook_rotate_secret.id
+  http_method = aws_api_gateway_method.post_webhook_rotate_secret.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /health (liveness, for load balancers and uptime checks)
+resource "aws_api_gateway_resource" "health" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "health"
+}
+
+resource "aws_api_gateway_method" "get_health" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.health.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_health" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.health.id
+  http_method = aws_api_gateway_method.get_health.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /ready (readiness, checking each dependency)
+resource "aws_api_gateway_resource" "ready" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "ready"
+}
+
+resource "aws_api_gateway_method" "get_ready" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.ready.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_ready" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.ready.id
+  http_method = aws_api_gateway_method.get_ready.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /version (the deployed build)
+resource "aws_api_gateway_resource" "version" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "version"
+}
+
+resource "aws_api_gateway_method" "get_version" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.version.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_version" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.version.id
+  http_method = aws_api_gateway_method.get_version.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /schemas/{schema_name} (a request type's JSON Schema)
+resource "aws_api_gateway_resource" "schemas" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "schemas"
+}
+
+resource "aws_api_gateway_resource" "schema_name" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.schemas.id
+  path_part   = "{schema_name}"
+}
+
+resource "aws_api_gateway_method" "get_schema" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.schema_name.id
+  http_method   = "GET"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.schema_name" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_get_schema" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.schema_name.id
+  http_method = aws_api_gateway_method.get_schema.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET/PUT methods on /ip-allowlist (the ranges the caller's API key may call from)
+resource "aws_api_gateway_resource" "ip_allowlist" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "ip-allowlist"
+}
+
+resource "aws_api_gateway_method" "get_ip_allowlist" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.ip_allowlist.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_ip_allowlist" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.ip_allowlist.id
+  http_method = aws_api_gateway_method.get_ip_allowlist.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "put_ip_allowlist" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.ip_allowlist.id
+  http_method   = "PUT"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_put_ip_allowlist" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.ip_allowlist.id
+  http_method = aws_api_gateway_method.put_ip_allowlist.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET/POST methods on /api-keys and POST on /api-keys/{key_id}/rotate and /revoke (the caller's issued keys)
+resource "aws_api_gateway_resource" "api_keys" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "api-keys"
+}
+
+resource "aws_api_gateway_resource" "api_key_id" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.api_keys.id
+  path_part   = "{key_id}"
+}
+
+resource "aws_api_gateway_resource" "api_key_rotate" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.api_key_id.id
+  path_part   = "rotate"
+}
+
+resource "aws_api_gateway_resource" "api_key_revoke" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.api_key_id.id
+  path_part   = "revoke"
+}
+
+resource "aws_api_gateway_method" "get_api_keys" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.api_keys.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_api_keys" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.api_keys.id
+  http_method = aws_api_gateway_method.get_api_keys.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "post_api_keys" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.api_keys.id
+  http_method   = "POST"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_post_api_keys" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.api_keys.id
+  http_method = aws_api_gateway_method.post_api_keys.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "post_api_key_rotate" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.api_key_rotate.id
+  http_method   = "POST"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.key_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_post_api_key_rotate" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.api_key_rotate.id
+  http_method = aws_api_gateway_method.post_api_key_rotate.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+resource "aws_api_gateway_method" "post_api_key_revoke" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.api_key_revoke.id
+  http_method   = "POST"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.key_id" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_post_api_key_revoke" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.api_key_revoke.id
+  http_method = aws_api_gateway_method.post_api_key_revoke.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /usage (the caller's API calls, payment volume and AI calculations this month)
+resource "aws_api_gateway_resource" "usage" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "usage"
+}
+
+resource "aws_api_gateway_method" "get_usage" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.usage.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_usage" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.usage.id
+  http_method = aws_api_gateway_method.get_usage.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /quotas (the caller's quota usage and limits)
+resource "aws_api_gateway_resource" "quotas" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "quotas"
+}
+
+resource "aws_api_gateway_method" "get_quotas" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.quotas.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_quotas" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.quotas.id
+  http_method = aws_api_gateway_method.get_quotas.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /currencies (supported corridors with their limits and fees)
+resource "aws_api_gateway_resource" "currencies" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "currencies"
+}
+
+resource "aws_api_gateway_method" "get_currencies" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.currencies.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_currencies" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.currencies.id
+  http_method = aws_api_gateway_method.get_currencies.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /fees/estimate (fees from the fee schedules, without the AI engine)
+resource "aws_api_gateway_resource" "fees_estimate" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.fees.id
+  path_part   = "estimate"
+}
+
+resource "aws_api_gateway_method" "get_fees_estimate" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.fees_estimate.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_fees_estimate" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.fees_estimate.id
+  http_method = aws_api_gateway_method.get_fees_estimate.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# GET method on /rates (the indicative rate for a currency pair)
+resource "aws_api_gateway_resource" "rates" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "rates"
+}
+
+resource "aws_api_gateway_method" "get_rates" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.rates.id
+  http_method   = "GET"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_get_rates" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.rates.id
+  http_method = aws_api_gateway_method.get_rates.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# POST method on /quotes/compare (the static and AI prices of a quote request side by side)
+resource "aws_api_gateway_resource" "quotes_compare" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.quotes.id
+  path_part   = "compare"
+}
+
+resource "aws_api_gateway_method" "post_quotes_compare" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.quotes_compare.id
+  http_method   = "POST"
+  authorization = "NONE"
+}
+
+resource "aws_api_gateway_integration" "lambda_post_quotes_compare" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.quotes_compare.id
+  http_method = aws_api_gateway_method.post_quotes_compare.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# Any method on /internal/{proxy+} (operators only - signed with IAM credentials)
+# Treasury, compliance, reconciliation, customer, ledger and payment override endpoints; the handler routes them.
+resource "aws_api_gateway_resource" "internal" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "internal"
+}
+
+resource "aws_api_gateway_resource" "internal_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.internal.id
+  path_part   = "{proxy+}"
+}
+
+resource "aws_api_gateway_method" "any_internal" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.internal_proxy.id
+  http_method   = "ANY"
+  authorization = "AWS_IAM"
+
+  request_parameters = {
+    "method.request.path.proxy" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_any_internal" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.internal_proxy.id
+  http_method = aws_api_gateway_method.any_internal.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# Any method on /v1/{proxy+} (every endpoint under its versioned path; the handler routes them)
+# Operator routes reached through it carry no IAM principal, so the handler refuses them.
+resource "aws_api_gateway_resource" "v1" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_rest_api.main.root_resource_id
+  path_part   = "v1"
+}
+
+resource "aws_api_gateway_resource" "v1_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.v1.id
+  path_part   = "{proxy+}"
+}
+
+resource "aws_api_gateway_method" "any_v1" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.v1_proxy.id
+  http_method   = "ANY"
+  authorization = "NONE"
+
+  request_parameters = {
+    "method.request.path.proxy" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_any_v1" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.v1_proxy.id
+  http_method = aws_api_gateway_method.any_v1.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
+# Any method on /v1/internal/{proxy+} (operators only - signed with IAM credentials)
+resource "aws_api_gateway_resource" "v1_internal" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.v1.id
+  path_part   = "internal"
+}
+
+resource "aws_api_gateway_resource" "v1_internal_proxy" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  parent_id   = aws_api_gateway_resource.v1_internal.id
+  path_part   = "{proxy+}"
+}
+
+resource "aws_api_gateway_method" "any_v1_internal" {
+  rest_api_id   = aws_api_gateway_rest_api.main.id
+  resource_id   = aws_api_gateway_resource.v1_internal_proxy.id
+  http_method   = "ANY"
+  authorization = "AWS_IAM"
+
+  request_parameters = {
+    "method.request.path.proxy" = true
+  }
+}
+
+resource "aws_api_gateway_integration" "lambda_any_v1_internal" {
+  rest_api_id = aws_api_gateway_rest_api.main.id
+  resource_id = aws_api_gateway_resource.v1_internal_proxy.id
+  http_method = aws_api_gateway_method.any_v1_internal.http_method
+
+  integration_http_method = "POST"
+  type                    = "AWS_PROXY"
+  uri                     = var.api_handler_invoke_arn
+}
+
 # CORS support - OPTIONS method for /payments
 resource "aws_api_gateway_method" "options_payments" {
   rest_api_id   = aws_api_gateway_rest_api.main.id
@@ -341,14 +1226,116 @@ resource "aws_api_gateway_deployment" "main" {
       aws_api_gateway_resource.payment_id.id,
       aws_api_gateway_resource.fees.id,
       aws_api_gateway_resource.fees_calculate.id,
+      aws_api_gateway_resource.audit.id,
+      aws_api_gateway_resource.reports.id,
+      aws_api_gateway_resource.reports_ai_cost.id,
+      aws_api_gateway_resource.reports_gas_trends.id,
+      aws_api_gateway_resource.payment_review.id,
+      aws_api_gateway_resource.fee_schedules.id,
+      aws_api_gateway_resource.fee_schedule_id.id,
+      aws_api_gateway_resource.pricing.id,
+      aws_api_gateway_resource.payment_fees.id,
+      aws_api_gateway_resource.internal_proxy.id,
+      aws_api_gateway_resource.v1_proxy.id,
+      aws_api_gateway_resource.v1_internal_proxy.id,
+      aws_api_gateway_resource.payments_batch.id,
+      aws_api_gateway_resource.payment_events.id,
+      aws_api_gateway_resource.webhooks.id,
+      aws_api_gateway_resource.webhook_id.id,
+      aws_api_gateway_resource.webhook_stats.id,
+      aws_api_gateway_resource.webhook_rotate_secret.id,
+      aws_api_gateway_resource.health.id,
+      aws_api_gateway_resource.ready.id,
+      aws_api_gateway_resource.version.id,
+      aws_api_gateway_resource.schemas.id,
+      aws_api_gateway_resource.schema_name.id,
+      aws_api_gateway_resource.ip_allowlist.id,
+      aws_api_gateway_resource.api_keys.id,
+      aws_api_gateway_resource.api_key_id.id,
+      aws_api_gateway_resource.api_key_rotate.id,
+      aws_api_gateway_resource.api_key_revoke.id,
+      aws_api_gateway_resource.usage.id,
+      aws_api_gateway_resource.quotas.id,
+      aws_api_gateway_resource.currencies.id,
+      aws_api_gateway_resource.fees_estimate.id,
+      aws_api_gateway_resource.rates.id,
+      aws_api_gateway_resource.quotes_compare.id,
       aws_api_gateway_method.post_payments.id,
       aws_api_gateway_method.post_quotes.id,
       aws_api_gateway_method.post_fees_calculate.id,
       aws_api_gateway_method.get_payment.id,
+      aws_api_gateway_method.get_audit.id,
+      aws_api_gateway_method.get_reports_ai_cost.id,
+      aws_api_gateway_method.get_reports_gas_trends.id,
+      aws_api_gateway_method.post_payment_review.id,
+      aws_api_gateway_method.get_fee_schedule.id,
+      aws_api_gateway_method.post_fee_schedule.id,
+      aws_api_gateway_method.get_pricing.id,
+      aws_api_gateway_method.get_payment_fees.id,
+      aws_api_gateway_method.any_internal.id,
+      aws_api_gateway_method.any_v1.id,
+      aws_api_gateway_method.any_v1_internal.id,
+      aws_api_gateway_method.post_payments_batch.id,
+      aws_api_gateway_method.get_payment_events.id,
+      aws_api_gateway_method.get_webhooks.id,
+      aws_api_gateway_method.post_webhooks.id,
+      aws_api_gateway_method.delete_webhook.id,
+      aws_api_gateway_method.get_webhook_stats.id,
+      aws_api_gateway_method.post_webhook_rotate_secret.id,
+      aws_api_gateway_method.get_health.id,
+      aws_api_gateway_method.get_ready.id,
+      aws_api_gateway_method.get_version.id,
+      aws_api_gateway_method.get_schema.id,
+      aws_api_gateway_method.get_ip_allowlist.id,
+      aws_api_gateway_method.put_ip_allowlist.id,
+      aws_api_gateway_method.get_api_keys.id,
+      aws_api_gateway_method.post_api_keys.id,
+      aws_api_gateway_method.post_api_key_rotate.id,
+      aws_api_gateway_method.post_api_key_revoke.id,
+      aws_api_gateway_method.get_usage.id,
+      aws_api_gateway_method.get_quotas.id,
+      aws_api_gateway_method.get_currencies.id,
+      aws_api_gateway_method.get_fees_estimate.id,
+      aws_api_gateway_method.get_rates.id,
+      aws_api_gateway_method.post_quotes_compare.id,
       aws_api_gateway_integration.lambda_payments.id,
       aws_api_gateway_integration.lambda_quotes.id,
       aws_api_gateway_integration.lambda_fees_calculate.id,
       aws_api_gateway_integration.lambda_get_payment.id,
+      aws_api_gateway_integration.lambda_get_audit.id,
+      aws_api_gateway_integration.lambda_get_reports_ai_cost.id,
+      aws_api_gateway_integration.lambda_get_reports_gas_trends.id,
+      aws_api_gateway_integration.lambda_payment_review.id,
+      aws_api_gateway_integration.lambda_get_fee_schedule.id,
+      aws_api_gateway_integration.lambda_post_fee_schedule.id,
+      aws_api_gateway_integration.lambda_get_pricing.id,
+      aws_api_gateway_integration.lambda_get_payment_fees.id,
+      aws_api_gateway_integration.lambda_any_internal.id,
+      aws_api_gateway_integration.lambda_any_v1.id,
+      aws_api_gateway_integration.lambda_any_v1_internal.id,
+      aws_api_gateway_integration.lambda_post_payments_batch.id,
+      aws_api_gateway_integration.lambda_get_payment_events.id,
+      aws_api_gateway_integration.lambda_get_webhooks.id,
+      aws_api_gateway_integration.lambda_post_webhooks.id,
+      aws_api_gateway_integration.lambda_delete_webhook.id,
+      aws_api_gateway_integration.lambda_get_webhook_stats.id,
+      aws_api_gateway_integration.lambda_post_webhook_rotate_secret.id,
+      aws_api_gateway_integration.lambda_get_health.id,
+      aws_api_gateway_integration.lambda_get_ready.id,
+      aws_api_gateway_integration.lambda_get_version.id,
+      aws_api_gateway_integration.lambda_get_schema.id,
+      aws_api_gateway_integration.lambda_get_ip_allowlist.id,
+      aws_api_gateway_integration.lambda_put_ip_allowlist.id,
+      aws_api_gateway_integration.lambda_get_api_keys.id,
+      aws_api_gateway_integration.lambda_post_api_keys.id,
+      aws_api_gateway_integration.lambda_post_api_key_rotate.id,
+      aws_api_gateway_integration.lambda_post_api_key_revoke.id,
+      aws_api_gateway_integration.lambda_get_usage.id,
+      aws_api_gateway_integration.lambda_get_quotas.id,
+      aws_api_gateway_integration.lambda_get_currencies.id,
+      aws_api_gateway_integration.lambda_get_fees_estimate.id,
+      aws_api_gateway_integration.lambda_get_rates.id,
+      aws_api_gateway_integration.lambda_post_quotes_compare.id,
       aws_api_gateway_integration.options_payments.id,
       aws_api_gateway_integration.options_quotes.id,
       aws_api_gateway_integration.options_payment_id.id,
@@ -365,6 +1352,40 @@ resource "aws_api_gateway_deployment" "main" {
     aws_api_gateway_integration.lambda_quotes,
     aws_api_gateway_integration.lambda_fees_calculate,
     aws_api_gateway_integration.lambda_get_payment,
+    aws_api_gateway_integration.lambda_get_audit,
+    aws_api_gateway_integration.lambda_get_reports_ai_cost,
+    aws_api_gateway_integration.lambda_get_reports_gas_trends,
+    aws_api_gateway_integration.lambda_payment_review,
+    aws_api_gateway_integration.lambda_get_fee_schedule,
+    aws_api_gateway_integration.lambda_post_fee_schedule,
+    aws_api_gateway_integration.lambda_get_pricing,
+    aws_api_gateway_integration.lambda_get_payment_fees,
+    aws_api_gateway_integration.lambda_any_internal,
+    aws_api_gateway_integration.lambda_any_v1,
+    aws_api_gateway_integration.lambda_any_v1_internal,
+    aws_api_gateway_integration.lambda_post_payments_batch,
+    aws_api_gateway_integration.lambda_get_payment_events,
+    aws_api_gateway_integration.lambda_get_webhooks,
+    aws_api_gateway_integration.lambda_post_webhooks,
+    aws_api_gateway_integration.lambda_delete_webhook,
+    aws_api_gateway_integration.lambda_get_webhook_stats,
+    aws_api_gateway_integration.lambda_post_webhook_rotate_secret,
+    aws_api_gateway_integration.lambda_get_health,
+    aws_api_gateway_integration.lambda_get_ready,
+    aws_api_gateway_integration.lambda_get_version,
+    aws_api_gateway_integration.lambda_get_schema,
+    aws_api_gateway_integration.lambda_get_ip_allowlist,
+    aws_api_gateway_integration.lambda_put_ip_allowlist,
+    aws_api_gateway_integration.lambda_get_api_keys,
+    aws_api_gateway_integration.lambda_post_api_keys,
+    aws_api_gateway_integration.lambda_post_api_key_rotate,
+    aws_api_gateway_integration.lambda_post_api_key_revoke,
+    aws_api_gateway_integration.lambda_get_usage,
+    aws_api_gateway_integration.lambda_get_quotas,
+    aws_api_gateway_integration.lambda_get_currencies,
+    aws_api_gateway_integration.lambda_get_fees_estimate,
+    aws_api_gateway_integration.lambda_get_rates,
+    aws_api_gateway_integration.lambda_post_quotes_compare,
     aws_api_gateway_integration.options_payments,
     aws_api_gateway_integration.options_quotes,
     aws_api_gateway_integration.options_payment_id,
@@ -440,6 +1461,11 @@ resource "aws_api_gateway_usage_plan" "main" {
     stage  = aws_api_gateway_stage.main.stage_name
   }
 
+  api_stages {
+    api_id = var.socket_api_id
+    stage  = var.socket_stage_name
+  }
+
   quota_settings {
     limit  = 10000
     period = "DAY"
@@ -17,3 +17,13 @@ variable "api_handler_function_name" {
   description = "API handler Lambda function name"
   type        = string
 }
+
+variable "socket_api_id" {
+  description = "WebSocket API ID, whose $connect route accepts the same API keys"
+  type        = string
+}
+
+variable "socket_stage_name" {
+  description = "WebSocket API stage name"
+  type        = string
+}
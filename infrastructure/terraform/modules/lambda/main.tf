@@ -29,6 +29,7 @@ resource "aws_iam_role_policy" "api_handler" {
         Action = [
           "dynamodb:PutItem",
           "dynamodb:GetItem",
+          "dynamodb:UpdateItem",
           "dynamodb:Query",
           "dynamodb:Scan"
         ]
@@ -53,6 +54,31 @@ resource "aws_iam_role_policy" "api_handler" {
         ]
         Resource = "arn:aws:secretsmanager:${var.aws_region}:*:secret:crypto-conversion/*"
       },
+      {
+        Effect = "Allow"
+        Action = [
+          "ssm:GetParametersByPath"
+        ]
+        Resource = "arn:aws:ssm:${var.aws_region}:*:parameter/${var.project_name}/${var.environment}/claude*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "bedrock:InvokeModel"
+        ]
+        Resource = [
+          "arn:aws:bedrock:*::foundation-model/*",
+          "arn:aws:bedrock:*:*:inference-profile/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
       {
         Effect = "Allow"
         Action = [
@@ -76,6 +102,10 @@ resource "aws_lambda_function" "api_handler" {
   timeout         = 30
   memory_size     = 512
 
+  tracing_config {
+    mode = "Active"
+  }
+
   environment {
     variables = {
       DYNAMODB_TABLE     = var.dynamodb_table_name
@@ -83,6 +113,9 @@ resource "aws_lambda_function" "api_handler" {
       PAYMENT_QUEUE_URL  = var.payment_queue_url
       WEBHOOK_QUEUE_URL  = var.webhook_queue_url
       LOG_LEVEL          = "INFO"
+      TRACING_ENABLED    = "true"
+      CLAUDE_PARAMETER_PATH = "/${var.project_name}/${var.environment}/claude"
+      LLM_PROVIDER          = var.llm_provider
     }
   }
 
@@ -91,6 +124,27 @@ resource "aws_lambda_function" "api_handler" {
   ]
 }
 
+# Default model ID for each AI fee engine provider
+locals {
+  llm_default_models = {
+    anthropic = "claude-sonnet-4-20250514"
+    bedrock   = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+    openai    = "gpt-4o"
+  }
+}
+
+# Model for AI fee calculation, in the selected provider's naming; edit the value and the next cold start picks it up
+# max_tokens, timeout_seconds and temperature can be added under the same path
+resource "aws_ssm_parameter" "claude_model" {
+  name  = "/${var.project_name}/${var.environment}/claude/model"
+  type  = "String"
+  value = local.llm_default_models[var.llm_provider]
+
+  lifecycle {
+    ignore_changes = [value]
+  }
+}
+
 # IAM Role for Worker Lambda
 resource "aws_iam_role" "worker_handler" {
   name = "${var.project_name}-worker-handler-role-${var.environment}"
@@ -143,6 +197,39 @@ resource "aws_iam_role_policy" "worker_handler" {
         ]
         Resource = var.webhook_queue_arn
       },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:Query",
+          "dynamodb:DeleteItem"
+        ]
+        Resource = [
+          var.socket_table_arn,
+          "${var.socket_table_arn}/index/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "execute-api:ManageConnections"
+        ]
+        Resource = "${var.socket_api_execution_arn}/*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "apigateway:GET"
+        ]
+        Resource = "arn:aws:apigateway:${var.aws_region}::/apikeys"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
       {
         Effect = "Allow"
         Action = [
@@ -166,12 +253,19 @@ resource "aws_lambda_function" "worker_handler" {
   timeout         = 300 # 5 minutes for payment processing
   memory_size     = 512
 
+  tracing_config {
+    mode = "Active"
+  }
+
   environment {
     variables = {
       DYNAMODB_TABLE     = var.dynamodb_table_name
       PAYMENT_QUEUE_URL  = var.payment_queue_url
       WEBHOOK_QUEUE_URL  = var.webhook_queue_url
       LOG_LEVEL          = "INFO"
+      TRACING_ENABLED    = "true"
+      SOCKET_SUBSCRIPTION_TABLE = var.socket_table_name
+      SOCKET_API_ENDPOINT       = var.socket_api_endpoint
     }
   }
 
@@ -223,6 +317,14 @@ resource "aws_iam_role_policy" "webhook_handler" {
         ]
         Resource = var.webhook_queue_arn
       },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
       {
         Effect = "Allow"
         Action = [
@@ -246,9 +348,14 @@ resource "aws_lambda_function" "webhook_handler" {
   timeout         = 30
   memory_size     = 256
 
+  tracing_config {
+    mode = "Active"
+  }
+
   environment {
     variables = {
       LOG_LEVEL          = "INFO"
+      TRACING_ENABLED    = "true"
     }
   }
 
@@ -264,3 +371,221 @@ resource "aws_lambda_event_source_mapping" "webhook_sqs" {
   batch_size       = 10
   enabled          = true
 }
+
+# IAM Role for Stream Handler Lambda
+resource "aws_iam_role" "stream_handler" {
+  name = "${var.project_name}-stream-handler-role-${var.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+# IAM Policy for Stream Handler
+# Streams bypass API Gateway, so the handler reads its API keys to authenticate clients
+resource "aws_iam_role_policy" "stream_handler" {
+  name = "${var.project_name}-stream-handler-policy-${var.environment}"
+  role = aws_iam_role.stream_handler.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:GetItem"
+        ]
+        Resource = var.dynamodb_table_arn
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "apigateway:GET"
+        ]
+        Resource = "arn:aws:apigateway:${var.aws_region}::/apikeys"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "logs:CreateLogGroup",
+          "logs:CreateLogStream",
+          "logs:PutLogEvents"
+        ]
+        Resource = "arn:aws:logs:${var.aws_region}:*:log-group:/aws/lambda/${var.project_name}-stream-handler-${var.environment}:*"
+      }
+    ]
+  })
+}
+
+# Stream Handler Lambda Function
+# Serves GET /payments/{payment_id}/stream; the timeout bounds how long one stream stays open
+resource "aws_lambda_function" "stream_handler" {
+  filename         = "${path.module}/../../../../build/stream-handler.zip"
+  function_name    = "${var.project_name}-stream-handler-${var.environment}"
+  role            = aws_iam_role.stream_handler.arn
+  handler         = "bootstrap"
+  source_code_hash = fileexists("${path.module}/../../../../build/stream-handler.zip") ? filebase64sha256("${path.module}/../../../../build/stream-handler.zip") : ""
+  runtime         = "provided.al2"
+  timeout         = 330
+  memory_size     = 128
+
+  tracing_config {
+    mode = "Active"
+  }
+
+  environment {
+    variables = {
+      DYNAMODB_TABLE     = var.dynamodb_table_name
+      STREAM_MAX_SECONDS = "300"
+      LOG_LEVEL          = "INFO"
+      TRACING_ENABLED    = "true"
+    }
+  }
+
+  depends_on = [
+    aws_iam_role_policy.stream_handler
+  ]
+}
+
+# Function URL for the Stream Handler
+# API Gateway buffers whole responses, so streams are served from a function URL that sends events as they're written
+resource "aws_lambda_function_url" "stream_handler" {
+  function_name      = aws_lambda_function.stream_handler.function_name
+  authorization_type = "NONE"
+  invoke_mode        = "RESPONSE_STREAM"
+
+  cors {
+    allow_origins = ["*"]
+    allow_methods = ["GET"]
+    allow_headers = ["content-type", "x-api-key", "last-event-id"]
+  }
+}
+
+# IAM Role for Socket Handler Lambda
+resource "aws_iam_role" "socket_handler" {
+  name = "${var.project_name}-socket-handler-role-${var.environment}"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "lambda.amazonaws.com"
+        }
+      }
+    ]
+  })
+}
+
+# IAM Policy for Socket Handler
+# The handler authorizes connections, so it reads the API keys like the stream handler
+resource "aws_iam_role_policy" "socket_handler" {
+  name = "${var.project_name}-socket-handler-policy-${var.environment}"
+  role = aws_iam_role.socket_handler.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:GetItem"
+        ]
+        Resource = var.dynamodb_table_arn
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:PutItem",
+          "dynamodb:DeleteItem",
+          "dynamodb:Query"
+        ]
+        Resource = [
+          var.socket_table_arn,
+          "${var.socket_table_arn}/index/*"
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "execute-api:ManageConnections"
+        ]
+        Resource = "${var.socket_api_execution_arn}/*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "apigateway:GET"
+        ]
+        Resource = "arn:aws:apigateway:${var.aws_region}::/apikeys"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "xray:PutTraceSegments",
+          "xray:PutTelemetryRecords"
+        ]
+        Resource = "*"
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "logs:CreateLogGroup",
+          "logs:CreateLogStream",
+          "logs:PutLogEvents"
+        ]
+        Resource = "arn:aws:logs:${var.aws_region}:*:log-group:/aws/lambda/${var.project_name}-socket-handler-${var.environment}:*"
+      }
+    ]
+  })
+}
+
+# Socket Handler Lambda Function
+# Handles the WebSocket API's $connect, $disconnect, subscribe and unsubscribe routes, and authorizes $connect
+resource "aws_lambda_function" "socket_handler" {
+  filename         = "${path.module}/../../../../build/socket-handler.zip"
+  function_name    = "${var.project_name}-socket-handler-${var.environment}"
+  role            = aws_iam_role.socket_handler.arn
+  handler         = "bootstrap"
+  source_code_hash = fileexists("${path.module}/../../../../build/socket-handler.zip") ? filebase64sha256("${path.module}/../../../../build/socket-handler.zip") : ""
+  runtime         = "provided.al2"
+  timeout         = 10
+  memory_size     = 128
+
+  tracing_config {
+    mode = "Active"
+  }
+
+  environment {
+    variables = {
+      DYNAMODB_TABLE            = var.dynamodb_table_name
+      SOCKET_SUBSCRIPTION_TABLE = var.socket_table_name
+      SOCKET_API_ENDPOINT       = var.socket_api_endpoint
+      LOG_LEVEL                 = "INFO"
+      TRACING_ENABLED           = "true"
+    }
+  }
+
+  depends_on = [
+    aws_iam_role_policy.socket_handler
+  ]
+}
@@ -22,3 +22,23 @@ output "webhook_handler_function_name" {
   description = "Webhook handler Lambda function name"
   value       = aws_lambda_function.webhook_handler.function_name
 }
+
+output "worker_handler_arn" {
+  description = "Worker handler Lambda function ARN"
+  value       = aws_lambda_function.worker_handler.arn
+}
+
+output "stream_handler_url" {
+  description = "Payment status stream function URL"
+  value       = aws_lambda_function_url.stream_handler.function_url
+}
+
+output "socket_handler_function_name" {
+  description = "Socket handler Lambda function name"
+  value       = aws_lambda_function.socket_handler.function_name
+}
+
+output "socket_handler_invoke_arn" {
+  description = "Socket handler Lambda invoke ARN"
+  value       = aws_lambda_function.socket_handler.invoke_arn
+}
@@ -67,3 +67,29 @@ variable "webhook_handler_log_group_arn" {
   description = "Webhook handler log group ARN"
   type        = string
 }
+
+variable "llm_provider" {
+  description = "Model provider for AI fee calculation (anthropic, bedrock or openai)"
+  type        = string
+  default     = "anthropic"
+}
+
+variable "socket_table_name" {
+  description = "WebSocket subscriptions table name"
+  type        = string
+}
+
+variable "socket_table_arn" {
+  description = "WebSocket subscriptions table ARN"
+  type        = string
+}
+
+variable "socket_api_endpoint" {
+  description = "WebSocket API connection management endpoint (https://{api-id}.execute-api.{region}.amazonaws.com/{stage})"
+  type        = string
+}
+
+variable "socket_api_execution_arn" {
+  description = "WebSocket API execution ARN, for posting to connections"
+  type        = string
+}
@@ -38,3 +38,20 @@ variable "lambda_memory_size" {
   type        = number
   default     = 512
 }
+
+variable "llm_provider" {
+  description = "Model provider for AI fee calculation: anthropic, bedrock (AWS-native, no API key) or openai"
+  type        = string
+  default     = "anthropic"
+
+  validation {
+    condition     = contains(["anthropic", "bedrock", "openai"], var.llm_provider)
+    error_message = "llm_provider must be anthropic, bedrock or openai."
+  }
+}
+
+variable "enable_step_functions" {
+  description = "Create the Step Functions state machine used when ORCHESTRATION_MODE=stepfunctions"
+  type        = bool
+  default     = false
+}
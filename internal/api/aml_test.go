@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/compliance/rules"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMLMonitoringAlertsAndCloses(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	alerts := database.NewMemoryAMLAlertRepository()
+	h := &Handler{
+		db:        db,
+		quoteCalc: quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), nil),
+		feeCalc:   fees.NewCalculator(),
+		corridors: corridors.Default(),
+		aml: rules.NewEngine(db, alerts, 24*time.Hour,
+			rules.HighRiskCountry{Risks: fees.NewMockDataProvider(), Corridors: corridors.Default(), MinScore: 6.0}),
+		amlAlerts: alerts,
+		cfg:       &config.Config{},
+	}
+
+	resp, err := h.route(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": "key_aml_0001"},
+		Body: `{"amount": 100000, "currency": "USD", "source_account": "acct_source",
+			"destination_account": "acct_destination", "beneficiary": {"name": "Ada Obi", "country": "NG"}}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+	var created models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+	assert.Equal(t, models.StatusPending, created.Status, "an alert doesn't stop the payment")
+
+	resp, err = h.route(ctx, asOperator(events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  "/internal/compliance/alerts",
+		QueryStringParameters: map[string]string{"status": models.AMLAlertStatusOpen},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var listed struct {
+		Alerts []*models.AMLAlert `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &listed))
+	require.Len(t, listed.Alerts, 1)
+	alert := listed.Alerts[0]
+	assert.Equal(t, created.PaymentID, alert.PaymentID)
+	assert.Equal(t, models.AMLRuleHighRiskCountry, alert.Rule)
+
+	closeRequest := func(body string) events.APIGatewayProxyRequest {
+		return asOperator(events.APIGatewayProxyRequest{
+			HTTPMethod:     http.MethodPost,
+			Path:           "/internal/compliance/alerts/" + alert.AlertID + "/close",
+			PathParameters: map[string]string{"alert_id": alert.AlertID},
+			Body:           body,
+		})
+	}
+
+	resp, err = h.route(ctx, closeRequest(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a resolution is required")
+
+	resp, err = h.route(ctx, closeRequest(`{"resolution": "family remittance, documented"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var closed models.AMLAlert
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &closed))
+	assert.Equal(t, models.AMLAlertStatusClosed, closed.Status)
+	assert.Equal(t, "family remittance, documented", closed.Resolution)
+
+	resp, err = h.route(ctx, closeRequest(`{"resolution": "again"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	disabled := &Handler{db: db, cfg: &config.Config{}}
+	resp, err = disabled.route(ctx, asOperator(events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/internal/compliance/alerts"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keyRequest(method, path, secret, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: method,
+		Path:       path,
+		Headers:    map[string]string{"x-api-key": secret},
+		Body:       body,
+	}
+}
+
+func TestIssuedAPIKeysAuthenticateRequests(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	h.apiKeys = apikeys.New(database.NewMemoryAPIKeyRepository(), time.Hour)
+
+	// Operators issue a customer's first key
+	resp, err := h.route(ctx, customerRequest(http.MethodPost, "/internal/customers/cust_acme/api-keys", nil, `{"name": "bootstrap"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, resp.Body)
+	var first models.IssuedAPIKey
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &first))
+	require.NotEmpty(t, first.Secret)
+	assert.NotContains(t, resp.Body, "key_hash")
+
+	// Requests with the key act as its customer
+	resp, err = h.route(ctx, keyRequest(http.MethodPost, "/api-keys", first.Secret, `{"name": "production"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, resp.Body)
+	var second models.IssuedAPIKey
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &second))
+	assert.Equal(t, "cust_acme", second.CustomerID)
+
+	resp, err = h.route(ctx, keyRequest(http.MethodGet, "/api-keys", second.Secret, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	assert.NotContains(t, resp.Body, first.Secret, "listed keys carry no secrets")
+	var listed struct {
+		APIKeys []models.APIKeyView `json:"api_keys"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &listed))
+	require.Len(t, listed.APIKeys, 2)
+	assert.NotNil(t, listed.APIKeys[0].LastUsedAt)
+
+	resp, err = h.route(ctx, keyRequest(http.MethodPost, "/api-keys/"+first.KeyID+"/revoke", second.Secret, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, keyRequest(http.MethodGet, "/api-keys", first.Secret, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"code":"UNAUTHORIZED"`)
+
+	// Values without the issued keys' prefix are API Gateway's to check
+	resp, err = h.route(ctx, keyRequest(http.MethodGet, "/api-keys", "gateway-key-value", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Body, "An API key is required")
+}
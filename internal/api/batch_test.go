@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"crypto-conversion/internal/validator"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchHandler(db *database.MemoryPaymentRepository) *Handler {
+	return &Handler{
+		db:           db,
+		quoteCalc:    quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), nil),
+		feeCalc:      fees.NewCalculator(),
+		corridors:    corridors.Default(),
+		destinations: validator.NewCountryPolicy(nil, []string{"KP"}),
+		cfg:          &config.Config{Batches: config.BatchConfig{MaxPayments: 3}},
+	}
+}
+
+func batchRequest(idempotencyKey, body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments/batch",
+		Headers:    map[string]string{"Idempotency-Key": idempotencyKey},
+		Body:       body,
+	}
+}
+
+func TestCreatePaymentBatch(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+
+	body := `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"},
+		{"amount": 250000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_2"}
+	]}`
+	resp, err := h.route(ctx, batchRequest("key_batch_1", body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	var batch models.BatchPaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &batch))
+	assert.Equal(t, models.BatchStatusAccepted, batch.Status)
+	assert.NotEmpty(t, batch.BatchID)
+	require.Len(t, batch.Results, 2)
+	for i, result := range batch.Results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, models.StatusPending, result.Status)
+		assert.Nil(t, result.Error)
+
+		stored, err := db.GetPaymentByID(ctx, result.PaymentID)
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, batch.BatchID, stored.BatchID)
+		assert.Equal(t, models.BatchIdempotencyKey("key_batch_1", i), stored.IdempotencyKey)
+	}
+
+	// One outbox message carries every job
+	messages, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, models.OutboxKindPaymentJobBatch, messages[0].Kind)
+	var jobs []*models.PaymentJob
+	require.NoError(t, json.Unmarshal([]byte(messages[0].Payload), &jobs))
+	require.Len(t, jobs, 2)
+	assert.Equal(t, batch.Results[1].PaymentID, jobs[1].PaymentID)
+	assert.Equal(t, "acct_dest_2", jobs[1].DestinationAccount)
+
+	// A retried batch gets the original response, and the batch's key covers its payments
+	replay, err := h.route(ctx, batchRequest("key_batch_1", body))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, replay.StatusCode, replay.Body)
+	assert.Equal(t, "true", replay.Headers["Idempotent-Replayed"])
+	assert.JSONEq(t, resp.Body, replay.Body)
+
+	resp, err = h.route(ctx, batchRequest("key_batch_1", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_3"}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, resp.Body)
+}
+
+func TestCreatePaymentBatchRejectsInvalidItems(t *testing.T) {
+	ctx := context.Background()
+	quoteDB := database.NewMemoryQuoteRepository()
+	require.NoError(t, quoteDB.CreateQuote(ctx, &quotes.Quote{
+		QuoteID:          "quote_batch",
+		Amount:           100000,
+		FromCurrency:     "USD",
+		ToCurrency:       "EUR",
+		ExchangeRate:     0.92,
+		GuaranteedPayout: 92000,
+		ExpiresAt:        time.Now().Add(time.Minute),
+	}))
+	db := database.NewMemoryPaymentRepository()
+	db.EnableQuoteConsumption(quoteDB)
+	h := batchHandler(db)
+	h.quoteDB = quoteDB
+
+	resp, err := h.route(ctx, batchRequest("key_batch_2", `{"payments": [
+		{"amount": 100000, "currency": "EUR", "source_account": "acct_source", "destination_account": "acct_dest_1", "quote_id": "quote_batch"},
+		{"amount": 0, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_2"},
+		{"amount": 100000, "currency": "EUR", "source_account": "acct_source", "destination_account": "acct_dest_3", "quote_id": "quote_batch"}
+	]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, resp.Body)
+
+	var batch models.BatchPaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &batch))
+	assert.Equal(t, models.BatchStatusRejected, batch.Status)
+	assert.Empty(t, batch.BatchID)
+	require.Len(t, batch.Results, 3)
+	assert.Nil(t, batch.Results[0].Error, "the valid item isn't blamed")
+	assert.Empty(t, batch.Results[0].PaymentID, "nor created")
+	require.NotNil(t, batch.Results[1].Error)
+	assert.Equal(t, "VALIDATION_ERROR", batch.Results[1].Error.Code)
+	require.NotNil(t, batch.Results[2].Error)
+	assert.Contains(t, batch.Results[2].Error.Message, "quote_id")
+
+	// Nothing was saved, so the quote is still there for a corrected batch
+	messages, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	quote, err := quoteDB.GetQuote(ctx, "quote_batch")
+	require.NoError(t, err)
+	assert.Nil(t, quote.ConsumedAt)
+}
+
+func TestCreatePaymentBatchSize(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	item := `{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`
+
+	for _, body := range []string{
+		`{"payments": []}`,
+		`{"payments": [` + item + `,` + item + `,` + item + `,` + item + `]}`,
+	} {
+		resp, err := h.route(context.Background(), batchRequest("key_batch_3", body))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, resp.Body)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/compliance/sanctions"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"crypto-conversion/internal/validator"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanctionsHitHoldsPaymentForCompliance(t *testing.T) {
+	ctx := context.Background()
+	list := sanctions.NewList(sanctions.OFACListName)
+	list.AddName("DOE, Sanctioned")
+
+	db := database.NewMemoryPaymentRepository()
+	h := &Handler{
+		db:        db,
+		quoteCalc: quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), nil),
+		feeCalc:   fees.NewCalculator(),
+		corridors: corridors.Default(),
+		screener:  list,
+		cfg:       &config.Config{},
+	}
+
+	resp, err := h.route(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": "key_sanctions_1"},
+		Body: `{"amount": 100000, "currency": "USD", "source_account": "acct_source",
+			"destination_account": "acct_destination", "beneficiary": {"name": "Sanctioned Doe", "country": "us"}}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+	var created models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+	assert.Equal(t, models.StatusComplianceHold, created.Status)
+
+	resp, err = h.route(ctx, asOperator(events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/internal/compliance/holds"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var listed struct {
+		Payments []*models.Payment `json:"payments"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &listed))
+	require.Len(t, listed.Payments, 1)
+	assert.Equal(t, "US", listed.Payments[0].Beneficiary.Country)
+	require.NotNil(t, listed.Payments[0].Screening)
+	assert.Equal(t, models.ScreeningStageCreation, listed.Payments[0].Screening.Stage)
+
+	resolve := func(body string) events.APIGatewayProxyResponse {
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod:     http.MethodPost,
+			Path:           "/internal/compliance/holds/" + created.PaymentID + "/resolve",
+			PathParameters: map[string]string{"payment_id": created.PaymentID},
+			Body:           body,
+		}
+		request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/compliance"
+		resp, err := h.route(ctx, request)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusBadRequest, resolve(`{"decision": "release"}`).StatusCode, "a reason is required")
+
+	resp = resolve(`{"decision": "release", "reason": "Different date of birth"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err := db.GetPaymentByID(ctx, created.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, stored.Status)
+	assert.True(t, stored.Screening.Released())
+
+	assert.Equal(t, http.StatusConflict, resolve(`{"decision": "reject", "reason": "Too late"}`).StatusCode)
+}
+
+func TestRestrictedDestinationRefusesPayment(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := &Handler{
+		db:           db,
+		quoteCalc:    quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), nil),
+		feeCalc:      fees.NewCalculator(),
+		corridors:    corridors.Default(),
+		destinations: validator.NewCountryPolicy([]string{"DE", "GB", "US"}, nil),
+		cfg:          &config.Config{},
+	}
+
+	create := func(key, body string) events.APIGatewayProxyResponse {
+		resp, err := h.route(ctx, events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/payments",
+			Headers:    map[string]string{"Idempotency-Key": key},
+			Body:       body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := create("key_destination_1", `{"amount": 100000, "currency": "EUR", "source_account": "acct_source",
+		"destination_account": "acct_destination", "beneficiary": {"name": "Ada Obi", "country": "NG"}}`)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, resp.Body)
+	assert.Contains(t, resp.Body, "DESTINATION_RESTRICTED")
+
+	resp = create("key_destination_2", `{"amount": 100000, "currency": "BRL", "source_account": "acct_source",
+		"destination_account": "acct_destination"}`)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "the BRL corridor pays out in Brazil")
+
+	resp = create("key_destination_3", `{"amount": 100000, "currency": "EUR", "source_account": "acct_source",
+		"destination_account": "acct_destination"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/currencies"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var catalog models.CurrencyCatalog
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &catalog))
+
+	require.Len(t, catalog.SourceCurrencies, 2)
+	assert.Equal(t, "EUR", catalog.SourceCurrencies[0].Code)
+	assert.Equal(t, []string{"USD"}, catalog.SourceCurrencies[0].Destinations)
+	assert.Equal(t, "USD", catalog.SourceCurrencies[1].Code)
+	assert.Equal(t, []string{"BRL", "EUR", "GBP"}, catalog.SourceCurrencies[1].Destinations)
+	assert.Equal(t, 2, catalog.SourceCurrencies[1].MinorUnits)
+
+	var codes []string
+	for _, currency := range catalog.DestinationCurrencies {
+		codes = append(codes, currency.Code)
+	}
+	assert.Equal(t, []string{"BRL", "EUR", "GBP", "USD"}, codes)
+
+	require.Len(t, catalog.Corridors, len(corridors.Default().List()))
+	assert.Equal(t, "EUR-USD", catalog.Corridors[0].CorridorID, "ordered by ID")
+	var brl *models.CorridorCapability
+	for _, corridor := range catalog.Corridors {
+		if corridor.CorridorID == "USD-BRL" {
+			brl = corridor
+		}
+	}
+	require.NotNil(t, brl)
+	assert.Equal(t, "BR", brl.DestinationCountry)
+	assert.Equal(t, int64(100), brl.MinAmount)
+	assert.Equal(t, int64(100000000), brl.MaxAmount)
+	assert.Equal(t, "USD", brl.Fees.Currency)
+	assert.NotEmpty(t, brl.Fees.Tiers)
+	require.Len(t, brl.Fees.Surcharges, 1)
+	assert.Equal(t, "IOF", brl.Fees.Surcharges[0].Name)
+}
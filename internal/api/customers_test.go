@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/compliance/kyc"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func customerRequest(method, path string, params map[string]string, body string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     method,
+		Path:           path,
+		PathParameters: params,
+		Body:           body,
+	}
+	return asOperator(request)
+}
+
+// asOperator marks a request as IAM-authorized, as API Gateway passes on an operator's signed call
+func asOperator(request events.APIGatewayProxyRequest) events.APIGatewayProxyRequest {
+	request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/ops"
+	return request
+}
+
+func TestCustomerEndpoints(t *testing.T) {
+	ctx := context.Background()
+	h := &Handler{customers: customers.New(database.NewMemoryCustomerRepository()), cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, customerRequest(http.MethodPost, "/internal/customers", nil,
+		`{"customer_id": "key_acme", "name": "Acme", "tier": "Business", "limits": {"max_payment_amount": 500000}}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, resp.Body)
+	var created models.Customer
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+	assert.Equal(t, models.CustomerTierBusiness, created.Tier)
+	assert.Len(t, created.WebhookSecrets, 1)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodPost, "/internal/customers", nil, `{"customer_id": "key_acme", "name": "Acme"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	params := map[string]string{"customer_id": "key_acme"}
+	resp, err = h.route(ctx, customerRequest(http.MethodPut, "/internal/customers/key_acme", params, `{"name": "Acme Ltd", "tier": "platinum"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unknown tiers are refused")
+
+	resp, err = h.route(ctx, customerRequest(http.MethodPost, "/internal/customers/key_acme/accounts", params, `{"account_id": "acct_ops"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodPost, "/internal/customers/key_acme/accounts", params, `{"account_id": "acct_ops"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodGet, "/internal/customers/key_acme", params, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var fetched models.Customer
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &fetched))
+	require.Len(t, fetched.Accounts, 1)
+	assert.Equal(t, "acct_ops", fetched.Accounts[0].AccountID)
+
+	accountParams := map[string]string{"customer_id": "key_acme", "account_id": "acct_ops"}
+	resp, err = h.route(ctx, customerRequest(http.MethodDelete, "/internal/customers/key_acme/accounts/acct_ops", accountParams, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodDelete, "/internal/customers/key_acme", params, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodGet, "/internal/customers/key_acme", params, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	disabled := &Handler{cfg: &config.Config{}}
+	resp, err = disabled.route(ctx, customerRequest(http.MethodGet, "/internal/customers", nil, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestOperatorEndpointsRefuseAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	h := &Handler{customers: customers.New(database.NewMemoryCustomerRepository()), cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, apiKeyRequest(http.MethodGet, "/internal/customers", "198.51.100.1", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"code":"FORBIDDEN"`)
+
+	resp, err = h.route(ctx, keyRequest(http.MethodPost, "/v1/internal/customers", "sk_live_guess", `{"customer_id": "key_acme", "name": "Acme"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "issued keys are refused before they're looked up")
+
+	resp, err = h.route(ctx, customerRequest(http.MethodGet, "/internal/customers", nil, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+}
+
+func TestCreatePaymentEnforcesCustomerRecord(t *testing.T) {
+	ctx := context.Background()
+	service := customers.New(database.NewMemoryCustomerRepository())
+	_, err := service.Create(ctx, &models.CustomerRequest{
+		CustomerID: "key_acme",
+		Name:       "Acme",
+		Limits:     models.CustomerLimits{MaxPaymentAmount: 50000},
+	})
+	require.NoError(t, err)
+	_, err = service.LinkAccount(ctx, "key_acme", &models.CustomerAccountRequest{AccountID: "acct_ops"})
+	require.NoError(t, err)
+
+	h := &Handler{db: database.NewMemoryPaymentRepository(), customers: service, cfg: &config.Config{}}
+	payment := func(key string, amount int64, sourceAccount string) events.APIGatewayProxyResponse {
+		body, _ := json.Marshal(models.PaymentRequest{
+			Amount:             amount,
+			Currency:           "EUR",
+			SourceAccount:      sourceAccount,
+			DestinationAccount: "acct_destination",
+		})
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/payments",
+			Headers:    map[string]string{"Idempotency-Key": key},
+			Body:       string(body),
+		}
+		request.RequestContext.Identity.APIKeyID = "key_acme"
+		resp, err := h.route(ctx, request)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := payment("key_customer_1", 100000, "acct_ops")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, "LIMIT_EXCEEDED")
+
+	resp = payment("key_customer_2", 10000, "acct_other")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, "ACCOUNT_NOT_LINKED")
+}
+
+func TestCreatePaymentRequiresKYC(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryCustomerRepository()
+	service := customers.New(store)
+	_, err := service.Create(ctx, &models.CustomerRequest{CustomerID: "key_acme", Name: "Acme"})
+	require.NoError(t, err)
+
+	h := &Handler{
+		db:        database.NewMemoryPaymentRepository(),
+		customers: service,
+		kyc:       kyc.NewVerifier(kyc.NewMockProvider(), store),
+		kycGate:   kyc.NewGate(50000),
+		cfg:       &config.Config{},
+	}
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": "key_kyc_0001"},
+		Body:       `{"amount": 100000, "currency": "EUR", "source_account": "acct_source", "destination_account": "acct_destination"}`,
+	}
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+
+	resp, err := h.route(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, "KYC_REQUIRED")
+
+	params := map[string]string{"customer_id": "key_acme"}
+	resp, err = h.route(ctx, customerRequest(http.MethodPost, "/internal/customers/key_acme/kyc", params, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, customerRequest(http.MethodPost, "/internal/customers/key_acme/kyc/sync", params, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var customer models.Customer
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &customer))
+	assert.Equal(t, models.KYCStatusVerified, customer.KYC.Status)
+
+	stored, err := service.Get(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.Nil(t, kyc.NewGate(50000).Check(stored, 100000), "the verified customer clears the gate")
+}
+
+func apiKeyRequest(method, path, sourceIP, body string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{HTTPMethod: method, Path: path, Body: body}
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+	request.RequestContext.Identity.SourceIP = sourceIP
+	return request
+}
+
+func TestIPAllowlistRestrictsAPIKey(t *testing.T) {
+	ctx := context.Background()
+	service := customers.New(database.NewMemoryCustomerRepository())
+	_, err := service.Create(ctx, &models.CustomerRequest{CustomerID: "key_acme", Name: "Acme"})
+	require.NoError(t, err)
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	h.customers = service
+
+	resp, err := h.route(ctx, apiKeyRequest(http.MethodGet, "/ip-allowlist", "198.51.100.1", ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	assert.JSONEq(t, `{"allowed_ips": []}`, resp.Body)
+
+	// A list that would lock out the caller is refused
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodPut, "/ip-allowlist", "198.51.100.1", `{"allowed_ips": ["203.0.113.0/24"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, "198.51.100.1")
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodPut, "/ip-allowlist", "203.0.113.9", `{"allowed_ips": ["203.0.113.0/24"]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/payments/pay_missing", "198.51.100.1", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"code":"IP_NOT_ALLOWED"`)
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/payments/pay_missing", "203.0.113.9", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "calls from the allowlist go through")
+
+	// Operators can lift the restriction for a customer locked out of its own key
+	resp, err = h.route(ctx, customerRequest(http.MethodPut, "/internal/customers/key_acme/ip-allowlist", nil, `{"allowed_ips": []}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/ip-allowlist", "198.51.100.1", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
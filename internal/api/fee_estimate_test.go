@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func estimateRequest(params map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/fees/estimate", QueryStringParameters: params}
+}
+
+func TestEstimateFees(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, estimateRequest(map[string]string{"amount": "100000", "currency": "EUR"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var estimate fees.FeeEstimate
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &estimate))
+	assert.Equal(t, "USD-EUR", estimate.CorridorID)
+	assert.Equal(t, "USD", estimate.FeeCurrency)
+	assert.Equal(t, 0.020, estimate.Tier.Rate, "$1,000 is in the top band")
+	assert.Equal(t, int64(100), estimate.Tier.FixedFee)
+	assert.Equal(t, int64(2100), estimate.FeeAmount)
+	assert.Equal(t, int64(102100), estimate.TotalAmount)
+	assert.InDelta(t, 0.021, estimate.EffectiveRate, 1e-9)
+
+	// Surcharges are itemized on top of the platform fee
+	resp, err = h.route(ctx, estimateRequest(map[string]string{"amount": "100000", "currency": "brl", "from": "usd"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &estimate))
+	assert.Equal(t, "USD-BRL", estimate.CorridorID)
+	require.Len(t, estimate.Surcharges, 1)
+	assert.Equal(t, int64(380), estimate.Surcharges[0].Amount)
+	assert.Equal(t, estimate.PlatformFee+380, estimate.FeeAmount)
+}
+
+func TestEstimateFeesValidatesQuery(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	cases := map[string]struct {
+		params map[string]string
+		field  string
+	}{
+		"missing":     {map[string]string{}, `"field":"amount"`},
+		"not integer": {map[string]string{"amount": "10.50", "currency": "EUR"}, `"field":"amount"`},
+		"unsupported": {map[string]string{"amount": "10000", "currency": "JPY"}, `"field":"currency"`},
+		"below min":   {map[string]string{"amount": "50", "currency": "EUR"}, `"field":"amount"`},
+	}
+	for name, tc := range cases {
+		resp, err := h.route(ctx, estimateRequest(tc.params))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		assert.Contains(t, resp.Body, tc.field, name)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/grpcapi"
+	"crypto-conversion/internal/grpcapi/paymentsv1"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcClient serves the handler's payment service in memory and returns a client for it
+// with a context authenticated as customerID
+func grpcClient(t *testing.T, h *Handler, customerID string) (paymentsv1.PaymentServiceClient, context.Context) {
+	if h.apiKeys == nil {
+		h.apiKeys = apikeys.New(database.NewMemoryAPIKeyRepository(), time.Hour)
+	}
+	issued, err := h.apiKeys.Issue(context.Background(), customerID, &models.APIKeyRequest{Name: "grpc"})
+	require.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpcapi.Register(h.HandleRequest, h.apiKeys)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpcapi.MetadataAPIKey, issued.Secret)
+	return paymentsv1.NewPaymentServiceClient(conn), ctx
+}
+
+func TestGRPCSharesTheRESTHandler(t *testing.T) {
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+	h.quoteDB = database.NewMemoryQuoteRepository()
+	client, ctx := grpcClient(t, h, "cust_treasury")
+
+	quote, err := client.GenerateQuote(ctx, &paymentsv1.GenerateQuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.NoError(t, err)
+	assert.NotEmpty(t, quote.QuoteId)
+	assert.Equal(t, "EUR", quote.PayoutCurrency)
+	assert.Equal(t, quote.Fees.TotalFees, quote.Fees.PlatformFee+quote.Fees.OnrampFee+quote.Fees.OfframpFee+quote.Fees.RegulatoryFee)
+	assert.NotNil(t, quote.ExpiresAt)
+
+	req := &paymentsv1.CreatePaymentRequest{
+		IdempotencyKey:     "key_grpc_1",
+		Amount:             100000,
+		Currency:           "EUR",
+		SourceAccount:      "acct_source",
+		DestinationAccount: "acct_dest",
+		QuoteId:            quote.QuoteId,
+	}
+	created, err := client.CreatePayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, string(models.StatusPending), created.Status)
+
+	// Idempotency keys are scoped to the API key, as they are over REST
+	replayed, err := client.CreatePayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, created.PaymentId, replayed.PaymentId)
+	stored, err := db.GetPaymentByID(ctx, created.PaymentId)
+	require.NoError(t, err)
+	assert.Equal(t, "cust_treasury", stored.IdempotencyScope)
+
+	payment, err := client.GetPayment(ctx, &paymentsv1.GetPaymentRequest{PaymentId: created.PaymentId})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100000), payment.Amount)
+	assert.Equal(t, quote.QuoteId, payment.QuoteId)
+	assert.Equal(t, quote.GuaranteedPayout, payment.GuaranteedPayoutAmount)
+	assert.NotNil(t, payment.CreatedAt)
+}
+
+func TestGRPCMapsRESTErrors(t *testing.T) {
+	client, ctx := grpcClient(t, batchHandler(database.NewMemoryPaymentRepository()), "cust_treasury")
+
+	_, err := client.GetPayment(ctx, &paymentsv1.GetPaymentRequest{PaymentId: "pay_missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	var trailer metadata.MD
+	_, err = client.CreatePayment(ctx, &paymentsv1.CreatePaymentRequest{IdempotencyKey: "key_grpc_bad", Currency: "EUR"}, grpc.Trailer(&trailer))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, []string{"VALIDATION_ERROR"}, trailer.Get(grpcapi.MetadataErrorCode))
+	assert.Equal(t, []string{"false"}, trailer.Get(grpcapi.MetadataErrorRetryable))
+	assert.Equal(t, []string{"E1003"}, trailer.Get(grpcapi.MetadataErrorDocID))
+
+	_, err = client.CreatePayment(ctx, &paymentsv1.CreatePaymentRequest{Amount: 100000, Currency: "EUR", SourceAccount: "acct_source", DestinationAccount: "acct_dest"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "the Idempotency-Key header is required, so is the field")
+}
+
+func TestGRPCRequiresAnIssuedAPIKey(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	client, ctx := grpcClient(t, h, "cust_treasury")
+	req := &paymentsv1.GetPaymentRequest{PaymentId: "pay_missing"}
+
+	_, err := client.GetPayment(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Naming a customer isn't enough
+	forged := metadata.AppendToOutgoingContext(context.Background(), "x-api-key-id", "cust_treasury")
+	_, err = client.GetPayment(forged, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	forged = metadata.AppendToOutgoingContext(context.Background(), grpcapi.MetadataAPIKey, "sk_not_issued")
+	_, err = client.GetPayment(forged, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Revoking the key cuts the caller off
+	_, err = client.GetPayment(ctx, req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	keys, err := h.apiKeys.List(context.Background(), "cust_treasury")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	_, err = h.apiKeys.Revoke(context.Background(), "cust_treasury", keys[0].KeyID)
+	require.NoError(t, err)
+	_, err = client.GetPayment(ctx, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPCAppliesTheIPAllowlist(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	client, ctx := grpcClient(t, h, "cust_treasury")
+	h.customers = customers.New(database.NewMemoryCustomerRepository())
+	_, err := h.customers.Create(context.Background(), &models.CustomerRequest{CustomerID: "cust_treasury", Name: "Treasury"})
+	require.NoError(t, err)
+	_, err = h.customers.SetAllowedIPs(context.Background(), "cust_treasury", &models.CustomerIPAllowlistRequest{AllowedIPs: []string{"203.0.113.0/24"}})
+	require.NoError(t, err)
+
+	// In-memory connections have no IP address, so they're never on an allowlist
+	_, err = client.GetPayment(ctx, &paymentsv1.GetPaymentRequest{PaymentId: "pay_missing"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
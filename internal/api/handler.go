@@ -0,0 +1,3850 @@
+// Package api serves the payments REST API behind API Gateway, and over gRPC when run outside Lambda
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/audit"
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/compliance/kyc"
+	"crypto-conversion/internal/compliance/rules"
+	"crypto-conversion/internal/compliance/sanctions"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/eventbus"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/fx"
+	"crypto-conversion/internal/grpcapi"
+	"crypto-conversion/internal/health"
+	"crypto-conversion/internal/jsonschema"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/quotas"
+	"crypto-conversion/internal/quotes"
+	"crypto-conversion/internal/reporting"
+	"crypto-conversion/internal/router"
+	"crypto-conversion/internal/tracing"
+	"crypto-conversion/internal/validator"
+	"crypto-conversion/internal/version"
+	"crypto-conversion/internal/webhooks"
+)
+
+// Audit export page sizes
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// transitionLockTTL bounds how long a forced transition keeps workers off the payment
+const transitionLockTTL = time.Minute
+
+// defaultAICostReportWindow is how far back GET /reports/ai-cost looks when since is omitted
+const defaultAICostReportWindow = 30 * 24 * time.Hour
+
+// defaultWebhookStatsHours is how far back GET /webhooks/{subscription_id}/stats looks when hours is omitted
+const defaultWebhookStatsHours = 24
+
+// Handler manages the API Lambda dependencies
+type Handler struct {
+	db           database.PaymentRepository
+	quoteDB      database.QuoteRepository
+	queue        *queue.Client
+	events       *eventbus.Client
+	audit        *audit.Logger
+	feeCalc      *fees.Calculator
+	aiFeeCalc    *fees.AIFeeCalculator
+	feeShadow    *fees.Shadow // Records AI fees against charged static fees; nil unless shadow mode is on
+	quoteCalc    *quotes.Calculator
+	corridors    *corridors.Registry
+	destinations *validator.CountryPolicy
+	feeSchedules database.FeeScheduleRepository    // nil unless fee schedules are enabled
+	promos       database.PromoRepository          // nil unless promo codes are enabled
+	invoices     database.FeeInvoiceRepository     // nil unless fee invoices are enabled
+	treasury     database.TreasuryRepository       // nil unless treasury tracking is enabled
+	ledger       *ledger.Ledger                    // nil unless the double-entry ledger is enabled
+	breaks       database.ReconciliationRepository // nil unless reconciliation is enabled
+	settlements  reporting.SettlementReportStore   // nil unless REPORT_BUCKET is set
+	customers    *customers.Service                // nil unless customer records are enabled
+	apiKeys      *apikeys.Service                  // nil unless issued API keys are enabled
+	usage        database.UsageRepository          // nil unless usage metering is enabled
+	quotas       *quotas.Enforcer                  // nil unless payment quotas are enabled
+	kyc          *kyc.Verifier                     // nil unless KYC is enabled
+	kycGate      *kyc.Gate                         // nil unless KYC is enabled
+	screener     sanctions.Screener                // nil unless sanctions screening is enabled
+	holds        *payment.HoldPolicy               // nil unless a manual review hold trigger is configured
+	aml          *rules.Engine                     // nil unless AML monitoring is enabled
+	amlAlerts    database.AMLAlertRepository       // nil unless AML monitoring is enabled
+	webhooks     *webhooks.Service                 // nil unless webhook subscriptions are enabled
+	health       *health.Checker
+	cfg          *config.Config
+	router       *router.Router // Built on the first request
+	routerOnce   sync.Once
+}
+
+// NewHandler creates a new API handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	// Initialize payment and quote storage for the configured backend
+	db, quoteDB, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize queue client
+	q, err := queue.NewClient(cfg.AWS.Region, cfg.Queue.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lifecycle events go to EventBridge when a bus is configured
+	var events *eventbus.Client
+	if cfg.Events.BusName != "" {
+		events, err = eventbus.NewClient(cfg.AWS.Region, cfg.Events.BusName, cfg.Events.Source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Payment creation is chained into the audit log when enabled
+	var auditLog *audit.Logger
+	if cfg.Audit.Enabled {
+		auditRepo, err := database.NewAuditRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		auditLog = audit.NewLogger(auditRepo)
+	}
+
+	// Initialize fee calculator
+	feeCalc := fees.NewCalculator()
+
+	// Stored fee schedules replace the built-in tiers without a redeploy
+	var feeSchedules database.FeeScheduleRepository
+	if cfg.FeeSchedules.Enabled {
+		feeSchedules, err = database.NewFeeScheduleRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		feeCalc.EnableSchedules(feeSchedules, time.Duration(cfg.FeeSchedules.CacheSeconds)*time.Second)
+	}
+
+	// Rolling 30-day volume lowers the platform fee rate for high-volume customers
+	if cfg.VolumeDiscounts.Enabled {
+		tiers, err := fees.ParseVolumeTiers(cfg.VolumeDiscounts.Tiers)
+		if err != nil {
+			return nil, err
+		}
+		volumes, err := database.NewVolumeRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		feeCalc.EnableVolumeDiscounts(volumes, tiers)
+	}
+
+	var promos database.PromoRepository
+	if cfg.Promos.Enabled {
+		promos, err = database.NewPromoRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Fee invoices are issued by the worker when payments complete
+	var invoices database.FeeInvoiceRepository
+	if cfg.FeeInvoices.Enabled {
+		invoices, err = database.NewFeeInvoiceRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Treasury balances are moved by the worker; the API reports and tops them up
+	var treasury database.TreasuryRepository
+	if cfg.Treasury.Enabled {
+		treasury, err = database.NewTreasuryRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Ledger transactions are posted by the worker; the API reports them
+	var paymentLedger *ledger.Ledger
+	if cfg.Ledger.Enabled {
+		store, err := database.NewLedgerRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		paymentLedger = ledger.New(store)
+	}
+
+	// Reconciliation breaks are found by the reconciler; the API lists and resolves them
+	var breaks database.ReconciliationRepository
+	if cfg.Reconciliation.Enabled {
+		breaks, err = database.NewReconciliationRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Customer records set each API key's tier and limits
+	var customerService *customers.Service
+	var verifier *kyc.Verifier
+	var kycGate *kyc.Gate
+	if cfg.Customers.Enabled {
+		store, err := database.NewCustomerRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		customerService = customers.New(store)
+
+		// KYC status is kept on the customer records; payments above the threshold need a verified customer
+		if cfg.KYC.Enabled {
+			provider, err := kyc.NewProvider(cfg.KYC)
+			if err != nil {
+				return nil, err
+			}
+			verifier = kyc.NewVerifier(provider, store)
+			kycGate = kyc.NewGate(cfg.KYC.Threshold)
+		}
+	}
+
+	// Counterparties are screened against sanctions lists before a payment is accepted
+	var screener sanctions.Screener
+	if cfg.Sanctions.Enabled {
+		screener, err = sanctions.Load(context.Background(), cfg.Sanctions.SDNURL, cfg.Sanctions.Countries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Settlement reports are written nightly by the settlement report Lambda; the API serves them
+	var settlements reporting.SettlementReportStore
+	if cfg.Reporting.Bucket != "" {
+		settlements, err = reporting.NewS3Exporter(cfg.AWS.Region, cfg.Reporting.Bucket, cfg.Reporting.Prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Customers register their own webhook endpoints; operators attach client certificates to them
+	var webhookService *webhooks.Service
+	if cfg.Webhooks.Enabled {
+		store, err := database.NewWebhookSubscriptionRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		deliveries, err := database.NewWebhookDeliveryRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		webhookService = webhooks.New(store, deliveries, webhooks.NewClientCache(cfg.AWS.Region, cfg.Webhooks.ClientCacheTTL))
+	}
+
+	// Keys the API issues authenticate requests alongside API Gateway's
+	var apiKeyService *apikeys.Service
+	if cfg.APIKeys.Enabled {
+		store, err := database.NewAPIKeyRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		apiKeyService = apikeys.New(store, cfg.APIKeys.RotationGrace)
+	}
+
+	// Each customer's API calls, payments and AI fee calculations are counted for usage-based billing
+	var usage database.UsageRepository
+	if cfg.Usage.Enabled {
+		usage, err = database.NewUsageRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each customer tier's daily and monthly payment counts and volume are capped
+	var quotaEnforcer *quotas.Enforcer
+	if cfg.Quotas.Enabled {
+		tiers := quotas.Tiers{}
+		if cfg.Quotas.Tiers != "" {
+			tiers, err = quotas.ParseTiers([]byte(cfg.Quotas.Tiers))
+			if err != nil {
+				return nil, err
+			}
+		}
+		store, err := database.NewQuotaRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		quotaEnforcer = quotas.New(store, tiers)
+	}
+
+	// Negotiated customer pricing overrides the schedule for payments and quotes
+	if cfg.CustomerPricing.Profiles != "" {
+		pricing, err := fees.ParseCustomerPricing([]byte(cfg.CustomerPricing.Profiles))
+		if err != nil {
+			return nil, err
+		}
+		feeCalc.SetCustomerPricing(pricing)
+	}
+
+	// Supported corridors (built-in, CORRIDORS_JSON, or the corridor table)
+	registry, err := database.NewCorridorRegistry(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	feeCalc.SetCorridors(registry)
+
+	// Large or risky payments are held for an operator's approval before they start
+	var holds *payment.HoldPolicy
+	if cfg.Holds.Enabled() {
+		holds = &payment.HoldPolicy{
+			AmountThreshold: cfg.Holds.AmountThreshold,
+			RiskScore:       cfg.Holds.RiskScore,
+			SanctionsHit:    cfg.Holds.SanctionsHit,
+			Risks:           fees.NewMockDataProvider(),
+			Corridors:       registry,
+		}
+	}
+
+	// Each new payment is checked against the AML monitoring rules; alerts are held for compliance review
+	var amlEngine *rules.Engine
+	var amlAlerts database.AMLAlertRepository
+	if cfg.AML.Enabled {
+		amlAlerts, err = database.NewAMLAlertRepository(context.Background(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		window := time.Duration(cfg.AML.WindowHours) * time.Hour
+		amlEngine = rules.NewEngine(db, amlAlerts, window, rules.Default(cfg.AML, registry)...)
+	}
+
+	// Initialize AI fee calculator (uses the configured model provider)
+	var aiFeeCalc *fees.AIFeeCalculator
+	if cfg.Anthropic.AIEnabled() {
+		// Market data sources (built-in, overridden or extended by DATA_SOURCES_JSON)
+		dataSources := fees.DefaultSourceRegistry()
+		if cfg.DataSources.Definitions != "" {
+			dataSources, err = fees.ParseSourcesJSON([]byte(cfg.DataSources.Definitions))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fxRates, err := fx.NewChainFromNames(cfg.FX.Sources, cfg.FX.OpenExchangeRatesAppID, dataSources.FXEndpoints())
+		if err != nil {
+			return nil, err
+		}
+		fxRates.DivergenceThreshold = cfg.FX.DivergenceThreshold
+		fxRates.Verify = cfg.FX.VerifySources
+
+		// Share fetched market data across Lambda instances when a cache table is configured
+		var sharedCache fees.SharedCache
+		if cfg.FX.CacheTableName != "" {
+			marketCache, err := database.NewMarketCacheClient(cfg.AWS.Region, cfg.FX.CacheTableName, cfg.Database.Endpoint)
+			if err != nil {
+				return nil, err
+			}
+			sharedCache = marketCache
+		}
+
+		// Model settings come from env or Parameter Store; transient failures are retried with backoff
+		llmConfig := fees.DefaultLLMConfig()
+		llmConfig.Provider = cfg.Anthropic.Provider
+		llmConfig.Model = cfg.Anthropic.Model
+		llmConfig.MaxTokens = cfg.Anthropic.MaxTokens
+		llmConfig.Timeout = cfg.Anthropic.Timeout
+		llmConfig.Temperature = cfg.Anthropic.Temperature
+		llmConfig.Region = cfg.AWS.Region
+		llmConfig.PromptVersion = cfg.Anthropic.PromptVersion
+		llmConfig.Retry.MaxAttempts = cfg.Anthropic.MaxAttempts
+		llmConfig.Retry.MaxConcurrency = cfg.Anthropic.MaxConcurrency
+
+		llm, err := fees.NewLLMClient(llmConfig, cfg.Anthropic.APIKey)
+		if err != nil {
+			return nil, err
+		}
+
+		aiFeeCalc = fees.NewAIFeeCalculator(llm, registry, fxRates, sharedCache, llmConfig)
+		aiFeeCalc.SetDataSources(dataSources)
+
+		// Chain health also scores settlement latency of our own recent payments
+		aiFeeCalc.SetSettlements(db)
+
+		// Record gas readings so routing and the prompt see 1h/24h averages, not one reading
+		if cfg.GasHistory.Enabled {
+			gasHistory, err := database.NewGasPriceRepository(context.Background(), cfg)
+			if err != nil {
+				return nil, err
+			}
+			aiFeeCalc.SetGasHistory(gasHistory)
+		}
+
+		logger.Info("AI fee calculator initialized", logger.Fields{
+			"provider":       llmConfig.Provider,
+			"model":          llmConfig.Model,
+			"prompt_version": llmConfig.PromptVersion,
+		})
+	} else {
+		logger.Warn("LLM API key not configured - AI fee calculation disabled", logger.Fields{
+			"provider": cfg.Anthropic.Provider,
+		})
+	}
+
+	// In shadow mode payments keep the static fee while the AI engine is evaluated in the background
+	var feeShadow *fees.Shadow
+	if cfg.FeeShadow.Enabled {
+		if aiFeeCalc == nil {
+			logger.Warn("Fee shadow mode enabled but AI fee calculation is disabled", logger.Fields{})
+		} else {
+			comparisons, err := database.NewFeeComparisonRepository(context.Background(), cfg)
+			if err != nil {
+				return nil, err
+			}
+			feeShadow = fees.NewShadow(aiFeeCalc, comparisons, cfg.FeeShadow.Timeout)
+		}
+	}
+
+	// Quote validity policy (flat 60s default, env overrides per corridor and tier)
+	ttlPolicy := quotes.DefaultTTLPolicy()
+	ttlPolicy.Default = cfg.Quotes.DefaultTTL
+	ttlPolicy.Corridors = cfg.Quotes.CorridorTTLs
+	ttlPolicy.Tiers = cfg.Quotes.TierTTLs
+	ttlPolicy.VolatileTTL = cfg.Quotes.VolatileTTL
+	ttlPolicy.VolatilityThreshold = cfg.Quotes.VolatilityThreshold
+
+	// Live rate providers (none configured simulates rates)
+	rateProviders, err := quotes.NewRateProviders(cfg.Quotes.RateProviders, cfg.Quotes.CircleAPIURL, cfg.Quotes.CircleAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Sandbox.Enabled {
+		rateProviders = nil // Sandbox rates are simulated
+	}
+
+	// Initialize quote calculator
+	quoteCalc := quotes.NewCalculator(feeCalc, ttlPolicy, registry, rateProviders)
+	if aiFeeCalc != nil {
+		// Quotes name the healthiest corridor chain to settle on
+		quoteCalc.SetChainAdvisor(aiFeeCalc)
+		// Indicative rates compare against the fee engine's cached mid-rates
+		quoteCalc.SetMidRateSource(aiFeeCalc)
+	}
+	quoteCalc.SetSLAPolicy(chains.SLAPolicy{Default: cfg.Quotes.SettlementSLA, Chains: cfg.Quotes.ChainSLAs})
+
+	checker, err := newHealthChecker(cfg, db, quoteDB, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		db:           db,
+		quoteDB:      quoteDB,
+		queue:        q,
+		events:       events,
+		audit:        auditLog,
+		feeCalc:      feeCalc,
+		aiFeeCalc:    aiFeeCalc,
+		feeShadow:    feeShadow,
+		quoteCalc:    quoteCalc,
+		corridors:    registry,
+		destinations: validator.NewCountryPolicy(cfg.Destinations.Allowed, cfg.Destinations.Denied),
+		feeSchedules: feeSchedules,
+		promos:       promos,
+		invoices:     invoices,
+		treasury:     treasury,
+		ledger:       paymentLedger,
+		breaks:       breaks,
+		settlements:  settlements,
+		customers:    customerService,
+		apiKeys:      apiKeyService,
+		usage:        usage,
+		quotas:       quotaEnforcer,
+		kyc:          verifier,
+		kycGate:      kycGate,
+		screener:     screener,
+		holds:        holds,
+		aml:          amlEngine,
+		amlAlerts:    amlAlerts,
+		webhooks:     webhookService,
+		health:       checker,
+		cfg:          cfg,
+	}, nil
+}
+
+// newHealthChecker checks payment and quote storage and the payment queue, which every payment needs,
+// and the fee engine's market data sources when enabled
+func newHealthChecker(cfg *config.Config, db database.PaymentRepository, quoteDB database.QuoteRepository, q *queue.Client) (*health.Checker, error) {
+	checker := health.NewChecker(cfg.Health.Timeout)
+	if hc, ok := db.(database.HealthChecker); ok {
+		checker.Add("payments_store", true, hc.CheckHealth)
+	}
+	if hc, ok := quoteDB.(database.HealthChecker); ok {
+		checker.Add("quotes_store", true, hc.CheckHealth)
+	}
+	if cfg.Queue.PaymentQueueURL != "" {
+		checker.Add("payment_queue", true, func(ctx context.Context) error {
+			return q.CheckQueue(ctx, cfg.Queue.PaymentQueueURL)
+		})
+	}
+
+	if cfg.Health.DataSources {
+		dataSources := fees.DefaultSourceRegistry()
+		if cfg.DataSources.Definitions != "" {
+			var err error
+			dataSources, err = fees.ParseSourcesJSON([]byte(cfg.DataSources.Definitions))
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, source := range dataSources.HealthSources() {
+			source := source
+			checker.Add("data_source:"+source.GetName(), false, func(ctx context.Context) error {
+				_, err := source.Fetch(ctx)
+				return err
+			})
+		}
+	}
+	return checker, nil
+}
+
+// HandleRequest handles the API Gateway request
+func (h *Handler) HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (response events.APIGatewayProxyResponse, err error) {
+	correlationID := correlationIDHeader(request)
+	logger.Info("Received API request", logger.Fields{
+		"path":           request.Path,
+		"method":         request.HTTPMethod,
+		"correlation_id": correlationID,
+	})
+
+	// A panicking handler gets a JSON 500 instead of failing the invocation, which API Gateway
+	// would return as a bare 502; every response carries the correlation ID its logs are under
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			response, err = panicResponse(request, correlationID, recovered)
+		}
+		if response.Headers == nil {
+			response.Headers = map[string]string{}
+		}
+		response.Headers["X-Correlation-ID"] = correlationID
+	}()
+
+	// Trace each route in its own subsegment so handlers can annotate it with the payment ID
+	err = tracing.Capture(ctx, request.HTTPMethod+" "+request.Resource, func(ctx context.Context) error {
+		var err error
+		response, err = h.route(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// correlationIDHeader returns the request's X-Correlation-ID header, else API Gateway's request ID
+// A caller tracing a request across services sends its own; IDs that couldn't be a header value are replaced.
+func correlationIDHeader(request events.APIGatewayProxyRequest) string {
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, "X-Correlation-ID") && validCorrelationID(value) {
+			return value
+		}
+	}
+	if request.RequestContext.RequestID != "" {
+		return request.RequestContext.RequestID
+	}
+	return uuid.New().String()
+}
+
+// validCorrelationID reports whether a caller's correlation ID is short printable ASCII without spaces
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// panicResponse logs a handler's panic with its stack trace and returns a structured INTERNAL_ERROR
+// The panic value stays in the logs: it can hold internals the caller shouldn't see.
+func panicResponse(request events.APIGatewayProxyRequest, correlationID string, recovered interface{}) (events.APIGatewayProxyResponse, error) {
+	logger.Error("Handler panicked", logger.Fields{
+		"panic":          fmt.Sprint(recovered),
+		"stack":          string(debug.Stack()),
+		"path":           request.Path,
+		"method":         request.HTTPMethod,
+		"correlation_id": correlationID,
+	})
+	metrics.Count("HandlerPanics", metrics.Dimensions{})
+
+	appErr := errors.ErrInternalServer("An unexpected error occurred", nil)
+	appErr.CorrelationID = correlationID
+	return appErrorResponse(appErr)
+}
+
+// route dispatches the request to the handler for its method and path
+func (h *Handler) route(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	h.routerOnce.Do(func() { h.router = h.newRouter() })
+
+	match, allowed, found := h.router.Match(request.HTTPMethod, request.Path)
+	if !found {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Endpoint not found")
+	}
+	if match == nil {
+		resp, err := errorResponse(http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", fmt.Sprintf("%s is not allowed on %s", request.HTTPMethod, request.Path))
+		resp.Headers["Allow"] = strings.Join(allowed, ", ")
+		return resp, err
+	}
+
+	// The router's parameters replace API Gateway's, which a proxy resource leaves empty
+	params := make(map[string]string, len(request.PathParameters)+len(match.Params))
+	for name, value := range request.PathParameters {
+		params[name] = value
+	}
+	for name, value := range match.Params {
+		params[name] = value
+	}
+	request.PathParameters = params
+
+	if operatorRoute(match.Route) {
+		if hasCustomerIdentity(request) {
+			return errorResponse(http.StatusForbidden, "FORBIDDEN", "Operator endpoints can't be called with an API key")
+		}
+		// /v1/{proxy+} isn't IAM-authorized, so operator routes reached through it have no principal
+		if request.RequestContext.Identity.UserArn == "" {
+			return errorResponse(http.StatusForbidden, "FORBIDDEN", "Operator endpoints require IAM credentials")
+		}
+	}
+	if appErr := h.authenticateAPIKey(ctx, &request); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	if appErr := h.checkSourceIP(ctx, request); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	h.recordUsage(ctx, request, models.UsageMetricAPICalls, "", 1)
+	if appErr := validateBody(request, match.Route); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	return match.Handler()(ctx, request)
+}
+
+// operatorRoute reports whether a route is for operators, whose calls API Gateway authorizes with IAM credentials
+func operatorRoute(route *router.Route) bool {
+	for _, prefix := range operatorPrefixes {
+		if strings.HasPrefix(route.Pattern, prefix) {
+			return true
+		}
+	}
+	return route.Pattern == "/payments/{payment_id}/review"
+}
+
+// operatorPrefixes are the path prefixes of operator routes; those outside /internal predate it
+var operatorPrefixes = []string{"/internal/", "/audit", "/reports/", "/fee-schedules/"}
+
+// hasCustomerIdentity reports whether the request carries an API key, API Gateway's or one we issued
+func hasCustomerIdentity(request events.APIGatewayProxyRequest) bool {
+	return request.RequestContext.Identity.APIKeyID != "" || apiKeyHeader(request) != ""
+}
+
+// apiVersion is the version unprefixed paths are served by
+const apiVersion = "v1"
+
+// newRouter registers every endpoint on v1, served under /v1 and on unprefixed paths
+// A breaking change registers its replacement on a v2 added with router.AddVersion("v2", v1).
+func (h *Handler) newRouter() *router.Router {
+	r := router.New(apiVersion)
+	v1 := r.Version(apiVersion)
+
+	// withParam adapts a handler that takes one path parameter
+	withParam := func(name string, handle func(ctx context.Context, request events.APIGatewayProxyRequest, value string) (events.APIGatewayProxyResponse, error)) router.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return handle(ctx, request, request.PathParameters[name])
+		}
+	}
+
+	v1.Handle(http.MethodPost, "/quotes", h.handleCreateQuote)
+	v1.Handle(http.MethodPost, "/quotes/compare", h.handleCompareQuotes)
+	v1.Handle(http.MethodPost, "/payments", h.handleCreatePayment)
+	v1.Handle(http.MethodPost, "/payments/batch", h.handleCreatePaymentBatch)
+	v1.Handle(http.MethodGet, "/payments/{payment_id}", withParam("payment_id", h.handleGetPayment))
+	v1.Handle(http.MethodGet, "/payments/{payment_id}/events", withParam("payment_id", h.handleGetPaymentEvents))
+	v1.Handle(http.MethodGet, "/payments/{payment_id}/fees", withParam("payment_id", h.handleGetFeeInvoice))
+	v1.Handle(http.MethodPost, "/payments/{payment_id}/review", withParam("payment_id", h.handleReviewPayment))
+	v1.Handle(http.MethodPost, "/fees/calculate", h.handleCalculateFees)
+	v1.Handle(http.MethodGet, "/fees/estimate", h.handleEstimateFees)
+
+	v1.Handle(http.MethodGet, "/version", func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleVersion()
+	})
+	v1.Handle(http.MethodGet, "/schemas/{schema_name}", withParam("schema_name", func(_ context.Context, _ events.APIGatewayProxyRequest, name string) (events.APIGatewayProxyResponse, error) {
+		return handleGetSchema(name)
+	}))
+	v1.Handle(http.MethodGet, "/health", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleHealth(ctx, h.health.Liveness)
+	})
+	v1.Handle(http.MethodGet, "/ready", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleHealth(ctx, h.health.Readiness)
+	})
+	v1.Handle(http.MethodGet, "/currencies", h.handleListCurrencies)
+	v1.Handle(http.MethodGet, "/rates", h.handleGetRate)
+	v1.Handle(http.MethodGet, "/pricing", h.handleGetPricing)
+	v1.Handle(http.MethodGet, "/usage", h.handleGetUsage)
+	v1.Handle(http.MethodGet, "/quotas", h.handleGetQuotas)
+	v1.Handle(http.MethodGet, "/audit", h.handleExportAudit)
+	v1.Handle(http.MethodGet, "/reports/ai-cost", h.handleAICostReport)
+	v1.Handle(http.MethodGet, "/reports/gas-trends", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleGasTrends(ctx)
+	})
+	v1.Handle(http.MethodGet, "/fee-schedules/{schedule_id}", withParam("schedule_id", func(ctx context.Context, _ events.APIGatewayProxyRequest, scheduleID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleListFeeSchedules(ctx, scheduleID)
+	}))
+	v1.Handle(http.MethodPost, "/fee-schedules/{schedule_id}", withParam("schedule_id", h.handleCreateFeeSchedule))
+
+	v1.Handle(http.MethodGet, "/webhooks", h.handleListWebhookSubscriptions)
+	v1.Handle(http.MethodPost, "/webhooks", h.handleCreateWebhookSubscription)
+	v1.Handle(http.MethodDelete, "/webhooks/{subscription_id}", withParam("subscription_id", h.handleDeleteWebhookSubscription))
+	v1.Handle(http.MethodGet, "/webhooks/{subscription_id}/stats", withParam("subscription_id", h.handleGetWebhookStats))
+	v1.Handle(http.MethodPost, "/webhooks/{subscription_id}/rotate-secret", withParam("subscription_id", h.handleRotateWebhookSecret))
+	v1.Handle(http.MethodGet, "/api-keys", h.handleListAPIKeys)
+	v1.Handle(http.MethodPost, "/api-keys", h.handleIssueAPIKey)
+	v1.Handle(http.MethodPost, "/api-keys/{key_id}/rotate", withParam("key_id", h.handleRotateAPIKey))
+	v1.Handle(http.MethodPost, "/api-keys/{key_id}/revoke", withParam("key_id", h.handleRevokeAPIKey))
+	v1.Handle(http.MethodGet, "/ip-allowlist", h.handleGetIPAllowlist)
+	v1.Handle(http.MethodPut, "/ip-allowlist", h.handleSetIPAllowlist)
+
+	// Operator endpoints
+	v1.Handle(http.MethodGet, "/internal/treasury", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleGetTreasury(ctx)
+	})
+	v1.Handle(http.MethodPost, "/internal/treasury", h.handleAdjustTreasury)
+	v1.Handle(http.MethodGet, "/internal/compliance/holds", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleListComplianceHolds(ctx)
+	})
+	v1.Handle(http.MethodPost, "/internal/compliance/holds/{payment_id}/resolve", withParam("payment_id", h.handleResolveComplianceHold))
+	v1.Handle(http.MethodGet, "/internal/compliance/alerts", h.handleListAMLAlerts)
+	v1.Handle(http.MethodPost, "/internal/compliance/alerts/{alert_id}/close", withParam("alert_id", h.handleCloseAMLAlert))
+	v1.Handle(http.MethodGet, "/internal/reconciliation/breaks", h.handleListBreaks)
+	v1.Handle(http.MethodPost, "/internal/reconciliation/breaks/{break_id}/resolve", withParam("break_id", h.handleResolveBreak))
+	v1.Handle(http.MethodGet, "/internal/customers", func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return h.handleListCustomers(ctx)
+	})
+	v1.Handle(http.MethodPost, "/internal/customers", h.handleCreateCustomer)
+	v1.Handle(http.MethodGet, "/internal/customers/{customer_id}", withParam("customer_id", func(ctx context.Context, _ events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleGetCustomer(ctx, customerID)
+	}))
+	v1.Handle(http.MethodPut, "/internal/customers/{customer_id}", withParam("customer_id", h.handleUpdateCustomer))
+	v1.Handle(http.MethodDelete, "/internal/customers/{customer_id}", withParam("customer_id", h.handleDeleteCustomer))
+	v1.Handle(http.MethodPost, "/internal/customers/{customer_id}/kyc", withParam("customer_id", h.handleStartKYC))
+	v1.Handle(http.MethodPost, "/internal/customers/{customer_id}/kyc/sync", withParam("customer_id", func(ctx context.Context, _ events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleSyncKYC(ctx, customerID)
+	}))
+	v1.Handle(http.MethodPost, "/internal/customers/{customer_id}/api-keys", withParam("customer_id", h.handleIssueCustomerAPIKey))
+	v1.Handle(http.MethodPost, "/internal/customers/{customer_id}/api-keys/{key_id}/revoke", withParam("customer_id", func(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleRevokeCustomerAPIKey(ctx, request, customerID, request.PathParameters["key_id"])
+	}))
+	v1.Handle(http.MethodPut, "/internal/customers/{customer_id}/ip-allowlist", withParam("customer_id", h.handleSetCustomerIPAllowlist))
+	v1.Handle(http.MethodPost, "/internal/customers/{customer_id}/accounts", withParam("customer_id", h.handleLinkCustomerAccount))
+	v1.Handle(http.MethodDelete, "/internal/customers/{customer_id}/accounts/{account_id}", withParam("customer_id", func(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleUnlinkCustomerAccount(ctx, request, customerID, request.PathParameters["account_id"])
+	}))
+	v1.Handle(http.MethodPut, "/internal/webhooks/{subscription_id}/mtls", withParam("subscription_id", h.handleSetWebhookMTLS))
+	v1.Handle(http.MethodGet, "/internal/reports/settlements/{report_date}", withParam("report_date", func(ctx context.Context, _ events.APIGatewayProxyRequest, reportDate string) (events.APIGatewayProxyResponse, error) {
+		return h.handleGetSettlementReport(ctx, reportDate)
+	}))
+	v1.Handle(http.MethodGet, "/internal/payments/{payment_id}/ledger", withParam("payment_id", func(ctx context.Context, _ events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleGetPaymentLedger(ctx, paymentID)
+	}))
+	v1.Handle(http.MethodPost, "/internal/payments/{payment_id}/transition", withParam("payment_id", h.handleForceTransition))
+	v1.Handle(http.MethodPost, "/internal/payments/{payment_id}/approve", withParam("payment_id", func(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleResolveHold(ctx, request, paymentID, models.HoldDecisionApprove)
+	}))
+	v1.Handle(http.MethodPost, "/internal/payments/{payment_id}/reject", withParam("payment_id", func(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+		return h.handleResolveHold(ctx, request, paymentID, models.HoldDecisionReject)
+	}))
+
+	return r
+}
+
+// handleVersion handles GET /version, reporting the running build and the schema versions it reads and writes
+func (h *Handler) handleVersion() (events.APIGatewayProxyResponse, error) {
+	body, _ := json.Marshal(buildInfo())
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// buildInfo is the running build with the versions of the payment job and webhook event messages and the Postgres schema
+func buildInfo() version.Info {
+	info := version.Get()
+	info.Schemas = map[string]string{
+		"payment_job":   strconv.Itoa(models.PaymentJobSchemaVersion),
+		"webhook_event": strconv.Itoa(models.WebhookEventSchemaVersion),
+		"postgres":      database.LatestMigration(),
+	}
+	return info
+}
+
+// requestSchemas are the JSON Schemas request bodies are checked against before their handler unmarshals them, by route
+var requestSchemas = map[string]*jsonschema.Schema{
+	"POST /quotes":         jsonschema.Generate(quotes.QuoteRequest{}),
+	"POST /payments":       jsonschema.Generate(models.PaymentRequest{}),
+	"POST /payments/batch": jsonschema.Generate(models.BatchPaymentRequest{}),
+	"POST /fees/calculate": jsonschema.Generate(fees.AIFeeRequest{}),
+}
+
+// validateBody checks a request's body against its route's schema, reporting every field of the wrong type
+func validateBody(request events.APIGatewayProxyRequest, matched *router.Route) *errors.AppError {
+	route := matched.Method + " " + matched.Pattern
+	schema, ok := requestSchemas[route]
+	if !ok {
+		return nil
+	}
+
+	fields, err := schema.Validate([]byte(request.Body))
+	if err != nil {
+		metrics.Count("SchemaValidationFailures", metrics.Dimensions{"Route": route})
+		return errors.New("INVALID_JSON", fmt.Sprintf("Invalid request body: %s", err.Error()), http.StatusBadRequest, nil)
+	}
+	if len(fields) > 0 {
+		metrics.Count("SchemaValidationFailures", metrics.Dimensions{"Route": route})
+		return errors.ErrValidationFields(fields)
+	}
+	return nil
+}
+
+// handleGetSchema handles GET /schemas/{schema_name}, returning the JSON Schema of a request body such as PaymentRequest
+func handleGetSchema(name string) (events.APIGatewayProxyResponse, error) {
+	for _, schema := range requestSchemas {
+		if schema.Title != name {
+			continue
+		}
+		body, _ := json.Marshal(schema)
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type":                "application/schema+json",
+				"Access-Control-Allow-Origin": "*",
+			},
+			Body: string(body),
+		}, nil
+	}
+	return errorResponse(http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Schema '%s' not found", name))
+}
+
+// handleHealth handles GET /health and GET /ready, reporting each dependency's status
+func (h *Handler) handleHealth(ctx context.Context, run func(ctx context.Context) (int, *health.Report)) (events.APIGatewayProxyResponse, error) {
+	status, report := run(ctx)
+	body, _ := json.Marshal(report)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Cache-Control": "no-store",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// handleExportAudit handles GET /audit, returning a verified page of the audit log
+func (h *Handler) handleExportAudit(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.audit == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Audit log is not enabled")
+	}
+
+	after, err := strconv.ParseInt(queryParam(request, "after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "after must be a non-negative sequence number")
+	}
+
+	limit, err := strconv.Atoi(queryParam(request, "limit", strconv.Itoa(defaultAuditPageSize)))
+	if err != nil || limit <= 0 || limit > maxAuditPageSize {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("limit must be between 1 and %d", maxAuditPageSize))
+	}
+
+	entries, err := h.audit.List(ctx, after, limit)
+	if err != nil {
+		logger.Error("Failed to export audit log", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export audit log")
+	}
+
+	response := models.AuditExportResponse{
+		Entries:  entries,
+		Verified: true,
+	}
+	if response.Entries == nil {
+		response.Entries = []*models.AuditEntry{}
+	}
+	if err := audit.Verify(entries); err != nil {
+		response.Verified = false
+		response.Error = err.Error()
+	}
+	if len(entries) == limit {
+		response.Next = entries[len(entries)-1].Sequence
+	}
+
+	responseBody, _ := json.Marshal(response)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// recordAudit appends an event to the audit log when enabled
+// Failures are logged rather than returned: the audited operation has already been persisted.
+func (h *Handler) recordAudit(ctx context.Context, event audit.Event) {
+	if h.audit == nil {
+		return
+	}
+
+	if _, err := h.audit.Record(ctx, event); err != nil {
+		logger.Error("Failed to write audit entry", logger.Fields{
+			"action":      event.Action,
+			"resource_id": event.ResourceID,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// requestActor identifies the caller for the audit log: the IAM principal or API key if present, otherwise the source IP
+func requestActor(request events.APIGatewayProxyRequest) string {
+	if request.RequestContext.Identity.UserArn != "" {
+		return "iam:" + request.RequestContext.Identity.UserArn
+	}
+	if request.RequestContext.Identity.APIKeyID != "" {
+		return "api_key:" + request.RequestContext.Identity.APIKeyID
+	}
+	return "ip:" + request.RequestContext.Identity.SourceIP
+}
+
+// queryParam returns a query string parameter or its default
+func queryParam(request events.APIGatewayProxyRequest, name, defaultValue string) string {
+	if value := request.QueryStringParameters[name]; value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// handleCreateQuote handles POST /quotes
+func (h *Handler) handleCreateQuote(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Parse request body
+	var quoteReq quotes.QuoteRequest
+	if err := json.Unmarshal([]byte(request.Body), &quoteReq); err != nil {
+		logger.Error("Failed to parse quote request body", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	quoteReq.CustomerTier = h.customerTier(customer, request)
+	quoteReq.Customer = request.RequestContext.Identity.APIKeyID
+
+	// Quotes only check a promo is usable; the payment redeems it
+	if quoteReq.PromoCode != "" {
+		promo, appErr := h.lookupPromo(ctx, quoteReq.PromoCode)
+		if appErr != nil {
+			return appErrorResponse(appErr)
+		}
+		quoteReq.Promo = promo
+	}
+
+	// Generate quote
+	quote, err := h.quoteCalc.GenerateQuote(ctx, &quoteReq)
+	if err != nil {
+		logger.Warn("Quote generation failed", logger.Fields{"error": err.Error()})
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusServiceUnavailable {
+			return errorResponse(appErr.StatusCode, appErr.Code, appErr.Message)
+		}
+		return errorResponse(http.StatusBadRequest, "QUOTE_ERROR", err.Error())
+	}
+
+	// Store quote in database
+	if err := h.quoteDB.CreateQuote(ctx, quote); err != nil {
+		logger.Error("Failed to store quote", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quote.QuoteID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create quote")
+	}
+
+	// Notify downstream subscribers (best-effort)
+	if h.events != nil {
+		if err := h.events.Publish(ctx, eventbus.DetailTypeQuoteCreated, quote); err != nil {
+			logger.Warn("Failed to publish quote event", logger.Fields{
+				"error":    err.Error(),
+				"quote_id": quote.QuoteID,
+			})
+		}
+	}
+
+	// Return quote response
+	responseBody, _ := json.Marshal(quote.ToResponse())
+
+	logger.Info("Quote created successfully", logger.Fields{
+		"quote_id":          quote.QuoteID,
+		"amount":            quote.Amount,
+		"guaranteed_payout": quote.GuaranteedPayout,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleCompareQuotes handles POST /quotes/compare, pricing a quote request both deterministically and with
+// the AI engine, side by side. The static quote isn't stored, so it can't be used to create a payment.
+func (h *Handler) handleCompareQuotes(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.aiFeeCalc == nil {
+		return errorResponse(http.StatusServiceUnavailable, "AI_UNAVAILABLE", "AI fee calculation is not available")
+	}
+
+	var quoteReq quotes.QuoteRequest
+	if err := json.Unmarshal([]byte(request.Body), &quoteReq); err != nil {
+		logger.Error("Failed to parse quote comparison body", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	quoteReq.CustomerTier = h.customerTier(customer, request)
+	quoteReq.Customer = request.RequestContext.Identity.APIKeyID
+	if quoteReq.PromoCode != "" {
+		promo, appErr := h.lookupPromo(ctx, quoteReq.PromoCode)
+		if appErr != nil {
+			return appErrorResponse(appErr)
+		}
+		quoteReq.Promo = promo
+	}
+
+	quote, err := h.quoteCalc.GenerateQuote(ctx, &quoteReq)
+	if err != nil {
+		logger.Warn("Quote generation failed", logger.Fields{"error": err.Error()})
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusServiceUnavailable {
+			return errorResponse(appErr.StatusCode, appErr.Code, appErr.Message)
+		}
+		return errorResponse(http.StatusBadRequest, "QUOTE_ERROR", err.Error())
+	}
+
+	tier := quoteReq.CustomerTier
+	if tier == "" {
+		tier = "standard"
+	}
+	feeReq := &fees.AIFeeRequest{
+		Amount:       quote.Amount,
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Priority:     "standard",
+		CustomerTier: tier,
+		Customer:     quoteReq.Customer,
+	}
+	feeResp, err := h.aiFeeCalc.Calculate(ctx, feeReq)
+	if err != nil {
+		logger.Error("AI fee calculation failed", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "CALCULATION_ERROR", "Failed to calculate fees")
+	}
+	h.recordUsage(ctx, request, models.UsageMetricAIFeeCalculations, "", 1)
+
+	comparison := quotes.Compare(quote, feeResp)
+	responseBody, _ := json.Marshal(comparison)
+
+	logger.Info("Quotes compared", logger.Fields{
+		"amount":        quote.Amount,
+		"from_currency": quote.FromCurrency,
+		"to_currency":   quote.ToCurrency,
+		"static_fees":   quote.TotalFees,
+		"ai_fee":        feeResp.TotalFee,
+		"delta":         comparison.Delta.Fee,
+		"ai_fallback":   feeResp.Fallback,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleCreatePayment handles POST /payments
+func (h *Handler) handleCreatePayment(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	// Extract idempotency key from headers
+	idempotencyKey := idempotencyKeyHeader(request)
+
+	// Validate idempotency key
+	if err := validator.ValidateIdempotencyKey(idempotencyKey); err != nil {
+		appErr := err.(*errors.AppError)
+		return errorResponse(appErr.StatusCode, appErr.Code, appErr.Message)
+	}
+
+	// Check if payment with this idempotency key already exists; keys are unique per API key
+	existingPayment, err := h.db.GetPaymentByIdempotencyKey(ctx, request.RequestContext.Identity.APIKeyID, idempotencyKey)
+	if err != nil {
+		logger.Error("Failed to check idempotency key", logger.Fields{
+			"error":           err.Error(),
+			"idempotency_key": idempotencyKey,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process request")
+	}
+
+	if existingPayment != nil {
+		return replayPayment(request, idempotencyKey, existingPayment, "A payment with this idempotency key already exists")
+	}
+
+	// Parse request body
+	var paymentReq models.PaymentRequest
+	if err := json.Unmarshal([]byte(request.Body), &paymentReq); err != nil {
+		logger.Error("Failed to parse request body", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	payment, customer, appErr := h.preparePayment(ctx, request, idempotencyKey, &paymentReq)
+	if appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	paymentID := payment.PaymentID
+
+	// Create payment job; a held payment's job is a no-op until compliance releases it
+	outboxMsg, err := models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, paymentID, models.NewPaymentJob(payment))
+	if err != nil {
+		logger.Error("Failed to build outbox message", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		h.releaseReservations(ctx, payment)
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create payment")
+	}
+
+	// The 202 Accepted response is saved with the payment, so a retry under the same key gets it again
+	response := models.PaymentResponse{
+		PaymentID: paymentID,
+		Status:    payment.Status,
+		Message:   "Payment accepted for processing",
+	}
+	switch payment.Status {
+	case models.StatusComplianceHold:
+		response.Message = "Payment accepted and held for compliance review"
+	case models.StatusOnHold:
+		response.Message = "Payment accepted and held for manual approval"
+	}
+
+	responseBody, _ := json.Marshal(response)
+	payment.IdempotentResponse = models.NewIdempotentResponse(request.Body, http.StatusAccepted, responseBody)
+
+	// Save payment and its job atomically, consuming its quote; the outbox relay hands the job to the orchestrator
+	// Marking the quote consumed feeds the quote.consumed webhook via the quotes table stream.
+	if err := h.db.CreatePaymentWithOutbox(ctx, payment, outboxMsg); err != nil {
+		logger.Error("Failed to create payment", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		h.releaseReservations(ctx, payment)
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusConflict {
+			// A concurrent retry created the payment first
+			if appErr.Code == "DUPLICATE_REQUEST" {
+				if existing, err := h.db.GetPaymentByIdempotencyKey(ctx, payment.IdempotencyScope, idempotencyKey); err == nil && existing != nil {
+					return replayPayment(request, idempotencyKey, existing, "A payment with this idempotency key already exists")
+				}
+			}
+			return appErrorResponse(appErr)
+		}
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create payment")
+	}
+
+	h.paymentAccepted(ctx, request, payment, customer)
+
+	logger.Info("Payment accepted", logger.Fields{
+		"payment_id":      paymentID,
+		"idempotency_key": idempotencyKey,
+	})
+
+	return paymentCreatedResponse(http.StatusAccepted, string(responseBody), false)
+}
+
+// replayPayment answers a request whose idempotency key already created a payment
+// A body matching the first request's gets the response that request did; a different body, or a key
+// whose payment was created before responses were kept, is refused as a duplicate.
+func replayPayment(request events.APIGatewayProxyRequest, idempotencyKey string, existing *models.Payment, conflictMessage string) (events.APIGatewayProxyResponse, error) {
+	if !existing.IdempotentResponse.Matches(request.Body) {
+		logger.Warn("Duplicate idempotency key", logger.Fields{
+			"idempotency_key": idempotencyKey,
+			"payment_id":      existing.PaymentID,
+		})
+		return errorResponse(http.StatusConflict, "DUPLICATE_REQUEST", conflictMessage)
+	}
+
+	metrics.Count("IdempotentReplays", metrics.Dimensions{})
+	logger.Info("Replaying idempotent response", logger.Fields{
+		"idempotency_key": idempotencyKey,
+		"payment_id":      existing.PaymentID,
+	})
+	return paymentCreatedResponse(existing.IdempotentResponse.StatusCode, existing.IdempotentResponse.Body, true)
+}
+
+// paymentCreatedResponse creates a POST /payments or POST /payments/batch response
+// Replays are marked with an Idempotent-Replayed header.
+func paymentCreatedResponse(statusCode int, body string, replayed bool) (events.APIGatewayProxyResponse, error) {
+	headers := map[string]string{
+		"Content-Type":                 "application/json",
+		"Access-Control-Allow-Origin":  "*",
+		"Access-Control-Allow-Methods": "POST,OPTIONS",
+		"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,Idempotency-Key",
+	}
+	if replayed {
+		headers["Idempotent-Replayed"] = "true"
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       body,
+	}, nil
+}
+
+// preparePayment validates a payment request and prices it into a new payment, not yet saved
+// A promo code is redeemed along the way; callers release it if the payment isn't created after all.
+func (h *Handler) preparePayment(ctx context.Context, request events.APIGatewayProxyRequest, idempotencyKey string, paymentReq *models.PaymentRequest) (*models.Payment, *models.Customer, *errors.AppError) {
+	// Validate payment request
+	if err := validator.ValidatePaymentRequest(paymentReq); err != nil {
+		appErr := err.(*errors.AppError)
+		logger.Warn("Validation failed", logger.Fields{
+			"error": appErr.Message,
+		})
+		return nil, nil, appErr
+	}
+
+	// Prohibited destinations are refused here rather than failing at the off-ramp
+	if err := h.destinations.Check(validator.PaymentDestination(paymentReq, h.corridors)); err != nil {
+		appErr := err.(*errors.AppError)
+		logger.Warn("Payment to restricted destination", logger.Fields{
+			"error": appErr.Message,
+		})
+		return nil, nil, appErr
+	}
+
+	// A customer's record sets its per-payment limit and, once accounts are linked, which accounts it may fund from
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	if customer != nil {
+		if appErr := customers.CheckPayment(customer, paymentReq); appErr != nil {
+			logger.Warn("Payment refused by customer record", logger.Fields{
+				"customer_id": customer.CustomerID,
+				"code":        appErr.Code,
+			})
+			return nil, nil, appErr
+		}
+	}
+	if h.kycGate != nil {
+		if appErr := h.kycGate.Check(customer, paymentReq.Amount); appErr != nil {
+			logger.Warn("Payment refused pending KYC", logger.Fields{
+				"customer_id": request.RequestContext.Identity.APIKeyID,
+				"amount":      paymentReq.Amount,
+			})
+			metrics.Count("KYCRejections", metrics.Dimensions{})
+			return nil, nil, appErr
+		}
+	}
+
+	// Generate payment ID
+	paymentID := uuid.New().String()
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	sourceCurrency := strings.ToUpper(paymentReq.SourceCurrency)
+	if sourceCurrency == "" {
+		sourceCurrency = models.DefaultSourceCurrency
+	}
+
+	// Check if quote_id is provided and validate it
+	var guaranteedPayout int64
+	var expectedRate float64
+	var onrampFee, offrampFee int64
+	var aiUsage *models.AIUsage
+	promoCode := paymentReq.PromoCode
+	chain := strings.ToLower(paymentReq.Chain)
+	var quoteOffRampChain string
+	var settlementSLASeconds int
+
+	// Wallet payouts send USDC to destination_account on chain; bank payouts are recorded without a type
+	var payoutType string
+	if paymentReq.PayoutType == models.PayoutTypeWallet {
+		payoutType = models.PayoutTypeWallet
+		paymentReq.Currency = models.WalletPayoutCurrency
+	}
+	if paymentReq.QuoteID != "" {
+		quote, err := h.quoteDB.GetQuote(ctx, paymentReq.QuoteID)
+		if err != nil {
+			logger.Error("Failed to fetch quote", logger.Fields{
+				"error":    err.Error(),
+				"quote_id": paymentReq.QuoteID,
+			})
+			return nil, nil, errors.New("INVALID_QUOTE", "Quote not found or expired", http.StatusBadRequest, nil)
+		}
+
+		// Validate quote hasn't expired
+		if time.Now().After(quote.ExpiresAt) {
+			logger.Warn("Quote expired", logger.Fields{
+				"quote_id":   paymentReq.QuoteID,
+				"expires_at": quote.ExpiresAt,
+			})
+			return nil, nil, errors.New("QUOTE_EXPIRED", "Quote has expired", http.StatusBadRequest, nil)
+		}
+
+		// A quote pays out once; creating the payment consumes it, so a racing request is still refused there
+		if quote.ConsumedAt != nil {
+			logger.Warn("Quote already consumed", logger.Fields{
+				"quote_id":   paymentReq.QuoteID,
+				"payment_id": quote.PaymentID,
+			})
+			return nil, nil, errors.ErrQuoteConsumed(paymentReq.QuoteID)
+		}
+
+		// Validate amount matches quote
+		if quote.Amount != paymentReq.Amount {
+			logger.Warn("Amount mismatch with quote", logger.Fields{
+				"quote_id":       paymentReq.QuoteID,
+				"quote_amount":   quote.Amount,
+				"payment_amount": paymentReq.Amount,
+			})
+			return nil, nil, errors.New("AMOUNT_MISMATCH", "Payment amount does not match quote", http.StatusBadRequest, nil)
+		}
+
+		// Validate the payment uses the quote's corridor
+		if quote.FromCurrency != sourceCurrency || !strings.EqualFold(quote.ToCurrency, paymentReq.Currency) {
+			logger.Warn("Currency mismatch with quote", logger.Fields{
+				"quote_id":        paymentReq.QuoteID,
+				"quote_corridor":  quote.FromCurrency + "-" + quote.ToCurrency,
+				"source_currency": sourceCurrency,
+				"currency":        paymentReq.Currency,
+			})
+			return nil, nil, errors.New("CURRENCY_MISMATCH", "Payment currencies do not match quote", http.StatusBadRequest, nil)
+		}
+
+		guaranteedPayout = quote.GuaranteedPayout
+		expectedRate = quote.ExchangeRate
+		onrampFee = quote.OnrampFee
+		offrampFee = quote.OfframpFee
+		aiUsage = quote.AIUsage
+		if promoCode == "" {
+			promoCode = quote.PromoCode
+		}
+		if chain == "" {
+			// Settle on the chain the quote found healthiest
+			chain = quote.SettlementChain
+		}
+		if chain == quote.SettlementChain {
+			quoteOffRampChain = quote.OffRampChain
+			settlementSLASeconds = quote.SettlementSLASeconds
+		}
+		logger.Info("Using quote for payment", logger.Fields{
+			"quote_id":          paymentReq.QuoteID,
+			"guaranteed_payout": guaranteedPayout,
+		})
+	} else {
+		// Without a quote, the rate at acceptance is the baseline for the execution-time slippage check
+		rate, err := h.quoteCalc.ExecutableRate(ctx, sourceCurrency, paymentReq.Currency, paymentReq.Amount)
+		if err != nil && payoutType != models.PayoutTypeWallet && !strings.EqualFold(sourceCurrency, paymentReq.Currency) {
+			// The off-ramp pays out the amount converted at this rate, so a bank payout can't go ahead without one
+			logger.Warn("No rate for cross-currency payment", logger.Fields{
+				"payment_id": paymentID,
+				"error":      err.Error(),
+			})
+			if appErr, ok := err.(*errors.AppError); ok {
+				return nil, nil, appErr
+			}
+			return nil, nil, errors.New("QUOTE_UNAVAILABLE", "No rate is currently available for this payment", http.StatusServiceUnavailable, nil)
+		}
+		if err != nil {
+			logger.Warn("No indicative rate for payment, slippage check disabled", logger.Fields{
+				"payment_id": paymentID,
+				"error":      err.Error(),
+			})
+		}
+		expectedRate = rate
+
+		// Unsupported corridors were logged above; their provider fees are left unrecorded
+		onrampFee, offrampFee, _ = h.quoteCalc.EstimateProviderFees(sourceCurrency, paymentReq.Currency, paymentReq.Amount)
+	}
+
+	// Calculate fees on the payment's corridor; negotiated pricing is keyed by the caller's API key, then the source account
+	var feeResult *fees.FeeResult
+	var offRampChain string
+	if corridor, err := h.corridors.Lookup(sourceCurrency, paymentReq.Currency); err == nil {
+		// USDC minted on a chain the off-ramp doesn't redeem on is bridged over CCTP
+		offRampChain = corridor.OfframpChainFor(chain)
+		if offRampChain != "" && quoteOffRampChain != "" {
+			offRampChain = quoteOffRampChain
+		}
+		feeResult = h.feeCalc.CalculateCorridorFee(ctx, corridor, paymentReq.Amount,
+			request.RequestContext.Identity.APIKeyID, paymentReq.SourceAccount)
+	} else {
+		feeResult = h.feeCalc.CalculateFeeForCurrency(ctx, paymentReq.Amount, paymentReq.Currency,
+			request.RequestContext.Identity.APIKeyID, paymentReq.SourceAccount)
+	}
+
+	// The payment is held to the settlement time its quote promised, or else the one its chains promise now
+	if settlementSLASeconds == 0 {
+		settlementSLASeconds = int(h.quoteCalc.SettlementSLA(chain, offRampChain) / time.Second)
+	}
+
+	// Redeeming counts against the promo's usage cap, so it happens only once the payment is otherwise valid,
+	// and is released again if the payment can't be created
+	var promoDiscount int64
+	if promoCode != "" {
+		promo, appErr := h.redeemPromo(ctx, promoCode)
+		if appErr != nil {
+			return nil, nil, appErr
+		}
+		promoCode = promo.Code
+		promoDiscount = promo.Discount(feeResult.PlatformFee())
+		feeResult.FeeAmount -= promoDiscount
+		feeResult.TotalAmount -= promoDiscount
+	}
+
+	logger.Info("Fee calculated for payment", logger.Fields{
+		"payment_id":   paymentID,
+		"base_amount":  paymentReq.Amount,
+		"fee_amount":   feeResult.FeeAmount,
+		"total_amount": feeResult.TotalAmount,
+		"schedule_id":  feeResult.ScheduleID,
+	})
+
+	// Create payment record
+	p := &models.Payment{
+		PaymentID:              paymentID,
+		IdempotencyKey:         idempotencyKey,
+		IdempotencyScope:       request.RequestContext.Identity.APIKeyID,
+		Amount:                 paymentReq.Amount,
+		Currency:               paymentReq.Currency,
+		SourceCurrency:         sourceCurrency,
+		SourceAccount:          paymentReq.SourceAccount,
+		CustomerID:             models.CustomerID(request.RequestContext.Identity.APIKeyID, paymentReq.SourceAccount),
+		DestinationAccount:     paymentReq.DestinationAccount,
+		Beneficiary:            paymentReq.Beneficiary,
+		CallbackURL:            paymentReq.CallbackURL,
+		Status:                 models.StatusPending,
+		FeeAmount:              feeResult.FeeAmount,
+		FeeCurrency:            feeResult.FeeCurrency,
+		FeeScheduleID:          feeResult.ScheduleID,
+		FeeScheduleVersion:     feeResult.ScheduleVersion,
+		PricingCustomer:        feeResult.PricingCustomer,
+		FeeDiscount:            feeResult.Discount,
+		PromoCode:              promoCode,
+		PromoDiscount:          promoDiscount,
+		FeeLimit:               feeResult.FeeLimit,
+		RegulatoryFee:          feeResult.Surcharge,
+		OnrampFee:              onrampFee,
+		OfframpFee:             offrampFee,
+		QuoteID:                paymentReq.QuoteID,
+		GuaranteedPayoutAmount: guaranteedPayout,
+		ExpectedRate:           expectedRate,
+		Chain:                  chain,
+		OffRampChain:           offRampChain,
+		SettlementSLASeconds:   settlementSLASeconds,
+		PayoutType:             payoutType,
+		Sandbox:                h.cfg.Sandbox.Enabled || (customer != nil && customer.Sandbox),
+		AIUsage:                aiUsage,
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
+	}
+
+	// A counterparty on a sanctions list holds the payment for compliance instead of starting it
+	if appErr := h.screenPayment(ctx, p); appErr != nil {
+		h.releasePromo(ctx, p)
+		return nil, nil, appErr
+	}
+
+	// A large or risky payment waits for an operator's approval; one held for compliance is checked once released
+	if p.Status == models.StatusPending {
+		h.holds.HoldIfTriggered(p)
+	}
+
+	// The quota is reserved last, once nothing else can refuse the payment, and released if it isn't saved
+	if appErr := h.reserveQuota(ctx, request, p, customer); appErr != nil {
+		h.releasePromo(ctx, p)
+		return nil, nil, appErr
+	}
+
+	return p, customer, nil
+}
+
+// paymentAccepted runs the follow-up of a newly saved payment: shadow fees, metrics, usage, alerts, monitoring and audit
+func (h *Handler) paymentAccepted(ctx context.Context, request events.APIGatewayProxyRequest, p *models.Payment, customer *models.Customer) {
+	// The charged fee is already fixed; the AI fee is only recorded for comparison
+	if h.feeShadow != nil {
+		h.feeShadow.Compare(ctx, p.PaymentID, h.shadowFeeRequest(request, p, h.customerTier(customer, request)), p.FeeAmount)
+	}
+
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(p.Status)})
+	if !p.Sandbox {
+		// Sandbox payments aren't billed as usage
+		h.recordUsage(ctx, request, models.UsageMetricPayments, "", 1)
+		h.recordUsage(ctx, request, models.UsageMetricPaymentVolume, p.FundingCurrency(), p.Amount)
+	}
+	switch p.Status {
+	case models.StatusComplianceHold:
+		alertComplianceHold(p)
+	case models.StatusOnHold:
+		payment.AlertHold(p)
+	}
+	h.monitorPayment(ctx, p)
+	h.recordAudit(ctx, audit.Event{
+		Actor:        requestActor(request),
+		Action:       audit.ActionPaymentCreated,
+		ResourceType: audit.ResourcePayment,
+		ResourceID:   p.PaymentID,
+		Details: map[string]string{
+			"amount":          strconv.FormatInt(p.Amount, 10),
+			"currency":        p.Currency,
+			"idempotency_key": p.IdempotencyKey,
+			"quote_id":        p.QuoteID,
+		},
+	})
+}
+
+// idempotencyKeyHeader returns the request's Idempotency-Key header
+func idempotencyKeyHeader(request events.APIGatewayProxyRequest) string {
+	if key := request.Headers["Idempotency-Key"]; key != "" {
+		return key
+	}
+	// Try lowercase header name (API Gateway can normalize headers)
+	return request.Headers["idempotency-key"]
+}
+
+// handleCreatePaymentBatch handles POST /payments/batch, creating up to BATCH_PAYMENTS_MAX payments at once
+// The batch is all-or-nothing under one idempotency key: every payment is validated before any is saved,
+// and the payments and one outbox message carrying all their jobs are written in a single transaction.
+// A batch with an invalid payment is rejected with the error of each invalid item.
+func (h *Handler) handleCreatePaymentBatch(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	idempotencyKey := idempotencyKeyHeader(request)
+	if err := validator.ValidateIdempotencyKey(idempotencyKey); err != nil {
+		return appErrorResponse(err.(*errors.AppError))
+	}
+
+	// A retried batch finds its first payment under the batch's key
+	existing, err := h.db.GetPaymentByIdempotencyKey(ctx, request.RequestContext.Identity.APIKeyID, models.BatchIdempotencyKey(idempotencyKey, 0))
+	if err != nil {
+		logger.Error("Failed to check idempotency key", logger.Fields{
+			"error":           err.Error(),
+			"idempotency_key": idempotencyKey,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process request")
+	}
+	if existing != nil {
+		return replayPayment(request, idempotencyKey, existing, "A payment batch with this idempotency key already exists")
+	}
+
+	var batchReq models.BatchPaymentRequest
+	if err := json.Unmarshal([]byte(request.Body), &batchReq); err != nil {
+		logger.Error("Failed to parse request body", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if len(batchReq.Payments) == 0 {
+		return appErrorResponse(errors.ErrValidation("payments", "must contain at least one payment"))
+	}
+	if limit := h.cfg.Batches.MaxPayments; len(batchReq.Payments) > limit {
+		return appErrorResponse(errors.ErrValidation("payments", fmt.Sprintf("must contain at most %d payments", limit)))
+	}
+
+	// Prepare every payment, collecting each item's error rather than stopping at the first
+	batchID := uuid.New().String()
+	results := make([]models.BatchPaymentResult, len(batchReq.Payments))
+	prepared := make([]*models.Payment, 0, len(batchReq.Payments))
+	payers := make([]*models.Customer, 0, len(batchReq.Payments))
+	quoteIDs := make(map[string]bool)
+	var rejection *errors.AppError
+	for i := range batchReq.Payments {
+		results[i].Index = i
+		paymentReq := &batchReq.Payments[i]
+
+		// A quote pays out once, so two payments in a batch can't share one
+		var appErr *errors.AppError
+		if paymentReq.QuoteID != "" && quoteIDs[paymentReq.QuoteID] {
+			appErr = errors.ErrValidation("quote_id", "is used by another payment in the batch")
+		} else {
+			if paymentReq.QuoteID != "" {
+				quoteIDs[paymentReq.QuoteID] = true
+			}
+			var p *models.Payment
+			var customer *models.Customer
+			p, customer, appErr = h.preparePayment(ctx, request, models.BatchIdempotencyKey(idempotencyKey, i), paymentReq)
+			if appErr == nil {
+				p.BatchID = batchID
+				prepared = append(prepared, p)
+				payers = append(payers, customer)
+			}
+		}
+		if appErr != nil {
+			detail := errors.ToErrorResponse(appErr).Error
+			results[i].Error = &detail
+			if rejection == nil {
+				rejection = appErr
+			}
+		}
+	}
+
+	// Nothing is saved unless every payment is valid
+	if rejection != nil {
+		for _, p := range prepared {
+			h.releaseReservations(ctx, p)
+		}
+		logger.Warn("Payment batch rejected", logger.Fields{
+			"idempotency_key": idempotencyKey,
+			"count":           len(results),
+			"invalid":         len(results) - len(prepared),
+		})
+		return paymentBatchResponse(rejection.StatusCode, models.BatchPaymentResponse{
+			Status:  models.BatchStatusRejected,
+			Results: results,
+		})
+	}
+
+	jobs := make([]*models.PaymentJob, len(prepared))
+	for i, p := range prepared {
+		jobs[i] = models.NewPaymentJob(p)
+		results[i].PaymentID = p.PaymentID
+		results[i].Status = p.Status
+	}
+
+	// The batch's 202 Accepted response is saved with its first payment, where a retry looks it up
+	responseBody, _ := json.Marshal(models.BatchPaymentResponse{
+		BatchID: batchID,
+		Status:  models.BatchStatusAccepted,
+		Results: results,
+	})
+	prepared[0].IdempotentResponse = models.NewIdempotentResponse(request.Body, http.StatusAccepted, responseBody)
+
+	outboxMsg, err := models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJobBatch, batchID, jobs)
+	if err == nil {
+		err = h.db.CreatePaymentsWithOutbox(ctx, prepared, outboxMsg)
+	}
+	if err != nil {
+		logger.Error("Failed to create payment batch", logger.Fields{
+			"error":    err.Error(),
+			"batch_id": batchID,
+		})
+		for _, p := range prepared {
+			h.releaseReservations(ctx, p)
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusConflict {
+			return appErrorResponse(appErr)
+		}
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create payment batch")
+	}
+
+	for i, p := range prepared {
+		h.paymentAccepted(ctx, request, p, payers[i])
+	}
+	metrics.Count("PaymentBatches", metrics.Dimensions{})
+
+	logger.Info("Payment batch accepted", logger.Fields{
+		"batch_id":        batchID,
+		"idempotency_key": idempotencyKey,
+		"count":           len(prepared),
+	})
+
+	return paymentCreatedResponse(http.StatusAccepted, string(responseBody), false)
+}
+
+// paymentBatchResponse creates a POST /payments/batch response
+func paymentBatchResponse(statusCode int, body models.BatchPaymentResponse) (events.APIGatewayProxyResponse, error) {
+	responseBody, _ := json.Marshal(body)
+	return paymentCreatedResponse(statusCode, string(responseBody), false)
+}
+
+// handleGetPayment handles GET /payments/{payment_id}
+func (h *Handler) handleGetPayment(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	logger.Info("Fetching payment", logger.Fields{"payment_id": paymentID})
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	// Get payment from database
+	payment, err := h.callerPayment(ctx, request, paymentID)
+	if err != nil {
+		logger.Error("Failed to fetch payment", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	}
+
+	// Marshal payment to JSON
+	responseBody, err := json.Marshal(payment)
+	if err != nil {
+		logger.Error("Failed to marshal payment response", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process payment data")
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// callerPayment fetches a payment created with the caller's API key
+// Payments are scoped to that key, as their idempotency keys are, so another caller's payment isn't found.
+func (h *Handler) callerPayment(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (*models.Payment, error) {
+	payment, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.IdempotencyScope != request.RequestContext.Identity.APIKeyID {
+		return nil, errors.ErrPaymentNotFound(paymentID)
+	}
+	return payment, nil
+}
+
+// handleGetPaymentEvents handles GET /payments/{payment_id}/events, returning the payment's customer-facing timeline
+func (h *Handler) handleGetPaymentEvents(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	payment, err := h.callerPayment(ctx, request, paymentID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to fetch payment", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch payment")
+	}
+
+	responseBody, _ := json.Marshal(models.NewPaymentEvents(payment))
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetFeeInvoice handles GET /payments/{payment_id}/fees, returning the fee invoice issued when the payment completed
+func (h *Handler) handleGetFeeInvoice(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	if h.invoices == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Fee invoices are not enabled")
+	}
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	invoice, err := h.invoices.GetFeeInvoice(ctx, paymentID)
+	if err == nil {
+		_, err = h.callerPayment(ctx, request, paymentID)
+	}
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to fetch fee invoice", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch fee invoice")
+	}
+
+	responseBody, _ := json.Marshal(invoice)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetPaymentLedger handles GET /internal/payments/{payment_id}/ledger, returning the payment's
+// ledger transactions and the account balances they net to
+func (h *Handler) handleGetPaymentLedger(ctx context.Context, paymentID string) (events.APIGatewayProxyResponse, error) {
+	if h.ledger == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Ledger is not enabled")
+	}
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	payment, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to fetch payment", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch payment")
+	}
+
+	txns, err := h.ledger.Transactions(ctx, paymentID)
+	if err != nil {
+		logger.Error("Failed to fetch ledger transactions", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch ledger")
+	}
+	if txns == nil {
+		txns = []*models.LedgerTransaction{}
+	}
+
+	responseBody, _ := json.Marshal(models.PaymentLedger{
+		PaymentID:    paymentID,
+		Status:       payment.Status,
+		Transactions: txns,
+		Balances:     ledger.Balances(txns),
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleReviewPayment handles POST /payments/{payment_id}/review, an operator's decision on a
+// payment held because its execution rate slipped past the limit
+func (h *Handler) handleReviewPayment(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	var reviewReq models.ReviewRequest
+	if err := json.Unmarshal([]byte(request.Body), &reviewReq); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if reviewReq.Decision != models.ReviewDecisionApprove && reviewReq.Decision != models.ReviewDecisionReject {
+		return appErrorResponse(errors.ErrValidationFields([]errors.FieldError{
+			{Field: "decision", Code: errors.FieldUnsupported, Reason: "must be approve or reject"},
+		}))
+	}
+
+	pmt, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return errorResponse(http.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	}
+
+	actor := requestActor(request)
+	if err := payment.ResolveReview(pmt, reviewReq.Decision, actor, reviewReq.Reason); err != nil {
+		return errorResponse(http.StatusConflict, "CONFLICT", err.Error())
+	}
+
+	// Save the decision and the payment's next job atomically
+	outboxMsg, err := models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, pmt.PaymentID, models.NewPaymentJob(pmt))
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to review payment")
+	}
+	if err := h.db.UpdatePaymentWithOutbox(ctx, pmt, outboxMsg); err != nil {
+		logger.Error("Failed to save payment review", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to review payment")
+	}
+
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(pmt.Status)})
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "slippage_review", audit.ResourcePayment, paymentID, map[string]string{
+			"decision":       reviewReq.Decision,
+			"reason":         reviewReq.Reason,
+			"expected_rate":  strconv.FormatFloat(pmt.ExpectedRate, 'f', -1, 64),
+			"execution_rate": strconv.FormatFloat(pmt.ExecutionRate, 'f', -1, 64),
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		}
+	}
+
+	logger.Info("Payment review resolved", logger.Fields{
+		"payment_id": paymentID,
+		"decision":   reviewReq.Decision,
+		"actor":      actor,
+		"status":     pmt.Status,
+	})
+
+	responseBody, _ := json.Marshal(models.PaymentResponse{
+		PaymentID: pmt.PaymentID,
+		Status:    pmt.Status,
+		Message:   "Review decision recorded",
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleCalculateFees handles POST /fees/calculate
+func (h *Handler) handleCalculateFees(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Check if AI fee calculator is available
+	if h.aiFeeCalc == nil {
+		logger.Error("AI fee calculator not initialized", logger.Fields{})
+		return errorResponse(http.StatusServiceUnavailable, "AI_UNAVAILABLE", "AI fee calculation is not available")
+	}
+
+	// Parse request body
+	var feeReq fees.AIFeeRequest
+	if err := json.Unmarshal([]byte(request.Body), &feeReq); err != nil {
+		logger.Error("Failed to parse fee request body", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	// Set defaults for optional fields
+	if feeReq.Priority == "" {
+		feeReq.Priority = "standard"
+	}
+	// A customer record's tier replaces the one the caller passed
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	if customer != nil {
+		feeReq.CustomerTier = customer.Tier
+	}
+	if feeReq.CustomerTier == "" {
+		feeReq.CustomerTier = "standard"
+	}
+	feeReq.Customer = request.RequestContext.Identity.APIKeyID
+
+	// Reject unusable requests before they cost a model call
+	if err := validator.ValidateFeeRequest(&feeReq, h.corridors); err != nil {
+		appErr := err.(*errors.AppError)
+		logger.Warn("Fee request validation failed", logger.Fields{
+			"error": appErr.Message,
+		})
+		return appErrorResponse(appErr)
+	}
+	if err := h.destinations.Check(validator.FeeDestination(&feeReq, h.corridors)); err != nil {
+		appErr := err.(*errors.AppError)
+		logger.Warn("Fee request for restricted destination", logger.Fields{
+			"error": appErr.Message,
+		})
+		return appErrorResponse(appErr)
+	}
+
+	logger.Info("Calculating AI fees", logger.Fields{
+		"amount":        feeReq.Amount,
+		"from_currency": feeReq.FromCurrency,
+		"to_currency":   feeReq.ToCurrency,
+		"destination":   feeReq.DestinationCountry,
+	})
+
+	// Call AI fee calculator
+	feeResp, err := h.aiFeeCalc.Calculate(ctx, &feeReq)
+	if err != nil {
+		logger.Error("AI fee calculation failed", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "CALCULATION_ERROR", "Failed to calculate fees")
+	}
+
+	if feeReq.QuoteID != "" && feeResp.Usage != nil {
+		h.recordQuoteAIUsage(ctx, feeReq.QuoteID, feeResp.Usage)
+	}
+	h.recordUsage(ctx, request, models.UsageMetricAIFeeCalculations, "", 1)
+
+	// Return fee response
+	responseBody, _ := json.Marshal(feeResp)
+
+	logger.Info("AI fees calculated successfully", logger.Fields{
+		"total_fee":        feeResp.TotalFee,
+		"confidence_score": feeResp.ConfidenceScore,
+		"prompt_version":   feeResp.PromptVersion,
+		"onramp":           feeResp.Provider.Onramp,
+		"offramp":          feeResp.Provider.Offramp,
+	})
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleEstimateFees handles GET /fees/estimate?amount=&currency=, pricing a payment from the fee schedules
+// without the AI engine. from is the source currency and defaults to USD; the caller's negotiated pricing
+// and volume discount apply as they would to its payment.
+func (h *Handler) handleEstimateFees(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var fields []errors.FieldError
+	amount, err := strconv.ParseInt(queryParam(request, "amount", ""), 10, 64)
+	switch {
+	case request.QueryStringParameters["amount"] == "":
+		fields = append(fields, errors.FieldError{Field: "amount", Code: errors.FieldRequired, Reason: "is required"})
+	case err != nil:
+		fields = append(fields, errors.FieldError{Field: "amount", Code: errors.FieldInvalidFormat, Reason: "must be an integer in minor units"})
+	}
+	currency := queryParam(request, "currency", "")
+	if currency == "" {
+		fields = append(fields, errors.FieldError{Field: "currency", Code: errors.FieldRequired, Reason: "is required"})
+	}
+	if len(fields) > 0 {
+		return appErrorResponse(errors.ErrValidationFields(fields))
+	}
+
+	from := queryParam(request, "from", models.DefaultSourceCurrency)
+	corridor, err := h.corridors.Lookup(from, currency)
+	if err != nil {
+		return appErrorResponse(errors.ErrValidationFields([]errors.FieldError{{
+			Field:  "currency",
+			Code:   errors.FieldUnsupported,
+			Reason: fmt.Sprintf("%s to %s is not a supported corridor", strings.ToUpper(from), strings.ToUpper(currency)),
+		}}))
+	}
+	if err := corridor.ValidateAmount(amount); err != nil {
+		return appErrorResponse(err.(*errors.AppError))
+	}
+
+	estimate := h.feeCalc.Estimate(ctx, corridor, amount, request.RequestContext.Identity.APIKeyID)
+	responseBody, _ := json.Marshal(estimate)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetRate handles GET /rates?from=USD&to=EUR, returning the indicative rate against the mid-market rate
+// amount, in source minor units, is optional. The rate isn't locked in; POST /quotes does that.
+func (h *Handler) handleGetRate(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var fields []errors.FieldError
+	from, to := queryParam(request, "from", ""), queryParam(request, "to", "")
+	if from == "" {
+		fields = append(fields, errors.FieldError{Field: "from", Code: errors.FieldRequired, Reason: "is required"})
+	}
+	if to == "" {
+		fields = append(fields, errors.FieldError{Field: "to", Code: errors.FieldRequired, Reason: "is required"})
+	}
+	var amount int64
+	if value := queryParam(request, "amount", ""); value != "" {
+		var err error
+		if amount, err = strconv.ParseInt(value, 10, 64); err != nil || amount <= 0 {
+			fields = append(fields, errors.FieldError{Field: "amount", Code: errors.FieldInvalidFormat, Reason: "must be a positive integer in minor units"})
+		}
+	}
+	if len(fields) > 0 {
+		return appErrorResponse(errors.ErrValidationFields(fields))
+	}
+
+	rate, err := h.quoteCalc.IndicativeRate(ctx, from, to, amount)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to get indicative rate", logger.Fields{"from": from, "to": to, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get rate")
+	}
+
+	responseBody, _ := json.Marshal(rate)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleListCurrencies handles GET /currencies, listing the enabled corridors with their limits and fees
+// so clients can offer the supported currency pairs without hardcoding them
+func (h *Handler) handleListCurrencies(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	list := h.corridors.List()
+	capabilities := make([]*models.CorridorCapability, len(list))
+	for i, corridor := range list {
+		capabilities[i] = models.NewCorridorCapability(corridor, h.feeCalc.CorridorFeeSummary(ctx, corridor))
+	}
+
+	responseBody, _ := json.Marshal(models.NewCurrencyCatalog(capabilities))
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetPricing handles GET /pricing, returning the caller's rolling volume and discount tier
+// Only API key callers can look up their volume; accepting an account ID would expose other customers'.
+func (h *Handler) handleGetPricing(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	status, err := h.feeCalc.PricingStatus(ctx, customerID)
+	if err != nil {
+		logger.Error("Failed to load pricing status", logger.Fields{"customer_id": customerID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load pricing")
+	}
+
+	responseBody, _ := json.Marshal(status)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetUsage handles GET /usage, returning the caller's metered usage for a month
+// period is YYYY-MM and defaults to the current UTC month, which is still being metered.
+func (h *Handler) handleGetUsage(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.usage == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Usage metering is not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	period := queryParam(request, "period", models.UsagePeriod(time.Now()))
+	if _, err := time.Parse(models.UsagePeriodFormat, period); err != nil {
+		return appErrorResponse(errors.ErrValidationFields([]errors.FieldError{{
+			Field:  "period",
+			Code:   errors.FieldInvalidFormat,
+			Reason: "must be a month as YYYY-MM",
+		}}))
+	}
+
+	records, err := h.usage.ListUsage(ctx, customerID, period)
+	if err != nil {
+		logger.Error("Failed to load usage", logger.Fields{"customer_id": customerID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load usage")
+	}
+
+	responseBody, _ := json.Marshal(models.NewUsageSummary(customerID, period, records))
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetQuotas handles GET /quotas, returning what the caller has used and has left of its payment quotas
+// Volume is in USD cents; a limit the caller's tier doesn't set is reported without limit or remaining.
+func (h *Handler) handleGetQuotas(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.quotas == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Payment quotas are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	status, err := h.quotas.Status(ctx, customerID, h.quotaTier(customer, request), time.Now())
+	if err != nil {
+		logger.Error("Failed to load quotas", logger.Fields{"customer_id": customerID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load quotas")
+	}
+
+	responseBody, _ := json.Marshal(status)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// recordUsage adds to one of the caller's usage counters for the current month
+// Only API key callers are metered. Failures are logged rather than returned: the request itself succeeded.
+func (h *Handler) recordUsage(ctx context.Context, request events.APIGatewayProxyRequest, metric, currency string, quantity int64) {
+	customerID := request.RequestContext.Identity.APIKeyID
+	if h.usage == nil || customerID == "" {
+		return
+	}
+	record := models.NewUsageRecord(customerID, metric, currency, quantity, time.Now())
+	if err := h.usage.AddUsage(ctx, record); err != nil {
+		logger.Warn("Failed to record usage", logger.Fields{
+			"error":       err.Error(),
+			"customer_id": customerID,
+			"metric":      metric,
+		})
+		metrics.Count("UsageRecordFailures", metrics.Dimensions{"Metric": metric})
+	}
+}
+
+// lookupPromo finds a promo code and checks it can be used now
+func (h *Handler) lookupPromo(ctx context.Context, code string) (*models.Promo, *errors.AppError) {
+	if h.promos == nil {
+		return nil, errors.ErrValidation("promo_code", "promo codes are not enabled")
+	}
+
+	promo, err := h.promos.GetPromo(ctx, models.NormalizePromoCode(code))
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		if appErr.Code == "PROMO_NOT_FOUND" {
+			return nil, errors.ErrValidation("promo_code", "unknown promo code")
+		}
+		return nil, errors.New("INTERNAL_ERROR", "Failed to look up promo code", http.StatusInternalServerError, err)
+	}
+	if reason := promo.Unavailable(time.Now()); reason != "" {
+		return nil, errors.ErrValidation("promo_code", reason)
+	}
+	return promo, nil
+}
+
+// redeemPromo checks a promo code and counts one use of it
+func (h *Handler) redeemPromo(ctx context.Context, code string) (*models.Promo, *errors.AppError) {
+	promo, appErr := h.lookupPromo(ctx, code)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if err := h.promos.RedeemPromo(ctx, promo.Code); err != nil {
+		appErr := err.(*errors.AppError)
+		if appErr.Code == "CONFLICT" {
+			// Another payment took the last use since the lookup
+			return nil, errors.ErrValidation("promo_code", "promo code has no uses left")
+		}
+		return nil, errors.New("INTERNAL_ERROR", "Failed to redeem promo code", http.StatusInternalServerError, err)
+	}
+
+	metrics.Count("PromoRedemptions", metrics.Dimensions{"PromoCode": promo.Code})
+	return promo, nil
+}
+
+// releasePromo gives back the promo use redeemed for a payment that failed to be created
+// A failed release only over-counts the promo's uses, so it is logged rather than returned.
+func (h *Handler) releasePromo(ctx context.Context, payment *models.Payment) {
+	if payment.PromoCode == "" || h.promos == nil {
+		return
+	}
+
+	if err := h.promos.ReleasePromo(ctx, payment.PromoCode); err != nil {
+		logger.Error("Failed to release promo code", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": payment.PaymentID,
+			"promo_code": payment.PromoCode,
+		})
+	}
+}
+
+// reserveQuota counts a payment against its caller's tier quotas; callers without a tier get the standard tier's
+// Only API key callers have quotas, and sandbox payments don't count against them.
+func (h *Handler) reserveQuota(ctx context.Context, request events.APIGatewayProxyRequest, p *models.Payment, customer *models.Customer) *errors.AppError {
+	if h.quotas == nil || p.IdempotencyScope == "" || p.Sandbox {
+		return nil
+	}
+
+	tier := h.quotaTier(customer, request)
+	if err := h.quotas.Reserve(ctx, p.IdempotencyScope, tier, p); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == "QUOTA_EXCEEDED" {
+			logger.Warn("Payment quota exceeded", logger.Fields{
+				"payment_id":  p.PaymentID,
+				"customer_id": p.IdempotencyScope,
+				"tier":        tier,
+				"error":       appErr.Message,
+			})
+			metrics.Count("QuotaRejections", metrics.Dimensions{"Tier": tier})
+			return appErr
+		}
+		return errors.New("INTERNAL_ERROR", "Failed to reserve payment quota", http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+// releaseReservations gives back the promo use and quota reserved for a payment that failed to be created
+// A failed release only over-counts, so it is logged rather than returned.
+func (h *Handler) releaseReservations(ctx context.Context, payment *models.Payment) {
+	h.releasePromo(ctx, payment)
+
+	if h.quotas == nil || payment.IdempotencyScope == "" || payment.Sandbox {
+		return
+	}
+	if err := h.quotas.Release(ctx, payment.IdempotencyScope, payment); err != nil {
+		logger.Error("Failed to release payment quota", logger.Fields{
+			"error":       err.Error(),
+			"payment_id":  payment.PaymentID,
+			"customer_id": payment.IdempotencyScope,
+		})
+	}
+}
+
+// quotaTier returns the tier whose quotas apply to the caller
+func (h *Handler) quotaTier(customer *models.Customer, request events.APIGatewayProxyRequest) string {
+	if tier := h.customerTier(customer, request); tier != "" {
+		return tier
+	}
+	return "standard"
+}
+
+// shadowFeeRequest builds the AI fee request a payment would have made
+func (h *Handler) shadowFeeRequest(request events.APIGatewayProxyRequest, payment *models.Payment, tier string) *fees.AIFeeRequest {
+	if tier == "" {
+		tier = "standard"
+	}
+	return &fees.AIFeeRequest{
+		Amount:       payment.Amount,
+		FromCurrency: payment.SourceCurrency,
+		ToCurrency:   strings.ToUpper(payment.Currency),
+		Priority:     "standard",
+		CustomerTier: tier,
+		Customer:     request.RequestContext.Identity.APIKeyID,
+	}
+}
+
+// screenPayment screens a new payment's counterparty, moving it to COMPLIANCE_HOLD on a sanctions hit
+// Payments aren't accepted unscreened, so a screening failure refuses the payment.
+func (h *Handler) screenPayment(ctx context.Context, p *models.Payment) *errors.AppError {
+	if h.screener == nil {
+		return nil
+	}
+	matches, err := h.screener.Screen(ctx, models.ScreeningSubjectFor(p))
+	if err != nil {
+		logger.Error("Failed to screen payment", logger.Fields{"payment_id": p.PaymentID, "error": err.Error()})
+		return errors.ErrInternalServer("Failed to screen payment", err)
+	}
+	if len(matches) > 0 {
+		payment.HoldForCompliance(p, models.ScreeningStageCreation, matches)
+	}
+	return nil
+}
+
+// alertComplianceHold alerts compliance to a payment held when it was created
+func alertComplianceHold(p *models.Payment) {
+	payment.AlertComplianceHold(p)
+}
+
+// monitorPayment runs a new payment through the AML monitoring rules
+// Alerts are leads for compliance review and don't stop the payment, so a monitoring failure is only logged.
+func (h *Handler) monitorPayment(ctx context.Context, p *models.Payment) {
+	if h.aml == nil {
+		return
+	}
+	if _, err := h.aml.Evaluate(ctx, p); err != nil {
+		logger.Error("Failed to run AML monitoring", logger.Fields{"payment_id": p.PaymentID, "error": err.Error()})
+	}
+}
+
+// lookupCustomer returns the calling API key's customer record, or nil if it has none or customers aren't enabled
+func (h *Handler) lookupCustomer(ctx context.Context, request events.APIGatewayProxyRequest) (*models.Customer, *errors.AppError) {
+	if h.customers == nil {
+		return nil, nil
+	}
+	customer, err := h.customers.Lookup(ctx, request.RequestContext.Identity.APIKeyID)
+	if err != nil {
+		logger.Error("Failed to look up customer", logger.Fields{
+			"customer_id": request.RequestContext.Identity.APIKeyID,
+			"error":       err.Error(),
+		})
+		return nil, errors.ErrInternalServer("Failed to load customer", err)
+	}
+	return customer, nil
+}
+
+// authenticateAPIKey identifies a caller using a key the API issued, as API Gateway identifies one using its keys
+// The caller acts as the key's customer from then on. Only X-Api-Key values with the issued keys' prefix are
+// checked, so API Gateway keys sent in the same header are left to API Gateway.
+func (h *Handler) authenticateAPIKey(ctx context.Context, request *events.APIGatewayProxyRequest) *errors.AppError {
+	if h.apiKeys == nil || request.RequestContext.Identity.APIKeyID != "" {
+		return nil
+	}
+	secret := apiKeyHeader(*request)
+	if !strings.HasPrefix(secret, models.APIKeySecretPrefix) {
+		return nil
+	}
+
+	key, err := h.apiKeys.Authenticate(ctx, secret)
+	if err != nil {
+		logger.Error("Failed to check API key", logger.Fields{"error": err.Error()})
+		return errors.ErrInternalServer("Failed to check API key", err)
+	}
+	if key == nil {
+		metrics.Count("APIKeyRejections", metrics.Dimensions{})
+		return errors.New("UNAUTHORIZED", "The API key is invalid, expired or revoked", http.StatusUnauthorized, nil)
+	}
+	request.RequestContext.Identity.APIKeyID = key.CustomerID
+	return nil
+}
+
+// apiKeyHeader returns the request's X-Api-Key header, however the client cased it
+func apiKeyHeader(request events.APIGatewayProxyRequest) string {
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, "X-Api-Key") {
+			return value
+		}
+	}
+	return ""
+}
+
+// checkSourceIP refuses an API key's call from outside its customer's IP allowlist
+// Calls without an API key, such as IAM-authorized operator calls, aren't checked. gRPC calls carry
+// the peer's address, so they are.
+func (h *Handler) checkSourceIP(ctx context.Context, request events.APIGatewayProxyRequest) *errors.AppError {
+	identity := request.RequestContext.Identity
+	if identity.APIKeyID == "" || identity.SourceIP == "" {
+		return nil
+	}
+	customer, appErr := h.lookupCustomer(ctx, request)
+	if appErr != nil || customer == nil {
+		return appErr
+	}
+	if appErr := customers.CheckSourceIP(customer, identity.SourceIP); appErr != nil {
+		logger.Warn("Refused call from outside the API key's IP allowlist", logger.Fields{
+			"customer_id": customer.CustomerID,
+			"source_ip":   identity.SourceIP,
+			"path":        request.Path,
+		})
+		metrics.Count("IPAllowlistRejections", metrics.Dimensions{})
+		return appErr
+	}
+	return nil
+}
+
+// customerTier returns the caller's pricing tier: its customer record's, else the tier configured for its API key
+func (h *Handler) customerTier(customer *models.Customer, request events.APIGatewayProxyRequest) string {
+	if customer != nil {
+		return customer.Tier
+	}
+	return h.cfg.Quotes.APIKeyTiers[request.RequestContext.Identity.APIKeyID]
+}
+
+// recordQuoteAIUsage adds a fee calculation's Claude spend to its quote, from where it is copied onto the payment
+// Failures are logged rather than returned: the fee recommendation is still valid.
+func (h *Handler) recordQuoteAIUsage(ctx context.Context, quoteID string, usage *models.AIUsage) {
+	quote, err := h.quoteDB.GetQuote(ctx, quoteID)
+	if err == nil {
+		total := &models.AIUsage{}
+		total.Add(quote.AIUsage)
+		total.Add(usage)
+		err = h.quoteDB.SetQuoteAIUsage(ctx, quoteID, total)
+	}
+	if err != nil {
+		logger.Warn("Failed to record AI usage on quote", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quoteID,
+		})
+	}
+}
+
+// handleAICostReport handles GET /reports/ai-cost, aggregating AI spend on payments by corridor and customer
+func (h *Handler) handleAICostReport(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	until := time.Now().UTC()
+	if value := request.QueryStringParameters["until"]; value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "until must be an RFC3339 timestamp")
+		}
+		until = parsed
+	}
+
+	since := until.Add(-defaultAICostReportWindow)
+	if value := request.QueryStringParameters["since"]; value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "since must be an RFC3339 timestamp")
+		}
+		since = parsed
+	}
+	if !since.Before(until) {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "since must be before until")
+	}
+
+	usages, err := h.db.ListPaymentAIUsage(ctx, since, until)
+	if err != nil {
+		logger.Error("Failed to list payment AI usage", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to build AI cost report")
+	}
+
+	responseBody, _ := json.Marshal(fees.BuildAICostReport(usages, since, until))
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGasTrends handles GET /reports/gas-trends, returning recorded gas averages and trend per chain
+func (h *Handler) handleGasTrends(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	if h.aiFeeCalc == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Gas history is not enabled")
+	}
+
+	trends, err := h.aiFeeCalc.GasTrends(ctx)
+	if err != nil {
+		logger.Error("Failed to load gas trends", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load gas trends")
+	}
+	if trends == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Gas history is not enabled")
+	}
+
+	responseBody, _ := json.Marshal(fees.GasTrendReport{
+		GeneratedAt: time.Now().UTC(),
+		Trends:      trends,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleListFeeSchedules handles GET /fee-schedules/{schedule_id}, returning every version and the one in effect
+func (h *Handler) handleListFeeSchedules(ctx context.Context, scheduleID string) (events.APIGatewayProxyResponse, error) {
+	if h.feeSchedules == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Fee schedules are not enabled")
+	}
+
+	versions, err := h.feeSchedules.ListFeeSchedules(ctx, scheduleID)
+	if err != nil {
+		logger.Error("Failed to list fee schedules", logger.Fields{"schedule_id": scheduleID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list fee schedules")
+	}
+	if versions == nil {
+		versions = []*models.FeeSchedule{}
+	}
+
+	responseBody, _ := json.Marshal(models.FeeScheduleListResponse{
+		ScheduleID: scheduleID,
+		Active:     models.ActiveFeeSchedule(versions, time.Now()),
+		Versions:   versions,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleCreateFeeSchedule handles POST /fee-schedules/{schedule_id}, adding the schedule's next version
+func (h *Handler) handleCreateFeeSchedule(ctx context.Context, request events.APIGatewayProxyRequest, scheduleID string) (events.APIGatewayProxyResponse, error) {
+	if h.feeSchedules == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Fee schedules are not enabled")
+	}
+	if !h.knownFeeSchedule(scheduleID) {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("Unknown fee schedule '%s'", scheduleID))
+	}
+
+	var scheduleReq models.FeeScheduleRequest
+	if err := json.Unmarshal([]byte(request.Body), &scheduleReq); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if err := scheduleReq.Tiers.Validate(); err != nil {
+		return appErrorResponse(err.(*errors.AppError))
+	}
+
+	versions, err := h.feeSchedules.ListFeeSchedules(ctx, scheduleID)
+	if err != nil {
+		logger.Error("Failed to list fee schedules", logger.Fields{"schedule_id": scheduleID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update fee schedule")
+	}
+
+	now := time.Now().UTC()
+	schedule := &models.FeeSchedule{
+		ScheduleID:    scheduleID,
+		Version:       1,
+		Tiers:         scheduleReq.Tiers,
+		EffectiveFrom: now,
+		CreatedBy:     requestActor(request),
+		CreatedAt:     now,
+	}
+	if len(versions) > 0 {
+		schedule.Version = versions[len(versions)-1].Version + 1
+	}
+	if scheduleReq.EffectiveFrom != nil {
+		schedule.EffectiveFrom = scheduleReq.EffectiveFrom.UTC()
+	}
+
+	// Two admins racing for the same version: the loser retries against the new latest
+	if err := h.feeSchedules.CreateFeeSchedule(ctx, schedule); err != nil {
+		appErr := err.(*errors.AppError)
+		if appErr.Code != "CONFLICT" {
+			logger.Error("Failed to create fee schedule", logger.Fields{"schedule_id": scheduleID, "error": err.Error()})
+		}
+		return appErrorResponse(appErr)
+	}
+	h.feeCalc.InvalidateSchedule(scheduleID)
+
+	tiers, _ := json.Marshal(schedule.Tiers)
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, schedule.CreatedBy, "fee_schedule_update", audit.ResourceFeeSchedule, scheduleID, map[string]string{
+			"version":        strconv.FormatInt(schedule.Version, 10),
+			"effective_from": schedule.EffectiveFrom.Format(time.RFC3339),
+			"tiers":          string(tiers),
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"schedule_id": scheduleID, "error": err.Error()})
+		}
+	}
+
+	logger.Info("Fee schedule updated", logger.Fields{
+		"schedule_id":    scheduleID,
+		"version":        schedule.Version,
+		"effective_from": schedule.EffectiveFrom.Format(time.RFC3339),
+		"actor":          schedule.CreatedBy,
+	})
+
+	responseBody, _ := json.Marshal(schedule)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusCreated,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetTreasury handles GET /internal/treasury, returning every treasury balance flagged against its low-balance threshold
+func (h *Handler) handleGetTreasury(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	if h.treasury == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Treasury tracking is not enabled")
+	}
+
+	balances, err := h.treasury.ListTreasuryBalances(ctx)
+	if err != nil {
+		logger.Error("Failed to list treasury balances", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load treasury balances")
+	}
+	if balances == nil {
+		balances = []*models.TreasuryBalance{}
+	}
+
+	thresholds := models.TreasuryThresholds(h.cfg.Treasury.LowBalanceThresholds)
+	for _, balance := range balances {
+		balance.SetThreshold(thresholds[balance.Account])
+	}
+
+	responseBody, _ := json.Marshal(models.TreasuryReport{
+		GeneratedAt: time.Now().UTC(),
+		Balances:    balances,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleAdjustTreasury handles POST /internal/treasury, recording an operator's top-up or withdrawal
+// Each reference is applied once, so a retried request doesn't fund the account twice.
+func (h *Handler) handleAdjustTreasury(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.treasury == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Treasury tracking is not enabled")
+	}
+
+	var adjustment models.TreasuryAdjustmentRequest
+	if err := json.Unmarshal([]byte(request.Body), &adjustment); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := adjustment.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	entry := adjustment.Entry()
+	movement := &models.TreasuryMovement{
+		MovementID: models.TreasuryLegAdjustment + ":" + adjustment.Reference,
+		Leg:        models.TreasuryLegAdjustment,
+		Entries:    []models.TreasuryEntry{entry},
+		CreatedBy:  requestActor(request),
+		CreatedAt:  time.Now().UTC(),
+	}
+	applied, err := h.treasury.ApplyTreasuryMovement(ctx, movement)
+	if err != nil {
+		logger.Error("Failed to apply treasury adjustment", logger.Fields{"account": entry.Account(), "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to adjust treasury balance")
+	}
+
+	if applied {
+		if h.audit != nil {
+			if _, err := h.audit.RecordAdminAction(ctx, movement.CreatedBy, "treasury_adjustment", audit.ResourceTreasury, entry.Account(), map[string]string{
+				"amount":    strconv.FormatInt(entry.Amount, 10),
+				"reference": adjustment.Reference,
+			}); err != nil {
+				logger.Error("Failed to write audit entry", logger.Fields{"account": entry.Account(), "error": err.Error()})
+			}
+		}
+
+		logger.Info("Treasury balance adjusted", logger.Fields{
+			"account":   entry.Account(),
+			"amount":    entry.Amount,
+			"reference": adjustment.Reference,
+			"actor":     movement.CreatedBy,
+		})
+	}
+
+	balance, err := h.treasury.GetTreasuryBalance(ctx, entry.Account())
+	if err != nil || balance == nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load treasury balance")
+	}
+	balance.SetThreshold(models.TreasuryThresholds(h.cfg.Treasury.LowBalanceThresholds)[balance.Account])
+
+	// A repeated reference returns the current balance without applying the adjustment again
+	statusCode := http.StatusCreated
+	if !applied {
+		statusCode = http.StatusOK
+	}
+	responseBody, _ := json.Marshal(balance)
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// knownFeeSchedule reports whether scheduleID is the default schedule or a supported corridor
+func (h *Handler) knownFeeSchedule(scheduleID string) bool {
+	if scheduleID == models.DefaultFeeScheduleID {
+		return true
+	}
+	for _, corridor := range h.corridors.List() {
+		if corridor.ID == scheduleID {
+			return true
+		}
+	}
+	return false
+}
+
+// errorResponse creates an error response
+func errorResponse(statusCode int, code, message string) (events.APIGatewayProxyResponse, error) {
+	return appErrorResponse(errors.New(code, message, statusCode, nil))
+}
+
+// appErrorResponse creates an error response from an AppError, including any field-level errors
+func appErrorResponse(appErr *errors.AppError) (events.APIGatewayProxyResponse, error) {
+	body, _ := json.Marshal(errors.ToErrorResponse(appErr))
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: appErr.StatusCode,
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Methods": "GET,POST,OPTIONS",
+			"Access-Control-Allow-Headers": "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token,Idempotency-Key",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// RecordStartup logs the running build and records the configuration this instance started with
+func (h *Handler) RecordStartup(ctx context.Context) {
+	info := buildInfo()
+	logger.Info("Starting api-handler", logger.Fields{
+		"git_sha":    info.GitSHA,
+		"build_time": info.BuildTime,
+		"schemas":    info.Schemas,
+	})
+
+	if h.audit != nil {
+		if _, err := h.audit.RecordConfigLoaded(ctx, "api-handler", h.cfg.AuditSnapshot()); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"error": err.Error()})
+		}
+	}
+}
+
+// ServeHealth serves the dependency checks over plain HTTP for load balancers and canaries
+func (h *Handler) ServeHealth(addr string) {
+	health.Serve(addr, h.health)
+}
+
+// ListenAndServeGRPC serves the same requests to internal consumers over gRPC, checking issued keys when enabled
+func (h *Handler) ListenAndServeGRPC(addr string) error {
+	var auth grpcapi.Authenticator
+	if h.apiKeys != nil {
+		auth = h.apiKeys
+	}
+	return grpcapi.ListenAndServe(addr, h.HandleRequest, auth)
+}
+
+// handleListBreaks handles GET /internal/reconciliation/breaks, returning reconciliation breaks oldest first
+// An optional ?status=open|resolved filters them.
+func (h *Handler) handleListBreaks(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.breaks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Reconciliation is not enabled")
+	}
+
+	status := queryParam(request, "status", "")
+	if status != "" && status != models.BreakStatusOpen && status != models.BreakStatusResolved {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "status must be open or resolved")
+	}
+
+	breaks, err := h.breaks.ListBreaks(ctx, status)
+	if err != nil {
+		logger.Error("Failed to list reconciliation breaks", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load reconciliation breaks")
+	}
+	if breaks == nil {
+		breaks = []*models.ReconciliationBreak{}
+	}
+
+	responseBody, _ := json.Marshal(map[string]interface{}{"breaks": breaks})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleResolveBreak handles POST /internal/reconciliation/breaks/{break_id}/resolve, recording how an operator
+// settled a break; a break can be resolved once
+func (h *Handler) handleResolveBreak(ctx context.Context, request events.APIGatewayProxyRequest, breakID string) (events.APIGatewayProxyResponse, error) {
+	if h.breaks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Reconciliation is not enabled")
+	}
+
+	var resolution models.BreakResolutionRequest
+	if err := json.Unmarshal([]byte(request.Body), &resolution); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := resolution.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	actor := requestActor(request)
+	if err := h.breaks.ResolveBreak(ctx, breakID, resolution.Resolution, actor, time.Now().UTC()); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to resolve reconciliation break", logger.Fields{"break_id": breakID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve reconciliation break")
+	}
+
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "reconciliation_resolve", audit.ResourceBreak, breakID, map[string]string{
+			"resolution": resolution.Resolution,
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"break_id": breakID, "error": err.Error()})
+		}
+	}
+	logger.Info("Reconciliation break resolved", logger.Fields{"break_id": breakID, "actor": actor})
+
+	brk, err := h.breaks.GetBreak(ctx, breakID)
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load reconciliation break")
+	}
+	responseBody, _ := json.Marshal(brk)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleListAMLAlerts handles GET /internal/compliance/alerts, returning AML monitoring alerts oldest first
+// An optional ?status=open|closed filters them.
+func (h *Handler) handleListAMLAlerts(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.amlAlerts == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "AML monitoring is not enabled")
+	}
+
+	status := queryParam(request, "status", "")
+	if status != "" && status != models.AMLAlertStatusOpen && status != models.AMLAlertStatusClosed {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "status must be open or closed")
+	}
+
+	alerts, err := h.amlAlerts.ListAlerts(ctx, status)
+	if err != nil {
+		logger.Error("Failed to list AML alerts", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load AML alerts")
+	}
+	if alerts == nil {
+		alerts = []*models.AMLAlert{}
+	}
+
+	responseBody, _ := json.Marshal(map[string]interface{}{"alerts": alerts})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleCloseAMLAlert handles POST /internal/compliance/alerts/{alert_id}/close, recording a compliance
+// officer's disposition of an alert; an alert can be closed once
+func (h *Handler) handleCloseAMLAlert(ctx context.Context, request events.APIGatewayProxyRequest, alertID string) (events.APIGatewayProxyResponse, error) {
+	if h.amlAlerts == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "AML monitoring is not enabled")
+	}
+
+	var closeReq models.AMLAlertCloseRequest
+	if err := json.Unmarshal([]byte(request.Body), &closeReq); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := closeReq.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	actor := requestActor(request)
+	if err := h.amlAlerts.CloseAlert(ctx, alertID, closeReq.Resolution, actor, time.Now().UTC()); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+			return appErrorResponse(appErr)
+		}
+		logger.Error("Failed to close AML alert", logger.Fields{"alert_id": alertID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to close AML alert")
+	}
+
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "aml_alert_close", audit.ResourceAMLAlert, alertID, map[string]string{
+			"resolution": closeReq.Resolution,
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"alert_id": alertID, "error": err.Error()})
+		}
+	}
+	logger.Info("AML alert closed", logger.Fields{"alert_id": alertID, "actor": actor})
+
+	alert, err := h.amlAlerts.GetAlert(ctx, alertID)
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load AML alert")
+	}
+	responseBody, _ := json.Marshal(alert)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleGetSettlementReport handles GET /internal/reports/settlements/{report_date}, returning the day's
+// settlement report rows by corridor and chain
+func (h *Handler) handleGetSettlementReport(ctx context.Context, reportDate string) (events.APIGatewayProxyResponse, error) {
+	if h.settlements == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Settlement reports are not enabled")
+	}
+	if _, err := time.Parse(models.RevenueReportDateFormat, reportDate); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", "report_date must be YYYY-MM-DD")
+	}
+
+	reports, err := h.settlements.GetSettlementReport(ctx, reportDate)
+	if err != nil {
+		logger.Error("Failed to load settlement report", logger.Fields{"report_date": reportDate, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load settlement report")
+	}
+	if reports == nil {
+		return errorResponse(http.StatusNotFound, "REPORT_NOT_FOUND", fmt.Sprintf("No settlement report for %s; days are reported after they end", reportDate))
+	}
+
+	responseBody, _ := json.Marshal(map[string]interface{}{
+		"report_date": reportDate,
+		"rows":        reports,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleListCustomers handles GET /internal/customers, returning every customer record
+func (h *Handler) handleListCustomers(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	list, err := h.customers.List(ctx)
+	if err != nil {
+		logger.Error("Failed to list customers", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load customers")
+	}
+	if list == nil {
+		list = []*models.Customer{}
+	}
+	return customerResponse(http.StatusOK, map[string]interface{}{"customers": list})
+}
+
+// handleCreateCustomer handles POST /internal/customers, creating the record for an API key
+func (h *Handler) handleCreateCustomer(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	var req models.CustomerRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	customer, err := h.customers.Create(ctx, &req)
+	if err != nil {
+		return customerErrorResponse(err, req.CustomerID, "Failed to create customer")
+	}
+	h.auditCustomer(ctx, request, "customer_create", customer.CustomerID, map[string]string{"tier": customer.Tier})
+	return customerResponse(http.StatusCreated, customer)
+}
+
+// handleGetCustomer handles GET /internal/customers/{customer_id}
+func (h *Handler) handleGetCustomer(ctx context.Context, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	customer, err := h.customers.Get(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to load customer")
+	}
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleUpdateCustomer handles PUT /internal/customers/{customer_id}, replacing its name, tier and limits
+func (h *Handler) handleUpdateCustomer(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	var req models.CustomerRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	customer, err := h.customers.Update(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to update customer")
+	}
+	h.auditCustomer(ctx, request, "customer_update", customerID, map[string]string{
+		"tier":               customer.Tier,
+		"max_payment_amount": strconv.FormatInt(customer.Limits.MaxPaymentAmount, 10),
+	})
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleDeleteCustomer handles DELETE /internal/customers/{customer_id}
+func (h *Handler) handleDeleteCustomer(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	if err := h.customers.Delete(ctx, customerID); err != nil {
+		return customerErrorResponse(err, customerID, "Failed to delete customer")
+	}
+	h.auditCustomer(ctx, request, "customer_delete", customerID, nil)
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNoContent,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+// handleLinkCustomerAccount handles POST /internal/customers/{customer_id}/accounts
+func (h *Handler) handleLinkCustomerAccount(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	var req models.CustomerAccountRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	customer, err := h.customers.LinkAccount(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to link account")
+	}
+	h.auditCustomer(ctx, request, "customer_account_link", customerID, map[string]string{"account_id": req.AccountID})
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleUnlinkCustomerAccount handles DELETE /internal/customers/{customer_id}/accounts/{account_id}
+func (h *Handler) handleUnlinkCustomerAccount(ctx context.Context, request events.APIGatewayProxyRequest, customerID, accountID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	customer, err := h.customers.UnlinkAccount(ctx, customerID, accountID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to unlink account")
+	}
+	h.auditCustomer(ctx, request, "customer_account_unlink", customerID, map[string]string{"account_id": accountID})
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleSetCustomerIPAllowlist handles PUT /internal/customers/{customer_id}/ip-allowlist, replacing the CIDR
+// ranges the customer's API key may call from; operators use it to restore a customer locked out by its own list
+func (h *Handler) handleSetCustomerIPAllowlist(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+
+	var req models.CustomerIPAllowlistRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	customer, err := h.customers.SetAllowedIPs(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to update IP allowlist")
+	}
+	h.auditCustomer(ctx, request, "customer_ip_allowlist", customerID, map[string]string{"allowed_ips": strings.Join(customer.AllowedIPs, ",")})
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleIssueCustomerAPIKey handles POST /internal/customers/{customer_id}/api-keys, issuing a customer its first key
+func (h *Handler) handleIssueCustomerAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+
+	var req models.APIKeyRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	issued, err := h.apiKeys.Issue(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to issue API key")
+	}
+	h.auditCustomer(ctx, request, "customer_api_key_issue", customerID, map[string]string{"key_id": issued.KeyID})
+	return customerResponse(http.StatusCreated, issued)
+}
+
+// handleRevokeCustomerAPIKey handles POST /internal/customers/{customer_id}/api-keys/{key_id}/revoke, for a leaked key
+func (h *Handler) handleRevokeCustomerAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, customerID, keyID string) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+
+	key, err := h.apiKeys.Revoke(ctx, customerID, keyID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to revoke API key")
+	}
+	h.auditCustomer(ctx, request, "customer_api_key_revoke", customerID, map[string]string{"key_id": keyID})
+	return customerResponse(http.StatusOK, key)
+}
+
+// handleStartKYC handles POST /internal/customers/{customer_id}/kyc, opening a verification with the KYC provider
+func (h *Handler) handleStartKYC(ctx context.Context, request events.APIGatewayProxyRequest, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.kyc == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "KYC is not enabled")
+	}
+
+	customer, err := h.kyc.Start(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to start KYC verification")
+	}
+	h.auditCustomer(ctx, request, "customer_kyc_start", customerID, map[string]string{
+		"provider":   customer.KYC.Provider,
+		"inquiry_id": customer.KYC.InquiryID,
+	})
+	return customerResponse(http.StatusOK, customer)
+}
+
+// handleSyncKYC handles POST /internal/customers/{customer_id}/kyc/sync, recording the provider's decision
+// on a pending verification
+func (h *Handler) handleSyncKYC(ctx context.Context, customerID string) (events.APIGatewayProxyResponse, error) {
+	if h.kyc == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "KYC is not enabled")
+	}
+
+	customer, err := h.kyc.Sync(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to check KYC verification")
+	}
+	return customerResponse(http.StatusOK, customer)
+}
+
+// auditCustomer records an admin change to a customer record
+func (h *Handler) auditCustomer(ctx context.Context, request events.APIGatewayProxyRequest, action, customerID string, details map[string]string) {
+	if h.audit == nil {
+		return
+	}
+	if _, err := h.audit.RecordAdminAction(ctx, requestActor(request), action, audit.ResourceCustomer, customerID, details); err != nil {
+		logger.Error("Failed to write audit entry", logger.Fields{"customer_id": customerID, "error": err.Error()})
+	}
+}
+
+// customerErrorResponse returns a customer service error, hiding the detail of storage failures
+func customerErrorResponse(err error, customerID, message string) (events.APIGatewayProxyResponse, error) {
+	if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode < http.StatusInternalServerError {
+		return appErrorResponse(appErr)
+	}
+	logger.Error(message, logger.Fields{"customer_id": customerID, "error": err.Error()})
+	return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", message)
+}
+
+// customerResponse returns body as JSON
+func customerResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	responseBody, _ := json.Marshal(body)
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleListAPIKeys handles GET /api-keys, returning the calling customer's keys without their secrets
+func (h *Handler) handleListAPIKeys(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	keys, err := h.apiKeys.List(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to load API keys")
+	}
+	return customerResponse(http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+// handleIssueAPIKey handles POST /api-keys, issuing the calling customer another key
+// The response is the only time the key's secret is returned.
+func (h *Handler) handleIssueAPIKey(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	var req models.APIKeyRequest
+	if request.Body != "" {
+		if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+			return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		}
+	}
+
+	issued, err := h.apiKeys.Issue(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to issue API key")
+	}
+	h.auditAPIKey(ctx, request, audit.ActionAPIKeyIssued, customerID, issued.KeyID)
+	return customerResponse(http.StatusCreated, issued)
+}
+
+// handleRotateAPIKey handles POST /api-keys/{key_id}/rotate, issuing a replacement for one of the caller's keys
+// The old key keeps working for API_KEY_ROTATION_GRACE_SECONDS.
+func (h *Handler) handleRotateAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, keyID string) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	issued, err := h.apiKeys.Rotate(ctx, customerID, keyID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to rotate API key")
+	}
+	h.auditAPIKey(ctx, request, audit.ActionAPIKeyRotated, customerID, keyID)
+	return customerResponse(http.StatusCreated, issued)
+}
+
+// handleRevokeAPIKey handles POST /api-keys/{key_id}/revoke, refusing one of the caller's keys immediately
+func (h *Handler) handleRevokeAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, keyID string) (events.APIGatewayProxyResponse, error) {
+	if h.apiKeys == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "API keys are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	key, err := h.apiKeys.Revoke(ctx, customerID, keyID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to revoke API key")
+	}
+	h.auditAPIKey(ctx, request, audit.ActionAPIKeyRevoked, customerID, keyID)
+	return customerResponse(http.StatusOK, key)
+}
+
+// auditAPIKey records a customer's change to one of its API keys
+func (h *Handler) auditAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, action, customerID, keyID string) {
+	h.recordAudit(ctx, audit.Event{
+		Actor:        requestActor(request),
+		Action:       action,
+		ResourceType: audit.ResourceCustomer,
+		ResourceID:   customerID,
+		Details:      map[string]string{"key_id": keyID},
+	})
+}
+
+// handleGetIPAllowlist handles GET /ip-allowlist, returning the CIDR ranges the calling API key may call from
+func (h *Handler) handleGetIPAllowlist(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	customer, err := h.customers.Get(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to load IP allowlist")
+	}
+	return customerResponse(http.StatusOK, map[string]interface{}{"allowed_ips": allowedIPs(customer)})
+}
+
+// handleSetIPAllowlist handles PUT /ip-allowlist, restricting the calling API key to CIDR ranges
+// A list that leaves out the address the change is made from is refused, so a key can't lock itself out.
+func (h *Handler) handleSetIPAllowlist(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.customers == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Customer records are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	var req models.CustomerIPAllowlistRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := req.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+	sourceIP := request.RequestContext.Identity.SourceIP
+	if proposed := (&models.Customer{AllowedIPs: req.AllowedIPs}); !proposed.AllowsIP(sourceIP) {
+		return appErrorResponse(errors.ErrValidationFields([]errors.FieldError{{
+			Field:  "allowed_ips",
+			Code:   errors.FieldConflict,
+			Reason: fmt.Sprintf("must include the address you are calling from (%s)", sourceIP),
+		}}))
+	}
+
+	customer, err := h.customers.SetAllowedIPs(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to update IP allowlist")
+	}
+	h.recordAudit(ctx, audit.Event{
+		Actor:        requestActor(request),
+		Action:       audit.ActionIPAllowlistUpdated,
+		ResourceType: audit.ResourceCustomer,
+		ResourceID:   customerID,
+		Details:      map[string]string{"allowed_ips": strings.Join(customer.AllowedIPs, ",")},
+	})
+	return customerResponse(http.StatusOK, map[string]interface{}{"allowed_ips": allowedIPs(customer)})
+}
+
+// allowedIPs returns a customer's allowlist, empty rather than null when it has none
+func allowedIPs(customer *models.Customer) []string {
+	if customer.AllowedIPs == nil {
+		return []string{}
+	}
+	return customer.AllowedIPs
+}
+
+// handleListWebhookSubscriptions handles GET /webhooks, returning the calling API key's subscriptions
+func (h *Handler) handleListWebhookSubscriptions(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	list, err := h.webhooks.List(ctx, customerID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to load webhook subscriptions")
+	}
+	return customerResponse(http.StatusOK, map[string]interface{}{"subscriptions": list})
+}
+
+// handleCreateWebhookSubscription handles POST /webhooks, registering an endpoint for the calling API key's webhooks
+func (h *Handler) handleCreateWebhookSubscription(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	sub, err := h.webhooks.Create(ctx, customerID, &req)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to create webhook subscription")
+	}
+	return customerResponse(http.StatusCreated, sub)
+}
+
+// handleDeleteWebhookSubscription handles DELETE /webhooks/{subscription_id}
+func (h *Handler) handleDeleteWebhookSubscription(ctx context.Context, request events.APIGatewayProxyRequest, subscriptionID string) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	if err := h.webhooks.Delete(ctx, customerID, subscriptionID); err != nil {
+		return customerErrorResponse(err, customerID, "Failed to delete webhook subscription")
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusNoContent,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
+
+// handleGetWebhookStats handles GET /webhooks/{subscription_id}/stats, summarizing the delivery attempts to one of
+// the calling API key's endpoints over the last `hours` (default 24, at most the delivery retention)
+func (h *Handler) handleGetWebhookStats(ctx context.Context, request events.APIGatewayProxyRequest, subscriptionID string) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	maxHours := int(models.WebhookDeliveryRetention / time.Hour)
+	hours, err := strconv.Atoi(queryParam(request, "hours", strconv.Itoa(defaultWebhookStatsHours)))
+	if err != nil || hours <= 0 || hours > maxHours {
+		return errorResponse(http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("hours must be between 1 and %d", maxHours))
+	}
+
+	stats, err := h.webhooks.Stats(ctx, customerID, subscriptionID, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to load webhook delivery statistics")
+	}
+	return customerResponse(http.StatusOK, stats)
+}
+
+// handleRotateWebhookSecret handles POST /webhooks/{subscription_id}/rotate-secret, giving one of the calling
+// API key's endpoints a new signing secret while the one it replaces keeps signing for a day
+func (h *Handler) handleRotateWebhookSecret(ctx context.Context, request events.APIGatewayProxyRequest, subscriptionID string) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+	customerID := request.RequestContext.Identity.APIKeyID
+	if customerID == "" {
+		return errorResponse(http.StatusUnauthorized, "UNAUTHORIZED", "An API key is required")
+	}
+
+	sub, err := h.webhooks.RotateSecret(ctx, customerID, subscriptionID)
+	if err != nil {
+		return customerErrorResponse(err, customerID, "Failed to rotate webhook signing secret")
+	}
+	return customerResponse(http.StatusOK, sub)
+}
+
+// handleSetWebhookMTLS handles PUT /internal/webhooks/{subscription_id}/mtls, attaching the client certificate
+// in a Secrets Manager secret to a subscription, or removing it when secret_id is empty
+// Only operators can name a secret, so customers can't have deliveries present another customer's certificate.
+func (h *Handler) handleSetWebhookMTLS(ctx context.Context, request events.APIGatewayProxyRequest, subscriptionID string) (events.APIGatewayProxyResponse, error) {
+	if h.webhooks == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Webhook subscriptions are not enabled")
+	}
+
+	var req models.WebhookMTLSRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+
+	sub, err := h.webhooks.SetMTLS(ctx, subscriptionID, &req)
+	if err != nil {
+		return customerErrorResponse(err, subscriptionID, "Failed to configure webhook mTLS")
+	}
+	if h.audit != nil {
+		details := map[string]string{"customer_id": sub.CustomerID, "secret_id": req.SecretID}
+		if _, err := h.audit.RecordAdminAction(ctx, requestActor(request), "webhook_mtls_set", audit.ResourceWebhook, subscriptionID, details); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"subscription_id": subscriptionID, "error": err.Error()})
+		}
+	}
+	return customerResponse(http.StatusOK, sub)
+}
+
+// handleListComplianceHolds handles GET /internal/compliance/holds, returning payments held on a sanctions hit
+// oldest first
+func (h *Handler) handleListComplianceHolds(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	if h.screener == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Sanctions screening is not enabled")
+	}
+
+	held, err := h.db.ListPaymentsByStatus(ctx, models.StatusComplianceHold)
+	if err != nil {
+		logger.Error("Failed to list held payments", logger.Fields{"error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load held payments")
+	}
+	if held == nil {
+		held = []*models.Payment{}
+	}
+
+	responseBody, _ := json.Marshal(map[string]interface{}{"payments": held})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleForceTransition handles POST /internal/payments/{payment_id}/transition, moving a stuck payment by hand
+// It takes the payment's processing lock so no worker step runs concurrently, queues the webhook of a terminal
+// status or, if asked, a job for the new status, and audits the change.
+func (h *Handler) handleForceTransition(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	var transitionReq models.TransitionRequest
+	if err := json.Unmarshal([]byte(request.Body), &transitionReq); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := transitionReq.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	pmt, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return errorResponse(http.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	}
+	from := pmt.Status
+
+	owner := uuid.New().String()
+	acquired, err := h.db.AcquireProcessingLock(ctx, paymentID, from, owner, transitionLockTTL)
+	if err != nil {
+		logger.Error("Failed to lock payment", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to transition payment")
+	}
+	if !acquired {
+		appErr := errors.ErrConflict("Payment is being processed; retry shortly")
+		appErr.Retryable = true // The lock is released once the running step finishes
+		return appErrorResponse(appErr)
+	}
+	defer func() {
+		if err := h.db.ReleaseProcessingLock(ctx, paymentID, owner); err != nil {
+			logger.Warn("Failed to release processing lock", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		}
+	}()
+
+	actor := requestActor(request)
+	if err := payment.ForceTransition(pmt, transitionReq.Status, actor, transitionReq.Reason); err != nil {
+		return errorResponse(http.StatusConflict, "CONFLICT", err.Error())
+	}
+	pmt.LockOwner = owner
+
+	// Save the transition with the terminal webhook or requested job, if any
+	var outboxMsg *models.OutboxMessage
+	switch {
+	case pmt.Status.IsTerminal():
+		outboxMsg, err = payment.NewWebhookOutboxMessage(pmt)
+	case transitionReq.Enqueue:
+		outboxMsg, err = models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, pmt.PaymentID, models.NewPaymentJob(pmt))
+	}
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to transition payment")
+	}
+	if outboxMsg != nil {
+		err = h.db.UpdatePaymentWithOutbox(ctx, pmt, outboxMsg)
+	} else {
+		err = h.db.UpdatePayment(ctx, pmt)
+	}
+	if err != nil {
+		logger.Error("Failed to save forced transition", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to transition payment")
+	}
+
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(pmt.Status)})
+	metrics.Count("ForcedTransitions", metrics.Dimensions{"Status": string(pmt.Status)})
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "payment_transition", audit.ResourcePayment, paymentID, map[string]string{
+			"from":    string(from),
+			"to":      string(pmt.Status),
+			"reason":  transitionReq.Reason,
+			"enqueue": strconv.FormatBool(transitionReq.Enqueue),
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		}
+	}
+
+	logger.Warn("Payment transition forced", logger.Fields{
+		"payment_id": paymentID,
+		"from":       from,
+		"to":         pmt.Status,
+		"actor":      actor,
+		"enqueued":   transitionReq.Enqueue,
+	})
+
+	responseBody, _ := json.Marshal(models.PaymentResponse{
+		PaymentID: pmt.PaymentID,
+		Status:    pmt.Status,
+		Message:   "Payment transitioned",
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleResolveHold handles POST /internal/payments/{payment_id}/approve and /reject for a payment in ON_HOLD
+// Approval resumes the payment where it was held; rejection fails or reverses it. The decision is recorded
+// in the payment's state history and the audit log.
+func (h *Handler) handleResolveHold(ctx context.Context, request events.APIGatewayProxyRequest, paymentID, decision string) (events.APIGatewayProxyResponse, error) {
+	if h.holds == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Manual review holds are not enabled")
+	}
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	var holdReq models.HoldDecisionRequest
+	if request.Body != "" {
+		if err := json.Unmarshal([]byte(request.Body), &holdReq); err != nil {
+			return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		}
+	}
+
+	pmt, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return errorResponse(http.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	}
+
+	actor := requestActor(request)
+	if err := payment.ResolveHold(pmt, decision, actor, holdReq.Reason); err != nil {
+		return errorResponse(http.StatusConflict, "CONFLICT", err.Error())
+	}
+
+	// Save the decision with the payment's next job, or its webhook if the rejection failed it
+	var outboxMsg *models.OutboxMessage
+	if pmt.Status.IsTerminal() {
+		outboxMsg, err = payment.NewWebhookOutboxMessage(pmt)
+	} else {
+		outboxMsg, err = models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, pmt.PaymentID, models.NewPaymentJob(pmt))
+	}
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve hold")
+	}
+	if err := h.db.UpdatePaymentWithOutbox(ctx, pmt, outboxMsg); err != nil {
+		logger.Error("Failed to save hold decision", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve hold")
+	}
+
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(pmt.Status)})
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "payment_hold", audit.ResourcePayment, paymentID, map[string]string{
+			"decision": decision,
+			"reason":   holdReq.Reason,
+			"triggers": strings.Join(pmt.Hold.Triggers, ","),
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		}
+	}
+
+	logger.Info("Payment hold resolved", logger.Fields{
+		"payment_id": paymentID,
+		"decision":   decision,
+		"actor":      actor,
+		"status":     pmt.Status,
+	})
+
+	responseBody, _ := json.Marshal(models.PaymentResponse{
+		PaymentID: pmt.PaymentID,
+		Status:    pmt.Status,
+		Message:   "Hold decision recorded",
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// handleResolveComplianceHold handles POST /internal/compliance/holds/{payment_id}/resolve, applying a
+// compliance officer's decision to a payment held on a sanctions hit
+func (h *Handler) handleResolveComplianceHold(ctx context.Context, request events.APIGatewayProxyRequest, paymentID string) (events.APIGatewayProxyResponse, error) {
+	if h.screener == nil {
+		return errorResponse(http.StatusNotFound, "NOT_FOUND", "Sanctions screening is not enabled")
+	}
+	tracing.Annotate(ctx, "payment_id", paymentID)
+
+	var reviewReq models.ComplianceReviewRequest
+	if err := json.Unmarshal([]byte(request.Body), &reviewReq); err != nil {
+		return errorResponse(http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	}
+	if appErr := reviewReq.Validate(); appErr != nil {
+		return appErrorResponse(appErr)
+	}
+
+	pmt, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return errorResponse(http.StatusNotFound, "PAYMENT_NOT_FOUND", "Payment not found")
+	}
+
+	actor := requestActor(request)
+	if err := payment.ResolveComplianceHold(pmt, reviewReq.Decision, actor, reviewReq.Reason); err != nil {
+		return errorResponse(http.StatusConflict, "CONFLICT", err.Error())
+	}
+
+	// A released payment may still need an operator's approval before it goes ahead
+	held := reviewReq.Decision == models.ComplianceDecisionRelease && h.holds.HoldIfTriggered(pmt)
+
+	// Save the decision with the payment's next job, or its webhook if the rejection failed it
+	var outboxMsg *models.OutboxMessage
+	if pmt.Status.IsTerminal() {
+		outboxMsg, err = payment.NewWebhookOutboxMessage(pmt)
+	} else {
+		outboxMsg, err = models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, pmt.PaymentID, models.NewPaymentJob(pmt))
+	}
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve compliance hold")
+	}
+	if err := h.db.UpdatePaymentWithOutbox(ctx, pmt, outboxMsg); err != nil {
+		logger.Error("Failed to save compliance decision", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errorResponse(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve compliance hold")
+	}
+
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(pmt.Status)})
+	if held {
+		payment.AlertHold(pmt)
+	}
+	if h.audit != nil {
+		if _, err := h.audit.RecordAdminAction(ctx, actor, "compliance_review", audit.ResourcePayment, paymentID, map[string]string{
+			"decision": reviewReq.Decision,
+			"reason":   reviewReq.Reason,
+			"stage":    pmt.Screening.Stage,
+		}); err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		}
+	}
+
+	logger.Info("Compliance hold resolved", logger.Fields{
+		"payment_id": paymentID,
+		"decision":   reviewReq.Decision,
+		"actor":      actor,
+		"status":     pmt.Status,
+	})
+
+	responseBody, _ := json.Marshal(models.PaymentResponse{
+		PaymentID: pmt.PaymentID,
+		Status:    pmt.Status,
+		Message:   "Compliance decision recorded",
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
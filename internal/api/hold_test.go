@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/compliance/sanctions"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func holdHandler(db *database.MemoryPaymentRepository, policy *payment.HoldPolicy) *Handler {
+	return &Handler{
+		db:        db,
+		quoteCalc: quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), nil),
+		feeCalc:   fees.NewCalculator(),
+		corridors: corridors.Default(),
+		holds:     policy,
+		cfg:       &config.Config{},
+	}
+}
+
+func createHeldPayment(t *testing.T, h *Handler, key, body string) models.PaymentResponse {
+	t.Helper()
+	resp, err := h.route(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": key},
+		Body:       body,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+	var created models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+	return created
+}
+
+func decideHold(t *testing.T, h *Handler, paymentID, decision, body string) events.APIGatewayProxyResponse {
+	t.Helper()
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodPost,
+		Path:           "/internal/payments/" + paymentID + "/" + decision,
+		PathParameters: map[string]string{"payment_id": paymentID},
+		Body:           body,
+	}
+	request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/ops"
+	resp, err := h.route(context.Background(), request)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestLargePaymentHeldUntilApproved(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := holdHandler(db, &payment.HoldPolicy{AmountThreshold: 500000})
+
+	small := createHeldPayment(t, h, "key_hold_small", `{"amount": 100000, "currency": "USD",
+		"source_account": "acct_source", "destination_account": "acct_destination"}`)
+	assert.Equal(t, models.StatusPending, small.Status)
+
+	large := createHeldPayment(t, h, "key_hold_large", `{"amount": 500000, "currency": "USD",
+		"source_account": "acct_source", "destination_account": "acct_destination"}`)
+	assert.Equal(t, models.StatusOnHold, large.Status)
+	stored, err := db.GetPaymentByID(ctx, large.PaymentID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Hold)
+	assert.Equal(t, []string{models.HoldTriggerAmount}, stored.Hold.Triggers)
+	assert.Equal(t, models.StatusPending, stored.Hold.HeldFrom)
+
+	assert.Equal(t, http.StatusConflict, decideHold(t, h, small.PaymentID, "approve", "").StatusCode)
+
+	resp := decideHold(t, h, large.PaymentID, "approve", `{"reason": "Known customer"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err = db.GetPaymentByID(ctx, large.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, stored.Status)
+	assert.True(t, stored.Hold.Approved())
+	last := stored.StateHistory[len(stored.StateHistory)-1]
+	assert.Equal(t, models.StatusOnHold, last.FromStatus)
+	assert.Contains(t, last.Message, "Hold approved by iam:arn:aws:iam::123456789012:user/ops: Known customer")
+
+	// The approval queues the payment's job
+	msgs, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxKindPaymentJob, msgs[len(msgs)-1].Kind)
+	assert.Equal(t, large.PaymentID, msgs[len(msgs)-1].PaymentID)
+
+	assert.Equal(t, http.StatusConflict, decideHold(t, h, large.PaymentID, "reject", "").StatusCode)
+}
+
+func TestRiskyPaymentHeldAndRejected(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := holdHandler(db, &payment.HoldPolicy{RiskScore: 6, Risks: fees.NewMockDataProvider(), Corridors: corridors.Default()})
+
+	created := createHeldPayment(t, h, "key_hold_risk", `{"amount": 100000, "currency": "EUR", "source_account": "acct_source",
+		"destination_account": "acct_destination", "beneficiary": {"name": "Ada Obi", "country": "NG"}}`)
+	require.Equal(t, models.StatusOnHold, created.Status)
+
+	resp := decideHold(t, h, created.PaymentID, "reject", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err := db.GetPaymentByID(ctx, created.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, stored.Status)
+	assert.Equal(t, []string{models.HoldTriggerRiskScore}, stored.Hold.Triggers)
+	assert.NotNil(t, stored.ProcessedAt)
+
+	msgs, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.OutboxKindWebhookEvent, msgs[len(msgs)-1].Kind)
+}
+
+func TestReleasedSanctionsHitHeldForApproval(t *testing.T) {
+	ctx := context.Background()
+	list := sanctions.NewList(sanctions.OFACListName)
+	list.AddName("DOE, Sanctioned")
+
+	db := database.NewMemoryPaymentRepository()
+	h := holdHandler(db, &payment.HoldPolicy{SanctionsHit: true})
+	h.screener = list
+
+	created := createHeldPayment(t, h, "key_hold_sanctions", `{"amount": 100000, "currency": "USD", "source_account": "acct_source",
+		"destination_account": "acct_destination", "beneficiary": {"name": "Sanctioned Doe", "country": "US"}}`)
+	require.Equal(t, models.StatusComplianceHold, created.Status)
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodPost,
+		Path:           "/internal/compliance/holds/" + created.PaymentID + "/resolve",
+		PathParameters: map[string]string{"payment_id": created.PaymentID},
+		Body:           `{"decision": "release", "reason": "Different date of birth"}`,
+	}
+	resp, err := h.route(ctx, asOperator(request))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	stored, err := db.GetPaymentByID(ctx, created.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnHold, stored.Status)
+	assert.Equal(t, []string{models.HoldTriggerSanctions}, stored.Hold.Triggers)
+
+	resp = decideHold(t, h, created.PaymentID, "approve", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err = db.GetPaymentByID(ctx, created.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, stored.Status)
+}
+
+func TestHoldEndpointsDisabled(t *testing.T) {
+	h := holdHandler(database.NewMemoryPaymentRepository(), nil)
+	assert.Equal(t, http.StatusNotFound, decideHold(t, h, "pay_1", "approve", "").StatusCode)
+}
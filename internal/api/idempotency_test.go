@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentReplaysTheOriginalResponse(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+
+	create := func(body string) events.APIGatewayProxyResponse {
+		resp, err := h.route(ctx, events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/payments",
+			Headers:    map[string]string{"Idempotency-Key": "key_replay_1"},
+			Body:       body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	first := create(`{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`)
+	require.Equal(t, http.StatusAccepted, first.StatusCode, first.Body)
+	assert.Empty(t, first.Headers["Idempotent-Replayed"])
+
+	// The same request, serialized differently, gets the original response and creates nothing
+	replay := create(`{"destination_account":"acct_dest","source_account":"acct_source","currency":"USD","amount":100000}`)
+	assert.Equal(t, http.StatusAccepted, replay.StatusCode, replay.Body)
+	assert.Equal(t, "true", replay.Headers["Idempotent-Replayed"])
+	assert.Equal(t, first.Body, replay.Body)
+
+	var response models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(first.Body), &response))
+	messages, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	// The stored response isn't returned with the payment
+	get, err := h.route(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/payments/" + response.PaymentID,
+		PathParameters: map[string]string{"payment_id": response.PaymentID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, get.StatusCode, get.Body)
+	assert.NotContains(t, get.Body, "idempotent_response")
+
+	// A different request under the same key is refused
+	conflict := create(`{"amount": 200000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`)
+	assert.Equal(t, http.StatusConflict, conflict.StatusCode, conflict.Body)
+}
+
+func TestIdempotencyKeysAreScopedToTheAPIKey(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	create := func(apiKeyID string) models.PaymentResponse {
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodPost,
+			Path:       "/payments",
+			Headers:    map[string]string{"Idempotency-Key": "key_shared"},
+			Body:       `{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`,
+		}
+		request.RequestContext.Identity.APIKeyID = apiKeyID
+		resp, err := h.route(ctx, request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+		var response models.PaymentResponse
+		require.NoError(t, json.Unmarshal([]byte(resp.Body), &response))
+		return response
+	}
+
+	// The same key from two customers creates two payments; each customer's retry finds its own
+	first := create("api_key_a")
+	second := create("api_key_b")
+	assert.NotEqual(t, first.PaymentID, second.PaymentID)
+	assert.Equal(t, first.PaymentID, create("api_key_a").PaymentID)
+	assert.Equal(t, second.PaymentID, create("api_key_b").PaymentID)
+}
+
+func TestPaymentsAreOnlyVisibleToTheirAPIKey(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+	invoices := database.NewMemoryFeeInvoiceRepository()
+	h.invoices = invoices
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": "key_visible"},
+		Body:       `{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`,
+	}
+	request.RequestContext.Identity.APIKeyID = "api_key_a"
+	resp, err := h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+	var created models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+
+	stored, err := db.GetPaymentByID(ctx, created.PaymentID)
+	require.NoError(t, err)
+	require.NoError(t, invoices.CreateFeeInvoice(ctx, models.NewFeeInvoice(stored, stored.CreatedAt)))
+
+	get := func(path, apiKeyID string) events.APIGatewayProxyResponse {
+		request := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path}
+		request.RequestContext.Identity.APIKeyID = apiKeyID
+		resp, err := h.route(ctx, request)
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, path := range []string{"/payments/" + created.PaymentID, "/payments/" + created.PaymentID + "/events", "/payments/" + created.PaymentID + "/fees"} {
+		assert.Equal(t, http.StatusOK, get(path, "api_key_a").StatusCode, path)
+
+		other := get(path, "api_key_b")
+		assert.Equal(t, http.StatusNotFound, other.StatusCode, path)
+		assert.Contains(t, other.Body, `"code":"PAYMENT_NOT_FOUND"`, path)
+		assert.Equal(t, http.StatusNotFound, get(path, "").StatusCode, path)
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ledgerRequest(paymentID string) events.APIGatewayProxyRequest {
+	return asOperator(events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/internal/payments/" + paymentID + "/ledger",
+		Resource:       "/internal/payments/{payment_id}/ledger",
+		PathParameters: map[string]string{"payment_id": paymentID},
+	})
+}
+
+func TestGetPaymentLedger(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	require.NoError(t, db.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_1",
+		IdempotencyKey: "key_1",
+		Amount:         100000,
+		Currency:       "EUR",
+		Status:         models.StatusOnrampComplete,
+		CreatedAt:      time.Now(),
+	}))
+
+	l := ledger.New(database.NewMemoryLedgerRepository())
+	_, err := l.Post(ctx, &models.LedgerTransaction{
+		TransactionID: "pay_1:onramp",
+		PaymentID:     "pay_1",
+		Leg:           models.LedgerLegOnramp,
+		Entries:       ledger.Onramp("base", ledger.Amounts{Funds: 100000}),
+		CreatedAt:     time.Now(),
+	})
+	require.NoError(t, err)
+
+	h := &Handler{db: db, ledger: l, cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, ledgerRequest("pay_1"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var body models.PaymentLedger
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &body))
+	assert.Equal(t, models.StatusOnrampComplete, body.Status)
+	require.Len(t, body.Transactions, 1)
+	assert.Equal(t, "pay_1:onramp", body.Transactions[0].TransactionID)
+	require.Len(t, body.Balances, 2)
+	assert.Equal(t, models.LedgerCustomerFunds, body.Balances[0].Account)
+	assert.Equal(t, int64(100000), body.Balances[0].Balance)
+
+	resp, err = h.route(ctx, ledgerRequest("pay_missing"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	disabled := &Handler{db: db, cfg: &config.Config{}}
+	resp, err = disabled.route(ctx, ledgerRequest("pay_1"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
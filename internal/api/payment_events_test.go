@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func paymentEventsRequest(paymentID string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/payments/" + paymentID + "/events",
+		PathParameters: map[string]string{"payment_id": paymentID},
+	}
+}
+
+func TestGetPaymentEvents(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return created.Add(time.Duration(minutes) * time.Minute) }
+
+	db := database.NewMemoryPaymentRepository()
+	require.NoError(t, db.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_timeline",
+		IdempotencyKey: "key_timeline",
+		Amount:         2500000,
+		Currency:       "EUR",
+		Status:         models.StatusOnrampPending,
+		CreatedAt:      created,
+		StateHistory: []models.StateTransition{
+			{FromStatus: models.StatusPending, ToStatus: models.StatusOnHold, Timestamp: at(0), Message: "Held for manual approval: amount 2500000 USD reaches the hold threshold"},
+			{FromStatus: models.StatusOnHold, ToStatus: models.StatusComplianceHold, Timestamp: at(5), Message: "Sanctions match on beneficiary"},
+			{FromStatus: models.StatusComplianceHold, ToStatus: models.StatusPending, Timestamp: at(30), Message: "Hold approved by iam:arn:aws:iam::123456789012:user/ops"},
+			{FromStatus: models.StatusPending, ToStatus: models.StatusOnrampPending, Timestamp: at(31), Message: "Onramp initiated: tx_123"},
+		},
+	}))
+	h := &Handler{db: db, cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, paymentEventsRequest("pay_timeline"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var body models.PaymentEvents
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &body))
+	assert.Equal(t, "pay_timeline", body.PaymentID)
+	assert.Equal(t, models.StatusOnrampPending, body.Status)
+
+	// Both holds show as one review step, and no internal message reaches the customer
+	require.Len(t, body.Events, 4)
+	assert.Equal(t, models.PaymentEvent{Status: models.StatusPending, Timestamp: created, Message: "Payment received"}, body.Events[0])
+	assert.Equal(t, models.PaymentEvent{Status: models.StatusUnderReview, Timestamp: at(0), Message: "Payment is under review"}, body.Events[1])
+	assert.Equal(t, models.StatusPending, body.Events[2].Status)
+	assert.Equal(t, at(30), body.Events[2].Timestamp)
+	assert.Equal(t, models.StatusOnrampPending, body.Events[3].Status)
+	assert.NotContains(t, resp.Body, "Sanctions")
+	assert.NotContains(t, resp.Body, "iam:")
+	assert.NotContains(t, resp.Body, "tx_123")
+}
+
+func TestGetPaymentEventsNotFound(t *testing.T) {
+	h := &Handler{db: database.NewMemoryPaymentRepository(), cfg: &config.Config{}}
+
+	resp, err := h.route(context.Background(), paymentEventsRequest("pay_missing"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createFailingRepository refuses to create payments, like a throttled or unavailable table
+type createFailingRepository struct {
+	*database.MemoryPaymentRepository
+}
+
+func (r *createFailingRepository) CreatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	return errors.ErrDatabaseOperation("transact_create", context.DeadlineExceeded)
+}
+
+func createPaymentRequest(idempotencyKey string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Headers:    map[string]string{"Idempotency-Key": idempotencyKey},
+		Body: `{"amount": 100000, "currency": "EUR", "source_account": "acct_source",
+			"destination_account": "acct_destination", "quote_id": "quote_promo", "promo_code": "launch"}`,
+	}
+}
+
+func TestFailedPaymentCreationReleasesPromo(t *testing.T) {
+	ctx := context.Background()
+	quoteDB := database.NewMemoryQuoteRepository()
+	require.NoError(t, quoteDB.CreateQuote(ctx, &quotes.Quote{
+		QuoteID:          "quote_promo",
+		Amount:           100000,
+		FromCurrency:     "USD",
+		ToCurrency:       "EUR",
+		ExchangeRate:     0.92,
+		GuaranteedPayout: 90000,
+		ExpiresAt:        time.Now().Add(time.Minute),
+	}))
+	promos := database.NewMemoryPromoRepository()
+	require.NoError(t, promos.CreatePromo(ctx, &models.Promo{Code: "LAUNCH", PercentOff: 0.5, MaxUses: 1, ValidFrom: time.Now().Add(-time.Hour)}))
+
+	h := &Handler{
+		db:        &createFailingRepository{database.NewMemoryPaymentRepository()},
+		quoteDB:   quoteDB,
+		feeCalc:   fees.NewCalculator(),
+		corridors: corridors.Default(),
+		promos:    promos,
+		cfg:       &config.Config{},
+	}
+
+	resp, err := h.route(ctx, createPaymentRequest("key_promo_1"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode, resp.Body)
+
+	promo, err := promos.GetPromo(ctx, "LAUNCH")
+	require.NoError(t, err)
+	assert.Zero(t, promo.Uses, "the failed payment's use was given back")
+
+	// The promo's only use is still there for the retry
+	payments := database.NewMemoryPaymentRepository()
+	payments.EnableQuoteConsumption(quoteDB)
+	h.db = payments
+	resp, err = h.route(ctx, createPaymentRequest("key_promo_2"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	promo, err = promos.GetPromo(ctx, "LAUNCH")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), promo.Uses)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaExceededReleasesBatch(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+	h.quotas = quotas.New(database.NewMemoryQuotaRepository(), quotas.Tiers{
+		"standard": {DailyCount: 2, MonthlyVolume: 1000000},
+	})
+
+	// The third payment is over the daily count, so the whole batch is refused and its reservations released
+	request := batchRequest("key_quota_1", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"},
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_2"},
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_3"}
+	]}`)
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+	resp, err := h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, resp.Body)
+	assert.Contains(t, resp.Body, "QUOTA_EXCEEDED")
+	assert.Contains(t, resp.Body, "daily")
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/quotas", "", ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var status models.QuotaStatus
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &status))
+	assert.Equal(t, "standard", status.Tier)
+	assert.Zero(t, status.Daily.Payments.Used)
+	assert.Zero(t, status.Monthly.Volume.Used)
+
+	// Two payments fit
+	request = batchRequest("key_quota_2", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"},
+		{"amount": 150000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_2"}
+	]}`)
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/quotas", "", ""))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &status))
+	assert.Equal(t, int64(2), status.Daily.Payments.Used)
+	assert.Equal(t, int64(0), *status.Daily.Payments.Remaining)
+	assert.Nil(t, status.Monthly.Payments.Limit, "no monthly count limit")
+	assert.Equal(t, int64(250000), status.Monthly.Volume.Used)
+	assert.Equal(t, int64(750000), *status.Monthly.Volume.Remaining)
+
+	// IAM-authorized callers have no quotas
+	resp, err = h.route(ctx, batchRequest("key_quota_3", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+}
+
+func TestGetQuotasRequiresFeatureAndKey(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, apiKeyRequest(http.MethodGet, "/quotas", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "quotas are off")
+
+	h.quotas = quotas.New(database.NewMemoryQuotaRepository(), quotas.Tiers{})
+	request := apiKeyRequest(http.MethodGet, "/quotas", "", "")
+	request.RequestContext.Identity.APIKeyID = ""
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compareRequest(body string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Path: "/quotes/compare", Body: body}
+}
+
+func TestCompareQuotes(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	// Without a model client the AI engine returns its fallback fees, so no network is needed
+	h.aiFeeCalc = fees.NewAIFeeCalculator(nil, nil, nil, nil, fees.DefaultLLMConfig())
+
+	resp, err := h.route(ctx, compareRequest(`{"from_currency": "USD", "to_currency": "EUR", "amount": 100000}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var comparison quotes.QuoteComparison
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &comparison))
+	assert.Equal(t, int64(100000), comparison.Amount)
+	assert.Equal(t, "USD", comparison.FeeCurrency)
+	assert.Positive(t, comparison.Static.TotalFees)
+	assert.Positive(t, comparison.Static.GuaranteedPayout)
+	assert.True(t, comparison.AI.Fallback)
+	assert.Equal(t, "Base", comparison.AI.SettlementChain)
+	assert.Equal(t, comparison.AI.TotalFee-comparison.Static.TotalFees, comparison.Delta.Fee)
+	assert.NotEmpty(t, comparison.Delta.Cheaper)
+}
+
+func TestCompareQuotesRequiresAIEngine(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, compareRequest(`{"from_currency": "USD", "to_currency": "EUR", "amount": 100000}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Contains(t, resp.Body, "AI_UNAVAILABLE")
+}
+
+func TestCompareQuotesRejectsUnsupportedCorridor(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	h.aiFeeCalc = fees.NewAIFeeCalculator(nil, nil, nil, nil, fees.DefaultLLMConfig())
+
+	resp, err := h.route(ctx, compareRequest(`{"from_currency": "USD", "to_currency": "XYZ", "amount": 100000}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rateRequest(params map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/rates", QueryStringParameters: params}
+}
+
+func TestGetRate(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, rateRequest(map[string]string{"from": "USD", "to": "GBP"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var rate quotes.IndicativeRate
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &rate))
+	assert.Equal(t, "USD-GBP", rate.CorridorID)
+	assert.Equal(t, quotes.RateSourceCorridor, rate.MidRateSource, "no live FX rates without the fee engine")
+	assert.Positive(t, rate.Rate)
+	assert.InDelta(t, (rate.MidRate-rate.Rate)/rate.MidRate, rate.Spread, 1e-9)
+	assert.False(t, rate.Timestamp.IsZero())
+}
+
+func TestGetRateValidatesQuery(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, rateRequest(map[string]string{"amount": "ten"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	for _, field := range []string{"from", "to", "amount"} {
+		assert.Contains(t, resp.Body, `"field":"`+field+`"`)
+	}
+
+	resp, err = h.route(ctx, rateRequest(map[string]string{"from": "USD", "to": "JPY"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, "not a supported corridor")
+}
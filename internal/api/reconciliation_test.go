@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveBreakRequest(breakID, body string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodPost,
+		Path:           "/internal/reconciliation/breaks/" + breakID + "/resolve",
+		Resource:       "/internal/reconciliation/breaks/{break_id}/resolve",
+		PathParameters: map[string]string{"break_id": breakID},
+		Body:           body,
+	}
+	request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/ops"
+	return request
+}
+
+func TestResolveReconciliationBreak(t *testing.T) {
+	ctx := context.Background()
+	breaks := database.NewMemoryReconciliationRepository()
+	_, err := breaks.RecordBreak(ctx, &models.ReconciliationBreak{
+		BreakID:    "circle:offramp_1:amount_mismatch",
+		Kind:       models.BreakAmountMismatch,
+		Status:     models.BreakStatusOpen,
+		DetectedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	h := &Handler{breaks: breaks, cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, asOperator(events.APIGatewayProxyRequest{
+		HTTPMethod:            http.MethodGet,
+		Path:                  "/internal/reconciliation/breaks",
+		QueryStringParameters: map[string]string{"status": models.BreakStatusOpen},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var listed struct {
+		Breaks []*models.ReconciliationBreak `json:"breaks"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &listed))
+	require.Len(t, listed.Breaks, 1)
+
+	resp, err = h.route(ctx, resolveBreakRequest("circle:offramp_1:amount_mismatch", `{}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = h.route(ctx, resolveBreakRequest("circle:offramp_1:amount_mismatch", `{"resolution": "Circle corrected the payout"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var resolved models.ReconciliationBreak
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &resolved))
+	assert.Equal(t, models.BreakStatusResolved, resolved.Status)
+	assert.Equal(t, "iam:arn:aws:iam::123456789012:user/ops", resolved.ResolvedBy)
+
+	resp, err = h.route(ctx, resolveBreakRequest("circle:offramp_1:amount_mismatch", `{"resolution": "again"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = h.route(ctx, resolveBreakRequest("circle:unknown:amount_mismatch", `{"resolution": "n/a"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanickingHandlerReturnsInternalError(t *testing.T) {
+	// A handler without its repository panics on the nil interface
+	h := &Handler{}
+
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/payments/pay_1",
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: "req-123"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Headers["Content-Type"])
+	assert.Equal(t, "req-123", resp.Headers["X-Correlation-ID"])
+
+	var body struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &body))
+	assert.Equal(t, "INTERNAL_ERROR", body.Error["code"])
+	assert.Equal(t, true, body.Error["retryable"])
+	assert.Equal(t, "req-123", body.Error["correlation_id"])
+	assert.NotContains(t, resp.Body, "nil pointer")
+}
+
+func TestCorrelationIDHeader(t *testing.T) {
+	request := events.APIGatewayProxyRequest{
+		Headers:        map[string]string{"x-correlation-id": "trace-abc"},
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: "req-123"},
+	}
+	assert.Equal(t, "trace-abc", correlationIDHeader(request))
+
+	// IDs that couldn't be echoed in a header fall back to the request ID
+	request.Headers["x-correlation-id"] = "bad id\n"
+	assert.Equal(t, "req-123", correlationIDHeader(request))
+
+	request.RequestContext.RequestID = ""
+	assert.NotEmpty(t, correlationIDHeader(request))
+}
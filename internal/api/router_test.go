@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1PrefixServesTheSameEndpoints(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/v1/payments",
+		Headers:    map[string]string{"Idempotency-Key": "key_v1_prefixed"},
+		Body:       `{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+	var created models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &created))
+
+	// Path parameters come from the path, without API Gateway's
+	for _, path := range []string{"/payments/" + created.PaymentID, "/v1/payments/" + created.PaymentID} {
+		resp, err = h.route(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, resp.Body, created.PaymentID)
+	}
+}
+
+func TestUnroutedRequests(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/v1/nothing-here"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"code":"NOT_FOUND"`)
+
+	resp, err = h.route(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodDelete, Path: "/v1/payments/pay_1"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, http.MethodGet, resp.Headers["Allow"])
+	assert.Contains(t, resp.Body, `"code":"METHOD_NOT_ALLOWED"`)
+}
+
+func TestEveryRouteIsServedUnderV1(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	h.routerOnce.Do(func() { h.router = h.newRouter() })
+
+	for _, route := range h.router.Routes() {
+		assert.Equal(t, apiVersion, route.Version)
+		path := strings.NewReplacer("{", "", "}", "").Replace(route.Pattern)
+		match, _, found := h.router.Match(route.Method, "/v1"+path)
+		require.True(t, found, route.Pattern)
+		require.NotNil(t, match, route.Pattern)
+		assert.Equal(t, route.Pattern, match.Route.Pattern)
+	}
+}
+
+func TestOperatorRoutesRequireIAMThroughV1(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	// /v1/{proxy+} isn't IAM-authorized, so an unsigned call arrives without a principal
+	for _, path := range []string{"/v1/audit", "/v1/reports/gas-trends", "/v1/fee-schedules/standard", "/v1/internal/treasury"} {
+		resp, err := h.route(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: path})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, path)
+	}
+	resp, err := h.route(ctx, events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Path: "/v1/payments/pay_1/review", Body: `{"decision": "approve"}`})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = h.route(ctx, asOperator(events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Path: "/v1/payments/pay_1/review", Body: `{"decision": "approve"}`}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "signed calls reach the handler")
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSandboxCheckPayment creates a payment under the acme API key and returns it as stored
+func createSandboxCheckPayment(t *testing.T, h *Handler, db *database.MemoryPaymentRepository, idempotencyKey string) *models.Payment {
+	ctx := context.Background()
+	request := apiKeyRequest(http.MethodPost, "/payments", "203.0.113.7",
+		`{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest"}`)
+	request.Headers = map[string]string{"Idempotency-Key": idempotencyKey}
+	resp, err := h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	var response models.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &response))
+	stored, err := db.GetPaymentByID(ctx, response.PaymentID)
+	require.NoError(t, err)
+	return stored
+}
+
+func TestSandboxConfigMarksEveryPayment(t *testing.T) {
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+
+	assert.False(t, createSandboxCheckPayment(t, h, db, "key_sandbox_live").Sandbox)
+
+	h.cfg.Sandbox.Enabled = true
+	assert.True(t, createSandboxCheckPayment(t, h, db, "key_sandbox_on").Sandbox)
+}
+
+func TestSandboxCustomerMarksItsPayments(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+	h.customers = customers.New(database.NewMemoryCustomerRepository())
+	_, err := h.customers.Create(ctx, &models.CustomerRequest{CustomerID: "key_acme", Name: "Acme", Sandbox: true})
+	require.NoError(t, err)
+
+	stored := createSandboxCheckPayment(t, h, db, "key_sandbox_tenant")
+	assert.True(t, stored.Sandbox)
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/payments/" + stored.PaymentID,
+		PathParameters: map[string]string{"payment_id": stored.PaymentID},
+	}
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+	get, err := h.route(ctx, request)
+	require.NoError(t, err)
+	assert.Contains(t, get.Body, `"sandbox":true`)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/jsonschema"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMistypedBodyReportsEveryFieldPath(t *testing.T) {
+	db := database.NewMemoryPaymentRepository()
+	h := batchHandler(db)
+
+	body := `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"},
+		{"amount": "250000", "currency": "USD", "source_account": "acct_source", "destination_account": 42}
+	]}`
+	resp, err := h.route(context.Background(), batchRequest("key_batch_typed", body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode, resp.Body)
+
+	var errResp errors.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &errResp))
+	assert.Equal(t, "VALIDATION_ERROR", errResp.Error.Code)
+	assert.Equal(t, []errors.FieldErrorDetail{
+		{Field: "payments[1].amount", Code: errors.FieldInvalidType, Message: "must be an integer"},
+		{Field: "payments[1].destination_account", Code: errors.FieldInvalidType, Message: "must be a string"},
+	}, errResp.Error.Errors)
+
+	// Nothing was created, not even the valid payment
+	messages, err := db.ListOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestMalformedBodyIsInvalidJSON(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/quotes",
+		Body:       `{"amount": 100000,}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"code":"INVALID_JSON"`)
+	assert.Contains(t, resp.Body, "invalid character")
+}
+
+func TestGetSchema(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/schemas/PaymentRequest",
+		PathParameters: map[string]string{"schema_name": "PaymentRequest"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &schema))
+	assert.Equal(t, jsonschema.TypeObject, schema.Type)
+	assert.Equal(t, jsonschema.TypeInteger, schema.Properties["amount"].Type)
+	assert.Equal(t, jsonschema.TypeString, schema.Properties["beneficiary"].Properties["country"].Type)
+
+	resp, err = h.route(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/schemas/Payment",
+		PathParameters: map[string]string{"schema_name": "Payment"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticSettlementStore serves fixed settlement reports
+type staticSettlementStore map[string][]*models.SettlementReport
+
+func (s staticSettlementStore) PutSettlementReport(ctx context.Context, reportDate string, reports []*models.SettlementReport) error {
+	s[reportDate] = reports
+	return nil
+}
+
+func (s staticSettlementStore) GetSettlementReport(ctx context.Context, reportDate string) ([]*models.SettlementReport, error) {
+	return s[reportDate], nil
+}
+
+func settlementReportRequest(reportDate string) events.APIGatewayProxyRequest {
+	return asOperator(events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/internal/reports/settlements/" + reportDate,
+		Resource:       "/internal/reports/settlements/{report_date}",
+		PathParameters: map[string]string{"report_date": reportDate},
+	})
+}
+
+func TestGetSettlementReport(t *testing.T) {
+	ctx := context.Background()
+	store := staticSettlementStore{
+		"2026-03-01": {{ReportDate: "2026-03-01", Corridor: "USD-EUR", Chain: "base", Payments: 2, NetPayouts: 180000}},
+	}
+	h := &Handler{settlements: store, cfg: &config.Config{}}
+
+	resp, err := h.route(ctx, settlementReportRequest("2026-03-01"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var body struct {
+		ReportDate string                     `json:"report_date"`
+		Rows       []*models.SettlementReport `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &body))
+	assert.Equal(t, "2026-03-01", body.ReportDate)
+	require.Len(t, body.Rows, 1)
+	assert.Equal(t, int64(180000), body.Rows[0].NetPayouts)
+
+	resp, err = h.route(ctx, settlementReportRequest("2026-03-02"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = h.route(ctx, settlementReportRequest("yesterday"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	disabled := &Handler{cfg: &config.Config{}}
+	resp, err = disabled.route(ctx, settlementReportRequest("2026-03-01"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
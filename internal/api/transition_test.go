@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transitionRequest(paymentID, body string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodPost,
+		Path:           "/internal/payments/" + paymentID + "/transition",
+		PathParameters: map[string]string{"payment_id": paymentID},
+		Body:           body,
+	}
+	request.RequestContext.Identity.UserArn = "arn:aws:iam::123456789012:user/ops"
+	return request
+}
+
+func TestForceTransition(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	require.NoError(t, db.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_stuck",
+		IdempotencyKey: "key_stuck",
+		Amount:         10000,
+		Currency:       "EUR",
+		Status:         models.StatusTimedOut,
+		ErrorMessage:   "Offramp settlement timed out",
+		SweepCount:     3,
+	}))
+	h := &Handler{db: db, cfg: &config.Config{}}
+
+	transition := func(body string) events.APIGatewayProxyResponse {
+		resp, err := h.route(ctx, transitionRequest("pay_stuck", body))
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := transition(`{"status": "SETTLED", "reason": "typo"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = transition(`{"status": "OFFRAMP_PENDING"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a reason is required")
+	resp = transition(`{"status": "COMPLETED", "reason": "Settled", "enqueue": true}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a terminal status has no job")
+
+	// Back to polling once the provider confirmed the transfer is still live
+	resp = transition(`{"status": "OFFRAMP_PENDING", "reason": "Provider confirmed the payout is in flight", "enqueue": true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err := db.GetPaymentByID(ctx, "pay_stuck")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOfframpPending, stored.Status)
+	assert.Empty(t, stored.ErrorMessage)
+	assert.Zero(t, stored.SweepCount)
+	assert.Empty(t, stored.LockOwner)
+	last := stored.StateHistory[len(stored.StateHistory)-1]
+	assert.Equal(t, models.StatusTimedOut, last.FromStatus)
+	assert.Contains(t, last.Message, "Provider confirmed the payout is in flight")
+
+	msgs, err := db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, models.OutboxKindPaymentJob, msgs[0].Kind)
+
+	assert.Equal(t, http.StatusConflict, transition(`{"status": "OFFRAMP_PENDING", "reason": "again"}`).StatusCode)
+
+	// A worker holding the payment keeps the transition out
+	acquired, err := db.AcquireProcessingLock(ctx, "pay_stuck", models.StatusOfframpPending, "worker_a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.Equal(t, http.StatusConflict, transition(`{"status": "COMPLETED", "reason": "Settled"}`).StatusCode)
+	require.NoError(t, db.ReleaseProcessingLock(ctx, "pay_stuck", "worker_a"))
+
+	// A terminal status queues the webhook
+	resp = transition(`{"status": "COMPLETED", "reason": "Provider statement shows the payout settled"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	stored, err = db.GetPaymentByID(ctx, "pay_stuck")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCompleted, stored.Status)
+	assert.NotNil(t, stored.ProcessedAt)
+	msgs, err = db.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, models.OutboxKindWebhookEvent, msgs[1].Kind)
+
+	resp, err = h.route(ctx, transitionRequest("pay_missing", `{"status": "FAILED", "reason": "Cleanup"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
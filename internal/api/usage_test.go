@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageMetersCallsAndPayments(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+	h.usage = database.NewMemoryUsageRepository()
+
+	request := batchRequest("key_usage_1", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"},
+		{"amount": 250000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_2"}
+	]}`)
+	request.RequestContext.Identity.APIKeyID = "key_acme"
+	resp, err := h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	// A replay is a call, but its payments were already counted
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	// IAM-authorized callers aren't metered
+	resp, err = h.route(ctx, batchRequest("key_usage_2", `{"payments": [
+		{"amount": 100000, "currency": "USD", "source_account": "acct_source", "destination_account": "acct_dest_1"}
+	]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, resp.Body)
+
+	resp, err = h.route(ctx, apiKeyRequest(http.MethodGet, "/usage", "", ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	var summary models.UsageSummary
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &summary))
+	assert.Equal(t, "key_acme", summary.CustomerID)
+	assert.Equal(t, int64(3), summary.APICalls, "two batches and this read")
+	assert.Equal(t, int64(2), summary.Payments)
+	assert.Equal(t, map[string]int64{"USD": 350000}, summary.PaymentVolume)
+	assert.Zero(t, summary.AIFeeCalculations)
+}
+
+func TestGetUsageValidatesRequest(t *testing.T) {
+	ctx := context.Background()
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(ctx, apiKeyRequest(http.MethodGet, "/usage", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "metering is off")
+
+	h.usage = database.NewMemoryUsageRepository()
+	request := apiKeyRequest(http.MethodGet, "/usage", "", "")
+	request.QueryStringParameters = map[string]string{"period": "2026-13"}
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, resp.Body, `"field":"period"`)
+
+	request.QueryStringParameters = map[string]string{"period": "2025-01"}
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+	assert.Contains(t, resp.Body, `"api_calls":0`, "the calls are counted in the current month")
+
+	request.RequestContext.Identity.APIKeyID = ""
+	resp, err = h.route(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
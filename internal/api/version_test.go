@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/version"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetVersion(t *testing.T) {
+	h := batchHandler(database.NewMemoryPaymentRepository())
+
+	resp, err := h.route(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/version"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var info version.Info
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &info))
+	assert.NotEmpty(t, info.GitSHA)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.Equal(t, strconv.Itoa(models.PaymentJobSchemaVersion), info.Schemas["payment_job"])
+	assert.Equal(t, strconv.Itoa(models.WebhookEventSchemaVersion), info.Schemas["webhook_event"])
+	assert.Regexp(t, `^[0-9]{4}$`, info.Schemas["postgres"])
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/webhooks"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func webhookStatsRequest(apiKeyID, subscriptionID, hours string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Path:           "/webhooks/" + subscriptionID + "/stats",
+		PathParameters: map[string]string{"subscription_id": subscriptionID},
+	}
+	request.RequestContext.Identity.APIKeyID = apiKeyID
+	if hours != "" {
+		request.QueryStringParameters = map[string]string{"hours": hours}
+	}
+	return request
+}
+
+func TestGetWebhookStats(t *testing.T) {
+	ctx := context.Background()
+	service := webhooks.New(database.NewMemoryWebhookSubscriptionRepository(), database.NewMemoryWebhookDeliveryRepository(), nil)
+	sub, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "https://acme.example.com/hooks"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	record := func(ago time.Duration, latency time.Duration, err error) {
+		delivery := models.NewWebhookDelivery(sub.SubscriptionID, now.Add(-ago), latency)
+		delivery.EventType = "payment.completed"
+		delivery.Success = err == nil
+		if err != nil {
+			delivery.StatusCode = http.StatusServiceUnavailable
+			delivery.Error = err.Error()
+		}
+		require.NoError(t, service.RecordDelivery(ctx, delivery))
+	}
+	record(30*time.Hour, 9*time.Second, errors.New("too old to count"))
+	record(3*time.Hour, 120*time.Millisecond, nil)
+	record(2*time.Hour, 2*time.Second, errors.New("webhook request failed with status: 503"))
+	record(time.Hour, 80*time.Millisecond, nil)
+	record(time.Minute, 100*time.Millisecond, nil)
+
+	h := &Handler{cfg: &config.Config{}, webhooks: service}
+
+	resp, err := h.route(ctx, webhookStatsRequest("key_acme", sub.SubscriptionID, ""))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, resp.Body)
+
+	var stats models.WebhookDeliveryStats
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &stats))
+	assert.Equal(t, 4, stats.Deliveries, "attempts before the window are left out")
+	assert.Equal(t, 3, stats.Succeeded)
+	assert.Equal(t, 1, stats.Failed)
+	assert.InDelta(t, 0.75, stats.SuccessRate, 1e-9)
+	assert.Equal(t, int64(2000), stats.P95LatencyMs)
+	require.Len(t, stats.RecentFailures, 1)
+	assert.Equal(t, http.StatusServiceUnavailable, stats.RecentFailures[0].StatusCode)
+
+	resp, err = h.route(ctx, webhookStatsRequest("key_acme", sub.SubscriptionID, "48"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(resp.Body), &stats))
+	assert.Equal(t, 5, stats.Deliveries)
+	assert.Equal(t, "too old to count", stats.RecentFailures[1].Error, "recent failures are newest first")
+
+	resp, err = h.route(ctx, webhookStatsRequest("key_other", sub.SubscriptionID, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "another customer's endpoint can't be inspected")
+
+	resp, err = h.route(ctx, webhookStatsRequest("key_acme", sub.SubscriptionID, "1000"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "the window can't exceed delivery retention")
+
+	resp, err = h.route(ctx, webhookStatsRequest("", sub.SubscriptionID, ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,140 @@
+package apikeys
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// GatewayAuthenticator resolves an API Gateway key to its ID, returning an error for a key that isn't valid
+type GatewayAuthenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (string, error)
+}
+
+// CustomerLookup returns the customer calling with an API key, or nil if it has no customer record
+type CustomerLookup interface {
+	Lookup(ctx context.Context, customerID string) (*models.Customer, error)
+}
+
+// Caller is who an API key identifies
+type Caller struct {
+	ID      string // The issued key's customer, or the API Gateway key's ID; payments are scoped to it
+	KeyHash string // The issued key's hash, to check it again later; empty for API Gateway keys
+}
+
+// Callers authenticates clients of the endpoints served outside the REST API's handler, payment streams
+// and the WebSocket API, as the API handler and API Gateway authenticate REST calls: issued keys act as
+// their customer, API Gateway keys as themselves, and either is refused outside its customer's IP allowlist.
+type Callers struct {
+	keys      *Service             // nil unless the API issues keys
+	gateway   GatewayAuthenticator // nil to accept issued keys only
+	customers CustomerLookup       // nil unless customer records, and so IP allowlists, are enabled
+}
+
+// NewCallers creates a caller authenticator; any of its arguments may be nil
+func NewCallers(keys *Service, gateway GatewayAuthenticator, customers CustomerLookup) *Callers {
+	return &Callers{keys: keys, gateway: gateway, customers: customers}
+}
+
+// LoadCallers creates the caller authenticator for the configured deployment: API Gateway's keys, the keys the
+// API issues when API_KEYS_ENABLED is set, and customers' IP allowlists when CUSTOMERS_ENABLED is set
+func LoadCallers(ctx context.Context, cfg *config.Config) (*Callers, error) {
+	gateway, err := NewGatewayKeys(cfg.AWS.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys *Service
+	if cfg.APIKeys.Enabled {
+		store, err := database.NewAPIKeyRepository(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		keys = New(store, cfg.APIKeys.RotationGrace)
+	}
+
+	var lookup CustomerLookup
+	if cfg.Customers.Enabled {
+		store, err := database.NewCustomerRepository(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		lookup = customers.New(store)
+	}
+
+	return NewCallers(keys, gateway, lookup), nil
+}
+
+// Authenticate returns the caller using apiKey from sourceIP
+// Keys that aren't valid are refused with an *errors.AppError, as are callers outside their IP allowlist;
+// other errors mean the key couldn't be checked.
+func (c *Callers) Authenticate(ctx context.Context, apiKey, sourceIP string) (*Caller, error) {
+	caller, err := c.identify(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.customers != nil && sourceIP != "" {
+		customer, err := c.customers.Lookup(ctx, caller.ID)
+		if err != nil {
+			return nil, err
+		}
+		if customer != nil {
+			if appErr := customers.CheckSourceIP(customer, sourceIP); appErr != nil {
+				logger.Warn("Refused connection from outside the API key's IP allowlist", logger.Fields{
+					"customer_id": customer.CustomerID,
+					"source_ip":   sourceIP,
+				})
+				metrics.Count("IPAllowlistRejections", metrics.Dimensions{})
+				return nil, appErr
+			}
+		}
+	}
+	return caller, nil
+}
+
+// identify resolves an API key to its caller: keys with the issued keys' prefix against the issued keys,
+// any other against API Gateway's
+func (c *Callers) identify(ctx context.Context, apiKey string) (*Caller, error) {
+	if c.keys != nil && strings.HasPrefix(apiKey, models.APIKeySecretPrefix) {
+		key, err := c.keys.Authenticate(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			metrics.Count("APIKeyRejections", metrics.Dimensions{})
+			return nil, errors.New("UNAUTHORIZED", "The API key is invalid, expired or revoked", http.StatusUnauthorized, nil)
+		}
+		return &Caller{ID: key.CustomerID, KeyHash: key.KeyHash}, nil
+	}
+
+	if c.gateway == nil {
+		return nil, errors.New("UNAUTHORIZED", "The API key is invalid, expired or revoked", http.StatusUnauthorized, nil)
+	}
+	keyID, err := c.gateway.Authenticate(ctx, apiKey)
+	if err != nil {
+		return nil, errors.New("FORBIDDEN", "The API key is not valid", http.StatusForbidden, err)
+	}
+	return &Caller{ID: keyID}, nil
+}
+
+// Active reports whether an authenticated caller's key is still usable
+// API Gateway keys can't be revoked by their customer, so they stay active.
+func (c *Callers) Active(ctx context.Context, caller *Caller) (bool, error) {
+	if caller.KeyHash == "" || c.keys == nil {
+		return true, nil
+	}
+	key, err := c.keys.Active(ctx, caller.KeyHash)
+	if err != nil {
+		return false, err
+	}
+	return key != nil, nil
+}
@@ -0,0 +1,78 @@
+package apikeys
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigateway"
+	"crypto-conversion/internal/tracing"
+)
+
+// gatewayKeysTTL is how long the API Gateway keys are cached; a new or disabled key takes effect within it
+const gatewayKeysTTL = 5 * time.Minute
+
+// GatewayKeys authenticates clients with the API Gateway keys the rest of the API accepts
+// Streams are served from a Lambda function URL and WebSocket connections through an authorizer, both outside
+// the REST API's key check, so the key is checked here.
+type GatewayKeys struct {
+	svc *apigateway.APIGateway
+
+	mu       sync.Mutex
+	keys     map[string]string // Key value -> key ID, enabled keys only
+	loadedAt time.Time
+}
+
+// NewGatewayKeys creates an API Gateway key authenticator
+func NewGatewayKeys(region string) (*GatewayKeys, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayKeys{svc: apigateway.New(tracing.AWSSession(sess))}, nil
+}
+
+// Authenticate returns the ID of an enabled API Gateway key
+func (g *GatewayKeys) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.keys == nil || time.Since(g.loadedAt) > gatewayKeysTTL {
+		keys, err := g.load(ctx)
+		if err != nil {
+			return "", err
+		}
+		g.keys, g.loadedAt = keys, time.Now()
+	}
+
+	id, ok := g.keys[apiKey]
+	if !ok {
+		return "", fmt.Errorf("unknown API key")
+	}
+	return id, nil
+}
+
+// load lists every enabled API Gateway key with its value
+func (g *GatewayKeys) load(ctx context.Context) (map[string]string, error) {
+	keys := make(map[string]string)
+	err := g.svc.GetApiKeysPagesWithContext(ctx, &apigateway.GetApiKeysInput{
+		IncludeValues: aws.Bool(true),
+	}, func(page *apigateway.GetApiKeysOutput, lastPage bool) bool {
+		for _, key := range page.Items {
+			if aws.BoolValue(key.Enabled) {
+				keys[aws.StringValue(key.Value)] = aws.StringValue(key.Id)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,189 @@
+// Package apikeys issues, rotates and revokes the API keys customers call with
+// Keys are stored only as the SHA-256 hash of their secret, so a leaked table can't be used to call the API.
+// A rotated key keeps working for a grace period, so the customer can deploy its replacement first.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/google/uuid"
+)
+
+// keyIDPrefix marks API key IDs
+const keyIDPrefix = "key_"
+
+// secretBytes is the length of a generated secret, before hex encoding
+const secretBytes = 32
+
+// prefixLength is how much of the secret is kept in the clear to tell keys apart, e.g. sk_1a2b3c4d
+const prefixLength = len(models.APIKeySecretPrefix) + 8
+
+// lastUsedInterval is how stale a key's last use may get before a request records it again,
+// so busy keys don't write on every request
+const lastUsedInterval = time.Minute
+
+// Service manages the API keys issued to customers
+type Service struct {
+	store         database.APIKeyRepository
+	rotationGrace time.Duration
+}
+
+// New creates an API key service backed by store; rotated keys keep working for rotationGrace
+func New(store database.APIKeyRepository, rotationGrace time.Duration) *Service {
+	return &Service{store: store, rotationGrace: rotationGrace}
+}
+
+// Issue creates a key for a customer, returning its secret
+func (s *Service) Issue(ctx context.Context, customerID string, req *models.APIKeyRequest) (*models.IssuedAPIKey, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+	return s.issue(ctx, customerID, req.Name, time.Now().UTC())
+}
+
+// issue generates and stores a new key
+func (s *Service) issue(ctx context.Context, customerID, name string, now time.Time) (*models.IssuedAPIKey, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to generate API key", err)
+	}
+
+	key := &models.APIKey{
+		KeyID:      keyIDPrefix + uuid.New().String(),
+		KeyHash:    Hash(secret),
+		Prefix:     secret[:prefixLength],
+		CustomerID: customerID,
+		Name:       name,
+		CreatedAt:  now,
+	}
+	if err := s.store.CreateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return &models.IssuedAPIKey{APIKey: key, Status: key.Status(now), Secret: secret}, nil
+}
+
+// List returns a customer's keys with their status, oldest first
+func (s *Service) List(ctx context.Context, customerID string) ([]models.APIKeyView, error) {
+	keys, err := s.store.ListAPIKeys(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	views := make([]models.APIKeyView, 0, len(keys))
+	for _, key := range keys {
+		views = append(views, models.APIKeyView{APIKey: key, Status: key.Status(now)})
+	}
+	return views, nil
+}
+
+// Get retrieves one of a customer's keys
+// Another customer's key is reported as not found, so IDs can't be probed.
+func (s *Service) Get(ctx context.Context, customerID, keyID string) (*models.APIKey, error) {
+	keys, err := s.store.ListAPIKeys(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.KeyID == keyID {
+			return key, nil
+		}
+	}
+	return nil, errors.ErrAPIKeyNotFound(keyID)
+}
+
+// Rotate issues a replacement for one of a customer's keys, with the same name
+// The old key keeps working for the rotation grace period and is then refused. A key that was already
+// rotated, expired or revoked can't be rotated; the customer issues a new one instead.
+func (s *Service) Rotate(ctx context.Context, customerID, keyID string) (*models.IssuedAPIKey, error) {
+	key, err := s.Get(ctx, customerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if status := key.Status(now); status != models.APIKeyStatusActive {
+		return nil, errors.ErrConflict(fmt.Sprintf("API key %s is %s and can't be rotated", keyID, status))
+	}
+
+	replacement, err := s.issue(ctx, customerID, key.Name, now)
+	if err != nil {
+		return nil, err
+	}
+	expires := now.Add(s.rotationGrace)
+	key.ExpiresAt = &expires
+	key.RotatedTo = replacement.KeyID
+	if err := s.store.UpdateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return replacement, nil
+}
+
+// Revoke refuses one of a customer's keys from now on; revoking a revoked key changes nothing
+func (s *Service) Revoke(ctx context.Context, customerID, keyID string) (*models.APIKeyView, error) {
+	key, err := s.Get(ctx, customerID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if key.RevokedAt == nil {
+		key.RevokedAt = &now
+		if err := s.store.UpdateAPIKey(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	return &models.APIKeyView{APIKey: key, Status: key.Status(now)}, nil
+}
+
+// Authenticate returns the usable key with the given secret, or nil if there is none
+// The key's last use is recorded at most once a minute; failing to record it doesn't fail the request.
+func (s *Service) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	return s.Active(ctx, Hash(secret))
+}
+
+// Active returns the usable key with the given hash, or nil if there is none, recording its use as Authenticate does
+// Connections that outlive a request check their key again with it, so revoking the key cuts them off.
+func (s *Service) Active(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := s.store.GetAPIKey(ctx, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if key == nil || !key.Usable(now) {
+		return nil, nil
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedInterval {
+		if err := s.store.TouchAPIKey(ctx, key.KeyHash, now); err != nil {
+			logger.Warn("Failed to record API key use", logger.Fields{"key_id": key.KeyID, "error": err.Error()})
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// Hash returns the hex SHA-256 of a secret, the form keys are stored and looked up in
+// The secrets are random, so an unsalted fast hash is enough: there's no dictionary to search.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret generates a random API key secret
+func newSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return models.APIKeySecretPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// Client stores expired payment records as JSON objects in S3
+type Client struct {
+	svc    *s3.S3
+	bucket string
+	prefix string
+}
+
+// NewClient creates a new S3 archive client
+func NewClient(region, bucket, prefix string) (*Client, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		svc:    s3.New(tracing.AWSSession(sess)),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// key returns the object key for a payment
+// Keys are flat by payment ID so archived records can be fetched directly
+func (c *Client) key(paymentID string) string {
+	return fmt.Sprintf("%spayments/%s.json", c.prefix, paymentID)
+}
+
+// ArchivePayment writes a payment record to S3
+func (c *Client) ArchivePayment(ctx context.Context, payment *models.Payment) error {
+	body, err := json.Marshal(payment)
+	if err != nil {
+		return errors.ErrDatabaseOperation("archive_marshal", err)
+	}
+
+	_, err = c.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(c.bucket),
+		Key:         aws.String(c.key(payment.PaymentID)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		logger.Error("Failed to archive payment", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": payment.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("archive_put", err)
+	}
+
+	return nil
+}
+
+// GetArchivedPayment reads an archived payment record from S3
+// Returns nil, nil if the payment was never archived
+func (c *Client) GetArchivedPayment(ctx context.Context, paymentID string) (*models.Payment, error) {
+	result, err := c.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(c.key(paymentID)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, nil
+		}
+		logger.Error("Failed to read archived payment", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return nil, errors.ErrDatabaseOperation("archive_get", err)
+	}
+	defer result.Body.Close()
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, errors.ErrDatabaseOperation("archive_read", err)
+	}
+
+	var payment models.Payment
+	if err := json.Unmarshal(body, &payment); err != nil {
+		return nil, errors.ErrDatabaseOperation("archive_unmarshal", err)
+	}
+
+	return &payment, nil
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+)
+
+// Audited actions
+const (
+	ActionPaymentCreated      = "payment.created"
+	ActionPaymentStateChanged = "payment.state_changed"
+	ActionConfigLoaded        = "config.loaded"
+	ActionIPAllowlistUpdated  = "customer.ip_allowlist_updated"
+	ActionAPIKeyIssued        = "customer.api_key_issued"
+	ActionAPIKeyRotated       = "customer.api_key_rotated"
+	ActionAPIKeyRevoked       = "customer.api_key_revoked"
+
+	// adminActionPrefix namespaces manual operator actions, e.g. admin.force_transition
+	adminActionPrefix = "admin."
+)
+
+// Resource types
+const (
+	ResourcePayment     = "payment"
+	ResourceConfig      = "config"
+	ResourceFeeSchedule = "fee_schedule"
+	ResourceTreasury    = "treasury"
+	ResourceBreak       = "reconciliation_break"
+	ResourceCustomer    = "customer"
+	ResourceAMLAlert    = "aml_alert"
+	ResourceWebhook     = "webhook_subscription"
+)
+
+// maxAppendAttempts bounds retries when concurrent writers race for the next sequence
+const maxAppendAttempts = 10
+
+// Every writer appends to one chain, so losers back off with full jitter before re-reading
+// its head; otherwise a burst of transitions keeps colliding on the same sequence.
+const (
+	appendInitialBackoff = 5 * time.Millisecond
+	appendMaxBackoff     = 250 * time.Millisecond
+)
+
+// Event describes something to be recorded: who did what to which resource
+type Event struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Details      map[string]string
+}
+
+// Logger appends hash-chained entries to the audit log
+type Logger struct {
+	repo database.AuditRepository
+}
+
+// NewLogger creates an audit logger backed by repo
+func NewLogger(repo database.AuditRepository) *Logger {
+	return &Logger{repo: repo}
+}
+
+// Record appends an event as the next entry in the chain
+func (l *Logger) Record(ctx context.Context, event Event) (*models.AuditEntry, error) {
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		latest, err := l.repo.GetLatestAuditEntry(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &models.AuditEntry{
+			Sequence:     1,
+			EntryID:      uuid.New().String(),
+			Timestamp:    time.Now().UTC().Truncate(time.Microsecond),
+			Actor:        event.Actor,
+			Action:       event.Action,
+			ResourceType: event.ResourceType,
+			ResourceID:   event.ResourceID,
+			Details:      event.Details,
+		}
+		if latest != nil {
+			entry.Sequence = latest.Sequence + 1
+			entry.PrevHash = latest.Hash
+		}
+		entry.Hash = Hash(entry)
+
+		err = l.repo.AppendAuditEntry(ctx, entry)
+		if err == nil {
+			return entry, nil
+		}
+		if !isConflict(err) {
+			return nil, err
+		}
+		// Another writer took this sequence; back off, then re-read the head of the chain and retry
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.ErrConflict(fmt.Sprintf("Audit append lost the race %d times", maxAppendAttempts))
+}
+
+// RecordAdminAction records a manual operator action such as a forced state transition
+func (l *Logger) RecordAdminAction(ctx context.Context, actor, action, resourceType, resourceID string, details map[string]string) (*models.AuditEntry, error) {
+	return l.Record(ctx, Event{
+		Actor:        actor,
+		Action:       adminActionPrefix + action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Details:      details,
+	})
+}
+
+// RecordConfigLoaded records the effective configuration a service started with
+// Comparing consecutive config.loaded entries for a service shows when its configuration changed.
+func (l *Logger) RecordConfigLoaded(ctx context.Context, service string, settings map[string]string) (*models.AuditEntry, error) {
+	return l.Record(ctx, Event{
+		Actor:        service,
+		Action:       ActionConfigLoaded,
+		ResourceType: ResourceConfig,
+		ResourceID:   service,
+		Details:      settings,
+	})
+}
+
+// List returns up to limit entries after the given sequence
+func (l *Logger) List(ctx context.Context, afterSequence int64, limit int) ([]*models.AuditEntry, error) {
+	return l.repo.ListAuditEntries(ctx, afterSequence, limit)
+}
+
+// hashInput is the canonical form hashed for each entry
+// Timestamps are formatted in UTC so the hash survives storage round-trips.
+type hashInput struct {
+	Sequence     int64             `json:"sequence"`
+	EntryID      string            `json:"entry_id"`
+	Timestamp    string            `json:"timestamp"`
+	Actor        string            `json:"actor"`
+	Action       string            `json:"action"`
+	ResourceType string            `json:"resource_type"`
+	ResourceID   string            `json:"resource_id"`
+	Details      map[string]string `json:"details"`
+	PrevHash     string            `json:"prev_hash"`
+}
+
+// Hash computes the SHA-256 chain hash of an entry, covering every field except Hash itself
+func Hash(entry *models.AuditEntry) string {
+	// Stores drop empty maps, so nil and empty details must hash the same
+	details := entry.Details
+	if len(details) == 0 {
+		details = nil
+	}
+
+	data, _ := json.Marshal(hashInput{
+		Sequence:     entry.Sequence,
+		EntryID:      entry.EntryID,
+		Timestamp:    entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		Actor:        entry.Actor,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		Details:      details,
+		PrevHash:     entry.PrevHash,
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks that each entry's hash matches its contents and links to the entry before it
+// Entries must be consecutive and in sequence order, as returned by List.
+func Verify(entries []*models.AuditEntry) error {
+	for i, entry := range entries {
+		if Hash(entry) != entry.Hash {
+			return fmt.Errorf("entry %d: hash does not match contents", entry.Sequence)
+		}
+
+		if i == 0 {
+			if entry.Sequence == 1 && entry.PrevHash != "" {
+				return fmt.Errorf("entry 1: first entry has a previous hash")
+			}
+			continue
+		}
+
+		prev := entries[i-1]
+		if entry.Sequence != prev.Sequence+1 {
+			return fmt.Errorf("entry %d: sequence gap after entry %d", entry.Sequence, prev.Sequence)
+		}
+		if entry.PrevHash != prev.Hash {
+			return fmt.Errorf("entry %d: previous hash does not match entry %d", entry.Sequence, prev.Sequence)
+		}
+	}
+
+	return nil
+}
+
+// sleepBackoff waits a jittered, exponentially growing delay before the next append attempt
+func sleepBackoff(ctx context.Context, attempt int) error {
+	window := appendInitialBackoff << attempt
+	if window > appendMaxBackoff {
+		window = appendMaxBackoff
+	}
+
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(window) + 1)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isConflict reports whether err is a lost race for the next sequence
+func isConflict(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Code == "CONFLICT"
+}
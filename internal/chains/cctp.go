@@ -0,0 +1,21 @@
+package chains
+
+import "strings"
+
+// cctpDomains are Circle's CCTP domain identifiers for the chains we settle on
+// USDC burned on one domain is minted on another once Circle attests to the burn.
+var cctpDomains = map[string]uint32{
+	"ethereum":  0,
+	"avalanche": 1,
+	"optimism":  2,
+	"arbitrum":  3,
+	"solana":    5,
+	"base":      6,
+	"polygon":   7,
+}
+
+// CCTPDomain returns the chain's CCTP domain, and false if CCTP can't bridge USDC on it
+func CCTPDomain(chain string) (uint32, bool) {
+	domain, ok := cctpDomains[strings.ToLower(chain)]
+	return domain, ok
+}
@@ -0,0 +1,225 @@
+package chains
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/models"
+)
+
+// Health levels, from the chain's score
+const (
+	LevelHealthy   = "healthy"   // Score of at least 75
+	LevelCongested = "congested" // Score of at least 40; usable, but slower or pricier than usual
+	LevelDegraded  = "degraded"  // Avoid unless every chain is degraded
+	LevelUnknown   = "unknown"   // No signals to score
+)
+
+// Provider statuses for a chain, as reported by the providers that mint and redeem USDC on it
+const (
+	ProviderOperational = "operational"
+	ProviderDegraded    = "degraded"
+	ProviderOutage      = "outage"
+)
+
+// Component weights; a missing signal's weight is spread over the others
+const (
+	gasWeight        = 0.40
+	settlementWeight = 0.35
+	providerWeight   = 0.25
+)
+
+// Scoring thresholds
+const (
+	gasPressureFloor    = 1.25 // Gas at up to 1.25x its baseline scores full marks
+	gasPressureCeiling  = 3.0  // Gas at 3x its baseline or more scores zero
+	latencyCeilingRatio = 3.0  // A median settlement at 3x the expected time scores zero
+	minSettlements      = 3    // Fewer recent settlements than this aren't scored
+)
+
+// expectedSettlement is the typical creation-to-completion time of a payment over each chain
+// Chains not listed use defaultExpectedSettlement.
+var expectedSettlement = map[string]time.Duration{
+	"base":      4 * time.Minute,
+	"polygon":   5 * time.Minute,
+	"arbitrum":  4 * time.Minute,
+	"optimism":  4 * time.Minute,
+	"solana":    3 * time.Minute,
+	"avalanche": 3 * time.Minute,
+	"ethereum":  8 * time.Minute,
+}
+
+const defaultExpectedSettlement = 5 * time.Minute
+
+// GasSignal is a chain's current gas price and what it normally costs, in the chain's own unit
+type GasSignal struct {
+	Standard float64
+	Fast     float64 // 0 when the oracle reports a single price
+	Baseline float64 // 24h average standard price; 0 without gas history
+}
+
+// pressure is how far above normal gas is: current over baseline when history exists,
+// else the spread between fast and standard percentiles, which widens as blocks fill
+func (g *GasSignal) pressure() (float64, bool) {
+	switch {
+	case g.Standard <= 0:
+		return 0, false
+	case g.Baseline > 0:
+		return g.Standard / g.Baseline, true
+	case g.Fast > 0:
+		return g.Fast / g.Standard, true
+	}
+	return 0, false
+}
+
+// Signals are the live inputs for one chain, gathered by the caller
+type Signals struct {
+	Gas            *GasSignal // nil without a gas reading
+	ProviderStatus string     // One of the Provider* statuses; "" when unknown
+}
+
+// Health is a chain's combined health score
+type Health struct {
+	Chain            string             `json:"chain"`
+	Score            float64            `json:"score"` // 0-100, higher is healthier
+	Level            string             `json:"level"`
+	Components       map[string]float64 `json:"components,omitempty"` // Per-signal scores: "gas", "settlement", "provider"
+	Settlements      int                `json:"settlements"`          // Recent settlements scored
+	MedianSettlement string             `json:"median_settlement,omitempty"`
+	TimeoutRate      float64            `json:"timeout_rate,omitempty"`
+	Reasons          []string           `json:"reasons,omitempty"`
+}
+
+// Score combines a chain's signals and recent settlements into its health
+func Score(chain string, signals Signals, settlements []*models.Settlement) *Health {
+	health := &Health{Chain: chain, Components: make(map[string]float64)}
+	var weighted, weights float64
+
+	if signals.Gas != nil {
+		if pressure, ok := signals.Gas.pressure(); ok {
+			score := 100 * clamp(1-(pressure-gasPressureFloor)/(gasPressureCeiling-gasPressureFloor))
+			health.Components["gas"] = round(score)
+			weighted += gasWeight * score
+			weights += gasWeight
+			if score < 50 {
+				health.Reasons = append(health.Reasons, fmt.Sprintf("gas at %.1fx normal", pressure))
+			}
+		}
+	}
+
+	if len(settlements) >= minSettlements {
+		median, timeoutRate := settlementStats(settlements)
+		expected, ok := expectedSettlement[chain]
+		if !ok {
+			expected = defaultExpectedSettlement
+		}
+		ratio := float64(median) / float64(expected)
+		score := 100 * clamp(1-(ratio-1)/(latencyCeilingRatio-1)) * (1 - timeoutRate)
+
+		health.Settlements = len(settlements)
+		health.MedianSettlement = median.Round(time.Second).String()
+		health.TimeoutRate = round(timeoutRate)
+		health.Components["settlement"] = round(score)
+		weighted += settlementWeight * score
+		weights += settlementWeight
+		if ratio > 1.5 {
+			health.Reasons = append(health.Reasons, fmt.Sprintf("settlements taking %s (expected %s)", health.MedianSettlement, expected))
+		}
+		if timeoutRate > 0 {
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%.0f%% of recent settlements timed out", timeoutRate*100))
+		}
+	}
+
+	if score, ok := providerScores[signals.ProviderStatus]; ok {
+		health.Components["provider"] = score
+		weighted += providerWeight * score
+		weights += providerWeight
+		if signals.ProviderStatus != ProviderOperational {
+			health.Reasons = append(health.Reasons, "provider "+signals.ProviderStatus)
+		}
+	}
+
+	if weights == 0 {
+		health.Level = LevelUnknown
+		return health
+	}
+	health.Score = round(weighted / weights)
+	health.Level = level(health.Score)
+
+	// An outage blocks settlement however good the other signals look
+	if signals.ProviderStatus == ProviderOutage {
+		health.Level = LevelDegraded
+	}
+	return health
+}
+
+// providerScores maps a provider status to its component score
+var providerScores = map[string]float64{
+	ProviderOperational: 100,
+	ProviderDegraded:    50,
+	ProviderOutage:      0,
+}
+
+// settlementStats returns the median latency of completed settlements and the share that timed out
+func settlementStats(settlements []*models.Settlement) (time.Duration, float64) {
+	var latencies []time.Duration
+	timedOut := 0
+	for _, s := range settlements {
+		if s.Status == models.StatusTimedOut {
+			timedOut++
+			continue
+		}
+		latencies = append(latencies, s.Latency())
+	}
+	timeoutRate := float64(timedOut) / float64(len(settlements))
+	if len(latencies) == 0 {
+		// Every recent settlement timed out
+		return 0, timeoutRate
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies[len(latencies)/2], timeoutRate
+}
+
+func level(score float64) string {
+	switch {
+	case score >= 75:
+		return LevelHealthy
+	case score >= 40:
+		return LevelCongested
+	default:
+		return LevelDegraded
+	}
+}
+
+// Best returns the healthiest of candidates, preferring earlier candidates on a tie
+// Chains of unknown health rank below scored usable chains but above degraded ones.
+// Returns "" when candidates is empty.
+func Best(healths map[string]*Health, candidates []string) string {
+	best := ""
+	bestScore := math.Inf(-1)
+	for _, chain := range candidates {
+		score := -0.5 // Unknown health ranks below any scored chain
+		if health, ok := healths[chain]; ok && health.Level != LevelUnknown {
+			score = health.Score
+			if health.Level == LevelDegraded {
+				score -= 100 // Below any usable chain, whatever its score
+			}
+		}
+		if score > bestScore {
+			best, bestScore = chain, score
+		}
+	}
+	return best
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// round keeps scores to two decimals so they read cleanly in prompts and responses
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,92 @@
+package chains
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// Monitor defaults
+const (
+	DefaultSettlementWindow = time.Hour       // How far back settlements are scored
+	DefaultCacheDuration    = 2 * time.Minute // How long settlements are reused before re-reading storage
+)
+
+// SettlementSource lists our recent settlements
+// Satisfied by database.PaymentRepository.
+type SettlementSource interface {
+	ListSettlements(ctx context.Context, since time.Time) ([]*models.Settlement, error)
+}
+
+// Monitor scores chain health from live signals and our own recent settlements
+// Settlements are read at most once per cache duration, so scoring on every fee request doesn't
+// scan the payments table each time.
+type Monitor struct {
+	settlements   SettlementSource // Optional; chains are scored on live signals alone without it
+	window        time.Duration
+	cacheDuration time.Duration
+
+	mu        sync.Mutex
+	byChain   map[string][]*models.Settlement
+	fetchedAt time.Time // Zero until the first read
+	now       func() time.Time
+}
+
+// NewMonitor creates a chain health monitor
+// settlements may be nil to score on gas and provider status alone.
+func NewMonitor(settlements SettlementSource) *Monitor {
+	return &Monitor{
+		settlements:   settlements,
+		window:        DefaultSettlementWindow,
+		cacheDuration: DefaultCacheDuration,
+		now:           time.Now,
+	}
+}
+
+// Health scores each chain in signals
+// A failure to read settlements is logged and the chains are scored without them.
+func (m *Monitor) Health(ctx context.Context, signals map[string]Signals) map[string]*Health {
+	settlements := m.recentSettlements(ctx)
+
+	healths := make(map[string]*Health, len(signals))
+	for chain, s := range signals {
+		healths[chain] = Score(chain, s, settlements[chain])
+	}
+	return healths
+}
+
+// recentSettlements returns settlements within the window, grouped by chain
+func (m *Monitor) recentSettlements(ctx context.Context) map[string][]*models.Settlement {
+	if m.settlements == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if !m.fetchedAt.IsZero() && now.Sub(m.fetchedAt) < m.cacheDuration {
+		return m.byChain
+	}
+
+	list, err := m.settlements.ListSettlements(ctx, now.Add(-m.window))
+	if err != nil {
+		logger.Warn("Failed to load recent settlements for chain health", logger.Fields{"error": err.Error()})
+		// Keep serving the last settlements, and wait out the cache duration before retrying
+		m.fetchedAt = now
+		return m.byChain
+	}
+
+	byChain := make(map[string][]*models.Settlement)
+	for _, s := range list {
+		chain := strings.ToLower(s.Chain)
+		byChain[chain] = append(byChain[chain], s)
+	}
+	m.byChain = byChain
+	m.fetchedAt = now
+	return byChain
+}
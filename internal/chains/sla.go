@@ -0,0 +1,52 @@
+package chains
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultSettlementSLAs are the settlement times promised per chain, from on-ramp initiation to payout settlement
+// They allow for the chain's finality on top of typical on- and off-ramp processing.
+var defaultSettlementSLAs = map[string]time.Duration{
+	"solana":    10 * time.Minute,
+	"base":      15 * time.Minute,
+	"polygon":   15 * time.Minute,
+	"arbitrum":  15 * time.Minute,
+	"optimism":  15 * time.Minute,
+	"avalanche": 15 * time.Minute,
+	"ethereum":  30 * time.Minute,
+}
+
+// DefaultSettlementSLA is promised on a chain without its own SLA, or when the chain isn't known yet
+const DefaultSettlementSLA = 30 * time.Minute
+
+// SLAPolicy decides the settlement time promised to a payment
+type SLAPolicy struct {
+	Default time.Duration
+	Chains  map[string]time.Duration // Overrides of the built-in per-chain SLAs
+}
+
+// SettlementSLA returns the settlement time promised for USDC minted on chain
+// A payment bridged over CCTP to offRampChain is promised the time of both chains.
+func (p SLAPolicy) SettlementSLA(chain, offRampChain string) time.Duration {
+	sla := p.chainSLA(chain)
+	if offRampChain != "" && !strings.EqualFold(offRampChain, chain) {
+		sla += p.chainSLA(offRampChain)
+	}
+	return sla
+}
+
+// chainSLA returns the settlement time promised on one chain
+func (p SLAPolicy) chainSLA(chain string) time.Duration {
+	chain = strings.ToLower(chain)
+	if sla, ok := p.Chains[chain]; ok {
+		return sla
+	}
+	if sla, ok := defaultSettlementSLAs[chain]; ok {
+		return sla
+	}
+	if p.Default > 0 {
+		return p.Default
+	}
+	return DefaultSettlementSLA
+}
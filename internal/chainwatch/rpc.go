@@ -0,0 +1,184 @@
+package chainwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rpcTimeout bounds each JSON-RPC request
+const rpcTimeout = 10 * time.Second
+
+// rpcClient posts JSON-RPC 2.0 requests to a chain node
+type rpcClient struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func newRPCClient(name, url string) rpcClient {
+	return rpcClient{name: name, url: url, client: &http.Client{Timeout: rpcTimeout}}
+}
+
+// rpcError is a JSON-RPC error member
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// call invokes method and decodes its result into result
+func (c rpcClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s RPC request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s RPC returned status %d: %s", c.name, resp.StatusCode, string(respBody))
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s %s error %d: %s", c.name, method, response.Error.Code, response.Error.Message)
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// EVMClient reads receipts from an EVM chain's JSON-RPC node
+type EVMClient struct {
+	rpc rpcClient
+}
+
+// NewEVMClient creates a receipt reader for an EVM chain
+func NewEVMClient(chain, rpcURL string) *EVMClient {
+	return &EVMClient{rpc: newRPCClient(chain, rpcURL)}
+}
+
+// Receipt reads the transaction's receipt, the chain head and the finalized block
+// Nodes that don't support the finalized block tag report nothing as finalized.
+func (c *EVMClient) Receipt(ctx context.Context, txHash string) (*Receipt, error) {
+	var receipt *struct {
+		BlockNumber string `json:"blockNumber"`
+		Status      string `json:"status"`
+	}
+	if err := c.rpc.call(ctx, "eth_getTransactionReceipt", []interface{}{txHash}, &receipt); err != nil {
+		return nil, err
+	}
+	if receipt == nil || receipt.BlockNumber == "" {
+		// Not mined yet
+		return &Receipt{}, nil
+	}
+
+	block, err := parseHexUint(receipt.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt block number: %w", err)
+	}
+
+	var head string
+	if err := c.rpc.call(ctx, "eth_blockNumber", []interface{}{}, &head); err != nil {
+		return nil, err
+	}
+	headNumber, err := parseHexUint(head)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number: %w", err)
+	}
+
+	result := &Receipt{
+		BlockNumber: block,
+		Reverted:    receipt.Status == "0x0",
+	}
+	if headNumber >= block {
+		result.Confirmations = int(headNumber-block) + 1
+	}
+
+	var finalized *struct {
+		Number string `json:"number"`
+	}
+	if err := c.rpc.call(ctx, "eth_getBlockByNumber", []interface{}{"finalized", false}, &finalized); err == nil && finalized != nil {
+		if number, err := parseHexUint(finalized.Number); err == nil {
+			result.Finalized = number >= block
+		}
+	}
+	return result, nil
+}
+
+// parseHexUint parses a 0x-prefixed quantity
+func parseHexUint(hex string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(hex, "0x"), 16, 64)
+}
+
+// solanaMaxConfirmations is the confirmation count Solana stops reporting at once a slot is rooted
+const solanaMaxConfirmations = 32
+
+// SolanaClient reads signature statuses from a Solana JSON-RPC node
+type SolanaClient struct {
+	rpc rpcClient
+}
+
+// NewSolanaClient creates a signature status reader for Solana
+func NewSolanaClient(rpcURL string) *SolanaClient {
+	return &SolanaClient{rpc: newRPCClient("solana", rpcURL)}
+}
+
+// Receipt reads the signature's status
+func (c *SolanaClient) Receipt(ctx context.Context, signature string) (*Receipt, error) {
+	var result struct {
+		Value []*struct {
+			Slot               uint64          `json:"slot"`
+			Confirmations      *int            `json:"confirmations"` // null once finalized
+			Err                json.RawMessage `json:"err"`
+			ConfirmationStatus string          `json:"confirmationStatus"`
+		} `json:"value"`
+	}
+	params := []interface{}{[]string{signature}, map[string]bool{"searchTransactionHistory": true}}
+	if err := c.rpc.call(ctx, "getSignatureStatuses", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Value) == 0 || result.Value[0] == nil {
+		// Not landed yet
+		return &Receipt{}, nil
+	}
+
+	status := result.Value[0]
+	receipt := &Receipt{
+		BlockNumber: status.Slot,
+		Finalized:   status.ConfirmationStatus == "finalized",
+		Reverted:    len(status.Err) > 0 && string(status.Err) != "null",
+	}
+	if status.Confirmations != nil {
+		receipt.Confirmations = *status.Confirmations
+	} else {
+		receipt.Confirmations = solanaMaxConfirmations
+	}
+	return receipt, nil
+}
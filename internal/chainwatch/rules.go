@@ -0,0 +1,31 @@
+package chainwatch
+
+// Rule is when a transaction on a chain is final enough for a payment to move on
+type Rule struct {
+	Confirmations int  // Blocks including the transaction's own; ignored when Finalized is set
+	Finalized     bool // Wait for the chain's own finality: the finalized block tag on EVM chains, finalized commitment on Solana
+}
+
+// DefaultRules are the finality rules for the chains we settle on
+// L2s are trusted once the sequencer has built on the transaction for a few seconds; waiting for
+// their L1 finality would add 15+ minutes to every payment.
+var DefaultRules = map[string]Rule{
+	"ethereum":  {Finalized: true},   // Two epochs, ~13 minutes
+	"polygon":   {Finalized: true},   // Milestones, a few seconds
+	"solana":    {Finalized: true},   // 32 slots, ~13 seconds
+	"base":      {Confirmations: 10}, // ~20 seconds
+	"optimism":  {Confirmations: 10}, // ~20 seconds
+	"arbitrum":  {Confirmations: 40}, // ~10 seconds
+	"avalanche": {Confirmations: 1},  // Single-block finality
+}
+
+// met reports whether a receipt satisfies the rule
+func (r Rule) met(receipt *Receipt) bool {
+	if receipt.Reverted || receipt.BlockNumber == 0 {
+		return false
+	}
+	if r.Finalized {
+		return receipt.Finalized
+	}
+	return receipt.Confirmations >= r.Confirmations
+}
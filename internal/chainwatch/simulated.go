@@ -0,0 +1,44 @@
+package chainwatch
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+)
+
+// simulatedBlocksPerCheck is how far a simulated chain advances between checks
+const simulatedBlocksPerCheck = 64
+
+// SimulatedClient is a mock chain for the mock providers' transactions
+// A transaction is included with one confirmation when first checked, and final from the second check on.
+type SimulatedClient struct {
+	mu     sync.Mutex
+	blocks map[string]uint64 // Block each transaction was included in
+	checks map[string]int
+}
+
+// NewSimulatedClient creates a simulated chain
+func NewSimulatedClient() *SimulatedClient {
+	return &SimulatedClient{
+		blocks: make(map[string]uint64),
+		checks: make(map[string]int),
+	}
+}
+
+// Receipt returns the transaction's simulated inclusion
+func (c *SimulatedClient) Receipt(ctx context.Context, txHash string) (*Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.blocks[txHash]; !ok {
+		c.blocks[txHash] = 1_000_000 + uint64(rand.Intn(1_000_000))
+	}
+	c.checks[txHash]++
+	checks := c.checks[txHash]
+
+	return &Receipt{
+		BlockNumber:   c.blocks[txHash],
+		Confirmations: 1 + (checks-1)*simulatedBlocksPerCheck,
+		Finalized:     checks > 1,
+	}, nil
+}
@@ -0,0 +1,118 @@
+// Package chainwatch checks that a payment's on-chain transactions have reached finality
+// before the payment state machine moves past them.
+package chainwatch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/models"
+)
+
+// Receipt is a transaction's inclusion as reported by a chain
+type Receipt struct {
+	BlockNumber   uint64 // 0 while the transaction isn't included yet
+	Confirmations int
+	Finalized     bool // Covered by the chain's own finality
+	Reverted      bool
+}
+
+// Client reads transaction receipts from one chain
+type Client interface {
+	Receipt(ctx context.Context, txHash string) (*Receipt, error)
+}
+
+// Watcher checks transactions against each chain's finality rule
+type Watcher struct {
+	clients map[string]Client
+	rules   map[string]Rule
+	now     func() time.Time
+}
+
+// New creates a watcher over the given chain clients
+// Chains without a rule in rules use DefaultRules, and chains without either need one confirmation.
+func New(clients map[string]Client, rules map[string]Rule) *Watcher {
+	merged := make(map[string]Rule, len(DefaultRules)+len(rules))
+	for chain, rule := range DefaultRules {
+		merged[chain] = rule
+	}
+	for chain, rule := range rules {
+		merged[strings.ToLower(chain)] = rule
+	}
+
+	normalized := make(map[string]Client, len(clients))
+	for chain, client := range clients {
+		normalized[strings.ToLower(chain)] = client
+	}
+
+	return &Watcher{clients: normalized, rules: merged, now: time.Now}
+}
+
+// NewFromConfig creates a watcher reading each chain in rpcURLs from its JSON-RPC node
+// With no RPC URLs every default chain is simulated, matching the mock providers' fake transactions.
+// confirmations replaces a chain's rule with a required confirmation count.
+func NewFromConfig(rpcURLs map[string]string, confirmations map[string]int) *Watcher {
+	rules := make(map[string]Rule, len(confirmations))
+	for chain, n := range confirmations {
+		rules[strings.ToLower(chain)] = Rule{Confirmations: n}
+	}
+
+	clients := make(map[string]Client)
+	if len(rpcURLs) == 0 {
+		for chain := range DefaultRules {
+			clients[chain] = NewSimulatedClient()
+		}
+		return New(clients, rules)
+	}
+
+	for chain, url := range rpcURLs {
+		chain = strings.ToLower(chain)
+		if chain == "solana" {
+			clients[chain] = NewSolanaClient(url)
+		} else {
+			clients[chain] = NewEVMClient(chain, url)
+		}
+	}
+	return New(clients, rules)
+}
+
+// Watches reports whether the watcher can check transactions on chain
+func (w *Watcher) Watches(chain string) bool {
+	_, ok := w.clients[strings.ToLower(chain)]
+	return ok
+}
+
+// Rule returns the finality rule transactions on chain are checked against
+func (w *Watcher) Rule(chain string) Rule {
+	if rule, ok := w.rules[strings.ToLower(chain)]; ok {
+		return rule
+	}
+	return Rule{Confirmations: 1}
+}
+
+// Check reads a transaction's confirmations and whether it meets its chain's finality rule
+// Returns nil without error for chains the watcher doesn't watch.
+func (w *Watcher) Check(ctx context.Context, chain, txHash string) (*models.ChainConfirmation, error) {
+	chain = strings.ToLower(chain)
+	client, ok := w.clients[chain]
+	if !ok {
+		return nil, nil
+	}
+
+	receipt, err := client.Receipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s transaction %s: %w", chain, txHash, err)
+	}
+
+	return &models.ChainConfirmation{
+		Chain:         chain,
+		TxHash:        txHash,
+		BlockNumber:   receipt.BlockNumber,
+		Confirmations: receipt.Confirmations,
+		Reverted:      receipt.Reverted,
+		Final:         w.Rule(chain).met(receipt),
+		CheckedAt:     w.now(),
+	}, nil
+}
@@ -0,0 +1,133 @@
+// Package chaos injects failures and delays into the payment pipeline's database, queue, provider
+// and data source calls, so the state machine's recovery paths can be exercised outside production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/logger"
+)
+
+// Point is a kind of call faults are injected into
+type Point string
+
+const (
+	PointDatabase   Point = "database"   // Payment reads, writes and processing locks
+	PointQueue      Point = "queue"      // Re-enqueued payment jobs
+	PointProvider   Point = "provider"   // On-ramp, off-ramp, bridge and wallet transfers
+	PointDataSource Point = "datasource" // Execution rates and on-chain confirmations
+)
+
+// ErrInjected is wrapped by every failure the injector causes
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Fault is what is injected into one point's calls
+type Fault struct {
+	FailureRate float64 // Chance a call fails with ErrInjected
+	DelayRate   float64 // Chance a call waits Delay before running
+	Delay       time.Duration
+}
+
+// Injector decides, call by call, whether to delay or fail it
+type Injector struct {
+	faults   map[Point]Fault
+	payments map[string]bool // Payments faults are confined to; empty for every payment
+	rng      *rand.Rand
+	mu       sync.Mutex
+}
+
+// New creates an injector applying faults to each point
+// When payments is non-empty only calls for those payment IDs are faulted; calls not made for a payment never are.
+// A zero seed picks a random sequence.
+func New(faults map[Point]Fault, payments []string, seed int64) *Injector {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	targets := make(map[string]bool, len(payments))
+	for _, id := range payments {
+		targets[id] = true
+	}
+	return &Injector{
+		faults:   faults,
+		payments: targets,
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NewFromConfig creates an injector from CHAOS_* settings, keyed by point name
+func NewFromConfig(cfg config.ChaosConfig) *Injector {
+	faults := make(map[Point]Fault)
+	for _, point := range []Point{PointDatabase, PointQueue, PointProvider, PointDataSource} {
+		fault := Fault{
+			FailureRate: cfg.FailureRates[string(point)],
+			DelayRate:   cfg.DelayRates[string(point)],
+			Delay:       cfg.Delays[string(point)],
+		}
+		if fault != (Fault{}) {
+			faults[point] = fault
+		}
+	}
+	return New(faults, cfg.Payments, cfg.Seed)
+}
+
+type paymentIDKey struct{}
+
+// WithPaymentID tags ctx with the payment its calls are made for, for calls that don't name it
+func WithPaymentID(ctx context.Context, paymentID string) context.Context {
+	return context.WithValue(ctx, paymentIDKey{}, paymentID)
+}
+
+// paymentIDFrom returns the payment ctx was tagged with, if any
+func paymentIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(paymentIDKey{}).(string)
+	return id
+}
+
+// Inject delays or fails a call at point made for paymentID, or for the payment ctx is tagged with when empty
+// It returns an error wrapping ErrInjected when the call should fail, or ctx's error if it ends during a delay.
+func (i *Injector) Inject(ctx context.Context, point Point, op, paymentID string) error {
+	if paymentID == "" {
+		paymentID = paymentIDFrom(ctx)
+	}
+	if len(i.payments) > 0 && !i.payments[paymentID] {
+		return nil
+	}
+	fault, ok := i.faults[point]
+	if !ok {
+		return nil
+	}
+
+	i.mu.Lock()
+	delay := fault.Delay > 0 && i.rng.Float64() < fault.DelayRate
+	fail := i.rng.Float64() < fault.FailureRate
+	i.mu.Unlock()
+
+	if delay {
+		logger.Warn("Chaos delay injected", logger.Fields{
+			"point":      point,
+			"op":         op,
+			"payment_id": paymentID,
+			"delay":      fault.Delay.String(),
+		})
+		select {
+		case <-time.After(fault.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fail {
+		logger.Warn("Chaos failure injected", logger.Fields{
+			"point":      point,
+			"op":         op,
+			"payment_id": paymentID,
+		})
+		return fmt.Errorf("%w: %s %s", ErrInjected, point, op)
+	}
+	return nil
+}
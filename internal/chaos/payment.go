@@ -0,0 +1,236 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+)
+
+// Database injects faults into the state machine's payment reads, writes and processing locks
+func Database(db payment.DatabaseClient, injector *Injector) payment.DatabaseClient {
+	return &chaosDatabase{db: db, injector: injector}
+}
+
+type chaosDatabase struct {
+	db       payment.DatabaseClient
+	injector *Injector
+}
+
+func (c *chaosDatabase) UpdatePayment(ctx context.Context, p *models.Payment) error {
+	if err := c.injector.Inject(ctx, PointDatabase, "UpdatePayment", p.PaymentID); err != nil {
+		return err
+	}
+	return c.db.UpdatePayment(ctx, p)
+}
+
+func (c *chaosDatabase) UpdatePaymentWithOutbox(ctx context.Context, p *models.Payment, msg *models.OutboxMessage) error {
+	if err := c.injector.Inject(ctx, PointDatabase, "UpdatePaymentWithOutbox", p.PaymentID); err != nil {
+		return err
+	}
+	return c.db.UpdatePaymentWithOutbox(ctx, p, msg)
+}
+
+func (c *chaosDatabase) GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	if err := c.injector.Inject(ctx, PointDatabase, "GetPaymentByID", paymentID); err != nil {
+		return nil, err
+	}
+	return c.db.GetPaymentByID(ctx, paymentID)
+}
+
+func (c *chaosDatabase) AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error) {
+	if err := c.injector.Inject(ctx, PointDatabase, "AcquireProcessingLock", paymentID); err != nil {
+		return false, err
+	}
+	return c.db.AcquireProcessingLock(ctx, paymentID, expectedStatus, owner, ttl)
+}
+
+func (c *chaosDatabase) ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error {
+	if err := c.injector.Inject(ctx, PointDatabase, "ReleaseProcessingLock", paymentID); err != nil {
+		return err
+	}
+	return c.db.ReleaseProcessingLock(ctx, paymentID, owner)
+}
+
+// Queue injects faults into the state machine's re-enqueued payment jobs
+func Queue(queue payment.QueueClient, injector *Injector) payment.QueueClient {
+	return &chaosQueue{queue: queue, injector: injector}
+}
+
+type chaosQueue struct {
+	queue    payment.QueueClient
+	injector *Injector
+}
+
+func (c *chaosQueue) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
+	if err := c.injector.Inject(ctx, PointQueue, "EnqueuePaymentWithDelay", job.PaymentID); err != nil {
+		return err
+	}
+	return c.queue.EnqueuePaymentWithDelay(ctx, job, delaySeconds)
+}
+
+// OnRamp injects faults into on-ramp transfers and reversals
+// Provider calls don't name their payment, so targeting payments needs ctx tagged with WithPaymentID.
+func OnRamp(provider payment.OnRampProvider, injector *Injector) payment.OnRampProvider {
+	return &chaosOnRamp{provider: provider, injector: injector}
+}
+
+type chaosOnRamp struct {
+	provider payment.OnRampProvider
+	injector *Injector
+}
+
+func (c *chaosOnRamp) InitiateTransfer(ctx context.Context, amount int64, currency string) (string, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "OnRamp.InitiateTransfer", ""); err != nil {
+		return "", err
+	}
+	return c.provider.InitiateTransfer(ctx, amount, currency)
+}
+
+func (c *chaosOnRamp) GetTransferStatus(ctx context.Context, txID string) (*payment.Transfer, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "OnRamp.GetTransferStatus", ""); err != nil {
+		return nil, err
+	}
+	return c.provider.GetTransferStatus(ctx, txID)
+}
+
+func (c *chaosOnRamp) InitiateReversal(ctx context.Context, stablecoinAmount int64, currency, sourceAccount string) (string, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "OnRamp.InitiateReversal", ""); err != nil {
+		return "", err
+	}
+	return c.provider.InitiateReversal(ctx, stablecoinAmount, currency, sourceAccount)
+}
+
+// OffRamp injects faults into off-ramp transfers
+func OffRamp(provider payment.OffRampProvider, injector *Injector) payment.OffRampProvider {
+	return &chaosOffRamp{provider: provider, injector: injector}
+}
+
+type chaosOffRamp struct {
+	provider payment.OffRampProvider
+	injector *Injector
+}
+
+func (c *chaosOffRamp) InitiateTransfer(ctx context.Context, stablecoinAmount int64, currency string) (string, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "OffRamp.InitiateTransfer", ""); err != nil {
+		return "", err
+	}
+	return c.provider.InitiateTransfer(ctx, stablecoinAmount, currency)
+}
+
+func (c *chaosOffRamp) GetTransferStatus(ctx context.Context, txID string) (*payment.Transfer, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "OffRamp.GetTransferStatus", ""); err != nil {
+		return nil, err
+	}
+	return c.provider.GetTransferStatus(ctx, txID)
+}
+
+// Bridge injects faults into CCTP bridge transfers
+func Bridge(provider payment.BridgeProvider, injector *Injector) payment.BridgeProvider {
+	return &chaosBridge{provider: provider, injector: injector}
+}
+
+type chaosBridge struct {
+	provider payment.BridgeProvider
+	injector *Injector
+}
+
+func (c *chaosBridge) InitiateTransfer(ctx context.Context, stablecoinAmount int64, sourceChain, destinationChain string) (string, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "Bridge.InitiateTransfer", ""); err != nil {
+		return "", err
+	}
+	return c.provider.InitiateTransfer(ctx, stablecoinAmount, sourceChain, destinationChain)
+}
+
+func (c *chaosBridge) GetTransferStatus(ctx context.Context, txID string) (*payment.Transfer, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "Bridge.GetTransferStatus", ""); err != nil {
+		return nil, err
+	}
+	return c.provider.GetTransferStatus(ctx, txID)
+}
+
+// Wallet injects faults into treasury wallet sends
+func Wallet(provider payment.WalletProvider, injector *Injector) payment.WalletProvider {
+	return &chaosWallet{provider: provider, injector: injector}
+}
+
+type chaosWallet struct {
+	provider payment.WalletProvider
+	injector *Injector
+}
+
+func (c *chaosWallet) InitiateTransfer(ctx context.Context, stablecoinAmount int64, chain, address string) (string, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "Wallet.InitiateTransfer", ""); err != nil {
+		return "", err
+	}
+	return c.provider.InitiateTransfer(ctx, stablecoinAmount, chain, address)
+}
+
+func (c *chaosWallet) GetTransferStatus(ctx context.Context, txID string) (*payment.Transfer, error) {
+	if err := c.injector.Inject(ctx, PointProvider, "Wallet.GetTransferStatus", ""); err != nil {
+		return nil, err
+	}
+	return c.provider.GetTransferStatus(ctx, txID)
+}
+
+// Sandbox injects faults into sandbox payments' on-ramp, off-ramp, bridge and wallet transfers
+// as the regular providers' wrappers do, so chaos runs exercise sandbox payments too.
+func Sandbox(sandbox payment.SandboxProviders, injector *Injector) payment.SandboxProviders {
+	return &chaosSandbox{sandbox: sandbox, injector: injector}
+}
+
+type chaosSandbox struct {
+	sandbox  payment.SandboxProviders
+	injector *Injector
+}
+
+func (c *chaosSandbox) OnRamp(p *models.Payment) payment.OnRampProvider {
+	return OnRamp(c.sandbox.OnRamp(p), c.injector)
+}
+
+func (c *chaosSandbox) OffRamp(p *models.Payment) payment.OffRampProvider {
+	return OffRamp(c.sandbox.OffRamp(p), c.injector)
+}
+
+func (c *chaosSandbox) Bridge() payment.BridgeProvider {
+	return Bridge(c.sandbox.Bridge(), c.injector)
+}
+
+func (c *chaosSandbox) Wallet() payment.WalletProvider {
+	return Wallet(c.sandbox.Wallet(), c.injector)
+}
+
+// RateSource injects faults into the execution rates the slippage check reads
+func RateSource(source payment.RateSource, injector *Injector) payment.RateSource {
+	return &chaosRateSource{source: source, injector: injector}
+}
+
+type chaosRateSource struct {
+	source   payment.RateSource
+	injector *Injector
+}
+
+func (c *chaosRateSource) ExecutableRate(ctx context.Context, from, to string, amount int64) (float64, error) {
+	if err := c.injector.Inject(ctx, PointDataSource, "ExecutableRate", ""); err != nil {
+		return 0, err
+	}
+	return c.source.ExecutableRate(ctx, from, to, amount)
+}
+
+// ConfirmationWatcher injects faults into on-chain finality checks
+func ConfirmationWatcher(watcher payment.ConfirmationWatcher, injector *Injector) payment.ConfirmationWatcher {
+	return &chaosWatcher{watcher: watcher, injector: injector}
+}
+
+type chaosWatcher struct {
+	watcher  payment.ConfirmationWatcher
+	injector *Injector
+}
+
+func (c *chaosWatcher) Check(ctx context.Context, chain, txHash string) (*models.ChainConfirmation, error) {
+	if err := c.injector.Inject(ctx, PointDataSource, "Check", ""); err != nil {
+		return nil, err
+	}
+	return c.watcher.Check(ctx, chain, txHash)
+}
@@ -0,0 +1,146 @@
+// Package kyc verifies customer identities with a KYC provider and gates payments on the result
+package kyc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// Provider runs identity verifications for customers
+type Provider interface {
+	// Name identifies the provider on customer records
+	Name() string
+	// Start opens a verification for the customer, returning the provider's ID for it
+	Start(ctx context.Context, customer *models.Customer) (string, error)
+	// Status returns where a verification stands, as one of the models.KYCStatus values
+	Status(ctx context.Context, inquiryID string) (string, error)
+}
+
+// NewProvider creates the configured KYC provider
+func NewProvider(cfg config.KYCConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "persona":
+		if cfg.PersonaAPIKey == "" || cfg.PersonaTemplateID == "" {
+			return nil, fmt.Errorf("the persona KYC provider needs PERSONA_API_KEY and PERSONA_TEMPLATE_ID")
+		}
+		return NewPersona(cfg.PersonaAPIURL, cfg.PersonaAPIKey, cfg.PersonaTemplateID), nil
+	case "mock":
+		return NewMockProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown KYC provider %q", cfg.Provider)
+	}
+}
+
+// Gate refuses payments above a threshold from customers who haven't passed KYC
+type Gate struct {
+	threshold int64
+}
+
+// NewGate creates a gate for payments above threshold, in minor units; 0 gates every payment
+func NewGate(threshold int64) *Gate {
+	return &Gate{threshold: threshold}
+}
+
+// Check returns why a customer may not make a payment of amount, or nil if it may
+// A caller without a customer record has never been verified.
+func (g *Gate) Check(customer *models.Customer, amount int64) *errors.AppError {
+	if amount <= g.threshold {
+		return nil
+	}
+	if customer == nil || !customer.KYC.Verified() {
+		return errors.ErrKYCRequired(g.threshold)
+	}
+	return nil
+}
+
+// Verifier starts customers' verifications and records the provider's decisions on their records
+type Verifier struct {
+	provider Provider
+	store    database.CustomerRepository
+}
+
+// NewVerifier creates a verifier that keeps verification status on the customer records in store
+func NewVerifier(provider Provider, store database.CustomerRepository) *Verifier {
+	return &Verifier{provider: provider, store: store}
+}
+
+// Start opens a verification for a customer and marks it pending
+// A customer that is verified, or whose verification is still pending, can't be started again.
+func (v *Verifier) Start(ctx context.Context, customerID string) (*models.Customer, error) {
+	customer, err := v.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	switch customer.KYC.Status {
+	case models.KYCStatusVerified:
+		return nil, errors.ErrConflict(fmt.Sprintf("Customer %s is already verified", customerID))
+	case models.KYCStatusPending:
+		return nil, errors.ErrConflict(fmt.Sprintf("Customer %s already has a verification in progress", customerID))
+	}
+
+	inquiryID, err := v.provider.Start(ctx, customer)
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to start KYC verification", err)
+	}
+
+	now := time.Now().UTC()
+	customer.KYC = models.CustomerKYC{
+		Status:    models.KYCStatusPending,
+		Provider:  v.provider.Name(),
+		InquiryID: inquiryID,
+		CheckedAt: &now,
+	}
+	customer.UpdatedAt = now
+	if err := v.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	logger.Info("KYC verification started", logger.Fields{
+		"customer_id": customerID,
+		"provider":    v.provider.Name(),
+		"inquiry_id":  inquiryID,
+	})
+	return customer, nil
+}
+
+// Sync asks the provider for a pending verification's decision and records it
+// Customers without a pending verification are returned unchanged.
+func (v *Verifier) Sync(ctx context.Context, customerID string) (*models.Customer, error) {
+	customer, err := v.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.KYC.Status != models.KYCStatusPending {
+		return customer, nil
+	}
+
+	status, err := v.provider.Status(ctx, customer.KYC.InquiryID)
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to check KYC verification", err)
+	}
+
+	now := time.Now().UTC()
+	customer.KYC.Status = status
+	customer.KYC.CheckedAt = &now
+	if status == models.KYCStatusVerified {
+		customer.KYC.VerifiedAt = &now
+	}
+	customer.UpdatedAt = now
+	if err := v.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	if status != models.KYCStatusPending {
+		logger.Info("KYC verification decided", logger.Fields{
+			"customer_id": customerID,
+			"inquiry_id":  customer.KYC.InquiryID,
+			"status":      status,
+		})
+	}
+	return customer, nil
+}
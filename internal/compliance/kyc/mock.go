@@ -0,0 +1,28 @@
+package kyc
+
+import (
+	"context"
+
+	"crypto-conversion/internal/models"
+)
+
+// MockProvider verifies every customer it is asked to, for local development and sandboxes
+type MockProvider struct{}
+
+// NewMockProvider creates a provider that approves every verification
+func NewMockProvider() *MockProvider {
+	return &MockProvider{}
+}
+
+// Name implements Provider
+func (p *MockProvider) Name() string { return "mock" }
+
+// Start implements Provider
+func (p *MockProvider) Start(ctx context.Context, customer *models.Customer) (string, error) {
+	return "mock_inq_" + customer.CustomerID, nil
+}
+
+// Status implements Provider
+func (p *MockProvider) Status(ctx context.Context, inquiryID string) (string, error) {
+	return models.KYCStatusVerified, nil
+}
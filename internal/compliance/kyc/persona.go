@@ -0,0 +1,130 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// personaVersion pins the Persona API version responses are decoded against
+const personaVersion = "2023-01-05"
+
+// Persona runs verifications as Persona inquiries
+// The customer completes the inquiry in Persona's hosted flow; Status reads back Persona's decision.
+type Persona struct {
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	templateID string
+}
+
+// NewPersona creates a Persona client that opens inquiries from templateID
+func NewPersona(baseURL, apiKey, templateID string) *Persona {
+	return &Persona{
+		client:     tracing.HTTPClient(&http.Client{Timeout: 10 * time.Second}),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		templateID: templateID,
+	}
+}
+
+// Name implements Provider
+func (p *Persona) Name() string { return "persona" }
+
+// personaInquiry is the part of a Persona inquiry response we read
+type personaInquiry struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Start implements Provider, opening an inquiry referenced by the customer ID
+func (p *Persona) Start(ctx context.Context, customer *models.Customer) (string, error) {
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{
+			"attributes": map[string]string{
+				"inquiry-template-id": p.templateID,
+				"reference-id":        customer.CustomerID,
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode inquiry: %w", err)
+	}
+
+	var inquiry personaInquiry
+	if err := p.do(ctx, http.MethodPost, "/api/v1/inquiries", body, &inquiry); err != nil {
+		return "", err
+	}
+	if inquiry.Data.ID == "" {
+		return "", fmt.Errorf("persona returned an inquiry without an ID")
+	}
+	return inquiry.Data.ID, nil
+}
+
+// Status implements Provider
+func (p *Persona) Status(ctx context.Context, inquiryID string) (string, error) {
+	var inquiry personaInquiry
+	if err := p.do(ctx, http.MethodGet, "/api/v1/inquiries/"+url.PathEscape(inquiryID), nil, &inquiry); err != nil {
+		return "", err
+	}
+	return personaStatus(inquiry.Data.Attributes.Status), nil
+}
+
+// do sends an authenticated request and decodes the JSON response into dest
+func (p *Persona) do(ctx context.Context, method, path string, body []byte, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Persona-Version", personaVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, dest); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+// personaStatus normalizes a Persona inquiry status
+// Completed inquiries still await Persona's decision; failed and expired ones can be started again.
+func personaStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "approved":
+		return models.KYCStatusVerified
+	case "declined":
+		return models.KYCStatusRejected
+	case "failed", "expired":
+		return models.KYCStatusUnverified
+	default:
+		return models.KYCStatusPending
+	}
+}
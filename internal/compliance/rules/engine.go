@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// History looks up a customer's recent payments
+type History interface {
+	ListPaymentsByCustomer(ctx context.Context, customerID string, since time.Time) ([]*models.Payment, error)
+}
+
+// Engine evaluates each payment against the monitoring rules and records an alert for every rule it trips
+type Engine struct {
+	history History
+	store   database.AMLAlertRepository
+	window  time.Duration
+	rules   []Rule
+}
+
+// NewEngine creates an engine looking back window over a customer's payments
+func NewEngine(history History, store database.AMLAlertRepository, window time.Duration, rules ...Rule) *Engine {
+	return &Engine{history: history, store: store, window: window, rules: rules}
+}
+
+// Default creates the configured structuring, velocity and high-risk country rules
+// Country risk comes from the fee engine's country risk data.
+func Default(cfg config.AMLConfig, registry *corridors.Registry) []Rule {
+	return []Rule{
+		Structuring{Threshold: cfg.StructuringThreshold, Margin: cfg.StructuringMargin, Count: cfg.StructuringCount},
+		Velocity{MaxCount: cfg.VelocityMaxCount, MaxAmount: cfg.VelocityMaxAmount},
+		HighRiskCountry{Risks: fees.NewMockDataProvider(), Corridors: registry, MinScore: cfg.HighRiskScore},
+	}
+}
+
+// Evaluate runs every rule on the payment, returning the alerts it newly raised
+// Re-evaluating a payment records nothing twice, so retries are safe.
+func (e *Engine) Evaluate(ctx context.Context, payment *models.Payment) ([]*models.AMLAlert, error) {
+	recent, err := e.history.ListPaymentsByCustomer(ctx, payment.CustomerID, payment.CreatedAt.Add(-e.window))
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*models.Payment, 0, len(recent))
+	for _, p := range recent {
+		if p.PaymentID != payment.PaymentID {
+			history = append(history, p)
+		}
+	}
+
+	var raised []*models.AMLAlert
+	for _, rule := range e.rules {
+		finding := rule.Evaluate(payment, history)
+		if finding == nil {
+			continue
+		}
+
+		alert := &models.AMLAlert{
+			AlertID:    models.AMLAlertID(payment.PaymentID, rule.Name()),
+			PaymentID:  payment.PaymentID,
+			CustomerID: payment.CustomerID,
+			Rule:       rule.Name(),
+			Severity:   finding.Severity,
+			Detail:     finding.Detail,
+			Status:     models.AMLAlertStatusOpen,
+			CreatedAt:  time.Now().UTC(),
+		}
+		recorded, err := e.store.RecordAlert(ctx, alert)
+		if err != nil {
+			return raised, err
+		}
+		if !recorded {
+			continue
+		}
+
+		raised = append(raised, alert)
+		metrics.Count("AMLAlerts", metrics.Dimensions{"Rule": alert.Rule, "Severity": alert.Severity})
+
+		// Compliance alarms on this log line via a CloudWatch metric filter
+		logger.Error("ALERT: payment flagged by AML monitoring", logger.Fields{
+			"alert":       "aml_alert",
+			"alert_id":    alert.AlertID,
+			"payment_id":  alert.PaymentID,
+			"customer_id": alert.CustomerID,
+			"rule":        alert.Rule,
+			"severity":    alert.Severity,
+			"detail":      alert.Detail,
+		})
+	}
+	return raised, nil
+}
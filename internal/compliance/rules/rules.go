@@ -0,0 +1,147 @@
+// Package rules monitors payments for money-laundering patterns, raising alerts for compliance review
+package rules
+
+import (
+	"fmt"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/money"
+)
+
+// Finding is what a rule found suspicious about a payment
+type Finding struct {
+	Severity string
+	Detail   string
+}
+
+// Rule checks a payment against the customer's other payments in the monitoring window, oldest first
+// It returns nil when the payment raises nothing.
+type Rule interface {
+	Name() string
+	Evaluate(payment *models.Payment, history []*models.Payment) *Finding
+}
+
+// Structuring flags customers splitting payments to stay just under a reporting threshold
+type Structuring struct {
+	Threshold int64   // The reporting threshold
+	Margin    float64 // Payments within this fraction below Threshold count as near it
+	Count     int     // Near-threshold payments in the window, this one included, that raise an alert
+}
+
+// Name identifies the rule on alerts
+func (r Structuring) Name() string { return models.AMLRuleStructuring }
+
+// Evaluate flags the payment if it and enough of the window's payments fall just under the threshold
+func (r Structuring) Evaluate(payment *models.Payment, history []*models.Payment) *Finding {
+	if r.Threshold <= 0 || r.Count <= 0 || !r.nearThreshold(payment.Amount) {
+		return nil
+	}
+
+	near := 1
+	for _, p := range history {
+		if r.nearThreshold(p.Amount) {
+			near++
+		}
+	}
+	if near < r.Count {
+		return nil
+	}
+	return &Finding{
+		Severity: models.AMLSeverityHigh,
+		Detail: fmt.Sprintf("%d payments between %s and %s in the window",
+			near, money.Format(r.floor(), payment.FundingCurrency()), money.Format(r.Threshold, payment.FundingCurrency())),
+	}
+}
+
+// floor is the smallest amount counted as near the threshold
+func (r Structuring) floor() int64 {
+	return r.Threshold - int64(float64(r.Threshold)*r.Margin)
+}
+
+// nearThreshold reports whether amount is just under the threshold
+func (r Structuring) nearThreshold(amount int64) bool {
+	return amount >= r.floor() && amount < r.Threshold
+}
+
+// Velocity flags customers sending more payments, or more value, in the window than the limits allow
+type Velocity struct {
+	MaxCount  int   // 0 = no count limit
+	MaxAmount int64 // 0 = no amount limit
+}
+
+// Name identifies the rule on alerts
+func (r Velocity) Name() string { return models.AMLRuleVelocity }
+
+// Evaluate flags the payment if it takes the window's count or total over a limit
+func (r Velocity) Evaluate(payment *models.Payment, history []*models.Payment) *Finding {
+	count := len(history) + 1
+	total := payment.Amount
+	for _, p := range history {
+		total += p.Amount
+	}
+
+	switch {
+	case r.MaxCount > 0 && count > r.MaxCount:
+		return &Finding{
+			Severity: models.AMLSeverityMedium,
+			Detail:   fmt.Sprintf("%d payments in the window, over the limit of %d", count, r.MaxCount),
+		}
+	case r.MaxAmount > 0 && total > r.MaxAmount:
+		return &Finding{
+			Severity: models.AMLSeverityMedium,
+			Detail: fmt.Sprintf("%s sent in the window, over the limit of %s",
+				money.Format(total, payment.FundingCurrency()), money.Format(r.MaxAmount, payment.FundingCurrency())),
+		}
+	}
+	return nil
+}
+
+// CountryRisks scores destination countries; fees.MockDataProvider is one
+type CountryRisks interface {
+	GetCountryRisk(country string) fees.CountryRisk
+}
+
+// HighRiskCountry flags payouts to countries whose risk score reaches MinScore
+// The destination is the beneficiary's country, else the payout country of the payment's corridor.
+type HighRiskCountry struct {
+	Risks     CountryRisks
+	Corridors *corridors.Registry
+	MinScore  float64
+}
+
+// Name identifies the rule on alerts
+func (r HighRiskCountry) Name() string { return models.AMLRuleHighRiskCountry }
+
+// Evaluate flags the payment if its destination country scores high-risk
+func (r HighRiskCountry) Evaluate(payment *models.Payment, history []*models.Payment) *Finding {
+	country := r.destination(payment)
+	if country == "" {
+		return nil
+	}
+
+	risk := r.Risks.GetCountryRisk(country)
+	if risk.RiskScore < r.MinScore {
+		return nil
+	}
+	return &Finding{
+		Severity: models.AMLSeverityHigh,
+		Detail:   fmt.Sprintf("Payout to %s, risk score %.1f (%s)", country, risk.RiskScore, risk.Tier),
+	}
+}
+
+// destination returns the country the payment pays out in, or "" if it can't be told
+func (r HighRiskCountry) destination(payment *models.Payment) string {
+	if payment.Beneficiary != nil && payment.Beneficiary.Country != "" {
+		return payment.Beneficiary.Country
+	}
+	if r.Corridors == nil || payment.IsWalletPayout() {
+		return ""
+	}
+	corridor, err := r.Corridors.Lookup(payment.FundingCurrency(), payment.Currency)
+	if err != nil {
+		return ""
+	}
+	return corridor.DestinationCountry
+}
@@ -0,0 +1,101 @@
+// Package sanctions screens payment counterparties against sanctions lists
+package sanctions
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"crypto-conversion/internal/models"
+)
+
+// Screener checks a payment's counterparty against sanctions lists, returning every entry it matched
+type Screener interface {
+	Screen(ctx context.Context, subject models.ScreeningSubject) ([]models.ScreeningMatch, error)
+}
+
+// List is a sanctions list held in memory: sanctioned names, addresses and countries
+// Names match regardless of case, punctuation and word order, so "KIM, Jong Un" matches "Jong-Un Kim".
+// Wallet addresses match case-insensitively; countries by ISO 3166-1 alpha-2 code.
+type List struct {
+	name      string
+	names     map[string]string // Normalized name -> list entry
+	addresses map[string]string // Lowercased address -> list entry
+	countries map[string]bool
+}
+
+// NewList creates an empty list reported on matches as name
+func NewList(name string) *List {
+	return &List{
+		name:      name,
+		names:     make(map[string]string),
+		addresses: make(map[string]string),
+		countries: make(map[string]bool),
+	}
+}
+
+// AddName adds a sanctioned person or entity
+func (l *List) AddName(name string) {
+	if key := normalizeName(name); key != "" {
+		l.names[key] = name
+	}
+}
+
+// AddAddress adds a sanctioned wallet address, recorded against the entry it belongs to
+func (l *List) AddAddress(address, entry string) {
+	if address = strings.ToLower(strings.TrimSpace(address)); address != "" {
+		l.addresses[address] = entry
+	}
+}
+
+// AddCountry adds a comprehensively sanctioned country
+func (l *List) AddCountry(code string) {
+	if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+		l.countries[code] = true
+	}
+}
+
+// Size returns how many names, addresses and countries the list holds
+func (l *List) Size() int {
+	return len(l.names) + len(l.addresses) + len(l.countries)
+}
+
+// Screen implements Screener
+func (l *List) Screen(ctx context.Context, subject models.ScreeningSubject) ([]models.ScreeningMatch, error) {
+	var matches []models.ScreeningMatch
+	if entry, ok := l.names[normalizeName(subject.Name)]; ok && subject.Name != "" {
+		matches = append(matches, models.ScreeningMatch{List: l.name, Field: models.ScreeningFieldName, Value: subject.Name, Entry: entry})
+	}
+	if code := strings.ToUpper(subject.Country); l.countries[code] {
+		matches = append(matches, models.ScreeningMatch{List: l.name, Field: models.ScreeningFieldCountry, Value: subject.Country, Entry: code})
+	}
+	if entry, ok := l.addresses[strings.ToLower(strings.TrimSpace(subject.WalletAddress))]; ok && subject.WalletAddress != "" {
+		matches = append(matches, models.ScreeningMatch{List: l.name, Field: models.ScreeningFieldAddress, Value: subject.WalletAddress, Entry: entry})
+	}
+	return matches, nil
+}
+
+// normalizeName reduces a name to its upper-cased words in sorted order
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// Load builds the screening list: the OFAC SDN list from sdnURL, if set, plus the sanctioned countries
+func Load(ctx context.Context, sdnURL string, countries []string) (*List, error) {
+	list := NewList(OFACListName)
+	if sdnURL != "" {
+		var err error
+		if list, err = LoadOFAC(ctx, sdnURL); err != nil {
+			return nil, err
+		}
+	}
+	for _, code := range countries {
+		list.AddCountry(code)
+	}
+	return list, nil
+}
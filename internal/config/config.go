@@ -4,25 +4,118 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
-	AWS        AWSConfig
-	Database   DatabaseConfig
-	Queue      QueueConfig
-	Logging    LoggingConfig
-	Anthropic  AnthropicConfig
+	AWS             AWSConfig
+	Database        DatabaseConfig
+	Encryption      EncryptionConfig
+	Queue           QueueConfig
+	Logging         LoggingConfig
+	Anthropic       AnthropicConfig
+	Polling         PollingConfig
+	Orchestration   OrchestrationConfig
+	Events          EventsConfig
+	Retention       RetentionConfig
+	Storage         StorageConfig
+	Metrics         MetricsConfig
+	Tracing         TracingConfig
+	Audit           AuditConfig
+	FeeShadow       FeeShadowConfig
+	FeeSchedules    FeeScheduleConfig
+	CustomerPricing CustomerPricingConfig
+	Promos          PromoConfig
+	VolumeDiscounts VolumeDiscountConfig
+	FeeInvoices     FeeInvoiceConfig
+	Reporting       ReportingConfig
+	GasHistory      GasHistoryConfig
+	Quotes          QuoteConfig
+	Corridors       CorridorConfig
+	Destinations    DestinationConfig
+	FX              FXConfig
+	DataSources     DataSourceConfig
+	Slippage        SlippageConfig
+	ChainWatch      ChainWatchConfig
+	Treasury        TreasuryConfig
+	Ledger          LedgerConfig
+	Reconciliation  ReconciliationConfig
+	Customers       CustomerConfig
+	APIKeys         APIKeyConfig
+	Usage           UsageConfig
+	Quotas          QuotaConfig
+	KYC             KYCConfig
+	Sanctions       SanctionsConfig
+	AML             AMLConfig
+	Batches         BatchConfig
+	StalePayments   StalePaymentConfig
+	Holds           HoldConfig
+	Sandbox         SandboxConfig
+	Chaos           ChaosConfig
+	Webhooks        WebhookConfig
+	Streams         StreamConfig
+	Sockets         SocketConfig
+	GRPC            GRPCConfig
+	Health          HealthConfig
 }
 
-// AnthropicConfig holds Anthropic API configuration
+// LLM providers for AI fee calculation
+const (
+	LLMProviderAnthropic = "anthropic"
+	LLMProviderBedrock   = "bedrock"
+	LLMProviderOpenAI    = "openai"
+)
+
+// defaultLLMModels is the model used for each provider when CLAUDE_MODEL is unset
+var defaultLLMModels = map[string]string{
+	LLMProviderAnthropic: "claude-sonnet-4-20250514",
+	LLMProviderBedrock:   "us.anthropic.claude-sonnet-4-20250514-v1:0",
+	LLMProviderOpenAI:    "gpt-4o",
+}
+
+// AnthropicConfig holds AI fee engine configuration
 type AnthropicConfig struct {
-	APIKey string
+	Provider       string // Model provider: anthropic, bedrock or openai
+	APIKey         string // Key for the selected provider; unused for bedrock
+	Model          string // Model ID for the selected provider
+	MaxTokens      int
+	Timeout        time.Duration // Per-attempt HTTP timeout
+	Temperature    float64
+	MaxAttempts    int    // Claude API attempts per fee request, including retries
+	MaxConcurrency int    // Concurrent Claude calls per Lambda instance (0 = unlimited)
+	PromptVersion  string // Prompt template version; empty uses the built-in default
+	ParameterPath  string // Parameter Store path whose values override the settings above
+}
+
+// AIEnabled reports whether the AI fee engine has what its provider needs
+// Bedrock authorizes with the Lambda role; the other providers need an API key.
+func (a AnthropicConfig) AIEnabled() bool {
+	return a.Provider == LLMProviderBedrock || a.APIKey != ""
+}
+
+// llmAPIKeyEnv is the environment variable holding the API key for provider
+func llmAPIKeyEnv(provider string) string {
+	if provider == LLMProviderOpenAI {
+		return "OPENAI_API_KEY"
+	}
+	return "ANTHROPIC_API_KEY"
 }
 
-// LoadAnthropicAPIKey loads the Anthropic API key with Secrets Manager fallback
-func (c *Config) LoadAnthropicAPIKey(ctx context.Context) error {
-	apiKey, err := GetAnthropicAPIKey(ctx, c.AWS.Region)
+// LoadLLMAPIKey loads the selected provider's API key with Secrets Manager fallback
+func (c *Config) LoadLLMAPIKey(ctx context.Context) error {
+	var apiKey string
+	var err error
+	switch c.Anthropic.Provider {
+	case LLMProviderBedrock:
+		return nil
+	case LLMProviderOpenAI:
+		apiKey, err = GetOpenAIAPIKey(ctx, c.AWS.Region)
+	default:
+		apiKey, err = GetAnthropicAPIKey(ctx, c.AWS.Region)
+	}
 	if err != nil {
 		// Log but don't fail - AI features are optional
 		return nil
@@ -38,9 +131,17 @@ type AWSConfig struct {
 
 // DatabaseConfig holds DynamoDB configuration
 type DatabaseConfig struct {
-	TableName      string
-	QuoteTableName string
-	Endpoint       string // For local testing
+	TableName            string
+	QuoteTableName       string
+	OutboxTableName      string
+	IdempotencyTableName string // Claims each API key's idempotency keys, so a key can't create two payments
+	Endpoint             string // For local testing
+}
+
+// EncryptionConfig holds field-level encryption configuration for account identifiers at rest
+type EncryptionConfig struct {
+	KMSKeyID      string        // KMS key that wraps the data keys; empty stores account identifiers in plaintext
+	DataKeyMaxAge time.Duration // How long one data key encrypts new values before another is generated
 }
 
 // QueueConfig holds SQS configuration
@@ -50,21 +151,369 @@ type QueueConfig struct {
 	Endpoint        string // For local testing
 }
 
+// PollingConfig holds settlement polling backoff configuration
+type PollingConfig struct {
+	InitialDelaySeconds int
+	MaxDelaySeconds     int
+	Multiplier          float64
+	MaxPollAttempts     int
+	MaxStageDuration    time.Duration
+}
+
+// StalePaymentConfig holds stale-payment sweeper configuration
+type StalePaymentConfig struct {
+	SLA         time.Duration            // How long a non-terminal payment may go without an update
+	StatusSLAs  map[string]time.Duration // Per-status overrides of SLA
+	MaxRequeues int                      // Re-enqueues of a stuck payment before it is expired
+}
+
+// OrchestrationConfig selects how payments are driven through the state machine
+type OrchestrationConfig struct {
+	Mode            string // "sqs" (default) or "stepfunctions"
+	StateMachineARN string
+}
+
+// UseStepFunctions reports whether payments are orchestrated by Step Functions
+func (o OrchestrationConfig) UseStepFunctions() bool {
+	return o.Mode == "stepfunctions"
+}
+
+// EventsConfig holds EventBridge lifecycle event configuration
+type EventsConfig struct {
+	BusName string // Empty disables event publishing
+	Source  string
+}
+
+// MetricsConfig holds CloudWatch embedded metric format configuration
+type MetricsConfig struct {
+	Namespace      string
+	PrometheusAddr string // Serves /metrics when set (local, non-Lambda runs only)
+}
+
+// TracingConfig holds AWS X-Ray configuration
+type TracingConfig struct {
+	Enabled bool // Requires active tracing on the Lambda function
+}
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// FeeShadowConfig holds shadow-mode configuration for evaluating the AI fee engine
+// When enabled, payments are still charged the static fee while the AI engine runs in the
+// background and both results are recorded for comparison.
+type FeeShadowConfig struct {
+	Enabled   bool
+	TableName string
+	Timeout   time.Duration // Upper bound on each background AI calculation
+}
+
+// FeeScheduleConfig holds stored fee schedule configuration
+// When enabled, platform fee tiers are read from the fee schedule table instead of the built-in schedule.
+type FeeScheduleConfig struct {
+	Enabled      bool
+	TableName    string
+	CacheSeconds int // How long each Lambda instance reuses schedules before re-reading the table
+}
+
+// CustomerPricingConfig holds negotiated per-customer pricing
+type CustomerPricingConfig struct {
+	Profiles string // JSON object of pricing keyed by API key ID or account
+}
+
+// PromoConfig holds promo code configuration
+type PromoConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// VolumeDiscountConfig holds rolling-volume fee discount configuration
+// When enabled, completed payments count toward their customer's 30-day volume, and customers
+// above a tier's volume pay that tier's platform fee rate.
+type VolumeDiscountConfig struct {
+	Enabled   bool
+	TableName string
+	Tiers     string // "volume=rate" pairs: USD cents of 30-day volume and the platform fee rate above it
+}
+
+// FeeInvoiceConfig holds fee invoice configuration
+type FeeInvoiceConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// ReportingConfig holds revenue reporting configuration
+type ReportingConfig struct {
+	TableName string
+	Bucket    string // S3 bucket for the CSV export read by finance
+	Prefix    string
+}
+
+// GasHistoryConfig holds gas price history configuration
+type GasHistoryConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// QuoteConfig holds quote validity (TTL) policy configuration
+type QuoteConfig struct {
+	DefaultTTL          time.Duration
+	CorridorTTLs        map[string]time.Duration // e.g. QUOTE_TTL_CORRIDORS="USD-EUR=60,USD-GBP=45"
+	TierTTLs            map[string]time.Duration // e.g. QUOTE_TTL_TIERS="enterprise=300"
+	VolatileTTL         time.Duration            // Cap applied when provider rates diverge (0 = no cap)
+	VolatilityThreshold float64                  // Provider rate spread, as a fraction, that triggers the cap
+	APIKeyTiers         map[string]string        // API Gateway key ID -> customer tier
+	RateProviders       string                   // Live rate providers, e.g. "circle,coinbase"; empty simulates rates
+	CircleAPIURL        string
+	CircleAPIKey        string
+	SettlementSLA       time.Duration            // Settlement time promised on a chain without its own
+	ChainSLAs           map[string]time.Duration // e.g. SETTLEMENT_SLA_CHAINS="ethereum=1800,solana=300"
+}
+
+// CorridorConfig selects where supported corridor definitions are loaded from
+type CorridorConfig struct {
+	Definitions string // JSON array of corridors; takes precedence over TableName
+	TableName   string // DynamoDB table of corridors; empty uses the built-in corridors
+}
+
+// DestinationConfig restricts the countries payments may pay out in, as ISO 3166-1 alpha-2 codes
+type DestinationConfig struct {
+	Allowed []string // Only these countries are served; empty allows every country not denied
+	Denied  []string // Never served, even if allowed
+}
+
+// FXConfig holds FX rate source failover configuration
+type FXConfig struct {
+	Sources                string  // Comma-separated source names in priority order
+	OpenExchangeRatesAppID string  // Required for the openexchangerates source
+	DivergenceThreshold    float64 // Relative disagreement between sources that is flagged
+	VerifySources          bool    // Cross-check the serving source against the next healthy one
+	CacheTableName         string  // DynamoDB table sharing FX and gas data across instances; empty caches per instance
+}
+
+// DataSourceConfig holds market data source overrides for the AI fee engine
+type DataSourceConfig struct {
+	Definitions string // JSON array of gas, status, token price and FX sources; replaces or adds to the built-in ones
+}
+
+// SlippageConfig holds the execution-time rate check configuration
+type SlippageConfig struct {
+	MaxSlippage float64 // Largest tolerated shortfall versus the expected rate, as a fraction
+	Action      string  // "review" holds the payment for an operator, "fail" reverses it
+}
+
+// ChainWatchConfig holds on-chain finality check configuration
+type ChainWatchConfig struct {
+	Enabled       bool
+	RPCURLs       map[string]string // e.g. CHAINWATCH_RPC_URLS="base=https://...,solana=https://..."; empty simulates every chain
+	Confirmations map[string]int    // e.g. CHAINWATCH_CONFIRMATIONS="ethereum=32"; replaces the chain's default rule
+}
+
+// TreasuryConfig holds treasury balance tracking configuration
+type TreasuryConfig struct {
+	Enabled              bool
+	TableName            string
+	LowBalanceThresholds map[string]int // e.g. TREASURY_LOW_BALANCE_THRESHOLDS="chain:base:USDC=5000000,provider:offramp:EUR=1000000"
+}
+
+// LedgerConfig holds double-entry ledger configuration
+type LedgerConfig struct {
+	Enabled   bool
+	TableName string
+	GasCosts  map[string]int // USDC minor units per transaction we send, e.g. LEDGER_GAS_COSTS="base=1,ethereum=150"
+}
+
+// CustomerConfig holds customer record configuration
+type CustomerConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// APIKeyConfig holds issued API key configuration
+type APIKeyConfig struct {
+	Enabled       bool
+	TableName     string
+	RotationGrace time.Duration // How long a rotated key keeps working alongside its replacement
+}
+
+// UsageConfig holds per-customer usage metering configuration
+type UsageConfig struct {
+	Enabled   bool
+	TableName string
+}
+
+// QuotaConfig holds per-tier payment quota configuration
+type QuotaConfig struct {
+	Enabled   bool
+	TableName string
+	Tiers     string // JSON object of daily and monthly limits keyed by customer tier
+}
+
+// WebhookConfig holds webhook subscription configuration
+type WebhookConfig struct {
+	Enabled           bool
+	TableName         string
+	DeliveryTableName string        // Each delivery attempt to a subscription, for its delivery statistics
+	ClientCacheTTL    time.Duration // How long an mTLS client certificate fetched from Secrets Manager is reused
+}
+
+// StreamConfig holds payment status streaming configuration
+type StreamConfig struct {
+	PollInterval time.Duration // How often an open stream checks its payment for new transitions
+	MaxDuration  time.Duration // How long a stream stays open before the client must reconnect
+	RequireKey   bool          // Require an API Gateway key on streams, which bypass API Gateway (off for local runs)
+}
+
+// SocketConfig holds WebSocket status notification configuration
+type SocketConfig struct {
+	TableName   string // Which connections are subscribed to which payments
+	APIEndpoint string // The WebSocket API's connection management endpoint; the worker pushes transitions when set
+}
+
+// GRPCConfig holds the internal gRPC server configuration
+type GRPCConfig struct {
+	Addr string // Serves the gRPC payment service instead of handling Lambda events when set (e.g. ":9090")
+}
+
+// HealthConfig holds dependency health check configuration
+type HealthConfig struct {
+	Addr        string        // Serves GET /health and GET /ready when set (server mode, e.g. ":8081")
+	Timeout     time.Duration // How long each dependency has to answer
+	DataSources bool          // Also check the fee engine's market data sources; they never fail readiness
+}
+
+// KYCConfig holds customer identity verification configuration
+type KYCConfig struct {
+	Enabled           bool
+	Provider          string // "persona" or "mock"
+	Threshold         int64  // Payments above this amount, in minor units, need a verified customer (0 = every payment)
+	PersonaAPIURL     string
+	PersonaAPIKey     string
+	PersonaTemplateID string // The Persona inquiry template customers are verified against
+}
+
+// SanctionsConfig holds sanctions screening configuration
+type SanctionsConfig struct {
+	Enabled   bool
+	SDNURL    string   // OFAC SDN list in CSV format, downloaded at cold start; empty screens countries only
+	Countries []string // Comprehensively sanctioned countries, as ISO 3166-1 alpha-2 codes
+}
+
+// SandboxConfig holds sandbox mode configuration
+// Customers flagged as sandbox get sandbox payments whatever Enabled is set to.
+type SandboxConfig struct {
+	Enabled bool // Every payment runs in the sandbox, and rates are simulated
+}
+
+// ChaosConfig holds fault injection configuration, for exercising recovery paths outside production
+// Rates and delays are keyed by the kind of call: database, queue, provider or datasource.
+type ChaosConfig struct {
+	Enabled      bool
+	FailureRates map[string]float64       // Chance each call fails
+	DelayRates   map[string]float64       // Chance each call is delayed first
+	Delays       map[string]time.Duration // How long a delayed call waits
+	Payments     []string                 // Payment IDs faults are confined to; empty for every payment
+	Seed         int64                    // Seeds which calls are faulted; 0 for a random sequence
+}
+
+// HoldConfig holds manual review hold configuration; each trigger is off at its zero value
+type HoldConfig struct {
+	AmountThreshold int64   // Payments of at least this amount, in minor units of the funding currency, are held
+	RiskScore       float64 // Payouts to countries whose risk score reaches this are held
+	SanctionsHit    bool    // Payments whose sanctions hit compliance released are held for a second approval
+}
+
+// Enabled reports whether any hold trigger is configured
+func (c HoldConfig) Enabled() bool {
+	return c.AmountThreshold > 0 || c.RiskScore > 0 || c.SanctionsHit
+}
+
+// AMLConfig holds AML transaction monitoring configuration
+// Amounts are in minor units of the payment's funding currency.
+type AMLConfig struct {
+	Enabled              bool
+	TableName            string
+	WindowHours          int     // How far back the structuring and velocity rules look at a customer's payments
+	StructuringThreshold int64   // Reporting threshold payments are split to stay under, e.g. 1000000 ($10,000)
+	StructuringMargin    float64 // Payments within this fraction below the threshold count as near it
+	StructuringCount     int     // Near-threshold payments in the window that raise an alert
+	VelocityMaxCount     int     // Payments in the window above which an alert is raised (0 = no limit)
+	VelocityMaxAmount    int64   // Total amount in the window above which an alert is raised (0 = no limit)
+	HighRiskScore        float64 // Destination country risk score at or above which an alert is raised
+}
+
+// BatchConfig holds batch payment creation configuration
+type BatchConfig struct {
+	MaxPayments int // Most payments POST /payments/batch accepts in one request
+}
+
+// maxBatchPayments is the largest batch created in one DynamoDB transaction: up to three items per payment
+// (the payment, its consumed quote and, once, the outbox message) within the limit of 100
+const maxBatchPayments = 33
+
+// ReconciliationConfig holds provider statement reconciliation configuration
+type ReconciliationConfig struct {
+	Enabled      bool
+	TableName    string
+	LookbackDays int // Days before the statement window whose payments may settle inside it
+}
+
+// RetentionConfig holds payment record retention and archival configuration
+type RetentionConfig struct {
+	Days          int // Days a terminal payment stays in DynamoDB (0 = keep forever)
+	ArchiveBucket string
+	ArchivePrefix string
+}
+
+// Storage backends selectable via STORAGE_BACKEND
+const (
+	StorageDynamoDB = "dynamodb"
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory" // Local development only; state is lost between invocations
+)
+
+// StorageConfig selects the payment and quote storage backend
+type StorageConfig struct {
+	Backend     string
+	DatabaseURL string // Postgres connection string
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level string
+	Level      string
+	MaskFields []string // Field names whose values are masked in logs; empty keeps the logger's defaults
 }
 
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
+	llmProvider := strings.ToLower(getEnv("LLM_PROVIDER", LLMProviderAnthropic))
+
+	// A mistyped rate would otherwise switch fault injection off without a word
+	chaosFailureRates, err := getEnvRates("CHAOS_FAILURE_RATES")
+	if err != nil {
+		return nil, err
+	}
+	chaosDelayRates, err := getEnvRates("CHAOS_DELAY_RATES")
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		AWS: AWSConfig{
 			Region: getEnv("AWS_REGION", "us-east-1"),
 		},
 		Database: DatabaseConfig{
-			TableName:      getEnv("DYNAMODB_TABLE", "payments"),
-			QuoteTableName: getEnv("QUOTE_TABLE", "quotes"),
-			Endpoint:       getEnv("DYNAMODB_ENDPOINT", ""), // Empty for AWS, set for local
+			TableName:            getEnv("DYNAMODB_TABLE", "payments"),
+			QuoteTableName:       getEnv("QUOTE_TABLE", "quotes"),
+			OutboxTableName:      getEnv("OUTBOX_TABLE", "outbox"),
+			IdempotencyTableName: getEnv("IDEMPOTENCY_TABLE", "idempotency-keys"),
+			Endpoint:             getEnv("DYNAMODB_ENDPOINT", ""), // Empty for AWS, set for local
+		},
+		Encryption: EncryptionConfig{
+			KMSKeyID:      getEnv("FIELD_ENCRYPTION_KMS_KEY_ID", ""),
+			DataKeyMaxAge: time.Duration(getEnvInt("FIELD_ENCRYPTION_DATA_KEY_MAX_AGE_SECONDS", 3600)) * time.Second,
 		},
 		Queue: QueueConfig{
 			PaymentQueueURL: getEnv("PAYMENT_QUEUE_URL", ""),
@@ -72,10 +521,234 @@ func Load() (*Config, error) {
 			Endpoint:        getEnv("SQS_ENDPOINT", ""), // Empty for AWS, set for local
 		},
 		Logging: LoggingConfig{
-			Level: getEnv("LOG_LEVEL", "INFO"),
+			Level:      getEnv("LOG_LEVEL", "INFO"),
+			MaskFields: getEnvList("LOG_MASK_FIELDS", ""),
 		},
 		Anthropic: AnthropicConfig{
-			APIKey: getEnv("ANTHROPIC_API_KEY", ""),
+			Provider:       llmProvider,
+			APIKey:         getEnv(llmAPIKeyEnv(llmProvider), ""),
+			Model:          getEnv("CLAUDE_MODEL", defaultLLMModels[llmProvider]),
+			MaxTokens:      getEnvInt("CLAUDE_MAX_TOKENS", 2048),
+			Timeout:        time.Duration(getEnvInt("CLAUDE_TIMEOUT_SECONDS", 30)) * time.Second,
+			Temperature:    getEnvFloat("CLAUDE_TEMPERATURE", 1.0),
+			MaxAttempts:    getEnvInt("CLAUDE_MAX_ATTEMPTS", 3),
+			MaxConcurrency: getEnvInt("CLAUDE_MAX_CONCURRENCY", 4),
+			PromptVersion:  getEnv("PROMPT_VERSION", ""),
+			ParameterPath:  getEnv("CLAUDE_PARAMETER_PATH", ""),
+		},
+		Polling: PollingConfig{
+			InitialDelaySeconds: getEnvInt("POLL_INITIAL_DELAY_SECONDS", 15),
+			MaxDelaySeconds:     getEnvInt("POLL_MAX_DELAY_SECONDS", 300),
+			Multiplier:          getEnvFloat("POLL_BACKOFF_MULTIPLIER", 2.0),
+			MaxPollAttempts:     getEnvInt("POLL_MAX_ATTEMPTS", 20),
+			MaxStageDuration:    time.Duration(getEnvInt("POLL_MAX_STAGE_SECONDS", 3600)) * time.Second,
+		},
+		StalePayments: StalePaymentConfig{
+			SLA:         time.Duration(getEnvInt("STALE_PAYMENT_SLA_SECONDS", 1800)) * time.Second,
+			StatusSLAs:  getEnvDurations("STALE_PAYMENT_STATUS_SLA_SECONDS"),
+			MaxRequeues: getEnvInt("STALE_PAYMENT_MAX_REQUEUES", 3),
+		},
+		Orchestration: OrchestrationConfig{
+			Mode:            getEnv("ORCHESTRATION_MODE", "sqs"),
+			StateMachineARN: getEnv("STATE_MACHINE_ARN", ""),
+		},
+		Events: EventsConfig{
+			BusName: getEnv("EVENT_BUS_NAME", ""),
+			Source:  getEnv("EVENT_SOURCE", "crypto-conversion"),
+		},
+		Storage: StorageConfig{
+			Backend:     getEnv("STORAGE_BACKEND", StorageDynamoDB),
+			DatabaseURL: getEnv("DATABASE_URL", ""),
+		},
+		Retention: RetentionConfig{
+			Days:          getEnvInt("PAYMENT_RETENTION_DAYS", 0),
+			ArchiveBucket: getEnv("ARCHIVE_BUCKET", ""),
+			ArchivePrefix: getEnv("ARCHIVE_PREFIX", ""),
+		},
+		Metrics: MetricsConfig{
+			Namespace:      getEnv("METRICS_NAMESPACE", "CryptoConversion"),
+			PrometheusAddr: getEnv("PROMETHEUS_ADDR", ""),
+		},
+		Tracing: TracingConfig{
+			Enabled: getEnvBool("TRACING_ENABLED", false),
+		},
+		Audit: AuditConfig{
+			Enabled:   getEnvBool("AUDIT_ENABLED", false),
+			TableName: getEnv("AUDIT_TABLE", "audit-log"),
+		},
+		FeeShadow: FeeShadowConfig{
+			Enabled:   getEnvBool("FEE_SHADOW_ENABLED", false),
+			TableName: getEnv("FEE_COMPARISON_TABLE", "fee-comparisons"),
+			Timeout:   time.Duration(getEnvInt("FEE_SHADOW_TIMEOUT_SECONDS", 60)) * time.Second,
+		},
+		FeeSchedules: FeeScheduleConfig{
+			Enabled:      getEnvBool("FEE_SCHEDULES_ENABLED", false),
+			TableName:    getEnv("FEE_SCHEDULE_TABLE", "fee-schedules"),
+			CacheSeconds: getEnvInt("FEE_SCHEDULE_CACHE_SECONDS", 60),
+		},
+		CustomerPricing: CustomerPricingConfig{
+			Profiles: getEnv("CUSTOMER_PRICING_JSON", ""),
+		},
+		Promos: PromoConfig{
+			Enabled:   getEnvBool("PROMOS_ENABLED", false),
+			TableName: getEnv("PROMO_TABLE", "promos"),
+		},
+		VolumeDiscounts: VolumeDiscountConfig{
+			Enabled:   getEnvBool("VOLUME_DISCOUNTS_ENABLED", false),
+			TableName: getEnv("CUSTOMER_VOLUME_TABLE", "customer-volumes"),
+			Tiers:     getEnv("VOLUME_DISCOUNT_TIERS", "10000000=0.022,100000000=0.018"),
+		},
+		FeeInvoices: FeeInvoiceConfig{
+			Enabled:   getEnvBool("FEE_INVOICES_ENABLED", false),
+			TableName: getEnv("FEE_INVOICE_TABLE", "fee-invoices"),
+		},
+		Reporting: ReportingConfig{
+			TableName: getEnv("REVENUE_REPORT_TABLE", "revenue-reports"),
+			Bucket:    getEnv("REPORT_BUCKET", ""),
+			Prefix:    getEnv("REPORT_PREFIX", ""),
+		},
+		GasHistory: GasHistoryConfig{
+			Enabled:   getEnvBool("GAS_HISTORY_ENABLED", false),
+			TableName: getEnv("GAS_PRICE_TABLE", "gas-prices"),
+		},
+		Quotes: QuoteConfig{
+			DefaultTTL:          time.Duration(getEnvInt("QUOTE_TTL_DEFAULT_SECONDS", 60)) * time.Second,
+			CorridorTTLs:        getEnvDurations("QUOTE_TTL_CORRIDORS"),
+			TierTTLs:            getEnvDurations("QUOTE_TTL_TIERS"),
+			VolatileTTL:         time.Duration(getEnvInt("QUOTE_TTL_VOLATILE_SECONDS", 0)) * time.Second,
+			VolatilityThreshold: getEnvFloat("QUOTE_VOLATILITY_THRESHOLD", 0.005),
+			APIKeyTiers:         getEnvMap("QUOTE_TIER_API_KEYS"),
+			RateProviders:       getEnv("QUOTE_RATE_PROVIDERS", ""),
+			CircleAPIURL:        getEnv("CIRCLE_API_URL", "https://api.circle.com"),
+			CircleAPIKey:        getEnv("CIRCLE_API_KEY", ""),
+			SettlementSLA:       time.Duration(getEnvInt("SETTLEMENT_SLA_DEFAULT_SECONDS", 1800)) * time.Second,
+			ChainSLAs:           getEnvDurations("SETTLEMENT_SLA_CHAINS"),
+		},
+		Corridors: CorridorConfig{
+			Definitions: getEnv("CORRIDORS_JSON", ""),
+			TableName:   getEnv("CORRIDOR_TABLE", ""),
+		},
+		Destinations: DestinationConfig{
+			Allowed: getEnvList("DESTINATION_COUNTRIES_ALLOWED", ""),
+			Denied:  getEnvList("DESTINATION_COUNTRIES_DENIED", ""),
+		},
+		FX: FXConfig{
+			Sources:                getEnv("FX_SOURCES", "exchangerate-api,ecb,openexchangerates"),
+			OpenExchangeRatesAppID: getEnv("OPEN_EXCHANGE_RATES_APP_ID", ""),
+			DivergenceThreshold:    getEnvFloat("FX_DIVERGENCE_THRESHOLD", 0.01),
+			VerifySources:          getEnvBool("FX_VERIFY_SOURCES", false),
+			CacheTableName:         getEnv("MARKET_DATA_CACHE_TABLE", ""),
+		},
+		DataSources: DataSourceConfig{
+			Definitions: getEnv("DATA_SOURCES_JSON", ""),
+		},
+		Slippage: SlippageConfig{
+			MaxSlippage: getEnvFloat("MAX_SLIPPAGE", 0.01),
+			Action:      getEnv("SLIPPAGE_ACTION", "review"),
+		},
+		ChainWatch: ChainWatchConfig{
+			Enabled:       getEnvBool("CHAINWATCH_ENABLED", false),
+			RPCURLs:       getEnvMap("CHAINWATCH_RPC_URLS"),
+			Confirmations: getEnvInts("CHAINWATCH_CONFIRMATIONS"),
+		},
+		Treasury: TreasuryConfig{
+			Enabled:              getEnvBool("TREASURY_ENABLED", false),
+			TableName:            getEnv("TREASURY_TABLE", "treasury"),
+			LowBalanceThresholds: getEnvInts("TREASURY_LOW_BALANCE_THRESHOLDS"),
+		},
+		Ledger: LedgerConfig{
+			Enabled:   getEnvBool("LEDGER_ENABLED", false),
+			TableName: getEnv("LEDGER_TABLE", "ledger"),
+			GasCosts:  getEnvInts("LEDGER_GAS_COSTS"),
+		},
+		Reconciliation: ReconciliationConfig{
+			Enabled:      getEnvBool("RECONCILIATION_ENABLED", false),
+			TableName:    getEnv("RECONCILIATION_TABLE", "reconciliation-breaks"),
+			LookbackDays: getEnvInt("RECONCILIATION_LOOKBACK_DAYS", 3),
+		},
+		Customers: CustomerConfig{
+			Enabled:   getEnvBool("CUSTOMERS_ENABLED", false),
+			TableName: getEnv("CUSTOMER_TABLE", "customers"),
+		},
+		APIKeys: APIKeyConfig{
+			Enabled:       getEnvBool("API_KEYS_ENABLED", false),
+			TableName:     getEnv("API_KEY_TABLE", "api-keys"),
+			RotationGrace: time.Duration(getEnvInt("API_KEY_ROTATION_GRACE_SECONDS", 86400)) * time.Second,
+		},
+		Usage: UsageConfig{
+			Enabled:   getEnvBool("USAGE_METERING_ENABLED", false),
+			TableName: getEnv("USAGE_TABLE", "usage"),
+		},
+		Quotas: QuotaConfig{
+			Enabled:   getEnvBool("QUOTAS_ENABLED", false),
+			TableName: getEnv("QUOTA_TABLE", "quotas"),
+			Tiers:     getEnv("QUOTA_TIERS", ""),
+		},
+		Webhooks: WebhookConfig{
+			Enabled:           getEnvBool("WEBHOOK_SUBSCRIPTIONS_ENABLED", false),
+			TableName:         getEnv("WEBHOOK_SUBSCRIPTION_TABLE", "webhook-subscriptions"),
+			DeliveryTableName: getEnv("WEBHOOK_DELIVERY_TABLE", "webhook-deliveries"),
+			ClientCacheTTL:    time.Duration(getEnvInt("WEBHOOK_MTLS_CACHE_SECONDS", 300)) * time.Second,
+		},
+		Streams: StreamConfig{
+			PollInterval: time.Duration(getEnvInt("STREAM_POLL_MS", 1000)) * time.Millisecond,
+			MaxDuration:  time.Duration(getEnvInt("STREAM_MAX_SECONDS", 300)) * time.Second,
+			RequireKey:   getEnvBool("STREAM_REQUIRE_API_KEY", true),
+		},
+		Sockets: SocketConfig{
+			TableName:   getEnv("SOCKET_SUBSCRIPTION_TABLE", "socket-subscriptions"),
+			APIEndpoint: getEnv("SOCKET_API_ENDPOINT", ""),
+		},
+		GRPC: GRPCConfig{
+			Addr: getEnv("GRPC_ADDR", ""),
+		},
+		Health: HealthConfig{
+			Addr:        getEnv("HEALTH_ADDR", ""),
+			Timeout:     time.Duration(getEnvInt("HEALTH_CHECK_TIMEOUT_MS", 2000)) * time.Millisecond,
+			DataSources: getEnvBool("HEALTH_CHECK_DATA_SOURCES", false),
+		},
+		Sanctions: SanctionsConfig{
+			Enabled:   getEnvBool("SANCTIONS_SCREENING_ENABLED", false),
+			SDNURL:    getEnv("SANCTIONS_SDN_URL", "https://www.treasury.gov/ofac/downloads/sdn.csv"),
+			Countries: getEnvList("SANCTIONS_COUNTRIES", "CU,IR,KP,SY"),
+		},
+		Holds: HoldConfig{
+			AmountThreshold: int64(getEnvInt("HOLD_AMOUNT_THRESHOLD", 0)),
+			RiskScore:       getEnvFloat("HOLD_RISK_SCORE", 0),
+			SanctionsHit:    getEnvBool("HOLD_ON_SANCTIONS_HIT", false),
+		},
+		Sandbox: SandboxConfig{
+			Enabled: getEnvBool("SANDBOX_ENABLED", false),
+		},
+		Chaos: ChaosConfig{
+			Enabled:      getEnvBool("CHAOS_ENABLED", false),
+			FailureRates: chaosFailureRates,
+			DelayRates:   chaosDelayRates,
+			Delays:       getEnvDurations("CHAOS_DELAYS"),
+			Payments:     getEnvList("CHAOS_PAYMENT_IDS", ""),
+			Seed:         int64(getEnvInt("CHAOS_SEED", 0)),
+		},
+		AML: AMLConfig{
+			Enabled:              getEnvBool("AML_MONITORING_ENABLED", false),
+			TableName:            getEnv("AML_ALERT_TABLE", "aml-alerts"),
+			WindowHours:          getEnvInt("AML_WINDOW_HOURS", 24),
+			StructuringThreshold: int64(getEnvInt("AML_STRUCTURING_THRESHOLD", 1000000)),
+			StructuringMargin:    getEnvFloat("AML_STRUCTURING_MARGIN", 0.1),
+			StructuringCount:     getEnvInt("AML_STRUCTURING_COUNT", 3),
+			VelocityMaxCount:     getEnvInt("AML_VELOCITY_MAX_COUNT", 10),
+			VelocityMaxAmount:    int64(getEnvInt("AML_VELOCITY_MAX_AMOUNT", 5000000)),
+			HighRiskScore:        getEnvFloat("AML_HIGH_RISK_SCORE", 6.0),
+		},
+		Batches: BatchConfig{
+			MaxPayments: getEnvInt("BATCH_PAYMENTS_MAX", 25),
+		},
+		KYC: KYCConfig{
+			Enabled:           getEnvBool("KYC_ENABLED", false),
+			Provider:          getEnv("KYC_PROVIDER", "persona"),
+			Threshold:         int64(getEnvInt("KYC_THRESHOLD", 0)),
+			PersonaAPIURL:     getEnv("PERSONA_API_URL", "https://withpersona.com"),
+			PersonaAPIKey:     getEnv("PERSONA_API_KEY", ""),
+			PersonaTemplateID: getEnv("PERSONA_TEMPLATE_ID", ""),
 		},
 	}
 
@@ -88,9 +761,132 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("DYNAMODB_TABLE is required")
 	}
 
+	if cfg.Storage.Backend == StoragePostgres && cfg.Storage.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL is required when STORAGE_BACKEND=postgres")
+	}
+
+	if cfg.Encryption.KMSKeyID != "" && cfg.Storage.Backend != StorageDynamoDB {
+		return nil, fmt.Errorf("FIELD_ENCRYPTION_KMS_KEY_ID is only supported with STORAGE_BACKEND=dynamodb")
+	}
+
+	if cfg.Orchestration.UseStepFunctions() && cfg.Orchestration.StateMachineARN == "" {
+		return nil, fmt.Errorf("STATE_MACHINE_ARN is required when ORCHESTRATION_MODE=stepfunctions")
+	}
+
+	if cfg.StalePayments.SLA <= 0 {
+		return nil, fmt.Errorf("STALE_PAYMENT_SLA_SECONDS must be positive, got %v", cfg.StalePayments.SLA)
+	}
+	if cfg.StalePayments.MaxRequeues < 0 {
+		return nil, fmt.Errorf("STALE_PAYMENT_MAX_REQUEUES must not be negative, got %d", cfg.StalePayments.MaxRequeues)
+	}
+	if cfg.Quotes.SettlementSLA <= 0 {
+		return nil, fmt.Errorf("SETTLEMENT_SLA_DEFAULT_SECONDS must be positive, got %v", cfg.Quotes.SettlementSLA)
+	}
+
+	if cfg.Slippage.Action != "review" && cfg.Slippage.Action != "fail" {
+		return nil, fmt.Errorf("SLIPPAGE_ACTION must be review or fail, got %q", cfg.Slippage.Action)
+	}
+	if cfg.KYC.Enabled && !cfg.Customers.Enabled {
+		return nil, fmt.Errorf("KYC_ENABLED needs CUSTOMERS_ENABLED; verification status is kept on customer records")
+	}
+	if cfg.KYC.Provider != "persona" && cfg.KYC.Provider != "mock" {
+		return nil, fmt.Errorf("KYC_PROVIDER must be persona or mock, got %q", cfg.KYC.Provider)
+	}
+	if cfg.AML.StructuringMargin <= 0 || cfg.AML.StructuringMargin >= 1 {
+		return nil, fmt.Errorf("AML_STRUCTURING_MARGIN must be between 0 and 1, got %v", cfg.AML.StructuringMargin)
+	}
+	if cfg.Holds.AmountThreshold < 0 || cfg.Holds.RiskScore < 0 {
+		return nil, fmt.Errorf("HOLD_AMOUNT_THRESHOLD and HOLD_RISK_SCORE must not be negative")
+	}
+	if cfg.Holds.SanctionsHit && !cfg.Sanctions.Enabled {
+		return nil, fmt.Errorf("HOLD_ON_SANCTIONS_HIT needs SANCTIONS_SCREENING_ENABLED")
+	}
+	if cfg.Batches.MaxPayments < 1 || cfg.Batches.MaxPayments > maxBatchPayments {
+		return nil, fmt.Errorf("BATCH_PAYMENTS_MAX must be between 1 and %d, got %d", maxBatchPayments, cfg.Batches.MaxPayments)
+	}
+	if cfg.Streams.PollInterval <= 0 || cfg.Streams.MaxDuration <= 0 {
+		return nil, fmt.Errorf("STREAM_POLL_MS and STREAM_MAX_SECONDS must be positive")
+	}
+	if _, ok := defaultLLMModels[cfg.Anthropic.Provider]; !ok {
+		return nil, fmt.Errorf("LLM_PROVIDER must be anthropic, bedrock or openai, got %q", cfg.Anthropic.Provider)
+	}
+	if cfg.Anthropic.Temperature < 0 || cfg.Anthropic.Temperature > 1 {
+		return nil, fmt.Errorf("CLAUDE_TEMPERATURE must be between 0 and 1, got %v", cfg.Anthropic.Temperature)
+	}
+
 	return cfg, nil
 }
 
+// AuditSnapshot returns the non-secret settings recorded in the audit log at startup
+func (c *Config) AuditSnapshot() map[string]string {
+	return map[string]string{
+		"storage_backend":      c.Storage.Backend,
+		"field_encryption":     strconv.FormatBool(c.Encryption.KMSKeyID != ""),
+		"log_mask_fields":      strings.Join(c.Logging.MaskFields, ","),
+		"batch_payments_max":   strconv.Itoa(c.Batches.MaxPayments),
+		"orchestration_mode":   c.Orchestration.Mode,
+		"poll_initial_delay":   strconv.Itoa(c.Polling.InitialDelaySeconds),
+		"poll_max_delay":       strconv.Itoa(c.Polling.MaxDelaySeconds),
+		"poll_multiplier":      strconv.FormatFloat(c.Polling.Multiplier, 'f', -1, 64),
+		"poll_max_attempts":    strconv.Itoa(c.Polling.MaxPollAttempts),
+		"poll_max_stage":       c.Polling.MaxStageDuration.String(),
+		"stale_payment_sla":    c.StalePayments.SLA.String(),
+		"stale_max_requeues":   strconv.Itoa(c.StalePayments.MaxRequeues),
+		"retention_days":       strconv.Itoa(c.Retention.Days),
+		"event_bus":            c.Events.BusName,
+		"tracing_enabled":      strconv.FormatBool(c.Tracing.Enabled),
+		"ai_fees_enabled":      strconv.FormatBool(c.Anthropic.AIEnabled()),
+		"llm_provider":         c.Anthropic.Provider,
+		"fee_shadow_enabled":   strconv.FormatBool(c.FeeShadow.Enabled),
+		"fee_schedules":        strconv.FormatBool(c.FeeSchedules.Enabled),
+		"customer_pricing":     strconv.FormatBool(c.CustomerPricing.Profiles != ""),
+		"promos_enabled":       strconv.FormatBool(c.Promos.Enabled),
+		"volume_discounts":     strconv.FormatBool(c.VolumeDiscounts.Enabled),
+		"fee_invoices":         strconv.FormatBool(c.FeeInvoices.Enabled),
+		"gas_history":          strconv.FormatBool(c.GasHistory.Enabled),
+		"claude_model":         c.Anthropic.Model,
+		"claude_max_tokens":    strconv.Itoa(c.Anthropic.MaxTokens),
+		"claude_timeout":       c.Anthropic.Timeout.String(),
+		"claude_temperature":   strconv.FormatFloat(c.Anthropic.Temperature, 'f', -1, 64),
+		"claude_max_attempts":  strconv.Itoa(c.Anthropic.MaxAttempts),
+		"claude_concurrency":   strconv.Itoa(c.Anthropic.MaxConcurrency),
+		"prompt_version":       c.Anthropic.PromptVersion,
+		"quote_ttl_default":    c.Quotes.DefaultTTL.String(),
+		"quote_ttl_volatile":   c.Quotes.VolatileTTL.String(),
+		"quote_rate_providers": c.Quotes.RateProviders,
+		"settlement_sla":       c.Quotes.SettlementSLA.String(),
+		"fx_sources":           c.FX.Sources,
+		"market_data_cache":    c.FX.CacheTableName,
+		"custom_data_sources":  strconv.FormatBool(c.DataSources.Definitions != ""),
+		"max_slippage":         strconv.FormatFloat(c.Slippage.MaxSlippage, 'f', -1, 64),
+		"slippage_action":      c.Slippage.Action,
+		"chain_watch":          strconv.FormatBool(c.ChainWatch.Enabled),
+		"treasury":             strconv.FormatBool(c.Treasury.Enabled),
+		"ledger":               strconv.FormatBool(c.Ledger.Enabled),
+		"reconciliation":       strconv.FormatBool(c.Reconciliation.Enabled),
+		"customers":            strconv.FormatBool(c.Customers.Enabled),
+		"kyc":                  strconv.FormatBool(c.KYC.Enabled),
+		"kyc_provider":         c.KYC.Provider,
+		"kyc_threshold":        strconv.FormatInt(c.KYC.Threshold, 10),
+		"sanctions_screening":  strconv.FormatBool(c.Sanctions.Enabled),
+		"sanctions_countries":  strings.Join(c.Sanctions.Countries, ","),
+		"aml_monitoring":       strconv.FormatBool(c.AML.Enabled),
+		"destinations_allowed": strings.Join(c.Destinations.Allowed, ","),
+		"destinations_denied":  strings.Join(c.Destinations.Denied, ","),
+		"aml_window_hours":     strconv.Itoa(c.AML.WindowHours),
+		"hold_amount":          strconv.FormatInt(c.Holds.AmountThreshold, 10),
+		"hold_risk_score":      strconv.FormatFloat(c.Holds.RiskScore, 'f', -1, 64),
+		"hold_sanctions_hit":   strconv.FormatBool(c.Holds.SanctionsHit),
+		"sandbox":              strconv.FormatBool(c.Sandbox.Enabled),
+		"chaos":                strconv.FormatBool(c.Chaos.Enabled),
+		"webhooks":             strconv.FormatBool(c.Webhooks.Enabled),
+		"stream_poll_interval": c.Streams.PollInterval.String(),
+		"stream_max_duration":  c.Streams.MaxDuration.String(),
+		"stream_require_key":   strconv.FormatBool(c.Streams.RequireKey),
+		"socket_push_enabled":  strconv.FormatBool(c.Sockets.APIEndpoint != ""),
+	}
+}
+
 // getEnv gets an environment variable with a default fallback
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -98,3 +894,106 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an integer environment variable with a default fallback
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat gets a float environment variable with a default fallback
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool gets a boolean environment variable with a default fallback
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvMap parses a "key=value,key=value" environment variable, skipping malformed pairs
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+// getEnvList reads a comma-separated list, skipping blank entries
+func getEnvList(key, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvDurations parses a "key=seconds,key=seconds" environment variable, skipping malformed pairs
+func getEnvDurations(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for k, v := range getEnvMap(key) {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			result[k] = time.Duration(seconds) * time.Second
+		}
+	}
+	return result
+}
+
+// getEnvInts parses a "key=n,key=n" environment variable, skipping malformed pairs
+func getEnvInts(key string) map[string]int {
+	result := make(map[string]int)
+	for k, v := range getEnvMap(key) {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			result[k] = n
+		}
+	}
+	return result
+}
+
+// chaosPoints are the kinds of call chaos rates are keyed by (see chaos.Point)
+var chaosPoints = map[string]bool{"database": true, "queue": true, "provider": true, "datasource": true}
+
+// getEnvRates parses a "point=x,point=x" environment variable of chances between 0 and 1,
+// failing on any pair that isn't one rather than skipping it
+func getEnvRates(key string) (map[string]float64, error) {
+	result := make(map[string]float64)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || !chaosPoints[k] {
+			return nil, fmt.Errorf("%s: %q must be database, queue, provider or datasource, then =rate", key, pair)
+		}
+		x, err := strconv.ParseFloat(v, 64)
+		if err != nil || x < 0 || x > 1 {
+			return nil, fmt.Errorf("%s: %s rate must be between 0 and 1, got %q", key, k, v)
+		}
+		if x > 0 {
+			result[k] = x
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// GetParametersByPath retrieves every parameter under path from SSM Parameter Store, keyed by leaf name
+func GetParametersByPath(ctx context.Context, parameterPath, region string) (map[string]string, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create AWS session: %w", err)
+	}
+
+	client := ssm.New(sess)
+
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(parameterPath),
+		WithDecryption: aws.Bool(true),
+	}
+
+	params := make(map[string]string)
+	err = client.GetParametersByPathPagesWithContext(ctx, input, func(page *ssm.GetParametersByPathOutput, lastPage bool) bool {
+		for _, param := range page.Parameters {
+			params[path.Base(aws.StringValue(param.Name))] = aws.StringValue(param.Value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve parameters: %w", err)
+	}
+
+	return params, nil
+}
+
+// LoadClaudeParameters overrides Claude settings with values from Parameter Store
+// Parameters live under CLAUDE_PARAMETER_PATH as model, max_tokens, timeout_seconds, temperature and prompt_version;
+// missing ones keep their environment value. Changes take effect on the next cold start.
+func (c *Config) LoadClaudeParameters(ctx context.Context) error {
+	if c.Anthropic.ParameterPath == "" {
+		return nil
+	}
+
+	params, err := GetParametersByPath(ctx, c.Anthropic.ParameterPath, c.AWS.Region)
+	if err != nil {
+		return err
+	}
+	return c.Anthropic.applyParameters(params)
+}
+
+// applyParameters overrides settings from Parameter Store leaf names, rejecting malformed values
+func (a *AnthropicConfig) applyParameters(params map[string]string) error {
+	if model := strings.TrimSpace(params["model"]); model != "" {
+		a.Model = model
+	}
+	if version := strings.TrimSpace(params["prompt_version"]); version != "" {
+		a.PromptVersion = version
+	}
+	if value, ok := params["max_tokens"]; ok {
+		maxTokens, err := strconv.Atoi(value)
+		if err != nil || maxTokens <= 0 {
+			return fmt.Errorf("invalid max_tokens parameter: %q", value)
+		}
+		a.MaxTokens = maxTokens
+	}
+	if value, ok := params["timeout_seconds"]; ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("invalid timeout_seconds parameter: %q", value)
+		}
+		a.Timeout = time.Duration(seconds) * time.Second
+	}
+	if value, ok := params["temperature"]; ok {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 1 {
+			return fmt.Errorf("invalid temperature parameter: %q", value)
+		}
+		a.Temperature = temperature
+	}
+	return nil
+}
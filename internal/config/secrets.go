@@ -43,16 +43,25 @@ func GetSecretValue(ctx context.Context, secretName, region string) (string, err
 
 // GetAnthropicAPIKey retrieves the Anthropic API key from Secrets Manager or environment
 func GetAnthropicAPIKey(ctx context.Context, region string) (string, error) {
+	return getAPIKey(ctx, region, "ANTHROPIC_API_KEY", "crypto-conversion/anthropic-api-key")
+}
+
+// GetOpenAIAPIKey retrieves the OpenAI API key from Secrets Manager or environment
+func GetOpenAIAPIKey(ctx context.Context, region string) (string, error) {
+	return getAPIKey(ctx, region, "OPENAI_API_KEY", "crypto-conversion/openai-api-key")
+}
+
+// getAPIKey reads envVar, falling back to a JSON secret keyed by its own name
+func getAPIKey(ctx context.Context, region, envVar, secretName string) (string, error) {
 	// First, try to get from environment variable (for local development)
-	if apiKey := getEnv("ANTHROPIC_API_KEY", ""); apiKey != "" {
+	if apiKey := getEnv(envVar, ""); apiKey != "" {
 		return apiKey, nil
 	}
 
 	// Fetch from Secrets Manager
-	secretName := "crypto-conversion/anthropic-api-key"
 	secretString, err := GetSecretValue(ctx, secretName, region)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Anthropic API key: %w", err)
+		return "", fmt.Errorf("failed to get %s: %w", secretName, err)
 	}
 
 	// Parse JSON secret and extract the API key
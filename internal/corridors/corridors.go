@@ -0,0 +1,256 @@
+package corridors
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/money"
+)
+
+// FeeTier is one band of a platform fee schedule
+type FeeTier struct {
+	UpTo     int64   `json:"up_to" dynamodbav:"up_to"`         // Exclusive upper bound in source minor units (0 = no bound)
+	Rate     float64 `json:"rate" dynamodbav:"rate"`           // Percentage fee, e.g. 0.029 for 2.9%
+	FixedFee int64   `json:"fixed_fee" dynamodbav:"fixed_fee"` // Fixed fee in source minor units
+}
+
+// FeeSchedule is a list of tiers ordered by ascending UpTo, ending with an unbounded tier
+type FeeSchedule []FeeTier
+
+// DefaultFeeSchedule is the platform fee applied when a corridor does not define its own:
+// 2.9% + $0.30 under $100, 2.5% + $0.50 under $1,000, 2.0% + $1.00 above
+// Amounts are in two-decimal minor units; use ForCurrency for other currencies.
+var DefaultFeeSchedule = FeeSchedule{
+	{UpTo: 10000, Rate: 0.029, FixedFee: 30},
+	{UpTo: 100000, Rate: 0.025, FixedFee: 50},
+	{UpTo: 0, Rate: 0.020, FixedFee: 100},
+}
+
+// Tier returns the tier that applies to amount
+func (s FeeSchedule) Tier(amount int64) FeeTier {
+	if len(s) == 0 {
+		return DefaultFeeSchedule.Tier(amount)
+	}
+	for _, tier := range s {
+		if tier.UpTo == 0 || amount < tier.UpTo {
+			return tier
+		}
+	}
+	return s[len(s)-1]
+}
+
+// Validate checks that a schedule is usable: ascending bounds, ending with exactly one unbounded tier
+func (s FeeSchedule) Validate() error {
+	if len(s) == 0 {
+		return errors.ErrValidation("tiers", "at least one tier is required")
+	}
+	for i, tier := range s {
+		last := i == len(s)-1
+		switch {
+		case tier.Rate < 0 || tier.Rate >= 1:
+			return errors.ErrValidation("tiers", fmt.Sprintf("tier %d: rate must be between 0 and 1", i+1))
+		case tier.FixedFee < 0:
+			return errors.ErrValidation("tiers", fmt.Sprintf("tier %d: fixed_fee must not be negative", i+1))
+		case last && tier.UpTo != 0:
+			return errors.ErrValidation("tiers", "the last tier must be unbounded (up_to 0)")
+		case !last && tier.UpTo <= 0:
+			return errors.ErrValidation("tiers", fmt.Sprintf("tier %d: only the last tier may be unbounded", i+1))
+		case i > 0 && !last && tier.UpTo <= s[i-1].UpTo:
+			return errors.ErrValidation("tiers", fmt.Sprintf("tier %d: up_to must be above the previous tier's", i+1))
+		}
+	}
+	return nil
+}
+
+// ForCurrency rescales a two-decimal schedule's bounds and fixed fees into currency's minor units
+// Major-unit values are kept, so a 100.00 bound is 100 for JPY and 100000 for BHD.
+func (s FeeSchedule) ForCurrency(currency string) FeeSchedule {
+	factor := money.Rescale("USD", currency)
+	if factor == 1 {
+		return s
+	}
+
+	scaled := make(FeeSchedule, len(s))
+	for i, tier := range s {
+		scaled[i] = FeeTier{
+			UpTo:     int64(math.Round(float64(tier.UpTo) * factor)),
+			Rate:     tier.Rate,
+			FixedFee: int64(math.Round(float64(tier.FixedFee) * factor)),
+		}
+	}
+	return scaled
+}
+
+// Surcharge is a regulatory charge passed through on a corridor, e.g. Brazil's IOF tax on foreign exchange
+// Surcharges are added to the platform fee after any discount, limit or promo, which never reduce them.
+type Surcharge struct {
+	Name     string  `json:"name" dynamodbav:"name"`
+	Rate     float64 `json:"rate" dynamodbav:"rate"`                               // e.g. 0.0038 for 0.38%
+	FixedFee int64   `json:"fixed_fee,omitempty" dynamodbav:"fixed_fee,omitempty"` // Minor units of the source currency
+}
+
+// Amount returns the surcharge due on amount
+func (s Surcharge) Amount(amount int64) int64 {
+	return int64(math.Round(float64(amount)*s.Rate)) + s.FixedFee
+}
+
+// Corridor describes a supported source -> destination currency pair
+type Corridor struct {
+	ID                  string      `json:"corridor_id" dynamodbav:"corridor_id"` // e.g. "USD-EUR"
+	SourceCurrency      string      `json:"source_currency" dynamodbav:"source_currency"`
+	DestinationCurrency string      `json:"destination_currency" dynamodbav:"destination_currency"`
+	DestinationCountry  string      `json:"destination_country,omitempty" dynamodbav:"destination_country,omitempty"` // ISO 3166 alpha-2 payout country, e.g. "DE"
+	Enabled             bool        `json:"enabled" dynamodbav:"enabled"`
+	OnRampProvider      string      `json:"onramp_provider" dynamodbav:"onramp_provider"`
+	OffRampProvider     string      `json:"offramp_provider" dynamodbav:"offramp_provider"`
+	PayoutRail          string      `json:"payout_rail" dynamodbav:"payout_rail"`                           // SEPA, FASTER_PAYMENTS, ...
+	RateProviders       []string    `json:"rate_providers" dynamodbav:"rate_providers"`                     // Providers checked for the best FX rate
+	Chains              []string    `json:"chains" dynamodbav:"chains"`                                     // Settlement chains, preferred first
+	OfframpChains       []string    `json:"offramp_chains,omitempty" dynamodbav:"offramp_chains,omitempty"` // Chains the off-ramp redeems USDC on, preferred first; empty accepts any settlement chain
+	MidMarketRate       float64     `json:"mid_market_rate" dynamodbav:"mid_market_rate"`
+	OfframpFeeRate      float64     `json:"offramp_fee_rate" dynamodbav:"offramp_fee_rate"`
+	OfframpFixedFee     int64       `json:"offramp_fixed_fee" dynamodbav:"offramp_fixed_fee"`
+	FeeSchedule         FeeSchedule `json:"fee_schedule,omitempty" dynamodbav:"fee_schedule,omitempty"` // Empty uses DefaultFeeSchedule
+	MinFee              int64       `json:"min_fee,omitempty" dynamodbav:"min_fee,omitempty"`           // Platform fee floor in source minor units (0 = none)
+	MaxFee              int64       `json:"max_fee,omitempty" dynamodbav:"max_fee,omitempty"`           // Platform fee cap in source minor units (0 = none)
+	Surcharges          []Surcharge `json:"surcharges,omitempty" dynamodbav:"surcharges,omitempty"`     // Regulatory charges on top of the platform fee
+	MinAmount           int64       `json:"min_amount" dynamodbav:"min_amount"`
+	MaxAmount           int64       `json:"max_amount" dynamodbav:"max_amount"` // 0 = no limit
+}
+
+// Key returns the registry key for a currency pair
+func Key(source, destination string) string {
+	return strings.ToUpper(source) + "-" + strings.ToUpper(destination)
+}
+
+// Fees returns the corridor's platform fee schedule
+func (c Corridor) Fees() FeeSchedule {
+	if len(c.FeeSchedule) == 0 {
+		return DefaultFeeSchedule.ForCurrency(c.SourceCurrency)
+	}
+	return c.FeeSchedule
+}
+
+// OfframpFee estimates the off-ramp provider fee for amount
+func (c Corridor) OfframpFee(amount int64) int64 {
+	return int64(float64(amount)*c.OfframpFeeRate) + c.OfframpFixedFee
+}
+
+// OfframpChainFor returns the chain the off-ramp redeems on for USDC minted on chain
+// Returns "" when the off-ramp accepts chain as is, so no bridge is needed.
+func (c Corridor) OfframpChainFor(chain string) string {
+	if chain == "" || len(c.OfframpChains) == 0 {
+		return ""
+	}
+	for _, accepted := range c.OfframpChains {
+		if strings.EqualFold(accepted, chain) {
+			return ""
+		}
+	}
+	return c.OfframpChains[0]
+}
+
+// ValidateAmount checks amount against the corridor's limits
+func (c Corridor) ValidateAmount(amount int64) error {
+	if amount <= 0 {
+		return errors.ErrValidation("amount", "must be greater than 0")
+	}
+	if amount < c.MinAmount {
+		return errors.ErrValidation("amount", fmt.Sprintf("is below the %s minimum of %d", c.ID, c.MinAmount))
+	}
+	if c.MaxAmount > 0 && amount > c.MaxAmount {
+		return errors.ErrValidation("amount", fmt.Sprintf("exceeds the %s maximum of %d", c.ID, c.MaxAmount))
+	}
+	return nil
+}
+
+// validate checks that a corridor definition is usable
+func (c Corridor) validate() error {
+	switch {
+	case c.SourceCurrency == "" || c.DestinationCurrency == "":
+		return fmt.Errorf("corridor %q: source and destination currencies are required", c.ID)
+	case c.ID != Key(c.SourceCurrency, c.DestinationCurrency):
+		return fmt.Errorf("corridor %q: id must be %s", c.ID, Key(c.SourceCurrency, c.DestinationCurrency))
+	case c.MidMarketRate <= 0:
+		return fmt.Errorf("corridor %q: mid_market_rate must be positive", c.ID)
+	case len(c.RateProviders) == 0:
+		return fmt.Errorf("corridor %q: at least one rate provider is required", c.ID)
+	case c.MaxAmount > 0 && c.MaxAmount < c.MinAmount:
+		return fmt.Errorf("corridor %q: max_amount is below min_amount", c.ID)
+	case c.MinFee < 0 || c.MaxFee < 0:
+		return fmt.Errorf("corridor %q: min_fee and max_fee must not be negative", c.ID)
+	case c.MaxFee > 0 && c.MaxFee < c.MinFee:
+		return fmt.Errorf("corridor %q: max_fee is below min_fee", c.ID)
+	}
+	if len(c.FeeSchedule) > 0 {
+		if err := c.FeeSchedule.Validate(); err != nil {
+			return fmt.Errorf("corridor %q: fee_schedule: %w", c.ID, err)
+		}
+	}
+	for _, chain := range c.OfframpChains {
+		if chain == "" || chain != strings.ToLower(chain) {
+			return fmt.Errorf("corridor %q: offramp_chains must be lowercase chain names", c.ID)
+		}
+	}
+	for _, surcharge := range c.Surcharges {
+		if surcharge.Name == "" || surcharge.Rate < 0 || surcharge.Rate >= 1 || surcharge.FixedFee < 0 {
+			return fmt.Errorf("corridor %q: surcharges need a name, a rate between 0 and 1 and a non-negative fixed_fee", c.ID)
+		}
+	}
+	return nil
+}
+
+// Registry holds the supported corridors
+type Registry struct {
+	corridors map[string]Corridor
+}
+
+// NewRegistry builds a registry, rejecting invalid or duplicate corridors
+func NewRegistry(list []Corridor) (*Registry, error) {
+	r := &Registry{corridors: make(map[string]Corridor, len(list))}
+	for _, c := range list {
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := r.corridors[c.ID]; exists {
+			return nil, fmt.Errorf("corridor %q defined more than once", c.ID)
+		}
+		r.corridors[c.ID] = c
+	}
+	return r, nil
+}
+
+// ParseJSON builds a registry from a JSON array of corridors
+func ParseJSON(data []byte) (*Registry, error) {
+	var list []Corridor
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid corridor definitions: %w", err)
+	}
+	return NewRegistry(list)
+}
+
+// Lookup returns the enabled corridor for a currency pair
+func (r *Registry) Lookup(source, destination string) (Corridor, error) {
+	c, ok := r.corridors[Key(source, destination)]
+	if !ok || !c.Enabled {
+		return Corridor{}, errors.ErrValidation("to_currency",
+			fmt.Sprintf("%s to %s is not a supported corridor", strings.ToUpper(source), strings.ToUpper(destination)))
+	}
+	return c, nil
+}
+
+// List returns every enabled corridor, ordered by ID
+func (r *Registry) List() []Corridor {
+	list := make([]Corridor, 0, len(r.corridors))
+	for _, c := range r.corridors {
+		if c.Enabled {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
@@ -0,0 +1,116 @@
+package corridors
+
+// defaultChains are the settlement chains every built-in corridor supports, cheapest first
+var defaultChains = []string{"base", "polygon", "arbitrum", "optimism", "solana", "avalanche", "ethereum"}
+
+// gbpFeeSchedule undercuts the default on USD-GBP, where Faster Payments makes payout cheap:
+// 2.5% + $0.30 under $100, 2.2% + $0.50 under $1,000, 1.8% + $1.00 above
+var gbpFeeSchedule = FeeSchedule{
+	{UpTo: 10000, Rate: 0.025, FixedFee: 30},
+	{UpTo: 100000, Rate: 0.022, FixedFee: 50},
+	{UpTo: 0, Rate: 0.018, FixedFee: 100},
+}
+
+// brlFeeSchedule covers Bridge's higher BRL liquidity cost on USD-BRL:
+// 3.5% + $0.50 under $100, 3.0% + $0.75 under $1,000, 2.5% + $1.50 above
+var brlFeeSchedule = FeeSchedule{
+	{UpTo: 10000, Rate: 0.035, FixedFee: 50},
+	{UpTo: 100000, Rate: 0.030, FixedFee: 75},
+	{UpTo: 0, Rate: 0.025, FixedFee: 150},
+}
+
+// brlSurcharges are the regulatory charges on USD-BRL: Brazil's 0.38% IOF tax on foreign exchange for remittances
+var brlSurcharges = []Surcharge{{Name: "IOF", Rate: 0.0038}}
+
+// Defaults are the corridors served when no definitions are configured
+var Defaults = []Corridor{
+	{
+		ID:                  "USD-EUR",
+		SourceCurrency:      "USD",
+		DestinationCurrency: "EUR",
+		DestinationCountry:  "DE",
+		Enabled:             true,
+		OnRampProvider:      "Circle",
+		OffRampProvider:     "Circle",
+		PayoutRail:          "SEPA",
+		RateProviders:       []string{"Circle", "Bridge", "Coinbase"},
+		Chains:              defaultChains,
+		MidMarketRate:       0.9195,
+		OfframpFeeRate:      0.015, // 1.5% + $0.75
+		OfframpFixedFee:     75,
+		MinAmount:           100,        // $1.00
+		MaxAmount:           1000000000, // $10M
+	},
+	{
+		ID:                  "USD-GBP",
+		SourceCurrency:      "USD",
+		DestinationCurrency: "GBP",
+		DestinationCountry:  "GB",
+		Enabled:             true,
+		OnRampProvider:      "Circle",
+		OffRampProvider:     "Circle",
+		PayoutRail:          "FASTER_PAYMENTS",
+		RateProviders:       []string{"Circle", "Bridge", "Coinbase"},
+		Chains:              defaultChains,
+		MidMarketRate:       0.7900,
+		OfframpFeeRate:      0.01, // Faster Payments is cheaper: 1% + $0.25
+		OfframpFixedFee:     25,
+		FeeSchedule:         gbpFeeSchedule,
+		MinAmount:           100,        // $1.00
+		MaxAmount:           1000000000, // $10M
+	},
+	{
+		ID:                  "EUR-USD",
+		SourceCurrency:      "EUR",
+		DestinationCurrency: "USD",
+		DestinationCountry:  "US",
+		Enabled:             true,
+		OnRampProvider:      "Circle", // Circle EUR on-ramp mints USDC from EUR
+		OffRampProvider:     "Circle", // Circle USDC redemption to a USD bank account
+		PayoutRail:          "WIRE",
+		RateProviders:       []string{"Circle", "Bridge", "Coinbase"},
+		Chains:              defaultChains,
+		MidMarketRate:       InvertRate(0.9195),
+		OfframpFeeRate:      0.01, // 1% + €0.50
+		OfframpFixedFee:     50,
+		MinAmount:           100,        // €1.00
+		MaxAmount:           1000000000, // €10M
+	},
+	{
+		ID:                  "USD-BRL",
+		SourceCurrency:      "USD",
+		DestinationCurrency: "BRL",
+		DestinationCountry:  "BR",
+		Enabled:             true,
+		OnRampProvider:      "Circle",
+		OffRampProvider:     "Bridge", // Bridge redeems USDC to BRL and pays out over PIX
+		PayoutRail:          "PIX",
+		RateProviders:       []string{"Bridge", "Circle"},
+		Chains:              defaultChains,
+		MidMarketRate:       5.05,
+		OfframpFeeRate:      0.012, // 1.2% + $0.50
+		OfframpFixedFee:     50,
+		FeeSchedule:         brlFeeSchedule,
+		Surcharges:          brlSurcharges,
+		MinAmount:           100,       // $1.00
+		MaxAmount:           100000000, // $1M, pending higher PIX limits
+	},
+}
+
+// InvertRate converts a USD-based rate (units per USD) into the rate for the reverse pair
+// e.g. USD/EUR 0.9195 becomes EUR/USD 1.0875
+func InvertRate(rate float64) float64 {
+	if rate == 0 {
+		return 0
+	}
+	return 1 / rate
+}
+
+// Default returns a registry of the built-in corridors
+func Default() *Registry {
+	r, err := NewRegistry(Defaults)
+	if err != nil {
+		panic(err) // Built-in definitions are static; a failure here is a programming error
+	}
+	return r
+}
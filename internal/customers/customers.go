@@ -0,0 +1,209 @@
+package customers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+)
+
+// webhookSecretBytes is the length of a generated webhook signing secret
+const webhookSecretBytes = 32
+
+// Service manages customer records and applies their tier and limits to requests
+type Service struct {
+	store database.CustomerRepository
+}
+
+// New creates a customer service backed by store
+func New(store database.CustomerRepository) *Service {
+	return &Service{store: store}
+}
+
+// Create stores a new customer with a freshly generated webhook secret
+func (s *Service) Create(ctx context.Context, req *models.CustomerRequest) (*models.Customer, error) {
+	if req.CustomerID == "" {
+		return nil, errors.ErrValidation("customer_id", "is required")
+	}
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to generate webhook secret", err)
+	}
+
+	now := time.Now().UTC()
+	customer := &models.Customer{
+		CustomerID:     req.CustomerID,
+		Name:           req.Name,
+		Tier:           req.Tier,
+		Limits:         req.Limits,
+		Sandbox:        req.Sandbox,
+		WebhookSecrets: []string{secret},
+		KYC:            models.CustomerKYC{Status: models.KYCStatusUnverified},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.store.CreateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// Get retrieves a customer
+func (s *Service) Get(ctx context.Context, customerID string) (*models.Customer, error) {
+	return s.store.GetCustomer(ctx, customerID)
+}
+
+// List returns every customer, ordered by ID
+func (s *Service) List(ctx context.Context) ([]*models.Customer, error) {
+	return s.store.ListCustomers(ctx)
+}
+
+// Update changes a customer's name, tier, limits and sandbox flag
+func (s *Service) Update(ctx context.Context, customerID string, req *models.CustomerRequest) (*models.Customer, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	customer, err := s.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	customer.Name = req.Name
+	customer.Tier = req.Tier
+	customer.Limits = req.Limits
+	customer.Sandbox = req.Sandbox
+	customer.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// Delete removes a customer
+func (s *Service) Delete(ctx context.Context, customerID string) error {
+	return s.store.DeleteCustomer(ctx, customerID)
+}
+
+// LinkAccount links a source account to a customer, failing with a conflict if it already is
+func (s *Service) LinkAccount(ctx context.Context, customerID string, req *models.CustomerAccountRequest) (*models.Customer, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	customer, err := s.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Account(req.AccountID) != nil {
+		return nil, errors.ErrConflict(fmt.Sprintf("Account %s is already linked to customer %s", req.AccountID, customerID))
+	}
+
+	now := time.Now().UTC()
+	customer.Accounts = append(customer.Accounts, models.CustomerAccount{
+		AccountID: req.AccountID,
+		Label:     req.Label,
+		LinkedAt:  now,
+	})
+	customer.UpdatedAt = now
+	if err := s.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// UnlinkAccount removes a linked account from a customer
+func (s *Service) UnlinkAccount(ctx context.Context, customerID, accountID string) (*models.Customer, error) {
+	customer, err := s.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := customer.Accounts[:0]
+	for _, account := range customer.Accounts {
+		if account.AccountID != accountID {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) == len(customer.Accounts) {
+		return nil, errors.New("ACCOUNT_NOT_FOUND", fmt.Sprintf("Account '%s' is not linked to customer '%s'", accountID, customerID), 404, nil)
+	}
+	customer.Accounts = accounts
+	customer.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// SetAllowedIPs replaces the CIDR ranges a customer's API key may call from
+func (s *Service) SetAllowedIPs(ctx context.Context, customerID string, req *models.CustomerIPAllowlistRequest) (*models.Customer, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	customer, err := s.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	customer.AllowedIPs = req.AllowedIPs
+	customer.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateCustomer(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// Lookup returns the customer calling with an API key, or nil if it has no customer record
+func (s *Service) Lookup(ctx context.Context, customerID string) (*models.Customer, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+	customer, err := s.store.GetCustomer(ctx, customerID)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && appErr.Code == "CUSTOMER_NOT_FOUND" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+// CheckPayment returns why a customer may not make a payment, or nil if it may
+// Payments must stay within the customer's limits and, once it has linked accounts, be funded from one.
+func CheckPayment(customer *models.Customer, payment *models.PaymentRequest) *errors.AppError {
+	if max := customer.Limits.MaxPaymentAmount; max > 0 && payment.Amount > max {
+		return errors.ErrLimitExceeded(fmt.Sprintf("Payment amount %d exceeds your per-payment limit of %d", payment.Amount, max))
+	}
+	if len(customer.Accounts) > 0 && customer.Account(payment.SourceAccount) == nil {
+		return errors.ErrAccountNotLinked(payment.SourceAccount)
+	}
+	return nil
+}
+
+// CheckSourceIP returns why a customer's API key may not be used from sourceIP, or nil if it may
+func CheckSourceIP(customer *models.Customer, sourceIP string) *errors.AppError {
+	if !customer.AllowsIP(sourceIP) {
+		return errors.ErrIPNotAllowed(sourceIP)
+	}
+	return nil
+}
+
+// newWebhookSecret generates a random webhook signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// AMLAlertClient stores AML alerts in DynamoDB, keyed by alert ID
+type AMLAlertClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewAMLAlertClient creates a new AML alert database client
+func NewAMLAlertClient(region, tableName, endpoint string) (*AMLAlertClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AMLAlertClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordAlert writes an alert, returning false if it was already recorded
+// An earlier alert is left alone, so re-evaluating a payment never reopens one compliance closed.
+func (c *AMLAlertClient) RecordAlert(ctx context.Context, alert *models.AMLAlert) (bool, error) {
+	av, err := dynamodbattribute.MarshalMap(alert)
+	if err != nil {
+		logger.Error("Failed to marshal AML alert", logger.Fields{"error": err.Error()})
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(alert_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return false, nil
+		}
+		logger.Error("Failed to record AML alert", logger.Fields{"error": err.Error(), "alert_id": alert.AlertID})
+		return false, errors.ErrDatabaseOperation("record_alert", err)
+	}
+
+	return true, nil
+}
+
+// GetAlert retrieves an alert
+func (c *AMLAlertClient) GetAlert(ctx context.Context, alertID string) (*models.AMLAlert, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"alert_id": {S: aws.String(alertID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get AML alert", logger.Fields{"error": err.Error(), "alert_id": alertID})
+		return nil, errors.ErrDatabaseOperation("get_alert", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrAMLAlertNotFound(alertID)
+	}
+
+	var alert models.AMLAlert
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &alert); err != nil {
+		logger.Error("Failed to unmarshal AML alert", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &alert, nil
+}
+
+// ListAlerts returns the alerts with a status, or every alert if status is empty, oldest first
+// Alerts are rare next to payments, so a scan is cheaper than keeping a status index.
+func (c *AMLAlertClient) ListAlerts(ctx context.Context, status string) ([]*models.AMLAlert, error) {
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(c.tableName),
+	}
+	if status != "" {
+		expr, err := expression.NewBuilder().
+			WithFilter(expression.Name("status").Equal(expression.Value(status))).
+			Build()
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("build_expression", err)
+		}
+		input.FilterExpression = expr.Filter()
+		input.ExpressionAttributeNames = expr.Names()
+		input.ExpressionAttributeValues = expr.Values()
+	}
+
+	var alerts []*models.AMLAlert
+	var unmarshalErr error
+	err := c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var alert models.AMLAlert
+			if err := dynamodbattribute.UnmarshalMap(item, &alert); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			alerts = append(alerts, &alert)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan AML alerts", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_alerts", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sortAMLAlerts(alerts)
+	return alerts, nil
+}
+
+// CloseAlert marks an open alert closed, failing with a conflict if it already was
+func (c *AMLAlertClient) CloseAlert(ctx context.Context, alertID, resolution, resolvedBy string, at time.Time) error {
+	update := expression.Set(expression.Name("status"), expression.Value(models.AMLAlertStatusClosed)).
+		Set(expression.Name("resolution"), expression.Value(resolution)).
+		Set(expression.Name("resolved_by"), expression.Value(resolvedBy)).
+		Set(expression.Name("resolved_at"), expression.Value(at))
+	condition := expression.Name("status").Equal(expression.Value(models.AMLAlertStatusOpen))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"alert_id": {S: aws.String(alertID)},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			if _, err := c.GetAlert(ctx, alertID); err != nil {
+				return err
+			}
+			return errors.ErrConflict(fmt.Sprintf("AML alert %s is already closed", alertID))
+		}
+		logger.Error("Failed to close AML alert", logger.Fields{"error": err.Error(), "alert_id": alertID})
+		return errors.ErrDatabaseOperation("close_alert", err)
+	}
+
+	return nil
+}
+
+// sortAMLAlerts orders alerts oldest first, by ID within a payment
+func sortAMLAlerts(alerts []*models.AMLAlert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].CreatedAt.Equal(alerts[j].CreatedAt) {
+			return alerts[i].CreatedAt.Before(alerts[j].CreatedAt)
+		}
+		return alerts[i].AlertID < alerts[j].AlertID
+	})
+}
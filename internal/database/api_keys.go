@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// apiKeyCustomerIndex is the GSI listing a customer's keys
+const apiKeyCustomerIndex = "customer-id-index"
+
+// APIKeyClient stores API keys in DynamoDB, keyed by the hash of their secret
+// Every authenticated request reads its key by hash, so the hash is the table's key; a customer's keys
+// are listed through the customer ID index.
+type APIKeyClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewAPIKeyClient creates a new API key database client
+func NewAPIKeyClient(region, tableName, endpoint string) (*APIKeyClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKeyClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateAPIKey writes a new key, failing with a conflict if its hash exists
+func (c *APIKeyClient) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return c.putAPIKey(ctx, key, true)
+}
+
+// UpdateAPIKey replaces an existing key
+func (c *APIKeyClient) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return c.putAPIKey(ctx, key, false)
+}
+
+// putAPIKey writes a new key, or replaces an existing one
+func (c *APIKeyClient) putAPIKey(ctx context.Context, key *models.APIKey, create bool) error {
+	condition := "attribute_exists(key_hash)"
+	if create {
+		condition = "attribute_not_exists(key_hash)"
+	}
+
+	av, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		logger.Error("Failed to marshal API key", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String(condition),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			if create {
+				return errors.ErrConflict(fmt.Sprintf("API key %s already exists", key.KeyID))
+			}
+			return errors.ErrAPIKeyNotFound(key.KeyID)
+		}
+		logger.Error("Failed to save API key", logger.Fields{"error": err.Error(), "key_id": key.KeyID})
+		return errors.ErrDatabaseOperation("put_api_key", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey retrieves the key whose secret has the given hash, or nil if no key has it
+func (c *APIKeyClient) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"key_hash": {S: aws.String(keyHash)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get API key", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("get_api_key", err)
+	}
+	if result.Item == nil {
+		return nil, nil // Not found, but not an error
+	}
+
+	var key models.APIKey
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &key); err != nil {
+		logger.Error("Failed to unmarshal API key", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys returns a customer's keys, oldest first
+func (c *APIKeyClient) ListAPIKeys(ctx context.Context, customerID string) ([]*models.APIKey, error) {
+	keys := []*models.APIKey{}
+	var unmarshalErr error
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		IndexName:              aws.String(apiKeyCustomerIndex),
+		KeyConditionExpression: aws.String("customer_id = :customer"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":customer": {S: aws.String(customerID)},
+		},
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var key models.APIKey
+			if err := dynamodbattribute.UnmarshalMap(item, &key); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			keys = append(keys, &key)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query API keys", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("list_api_keys", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// TouchAPIKey records when a key was last used; keys are never deleted, so a missing one is ignored
+func (c *APIKeyClient) TouchAPIKey(ctx context.Context, keyHash string, usedAt time.Time) error {
+	av, err := dynamodbattribute.Marshal(usedAt.UTC())
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"key_hash": {S: aws.String(keyHash)},
+		},
+		UpdateExpression:          aws.String("SET last_used_at = :used"),
+		ConditionExpression:       aws.String("attribute_exists(key_hash)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":used": av},
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return nil
+		}
+		logger.Error("Failed to record API key use", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("touch_api_key", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// auditChain is the partition key shared by every audit entry
+// A single partition keeps the log totally ordered by sequence; audit volume is far below partition limits.
+const auditChain = "audit"
+
+// AuditClient handles audit log storage operations
+type AuditClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewAuditClient creates a new audit log database client
+func NewAuditClient(region, tableName, endpoint string) (*AuditClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// AppendAuditEntry writes an entry, failing with a conflict if its sequence is already taken
+func (c *AuditClient) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		logger.Error("Failed to marshal audit entry", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+	av["chain"] = &dynamodb.AttributeValue{S: aws.String(auditChain)}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(#seq)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#seq": aws.String("sequence"),
+		},
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, input)
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Audit sequence %d already written", entry.Sequence))
+		}
+		logger.Error("Failed to append audit entry", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("append_audit", err)
+	}
+
+	return nil
+}
+
+// GetLatestAuditEntry returns the most recent entry, or nil if the log is empty
+func (c *AuditClient) GetLatestAuditEntry(ctx context.Context) (*models.AuditEntry, error) {
+	entries, err := c.queryAuditEntries(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("#chain = :chain"),
+		ExpressionAttributeNames: map[string]*string{
+			"#chain": aws.String("chain"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":chain": {S: aws.String(auditChain)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(1),
+		ConsistentRead:   aws.Bool(true),
+	})
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// ListAuditEntries returns up to limit entries after the given sequence, oldest first
+func (c *AuditClient) ListAuditEntries(ctx context.Context, afterSequence int64, limit int) ([]*models.AuditEntry, error) {
+	return c.queryAuditEntries(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("#chain = :chain AND #seq > :after"),
+		ExpressionAttributeNames: map[string]*string{
+			"#chain": aws.String("chain"),
+			"#seq":   aws.String("sequence"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":chain": {S: aws.String(auditChain)},
+			":after": {N: aws.String(strconv.FormatInt(afterSequence, 10))},
+		},
+		Limit: aws.Int64(int64(limit)),
+	})
+}
+
+// queryAuditEntries runs a query against the audit chain and unmarshals the results
+func (c *AuditClient) queryAuditEntries(ctx context.Context, input *dynamodb.QueryInput) ([]*models.AuditEntry, error) {
+	result, err := c.svc.QueryWithContext(ctx, input)
+	if err != nil {
+		logger.Error("Failed to query audit log", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("query_audit", err)
+	}
+
+	var entries []*models.AuditEntry
+	if err := dynamodbattribute.UnmarshalListOfMaps(result.Items, &entries); err != nil {
+		logger.Error("Failed to unmarshal audit entries", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return entries, nil
+}
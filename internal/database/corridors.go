@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+)
+
+// CorridorClient reads corridor definitions from DynamoDB
+type CorridorClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewCorridorClient creates a new corridor table client
+func NewCorridorClient(region, tableName, endpoint string) (*CorridorClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CorridorClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// ListCorridors returns every corridor definition in the table
+// The table holds a handful of items, so a paginated scan is fine.
+func (c *CorridorClient) ListCorridors(ctx context.Context) ([]corridors.Corridor, error) {
+	var list []corridors.Corridor
+	var scanErr error
+
+	err := c.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:      aws.String(c.tableName),
+		ConsistentRead: aws.Bool(true),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var items []corridors.Corridor
+		if scanErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &items); scanErr != nil {
+			return false
+		}
+		list = append(list, items...)
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan corridors", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan_corridors", err)
+	}
+	if scanErr != nil {
+		logger.Error("Failed to unmarshal corridors", logger.Fields{"error": scanErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", scanErr)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// CustomerClient stores customers in DynamoDB, keyed by customer ID
+type CustomerClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewCustomerClient creates a new customer database client
+func NewCustomerClient(region, tableName, endpoint string) (*CustomerClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomerClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateCustomer writes a new customer, failing with a conflict if the ID exists
+func (c *CustomerClient) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	return c.putCustomer(ctx, customer, true)
+}
+
+// UpdateCustomer replaces an existing customer
+func (c *CustomerClient) UpdateCustomer(ctx context.Context, customer *models.Customer) error {
+	return c.putCustomer(ctx, customer, false)
+}
+
+// putCustomer writes a new customer, or replaces an existing one
+func (c *CustomerClient) putCustomer(ctx context.Context, customer *models.Customer, create bool) error {
+	condition := "attribute_exists(customer_id)"
+	if create {
+		condition = "attribute_not_exists(customer_id)"
+	}
+
+	av, err := dynamodbattribute.MarshalMap(customer)
+	if err != nil {
+		logger.Error("Failed to marshal customer", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String(condition),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			if create {
+				return errors.ErrConflict(fmt.Sprintf("Customer %s already exists", customer.CustomerID))
+			}
+			return errors.ErrCustomerNotFound(customer.CustomerID)
+		}
+		logger.Error("Failed to save customer", logger.Fields{"error": err.Error(), "customer_id": customer.CustomerID})
+		return errors.ErrDatabaseOperation("put_customer", err)
+	}
+
+	return nil
+}
+
+// GetCustomer retrieves a customer
+func (c *CustomerClient) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"customer_id": {S: aws.String(customerID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get customer", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("get_customer", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrCustomerNotFound(customerID)
+	}
+
+	var customer models.Customer
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &customer); err != nil {
+		logger.Error("Failed to unmarshal customer", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &customer, nil
+}
+
+// ListCustomers returns every customer, ordered by ID
+func (c *CustomerClient) ListCustomers(ctx context.Context) ([]*models.Customer, error) {
+	customers := []*models.Customer{}
+	var unmarshalErr error
+	err := c.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(c.tableName),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var customer models.Customer
+			if err := dynamodbattribute.UnmarshalMap(item, &customer); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			customers = append(customers, &customer)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan customers", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_customers", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sort.Slice(customers, func(i, j int) bool { return customers[i].CustomerID < customers[j].CustomerID })
+	return customers, nil
+}
+
+// DeleteCustomer removes a customer
+func (c *CustomerClient) DeleteCustomer(ctx context.Context, customerID string) error {
+	_, err := c.svc.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"customer_id": {S: aws.String(customerID)},
+		},
+		ConditionExpression: aws.String("attribute_exists(customer_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrCustomerNotFound(customerID)
+		}
+		logger.Error("Failed to delete customer", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return errors.ErrDatabaseOperation("delete_customer", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -12,12 +14,24 @@ import (
 	"crypto-conversion/internal/errors"
 	"crypto-conversion/internal/logger"
 	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
 )
 
 // Client represents a DynamoDB client
 type Client struct {
-	svc       *dynamodb.DynamoDB
-	tableName string
+	svc              *dynamodb.DynamoDB
+	tableName        string
+	outboxTable      string
+	quoteTable       string // Quotes consumed by the payments created here
+	idempotencyTable string // Idempotency keys claimed by the payments created here
+	retention        time.Duration
+	archive          Archive
+	fields           *FieldCipher // Encrypts account identifiers at rest; nil stores them in plaintext
+}
+
+// Archive interface for reading payments that have been exported before TTL deletion
+type Archive interface {
+	GetArchivedPayment(ctx context.Context, paymentID string) (*models.Payment, error)
 }
 
 // NewClient creates a new DynamoDB client
@@ -29,7 +43,7 @@ func NewClient(region, tableName, endpoint string) (*Client, error) {
 		return nil, err
 	}
 
-	svc := dynamodb.New(sess)
+	svc := dynamodb.New(tracing.AWSSession(sess))
 
 	// Override endpoint for local testing
 	if endpoint != "" {
@@ -42,9 +56,43 @@ func NewClient(region, tableName, endpoint string) (*Client, error) {
 	}, nil
 }
 
+// EnableRetention expires payments the retention period after they are archived
+// When archive is set, GetPaymentByID falls back to it for records DynamoDB has deleted.
+func (c *Client) EnableRetention(retention time.Duration, archive Archive) {
+	c.retention = retention
+	c.archive = archive
+}
+
+// CheckHealth verifies the payment table and the outbox and idempotency tables written with it can be read
+func (c *Client) CheckHealth(ctx context.Context) error {
+	return checkTables(ctx, c.svc, c.tableName, c.outboxTable, c.idempotencyTable)
+}
+
+// checkTables describes each named table, failing unless it exists and is usable
+func checkTables(ctx context.Context, svc *dynamodb.DynamoDB, tables ...string) error {
+	for _, table := range tables {
+		if table == "" {
+			continue
+		}
+		out, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+		if err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+		if status := aws.StringValue(out.Table.TableStatus); status != dynamodb.TableStatusActive && status != dynamodb.TableStatusUpdating {
+			return fmt.Errorf("table %s is %s", table, status)
+		}
+	}
+	return nil
+}
+
 // CreatePayment creates a new payment record
 func (c *Client) CreatePayment(ctx context.Context, payment *models.Payment) error {
-	av, err := dynamodbattribute.MarshalMap(payment)
+	stored, err := c.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+
+	av, err := dynamodbattribute.MarshalMap(stored)
 	if err != nil {
 		logger.Error("Failed to marshal payment", logger.Fields{"error": err.Error()})
 		return errors.ErrDatabaseOperation("marshal", err)
@@ -92,6 +140,19 @@ func (c *Client) GetPaymentByID(ctx context.Context, paymentID string) (*models.
 	}
 
 	if result.Item == nil {
+		// The record may have expired out of DynamoDB after being archived
+		if c.archive != nil {
+			archived, err := c.archive.GetArchivedPayment(ctx, paymentID)
+			if err != nil {
+				return nil, err
+			}
+			if archived != nil {
+				if err := c.fields.decryptPayment(ctx, archived); err != nil {
+					return nil, err
+				}
+				return archived, nil
+			}
+		}
 		return nil, errors.ErrPaymentNotFound(paymentID)
 	}
 
@@ -101,31 +162,43 @@ func (c *Client) GetPaymentByID(ctx context.Context, paymentID string) (*models.
 		logger.Error("Failed to unmarshal payment", logger.Fields{"error": err.Error()})
 		return nil, errors.ErrDatabaseOperation("unmarshal", err)
 	}
+	if err := c.fields.decryptPayment(ctx, &payment); err != nil {
+		return nil, err
+	}
 
 	return &payment, nil
 }
 
-// GetPaymentByIdempotencyKey retrieves a payment by its idempotency key
-func (c *Client) GetPaymentByIdempotencyKey(ctx context.Context, idempotencyKey string) (*models.Payment, error) {
-	// Create a filter expression
-	filt := expression.Name("idempotency_key").Equal(expression.Value(idempotencyKey))
-	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+// idempotencyKeyIndex is the payments table's GSI on idempotency_key
+const idempotencyKeyIndex = "idempotency-key-index"
+
+// GetPaymentByIdempotencyKey retrieves a payment by its idempotency key within an API key's scope
+// Keys are unique per scope, so the index is queried for the key and the other scopes' payments filtered out.
+func (c *Client) GetPaymentByIdempotencyKey(ctx context.Context, scope, idempotencyKey string) (*models.Payment, error) {
+	keyCond := expression.Key("idempotency_key").Equal(expression.Value(idempotencyKey))
+	filt := expression.Name("idempotency_scope").Equal(expression.Value(scope))
+	if scope == "" {
+		filt = expression.AttributeNotExists(expression.Name("idempotency_scope"))
+	}
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithFilter(filt).Build()
 	if err != nil {
 		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
 		return nil, errors.ErrDatabaseOperation("build_expression", err)
 	}
 
-	input := &dynamodb.ScanInput{
+	input := &dynamodb.QueryInput{
 		TableName:                 aws.String(c.tableName),
+		IndexName:                 aws.String(idempotencyKeyIndex),
+		KeyConditionExpression:    expr.KeyCondition(),
 		FilterExpression:          expr.Filter(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
 
-	result, err := c.svc.ScanWithContext(ctx, input)
+	result, err := c.svc.QueryWithContext(ctx, input)
 	if err != nil {
-		logger.Error("Failed to scan for payment", logger.Fields{"error": err.Error()})
-		return nil, errors.ErrDatabaseOperation("scan", err)
+		logger.Error("Failed to query for payment", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("query", err)
 	}
 
 	if len(result.Items) == 0 {
@@ -138,6 +211,9 @@ func (c *Client) GetPaymentByIdempotencyKey(ctx context.Context, idempotencyKey
 		logger.Error("Failed to unmarshal payment", logger.Fields{"error": err.Error()})
 		return nil, errors.ErrDatabaseOperation("unmarshal", err)
 	}
+	if err := c.fields.decryptPayment(ctx, &payment); err != nil {
+		return nil, err
+	}
 
 	return &payment, nil
 }
@@ -153,7 +229,7 @@ func (c *Client) UpdatePaymentStatus(ctx context.Context, paymentID string, stat
 		update = update.Set(expression.Name("error_message"), expression.Value(errorMsg))
 	}
 
-	if status == models.StatusCompleted || status == models.StatusFailed {
+	if status.IsTerminal() {
 		update = update.Set(expression.Name("processed_at"), expression.Value(now))
 	}
 
@@ -238,22 +314,43 @@ func (c *Client) UpdatePaymentTransactions(ctx context.Context, paymentID, onRam
 }
 
 // UpdatePayment updates the entire payment record
+// The write is rejected if another worker holds an unexpired processing lock
 func (c *Client) UpdatePayment(ctx context.Context, payment *models.Payment) error {
 	payment.UpdatedAt = time.Now()
 
-	av, err := dynamodbattribute.MarshalMap(payment)
+	stored, err := c.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+
+	av, err := dynamodbattribute.MarshalMap(stored)
 	if err != nil {
 		logger.Error("Failed to marshal payment", logger.Fields{"error": err.Error()})
 		return errors.ErrDatabaseOperation("marshal", err)
 	}
 
+	expr, err := expression.NewBuilder().WithCondition(lockFreeCondition(payment.LockOwner)).Build()
+	if err != nil {
+		logger.Error("Failed to build condition expression", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
 	input := &dynamodb.PutItemInput{
-		TableName: aws.String(c.tableName),
-		Item:      av,
+		TableName:                 aws.String(c.tableName),
+		Item:                      av,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
 	}
 
 	_, err = c.svc.PutItemWithContext(ctx, input)
 	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			logger.Warn("Payment locked by another worker", logger.Fields{
+				"payment_id": payment.PaymentID,
+			})
+			return errors.ErrConflict(fmt.Sprintf("Payment '%s' is locked by another worker", payment.PaymentID))
+		}
 		logger.Error("Failed to update payment", logger.Fields{
 			"error":      err.Error(),
 			"payment_id": payment.PaymentID,
@@ -267,3 +364,483 @@ func (c *Client) UpdatePayment(ctx context.Context, payment *models.Payment) err
 	})
 	return nil
 }
+
+// lockFreeCondition allows a write when no other worker holds an unexpired lock
+func lockFreeCondition(owner string) expression.ConditionBuilder {
+	cond := expression.Name("lock_owner").AttributeNotExists().
+		Or(expression.Name("lock_expires_at").LessThan(expression.Value(time.Now().Unix())))
+	if owner != "" {
+		cond = cond.Or(expression.Name("lock_owner").Equal(expression.Value(owner)))
+	}
+	return cond
+}
+
+// AcquireProcessingLock claims a payment for a single worker step
+// The lock is only granted while the payment is still in expectedStatus and no
+// other worker holds an unexpired lock, so duplicate deliveries return false.
+func (c *Client) AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	update := expression.Set(expression.Name("lock_owner"), expression.Value(owner)).
+		Set(expression.Name("lock_expires_at"), expression.Value(now.Add(ttl).Unix()))
+
+	cond := expression.Name("status").Equal(expression.Value(expectedStatus)).And(
+		expression.Name("lock_owner").AttributeNotExists().
+			Or(expression.Name("lock_expires_at").LessThan(expression.Value(now.Unix()))),
+	)
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		logger.Error("Failed to build lock expression", logger.Fields{"error": err.Error()})
+		return false, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"payment_id": {
+				S: aws.String(paymentID),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return false, nil
+		}
+		logger.Error("Failed to acquire processing lock", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return false, errors.ErrDatabaseOperation("acquire_lock", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseProcessingLock clears the lock if it is still held by owner
+func (c *Client) ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error {
+	update := expression.Remove(expression.Name("lock_owner")).
+		Remove(expression.Name("lock_expires_at"))
+	cond := expression.Name("lock_owner").Equal(expression.Value(owner))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"payment_id": {
+				S: aws.String(paymentID),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		// Lock already expired and was taken over - nothing to release
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return nil
+		}
+		return errors.ErrDatabaseOperation("release_lock", err)
+	}
+
+	return nil
+}
+
+// ScanUnarchivedPayments returns terminal payments that have not been exported to the archive yet
+// Account identifiers are left as stored, so field-encrypted values reach the archive still encrypted.
+func (c *Client) ScanUnarchivedPayments(ctx context.Context) ([]*models.Payment, error) {
+	filt := expression.Name("status").In(
+		expression.Value(models.StatusCompleted),
+		expression.Value(models.StatusFailed),
+		expression.Value(models.StatusTimedOut),
+	).And(expression.Name("archived_at").AttributeNotExists())
+
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var payments []*models.Payment
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			payments = append(payments, &payment)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan unarchived payments", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return payments, nil
+}
+
+// ListPaymentAIUsage returns the AI usage of payments created in [since, until)
+func (c *Client) ListPaymentAIUsage(ctx context.Context, since, until time.Time) ([]*models.AIUsage, error) {
+	filt := expression.Name("ai_usage").AttributeExists().
+		And(expression.Name("created_at").GreaterThanEqual(expression.Value(since))).
+		And(expression.Name("created_at").LessThan(expression.Value(until)))
+	proj := expression.NamesList(expression.Name("ai_usage"))
+
+	expr, err := expression.NewBuilder().WithFilter(filt).WithProjection(proj).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var usages []*models.AIUsage
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			usages = append(usages, payment.AIUsage)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan payment AI usage", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return usages, nil
+}
+
+// ListRampTransfers returns the on-ramp and off-ramp transfers of payments created in [since, until)
+func (c *Client) ListRampTransfers(ctx context.Context, since, until time.Time) ([]*models.RampTransfer, error) {
+	filt := expression.Name("on_ramp_tx_id").AttributeExists().
+		And(expression.Name("created_at").GreaterThanEqual(expression.Value(since))).
+		And(expression.Name("created_at").LessThan(expression.Value(until)))
+
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var transfers []*models.RampTransfer
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			transfers = append(transfers, models.RampTransfersFromPayment(&payment)...)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan ramp transfers", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return transfers, nil
+}
+
+// ListCompletedPayments returns the payments that completed in [since, until)
+func (c *Client) ListCompletedPayments(ctx context.Context, since, until time.Time) ([]*models.Payment, error) {
+	filt := expression.Name("status").Equal(expression.Value(models.StatusCompleted)).
+		And(expression.Name("processed_at").GreaterThanEqual(expression.Value(since)))
+
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var payments []*models.Payment
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			// The string filter is approximate across timestamp precisions; apply the exact bounds here
+			if payment.ProcessedAt != nil && !payment.ProcessedAt.Before(since) && payment.ProcessedAt.Before(until) {
+				payments = append(payments, &payment)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan completed payments", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	if err := c.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListPaymentsByStatus returns the payments currently in status, oldest first
+func (c *Client) ListPaymentsByStatus(ctx context.Context, status models.PaymentStatus) ([]*models.Payment, error) {
+	expr, err := expression.NewBuilder().WithFilter(expression.Name("status").Equal(expression.Value(status))).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	var payments []*models.Payment
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			payments = append(payments, &payment)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan payments by status", logger.Fields{"status": status, "error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	if err := c.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	sortPaymentsByCreation(payments)
+	return payments, nil
+}
+
+// ListPaymentsByCustomer returns the customer's payments created since the given time, oldest first
+func (c *Client) ListPaymentsByCustomer(ctx context.Context, customerID string, since time.Time) ([]*models.Payment, error) {
+	filt := expression.Name("customer_id").Equal(expression.Value(customerID)).
+		And(expression.Name("created_at").GreaterThanEqual(expression.Value(since)))
+
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var payments []*models.Payment
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			// The string filter is approximate across timestamp precisions; apply the exact bound here
+			if !payment.CreatedAt.Before(since) {
+				payments = append(payments, &payment)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan customer payments", logger.Fields{"customer_id": customerID, "error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	if err := c.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	sortPaymentsByCreation(payments)
+	return payments, nil
+}
+
+// sortPaymentsByCreation orders payments oldest first
+func sortPaymentsByCreation(payments []*models.Payment) {
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].CreatedAt.Equal(payments[j].CreatedAt) {
+			return payments[i].CreatedAt.Before(payments[j].CreatedAt)
+		}
+		return payments[i].PaymentID < payments[j].PaymentID
+	})
+}
+
+// ListSettlements returns the settlements of payments with a chain that finished since the given time
+func (c *Client) ListSettlements(ctx context.Context, since time.Time) ([]*models.Settlement, error) {
+	filt := expression.Name("chain").AttributeExists().
+		And(expression.Name("processed_at").GreaterThanEqual(expression.Value(since))).
+		And(expression.Name("status").In(
+			expression.Value(models.StatusCompleted),
+			expression.Value(models.StatusTimedOut),
+		))
+	proj := expression.NamesList(
+		expression.Name("payment_id"),
+		expression.Name("chain"),
+		expression.Name("status"),
+		expression.Name("created_at"),
+		expression.Name("processed_at"),
+	)
+
+	expr, err := expression.NewBuilder().WithFilter(filt).WithProjection(proj).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var settlements []*models.Settlement
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var payment models.Payment
+			if err := dynamodbattribute.UnmarshalMap(item, &payment); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			// The string filter is approximate across timestamp precisions; apply the exact bound here
+			if settlement, ok := models.SettlementFromPayment(&payment); ok && !settlement.FinishedAt.Before(since) {
+				settlements = append(settlements, settlement)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan settlements", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal payment", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return settlements, nil
+}
+
+// MarkPaymentArchived records that a payment has been exported to the archive
+// The retention clock starts here, so a record is never expired before it has been archived.
+func (c *Client) MarkPaymentArchived(ctx context.Context, paymentID string, archivedAt time.Time) error {
+	update := expression.Set(expression.Name("archived_at"), expression.Value(archivedAt))
+	if c.retention > 0 {
+		update = update.Set(expression.Name("ttl"), expression.Value(archivedAt.Add(c.retention).Unix()))
+	}
+
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"payment_id": {
+				S: aws.String(paymentID),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		logger.Error("Failed to mark payment archived", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errors.ErrDatabaseOperation("mark_archived", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// encryptedFieldPrefix marks a field value as "enc:v2:<wrapped data key>:<nonce and ciphertext>", both base64
+// Values without it are plaintext written before encryption was enabled; they are read as-is and
+// encrypted the next time the payment is saved.
+const encryptedFieldPrefix = "enc:v2:"
+
+// legacyFieldPrefix marks values authenticated with only their field name, which could be swapped between
+// payments undetected. They stay readable and are rewritten as v2 the next time the payment is saved.
+const legacyFieldPrefix = "enc:v1:"
+
+// Encrypted payment fields, named as stored so a value can't be moved to another field and still decrypt
+// Values are also bound to their payment's ID, so they can't be moved to another payment.
+const (
+	fieldSourceAccount      = "source_account"
+	fieldDestinationAccount = "destination_account"
+)
+
+// FieldCipher envelope-encrypts account identifiers with AES-256-GCM data keys issued by KMS
+// A data key encrypts fields for at most its max age before a new one is generated, and each value
+// carries its data key wrapped by the KMS key, so rotating the KMS key (or switching to a new one)
+// never strands older values: KMS unwraps them with whichever key version wrapped them.
+type FieldCipher struct {
+	kms    kmsiface.KMSAPI
+	keyID  string
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	active    *dataKey
+	unwrapped map[string][]byte // Wrapped data key -> plaintext key, so reads don't call KMS per value
+}
+
+// dataKey is a plaintext data key and its KMS-wrapped form
+type dataKey struct {
+	plaintext []byte
+	wrapped   string
+	createdAt time.Time
+}
+
+// NewFieldCipher creates a field cipher that wraps data keys with the KMS key keyID
+func NewFieldCipher(region, keyID string, maxAge time.Duration) (*FieldCipher, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewFieldCipherWithClient(kms.New(tracing.AWSSession(sess)), keyID, maxAge), nil
+}
+
+// NewFieldCipherWithClient creates a field cipher on an existing KMS client
+func NewFieldCipherWithClient(client kmsiface.KMSAPI, keyID string, maxAge time.Duration) *FieldCipher {
+	return &FieldCipher{
+		kms:       client,
+		keyID:     keyID,
+		maxAge:    maxAge,
+		unwrapped: make(map[string][]byte),
+	}
+}
+
+// Encrypt encrypts a payment's field value; empty values are left empty
+func (f *FieldCipher) Encrypt(ctx context.Context, paymentID, field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	key, err := f.dataKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(key.plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), additionalData(paymentID, field))
+	return encryptedFieldPrefix + key.wrapped + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a payment's field value written by Encrypt; plaintext values are returned unchanged
+// It fails if the value was encrypted for another payment or field.
+func (f *FieldCipher) Decrypt(ctx context.Context, paymentID, field, value string) (string, error) {
+	var body string
+	var aad []byte
+	switch {
+	case strings.HasPrefix(value, encryptedFieldPrefix):
+		body, aad = strings.TrimPrefix(value, encryptedFieldPrefix), additionalData(paymentID, field)
+	case strings.HasPrefix(value, legacyFieldPrefix):
+		body, aad = strings.TrimPrefix(value, legacyFieldPrefix), []byte(field)
+	default:
+		return value, nil
+	}
+
+	wrapped, sealedText, ok := strings.Cut(body, ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted %s", field)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(sealedText)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted %s: %w", field, err)
+	}
+
+	plaintextKey, err := f.unwrap(ctx, wrapped)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(plaintextKey)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted %s", field)
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// additionalData is what a value is authenticated with besides its ciphertext: its payment and field
+func additionalData(paymentID, field string) []byte {
+	return []byte(paymentID + "/" + field)
+}
+
+// dataKey returns the data key new values are encrypted with, generating one if the last is too old
+func (f *FieldCipher) dataKey(ctx context.Context) (*dataKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.active != nil && time.Since(f.active.createdAt) < f.maxAge {
+		return f.active, nil
+	}
+
+	out, err := f.kms.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(f.keyID),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		logger.Error("Failed to generate data key", logger.Fields{"key_id": f.keyID, "error": err.Error()})
+		return nil, err
+	}
+
+	key := &dataKey{
+		plaintext: out.Plaintext,
+		wrapped:   base64.StdEncoding.EncodeToString(out.CiphertextBlob),
+		createdAt: time.Now(),
+	}
+	f.active = key
+	f.unwrapped[key.wrapped] = key.plaintext
+	return key, nil
+}
+
+// unwrap returns the plaintext of a wrapped data key, asking KMS the first time it is seen
+func (f *FieldCipher) unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if plaintext, ok := f.unwrapped[wrapped]; ok {
+		return plaintext, nil
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("malformed wrapped data key: %w", err)
+	}
+	out, err := f.kms.DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		logger.Error("Failed to unwrap data key", logger.Fields{"error": err.Error()})
+		return nil, err
+	}
+	f.unwrapped[wrapped] = out.Plaintext
+	return out.Plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher from a data key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EnableFieldEncryption encrypts payments' account identifiers at rest from now on
+// Records written before stay readable and are encrypted when next saved.
+func (c *Client) EnableFieldEncryption(fields *FieldCipher) {
+	c.fields = fields
+}
+
+// encryptPayment returns the payment as it is stored: a copy with its account identifiers encrypted
+// A nil cipher stores the payment as it is.
+func (f *FieldCipher) encryptPayment(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	if f == nil {
+		return payment, nil
+	}
+
+	stored := *payment
+	var err error
+	if stored.SourceAccount, err = f.Encrypt(ctx, payment.PaymentID, fieldSourceAccount, payment.SourceAccount); err != nil {
+		return nil, errors.ErrDatabaseOperation("encrypt", err)
+	}
+	if stored.DestinationAccount, err = f.Encrypt(ctx, payment.PaymentID, fieldDestinationAccount, payment.DestinationAccount); err != nil {
+		return nil, errors.ErrDatabaseOperation("encrypt", err)
+	}
+	return &stored, nil
+}
+
+// decryptPayment decrypts a stored payment's account identifiers in place
+func (f *FieldCipher) decryptPayment(ctx context.Context, payment *models.Payment) error {
+	if f == nil {
+		return nil
+	}
+
+	var err error
+	if payment.SourceAccount, err = f.Decrypt(ctx, payment.PaymentID, fieldSourceAccount, payment.SourceAccount); err != nil {
+		logger.Error("Failed to decrypt payment", logger.Fields{"payment_id": payment.PaymentID, "error": err.Error()})
+		return errors.ErrDatabaseOperation("decrypt", err)
+	}
+	if payment.DestinationAccount, err = f.Decrypt(ctx, payment.PaymentID, fieldDestinationAccount, payment.DestinationAccount); err != nil {
+		logger.Error("Failed to decrypt payment", logger.Fields{"payment_id": payment.PaymentID, "error": err.Error()})
+		return errors.ErrDatabaseOperation("decrypt", err)
+	}
+	return nil
+}
+
+// decryptPayments decrypts each stored payment's account identifiers in place
+func (f *FieldCipher) decryptPayments(ctx context.Context, payments []*models.Payment) error {
+	for _, payment := range payments {
+		if err := f.decryptPayment(ctx, payment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
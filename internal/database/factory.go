@@ -0,0 +1,551 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/archive"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/corridors"
+)
+
+// postgresClients holds one connection pool per database URL, shared by every repository in the process
+var (
+	postgresMu      sync.Mutex
+	postgresClients = make(map[string]*PostgresClient)
+)
+
+// sharedPostgresClient returns the process-wide client for databaseURL, connecting and migrating on first use
+func sharedPostgresClient(ctx context.Context, databaseURL string) (*PostgresClient, error) {
+	postgresMu.Lock()
+	defer postgresMu.Unlock()
+
+	if client, ok := postgresClients[databaseURL]; ok {
+		return client, nil
+	}
+
+	client, err := NewPostgresClient(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	postgresClients[databaseURL] = client
+	return client, nil
+}
+
+// NewRepositories builds the payment and quote repositories for the configured storage backend
+func NewRepositories(ctx context.Context, cfg *config.Config) (PaymentRepository, QuoteRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		payments, err := NewClient(cfg.AWS.Region, cfg.Database.TableName, cfg.Database.Endpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		payments.outboxTable = cfg.Database.OutboxTableName
+		payments.quoteTable = cfg.Database.QuoteTableName
+		payments.idempotencyTable = cfg.Database.IdempotencyTableName
+		quoteRepo, err := NewQuoteClient(cfg.AWS.Region, cfg.Database.QuoteTableName, cfg.Database.Endpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := enableRetention(cfg, payments); err != nil {
+			return nil, nil, err
+		}
+		if err := enableFieldEncryption(cfg, payments); err != nil {
+			return nil, nil, err
+		}
+		return payments, quoteRepo, nil
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		payments := NewPostgresPaymentRepository(client)
+		if err := enableRetention(cfg, payments); err != nil {
+			return nil, nil, err
+		}
+		if err := enableFieldEncryption(cfg, payments); err != nil {
+			return nil, nil, err
+		}
+		return payments, NewPostgresQuoteRepository(client), nil
+
+	case config.StorageMemory:
+		payments, quoteRepo := NewMemoryPaymentRepository(), NewMemoryQuoteRepository()
+		payments.EnableQuoteConsumption(quoteRepo)
+		return payments, quoteRepo, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewAuditRepository builds the audit log repository for the configured storage backend
+func NewAuditRepository(ctx context.Context, cfg *config.Config) (AuditRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewAuditClient(cfg.AWS.Region, cfg.Audit.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresAuditRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryAuditRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewFeeComparisonRepository builds the shadow-mode fee comparison repository for the configured storage backend
+func NewFeeComparisonRepository(ctx context.Context, cfg *config.Config) (FeeComparisonRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewFeeComparisonClient(cfg.AWS.Region, cfg.FeeShadow.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresFeeComparisonRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryFeeComparisonRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewFeeScheduleRepository builds the fee schedule repository for the configured storage backend
+func NewFeeScheduleRepository(ctx context.Context, cfg *config.Config) (FeeScheduleRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewFeeScheduleClient(cfg.AWS.Region, cfg.FeeSchedules.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresFeeScheduleRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryFeeScheduleRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewPromoRepository builds the promo code repository for the configured storage backend
+func NewPromoRepository(ctx context.Context, cfg *config.Config) (PromoRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewPromoClient(cfg.AWS.Region, cfg.Promos.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresPromoRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryPromoRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewVolumeRepository builds the customer volume repository for the configured storage backend
+func NewVolumeRepository(ctx context.Context, cfg *config.Config) (VolumeRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewVolumeClient(cfg.AWS.Region, cfg.VolumeDiscounts.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresVolumeRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryVolumeRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewGasPriceRepository builds the gas price history repository for the configured storage backend
+func NewGasPriceRepository(ctx context.Context, cfg *config.Config) (GasPriceRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewGasPriceClient(cfg.AWS.Region, cfg.GasHistory.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresGasPriceRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryGasPriceRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewFeeInvoiceRepository builds the fee invoice repository for the configured storage backend
+func NewFeeInvoiceRepository(ctx context.Context, cfg *config.Config) (FeeInvoiceRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewFeeInvoiceClient(cfg.AWS.Region, cfg.FeeInvoices.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresFeeInvoiceRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryFeeInvoiceRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewRevenueReportRepository builds the revenue report repository for the configured storage backend
+func NewRevenueReportRepository(ctx context.Context, cfg *config.Config) (RevenueReportRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewRevenueReportClient(cfg.AWS.Region, cfg.Reporting.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresRevenueReportRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryRevenueReportRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewTreasuryRepository builds the treasury balance repository for the configured storage backend
+func NewTreasuryRepository(ctx context.Context, cfg *config.Config) (TreasuryRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewTreasuryClient(cfg.AWS.Region, cfg.Treasury.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresTreasuryRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryTreasuryRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewLedgerRepository builds the ledger repository for the configured storage backend
+func NewLedgerRepository(ctx context.Context, cfg *config.Config) (LedgerRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewLedgerClient(cfg.AWS.Region, cfg.Ledger.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresLedgerRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryLedgerRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewCustomerRepository builds the customer repository for the configured storage backend
+func NewCustomerRepository(ctx context.Context, cfg *config.Config) (CustomerRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewCustomerClient(cfg.AWS.Region, cfg.Customers.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresCustomerRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryCustomerRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewReconciliationRepository builds the reconciliation break repository for the configured storage backend
+func NewReconciliationRepository(ctx context.Context, cfg *config.Config) (ReconciliationRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewReconciliationClient(cfg.AWS.Region, cfg.Reconciliation.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresReconciliationRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryReconciliationRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewAMLAlertRepository builds the AML alert repository for the configured storage backend
+func NewAMLAlertRepository(ctx context.Context, cfg *config.Config) (AMLAlertRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewAMLAlertClient(cfg.AWS.Region, cfg.AML.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresAMLAlertRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryAMLAlertRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewWebhookSubscriptionRepository builds the webhook subscription repository for the configured storage backend
+func NewWebhookSubscriptionRepository(ctx context.Context, cfg *config.Config) (WebhookSubscriptionRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewWebhookSubscriptionClient(cfg.AWS.Region, cfg.Webhooks.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresWebhookSubscriptionRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryWebhookSubscriptionRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewAPIKeyRepository builds the API key repository for the configured storage backend
+func NewAPIKeyRepository(ctx context.Context, cfg *config.Config) (APIKeyRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewAPIKeyClient(cfg.AWS.Region, cfg.APIKeys.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresAPIKeyRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryAPIKeyRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewUsageRepository builds the usage metering repository for the configured storage backend
+func NewUsageRepository(ctx context.Context, cfg *config.Config) (UsageRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewUsageClient(cfg.AWS.Region, cfg.Usage.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresUsageRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryUsageRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewQuotaRepository builds the quota counter repository for the configured storage backend
+func NewQuotaRepository(ctx context.Context, cfg *config.Config) (QuotaRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewQuotaClient(cfg.AWS.Region, cfg.Quotas.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresQuotaRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryQuotaRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewWebhookDeliveryRepository builds the webhook delivery repository for the configured storage backend
+func NewWebhookDeliveryRepository(ctx context.Context, cfg *config.Config) (WebhookDeliveryRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewWebhookDeliveryClient(cfg.AWS.Region, cfg.Webhooks.DeliveryTableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresWebhookDeliveryRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemoryWebhookDeliveryRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewSocketSubscriptionRepository builds the WebSocket subscription repository for the configured storage backend
+func NewSocketSubscriptionRepository(ctx context.Context, cfg *config.Config) (SocketSubscriptionRepository, error) {
+	switch cfg.Storage.Backend {
+	case config.StorageDynamoDB:
+		return NewSocketSubscriptionClient(cfg.AWS.Region, cfg.Sockets.TableName, cfg.Database.Endpoint)
+
+	case config.StoragePostgres:
+		client, err := sharedPostgresClient(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresSocketSubscriptionRepository(client), nil
+
+	case config.StorageMemory:
+		return NewMemorySocketSubscriptionRepository(), nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Storage.Backend)
+	}
+}
+
+// NewCorridorRegistry loads the supported corridors
+// Definitions come from CORRIDORS_JSON if set, else from the DynamoDB corridor table if
+// configured, else the built-in corridors.
+func NewCorridorRegistry(ctx context.Context, cfg *config.Config) (*corridors.Registry, error) {
+	switch {
+	case cfg.Corridors.Definitions != "":
+		return corridors.ParseJSON([]byte(cfg.Corridors.Definitions))
+
+	case cfg.Corridors.TableName != "":
+		client, err := NewCorridorClient(cfg.AWS.Region, cfg.Corridors.TableName, cfg.Database.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		list, err := client.ListCorridors(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("corridor table %s is empty", cfg.Corridors.TableName)
+		}
+		return corridors.NewRegistry(list)
+
+	default:
+		return corridors.Default(), nil
+	}
+}
+
+// retentionEnabler is implemented by repositories that support record expiry
+type retentionEnabler interface {
+	EnableRetention(retention time.Duration, archive Archive)
+}
+
+// enableRetention configures payment TTL and the archive lookup fallback
+func enableRetention(cfg *config.Config, repo retentionEnabler) error {
+	if cfg.Retention.Days <= 0 {
+		return nil
+	}
+
+	var store Archive
+	if cfg.Retention.ArchiveBucket != "" {
+		client, err := archive.NewClient(cfg.AWS.Region, cfg.Retention.ArchiveBucket, cfg.Retention.ArchivePrefix)
+		if err != nil {
+			return err
+		}
+		store = client
+	}
+
+	repo.EnableRetention(time.Duration(cfg.Retention.Days)*24*time.Hour, store)
+	return nil
+}
+
+// fieldEncryptionEnabler is implemented by payment repositories that can encrypt account identifiers
+type fieldEncryptionEnabler interface {
+	EnableFieldEncryption(fields *FieldCipher)
+}
+
+// enableFieldEncryption encrypts account identifiers under the configured KMS key, if any
+func enableFieldEncryption(cfg *config.Config, repo fieldEncryptionEnabler) error {
+	if cfg.Encryption.KMSKeyID == "" {
+		return nil
+	}
+
+	fields, err := NewFieldCipher(cfg.AWS.Region, cfg.Encryption.KMSKeyID, cfg.Encryption.DataKeyMaxAge)
+	if err != nil {
+		return err
+	}
+	repo.EnableFieldEncryption(fields)
+	return nil
+}
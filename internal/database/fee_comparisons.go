@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// FeeComparisonClient stores shadow-mode fee comparisons in DynamoDB
+type FeeComparisonClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewFeeComparisonClient creates a new fee comparison database client
+func NewFeeComparisonClient(region, tableName, endpoint string) (*FeeComparisonClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeComparisonClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordFeeComparison writes a comparison keyed by payment ID
+func (c *FeeComparisonClient) RecordFeeComparison(ctx context.Context, comparison *models.FeeComparison) error {
+	av, err := dynamodbattribute.MarshalMap(comparison)
+	if err != nil {
+		logger.Error("Failed to marshal fee comparison", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		logger.Error("Failed to record fee comparison", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": comparison.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("record_fee_comparison", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// FeeScheduleClient stores fee schedule versions in DynamoDB, keyed by schedule ID and version
+type FeeScheduleClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewFeeScheduleClient creates a new fee schedule database client
+func NewFeeScheduleClient(region, tableName, endpoint string) (*FeeScheduleClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeScheduleClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateFeeSchedule writes a new version, failing with a conflict if it already exists
+func (c *FeeScheduleClient) CreateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	av, err := dynamodbattribute.MarshalMap(schedule)
+	if err != nil {
+		logger.Error("Failed to marshal fee schedule", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(#version)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#version": aws.String("version"),
+		},
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Fee schedule %s version %d already exists", schedule.ScheduleID, schedule.Version))
+		}
+		logger.Error("Failed to create fee schedule", logger.Fields{
+			"error":       err.Error(),
+			"schedule_id": schedule.ScheduleID,
+		})
+		return errors.ErrDatabaseOperation("create_fee_schedule", err)
+	}
+
+	return nil
+}
+
+// ListFeeSchedules returns every version of a schedule, oldest first
+func (c *FeeScheduleClient) ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error) {
+	var versions []*models.FeeSchedule
+	var unmarshalErr error
+
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("schedule_id = :id"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id": {S: aws.String(scheduleID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var items []*models.FeeSchedule
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &items); unmarshalErr != nil {
+			return false
+		}
+		versions = append(versions, items...)
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query fee schedules", logger.Fields{"error": err.Error(), "schedule_id": scheduleID})
+		return nil, errors.ErrDatabaseOperation("query_fee_schedules", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal fee schedules", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return versions, nil
+}
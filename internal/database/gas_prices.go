@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// GasPriceClient stores gas price snapshots in DynamoDB, keyed by chain and recording time
+// Snapshots expire through the table's TTL once they leave retention.
+type GasPriceClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewGasPriceClient creates a new gas price history client
+func NewGasPriceClient(region, tableName, endpoint string) (*GasPriceClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GasPriceClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordGasPrice writes a gas price snapshot
+func (c *GasPriceClient) RecordGasPrice(ctx context.Context, snapshot *models.GasSnapshot) error {
+	av, err := dynamodbattribute.MarshalMap(snapshot)
+	if err != nil {
+		logger.Error("Failed to marshal gas snapshot", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		logger.Error("Failed to record gas price", logger.Fields{"error": err.Error(), "chain": snapshot.Chain})
+		return errors.ErrDatabaseOperation("record_gas_price", err)
+	}
+
+	return nil
+}
+
+// ListGasPrices returns a chain's snapshots recorded since the given time, oldest first
+func (c *GasPriceClient) ListGasPrices(ctx context.Context, chain string, since time.Time) ([]*models.GasSnapshot, error) {
+	var snapshots []*models.GasSnapshot
+	var unmarshalErr error
+
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("#chain = :chain AND recorded_at >= :since"),
+		ExpressionAttributeNames: map[string]*string{
+			"#chain": aws.String("chain"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":chain": {S: aws.String(chain)},
+			":since": {N: aws.String(fmt.Sprintf("%d", since.Unix()))},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var snapshot models.GasSnapshot
+			if unmarshalErr = dynamodbattribute.UnmarshalMap(item, &snapshot); unmarshalErr != nil {
+				return false
+			}
+			snapshots = append(snapshots, &snapshot)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query gas prices", logger.Fields{"error": err.Error(), "chain": chain})
+		return nil, errors.ErrDatabaseOperation("query_gas_prices", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal gas snapshot", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// FeeInvoiceClient stores fee invoices in DynamoDB, keyed by payment
+type FeeInvoiceClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewFeeInvoiceClient creates a new fee invoice database client
+func NewFeeInvoiceClient(region, tableName, endpoint string) (*FeeInvoiceClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeInvoiceClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateFeeInvoice writes a payment's fee invoice, failing with a conflict if it was already issued
+// Invoices are never overwritten, so a redelivered completion can't change what the customer was shown.
+func (c *FeeInvoiceClient) CreateFeeInvoice(ctx context.Context, invoice *models.FeeInvoice) error {
+	av, err := dynamodbattribute.MarshalMap(invoice)
+	if err != nil {
+		logger.Error("Failed to marshal fee invoice", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(payment_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Fee invoice for payment %s already exists", invoice.PaymentID))
+		}
+		logger.Error("Failed to create fee invoice", logger.Fields{"error": err.Error(), "payment_id": invoice.PaymentID})
+		return errors.ErrDatabaseOperation("create_fee_invoice", err)
+	}
+
+	return nil
+}
+
+// GetFeeInvoice retrieves a payment's fee invoice
+func (c *FeeInvoiceClient) GetFeeInvoice(ctx context.Context, paymentID string) (*models.FeeInvoice, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"payment_id": {S: aws.String(paymentID)},
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to get fee invoice", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("get_fee_invoice", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrFeeInvoiceNotFound(paymentID)
+	}
+
+	var invoice models.FeeInvoice
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &invoice); err != nil {
+		logger.Error("Failed to unmarshal fee invoice", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &invoice, nil
+}
+
+// ListFeeInvoices returns the invoices of payments completed in [since, until)
+// completed_at is stored as an RFC3339 string, so the scan filter compares strings on a window widened by a
+// second (fractional seconds don't sort lexically) and the exact bounds are applied after unmarshalling.
+func (c *FeeInvoiceClient) ListFeeInvoices(ctx context.Context, since, until time.Time) ([]*models.FeeInvoice, error) {
+	filt := expression.Name("completed_at").Between(
+		expression.Value(since.Add(-time.Second).UTC().Format(time.RFC3339)),
+		expression.Value(until.Add(time.Second).UTC().Format(time.RFC3339)),
+	)
+
+	expr, err := expression.NewBuilder().WithFilter(filt).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var invoices []*models.FeeInvoice
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var invoice models.FeeInvoice
+			if err := dynamodbattribute.UnmarshalMap(item, &invoice); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			if !invoice.CompletedAt.Before(since) && invoice.CompletedAt.Before(until) {
+				invoices = append(invoices, &invoice)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan fee invoices", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal fee invoice", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return invoices, nil
+}
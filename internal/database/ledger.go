@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// LedgerClient stores ledger transactions in DynamoDB, keyed by payment and transaction
+type LedgerClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewLedgerClient creates a new ledger database client
+func NewLedgerClient(region, tableName, endpoint string) (*LedgerClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateLedgerTransaction writes a transaction, failing with a conflict if it was already posted
+func (c *LedgerClient) CreateLedgerTransaction(ctx context.Context, txn *models.LedgerTransaction) error {
+	av, err := dynamodbattribute.MarshalMap(txn)
+	if err != nil {
+		logger.Error("Failed to marshal ledger transaction", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(transaction_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Ledger transaction %s already exists", txn.TransactionID))
+		}
+		logger.Error("Failed to create ledger transaction", logger.Fields{"error": err.Error(), "transaction_id": txn.TransactionID})
+		return errors.ErrDatabaseOperation("create_ledger_transaction", err)
+	}
+
+	return nil
+}
+
+// ListLedgerTransactions returns a payment's transactions, oldest first
+// Reads are consistent so invariants are checked against every earlier posting.
+func (c *LedgerClient) ListLedgerTransactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error) {
+	var txns []*models.LedgerTransaction
+	var unmarshalErr error
+
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("payment_id = :payment"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":payment": {S: aws.String(paymentID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var pageTxns []*models.LedgerTransaction
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageTxns); unmarshalErr != nil {
+			return false
+		}
+		txns = append(txns, pageTxns...)
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query ledger", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("query_ledger", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal ledger transactions", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sort.SliceStable(txns, func(i, j int) bool { return txns[i].CreatedAt.Before(txns[j].CreatedAt) })
+	return txns, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"crypto-conversion/internal/errors"
+)
+
+// MarketCacheClient stores fetched market data (FX rates, gas prices, provider status) in
+// DynamoDB so every Lambda instance shares one copy instead of each hitting rate-limited APIs
+type MarketCacheClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewMarketCacheClient creates a new market data cache client
+func NewMarketCacheClient(region, tableName, endpoint string) (*MarketCacheClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarketCacheClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// Get decodes the cached value for key into dest
+// DynamoDB TTL deletion lags by up to a couple of days, so expired items are filtered here.
+func (c *MarketCacheClient) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"cache_key": {S: aws.String(key)},
+		},
+	})
+	if err != nil {
+		return false, errors.ErrDatabaseOperation("get_market_cache", err)
+	}
+	if result.Item == nil || result.Item["value"] == nil || result.Item["ttl"] == nil {
+		return false, nil
+	}
+
+	expiresAt, err := strconv.ParseInt(aws.StringValue(result.Item["ttl"].N), 10, 64)
+	if err != nil || time.Now().Unix() >= expiresAt {
+		return false, nil
+	}
+
+	if err := json.Unmarshal([]byte(aws.StringValue(result.Item["value"].S)), dest); err != nil {
+		return false, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return true, nil
+}
+
+// Set stores value under key, expiring after ttl
+func (c *MarketCacheClient) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	expiresAt := time.Now().Add(ttl).Unix()
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			"cache_key": {S: aws.String(key)},
+			"value":     {S: aws.String(string(data))},
+			"ttl":       {N: aws.String(strconv.FormatInt(expiresAt, 10))},
+		},
+	})
+	if err != nil {
+		return errors.ErrDatabaseOperation("put_market_cache", err)
+	}
+	return nil
+}
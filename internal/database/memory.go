@@ -0,0 +1,1637 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+)
+
+// MemoryPaymentRepository stores payments in process memory
+// Intended for unit tests and local development; it mirrors the DynamoDB
+// conditional semantics (idempotency keys, processing locks) but nothing is persisted.
+type MemoryPaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[string]*models.Payment
+	outbox   []*models.OutboxMessage
+	quotes   *MemoryQuoteRepository // Quotes consumed by the payments created here; nil leaves them untouched
+}
+
+// NewMemoryPaymentRepository creates an empty in-memory payment repository
+func NewMemoryPaymentRepository() *MemoryPaymentRepository {
+	return &MemoryPaymentRepository{
+		payments: make(map[string]*models.Payment),
+	}
+}
+
+// CheckHealth always succeeds; memory storage is never unreachable
+func (r *MemoryPaymentRepository) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+// CreatePayment stores a new payment, rejecting duplicate idempotency keys
+func (r *MemoryPaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.createLocked(payment)
+}
+
+// EnableQuoteConsumption marks the quote a payment was made from consumed when the payment is created
+func (r *MemoryPaymentRepository) EnableQuoteConsumption(quotes *MemoryQuoteRepository) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.quotes = quotes
+}
+
+// CreatePaymentWithOutbox stores a new payment and its outbox message together
+// A payment made from a quote also marks the quote consumed, failing if another payment already used it.
+func (r *MemoryPaymentRepository) CreatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payment.QuoteID != "" && r.quotes != nil {
+		r.quotes.mu.Lock()
+		defer r.quotes.mu.Unlock()
+
+		if quote, ok := r.quotes.quotes[payment.QuoteID]; !ok || quote.ConsumedAt != nil {
+			return errors.ErrQuoteConsumed(payment.QuoteID)
+		}
+	}
+	if err := r.createLocked(payment); err != nil {
+		return err
+	}
+	if payment.QuoteID != "" && r.quotes != nil {
+		quote := r.quotes.quotes[payment.QuoteID]
+		consumedAt := payment.CreatedAt
+		quote.ConsumedAt = &consumedAt
+		quote.PaymentID = payment.PaymentID
+	}
+
+	r.outbox = append(r.outbox, tracedOutbox(ctx, msg))
+	return nil
+}
+
+// CreatePaymentsWithOutbox stores a batch of payments and their outbox message together, or none of them
+func (r *MemoryPaymentRepository) CreatePaymentsWithOutbox(ctx context.Context, payments []*models.Payment, msg *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.quotes != nil {
+		r.quotes.mu.Lock()
+		defer r.quotes.mu.Unlock()
+	}
+
+	// Check the whole batch before writing any of it
+	keys := make(map[string]bool, len(payments))
+	quoteIDs := make(map[string]bool)
+	for _, payment := range payments {
+		if keys[payment.IdempotencyKey] {
+			return errors.ErrDuplicateRequest(payment.IdempotencyKey)
+		}
+		keys[payment.IdempotencyKey] = true
+		for _, existing := range r.payments {
+			if sameIdempotencyKey(existing, payment.IdempotencyScope, payment.IdempotencyKey) {
+				return errors.ErrDuplicateRequest(payment.IdempotencyKey)
+			}
+		}
+
+		if payment.QuoteID != "" && r.quotes != nil {
+			if quote, ok := r.quotes.quotes[payment.QuoteID]; !ok || quote.ConsumedAt != nil || quoteIDs[payment.QuoteID] {
+				return errors.ErrQuoteConsumed(payment.QuoteID)
+			}
+			quoteIDs[payment.QuoteID] = true
+		}
+	}
+
+	for _, payment := range payments {
+		r.payments[payment.PaymentID] = copyPayment(payment)
+		if payment.QuoteID != "" && r.quotes != nil {
+			quote := r.quotes.quotes[payment.QuoteID]
+			consumedAt := payment.CreatedAt
+			quote.ConsumedAt = &consumedAt
+			quote.PaymentID = payment.PaymentID
+		}
+	}
+
+	r.outbox = append(r.outbox, tracedOutbox(ctx, msg))
+	return nil
+}
+
+// createLocked inserts a payment; the caller must hold the write lock
+func (r *MemoryPaymentRepository) createLocked(payment *models.Payment) error {
+	for _, existing := range r.payments {
+		if sameIdempotencyKey(existing, payment.IdempotencyScope, payment.IdempotencyKey) {
+			return errors.ErrDuplicateRequest(payment.IdempotencyKey)
+		}
+	}
+
+	r.payments[payment.PaymentID] = copyPayment(payment)
+	return nil
+}
+
+// GetPaymentByID retrieves a payment by its ID
+func (r *MemoryPaymentRepository) GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[paymentID]
+	if !ok {
+		return nil, errors.ErrPaymentNotFound(paymentID)
+	}
+	return copyPayment(payment), nil
+}
+
+// GetPaymentByIdempotencyKey retrieves a payment by its idempotency key within an API key's scope
+func (r *MemoryPaymentRepository) GetPaymentByIdempotencyKey(ctx context.Context, scope, idempotencyKey string) (*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, payment := range r.payments {
+		if sameIdempotencyKey(payment, scope, idempotencyKey) {
+			return copyPayment(payment), nil
+		}
+	}
+	return nil, nil // Not found, but not an error
+}
+
+// sameIdempotencyKey reports whether a payment was created under the idempotency key in the scope
+func sameIdempotencyKey(payment *models.Payment, scope, idempotencyKey string) bool {
+	return payment.IdempotencyScope == scope && payment.IdempotencyKey == idempotencyKey
+}
+
+// UpdatePayment replaces a payment unless another worker holds an unexpired lock
+func (r *MemoryPaymentRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.updateLocked(payment)
+}
+
+// UpdatePaymentWithOutbox replaces a payment and queues an outbox message together
+func (r *MemoryPaymentRepository) UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.updateLocked(payment); err != nil {
+		return err
+	}
+
+	r.outbox = append(r.outbox, tracedOutbox(ctx, msg))
+	return nil
+}
+
+// updateLocked replaces a payment; the caller must hold the write lock
+func (r *MemoryPaymentRepository) updateLocked(payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+
+	if existing, ok := r.payments[payment.PaymentID]; ok && lockHeldByOther(existing, payment.LockOwner) {
+		return errors.ErrConflict(fmt.Sprintf("Payment '%s' is locked by another worker", payment.PaymentID))
+	}
+
+	r.payments[payment.PaymentID] = copyPayment(payment)
+	return nil
+}
+
+// AcquireProcessingLock claims a payment if it is still in expectedStatus and unlocked
+func (r *MemoryPaymentRepository) AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[paymentID]
+	if !ok || payment.Status != expectedStatus || lockHeldByOther(payment, "") {
+		return false, nil
+	}
+
+	payment.LockOwner = owner
+	payment.LockExpiresAt = time.Now().Add(ttl).Unix()
+	return true, nil
+}
+
+// ReleaseProcessingLock clears the lock if it is still held by owner
+func (r *MemoryPaymentRepository) ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payment, ok := r.payments[paymentID]; ok && payment.LockOwner == owner {
+		payment.LockOwner = ""
+		payment.LockExpiresAt = 0
+	}
+	return nil
+}
+
+// ScanUnarchivedPayments returns terminal payments that have not been exported to the archive yet
+func (r *MemoryPaymentRepository) ScanUnarchivedPayments(ctx context.Context) ([]*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.Payment
+	for _, payment := range r.payments {
+		if payment.Status.IsTerminal() && payment.ArchivedAt == nil {
+			payments = append(payments, copyPayment(payment))
+		}
+	}
+	return payments, nil
+}
+
+// ListPaymentAIUsage returns the AI usage of payments created in [since, until)
+func (r *MemoryPaymentRepository) ListPaymentAIUsage(ctx context.Context, since, until time.Time) ([]*models.AIUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var usages []*models.AIUsage
+	for _, payment := range r.payments {
+		if payment.AIUsage != nil && !payment.CreatedAt.Before(since) && payment.CreatedAt.Before(until) {
+			usage := *payment.AIUsage
+			usages = append(usages, &usage)
+		}
+	}
+	return usages, nil
+}
+
+// ListRampTransfers returns the on-ramp and off-ramp transfers of payments created in [since, until)
+func (r *MemoryPaymentRepository) ListRampTransfers(ctx context.Context, since, until time.Time) ([]*models.RampTransfer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var transfers []*models.RampTransfer
+	for _, payment := range r.payments {
+		if !payment.CreatedAt.Before(since) && payment.CreatedAt.Before(until) {
+			transfers = append(transfers, models.RampTransfersFromPayment(payment)...)
+		}
+	}
+	return transfers, nil
+}
+
+// ListCompletedPayments returns the payments that completed in [since, until)
+func (r *MemoryPaymentRepository) ListCompletedPayments(ctx context.Context, since, until time.Time) ([]*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.Payment
+	for _, payment := range r.payments {
+		if payment.Status != models.StatusCompleted || payment.ProcessedAt == nil {
+			continue
+		}
+		if !payment.ProcessedAt.Before(since) && payment.ProcessedAt.Before(until) {
+			payments = append(payments, copyPayment(payment))
+		}
+	}
+	return payments, nil
+}
+
+// ListPaymentsByStatus returns the payments currently in status, oldest first
+func (r *MemoryPaymentRepository) ListPaymentsByStatus(ctx context.Context, status models.PaymentStatus) ([]*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.Payment
+	for _, payment := range r.payments {
+		if payment.Status == status {
+			payments = append(payments, copyPayment(payment))
+		}
+	}
+	sortPaymentsByCreation(payments)
+	return payments, nil
+}
+
+// ListPaymentsByCustomer returns the customer's payments created since the given time, oldest first
+func (r *MemoryPaymentRepository) ListPaymentsByCustomer(ctx context.Context, customerID string, since time.Time) ([]*models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var payments []*models.Payment
+	for _, payment := range r.payments {
+		if payment.CustomerID == customerID && !payment.CreatedAt.Before(since) {
+			payments = append(payments, copyPayment(payment))
+		}
+	}
+	sortPaymentsByCreation(payments)
+	return payments, nil
+}
+
+// ListSettlements returns the settlements of payments with a chain that finished since the given time
+func (r *MemoryPaymentRepository) ListSettlements(ctx context.Context, since time.Time) ([]*models.Settlement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var settlements []*models.Settlement
+	for _, payment := range r.payments {
+		if settlement, ok := models.SettlementFromPayment(payment); ok && !settlement.FinishedAt.Before(since) {
+			settlements = append(settlements, settlement)
+		}
+	}
+	return settlements, nil
+}
+
+// MarkPaymentArchived records that a payment has been exported to the archive
+func (r *MemoryPaymentRepository) MarkPaymentArchived(ctx context.Context, paymentID string, archivedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[paymentID]
+	if !ok {
+		return errors.ErrPaymentNotFound(paymentID)
+	}
+	payment.ArchivedAt = &archivedAt
+	return nil
+}
+
+// ListOutboxMessages returns up to limit undelivered outbox messages, oldest first
+func (r *MemoryPaymentRepository) ListOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var messages []*models.OutboxMessage
+	for _, msg := range r.outbox {
+		if len(messages) >= limit {
+			break
+		}
+		clone := *msg
+		messages = append(messages, &clone)
+	}
+	return messages, nil
+}
+
+// DeleteOutboxMessage removes a delivered outbox message
+func (r *MemoryPaymentRepository) DeleteOutboxMessage(ctx context.Context, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, msg := range r.outbox {
+		if msg.MessageID == messageID {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// lockHeldByOther reports whether someone other than owner holds an unexpired lock
+func lockHeldByOther(payment *models.Payment, owner string) bool {
+	if payment.LockOwner == "" || payment.LockOwner == owner {
+		return false
+	}
+	return payment.LockExpiresAt >= time.Now().Unix()
+}
+
+// copyPayment returns a copy so callers cannot mutate stored records
+func copyPayment(payment *models.Payment) *models.Payment {
+	clone := *payment
+	clone.StateHistory = append([]models.StateTransition(nil), payment.StateHistory...)
+	return &clone
+}
+
+// MemoryQuoteRepository stores quotes in process memory
+type MemoryQuoteRepository struct {
+	mu     sync.RWMutex
+	quotes map[string]*quotes.Quote
+}
+
+// NewMemoryQuoteRepository creates an empty in-memory quote repository
+func NewMemoryQuoteRepository() *MemoryQuoteRepository {
+	return &MemoryQuoteRepository{
+		quotes: make(map[string]*quotes.Quote),
+	}
+}
+
+// CheckHealth always succeeds; memory storage is never unreachable
+func (r *MemoryQuoteRepository) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+// CreateQuote stores a new quote
+func (r *MemoryQuoteRepository) CreateQuote(ctx context.Context, quote *quotes.Quote) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := *quote
+	r.quotes[quote.QuoteID] = &clone
+	return nil
+}
+
+// GetQuote retrieves a quote by ID, treating TTL-expired quotes as missing
+func (r *MemoryQuoteRepository) GetQuote(ctx context.Context, quoteID string) (*quotes.Quote, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quote, ok := r.quotes[quoteID]
+	if !ok || (quote.TTL > 0 && quote.TTL < time.Now().Unix()) {
+		return nil, errors.ErrQuoteNotFound(quoteID)
+	}
+
+	clone := *quote
+	return &clone, nil
+}
+
+// MarkQuoteConsumed records the payment that used a quote
+func (r *MemoryQuoteRepository) MarkQuoteConsumed(ctx context.Context, quoteID, paymentID string, consumedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quote, ok := r.quotes[quoteID]
+	if !ok || quote.ConsumedAt != nil {
+		return errors.ErrQuoteConsumed(quoteID)
+	}
+
+	quote.ConsumedAt = &consumedAt
+	quote.PaymentID = paymentID
+	return nil
+}
+
+// SetQuoteAIUsage records the accumulated AI spend on a quote
+func (r *MemoryQuoteRepository) SetQuoteAIUsage(ctx context.Context, quoteID string, usage *models.AIUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quote, ok := r.quotes[quoteID]
+	if !ok {
+		return errors.ErrQuoteNotFound(quoteID)
+	}
+	clone := *usage
+	quote.AIUsage = &clone
+	return nil
+}
+
+// MemoryAuditRepository stores audit entries in process memory
+type MemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []*models.AuditEntry
+}
+
+// NewMemoryAuditRepository creates an empty in-memory audit repository
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+// AppendAuditEntry stores an entry, failing with a conflict unless it is the next sequence
+func (r *MemoryAuditRepository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.Sequence != int64(len(r.entries))+1 {
+		return errors.ErrConflict(fmt.Sprintf("Audit sequence %d already written", entry.Sequence))
+	}
+
+	clone := *entry
+	r.entries = append(r.entries, &clone)
+	return nil
+}
+
+// GetLatestAuditEntry returns the most recent entry, or nil if the log is empty
+func (r *MemoryAuditRepository) GetLatestAuditEntry(ctx context.Context) (*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.entries) == 0 {
+		return nil, nil
+	}
+
+	clone := *r.entries[len(r.entries)-1]
+	return &clone, nil
+}
+
+// ListAuditEntries returns up to limit entries after the given sequence, oldest first
+func (r *MemoryAuditRepository) ListAuditEntries(ctx context.Context, afterSequence int64, limit int) ([]*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*models.AuditEntry
+	for _, entry := range r.entries {
+		if entry.Sequence <= afterSequence {
+			continue
+		}
+		if len(entries) == limit {
+			break
+		}
+		clone := *entry
+		entries = append(entries, &clone)
+	}
+	return entries, nil
+}
+
+// MemoryFeeScheduleRepository stores fee schedule versions in process memory
+type MemoryFeeScheduleRepository struct {
+	mu        sync.RWMutex
+	schedules map[string][]*models.FeeSchedule
+}
+
+// NewMemoryFeeScheduleRepository creates an empty in-memory fee schedule repository
+func NewMemoryFeeScheduleRepository() *MemoryFeeScheduleRepository {
+	return &MemoryFeeScheduleRepository{schedules: make(map[string][]*models.FeeSchedule)}
+}
+
+// CreateFeeSchedule stores a new version, failing with a conflict if it already exists
+func (r *MemoryFeeScheduleRepository) CreateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.schedules[schedule.ScheduleID] {
+		if existing.Version == schedule.Version {
+			return errors.ErrConflict(fmt.Sprintf("Fee schedule %s version %d already exists", schedule.ScheduleID, schedule.Version))
+		}
+	}
+
+	clone := *schedule
+	versions := append(r.schedules[schedule.ScheduleID], &clone)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	r.schedules[schedule.ScheduleID] = versions
+	return nil
+}
+
+// ListFeeSchedules returns every version of a schedule, oldest first
+func (r *MemoryFeeScheduleRepository) ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]*models.FeeSchedule, 0, len(r.schedules[scheduleID]))
+	for _, schedule := range r.schedules[scheduleID] {
+		clone := *schedule
+		versions = append(versions, &clone)
+	}
+	return versions, nil
+}
+
+// MemoryPromoRepository stores promo codes in process memory
+type MemoryPromoRepository struct {
+	mu     sync.Mutex
+	promos map[string]*models.Promo
+}
+
+// NewMemoryPromoRepository creates an empty in-memory promo code repository
+func NewMemoryPromoRepository() *MemoryPromoRepository {
+	return &MemoryPromoRepository{promos: make(map[string]*models.Promo)}
+}
+
+// CreatePromo stores a new promo code, failing with a conflict if the code exists
+func (r *MemoryPromoRepository) CreatePromo(ctx context.Context, promo *models.Promo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.promos[promo.Code]; exists {
+		return errors.ErrConflict(fmt.Sprintf("Promo code %s already exists", promo.Code))
+	}
+	clone := *promo
+	r.promos[promo.Code] = &clone
+	return nil
+}
+
+// GetPromo retrieves a promo code
+func (r *MemoryPromoRepository) GetPromo(ctx context.Context, code string) (*models.Promo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promo, ok := r.promos[code]
+	if !ok {
+		return nil, errors.ErrPromoNotFound(code)
+	}
+	clone := *promo
+	return &clone, nil
+}
+
+// RedeemPromo counts one use of a promo code, failing with a conflict once its uses run out
+func (r *MemoryPromoRepository) RedeemPromo(ctx context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	promo, ok := r.promos[code]
+	if !ok || (promo.MaxUses > 0 && promo.Uses >= promo.MaxUses) {
+		return errors.ErrConflict(fmt.Sprintf("Promo code %s has no uses left", code))
+	}
+	promo.Uses++
+	return nil
+}
+
+// ReleasePromo gives back one use of a promo code redeemed for a payment that was never created
+func (r *MemoryPromoRepository) ReleasePromo(ctx context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if promo, ok := r.promos[code]; ok && promo.Uses > 0 {
+		promo.Uses--
+	}
+	return nil
+}
+
+// MemoryVolumeRepository stores completed payment volume in process memory
+type MemoryVolumeRepository struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]models.VolumeEntry // customer ID -> payment ID -> entry
+}
+
+// NewMemoryVolumeRepository creates an empty in-memory customer volume repository
+func NewMemoryVolumeRepository() *MemoryVolumeRepository {
+	return &MemoryVolumeRepository{entries: make(map[string]map[string]models.VolumeEntry)}
+}
+
+// RecordVolume stores a completed payment's volume entry; rewriting the same payment is a no-op
+func (r *MemoryVolumeRepository) RecordVolume(ctx context.Context, entry *models.VolumeEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries[entry.CustomerID] == nil {
+		r.entries[entry.CustomerID] = make(map[string]models.VolumeEntry)
+	}
+	if _, exists := r.entries[entry.CustomerID][entry.PaymentID]; !exists {
+		r.entries[entry.CustomerID][entry.PaymentID] = *entry
+	}
+	return nil
+}
+
+// RollingVolume sums a customer's completed payment volume since the given time, in USD cents
+func (r *MemoryVolumeRepository) RollingVolume(ctx context.Context, customerID string, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total int64
+	for _, entry := range r.entries[customerID] {
+		if !entry.CompletedAt.Before(since) {
+			total += entry.AmountUSD
+		}
+	}
+	return total, nil
+}
+
+// MemoryGasPriceRepository stores gas price snapshots in process memory
+type MemoryGasPriceRepository struct {
+	mu        sync.RWMutex
+	snapshots map[string]map[int64]models.GasSnapshot // chain -> recorded second -> snapshot
+}
+
+// NewMemoryGasPriceRepository creates an empty in-memory gas price history repository
+func NewMemoryGasPriceRepository() *MemoryGasPriceRepository {
+	return &MemoryGasPriceRepository{snapshots: make(map[string]map[int64]models.GasSnapshot)}
+}
+
+// RecordGasPrice stores a gas price snapshot, replacing any reading for the same chain and second
+func (r *MemoryGasPriceRepository) RecordGasPrice(ctx context.Context, snapshot *models.GasSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.snapshots[snapshot.Chain] == nil {
+		r.snapshots[snapshot.Chain] = make(map[int64]models.GasSnapshot)
+	}
+	r.snapshots[snapshot.Chain][snapshot.RecordedAt.Unix()] = *snapshot
+	return nil
+}
+
+// ListGasPrices returns a chain's snapshots recorded since the given time, oldest first
+func (r *MemoryGasPriceRepository) ListGasPrices(ctx context.Context, chain string, since time.Time) ([]*models.GasSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var snapshots []*models.GasSnapshot
+	for _, snapshot := range r.snapshots[chain] {
+		if !snapshot.RecordedAt.Before(since) {
+			clone := snapshot
+			snapshots = append(snapshots, &clone)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].RecordedAt.Before(snapshots[j].RecordedAt) })
+	return snapshots, nil
+}
+
+// MemoryMarketCache stores market data in process memory
+// It mirrors MarketCacheClient's expiry semantics for tests and local development.
+type MemoryMarketCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryMarketCache creates an empty in-memory market data cache
+func NewMemoryMarketCache() *MemoryMarketCache {
+	return &MemoryMarketCache{
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+// Get decodes the cached value for key into dest
+func (c *MemoryMarketCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return false, nil
+	}
+	if err := json.Unmarshal(entry.value, dest); err != nil {
+		return false, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return true, nil
+}
+
+// Set stores value under key, expiring after ttl
+func (c *MemoryMarketCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = memoryCacheEntry{value: data, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// MemoryFeeComparisonRepository stores shadow-mode fee comparisons in process memory
+type MemoryFeeComparisonRepository struct {
+	mu          sync.RWMutex
+	comparisons map[string]*models.FeeComparison
+}
+
+// NewMemoryFeeComparisonRepository creates an empty in-memory fee comparison repository
+func NewMemoryFeeComparisonRepository() *MemoryFeeComparisonRepository {
+	return &MemoryFeeComparisonRepository{comparisons: make(map[string]*models.FeeComparison)}
+}
+
+// RecordFeeComparison stores a comparison keyed by payment ID
+func (r *MemoryFeeComparisonRepository) RecordFeeComparison(ctx context.Context, comparison *models.FeeComparison) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clone := *comparison
+	r.comparisons[comparison.PaymentID] = &clone
+	return nil
+}
+
+// GetFeeComparison returns the comparison for a payment, or nil if none was recorded
+func (r *MemoryFeeComparisonRepository) GetFeeComparison(paymentID string) *models.FeeComparison {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comparison, ok := r.comparisons[paymentID]
+	if !ok {
+		return nil
+	}
+	clone := *comparison
+	return &clone
+}
+
+// MemoryFeeInvoiceRepository stores fee invoices in process memory
+type MemoryFeeInvoiceRepository struct {
+	mu       sync.Mutex
+	invoices map[string]*models.FeeInvoice
+}
+
+// NewMemoryFeeInvoiceRepository creates an empty in-memory fee invoice repository
+func NewMemoryFeeInvoiceRepository() *MemoryFeeInvoiceRepository {
+	return &MemoryFeeInvoiceRepository{invoices: make(map[string]*models.FeeInvoice)}
+}
+
+// CreateFeeInvoice stores a payment's fee invoice, failing with a conflict if it was already issued
+func (r *MemoryFeeInvoiceRepository) CreateFeeInvoice(ctx context.Context, invoice *models.FeeInvoice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.invoices[invoice.PaymentID]; exists {
+		return errors.ErrConflict(fmt.Sprintf("Fee invoice for payment %s already exists", invoice.PaymentID))
+	}
+	clone := *invoice
+	r.invoices[invoice.PaymentID] = &clone
+	return nil
+}
+
+// GetFeeInvoice retrieves a payment's fee invoice
+func (r *MemoryFeeInvoiceRepository) GetFeeInvoice(ctx context.Context, paymentID string) (*models.FeeInvoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invoice, ok := r.invoices[paymentID]
+	if !ok {
+		return nil, errors.ErrFeeInvoiceNotFound(paymentID)
+	}
+	clone := *invoice
+	return &clone, nil
+}
+
+// ListFeeInvoices returns the invoices of payments completed in [since, until)
+func (r *MemoryFeeInvoiceRepository) ListFeeInvoices(ctx context.Context, since, until time.Time) ([]*models.FeeInvoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invoices []*models.FeeInvoice
+	for _, invoice := range r.invoices {
+		if !invoice.CompletedAt.Before(since) && invoice.CompletedAt.Before(until) {
+			clone := *invoice
+			invoices = append(invoices, &clone)
+		}
+	}
+	return invoices, nil
+}
+
+// MemoryRevenueReportRepository stores revenue report rows in process memory
+type MemoryRevenueReportRepository struct {
+	mu      sync.Mutex
+	reports map[string]map[string]*models.RevenueReport // report date -> corridor#customer -> row
+}
+
+// NewMemoryRevenueReportRepository creates an empty in-memory revenue report repository
+func NewMemoryRevenueReportRepository() *MemoryRevenueReportRepository {
+	return &MemoryRevenueReportRepository{reports: make(map[string]map[string]*models.RevenueReport)}
+}
+
+// PutRevenueReports stores report rows, replacing any earlier rows for the same date, corridor and customer
+func (r *MemoryRevenueReportRepository) PutRevenueReports(ctx context.Context, reports []*models.RevenueReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, report := range reports {
+		day, ok := r.reports[report.ReportDate]
+		if !ok {
+			day = make(map[string]*models.RevenueReport)
+			r.reports[report.ReportDate] = day
+		}
+		clone := *report
+		day[revenueReportKey(report)] = &clone
+	}
+	return nil
+}
+
+// ListRevenueReports returns every row of a day's report, ordered by corridor and customer
+func (r *MemoryRevenueReportRepository) ListRevenueReports(ctx context.Context, reportDate string) ([]*models.RevenueReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reports []*models.RevenueReport
+	for _, report := range r.reports[reportDate] {
+		clone := *report
+		reports = append(reports, &clone)
+	}
+	sort.Slice(reports, func(i, j int) bool { return revenueReportKey(reports[i]) < revenueReportKey(reports[j]) })
+	return reports, nil
+}
+
+// MemoryTreasuryRepository stores treasury balances in process memory
+type MemoryTreasuryRepository struct {
+	mu        sync.Mutex
+	balances  map[string]*models.TreasuryBalance // account -> balance
+	movements map[string]bool                    // applied movement IDs
+}
+
+// NewMemoryTreasuryRepository creates an empty in-memory treasury repository
+func NewMemoryTreasuryRepository() *MemoryTreasuryRepository {
+	return &MemoryTreasuryRepository{
+		balances:  make(map[string]*models.TreasuryBalance),
+		movements: make(map[string]bool),
+	}
+}
+
+// ApplyTreasuryMovement adds a movement's entries to their balances, reporting false if it was already applied
+func (r *MemoryTreasuryRepository) ApplyTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.movements[movement.MovementID] {
+		return false, nil
+	}
+	r.applyLocked(movement)
+	return true, nil
+}
+
+// ReserveTreasuryMovement applies a movement only if every account it debits covers the debit
+// It reports true once the movement has been applied, now or before, and false if an account is short.
+func (r *MemoryTreasuryRepository) ReserveTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.movements[movement.MovementID] {
+		return true, nil
+	}
+	for _, entry := range movement.Entries {
+		if entry.Amount >= 0 {
+			continue
+		}
+		balance, ok := r.balances[entry.Account()]
+		if !ok || balance.Balance < -entry.Amount {
+			return false, nil
+		}
+	}
+	r.applyLocked(movement)
+	return true, nil
+}
+
+// applyLocked records a movement and adds its entries to their balances; r.mu must be held
+func (r *MemoryTreasuryRepository) applyLocked(movement *models.TreasuryMovement) {
+	r.movements[movement.MovementID] = true
+
+	now := time.Now().UTC()
+	for _, entry := range movement.Entries {
+		balance, ok := r.balances[entry.Account()]
+		if !ok {
+			balance = &models.TreasuryBalance{
+				Account:  entry.Account(),
+				Kind:     entry.Kind,
+				Location: entry.Location,
+				Currency: entry.Currency,
+			}
+			r.balances[entry.Account()] = balance
+		}
+		balance.Balance += entry.Amount
+		balance.UpdatedAt = now
+	}
+}
+
+// GetTreasuryBalance retrieves an account's balance, or nil if nothing has moved through it
+func (r *MemoryTreasuryRepository) GetTreasuryBalance(ctx context.Context, account string) (*models.TreasuryBalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	balance, ok := r.balances[account]
+	if !ok {
+		return nil, nil
+	}
+	clone := *balance
+	return &clone, nil
+}
+
+// ListTreasuryBalances returns every tracked balance, ordered by account
+func (r *MemoryTreasuryRepository) ListTreasuryBalances(ctx context.Context) ([]*models.TreasuryBalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var balances []*models.TreasuryBalance
+	for _, balance := range r.balances {
+		clone := *balance
+		balances = append(balances, &clone)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Account < balances[j].Account })
+	return balances, nil
+}
+
+// MemoryLedgerRepository stores ledger transactions in process memory
+type MemoryLedgerRepository struct {
+	mu   sync.Mutex
+	txns map[string][]*models.LedgerTransaction // payment ID -> transactions, oldest first
+}
+
+// NewMemoryLedgerRepository creates an empty in-memory ledger repository
+func NewMemoryLedgerRepository() *MemoryLedgerRepository {
+	return &MemoryLedgerRepository{txns: make(map[string][]*models.LedgerTransaction)}
+}
+
+// CreateLedgerTransaction stores a transaction, failing with a conflict if it was already posted
+func (r *MemoryLedgerRepository) CreateLedgerTransaction(ctx context.Context, txn *models.LedgerTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, posted := range r.txns[txn.PaymentID] {
+		if posted.TransactionID == txn.TransactionID {
+			return errors.ErrConflict(fmt.Sprintf("Ledger transaction %s already exists", txn.TransactionID))
+		}
+	}
+	clone := *txn
+	clone.Entries = append([]models.LedgerEntry(nil), txn.Entries...)
+	r.txns[txn.PaymentID] = append(r.txns[txn.PaymentID], &clone)
+	return nil
+}
+
+// ListLedgerTransactions returns a payment's transactions, oldest first
+func (r *MemoryLedgerRepository) ListLedgerTransactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var txns []*models.LedgerTransaction
+	for _, txn := range r.txns[paymentID] {
+		clone := *txn
+		txns = append(txns, &clone)
+	}
+	return txns, nil
+}
+
+// MemoryReconciliationRepository stores reconciliation breaks in process memory
+type MemoryReconciliationRepository struct {
+	mu     sync.Mutex
+	breaks map[string]*models.ReconciliationBreak
+}
+
+// NewMemoryReconciliationRepository creates an empty in-memory reconciliation break repository
+func NewMemoryReconciliationRepository() *MemoryReconciliationRepository {
+	return &MemoryReconciliationRepository{breaks: make(map[string]*models.ReconciliationBreak)}
+}
+
+// RecordBreak stores a break, returning false if it was already recorded
+func (r *MemoryReconciliationRepository) RecordBreak(ctx context.Context, brk *models.ReconciliationBreak) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.breaks[brk.BreakID]; exists {
+		return false, nil
+	}
+	clone := *brk
+	r.breaks[brk.BreakID] = &clone
+	return true, nil
+}
+
+// GetBreak retrieves a break
+func (r *MemoryReconciliationRepository) GetBreak(ctx context.Context, breakID string) (*models.ReconciliationBreak, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	brk, ok := r.breaks[breakID]
+	if !ok {
+		return nil, errors.ErrBreakNotFound(breakID)
+	}
+	clone := *brk
+	return &clone, nil
+}
+
+// ListBreaks returns the breaks with a status, or every break if status is empty, oldest first
+func (r *MemoryReconciliationRepository) ListBreaks(ctx context.Context, status string) ([]*models.ReconciliationBreak, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var breaks []*models.ReconciliationBreak
+	for _, brk := range r.breaks {
+		if status == "" || brk.Status == status {
+			clone := *brk
+			breaks = append(breaks, &clone)
+		}
+	}
+	sortBreaks(breaks)
+	return breaks, nil
+}
+
+// ResolveBreak marks an open break resolved, failing with a conflict if it already was
+func (r *MemoryReconciliationRepository) ResolveBreak(ctx context.Context, breakID, resolution, resolvedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	brk, ok := r.breaks[breakID]
+	if !ok {
+		return errors.ErrBreakNotFound(breakID)
+	}
+	if brk.Status != models.BreakStatusOpen {
+		return errors.ErrConflict(fmt.Sprintf("Reconciliation break %s is already resolved", breakID))
+	}
+	brk.Status = models.BreakStatusResolved
+	brk.Resolution = resolution
+	brk.ResolvedBy = resolvedBy
+	brk.ResolvedAt = &at
+	return nil
+}
+
+// MemoryCustomerRepository stores customers in process memory
+type MemoryCustomerRepository struct {
+	mu        sync.Mutex
+	customers map[string]*models.Customer
+}
+
+// NewMemoryCustomerRepository creates an empty in-memory customer repository
+func NewMemoryCustomerRepository() *MemoryCustomerRepository {
+	return &MemoryCustomerRepository{customers: make(map[string]*models.Customer)}
+}
+
+// copyCustomer returns a copy of customer that shares no slices with it
+func copyCustomer(customer *models.Customer) *models.Customer {
+	clone := *customer
+	clone.WebhookSecrets = append([]string(nil), customer.WebhookSecrets...)
+	clone.Accounts = append([]models.CustomerAccount(nil), customer.Accounts...)
+	clone.AllowedIPs = append([]string(nil), customer.AllowedIPs...)
+	return &clone
+}
+
+// CreateCustomer stores a new customer, failing with a conflict if the ID exists
+func (r *MemoryCustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.customers[customer.CustomerID]; exists {
+		return errors.ErrConflict(fmt.Sprintf("Customer %s already exists", customer.CustomerID))
+	}
+	r.customers[customer.CustomerID] = copyCustomer(customer)
+	return nil
+}
+
+// GetCustomer retrieves a customer
+func (r *MemoryCustomerRepository) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return nil, errors.ErrCustomerNotFound(customerID)
+	}
+	return copyCustomer(customer), nil
+}
+
+// ListCustomers returns every customer, ordered by ID
+func (r *MemoryCustomerRepository) ListCustomers(ctx context.Context) ([]*models.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customers := make([]*models.Customer, 0, len(r.customers))
+	for _, customer := range r.customers {
+		customers = append(customers, copyCustomer(customer))
+	}
+	sort.Slice(customers, func(i, j int) bool { return customers[i].CustomerID < customers[j].CustomerID })
+	return customers, nil
+}
+
+// UpdateCustomer replaces an existing customer
+func (r *MemoryCustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.customers[customer.CustomerID]; !exists {
+		return errors.ErrCustomerNotFound(customer.CustomerID)
+	}
+	r.customers[customer.CustomerID] = copyCustomer(customer)
+	return nil
+}
+
+// DeleteCustomer removes a customer
+func (r *MemoryCustomerRepository) DeleteCustomer(ctx context.Context, customerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.customers[customerID]; !exists {
+		return errors.ErrCustomerNotFound(customerID)
+	}
+	delete(r.customers, customerID)
+	return nil
+}
+
+// MemoryAMLAlertRepository stores AML alerts in process memory
+type MemoryAMLAlertRepository struct {
+	mu     sync.Mutex
+	alerts map[string]*models.AMLAlert
+}
+
+// NewMemoryAMLAlertRepository creates an empty in-memory AML alert repository
+func NewMemoryAMLAlertRepository() *MemoryAMLAlertRepository {
+	return &MemoryAMLAlertRepository{alerts: make(map[string]*models.AMLAlert)}
+}
+
+// RecordAlert stores an alert, returning false if it was already recorded
+func (r *MemoryAMLAlertRepository) RecordAlert(ctx context.Context, alert *models.AMLAlert) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.alerts[alert.AlertID]; exists {
+		return false, nil
+	}
+	clone := *alert
+	r.alerts[alert.AlertID] = &clone
+	return true, nil
+}
+
+// GetAlert retrieves an alert
+func (r *MemoryAMLAlertRepository) GetAlert(ctx context.Context, alertID string) (*models.AMLAlert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert, ok := r.alerts[alertID]
+	if !ok {
+		return nil, errors.ErrAMLAlertNotFound(alertID)
+	}
+	clone := *alert
+	return &clone, nil
+}
+
+// ListAlerts returns the alerts with a status, or every alert if status is empty, oldest first
+func (r *MemoryAMLAlertRepository) ListAlerts(ctx context.Context, status string) ([]*models.AMLAlert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var alerts []*models.AMLAlert
+	for _, alert := range r.alerts {
+		if status == "" || alert.Status == status {
+			clone := *alert
+			alerts = append(alerts, &clone)
+		}
+	}
+	sortAMLAlerts(alerts)
+	return alerts, nil
+}
+
+// CloseAlert marks an open alert closed, failing with a conflict if it already was
+func (r *MemoryAMLAlertRepository) CloseAlert(ctx context.Context, alertID, resolution, resolvedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	alert, ok := r.alerts[alertID]
+	if !ok {
+		return errors.ErrAMLAlertNotFound(alertID)
+	}
+	if alert.Status != models.AMLAlertStatusOpen {
+		return errors.ErrConflict(fmt.Sprintf("AML alert %s is already closed", alertID))
+	}
+	alert.Status = models.AMLAlertStatusClosed
+	alert.Resolution = resolution
+	alert.ResolvedBy = resolvedBy
+	alert.ResolvedAt = &at
+	return nil
+}
+
+// MemoryWebhookSubscriptionRepository stores webhook subscriptions in process memory
+type MemoryWebhookSubscriptionRepository struct {
+	mu   sync.Mutex
+	subs map[string]*models.WebhookSubscription
+}
+
+// NewMemoryWebhookSubscriptionRepository creates an empty in-memory webhook subscription repository
+func NewMemoryWebhookSubscriptionRepository() *MemoryWebhookSubscriptionRepository {
+	return &MemoryWebhookSubscriptionRepository{subs: make(map[string]*models.WebhookSubscription)}
+}
+
+// copyWebhookSubscription returns a copy of sub that shares no slices or pointers with it
+func copyWebhookSubscription(sub *models.WebhookSubscription) *models.WebhookSubscription {
+	clone := *sub
+	clone.EventTypes = append([]string(nil), sub.EventTypes...)
+	clone.SigningSecrets = append([]string(nil), sub.SigningSecrets...)
+	if sub.MTLS != nil {
+		mtls := *sub.MTLS
+		clone.MTLS = &mtls
+	}
+	return &clone
+}
+
+// CreateWebhookSubscription stores a new subscription, failing with a conflict if the ID exists
+func (r *MemoryWebhookSubscriptionRepository) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subs[sub.SubscriptionID]; exists {
+		return errors.ErrConflict(fmt.Sprintf("Webhook subscription %s already exists", sub.SubscriptionID))
+	}
+	r.subs[sub.SubscriptionID] = copyWebhookSubscription(sub)
+	return nil
+}
+
+// GetWebhookSubscription retrieves a subscription
+func (r *MemoryWebhookSubscriptionRepository) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.subs[subscriptionID]
+	if !ok {
+		return nil, errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+	}
+	return copyWebhookSubscription(sub), nil
+}
+
+// ListWebhookSubscriptions returns a customer's subscriptions, oldest first
+func (r *MemoryWebhookSubscriptionRepository) ListWebhookSubscriptions(ctx context.Context, customerID string) ([]*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subs := []*models.WebhookSubscription{}
+	for _, sub := range r.subs {
+		if sub.CustomerID == customerID {
+			subs = append(subs, copyWebhookSubscription(sub))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs, nil
+}
+
+// UpdateWebhookSubscription replaces an existing subscription
+func (r *MemoryWebhookSubscriptionRepository) UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subs[sub.SubscriptionID]; !exists {
+		return errors.ErrWebhookSubscriptionNotFound(sub.SubscriptionID)
+	}
+	r.subs[sub.SubscriptionID] = copyWebhookSubscription(sub)
+	return nil
+}
+
+// DeleteWebhookSubscription removes a subscription
+func (r *MemoryWebhookSubscriptionRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subs[subscriptionID]; !exists {
+		return errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+	}
+	delete(r.subs, subscriptionID)
+	return nil
+}
+
+// MemoryWebhookDeliveryRepository stores webhook delivery attempts in process memory
+type MemoryWebhookDeliveryRepository struct {
+	mu         sync.RWMutex
+	deliveries map[string][]models.WebhookDelivery // subscription -> attempts in the order recorded
+}
+
+// NewMemoryWebhookDeliveryRepository creates an empty in-memory webhook delivery repository
+func NewMemoryWebhookDeliveryRepository() *MemoryWebhookDeliveryRepository {
+	return &MemoryWebhookDeliveryRepository{deliveries: make(map[string][]models.WebhookDelivery)}
+}
+
+// RecordWebhookDelivery stores a delivery attempt
+func (r *MemoryWebhookDeliveryRepository) RecordWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries[delivery.SubscriptionID] = append(r.deliveries[delivery.SubscriptionID], *delivery)
+	return nil
+}
+
+// ListWebhookDeliveries returns a subscription's delivery attempts since the given time, oldest first
+func (r *MemoryWebhookDeliveryRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID string, since time.Time) ([]*models.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.deliveries[subscriptionID] {
+		if delivery.DeliveredNanos >= since.UnixNano() {
+			clone := delivery
+			deliveries = append(deliveries, &clone)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].DeliveredNanos < deliveries[j].DeliveredNanos })
+	return deliveries, nil
+}
+
+// MemorySocketSubscriptionRepository stores WebSocket subscriptions in process memory
+type MemorySocketSubscriptionRepository struct {
+	mu   sync.RWMutex
+	subs map[string]map[string]models.SocketSubscription // payment -> connection -> subscription
+}
+
+// NewMemorySocketSubscriptionRepository creates an empty in-memory WebSocket subscription repository
+func NewMemorySocketSubscriptionRepository() *MemorySocketSubscriptionRepository {
+	return &MemorySocketSubscriptionRepository{subs: make(map[string]map[string]models.SocketSubscription)}
+}
+
+// SubscribeConnection stores a subscription, replacing the connection's existing one to the payment
+func (r *MemorySocketSubscriptionRepository) SubscribeConnection(ctx context.Context, sub *models.SocketSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.subs[sub.PaymentID] == nil {
+		r.subs[sub.PaymentID] = make(map[string]models.SocketSubscription)
+	}
+	r.subs[sub.PaymentID][sub.ConnectionID] = *sub
+	return nil
+}
+
+// UnsubscribeConnection removes a connection's subscription to a payment, if it has one
+func (r *MemorySocketSubscriptionRepository) UnsubscribeConnection(ctx context.Context, connectionID, paymentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.subs[paymentID], connectionID)
+	return nil
+}
+
+// ListPaymentSubscriptions returns the unexpired subscriptions to a payment, oldest first
+func (r *MemorySocketSubscriptionRepository) ListPaymentSubscriptions(ctx context.Context, paymentID string) ([]*models.SocketSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var subs []*models.SocketSubscription
+	for _, sub := range r.subs[paymentID] {
+		if sub.ExpiresAt.After(now) {
+			clone := sub
+			subs = append(subs, &clone)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs, nil
+}
+
+// DeleteConnectionSubscriptions removes every subscription of a closed connection
+func (r *MemorySocketSubscriptionRepository) DeleteConnectionSubscriptions(ctx context.Context, connectionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, subs := range r.subs {
+		delete(subs, connectionID)
+	}
+	return nil
+}
+
+// MemoryAPIKeyRepository stores API keys in process memory
+type MemoryAPIKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*models.APIKey // Key hash -> key
+}
+
+// NewMemoryAPIKeyRepository creates an empty in-memory API key repository
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: make(map[string]*models.APIKey)}
+}
+
+// copyAPIKey returns a copy of key that shares no pointers with it
+func copyAPIKey(key *models.APIKey) *models.APIKey {
+	clone := *key
+	for _, t := range []**time.Time{&clone.ExpiresAt, &clone.RevokedAt, &clone.LastUsedAt} {
+		if *t != nil {
+			copied := **t
+			*t = &copied
+		}
+	}
+	return &clone
+}
+
+// CreateAPIKey stores a new key, failing with a conflict if its hash exists
+func (r *MemoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key.KeyHash]; exists {
+		return errors.ErrConflict(fmt.Sprintf("API key %s already exists", key.KeyID))
+	}
+	r.keys[key.KeyHash] = copyAPIKey(key)
+	return nil
+}
+
+// GetAPIKey retrieves the key whose secret has the given hash, or nil if no key has it
+func (r *MemoryAPIKeyRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[keyHash]
+	if !ok {
+		return nil, nil
+	}
+	return copyAPIKey(key), nil
+}
+
+// ListAPIKeys returns a customer's keys, oldest first
+func (r *MemoryAPIKeyRepository) ListAPIKeys(ctx context.Context, customerID string) ([]*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []*models.APIKey{}
+	for _, key := range r.keys {
+		if key.CustomerID == customerID {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// UpdateAPIKey replaces an existing key
+func (r *MemoryAPIKeyRepository) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key.KeyHash]; !exists {
+		return errors.ErrAPIKeyNotFound(key.KeyID)
+	}
+	r.keys[key.KeyHash] = copyAPIKey(key)
+	return nil
+}
+
+// TouchAPIKey records when a key was last used; a missing key is ignored
+func (r *MemoryAPIKeyRepository) TouchAPIKey(ctx context.Context, keyHash string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.keys[keyHash]; ok {
+		used := usedAt.UTC()
+		key.LastUsedAt = &used
+	}
+	return nil
+}
+
+// MemoryUsageRepository stores usage counters in process memory
+type MemoryUsageRepository struct {
+	mu       sync.Mutex
+	counters map[string]map[string]*models.UsageRecord // Customer ID -> usage key -> counter
+}
+
+// NewMemoryUsageRepository creates an empty in-memory usage repository
+func NewMemoryUsageRepository() *MemoryUsageRepository {
+	return &MemoryUsageRepository{counters: make(map[string]map[string]*models.UsageRecord)}
+}
+
+// AddUsage adds the record's quantity to its counter, creating the counter on first use
+func (r *MemoryUsageRepository) AddUsage(ctx context.Context, record *models.UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.counters[record.CustomerID] == nil {
+		r.counters[record.CustomerID] = make(map[string]*models.UsageRecord)
+	}
+	counter, ok := r.counters[record.CustomerID][record.UsageKey]
+	if !ok {
+		clone := *record
+		clone.Quantity = 0
+		counter = &clone
+		r.counters[record.CustomerID][record.UsageKey] = counter
+	}
+	counter.Quantity += record.Quantity
+	counter.UpdatedAt = record.UpdatedAt
+	return nil
+}
+
+// ListUsage returns a customer's counters for a period
+func (r *MemoryUsageRepository) ListUsage(ctx context.Context, customerID, period string) ([]*models.UsageRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := []*models.UsageRecord{}
+	for _, counter := range r.counters[customerID] {
+		if counter.Period == period {
+			clone := *counter
+			records = append(records, &clone)
+		}
+	}
+	models.SortUsageRecords(records)
+	return records, nil
+}
+
+// ListPeriodUsage returns every customer's counters for a period, ordered by customer, metric and currency
+func (r *MemoryUsageRepository) ListPeriodUsage(ctx context.Context, period string) ([]*models.UsageRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := []*models.UsageRecord{}
+	for _, counters := range r.counters {
+		for _, counter := range counters {
+			if counter.Period == period {
+				clone := *counter
+				records = append(records, &clone)
+			}
+		}
+	}
+	models.SortUsageRecords(records)
+	return records, nil
+}
+
+// MemoryQuotaRepository stores quota counters in process memory
+type MemoryQuotaRepository struct {
+	mu       sync.Mutex
+	counters map[string]map[string]*models.QuotaCounter // Customer ID -> window key -> counter
+}
+
+// NewMemoryQuotaRepository creates an empty in-memory quota repository
+func NewMemoryQuotaRepository() *MemoryQuotaRepository {
+	return &MemoryQuotaRepository{counters: make(map[string]map[string]*models.QuotaCounter)}
+}
+
+// ReserveQuota counts a payment of volume against every window, failing with QUOTA_EXCEEDED
+// and counting nothing if it would take any window past its limits
+func (r *MemoryQuotaRepository) ReserveQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, window := range windows {
+		if window.Exceeds(r.counters[customerID][window.Key], volume) {
+			return errors.ErrQuotaExceeded(window.Period, window.ResetsAt)
+		}
+	}
+	r.add(customerID, windows, 1, volume)
+	return nil
+}
+
+// ReleaseQuota gives back a payment reserved against every window
+func (r *MemoryQuotaRepository) ReleaseQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.add(customerID, windows, -1, -volume)
+	return nil
+}
+
+// add adds payments and volume to each window's counter; the caller holds the lock
+func (r *MemoryQuotaRepository) add(customerID string, windows []models.QuotaWindow, payments, volume int64) {
+	if r.counters[customerID] == nil {
+		r.counters[customerID] = make(map[string]*models.QuotaCounter)
+	}
+	for _, window := range windows {
+		counter, ok := r.counters[customerID][window.Key]
+		if !ok {
+			counter = &models.QuotaCounter{CustomerID: customerID, WindowKey: window.Key}
+			r.counters[customerID][window.Key] = counter
+		}
+		counter.Payments += payments
+		counter.Volume += volume
+		counter.TTL = window.ExpiresAt().Unix()
+	}
+}
+
+// GetQuotaCounters returns a customer's counters for the given windows; windows without payments are left out
+func (r *MemoryQuotaRepository) GetQuotaCounters(ctx context.Context, customerID string, windowKeys []string) (map[string]*models.QuotaCounter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := make(map[string]*models.QuotaCounter, len(windowKeys))
+	for _, key := range windowKeys {
+		if counter, ok := r.counters[customerID][key]; ok {
+			clone := *counter
+			counters[key] = &clone
+		}
+	}
+	return counters, nil
+}
@@ -0,0 +1,23 @@
+-- Payments: reporting columns plus the full record as JSONB
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id            TEXT PRIMARY KEY,
+    idempotency_key       TEXT NOT NULL UNIQUE,
+    status                TEXT NOT NULL,
+    amount                BIGINT NOT NULL,
+    currency              TEXT NOT NULL,
+    fee_amount            BIGINT NOT NULL DEFAULT 0,
+    chain                 TEXT,
+    quote_id              TEXT,
+    settlement_task_token TEXT,
+    lock_owner            TEXT,
+    lock_expires_at       BIGINT,
+    ttl                   BIGINT,
+    archived_at           TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL,
+    updated_at            TIMESTAMPTZ NOT NULL,
+    record                JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payments_status_idx ON payments (status);
+CREATE INDEX IF NOT EXISTS payments_created_at_idx ON payments (created_at);
+CREATE INDEX IF NOT EXISTS payments_ttl_idx ON payments (ttl) WHERE archived_at IS NULL;
@@ -0,0 +1,13 @@
+-- Quotes: reporting columns plus the full record as JSONB
+CREATE TABLE IF NOT EXISTS quotes (
+    quote_id      TEXT PRIMARY KEY,
+    from_currency TEXT NOT NULL,
+    to_currency   TEXT NOT NULL,
+    amount        BIGINT NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    ttl           BIGINT,
+    created_at    TIMESTAMPTZ NOT NULL,
+    record        JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS quotes_created_at_idx ON quotes (created_at);
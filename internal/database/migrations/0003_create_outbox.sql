@@ -0,0 +1,10 @@
+-- Outbox: messages written in the same transaction as the record change that produced them
+CREATE TABLE IF NOT EXISTS outbox (
+    message_id TEXT PRIMARY KEY,
+    kind       TEXT NOT NULL,
+    payment_id TEXT NOT NULL,
+    payload    JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_created_at_idx ON outbox (created_at);
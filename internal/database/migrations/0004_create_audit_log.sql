@@ -0,0 +1,19 @@
+-- Audit log: append-only, hash-chained record of payment, admin and config events
+CREATE TABLE IF NOT EXISTS audit_log (
+    sequence      BIGINT PRIMARY KEY,
+    entry_id      TEXT NOT NULL,
+    timestamp     TIMESTAMPTZ NOT NULL,
+    actor         TEXT NOT NULL,
+    action        TEXT NOT NULL,
+    resource_type TEXT NOT NULL,
+    resource_id   TEXT NOT NULL,
+    details       JSONB NOT NULL,
+    prev_hash     TEXT NOT NULL,
+    hash          TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_resource_idx ON audit_log (resource_type, resource_id);
+
+-- Entries are immutable once written
+CREATE OR REPLACE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING;
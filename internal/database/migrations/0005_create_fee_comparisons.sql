@@ -0,0 +1,19 @@
+-- Fee comparisons: shadow-mode samples of the static fee charged versus the AI engine's fee
+CREATE TABLE IF NOT EXISTS fee_comparisons (
+    payment_id      TEXT PRIMARY KEY,
+    amount          BIGINT NOT NULL,
+    source_currency TEXT NOT NULL,
+    currency        TEXT NOT NULL,
+    static_fee      BIGINT NOT NULL,
+    ai_fee          BIGINT NOT NULL,
+    delta           BIGINT NOT NULL,
+    delta_percent   DOUBLE PRECISION NOT NULL,
+    ai_chain        TEXT NOT NULL,
+    ai_confidence   DOUBLE PRECISION NOT NULL,
+    ai_fallback     BOOLEAN NOT NULL,
+    ai_error        TEXT NOT NULL,
+    ai_latency_ms   BIGINT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fee_comparisons_created_at_idx ON fee_comparisons (created_at);
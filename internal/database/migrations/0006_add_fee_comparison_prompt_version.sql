@@ -0,0 +1,2 @@
+-- Prompt template version that produced each AI fee
+ALTER TABLE fee_comparisons ADD COLUMN IF NOT EXISTS prompt_version TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Fee schedules: effective-dated versions of the platform fee tiers, never updated in place
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    schedule_id    TEXT NOT NULL,
+    version        BIGINT NOT NULL,
+    tiers          JSONB NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_by     TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (schedule_id, version)
+);
@@ -0,0 +1,11 @@
+-- Promo codes: fee discounts with a validity window and an optional usage cap
+CREATE TABLE IF NOT EXISTS promos (
+    code        TEXT PRIMARY KEY,
+    percent_off DOUBLE PRECISION NOT NULL DEFAULT 0,
+    amount_off  BIGINT NOT NULL DEFAULT 0,
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ,
+    max_uses    BIGINT NOT NULL DEFAULT 0,
+    uses        BIGINT NOT NULL DEFAULT 0 CHECK (max_uses = 0 OR uses <= max_uses),
+    created_at  TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,10 @@
+-- Customer volume: one row per completed payment, summed over a rolling window for volume discounts
+CREATE TABLE IF NOT EXISTS customer_volumes (
+    customer_id  TEXT NOT NULL,
+    payment_id   TEXT NOT NULL,
+    amount_usd   BIGINT NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (customer_id, payment_id)
+);
+
+CREATE INDEX IF NOT EXISTS customer_volumes_completed_at_idx ON customer_volumes (customer_id, completed_at);
@@ -0,0 +1,23 @@
+-- Fee invoices: the immutable record of the fees on each completed payment
+CREATE TABLE IF NOT EXISTS fee_invoices (
+    payment_id           TEXT PRIMARY KEY,
+    customer_id          TEXT NOT NULL DEFAULT '',
+    quote_id             TEXT NOT NULL DEFAULT '',
+    amount               BIGINT NOT NULL,
+    currency             TEXT NOT NULL,
+    platform_fee         BIGINT NOT NULL,
+    regulatory_fee       BIGINT NOT NULL DEFAULT 0,
+    onramp_fee           BIGINT NOT NULL DEFAULT 0,
+    offramp_fee          BIGINT NOT NULL DEFAULT 0,
+    gas_fee              BIGINT NOT NULL DEFAULT 0,
+    total_fees           BIGINT NOT NULL,
+    negotiated_discount  BIGINT NOT NULL DEFAULT 0,
+    promo_code           TEXT NOT NULL DEFAULT '',
+    promo_discount       BIGINT NOT NULL DEFAULT 0,
+    pricing_customer     TEXT NOT NULL DEFAULT '',
+    fee_limit            TEXT NOT NULL DEFAULT '',
+    fee_schedule_id      TEXT NOT NULL DEFAULT '',
+    fee_schedule_version BIGINT NOT NULL DEFAULT 0,
+    completed_at         TIMESTAMPTZ NOT NULL,
+    issued_at            TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,4 @@
+-- Payout currency of each invoiced payment, so revenue can be reported by corridor
+ALTER TABLE fee_invoices ADD COLUMN IF NOT EXISTS payout_currency TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS fee_invoices_completed_at_idx ON fee_invoices (completed_at);
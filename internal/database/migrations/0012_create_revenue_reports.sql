@@ -0,0 +1,15 @@
+-- Daily revenue report: one row per UTC day, corridor and customer, rewritten when a day is re-run
+CREATE TABLE IF NOT EXISTS revenue_reports (
+    report_date      DATE NOT NULL,
+    corridor         TEXT NOT NULL,
+    customer_id      TEXT NOT NULL,
+    currency         TEXT NOT NULL,
+    payments         INTEGER NOT NULL,
+    volume           BIGINT NOT NULL,
+    platform_revenue BIGINT NOT NULL,
+    regulatory_fees  BIGINT NOT NULL,
+    provider_costs   BIGINT NOT NULL,
+    gas_spend        BIGINT NOT NULL,
+    generated_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (report_date, corridor, customer_id)
+);
@@ -0,0 +1,8 @@
+-- Gas price history: one row per chain and reading, averaged for gas trends
+CREATE TABLE IF NOT EXISTS gas_prices (
+    chain       TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    gas_price   DOUBLE PRECISION NOT NULL,
+    cost_usd    DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (chain, recorded_at)
+);
@@ -0,0 +1,20 @@
+-- Treasury: our USDC balance per chain and fiat float per provider, in minor units
+CREATE TABLE IF NOT EXISTS treasury (
+    account    TEXT PRIMARY KEY,
+    kind       TEXT NOT NULL,
+    location   TEXT NOT NULL,
+    currency   TEXT NOT NULL,
+    balance    BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Applied treasury movements: one row per payment leg or manual adjustment, so none is applied twice
+CREATE TABLE IF NOT EXISTS treasury_movements (
+    movement_id TEXT PRIMARY KEY,
+    payment_id  TEXT,
+    leg         TEXT NOT NULL,
+    record      JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS treasury_movements_payment_idx ON treasury_movements (payment_id);
@@ -0,0 +1,25 @@
+-- Ledger: one row per double-entry transaction recorded for a payment leg
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    transaction_id TEXT PRIMARY KEY,
+    payment_id     TEXT NOT NULL,
+    leg            TEXT NOT NULL,
+    record         JSONB NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ledger_transactions_payment_idx ON ledger_transactions (payment_id);
+
+-- Ledger entries: each debit and credit, for finance to sum by account
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    transaction_id TEXT NOT NULL REFERENCES ledger_transactions (transaction_id),
+    line           INT NOT NULL,
+    payment_id     TEXT NOT NULL,
+    account        TEXT NOT NULL,
+    side           TEXT NOT NULL CHECK (side IN ('debit', 'credit')),
+    amount         BIGINT NOT NULL CHECK (amount > 0),
+    currency       TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (transaction_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_account_idx ON ledger_entries (account, created_at);
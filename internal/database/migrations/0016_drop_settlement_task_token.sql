@@ -0,0 +1,2 @@
+-- Step Functions settlement callbacks were removed; executions always poll on a Wait state
+ALTER TABLE payments DROP COLUMN IF EXISTS settlement_task_token;
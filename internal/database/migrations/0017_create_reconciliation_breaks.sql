@@ -0,0 +1,9 @@
+-- Reconciliation breaks: disagreements between a provider statement and our payments, held until resolved
+CREATE TABLE IF NOT EXISTS reconciliation_breaks (
+    break_id    TEXT PRIMARY KEY,
+    status      TEXT NOT NULL,
+    record      JSONB NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS reconciliation_breaks_status_idx ON reconciliation_breaks (status, detected_at);
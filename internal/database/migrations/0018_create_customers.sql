@@ -0,0 +1,7 @@
+-- Customers: one row per API customer, with its tier, limits and linked accounts in the record
+CREATE TABLE IF NOT EXISTS customers (
+    customer_id TEXT PRIMARY KEY,
+    record      JSONB NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,12 @@
+-- AML alerts: payments transaction monitoring flagged, held until compliance closes them
+CREATE TABLE IF NOT EXISTS aml_alerts (
+    alert_id   TEXT PRIMARY KEY,
+    status     TEXT NOT NULL,
+    record     JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS aml_alerts_status_idx ON aml_alerts (status, created_at);
+
+-- Monitoring rules look back over a customer's recent payments
+CREATE INDEX IF NOT EXISTS payments_customer_idx ON payments ((record->>'customer_id'), created_at);
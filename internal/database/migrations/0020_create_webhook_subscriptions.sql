@@ -0,0 +1,10 @@
+-- Webhook subscriptions: endpoints customers registered for their payments' webhooks
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id TEXT PRIMARY KEY,
+    customer_id     TEXT NOT NULL,
+    record          JSONB NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_customer_idx ON webhook_subscriptions (customer_id);
@@ -0,0 +1,8 @@
+-- Webhook deliveries: one row per attempt to a subscription's endpoint, summarized into delivery statistics
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    subscription_id TEXT NOT NULL,
+    delivered_ns    BIGINT NOT NULL,
+    record          JSONB NOT NULL,
+    delivered_at    TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (subscription_id, delivered_ns)
+);
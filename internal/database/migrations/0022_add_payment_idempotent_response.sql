@@ -0,0 +1,2 @@
+-- Response a payment request was first answered with, replayed to retries under the same idempotency key
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS idempotent_response JSONB;
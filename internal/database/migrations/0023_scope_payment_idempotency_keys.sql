@@ -0,0 +1,5 @@
+-- Idempotency keys are unique per API key rather than across every caller
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS idempotency_scope TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_idempotency_key_key;
+CREATE UNIQUE INDEX IF NOT EXISTS payments_idempotency_key_idx ON payments (idempotency_scope, idempotency_key);
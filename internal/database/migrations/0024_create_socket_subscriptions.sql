@@ -0,0 +1,11 @@
+-- WebSocket subscriptions: one row per connection subscribed to a payment's status transitions
+CREATE TABLE IF NOT EXISTS socket_subscriptions (
+    payment_id    TEXT NOT NULL,
+    connection_id TEXT NOT NULL,
+    record        JSONB NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (payment_id, connection_id)
+);
+
+-- A closed connection's subscriptions are deleted together
+CREATE INDEX IF NOT EXISTS socket_subscriptions_connection_idx ON socket_subscriptions (connection_id);
@@ -0,0 +1,11 @@
+-- API keys: one row per issued key, keyed by the SHA-256 hash of its secret
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_hash     TEXT PRIMARY KEY,
+    key_id       TEXT NOT NULL UNIQUE,
+    customer_id  TEXT NOT NULL,
+    record       JSONB NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_customer_idx ON api_keys (customer_id);
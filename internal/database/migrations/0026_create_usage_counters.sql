@@ -0,0 +1,13 @@
+-- Usage counters: one row per customer, month, metric and currency, incremented in place for billing
+CREATE TABLE IF NOT EXISTS usage_counters (
+    customer_id TEXT NOT NULL,
+    usage_key   TEXT NOT NULL,
+    period      TEXT NOT NULL,
+    metric      TEXT NOT NULL,
+    currency    TEXT NOT NULL DEFAULT '',
+    quantity    BIGINT NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (customer_id, usage_key)
+);
+
+CREATE INDEX IF NOT EXISTS usage_counters_period_idx ON usage_counters (period);
@@ -0,0 +1,9 @@
+-- Quota counters: payments and USD volume per customer and daily or monthly window
+CREATE TABLE IF NOT EXISTS quota_counters (
+    customer_id TEXT NOT NULL,
+    window_key  TEXT NOT NULL,
+    payments    BIGINT NOT NULL,
+    volume      BIGINT NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (customer_id, window_key)
+);
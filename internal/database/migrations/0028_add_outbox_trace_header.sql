@@ -0,0 +1,2 @@
+-- X-Ray trace of the request that wrote each outbox message, continued when the relay delivers it
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS trace_header TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,354 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// tracedOutbox returns a copy of msg stamped with the writing request's trace header, if it has one
+// The relay continues that trace when it delivers the message.
+func tracedOutbox(ctx context.Context, msg *models.OutboxMessage) *models.OutboxMessage {
+	traced := *msg
+	if header := tracing.TraceHeader(ctx); header != "" {
+		traced.TraceHeader = header
+	}
+	return &traced
+}
+
+// CreatePaymentWithOutbox creates a payment and its outbox message in one transaction
+// Either both are written or neither is, so a payment can never be orphaned without its job. A payment
+// made from a quote also marks the quote consumed in the same transaction, failing if another payment
+// already used it.
+func (c *Client) CreatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	stored, err := c.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+
+	paymentItem, err := dynamodbattribute.MarshalMap(stored)
+	if err != nil {
+		logger.Error("Failed to marshal payment", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	outboxItem, err := dynamodbattribute.MarshalMap(tracedOutbox(ctx, msg))
+	if err != nil {
+		logger.Error("Failed to marshal outbox message", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName: aws.String(c.tableName),
+					Item:      paymentItem,
+					// Ensure idempotency key doesn't already exist
+					ConditionExpression: aws.String("attribute_not_exists(idempotency_key)"),
+				},
+			},
+			{
+				Put: &dynamodb.Put{
+					TableName: aws.String(c.outboxTable),
+					Item:      outboxItem,
+				},
+			},
+		},
+	}
+	if payment.QuoteID != "" && c.quoteTable != "" {
+		consume, err := consumeQuoteItem(c.quoteTable, payment)
+		if err != nil {
+			return err
+		}
+		input.TransactItems = append(input.TransactItems, consume)
+	}
+	claimIndex := -1
+	if c.idempotencyTable != "" {
+		claimIndex = len(input.TransactItems)
+		input.TransactItems = append(input.TransactItems, claimIdempotencyKeyItem(c.idempotencyTable, payment))
+	}
+
+	_, err = c.svc.TransactWriteItemsWithContext(ctx, input)
+	if err != nil {
+		if isConditionalCancellation(err, 0) || isConditionalCancellation(err, claimIndex) {
+			return errors.ErrDuplicateRequest(payment.IdempotencyKey)
+		}
+		if isConditionalCancellation(err, 2) {
+			return errors.ErrQuoteConsumed(payment.QuoteID)
+		}
+		logger.Error("Failed to create payment with outbox", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": payment.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("transact_create", err)
+	}
+
+	logger.Info("Payment created", logger.Fields{
+		"payment_id":      payment.PaymentID,
+		"idempotency_key": payment.IdempotencyKey,
+		"outbox_id":       msg.MessageID,
+	})
+	return nil
+}
+
+// maxTransactItems is the most items DynamoDB accepts in one TransactWriteItems call
+const maxTransactItems = 100
+
+// CreatePaymentsWithOutbox creates a batch of payments and the outbox message carrying their jobs in one transaction
+// The batch is all-or-nothing: a duplicate idempotency key or an already consumed quote on any payment
+// fails the whole batch with that payment's error. A payment uses two transaction items with a quote
+// and one without, plus one for the batch's idempotency key claim and one for the outbox message,
+// within DynamoDB's limit of 100.
+func (c *Client) CreatePaymentsWithOutbox(ctx context.Context, payments []*models.Payment, msg *models.OutboxMessage) error {
+	var items []*dynamodb.TransactWriteItem
+	var itemErrors []error // The error each item's failed condition stands for, by item index
+	for _, payment := range payments {
+		stored, err := c.fields.encryptPayment(ctx, payment)
+		if err != nil {
+			return err
+		}
+		paymentItem, err := dynamodbattribute.MarshalMap(stored)
+		if err != nil {
+			logger.Error("Failed to marshal payment", logger.Fields{"error": err.Error()})
+			return errors.ErrDatabaseOperation("marshal", err)
+		}
+		items = append(items, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				TableName:           aws.String(c.tableName),
+				Item:                paymentItem,
+				ConditionExpression: aws.String("attribute_not_exists(idempotency_key)"),
+			},
+		})
+		itemErrors = append(itemErrors, errors.ErrDuplicateRequest(payment.IdempotencyKey))
+
+		if payment.QuoteID != "" && c.quoteTable != "" {
+			consume, err := consumeQuoteItem(c.quoteTable, payment)
+			if err != nil {
+				return err
+			}
+			items = append(items, consume)
+			itemErrors = append(itemErrors, errors.ErrQuoteConsumed(payment.QuoteID))
+		}
+	}
+
+	// The batch's key is claimed once, under its first payment's key, where a retry looks it up
+	if c.idempotencyTable != "" && len(payments) > 0 {
+		items = append(items, claimIdempotencyKeyItem(c.idempotencyTable, payments[0]))
+		itemErrors = append(itemErrors, errors.ErrDuplicateRequest(payments[0].IdempotencyKey))
+	}
+
+	outboxItem, err := dynamodbattribute.MarshalMap(tracedOutbox(ctx, msg))
+	if err != nil {
+		logger.Error("Failed to marshal outbox message", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+	items = append(items, &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName: aws.String(c.outboxTable),
+			Item:      outboxItem,
+		},
+	})
+	if len(items) > maxTransactItems {
+		return errors.ErrInvalidRequest(fmt.Sprintf("Batch needs %d transaction items; at most %d fit in one transaction", len(items), maxTransactItems), nil)
+	}
+
+	_, err = c.svc.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		for i, itemErr := range itemErrors {
+			if isConditionalCancellation(err, i) {
+				return itemErr
+			}
+		}
+		logger.Error("Failed to create payment batch with outbox", logger.Fields{
+			"error":     err.Error(),
+			"outbox_id": msg.MessageID,
+		})
+		return errors.ErrDatabaseOperation("transact_create_batch", err)
+	}
+
+	logger.Info("Payment batch created", logger.Fields{
+		"count":     len(payments),
+		"outbox_id": msg.MessageID,
+	})
+	return nil
+}
+
+// consumeQuoteItem marks the payment's quote consumed, on condition that no payment has used it yet
+// The update is what the quotes stream turns into a quote.consumed webhook.
+func consumeQuoteItem(quoteTable string, payment *models.Payment) (*dynamodb.TransactWriteItem, error) {
+	update := expression.Set(expression.Name("consumed_at"), expression.Value(payment.CreatedAt)).
+		Set(expression.Name("payment_id"), expression.Value(payment.PaymentID))
+	condition := expression.AttributeExists(expression.Name("quote_id")).
+		And(expression.AttributeNotExists(expression.Name("consumed_at")))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	return &dynamodb.TransactWriteItem{
+		Update: &dynamodb.Update{
+			TableName: aws.String(quoteTable),
+			Key: map[string]*dynamodb.AttributeValue{
+				"quote_id": {S: aws.String(payment.QuoteID)},
+			},
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		},
+	}, nil
+}
+
+// claimIdempotencyKeyItem claims a payment's idempotency key within its API key's scope
+// The payment's own put can only check its own item, so this claim is what stops one key creating two payments.
+func claimIdempotencyKeyItem(idempotencyTable string, payment *models.Payment) *dynamodb.TransactWriteItem {
+	return &dynamodb.TransactWriteItem{
+		Put: &dynamodb.Put{
+			TableName: aws.String(idempotencyTable),
+			Item: map[string]*dynamodb.AttributeValue{
+				"scoped_key": {S: aws.String(payment.IdempotencyScope + "#" + payment.IdempotencyKey)},
+				"payment_id": {S: aws.String(payment.PaymentID)},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(scoped_key)"),
+		},
+	}
+}
+
+// UpdatePaymentWithOutbox updates a payment and writes an outbox message in one transaction
+// Used for terminal transitions so the webhook is queued if and only if the status change lands.
+func (c *Client) UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	payment.UpdatedAt = time.Now()
+
+	stored, err := c.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+
+	paymentItem, err := dynamodbattribute.MarshalMap(stored)
+	if err != nil {
+		logger.Error("Failed to marshal payment", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	outboxItem, err := dynamodbattribute.MarshalMap(tracedOutbox(ctx, msg))
+	if err != nil {
+		logger.Error("Failed to marshal outbox message", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(lockFreeCondition(payment.LockOwner)).Build()
+	if err != nil {
+		logger.Error("Failed to build condition expression", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName:                 aws.String(c.tableName),
+					Item:                      paymentItem,
+					ConditionExpression:       expr.Condition(),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+				},
+			},
+			{
+				Put: &dynamodb.Put{
+					TableName: aws.String(c.outboxTable),
+					Item:      outboxItem,
+				},
+			},
+		},
+	}
+
+	_, err = c.svc.TransactWriteItemsWithContext(ctx, input)
+	if err != nil {
+		if isConditionalCancellation(err, 0) {
+			logger.Warn("Payment locked by another worker", logger.Fields{
+				"payment_id": payment.PaymentID,
+			})
+			return errors.ErrConflict(fmt.Sprintf("Payment '%s' is locked by another worker", payment.PaymentID))
+		}
+		logger.Error("Failed to update payment with outbox", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": payment.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("transact_update", err)
+	}
+
+	logger.Info("Payment updated", logger.Fields{
+		"payment_id": payment.PaymentID,
+		"status":     payment.Status,
+		"outbox_id":  msg.MessageID,
+	})
+	return nil
+}
+
+// ListOutboxMessages returns up to limit undelivered outbox messages
+func (c *Client) ListOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(c.outboxTable),
+		Limit:     aws.Int64(int64(limit)),
+	}
+
+	result, err := c.svc.ScanWithContext(ctx, input)
+	if err != nil {
+		logger.Error("Failed to scan outbox", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan_outbox", err)
+	}
+
+	messages := make([]*models.OutboxMessage, 0, len(result.Items))
+	for _, item := range result.Items {
+		var msg models.OutboxMessage
+		if err := dynamodbattribute.UnmarshalMap(item, &msg); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	return messages, nil
+}
+
+// DeleteOutboxMessage removes a delivered outbox message
+func (c *Client) DeleteOutboxMessage(ctx context.Context, messageID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.outboxTable),
+		Key: map[string]*dynamodb.AttributeValue{
+			"message_id": {
+				S: aws.String(messageID),
+			},
+		},
+	}
+
+	if _, err := c.svc.DeleteItemWithContext(ctx, input); err != nil {
+		logger.Error("Failed to delete outbox message", logger.Fields{
+			"error":      err.Error(),
+			"message_id": messageID,
+		})
+		return errors.ErrDatabaseOperation("delete_outbox", err)
+	}
+
+	return nil
+}
+
+// isConditionalCancellation reports whether a transaction was cancelled because
+// the condition on the item at index failed
+func isConditionalCancellation(err error, index int) bool {
+	canceled, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok || index < 0 || index >= len(canceled.CancellationReasons) {
+		return false
+	}
+	return aws.StringValue(canceled.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
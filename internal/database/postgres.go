@@ -0,0 +1,2481 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// uniqueViolation is the Postgres SQLSTATE for a unique constraint failure
+const uniqueViolation = "23505"
+
+// migrationLockKey is the advisory lock that serializes migrations across cold-starting Lambdas
+const migrationLockKey = 7264103377
+
+// PostgresClient holds the connection pool shared by the Postgres repositories
+type PostgresClient struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresClient connects to Postgres and applies pending schema migrations
+func NewPostgresClient(ctx context.Context, databaseURL string) (*PostgresClient, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, errors.ErrDatabaseOperation("connect", err)
+	}
+
+	client := &PostgresClient{pool: pool}
+	if err := client.Migrate(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Migrate applies embedded migrations that have not yet been recorded
+// Each migration runs in its own transaction while holding an advisory lock, so
+// concurrent cold starts apply every migration exactly once.
+func (c *PostgresClient) Migrate(ctx context.Context) error {
+	conn, err := c.pool.Acquire(ctx)
+	if err != nil {
+		return errors.ErrDatabaseOperation("migrate", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return errors.ErrDatabaseOperation("migrate", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			logger.Warn("Failed to release migration lock", logger.Fields{"error": err.Error()})
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return errors.ErrDatabaseOperation("migrate", err)
+	}
+
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return errors.ErrDatabaseOperation("migrate", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied bool
+		if err := conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, name).Scan(&applied); err != nil {
+			return errors.ErrDatabaseOperation("migrate", err)
+		}
+		if applied {
+			continue
+		}
+
+		sql, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return errors.ErrDatabaseOperation("migrate", err)
+		}
+
+		err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, string(sql)); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, name)
+			return err
+		})
+		if err != nil {
+			logger.Error("Migration failed", logger.Fields{"migration": name, "error": err.Error()})
+			return errors.ErrDatabaseOperation("migrate", err)
+		}
+
+		logger.Info("Migration applied", logger.Fields{"migration": name})
+	}
+
+	return nil
+}
+
+// LatestMigration returns the version of the newest embedded migration, which Postgres storage is migrated to on connect
+func LatestMigration() string {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	latest := names[len(names)-1]
+	if i := strings.IndexByte(latest, '_'); i > 0 {
+		return latest[:i]
+	}
+	return strings.TrimSuffix(latest, ".sql")
+}
+
+// Close releases the connection pool
+func (c *PostgresClient) Close() {
+	c.pool.Close()
+}
+
+// Ping verifies the database accepts connections
+func (c *PostgresClient) Ping(ctx context.Context) error {
+	return c.pool.Ping(ctx)
+}
+
+// PostgresPaymentRepository stores payments in Postgres
+type PostgresPaymentRepository struct {
+	client    *PostgresClient
+	retention time.Duration
+	archive   Archive      // Read when a payment's row is gone; nil reports it not found
+	fields    *FieldCipher // Encrypts account identifiers in the record; nil stores them in plaintext
+}
+
+// NewPostgresPaymentRepository creates a payment repository on the shared pool
+func NewPostgresPaymentRepository(client *PostgresClient) *PostgresPaymentRepository {
+	return &PostgresPaymentRepository{client: client}
+}
+
+// EnableRetention stamps a TTL on terminal payments so the archiver can export them
+// Postgres does not expire rows itself; archived rows stay queryable for reporting.
+// When archive is set, GetPaymentByID falls back to it for rows that have been purged.
+func (r *PostgresPaymentRepository) EnableRetention(retention time.Duration, archive Archive) {
+	r.retention = retention
+	r.archive = archive
+}
+
+// EnableFieldEncryption encrypts payments' account identifiers at rest from now on
+// Records written before stay readable and are encrypted when next saved.
+func (r *PostgresPaymentRepository) EnableFieldEncryption(fields *FieldCipher) {
+	r.fields = fields
+}
+
+// pgExecer is satisfied by both the pool and a transaction
+type pgExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// CheckHealth verifies the database accepts connections
+func (r *PostgresPaymentRepository) CheckHealth(ctx context.Context) error {
+	return r.client.Ping(ctx)
+}
+
+// CreatePayment inserts a new payment, rejecting duplicate idempotency keys
+func (r *PostgresPaymentRepository) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	if err := r.insertPayment(ctx, r.client.pool, payment); err != nil {
+		return err
+	}
+
+	logger.Info("Payment created", logger.Fields{
+		"payment_id":      payment.PaymentID,
+		"idempotency_key": payment.IdempotencyKey,
+	})
+	return nil
+}
+
+// CreatePaymentWithOutbox inserts a payment and its outbox message in one transaction
+// A payment made from a quote also marks the quote consumed, failing if another payment already used it.
+func (r *PostgresPaymentRepository) CreatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	err := pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		if payment.QuoteID != "" {
+			if err := consumeQuote(ctx, tx, payment.QuoteID, payment.PaymentID, payment.CreatedAt); err != nil {
+				return err
+			}
+		}
+		if err := r.insertPayment(ctx, tx, payment); err != nil {
+			return err
+		}
+		return insertOutbox(ctx, tx, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Payment created", logger.Fields{
+		"payment_id":      payment.PaymentID,
+		"idempotency_key": payment.IdempotencyKey,
+		"outbox_id":       msg.MessageID,
+	})
+	return nil
+}
+
+// CreatePaymentsWithOutbox inserts a batch of payments and the outbox message carrying their jobs in one transaction
+// Any payment's duplicate idempotency key or consumed quote rolls back the whole batch.
+func (r *PostgresPaymentRepository) CreatePaymentsWithOutbox(ctx context.Context, payments []*models.Payment, msg *models.OutboxMessage) error {
+	err := pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		for _, payment := range payments {
+			if payment.QuoteID != "" {
+				if err := consumeQuote(ctx, tx, payment.QuoteID, payment.PaymentID, payment.CreatedAt); err != nil {
+					return err
+				}
+			}
+			if err := r.insertPayment(ctx, tx, payment); err != nil {
+				return err
+			}
+		}
+		return insertOutbox(ctx, tx, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Payment batch created", logger.Fields{
+		"count":     len(payments),
+		"outbox_id": msg.MessageID,
+	})
+	return nil
+}
+
+// insertPayment writes a new payment row
+func (r *PostgresPaymentRepository) insertPayment(ctx context.Context, db pgExecer, payment *models.Payment) error {
+	stored, err := r.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(stored)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+	// Kept out of the record so it isn't returned with the payment
+	var response []byte
+	if payment.IdempotentResponse != nil {
+		if response, err = json.Marshal(payment.IdempotentResponse); err != nil {
+			return errors.ErrDatabaseOperation("marshal", err)
+		}
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO payments (payment_id, idempotency_key, status, amount, currency, fee_amount, chain, quote_id,
+			lock_owner, lock_expires_at, ttl, archived_at, created_at, updated_at, record, idempotent_response, idempotency_scope)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, 0), NULLIF($11, 0), $12, $13, $14, $15, $16, $17)`,
+		payment.PaymentID, payment.IdempotencyKey, payment.Status, payment.Amount, payment.Currency, payment.FeeAmount,
+		payment.Chain, payment.QuoteID, payment.LockOwner, payment.LockExpiresAt, payment.TTL,
+		payment.ArchivedAt, payment.CreatedAt, payment.UpdatedAt, record, response, payment.IdempotencyScope)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrDuplicateRequest(payment.IdempotencyKey)
+		}
+		logger.Error("Failed to create payment", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("create", err)
+	}
+
+	return nil
+}
+
+// insertOutbox writes an outbox message row
+func insertOutbox(ctx context.Context, db pgExecer, msg *models.OutboxMessage) error {
+	msg = tracedOutbox(ctx, msg)
+	_, err := db.Exec(ctx, `
+		INSERT INTO outbox (message_id, kind, payment_id, payload, created_at, trace_header)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		msg.MessageID, msg.Kind, msg.PaymentID, msg.Payload, msg.CreatedAt, msg.TraceHeader)
+	if err != nil {
+		logger.Error("Failed to write outbox message", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("create_outbox", err)
+	}
+	return nil
+}
+
+// ListOutboxMessages returns up to limit undelivered outbox messages, oldest first
+func (r *PostgresPaymentRepository) ListOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT message_id, kind, payment_id, payload::text, created_at, trace_header FROM outbox
+		ORDER BY created_at LIMIT $1`, limit)
+	if err != nil {
+		logger.Error("Failed to list outbox", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan_outbox", err)
+	}
+	defer rows.Close()
+
+	var messages []*models.OutboxMessage
+	for rows.Next() {
+		var msg models.OutboxMessage
+		if err := rows.Scan(&msg.MessageID, &msg.Kind, &msg.PaymentID, &msg.Payload, &msg.CreatedAt, &msg.TraceHeader); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan_outbox", err)
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan_outbox", err)
+	}
+
+	return messages, nil
+}
+
+// DeleteOutboxMessage removes a delivered outbox message
+func (r *PostgresPaymentRepository) DeleteOutboxMessage(ctx context.Context, messageID string) error {
+	if _, err := r.client.pool.Exec(ctx, `DELETE FROM outbox WHERE message_id = $1`, messageID); err != nil {
+		return errors.ErrDatabaseOperation("delete_outbox", err)
+	}
+	return nil
+}
+
+// paymentColumns are selected by every payment read and scanned by scanPayment
+const paymentColumns = `record, COALESCE(lock_owner, ''), COALESCE(lock_expires_at, 0), COALESCE(ttl, 0), COALESCE(idempotent_response, 'null'), idempotency_scope`
+
+// scanPayment rebuilds a payment from its JSONB record and the columns hidden from JSON
+func scanPayment(row pgx.Row) (*models.Payment, error) {
+	var record, response []byte
+	var payment models.Payment
+	var lockOwner, scope string
+	var lockExpiresAt, ttl int64
+
+	if err := row.Scan(&record, &lockOwner, &lockExpiresAt, &ttl, &response, &scope); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(record, &payment); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(response, &payment.IdempotentResponse); err != nil {
+		return nil, err
+	}
+
+	payment.LockOwner = lockOwner
+	payment.LockExpiresAt = lockExpiresAt
+	payment.TTL = ttl
+	payment.IdempotencyScope = scope
+	return &payment, nil
+}
+
+// GetPaymentByID retrieves a payment by its ID
+func (r *PostgresPaymentRepository) GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	payment, err := scanPayment(r.client.pool.QueryRow(ctx, `SELECT `+paymentColumns+` FROM payments WHERE payment_id = $1`, paymentID))
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return r.getArchivedPayment(ctx, paymentID)
+		}
+		logger.Error("Failed to get payment", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("get", err)
+	}
+	if err := r.fields.decryptPayment(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// getArchivedPayment reads a payment whose row has been purged after it was archived
+func (r *PostgresPaymentRepository) getArchivedPayment(ctx context.Context, paymentID string) (*models.Payment, error) {
+	if r.archive == nil {
+		return nil, errors.ErrPaymentNotFound(paymentID)
+	}
+	archived, err := r.archive.GetArchivedPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if archived == nil {
+		return nil, errors.ErrPaymentNotFound(paymentID)
+	}
+	if err := r.fields.decryptPayment(ctx, archived); err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// GetPaymentByIdempotencyKey retrieves a payment by its idempotency key within an API key's scope
+func (r *PostgresPaymentRepository) GetPaymentByIdempotencyKey(ctx context.Context, scope, idempotencyKey string) (*models.Payment, error) {
+	payment, err := scanPayment(r.client.pool.QueryRow(ctx, `SELECT `+paymentColumns+` FROM payments WHERE idempotency_scope = $1 AND idempotency_key = $2`, scope, idempotencyKey))
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, nil // Not found, but not an error
+		}
+		logger.Error("Failed to get payment by idempotency key", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("get", err)
+	}
+	if err := r.fields.decryptPayment(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// UpdatePayment replaces the payment unless another worker holds an unexpired lock
+func (r *PostgresPaymentRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	if err := r.updatePayment(ctx, r.client.pool, payment); err != nil {
+		return err
+	}
+
+	logger.Info("Payment updated", logger.Fields{
+		"payment_id": payment.PaymentID,
+		"status":     payment.Status,
+	})
+	return nil
+}
+
+// UpdatePaymentWithOutbox updates a payment and writes an outbox message in one transaction
+func (r *PostgresPaymentRepository) UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error {
+	err := pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		if err := r.updatePayment(ctx, tx, payment); err != nil {
+			return err
+		}
+		return insertOutbox(ctx, tx, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Payment updated", logger.Fields{
+		"payment_id": payment.PaymentID,
+		"status":     payment.Status,
+		"outbox_id":  msg.MessageID,
+	})
+	return nil
+}
+
+// updatePayment rewrites a payment row if no other worker holds an unexpired lock
+func (r *PostgresPaymentRepository) updatePayment(ctx context.Context, db pgExecer, payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+
+	stored, err := r.fields.encryptPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(stored)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := db.Exec(ctx, `
+		UPDATE payments SET status = $2, amount = $3, currency = $4, fee_amount = $5, chain = NULLIF($6, ''),
+			quote_id = NULLIF($7, ''), lock_owner = NULLIF($8, ''),
+			lock_expires_at = NULLIF($9, 0), ttl = NULLIF($10, 0), archived_at = $11, updated_at = $12, record = $13
+		WHERE payment_id = $1
+			AND (lock_owner IS NULL OR lock_owner = $8 OR lock_expires_at < $14)`,
+		payment.PaymentID, payment.Status, payment.Amount, payment.Currency, payment.FeeAmount, payment.Chain,
+		payment.QuoteID, payment.LockOwner, payment.LockExpiresAt, payment.TTL,
+		payment.ArchivedAt, payment.UpdatedAt, record, time.Now().Unix())
+	if err != nil {
+		logger.Error("Failed to update payment", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": payment.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("update", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		logger.Warn("Payment locked by another worker", logger.Fields{
+			"payment_id": payment.PaymentID,
+		})
+		return errors.ErrConflict(fmt.Sprintf("Payment '%s' is locked by another worker", payment.PaymentID))
+	}
+
+	return nil
+}
+
+// AcquireProcessingLock claims a payment if it is still in expectedStatus and unlocked
+func (r *PostgresPaymentRepository) AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE payments SET lock_owner = $3, lock_expires_at = $4
+		WHERE payment_id = $1 AND status = $2 AND (lock_owner IS NULL OR lock_expires_at < $5)`,
+		paymentID, expectedStatus, owner, now.Add(ttl).Unix(), now.Unix())
+	if err != nil {
+		logger.Error("Failed to acquire processing lock", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return false, errors.ErrDatabaseOperation("acquire_lock", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseProcessingLock clears the lock if it is still held by owner
+func (r *PostgresPaymentRepository) ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error {
+	_, err := r.client.pool.Exec(ctx, `
+		UPDATE payments SET lock_owner = NULL, lock_expires_at = NULL
+		WHERE payment_id = $1 AND lock_owner = $2`, paymentID, owner)
+	if err != nil {
+		return errors.ErrDatabaseOperation("release_lock", err)
+	}
+	return nil
+}
+
+// ScanUnarchivedPayments returns terminal payments that have not been exported to the archive yet
+func (r *PostgresPaymentRepository) ScanUnarchivedPayments(ctx context.Context) ([]*models.Payment, error) {
+	rows, err := r.client.pool.Query(ctx, `SELECT `+paymentColumns+` FROM payments WHERE status IN ($1, $2, $3) AND archived_at IS NULL`,
+		models.StatusCompleted, models.StatusFailed, models.StatusTimedOut)
+	if err != nil {
+		logger.Error("Failed to scan unarchived payments", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	if err := r.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListPaymentAIUsage returns the AI usage of payments created in [since, until)
+func (r *PostgresPaymentRepository) ListPaymentAIUsage(ctx context.Context, since, until time.Time) ([]*models.AIUsage, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record->'ai_usage' FROM payments
+		WHERE created_at >= $1 AND created_at < $2 AND record ? 'ai_usage'`, since, until)
+	if err != nil {
+		logger.Error("Failed to scan payment AI usage", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var usages []*models.AIUsage
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var usage models.AIUsage
+		if err := json.Unmarshal(record, &usage); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		usages = append(usages, &usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	return usages, nil
+}
+
+// ListRampTransfers returns the on-ramp and off-ramp transfers of payments created in [since, until)
+func (r *PostgresPaymentRepository) ListRampTransfers(ctx context.Context, since, until time.Time) ([]*models.RampTransfer, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM payments
+		WHERE created_at >= $1 AND created_at < $2 AND record ? 'on_ramp_tx_id'`, since, until)
+	if err != nil {
+		logger.Error("Failed to scan ramp transfers", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var transfers []*models.RampTransfer
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var payment models.Payment
+		if err := json.Unmarshal(record, &payment); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		transfers = append(transfers, models.RampTransfersFromPayment(&payment)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	return transfers, nil
+}
+
+// ListCompletedPayments returns the payments that completed in [since, until)
+func (r *PostgresPaymentRepository) ListCompletedPayments(ctx context.Context, since, until time.Time) ([]*models.Payment, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM payments
+		WHERE status = $1
+		AND (record->>'processed_at')::timestamptz >= $2 AND (record->>'processed_at')::timestamptz < $3`,
+		models.StatusCompleted, since, until)
+	if err != nil {
+		logger.Error("Failed to scan completed payments", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var payment models.Payment
+		if err := json.Unmarshal(record, &payment); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		payments = append(payments, &payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	if err := r.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListPaymentsByStatus returns the payments currently in status, oldest first
+func (r *PostgresPaymentRepository) ListPaymentsByStatus(ctx context.Context, status models.PaymentStatus) ([]*models.Payment, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM payments
+		WHERE status = $1
+		ORDER BY created_at`,
+		status)
+	if err != nil {
+		logger.Error("Failed to scan payments by status", logger.Fields{"status": status, "error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var payment models.Payment
+		if err := json.Unmarshal(record, &payment); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		payments = append(payments, &payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	if err := r.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListPaymentsByCustomer returns the customer's payments created since the given time, oldest first
+func (r *PostgresPaymentRepository) ListPaymentsByCustomer(ctx context.Context, customerID string, since time.Time) ([]*models.Payment, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM payments
+		WHERE record->>'customer_id' = $1 AND created_at >= $2
+		ORDER BY created_at`,
+		customerID, since)
+	if err != nil {
+		logger.Error("Failed to scan customer payments", logger.Fields{"customer_id": customerID, "error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var payment models.Payment
+		if err := json.Unmarshal(record, &payment); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		payments = append(payments, &payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	if err := r.fields.decryptPayments(ctx, payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ListSettlements returns the settlements of payments with a chain that finished since the given time
+func (r *PostgresPaymentRepository) ListSettlements(ctx context.Context, since time.Time) ([]*models.Settlement, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM payments
+		WHERE chain IS NOT NULL AND chain <> '' AND status IN ($1, $2)
+		AND (record->>'processed_at')::timestamptz >= $3`,
+		models.StatusCompleted, models.StatusTimedOut, since)
+	if err != nil {
+		logger.Error("Failed to scan settlements", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var settlements []*models.Settlement
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("scan", err)
+		}
+		var payment models.Payment
+		if err := json.Unmarshal(record, &payment); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		if settlement, ok := models.SettlementFromPayment(&payment); ok {
+			settlements = append(settlements, settlement)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	return settlements, nil
+}
+
+// MarkPaymentArchived records that a payment has been exported to the archive
+// The retention clock starts here, so a record is never expired before it has been archived.
+func (r *PostgresPaymentRepository) MarkPaymentArchived(ctx context.Context, paymentID string, archivedAt time.Time) error {
+	var ttl int64
+	if r.retention > 0 {
+		ttl = archivedAt.Add(r.retention).Unix()
+	}
+
+	_, err := r.client.pool.Exec(ctx, `
+		UPDATE payments SET archived_at = $2, ttl = NULLIF($3, 0), record = jsonb_set(record, '{archived_at}', to_jsonb($2::timestamptz))
+		WHERE payment_id = $1`, paymentID, archivedAt, ttl)
+	if err != nil {
+		logger.Error("Failed to mark payment archived", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": paymentID,
+		})
+		return errors.ErrDatabaseOperation("mark_archived", err)
+	}
+	return nil
+}
+
+// PostgresQuoteRepository stores quotes in Postgres
+type PostgresQuoteRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresQuoteRepository creates a quote repository on the shared pool
+func NewPostgresQuoteRepository(client *PostgresClient) *PostgresQuoteRepository {
+	return &PostgresQuoteRepository{client: client}
+}
+
+// CheckHealth verifies the database accepts connections
+func (r *PostgresQuoteRepository) CheckHealth(ctx context.Context) error {
+	return r.client.Ping(ctx)
+}
+
+// CreateQuote stores a new quote
+func (r *PostgresQuoteRepository) CreateQuote(ctx context.Context, quote *quotes.Quote) error {
+	record, err := json.Marshal(quote)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO quotes (quote_id, from_currency, to_currency, amount, expires_at, ttl, created_at, record)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8)`,
+		quote.QuoteID, quote.FromCurrency, quote.ToCurrency, quote.Amount, quote.ExpiresAt, quote.TTL, quote.CreatedAt, record)
+	if err != nil {
+		logger.Error("Failed to create quote", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("create", err)
+	}
+
+	logger.Info("Quote created", logger.Fields{
+		"quote_id":   quote.QuoteID,
+		"amount":     quote.Amount,
+		"expires_at": quote.ExpiresAt,
+	})
+	return nil
+}
+
+// GetQuote retrieves a quote by ID, treating TTL-expired quotes as missing
+func (r *PostgresQuoteRepository) GetQuote(ctx context.Context, quoteID string) (*quotes.Quote, error) {
+	var record []byte
+	var ttl int64
+
+	err := r.client.pool.QueryRow(ctx, `
+		SELECT record, COALESCE(ttl, 0) FROM quotes
+		WHERE quote_id = $1 AND (ttl IS NULL OR ttl >= $2)`, quoteID, time.Now().Unix()).Scan(&record, &ttl)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrQuoteNotFound(quoteID)
+		}
+		logger.Error("Failed to get quote", logger.Fields{"error": err.Error(), "quote_id": quoteID})
+		return nil, errors.ErrDatabaseOperation("get", err)
+	}
+
+	var quote quotes.Quote
+	if err := json.Unmarshal(record, &quote); err != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	quote.TTL = ttl
+
+	return &quote, nil
+}
+
+// MarkQuoteConsumed records the payment that used a quote
+func (r *PostgresQuoteRepository) MarkQuoteConsumed(ctx context.Context, quoteID, paymentID string, consumedAt time.Time) error {
+	return consumeQuote(ctx, r.client.pool, quoteID, paymentID, consumedAt)
+}
+
+// consumeQuote records the payment that used a quote, failing if another payment already used it
+func consumeQuote(ctx context.Context, db pgExecer, quoteID, paymentID string, consumedAt time.Time) error {
+	tag, err := db.Exec(ctx, `
+		UPDATE quotes SET record = record || jsonb_build_object('consumed_at', $2::timestamptz, 'payment_id', $3::text)
+		WHERE quote_id = $1 AND NOT record ? 'consumed_at'`, quoteID, consumedAt, paymentID)
+	if err != nil {
+		logger.Error("Failed to mark quote consumed", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quoteID,
+		})
+		return errors.ErrDatabaseOperation("mark_consumed", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrQuoteConsumed(quoteID)
+	}
+	return nil
+}
+
+// SetQuoteAIUsage records the accumulated AI spend on a quote
+func (r *PostgresQuoteRepository) SetQuoteAIUsage(ctx context.Context, quoteID string, usage *models.AIUsage) error {
+	encoded, err := json.Marshal(usage)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE quotes SET record = jsonb_set(record, '{ai_usage}', $2::jsonb)
+		WHERE quote_id = $1`, quoteID, encoded)
+	if err != nil {
+		logger.Error("Failed to record quote AI usage", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quoteID,
+		})
+		return errors.ErrDatabaseOperation("set_ai_usage", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrQuoteNotFound(quoteID)
+	}
+	return nil
+}
+
+// PostgresAuditRepository stores audit entries in Postgres
+// The audit_log table rejects UPDATE and DELETE, so entries can only be appended.
+type PostgresAuditRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresAuditRepository creates an audit repository on the shared pool
+func NewPostgresAuditRepository(client *PostgresClient) *PostgresAuditRepository {
+	return &PostgresAuditRepository{client: client}
+}
+
+// auditColumns are selected by every audit read and scanned by scanAuditEntry
+const auditColumns = `sequence, entry_id, timestamp, actor, action, resource_type, resource_id, details, prev_hash, hash`
+
+// AppendAuditEntry writes an entry, failing with a conflict if its sequence is already taken
+func (r *PostgresAuditRepository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO audit_log (`+auditColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.Sequence, entry.EntryID, entry.Timestamp, entry.Actor, entry.Action,
+		entry.ResourceType, entry.ResourceID, details, entry.PrevHash, entry.Hash)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Audit sequence %d already written", entry.Sequence))
+		}
+		logger.Error("Failed to append audit entry", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("append_audit", err)
+	}
+
+	return nil
+}
+
+// GetLatestAuditEntry returns the most recent entry, or nil if the log is empty
+func (r *PostgresAuditRepository) GetLatestAuditEntry(ctx context.Context) (*models.AuditEntry, error) {
+	entry, err := scanAuditEntry(r.client.pool.QueryRow(ctx, `
+		SELECT `+auditColumns+` FROM audit_log ORDER BY sequence DESC LIMIT 1`))
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logger.Error("Failed to get latest audit entry", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("query_audit", err)
+	}
+	return entry, nil
+}
+
+// ListAuditEntries returns up to limit entries after the given sequence, oldest first
+func (r *PostgresAuditRepository) ListAuditEntries(ctx context.Context, afterSequence int64, limit int) ([]*models.AuditEntry, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT `+auditColumns+` FROM audit_log
+		WHERE sequence > $1 ORDER BY sequence LIMIT $2`, afterSequence, limit)
+	if err != nil {
+		logger.Error("Failed to list audit entries", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("query_audit", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("query_audit", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_audit", err)
+	}
+
+	return entries, nil
+}
+
+// scanAuditEntry reads one audit_log row selected with auditColumns
+func scanAuditEntry(row pgx.Row) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	var details []byte
+
+	err := row.Scan(&entry.Sequence, &entry.EntryID, &entry.Timestamp, &entry.Actor, &entry.Action,
+		&entry.ResourceType, &entry.ResourceID, &details, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(details, &entry.Details); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// PostgresFeeComparisonRepository stores shadow-mode fee comparisons in Postgres
+type PostgresFeeComparisonRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresFeeComparisonRepository creates a fee comparison repository on the shared pool
+func NewPostgresFeeComparisonRepository(client *PostgresClient) *PostgresFeeComparisonRepository {
+	return &PostgresFeeComparisonRepository{client: client}
+}
+
+// RecordFeeComparison writes a comparison keyed by payment ID
+func (r *PostgresFeeComparisonRepository) RecordFeeComparison(ctx context.Context, comparison *models.FeeComparison) error {
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO fee_comparisons (payment_id, amount, source_currency, currency, static_fee, ai_fee,
+			delta, delta_percent, ai_chain, ai_confidence, ai_fallback, ai_error, ai_latency_ms, prompt_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (payment_id) DO NOTHING`,
+		comparison.PaymentID, comparison.Amount, comparison.SourceCurrency, comparison.Currency,
+		comparison.StaticFee, comparison.AIFee, comparison.Delta, comparison.DeltaPercent,
+		comparison.AIChain, comparison.AIConfidence, comparison.AIFallback, comparison.AIError,
+		comparison.AILatencyMs, comparison.PromptVersion, comparison.CreatedAt)
+	if err != nil {
+		logger.Error("Failed to record fee comparison", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": comparison.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("record_fee_comparison", err)
+	}
+	return nil
+}
+
+// PostgresFeeScheduleRepository stores fee schedule versions in Postgres
+type PostgresFeeScheduleRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresFeeScheduleRepository creates a fee schedule repository on the shared pool
+func NewPostgresFeeScheduleRepository(client *PostgresClient) *PostgresFeeScheduleRepository {
+	return &PostgresFeeScheduleRepository{client: client}
+}
+
+// CreateFeeSchedule writes a new version, failing with a conflict if it already exists
+func (r *PostgresFeeScheduleRepository) CreateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error {
+	tiers, err := json.Marshal(schedule.Tiers)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO fee_schedules (schedule_id, version, tiers, effective_from, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		schedule.ScheduleID, schedule.Version, tiers, schedule.EffectiveFrom, schedule.CreatedBy, schedule.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Fee schedule %s version %d already exists", schedule.ScheduleID, schedule.Version))
+		}
+		logger.Error("Failed to create fee schedule", logger.Fields{
+			"error":       err.Error(),
+			"schedule_id": schedule.ScheduleID,
+		})
+		return errors.ErrDatabaseOperation("create_fee_schedule", err)
+	}
+	return nil
+}
+
+// ListFeeSchedules returns every version of a schedule, oldest first
+func (r *PostgresFeeScheduleRepository) ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT schedule_id, version, tiers, effective_from, created_by, created_at
+		FROM fee_schedules WHERE schedule_id = $1 ORDER BY version`, scheduleID)
+	if err != nil {
+		logger.Error("Failed to list fee schedules", logger.Fields{"error": err.Error(), "schedule_id": scheduleID})
+		return nil, errors.ErrDatabaseOperation("query_fee_schedules", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.FeeSchedule
+	for rows.Next() {
+		var schedule models.FeeSchedule
+		var tiers []byte
+		if err := rows.Scan(&schedule.ScheduleID, &schedule.Version, &tiers, &schedule.EffectiveFrom,
+			&schedule.CreatedBy, &schedule.CreatedAt); err != nil {
+			return nil, errors.ErrDatabaseOperation("query_fee_schedules", err)
+		}
+		if err := json.Unmarshal(tiers, &schedule.Tiers); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		versions = append(versions, &schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_fee_schedules", err)
+	}
+
+	return versions, nil
+}
+
+// PostgresPromoRepository stores promo codes in Postgres
+type PostgresPromoRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresPromoRepository creates a promo code repository on the shared pool
+func NewPostgresPromoRepository(client *PostgresClient) *PostgresPromoRepository {
+	return &PostgresPromoRepository{client: client}
+}
+
+// CreatePromo writes a new promo code, failing with a conflict if the code exists
+func (r *PostgresPromoRepository) CreatePromo(ctx context.Context, promo *models.Promo) error {
+	var validUntil *time.Time
+	if !promo.ValidUntil.IsZero() {
+		validUntil = &promo.ValidUntil
+	}
+
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO promos (code, percent_off, amount_off, valid_from, valid_until, max_uses, uses, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		promo.Code, promo.PercentOff, promo.AmountOff, promo.ValidFrom, validUntil, promo.MaxUses, promo.Uses, promo.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Promo code %s already exists", promo.Code))
+		}
+		logger.Error("Failed to create promo", logger.Fields{"error": err.Error(), "code": promo.Code})
+		return errors.ErrDatabaseOperation("create_promo", err)
+	}
+	return nil
+}
+
+// GetPromo retrieves a promo code
+func (r *PostgresPromoRepository) GetPromo(ctx context.Context, code string) (*models.Promo, error) {
+	var promo models.Promo
+	var validUntil *time.Time
+	err := r.client.pool.QueryRow(ctx, `
+		SELECT code, percent_off, amount_off, valid_from, valid_until, max_uses, uses, created_at
+		FROM promos WHERE code = $1`, code).
+		Scan(&promo.Code, &promo.PercentOff, &promo.AmountOff, &promo.ValidFrom, &validUntil,
+			&promo.MaxUses, &promo.Uses, &promo.CreatedAt)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrPromoNotFound(code)
+		}
+		logger.Error("Failed to get promo", logger.Fields{"error": err.Error(), "code": code})
+		return nil, errors.ErrDatabaseOperation("get_promo", err)
+	}
+	if validUntil != nil {
+		promo.ValidUntil = *validUntil
+	}
+	return &promo, nil
+}
+
+// RedeemPromo counts one use of a promo code, failing with a conflict once its uses run out
+func (r *PostgresPromoRepository) RedeemPromo(ctx context.Context, code string) error {
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE promos SET uses = uses + 1
+		WHERE code = $1 AND (max_uses = 0 OR uses < max_uses)`, code)
+	if err != nil {
+		logger.Error("Failed to redeem promo", logger.Fields{"error": err.Error(), "code": code})
+		return errors.ErrDatabaseOperation("redeem_promo", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrConflict(fmt.Sprintf("Promo code %s has no uses left", code))
+	}
+	return nil
+}
+
+// ReleasePromo gives back one use of a promo code redeemed for a payment that was never created
+func (r *PostgresPromoRepository) ReleasePromo(ctx context.Context, code string) error {
+	_, err := r.client.pool.Exec(ctx, `UPDATE promos SET uses = uses - 1 WHERE code = $1 AND uses > 0`, code)
+	if err != nil {
+		logger.Error("Failed to release promo", logger.Fields{"error": err.Error(), "code": code})
+		return errors.ErrDatabaseOperation("release_promo", err)
+	}
+	return nil
+}
+
+// PostgresVolumeRepository stores completed payment volume in Postgres
+type PostgresVolumeRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresVolumeRepository creates a customer volume repository on the shared pool
+func NewPostgresVolumeRepository(client *PostgresClient) *PostgresVolumeRepository {
+	return &PostgresVolumeRepository{client: client}
+}
+
+// RecordVolume writes a completed payment's volume entry; rewriting the same payment is a no-op
+func (r *PostgresVolumeRepository) RecordVolume(ctx context.Context, entry *models.VolumeEntry) error {
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO customer_volumes (customer_id, payment_id, amount_usd, completed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (customer_id, payment_id) DO NOTHING`,
+		entry.CustomerID, entry.PaymentID, entry.AmountUSD, entry.CompletedAt)
+	if err != nil {
+		logger.Error("Failed to record volume", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": entry.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("record_volume", err)
+	}
+	return nil
+}
+
+// RollingVolume sums a customer's completed payment volume since the given time, in USD cents
+func (r *PostgresVolumeRepository) RollingVolume(ctx context.Context, customerID string, since time.Time) (int64, error) {
+	var total int64
+	err := r.client.pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount_usd), 0)::BIGINT FROM customer_volumes
+		WHERE customer_id = $1 AND completed_at >= $2`, customerID, since).Scan(&total)
+	if err != nil {
+		logger.Error("Failed to query volume", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return 0, errors.ErrDatabaseOperation("query_volume", err)
+	}
+	return total, nil
+}
+
+// PostgresGasPriceRepository stores gas price snapshots in Postgres
+type PostgresGasPriceRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresGasPriceRepository creates a gas price history repository on the shared pool
+func NewPostgresGasPriceRepository(client *PostgresClient) *PostgresGasPriceRepository {
+	return &PostgresGasPriceRepository{client: client}
+}
+
+// RecordGasPrice writes a gas price snapshot, replacing any reading for the same chain and second
+func (r *PostgresGasPriceRepository) RecordGasPrice(ctx context.Context, snapshot *models.GasSnapshot) error {
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO gas_prices (chain, recorded_at, gas_price, cost_usd)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain, recorded_at) DO UPDATE SET gas_price = EXCLUDED.gas_price, cost_usd = EXCLUDED.cost_usd`,
+		snapshot.Chain, snapshot.RecordedAt.Truncate(time.Second), snapshot.GasPrice, snapshot.CostUSD)
+	if err != nil {
+		logger.Error("Failed to record gas price", logger.Fields{"error": err.Error(), "chain": snapshot.Chain})
+		return errors.ErrDatabaseOperation("record_gas_price", err)
+	}
+	return nil
+}
+
+// ListGasPrices returns a chain's snapshots recorded since the given time, oldest first
+func (r *PostgresGasPriceRepository) ListGasPrices(ctx context.Context, chain string, since time.Time) ([]*models.GasSnapshot, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT chain, recorded_at, gas_price, cost_usd FROM gas_prices
+		WHERE chain = $1 AND recorded_at >= $2
+		ORDER BY recorded_at`, chain, since)
+	if err != nil {
+		logger.Error("Failed to query gas prices", logger.Fields{"error": err.Error(), "chain": chain})
+		return nil, errors.ErrDatabaseOperation("query_gas_prices", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*models.GasSnapshot
+	for rows.Next() {
+		var snapshot models.GasSnapshot
+		if err := rows.Scan(&snapshot.Chain, &snapshot.RecordedAt, &snapshot.GasPrice, &snapshot.CostUSD); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_gas_prices", err)
+	}
+
+	return snapshots, nil
+}
+
+// PostgresFeeInvoiceRepository stores fee invoices in Postgres
+type PostgresFeeInvoiceRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresFeeInvoiceRepository creates a fee invoice repository on the shared pool
+func NewPostgresFeeInvoiceRepository(client *PostgresClient) *PostgresFeeInvoiceRepository {
+	return &PostgresFeeInvoiceRepository{client: client}
+}
+
+// feeInvoiceColumns lists fee_invoices columns in scanFeeInvoice order
+const feeInvoiceColumns = `payment_id, customer_id, quote_id, amount, currency, payout_currency, platform_fee, regulatory_fee,
+	onramp_fee, offramp_fee, gas_fee, total_fees, negotiated_discount, promo_code, promo_discount,
+	pricing_customer, fee_limit, fee_schedule_id, fee_schedule_version, completed_at, issued_at`
+
+// scanFeeInvoice reads a fee_invoices row selected with feeInvoiceColumns
+func scanFeeInvoice(row pgx.Row) (*models.FeeInvoice, error) {
+	var invoice models.FeeInvoice
+	err := row.Scan(&invoice.PaymentID, &invoice.CustomerID, &invoice.QuoteID, &invoice.Amount, &invoice.Currency,
+		&invoice.PayoutCurrency, &invoice.PlatformFee, &invoice.RegulatoryFee, &invoice.OnrampFee, &invoice.OfframpFee,
+		&invoice.GasFee, &invoice.TotalFees, &invoice.NegotiatedDiscount, &invoice.PromoCode, &invoice.PromoDiscount,
+		&invoice.PricingCustomer, &invoice.FeeLimit, &invoice.FeeScheduleID, &invoice.FeeScheduleVersion,
+		&invoice.CompletedAt, &invoice.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// CreateFeeInvoice writes a payment's fee invoice, failing with a conflict if it was already issued
+func (r *PostgresFeeInvoiceRepository) CreateFeeInvoice(ctx context.Context, invoice *models.FeeInvoice) error {
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO fee_invoices (`+feeInvoiceColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)`,
+		invoice.PaymentID, invoice.CustomerID, invoice.QuoteID, invoice.Amount, invoice.Currency, invoice.PayoutCurrency,
+		invoice.PlatformFee, invoice.RegulatoryFee, invoice.OnrampFee, invoice.OfframpFee, invoice.GasFee, invoice.TotalFees,
+		invoice.NegotiatedDiscount, invoice.PromoCode, invoice.PromoDiscount, invoice.PricingCustomer, invoice.FeeLimit,
+		invoice.FeeScheduleID, invoice.FeeScheduleVersion, invoice.CompletedAt, invoice.IssuedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Fee invoice for payment %s already exists", invoice.PaymentID))
+		}
+		logger.Error("Failed to create fee invoice", logger.Fields{"error": err.Error(), "payment_id": invoice.PaymentID})
+		return errors.ErrDatabaseOperation("create_fee_invoice", err)
+	}
+	return nil
+}
+
+// GetFeeInvoice retrieves a payment's fee invoice
+func (r *PostgresFeeInvoiceRepository) GetFeeInvoice(ctx context.Context, paymentID string) (*models.FeeInvoice, error) {
+	invoice, err := scanFeeInvoice(r.client.pool.QueryRow(ctx,
+		`SELECT `+feeInvoiceColumns+` FROM fee_invoices WHERE payment_id = $1`, paymentID))
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrFeeInvoiceNotFound(paymentID)
+		}
+		logger.Error("Failed to get fee invoice", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("get_fee_invoice", err)
+	}
+	return invoice, nil
+}
+
+// ListFeeInvoices returns the invoices of payments completed in [since, until)
+func (r *PostgresFeeInvoiceRepository) ListFeeInvoices(ctx context.Context, since, until time.Time) ([]*models.FeeInvoice, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT `+feeInvoiceColumns+` FROM fee_invoices
+		WHERE completed_at >= $1 AND completed_at < $2`, since, until)
+	if err != nil {
+		logger.Error("Failed to scan fee invoices", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+	defer rows.Close()
+
+	var invoices []*models.FeeInvoice
+	for rows.Next() {
+		invoice, err := scanFeeInvoice(rows)
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		invoices = append(invoices, invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("scan", err)
+	}
+
+	return invoices, nil
+}
+
+// PostgresRevenueReportRepository stores revenue report rows in Postgres
+type PostgresRevenueReportRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresRevenueReportRepository creates a revenue report repository on the shared pool
+func NewPostgresRevenueReportRepository(client *PostgresClient) *PostgresRevenueReportRepository {
+	return &PostgresRevenueReportRepository{client: client}
+}
+
+// PutRevenueReports writes report rows, replacing any earlier rows for the same date, corridor and customer
+func (r *PostgresRevenueReportRepository) PutRevenueReports(ctx context.Context, reports []*models.RevenueReport) error {
+	for _, report := range reports {
+		_, err := r.client.pool.Exec(ctx, `
+			INSERT INTO revenue_reports (report_date, corridor, customer_id, currency, payments, volume,
+				platform_revenue, regulatory_fees, provider_costs, gas_spend, generated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (report_date, corridor, customer_id) DO UPDATE SET
+				currency = EXCLUDED.currency, payments = EXCLUDED.payments, volume = EXCLUDED.volume,
+				platform_revenue = EXCLUDED.platform_revenue, regulatory_fees = EXCLUDED.regulatory_fees,
+				provider_costs = EXCLUDED.provider_costs, gas_spend = EXCLUDED.gas_spend,
+				generated_at = EXCLUDED.generated_at`,
+			report.ReportDate, report.Corridor, report.CustomerID, report.Currency, report.Payments, report.Volume,
+			report.PlatformRevenue, report.RegulatoryFees, report.ProviderCosts, report.GasSpend, report.GeneratedAt)
+		if err != nil {
+			logger.Error("Failed to put revenue report", logger.Fields{
+				"error":       err.Error(),
+				"report_date": report.ReportDate,
+				"corridor":    report.Corridor,
+			})
+			return errors.ErrDatabaseOperation("put_revenue_report", err)
+		}
+	}
+	return nil
+}
+
+// ListRevenueReports returns every row of a day's report
+func (r *PostgresRevenueReportRepository) ListRevenueReports(ctx context.Context, reportDate string) ([]*models.RevenueReport, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT to_char(report_date, 'YYYY-MM-DD'), corridor, customer_id, currency, payments, volume,
+			platform_revenue, regulatory_fees, provider_costs, gas_spend, generated_at
+		FROM revenue_reports WHERE report_date = $1
+		ORDER BY corridor, customer_id`, reportDate)
+	if err != nil {
+		logger.Error("Failed to query revenue reports", logger.Fields{"error": err.Error(), "report_date": reportDate})
+		return nil, errors.ErrDatabaseOperation("query", err)
+	}
+	defer rows.Close()
+
+	var reports []*models.RevenueReport
+	for rows.Next() {
+		var report models.RevenueReport
+		if err := rows.Scan(&report.ReportDate, &report.Corridor, &report.CustomerID, &report.Currency, &report.Payments,
+			&report.Volume, &report.PlatformRevenue, &report.RegulatoryFees, &report.ProviderCosts, &report.GasSpend,
+			&report.GeneratedAt); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		reports = append(reports, &report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query", err)
+	}
+
+	return reports, nil
+}
+
+// PostgresTreasuryRepository stores treasury balances in Postgres
+type PostgresTreasuryRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresTreasuryRepository creates a treasury repository on the shared pool
+func NewPostgresTreasuryRepository(client *PostgresClient) *PostgresTreasuryRepository {
+	return &PostgresTreasuryRepository{client: client}
+}
+
+// ApplyTreasuryMovement adds a movement's entries to their balances, reporting false if it was already applied
+// The movement record and balance updates are written in one transaction.
+func (r *PostgresTreasuryRepository) ApplyTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	record, err := json.Marshal(movement)
+	if err != nil {
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	applied := false
+	err = pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO treasury_movements (movement_id, payment_id, leg, record, created_at)
+			VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+			ON CONFLICT (movement_id) DO NOTHING`,
+			movement.MovementID, movement.PaymentID, movement.Leg, record, movement.CreatedAt)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, entry := range movement.Entries {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO treasury (account, kind, location, currency, balance, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (account) DO UPDATE SET balance = treasury.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at`,
+				entry.Account(), entry.Kind, entry.Location, entry.Currency, entry.Amount, now)
+			if err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to apply treasury movement", logger.Fields{"error": err.Error(), "movement_id": movement.MovementID})
+		return false, errors.ErrDatabaseOperation("apply_treasury_movement", err)
+	}
+
+	return applied, nil
+}
+
+// errTreasuryShort rolls back a reservation an account can't cover
+var errTreasuryShort = stderrors.New("treasury balance does not cover the debit")
+
+// ReserveTreasuryMovement applies a movement only if every account it debits covers the debit
+// It reports true once the movement has been applied, now or before, and false if an account is short.
+// Debits only update rows whose balance covers them, so concurrent payments can't both take the last
+// of an account.
+func (r *PostgresTreasuryRepository) ReserveTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	record, err := json.Marshal(movement)
+	if err != nil {
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	err = pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO treasury_movements (movement_id, payment_id, leg, record, created_at)
+			VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+			ON CONFLICT (movement_id) DO NOTHING`,
+			movement.MovementID, movement.PaymentID, movement.Leg, record, movement.CreatedAt)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, entry := range movement.Entries {
+			if entry.Amount >= 0 {
+				_, err := tx.Exec(ctx, `
+					INSERT INTO treasury (account, kind, location, currency, balance, updated_at)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					ON CONFLICT (account) DO UPDATE SET balance = treasury.balance + EXCLUDED.balance, updated_at = EXCLUDED.updated_at`,
+					entry.Account(), entry.Kind, entry.Location, entry.Currency, entry.Amount, now)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			tag, err := tx.Exec(ctx, `
+				UPDATE treasury SET balance = balance + $2, updated_at = $3
+				WHERE account = $1 AND balance >= -$2`,
+				entry.Account(), entry.Amount, now)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return errTreasuryShort
+			}
+		}
+		return nil
+	})
+	if stderrors.Is(err, errTreasuryShort) {
+		return false, nil
+	}
+	if err != nil {
+		logger.Error("Failed to reserve treasury movement", logger.Fields{"error": err.Error(), "movement_id": movement.MovementID})
+		return false, errors.ErrDatabaseOperation("reserve_treasury_movement", err)
+	}
+
+	return true, nil
+}
+
+// GetTreasuryBalance retrieves an account's balance, or nil if nothing has moved through it
+func (r *PostgresTreasuryRepository) GetTreasuryBalance(ctx context.Context, account string) (*models.TreasuryBalance, error) {
+	var balance models.TreasuryBalance
+	err := r.client.pool.QueryRow(ctx, `
+		SELECT account, kind, location, currency, balance, updated_at FROM treasury WHERE account = $1`, account).
+		Scan(&balance.Account, &balance.Kind, &balance.Location, &balance.Currency, &balance.Balance, &balance.UpdatedAt)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logger.Error("Failed to get treasury balance", logger.Fields{"error": err.Error(), "account": account})
+		return nil, errors.ErrDatabaseOperation("get_treasury_balance", err)
+	}
+	return &balance, nil
+}
+
+// ListTreasuryBalances returns every tracked balance, ordered by account
+func (r *PostgresTreasuryRepository) ListTreasuryBalances(ctx context.Context) ([]*models.TreasuryBalance, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT account, kind, location, currency, balance, updated_at FROM treasury ORDER BY account`)
+	if err != nil {
+		logger.Error("Failed to query treasury balances", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("query_treasury", err)
+	}
+	defer rows.Close()
+
+	var balances []*models.TreasuryBalance
+	for rows.Next() {
+		var balance models.TreasuryBalance
+		if err := rows.Scan(&balance.Account, &balance.Kind, &balance.Location, &balance.Currency, &balance.Balance, &balance.UpdatedAt); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		balances = append(balances, &balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_treasury", err)
+	}
+
+	return balances, nil
+}
+
+// PostgresLedgerRepository stores ledger transactions in Postgres, with one row per entry for finance queries
+type PostgresLedgerRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresLedgerRepository creates a ledger repository on the shared pool
+func NewPostgresLedgerRepository(client *PostgresClient) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{client: client}
+}
+
+// CreateLedgerTransaction writes a transaction and its entries, failing with a conflict if it was already posted
+func (r *PostgresLedgerRepository) CreateLedgerTransaction(ctx context.Context, txn *models.LedgerTransaction) error {
+	record, err := json.Marshal(txn)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	err = pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO ledger_transactions (transaction_id, payment_id, leg, record, created_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			txn.TransactionID, txn.PaymentID, txn.Leg, record, txn.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for line, entry := range txn.Entries {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO ledger_entries (transaction_id, line, payment_id, account, side, amount, currency, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				txn.TransactionID, line, txn.PaymentID, entry.Account, entry.Side, entry.Amount, entry.Currency, txn.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Ledger transaction %s already exists", txn.TransactionID))
+		}
+		logger.Error("Failed to create ledger transaction", logger.Fields{"error": err.Error(), "transaction_id": txn.TransactionID})
+		return errors.ErrDatabaseOperation("create_ledger_transaction", err)
+	}
+	return nil
+}
+
+// ListLedgerTransactions returns a payment's transactions, oldest first
+func (r *PostgresLedgerRepository) ListLedgerTransactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM ledger_transactions WHERE payment_id = $1 ORDER BY created_at, transaction_id`, paymentID)
+	if err != nil {
+		logger.Error("Failed to query ledger", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("query_ledger", err)
+	}
+	defer rows.Close()
+
+	var txns []*models.LedgerTransaction
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("query_ledger", err)
+		}
+		var txn models.LedgerTransaction
+		if err := json.Unmarshal(record, &txn); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		txns = append(txns, &txn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_ledger", err)
+	}
+
+	return txns, nil
+}
+
+// PostgresReconciliationRepository stores reconciliation breaks in Postgres
+type PostgresReconciliationRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresReconciliationRepository creates a reconciliation break repository on the shared pool
+func NewPostgresReconciliationRepository(client *PostgresClient) *PostgresReconciliationRepository {
+	return &PostgresReconciliationRepository{client: client}
+}
+
+// RecordBreak writes a break, returning false if it was already recorded
+func (r *PostgresReconciliationRepository) RecordBreak(ctx context.Context, brk *models.ReconciliationBreak) (bool, error) {
+	record, err := json.Marshal(brk)
+	if err != nil {
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		INSERT INTO reconciliation_breaks (break_id, status, record, detected_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (break_id) DO NOTHING`,
+		brk.BreakID, brk.Status, record, brk.DetectedAt)
+	if err != nil {
+		logger.Error("Failed to record reconciliation break", logger.Fields{"error": err.Error(), "break_id": brk.BreakID})
+		return false, errors.ErrDatabaseOperation("record_break", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetBreak retrieves a break
+func (r *PostgresReconciliationRepository) GetBreak(ctx context.Context, breakID string) (*models.ReconciliationBreak, error) {
+	var record []byte
+	err := r.client.pool.QueryRow(ctx, `SELECT record FROM reconciliation_breaks WHERE break_id = $1`, breakID).Scan(&record)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrBreakNotFound(breakID)
+		}
+		logger.Error("Failed to get reconciliation break", logger.Fields{"error": err.Error(), "break_id": breakID})
+		return nil, errors.ErrDatabaseOperation("get_break", err)
+	}
+
+	var brk models.ReconciliationBreak
+	if err := json.Unmarshal(record, &brk); err != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return &brk, nil
+}
+
+// ListBreaks returns the breaks with a status, or every break if status is empty, oldest first
+func (r *PostgresReconciliationRepository) ListBreaks(ctx context.Context, status string) ([]*models.ReconciliationBreak, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM reconciliation_breaks
+		WHERE $1 = '' OR status = $1
+		ORDER BY detected_at, break_id`, status)
+	if err != nil {
+		logger.Error("Failed to list reconciliation breaks", logger.Fields{"error": err.Error(), "status": status})
+		return nil, errors.ErrDatabaseOperation("list_breaks", err)
+	}
+	defer rows.Close()
+
+	var breaks []*models.ReconciliationBreak
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_breaks", err)
+		}
+		var brk models.ReconciliationBreak
+		if err := json.Unmarshal(record, &brk); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		breaks = append(breaks, &brk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_breaks", err)
+	}
+
+	return breaks, nil
+}
+
+// ResolveBreak marks an open break resolved, failing with a conflict if it already was
+func (r *PostgresReconciliationRepository) ResolveBreak(ctx context.Context, breakID, resolution, resolvedBy string, at time.Time) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status":      models.BreakStatusResolved,
+		"resolution":  resolution,
+		"resolved_by": resolvedBy,
+		"resolved_at": at,
+	})
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE reconciliation_breaks SET status = $2, record = record || $3::jsonb
+		WHERE break_id = $1 AND status = $4`,
+		breakID, models.BreakStatusResolved, patch, models.BreakStatusOpen)
+	if err != nil {
+		logger.Error("Failed to resolve reconciliation break", logger.Fields{"error": err.Error(), "break_id": breakID})
+		return errors.ErrDatabaseOperation("resolve_break", err)
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.GetBreak(ctx, breakID); err != nil {
+			return err
+		}
+		return errors.ErrConflict(fmt.Sprintf("Reconciliation break %s is already resolved", breakID))
+	}
+	return nil
+}
+
+// PostgresCustomerRepository stores customers in Postgres
+type PostgresCustomerRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresCustomerRepository creates a customer repository on the shared pool
+func NewPostgresCustomerRepository(client *PostgresClient) *PostgresCustomerRepository {
+	return &PostgresCustomerRepository{client: client}
+}
+
+// CreateCustomer writes a new customer, failing with a conflict if the ID exists
+func (r *PostgresCustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) error {
+	record, err := json.Marshal(customer)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO customers (customer_id, record, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)`,
+		customer.CustomerID, record, customer.CreatedAt, customer.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Customer %s already exists", customer.CustomerID))
+		}
+		logger.Error("Failed to create customer", logger.Fields{"error": err.Error(), "customer_id": customer.CustomerID})
+		return errors.ErrDatabaseOperation("create_customer", err)
+	}
+	return nil
+}
+
+// GetCustomer retrieves a customer
+func (r *PostgresCustomerRepository) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	var record []byte
+	err := r.client.pool.QueryRow(ctx, `SELECT record FROM customers WHERE customer_id = $1`, customerID).Scan(&record)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrCustomerNotFound(customerID)
+		}
+		logger.Error("Failed to get customer", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("get_customer", err)
+	}
+
+	var customer models.Customer
+	if err := json.Unmarshal(record, &customer); err != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return &customer, nil
+}
+
+// ListCustomers returns every customer, ordered by ID
+func (r *PostgresCustomerRepository) ListCustomers(ctx context.Context) ([]*models.Customer, error) {
+	rows, err := r.client.pool.Query(ctx, `SELECT record FROM customers ORDER BY customer_id`)
+	if err != nil {
+		logger.Error("Failed to list customers", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_customers", err)
+	}
+	defer rows.Close()
+
+	customers := []*models.Customer{}
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_customers", err)
+		}
+		var customer models.Customer
+		if err := json.Unmarshal(record, &customer); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		customers = append(customers, &customer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_customers", err)
+	}
+
+	return customers, nil
+}
+
+// UpdateCustomer replaces an existing customer
+func (r *PostgresCustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) error {
+	record, err := json.Marshal(customer)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE customers SET record = $2, updated_at = $3 WHERE customer_id = $1`,
+		customer.CustomerID, record, customer.UpdatedAt)
+	if err != nil {
+		logger.Error("Failed to update customer", logger.Fields{"error": err.Error(), "customer_id": customer.CustomerID})
+		return errors.ErrDatabaseOperation("update_customer", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrCustomerNotFound(customer.CustomerID)
+	}
+	return nil
+}
+
+// DeleteCustomer removes a customer
+func (r *PostgresCustomerRepository) DeleteCustomer(ctx context.Context, customerID string) error {
+	tag, err := r.client.pool.Exec(ctx, `DELETE FROM customers WHERE customer_id = $1`, customerID)
+	if err != nil {
+		logger.Error("Failed to delete customer", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return errors.ErrDatabaseOperation("delete_customer", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrCustomerNotFound(customerID)
+	}
+	return nil
+}
+
+// PostgresAMLAlertRepository stores AML alerts in Postgres
+type PostgresAMLAlertRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresAMLAlertRepository creates an AML alert repository on the shared pool
+func NewPostgresAMLAlertRepository(client *PostgresClient) *PostgresAMLAlertRepository {
+	return &PostgresAMLAlertRepository{client: client}
+}
+
+// RecordAlert writes an alert, returning false if it was already recorded
+func (r *PostgresAMLAlertRepository) RecordAlert(ctx context.Context, alert *models.AMLAlert) (bool, error) {
+	record, err := json.Marshal(alert)
+	if err != nil {
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		INSERT INTO aml_alerts (alert_id, status, record, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (alert_id) DO NOTHING`,
+		alert.AlertID, alert.Status, record, alert.CreatedAt)
+	if err != nil {
+		logger.Error("Failed to record AML alert", logger.Fields{"error": err.Error(), "alert_id": alert.AlertID})
+		return false, errors.ErrDatabaseOperation("record_alert", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetAlert retrieves an alert
+func (r *PostgresAMLAlertRepository) GetAlert(ctx context.Context, alertID string) (*models.AMLAlert, error) {
+	var record []byte
+	err := r.client.pool.QueryRow(ctx, `SELECT record FROM aml_alerts WHERE alert_id = $1`, alertID).Scan(&record)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrAMLAlertNotFound(alertID)
+		}
+		logger.Error("Failed to get AML alert", logger.Fields{"error": err.Error(), "alert_id": alertID})
+		return nil, errors.ErrDatabaseOperation("get_alert", err)
+	}
+
+	var alert models.AMLAlert
+	if err := json.Unmarshal(record, &alert); err != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return &alert, nil
+}
+
+// ListAlerts returns the alerts with a status, or every alert if status is empty, oldest first
+func (r *PostgresAMLAlertRepository) ListAlerts(ctx context.Context, status string) ([]*models.AMLAlert, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM aml_alerts
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at, alert_id`, status)
+	if err != nil {
+		logger.Error("Failed to list AML alerts", logger.Fields{"error": err.Error(), "status": status})
+		return nil, errors.ErrDatabaseOperation("list_alerts", err)
+	}
+	defer rows.Close()
+
+	var alerts []*models.AMLAlert
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_alerts", err)
+		}
+		var alert models.AMLAlert
+		if err := json.Unmarshal(record, &alert); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		alerts = append(alerts, &alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_alerts", err)
+	}
+
+	return alerts, nil
+}
+
+// CloseAlert marks an open alert closed, failing with a conflict if it already was
+func (r *PostgresAMLAlertRepository) CloseAlert(ctx context.Context, alertID, resolution, resolvedBy string, at time.Time) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status":      models.AMLAlertStatusClosed,
+		"resolution":  resolution,
+		"resolved_by": resolvedBy,
+		"resolved_at": at,
+	})
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE aml_alerts SET status = $2, record = record || $3::jsonb
+		WHERE alert_id = $1 AND status = $4`,
+		alertID, models.AMLAlertStatusClosed, patch, models.AMLAlertStatusOpen)
+	if err != nil {
+		logger.Error("Failed to close AML alert", logger.Fields{"error": err.Error(), "alert_id": alertID})
+		return errors.ErrDatabaseOperation("close_alert", err)
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := r.GetAlert(ctx, alertID); err != nil {
+			return err
+		}
+		return errors.ErrConflict(fmt.Sprintf("AML alert %s is already closed", alertID))
+	}
+	return nil
+}
+
+// PostgresWebhookSubscriptionRepository stores webhook subscriptions in Postgres
+type PostgresWebhookSubscriptionRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresWebhookSubscriptionRepository creates a webhook subscription repository on the shared pool
+func NewPostgresWebhookSubscriptionRepository(client *PostgresClient) *PostgresWebhookSubscriptionRepository {
+	return &PostgresWebhookSubscriptionRepository{client: client}
+}
+
+// CreateWebhookSubscription writes a new subscription, failing with a conflict if the ID exists
+func (r *PostgresWebhookSubscriptionRepository) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	record, err := json.Marshal(sub)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO webhook_subscriptions (subscription_id, customer_id, record, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		sub.SubscriptionID, sub.CustomerID, record, sub.CreatedAt, sub.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("Webhook subscription %s already exists", sub.SubscriptionID))
+		}
+		logger.Error("Failed to create webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": sub.SubscriptionID})
+		return errors.ErrDatabaseOperation("create_webhook_subscription", err)
+	}
+	return nil
+}
+
+// GetWebhookSubscription retrieves a subscription
+func (r *PostgresWebhookSubscriptionRepository) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	var record []byte
+	err := r.client.pool.QueryRow(ctx, `SELECT record FROM webhook_subscriptions WHERE subscription_id = $1`, subscriptionID).Scan(&record)
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+		}
+		logger.Error("Failed to get webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return nil, errors.ErrDatabaseOperation("get_webhook_subscription", err)
+	}
+
+	var sub models.WebhookSubscription
+	if err := json.Unmarshal(record, &sub); err != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+	return &sub, nil
+}
+
+// ListWebhookSubscriptions returns a customer's subscriptions, oldest first
+func (r *PostgresWebhookSubscriptionRepository) ListWebhookSubscriptions(ctx context.Context, customerID string) ([]*models.WebhookSubscription, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM webhook_subscriptions WHERE customer_id = $1 ORDER BY created_at`, customerID)
+	if err != nil {
+		logger.Error("Failed to list webhook subscriptions", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("list_webhook_subscriptions", err)
+	}
+	defer rows.Close()
+
+	subs := []*models.WebhookSubscription{}
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_webhook_subscriptions", err)
+		}
+		var sub models.WebhookSubscription
+		if err := json.Unmarshal(record, &sub); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		subs = append(subs, &sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_webhook_subscriptions", err)
+	}
+
+	return subs, nil
+}
+
+// UpdateWebhookSubscription replaces an existing subscription
+func (r *PostgresWebhookSubscriptionRepository) UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	record, err := json.Marshal(sub)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `
+		UPDATE webhook_subscriptions SET record = $2, updated_at = $3 WHERE subscription_id = $1`,
+		sub.SubscriptionID, record, sub.UpdatedAt)
+	if err != nil {
+		logger.Error("Failed to update webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": sub.SubscriptionID})
+		return errors.ErrDatabaseOperation("update_webhook_subscription", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrWebhookSubscriptionNotFound(sub.SubscriptionID)
+	}
+	return nil
+}
+
+// DeleteWebhookSubscription removes a subscription
+func (r *PostgresWebhookSubscriptionRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	tag, err := r.client.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE subscription_id = $1`, subscriptionID)
+	if err != nil {
+		logger.Error("Failed to delete webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return errors.ErrDatabaseOperation("delete_webhook_subscription", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+	}
+	return nil
+}
+
+// PostgresWebhookDeliveryRepository stores webhook delivery attempts in Postgres
+type PostgresWebhookDeliveryRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresWebhookDeliveryRepository creates a webhook delivery repository on the shared pool
+func NewPostgresWebhookDeliveryRepository(client *PostgresClient) *PostgresWebhookDeliveryRepository {
+	return &PostgresWebhookDeliveryRepository{client: client}
+}
+
+// RecordWebhookDelivery writes a delivery attempt
+func (r *PostgresWebhookDeliveryRepository) RecordWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	record, err := json.Marshal(delivery)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, delivered_ns, record, delivered_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, delivered_ns) DO NOTHING`,
+		delivery.SubscriptionID, delivery.DeliveredNanos, record, delivery.DeliveredAt)
+	if err != nil {
+		logger.Error("Failed to record webhook delivery", logger.Fields{"error": err.Error(), "subscription_id": delivery.SubscriptionID})
+		return errors.ErrDatabaseOperation("record_webhook_delivery", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns a subscription's delivery attempts since the given time, oldest first
+func (r *PostgresWebhookDeliveryRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID string, since time.Time) ([]*models.WebhookDelivery, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT delivered_ns, record FROM webhook_deliveries
+		WHERE subscription_id = $1 AND delivered_ns >= $2
+		ORDER BY delivered_ns`, subscriptionID, since.UnixNano())
+	if err != nil {
+		logger.Error("Failed to query webhook deliveries", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return nil, errors.ErrDatabaseOperation("query_webhook_deliveries", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var nanos int64
+		var record []byte
+		if err := rows.Scan(&nanos, &record); err != nil {
+			return nil, errors.ErrDatabaseOperation("query_webhook_deliveries", err)
+		}
+		var delivery models.WebhookDelivery
+		if err := json.Unmarshal(record, &delivery); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		delivery.DeliveredNanos = nanos // Not part of the JSON record
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("query_webhook_deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+// PostgresSocketSubscriptionRepository stores WebSocket subscriptions in Postgres
+type PostgresSocketSubscriptionRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresSocketSubscriptionRepository creates a WebSocket subscription repository on the shared pool
+func NewPostgresSocketSubscriptionRepository(client *PostgresClient) *PostgresSocketSubscriptionRepository {
+	return &PostgresSocketSubscriptionRepository{client: client}
+}
+
+// SubscribeConnection stores a subscription, replacing the connection's existing one to the payment
+func (r *PostgresSocketSubscriptionRepository) SubscribeConnection(ctx context.Context, sub *models.SocketSubscription) error {
+	record, err := json.Marshal(sub)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO socket_subscriptions (payment_id, connection_id, record, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (payment_id, connection_id) DO UPDATE SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at`,
+		sub.PaymentID, sub.ConnectionID, record, sub.ExpiresAt)
+	if err != nil {
+		logger.Error("Failed to save socket subscription", logger.Fields{"error": err.Error(), "payment_id": sub.PaymentID})
+		return errors.ErrDatabaseOperation("subscribe_connection", err)
+	}
+	return nil
+}
+
+// UnsubscribeConnection removes a connection's subscription to a payment, if it has one
+func (r *PostgresSocketSubscriptionRepository) UnsubscribeConnection(ctx context.Context, connectionID, paymentID string) error {
+	_, err := r.client.pool.Exec(ctx, `
+		DELETE FROM socket_subscriptions WHERE payment_id = $1 AND connection_id = $2`, paymentID, connectionID)
+	if err != nil {
+		logger.Error("Failed to delete socket subscription", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return errors.ErrDatabaseOperation("unsubscribe_connection", err)
+	}
+	return nil
+}
+
+// ListPaymentSubscriptions returns the unexpired subscriptions to a payment
+func (r *PostgresSocketSubscriptionRepository) ListPaymentSubscriptions(ctx context.Context, paymentID string) ([]*models.SocketSubscription, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT record FROM socket_subscriptions
+		WHERE payment_id = $1 AND expires_at > now()`, paymentID)
+	if err != nil {
+		logger.Error("Failed to query socket subscriptions", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("list_payment_subscriptions", err)
+	}
+	defer rows.Close()
+
+	var subs []*models.SocketSubscription
+	for rows.Next() {
+		var record []byte
+		if err := rows.Scan(&record); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_payment_subscriptions", err)
+		}
+		var sub models.SocketSubscription
+		if err := json.Unmarshal(record, &sub); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		subs = append(subs, &sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_payment_subscriptions", err)
+	}
+
+	return subs, nil
+}
+
+// DeleteConnectionSubscriptions removes every subscription of a closed connection
+func (r *PostgresSocketSubscriptionRepository) DeleteConnectionSubscriptions(ctx context.Context, connectionID string) error {
+	_, err := r.client.pool.Exec(ctx, `DELETE FROM socket_subscriptions WHERE connection_id = $1`, connectionID)
+	if err != nil {
+		logger.Error("Failed to delete connection subscriptions", logger.Fields{"error": err.Error(), "connection_id": connectionID})
+		return errors.ErrDatabaseOperation("delete_connection_subscriptions", err)
+	}
+	return nil
+}
+
+// PostgresAPIKeyRepository stores API keys in Postgres
+// The hash and last use are columns rather than part of the record: the hash is never serialized,
+// and a key's use is recorded without rewriting it.
+type PostgresAPIKeyRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresAPIKeyRepository creates an API key repository on the shared pool
+func NewPostgresAPIKeyRepository(client *PostgresClient) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{client: client}
+}
+
+// CreateAPIKey writes a new key, failing with a conflict if its hash or ID exists
+func (r *PostgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	record, err := json.Marshal(key)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = r.client.pool.Exec(ctx, `
+		INSERT INTO api_keys (key_hash, key_id, customer_id, record, created_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		key.KeyHash, key.KeyID, key.CustomerID, record, key.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return errors.ErrConflict(fmt.Sprintf("API key %s already exists", key.KeyID))
+		}
+		logger.Error("Failed to create API key", logger.Fields{"error": err.Error(), "key_id": key.KeyID})
+		return errors.ErrDatabaseOperation("create_api_key", err)
+	}
+	return nil
+}
+
+// GetAPIKey retrieves the key whose secret has the given hash, or nil if no key has it
+func (r *PostgresAPIKeyRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.client.pool.QueryRow(ctx, `
+		SELECT key_hash, record, last_used_at FROM api_keys WHERE key_hash = $1`, keyHash))
+	if err != nil {
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		logger.Error("Failed to get API key", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("get_api_key", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns a customer's keys, oldest first
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context, customerID string) ([]*models.APIKey, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT key_hash, record, last_used_at FROM api_keys WHERE customer_id = $1 ORDER BY created_at`, customerID)
+	if err != nil {
+		logger.Error("Failed to list API keys", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("list_api_keys", err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("list_api_keys", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_api_keys", err)
+	}
+
+	return keys, nil
+}
+
+// UpdateAPIKey replaces an existing key's record
+func (r *PostgresAPIKeyRepository) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	record, err := json.Marshal(key)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	tag, err := r.client.pool.Exec(ctx, `UPDATE api_keys SET record = $2 WHERE key_hash = $1`, key.KeyHash, record)
+	if err != nil {
+		logger.Error("Failed to update API key", logger.Fields{"error": err.Error(), "key_id": key.KeyID})
+		return errors.ErrDatabaseOperation("update_api_key", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrAPIKeyNotFound(key.KeyID)
+	}
+	return nil
+}
+
+// TouchAPIKey records when a key was last used; a missing key is ignored
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, keyHash string, usedAt time.Time) error {
+	_, err := r.client.pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE key_hash = $1`, keyHash, usedAt.UTC())
+	if err != nil {
+		logger.Error("Failed to record API key use", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("touch_api_key", err)
+	}
+	return nil
+}
+
+// scanAPIKey reads a key from its hash, record and last use columns
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var keyHash string
+	var record []byte
+	var lastUsed *time.Time
+	if err := row.Scan(&keyHash, &record, &lastUsed); err != nil {
+		return nil, err
+	}
+
+	var key models.APIKey
+	if err := json.Unmarshal(record, &key); err != nil {
+		return nil, err
+	}
+	key.KeyHash = keyHash
+	key.LastUsedAt = lastUsed
+	return &key, nil
+}
+
+// PostgresUsageRepository stores usage counters in Postgres, one row per customer and usage key
+// Counters are incremented by an upsert, so concurrent requests never lose a count.
+type PostgresUsageRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresUsageRepository creates a usage repository on the shared pool
+func NewPostgresUsageRepository(client *PostgresClient) *PostgresUsageRepository {
+	return &PostgresUsageRepository{client: client}
+}
+
+// AddUsage adds the record's quantity to its counter, creating the counter on first use
+func (r *PostgresUsageRepository) AddUsage(ctx context.Context, record *models.UsageRecord) error {
+	_, err := r.client.pool.Exec(ctx, `
+		INSERT INTO usage_counters (customer_id, usage_key, period, metric, currency, quantity, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (customer_id, usage_key) DO UPDATE
+		SET quantity = usage_counters.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`,
+		record.CustomerID, record.UsageKey, record.Period, record.Metric, record.Currency, record.Quantity, record.UpdatedAt)
+	if err != nil {
+		logger.Error("Failed to add usage", logger.Fields{
+			"error":       err.Error(),
+			"customer_id": record.CustomerID,
+			"usage_key":   record.UsageKey,
+		})
+		return errors.ErrDatabaseOperation("add_usage", err)
+	}
+	return nil
+}
+
+// ListUsage returns a customer's counters for a period
+func (r *PostgresUsageRepository) ListUsage(ctx context.Context, customerID, period string) ([]*models.UsageRecord, error) {
+	return r.list(ctx, `
+		SELECT customer_id, usage_key, period, metric, currency, quantity, updated_at FROM usage_counters
+		WHERE customer_id = $1 AND period = $2 ORDER BY metric, currency`, customerID, period)
+}
+
+// ListPeriodUsage returns every customer's counters for a period, ordered by customer, metric and currency
+func (r *PostgresUsageRepository) ListPeriodUsage(ctx context.Context, period string) ([]*models.UsageRecord, error) {
+	return r.list(ctx, `
+		SELECT customer_id, usage_key, period, metric, currency, quantity, updated_at FROM usage_counters
+		WHERE period = $1 ORDER BY customer_id, metric, currency`, period)
+}
+
+// list runs a usage query
+func (r *PostgresUsageRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.UsageRecord, error) {
+	rows, err := r.client.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Failed to list usage", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_usage", err)
+	}
+	defer rows.Close()
+
+	records := []*models.UsageRecord{}
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.CustomerID, &record.UsageKey, &record.Period, &record.Metric,
+			&record.Currency, &record.Quantity, &record.UpdatedAt); err != nil {
+			return nil, errors.ErrDatabaseOperation("list_usage", err)
+		}
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("list_usage", err)
+	}
+	return records, nil
+}
+
+// PostgresQuotaRepository stores quota counters in Postgres, one row per customer and window
+// A payment is reserved against all its windows in one transaction; each upsert only applies while
+// the window stays within its limits, so concurrent payments can't together overshoot a quota.
+type PostgresQuotaRepository struct {
+	client *PostgresClient
+}
+
+// NewPostgresQuotaRepository creates a quota repository on the shared pool
+func NewPostgresQuotaRepository(client *PostgresClient) *PostgresQuotaRepository {
+	return &PostgresQuotaRepository{client: client}
+}
+
+// ReserveQuota counts a payment of volume against every window, failing with QUOTA_EXCEEDED
+// and counting nothing if it would take any window past its limits
+func (r *PostgresQuotaRepository) ReserveQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	var exceeded *models.QuotaWindow
+	err := pgx.BeginFunc(ctx, r.client.pool, func(tx pgx.Tx) error {
+		for i, window := range windows {
+			var payments int64
+			err := tx.QueryRow(ctx, `
+				INSERT INTO quota_counters (customer_id, window_key, payments, volume, expires_at)
+				VALUES ($1, $2, 1, $3, $4)
+				ON CONFLICT (customer_id, window_key) DO UPDATE
+				SET payments = quota_counters.payments + 1, volume = quota_counters.volume + EXCLUDED.volume
+				WHERE ($5 = 0 OR quota_counters.payments < $5)
+				AND ($6 = 0 OR quota_counters.volume + EXCLUDED.volume <= $6)
+				RETURNING payments`,
+				customerID, window.Key, volume, window.ExpiresAt(), window.MaxCount, window.MaxVolume).Scan(&payments)
+			if stderrors.Is(err, pgx.ErrNoRows) {
+				exceeded = &windows[i]
+				return err
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if exceeded != nil {
+		return errors.ErrQuotaExceeded(exceeded.Period, exceeded.ResetsAt)
+	}
+	if err != nil {
+		logger.Error("Failed to reserve quota", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return errors.ErrDatabaseOperation("reserve_quota", err)
+	}
+	return nil
+}
+
+// ReleaseQuota gives back a payment reserved against every window
+func (r *PostgresQuotaRepository) ReleaseQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	for _, window := range windows {
+		_, err := r.client.pool.Exec(ctx, `
+			UPDATE quota_counters SET payments = payments - 1, volume = volume - $3
+			WHERE customer_id = $1 AND window_key = $2`, customerID, window.Key, volume)
+		if err != nil {
+			logger.Error("Failed to release quota", logger.Fields{
+				"error":       err.Error(),
+				"customer_id": customerID,
+				"window":      window.Key,
+			})
+			return errors.ErrDatabaseOperation("release_quota", err)
+		}
+	}
+	return nil
+}
+
+// GetQuotaCounters returns a customer's counters for the given windows; windows without payments are left out
+func (r *PostgresQuotaRepository) GetQuotaCounters(ctx context.Context, customerID string, windowKeys []string) (map[string]*models.QuotaCounter, error) {
+	rows, err := r.client.pool.Query(ctx, `
+		SELECT window_key, payments, volume FROM quota_counters
+		WHERE customer_id = $1 AND window_key = ANY($2)`, customerID, windowKeys)
+	if err != nil {
+		logger.Error("Failed to get quota counters", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("get_quota", err)
+	}
+	defer rows.Close()
+
+	counters := make(map[string]*models.QuotaCounter, len(windowKeys))
+	for rows.Next() {
+		counter := &models.QuotaCounter{CustomerID: customerID}
+		if err := rows.Scan(&counter.WindowKey, &counter.Payments, &counter.Volume); err != nil {
+			return nil, errors.ErrDatabaseOperation("get_quota", err)
+		}
+		counters[counter.WindowKey] = counter
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrDatabaseOperation("get_quota", err)
+	}
+	return counters, nil
+}
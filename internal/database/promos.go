@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// PromoClient stores promo codes in DynamoDB, keyed by code
+type PromoClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewPromoClient creates a new promo code database client
+func NewPromoClient(region, tableName, endpoint string) (*PromoClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PromoClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreatePromo writes a new promo code, failing with a conflict if the code exists
+func (c *PromoClient) CreatePromo(ctx context.Context, promo *models.Promo) error {
+	av, err := dynamodbattribute.MarshalMap(promo)
+	if err != nil {
+		logger.Error("Failed to marshal promo", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(code)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Promo code %s already exists", promo.Code))
+		}
+		logger.Error("Failed to create promo", logger.Fields{"error": err.Error(), "code": promo.Code})
+		return errors.ErrDatabaseOperation("create_promo", err)
+	}
+
+	return nil
+}
+
+// GetPromo retrieves a promo code
+func (c *PromoClient) GetPromo(ctx context.Context, code string) (*models.Promo, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"code": {S: aws.String(code)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get promo", logger.Fields{"error": err.Error(), "code": code})
+		return nil, errors.ErrDatabaseOperation("get_promo", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrPromoNotFound(code)
+	}
+
+	var promo models.Promo
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &promo); err != nil {
+		logger.Error("Failed to unmarshal promo", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &promo, nil
+}
+
+// RedeemPromo counts one use of a promo code, failing with a conflict once its uses run out
+// The cap is enforced by the conditional update, so concurrent payments can't overspend it.
+func (c *PromoClient) RedeemPromo(ctx context.Context, code string) error {
+	update := expression.Add(expression.Name("uses"), expression.Value(1))
+	condition := expression.AttributeExists(expression.Name("code")).
+		And(expression.Or(
+			expression.Name("max_uses").Equal(expression.Value(0)),
+			expression.Name("uses").LessThan(expression.Name("max_uses")),
+		))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"code": {S: aws.String(code)},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrConflict(fmt.Sprintf("Promo code %s has no uses left", code))
+		}
+		logger.Error("Failed to redeem promo", logger.Fields{"error": err.Error(), "code": code})
+		return errors.ErrDatabaseOperation("redeem_promo", err)
+	}
+
+	return nil
+}
+
+// ReleasePromo gives back one use of a promo code redeemed for a payment that was never created
+func (c *PromoClient) ReleasePromo(ctx context.Context, code string) error {
+	update := expression.Add(expression.Name("uses"), expression.Value(-1))
+	condition := expression.Name("uses").GreaterThan(expression.Value(0))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"code": {S: aws.String(code)},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return nil
+		}
+		logger.Error("Failed to release promo", logger.Fields{"error": err.Error(), "code": code})
+		return errors.ErrDatabaseOperation("release_promo", err)
+	}
+
+	return nil
+}
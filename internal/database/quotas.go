@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// quotaAttributeNames names the counter attributes in expressions, clear of DynamoDB's reserved words
+var quotaAttributeNames = map[string]*string{
+	"#payments": aws.String("payments"),
+	"#volume":   aws.String("volume"),
+	"#ttl":      aws.String("ttl"),
+}
+
+// QuotaClient stores quota counters in DynamoDB, keyed by customer and window
+// A payment is reserved against all its windows in one transaction whose conditions enforce the limits,
+// so concurrent payments can't together overshoot a quota. Counters expire through the table's TTL.
+type QuotaClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewQuotaClient creates a new quota database client
+func NewQuotaClient(region, tableName, endpoint string) (*QuotaClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuotaClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// ReserveQuota counts a payment of volume against every window, failing with QUOTA_EXCEEDED
+// and counting nothing if it would take any window past its limits
+func (c *QuotaClient) ReserveQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	items := make([]*dynamodb.TransactWriteItem, len(windows))
+	for i, window := range windows {
+		update := c.update(customerID, window, 1, volume)
+
+		condition := "attribute_not_exists(#payments)"
+		if window.MaxCount > 0 {
+			condition += " OR #payments < :max_count"
+			update.ExpressionAttributeValues[":max_count"] = &dynamodb.AttributeValue{N: aws.String(fmt.Sprintf("%d", window.MaxCount))}
+		}
+		if window.MaxVolume > 0 {
+			condition = "(" + condition + ") AND (attribute_not_exists(#volume) OR #volume <= :volume_headroom)"
+			update.ExpressionAttributeValues[":volume_headroom"] = &dynamodb.AttributeValue{N: aws.String(fmt.Sprintf("%d", window.MaxVolume-volume))}
+		}
+		if window.MaxCount > 0 || window.MaxVolume > 0 {
+			update.ConditionExpression = aws.String(condition)
+		}
+		items[i] = &dynamodb.TransactWriteItem{Update: update}
+	}
+
+	_, err := c.svc.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		for i, window := range windows {
+			if isConditionalCancellation(err, i) {
+				return errors.ErrQuotaExceeded(window.Period, window.ResetsAt)
+			}
+		}
+		logger.Error("Failed to reserve quota", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return errors.ErrDatabaseOperation("reserve_quota", err)
+	}
+	return nil
+}
+
+// ReleaseQuota gives back a payment reserved against every window
+func (c *QuotaClient) ReleaseQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error {
+	for _, window := range windows {
+		update := c.update(customerID, window, -1, -volume)
+		_, err := c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 update.TableName,
+			Key:                       update.Key,
+			UpdateExpression:          update.UpdateExpression,
+			ExpressionAttributeNames:  update.ExpressionAttributeNames,
+			ExpressionAttributeValues: update.ExpressionAttributeValues,
+		})
+		if err != nil {
+			logger.Error("Failed to release quota", logger.Fields{
+				"error":       err.Error(),
+				"customer_id": customerID,
+				"window":      window.Key,
+			})
+			return errors.ErrDatabaseOperation("release_quota", err)
+		}
+	}
+	return nil
+}
+
+// update builds the unconditional update adding payments and volume to a window's counter
+func (c *QuotaClient) update(customerID string, window models.QuotaWindow, payments, volume int64) *dynamodb.Update {
+	return &dynamodb.Update{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"customer_id": {S: aws.String(customerID)},
+			"window_key":  {S: aws.String(window.Key)},
+		},
+		UpdateExpression:         aws.String("ADD #payments :payments, #volume :volume SET #ttl = :ttl"),
+		ExpressionAttributeNames: quotaAttributeNames,
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":payments": {N: aws.String(fmt.Sprintf("%d", payments))},
+			":volume":   {N: aws.String(fmt.Sprintf("%d", volume))},
+			":ttl":      {N: aws.String(fmt.Sprintf("%d", window.ExpiresAt().Unix()))},
+		},
+	}
+}
+
+// GetQuotaCounters returns a customer's counters for the given windows; windows without payments are left out
+func (c *QuotaClient) GetQuotaCounters(ctx context.Context, customerID string, windowKeys []string) (map[string]*models.QuotaCounter, error) {
+	counters := make(map[string]*models.QuotaCounter, len(windowKeys))
+	for _, key := range windowKeys {
+		result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(c.tableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"customer_id": {S: aws.String(customerID)},
+				"window_key":  {S: aws.String(key)},
+			},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			logger.Error("Failed to get quota counter", logger.Fields{"error": err.Error(), "customer_id": customerID})
+			return nil, errors.ErrDatabaseOperation("get_quota", err)
+		}
+		if result.Item == nil {
+			continue
+		}
+
+		var counter models.QuotaCounter
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &counter); err != nil {
+			return nil, errors.ErrDatabaseOperation("unmarshal", err)
+		}
+		counters[key] = &counter
+	}
+	return counters, nil
+}
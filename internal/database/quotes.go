@@ -2,12 +2,15 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"crypto-conversion/internal/errors"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
 	"crypto-conversion/internal/quotes"
 )
 
@@ -30,6 +33,11 @@ func NewQuoteClient(region, tableName, endpoint string) (*QuoteClient, error) {
 	}, nil
 }
 
+// CheckHealth verifies the quote table can be read
+func (c *QuoteClient) CheckHealth(ctx context.Context) error {
+	return checkTables(ctx, c.svc, c.tableName)
+}
+
 // CreateQuote stores a new quote in DynamoDB
 func (c *QuoteClient) CreateQuote(ctx context.Context, quote *quotes.Quote) error {
 	av, err := dynamodbattribute.MarshalMap(quote)
@@ -87,3 +95,82 @@ func (c *QuoteClient) GetQuote(ctx context.Context, quoteID string) (*quotes.Quo
 
 	return &quote, nil
 }
+
+// MarkQuoteConsumed records the payment that used a quote
+// The update is what the quotes stream turns into a quote.consumed webhook; a quote can only be consumed once.
+func (c *QuoteClient) MarkQuoteConsumed(ctx context.Context, quoteID, paymentID string, consumedAt time.Time) error {
+	update := expression.Set(expression.Name("consumed_at"), expression.Value(consumedAt)).
+		Set(expression.Name("payment_id"), expression.Value(paymentID))
+	condition := expression.AttributeExists(expression.Name("quote_id")).
+		And(expression.AttributeNotExists(expression.Name("consumed_at")))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"quote_id": {
+				S: aws.String(quoteID),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrQuoteConsumed(quoteID)
+		}
+		logger.Error("Failed to mark quote consumed", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quoteID,
+		})
+		return errors.ErrDatabaseOperation("mark_consumed", err)
+	}
+
+	return nil
+}
+
+// SetQuoteAIUsage records the accumulated AI spend on a quote
+func (c *QuoteClient) SetQuoteAIUsage(ctx context.Context, quoteID string, usage *models.AIUsage) error {
+	update := expression.Set(expression.Name("ai_usage"), expression.Value(usage))
+	condition := expression.AttributeExists(expression.Name("quote_id"))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"quote_id": {
+				S: aws.String(quoteID),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, input)
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrQuoteNotFound(quoteID)
+		}
+		logger.Error("Failed to record quote AI usage", logger.Fields{
+			"error":    err.Error(),
+			"quote_id": quoteID,
+		})
+		return errors.ErrDatabaseOperation("set_ai_usage", err)
+	}
+
+	return nil
+}
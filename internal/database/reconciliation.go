@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ReconciliationClient stores reconciliation breaks in DynamoDB, keyed by break ID
+type ReconciliationClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewReconciliationClient creates a new reconciliation break database client
+func NewReconciliationClient(region, tableName, endpoint string) (*ReconciliationClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconciliationClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordBreak writes a break, returning false if it was already recorded
+// An earlier run's break is left alone, so re-running never reopens one an operator resolved.
+func (c *ReconciliationClient) RecordBreak(ctx context.Context, brk *models.ReconciliationBreak) (bool, error) {
+	av, err := dynamodbattribute.MarshalMap(brk)
+	if err != nil {
+		logger.Error("Failed to marshal reconciliation break", logger.Fields{"error": err.Error()})
+		return false, errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(break_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return false, nil
+		}
+		logger.Error("Failed to record reconciliation break", logger.Fields{"error": err.Error(), "break_id": brk.BreakID})
+		return false, errors.ErrDatabaseOperation("record_break", err)
+	}
+
+	return true, nil
+}
+
+// GetBreak retrieves a break
+func (c *ReconciliationClient) GetBreak(ctx context.Context, breakID string) (*models.ReconciliationBreak, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"break_id": {S: aws.String(breakID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get reconciliation break", logger.Fields{"error": err.Error(), "break_id": breakID})
+		return nil, errors.ErrDatabaseOperation("get_break", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrBreakNotFound(breakID)
+	}
+
+	var brk models.ReconciliationBreak
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &brk); err != nil {
+		logger.Error("Failed to unmarshal reconciliation break", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &brk, nil
+}
+
+// ListBreaks returns the breaks with a status, or every break if status is empty, oldest first
+// Breaks are few and short-lived, so a scan is cheaper than keeping a status index.
+func (c *ReconciliationClient) ListBreaks(ctx context.Context, status string) ([]*models.ReconciliationBreak, error) {
+	input := &dynamodb.ScanInput{
+		TableName: aws.String(c.tableName),
+	}
+	if status != "" {
+		expr, err := expression.NewBuilder().
+			WithFilter(expression.Name("status").Equal(expression.Value(status))).
+			Build()
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("build_expression", err)
+		}
+		input.FilterExpression = expr.Filter()
+		input.ExpressionAttributeNames = expr.Names()
+		input.ExpressionAttributeValues = expr.Values()
+	}
+
+	var breaks []*models.ReconciliationBreak
+	var unmarshalErr error
+	err := c.svc.ScanPagesWithContext(ctx, input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var brk models.ReconciliationBreak
+			if err := dynamodbattribute.UnmarshalMap(item, &brk); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			breaks = append(breaks, &brk)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan reconciliation breaks", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_breaks", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sortBreaks(breaks)
+	return breaks, nil
+}
+
+// ResolveBreak marks an open break resolved, failing with a conflict if it already was
+func (c *ReconciliationClient) ResolveBreak(ctx context.Context, breakID, resolution, resolvedBy string, at time.Time) error {
+	update := expression.Set(expression.Name("status"), expression.Value(models.BreakStatusResolved)).
+		Set(expression.Name("resolution"), expression.Value(resolution)).
+		Set(expression.Name("resolved_by"), expression.Value(resolvedBy)).
+		Set(expression.Name("resolved_at"), expression.Value(at))
+	condition := expression.Name("status").Equal(expression.Value(models.BreakStatusOpen))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"break_id": {S: aws.String(breakID)},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			if _, err := c.GetBreak(ctx, breakID); err != nil {
+				return err
+			}
+			return errors.ErrConflict(fmt.Sprintf("Reconciliation break %s is already resolved", breakID))
+		}
+		logger.Error("Failed to resolve reconciliation break", logger.Fields{"error": err.Error(), "break_id": breakID})
+		return errors.ErrDatabaseOperation("resolve_break", err)
+	}
+
+	return nil
+}
+
+// sortBreaks orders breaks oldest first, by ID within a run
+func sortBreaks(breaks []*models.ReconciliationBreak) {
+	sort.Slice(breaks, func(i, j int) bool {
+		if !breaks[i].DetectedAt.Equal(breaks[j].DetectedAt) {
+			return breaks[i].DetectedAt.Before(breaks[j].DetectedAt)
+		}
+		return breaks[i].BreakID < breaks[j].BreakID
+	})
+}
@@ -0,0 +1,290 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+)
+
+// PaymentRepository is the storage contract for payment records
+// Implemented by the DynamoDB Client, PostgresPaymentRepository, and the in-memory MemoryPaymentRepository.
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *models.Payment) error
+	CreatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error
+	CreatePaymentsWithOutbox(ctx context.Context, payments []*models.Payment, msg *models.OutboxMessage) error
+	GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error)
+	GetPaymentByIdempotencyKey(ctx context.Context, scope, idempotencyKey string) (*models.Payment, error)
+	UpdatePayment(ctx context.Context, payment *models.Payment) error
+	UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error
+	AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error)
+	ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error
+	ScanUnarchivedPayments(ctx context.Context) ([]*models.Payment, error)
+	MarkPaymentArchived(ctx context.Context, paymentID string, archivedAt time.Time) error
+	ListPaymentAIUsage(ctx context.Context, since, until time.Time) ([]*models.AIUsage, error)
+	ListSettlements(ctx context.Context, since time.Time) ([]*models.Settlement, error)
+	ListRampTransfers(ctx context.Context, since, until time.Time) ([]*models.RampTransfer, error)
+	ListCompletedPayments(ctx context.Context, since, until time.Time) ([]*models.Payment, error)
+	ListPaymentsByStatus(ctx context.Context, status models.PaymentStatus) ([]*models.Payment, error)
+	ListPaymentsByCustomer(ctx context.Context, customerID string, since time.Time) ([]*models.Payment, error)
+	ListOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	DeleteOutboxMessage(ctx context.Context, messageID string) error
+}
+
+// QuoteRepository is the storage contract for quotes
+// Implemented by the DynamoDB QuoteClient, PostgresQuoteRepository, and the in-memory MemoryQuoteRepository.
+type QuoteRepository interface {
+	CreateQuote(ctx context.Context, quote *quotes.Quote) error
+	GetQuote(ctx context.Context, quoteID string) (*quotes.Quote, error)
+	MarkQuoteConsumed(ctx context.Context, quoteID, paymentID string, consumedAt time.Time) error
+	SetQuoteAIUsage(ctx context.Context, quoteID string, usage *models.AIUsage) error
+}
+
+// AuditRepository is the storage contract for the append-only audit log
+// Implemented by the DynamoDB AuditClient, PostgresAuditRepository, and the in-memory MemoryAuditRepository.
+type AuditRepository interface {
+	AppendAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	GetLatestAuditEntry(ctx context.Context) (*models.AuditEntry, error)
+	ListAuditEntries(ctx context.Context, afterSequence int64, limit int) ([]*models.AuditEntry, error)
+}
+
+// FeeComparisonRepository stores shadow-mode comparisons of static and AI fees
+// Implemented by the DynamoDB FeeComparisonClient, PostgresFeeComparisonRepository, and the in-memory MemoryFeeComparisonRepository.
+type FeeComparisonRepository interface {
+	RecordFeeComparison(ctx context.Context, comparison *models.FeeComparison) error
+}
+
+// FeeScheduleRepository stores effective-dated fee schedule versions
+// Implemented by the DynamoDB FeeScheduleClient, PostgresFeeScheduleRepository, and the in-memory MemoryFeeScheduleRepository.
+type FeeScheduleRepository interface {
+	CreateFeeSchedule(ctx context.Context, schedule *models.FeeSchedule) error
+	ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error)
+}
+
+// PromoRepository stores promo codes and counts their uses
+// Implemented by the DynamoDB PromoClient, PostgresPromoRepository, and the in-memory MemoryPromoRepository.
+type PromoRepository interface {
+	CreatePromo(ctx context.Context, promo *models.Promo) error
+	GetPromo(ctx context.Context, code string) (*models.Promo, error)
+	RedeemPromo(ctx context.Context, code string) error
+	ReleasePromo(ctx context.Context, code string) error
+}
+
+// VolumeRepository tracks completed payment volume per customer for volume discounts
+// Implemented by the DynamoDB VolumeClient, PostgresVolumeRepository, and the in-memory MemoryVolumeRepository.
+type VolumeRepository interface {
+	RecordVolume(ctx context.Context, entry *models.VolumeEntry) error
+	RollingVolume(ctx context.Context, customerID string, since time.Time) (int64, error)
+}
+
+// GasPriceRepository stores gas price snapshots per settlement chain for trend analysis
+// Implemented by the DynamoDB GasPriceClient, PostgresGasPriceRepository, and the in-memory MemoryGasPriceRepository.
+type GasPriceRepository interface {
+	RecordGasPrice(ctx context.Context, snapshot *models.GasSnapshot) error
+	ListGasPrices(ctx context.Context, chain string, since time.Time) ([]*models.GasSnapshot, error)
+}
+
+// FeeInvoiceRepository stores the immutable fee invoice issued for each completed payment
+// Implemented by the DynamoDB FeeInvoiceClient, PostgresFeeInvoiceRepository, and the in-memory MemoryFeeInvoiceRepository.
+type FeeInvoiceRepository interface {
+	CreateFeeInvoice(ctx context.Context, invoice *models.FeeInvoice) error
+	GetFeeInvoice(ctx context.Context, paymentID string) (*models.FeeInvoice, error)
+	ListFeeInvoices(ctx context.Context, since, until time.Time) ([]*models.FeeInvoice, error)
+}
+
+// RevenueReportRepository stores the daily revenue report rows consumed by finance
+// Implemented by the DynamoDB RevenueReportClient, PostgresRevenueReportRepository, and the in-memory MemoryRevenueReportRepository.
+type RevenueReportRepository interface {
+	PutRevenueReports(ctx context.Context, reports []*models.RevenueReport) error
+	ListRevenueReports(ctx context.Context, reportDate string) ([]*models.RevenueReport, error)
+}
+
+// TreasuryRepository tracks our USDC balance per chain and fiat float per provider
+// Implemented by the DynamoDB TreasuryClient, PostgresTreasuryRepository, and the in-memory MemoryTreasuryRepository.
+type TreasuryRepository interface {
+	ApplyTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error)
+	ReserveTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error)
+	GetTreasuryBalance(ctx context.Context, account string) (*models.TreasuryBalance, error)
+	ListTreasuryBalances(ctx context.Context) ([]*models.TreasuryBalance, error)
+}
+
+// LedgerRepository stores the double-entry transactions recorded for each payment leg
+// Implemented by the DynamoDB LedgerClient, PostgresLedgerRepository, and the in-memory MemoryLedgerRepository.
+type LedgerRepository interface {
+	CreateLedgerTransaction(ctx context.Context, txn *models.LedgerTransaction) error
+	ListLedgerTransactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error)
+}
+
+// CustomerRepository stores customer records and their linked accounts
+// Implemented by the DynamoDB CustomerClient, PostgresCustomerRepository, and the in-memory MemoryCustomerRepository.
+type CustomerRepository interface {
+	CreateCustomer(ctx context.Context, customer *models.Customer) error
+	GetCustomer(ctx context.Context, customerID string) (*models.Customer, error)
+	ListCustomers(ctx context.Context) ([]*models.Customer, error)
+	UpdateCustomer(ctx context.Context, customer *models.Customer) error
+	DeleteCustomer(ctx context.Context, customerID string) error
+}
+
+// ReconciliationRepository stores the breaks found reconciling provider statements against our payments
+// Implemented by the DynamoDB ReconciliationClient, PostgresReconciliationRepository, and the in-memory MemoryReconciliationRepository.
+type ReconciliationRepository interface {
+	RecordBreak(ctx context.Context, brk *models.ReconciliationBreak) (bool, error)
+	GetBreak(ctx context.Context, breakID string) (*models.ReconciliationBreak, error)
+	ListBreaks(ctx context.Context, status string) ([]*models.ReconciliationBreak, error)
+	ResolveBreak(ctx context.Context, breakID, resolution, resolvedBy string, at time.Time) error
+}
+
+// AMLAlertRepository stores the alerts AML transaction monitoring raises on payments
+// Implemented by the DynamoDB AMLAlertClient, PostgresAMLAlertRepository, and the in-memory MemoryAMLAlertRepository.
+type AMLAlertRepository interface {
+	RecordAlert(ctx context.Context, alert *models.AMLAlert) (bool, error)
+	GetAlert(ctx context.Context, alertID string) (*models.AMLAlert, error)
+	ListAlerts(ctx context.Context, status string) ([]*models.AMLAlert, error)
+	CloseAlert(ctx context.Context, alertID, resolution, resolvedBy string, at time.Time) error
+}
+
+// WebhookSubscriptionRepository stores the endpoints customers registered for their payments' webhooks
+// Implemented by the DynamoDB WebhookSubscriptionClient, PostgresWebhookSubscriptionRepository, and the in-memory MemoryWebhookSubscriptionRepository.
+type WebhookSubscriptionRepository interface {
+	CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, customerID string) ([]*models.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error
+}
+
+// APIKeyRepository stores the API keys issued to customers, by the hash of their secret
+// Implemented by the DynamoDB APIKeyClient, PostgresAPIKeyRepository, and the in-memory MemoryAPIKeyRepository.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, customerID string) ([]*models.APIKey, error)
+	UpdateAPIKey(ctx context.Context, key *models.APIKey) error
+	TouchAPIKey(ctx context.Context, keyHash string, usedAt time.Time) error
+}
+
+// UsageRepository keeps each customer's monthly usage counters for usage-based billing
+// Implemented by the DynamoDB UsageClient, PostgresUsageRepository, and the in-memory MemoryUsageRepository.
+type UsageRepository interface {
+	AddUsage(ctx context.Context, record *models.UsageRecord) error
+	ListUsage(ctx context.Context, customerID, period string) ([]*models.UsageRecord, error)
+	ListPeriodUsage(ctx context.Context, period string) ([]*models.UsageRecord, error)
+}
+
+// QuotaRepository counts the payments each customer makes in each daily and monthly quota window
+// Implemented by the DynamoDB QuotaClient, PostgresQuotaRepository, and the in-memory MemoryQuotaRepository.
+type QuotaRepository interface {
+	ReserveQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error
+	ReleaseQuota(ctx context.Context, customerID string, windows []models.QuotaWindow, volume int64) error
+	GetQuotaCounters(ctx context.Context, customerID string, windowKeys []string) (map[string]*models.QuotaCounter, error)
+}
+
+// WebhookDeliveryRepository stores each attempt to deliver a webhook to a subscription, for its delivery statistics
+// Implemented by the DynamoDB WebhookDeliveryClient, PostgresWebhookDeliveryRepository, and the in-memory MemoryWebhookDeliveryRepository.
+type WebhookDeliveryRepository interface {
+	RecordWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, since time.Time) ([]*models.WebhookDelivery, error)
+}
+
+// SocketSubscriptionRepository stores which WebSocket connections are subscribed to which payments
+// Implemented by the DynamoDB SocketSubscriptionClient, PostgresSocketSubscriptionRepository, and the in-memory MemorySocketSubscriptionRepository.
+type SocketSubscriptionRepository interface {
+	SubscribeConnection(ctx context.Context, sub *models.SocketSubscription) error
+	UnsubscribeConnection(ctx context.Context, connectionID, paymentID string) error
+	ListPaymentSubscriptions(ctx context.Context, paymentID string) ([]*models.SocketSubscription, error)
+	DeleteConnectionSubscriptions(ctx context.Context, connectionID string) error
+}
+
+// HealthChecker verifies the storage behind a repository can be reached
+// Implemented by the payment and quote repositories of every backend, for readiness checks.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+var (
+	_ PaymentRepository = (*Client)(nil)
+	_ PaymentRepository = (*MemoryPaymentRepository)(nil)
+	_ PaymentRepository = (*PostgresPaymentRepository)(nil)
+	_ QuoteRepository   = (*QuoteClient)(nil)
+	_ QuoteRepository   = (*MemoryQuoteRepository)(nil)
+	_ QuoteRepository   = (*PostgresQuoteRepository)(nil)
+	_ AuditRepository   = (*AuditClient)(nil)
+	_ AuditRepository   = (*MemoryAuditRepository)(nil)
+	_ AuditRepository   = (*PostgresAuditRepository)(nil)
+
+	_ FeeComparisonRepository = (*FeeComparisonClient)(nil)
+	_ FeeComparisonRepository = (*MemoryFeeComparisonRepository)(nil)
+	_ FeeComparisonRepository = (*PostgresFeeComparisonRepository)(nil)
+
+	_ FeeScheduleRepository = (*FeeScheduleClient)(nil)
+	_ FeeScheduleRepository = (*MemoryFeeScheduleRepository)(nil)
+	_ FeeScheduleRepository = (*PostgresFeeScheduleRepository)(nil)
+
+	_ PromoRepository = (*PromoClient)(nil)
+	_ PromoRepository = (*MemoryPromoRepository)(nil)
+	_ PromoRepository = (*PostgresPromoRepository)(nil)
+
+	_ VolumeRepository = (*VolumeClient)(nil)
+	_ VolumeRepository = (*MemoryVolumeRepository)(nil)
+	_ VolumeRepository = (*PostgresVolumeRepository)(nil)
+
+	_ FeeInvoiceRepository = (*FeeInvoiceClient)(nil)
+	_ FeeInvoiceRepository = (*MemoryFeeInvoiceRepository)(nil)
+	_ FeeInvoiceRepository = (*PostgresFeeInvoiceRepository)(nil)
+
+	_ GasPriceRepository = (*GasPriceClient)(nil)
+	_ GasPriceRepository = (*MemoryGasPriceRepository)(nil)
+	_ GasPriceRepository = (*PostgresGasPriceRepository)(nil)
+
+	_ RevenueReportRepository = (*RevenueReportClient)(nil)
+	_ RevenueReportRepository = (*MemoryRevenueReportRepository)(nil)
+	_ RevenueReportRepository = (*PostgresRevenueReportRepository)(nil)
+
+	_ TreasuryRepository = (*TreasuryClient)(nil)
+	_ TreasuryRepository = (*MemoryTreasuryRepository)(nil)
+	_ TreasuryRepository = (*PostgresTreasuryRepository)(nil)
+
+	_ LedgerRepository = (*LedgerClient)(nil)
+	_ LedgerRepository = (*MemoryLedgerRepository)(nil)
+	_ LedgerRepository = (*PostgresLedgerRepository)(nil)
+
+	_ CustomerRepository = (*CustomerClient)(nil)
+	_ CustomerRepository = (*MemoryCustomerRepository)(nil)
+	_ CustomerRepository = (*PostgresCustomerRepository)(nil)
+
+	_ ReconciliationRepository = (*ReconciliationClient)(nil)
+	_ ReconciliationRepository = (*MemoryReconciliationRepository)(nil)
+	_ ReconciliationRepository = (*PostgresReconciliationRepository)(nil)
+
+	_ AMLAlertRepository = (*AMLAlertClient)(nil)
+	_ AMLAlertRepository = (*MemoryAMLAlertRepository)(nil)
+	_ AMLAlertRepository = (*PostgresAMLAlertRepository)(nil)
+
+	_ WebhookSubscriptionRepository = (*WebhookSubscriptionClient)(nil)
+	_ WebhookSubscriptionRepository = (*MemoryWebhookSubscriptionRepository)(nil)
+	_ WebhookSubscriptionRepository = (*PostgresWebhookSubscriptionRepository)(nil)
+
+	_ WebhookDeliveryRepository = (*WebhookDeliveryClient)(nil)
+	_ WebhookDeliveryRepository = (*MemoryWebhookDeliveryRepository)(nil)
+	_ WebhookDeliveryRepository = (*PostgresWebhookDeliveryRepository)(nil)
+
+	_ UsageRepository = (*UsageClient)(nil)
+	_ UsageRepository = (*MemoryUsageRepository)(nil)
+	_ UsageRepository = (*PostgresUsageRepository)(nil)
+
+	_ QuotaRepository = (*QuotaClient)(nil)
+	_ QuotaRepository = (*MemoryQuotaRepository)(nil)
+	_ QuotaRepository = (*PostgresQuotaRepository)(nil)
+
+	_ SocketSubscriptionRepository = (*SocketSubscriptionClient)(nil)
+	_ SocketSubscriptionRepository = (*MemorySocketSubscriptionRepository)(nil)
+	_ SocketSubscriptionRepository = (*PostgresSocketSubscriptionRepository)(nil)
+
+	_ HealthChecker = (*Client)(nil)
+	_ HealthChecker = (*MemoryPaymentRepository)(nil)
+	_ HealthChecker = (*PostgresPaymentRepository)(nil)
+	_ HealthChecker = (*QuoteClient)(nil)
+	_ HealthChecker = (*MemoryQuoteRepository)(nil)
+	_ HealthChecker = (*PostgresQuoteRepository)(nil)
+)
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// RevenueReportClient stores revenue report rows in DynamoDB, keyed by report date and corridor/customer
+type RevenueReportClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewRevenueReportClient creates a new revenue report database client
+func NewRevenueReportClient(region, tableName, endpoint string) (*RevenueReportClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevenueReportClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// revenueReportKey returns a row's sort key within its report date
+func revenueReportKey(report *models.RevenueReport) string {
+	return report.Corridor + "#" + report.CustomerID
+}
+
+// PutRevenueReports writes report rows, replacing any earlier rows for the same date, corridor and customer
+func (c *RevenueReportClient) PutRevenueReports(ctx context.Context, reports []*models.RevenueReport) error {
+	for _, report := range reports {
+		av, err := dynamodbattribute.MarshalMap(report)
+		if err != nil {
+			logger.Error("Failed to marshal revenue report", logger.Fields{"error": err.Error()})
+			return errors.ErrDatabaseOperation("marshal", err)
+		}
+		av["report_key"] = &dynamodb.AttributeValue{S: aws.String(revenueReportKey(report))}
+
+		_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(c.tableName),
+			Item:      av,
+		})
+		if err != nil {
+			logger.Error("Failed to put revenue report", logger.Fields{
+				"error":       err.Error(),
+				"report_date": report.ReportDate,
+				"corridor":    report.Corridor,
+			})
+			return errors.ErrDatabaseOperation("put_revenue_report", err)
+		}
+	}
+
+	return nil
+}
+
+// ListRevenueReports returns every row of a day's report
+func (c *RevenueReportClient) ListRevenueReports(ctx context.Context, reportDate string) ([]*models.RevenueReport, error) {
+	keyCond := expression.Key("report_date").Equal(expression.Value(reportDate))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		logger.Error("Failed to build expression", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(c.tableName),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	var reports []*models.RevenueReport
+	var unmarshalErr error
+	err = c.svc.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var report models.RevenueReport
+			if err := dynamodbattribute.UnmarshalMap(item, &report); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			reports = append(reports, &report)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query revenue reports", logger.Fields{"error": err.Error(), "report_date": reportDate})
+		return nil, errors.ErrDatabaseOperation("query", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal revenue report", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return reports, nil
+}
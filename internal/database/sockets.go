@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// socketConnectionIndex is the GSI of socket subscriptions by connection, for deleting a closed connection's subscriptions
+const socketConnectionIndex = "connection-id-index"
+
+// SocketSubscriptionClient stores WebSocket subscriptions in DynamoDB, keyed by payment and connection
+// Subscriptions expire through the table's TTL once their connection can no longer be open.
+type SocketSubscriptionClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewSocketSubscriptionClient creates a new WebSocket subscription database client
+func NewSocketSubscriptionClient(region, tableName, endpoint string) (*SocketSubscriptionClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SocketSubscriptionClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// SubscribeConnection stores a subscription, replacing the connection's existing one to the payment
+func (c *SocketSubscriptionClient) SubscribeConnection(ctx context.Context, sub *models.SocketSubscription) error {
+	av, err := dynamodbattribute.MarshalMap(sub)
+	if err != nil {
+		logger.Error("Failed to marshal socket subscription", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		logger.Error("Failed to save socket subscription", logger.Fields{"error": err.Error(), "payment_id": sub.PaymentID})
+		return errors.ErrDatabaseOperation("subscribe_connection", err)
+	}
+
+	return nil
+}
+
+// UnsubscribeConnection removes a connection's subscription to a payment, if it has one
+func (c *SocketSubscriptionClient) UnsubscribeConnection(ctx context.Context, connectionID, paymentID string) error {
+	_, err := c.svc.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"payment_id":    {S: aws.String(paymentID)},
+			"connection_id": {S: aws.String(connectionID)},
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to delete socket subscription", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return errors.ErrDatabaseOperation("unsubscribe_connection", err)
+	}
+
+	return nil
+}
+
+// ListPaymentSubscriptions returns the unexpired subscriptions to a payment
+// TTL deletion lags expiry, so expired items are filtered out here.
+func (c *SocketSubscriptionClient) ListPaymentSubscriptions(ctx context.Context, paymentID string) ([]*models.SocketSubscription, error) {
+	var subs []*models.SocketSubscription
+	var unmarshalErr error
+
+	now := time.Now()
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("payment_id = :payment"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":payment": {S: aws.String(paymentID)},
+		},
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var sub models.SocketSubscription
+			if unmarshalErr = dynamodbattribute.UnmarshalMap(item, &sub); unmarshalErr != nil {
+				return false
+			}
+			if sub.ExpiresAt.After(now) {
+				subs = append(subs, &sub)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query socket subscriptions", logger.Fields{"error": err.Error(), "payment_id": paymentID})
+		return nil, errors.ErrDatabaseOperation("list_payment_subscriptions", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal socket subscription", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return subs, nil
+}
+
+// DeleteConnectionSubscriptions removes every subscription of a closed connection
+func (c *SocketSubscriptionClient) DeleteConnectionSubscriptions(ctx context.Context, connectionID string) error {
+	var paymentIDs []string
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		IndexName:              aws.String(socketConnectionIndex),
+		KeyConditionExpression: aws.String("connection_id = :connection"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":connection": {S: aws.String(connectionID)},
+		},
+		ProjectionExpression: aws.String("payment_id"),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			if id := item["payment_id"]; id != nil && id.S != nil {
+				paymentIDs = append(paymentIDs, *id.S)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query connection subscriptions", logger.Fields{"error": err.Error(), "connection_id": connectionID})
+		return errors.ErrDatabaseOperation("delete_connection_subscriptions", err)
+	}
+
+	for _, paymentID := range paymentIDs {
+		if err := c.UnsubscribeConnection(ctx, connectionID, paymentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
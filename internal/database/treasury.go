@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// treasuryMovementPrefix keys applied movements in the treasury table, alongside the balances
+const treasuryMovementPrefix = "movement#"
+
+// TreasuryClient stores treasury balances in DynamoDB, keyed by account
+// Each applied movement is also written to the table so it can never be applied twice.
+type TreasuryClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewTreasuryClient creates a new treasury database client
+func NewTreasuryClient(region, tableName, endpoint string) (*TreasuryClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TreasuryClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// ApplyTreasuryMovement adds a movement's entries to their balances, reporting false if it was already applied
+// The movement record and balance updates are written in one transaction.
+func (c *TreasuryClient) ApplyTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	items, err := c.movementItems(movement, false)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.svc.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isConditionalCancellation(err, 0) {
+			return false, nil
+		}
+		logger.Error("Failed to apply treasury movement", logger.Fields{
+			"error":       err.Error(),
+			"movement_id": movement.MovementID,
+		})
+		return false, errors.ErrDatabaseOperation("apply_treasury_movement", err)
+	}
+
+	return true, nil
+}
+
+// ReserveTreasuryMovement applies a movement only if every account it debits covers the debit
+// It reports true once the movement has been applied, now or before, and false if an account is short.
+// Each debit is conditioned on its balance in the same transaction, so concurrent payments can't
+// both take the last of an account.
+func (c *TreasuryClient) ReserveTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error) {
+	items, err := c.movementItems(movement, true)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.svc.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		if isConditionalCancellation(err, 0) {
+			return true, nil
+		}
+		for i := 1; i < len(items); i++ {
+			if isConditionalCancellation(err, i) {
+				return false, nil
+			}
+		}
+		logger.Error("Failed to reserve treasury movement", logger.Fields{
+			"error":       err.Error(),
+			"movement_id": movement.MovementID,
+		})
+		return false, errors.ErrDatabaseOperation("reserve_treasury_movement", err)
+	}
+
+	return true, nil
+}
+
+// movementItems builds the transaction writing a movement record and its balance updates
+// With covered set, each debit also requires its balance to cover it.
+func (c *TreasuryClient) movementItems(movement *models.TreasuryMovement, covered bool) ([]*dynamodb.TransactWriteItem, error) {
+	movementItem, err := dynamodbattribute.MarshalMap(movement)
+	if err != nil {
+		logger.Error("Failed to marshal treasury movement", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("marshal", err)
+	}
+	movementItem["account"] = &dynamodb.AttributeValue{S: aws.String(treasuryMovementPrefix + movement.MovementID)}
+
+	items := []*dynamodb.TransactWriteItem{{
+		Put: &dynamodb.Put{
+			TableName:           aws.String(c.tableName),
+			Item:                movementItem,
+			ConditionExpression: aws.String("attribute_not_exists(account)"),
+		},
+	}}
+
+	now := time.Now().UTC()
+	for _, entry := range movement.Entries {
+		update := expression.Add(expression.Name("balance"), expression.Value(entry.Amount)).
+			Set(expression.Name("kind"), expression.Value(entry.Kind)).
+			Set(expression.Name("location"), expression.Value(entry.Location)).
+			Set(expression.Name("currency"), expression.Value(entry.Currency)).
+			Set(expression.Name("updated_at"), expression.Value(now))
+		builder := expression.NewBuilder().WithUpdate(update)
+		if covered && entry.Amount < 0 {
+			builder = builder.WithCondition(expression.Name("balance").GreaterThanEqual(expression.Value(-entry.Amount)))
+		}
+		expr, err := builder.Build()
+		if err != nil {
+			return nil, errors.ErrDatabaseOperation("build_expression", err)
+		}
+
+		items = append(items, &dynamodb.TransactWriteItem{
+			Update: &dynamodb.Update{
+				TableName: aws.String(c.tableName),
+				Key: map[string]*dynamodb.AttributeValue{
+					"account": {S: aws.String(entry.Account())},
+				},
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+	}
+	return items, nil
+}
+
+// GetTreasuryBalance retrieves an account's balance, or nil if nothing has moved through it
+func (c *TreasuryClient) GetTreasuryBalance(ctx context.Context, account string) (*models.TreasuryBalance, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"account": {S: aws.String(account)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get treasury balance", logger.Fields{"error": err.Error(), "account": account})
+		return nil, errors.ErrDatabaseOperation("get_treasury_balance", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var balance models.TreasuryBalance
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &balance); err != nil {
+		logger.Error("Failed to unmarshal treasury balance", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &balance, nil
+}
+
+// ListTreasuryBalances returns every tracked balance, ordered by account
+func (c *TreasuryClient) ListTreasuryBalances(ctx context.Context) ([]*models.TreasuryBalance, error) {
+	var balances []*models.TreasuryBalance
+	var unmarshalErr error
+
+	err := c.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:        aws.String(c.tableName),
+		FilterExpression: aws.String("attribute_exists(balance)"),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var pageBalances []*models.TreasuryBalance
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageBalances); unmarshalErr != nil {
+			return false
+		}
+		balances = append(balances, pageBalances...)
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan treasury balances", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("scan_treasury", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal treasury balances", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Account < balances[j].Account })
+	return balances, nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// usagePeriodIndex is the GSI listing every customer's counters for a period, for the billing export
+const usagePeriodIndex = "period-index"
+
+// UsageClient stores usage counters in DynamoDB, keyed by customer and usage key
+// Counters are incremented in place with ADD, so concurrent requests never lose a count.
+type UsageClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewUsageClient creates a new usage database client
+func NewUsageClient(region, tableName, endpoint string) (*UsageClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UsageClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// AddUsage adds the record's quantity to its counter, creating the counter on first use
+func (c *UsageClient) AddUsage(ctx context.Context, record *models.UsageRecord) error {
+	updatedAt, err := dynamodbattribute.Marshal(record.UpdatedAt)
+	if err != nil {
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	update := "ADD quantity :quantity SET #period = :period, metric = :metric, updated_at = :updated"
+	values := map[string]*dynamodb.AttributeValue{
+		":quantity": {N: aws.String(fmt.Sprintf("%d", record.Quantity))},
+		":period":   {S: aws.String(record.Period)},
+		":metric":   {S: aws.String(record.Metric)},
+		":updated":  updatedAt,
+	}
+	if record.Currency != "" {
+		update += ", currency = :currency"
+		values[":currency"] = &dynamodb.AttributeValue{S: aws.String(record.Currency)}
+	}
+
+	_, err = c.svc.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"customer_id": {S: aws.String(record.CustomerID)},
+			"usage_key":   {S: aws.String(record.UsageKey)},
+		},
+		UpdateExpression:          aws.String(update),
+		ExpressionAttributeNames:  map[string]*string{"#period": aws.String("period")}, // Kept clear of reserved words
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		logger.Error("Failed to add usage", logger.Fields{
+			"error":       err.Error(),
+			"customer_id": record.CustomerID,
+			"usage_key":   record.UsageKey,
+		})
+		return errors.ErrDatabaseOperation("add_usage", err)
+	}
+	return nil
+}
+
+// ListUsage returns a customer's counters for a period
+func (c *UsageClient) ListUsage(ctx context.Context, customerID, period string) ([]*models.UsageRecord, error) {
+	return c.query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("customer_id = :customer AND begins_with(usage_key, :period)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":customer": {S: aws.String(customerID)},
+			":period":   {S: aws.String(period + "#")},
+		},
+	})
+}
+
+// ListPeriodUsage returns every customer's counters for a period, ordered by customer, metric and currency
+func (c *UsageClient) ListPeriodUsage(ctx context.Context, period string) ([]*models.UsageRecord, error) {
+	records, err := c.query(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(c.tableName),
+		IndexName:                aws.String(usagePeriodIndex),
+		KeyConditionExpression:   aws.String("#period = :period"),
+		ExpressionAttributeNames: map[string]*string{"#period": aws.String("period")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":period": {S: aws.String(period)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	models.SortUsageRecords(records)
+	return records, nil
+}
+
+// query collects every page of a usage query
+func (c *UsageClient) query(ctx context.Context, input *dynamodb.QueryInput) ([]*models.UsageRecord, error) {
+	records := []*models.UsageRecord{}
+	var unmarshalErr error
+	err := c.svc.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var record models.UsageRecord
+			if err := dynamodbattribute.UnmarshalMap(item, &record); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			records = append(records, &record)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query usage", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("list_usage", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+	return records, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// VolumeClient stores completed payment volume in DynamoDB, keyed by customer and payment
+// Entries expire through the table's TTL once they leave the rolling window.
+type VolumeClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewVolumeClient creates a new customer volume database client
+func NewVolumeClient(region, tableName, endpoint string) (*VolumeClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VolumeClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordVolume writes a completed payment's volume entry; rewriting the same payment is a no-op
+func (c *VolumeClient) RecordVolume(ctx context.Context, entry *models.VolumeEntry) error {
+	av, err := dynamodbattribute.MarshalMap(entry)
+	if err != nil {
+		logger.Error("Failed to marshal volume entry", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		logger.Error("Failed to record volume", logger.Fields{
+			"error":      err.Error(),
+			"payment_id": entry.PaymentID,
+		})
+		return errors.ErrDatabaseOperation("record_volume", err)
+	}
+
+	return nil
+}
+
+// RollingVolume sums a customer's completed payment volume since the given time, in USD cents
+func (c *VolumeClient) RollingVolume(ctx context.Context, customerID string, since time.Time) (int64, error) {
+	var total int64
+	var unmarshalErr error
+
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("customer_id = :customer"),
+		FilterExpression:       aws.String("completed_at >= :since"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":customer": {S: aws.String(customerID)},
+			":since":    {N: aws.String(fmt.Sprintf("%d", since.Unix()))},
+		},
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var entries []models.VolumeEntry
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &entries); unmarshalErr != nil {
+			return false
+		}
+		for _, entry := range entries {
+			total += entry.AmountUSD
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query volume", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return 0, errors.ErrDatabaseOperation("query_volume", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal volume entries", logger.Fields{"error": unmarshalErr.Error()})
+		return 0, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// WebhookSubscriptionClient stores webhook subscriptions in DynamoDB, keyed by subscription ID
+type WebhookSubscriptionClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewWebhookSubscriptionClient creates a new webhook subscription database client
+func NewWebhookSubscriptionClient(region, tableName, endpoint string) (*WebhookSubscriptionClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookSubscriptionClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// CreateWebhookSubscription writes a new subscription, failing with a conflict if the ID exists
+func (c *WebhookSubscriptionClient) CreateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return c.putWebhookSubscription(ctx, sub, true)
+}
+
+// UpdateWebhookSubscription replaces an existing subscription
+func (c *WebhookSubscriptionClient) UpdateWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return c.putWebhookSubscription(ctx, sub, false)
+}
+
+// putWebhookSubscription writes a new subscription, or replaces an existing one
+func (c *WebhookSubscriptionClient) putWebhookSubscription(ctx context.Context, sub *models.WebhookSubscription, create bool) error {
+	condition := "attribute_exists(subscription_id)"
+	if create {
+		condition = "attribute_not_exists(subscription_id)"
+	}
+
+	av, err := dynamodbattribute.MarshalMap(sub)
+	if err != nil {
+		logger.Error("Failed to marshal webhook subscription", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.tableName),
+		Item:                av,
+		ConditionExpression: aws.String(condition),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			if create {
+				return errors.ErrConflict(fmt.Sprintf("Webhook subscription %s already exists", sub.SubscriptionID))
+			}
+			return errors.ErrWebhookSubscriptionNotFound(sub.SubscriptionID)
+		}
+		logger.Error("Failed to save webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": sub.SubscriptionID})
+		return errors.ErrDatabaseOperation("put_webhook_subscription", err)
+	}
+
+	return nil
+}
+
+// GetWebhookSubscription retrieves a subscription
+func (c *WebhookSubscriptionClient) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	result, err := c.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"subscription_id": {S: aws.String(subscriptionID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		logger.Error("Failed to get webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return nil, errors.ErrDatabaseOperation("get_webhook_subscription", err)
+	}
+	if result.Item == nil {
+		return nil, errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+	}
+
+	var sub models.WebhookSubscription
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &sub); err != nil {
+		logger.Error("Failed to unmarshal webhook subscription", logger.Fields{"error": err.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", err)
+	}
+
+	return &sub, nil
+}
+
+// ListWebhookSubscriptions returns a customer's subscriptions, oldest first
+// Customers register a handful of endpoints at most, so a scan is cheaper than keeping a customer index.
+func (c *WebhookSubscriptionClient) ListWebhookSubscriptions(ctx context.Context, customerID string) ([]*models.WebhookSubscription, error) {
+	expr, err := expression.NewBuilder().
+		WithFilter(expression.Name("customer_id").Equal(expression.Value(customerID))).
+		Build()
+	if err != nil {
+		return nil, errors.ErrDatabaseOperation("build_expression", err)
+	}
+
+	subs := []*models.WebhookSubscription{}
+	var unmarshalErr error
+	err = c.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:                 aws.String(c.tableName),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var sub models.WebhookSubscription
+			if err := dynamodbattribute.UnmarshalMap(item, &sub); err != nil {
+				unmarshalErr = err
+				return false
+			}
+			subs = append(subs, &sub)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to scan webhook subscriptions", logger.Fields{"error": err.Error(), "customer_id": customerID})
+		return nil, errors.ErrDatabaseOperation("list_webhook_subscriptions", err)
+	}
+	if unmarshalErr != nil {
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	sort.Slice(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs, nil
+}
+
+// DeleteWebhookSubscription removes a subscription
+func (c *WebhookSubscriptionClient) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	_, err := c.svc.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"subscription_id": {S: aws.String(subscriptionID)},
+		},
+		ConditionExpression: aws.String("attribute_exists(subscription_id)"),
+	})
+	if err != nil {
+		if _, ok := err.(*dynamodb.ConditionalCheckFailedException); ok {
+			return errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+		}
+		logger.Error("Failed to delete webhook subscription", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return errors.ErrDatabaseOperation("delete_webhook_subscription", err)
+	}
+
+	return nil
+}
+
+// WebhookDeliveryClient stores webhook delivery attempts in DynamoDB, keyed by subscription and delivery time
+// Attempts expire through the table's TTL once they leave retention.
+type WebhookDeliveryClient struct {
+	svc       *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewWebhookDeliveryClient creates a new webhook delivery database client
+func NewWebhookDeliveryClient(region, tableName, endpoint string) (*WebhookDeliveryClient, error) {
+	client, err := NewClient(region, tableName, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookDeliveryClient{
+		svc:       client.svc,
+		tableName: tableName,
+	}, nil
+}
+
+// RecordWebhookDelivery writes a delivery attempt
+func (c *WebhookDeliveryClient) RecordWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	av, err := dynamodbattribute.MarshalMap(delivery)
+	if err != nil {
+		logger.Error("Failed to marshal webhook delivery", logger.Fields{"error": err.Error()})
+		return errors.ErrDatabaseOperation("marshal", err)
+	}
+
+	_, err = c.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      av,
+	})
+	if err != nil {
+		logger.Error("Failed to record webhook delivery", logger.Fields{"error": err.Error(), "subscription_id": delivery.SubscriptionID})
+		return errors.ErrDatabaseOperation("record_webhook_delivery", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns a subscription's delivery attempts since the given time, oldest first
+func (c *WebhookDeliveryClient) ListWebhookDeliveries(ctx context.Context, subscriptionID string, since time.Time) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	var unmarshalErr error
+
+	err := c.svc.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("subscription_id = :subscription AND delivered_ns >= :since"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":subscription": {S: aws.String(subscriptionID)},
+			":since":        {N: aws.String(strconv.FormatInt(since.UnixNano(), 10))},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			var delivery models.WebhookDelivery
+			if unmarshalErr = dynamodbattribute.UnmarshalMap(item, &delivery); unmarshalErr != nil {
+				return false
+			}
+			deliveries = append(deliveries, &delivery)
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("Failed to query webhook deliveries", logger.Fields{"error": err.Error(), "subscription_id": subscriptionID})
+		return nil, errors.ErrDatabaseOperation("query_webhook_deliveries", err)
+	}
+	if unmarshalErr != nil {
+		logger.Error("Failed to unmarshal webhook delivery", logger.Fields{"error": unmarshalErr.Error()})
+		return nil, errors.ErrDatabaseOperation("unmarshal", unmarshalErr)
+	}
+
+	return deliveries, nil
+}
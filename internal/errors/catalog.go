@@ -0,0 +1,131 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Codes a failed, reversed or timed-out payment records, reported on its webhooks
+const (
+	PaymentOnrampFailed         = "ONRAMP_FAILED"         // The on-ramp couldn't start or settle collecting the funds
+	PaymentPayoutFailed         = "PAYOUT_FAILED"         // The off-ramp, bridge or wallet transfer failed; the funds were returned
+	PaymentPayoutUnsupported    = "PAYOUT_UNSUPPORTED"    // The payout route isn't enabled; the funds were returned
+	PaymentTreasuryInsufficient = "TREASURY_INSUFFICIENT" // Not enough treasury float to pay out; the funds were returned
+	PaymentSlippageExceeded     = "SLIPPAGE_EXCEEDED"     // The rate moved past the slippage tolerance
+	PaymentReviewRejected       = "REVIEW_REJECTED"       // An operator rejected the payment's hold or slippage review
+	PaymentComplianceRejected   = "COMPLIANCE_REJECTED"   // A compliance officer rejected a sanctions screening match
+	PaymentReversalFailed       = "REVERSAL_FAILED"       // Returning the funds failed; they are recovered manually
+	PaymentTimedOut             = "PAYMENT_TIMED_OUT"     // A stage ran out of time with funds in flight
+	PaymentExpired              = "PAYMENT_EXPIRED"       // The payment never started; nothing moved
+	PaymentForced               = "PAYMENT_FORCED"        // An operator forced the payment into its final status
+)
+
+// CatalogEntry documents one error code
+// DocID is stable across releases and names the code's entry in the error catalog documentation;
+// Retryable says whether the same request may succeed if sent again unchanged, or for a payment
+// failure, whether a new payment for the same transfer may succeed.
+type CatalogEntry struct {
+	Code      string `json:"code"`
+	DocID     string `json:"doc_id"`
+	Retryable bool   `json:"retryable"`
+	Summary   string `json:"summary"`
+}
+
+// catalog lists every error code the API returns or a payment records
+// Doc IDs are grouped by kind: E1xxx invalid requests, E2xxx refused by policy, E3xxx not found,
+// E4xxx conflicts, E5xxx server and dependency failures, E6xxx payment failures.
+var catalog = map[string]CatalogEntry{}
+
+func init() {
+	for _, entry := range []CatalogEntry{
+		{"INVALID_REQUEST", "E1001", false, "The request is malformed"},
+		{"INVALID_JSON", "E1002", false, "The body isn't valid JSON"},
+		{"VALIDATION_ERROR", "E1003", false, "One or more fields are invalid; see errors"},
+		{"MISSING_HEADER", "E1004", false, "A required header is missing"},
+		{"QUOTE_ERROR", "E1005", false, "The quote request can't be priced"},
+		{"INVALID_QUOTE", "E1006", false, "The quote can't be used for this payment"},
+		{"CURRENCY_MISMATCH", "E1007", false, "The payment's currency differs from its quote's"},
+		{"AMOUNT_MISMATCH", "E1008", false, "The payment's amount differs from its quote's"},
+		{"QUOTE_EXPIRED", "E1009", false, "The quote expired; request a new one"},
+		{"METHOD_NOT_ALLOWED", "E1010", false, "The endpoint doesn't accept the HTTP method; see the Allow header"},
+
+		{"UNAUTHORIZED", "E2001", false, "The API key is missing or invalid"},
+		{"FORBIDDEN", "E2002", false, "The API key may not perform this operation"},
+		{"LIMIT_EXCEEDED", "E2003", false, "The payment exceeds one of the customer's limits"},
+		{"ACCOUNT_NOT_LINKED", "E2004", false, "The source account isn't linked to the customer"},
+		{"KYC_REQUIRED", "E2005", false, "The customer must pass identity verification first"},
+		{"DESTINATION_RESTRICTED", "E2006", false, "Payments to the destination country aren't permitted"},
+		{"IP_NOT_ALLOWED", "E2007", false, "The API key's IP allowlist doesn't include the caller's address"},
+		{"QUOTA_EXCEEDED", "E2008", false, "The customer's daily or monthly payment quota is used up until it resets"},
+
+		{"NOT_FOUND", "E3001", false, "The resource doesn't exist"},
+		{"PAYMENT_NOT_FOUND", "E3002", false, "The payment doesn't exist"},
+		{"QUOTE_NOT_FOUND", "E3003", false, "The quote doesn't exist or expired"},
+		{"PROMO_NOT_FOUND", "E3004", false, "The promo code doesn't exist"},
+		{"FEE_INVOICE_NOT_FOUND", "E3005", false, "The payment has no fee invoice yet"},
+		{"BREAK_NOT_FOUND", "E3006", false, "The reconciliation break doesn't exist"},
+		{"AML_ALERT_NOT_FOUND", "E3007", false, "The AML alert doesn't exist"},
+		{"CUSTOMER_NOT_FOUND", "E3008", false, "The customer doesn't exist"},
+		{"WEBHOOK_SUBSCRIPTION_NOT_FOUND", "E3009", false, "The webhook subscription doesn't exist"},
+		{"REPORT_NOT_FOUND", "E3010", false, "The report doesn't exist"},
+		{"ACCOUNT_NOT_FOUND", "E3011", false, "The account doesn't exist"},
+		{"API_KEY_NOT_FOUND", "E3012", false, "The API key doesn't exist"},
+
+		{"DUPLICATE_REQUEST", "E4001", false, "The idempotency key was used for a different request"},
+		{"CONFLICT", "E4002", false, "The resource's state doesn't allow the operation"},
+		{"QUOTE_CONSUMED", "E4003", false, "Another payment already used the quote"},
+
+		{"INTERNAL_ERROR", "E5001", true, "An unexpected server error"},
+		{"DATABASE_ERROR", "E5002", true, "A database operation failed"},
+		{"QUEUE_ERROR", "E5003", true, "Queueing the payment failed"},
+		{"EVENT_ERROR", "E5004", true, "Publishing an event failed"},
+		{"PAYMENT_PROCESSING_ERROR", "E5005", true, "Processing the payment failed"},
+		{"CALCULATION_ERROR", "E5006", true, "Calculating the fees failed"},
+		{"QUOTE_UNAVAILABLE", "E5007", true, "No rate provider could price the corridor"},
+		{"AI_UNAVAILABLE", "E5008", true, "AI fee calculation isn't available"},
+
+		{PaymentOnrampFailed, "E6001", true, "The on-ramp couldn't collect the funds"},
+		{PaymentPayoutFailed, "E6002", true, "The payout failed and the funds were returned"},
+		{PaymentPayoutUnsupported, "E6003", false, "The payout route isn't enabled"},
+		{PaymentTreasuryInsufficient, "E6004", true, "Treasury float was short; the funds were returned"},
+		{PaymentSlippageExceeded, "E6005", true, "The rate moved too far; request a new quote"},
+		{PaymentReviewRejected, "E6006", false, "An operator rejected the payment"},
+		{PaymentComplianceRejected, "E6007", false, "Compliance rejected the payment"},
+		{PaymentReversalFailed, "E6008", false, "Returning the funds failed; support recovers them"},
+		{PaymentTimedOut, "E6009", false, "A stage timed out with funds in flight; support resolves it"},
+		{PaymentExpired, "E6010", true, "The payment never started; nothing moved"},
+		{PaymentForced, "E6011", false, "An operator ended the payment"},
+	} {
+		catalog[entry.Code] = entry
+	}
+}
+
+// Lookup returns the catalog entry of an error code
+// Codes missing from the catalog have no doc ID and are retryable if their status is 429 or 5xx.
+func Lookup(code string, statusCode int) CatalogEntry {
+	if entry, ok := catalog[code]; ok {
+		return entry
+	}
+	return CatalogEntry{
+		Code:      code,
+		Retryable: statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError,
+	}
+}
+
+// Catalog returns every catalog entry, ordered by doc ID
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(catalog))
+	for _, entry := range catalog {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DocID < entries[j].DocID })
+	return entries
+}
+
+// classify sets an error's retryability and doc ID from the catalog
+func classify(e *AppError) *AppError {
+	entry := Lookup(e.Code, e.StatusCode)
+	e.Retryable = entry.Retryable
+	e.DocID = entry.DocID
+	return e
+}
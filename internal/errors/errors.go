@@ -3,16 +3,40 @@ package errors
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // AppError represents an application error with HTTP status code
 type AppError struct {
-	Code       string // Machine-readable error code
-	Message    string // Human-readable error message
-	StatusCode int    // HTTP status code
-	Err        error  // Underlying error
+	Code          string       // Machine-readable error code
+	Message       string       // Human-readable error message
+	StatusCode    int          // HTTP status code
+	Err           error        // Underlying error
+	Fields        []FieldError // Per-field validation failures from ErrValidationFields
+	Retryable     bool         // Whether the same request may succeed if sent again; set from the catalog
+	DocID         string       // The code's stable error catalog ID, e.g. E3002
+	CorrelationID string       // The request's correlation ID, returned so support can find its logs
 }
 
+// FieldError is one invalid request field
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"-"` // One of the Field* codes; empty means FieldInvalid
+	Reason string `json:"reason"`
+}
+
+// Field error codes, so clients can act on or translate a failure without parsing its reason
+const (
+	FieldRequired      = "REQUIRED"       // Missing or empty
+	FieldOutOfRange    = "OUT_OF_RANGE"   // A number or length outside its limits
+	FieldUnsupported   = "UNSUPPORTED"    // A well-formed value we don't support, e.g. a currency or chain
+	FieldInvalidFormat = "INVALID_FORMAT" // Not a valid address, IBAN, URL or country code
+	FieldInvalidType   = "INVALID_TYPE"   // The wrong JSON type, e.g. a string where a number belongs
+	FieldConflict      = "CONFLICT"       // Inconsistent with another field
+	FieldInvalid       = "INVALID"        // Any other failure
+)
+
 // Error implements the error interface
 func (e *AppError) Error() string {
 	if e.Err != nil {
@@ -28,124 +52,314 @@ func (e *AppError) Unwrap() error {
 
 // New creates a new AppError
 func New(code, message string, statusCode int, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       code,
 		Message:    message,
 		StatusCode: statusCode,
 		Err:        err,
-	}
+	})
 }
 
 // Common error constructors
 
 // ErrInvalidRequest creates an invalid request error
 func ErrInvalidRequest(message string, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "INVALID_REQUEST",
 		Message:    message,
 		StatusCode: http.StatusBadRequest,
 		Err:        err,
-	}
+	})
 }
 
 // ErrDuplicateRequest creates a duplicate request error
 func ErrDuplicateRequest(idempotencyKey string) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "DUPLICATE_REQUEST",
 		Message:    fmt.Sprintf("Request with idempotency key '%s' already exists", idempotencyKey),
 		StatusCode: http.StatusConflict,
 		Err:        nil,
-	}
+	})
 }
 
 // ErrPaymentNotFound creates a payment not found error
 func ErrPaymentNotFound(paymentID string) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "PAYMENT_NOT_FOUND",
 		Message:    fmt.Sprintf("Payment '%s' not found", paymentID),
 		StatusCode: http.StatusNotFound,
 		Err:        nil,
-	}
+	})
 }
 
 // ErrInternalServer creates an internal server error
 func ErrInternalServer(message string, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "INTERNAL_ERROR",
 		Message:    message,
 		StatusCode: http.StatusInternalServerError,
 		Err:        err,
-	}
+	})
 }
 
 // ErrDatabaseOperation creates a database operation error
 func ErrDatabaseOperation(operation string, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "DATABASE_ERROR",
 		Message:    fmt.Sprintf("Database operation '%s' failed", operation),
 		StatusCode: http.StatusInternalServerError,
 		Err:        err,
-	}
+	})
+}
+
+// ErrConflict creates a concurrent modification error
+func ErrConflict(message string) *AppError {
+	return classify(&AppError{
+		Code:       "CONFLICT",
+		Message:    message,
+		StatusCode: http.StatusConflict,
+		Err:        nil,
+	})
 }
 
 // ErrQueueOperation creates a queue operation error
 func ErrQueueOperation(operation string, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "QUEUE_ERROR",
 		Message:    fmt.Sprintf("Queue operation '%s' failed", operation),
 		StatusCode: http.StatusInternalServerError,
 		Err:        err,
-	}
+	})
+}
+
+// ErrEventOperation creates an event bus operation error
+func ErrEventOperation(operation string, err error) *AppError {
+	return classify(&AppError{
+		Code:       "EVENT_ERROR",
+		Message:    fmt.Sprintf("Event operation '%s' failed", operation),
+		StatusCode: http.StatusInternalServerError,
+		Err:        err,
+	})
 }
 
 // ErrPaymentProcessing creates a payment processing error
 func ErrPaymentProcessing(message string, err error) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "PAYMENT_PROCESSING_ERROR",
 		Message:    message,
 		StatusCode: http.StatusInternalServerError,
 		Err:        err,
-	}
+	})
 }
 
-// ErrValidation creates a validation error
+// ErrValidation creates a validation error for one invalid field
 func ErrValidation(field, reason string) *AppError {
-	return &AppError{
+	return ErrValidationFields([]FieldError{{Field: field, Reason: reason}})
+}
+
+// ErrValidationFields creates a validation error listing every invalid field
+func ErrValidationFields(fields []FieldError) *AppError {
+	if len(fields) == 1 {
+		return classify(&AppError{
+			Code:       "VALIDATION_ERROR",
+			Message:    fmt.Sprintf("Validation failed for field '%s': %s", fields[0].Field, fields[0].Reason),
+			StatusCode: http.StatusBadRequest,
+			Err:        nil,
+			Fields:     fields,
+		})
+	}
+
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = "'" + f.Field + "'"
+	}
+	return classify(&AppError{
 		Code:       "VALIDATION_ERROR",
-		Message:    fmt.Sprintf("Validation failed for field '%s': %s", field, reason),
+		Message:    fmt.Sprintf("Validation failed for fields %s", strings.Join(names, ", ")),
 		StatusCode: http.StatusBadRequest,
 		Err:        nil,
-	}
+		Fields:     fields,
+	})
 }
 
 // ErrMissingHeader creates a missing header error
 func ErrMissingHeader(headerName string) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "MISSING_HEADER",
 		Message:    fmt.Sprintf("Required header '%s' is missing", headerName),
 		StatusCode: http.StatusBadRequest,
 		Err:        nil,
-	}
+	})
 }
 
 // ErrQuoteNotFound creates a quote not found error
 func ErrQuoteNotFound(quoteID string) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "QUOTE_NOT_FOUND",
 		Message:    fmt.Sprintf("Quote '%s' not found or expired", quoteID),
 		StatusCode: http.StatusNotFound,
 		Err:        nil,
-	}
+	})
+}
+
+// ErrQuoteConsumed creates an error for a quote another payment already used
+func ErrQuoteConsumed(quoteID string) *AppError {
+	return classify(&AppError{
+		Code:       "QUOTE_CONSUMED",
+		Message:    fmt.Sprintf("Quote '%s' has already been used", quoteID),
+		StatusCode: http.StatusConflict,
+		Err:        nil,
+	})
+}
+
+// ErrPromoNotFound creates a promo code not found error
+func ErrPromoNotFound(code string) *AppError {
+	return classify(&AppError{
+		Code:       "PROMO_NOT_FOUND",
+		Message:    fmt.Sprintf("Promo code '%s' not found", code),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrFeeInvoiceNotFound creates an error for a payment without a fee invoice
+func ErrFeeInvoiceNotFound(paymentID string) *AppError {
+	return classify(&AppError{
+		Code:       "FEE_INVOICE_NOT_FOUND",
+		Message:    fmt.Sprintf("No fee invoice for payment '%s'; invoices are issued when a payment completes", paymentID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrBreakNotFound creates a reconciliation break not found error
+func ErrBreakNotFound(breakID string) *AppError {
+	return classify(&AppError{
+		Code:       "BREAK_NOT_FOUND",
+		Message:    fmt.Sprintf("Reconciliation break '%s' not found", breakID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrAMLAlertNotFound creates an AML alert not found error
+func ErrAMLAlertNotFound(alertID string) *AppError {
+	return classify(&AppError{
+		Code:       "AML_ALERT_NOT_FOUND",
+		Message:    fmt.Sprintf("AML alert '%s' not found", alertID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrCustomerNotFound creates a customer not found error
+func ErrCustomerNotFound(customerID string) *AppError {
+	return classify(&AppError{
+		Code:       "CUSTOMER_NOT_FOUND",
+		Message:    fmt.Sprintf("Customer '%s' not found", customerID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrWebhookSubscriptionNotFound creates a webhook subscription not found error
+func ErrWebhookSubscriptionNotFound(subscriptionID string) *AppError {
+	return classify(&AppError{
+		Code:       "WEBHOOK_SUBSCRIPTION_NOT_FOUND",
+		Message:    fmt.Sprintf("Webhook subscription '%s' not found", subscriptionID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrAPIKeyNotFound creates an API key not found error
+func ErrAPIKeyNotFound(keyID string) *AppError {
+	return classify(&AppError{
+		Code:       "API_KEY_NOT_FOUND",
+		Message:    fmt.Sprintf("API key '%s' not found", keyID),
+		StatusCode: http.StatusNotFound,
+		Err:        nil,
+	})
+}
+
+// ErrLimitExceeded creates an error for a payment over one of the customer's limits
+func ErrLimitExceeded(message string) *AppError {
+	return classify(&AppError{
+		Code:       "LIMIT_EXCEEDED",
+		Message:    message,
+		StatusCode: http.StatusForbidden,
+		Err:        nil,
+	})
+}
+
+// ErrAccountNotLinked creates an error for a payment funded from an account the customer hasn't linked
+func ErrAccountNotLinked(accountID string) *AppError {
+	return classify(&AppError{
+		Code:       "ACCOUNT_NOT_LINKED",
+		Message:    fmt.Sprintf("Source account '%s' is not linked to your customer record", accountID),
+		StatusCode: http.StatusForbidden,
+		Err:        nil,
+	})
+}
+
+// ErrKYCRequired creates an error for a payment that needs the customer to pass KYC first
+func ErrKYCRequired(threshold int64) *AppError {
+	return classify(&AppError{
+		Code:       "KYC_REQUIRED",
+		Message:    fmt.Sprintf("Payments above %d require a verified customer; complete identity verification first", threshold),
+		StatusCode: http.StatusForbidden,
+		Err:        nil,
+	})
+}
+
+// ErrDestinationRestricted creates an error for a payment to a country we don't pay out in
+func ErrDestinationRestricted(country string) *AppError {
+	return classify(&AppError{
+		Code:       "DESTINATION_RESTRICTED",
+		Message:    fmt.Sprintf("Payments to country '%s' are not permitted", country),
+		StatusCode: http.StatusForbidden,
+		Err:        nil,
+	})
+}
+
+// ErrIPNotAllowed creates an error for a call from outside the API key's IP allowlist
+func ErrIPNotAllowed(sourceIP string) *AppError {
+	return classify(&AppError{
+		Code:       "IP_NOT_ALLOWED",
+		Message:    fmt.Sprintf("Calls from %s are not allowed for this API key", sourceIP),
+		StatusCode: http.StatusForbidden,
+		Err:        nil,
+	})
+}
+
+// ErrQuotaExceeded creates an error for a payment past the customer's daily or monthly quota
+func ErrQuotaExceeded(period string, resetsAt time.Time) *AppError {
+	return classify(&AppError{
+		Code:       "QUOTA_EXCEEDED",
+		Message:    fmt.Sprintf("The %s payment quota is used up; it resets at %s", period, resetsAt.UTC().Format(time.RFC3339)),
+		StatusCode: http.StatusTooManyRequests,
+		Err:        nil,
+	})
 }
 
 // ErrQuoteExpired creates a quote expired error
 func ErrQuoteExpired(quoteID string) *AppError {
-	return &AppError{
+	return classify(&AppError{
 		Code:       "QUOTE_EXPIRED",
 		Message:    fmt.Sprintf("Quote '%s' has expired", quoteID),
 		StatusCode: http.StatusBadRequest,
 		Err:        nil,
-	}
+	})
+}
+
+// ErrQuoteUnavailable creates an error for when no rate provider could price a corridor
+func ErrQuoteUnavailable(corridorID string, err error) *AppError {
+	return classify(&AppError{
+		Code:       "QUOTE_UNAVAILABLE",
+		Message:    fmt.Sprintf("No rate is currently available for corridor '%s'", corridorID),
+		StatusCode: http.StatusServiceUnavailable,
+		Err:        err,
+	})
 }
 
 // ErrorResponse represents an error response structure
@@ -155,16 +369,40 @@ type ErrorResponse struct {
 
 // ErrorDetail contains error details for API responses
 type ErrorDetail struct {
+	Code          string             `json:"code"`
+	Message       string             `json:"message"`
+	DocID         string             `json:"doc_id,omitempty"`
+	Retryable     bool               `json:"retryable"`
+	CorrelationID string             `json:"correlation_id,omitempty"`
+	Errors        []FieldErrorDetail `json:"errors,omitempty"`
+	Fields        []FieldError       `json:"fields,omitempty"` // Errors without codes, kept for clients written against it
+}
+
+// FieldErrorDetail is one invalid field in an error response
+type FieldErrorDetail struct {
+	Field   string `json:"field"`
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
 // ToErrorResponse converts an AppError to an ErrorResponse
 func ToErrorResponse(err *AppError) ErrorResponse {
-	return ErrorResponse{
+	resp := ErrorResponse{
 		Error: ErrorDetail{
-			Code:    err.Code,
-			Message: err.Message,
+			Code:          err.Code,
+			Message:       err.Message,
+			DocID:         err.DocID,
+			Retryable:     err.Retryable,
+			CorrelationID: err.CorrelationID,
+			Fields:        err.Fields,
 		},
 	}
+	for _, f := range err.Fields {
+		code := f.Code
+		if code == "" {
+			code = FieldInvalid
+		}
+		resp.Error.Errors = append(resp.Error.Errors, FieldErrorDetail{Field: f.Field, Code: code, Message: f.Reason})
+	}
+	return resp
 }
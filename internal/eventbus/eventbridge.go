@@ -0,0 +1,89 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/tracing"
+)
+
+// Lifecycle event detail types
+const (
+	DetailTypePaymentStateChanged = "payment.state_changed"
+	DetailTypeQuoteCreated        = "quote.created"
+	DetailTypeSLABreached         = "sla.breached"
+)
+
+// Client publishes lifecycle events to an EventBridge bus
+type Client struct {
+	svc     *eventbridge.EventBridge
+	busName string
+	source  string
+}
+
+// NewClient creates a new EventBridge client
+func NewClient(region, busName, source string) (*Client, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		svc:     eventbridge.New(tracing.AWSSession(sess)),
+		busName: busName,
+		source:  source,
+	}, nil
+}
+
+// Publish sends a single event with the given detail type and JSON-encoded detail
+func (c *Client) Publish(ctx context.Context, detailType string, detail interface{}) error {
+	body, err := json.Marshal(detail)
+	if err != nil {
+		logger.Error("Failed to marshal event detail", logger.Fields{"error": err.Error()})
+		return errors.ErrEventOperation("marshal", err)
+	}
+
+	result, err := c.svc.PutEventsWithContext(ctx, &eventbridge.PutEventsInput{
+		Entries: []*eventbridge.PutEventsRequestEntry{
+			{
+				EventBusName: aws.String(c.busName),
+				Source:       aws.String(c.source),
+				DetailType:   aws.String(detailType),
+				Detail:       aws.String(string(body)),
+				Time:         aws.Time(time.Now()),
+			},
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to publish event", logger.Fields{
+			"error":       err.Error(),
+			"detail_type": detailType,
+		})
+		return errors.ErrEventOperation("put_events", err)
+	}
+
+	// PutEvents reports per-entry failures without returning an error
+	if aws.Int64Value(result.FailedEntryCount) > 0 {
+		entry := result.Entries[0]
+		logger.Error("Event rejected by EventBridge", logger.Fields{
+			"detail_type":   detailType,
+			"error_code":    aws.StringValue(entry.ErrorCode),
+			"error_message": aws.StringValue(entry.ErrorMessage),
+		})
+		return errors.ErrEventOperation("put_events", nil)
+	}
+
+	logger.Info("Event published", logger.Fields{
+		"detail_type": detailType,
+		"event_id":    aws.StringValue(result.Entries[0].EventId),
+	})
+	return nil
+}
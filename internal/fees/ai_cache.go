@@ -0,0 +1,193 @@
+package fees
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+)
+
+// aiFeeCacheTTL is how long a Claude fee recommendation is reused for matching requests
+const aiFeeCacheTTL = 5 * time.Minute
+
+// cacheKeyAIFee prefixes AI fee responses in the shared cache (+ fingerprint)
+const cacheKeyAIFee = "ai_fee:"
+
+// cachedAIFee is a fee recommendation and the amount it was priced for
+type cachedAIFee struct {
+	Amount    int64          `json:"amount"`
+	Response  *AIFeeResponse `json:"response"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// aiFeeCache holds recent fee recommendations by request fingerprint
+type aiFeeCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedAIFee
+}
+
+func newAIFeeCache() *aiFeeCache {
+	return &aiFeeCache{entries: make(map[string]*cachedAIFee)}
+}
+
+// get returns a live entry for fingerprint, dropping it if expired
+func (c *aiFeeCache) get(fingerprint string) *cachedAIFee {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[fingerprint]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		delete(c.entries, fingerprint)
+		return nil
+	}
+	return entry
+}
+
+// set stores entry under fingerprint, sweeping expired entries so the map stays small
+func (c *aiFeeCache) set(fingerprint string, entry *cachedAIFee) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, existing := range c.entries {
+		if now.After(existing.ExpiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[fingerprint] = entry
+}
+
+// amountBucket rounds an amount down to two significant digits, e.g. 123456 -> 120000
+// Requests in the same bucket share a recommendation; fees are rescaled to the exact amount.
+func amountBucket(amount int64) int64 {
+	if amount < 100 {
+		return amount
+	}
+	scale := int64(math.Pow(10, math.Floor(math.Log10(float64(amount)))-1))
+	return amount / scale * scale
+}
+
+// marketSnapshot is the coarse view of market data that changes a fee recommendation
+// Values are rounded so routine jitter between refreshes doesn't defeat the cache.
+type marketSnapshot struct {
+	FXRates   map[string]string `json:"fx_rates"`
+	GasStatus map[string]string `json:"gas_status"`
+	Providers map[string]string `json:"providers"`
+	Chains    map[string]string `json:"chains"` // Health level per chain
+}
+
+// marketSnapshotHash fingerprints the parts of the market context the prompt depends on
+func marketSnapshotHash(ctx *RealMarketContext) string {
+	snapshot := marketSnapshot{
+		FXRates: map[string]string{
+			ctx.Corridor.ID: fmt.Sprintf("%.3f", ctx.FXRate),
+		},
+		GasStatus: make(map[string]string, len(ctx.GasCosts)),
+		Providers: make(map[string]string, len(ctx.ProviderStatuses)),
+		Chains:    make(map[string]string, len(ctx.ChainHealth)),
+	}
+	for chain, gas := range ctx.GasCosts {
+		snapshot.GasStatus[chain] = gas.Status
+		if gas.Trend != nil {
+			snapshot.GasStatus[chain] += "/" + gas.Trend.Direction
+		}
+	}
+	for provider, health := range ctx.ProviderStatuses {
+		snapshot.Providers[provider] = health.Status
+	}
+	for chain, health := range ctx.ChainHealth {
+		snapshot.Chains[chain] = health.Level
+	}
+
+	// encoding/json sorts map keys, so equal snapshots encode identically
+	encoded, _ := json.Marshal(snapshot)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// aiFeeFingerprint keys a request by amount bucket, corridor, priority, tier, and market snapshot
+func aiFeeFingerprint(req *AIFeeRequest, marketCtx *RealMarketContext) string {
+	parts := []string{
+		fmt.Sprintf("%d", amountBucket(req.Amount)),
+		strings.ToUpper(req.FromCurrency) + "-" + strings.ToUpper(req.ToCurrency) + "-" + strings.ToUpper(req.DestinationCountry),
+		strings.ToLower(req.Priority),
+		strings.ToLower(req.CustomerTier),
+		marketSnapshotHash(marketCtx),
+	}
+	return strings.Join(parts, "|")
+}
+
+// scaleTo returns the cached recommendation priced for amount
+// Percentage-based components scale with the amount; gas is a flat cost and is kept as-is.
+func (e *cachedAIFee) scaleTo(amount int64) *AIFeeResponse {
+	resp := *e.Response
+	resp.RiskFactors = append([]string(nil), e.Response.RiskFactors...)
+	if amount == e.Amount || e.Amount == 0 {
+		return &resp
+	}
+
+	ratio := float64(amount) / float64(e.Amount)
+	scale := func(fee int64) int64 { return int64(math.Round(float64(fee) * ratio)) }
+
+	breakdown := e.Response.FeeBreakdown
+	resp.FeeBreakdown = FeeBreakdown{
+		PlatformFee: scale(breakdown.PlatformFee),
+		OnrampFee:   scale(breakdown.OnrampFee),
+		OfframpFee:  scale(breakdown.OfframpFee),
+		GasCost:     breakdown.GasCost,
+		RiskPremium: scale(breakdown.RiskPremium),
+	}
+	resp.TotalFee = resp.FeeBreakdown.PlatformFee + resp.FeeBreakdown.OnrampFee +
+		resp.FeeBreakdown.OfframpFee + resp.FeeBreakdown.GasCost + resp.FeeBreakdown.RiskPremium
+	return &resp
+}
+
+// cachedResponse looks up a recommendation locally, then in the shared cache
+func (a *AIFeeCalculator) cachedResponse(ctx context.Context, fingerprint string, amount int64) (*AIFeeResponse, bool) {
+	entry := a.cache.get(fingerprint)
+	if entry == nil && a.realData.loadShared(ctx, cacheKeyAIFee+fingerprint, &entry) && entry != nil {
+		if time.Now().After(entry.ExpiresAt) || entry.Response == nil {
+			entry = nil
+		} else {
+			a.cache.set(fingerprint, entry)
+		}
+	}
+	if entry == nil {
+		metrics.Count("AIFeeCacheRequests", metrics.Dimensions{"Result": "miss"})
+		return nil, false
+	}
+
+	metrics.Count("AIFeeCacheRequests", metrics.Dimensions{"Result": "hit"})
+	logger.Debug("AI fee served from cache", logger.Fields{"fingerprint": fingerprint})
+	return entry.scaleTo(amount), true
+}
+
+// storeResponse caches a fresh recommendation locally and in the shared cache
+func (a *AIFeeCalculator) storeResponse(ctx context.Context, fingerprint string, amount int64, resp *AIFeeResponse) {
+	stored := *resp
+	stored.Usage = nil // Reuse costs nothing
+	entry := &cachedAIFee{
+		Amount:    amount,
+		Response:  &stored,
+		ExpiresAt: time.Now().Add(aiFeeCacheTTL),
+	}
+	a.cache.set(fingerprint, entry)
+
+	if a.realData.shared == nil {
+		return
+	}
+	if err := a.realData.shared.Set(ctx, cacheKeyAIFee+fingerprint, entry, aiFeeCacheTTL); err != nil {
+		logger.Warn("Shared AI fee cache write failed", logger.Fields{"fingerprint": fingerprint, "error": err.Error()})
+	}
+}
@@ -0,0 +1,92 @@
+package fees
+
+import (
+	"context"
+	"testing"
+)
+
+func testMarketContext(fxRate float64, baseStatus string) *RealMarketContext {
+	return &RealMarketContext{
+		Corridor: CorridorContext{ID: "USD-EUR"},
+		FXRate:   fxRate,
+		GasCosts: map[string]GasCostEstimate{
+			"base": {Chain: "base", Status: baseStatus},
+		},
+		ProviderStatuses: map[string]ProviderHealth{
+			"circle": {Provider: "circle", Status: "operational", IsOperational: true},
+		},
+	}
+}
+
+func TestAmountBucket(t *testing.T) {
+	cases := map[int64]int64{
+		50:     50,
+		123:    120,
+		999:    990,
+		123456: 120000,
+		129999: 120000,
+	}
+	for amount, want := range cases {
+		if got := amountBucket(amount); got != want {
+			t.Errorf("amountBucket(%d) = %d, want %d", amount, got, want)
+		}
+	}
+}
+
+func TestAIFeeFingerprint(t *testing.T) {
+	req := &AIFeeRequest{Amount: 123456, FromCurrency: "USD", ToCurrency: "EUR", Priority: "standard", CustomerTier: "standard"}
+	base := aiFeeFingerprint(req, testMarketContext(0.9201, "low"))
+
+	// Same bucket and FX jitter below the rounding share a fingerprint
+	similar := *req
+	similar.Amount = 125000
+	if got := aiFeeFingerprint(&similar, testMarketContext(0.9203, "low")); got != base {
+		t.Errorf("expected shared fingerprint, got %s and %s", base, got)
+	}
+
+	// Tier, corridor, and market conditions each change it
+	tier := *req
+	tier.CustomerTier = "enterprise"
+	corridor := *req
+	corridor.ToCurrency = "GBP"
+	for name, got := range map[string]string{
+		"tier":     aiFeeFingerprint(&tier, testMarketContext(0.9201, "low")),
+		"corridor": aiFeeFingerprint(&corridor, testMarketContext(0.9201, "low")),
+		"gas":      aiFeeFingerprint(req, testMarketContext(0.9201, "high")),
+		"fx":       aiFeeFingerprint(req, testMarketContext(0.9301, "low")),
+	} {
+		if got == base {
+			t.Errorf("expected %s to change the fingerprint", name)
+		}
+	}
+}
+
+func TestAIFeeCacheScalesToAmount(t *testing.T) {
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
+	ctx := context.Background()
+
+	calc.storeResponse(ctx, "fp", 100000, &AIFeeResponse{
+		TotalFee: 3250,
+		FeeBreakdown: FeeBreakdown{
+			PlatformFee: 2000,
+			OnrampFee:   700,
+			OfframpFee:  500,
+			GasCost:     50,
+		},
+	})
+
+	resp, ok := calc.cachedResponse(ctx, "fp", 110000)
+	if !ok {
+		t.Fatal("expected cache hit")
+	}
+	if resp.FeeBreakdown.PlatformFee != 2200 || resp.FeeBreakdown.GasCost != 50 {
+		t.Errorf("unexpected breakdown: %+v", resp.FeeBreakdown)
+	}
+	if resp.TotalFee != 2200+770+550+50 {
+		t.Errorf("unexpected total fee: %d", resp.TotalFee)
+	}
+
+	if _, ok := calc.cachedResponse(ctx, "other", 110000); ok {
+		t.Error("expected cache miss for unknown fingerprint")
+	}
+}
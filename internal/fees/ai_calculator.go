@@ -1,33 +1,139 @@
 package fees
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 	"time"
+
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/fx"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/money"
 )
 
-// AIFeeCalculator uses Claude API for intelligent fee calculation
+// LLMConfig selects the model provider and request settings for fee calculation
+type LLMConfig struct {
+	Provider    string // ProviderAnthropic, ProviderBedrock or ProviderOpenAI
+	Model       string // Model ID in the provider's naming, e.g. a Bedrock model or inference profile ID
+	MaxTokens   int
+	Timeout     time.Duration // Per-attempt request timeout
+	Temperature float64
+	Region        string // AWS region for Bedrock
+	PromptVersion string // Prompt template version under prompts/; "" uses DefaultPromptVersion
+	Retry         LLMRetryConfig
+}
+
+// DefaultLLMConfig uses Claude Sonnet 4 on the Anthropic API with a 2048-token response budget and a 30s timeout
+func DefaultLLMConfig() LLMConfig {
+	return LLMConfig{
+		Provider:    ProviderAnthropic,
+		Model:       "claude-sonnet-4-20250514",
+		MaxTokens:   2048,
+		Timeout:     30 * time.Second,
+		Temperature: 1.0,
+		Retry:       DefaultLLMRetryConfig(),
+	}
+}
+
+// modelPricing is published per-million-token pricing by model family, used to estimate call cost
+// More specific families come first since models are matched by substring.
+var modelPricing = []struct {
+	family        string
+	input, output float64
+}{
+	{"opus", 15.00, 75.00},
+	{"sonnet", 3.00, 15.00},
+	{"haiku", 1.00, 5.00},
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10.00},
+}
+
+// AIFeeCalculator uses a language model for intelligent fee calculation
 type AIFeeCalculator struct {
-	apiKey       string
+	llm          LLMClient
+	corridors    *corridors.Registry
 	realData     *RealDataProvider
-	httpClient   *http.Client
 	cacheEnabled bool
+	cache        *aiFeeCache
+	config       LLMConfig
+	prompt       *promptTemplate
+	slots        chan struct{} // Caps concurrent model calls; nil when unlimited
 }
 
 // NewAIFeeCalculator creates a new AI-powered fee calculator
-func NewAIFeeCalculator(apiKey string) *AIFeeCalculator {
-	return &AIFeeCalculator{
-		apiKey:   apiKey,
-		realData: NewRealDataProvider(),
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// llm may be nil to always use the fallback fees; registry may be nil to serve the built-in corridors;
+// fxRates may be nil to use the default FX source chain; shared may be nil to cache per instance.
+// An unknown prompt version is logged and falls back to DefaultPromptVersion, so a bad rollout
+// degrades to the known-good prompt.
+func NewAIFeeCalculator(llm LLMClient, registry *corridors.Registry, fxRates *fx.Chain, shared SharedCache, config LLMConfig) *AIFeeCalculator {
+	prompt, err := loadPrompt(config.PromptVersion)
+	if err != nil {
+		logger.Error("Invalid prompt version, using default", logger.Fields{
+			"error":          err.Error(),
+			"prompt_version": config.PromptVersion,
+		})
+		prompt, _ = loadPrompt(DefaultPromptVersion)
+	}
+	if registry == nil {
+		registry = corridors.Default()
+	}
+
+	calc := &AIFeeCalculator{
+		llm:          llm,
+		corridors:    registry,
+		realData:     NewRealDataProvider(fxRates, shared),
 		cacheEnabled: true,
+		cache:        newAIFeeCache(),
+		config:       config,
+		prompt:       prompt,
+	}
+	if config.Retry.MaxConcurrency > 0 {
+		calc.slots = make(chan struct{}, config.Retry.MaxConcurrency)
+	}
+	return calc
+}
+
+// SetDataSources reads market data from the sources in registry instead of the built-in ones
+func (a *AIFeeCalculator) SetDataSources(registry *SourceRegistry) {
+	a.realData.SetDataSources(registry)
+}
+
+// SetGasHistory records gas readings to history and prices with 1h/24h gas trends
+func (a *AIFeeCalculator) SetGasHistory(history GasHistory) {
+	a.realData.SetGasHistory(history)
+}
+
+// SetSettlements scores chain health on our recent settlements from source as well as live signals
+func (a *AIFeeCalculator) SetSettlements(source chains.SettlementSource) {
+	a.realData.SetSettlements(source)
+}
+
+// ChainHealth returns the health of each chain the from-to corridor settles on
+func (a *AIFeeCalculator) ChainHealth(ctx context.Context, from, to string) (map[string]*chains.Health, error) {
+	corridor, err := a.corridors.Lookup(from, to)
+	if err != nil {
+		return nil, err
+	}
+	marketCtx, err := a.realData.GatherContext(ctx, newCorridorContext(corridor, ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather market context: %w", err)
 	}
+	return marketCtx.ChainHealth, nil
+}
+
+// FXRates returns the cached USD exchange rates the fee engine prices with
+func (a *AIFeeCalculator) FXRates(ctx context.Context) (*fx.Rates, error) {
+	return a.realData.getFXRates(ctx)
+}
+
+// GasTrends returns the recorded gas averages and trend of every monitored chain; nil without gas history
+func (a *AIFeeCalculator) GasTrends(ctx context.Context) ([]*GasTrend, error) {
+	return a.realData.GasTrends(ctx)
 }
 
 // AIFeeRequest represents the request for AI fee calculation
@@ -35,9 +141,11 @@ type AIFeeRequest struct {
 	Amount              int64  `json:"amount"`
 	FromCurrency        string `json:"from_currency"`
 	ToCurrency          string `json:"to_currency"`
-	DestinationCountry  string `json:"destination_country"`
+	DestinationCountry  string `json:"destination_country"` // Optional: defaults to the corridor's payout country
 	Priority            string `json:"priority"`
 	CustomerTier        string `json:"customer_tier"`
+	QuoteID             string `json:"quote_id,omitempty"` // Optional: attribute the AI spend to this quote
+	Customer            string `json:"-"`                  // Resolved from the caller's API key, never client-supplied
 }
 
 // AIFeeResponse represents the AI-generated fee recommendation
@@ -49,6 +157,9 @@ type AIFeeResponse struct {
 	EstimatedSettlementTime string   `json:"estimated_settlement_time"`
 	ConfidenceScore         float64  `json:"confidence_score"`
 	RiskFactors             []string `json:"risk_factors"`
+	Usage                   *models.AIUsage `json:"ai_usage,omitempty"` // Model spend for this calculation; nil for cache hits and fallbacks without a call
+	Fallback                bool            `json:"-"`                  // Static fallback fees rather than a model recommendation
+	PromptVersion           string          `json:"prompt_version,omitempty"` // Prompt template that produced the recommendation
 }
 
 // FeeBreakdown shows component-level fee structure
@@ -68,272 +179,157 @@ type ProviderRecommendation struct {
 	Reasoning string `json:"reasoning"`
 }
 
-// ClaudeRequest represents the API request to Claude
-type ClaudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []ClaudeMessage `json:"messages"`
-	System    string          `json:"system,omitempty"`
-}
-
-// ClaudeMessage represents a message in the conversation
-type ClaudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// ClaudeResponse represents the API response from Claude
-type ClaudeResponse struct {
-	ID      string `json:"id"`
-	Type    string `json:"type"`
-	Role    string `json:"role"`
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	Model        string `json:"model"`
-	StopReason   string `json:"stop_reason"`
-	Usage        struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
-	} `json:"usage"`
-}
-
 // Calculate performs AI-powered fee calculation
 func (a *AIFeeCalculator) Calculate(ctx context.Context, req *AIFeeRequest) (*AIFeeResponse, error) {
-	// If API key is missing, return fallback response
-	if a.apiKey == "" {
-		return a.fallbackResponse(req), nil
-	}
-
-	// Gather real-time market context
-	marketCtx, err := a.realData.GatherContext(ctx)
+	// Providers, payout rail and chains come from the corridor definition
+	corridor, err := a.corridors.Lookup(req.FromCurrency, req.ToCurrency)
 	if err != nil {
-		return nil, fmt.Errorf("failed to gather market context: %w", err)
+		return nil, err
 	}
+	route := newCorridorContext(corridor, req.DestinationCountry)
 
-	// Build prompts for Claude
-	systemPrompt, userPrompt := a.buildPrompt(req, marketCtx)
-
-	// Call Claude API
-	claudeResp, err := a.callClaudeAPI(ctx, systemPrompt, userPrompt)
-	if err != nil {
-		return nil, fmt.Errorf("claude API call failed: %w", err)
+	// Without a model client, return fallback response
+	if a.llm == nil {
+		return a.fallbackResponse(req, route), nil
 	}
 
-	// Parse JSON response from Claude
-	feeResp, err := a.parseClaudeResponse(claudeResp)
+	// Gather real-time market context
+	marketCtx, err := a.realData.GatherContext(ctx, route)
 	if err != nil {
-		// Return fallback response if parsing fails
-		return a.fallbackResponse(req), nil
+		return nil, fmt.Errorf("failed to gather market context: %w", err)
 	}
 
-	return feeResp, nil
-}
-
-// buildPrompt constructs the LLM prompt with context
-// Returns (systemPrompt, userPrompt)
-func (a *AIFeeCalculator) buildPrompt(req *AIFeeRequest, ctx *RealMarketContext) (string, string) {
-	systemPrompt := `You are an expert payment orchestration engine for USD→EUR stablecoin transfers. Your role is to analyze real-time market data and optimize routing decisions.
-
-ROUTING FLOW (3 steps):
-1. ON-RAMP: USD → USDC (Circle Mint API)
-2. BLOCKCHAIN: Move USDC on chain (or cross-chain if needed)
-3. OFF-RAMP: USDC → EUR (Circle Redemption API)
-
-You will receive REAL-TIME data:
-1. FX Rate: Live USD/EUR exchange rate
-2. Gas Costs: Actual gas prices for 5 chains (Base, Polygon, Arbitrum, Solana, Ethereum)
-3. Provider Status: Circle operational status for USDC minting/redeeming
-4. ETH Price: For accurate gas cost calculation in USD
-
-SUPPORTED CHAINS (all support Circle USDC):
-- Base (L2): ~$0.00 gas - DEFAULT CHOICE
-- Polygon (Sidechain): ~$0.001 gas - Backup L2
-- Arbitrum (L2): ~$0.01 gas - Popular L2
-- Solana (L1): ~$0.0009 gas - Fastest settlement
-- Ethereum (L1): Variable gas - Maximum security for large transfers
-
-OPTIMIZATION FACTORS:
-1. Gas Costs: Minimize blockchain fees (Base is almost always optimal)
-2. Provider Status: Verify Circle operational for chosen chain
-3. Transfer Amount: Large transfers (>$100K) may justify Ethereum security
-4. Speed: Solana for fastest settlement if needed
-
-SETTLEMENT TIME EXPECTATIONS (Base on transaction size AND selected route):
-
-Transaction Size Impact:
-- Small transfers (<$10K): Use fastest available route, minimal security overhead
-- Medium transfers ($10K-$100K): Balance speed and security
-- Large transfers (>$100K): Prioritize security, accept longer settlement times
-
-Chain-Specific Times (includes on-ramp + blockchain + off-ramp):
-- Base L2: 3-5 minutes (small/medium), 5-7 minutes (large - extra confirmations)
-- Polygon: 4-6 minutes (small/medium), 6-10 minutes (large - extra confirmations)
-- Arbitrum L2: 4-6 minutes (small/medium), 6-8 minutes (large)
-- Solana: 3-5 minutes (small/medium), 5-7 minutes (large - fastest overall)
-- Ethereum L1: 10-15 minutes (large only - maximum security)
-
-Settlement Breakdown:
-- Circle on-ramp (USD→USDC): 1-2 minutes
-- Blockchain confirmation: Chain-specific (10 sec for L2, 5-10 min for L1)
-- Circle off-ramp (USDC→EUR): 1-2 minutes
-
-CRITICAL: Be conservative with estimates - under-promise and over-deliver.
-Better to complete faster than expected than make users wait longer than estimated.
-Adjust settlement time based on BOTH the selected chain AND transaction amount.
-Example: $1,000 on Base L2 = "3-5 minutes", $500K on Ethereum L1 = "10-15 minutes"
-
-FEE STRUCTURE:
-- Platform Fee: 2% (our revenue)
-- On-ramp Fee: ~0.7% (Circle USD→USDC minting)
-- Off-ramp Fee: ~0.5% (Circle USDC→EUR redemption)
-- Gas Cost: Chain-specific (real-time)
-- Total: ~3.2% + gas
-
-Return ONLY valid JSON with this exact structure:
-{
-  "total_fee": <number in cents>,
-  "fee_breakdown": {
-    "platform_fee": <number>,
-    "onramp_fee": <number>,
-    "offramp_fee": <number>,
-    "gas_cost": <number>,
-    "risk_premium": <number>
-  },
-  "recommended_provider": {
-    "onramp": "Circle",
-    "offramp": "Circle",
-    "chain": "<blockchain>",
-    "reasoning": "<2-3 sentences explaining why this chain is optimal>"
-  },
-  "fee_explanation": "<2-3 sentences explaining total fee calculation>",
-  "estimated_settlement_time": "<human readable time>",
-  "confidence_score": <0.0 to 1.0>,
-  "risk_factors": ["<factor1>", "<factor2>"]
-}`
-
-	// Marshal context to JSON
-	ctxJSON, _ := json.MarshalIndent(ctx, "", "  ")
-
-	userPrompt := fmt.Sprintf(`Payment Request:
-- Amount: $%.2f %s → %s
-- Customer Tier: %s
-- Priority: %s
-
-Real-Time Market Data:
-%s
-
-Additional Context:
-- Current time: %s
-- Target: Minimize total cost while ensuring reliable settlement
-- Circle is primary provider for both on-ramp and off-ramp
-
-Calculate optimal fees and routing strategy based on real market data. Return ONLY the JSON response, no other text.`,
-		float64(req.Amount)/100.0,
-		req.FromCurrency,
-		req.ToCurrency,
-		req.CustomerTier,
-		req.Priority,
-		string(ctxJSON),
-		time.Now().Format(time.RFC3339),
-	)
-
-	return systemPrompt, userPrompt
-}
-
-// callClaudeAPI makes the HTTP request to Claude API
-func (a *AIFeeCalculator) callClaudeAPI(ctx context.Context, systemPrompt, userPrompt string) (*ClaudeResponse, error) {
-	reqBody := ClaudeRequest{
-		Model:     "claude-sonnet-4-20250514",
-		MaxTokens: 2048,
-		System:    systemPrompt,
-		Messages: []ClaudeMessage{
-			{
-				Role:    "user",
-				Content: userPrompt,
-			},
-		},
+	// Reuse a recent recommendation for the same kind of request under the same market conditions
+	var fingerprint string
+	if a.cacheEnabled {
+		// A new prompt version must not be served recommendations made under the old one
+		fingerprint = a.prompt.version + "|" + aiFeeFingerprint(req, marketCtx)
+		if cached, ok := a.cachedResponse(ctx, fingerprint, req.Amount); ok {
+			return cached, nil
+		}
 	}
 
-	jsonData, err := json.Marshal(reqBody)
+	// Build prompts for the model
+	systemPrompt, userPrompt, err := a.buildPrompt(req, marketCtx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to render prompt %s: %w", a.prompt.version, err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	// Call the configured provider
+	start := time.Now()
+	llmResp, err := a.completeWithRetry(ctx, systemPrompt, userPrompt)
+	usage := aiUsage(req, llmResp)
+	recordAICall(time.Since(start), usage, req.CustomerTier, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("LLM call failed: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", a.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := a.httpClient.Do(req)
+	// Extract the structured recommendation from the model's tool call
+	feeResp, err := a.parseFeeToolCall(llmResp)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		// Return fallback response if the tool input is missing or invalid; the tokens were still spent
+		logger.Warn("Invalid AI fee response, using fallback", logger.Fields{"error": err.Error()})
+		feeResp = a.fallbackResponse(req, route)
+		feeResp.Usage = usage
+		feeResp.PromptVersion = a.prompt.version
+		return feeResp, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
+	feeResp.PromptVersion = a.prompt.version
 
-	var claudeResp ClaudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if a.cacheEnabled {
+		a.storeResponse(ctx, fingerprint, req.Amount, feeResp)
 	}
 
-	return &claudeResp, nil
+	feeResp.Usage = usage
+	return feeResp, nil
 }
 
-// parseClaudeResponse extracts fee response from Claude's output
-func (a *AIFeeCalculator) parseClaudeResponse(claudeResp *ClaudeResponse) (*AIFeeResponse, error) {
-	if len(claudeResp.Content) == 0 {
-		return nil, fmt.Errorf("empty response from Claude")
-	}
+// buildPrompt renders the configured prompt version with the request and market context
+// Returns (systemPrompt, userPrompt, error)
+func (a *AIFeeCalculator) buildPrompt(req *AIFeeRequest, ctx *RealMarketContext) (string, string, error) {
+	// Marshal context to JSON
+	ctxJSON, _ := json.MarshalIndent(ctx, "", "  ")
 
-	text := claudeResp.Content[0].Text
+	return a.prompt.render(promptData{
+		ToolName:           feeToolName,
+		Amount:             money.Format(req.Amount, req.FromCurrency),
+		FromCurrency:       req.FromCurrency,
+		ToCurrency:         req.ToCurrency,
+		DestinationCountry: ctx.Corridor.DestinationCountry,
+		OnrampProvider:     ctx.Corridor.OnrampProvider,
+		OfframpProvider:    ctx.Corridor.OfframpProvider,
+		PayoutRail:         ctx.Corridor.PayoutRail,
+		Chains:             strings.Join(ctx.Corridor.Chains, ", "),
+		CustomerTier:       req.CustomerTier,
+		Priority:           req.Priority,
+		MarketData:         string(ctxJSON),
+		Now:                time.Now().Format(time.RFC3339),
+	})
+}
 
-	// Try to extract JSON from the response
-	// Claude might include markdown code blocks, so we need to clean it
-	text = cleanJSONResponse(text)
+// recordAICall emits duration, token usage and estimated cost metrics for a model call
+// Usage metrics carry the corridor and customer tier so AI spend can be broken down by both.
+func recordAICall(elapsed time.Duration, usage *models.AIUsage, customerTier string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	metrics.Duration("AICallDuration", elapsed, metrics.Dimensions{"Outcome": outcome})
 
-	var feeResp AIFeeResponse
-	if err := json.Unmarshal([]byte(text), &feeResp); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	if usage == nil {
+		return
 	}
 
-	return &feeResp, nil
+	dims := metrics.Dimensions{"Model": usage.Model, "Corridor": usage.Corridor, "CustomerTier": customerTier}
+	metrics.Emit("AIInputTokens", float64(usage.InputTokens), metrics.UnitCount, dims)
+	metrics.Emit("AIOutputTokens", float64(usage.OutputTokens), metrics.UnitCount, dims)
+	metrics.Emit("AICostUSD", usage.CostUSD, metrics.UnitNone, dims)
 }
 
-// cleanJSONResponse removes markdown code blocks and extra text
-func cleanJSONResponse(text string) string {
-	// Remove ```json and ``` markers
-	text = bytes.NewBuffer([]byte(text)).String()
-
-	// Find first { and last }
-	start := bytes.IndexByte([]byte(text), '{')
-	end := bytes.LastIndexByte([]byte(text), '}')
-
-	if start >= 0 && end >= 0 && end > start {
-		return text[start : end+1]
+// estimateCost returns the approximate USD cost of a call from its model and token usage
+// Unrecognised models are priced as Sonnet.
+func estimateCost(model string, inputTokens, outputTokens int) float64 {
+	input, output := 3.00, 15.00
+	for _, pricing := range modelPricing {
+		if strings.Contains(model, pricing.family) {
+			input, output = pricing.input, pricing.output
+			break
+		}
 	}
+	return float64(inputTokens)/1e6*input +
+		float64(outputTokens)/1e6*output
+}
 
-	return text
+// fallbackOfframpBps is the off-ramp fee the fallback assumes per payout rail, in basis points
+// Rails not listed use Circle redemption's 0.5%, e.g. USDC→EUR over SEPA.
+var fallbackOfframpBps = map[string]int64{
+	"FASTER_PAYMENTS": 30,  // USDC→GBP
+	"PIX":             100, // USDC→BRL through Bridge
 }
 
-// fallbackResponse provides a default response if AI fails
-func (a *AIFeeCalculator) fallbackResponse(req *AIFeeRequest) *AIFeeResponse {
+// fallbackResponse provides a default response if AI fails, routed over the corridor's providers
+func (a *AIFeeCalculator) fallbackResponse(req *AIFeeRequest, route CorridorContext) *AIFeeResponse {
+	offrampBps, ok := fallbackOfframpBps[route.PayoutRail]
+	if !ok {
+		offrampBps = 50
+	}
+
 	// Calculate basic fee (2% platform fee)
 	platformFee := req.Amount * 2 / 100
 	onrampFee := req.Amount * 7 / 1000   // 0.7%
-	offrampFee := req.Amount * 5 / 1000  // 0.5%
+	offrampFee := req.Amount * offrampBps / 10000
+	offrampRate := fmt.Sprintf("%.1f%%", float64(offrampBps)/100)
+	totalRate := fmt.Sprintf("%.1f%%", float64(270+offrampBps)/100)
 	gasCost := int64(0)                  // Base has ~$0.00 gas
 	totalFee := platformFee + onrampFee + offrampFee + gasCost
 
+	reasoning := fmt.Sprintf("Default routing using %s for on-ramp and %s for off-ramp with Base chain for minimal gas fees.",
+		route.OnrampProvider, route.OfframpProvider)
+	if route.OnrampProvider == route.OfframpProvider {
+		reasoning = fmt.Sprintf("Default routing using %s for both on-ramp and off-ramp with Base chain for minimal gas fees.", route.OnrampProvider)
+	}
+
 	return &AIFeeResponse{
 		TotalFee: totalFee,
 		FeeBreakdown: FeeBreakdown{
@@ -344,14 +340,15 @@ func (a *AIFeeCalculator) fallbackResponse(req *AIFeeRequest) *AIFeeResponse {
 			RiskPremium: 0,
 		},
 		Provider: ProviderRecommendation{
-			Onramp:    "Circle",
-			Offramp:   "Circle",
+			Onramp:    route.OnrampProvider,
+			Offramp:   route.OfframpProvider,
 			Chain:     "Base",
-			Reasoning: "Default routing using Circle for both on-ramp and off-ramp with Base chain for minimal gas fees.",
+			Reasoning: reasoning,
 		},
-		FeeExplanation:          "Standard 3.2% fee (2% platform + 0.7% on-ramp + 0.5% off-ramp) with negligible gas costs on Base L2.",
+		FeeExplanation:          fmt.Sprintf("Standard %s fee (2%% platform + 0.7%% on-ramp + %s off-ramp) with negligible gas costs on Base L2.", totalRate, offrampRate),
 		EstimatedSettlementTime: "3-5 minutes",
 		ConfidenceScore:         0.75,
 		RiskFactors:             []string{"Using fallback calculation - AI analysis unavailable"},
+		Fallback:                true,
 	}
 }
@@ -11,7 +11,7 @@ import (
 // It verifies that the RealDataProvider integration works correctly
 func TestAICalculatorIntegration(t *testing.T) {
 	// Create AI calculator (without API key, so it will use fallback)
-	calc := NewAIFeeCalculator("")
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
 
 	// Verify RealDataProvider is initialized
 	if calc.realData == nil {
@@ -22,7 +22,7 @@ func TestAICalculatorIntegration(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	marketCtx, err := calc.realData.GatherContext(ctx)
+	marketCtx, err := calc.realData.GatherContext(ctx, testCorridor(t, "USD", "EUR"))
 	if err != nil {
 		t.Fatalf("Failed to gather market context: %v", err)
 	}
@@ -70,7 +70,7 @@ func TestAICalculatorIntegration(t *testing.T) {
 // TestAICalculatorFallback tests that fallback works when API key is missing
 func TestAICalculatorFallback(t *testing.T) {
 	// Create calculator without API key
-	calc := NewAIFeeCalculator("")
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
 
 	ctx := context.Background()
 	req := &AIFeeRequest{
@@ -129,15 +129,41 @@ func TestAICalculatorFallback(t *testing.T) {
 	t.Logf("  Confidence: %.2f", resp.ConfidenceScore)
 }
 
+// TestAICalculatorFallbackCorridor tests that fallback routing and off-ramp fees follow the corridor
+func TestAICalculatorFallbackCorridor(t *testing.T) {
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
+
+	resp, err := calc.Calculate(context.Background(), &AIFeeRequest{
+		Amount:       100000,
+		FromCurrency: "USD",
+		ToCurrency:   "BRL",
+		Priority:     "standard",
+		CustomerTier: "standard",
+	})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	if resp.Provider.Onramp != "Circle" || resp.Provider.Offramp != "Bridge" {
+		t.Errorf("expected Circle on-ramp and Bridge off-ramp, got %s and %s", resp.Provider.Onramp, resp.Provider.Offramp)
+	}
+	if resp.FeeBreakdown.OfframpFee != 1000 {
+		t.Errorf("expected 1%% PIX off-ramp fee of 1000, got %d", resp.FeeBreakdown.OfframpFee)
+	}
+
+	if _, err := calc.Calculate(context.Background(), &AIFeeRequest{Amount: 100000, FromCurrency: "USD", ToCurrency: "JPY"}); err == nil {
+		t.Error("expected an error for a corridor that isn't supported")
+	}
+}
+
 // TestPromptStructure tests that the prompt is built correctly with RealMarketContext
 func TestPromptStructure(t *testing.T) {
-	calc := NewAIFeeCalculator("")
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
 
 	// Create real market context
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	marketCtx, err := calc.realData.GatherContext(ctx)
+	marketCtx, err := calc.realData.GatherContext(ctx, testCorridor(t, "USD", "EUR"))
 	if err != nil {
 		t.Fatalf("Failed to gather market context: %v", err)
 	}
@@ -151,16 +177,20 @@ func TestPromptStructure(t *testing.T) {
 		CustomerTier:       "standard",
 	}
 
-	prompt := calc.buildPrompt(req, marketCtx)
+	systemPrompt, userPrompt, err := calc.buildPrompt(req, marketCtx)
+	if err != nil {
+		t.Fatalf("Failed to build prompt: %v", err)
+	}
+	prompt := systemPrompt + userPrompt
 
 	// Verify prompt contains key elements
 	if prompt == "" {
 		t.Fatal("Prompt is empty")
 	}
 
-	// Check for USD→EUR specific content
-	if !containsString(prompt, "USD→EUR") {
-		t.Error("Prompt does not mention USD→EUR routing")
+	// Check for the request's corridor
+	if !containsString(prompt, "USD → EUR") {
+		t.Error("Prompt does not mention USD → EUR routing")
 	}
 
 	// Check for Circle provider
@@ -1,24 +1,40 @@
 package fees
 
 import (
+	"context"
 	"fmt"
 
+	"crypto-conversion/internal/corridors"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/money"
 )
 
 // Calculator handles fee calculations for cross-border payments
 type Calculator struct {
-	// Configuration could be injected here for different fee tiers
+	corridors *corridors.Registry        // nil prices every currency from the default schedule
+	schedules *scheduleCache             // nil prices from the built-in schedules
+	customers map[string]CustomerPricing // Negotiated pricing keyed by API key ID or account
+	volumes   *volumeDiscounts           // nil disables rolling-volume discounts
 }
 
 // FeeResult contains the calculated fee information
 type FeeResult struct {
-	FeeAmount    int64   `json:"fee_amount"`    // Fee in cents (same currency as input)
-	FeeCurrency  string  `json:"fee_currency"`  // Currency of the fee (USD for MVP)
-	FeeRate      float64 `json:"fee_rate"`      // Effective percentage rate used
-	FixedFee     int64   `json:"fixed_fee"`     // Fixed portion of fee in cents
-	BaseAmount   int64   `json:"base_amount"`   // Original amount before fees
-	TotalAmount  int64   `json:"total_amount"`  // Base amount + fees
+	FeeAmount       int64          `json:"fee_amount"`                 // Fee in cents (same currency as input)
+	FeeCurrency     string         `json:"fee_currency"`               // Currency of the fee: the funding currency that was priced
+	FeeRate         float64        `json:"fee_rate"`                   // Effective percentage rate used
+	FixedFee        int64          `json:"fixed_fee"`                  // Fixed portion of fee in cents
+	BaseAmount      int64          `json:"base_amount"`                // Original amount before fees
+	TotalAmount     int64          `json:"total_amount"`               // Base amount + fees
+	ScheduleID      string         `json:"schedule_id,omitempty"`      // Stored schedule used; empty for the built-in one
+	ScheduleVersion int64          `json:"schedule_version,omitempty"` // Version of ScheduleID in effect
+	PricingCustomer string         `json:"pricing_customer,omitempty"` // Customer whose negotiated pricing applied
+	Discount        int64          `json:"discount,omitempty"`         // Negotiated discount already taken off FeeAmount
+	VolumeTier      *VolumeTier    `json:"volume_tier,omitempty"`      // Volume tier whose rate applied
+	FeeLimit        string         `json:"fee_limit,omitempty"`        // FeeLimitMinimum or FeeLimitMaximum when the corridor's limit set FeeAmount
+	UnclampedFee    int64          `json:"unclamped_fee,omitempty"`    // Fee before FeeLimit was applied
+	Surcharge       int64          `json:"surcharge,omitempty"`        // Regulatory surcharges included in FeeAmount
+	Surcharges      []SurchargeFee `json:"surcharges,omitempty"`
 }
 
 // NewCalculator creates a new fee calculator
@@ -28,36 +44,29 @@ func NewCalculator() *Calculator {
 
 // CalculateFee calculates the fee for a payment based on amount and destination currency
 //
-// Fee Structure (USD amounts):
+// Fee Structure (USD amounts, corridors.DefaultFeeSchedule):
 //   - Amount < $100:      2.9% + $0.30
 //   - Amount < $1,000:    2.5% + $0.50
 //   - Amount >= $1,000:   2.0% + $1.00
 //
 // Parameters:
 //   - amount: Payment amount in cents
-//   - currency: Destination currency (affects fee tier, EUR for MVP)
+//   - currency: Destination currency, only logged; CalculateFeeForCurrency prices per corridor
+//   - customers: API key ID and/or account, tried in order for negotiated pricing
 //
 // Returns:
 //   - FeeResult with calculated fees
-func (c *Calculator) CalculateFee(amount int64, currency string) *FeeResult {
-	var percentageRate float64
-	var fixedFee int64
+func (c *Calculator) CalculateFee(amount int64, currency string, customers ...string) *FeeResult {
+	return c.applyCustomerPricing(c.CalculateFeeWithSchedule(amount, currency, corridors.DefaultFeeSchedule), customers)
+}
 
+// CalculateFeeWithSchedule calculates the fee using a corridor's fee schedule
+func (c *Calculator) CalculateFeeWithSchedule(amount int64, currency string, schedule corridors.FeeSchedule) *FeeResult {
 	// Determine fee tier based on amount
 	// All amounts are in cents (USD cents for MVP)
-	switch {
-	case amount < 10000: // Less than $100
-		percentageRate = 0.029 // 2.9%
-		fixedFee = 30          // $0.30 in cents
-
-	case amount < 100000: // Less than $1,000
-		percentageRate = 0.025 // 2.5%
-		fixedFee = 50          // $0.50 in cents
-
-	default: // $1,000 or more
-		percentageRate = 0.020 // 2.0%
-		fixedFee = 100         // $1.00 in cents
-	}
+	tier := schedule.Tier(amount)
+	percentageRate := tier.Rate
+	fixedFee := tier.FixedFee
 
 	// Calculate percentage-based fee
 	percentageFee := int64(float64(amount) * percentageRate)
@@ -67,7 +76,7 @@ func (c *Calculator) CalculateFee(amount int64, currency string) *FeeResult {
 
 	result := &FeeResult{
 		FeeAmount:   totalFee,
-		FeeCurrency: "USD", // All fees in USD for MVP
+		FeeCurrency: models.DefaultSourceCurrency, // Corridor pricing overrides this with its source currency
 		FeeRate:     percentageRate,
 		FixedFee:    fixedFee,
 		BaseAmount:  amount,
@@ -75,44 +84,70 @@ func (c *Calculator) CalculateFee(amount int64, currency string) *FeeResult {
 	}
 
 	logger.Info("Fee calculated", logger.Fields{
-		"base_amount":    amount,
-		"currency":       currency,
-		"fee_amount":     totalFee,
-		"fee_rate":       fmt.Sprintf("%.1f%%", percentageRate*100),
-		"fixed_fee":      fixedFee,
-		"total_amount":   result.TotalAmount,
+		"base_amount":  amount,
+		"currency":     currency,
+		"fee_amount":   totalFee,
+		"fee_rate":     fmt.Sprintf("%.1f%%", percentageRate*100),
+		"fixed_fee":    fixedFee,
+		"total_amount": result.TotalAmount,
 	})
 
 	return result
 }
 
-// CalculateFeeForCurrency is a convenience wrapper that logs currency-specific info
-// In production, this could apply different fees based on destination country/currency
-func (c *Calculator) CalculateFeeForCurrency(amount int64, currency string) *FeeResult {
-	// For MVP, we use the same fee structure regardless of destination currency
-	// In production, you might have:
-	// - Different fees for different corridors (USD->EUR vs USD->GBP)
-	// - Country-specific regulatory fees
-	// - Currency conversion spreads
-
-	result := c.CalculateFee(amount, currency)
+// CalculateFeeForCurrency calculates the fee for a USD-funded payment paying out in currency
+// The USD corridor for currency prices it, with that corridor's schedule, limits and surcharges (see
+// CalculateCorridorFee). Without a registry, or for a currency no corridor serves, it prices from the
+// stored default schedule when one is in effect, else the built-in one, then applies customers' pricing.
+func (c *Calculator) CalculateFeeForCurrency(ctx context.Context, amount int64, currency string, customers ...string) *FeeResult {
+	var result *FeeResult
+	if corridor, ok := c.corridorFor(currency); ok {
+		result = c.CalculateCorridorFee(ctx, corridor, amount, customers...)
+	} else {
+		result = c.CalculateFeeWithSchedule(amount, currency, corridors.DefaultFeeSchedule)
+		if stored := c.activeSchedule(ctx, models.DefaultFeeScheduleID); stored != nil {
+			result = c.withSchedule(c.CalculateFeeWithSchedule(amount, currency, stored.Tiers), stored)
+		}
+		result = c.applyCustomerPricing(result, customers)
+		result = c.applyVolumeDiscount(ctx, result, customers)
+	}
 
 	logger.Info("Currency-specific fee calculation", logger.Fields{
 		"destination_currency": currency,
-		"fee_amount":          result.FeeAmount,
-		"effective_rate":      fmt.Sprintf("%.2f%%", (float64(result.FeeAmount)/float64(amount))*100),
+		"fee_amount":           result.FeeAmount,
+		"surcharge":            result.Surcharge,
+		"effective_rate":       fmt.Sprintf("%.2f%%", (float64(result.FeeAmount)/float64(amount))*100),
+		"schedule_version":     result.ScheduleVersion,
 	})
 
 	return result
 }
 
+// SetCorridors prices CalculateFeeForCurrency from the registry's corridors
+func (c *Calculator) SetCorridors(registry *corridors.Registry) {
+	c.corridors = registry
+}
+
+// corridorFor returns the enabled corridor from the default source currency to currency
+func (c *Calculator) corridorFor(currency string) (corridors.Corridor, bool) {
+	if c.corridors == nil {
+		return corridors.Corridor{}, false
+	}
+	corridor, err := c.corridors.Lookup(models.DefaultSourceCurrency, currency)
+	return corridor, err == nil
+}
+
+// PlatformFee returns the fee without regulatory surcharges, the part discounts and promos apply to
+func (r *FeeResult) PlatformFee() int64 {
+	return r.FeeAmount - r.Surcharge
+}
+
 // FormatFeeForDisplay returns a human-readable fee string
 func (r *FeeResult) FormatFeeForDisplay() string {
-	dollars := float64(r.FeeAmount) / 100.0
-	return fmt.Sprintf("$%.2f (%d%% + $%.2f)",
-		dollars,
+	return fmt.Sprintf("%s (%d%% + %s)",
+		money.Format(r.FeeAmount, r.FeeCurrency),
 		int(r.FeeRate*100),
-		float64(r.FixedFee)/100.0)
+		money.Format(r.FixedFee, r.FeeCurrency))
 }
 
 // GetEffectiveRate returns the actual fee rate as a percentage of the base amount
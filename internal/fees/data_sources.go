@@ -6,8 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
+
+	"crypto-conversion/internal/tracing"
 )
 
 // DataSource is a generic interface for fetching real-time market data
@@ -21,6 +26,11 @@ type HTTPDataSource struct {
 	client  *http.Client
 	name    string
 	baseURL string
+
+	// Optional; sent as apiKeyHeader, or as the apiKeyParam query parameter
+	apiKey       string
+	apiKeyHeader string
+	apiKeyParam  string
 }
 
 // NewHTTPDataSource creates a new HTTP-based data source
@@ -28,9 +38,9 @@ func NewHTTPDataSource(name, baseURL string, timeout time.Duration) *HTTPDataSou
 	return &HTTPDataSource{
 		name:    name,
 		baseURL: baseURL,
-		client: &http.Client{
+		client: tracing.HTTPClient(&http.Client{
 			Timeout: timeout,
-		},
+		}),
 	}
 }
 
@@ -38,6 +48,21 @@ func (h *HTTPDataSource) GetName() string {
 	return h.name
 }
 
+// authorize adds the source's API key, if any, to req
+func (h *HTTPDataSource) authorize(req *http.Request) {
+	if h.apiKey == "" {
+		return
+	}
+	if h.apiKeyHeader != "" {
+		req.Header.Set(h.apiKeyHeader, h.apiKey)
+	}
+	if h.apiKeyParam != "" {
+		query := req.URL.Query()
+		query.Set(h.apiKeyParam, h.apiKey)
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
 // FetchJSON is a helper to fetch and parse JSON from an API
 func (h *HTTPDataSource) FetchJSON(ctx context.Context, endpoint string, result interface{}) error {
 	url := h.baseURL + endpoint
@@ -46,6 +71,7 @@ func (h *HTTPDataSource) FetchJSON(ctx context.Context, endpoint string, result
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	h.authorize(req)
 
 	resp, err := h.client.Do(req)
 	if err != nil {
@@ -65,147 +91,378 @@ func (h *HTTPDataSource) FetchJSON(ctx context.Context, endpoint string, result
 	return nil
 }
 
-// GasPriceSource fetches gas prices from blockchain explorers
-type GasPriceSource struct {
+// FetchJSONRPC posts a JSON-RPC 2.0 request for method to the source's URL and decodes the whole response into result
+// The response's error member is left for the caller to check.
+func (h *HTTPDataSource) FetchJSONRPC(ctx context.Context, method string, params interface{}, result interface{}) error {
+	reqBody := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s RPC request failed: %w", h.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s RPC returned status %d: %s", h.name, resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
+
+// Units of GasReading prices
+const (
+	GasUnitGwei          = "gwei"           // EVM price per unit of gas
+	GasUnitMicroLamports = "micro_lamports" // Solana priority fee per compute unit
+)
+
+// GasReading is a chain's gas price normalized from whichever oracle serves it
+type GasReading struct {
+	Chain          string    `json:"chain"`
+	Source         string    `json:"source"` // Data source name, e.g. "base-gas"
+	Unit           string    `json:"unit"`   // GasUnitGwei or GasUnitMicroLamports
+	Slow           float64   `json:"slow"`
+	Standard       float64   `json:"standard"`
+	Fast           float64   `json:"fast"`
+	NativePriceUSD float64   `json:"native_price_usd,omitempty"` // Price of the chain's gas token, when the oracle reports it
+	ObservedAt     time.Time `json:"observed_at"`
+}
+
+// NewGasPriceSource creates the gas oracle adapter for chain
+// Each adapter's Fetch returns a *GasReading.
+func NewGasPriceSource(chain string) DataSource {
+	d, ok := defaultDefinition(sourceKindGas + ":" + chain)
+	if !ok {
+		// Chains without a built-in oracle read Ethereum's
+		d, _ = defaultDefinition(sourceKindGas + ":ethereum")
+		d.Name = chain
+	}
+	return d.gasSource()
+}
+
+// BlockscoutGasSource reads gas prices from a Blockscout explorer's stats endpoint
+type BlockscoutGasSource struct {
+	*HTTPDataSource
+	chain string
+}
+
+// NewBlockscoutGasSource creates a Blockscout gas oracle adapter
+func NewBlockscoutGasSource(chain, name, baseURL string) *BlockscoutGasSource {
+	return &BlockscoutGasSource{
+		HTTPDataSource: NewHTTPDataSource(name, baseURL, 10*time.Second),
+		chain:          chain,
+	}
+}
+
+// BlockscoutStatsResponse is the part of Blockscout's /api/v2/stats response used for gas
+type BlockscoutStatsResponse struct {
+	CoinPrice string `json:"coin_price"` // Gas token price in USD; null when the explorer has no market data
+	GasPrices *struct {
+		Slow    blockscoutGasPrice `json:"slow"`
+		Average blockscoutGasPrice `json:"average"`
+		Fast    blockscoutGasPrice `json:"fast"`
+	} `json:"gas_prices"` // null until the explorer has indexed enough blocks
+	GasPriceUpdatedAt *time.Time `json:"gas_price_updated_at"`
+}
+
+// blockscoutGasPrice is one gas price tier in gwei
+// Older Blockscout releases report a bare number, newer ones an object with the gwei price under "price".
+type blockscoutGasPrice float64
+
+// UnmarshalJSON accepts either form of a Blockscout gas price
+func (p *blockscoutGasPrice) UnmarshalJSON(data []byte) error {
+	var price float64
+	if err := json.Unmarshal(data, &price); err == nil {
+		*p = blockscoutGasPrice(price)
+		return nil
+	}
+
+	var detailed struct {
+		Price *float64 `json:"price"`
+	}
+	if err := json.Unmarshal(data, &detailed); err != nil {
+		return fmt.Errorf("unrecognized Blockscout gas price %s", string(data))
+	}
+	if detailed.Price != nil {
+		*p = blockscoutGasPrice(*detailed.Price)
+	}
+	return nil
+}
+
+// reading normalizes the stats response into a GasReading
+func (r *BlockscoutStatsResponse) reading(chain, source string) (*GasReading, error) {
+	if r.GasPrices == nil || r.GasPrices.Average <= 0 {
+		return nil, fmt.Errorf("%s: Blockscout stats have no gas prices", chain)
+	}
+
+	reading := &GasReading{
+		Chain:      chain,
+		Source:     source,
+		Unit:       GasUnitGwei,
+		Slow:       float64(r.GasPrices.Slow),
+		Standard:   float64(r.GasPrices.Average),
+		Fast:       float64(r.GasPrices.Fast),
+		ObservedAt: time.Now(),
+	}
+	if r.GasPriceUpdatedAt != nil {
+		reading.ObservedAt = *r.GasPriceUpdatedAt
+	}
+	if r.CoinPrice != "" {
+		if price, err := strconv.ParseFloat(r.CoinPrice, 64); err == nil {
+			reading.NativePriceUSD = price
+		}
+	}
+	return reading, nil
+}
+
+// Fetch retrieves current gas prices
+func (b *BlockscoutGasSource) Fetch(ctx context.Context) (interface{}, error) {
+	var response BlockscoutStatsResponse
+	if err := b.FetchJSON(ctx, "/api/v2/stats", &response); err != nil {
+		return nil, err
+	}
+	return response.reading(b.chain, b.name)
+}
+
+// GasnowSource reads Ethereum gas prices from beaconcha.in's gasnow endpoint
+type GasnowSource struct {
 	*HTTPDataSource
 	chain string
 }
 
-// NewGasPriceSource creates a gas price data source
-func NewGasPriceSource(chain string) *GasPriceSource {
-	var baseURL string
-	switch chain {
-	case "ethereum":
-		baseURL = "https://beaconcha.in"
-	case "base":
-		baseURL = "https://base.blockscout.com"
-	case "polygon":
-		baseURL = "https://polygon.blockscout.com"
-	case "arbitrum":
-		baseURL = "https://arbitrum.blockscout.com"
-	case "solana":
-		baseURL = "https://solana.blockscout.com"
-	default:
-		baseURL = "https://beaconcha.in"
-	}
-
-	return &GasPriceSource{
-		HTTPDataSource: NewHTTPDataSource(fmt.Sprintf("%s-gas", chain), baseURL, 10*time.Second),
+// NewGasnowSource creates a beaconcha.in gasnow oracle adapter
+func NewGasnowSource(chain, name, baseURL string) *GasnowSource {
+	return &GasnowSource{
+		HTTPDataSource: NewHTTPDataSource(name, baseURL, 10*time.Second),
 		chain:          chain,
 	}
 }
 
-// GasOracleResponse represents the response from gas price APIs
-type GasOracleResponse struct {
+// GasnowResponse is beaconcha.in's /api/v1/execution/gasnow response
+type GasnowResponse struct {
 	Code int `json:"code"`
 	Data struct {
-		Rapid     int64   `json:"rapid"`      // fastest (wei)
-		Fast      int64   `json:"fast"`       // fast (wei)
-		Standard  int64   `json:"standard"`   // standard (wei)
-		Slow      int64   `json:"slow"`       // slow (wei)
-		Timestamp int64   `json:"timestamp"`
-		Price     float64 `json:"price"`      // ETH price in USD
-		PriceUSD  float64 `json:"priceUSD"`
+		Rapid     int64   `json:"rapid"`     // wei
+		Fast      int64   `json:"fast"`      // wei
+		Standard  int64   `json:"standard"`  // wei
+		Slow      int64   `json:"slow"`      // wei
+		Timestamp int64   `json:"timestamp"` // Unix milliseconds
+		PriceUSD  float64 `json:"priceUSD"`  // ETH price in USD
 	} `json:"data"`
 }
 
-// Fetch retrieves current gas prices
-func (g *GasPriceSource) Fetch(ctx context.Context) (interface{}, error) {
-	// Solana uses RPC API, different from EVM chains
-	if g.chain == "solana" {
-		return g.fetchSolanaGas(ctx)
+// reading normalizes the gasnow response into a GasReading
+func (r *GasnowResponse) reading(chain, source string) (*GasReading, error) {
+	if r.Code != http.StatusOK || r.Data.Standard <= 0 {
+		return nil, fmt.Errorf("%s: gasnow returned code %d with no standard gas price", chain, r.Code)
 	}
 
-	var endpoint string
-	if g.chain == "ethereum" {
-		endpoint = "/api/v1/execution/gasnow"
-	} else {
-		// For Base/Polygon/Arbitrum, use Blockscout stats
-		endpoint = "/api/v2/stats"
+	reading := &GasReading{
+		Chain:          chain,
+		Source:         source,
+		Unit:           GasUnitGwei,
+		Slow:           weiToGwei(r.Data.Slow),
+		Standard:       weiToGwei(r.Data.Standard),
+		Fast:           weiToGwei(r.Data.Fast),
+		NativePriceUSD: r.Data.PriceUSD,
+		ObservedAt:     time.Now(),
 	}
+	if r.Data.Timestamp > 0 {
+		reading.ObservedAt = time.UnixMilli(r.Data.Timestamp)
+	}
+	return reading, nil
+}
 
-	var response GasOracleResponse
-	err := g.FetchJSON(ctx, endpoint, &response)
-	if err != nil {
+// Fetch retrieves current gas prices
+func (g *GasnowSource) Fetch(ctx context.Context) (interface{}, error) {
+	var response GasnowResponse
+	if err := g.FetchJSON(ctx, "/api/v1/execution/gasnow", &response); err != nil {
 		return nil, err
 	}
+	return response.reading(g.chain, g.name)
+}
 
-	return &response, nil
+// SolanaGasSource reads recent priority fees from a Solana RPC node
+type SolanaGasSource struct {
+	*HTTPDataSource
 }
 
-// fetchSolanaGas fetches Solana prioritization fees via RPC
-func (g *GasPriceSource) fetchSolanaGas(ctx context.Context) (interface{}, error) {
-	// Solana RPC request for recent prioritization fees
-	reqBody := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"method":  "getRecentPrioritizationFees",
-		"params":  []interface{}{[]string{}},
+// NewSolanaGasSource creates a Solana RPC gas oracle adapter
+func NewSolanaGasSource(name, rpcURL string) *SolanaGasSource {
+	return &SolanaGasSource{
+		HTTPDataSource: NewHTTPDataSource(name, rpcURL, 10*time.Second),
 	}
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal Solana RPC request: %w", err)
+// SolanaPrioritizationFeesResponse is the JSON-RPC response to getRecentPrioritizationFees
+type SolanaPrioritizationFeesResponse struct {
+	Result []struct {
+		PrioritizationFee int64 `json:"prioritizationFee"` // Micro-lamports per compute unit
+		Slot              int64 `json:"slot"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reading normalizes recent priority fees into a GasReading
+// Slow, standard and fast are the 25th, 50th and 75th percentile fees over recent slots.
+func (r *SolanaPrioritizationFeesResponse) reading(source string) (*GasReading, error) {
+	if r.Error != nil {
+		return nil, fmt.Errorf("solana: RPC error %d: %s", r.Error.Code, r.Error.Message)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.mainnet-beta.solana.com", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Solana RPC request: %w", err)
+	prices := make([]float64, 0, len(r.Result))
+	for _, fee := range r.Result {
+		prices = append(prices, float64(fee.PrioritizationFee))
 	}
+	sort.Float64s(prices)
+
+	return &GasReading{
+		Chain:      "solana",
+		Source:     source,
+		Unit:       GasUnitMicroLamports,
+		Slow:       percentile(prices, 0.25),
+		Standard:   percentile(prices, 0.50),
+		Fast:       percentile(prices, 0.75),
+		ObservedAt: time.Now(),
+	}, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// percentile returns the p-th percentile of sorted values (nearest rank), or 0 when empty
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+}
 
-	resp, err := g.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Solana RPC request failed: %w", err)
+// Fetch retrieves recent priority fees
+func (s *SolanaGasSource) Fetch(ctx context.Context) (interface{}, error) {
+	var response SolanaPrioritizationFeesResponse
+	if err := s.FetchJSONRPC(ctx, "getRecentPrioritizationFees", []interface{}{[]string{}}, &response); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return response.reading(s.name)
+}
 
-	var rpcResp struct {
-		Result []struct {
-			PrioritizationFee int64 `json:"prioritizationFee"`
-			Slot              int64 `json:"slot"`
-		} `json:"result"`
+// evmFeeHistoryBlocks is how many recent blocks EVMRPCGasSource samples priority fees over
+const evmFeeHistoryBlocks = 10
+
+// EVMRPCGasSource reads gas prices from an EVM chain's JSON-RPC node
+// Used for chains without a Blockscout explorer, such as Avalanche C-Chain.
+type EVMRPCGasSource struct {
+	*HTTPDataSource
+	chain string
+}
+
+// NewEVMRPCGasSource creates an EVM JSON-RPC gas oracle adapter
+func NewEVMRPCGasSource(chain, name, rpcURL string) *EVMRPCGasSource {
+	return &EVMRPCGasSource{
+		HTTPDataSource: NewHTTPDataSource(name, rpcURL, 10*time.Second),
+		chain:          chain,
 	}
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Solana RPC response: %w", err)
+// EVMFeeHistoryResponse is the JSON-RPC response to eth_feeHistory
+type EVMFeeHistoryResponse struct {
+	Result *struct {
+		BaseFeePerGas []string   `json:"baseFeePerGas"` // Hex wei; one per block plus the next block's
+		Reward        [][]string `json:"reward"`        // Hex wei priority fees per block, at the requested percentiles
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reading normalizes fee history into a GasReading
+// Each tier is the next block's base fee plus the median, over recent blocks, of the 25th, 50th
+// and 75th percentile priority fees.
+func (r *EVMFeeHistoryResponse) reading(chain, source string) (*GasReading, error) {
+	if r.Error != nil {
+		return nil, fmt.Errorf("%s: RPC error %d: %s", chain, r.Error.Code, r.Error.Message)
+	}
+	if r.Result == nil || len(r.Result.BaseFeePerGas) == 0 {
+		return nil, fmt.Errorf("%s: fee history has no base fee", chain)
 	}
 
-	// Calculate average fee in lamports (1 SOL = 1e9 lamports)
-	// Convert to our standard response format
-	avgFee := int64(0)
-	if len(rpcResp.Result) > 0 {
-		sum := int64(0)
-		for _, fee := range rpcResp.Result {
-			sum += fee.PrioritizationFee
+	baseFee, err := parseHexWei(r.Result.BaseFeePerGas[len(r.Result.BaseFeePerGas)-1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", chain, err)
+	}
+
+	tiers := make([]float64, 3)
+	for tier := range tiers {
+		var rewards []float64
+		for _, block := range r.Result.Reward {
+			if tier >= len(block) {
+				continue
+			}
+			reward, err := parseHexWei(block[tier])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", chain, err)
+			}
+			rewards = append(rewards, reward)
 		}
-		avgFee = sum / int64(len(rpcResp.Result))
-	}
-
-	// Return in our standard format (convert lamports to "gwei-equivalent" for consistency)
-	return &GasOracleResponse{
-		Code: 200,
-		Data: struct {
-			Rapid     int64   `json:"rapid"`
-			Fast      int64   `json:"fast"`
-			Standard  int64   `json:"standard"`
-			Slow      int64   `json:"slow"`
-			Timestamp int64   `json:"timestamp"`
-			Price     float64 `json:"price"`
-			PriceUSD  float64 `json:"priceUSD"`
-		}{
-			Standard:  avgFee,      // Solana fee in lamports
-			Fast:      avgFee,
-			Rapid:     avgFee,
-			Slow:      avgFee,
-			Timestamp: time.Now().Unix(),
-			Price:     0,
-			PriceUSD:  0,
-		},
+		sort.Float64s(rewards)
+		tiers[tier] = (baseFee + percentile(rewards, 0.50)) / 1e9
+	}
+
+	return &GasReading{
+		Chain:      chain,
+		Source:     source,
+		Unit:       GasUnitGwei,
+		Slow:       tiers[0],
+		Standard:   tiers[1],
+		Fast:       tiers[2],
+		ObservedAt: time.Now(),
 	}, nil
 }
 
+// parseHexWei parses a JSON-RPC hex quantity of wei
+func parseHexWei(quantity string) (float64, error) {
+	if len(quantity) < 3 || quantity[:2] != "0x" {
+		return 0, fmt.Errorf("invalid hex quantity %q", quantity)
+	}
+	wei, err := strconv.ParseUint(quantity[2:], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex quantity %q: %w", quantity, err)
+	}
+	return float64(wei), nil
+}
+
+// Fetch retrieves recent base and priority fees
+func (e *EVMRPCGasSource) Fetch(ctx context.Context) (interface{}, error) {
+	params := []interface{}{fmt.Sprintf("0x%x", evmFeeHistoryBlocks), "latest", []int{25, 50, 75}}
+
+	var response EVMFeeHistoryResponse
+	if err := e.FetchJSONRPC(ctx, "eth_feeHistory", params, &response); err != nil {
+		return nil, err
+	}
+	return response.reading(e.chain, e.name)
+}
+
 // FXRateSource fetches foreign exchange rates
 type FXRateSource struct {
 	*HTTPDataSource
@@ -252,21 +509,13 @@ type ProviderStatusSource struct {
 }
 
 // NewProviderStatusSource creates a provider status data source
+// Providers without a built-in status page read Coinbase's.
 func NewProviderStatusSource(provider string) *ProviderStatusSource {
-	var baseURL string
-	switch provider {
-	case "coinbase":
-		baseURL = "https://status.coinbase.com"
-	case "circle":
-		baseURL = "https://status.circle.com"
-	default:
-		baseURL = "https://status.coinbase.com"
-	}
-
-	return &ProviderStatusSource{
-		HTTPDataSource: NewHTTPDataSource(fmt.Sprintf("%s-status", provider), baseURL, 10*time.Second),
-		provider:       provider,
+	d, ok := defaultDefinition(sourceKindStatus + ":" + provider)
+	if !ok {
+		d = SourceDefinition{Name: provider, Type: SourceTypeStatuspage, URL: "https://status.coinbase.com"}
 	}
+	return d.statusSource()
 }
 
 // StatusPageResponse represents Atlassian Statuspage API response
@@ -304,16 +553,15 @@ func (p *ProviderStatusSource) Fetch(ctx context.Context) (interface{}, error) {
 	return &response, nil
 }
 
-// ETHPriceSource fetches current ETH price (needed for gas cost calculation)
-type ETHPriceSource struct {
+// TokenPriceSource fetches current gas token prices (needed for gas cost calculation)
+// Covers ETH for Ethereum and its L2s, SOL for Solana, MATIC for Polygon and AVAX for Avalanche.
+type TokenPriceSource struct {
 	*HTTPDataSource
 }
 
-// NewETHPriceSource creates an ETH price data source
-func NewETHPriceSource() *ETHPriceSource {
-	return &ETHPriceSource{
-		HTTPDataSource: NewHTTPDataSource("eth-price", "https://api.coingecko.com", 10*time.Second),
-	}
+// NewTokenPriceSource creates a gas token price data source
+func NewTokenPriceSource() *TokenPriceSource {
+	return DefaultSourceRegistry().TokenPriceSource()
 }
 
 // CoinGeckoResponse represents CoinGecko API response
@@ -322,15 +570,38 @@ type CoinGeckoResponse struct {
 		USD float64 `json:"usd"`
 		EUR float64 `json:"eur"`
 	} `json:"ethereum"`
+	Solana struct {
+		USD float64 `json:"usd"`
+	} `json:"solana"`
+	MaticNetwork struct {
+		USD float64 `json:"usd"`
+	} `json:"matic-network"`
+	Avalanche struct {
+		USD float64 `json:"usd"`
+	} `json:"avalanche-2"`
 }
 
-// Fetch retrieves current ETH price
-func (e *ETHPriceSource) Fetch(ctx context.Context) (interface{}, error) {
+// TokenPrices holds the USD price of each chain's gas token
+// A zero price means the source didn't report that token.
+type TokenPrices struct {
+	ETH   float64 `json:"eth"`
+	SOL   float64 `json:"sol"`
+	MATIC float64 `json:"matic"`
+	AVAX  float64 `json:"avax"`
+}
+
+// Fetch retrieves current gas token prices
+func (t *TokenPriceSource) Fetch(ctx context.Context) (interface{}, error) {
 	var response CoinGeckoResponse
-	err := e.FetchJSON(ctx, "/api/v3/simple/price?ids=ethereum&vs_currencies=usd,eur", &response)
+	err := t.FetchJSON(ctx, "/api/v3/simple/price?ids=ethereum,solana,matic-network,avalanche-2&vs_currencies=usd,eur", &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, nil
+	return &TokenPrices{
+		ETH:   response.Ethereum.USD,
+		SOL:   response.Solana.USD,
+		MATIC: response.MaticNetwork.USD,
+		AVAX:  response.Avalanche.USD,
+	}, nil
 }
@@ -0,0 +1,199 @@
+package fees
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// gasOracleServer serves body for every request
+func gasOracleServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func fetchReading(t *testing.T, source DataSource) *GasReading {
+	t.Helper()
+	data, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	return data.(*GasReading)
+}
+
+func TestGasOracleBlockscoutNumericPrices(t *testing.T) {
+	server := gasOracleServer(t, `{
+		"coin_price": "0.2391",
+		"gas_prices": {"slow": 30.5, "average": 34.1, "fast": 40},
+		"gas_price_updated_at": "2026-03-01T12:00:00Z",
+		"total_blocks": "65000000"
+	}`)
+
+	reading := fetchReading(t, NewBlockscoutGasSource("polygon", "polygon-gas", server.URL))
+	if reading.Unit != GasUnitGwei || reading.Standard != 34.1 || reading.Slow != 30.5 || reading.Fast != 40 {
+		t.Errorf("reading = %+v, want gwei 30.5/34.1/40", reading)
+	}
+	if reading.NativePriceUSD != 0.2391 {
+		t.Errorf("NativePriceUSD = %v, want 0.2391", reading.NativePriceUSD)
+	}
+	if reading.ObservedAt.Unix() != 1772366400 {
+		t.Errorf("ObservedAt = %v, want the explorer's update time", reading.ObservedAt)
+	}
+}
+
+func TestGasOracleBlockscoutDetailedPrices(t *testing.T) {
+	server := gasOracleServer(t, `{
+		"coin_price": null,
+		"gas_prices": {
+			"slow": {"price": 0.01, "fiat_price": "0.01", "time": 12000},
+			"average": {"price": 0.012, "fiat_price": "0.01", "time": 6000},
+			"fast": {"price": 0.02, "fiat_price": "0.02", "time": 2000}
+		}
+	}`)
+
+	reading := fetchReading(t, NewBlockscoutGasSource("base", "base-gas", server.URL))
+	if reading.Standard != 0.012 || reading.Fast != 0.02 {
+		t.Errorf("reading = %+v, want average 0.012 and fast 0.02 gwei", reading)
+	}
+	if reading.NativePriceUSD != 0 {
+		t.Errorf("NativePriceUSD = %v, want 0 without a coin price", reading.NativePriceUSD)
+	}
+}
+
+func TestGasOracleBlockscoutWithoutGasPrices(t *testing.T) {
+	server := gasOracleServer(t, `{"coin_price": "3000", "gas_prices": null}`)
+
+	if _, err := NewBlockscoutGasSource("arbitrum", "arbitrum-gas", server.URL).Fetch(context.Background()); err == nil {
+		t.Error("expected an error when the explorer reports no gas prices")
+	}
+}
+
+func TestGasOracleGasnow(t *testing.T) {
+	server := gasOracleServer(t, `{"code": 200, "data": {
+		"rapid": 40000000000, "fast": 30000000000, "standard": 25000000000, "slow": 20000000000,
+		"timestamp": 1772366400000, "price": 3100.5, "priceUSD": 3100.5
+	}}`)
+
+	reading := fetchReading(t, NewGasnowSource("ethereum", "ethereum-gas", server.URL))
+	if reading.Unit != GasUnitGwei || reading.Standard != 25 || reading.Slow != 20 || reading.Fast != 30 {
+		t.Errorf("reading = %+v, want gwei 20/25/30", reading)
+	}
+	if reading.NativePriceUSD != 3100.5 {
+		t.Errorf("NativePriceUSD = %v, want 3100.5", reading.NativePriceUSD)
+	}
+	if reading.ObservedAt.Unix() != 1772366400 {
+		t.Errorf("ObservedAt = %v, want the oracle's timestamp", reading.ObservedAt)
+	}
+}
+
+func TestGasOracleSolanaPercentiles(t *testing.T) {
+	server := gasOracleServer(t, `{"jsonrpc": "2.0", "id": 1, "result": [
+		{"prioritizationFee": 0, "slot": 1}, {"prioritizationFee": 1000, "slot": 2},
+		{"prioritizationFee": 5000, "slot": 3}, {"prioritizationFee": 100000, "slot": 4}
+	]}`)
+
+	reading := fetchReading(t, NewSolanaGasSource("solana-gas", server.URL))
+	if reading.Unit != GasUnitMicroLamports || reading.Slow != 0 || reading.Standard != 1000 || reading.Fast != 5000 {
+		t.Errorf("reading = %+v, want micro-lamports 0/1000/5000", reading)
+	}
+}
+
+func TestGasOracleSolanaRPCError(t *testing.T) {
+	server := gasOracleServer(t, `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32005, "message": "Node is behind"}}`)
+
+	if _, err := NewSolanaGasSource("solana-gas", server.URL).Fetch(context.Background()); err == nil {
+		t.Error("expected an error for a JSON-RPC error response")
+	}
+}
+
+func TestGasOracleEVMRPCFeeHistory(t *testing.T) {
+	// Next base fee 1 gwei; priority fee medians 0.5, 1.5 and 3 gwei
+	server := gasOracleServer(t, `{"jsonrpc": "2.0", "id": 1, "result": {
+		"oldestBlock": "0x10",
+		"baseFeePerGas": ["0x77359400", "0x3b9aca00"],
+		"reward": [["0x1dcd6500", "0x59682f00", "0xb2d05e00"], ["0x1dcd6500", "0x59682f00", "0xb2d05e00"]]
+	}}`)
+
+	reading := fetchReading(t, NewEVMRPCGasSource("avalanche", "avalanche-gas", server.URL))
+	if reading.Chain != "avalanche" || reading.Unit != GasUnitGwei {
+		t.Errorf("reading = %+v, want avalanche in gwei", reading)
+	}
+	if reading.Slow != 1.5 || reading.Standard != 2.5 || reading.Fast != 4 {
+		t.Errorf("reading = %v/%v/%v, want gwei 1.5/2.5/4", reading.Slow, reading.Standard, reading.Fast)
+	}
+}
+
+func TestGasOracleEVMRPCError(t *testing.T) {
+	server := gasOracleServer(t, `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "method not found"}}`)
+
+	if _, err := NewEVMRPCGasSource("avalanche", "avalanche-gas", server.URL).Fetch(context.Background()); err == nil {
+		t.Error("expected an error for a JSON-RPC error response")
+	}
+}
+
+func TestGasCostEstimateUsesNativeTokenPrice(t *testing.T) {
+	// 30 gwei * 65,000 gas = 0.00195 POL at $0.50
+	polygon := gasCostEstimate("polygon", &GasReading{Unit: GasUnitGwei, Standard: 30, NativePriceUSD: 0.5}, TokenPrices{ETH: 3000, MATIC: 0.4})
+	if math.Abs(polygon.EstimatedCostUSD-0.000975) > 1e-9 {
+		t.Errorf("polygon cost = %v, want 0.000975", polygon.EstimatedCostUSD)
+	}
+
+	// Without a reported price, ETH-gas chains use the ETH price
+	base := gasCostEstimate("base", &GasReading{Unit: GasUnitGwei, Standard: 1}, TokenPrices{ETH: 2000})
+	if math.Abs(base.EstimatedCostUSD-0.13) > 1e-9 {
+		t.Errorf("base cost = %v, want 0.13", base.EstimatedCostUSD)
+	}
+
+	// 1,000 micro-lamports/CU * 200,000 CU = 200 lamports priority + 5,000 base
+	// 1,000 micro-lamports/CU * 200,000 CU = 200 lamports priority + 5,000 base, at the fetched SOL price
+	solana := gasCostEstimate("solana", &GasReading{Unit: GasUnitMicroLamports, Standard: 1000}, TokenPrices{ETH: 2000, SOL: 100})
+	if math.Abs(solana.GasPrice-0.0000052) > 1e-12 || math.Abs(solana.EstimatedCostUSD-0.00052) > 1e-9 {
+		t.Errorf("solana = %+v, want 0.0000052 SOL costing $0.00052", solana)
+	}
+
+	// Without a reported price, Polygon uses the fetched MATIC price
+	polygon = gasCostEstimate("polygon", &GasReading{Unit: GasUnitGwei, Standard: 30}, TokenPrices{ETH: 3000, MATIC: 0.4})
+	if math.Abs(polygon.EstimatedCostUSD-0.00078) > 1e-9 {
+		t.Errorf("polygon cost = %v, want 0.00078", polygon.EstimatedCostUSD)
+	}
+
+	// Avalanche gas is paid in AVAX: 2 gwei * 65,000 gas = 0.00013 AVAX at $20
+	avalanche := gasCostEstimate("avalanche", &GasReading{Unit: GasUnitGwei, Standard: 2}, TokenPrices{ETH: 3000, AVAX: 20})
+	if math.Abs(avalanche.EstimatedCostUSD-0.0026) > 1e-9 {
+		t.Errorf("avalanche cost = %v, want 0.0026", avalanche.EstimatedCostUSD)
+	}
+}
+
+func TestTokenPriceSource(t *testing.T) {
+	server := gasOracleServer(t, `{"ethereum": {"usd": 3100.5, "eur": 2850.1}, "solana": {"usd": 142.25, "eur": 130.9}, "matic-network": {"usd": 0.41, "eur": 0.38}, "avalanche-2": {"usd": 21.7, "eur": 20.0}}`)
+
+	source := NewTokenPriceSource()
+	source.HTTPDataSource = NewHTTPDataSource("token-prices", server.URL, time.Second)
+	data, err := source.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	prices := data.(*TokenPrices)
+	want := TokenPrices{ETH: 3100.5, SOL: 142.25, MATIC: 0.41, AVAX: 21.7}
+	if *prices != want {
+		t.Errorf("prices = %+v, want %+v", *prices, want)
+	}
+}
+
+func TestWithFallbackPricesFillsMissingTokens(t *testing.T) {
+	prices := withFallbackPrices(TokenPrices{ETH: 3100})
+
+	want := TokenPrices{ETH: 3100, SOL: solFallbackPriceUSD, MATIC: maticFallbackPriceUSD, AVAX: avaxFallbackPriceUSD}
+	if prices != want {
+		t.Errorf("prices = %+v, want %+v", prices, want)
+	}
+}
@@ -0,0 +1,52 @@
+package fees
+
+import (
+	"context"
+
+	"crypto-conversion/internal/corridors"
+)
+
+// FeeEstimate is the fee a payment on a corridor would be charged, returned by GET /fees/estimate
+// It is priced like the payment would be, without a model call, so it can be shown instantly.
+type FeeEstimate struct {
+	CorridorID      string            `json:"corridor_id"`
+	Amount          int64             `json:"amount"`       // Source minor units
+	FeeCurrency     string            `json:"fee_currency"` // The source currency
+	Tier            corridors.FeeTier `json:"tier"`         // Schedule band the amount falls in
+	ScheduleID      string            `json:"schedule_id,omitempty"`
+	ScheduleVersion int64             `json:"schedule_version,omitempty"`
+	VolumeTier      *VolumeTier       `json:"volume_tier,omitempty"`      // Volume tier whose rate applied
+	PricingCustomer string            `json:"pricing_customer,omitempty"` // Customer whose negotiated pricing applied
+	FeeLimit        string            `json:"fee_limit,omitempty"`        // FeeLimitMinimum or FeeLimitMaximum when the corridor's limit applied
+	PlatformFee     int64             `json:"platform_fee"`
+	Surcharges      []SurchargeFee    `json:"surcharges,omitempty"`
+	FeeAmount       int64             `json:"fee_amount"` // Platform fee plus surcharges
+	TotalAmount     int64             `json:"total_amount"`
+	EffectiveRate   float64           `json:"effective_rate"` // FeeAmount over Amount, e.g. 0.021 for 2.1%
+}
+
+// Estimate prices amount on a corridor as CalculateCorridorFee would price a payment for customers
+func (c *Calculator) Estimate(ctx context.Context, corridor corridors.Corridor, amount int64, customers ...string) *FeeEstimate {
+	tiers, _ := c.corridorSchedule(ctx, corridor)
+	result := c.CalculateCorridorFee(ctx, corridor, amount, customers...)
+
+	estimate := &FeeEstimate{
+		CorridorID:      corridor.ID,
+		Amount:          amount,
+		FeeCurrency:     result.FeeCurrency,
+		Tier:            tiers.Tier(amount),
+		ScheduleID:      result.ScheduleID,
+		ScheduleVersion: result.ScheduleVersion,
+		VolumeTier:      result.VolumeTier,
+		PricingCustomer: result.PricingCustomer,
+		FeeLimit:        result.FeeLimit,
+		PlatformFee:     result.PlatformFee(),
+		Surcharges:      result.Surcharges,
+		FeeAmount:       result.FeeAmount,
+		TotalAmount:     result.TotalAmount,
+	}
+	if amount > 0 {
+		estimate.EffectiveRate = float64(result.FeeAmount) / float64(amount)
+	}
+	return estimate
+}
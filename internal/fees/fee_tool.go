@@ -0,0 +1,119 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// feeToolName is the tool the model is required to call with its fee recommendation
+const feeToolName = "record_fee_recommendation"
+
+// ClaudeTool describes a tool Claude may call, with a JSON Schema for its input
+type ClaudeTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// ClaudeToolChoice forces Claude to answer by calling a specific tool
+type ClaudeToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// schemaObject builds a JSON Schema object with every property required
+func schemaObject(properties map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func schemaType(kind, description string) map[string]interface{} {
+	schema := map[string]interface{}{"type": kind}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
+
+// feeTool returns the tool whose input schema mirrors AIFeeResponse
+func feeTool() ClaudeTool {
+	amount := func(description string) map[string]interface{} {
+		schema := schemaType("integer", description)
+		schema["minimum"] = 0
+		return schema
+	}
+
+	return ClaudeTool{
+		Name:        feeToolName,
+		Description: "Record the fee and routing recommendation for the payment. All amounts are in minor units of the source currency, e.g. cents for USD.",
+		InputSchema: schemaObject(map[string]interface{}{
+			"total_fee": amount("Sum of every fee_breakdown component"),
+			"fee_breakdown": schemaObject(map[string]interface{}{
+				"platform_fee": amount(""),
+				"onramp_fee":   amount(""),
+				"offramp_fee":  amount(""),
+				"gas_cost":     amount(""),
+				"risk_premium": amount(""),
+			}),
+			"recommended_provider": schemaObject(map[string]interface{}{
+				"onramp":    schemaType("string", ""),
+				"offramp":   schemaType("string", ""),
+				"chain":     schemaType("string", "Blockchain to move USDC on"),
+				"reasoning": schemaType("string", "2-3 sentences explaining why this chain is optimal"),
+			}),
+			"fee_explanation":           schemaType("string", "2-3 sentences explaining the total fee calculation"),
+			"estimated_settlement_time": schemaType("string", "Human readable time, e.g. 3-5 minutes"),
+			"confidence_score": map[string]interface{}{
+				"type":    "number",
+				"minimum": 0,
+				"maximum": 1,
+			},
+			"risk_factors": map[string]interface{}{
+				"type":  "array",
+				"items": schemaType("string", ""),
+			},
+		}),
+	}
+}
+
+// parseFeeToolCall extracts the fee recommendation from the model's tool call
+func (a *AIFeeCalculator) parseFeeToolCall(resp *LLMResponse) (*AIFeeResponse, error) {
+	if resp.ToolName != feeToolName {
+		return nil, fmt.Errorf("no %s tool call in response (stop reason %q)", feeToolName, resp.StopReason)
+	}
+
+	var feeResp AIFeeResponse
+	if err := json.Unmarshal(resp.ToolInput, &feeResp); err != nil {
+		return nil, fmt.Errorf("failed to decode tool input: %w", err)
+	}
+	if err := validateFeeResponse(&feeResp); err != nil {
+		return nil, err
+	}
+	return &feeResp, nil
+}
+
+// validateFeeResponse checks the invariants the schema can't express
+func validateFeeResponse(resp *AIFeeResponse) error {
+	b := resp.FeeBreakdown
+	if b.PlatformFee < 0 || b.OnrampFee < 0 || b.OfframpFee < 0 || b.GasCost < 0 || b.RiskPremium < 0 {
+		return fmt.Errorf("invalid fee breakdown: negative component")
+	}
+	if sum := b.PlatformFee + b.OnrampFee + b.OfframpFee + b.GasCost + b.RiskPremium; resp.TotalFee != sum {
+		return fmt.Errorf("invalid fee response: total_fee %d does not match breakdown sum %d", resp.TotalFee, sum)
+	}
+	if resp.ConfidenceScore < 0 || resp.ConfidenceScore > 1 {
+		return fmt.Errorf("invalid fee response: confidence_score %v", resp.ConfidenceScore)
+	}
+	if resp.Provider.Chain == "" {
+		return fmt.Errorf("invalid fee response: no chain recommended")
+	}
+	return nil
+}
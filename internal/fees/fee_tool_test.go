@@ -0,0 +1,76 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const validFeeToolInput = `{
+	"total_fee": 3250,
+	"fee_breakdown": {"platform_fee": 2000, "onramp_fee": 700, "offramp_fee": 500, "gas_cost": 50, "risk_premium": 0},
+	"recommended_provider": {"onramp": "Circle", "offramp": "Circle", "chain": "Base", "reasoning": "Cheapest L2."},
+	"fee_explanation": "Standard fees.",
+	"estimated_settlement_time": "3-5 minutes",
+	"confidence_score": 0.9,
+	"risk_factors": []
+}`
+
+// toolResponse builds a normalized Claude response whose content is a single block
+func toolResponse(t *testing.T, block string) *LLMResponse {
+	var resp ClaudeResponse
+	if err := json.Unmarshal([]byte(`{"stop_reason":"tool_use","content":[`+block+`]}`), &resp); err != nil {
+		t.Fatalf("bad fixture: %v", err)
+	}
+	return resp.llmResponse()
+}
+
+func TestParseFeeToolCall(t *testing.T) {
+	calc := NewAIFeeCalculator(nil, nil, nil, nil, DefaultLLMConfig())
+
+	resp, err := calc.parseFeeToolCall(toolResponse(t, `{"type":"tool_use","name":"`+feeToolName+`","input":`+validFeeToolInput+`}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.TotalFee != 3250 || resp.Provider.Chain != "Base" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if _, err := calc.parseFeeToolCall(toolResponse(t, `{"type":"text","text":`+mustQuote(validFeeToolInput)+`}`)); err == nil {
+		t.Error("free-text JSON should be rejected")
+	}
+
+	mismatched := `{"type":"tool_use","name":"` + feeToolName + `","input":{"total_fee": 1, "fee_breakdown": {"platform_fee": 2000}, "recommended_provider": {"chain": "Base"}}}`
+	if _, err := calc.parseFeeToolCall(toolResponse(t, mismatched)); err == nil {
+		t.Error("total that doesn't match the breakdown should be rejected")
+	}
+}
+
+func TestClaudeRequestForcesFeeTool(t *testing.T) {
+	var body ClaudeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"content":[]}`))
+	}))
+	defer server.Close()
+
+	client := newAnthropicClient("test-key", DefaultLLMConfig())
+	client.apiURL = server.URL
+	if _, err := client.Complete(context.Background(), "system", "user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if body.ToolChoice == nil || body.ToolChoice.Type != "tool" || body.ToolChoice.Name != feeToolName {
+		t.Errorf("expected tool_choice forcing %s, got %+v", feeToolName, body.ToolChoice)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].InputSchema["type"] != "object" {
+		t.Errorf("expected the fee tool schema, got %+v", body.Tools)
+	}
+}
+
+func mustQuote(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -0,0 +1,156 @@
+package fees
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// gasTrendThreshold is how far the 1h average must move from the 24h average to count as a trend
+const gasTrendThreshold = 0.10
+
+// Gas trend directions
+const (
+	GasTrendRising  = "rising"
+	GasTrendFalling = "falling"
+	GasTrendStable  = "stable"
+	GasTrendUnknown = "unknown" // No readings in the last hour
+)
+
+// GasHistory stores gas price snapshots so routing and pricing can use averages instead of one reading
+// Satisfied by database.GasPriceRepository.
+type GasHistory interface {
+	RecordGasPrice(ctx context.Context, snapshot *models.GasSnapshot) error
+	ListGasPrices(ctx context.Context, chain string, since time.Time) ([]*models.GasSnapshot, error)
+}
+
+// GasTrend summarizes a chain's recorded gas prices over the last hour and day
+type GasTrend struct {
+	Chain          string  `json:"chain"`
+	AvgGasPrice1h  float64 `json:"avg_gas_price_1h"`
+	AvgGasPrice24h float64 `json:"avg_gas_price_24h"`
+	AvgCostUSD1h   float64 `json:"avg_cost_usd_1h"`
+	AvgCostUSD24h  float64 `json:"avg_cost_usd_24h"`
+	Samples1h      int     `json:"samples_1h"`
+	Samples24h     int     `json:"samples_24h"`
+	Direction      string  `json:"direction"` // "rising", "falling", "stable" or "unknown"
+}
+
+// GasTrendReport lists the gas trend of every monitored chain
+type GasTrendReport struct {
+	GeneratedAt time.Time   `json:"generated_at"`
+	Trends      []*GasTrend `json:"trends"`
+}
+
+// SetGasHistory records every fresh gas reading to history and adds trends to gathered gas costs
+func (r *RealDataProvider) SetGasHistory(history GasHistory) {
+	r.gasHistory = history
+}
+
+// GasTrend returns chain's 1h and 24h gas averages and trend direction
+// Returns nil, nil when gas history isn't configured.
+func (r *RealDataProvider) GasTrend(ctx context.Context, chain string) (*GasTrend, error) {
+	if r.gasHistory == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	snapshots, err := r.gasHistory.ListGasPrices(ctx, chain, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	return buildGasTrend(chain, snapshots, now), nil
+}
+
+// GasTrends returns the trend of every chain with a gas oracle, ordered by chain
+// Returns nil, nil when gas history isn't configured.
+func (r *RealDataProvider) GasTrends(ctx context.Context) ([]*GasTrend, error) {
+	if r.gasHistory == nil {
+		return nil, nil
+	}
+
+	chains := make([]string, 0, len(r.gasSources))
+	for chain := range r.gasSources {
+		chains = append(chains, chain)
+	}
+	sort.Strings(chains)
+
+	trends := make([]*GasTrend, 0, len(chains))
+	for _, chain := range chains {
+		trend, err := r.GasTrend(ctx, chain)
+		if err != nil {
+			return nil, err
+		}
+		trends = append(trends, trend)
+	}
+	return trends, nil
+}
+
+// recordGasPrice stores a fresh gas reading; failures are logged so pricing never waits on history
+func (r *RealDataProvider) recordGasPrice(ctx context.Context, estimate GasCostEstimate, fetchedAt time.Time) {
+	if r.gasHistory == nil {
+		return
+	}
+
+	snapshot := &models.GasSnapshot{
+		Chain:      estimate.Chain,
+		RecordedAt: fetchedAt,
+		GasPrice:   estimate.GasPrice,
+		CostUSD:    estimate.EstimatedCostUSD,
+		TTL:        fetchedAt.Add(models.GasHistoryRetention).Unix(),
+	}
+	if err := r.gasHistory.RecordGasPrice(ctx, snapshot); err != nil {
+		logger.Warn("Failed to record gas price history", logger.Fields{
+			"chain": estimate.Chain,
+			"error": err.Error(),
+		})
+	}
+}
+
+// buildGasTrend averages snapshots over the hour and day before now
+func buildGasTrend(chain string, snapshots []*models.GasSnapshot, now time.Time) *GasTrend {
+	trend := &GasTrend{Chain: chain, Direction: GasTrendUnknown}
+	hourAgo := now.Add(-time.Hour)
+
+	for _, snapshot := range snapshots {
+		trend.Samples24h++
+		trend.AvgGasPrice24h += snapshot.GasPrice
+		trend.AvgCostUSD24h += snapshot.CostUSD
+		if !snapshot.RecordedAt.Before(hourAgo) {
+			trend.Samples1h++
+			trend.AvgGasPrice1h += snapshot.GasPrice
+			trend.AvgCostUSD1h += snapshot.CostUSD
+		}
+	}
+	if trend.Samples24h > 0 {
+		trend.AvgGasPrice24h /= float64(trend.Samples24h)
+		trend.AvgCostUSD24h /= float64(trend.Samples24h)
+	}
+	if trend.Samples1h == 0 {
+		return trend
+	}
+	trend.AvgGasPrice1h /= float64(trend.Samples1h)
+	trend.AvgCostUSD1h /= float64(trend.Samples1h)
+
+	switch {
+	case trend.AvgGasPrice1h > trend.AvgGasPrice24h*(1+gasTrendThreshold):
+		trend.Direction = GasTrendRising
+	case trend.AvgGasPrice1h < trend.AvgGasPrice24h*(1-gasTrendThreshold):
+		trend.Direction = GasTrendFalling
+	default:
+		trend.Direction = GasTrendStable
+	}
+	return trend
+}
+
+// routingCostUSD is the gas cost used to compare chains: the 1h average when history has one,
+// so a single spiky reading doesn't flip the route, else the current estimate
+func routingCostUSD(estimate GasCostEstimate) float64 {
+	if estimate.Trend != nil && estimate.Trend.Samples1h > 0 {
+		return estimate.Trend.AvgCostUSD1h
+	}
+	return estimate.EstimatedCostUSD
+}
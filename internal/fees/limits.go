@@ -0,0 +1,39 @@
+package fees
+
+import (
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/logger"
+)
+
+// Fee limits recorded in FeeResult.FeeLimit when the platform fee was clamped
+const (
+	FeeLimitMinimum = "minimum"
+	FeeLimitMaximum = "maximum"
+)
+
+// applyFeeLimits clamps result's fee to the corridor's min_fee and max_fee
+// It runs after negotiated pricing and volume discounts, so neither can take a fee below the floor;
+// promo discounts come off the clamped fee.
+func (c *Calculator) applyFeeLimits(result *FeeResult, corridor corridors.Corridor) *FeeResult {
+	fee := result.FeeAmount
+	switch {
+	case corridor.MinFee > 0 && fee < corridor.MinFee:
+		result.FeeAmount = corridor.MinFee
+		result.FeeLimit = FeeLimitMinimum
+	case corridor.MaxFee > 0 && fee > corridor.MaxFee:
+		result.FeeAmount = corridor.MaxFee
+		result.FeeLimit = FeeLimitMaximum
+	default:
+		return result
+	}
+	result.UnclampedFee = fee
+	result.TotalAmount = result.BaseAmount + result.FeeAmount
+
+	logger.Info("Fee clamped to corridor limit", logger.Fields{
+		"corridor":      corridor.ID,
+		"limit":         result.FeeLimit,
+		"unclamped_fee": fee,
+		"fee_amount":    result.FeeAmount,
+	})
+	return result
+}
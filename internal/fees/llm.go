@@ -0,0 +1,92 @@
+package fees
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// LLM providers
+const (
+	ProviderAnthropic = "anthropic" // Anthropic Messages API
+	ProviderBedrock   = "bedrock"   // Claude on AWS Bedrock, authorized by the Lambda role
+	ProviderOpenAI    = "openai"    // OpenAI Chat Completions API
+)
+
+// LLMClient sends the fee prompts to a model that is required to answer with the fee tool
+type LLMClient interface {
+	Complete(ctx context.Context, systemPrompt, userPrompt string) (*LLMResponse, error)
+}
+
+// LLMResponse is a model's reply, normalized across providers
+type LLMResponse struct {
+	Model        string
+	ToolName     string          // Tool the model called, or "" if it answered in text
+	ToolInput    json.RawMessage // Arguments of that tool call
+	StopReason   string
+	InputTokens  int
+	OutputTokens int
+}
+
+// NewLLMClient creates the client for config.Provider
+// apiKey is required for the Anthropic and OpenAI APIs and ignored for Bedrock.
+func NewLLMClient(config LLMConfig, apiKey string) (LLMClient, error) {
+	switch config.Provider {
+	case ProviderAnthropic, "":
+		if apiKey == "" {
+			return nil, fmt.Errorf("anthropic provider requires an API key")
+		}
+		return newAnthropicClient(apiKey, config), nil
+	case ProviderBedrock:
+		return newBedrockClient(config)
+	case ProviderOpenAI:
+		if apiKey == "" {
+			return nil, fmt.Errorf("openai provider requires an API key")
+		}
+		return newOpenAIClient(apiKey, config), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", config.Provider)
+	}
+}
+
+// postJSON sends body to url and decodes a 200 response into out
+// Other statuses are returned as llmStatusError so they can be retried.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &llmStatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("retry-after")),
+			Body:       string(respBody),
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
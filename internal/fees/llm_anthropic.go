@@ -0,0 +1,118 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"crypto-conversion/internal/tracing"
+)
+
+const claudeAPIURL = "https://api.anthropic.com/v1/messages"
+
+// ClaudeRequest represents the API request to Claude
+type ClaudeRequest struct {
+	Model       string            `json:"model,omitempty"` // Omitted on Bedrock, where the model is in the URL
+	MaxTokens   int               `json:"max_tokens"`
+	Messages    []ClaudeMessage   `json:"messages"`
+	System      string            `json:"system,omitempty"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	Tools       []ClaudeTool      `json:"tools,omitempty"`
+	ToolChoice  *ClaudeToolChoice `json:"tool_choice,omitempty"`
+}
+
+// ClaudeMessage represents a message in the conversation
+type ClaudeMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ClaudeResponse represents the API response from Claude
+type ClaudeResponse struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text,omitempty"`
+		Name  string          `json:"name,omitempty"`  // Tool name on tool_use blocks
+		Input json.RawMessage `json:"input,omitempty"` // Tool input on tool_use blocks
+	} `json:"content"`
+	Model      string `json:"model"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicClient calls Claude through the Anthropic Messages API
+type anthropicClient struct {
+	apiKey     string
+	apiURL     string
+	httpClient *http.Client
+	config     LLMConfig
+}
+
+func newAnthropicClient(apiKey string, config LLMConfig) *anthropicClient {
+	return &anthropicClient{
+		apiKey: apiKey,
+		apiURL: claudeAPIURL,
+		httpClient: tracing.HTTPClient(&http.Client{
+			Timeout: config.Timeout,
+		}),
+		config: config,
+	}
+}
+
+// Complete makes the HTTP request to the Claude API
+func (c *anthropicClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (*LLMResponse, error) {
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": "2023-06-01",
+	}
+
+	var claudeResp ClaudeResponse
+	if err := postJSON(ctx, c.httpClient, c.apiURL, headers, claudeRequest(c.config, systemPrompt, userPrompt), &claudeResp); err != nil {
+		return nil, err
+	}
+	return claudeResp.llmResponse(), nil
+}
+
+// claudeRequest builds the Messages API request body, shared by the Anthropic and Bedrock clients
+func claudeRequest(config LLMConfig, systemPrompt, userPrompt string) ClaudeRequest {
+	temperature := config.Temperature
+	return ClaudeRequest{
+		Model:       config.Model,
+		MaxTokens:   config.MaxTokens,
+		Temperature: &temperature,
+		System:      systemPrompt,
+		Messages: []ClaudeMessage{
+			{
+				Role:    "user",
+				Content: userPrompt,
+			},
+		},
+		// Forcing the fee tool makes Claude return schema-shaped input instead of free text
+		Tools:      []ClaudeTool{feeTool()},
+		ToolChoice: &ClaudeToolChoice{Type: "tool", Name: feeToolName},
+	}
+}
+
+// llmResponse normalizes the response around its first tool_use block
+func (r *ClaudeResponse) llmResponse() *LLMResponse {
+	resp := &LLMResponse{
+		Model:        r.Model,
+		StopReason:   r.StopReason,
+		InputTokens:  r.Usage.InputTokens,
+		OutputTokens: r.Usage.OutputTokens,
+	}
+	for _, block := range r.Content {
+		if block.Type == "tool_use" {
+			resp.ToolName = block.Name
+			resp.ToolInput = block.Input
+			break
+		}
+	}
+	return resp
+}
@@ -0,0 +1,54 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClaudeRequestUsesConfiguredModel(t *testing.T) {
+	var body ClaudeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"content":[]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultLLMConfig()
+	config.Model = "claude-haiku-4-5"
+	config.MaxTokens = 1024
+	config.Temperature = 0.2
+
+	client := newAnthropicClient("test-key", config)
+	client.apiURL = server.URL
+	if _, err := client.Complete(context.Background(), "system", "user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if body.Model != "claude-haiku-4-5" || body.MaxTokens != 1024 {
+		t.Errorf("unexpected model settings: %s, %d", body.Model, body.MaxTokens)
+	}
+	if body.Temperature == nil || *body.Temperature != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", body.Temperature)
+	}
+}
+
+func TestEstimateCostByModelFamily(t *testing.T) {
+	cases := map[string]float64{
+		"claude-sonnet-4-20250514":                   3.00 + 15.00,
+		"claude-opus-4-1":                            15.00 + 75.00,
+		"claude-haiku-4-5":                           1.00 + 5.00,
+		"us.anthropic.claude-sonnet-4-20250514-v1:0": 3.00 + 15.00,
+		"gpt-4o-2024-08-06":                          2.50 + 10.00,
+		"gpt-4o-mini":                                0.15 + 0.60,
+		"unknown-model":                              3.00 + 15.00,
+	}
+	for model, want := range cases {
+		if got := estimateCost(model, 1e6, 1e6); math.Abs(got-want) > 1e-9 {
+			t.Errorf("estimateCost(%s) = %v, want %v", model, got, want)
+		}
+	}
+}
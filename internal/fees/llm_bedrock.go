@@ -0,0 +1,82 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/bedrockruntime"
+	"crypto-conversion/internal/tracing"
+)
+
+// bedrockAnthropicVersion is the Messages API version Bedrock expects in the request body
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// bedrockRequest is the Messages API body for Claude on Bedrock
+type bedrockRequest struct {
+	AnthropicVersion string `json:"anthropic_version"`
+	ClaudeRequest
+}
+
+// bedrockClient calls Claude through AWS Bedrock, so no third-party API key leaves AWS
+type bedrockClient struct {
+	svc    *bedrockruntime.BedrockRuntime
+	config LLMConfig
+}
+
+func newBedrockClient(config LLMConfig) (*bedrockClient, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:     aws.String(config.Region),
+		HTTPClient: &http.Client{Timeout: config.Timeout},
+		MaxRetries: aws.Int(0), // completeWithRetry owns retries
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bedrockClient{
+		svc:    bedrockruntime.New(tracing.AWSSession(sess)),
+		config: config,
+	}, nil
+}
+
+// Complete invokes the configured Bedrock model or inference profile
+func (c *bedrockClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (*LLMResponse, error) {
+	body := bedrockRequest{
+		AnthropicVersion: bedrockAnthropicVersion,
+		ClaudeRequest:    claudeRequest(c.config, systemPrompt, userPrompt),
+	}
+	body.Model = ""
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	output, err := c.svc.InvokeModelWithContext(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(c.config.Model),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        jsonData,
+	})
+	if err != nil {
+		// Surface throttling and service errors by status so they are retried like the HTTP providers
+		if reqErr, ok := err.(awserr.RequestFailure); ok {
+			return nil, &llmStatusError{StatusCode: reqErr.StatusCode(), Body: reqErr.Message()}
+		}
+		return nil, fmt.Errorf("bedrock request failed: %w", err)
+	}
+
+	var claudeResp ClaudeResponse
+	if err := json.Unmarshal(output.Body, &claudeResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if claudeResp.Model == "" {
+		claudeResp.Model = c.config.Model
+	}
+	return claudeResp.llmResponse(), nil
+}
@@ -0,0 +1,134 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"crypto-conversion/internal/tracing"
+)
+
+const openAIAPIURL = "https://api.openai.com/v1/chat/completions"
+
+// OpenAIRequest represents a Chat Completions request
+type OpenAIRequest struct {
+	Model               string            `json:"model"`
+	MaxCompletionTokens int               `json:"max_completion_tokens"`
+	Temperature         *float64          `json:"temperature,omitempty"`
+	Messages            []ClaudeMessage   `json:"messages"`
+	Tools               []OpenAITool      `json:"tools,omitempty"`
+	ToolChoice          *OpenAIToolChoice `json:"tool_choice,omitempty"`
+}
+
+// OpenAITool describes a function the model may call
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction is a function name with a JSON Schema for its arguments
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// OpenAIToolChoice forces the model to call a specific function
+type OpenAIToolChoice struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIResponse represents a Chat Completions response
+type OpenAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+		Message      struct {
+			ToolCalls []struct {
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"` // JSON-encoded arguments
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIClient calls an OpenAI chat model with the fee tool as a forced function call
+type openAIClient struct {
+	apiKey     string
+	apiURL     string
+	httpClient *http.Client
+	config     LLMConfig
+}
+
+func newOpenAIClient(apiKey string, config LLMConfig) *openAIClient {
+	return &openAIClient{
+		apiKey: apiKey,
+		apiURL: openAIAPIURL,
+		httpClient: tracing.HTTPClient(&http.Client{
+			Timeout: config.Timeout,
+		}),
+		config: config,
+	}
+}
+
+// Complete makes the HTTP request to the Chat Completions API
+func (c *openAIClient) Complete(ctx context.Context, systemPrompt, userPrompt string) (*LLMResponse, error) {
+	tool := feeTool()
+	temperature := c.config.Temperature
+	reqBody := OpenAIRequest{
+		Model:               c.config.Model,
+		MaxCompletionTokens: c.config.MaxTokens,
+		Temperature:         &temperature,
+		Messages: []ClaudeMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Tools: []OpenAITool{
+			{
+				Type: "function",
+				Function: OpenAIFunction{
+					Name:        tool.Name,
+					Description: tool.Description,
+					Parameters:  tool.InputSchema,
+				},
+			},
+		},
+		ToolChoice: &OpenAIToolChoice{Type: "function", Function: OpenAIFunction{Name: tool.Name}},
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + c.apiKey}
+
+	var openAIResp OpenAIResponse
+	if err := postJSON(ctx, c.httpClient, c.apiURL, headers, reqBody, &openAIResp); err != nil {
+		return nil, err
+	}
+	return openAIResp.llmResponse(), nil
+}
+
+// llmResponse normalizes the response around the first choice's first tool call
+func (r *OpenAIResponse) llmResponse() *LLMResponse {
+	resp := &LLMResponse{
+		Model:        r.Model,
+		InputTokens:  r.Usage.PromptTokens,
+		OutputTokens: r.Usage.CompletionTokens,
+	}
+	if len(r.Choices) == 0 {
+		return resp
+	}
+
+	choice := r.Choices[0]
+	resp.StopReason = choice.FinishReason
+	if len(choice.Message.ToolCalls) > 0 {
+		call := choice.Message.ToolCalls[0].Function
+		resp.ToolName = call.Name
+		resp.ToolInput = json.RawMessage(call.Arguments)
+	}
+	return resp
+}
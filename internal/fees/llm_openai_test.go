@@ -0,0 +1,74 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClientForcesFeeFunction(t *testing.T) {
+	var body OpenAIRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{
+			"model": "gpt-4o-2024-08-06",
+			"choices": [{"finish_reason": "stop", "message": {"tool_calls": [{"function": {"name": "` + feeToolName + `", "arguments": ` + mustQuote(validFeeToolInput) + `}}]}}],
+			"usage": {"prompt_tokens": 1200, "completion_tokens": 300}
+		}`))
+	}))
+	defer server.Close()
+
+	config := DefaultLLMConfig()
+	config.Provider = ProviderOpenAI
+	config.Model = "gpt-4o"
+	client := newOpenAIClient("test-key", config)
+	client.apiURL = server.URL
+
+	resp, err := client.Complete(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if auth != "Bearer test-key" {
+		t.Errorf("unexpected Authorization header %q", auth)
+	}
+	if body.Model != "gpt-4o" || len(body.Messages) != 2 || body.Messages[0].Role != "system" {
+		t.Errorf("unexpected request: %+v", body)
+	}
+	if body.ToolChoice == nil || body.ToolChoice.Function.Name != feeToolName || len(body.Tools) != 1 {
+		t.Errorf("expected tool_choice forcing %s, got %+v", feeToolName, body.ToolChoice)
+	}
+	if resp.InputTokens != 1200 || resp.OutputTokens != 300 {
+		t.Errorf("unexpected usage: %+v", resp)
+	}
+
+	feeResp, err := NewAIFeeCalculator(client, nil, nil, nil, config).parseFeeToolCall(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if feeResp.TotalFee != 3250 {
+		t.Errorf("unexpected fee response: %+v", feeResp)
+	}
+}
+
+func TestNewLLMClient(t *testing.T) {
+	config := DefaultLLMConfig()
+	if _, err := NewLLMClient(config, ""); err == nil {
+		t.Error("anthropic without an API key should be rejected")
+	}
+
+	config.Provider = ProviderBedrock
+	config.Region = "us-east-1"
+	if _, err := NewLLMClient(config, ""); err != nil {
+		t.Errorf("bedrock needs no API key: %v", err)
+	}
+
+	config.Provider = "palm"
+	if _, err := NewLLMClient(config, "key"); err == nil {
+		t.Error("unknown provider should be rejected")
+	}
+}
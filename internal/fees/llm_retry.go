@@ -0,0 +1,173 @@
+package fees
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+)
+
+// LLMRetryConfig controls retries and concurrency for model calls
+type LLMRetryConfig struct {
+	MaxAttempts    int           // Total attempts per fee request, including the first
+	InitialBackoff time.Duration // Upper bound of the first jittered delay
+	MaxBackoff     time.Duration // Upper bound of any single delay, including retry-after
+	MaxConcurrency int           // Concurrent model calls allowed per Lambda instance (0 = unlimited)
+}
+
+// DefaultLLMRetryConfig makes up to 3 attempts with jittered backoff and 4 concurrent calls
+func DefaultLLMRetryConfig() LLMRetryConfig {
+	return LLMRetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     8 * time.Second,
+		MaxConcurrency: 4,
+	}
+}
+
+// llmStatusError is a failed response from a model provider, carrying its HTTP status
+type llmStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the retry-after header, if present
+	Body       string
+}
+
+func (e *llmStatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether a failed call is worth repeating: rate limits, overload, 5xx, and timeouts
+func (e *llmStatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// retryReason classifies err for retry decisions and metrics, or returns "" if it is not retryable
+// A cancelled or expired caller context is never retried.
+func retryReason(ctx context.Context, err error) string {
+	if ctx.Err() != nil {
+		return ""
+	}
+
+	var statusErr *llmStatusError
+	if stderrors.As(err, &statusErr) {
+		if !statusErr.retryable() {
+			return ""
+		}
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			return "rate_limited"
+		}
+		return "server_error"
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return ""
+}
+
+// parseRetryAfter reads a retry-after header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// backoff returns the delay before the given retry (1-based)
+// The server's retry-after wins when present; otherwise the delay is full jitter over an
+// exponentially growing window. Both are capped at MaxBackoff.
+func (c LLMRetryConfig) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return minDuration(retryAfter, c.MaxBackoff)
+	}
+	window := float64(c.InitialBackoff) * math.Pow(2, float64(retry-1))
+	delay := time.Duration(rand.Float64() * window)
+	return minDuration(delay, c.MaxBackoff)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if b > 0 && a > b {
+		return b
+	}
+	return a
+}
+
+// acquire waits for a model call slot, returning a release func
+func (a *AIFeeCalculator) acquire(ctx context.Context) (func(), error) {
+	if a.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case a.slots <- struct{}{}:
+		return func() { <-a.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// completeWithRetry calls the model, retrying transient failures with backoff
+func (a *AIFeeCalculator) completeWithRetry(ctx context.Context, systemPrompt, userPrompt string) (*LLMResponse, error) {
+	attempts := a.config.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		release, err := a.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := a.llm.Complete(ctx, systemPrompt, userPrompt)
+		release()
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+
+		reason := retryReason(ctx, err)
+		if reason == "" || attempt == attempts {
+			break
+		}
+
+		var retryAfter time.Duration
+		var statusErr *llmStatusError
+		if stderrors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		delay := a.config.Retry.backoff(attempt, retryAfter)
+
+		metrics.Count("AIRetries", metrics.Dimensions{"Reason": reason})
+		logger.Warn("LLM call failed, retrying", logger.Fields{
+			"error":    err.Error(),
+			"reason":   reason,
+			"attempt":  attempt,
+			"delay_ms": delay.Milliseconds(),
+		})
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, lastErr
+		}
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,96 @@
+package fees
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryCalculator points a calculator at server with millisecond backoff
+func testRetryCalculator(server *httptest.Server, attempts int) *AIFeeCalculator {
+	config := DefaultLLMConfig()
+	config.Retry = LLMRetryConfig{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		MaxConcurrency: 1,
+	}
+	client := newAnthropicClient("test-key", config)
+	client.apiURL = server.URL
+	return NewAIFeeCalculator(client, nil, nil, nil, config)
+}
+
+func TestLLMRetryRecoversFromTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("retry-after", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(529) // Overloaded
+		default:
+			w.Write([]byte(`{"id":"msg_1","model":"claude-test","content":[{"type":"text","text":"{}"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := testRetryCalculator(server, 3).completeWithRetry(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if resp.Model != "claude-test" || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("unexpected response %q after %d calls", resp.Model, calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry-after should be capped at MaxBackoff, took %s", elapsed)
+	}
+}
+
+func TestLLMRetryGivesUp(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if _, err := testRetryCalculator(server, 3).completeWithRetry(context.Background(), "system", "user"); err == nil {
+		t.Fatal("expected error once attempts are exhausted")
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestLLMRetrySkipsClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if _, err := testRetryCalculator(server, 3).completeWithRetry(context.Background(), "system", "user"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("400 should not be retried, got %d calls", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("parseRetryAfter(\"2\") = %s", got)
+	}
+	if got := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); got <= 0 || got > time.Minute {
+		t.Errorf("unexpected HTTP-date delay %s", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("parseRetryAfter(\"soon\") = %s", got)
+	}
+}
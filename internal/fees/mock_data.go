@@ -1,6 +1,9 @@
 package fees
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // MockDataProvider provides simulated market data for AI fee calculation
 type MockDataProvider struct{}
@@ -148,8 +151,22 @@ func (m *MockDataProvider) GetFXVolatility(pair string) FXVolatility {
 	}
 }
 
-// GetCountryRisk returns mock country risk data
+// riskCountryNames maps ISO 3166-1 alpha-2 codes, as payments and corridors carry them, to the country risk names
+var riskCountryNames = map[string]string{
+	"DE": "Germany",
+	"BR": "Brazil",
+	"NG": "Nigeria",
+	"SG": "Singapore",
+	"US": "USA",
+	"GB": "UK",
+}
+
+// GetCountryRisk returns mock country risk data, by country name or ISO code
 func (m *MockDataProvider) GetCountryRisk(country string) CountryRisk {
+	if name, ok := riskCountryNames[strings.ToUpper(country)]; ok {
+		country = name
+	}
+
 	riskData := map[string]CountryRisk{
 		"Germany":   {Country: "Germany", RiskScore: 1.0, Tier: "low"},
 		"Brazil":    {Country: "Brazil", RiskScore: 4.5, Tier: "medium-high"},
@@ -0,0 +1,82 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+)
+
+// CustomerPricing is a customer's negotiated platform fee
+// Rate and FixedFee replace the schedule tier's when set; Discount then takes a share off the result.
+type CustomerPricing struct {
+	Rate     *float64 `json:"rate,omitempty"`      // e.g. 0.015 for 1.5%
+	FixedFee *int64   `json:"fixed_fee,omitempty"` // Minor units of the source currency
+	Discount float64  `json:"discount,omitempty"`  // e.g. 0.2 for 20% off the fee
+}
+
+// Validate checks the pricing is usable
+func (p CustomerPricing) Validate() error {
+	switch {
+	case p.Rate == nil && p.FixedFee == nil && p.Discount == 0:
+		return errors.ErrValidation("pricing", "must set rate, fixed_fee or discount")
+	case p.Rate != nil && (*p.Rate < 0 || *p.Rate >= 1):
+		return errors.ErrValidation("rate", "must be at least 0 and below 1")
+	case p.FixedFee != nil && *p.FixedFee < 0:
+		return errors.ErrValidation("fixed_fee", "must not be negative")
+	case p.Discount < 0 || p.Discount > 1:
+		return errors.ErrValidation("discount", "must be between 0 and 1")
+	}
+	return nil
+}
+
+// ParseCustomerPricing parses a JSON object of pricing keyed by API key ID or account
+func ParseCustomerPricing(data []byte) (map[string]CustomerPricing, error) {
+	var pricing map[string]CustomerPricing
+	if err := json.Unmarshal(data, &pricing); err != nil {
+		return nil, fmt.Errorf("invalid customer pricing: %w", err)
+	}
+	for customer, p := range pricing {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("customer pricing %s: %w", customer, err)
+		}
+	}
+	return pricing, nil
+}
+
+// SetCustomerPricing installs negotiated pricing keyed by API key ID or account
+func (c *Calculator) SetCustomerPricing(pricing map[string]CustomerPricing) {
+	c.customers = pricing
+}
+
+// applyCustomerPricing reprices result with the first of customers that has negotiated pricing
+func (c *Calculator) applyCustomerPricing(result *FeeResult, customers []string) *FeeResult {
+	for _, customer := range customers {
+		pricing, ok := c.customers[customer]
+		if customer == "" || !ok {
+			continue
+		}
+
+		if pricing.Rate != nil {
+			result.FeeRate = *pricing.Rate
+		}
+		if pricing.FixedFee != nil {
+			result.FixedFee = *pricing.FixedFee
+		}
+		fee := int64(float64(result.BaseAmount)*result.FeeRate) + result.FixedFee
+		result.Discount = int64(math.Round(float64(fee) * pricing.Discount))
+		result.FeeAmount = fee - result.Discount
+		result.TotalAmount = result.BaseAmount + result.FeeAmount
+		result.PricingCustomer = customer
+
+		logger.Info("Customer pricing applied", logger.Fields{
+			"customer":   customer,
+			"fee_amount": result.FeeAmount,
+			"discount":   result.Discount,
+		})
+		return result
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package fees
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Prompt templates live in prompts/<version>/ as system.tmpl and user.tmpl
+// A version is never edited once deployed: add a new directory instead, so every recorded
+// prompt_version maps to exactly one prompt and rolling back is a config change.
+//
+//go:embed prompts
+var promptFiles embed.FS
+
+// DefaultPromptVersion is the prompt used when none is configured
+const DefaultPromptVersion = "v3"
+
+// promptData is what the templates can reference
+type promptData struct {
+	ToolName           string
+	Amount             string // Formatted in the source currency
+	FromCurrency       string
+	ToCurrency         string
+	DestinationCountry string // ISO 3166 alpha-2
+	OnrampProvider     string
+	OfframpProvider    string
+	PayoutRail         string
+	Chains             string // Comma-separated, preferred first
+	CustomerTier       string
+	Priority           string
+	MarketData         string // Indented JSON market context
+	Now                string // RFC 3339
+}
+
+// promptTemplate is one version of the system and user prompts
+type promptTemplate struct {
+	version string
+	system  *template.Template
+	user    *template.Template
+}
+
+// PromptVersions lists the embedded prompt versions, oldest first
+func PromptVersions() []string {
+	entries, err := promptFiles.ReadDir("prompts")
+	if err != nil {
+		return nil
+	}
+
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// loadPrompt parses the templates for version and checks they render
+func loadPrompt(version string) (*promptTemplate, error) {
+	if version == "" {
+		version = DefaultPromptVersion
+	}
+
+	system, err := parsePromptFile(version, "system")
+	if err != nil {
+		return nil, err
+	}
+	user, err := parsePromptFile(version, "user")
+	if err != nil {
+		return nil, err
+	}
+	prompt := &promptTemplate{version: version, system: system, user: user}
+
+	// Templates reference promptData fields, so a typo only shows up at execution time
+	if _, _, err := prompt.render(promptData{}); err != nil {
+		return nil, fmt.Errorf("prompt version %q does not render: %w", version, err)
+	}
+	return prompt, nil
+}
+
+// parsePromptFile parses prompts/<version>/<name>.tmpl
+func parsePromptFile(version, name string) (*template.Template, error) {
+	file := name + ".tmpl"
+	tmpl, err := template.New(file).ParseFS(promptFiles, "prompts/"+version+"/"+file)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt version %q (have %s): %w", version, strings.Join(PromptVersions(), ", "), err)
+	}
+	return tmpl, nil
+}
+
+// render executes both templates
+func (p *promptTemplate) render(data promptData) (string, string, error) {
+	var system, user bytes.Buffer
+	if err := p.system.Execute(&system, data); err != nil {
+		return "", "", err
+	}
+	if err := p.user.Execute(&user, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimRight(system.String(), "\n"), strings.TrimRight(user.String(), "\n"), nil
+}
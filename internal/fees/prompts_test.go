@@ -0,0 +1,112 @@
+package fees
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadPromptRendersRequest(t *testing.T) {
+	prompt, err := loadPrompt("")
+	if err != nil {
+		t.Fatalf("default prompt should load: %v", err)
+	}
+	if prompt.version != DefaultPromptVersion {
+		t.Errorf("expected %s, got %s", DefaultPromptVersion, prompt.version)
+	}
+
+	system, user, err := prompt.render(promptData{
+		ToolName:     feeToolName,
+		Amount:       "$1,000.00",
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		CustomerTier: "premium",
+		Priority:     "urgent",
+		MarketData:   `{"fx_rate": 0.92}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(system, "calling the "+feeToolName+" tool") {
+		t.Error("system prompt should name the fee tool")
+	}
+	for _, want := range []string{"$1,000.00 USD → EUR", "Customer Tier: premium", "Priority: urgent", `{"fx_rate": 0.92}`} {
+		if !strings.Contains(user, want) {
+			t.Errorf("user prompt missing %q", want)
+		}
+	}
+	if strings.HasSuffix(system, "\n") || strings.HasSuffix(user, "\n") {
+		t.Error("rendered prompts should not end with the template file's newline")
+	}
+}
+
+func TestLoadPromptRendersCorridor(t *testing.T) {
+	prompt, err := loadPrompt("v2")
+	if err != nil {
+		t.Fatalf("v2 prompt should load: %v", err)
+	}
+
+	system, user, err := prompt.render(promptData{
+		ToolName:           feeToolName,
+		Amount:             "$1,000.00",
+		FromCurrency:       "USD",
+		ToCurrency:         "BRL",
+		DestinationCountry: "BR",
+		OnrampProvider:     "Circle",
+		OfframpProvider:    "Bridge",
+		PayoutRail:         "PIX",
+		Chains:             "base, polygon",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"USD → USDC (Circle)", "USDC → BRL (Bridge, paid out over PIX in BR)", "this corridor: base, polygon"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt missing %q", want)
+		}
+	}
+	if strings.Contains(system, "USD→EUR") {
+		t.Error("v2 system prompt should not be tied to USD→EUR")
+	}
+	if !strings.Contains(user, "Destination Country: BR") {
+		t.Error("user prompt should name the destination country")
+	}
+}
+
+func TestLoadPromptCoversAllChains(t *testing.T) {
+	prompt, err := loadPrompt("v3")
+	if err != nil {
+		t.Fatalf("v3 prompt should load: %v", err)
+	}
+
+	system, _, err := prompt.render(promptData{ToolName: feeToolName, Chains: "base, optimism, avalanche"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Optimism (L2)", "Avalanche C-Chain (L1)", "Chain Health", "AVAX"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt missing %q", want)
+		}
+	}
+}
+
+func TestPromptVersions(t *testing.T) {
+	versions := PromptVersions()
+	if len(versions) == 0 || versions[0] != "v1" {
+		t.Errorf("expected embedded v1 prompt, got %v", versions)
+	}
+	for _, version := range versions {
+		if _, err := loadPrompt(version); err != nil {
+			t.Errorf("prompt %s: %v", version, err)
+		}
+	}
+
+	if _, err := loadPrompt("v999"); err == nil {
+		t.Error("unknown version should be rejected")
+	}
+
+	config := DefaultLLMConfig()
+	config.PromptVersion = "v999"
+	if calc := NewAIFeeCalculator(nil, nil, nil, nil, config); calc.prompt.version != DefaultPromptVersion {
+		t.Errorf("unknown version should fall back to %s, got %s", DefaultPromptVersion, calc.prompt.version)
+	}
+}
@@ -4,21 +4,34 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 	"sync"
 	"time"
+
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/fx"
+	"crypto-conversion/internal/logger"
 )
 
 // RealDataProvider fetches live market data for fee optimization
 type RealDataProvider struct {
 	// Data sources
-	gasSources       map[string]*GasPriceSource
-	fxSource         *FXRateSource
+	gasSources       map[string]DataSource // Per-chain oracle adapters returning *GasReading
+	fxRates          *fx.Chain
 	providerSources  map[string]*ProviderStatusSource
-	ethPriceSource   *ETHPriceSource
+	tokenPriceSource *TokenPriceSource
 
 	// Caching
 	cache            *DataCache
+	shared           SharedCache // Optional; shares fetched data across Lambda instances
 	cacheDuration    time.Duration
+
+	// Optional; records gas readings and supplies 1h/24h trends
+	gasHistory       GasHistory
+
+	// Scores corridor chains; reads our recent settlements once SetSettlements is called
+	chainHealth      *chains.Monitor
 }
 
 // DataCache stores fetched data with timestamps
@@ -27,16 +40,16 @@ type DataCache struct {
 	gasData          map[string]*CachedGasData
 	fxData           *CachedFXData
 	providerData     map[string]*CachedProviderData
-	ethPrice         *CachedETHPrice
+	tokenPrices      *CachedTokenPrices
 }
 
 type CachedGasData struct {
-	Data      *GasOracleResponse
+	Data      *GasReading
 	FetchedAt time.Time
 }
 
 type CachedFXData struct {
-	Data      *FXRateResponse
+	Data      *fx.Rates
 	FetchedAt time.Time
 }
 
@@ -45,46 +58,87 @@ type CachedProviderData struct {
 	FetchedAt time.Time
 }
 
-type CachedETHPrice struct {
-	PriceUSD  float64
+type CachedTokenPrices struct {
+	Prices    TokenPrices
 	FetchedAt time.Time
 }
 
 // NewRealDataProvider creates a new real-time data provider
-// Optimized for USD→EUR transfers only
-func NewRealDataProvider() *RealDataProvider {
-	return &RealDataProvider{
-		gasSources: map[string]*GasPriceSource{
-			// Optimal 5 chains for USD→EUR transfers (ordered by typical preference)
-			"base":     NewGasPriceSource("base"),     // #1: Lowest cost (~$0.00), EVM L2, Coinbase-backed
-			"polygon":  NewGasPriceSource("polygon"),  // #2: Very low cost (~$0.001), popular sidechain
-			"arbitrum": NewGasPriceSource("arbitrum"), // #3: Low cost (~$0.01), popular EVM L2
-			"solana":   NewGasPriceSource("solana"),   // #4: Extremely fast & cheap (~$0.0002), non-EVM
-			"ethereum": NewGasPriceSource("ethereum"), // #5: High security, variable cost, most liquid
-		},
-		fxSource: NewFXRateSource("USD"),
-		providerSources: map[string]*ProviderStatusSource{
-			// Only providers that support USD→EUR
-			"circle": NewProviderStatusSource("circle"),
-			// Coinbase removed for now - Circle is primary provider
-		},
-		ethPriceSource: NewETHPriceSource(),
+// fxRates may be nil, in which case exchangerate-api.com with ECB failover is used.
+// shared may be nil, in which case each instance keeps its own cache.
+func NewRealDataProvider(fxRates *fx.Chain, shared SharedCache) *RealDataProvider {
+	if fxRates == nil {
+		fxRates = fx.NewChain(fx.NewExchangeRateAPISource(), fx.NewECBSource())
+	}
+	provider := &RealDataProvider{
+		fxRates: fxRates,
 		cache: &DataCache{
 			gasData:      make(map[string]*CachedGasData),
 			providerData: make(map[string]*CachedProviderData),
 		},
+		shared:        shared,
 		cacheDuration: 2 * time.Minute, // Cache data for 2 minutes to avoid rate limits
+		chainHealth:   chains.NewMonitor(nil),
 	}
+	provider.SetDataSources(DefaultSourceRegistry())
+	return provider
+}
+
+// SetDataSources replaces the gas, status and token price sources with those in registry
+// Call before the first GatherContext; cached readings from the previous sources are kept.
+func (r *RealDataProvider) SetDataSources(registry *SourceRegistry) {
+	r.gasSources = registry.GasSources()
+	r.providerSources = registry.StatusSources()
+	r.tokenPriceSource = registry.TokenPriceSource()
+}
+
+// SetSettlements scores chain health on our own recent settlements as well as live signals
+func (r *RealDataProvider) SetSettlements(source chains.SettlementSource) {
+	r.chainHealth = chains.NewMonitor(source)
 }
 
-// RealMarketContext contains real-time market data for USD→EUR transfers
+// RealMarketContext contains real-time market data for one corridor
 // Only includes data that directly affects fee calculation
 type RealMarketContext struct {
 	Timestamp         time.Time                    `json:"timestamp"`
-	FXRate            float64                      `json:"fx_rate_usd_eur"`       // Current USD/EUR exchange rate
+	Corridor          CorridorContext              `json:"corridor"`
+	FXRate            float64                      `json:"fx_rate"`               // Destination currency per unit of source currency
 	ETHPriceUSD       float64                      `json:"eth_price_usd"`         // ETH price for gas cost calculation
-	GasCosts          map[string]GasCostEstimate   `json:"gas_costs"`             // Gas costs per chain (Ethereum, Base)
-	ProviderStatuses  map[string]ProviderHealth    `json:"provider_statuses"`     // Circle operational status
+	SOLPriceUSD       float64                      `json:"sol_price_usd"`         // SOL price for Solana gas costs
+	MATICPriceUSD     float64                      `json:"matic_price_usd"`       // MATIC price for Polygon gas costs
+	AVAXPriceUSD      float64                      `json:"avax_price_usd"`        // AVAX price for Avalanche gas costs
+	GasCosts          map[string]GasCostEstimate   `json:"gas_costs"`             // Gas costs per corridor chain
+	ProviderStatuses  map[string]ProviderHealth    `json:"provider_statuses"`     // Corridor on-ramp and off-ramp provider status
+	ChainHealth       map[string]*chains.Health    `json:"chain_health"`          // Health score per corridor chain
+}
+
+// CorridorContext describes the route a payment takes, so the prompt isn't tied to one currency pair
+type CorridorContext struct {
+	ID                  string   `json:"id"`
+	SourceCurrency      string   `json:"source_currency"`
+	DestinationCurrency string   `json:"destination_currency"`
+	DestinationCountry  string   `json:"destination_country,omitempty"`
+	OnrampProvider      string   `json:"onramp_provider"`
+	OfframpProvider     string   `json:"offramp_provider"`
+	PayoutRail          string   `json:"payout_rail"`
+	Chains              []string `json:"chains"`
+}
+
+// newCorridorContext describes corridor paying out in country ("" uses the corridor's country)
+func newCorridorContext(corridor corridors.Corridor, country string) CorridorContext {
+	if country == "" {
+		country = corridor.DestinationCountry
+	}
+	return CorridorContext{
+		ID:                  corridor.ID,
+		SourceCurrency:      corridor.SourceCurrency,
+		DestinationCurrency: corridor.DestinationCurrency,
+		DestinationCountry:  country,
+		OnrampProvider:      corridor.OnRampProvider,
+		OfframpProvider:     corridor.OffRampProvider,
+		PayoutRail:          corridor.PayoutRail,
+		Chains:              corridor.Chains,
+	}
 }
 
 // GasCostEstimate shows the cost to transfer on each chain
@@ -93,6 +147,9 @@ type GasCostEstimate struct {
 	GasPrice         float64 `json:"gas_price_gwei"`
 	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
 	Status           string  `json:"status"` // "low", "medium", "high", "very_high"
+	Trend            *GasTrend `json:"trend,omitempty"` // Recorded averages; nil without gas history
+
+	fastGasPrice     float64 // Fast percentile in GasPrice's unit; 0 without a live reading
 }
 
 // ProviderHealth shows operational status of payment providers
@@ -103,12 +160,12 @@ type ProviderHealth struct {
 	Issues        []string `json:"issues,omitempty"`
 }
 
-// GatherContext fetches all real-time data needed for USD→EUR fee calculation
-func (r *RealDataProvider) GatherContext(ctx context.Context) (*RealMarketContext, error) {
+// GatherContext fetches all real-time data needed to price a payment on corridor
+func (r *RealDataProvider) GatherContext(ctx context.Context, corridor CorridorContext) (*RealMarketContext, error) {
 	// Use errgroup for concurrent fetching
 	var (
 		fxRate       float64
-		ethPrice     float64
+		tokenPrices  TokenPrices
 		gasCosts     map[string]GasCostEstimate
 		providerStats map[string]ProviderHealth
 		err          error
@@ -122,33 +179,30 @@ func (r *RealDataProvider) GatherContext(ctx context.Context) (*RealMarketContex
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		rate, fetchErr := r.getFXRate(ctx)
+		rates, fetchErr := r.getFXRates(ctx)
 		if fetchErr != nil {
 			errChan <- fmt.Errorf("FX rate fetch failed: %w", fetchErr)
 			return
 		}
+		rate, rateErr := rates.Rate(corridor.SourceCurrency, corridor.DestinationCurrency)
+		if rateErr != nil {
+			errChan <- fmt.Errorf("FX rate fetch failed: %w", rateErr)
+			return
+		}
 		fxRate = rate
 	}()
 
-	// Fetch ETH price
+	// Fetch gas token prices, then gas costs (priced in each chain's token)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		price, fetchErr := r.getETHPrice(ctx)
+		prices, fetchErr := r.getTokenPrices(ctx)
 		if fetchErr != nil {
-			errChan <- fmt.Errorf("ETH price fetch failed: %w", fetchErr)
+			errChan <- fmt.Errorf("token price fetch failed: %w", fetchErr)
 			return
 		}
-		ethPrice = price
-	}()
-
-	// Fetch gas costs (depends on ETH price, so we'll do it after)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		// Wait a tiny bit for ETH price to be available
-		time.Sleep(100 * time.Millisecond)
-		costs, fetchErr := r.getGasCosts(ctx, ethPrice)
+		tokenPrices = prices
+		costs, fetchErr := r.getGasCosts(ctx, prices, corridor.Chains)
 		if fetchErr != nil {
 			errChan <- fmt.Errorf("gas costs fetch failed: %w", fetchErr)
 			return
@@ -160,7 +214,7 @@ func (r *RealDataProvider) GatherContext(ctx context.Context) (*RealMarketContex
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		stats, fetchErr := r.getProviderStatuses(ctx)
+		stats, fetchErr := r.getProviderStatuses(ctx, corridor.OnrampProvider, corridor.OfframpProvider)
 		if fetchErr != nil {
 			errChan <- fmt.Errorf("provider status fetch failed: %w", fetchErr)
 			return
@@ -183,111 +237,170 @@ func (r *RealDataProvider) GatherContext(ctx context.Context) (*RealMarketContex
 		return nil, err
 	}
 
+	chainSignals := make(map[string]chains.Signals, len(corridor.Chains))
+	for _, chain := range corridor.Chains {
+		chainSignals[chain] = chains.Signals{
+			Gas:            gasSignal(gasCosts[chain]),
+			ProviderStatus: chainProviderStatus(chain, providerStats),
+		}
+	}
+
 	return &RealMarketContext{
 		Timestamp:        time.Now(),
+		Corridor:         corridor,
 		FXRate:           fxRate,
-		ETHPriceUSD:      ethPrice,
+		ETHPriceUSD:      tokenPrices.ETH,
+		SOLPriceUSD:      tokenPrices.SOL,
+		MATICPriceUSD:    tokenPrices.MATIC,
+		AVAXPriceUSD:     tokenPrices.AVAX,
 		GasCosts:         gasCosts,
 		ProviderStatuses: providerStats,
+		ChainHealth:      r.chainHealth.Health(ctx, chainSignals),
 	}, nil
 }
 
-// getFXRate fetches current USD/EUR exchange rate
-func (r *RealDataProvider) getFXRate(ctx context.Context) (float64, error) {
+// getFXRates fetches current USD exchange rates for every currency (EUR, GBP, ...)
+func (r *RealDataProvider) getFXRates(ctx context.Context) (*fx.Rates, error) {
 	// Check cache first
 	r.cache.mu.RLock()
 	if r.cache.fxData != nil && time.Since(r.cache.fxData.FetchedAt) < r.cacheDuration {
-		rate := r.cache.fxData.Data.Rates["EUR"]
+		rates := r.cache.fxData.Data
 		r.cache.mu.RUnlock()
-		return rate, nil
+		return rates, nil
 	}
 	r.cache.mu.RUnlock()
 
-	// Fetch fresh data
-	data, err := r.fxSource.Fetch(ctx)
-	if err != nil {
-		return 0, err
+	// Another instance may have fetched recently
+	var shared CachedFXData
+	if r.loadShared(ctx, cacheKeyFXRates, &shared) && shared.Data != nil && r.fresh(shared.FetchedAt) {
+		r.cache.mu.Lock()
+		r.cache.fxData = &shared
+		r.cache.mu.Unlock()
+		return shared.Data, nil
 	}
 
-	response := data.(*FXRateResponse)
+	// Fetch fresh data, failing over between sources
+	response, err := r.fxRates.Rates(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Cache the result
-	r.cache.mu.Lock()
-	r.cache.fxData = &CachedFXData{
+	cached := &CachedFXData{
 		Data:      response,
 		FetchedAt: time.Now(),
 	}
+	r.cache.mu.Lock()
+	r.cache.fxData = cached
 	r.cache.mu.Unlock()
+	r.storeShared(ctx, cacheKeyFXRates, cached)
 
-	return response.Rates["EUR"], nil
+	return response, nil
 }
 
-// getETHPrice fetches current ETH price in USD
-func (r *RealDataProvider) getETHPrice(ctx context.Context) (float64, error) {
+// getTokenPrices fetches current gas token prices in USD
+// Tokens the source doesn't report fall back to fixed prices, so gas costs are never priced at zero.
+func (r *RealDataProvider) getTokenPrices(ctx context.Context) (TokenPrices, error) {
 	// Check cache first
 	r.cache.mu.RLock()
-	if r.cache.ethPrice != nil && time.Since(r.cache.ethPrice.FetchedAt) < r.cacheDuration {
-		price := r.cache.ethPrice.PriceUSD
+	if r.cache.tokenPrices != nil && time.Since(r.cache.tokenPrices.FetchedAt) < r.cacheDuration {
+		prices := r.cache.tokenPrices.Prices
 		r.cache.mu.RUnlock()
-		return price, nil
+		return prices, nil
 	}
 	r.cache.mu.RUnlock()
 
+	// Another instance may have fetched recently
+	var shared CachedTokenPrices
+	if r.loadShared(ctx, cacheKeyTokenPrices, &shared) && r.fresh(shared.FetchedAt) {
+		r.cache.mu.Lock()
+		r.cache.tokenPrices = &shared
+		r.cache.mu.Unlock()
+		return shared.Prices, nil
+	}
+
 	// Fetch fresh data
-	data, err := r.ethPriceSource.Fetch(ctx)
+	data, err := r.tokenPriceSource.Fetch(ctx)
 	if err != nil {
-		return 0, err
+		return TokenPrices{}, err
 	}
 
-	response := data.(*CoinGeckoResponse)
+	prices := withFallbackPrices(*data.(*TokenPrices))
 
 	// Cache the result
-	r.cache.mu.Lock()
-	r.cache.ethPrice = &CachedETHPrice{
-		PriceUSD:  response.Ethereum.USD,
+	cached := &CachedTokenPrices{
+		Prices:    prices,
 		FetchedAt: time.Now(),
 	}
+	r.cache.mu.Lock()
+	r.cache.tokenPrices = cached
 	r.cache.mu.Unlock()
+	r.storeShared(ctx, cacheKeyTokenPrices, cached)
 
-	return response.Ethereum.USD, nil
+	return prices, nil
 }
 
-// getGasCosts fetches gas prices and calculates USD costs for each chain
-func (r *RealDataProvider) getGasCosts(ctx context.Context, ethPriceUSD float64) (map[string]GasCostEstimate, error) {
-	if ethPriceUSD == 0 {
-		// Fallback if ETH price fetch failed
-		ethPriceUSD = 2000.0
+// withFallbackPrices fills in any token price the source didn't report
+func withFallbackPrices(prices TokenPrices) TokenPrices {
+	fallbacks := []struct {
+		token    string
+		price    *float64
+		fallback float64
+	}{
+		{"ETH", &prices.ETH, ethFallbackPriceUSD},
+		{"SOL", &prices.SOL, solFallbackPriceUSD},
+		{"MATIC", &prices.MATIC, maticFallbackPriceUSD},
+		{"AVAX", &prices.AVAX, avaxFallbackPriceUSD},
+	}
+	for _, f := range fallbacks {
+		if *f.price > 0 {
+			continue
+		}
+		logger.Warn("Token price missing, using fallback", logger.Fields{
+			"token":    f.token,
+			"fallback": f.fallback,
+		})
+		*f.price = f.fallback
 	}
+	return prices
+}
 
+// getGasCosts fetches gas prices and calculates USD costs for each of chains
+func (r *RealDataProvider) getGasCosts(ctx context.Context, prices TokenPrices, chains []string) (map[string]GasCostEstimate, error) {
 	costs := make(map[string]GasCostEstimate)
 
-	for chain, source := range r.gasSources {
-		// Check cache
-		r.cache.mu.RLock()
-		if cached, ok := r.cache.gasData[chain]; ok && time.Since(cached.FetchedAt) < r.cacheDuration {
-			var gasPrice float64
-			var costUSD float64
-
-			if chain == "solana" {
-				lamports := cached.Data.Data.Standard
-				gasPrice = lamportsToSOL(lamports)
-				costUSD = calculateSolanaGasCostUSD(lamports, 180.0)
-			} else {
-				gasPrice = weiToGwei(cached.Data.Data.Standard)
-				costUSD = calculateGasCostUSD(gasPrice, ethPriceUSD)
-			}
-
+	for _, chain := range chains {
+		source, ok := r.gasSources[chain]
+		if !ok {
+			// No gas oracle for this chain; let the model price it conservatively
 			costs[chain] = GasCostEstimate{
 				Chain:            chain,
-				GasPrice:         gasPrice,
-				EstimatedCostUSD: costUSD,
-				Status:           classifyGasPrice(gasPrice, chain),
+				GasPrice:         getFallbackGasPrice(chain),
+				EstimatedCostUSD: 1.0,
+				Status:           "unknown",
 			}
+			continue
+		}
+
+		// Check cache
+		r.cache.mu.RLock()
+		if cached, ok := r.cache.gasData[chain]; ok && time.Since(cached.FetchedAt) < r.cacheDuration {
+			costs[chain] = gasCostEstimate(chain, cached.Data, prices)
 			r.cache.mu.RUnlock()
 			continue
 		}
 		r.cache.mu.RUnlock()
 
+		// Another instance may have fetched recently
+		var shared CachedGasData
+		if r.loadShared(ctx, cacheKeyGas+chain, &shared) && shared.Data != nil && r.fresh(shared.FetchedAt) {
+			r.cache.mu.Lock()
+			r.cache.gasData[chain] = &shared
+			r.cache.mu.Unlock()
+			costs[chain] = gasCostEstimate(chain, shared.Data, prices)
+			continue
+		}
+
 		// Fetch fresh data
 		data, err := source.Fetch(ctx)
 		if err != nil {
@@ -301,46 +414,175 @@ func (r *RealDataProvider) getGasCosts(ctx context.Context, ethPriceUSD float64)
 			continue
 		}
 
-		response := data.(*GasOracleResponse)
+		response := data.(*GasReading)
 
 		// Cache the result
-		r.cache.mu.Lock()
-		r.cache.gasData[chain] = &CachedGasData{
+		cached := &CachedGasData{
 			Data:      response,
 			FetchedAt: time.Now(),
 		}
+		r.cache.mu.Lock()
+		r.cache.gasData[chain] = cached
 		r.cache.mu.Unlock()
+		r.storeShared(ctx, cacheKeyGas+chain, cached)
 
-		var gasPrice float64
-		var costUSD float64
+		costs[chain] = gasCostEstimate(chain, response, prices)
+		r.recordGasPrice(ctx, costs[chain], cached.FetchedAt)
+	}
 
-		if chain == "solana" {
-			// Solana uses lamports, different calculation
-			lamports := response.Data.Standard
-			gasPrice = lamportsToSOL(lamports) // Convert to SOL for display
-			costUSD = calculateSolanaGasCostUSD(lamports, 180.0) // Assume $180 SOL price
-		} else {
-			// EVM chains use gwei
-			gasPrice = weiToGwei(response.Data.Standard)
-			costUSD = calculateGasCostUSD(gasPrice, ethPriceUSD)
+	// Attach recorded averages so a single reading isn't all routing and the model see
+	if r.gasHistory != nil {
+		for chain, estimate := range costs {
+			if _, ok := r.gasSources[chain]; !ok {
+				continue
+			}
+			trend, err := r.GasTrend(ctx, chain)
+			if err != nil {
+				logger.Warn("Failed to load gas price history", logger.Fields{
+					"chain": chain,
+					"error": err.Error(),
+				})
+				continue
+			}
+			estimate.Trend = trend
+			costs[chain] = estimate
 		}
+	}
 
-		costs[chain] = GasCostEstimate{
-			Chain:            chain,
-			GasPrice:         gasPrice,
-			EstimatedCostUSD: costUSD,
-			Status:           classifyGasPrice(gasPrice, chain),
+	return costs, nil
+}
+
+// gasCostEstimate converts a gas reading into a USD cost estimate for chain
+// GasPrice is in gwei on EVM chains and in SOL (base plus priority fee) on Solana.
+func gasCostEstimate(chain string, reading *GasReading, prices TokenPrices) GasCostEstimate {
+	var gasPrice, fastGasPrice float64
+	var costUSD float64
+
+	if reading.Unit == GasUnitMicroLamports {
+		// Solana charges a fixed base fee plus a priority fee per requested compute unit
+		priorityLamports := int64(reading.Standard * solanaComputeUnitLimit / 1e6)
+		gasPrice = lamportsToSOL(priorityLamports + solanaBaseFeeLamports)
+		if reading.Fast > 0 {
+			fastGasPrice = lamportsToSOL(int64(reading.Fast*solanaComputeUnitLimit/1e6) + solanaBaseFeeLamports)
 		}
+		costUSD = calculateSolanaGasCostUSD(priorityLamports, nativePriceUSD(chain, reading, prices))
+	} else {
+		// EVM chains price gas in gwei of their own gas token
+		gasPrice = reading.Standard
+		fastGasPrice = reading.Fast
+		costUSD = calculateGasCostUSD(gasPrice, nativePriceUSD(chain, reading, prices))
 	}
 
-	return costs, nil
+	return GasCostEstimate{
+		Chain:            chain,
+		GasPrice:         gasPrice,
+		EstimatedCostUSD: costUSD,
+		Status:           classifyGasPrice(gasPrice, chain),
+		fastGasPrice:     fastGasPrice,
+	}
 }
 
-// getProviderStatuses fetches operational status of payment providers
-func (r *RealDataProvider) getProviderStatuses(ctx context.Context) (map[string]ProviderHealth, error) {
+// gasSignal is the chain health input for a gas estimate, or nil when the chain has no live reading
+func gasSignal(estimate GasCostEstimate) *chains.GasSignal {
+	if estimate.Status == "unknown" {
+		return nil // Fallback price, not a reading
+	}
+	signal := &chains.GasSignal{
+		Standard: estimate.GasPrice,
+		Fast:     estimate.fastGasPrice,
+	}
+	if estimate.Trend != nil && estimate.Trend.Samples24h > 0 {
+		signal.Baseline = estimate.Trend.AvgGasPrice24h
+	}
+	return signal
+}
+
+// circleChainCodes are the chain codes in Circle's per-chain USDC status components
+var circleChainCodes = map[string]string{
+	"base":      "BASE",
+	"polygon":   "POLY",
+	"arbitrum":  "ARB",
+	"optimism":  "OP",
+	"avalanche": "AVAX",
+	"solana":    "SOL",
+	"ethereum":  "ETH",
+}
+
+// chainProviderStatus is the worst status the corridor's providers report for chain
+// Issues naming another chain's USDC component don't count against this one. Returns "" when no
+// provider status is known.
+func chainProviderStatus(chain string, statuses map[string]ProviderHealth) string {
+	status := ""
+	for _, health := range statuses {
+		if health.Status == "unknown" {
+			continue
+		}
+		if status == "" {
+			status = chains.ProviderOperational
+		}
+		for _, issue := range health.Issues {
+			if otherChainIssue(chain, issue) {
+				continue
+			}
+			if strings.Contains(issue, "major_outage") {
+				status = chains.ProviderOutage
+			} else if status == chains.ProviderOperational {
+				status = chains.ProviderDegraded
+			}
+		}
+	}
+	return status
+}
+
+// otherChainIssue reports whether issue is about a chain-specific component of a chain other than chain
+func otherChainIssue(chain, issue string) bool {
+	for other, code := range circleChainCodes {
+		if other != chain && strings.Contains(issue, "USDC - "+code+" - ") {
+			return true
+		}
+	}
+	return false
+}
+
+// nativePriceUSD is the USD price of chain's gas token
+// Oracles that report it are trusted; otherwise the fetched token price is used.
+func nativePriceUSD(chain string, reading *GasReading, prices TokenPrices) float64 {
+	if reading.NativePriceUSD > 0 {
+		return reading.NativePriceUSD
+	}
+	switch chain {
+	case "polygon":
+		return prices.MATIC
+	case "solana":
+		return prices.SOL
+	case "avalanche":
+		return prices.AVAX
+	default:
+		return prices.ETH
+	}
+}
+
+// getProviderStatuses fetches operational status of the given payment providers
+// Providers without a monitored status page are reported as "unknown" rather than left out.
+func (r *RealDataProvider) getProviderStatuses(ctx context.Context, providers ...string) (map[string]ProviderHealth, error) {
 	statuses := make(map[string]ProviderHealth)
 
-	for provider, source := range r.providerSources {
+	for _, name := range providers {
+		provider := strings.ToLower(name)
+		if _, done := statuses[provider]; done || provider == "" {
+			continue
+		}
+		source, ok := r.providerSources[provider]
+		if !ok {
+			statuses[provider] = ProviderHealth{
+				Provider:      provider,
+				Status:        "unknown",
+				IsOperational: true,
+				Issues:        []string{"Status not monitored"},
+			}
+			continue
+		}
+
 		// Check cache
 		r.cache.mu.RLock()
 		if cached, ok := r.cache.providerData[provider]; ok && time.Since(cached.FetchedAt) < r.cacheDuration {
@@ -350,6 +592,16 @@ func (r *RealDataProvider) getProviderStatuses(ctx context.Context) (map[string]
 		}
 		r.cache.mu.RUnlock()
 
+		// Another instance may have fetched recently
+		var shared CachedProviderData
+		if r.loadShared(ctx, cacheKeyProvider+provider, &shared) && shared.Data != nil && r.fresh(shared.FetchedAt) {
+			r.cache.mu.Lock()
+			r.cache.providerData[provider] = &shared
+			r.cache.mu.Unlock()
+			statuses[provider] = parseProviderHealth(provider, shared.Data)
+			continue
+		}
+
 		// Fetch fresh data
 		data, err := source.Fetch(ctx)
 		if err != nil {
@@ -366,12 +618,14 @@ func (r *RealDataProvider) getProviderStatuses(ctx context.Context) (map[string]
 		response := data.(*StatusPageResponse)
 
 		// Cache the result
-		r.cache.mu.Lock()
-		r.cache.providerData[provider] = &CachedProviderData{
+		cached := &CachedProviderData{
 			Data:      response,
 			FetchedAt: time.Now(),
 		}
+		r.cache.mu.Lock()
+		r.cache.providerData[provider] = cached
 		r.cache.mu.Unlock()
+		r.storeShared(ctx, cacheKeyProvider+provider, cached)
 
 		statuses[provider] = parseProviderHealth(provider, response)
 	}
@@ -379,6 +633,35 @@ func (r *RealDataProvider) getProviderStatuses(ctx context.Context) (map[string]
 	return statuses, nil
 }
 
+// fresh reports whether data fetched at fetchedAt is still within the cache duration
+func (r *RealDataProvider) fresh(fetchedAt time.Time) bool {
+	return time.Since(fetchedAt) < r.cacheDuration
+}
+
+// loadShared reads key from the shared cache into dest
+// Shared cache failures are logged and treated as a miss; upstream APIs are the fallback.
+func (r *RealDataProvider) loadShared(ctx context.Context, key string, dest interface{}) bool {
+	if r.shared == nil {
+		return false
+	}
+	found, err := r.shared.Get(ctx, key, dest)
+	if err != nil {
+		logger.Warn("Shared market data cache read failed", logger.Fields{"key": key, "error": err.Error()})
+		return false
+	}
+	return found
+}
+
+// storeShared writes freshly fetched data to the shared cache
+func (r *RealDataProvider) storeShared(ctx context.Context, key string, value interface{}) {
+	if r.shared == nil {
+		return
+	}
+	if err := r.shared.Set(ctx, key, value, r.cacheDuration); err != nil {
+		logger.Warn("Shared market data cache write failed", logger.Fields{"key": key, "error": err.Error()})
+	}
+}
+
 // Helper functions
 
 func weiToGwei(wei int64) float64 {
@@ -403,17 +686,27 @@ func calculateGasCostUSD(gasPriceGwei, ethPriceUSD float64) float64 {
 	return gasInETH * ethPriceUSD
 }
 
-func calculateSolanaGasCostUSD(lamports int64, solPriceUSD float64) float64 {
-	// Solana USDC transfer typically costs ~5000 lamports (0.000005 SOL)
-	// This is a fixed cost, not variable like EVM
-	baseTransferCost := 5000.0
+// Solana fee parameters for a USDC transfer
+const (
+	solanaBaseFeeLamports  = 5000   // Fixed fee per signature
+	solanaComputeUnitLimit = 200000 // Default compute unit limit; priority fees are charged on the limit requested
+)
 
-	// Convert lamports to SOL
-	costInSOL := (float64(lamports) + baseTransferCost) / 1e9
+// Gas token prices assumed when the price source doesn't report one
+const (
+	ethFallbackPriceUSD   = 2000.0
+	solFallbackPriceUSD   = 180.0
+	maticFallbackPriceUSD = 0.5
+	avaxFallbackPriceUSD  = 25.0
+)
 
-	// Convert to USD (SOL price ~$150-200 typically)
+func calculateSolanaGasCostUSD(priorityLamports int64, solPriceUSD float64) float64 {
+	// Base fee plus priority fee, in SOL
+	costInSOL := float64(priorityLamports+solanaBaseFeeLamports) / 1e9
+
+	// Convert to USD
 	if solPriceUSD == 0 {
-		solPriceUSD = 180.0 // Fallback SOL price
+		solPriceUSD = solFallbackPriceUSD
 	}
 	return costInSOL * solPriceUSD
 }
@@ -458,6 +751,26 @@ func classifyGasPrice(gasPrice float64, chain string) string {
 			return "high"
 		}
 		return "very_high"
+	case "optimism":
+		// Optimism L2, typically very cheap
+		if gasPrice < 0.5 {
+			return "low"
+		} else if gasPrice < 2 {
+			return "medium"
+		} else if gasPrice < 5 {
+			return "high"
+		}
+		return "very_high"
+	case "avalanche":
+		// Avalanche C-Chain uses AVAX; dynamic fees rarely leave single digits
+		if gasPrice < 5 {
+			return "low"
+		} else if gasPrice < 25 {
+			return "medium"
+		} else if gasPrice < 75 {
+			return "high"
+		}
+		return "very_high"
 	case "solana":
 		// Solana measures in lamports, extremely cheap
 		if gasPrice < 0.001 {
@@ -473,11 +786,13 @@ func classifyGasPrice(gasPrice float64, chain string) string {
 
 func getFallbackGasPrice(chain string) float64 {
 	fallbacks := map[string]float64{
-		"ethereum": 30.0,  // 30 gwei typical
-		"base":     0.5,   // Very low, subsidized
-		"polygon":  50.0,  // 50 gwei typical
-		"arbitrum": 0.1,   // Very low L2
-		"solana":   0.001, // Extremely low
+		"ethereum":  30.0,  // 30 gwei typical
+		"base":      0.5,   // Very low, subsidized
+		"polygon":   50.0,  // 50 gwei typical
+		"arbitrum":  0.1,   // Very low L2
+		"optimism":  0.1,   // Very low L2
+		"avalanche": 25.0,  // 25 gwei (nAVAX) typical
+		"solana":    0.001, // Extremely low
 	}
 	if price, ok := fallbacks[chain]; ok {
 		return price
@@ -493,7 +808,7 @@ func parseProviderHealth(provider string, status *StatusPageResponse) ProviderHe
 		Issues:        []string{},
 	}
 
-	// Define critical components for USD→EUR transfers (all 5 optimal chains)
+	// Define critical components for USDC transfers (all 7 supported chains)
 	criticalComponents := map[string][]string{
 		"circle": {
 			"Circle Mint APIs",
@@ -504,6 +819,10 @@ func parseProviderHealth(provider string, status *StatusPageResponse) ProviderHe
 			"USDC - POLY - Redeeming",
 			"USDC - ARB - Minting",      // Arbitrum (L2)
 			"USDC - ARB - Redeeming",
+			"USDC - OP - Minting",       // Optimism (L2)
+			"USDC - OP - Redeeming",
+			"USDC - AVAX - Minting",     // Avalanche C-Chain (L1)
+			"USDC - AVAX - Redeeming",
 			"USDC - SOL - Minting",      // Solana (L1)
 			"USDC - SOL - Redeeming",
 			"USDC - ETH - Minting",      // Ethereum (L1)
@@ -571,25 +890,45 @@ func parseProviderHealth(provider string, status *StatusPageResponse) ProviderHe
 	return health
 }
 
-// CalculateOptimalRoute determines the best routing based on real market data
-func (r *RealDataProvider) CalculateOptimalRoute(ctx context.Context, amountUSD int64) (*RouteRecommendation, error) {
-	marketCtx, err := r.GatherContext(ctx)
+// CalculateOptimalRoute determines the best routing for corridor based on real market data
+func (r *RealDataProvider) CalculateOptimalRoute(ctx context.Context, corridor CorridorContext, amountUSD int64) (*RouteRecommendation, error) {
+	marketCtx, err := r.GatherContext(ctx, corridor)
 	if err != nil {
 		return nil, fmt.Errorf("failed to gather market context: %w", err)
 	}
 
-	// Find cheapest gas chain
+	// Degraded chains are skipped unless every corridor chain is degraded
+	usable := make(map[string]bool, len(marketCtx.GasCosts))
+	for chain := range marketCtx.GasCosts {
+		if health, ok := marketCtx.ChainHealth[chain]; !ok || health.Level != chains.LevelDegraded {
+			usable[chain] = true
+		}
+	}
+	if len(usable) == 0 {
+		for chain := range marketCtx.GasCosts {
+			usable[chain] = true
+		}
+	}
+
+	// Find cheapest gas chain, by recent average where gas history is recorded
 	cheapestChain := "base"
 	lowestGasCost := math.MaxFloat64
 	for chain, gasCost := range marketCtx.GasCosts {
-		if gasCost.EstimatedCostUSD < lowestGasCost {
-			lowestGasCost = gasCost.EstimatedCostUSD
+		if !usable[chain] {
+			continue
+		}
+		if cost := routingCostUSD(gasCost); cost < lowestGasCost {
+			lowestGasCost = cost
 			cheapestChain = chain
 		}
 	}
+	chainLevel := chains.LevelUnknown
+	if health, ok := marketCtx.ChainHealth[cheapestChain]; ok {
+		chainLevel = health.Level
+	}
 
 	// Find best provider (prefer operational over degraded)
-	bestProvider := "circle"
+	bestProvider := strings.ToLower(corridor.OfframpProvider)
 	for provider, health := range marketCtx.ProviderStatuses {
 		if health.IsOperational && health.Status == "operational" {
 			bestProvider = provider
@@ -601,8 +940,8 @@ func (r *RealDataProvider) CalculateOptimalRoute(ctx context.Context, amountUSD
 		Chain:     cheapestChain,
 		Provider:  bestProvider,
 		GasCostUSD: lowestGasCost,
-		Reasoning: fmt.Sprintf("Selected %s chain (gas: $%.2f) with %s provider (status: %s)",
-			cheapestChain, lowestGasCost, bestProvider, marketCtx.ProviderStatuses[bestProvider].Status),
+		Reasoning: fmt.Sprintf("Selected %s chain (gas: $%.2f, health: %s) with %s provider (status: %s)",
+			cheapestChain, lowestGasCost, chainLevel, bestProvider, marketCtx.ProviderStatuses[bestProvider].Status),
 	}, nil
 }
 
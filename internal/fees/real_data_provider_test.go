@@ -5,14 +5,27 @@ import (
 	"encoding/json"
 	"testing"
 	"time"
+
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/corridors"
 )
 
+// testCorridor returns the built-in corridor context for source → destination
+func testCorridor(t *testing.T, source, destination string) CorridorContext {
+	t.Helper()
+	corridor, err := corridors.Default().Lookup(source, destination)
+	if err != nil {
+		t.Fatalf("corridor %s-%s: %v", source, destination, err)
+	}
+	return newCorridorContext(corridor, "")
+}
+
 func TestRealDataProvider_GatherContext(t *testing.T) {
-	provider := NewRealDataProvider()
+	provider := NewRealDataProvider(nil, nil)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	marketCtx, err := provider.GatherContext(ctx)
+	marketCtx, err := provider.GatherContext(ctx, testCorridor(t, "USD", "EUR"))
 	if err != nil {
 		t.Fatalf("Failed to gather market context: %v", err)
 	}
@@ -63,11 +76,11 @@ func TestRealDataProvider_GatherContext(t *testing.T) {
 }
 
 func TestRealDataProvider_CalculateOptimalRoute(t *testing.T) {
-	provider := NewRealDataProvider()
+	provider := NewRealDataProvider(nil, nil)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	route, err := provider.CalculateOptimalRoute(ctx, 100000) // $1000
+	route, err := provider.CalculateOptimalRoute(ctx, testCorridor(t, "USD", "EUR"), 100000) // $1000
 	if err != nil {
 		t.Fatalf("Failed to calculate optimal route: %v", err)
 	}
@@ -95,6 +108,63 @@ func TestRealDataProvider_CalculateOptimalRoute(t *testing.T) {
 	}
 }
 
+func TestChainProviderStatus(t *testing.T) {
+	statuses := map[string]ProviderHealth{
+		"circle": {
+			Provider: "circle",
+			Status:   "outage",
+			Issues:   []string{"USDC - SOL - Minting: major_outage", "USDC - POLY - Redeeming: partial_outage", "USDC - OP - Minting: partial_outage"},
+		},
+		"bridge": {Provider: "bridge", Status: "unknown"},
+	}
+
+	cases := map[string]string{
+		"solana":    chains.ProviderOutage,
+		"polygon":   chains.ProviderDegraded,
+		"optimism":  chains.ProviderDegraded,
+		"base":      chains.ProviderOperational,
+		"avalanche": chains.ProviderOperational,
+		"ethereum":  chains.ProviderOperational,
+	}
+	for chain, want := range cases {
+		if got := chainProviderStatus(chain, statuses); got != want {
+			t.Errorf("chainProviderStatus(%s) = %q, want %q", chain, got, want)
+		}
+	}
+
+	// Issues not tied to one chain count against every chain
+	statuses["circle"] = ProviderHealth{Provider: "circle", Status: "outage", Issues: []string{"Circle Mint APIs: major_outage"}}
+	if got := chainProviderStatus("base", statuses); got != chains.ProviderOutage {
+		t.Errorf("chainProviderStatus(base) = %q, want outage", got)
+	}
+
+	// Only providers without a status page
+	if got := chainProviderStatus("base", map[string]ProviderHealth{"bridge": {Status: "unknown"}}); got != "" {
+		t.Errorf("chainProviderStatus() = %q, want unknown", got)
+	}
+}
+
+func TestGasSignal(t *testing.T) {
+	prices := TokenPrices{ETH: 3000, SOL: 150, MATIC: 0.5}
+
+	evm := gasCostEstimate("base", &GasReading{Unit: GasUnitGwei, Standard: 0.02, Fast: 0.05}, prices)
+	evm.Trend = &GasTrend{AvgGasPrice24h: 0.01, Samples24h: 12}
+	if signal := gasSignal(evm); signal.Standard != 0.02 || signal.Fast != 0.05 || signal.Baseline != 0.01 {
+		t.Errorf("base signal = %+v, want standard 0.02, fast 0.05, baseline 0.01", signal)
+	}
+
+	// Solana priority fees are compared in SOL per transaction, like GasPrice
+	solana := gasCostEstimate("solana", &GasReading{Unit: GasUnitMicroLamports, Standard: 1000, Fast: 5000}, prices)
+	signal := gasSignal(solana)
+	if signal.Fast <= signal.Standard || signal.Standard != solana.GasPrice {
+		t.Errorf("solana signal = %+v, want fast above standard %v", signal, solana.GasPrice)
+	}
+
+	if gasSignal(GasCostEstimate{Chain: "optimism", GasPrice: 10, Status: "unknown"}) != nil {
+		t.Error("fallback gas estimate should have no signal")
+	}
+}
+
 func TestIndividualDataSources(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -123,12 +193,12 @@ func TestIndividualDataSources(t *testing.T) {
 				continue
 			}
 
-			response := data.(*GasOracleResponse)
-			t.Logf("%s Gas Prices:", chain)
-			t.Logf("  Slow: %.2f gwei", float64(response.Data.Slow)/1e9)
-			t.Logf("  Standard: %.2f gwei", float64(response.Data.Standard)/1e9)
-			t.Logf("  Fast: %.2f gwei", float64(response.Data.Fast)/1e9)
-			t.Logf("  Rapid: %.2f gwei", float64(response.Data.Rapid)/1e9)
+			reading := data.(*GasReading)
+			t.Logf("%s Gas Prices (%s):", chain, reading.Unit)
+			t.Logf("  Slow: %.4f", reading.Slow)
+			t.Logf("  Standard: %.4f", reading.Standard)
+			t.Logf("  Fast: %.4f", reading.Fast)
+			t.Logf("  Native price: $%.2f", reading.NativePriceUSD)
 		}
 	})
 
@@ -152,17 +222,18 @@ func TestIndividualDataSources(t *testing.T) {
 		}
 	})
 
-	t.Run("ETH Price Source", func(t *testing.T) {
-		source := NewETHPriceSource()
+	t.Run("Token Price Source", func(t *testing.T) {
+		source := NewTokenPriceSource()
 		data, err := source.Fetch(ctx)
 		if err != nil {
-			t.Fatalf("ETH price fetch failed: %v", err)
+			t.Fatalf("Token price fetch failed: %v", err)
 		}
 
-		response := data.(*CoinGeckoResponse)
-		t.Logf("ETH Price:")
-		t.Logf("  USD: $%.2f", response.Ethereum.USD)
-		t.Logf("  EUR: €%.2f", response.Ethereum.EUR)
+		prices := data.(*TokenPrices)
+		t.Logf("Token Prices:")
+		t.Logf("  ETH: $%.2f", prices.ETH)
+		t.Logf("  SOL: $%.2f", prices.SOL)
+		t.Logf("  MATIC: $%.4f", prices.MATIC)
 	})
 }
 
@@ -0,0 +1,138 @@
+package fees
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// ScheduleStore reads the effective-dated fee schedules managed through the admin API
+type ScheduleStore interface {
+	ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error)
+}
+
+// scheduleCache keeps every version of a schedule so future-dated ones take effect without a reload
+type scheduleCache struct {
+	mu       sync.Mutex
+	store    ScheduleStore
+	ttl      time.Duration
+	versions map[string][]*models.FeeSchedule
+	loadedAt map[string]time.Time
+}
+
+// EnableSchedules prices fees from store instead of the built-in schedules
+// Schedules are re-read at most every cacheTTL; until a schedule has a version in effect the
+// built-in one still applies.
+func (c *Calculator) EnableSchedules(store ScheduleStore, cacheTTL time.Duration) {
+	c.schedules = &scheduleCache{
+		store:    store,
+		ttl:      cacheTTL,
+		versions: make(map[string][]*models.FeeSchedule),
+		loadedAt: make(map[string]time.Time),
+	}
+}
+
+// InvalidateSchedule drops the cached versions of scheduleID so the next fee re-reads them
+func (c *Calculator) InvalidateSchedule(scheduleID string) {
+	if c.schedules == nil {
+		return
+	}
+	c.schedules.mu.Lock()
+	defer c.schedules.mu.Unlock()
+	delete(c.schedules.loadedAt, scheduleID)
+}
+
+// activeSchedule returns the stored version of scheduleID in effect now, or nil to use the built-in one
+func (c *Calculator) activeSchedule(ctx context.Context, scheduleID string) *models.FeeSchedule {
+	if c.schedules == nil {
+		return nil
+	}
+	return c.schedules.active(ctx, scheduleID, time.Now())
+}
+
+func (s *scheduleCache) active(ctx context.Context, scheduleID string, now time.Time) *models.FeeSchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if loadedAt, ok := s.loadedAt[scheduleID]; !ok || now.Sub(loadedAt) >= s.ttl {
+		versions, err := s.store.ListFeeSchedules(ctx, scheduleID)
+		if err != nil {
+			// Keep pricing from the last versions we read; with none, the built-in schedule applies
+			logger.Error("Failed to load fee schedule", logger.Fields{
+				"schedule_id": scheduleID,
+				"error":       err.Error(),
+			})
+			metrics.Count("FeeScheduleLoadFailures", metrics.Dimensions{"ScheduleID": scheduleID})
+		} else {
+			s.versions[scheduleID] = versions
+			s.loadedAt[scheduleID] = now
+		}
+	}
+
+	return models.ActiveFeeSchedule(s.versions[scheduleID], now)
+}
+
+// CalculateCorridorFee calculates the fee for a corridor: its platform fee, after customers' negotiated
+// pricing or volume discount and the corridor's fee limits, plus the corridor's regulatory surcharges
+// A stored schedule for the corridor wins, then the corridor's configured schedule, then the
+// stored default schedule rescaled to the source currency, then the built-in default.
+func (c *Calculator) CalculateCorridorFee(ctx context.Context, corridor corridors.Corridor, amount int64, customers ...string) *FeeResult {
+	result := c.corridorScheduleFee(ctx, corridor, amount)
+	result.FeeCurrency = corridor.SourceCurrency
+	result = c.applyCustomerPricing(result, customers)
+	result = c.applyFeeLimits(c.applyVolumeDiscount(ctx, result, customers), corridor)
+	return c.applySurcharges(result, corridor)
+}
+
+// corridorScheduleFee prices amount from the corridor's schedule before any negotiated pricing
+func (c *Calculator) corridorScheduleFee(ctx context.Context, corridor corridors.Corridor, amount int64) *FeeResult {
+	tiers, stored := c.corridorSchedule(ctx, corridor)
+	result := c.CalculateFeeWithSchedule(amount, corridor.DestinationCurrency, tiers)
+	if stored != nil {
+		return c.withSchedule(result, stored)
+	}
+	return result
+}
+
+// corridorSchedule returns the tiers in effect on a corridor, in its source minor units,
+// and the stored schedule version they come from, if any
+func (c *Calculator) corridorSchedule(ctx context.Context, corridor corridors.Corridor) (corridors.FeeSchedule, *models.FeeSchedule) {
+	if stored := c.activeSchedule(ctx, corridor.ID); stored != nil {
+		return stored.Tiers, stored
+	}
+	if len(corridor.FeeSchedule) == 0 {
+		if stored := c.activeSchedule(ctx, models.DefaultFeeScheduleID); stored != nil {
+			return stored.Tiers.ForCurrency(corridor.SourceCurrency), stored
+		}
+	}
+	return corridor.Fees(), nil
+}
+
+// CorridorFeeSummary describes the fees a corridor charges before any negotiated pricing or discount
+func (c *Calculator) CorridorFeeSummary(ctx context.Context, corridor corridors.Corridor) *models.FeeScheduleSummary {
+	tiers, stored := c.corridorSchedule(ctx, corridor)
+	summary := &models.FeeScheduleSummary{
+		Currency:   corridor.SourceCurrency,
+		Tiers:      tiers,
+		MinFee:     corridor.MinFee,
+		MaxFee:     corridor.MaxFee,
+		Surcharges: corridor.Surcharges,
+	}
+	if stored != nil {
+		summary.ScheduleID = stored.ScheduleID
+		summary.ScheduleVersion = stored.Version
+	}
+	return summary
+}
+
+// withSchedule records which stored schedule version priced result
+func (c *Calculator) withSchedule(result *FeeResult, schedule *models.FeeSchedule) *FeeResult {
+	result.ScheduleID = schedule.ScheduleID
+	result.ScheduleVersion = schedule.Version
+	return result
+}
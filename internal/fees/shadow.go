@@ -0,0 +1,112 @@
+package fees
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// ComparisonStore persists shadow-mode fee comparisons
+type ComparisonStore interface {
+	RecordFeeComparison(ctx context.Context, comparison *models.FeeComparison) error
+}
+
+// Shadow runs the AI calculator alongside the static fee without affecting what is charged
+type Shadow struct {
+	ai      *AIFeeCalculator
+	store   ComparisonStore
+	timeout time.Duration
+	wg      sync.WaitGroup
+}
+
+// NewShadow creates a shadow runner that records each AI result against the static fee in store
+func NewShadow(ai *AIFeeCalculator, store ComparisonStore, timeout time.Duration) *Shadow {
+	return &Shadow{
+		ai:      ai,
+		store:   store,
+		timeout: timeout,
+	}
+}
+
+// Compare calculates the AI fee for a payment in the background and records it against staticFee
+// The caller's cancellation doesn't stop the comparison, which is bounded by the shadow timeout instead.
+func (s *Shadow) Compare(ctx context.Context, paymentID string, req *AIFeeRequest, staticFee int64) {
+	ctx = context.WithoutCancel(ctx)
+	reqCopy := *req
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+
+		comparison := s.compare(ctx, paymentID, &reqCopy, staticFee)
+		if err := s.store.RecordFeeComparison(ctx, comparison); err != nil {
+			logger.Warn("Failed to record fee comparison", logger.Fields{
+				"error":      err.Error(),
+				"payment_id": paymentID,
+			})
+		}
+	}()
+}
+
+// Wait blocks until every comparison started so far has been recorded
+func (s *Shadow) Wait() {
+	s.wg.Wait()
+}
+
+// compare runs the AI calculator and builds the comparison record
+func (s *Shadow) compare(ctx context.Context, paymentID string, req *AIFeeRequest, staticFee int64) *models.FeeComparison {
+	comparison := &models.FeeComparison{
+		PaymentID:      paymentID,
+		Amount:         req.Amount,
+		SourceCurrency: req.FromCurrency,
+		Currency:       req.ToCurrency,
+		StaticFee:      staticFee,
+		CreatedAt:      time.Now(),
+	}
+
+	start := time.Now()
+	resp, err := s.ai.Calculate(ctx, req)
+	comparison.AILatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		comparison.AIError = err.Error()
+		metrics.Count("FeeShadowComparisons", metrics.Dimensions{"Outcome": "error"})
+		return comparison
+	}
+
+	comparison.AIFee = resp.TotalFee
+	comparison.Delta = resp.TotalFee - staticFee
+	if staticFee != 0 {
+		comparison.DeltaPercent = float64(comparison.Delta) / float64(staticFee) * 100
+	}
+	comparison.AIChain = resp.Provider.Chain
+	comparison.AIConfidence = resp.ConfidenceScore
+	comparison.AIFallback = resp.Fallback
+	comparison.PromptVersion = resp.PromptVersion
+
+	outcome := "success"
+	if resp.Fallback {
+		outcome = "fallback"
+	}
+	metrics.Count("FeeShadowComparisons", metrics.Dimensions{"Outcome": outcome})
+	metrics.Emit("FeeShadowDeltaPercent", comparison.DeltaPercent, metrics.UnitNone, metrics.Dimensions{
+		"Corridor": req.FromCurrency + "-" + req.ToCurrency,
+	})
+
+	logger.Info("Fee shadow comparison", logger.Fields{
+		"payment_id":    paymentID,
+		"static_fee":    staticFee,
+		"ai_fee":        resp.TotalFee,
+		"delta":         comparison.Delta,
+		"delta_percent": comparison.DeltaPercent,
+		"ai_chain":      resp.Provider.Chain,
+		"ai_fallback":   resp.Fallback,
+	})
+	return comparison
+}
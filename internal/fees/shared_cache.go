@@ -0,0 +1,23 @@
+package fees
+
+import (
+	"context"
+	"time"
+)
+
+// Shared cache keys for market data
+const (
+	cacheKeyFXRates     = "fx:rates"
+	cacheKeyTokenPrices = "token:prices"
+	cacheKeyGas         = "gas-reading:" // + chain
+	cacheKeyProvider    = "provider:"    // + provider
+)
+
+// SharedCache stores market data across Lambda instances and cold starts
+// Implementations JSON-encode values and treat entries older than ttl as missing.
+type SharedCache interface {
+	// Get decodes the entry for key into dest, reporting whether a live entry was found
+	Get(ctx context.Context, key string, dest interface{}) (bool, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
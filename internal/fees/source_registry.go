@@ -0,0 +1,261 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/fx"
+)
+
+// Source types: the upstream API a market data source speaks
+const (
+	SourceTypeBlockscout = "blockscout" // Gas: Blockscout explorer stats; name is the chain
+	SourceTypeGasnow     = "gasnow"     // Gas: beaconcha.in gasnow; name is the chain
+	SourceTypeSolanaRPC  = "solana-rpc" // Gas: Solana JSON-RPC node; name is the chain
+	SourceTypeEVMRPC     = "evm-rpc"    // Gas: EVM JSON-RPC node (eth_feeHistory); name is the chain
+	SourceTypeStatuspage = "statuspage" // Status: Atlassian Statuspage; name is the provider
+	SourceTypeCoinGecko  = "coingecko"  // Gas token prices
+	// FX sources use the internal/fx source names as their type
+)
+
+// Source kinds, derived from the type
+const (
+	sourceKindGas        = "gas"
+	sourceKindStatus     = "status"
+	sourceKindTokenPrice = "token_price"
+	sourceKindFX         = "fx"
+)
+
+// defaultSourceTimeout applies to definitions without a timeout
+const defaultSourceTimeout = 10 * time.Second
+
+// SourceDefinition declares one market data source
+type SourceDefinition struct {
+	Name           string `json:"name"` // Chain for gas sources, provider for status sources
+	Type           string `json:"type"`
+	URL            string `json:"url"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"` // Defaults to 10
+	APIKey         string `json:"api_key,omitempty"`
+}
+
+// defaultSourceDefinitions are the built-in market data sources
+var defaultSourceDefinitions = []SourceDefinition{
+	// Chains USDC settles on (ordered by typical preference); each corridor uses a subset
+	{Name: "base", Type: SourceTypeBlockscout, URL: "https://base.blockscout.com"},            // #1: Lowest cost (~$0.00), EVM L2, Coinbase-backed
+	{Name: "polygon", Type: SourceTypeBlockscout, URL: "https://polygon.blockscout.com"},      // #2: Very low cost (~$0.001), popular sidechain
+	{Name: "arbitrum", Type: SourceTypeBlockscout, URL: "https://arbitrum.blockscout.com"},    // #3: Low cost (~$0.01), popular EVM L2
+	{Name: "optimism", Type: SourceTypeBlockscout, URL: "https://optimism.blockscout.com"},    // #4: Low cost (~$0.01), OP Stack EVM L2
+	{Name: "solana", Type: SourceTypeSolanaRPC, URL: "https://api.mainnet-beta.solana.com"},   // #5: Extremely fast & cheap (~$0.0002), non-EVM
+	{Name: "avalanche", Type: SourceTypeEVMRPC, URL: "https://api.avax.network/ext/bc/C/rpc"}, // #6: Fast finality (~$0.01), EVM L1 (C-Chain)
+	{Name: "ethereum", Type: SourceTypeGasnow, URL: "https://beaconcha.in"},                   // #7: High security, variable cost, most liquid
+
+	// Providers with a monitored status page (Coinbase removed for now - Circle is primary provider)
+	{Name: "circle", Type: SourceTypeStatuspage, URL: "https://status.circle.com"},
+
+	{Name: "coingecko", Type: SourceTypeCoinGecko, URL: "https://api.coingecko.com"},
+}
+
+// kind returns which market data the definition's type supplies, or "" for an unknown type
+func (d SourceDefinition) kind() string {
+	switch d.Type {
+	case SourceTypeBlockscout, SourceTypeGasnow, SourceTypeSolanaRPC, SourceTypeEVMRPC:
+		return sourceKindGas
+	case SourceTypeStatuspage:
+		return sourceKindStatus
+	case SourceTypeCoinGecko:
+		return sourceKindTokenPrice
+	case fx.SourceExchangeRateAPI, fx.SourceECB, fx.SourceOpenExchangeRates:
+		return sourceKindFX
+	}
+	return ""
+}
+
+// key identifies the source a definition declares; a later definition with the same key replaces it
+// Gas and status sources are keyed by name, FX sources by type, and there is one token price source.
+func (d SourceDefinition) key() string {
+	switch kind := d.kind(); kind {
+	case sourceKindGas, sourceKindStatus:
+		return kind + ":" + d.Name
+	case sourceKindFX:
+		return kind + ":" + d.Type
+	default:
+		return kind
+	}
+}
+
+func (d SourceDefinition) timeout() time.Duration {
+	if d.TimeoutSeconds > 0 {
+		return time.Duration(d.TimeoutSeconds) * time.Second
+	}
+	return defaultSourceTimeout
+}
+
+// validate checks the definition can be built
+func (d SourceDefinition) validate() error {
+	kind := d.kind()
+	if kind == "" {
+		return fmt.Errorf("source %q: unknown type %q", d.Name, d.Type)
+	}
+	if (kind == sourceKindGas || kind == sourceKindStatus) && d.Name == "" {
+		return fmt.Errorf("%s source: name is required", d.Type)
+	}
+	parsed, err := url.Parse(d.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("source %q: url must be an http(s) URL", d.Name)
+	}
+	if d.TimeoutSeconds < 0 {
+		return fmt.Errorf("source %q: timeout_seconds must not be negative", d.Name)
+	}
+	if d.APIKey != "" {
+		switch d.Type {
+		case SourceTypeSolanaRPC, SourceTypeEVMRPC, SourceTypeStatuspage, fx.SourceExchangeRateAPI, fx.SourceECB:
+			return fmt.Errorf("source %q: %s takes no api_key; include any credentials in the url", d.Name, d.Type)
+		}
+	}
+	return nil
+}
+
+// httpSource builds the HTTP client for the definition, with its API key placed where the type expects it
+func (d SourceDefinition) httpSource(name string) *HTTPDataSource {
+	source := NewHTTPDataSource(name, d.URL, d.timeout())
+	source.apiKey = d.APIKey
+	switch d.Type {
+	case SourceTypeBlockscout, SourceTypeGasnow:
+		source.apiKeyParam = "apikey"
+	case SourceTypeCoinGecko:
+		source.apiKeyHeader = "x-cg-pro-api-key"
+	}
+	return source
+}
+
+// gasSource builds the gas oracle adapter for a gas definition
+func (d SourceDefinition) gasSource() DataSource {
+	name := fmt.Sprintf("%s-gas", d.Name)
+	switch d.Type {
+	case SourceTypeBlockscout:
+		return &BlockscoutGasSource{HTTPDataSource: d.httpSource(name), chain: d.Name}
+	case SourceTypeSolanaRPC:
+		return &SolanaGasSource{HTTPDataSource: d.httpSource(name)}
+	case SourceTypeEVMRPC:
+		return &EVMRPCGasSource{HTTPDataSource: d.httpSource(name), chain: d.Name}
+	default:
+		return &GasnowSource{HTTPDataSource: d.httpSource(name), chain: d.Name}
+	}
+}
+
+// statusSource builds the status page source for a status definition
+func (d SourceDefinition) statusSource() *ProviderStatusSource {
+	return &ProviderStatusSource{
+		HTTPDataSource: d.httpSource(fmt.Sprintf("%s-status", d.Name)),
+		provider:       d.Name,
+	}
+}
+
+// SourceRegistry holds the market data sources the fee engine reads
+// Configured definitions replace the built-in source of the same chain, provider or FX source,
+// or add a new one, so operators can swap providers or use private RPC endpoints without a deploy.
+type SourceRegistry struct {
+	definitions map[string]SourceDefinition
+}
+
+// DefaultSourceRegistry returns the built-in sources
+func DefaultSourceRegistry() *SourceRegistry {
+	registry, _ := NewSourceRegistry(nil)
+	return registry
+}
+
+// defaultDefinition returns the built-in definition with key
+func defaultDefinition(key string) (SourceDefinition, bool) {
+	for _, d := range defaultSourceDefinitions {
+		if d.key() == key {
+			return d, true
+		}
+	}
+	return SourceDefinition{}, false
+}
+
+// NewSourceRegistry builds a registry from the built-in sources overlaid with definitions
+func NewSourceRegistry(definitions []SourceDefinition) (*SourceRegistry, error) {
+	r := &SourceRegistry{definitions: make(map[string]SourceDefinition)}
+	for _, d := range defaultSourceDefinitions {
+		r.definitions[d.key()] = d
+	}
+	for _, d := range definitions {
+		if err := d.validate(); err != nil {
+			return nil, fmt.Errorf("invalid data source: %w", err)
+		}
+		r.definitions[d.key()] = d
+	}
+	return r, nil
+}
+
+// ParseSourcesJSON builds a registry from a JSON array of source definitions
+func ParseSourcesJSON(data []byte) (*SourceRegistry, error) {
+	var list []SourceDefinition
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid data source definitions: %w", err)
+	}
+	return NewSourceRegistry(list)
+}
+
+// GasSources returns a gas oracle adapter per chain
+func (r *SourceRegistry) GasSources() map[string]DataSource {
+	sources := make(map[string]DataSource)
+	for _, d := range r.definitions {
+		if d.kind() == sourceKindGas {
+			sources[d.Name] = d.gasSource()
+		}
+	}
+	return sources
+}
+
+// StatusSources returns a status page source per provider
+func (r *SourceRegistry) StatusSources() map[string]*ProviderStatusSource {
+	sources := make(map[string]*ProviderStatusSource)
+	for _, d := range r.definitions {
+		if d.kind() == sourceKindStatus {
+			sources[d.Name] = d.statusSource()
+		}
+	}
+	return sources
+}
+
+// TokenPriceSource returns the gas token price source
+func (r *SourceRegistry) TokenPriceSource() *TokenPriceSource {
+	d := r.definitions[sourceKindTokenPrice]
+	return &TokenPriceSource{
+		HTTPDataSource: d.httpSource("token-prices"),
+	}
+}
+
+// HealthSources returns every gas, status page and token price source, sorted by name, for liveness checks
+func (r *SourceRegistry) HealthSources() []DataSource {
+	var sources []DataSource
+	for _, source := range r.GasSources() {
+		sources = append(sources, source)
+	}
+	for _, source := range r.StatusSources() {
+		sources = append(sources, source)
+	}
+	sources = append(sources, r.TokenPriceSource())
+	sort.Slice(sources, func(i, j int) bool { return sources[i].GetName() < sources[j].GetName() })
+	return sources
+}
+
+// FXEndpoints returns the configured FX source endpoints, keyed by FX source name
+func (r *SourceRegistry) FXEndpoints() map[string]fx.Endpoint {
+	endpoints := make(map[string]fx.Endpoint)
+	for _, d := range r.definitions {
+		if d.kind() == sourceKindFX {
+			endpoints[d.Type] = fx.Endpoint{
+				URL:     d.URL,
+				Timeout: d.timeout(),
+				APIKey:  d.APIKey,
+			}
+		}
+	}
+	return endpoints
+}
@@ -0,0 +1,116 @@
+package fees
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/fx"
+)
+
+func TestDefaultSourceRegistry(t *testing.T) {
+	registry := DefaultSourceRegistry()
+
+	gas := registry.GasSources()
+	for _, chain := range []string{"base", "polygon", "arbitrum", "optimism", "solana", "avalanche", "ethereum"} {
+		if _, ok := gas[chain]; !ok {
+			t.Errorf("no built-in gas source for %s", chain)
+		}
+	}
+	if _, ok := gas["solana"].(*SolanaGasSource); !ok {
+		t.Errorf("solana gas source = %T, want *SolanaGasSource", gas["solana"])
+	}
+	if _, ok := gas["avalanche"].(*EVMRPCGasSource); !ok {
+		t.Errorf("avalanche gas source = %T, want *EVMRPCGasSource", gas["avalanche"])
+	}
+	if _, ok := registry.StatusSources()["circle"]; !ok {
+		t.Error("no built-in status source for circle")
+	}
+	if got := registry.TokenPriceSource().baseURL; got != "https://api.coingecko.com" {
+		t.Errorf("token price URL = %q, want CoinGecko", got)
+	}
+	if len(registry.FXEndpoints()) != 0 {
+		t.Errorf("FX endpoints = %v, want none by default", registry.FXEndpoints())
+	}
+}
+
+func TestParseSourcesJSONOverridesAndAdds(t *testing.T) {
+	registry, err := ParseSourcesJSON([]byte(`[
+		{"name": "solana", "type": "solana-rpc", "url": "https://rpc.example.com/?api-key=secret", "timeout_seconds": 3},
+		{"name": "optimism", "type": "blockscout", "url": "https://optimism.blockscout.com"},
+		{"name": "openexchangerates", "type": "openexchangerates", "url": "https://oxr.example.com/latest.json", "api_key": "app-123"}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseSourcesJSON() error = %v", err)
+	}
+
+	gas := registry.GasSources()
+	solana := gas["solana"].(*SolanaGasSource)
+	if solana.baseURL != "https://rpc.example.com/?api-key=secret" || solana.client.Timeout != 3*time.Second {
+		t.Errorf("solana = %s with %v timeout, want the private RPC with 3s", solana.baseURL, solana.client.Timeout)
+	}
+	if _, ok := gas["optimism"].(*BlockscoutGasSource); !ok {
+		t.Errorf("optimism gas source = %T, want *BlockscoutGasSource", gas["optimism"])
+	}
+	if _, ok := gas["base"]; !ok {
+		t.Error("built-in base source dropped by an unrelated override")
+	}
+
+	want := fx.Endpoint{URL: "https://oxr.example.com/latest.json", Timeout: defaultSourceTimeout, APIKey: "app-123"}
+	if got := registry.FXEndpoints()[fx.SourceOpenExchangeRates]; got != want {
+		t.Errorf("FX endpoint = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseSourcesJSONRejectsInvalidDefinitions(t *testing.T) {
+	cases := map[string]string{
+		"unknown type":       `[{"name": "base", "type": "etherscan", "url": "https://example.com"}]`,
+		"missing name":       `[{"type": "blockscout", "url": "https://example.com"}]`,
+		"missing url":        `[{"name": "base", "type": "blockscout"}]`,
+		"non-http url":       `[{"name": "base", "type": "blockscout", "url": "ftp://example.com"}]`,
+		"negative timeout":   `[{"name": "base", "type": "blockscout", "url": "https://example.com", "timeout_seconds": -1}]`,
+		"key on keyless api": `[{"name": "solana", "type": "solana-rpc", "url": "https://example.com", "api_key": "k"}]`,
+		"not an array":       `{"name": "base"}`,
+	}
+	for name, body := range cases {
+		if _, err := ParseSourcesJSON([]byte(body)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSourceAPIKeyPlacement(t *testing.T) {
+	var gotQuery, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("apikey")
+		gotHeader = r.Header.Get("x-cg-pro-api-key")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ethereum": {"usd": 3000}, "gas_prices": {"average": 1}, "coin_price": "1"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	registry, err := NewSourceRegistry([]SourceDefinition{
+		{Name: "base", Type: SourceTypeBlockscout, URL: server.URL, APIKey: "explorer-key"},
+		{Name: "coingecko-pro", Type: SourceTypeCoinGecko, URL: server.URL, APIKey: "cg-key"},
+	})
+	if err != nil {
+		t.Fatalf("NewSourceRegistry() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := registry.GasSources()["base"].Fetch(ctx); err != nil {
+		t.Fatalf("gas Fetch() error = %v", err)
+	}
+	if gotQuery != "explorer-key" || gotHeader != "" {
+		t.Errorf("blockscout sent query %q header %q, want the key as the apikey parameter", gotQuery, gotHeader)
+	}
+
+	if _, err := registry.TokenPriceSource().Fetch(ctx); err != nil {
+		t.Fatalf("token price Fetch() error = %v", err)
+	}
+	if gotHeader != "cg-key" || gotQuery != "" {
+		t.Errorf("coingecko sent query %q header %q, want the key in x-cg-pro-api-key", gotQuery, gotHeader)
+	}
+}
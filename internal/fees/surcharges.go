@@ -0,0 +1,24 @@
+package fees
+
+import (
+	"crypto-conversion/internal/corridors"
+)
+
+// SurchargeFee is a regulatory surcharge included in a FeeResult's FeeAmount
+type SurchargeFee struct {
+	Name   string `json:"name" dynamodbav:"name"`
+	Amount int64  `json:"amount" dynamodbav:"amount"` // Minor units of the source currency
+}
+
+// applySurcharges adds the corridor's regulatory surcharges to result's fee
+// They're added last so negotiated pricing, volume discounts and fee limits only shape the platform fee.
+func (c *Calculator) applySurcharges(result *FeeResult, corridor corridors.Corridor) *FeeResult {
+	for _, surcharge := range corridor.Surcharges {
+		amount := surcharge.Amount(result.BaseAmount)
+		result.Surcharges = append(result.Surcharges, SurchargeFee{Name: surcharge.Name, Amount: amount})
+		result.Surcharge += amount
+		result.FeeAmount += amount
+	}
+	result.TotalAmount = result.BaseAmount + result.FeeAmount
+	return result
+}
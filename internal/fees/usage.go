@@ -0,0 +1,66 @@
+package fees
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/models"
+)
+
+// aiUsage builds the usage record for a model response, or nil if no response came back
+func aiUsage(req *AIFeeRequest, resp *LLMResponse) *models.AIUsage {
+	if resp == nil {
+		return nil
+	}
+	return &models.AIUsage{
+		Model:        resp.Model,
+		Corridor:     strings.ToUpper(req.FromCurrency) + "-" + strings.ToUpper(req.ToCurrency),
+		Customer:     req.Customer,
+		Calls:        1,
+		InputTokens:  resp.InputTokens,
+		OutputTokens: resp.OutputTokens,
+		CostUSD:      estimateCost(resp.Model, resp.InputTokens, resp.OutputTokens),
+	}
+}
+
+// BuildAICostReport aggregates payment AI usage by corridor and customer, most expensive first
+func BuildAICostReport(usages []*models.AIUsage, since, until time.Time) *models.AICostReport {
+	report := &models.AICostReport{
+		Since:  since,
+		Until:  until,
+		Groups: []models.AICostGroup{},
+	}
+
+	groups := make(map[string]*models.AICostGroup)
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		key := usage.Corridor + "|" + usage.Customer
+		group, ok := groups[key]
+		if !ok {
+			group = &models.AICostGroup{Corridor: usage.Corridor, Customer: usage.Customer}
+			groups[key] = group
+		}
+		group.Payments++
+		group.Calls += usage.Calls
+		group.InputTokens += usage.InputTokens
+		group.OutputTokens += usage.OutputTokens
+		group.CostUSD += usage.CostUSD
+
+		report.Payments++
+		report.TotalCostUSD += usage.CostUSD
+	}
+
+	for _, group := range groups {
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].CostUSD != report.Groups[j].CostUSD {
+			return report.Groups[i].CostUSD > report.Groups[j].CostUSD
+		}
+		return report.Groups[i].Corridor+report.Groups[i].Customer < report.Groups[j].Corridor+report.Groups[j].Customer
+	})
+	return report
+}
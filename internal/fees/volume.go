@@ -0,0 +1,157 @@
+package fees
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// VolumeTier is the platform fee rate for customers above a rolling 30-day volume
+type VolumeTier struct {
+	MinVolume int64   `json:"min_volume"` // USD cents
+	Rate      float64 `json:"rate"`
+}
+
+// VolumeStore reads customers' rolling payment volume
+type VolumeStore interface {
+	RollingVolume(ctx context.Context, customerID string, since time.Time) (int64, error)
+}
+
+// volumeDiscounts holds the tiers in ascending MinVolume order
+type volumeDiscounts struct {
+	store VolumeStore
+	tiers []VolumeTier
+}
+
+// PricingStatus is a customer's current volume and discount tier, returned by GET /pricing
+type PricingStatus struct {
+	CustomerID        string       `json:"customer_id"`
+	Volume            int64        `json:"volume"` // USD cents completed in the window
+	WindowDays        int          `json:"window_days"`
+	CurrentTier       *VolumeTier  `json:"current_tier"` // Null below the first tier
+	NextTier          *VolumeTier  `json:"next_tier"`    // Null at the top tier
+	Tiers             []VolumeTier `json:"tiers"`
+	NegotiatedPricing bool         `json:"negotiated_pricing"` // Negotiated pricing replaces volume tiers
+}
+
+// ParseVolumeTiers parses "volume=rate" pairs, e.g. "10000000=0.022,100000000=0.018"
+func ParseVolumeTiers(spec string) ([]VolumeTier, error) {
+	var tiers []VolumeTier
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		volume, rate, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid volume tier %q: expected volume=rate", pair)
+		}
+		minVolume, err := strconv.ParseInt(strings.TrimSpace(volume), 10, 64)
+		if err != nil || minVolume <= 0 {
+			return nil, fmt.Errorf("invalid volume tier %q: volume must be a positive amount in cents", pair)
+		}
+		feeRate, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || feeRate < 0 || feeRate >= 1 {
+			return nil, fmt.Errorf("invalid volume tier %q: rate must be at least 0 and below 1", pair)
+		}
+		tiers = append(tiers, VolumeTier{MinVolume: minVolume, Rate: feeRate})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinVolume < tiers[j].MinVolume })
+	return tiers, nil
+}
+
+// EnableVolumeDiscounts lowers the platform fee rate for customers above a volume tier
+func (c *Calculator) EnableVolumeDiscounts(store VolumeStore, tiers []VolumeTier) {
+	c.volumes = &volumeDiscounts{store: store, tiers: tiers}
+}
+
+// tierFor returns the highest tier volume reaches, and the tier after it
+func (v *volumeDiscounts) tierFor(volume int64) (current, next *VolumeTier) {
+	for i := range v.tiers {
+		if volume < v.tiers[i].MinVolume {
+			return current, &v.tiers[i]
+		}
+		current = &v.tiers[i]
+	}
+	return current, nil
+}
+
+// PricingStatus returns a customer's rolling volume and the discount tier it earns
+func (c *Calculator) PricingStatus(ctx context.Context, customerID string) (*PricingStatus, error) {
+	_, negotiated := c.customers[customerID]
+	status := &PricingStatus{
+		CustomerID:        customerID,
+		WindowDays:        int(models.VolumeWindow / (24 * time.Hour)),
+		Tiers:             []VolumeTier{},
+		NegotiatedPricing: negotiated,
+	}
+	if c.volumes == nil || customerID == "" {
+		return status, nil
+	}
+
+	volume, err := c.volumes.store.RollingVolume(ctx, customerID, time.Now().Add(-models.VolumeWindow))
+	if err != nil {
+		return nil, err
+	}
+	status.Volume = volume
+	status.Tiers = c.volumes.tiers
+	status.CurrentTier, status.NextTier = c.volumes.tierFor(volume)
+	return status, nil
+}
+
+// applyVolumeDiscount lowers result's rate to the customer's volume tier, unless negotiated pricing already applied
+// The customer is the first of customers that is set, matching models.CustomerID.
+func (c *Calculator) applyVolumeDiscount(ctx context.Context, result *FeeResult, customers []string) *FeeResult {
+	if c.volumes == nil || result.PricingCustomer != "" {
+		return result
+	}
+
+	var customerID string
+	for _, customer := range customers {
+		if customer != "" {
+			customerID = customer
+			break
+		}
+	}
+	if customerID == "" {
+		return result
+	}
+
+	volume, err := c.volumes.store.RollingVolume(ctx, customerID, time.Now().Add(-models.VolumeWindow))
+	if err != nil {
+		// Charge the undiscounted fee rather than failing the payment
+		logger.Error("Failed to load customer volume", logger.Fields{
+			"customer_id": customerID,
+			"error":       err.Error(),
+		})
+		metrics.Count("VolumeLookupFailures", metrics.Dimensions{})
+		return result
+	}
+
+	tier, _ := c.volumes.tierFor(volume)
+	if tier == nil || tier.Rate >= result.FeeRate {
+		return result
+	}
+
+	result.FeeRate = tier.Rate
+	result.FeeAmount = int64(math.Round(float64(result.BaseAmount)*tier.Rate)) + result.FixedFee
+	result.TotalAmount = result.BaseAmount + result.FeeAmount
+	result.VolumeTier = tier
+
+	logger.Info("Volume discount applied", logger.Fields{
+		"customer_id": customerID,
+		"volume":      volume,
+		"fee_rate":    tier.Rate,
+		"fee_amount":  result.FeeAmount,
+	})
+	return result
+}
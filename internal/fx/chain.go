@@ -0,0 +1,246 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+)
+
+// Failover defaults
+const (
+	DefaultFailureThreshold    = 3               // Consecutive failures before a source is benched
+	DefaultCooldown            = 5 * time.Minute // How long a benched source is skipped
+	DefaultDivergenceThreshold = 0.01            // 1% disagreement between sources is flagged
+)
+
+// divergenceCurrencies are compared between sources; they cover every supported corridor
+var divergenceCurrencies = []string{"EUR", "GBP"}
+
+// Health is the tracked state of one source
+type Health struct {
+	Source              string    `json:"source"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	BenchedUntil        time.Time `json:"benched_until,omitempty"`
+}
+
+// Chain fetches rates from sources in priority order, failing over when one errors
+// A source that fails FailureThreshold times in a row is skipped for Cooldown, unless every
+// source is benched, in which case all are tried again rather than failing outright.
+type Chain struct {
+	sources             []Source
+	FailureThreshold    int
+	Cooldown            time.Duration
+	DivergenceThreshold float64
+	Verify              bool // Also fetch the next healthy source and compare it with the primary
+
+	mu     sync.Mutex
+	health map[string]*Health
+	now    func() time.Time
+}
+
+// NewChain creates a failover chain over sources, highest priority first
+func NewChain(sources ...Source) *Chain {
+	health := make(map[string]*Health, len(sources))
+	for _, s := range sources {
+		health[s.Name()] = &Health{Source: s.Name(), Healthy: true}
+	}
+	return &Chain{
+		sources:             sources,
+		FailureThreshold:    DefaultFailureThreshold,
+		Cooldown:            DefaultCooldown,
+		DivergenceThreshold: DefaultDivergenceThreshold,
+		health:              health,
+		now:                 time.Now,
+	}
+}
+
+// NewChainFromNames builds a chain from source names in priority order, e.g. "ecb,exchangerate-api"
+// endpoints may point a source at another URL, timeout or app ID, keyed by source name.
+// Open Exchange Rates is skipped when no app ID is configured.
+func NewChainFromNames(names, openExchangeRatesAppID string, endpoints map[string]Endpoint) (*Chain, error) {
+	var sources []Source
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		endpoint := endpoints[name]
+		switch name {
+		case SourceExchangeRateAPI:
+			source := NewExchangeRateAPISource()
+			source.apply(endpoint)
+			sources = append(sources, source)
+		case SourceECB:
+			source := NewECBSource()
+			source.apply(endpoint)
+			sources = append(sources, source)
+		case SourceOpenExchangeRates:
+			appID := openExchangeRatesAppID
+			if endpoint.APIKey != "" {
+				appID = endpoint.APIKey
+			}
+			if appID == "" {
+				logger.Warn("Skipping Open Exchange Rates FX source: no app ID configured", logger.Fields{})
+				continue
+			}
+			if endpoint.URL != "" {
+				endpoint.URL += "?app_id=" + url.QueryEscape(appID)
+			}
+			source := NewOpenExchangeRatesSource(appID)
+			source.apply(endpoint)
+			sources = append(sources, source)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown FX source: %s", name)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no FX sources configured")
+	}
+	return NewChain(sources...), nil
+}
+
+// Rates returns rates from the highest-priority healthy source
+func (c *Chain) Rates(ctx context.Context) (*Rates, error) {
+	var errs []string
+	for i, source := range c.candidates() {
+		rates, err := source.Fetch(ctx)
+		c.record(source.Name(), err)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", source.Name(), err.Error()))
+			continue
+		}
+
+		if i > 0 {
+			metrics.Count("FXFailovers", metrics.Dimensions{"Source": source.Name()})
+			logger.Warn("FX rates served by fallback source", logger.Fields{
+				"source": source.Name(),
+				"errors": strings.Join(errs, "; "),
+			})
+		}
+
+		if c.Verify {
+			c.verify(ctx, rates)
+		}
+		return rates, nil
+	}
+
+	return nil, fmt.Errorf("all FX sources failed: %s", strings.Join(errs, "; "))
+}
+
+// Health returns the tracked state of every source in priority order
+func (c *Chain) Health() []Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]Health, 0, len(c.sources))
+	for _, s := range c.sources {
+		h := *c.health[s.Name()]
+		h.Healthy = !c.benched(&h)
+		result = append(result, h)
+	}
+	return result
+}
+
+// candidates returns the sources to try, healthy ones first in priority order
+func (c *Chain) candidates() []Source {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var healthy, benched []Source
+	for _, s := range c.sources {
+		if c.benched(c.health[s.Name()]) {
+			benched = append(benched, s)
+		} else {
+			healthy = append(healthy, s)
+		}
+	}
+	if len(healthy) == 0 {
+		return benched
+	}
+	return healthy
+}
+
+// benched reports whether a source is sitting out its cooldown; callers hold mu
+func (c *Chain) benched(h *Health) bool {
+	return c.now().Before(h.BenchedUntil)
+}
+
+// record updates a source's health after a fetch
+func (c *Chain) record(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.health[name]
+	if err == nil {
+		h.ConsecutiveFailures = 0
+		h.LastError = ""
+		h.LastSuccess = c.now()
+		h.BenchedUntil = time.Time{}
+		return
+	}
+
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	metrics.Count("FXSourceFailures", metrics.Dimensions{"Source": name})
+	if h.ConsecutiveFailures >= c.FailureThreshold {
+		h.BenchedUntil = c.now().Add(c.Cooldown)
+		logger.Warn("FX source benched after repeated failures", logger.Fields{
+			"source":        name,
+			"failures":      h.ConsecutiveFailures,
+			"benched_until": h.BenchedUntil.Format(time.RFC3339),
+			"error":         h.LastError,
+		})
+	}
+}
+
+// verify fetches a second source and flags the primary's rates if they diverge
+func (c *Chain) verify(ctx context.Context, primary *Rates) {
+	for _, source := range c.candidates() {
+		if source.Name() == primary.Source {
+			continue
+		}
+
+		secondary, err := source.Fetch(ctx)
+		c.record(source.Name(), err)
+		if err != nil {
+			continue
+		}
+
+		if divergence, currency := Divergence(primary, secondary); divergence > c.DivergenceThreshold {
+			primary.Diverged = true
+			metrics.Count("FXSourceDivergence", metrics.Dimensions{"Currency": currency})
+			logger.Warn("FX sources disagree", logger.Fields{
+				"primary":    primary.Source,
+				"secondary":  secondary.Source,
+				"currency":   currency,
+				"divergence": divergence,
+			})
+		}
+		return
+	}
+}
+
+// Divergence returns the largest relative difference between two snapshots and the currency it was seen on
+func Divergence(a, b *Rates) (float64, string) {
+	var worst float64
+	var worstCurrency string
+	for _, currency := range divergenceCurrencies {
+		rateA, okA := a.Rates[currency]
+		rateB, okB := b.Rates[currency]
+		if !okA || !okB || rateA <= 0 {
+			continue
+		}
+		if d := math.Abs(rateA-rateB) / rateA; d > worst {
+			worst, worstCurrency = d, currency
+		}
+	}
+	return worst, worstCurrency
+}
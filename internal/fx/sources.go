@@ -0,0 +1,249 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"crypto-conversion/internal/tracing"
+)
+
+// Source names, used in FX_SOURCES to set the failover order
+const (
+	SourceExchangeRateAPI   = "exchangerate-api"
+	SourceECB               = "ecb"
+	SourceOpenExchangeRates = "openexchangerates"
+)
+
+// Built-in source URLs
+const (
+	exchangeRateAPIURL   = "https://api.exchangerate-api.com/v4/latest/USD"
+	ecbURL               = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	openExchangeRatesURL = "https://openexchangerates.org/api/latest.json"
+)
+
+// Endpoint overrides where a source fetches from; zero fields keep the source's defaults
+type Endpoint struct {
+	URL     string
+	Timeout time.Duration
+	APIKey  string // Open Exchange Rates app ID; the other sources take no key
+}
+
+// Rates is a snapshot of exchange rates quoted as units of currency per 1 USD
+type Rates struct {
+	Source    string             `json:"source"`
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+	Diverged  bool               `json:"diverged,omitempty"` // A verifying source disagreed beyond the threshold
+}
+
+// Rate returns units of to per unit of from, crossing through USD
+func (r *Rates) Rate(from, to string) (float64, error) {
+	fromRate, err := r.perUSD(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.perUSD(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// perUSD returns units of currency per USD
+func (r *Rates) perUSD(currency string) (float64, error) {
+	if currency == "USD" {
+		return 1, nil
+	}
+	rate, ok := r.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%s has no %s rate", r.Source, currency)
+	}
+	return rate, nil
+}
+
+// Source fetches USD-based exchange rates from one upstream provider
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) (*Rates, error)
+}
+
+// httpSource holds the HTTP plumbing shared by every source
+type httpSource struct {
+	client *http.Client
+	url    string
+}
+
+func newHTTPSource(url string) httpSource {
+	return httpSource{
+		client: tracing.HTTPClient(&http.Client{Timeout: 10 * time.Second}),
+		url:    url,
+	}
+}
+
+// apply points the source at endpoint
+func (h *httpSource) apply(endpoint Endpoint) {
+	if endpoint.URL != "" {
+		h.url = endpoint.URL
+	}
+	if endpoint.Timeout > 0 {
+		h.client.Timeout = endpoint.Timeout
+	}
+}
+
+// get fetches the source URL and returns the response body
+func (h httpSource) get(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// ExchangeRateAPISource fetches rates from exchangerate-api.com (free, no key)
+type ExchangeRateAPISource struct {
+	httpSource
+}
+
+// NewExchangeRateAPISource creates the exchangerate-api.com source
+func NewExchangeRateAPISource() *ExchangeRateAPISource {
+	return &ExchangeRateAPISource{newHTTPSource(exchangeRateAPIURL)}
+}
+
+// Name implements Source
+func (s *ExchangeRateAPISource) Name() string { return SourceExchangeRateAPI }
+
+// Fetch implements Source
+func (s *ExchangeRateAPISource) Fetch(ctx context.Context) (*Rates, error) {
+	body, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if response.Base != "USD" || len(response.Rates) == 0 {
+		return nil, fmt.Errorf("invalid response: base %q with %d rates", response.Base, len(response.Rates))
+	}
+
+	return &Rates{Source: s.Name(), Rates: response.Rates, FetchedAt: time.Now()}, nil
+}
+
+// ECBSource fetches the European Central Bank's daily reference rates (EUR-based, no key)
+type ECBSource struct {
+	httpSource
+}
+
+// NewECBSource creates the ECB reference rate source
+func NewECBSource() *ECBSource {
+	return &ECBSource{newHTTPSource(ecbURL)}
+}
+
+// Name implements Source
+func (s *ECBSource) Name() string { return SourceECB }
+
+// Fetch implements Source
+func (s *ECBSource) Fetch(ctx context.Context) (*Rates, error) {
+	body, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube>Cube>Cube"`
+	}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode XML: %w", err)
+	}
+
+	perEUR := make(map[string]float64, len(envelope.Rates))
+	for _, r := range envelope.Rates {
+		if rate, err := strconv.ParseFloat(r.Rate, 64); err == nil && rate > 0 {
+			perEUR[r.Currency] = rate
+		}
+	}
+
+	rates, err := rebaseEURToUSD(perEUR)
+	if err != nil {
+		return nil, err
+	}
+	return &Rates{Source: s.Name(), Rates: rates, FetchedAt: time.Now()}, nil
+}
+
+// rebaseEURToUSD converts ECB rates (units per EUR) to units per USD
+func rebaseEURToUSD(perEUR map[string]float64) (map[string]float64, error) {
+	usdPerEUR, ok := perEUR["USD"]
+	if !ok {
+		return nil, fmt.Errorf("invalid response: no USD reference rate")
+	}
+
+	rates := make(map[string]float64, len(perEUR)+1)
+	for currency, rate := range perEUR {
+		rates[currency] = rate / usdPerEUR
+	}
+	rates["EUR"] = 1 / usdPerEUR
+	rates["USD"] = 1
+	return rates, nil
+}
+
+// OpenExchangeRatesSource fetches rates from openexchangerates.org (requires an app ID)
+type OpenExchangeRatesSource struct {
+	httpSource
+}
+
+// NewOpenExchangeRatesSource creates the Open Exchange Rates source
+func NewOpenExchangeRatesSource(appID string) *OpenExchangeRatesSource {
+	return &OpenExchangeRatesSource{newHTTPSource(openExchangeRatesURL + "?app_id=" + url.QueryEscape(appID))}
+}
+
+// Name implements Source
+func (s *OpenExchangeRatesSource) Name() string { return SourceOpenExchangeRates }
+
+// Fetch implements Source
+func (s *OpenExchangeRatesSource) Fetch(ctx context.Context) (*Rates, error) {
+	body, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if response.Base != "USD" || len(response.Rates) == 0 {
+		return nil, fmt.Errorf("invalid response: base %q with %d rates", response.Base, len(response.Rates))
+	}
+
+	return &Rates{Source: s.Name(), Rates: response.Rates, FetchedAt: time.Now()}, nil
+}
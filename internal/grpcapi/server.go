@@ -0,0 +1,346 @@
+// Package grpcapi serves CreatePayment, GetPayment and GenerateQuote over gRPC for internal consumers
+// such as risk and treasury. Each call is translated into the API Gateway request of its REST endpoint
+// and run through the same handler, so validation, idempotency, pricing and error codes can't drift
+// between the two APIs. Callers authenticate with an issued API key, as REST callers do.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/grpcapi/paymentsv1"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+)
+
+// Metadata keys read from incoming calls
+const (
+	// MetadataAPIKey is the issued API key (sk_...) the caller authenticates with, as X-Api-Key is for REST
+	// The customer it was issued to scopes idempotency keys and selects negotiated pricing.
+	MetadataAPIKey = "x-api-key"
+
+	// MetadataErrorCode is the trailer carrying the REST error code (e.g. QUOTE_EXPIRED) of a failed call
+	MetadataErrorCode = "x-error-code"
+
+	// MetadataErrorRetryable ("true" or "false") and MetadataErrorDocID carry the code's error catalog entry
+	MetadataErrorRetryable = "x-error-retryable"
+	MetadataErrorDocID     = "x-error-doc-id"
+)
+
+// Authenticator looks up the usable API key with a secret, returning nil if there is none
+// It's satisfied by apikeys.Service, so revoked and expired keys are refused as they are over REST.
+type Authenticator interface {
+	Authenticate(ctx context.Context, secret string) (*models.APIKey, error)
+}
+
+// HandlerFunc handles an API Gateway request, like the api-handler Lambda's HandleRequest
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Server implements PaymentService on top of the REST handler
+type Server struct {
+	paymentsv1.UnimplementedPaymentServiceServer
+	handle HandlerFunc
+}
+
+// NewServer creates a PaymentService server that runs each call through handle
+func NewServer(handle HandlerFunc) *Server {
+	return &Server{handle: handle}
+}
+
+// Register creates a gRPC server with the payment service registered behind auth
+func Register(handle HandlerFunc, auth Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(AuthInterceptor(auth)))
+	server := grpc.NewServer(opts...)
+	paymentsv1.RegisterPaymentServiceServer(server, NewServer(handle))
+	return server
+}
+
+// ListenAndServe serves the payment service on addr until the listener fails
+// It refuses to start without an authenticator, since callers could otherwise act for any customer.
+func ListenAndServe(addr string, handle HandlerFunc, auth Authenticator) error {
+	if auth == nil {
+		return fmt.Errorf("gRPC payment service requires issued API keys to authenticate callers")
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("Serving gRPC payment service", logger.Fields{"addr": addr})
+	return Register(handle, auth).Serve(lis)
+}
+
+type customerIDKey struct{}
+
+// AuthInterceptor refuses calls without a usable API key in MetadataAPIKey
+// The customer the key was issued to is what the call acts for; nothing the caller asserts is trusted.
+func AuthInterceptor(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var secret string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataAPIKey); len(values) > 0 {
+				secret = values[0]
+			}
+		}
+		if secret == "" || !strings.HasPrefix(secret, models.APIKeySecretPrefix) {
+			metrics.Count("GRPCRequests", metrics.Dimensions{"Method": path.Base(info.FullMethod), "Code": codes.Unauthenticated.String()})
+			return nil, status.Error(codes.Unauthenticated, "An API key is required")
+		}
+
+		key, err := auth.Authenticate(ctx, secret)
+		if err != nil {
+			logger.Error("Failed to check API key", logger.Fields{"method": info.FullMethod, "error": err.Error()})
+			return nil, status.Error(codes.Internal, "Failed to check API key")
+		}
+		if key == nil {
+			metrics.Count("APIKeyRejections", metrics.Dimensions{})
+			return nil, status.Error(codes.Unauthenticated, "The API key is invalid, expired or revoked")
+		}
+		return handler(context.WithValue(ctx, customerIDKey{}, key.CustomerID), req)
+	}
+}
+
+// CreatePayment creates a payment, like POST /payments
+func (s *Server) CreatePayment(ctx context.Context, req *paymentsv1.CreatePaymentRequest) (*paymentsv1.CreatePaymentResponse, error) {
+	body := models.PaymentRequest{
+		Amount:             req.GetAmount(),
+		Currency:           req.GetCurrency(),
+		SourceCurrency:     req.GetSourceCurrency(),
+		SourceAccount:      req.GetSourceAccount(),
+		DestinationAccount: req.GetDestinationAccount(),
+		QuoteID:            req.GetQuoteId(),
+		PromoCode:          req.GetPromoCode(),
+		Chain:              req.GetChain(),
+		PayoutType:         req.GetPayoutType(),
+		CallbackURL:        req.GetCallbackUrl(),
+	}
+	if b := req.GetBeneficiary(); b != nil {
+		body.Beneficiary = &models.Beneficiary{Name: b.GetName(), Country: b.GetCountry()}
+	}
+
+	var headers map[string]string
+	if req.GetIdempotencyKey() != "" {
+		headers = map[string]string{"Idempotency-Key": req.GetIdempotencyKey()}
+	}
+
+	var created models.PaymentResponse
+	if err := s.call(ctx, "CreatePayment", http.MethodPost, "/payments", "/payments", nil, headers, body, &created); err != nil {
+		return nil, err
+	}
+	return &paymentsv1.CreatePaymentResponse{
+		PaymentId: created.PaymentID,
+		Status:    string(created.Status),
+		Message:   created.Message,
+	}, nil
+}
+
+// GetPayment returns a payment, like GET /payments/{payment_id}
+func (s *Server) GetPayment(ctx context.Context, req *paymentsv1.GetPaymentRequest) (*paymentsv1.Payment, error) {
+	if req.GetPaymentId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "payment_id is required")
+	}
+
+	params := map[string]string{"payment_id": req.GetPaymentId()}
+	var p models.Payment
+	if err := s.call(ctx, "GetPayment", http.MethodGet, "/payments/"+req.GetPaymentId(), "/payments/{payment_id}", params, nil, nil, &p); err != nil {
+		return nil, err
+	}
+	return paymentToProto(&p), nil
+}
+
+// GenerateQuote prices a conversion and holds its rate, like POST /quotes
+func (s *Server) GenerateQuote(ctx context.Context, req *paymentsv1.GenerateQuoteRequest) (*paymentsv1.Quote, error) {
+	body := quotes.QuoteRequest{
+		FromCurrency: req.GetFromCurrency(),
+		ToCurrency:   req.GetToCurrency(),
+		Amount:       req.GetAmount(),
+		PromoCode:    req.GetPromoCode(),
+	}
+
+	var q quotes.QuoteResponse
+	if err := s.call(ctx, "GenerateQuote", http.MethodPost, "/quotes", "/quotes", nil, nil, body, &q); err != nil {
+		return nil, err
+	}
+	return quoteToProto(&q), nil
+}
+
+// call runs one RPC through the REST handler and decodes its successful response into out
+// A body of nil sends no request body.
+func (s *Server) call(ctx context.Context, method, httpMethod, path, resource string, params, headers map[string]string, body, out interface{}) error {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     httpMethod,
+		Path:           path,
+		Resource:       resource,
+		PathParameters: params,
+		Headers:        headers,
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		request.Body = string(data)
+	}
+	// Set only by AuthInterceptor, so the handler applies the customer's IP allowlist, quotas and pricing
+	request.RequestContext.Identity.APIKeyID, _ = ctx.Value(customerIDKey{}).(string)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		request.RequestContext.Identity.SourceIP = sourceIP(p.Addr)
+	}
+
+	resp, err := s.handle(ctx, request)
+	if err != nil {
+		metrics.Count("GRPCRequests", metrics.Dimensions{"Method": method, "Code": codes.Internal.String()})
+		logger.Error("gRPC call failed", logger.Fields{"method": method, "error": err.Error()})
+		return status.Error(codes.Internal, "Internal error")
+	}
+
+	if resp.StatusCode >= 300 {
+		code := statusCode(resp.StatusCode)
+		metrics.Count("GRPCRequests", metrics.Dimensions{"Method": method, "Code": code.String()})
+
+		var errResp errors.ErrorResponse
+		if err := json.Unmarshal([]byte(resp.Body), &errResp); err != nil || errResp.Error.Message == "" {
+			return status.Error(code, http.StatusText(resp.StatusCode))
+		}
+		if errResp.Error.Code != "" {
+			trailer := metadata.Pairs(
+				MetadataErrorCode, errResp.Error.Code,
+				MetadataErrorRetryable, strconv.FormatBool(errResp.Error.Retryable))
+			if errResp.Error.DocID != "" {
+				trailer.Set(MetadataErrorDocID, errResp.Error.DocID)
+			}
+			grpc.SetTrailer(ctx, trailer)
+		}
+		return status.Error(code, errResp.Error.Message)
+	}
+
+	metrics.Count("GRPCRequests", metrics.Dimensions{"Method": method, "Code": codes.OK.String()})
+	if err := json.Unmarshal([]byte(resp.Body), out); err != nil {
+		logger.Error("Failed to decode handler response", logger.Fields{
+			"method": method,
+			"status": strconv.Itoa(resp.StatusCode),
+			"error":  err.Error(),
+		})
+		return status.Error(codes.Internal, "Internal error")
+	}
+	return nil
+}
+
+// sourceIP returns the caller's IP address, or the whole address when it has none (e.g. in-memory listeners)
+// so IP allowlists never match it.
+func sourceIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// statusCode maps a REST status code to the gRPC code with the same meaning
+func statusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusGone, http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// paymentToProto converts a payment as GET /payments/{payment_id} returns it
+func paymentToProto(p *models.Payment) *paymentsv1.Payment {
+	out := &paymentsv1.Payment{
+		PaymentId:              p.PaymentID,
+		IdempotencyKey:         p.IdempotencyKey,
+		Amount:                 p.Amount,
+		Currency:               p.Currency,
+		SourceCurrency:         p.SourceCurrency,
+		SourceAccount:          p.SourceAccount,
+		DestinationAccount:     p.DestinationAccount,
+		Status:                 string(p.Status),
+		FeeAmount:              p.FeeAmount,
+		FeeCurrency:            p.FeeCurrency,
+		QuoteId:                p.QuoteID,
+		GuaranteedPayoutAmount: p.GuaranteedPayoutAmount,
+		PayoutType:             p.PayoutType,
+		Chain:                  p.Chain,
+		OnRampTxId:             p.OnRampTxID,
+		OffRampTxId:            p.OffRampTxID,
+		ErrorMessage:           p.ErrorMessage,
+		CreatedAt:              timestamp(p.CreatedAt),
+		UpdatedAt:              timestamp(p.UpdatedAt),
+	}
+	if p.ProcessedAt != nil {
+		out.ProcessedAt = timestamp(*p.ProcessedAt)
+	}
+	for _, t := range p.StateHistory {
+		out.StateHistory = append(out.StateHistory, &paymentsv1.StateTransition{
+			FromStatus: string(t.FromStatus),
+			ToStatus:   string(t.ToStatus),
+			Timestamp:  timestamp(t.Timestamp),
+			Message:    t.Message,
+		})
+	}
+	return out
+}
+
+// quoteToProto converts a quote as POST /quotes returns it
+func quoteToProto(q *quotes.QuoteResponse) *paymentsv1.Quote {
+	return &paymentsv1.Quote{
+		QuoteId:      q.QuoteID,
+		Amount:       q.Amount,
+		Currency:     q.Currency,
+		ExchangeRate: q.ExchangeRate,
+		Fees: &paymentsv1.QuoteFees{
+			PlatformFee:   q.Fees.PlatformFee,
+			OnrampFee:     q.Fees.OnrampFee,
+			OfframpFee:    q.Fees.OfframpFee,
+			TotalFees:     q.Fees.TotalFees,
+			Currency:      q.Fees.Currency,
+			RegulatoryFee: q.Fees.RegulatoryFee,
+		},
+		GuaranteedPayout: q.GuaranteedPayout,
+		PayoutCurrency:   q.PayoutCurrency,
+		ExpiresAt:        timestamp(q.ExpiresAt),
+		ValidForSeconds:  int32(q.ValidForSeconds),
+		SettlementChain:  q.SettlementChain,
+	}
+}
+
+// timestamp converts a time, leaving unset times unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,185 @@
+// Package health checks the dependencies a service needs to handle requests
+// Liveness (GET /health) reports each dependency's status but succeeds while the process can answer;
+// readiness (GET /ready) fails while any critical dependency is down, so load balancers and canaries
+// stop sending traffic to an instance that can't serve it.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+)
+
+// DefaultTimeout bounds each dependency check
+const DefaultTimeout = 2 * time.Second
+
+// Statuses of a report and of each dependency in it
+const (
+	StatusOK          = "ok"          // Everything checked is up
+	StatusDegraded    = "degraded"    // A non-critical dependency is down
+	StatusUnavailable = "unavailable" // A critical dependency is down
+	StatusDown        = "down"        // The dependency failed its check
+)
+
+// Probe checks one dependency, returning why it can't be used
+type Probe func(ctx context.Context) error
+
+// check is a named dependency probe
+type check struct {
+	name     string
+	critical bool
+	probe    Probe
+}
+
+// Result is one dependency's status
+type Result struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the status of every checked dependency
+type Report struct {
+	Status    string    `json:"status"`
+	Checks    []Result  `json:"checks"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Ready reports whether every critical dependency is up
+func (r *Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Checker runs dependency checks concurrently
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a checker that gives each dependency timeout to answer
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a dependency; a critical dependency that is down makes the service unready
+func (c *Checker) Add(name string, critical bool, probe Probe) {
+	c.checks = append(c.checks, check{name: name, critical: critical, probe: probe})
+}
+
+// Run checks every dependency, in the order they were added
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Status:    StatusOK,
+		Checks:    make([]Result, len(c.checks)),
+		CheckedAt: time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run checks one dependency within the timeout
+func (c *Checker) run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.probe(ctx)
+	result := Result{
+		Name:      chk.name,
+		Status:    StatusOK,
+		Critical:  chk.critical,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		metrics.Count("HealthCheckFailures", metrics.Dimensions{"Dependency": chk.name})
+		logger.Warn("Health check failed", logger.Fields{
+			"dependency": chk.name,
+			"error":      err.Error(),
+		})
+	}
+	return result
+}
+
+// Liveness runs the checks for GET /health, which succeeds whatever the dependencies' status
+func (c *Checker) Liveness(ctx context.Context) (int, *Report) {
+	return http.StatusOK, c.Run(ctx)
+}
+
+// Readiness runs the checks for GET /ready, which fails with 503 while a critical dependency is down
+func (c *Checker) Readiness(ctx context.Context) (int, *Report) {
+	report := c.Run(ctx)
+	if !report.Ready() {
+		return http.StatusServiceUnavailable, report
+	}
+	return http.StatusOK, report
+}
+
+// Handler serves GET /health and GET /ready
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", c.serve(c.Liveness))
+	mux.HandleFunc("/ready", c.serve(c.Readiness))
+	return mux
+}
+
+// serve writes the report of a liveness or readiness check
+func (c *Checker) serve(run func(ctx context.Context) (int, *Report)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		status, report := run(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Serve serves GET /health and GET /ready on addr
+// Intended for server-mode runs behind a load balancer; the server runs until the process exits.
+func Serve(addr string, checker *Checker) *http.Server {
+	server := &http.Server{Addr: addr, Handler: checker.Handler()}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Health check server stopped", logger.Fields{
+				"addr":  addr,
+				"error": err.Error(),
+			})
+		}
+	}()
+	return server
+}
@@ -0,0 +1,235 @@
+// Package jsonschema generates JSON Schemas from request structs and validates raw bodies against them
+// Schemas are generated from the structs' json tags, so they can't drift from what handlers unmarshal.
+// They check JSON types only: which fields are required, and what values they take, is left to
+// internal/validator, which reports it with field codes. Validating before unmarshaling reports
+// every mistyped field by its path (e.g. payments[2].amount) instead of json.Unmarshal's first error.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Draft is the JSON Schema dialect generated schemas declare
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSON types
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Schema is the subset of JSON Schema generated for request bodies
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Minimum    *big.Int           `json:"minimum,omitempty"` // Integer bounds of the field's Go type
+	Maximum    *big.Int           `json:"maximum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+}
+
+// Generate builds the schema of v's type, titled with the type's name
+func Generate(v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := generate(t)
+	s.Schema = Draft
+	s.Title = t.Name()
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generate builds the schema of one Go type
+func generate(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: TypeString, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := uint(t.Bits())
+		min := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), bits-1))
+		max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits-1), big.NewInt(1))
+		return &Schema{Type: TypeInteger, Minimum: min, Maximum: max}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(t.Bits())), big.NewInt(1))
+		return &Schema{Type: TypeInteger, Minimum: big.NewInt(0), Maximum: max}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeArray, Items: generate(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: TypeObject}
+	case reflect.Struct:
+		s := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+		addFields(s, t)
+		return s
+	}
+	return &Schema{} // Interfaces take any value
+}
+
+// addFields adds a struct's exported, JSON-encoded fields to an object schema
+func addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(s, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = generate(field.Type)
+	}
+}
+
+// Validate checks a raw body against the schema, returning every field whose JSON type doesn't match
+// Fields the schema doesn't know are ignored and null is accepted anywhere, as json.Unmarshal
+// ignores and accepts them. A body that isn't JSON at all is returned as an error rather than as field errors.
+func (s *Schema) Validate(body []byte) ([]errors.FieldError, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+
+	var fields []errors.FieldError
+	s.validate("", value, &fields)
+	return fields, nil
+}
+
+// validate appends the type mismatches of value and, for objects and arrays, of its members
+func (s *Schema) validate(path string, value interface{}, fields *[]errors.FieldError) {
+	if value == nil {
+		return
+	}
+
+	switch s.Type {
+	case TypeObject:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+			return
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.property(name); ok {
+				prop.validate(join(path, name), object[name], fields)
+			}
+		}
+	case TypeArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+			return
+		}
+		for i, item := range items {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, fields)
+		}
+	case TypeString:
+		if _, ok := value.(string); !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+		}
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+		}
+	case TypeNumber:
+		if _, ok := value.(json.Number); !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+		}
+	case TypeInteger:
+		n, ok := value.(json.Number)
+		if !ok {
+			*fields = append(*fields, mismatch(path, s.Type))
+			return
+		}
+		i, ok := new(big.Int).SetString(n.String(), 10)
+		if !ok {
+			*fields = append(*fields, mismatch(path, s.Type)) // A fraction or exponent
+			return
+		}
+		if (s.Minimum != nil && i.Cmp(s.Minimum) < 0) || (s.Maximum != nil && i.Cmp(s.Maximum) > 0) {
+			*fields = append(*fields, errors.FieldError{
+				Field:  path,
+				Code:   errors.FieldOutOfRange,
+				Reason: fmt.Sprintf("must be an integer from %s to %s", s.Minimum, s.Maximum),
+			})
+		}
+	}
+}
+
+// property looks up an object property the way json.Unmarshal matches keys: exactly, else case-insensitively
+func (s *Schema) property(name string) (*Schema, bool) {
+	if prop, ok := s.Properties[name]; ok {
+		return prop, true
+	}
+	for key, prop := range s.Properties {
+		if strings.EqualFold(key, name) {
+			return prop, true
+		}
+	}
+	return nil, false
+}
+
+// mismatch is the field error of a value of the wrong JSON type; a body that isn't an object is reported as "body"
+func mismatch(path, want string) errors.FieldError {
+	if path == "" {
+		path = "body"
+	}
+	article := "a"
+	if want == TypeObject || want == TypeArray || want == TypeInteger {
+		article = "an"
+	}
+	return errors.FieldError{Field: path, Code: errors.FieldInvalidType, Reason: fmt.Sprintf("must be %s %s", article, want)}
+}
+
+// join appends an object key to a field path
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
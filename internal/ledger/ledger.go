@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+)
+
+// ErrInvariant is wrapped by every posting the ledger refuses to write
+var ErrInvariant = stderrors.New("ledger invariant violated")
+
+// normalSides are the account types the ledger accepts and the side each one's balance grows on
+var normalSides = map[string]string{
+	models.LedgerCustomerFunds:    models.LedgerCredit,
+	models.LedgerUSDCFloat:        models.LedgerDebit,
+	models.LedgerPayoutsInTransit: models.LedgerDebit,
+	models.LedgerFeeRevenue:       models.LedgerCredit,
+	models.LedgerGasExpense:       models.LedgerDebit,
+}
+
+// perPaymentAccounts hold only a payment's own funds, so a payment can never take them below zero
+var perPaymentAccounts = map[string]bool{
+	models.LedgerCustomerFunds:    true,
+	models.LedgerPayoutsInTransit: true,
+}
+
+// Store persists ledger transactions
+// CreateLedgerTransaction fails with a conflict for a transaction ID that was already written.
+type Store interface {
+	CreateLedgerTransaction(ctx context.Context, txn *models.LedgerTransaction) error
+	ListLedgerTransactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error)
+}
+
+// Ledger records double-entry transactions for payment legs, checking its invariants on every write
+type Ledger struct {
+	store Store
+}
+
+// New creates a ledger backed by store
+func New(store Store) *Ledger {
+	return &Ledger{store: store}
+}
+
+// Post writes a transaction, reporting false if it was already posted
+// The transaction must balance, and must not take the payment's customer funds or payouts in transit
+// below zero. Steps of a payment run under its processing lock, so postings for one payment don't race.
+func (l *Ledger) Post(ctx context.Context, txn *models.LedgerTransaction) (bool, error) {
+	if err := Check(txn); err != nil {
+		return false, err
+	}
+
+	existing, err := l.store.ListLedgerTransactions(ctx, txn.PaymentID)
+	if err != nil {
+		return false, err
+	}
+	for _, posted := range existing {
+		if posted.TransactionID == txn.TransactionID {
+			return false, nil
+		}
+	}
+
+	for _, balance := range Balances(append(existing, txn)) {
+		if perPaymentAccounts[accountType(balance.Account)] && balance.Balance < 0 {
+			return false, fmt.Errorf("%w: %s would leave %s at %d %s", ErrInvariant, txn.TransactionID, balance.Account, balance.Balance, balance.Currency)
+		}
+	}
+
+	if err := l.store.CreateLedgerTransaction(ctx, txn); err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && appErr.Code == "CONFLICT" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Transactions returns a payment's transactions, oldest first
+func (l *Ledger) Transactions(ctx context.Context, paymentID string) ([]*models.LedgerTransaction, error) {
+	return l.store.ListLedgerTransactions(ctx, paymentID)
+}
+
+// Check verifies a transaction on its own: known accounts, positive amounts, and debits equal to credits in each currency
+func Check(txn *models.LedgerTransaction) error {
+	if txn.TransactionID == "" || txn.PaymentID == "" || txn.Leg == "" {
+		return fmt.Errorf("%w: transaction, payment and leg are required", ErrInvariant)
+	}
+	if len(txn.Entries) < 2 {
+		return fmt.Errorf("%w: %s has %d entries, need at least 2", ErrInvariant, txn.TransactionID, len(txn.Entries))
+	}
+
+	net := make(map[string]int64)
+	for _, entry := range txn.Entries {
+		if _, ok := normalSides[entry.AccountType()]; !ok {
+			return fmt.Errorf("%w: %s posts to unknown account %q", ErrInvariant, txn.TransactionID, entry.Account)
+		}
+		if entry.Amount <= 0 {
+			return fmt.Errorf("%w: %s posts %d to %s, amounts must be positive", ErrInvariant, txn.TransactionID, entry.Amount, entry.Account)
+		}
+		if entry.Currency == "" {
+			return fmt.Errorf("%w: %s posts to %s without a currency", ErrInvariant, txn.TransactionID, entry.Account)
+		}
+
+		switch entry.Side {
+		case models.LedgerDebit:
+			net[entry.Currency] += entry.Amount
+		case models.LedgerCredit:
+			net[entry.Currency] -= entry.Amount
+		default:
+			return fmt.Errorf("%w: %s has entry side %q", ErrInvariant, txn.TransactionID, entry.Side)
+		}
+	}
+
+	for currency, diff := range net {
+		if diff != 0 {
+			return fmt.Errorf("%w: %s debits and credits differ by %d %s", ErrInvariant, txn.TransactionID, diff, currency)
+		}
+	}
+	return nil
+}
+
+// Balances nets transactions into a balance per account and currency, ordered by account
+func Balances(txns []*models.LedgerTransaction) []models.LedgerBalance {
+	type key struct{ account, currency string }
+	totals := make(map[key]*models.LedgerBalance)
+	var keys []key
+
+	for _, txn := range txns {
+		for _, entry := range txn.Entries {
+			k := key{entry.Account, entry.Currency}
+			balance, ok := totals[k]
+			if !ok {
+				balance = &models.LedgerBalance{Account: entry.Account, Currency: entry.Currency}
+				totals[k] = balance
+				keys = append(keys, k)
+			}
+			if entry.Side == models.LedgerDebit {
+				balance.Debits += entry.Amount
+			} else {
+				balance.Credits += entry.Amount
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].currency < keys[j].currency
+	})
+
+	balances := make([]models.LedgerBalance, 0, len(keys))
+	for _, k := range keys {
+		balance := *totals[k]
+		balance.Balance = balance.Debits - balance.Credits
+		if normalSides[accountType(balance.Account)] == models.LedgerCredit {
+			balance.Balance = -balance.Balance
+		}
+		balances = append(balances, balance)
+	}
+	return balances
+}
+
+// accountType strips the chain qualifier from an account
+func accountType(account string) string {
+	return models.LedgerEntry{Account: account}.AccountType()
+}
+
+// GasCosts normalizes configured per-chain gas costs, in USDC minor units, to lower-case chain names
+func GasCosts(raw map[string]int) map[string]int64 {
+	costs := make(map[string]int64, len(raw))
+	for chain, cost := range raw {
+		costs[strings.ToLower(chain)] = int64(cost)
+	}
+	return costs
+}
@@ -0,0 +1,199 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// amounts is a 1,000.00 USDC payment earning 12.50 in fees
+var amounts = ledger.Amounts{Funds: 100000, Fee: 1250}
+
+func transaction(leg string, entries []models.LedgerEntry) *models.LedgerTransaction {
+	return &models.LedgerTransaction{
+		TransactionID: "pay_1:" + leg,
+		PaymentID:     "pay_1",
+		Leg:           leg,
+		Entries:       entries,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// net sums an entry list per currency, debits positive
+func net(entries []models.LedgerEntry) map[string]int64 {
+	totals := make(map[string]int64)
+	for _, entry := range entries {
+		if entry.Side == models.LedgerDebit {
+			totals[entry.Currency] += entry.Amount
+		} else {
+			totals[entry.Currency] -= entry.Amount
+		}
+	}
+	return totals
+}
+
+func TestLegsBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.LedgerEntry
+		lines   int
+	}{
+		{"onramp", ledger.Onramp("Base", amounts), 2},
+		{"bridge", ledger.Bridge("base", "polygon", amounts, 3), 4},
+		{"bridge without gas", ledger.Bridge("base", "polygon", amounts, 0), 2},
+		{"payout", ledger.Payout("base", amounts, 1), 4},
+		{"completed", ledger.Completed(amounts), 3},
+		{"completed without fees", ledger.Completed(ledger.Amounts{Funds: 100000}), 2},
+		{"payout released", ledger.PayoutReleased("base", amounts), 2},
+		{"reversal", ledger.Reversal("polygon", amounts), 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, tt.entries, tt.lines)
+			for currency, diff := range net(tt.entries) {
+				assert.Zero(t, diff, "debits and credits differ in %s", currency)
+			}
+			assert.NoError(t, ledger.Check(transaction(tt.name, tt.entries)))
+		})
+	}
+}
+
+func TestCheckRejectsInvalidTransactions(t *testing.T) {
+	debit := func(account string, amount int64, currency string) models.LedgerEntry {
+		return models.LedgerEntry{Account: account, Side: models.LedgerDebit, Amount: amount, Currency: currency}
+	}
+	credit := func(account string, amount int64, currency string) models.LedgerEntry {
+		return models.LedgerEntry{Account: account, Side: models.LedgerCredit, Amount: amount, Currency: currency}
+	}
+
+	tests := []struct {
+		name    string
+		entries []models.LedgerEntry
+		want    string
+	}{
+		{
+			name:    "unbalanced",
+			entries: []models.LedgerEntry{debit("usdc_float:base", 100, "USDC"), credit(models.LedgerCustomerFunds, 99, "USDC")},
+			want:    "differ by 1 USDC",
+		},
+		{
+			name:    "balanced only across currencies",
+			entries: []models.LedgerEntry{debit("usdc_float:base", 100, "USDC"), credit(models.LedgerCustomerFunds, 100, "EUR")},
+			want:    "debits and credits differ",
+		},
+		{
+			name:    "single entry",
+			entries: []models.LedgerEntry{debit("usdc_float:base", 100, "USDC")},
+			want:    "need at least 2",
+		},
+		{
+			name:    "unknown account",
+			entries: []models.LedgerEntry{debit("suspense", 100, "USDC"), credit(models.LedgerCustomerFunds, 100, "USDC")},
+			want:    "unknown account",
+		},
+		{
+			name:    "negative amount",
+			entries: []models.LedgerEntry{debit("usdc_float:base", -100, "USDC"), credit(models.LedgerCustomerFunds, -100, "USDC")},
+			want:    "amounts must be positive",
+		},
+		{
+			name:    "missing currency",
+			entries: []models.LedgerEntry{debit("usdc_float:base", 100, ""), credit(models.LedgerCustomerFunds, 100, "")},
+			want:    "without a currency",
+		},
+		{
+			name: "unknown side",
+			entries: []models.LedgerEntry{
+				debit("usdc_float:base", 100, "USDC"),
+				{Account: models.LedgerCustomerFunds, Side: "both", Amount: 100, Currency: "USDC"},
+			},
+			want: "entry side",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ledger.Check(transaction("onramp", tt.entries))
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ledger.ErrInvariant)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestPostIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryLedgerRepository()
+	l := ledger.New(store)
+
+	posted, err := l.Post(ctx, transaction(models.LedgerLegOnramp, ledger.Onramp("base", amounts)))
+	require.NoError(t, err)
+	assert.True(t, posted)
+
+	// A retried step posts the same leg again
+	posted, err = l.Post(ctx, transaction(models.LedgerLegOnramp, ledger.Onramp("base", amounts)))
+	require.NoError(t, err)
+	assert.False(t, posted, "a leg is recorded once")
+
+	txns, err := l.Transactions(ctx, "pay_1")
+	require.NoError(t, err)
+	require.Len(t, txns, 1)
+	assert.Equal(t, "pay_1:onramp", txns[0].TransactionID)
+}
+
+func TestPostRejectsUnbalancedAndOverdrawnLegs(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryLedgerRepository()
+	l := ledger.New(store)
+
+	// Nothing has been received for the payment, so it can't be discharged
+	_, err := l.Post(ctx, transaction(models.LedgerLegCompleted, ledger.Completed(amounts)))
+	assert.ErrorIs(t, err, ledger.ErrInvariant)
+	assert.Contains(t, err.Error(), models.LedgerCustomerFunds)
+
+	unbalanced := ledger.Onramp("base", amounts)
+	unbalanced[0].Amount++
+	_, err = l.Post(ctx, transaction(models.LedgerLegOnramp, unbalanced))
+	assert.ErrorIs(t, err, ledger.ErrInvariant)
+
+	txns, err := l.Transactions(ctx, "pay_1")
+	require.NoError(t, err)
+	assert.Empty(t, txns, "refused postings are not written")
+}
+
+func TestBalancesOfCompletedPayment(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.New(database.NewMemoryLedgerRepository())
+
+	for _, txn := range []*models.LedgerTransaction{
+		transaction(models.LedgerLegOnramp, ledger.Onramp("base", amounts)),
+		transaction(models.LedgerLegOfframp, ledger.Payout("base", amounts, 2)),
+		transaction(models.LedgerLegCompleted, ledger.Completed(amounts)),
+	} {
+		posted, err := l.Post(ctx, txn)
+		require.NoError(t, err)
+		require.True(t, posted)
+	}
+
+	txns, err := l.Transactions(ctx, "pay_1")
+	require.NoError(t, err)
+
+	balances := make(map[string]int64)
+	for _, balance := range ledger.Balances(txns) {
+		balances[balance.Account] = balance.Balance
+	}
+	assert.Equal(t, map[string]int64{
+		models.LedgerCustomerFunds:    0,
+		models.LedgerPayoutsInTransit: 0,
+		models.LedgerFeeRevenue:       1250,
+		models.LedgerGasExpense:       2,
+		"usdc_float:base":             1248,
+	}, balances)
+}
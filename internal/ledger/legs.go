@@ -0,0 +1,90 @@
+package ledger
+
+import "crypto-conversion/internal/models"
+
+// Currency is what payment legs are recorded in: every payment moves through USDC
+const Currency = "USDC"
+
+// Amounts are what a payment moves, in USDC minor units
+type Amounts struct {
+	Funds int64 // USDC minted for the payment
+	Fee   int64 // Fees earned when the payment completes; never more than Funds
+}
+
+// Payout is what reaches the destination: the funds less the fees
+func (a Amounts) Payout() int64 {
+	return a.Funds - a.Fee
+}
+
+// Onramp records USDC minted on chain and held for the customer
+func Onramp(chain string, a Amounts) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerAccount(models.LedgerUSDCFloat, chain), a.Funds),
+		credit(models.LedgerCustomerFunds, a.Funds),
+	)
+}
+
+// Bridge records the customer's USDC moving between chains, and the gas of the burn on from
+func Bridge(from, to string, a Amounts, gas int64) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerAccount(models.LedgerUSDCFloat, to), a.Funds),
+		credit(models.LedgerAccount(models.LedgerUSDCFloat, from), a.Funds),
+		debit(models.LedgerGasExpense, gas),
+		credit(models.LedgerAccount(models.LedgerUSDCFloat, from), gas),
+	)
+}
+
+// Payout records the payout leaving our wallet on chain for the off-ramp or a wallet destination, and its gas
+// The fees stay in our wallet.
+func Payout(chain string, a Amounts, gas int64) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerPayoutsInTransit, a.Payout()),
+		credit(models.LedgerAccount(models.LedgerUSDCFloat, chain), a.Payout()),
+		debit(models.LedgerGasExpense, gas),
+		credit(models.LedgerAccount(models.LedgerUSDCFloat, chain), gas),
+	)
+}
+
+// Completed records the payout settling, discharging what we held for the customer and earning the fees
+func Completed(a Amounts) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerCustomerFunds, a.Funds),
+		credit(models.LedgerPayoutsInTransit, a.Payout()),
+		credit(models.LedgerFeeRevenue, a.Fee),
+	)
+}
+
+// PayoutReleased records a failed payout returning to our wallet on chain
+func PayoutReleased(chain string, a Amounts) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerAccount(models.LedgerUSDCFloat, chain), a.Payout()),
+		credit(models.LedgerPayoutsInTransit, a.Payout()),
+	)
+}
+
+// Reversal records the customer's funds being redeemed from chain back to the source account
+func Reversal(chain string, a Amounts) []models.LedgerEntry {
+	return entries(
+		debit(models.LedgerCustomerFunds, a.Funds),
+		credit(models.LedgerAccount(models.LedgerUSDCFloat, chain), a.Funds),
+	)
+}
+
+func debit(account string, amount int64) models.LedgerEntry {
+	return models.LedgerEntry{Account: account, Side: models.LedgerDebit, Amount: amount, Currency: Currency}
+}
+
+func credit(account string, amount int64) models.LedgerEntry {
+	return models.LedgerEntry{Account: account, Side: models.LedgerCredit, Amount: amount, Currency: Currency}
+}
+
+// entries drops zero lines, such as gas on chains without a configured cost or fees on a free payment
+func entries(lines ...models.LedgerEntry) []models.LedgerEntry {
+	kept := lines[:0]
+	for _, line := range lines {
+		if line.Amount != 0 {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"crypto-conversion/internal/version"
 )
 
 // Level represents logging levels
@@ -40,6 +42,8 @@ func (l Level) String() string {
 type Logger struct {
 	level  Level
 	logger *log.Logger
+	masker *masker
+	build  string // Git SHA of the running build, on every entry
 }
 
 // Fields represents structured log fields
@@ -56,6 +60,15 @@ func New(level Level) *Logger {
 	return &Logger{
 		level:  level,
 		logger: log.New(os.Stdout, "", 0),
+		masker: newMasker(DefaultMaskedFields),
+		build:  version.Short(),
+	}
+}
+
+// MaskFields replaces the field names whose values are masked; an empty list keeps the current one
+func (l *Logger) MaskFields(fields []string) {
+	if len(fields) > 0 {
+		l.masker = newMasker(fields)
 	}
 }
 
@@ -85,6 +98,7 @@ type logEntry struct {
 	Timestamp string                 `json:"timestamp"`
 	Level     string                 `json:"level"`
 	Message   string                 `json:"message"`
+	GitSHA    string                 `json:"git_sha,omitempty"`
 	Fields    map[string]interface{} `json:"fields,omitempty"`
 }
 
@@ -98,14 +112,15 @@ func (l *Logger) log(level Level, msg string, fields Fields) {
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Level:     level.String(),
 		Message:   msg,
-		Fields:    fields,
+		GitSHA:    l.build,
+		Fields:    l.masker.fields(fields),
 	}
 
 	// Marshal to JSON
 	data, err := json.Marshal(entry)
 	if err != nil {
 		// Fallback to simple logging
-		l.logger.Printf("[%s] %s %v", level.String(), msg, fields)
+		l.logger.Printf("[%s] %s %v", level.String(), msg, entry.Fields)
 		return
 	}
 
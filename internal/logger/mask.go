@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMaskedFields are the field names masked unless a logger is given its own list
+// They cover account identifiers, payout addresses and personal details of customers and beneficiaries.
+var DefaultMaskedFields = []string{
+	"source_account",
+	"destination_account",
+	"account_id",
+	"address",
+	"name",
+	"email",
+	"phone",
+}
+
+// maskVisibleSuffix is how many trailing characters of a masked value stay readable
+const maskVisibleSuffix = 4
+
+// masker replaces the values of denied fields before an entry is written
+// Field names match case-insensitively at any depth, including inside payloads logged as structs or maps.
+type masker struct {
+	denied map[string]bool
+}
+
+// newMasker creates a masker for the field names
+func newMasker(fields []string) *masker {
+	denied := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			denied[field] = true
+		}
+	}
+	return &masker{denied: denied}
+}
+
+// MaskValue partially masks a value, keeping the last four characters of one long enough
+// that they don't give most of it away: "DE89370400440532016789" becomes "****6789".
+func MaskValue(value string) string {
+	if len(value) < 2*maskVisibleSuffix {
+		return "****"
+	}
+	return "****" + value[len(value)-maskVisibleSuffix:]
+}
+
+// fields returns a copy of the fields with denied values masked
+func (m *masker) fields(fields Fields) Fields {
+	if m == nil || len(fields) == 0 || len(m.denied) == 0 {
+		return fields
+	}
+	masked := make(Fields, len(fields))
+	for k, v := range fields {
+		masked[k] = m.value(v, m.denied[strings.ToLower(k)])
+	}
+	return masked
+}
+
+// value masks a field value; a denied value is masked through and through
+func (m *masker) value(v interface{}, denied bool) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if denied {
+			return MaskValue(val)
+		}
+		return val
+	case bool, json.Number, int, int32, int64, uint, uint32, uint64, float32, float64, time.Time, time.Duration:
+		if denied {
+			return MaskValue(fmt.Sprint(val))
+		}
+		return val
+	case Fields:
+		return m.object(val, denied)
+	case map[string]interface{}:
+		return m.object(val, denied)
+	case []interface{}:
+		masked := make([]interface{}, len(val))
+		for i, item := range val {
+			masked[i] = m.value(item, denied)
+		}
+		return masked
+	}
+
+	// Payloads (structs, typed maps and slices) are masked in their JSON form, which is how they're written
+	data, err := json.Marshal(v)
+	if err != nil {
+		if denied {
+			return MaskValue(fmt.Sprint(v))
+		}
+		return v
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep large amounts exact
+	if err := decoder.Decode(&generic); err != nil {
+		return v
+	}
+	return m.value(generic, denied)
+}
+
+// object masks the values of a nested map
+func (m *masker) object(obj map[string]interface{}, denied bool) map[string]interface{} {
+	masked := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		masked[k] = m.value(v, denied || m.denied[strings.ToLower(k)])
+	}
+	return masked
+}
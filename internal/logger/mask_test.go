@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captured logs one entry through l and returns its decoded fields
+func captured(t *testing.T, l *Logger, fields Fields) map[string]interface{} {
+	var buf bytes.Buffer
+	l.logger.SetOutput(&buf)
+	l.Info("test", fields)
+
+	var entry struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	decoder := json.NewDecoder(&buf)
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&entry))
+	return entry.Fields
+}
+
+func TestMaskValue(t *testing.T) {
+	assert.Equal(t, "****6789", MaskValue("DE89370400440532016789"))
+	assert.Equal(t, "****5678", MaskValue("12345678"))
+	assert.Equal(t, "****", MaskValue("1234567"), "short values reveal nothing")
+	assert.Equal(t, "****", MaskValue(""))
+}
+
+func TestLoggerMasksDeniedFields(t *testing.T) {
+	type beneficiary struct {
+		Name    string `json:"name"`
+		Country string `json:"country"`
+	}
+	type payload struct {
+		SourceAccount string       `json:"source_account"`
+		Amount        int64        `json:"amount"`
+		Beneficiary   *beneficiary `json:"beneficiary"`
+	}
+
+	fields := captured(t, New(INFO), Fields{
+		"payment_id":          "pay_1",
+		"destination_account": "GB29NWBK60161331926819",
+		"Address":             "0x52908400098527886E0F7030069857D2E4169EE7",
+		"request": payload{
+			SourceAccount: "acct_0001234567",
+			Amount:        9007199254740993,
+			Beneficiary:   &beneficiary{Name: "Jane Doe", Country: "DE"},
+		},
+	})
+
+	assert.Equal(t, "pay_1", fields["payment_id"])
+	assert.Equal(t, "****6819", fields["destination_account"])
+	assert.Equal(t, "****9EE7", fields["Address"], "field names match case-insensitively")
+
+	request := fields["request"].(map[string]interface{})
+	assert.Equal(t, "****4567", request["source_account"])
+	assert.Equal(t, json.Number("9007199254740993"), request["amount"], "amounts stay exact")
+	nested := request["beneficiary"].(map[string]interface{})
+	assert.Equal(t, "**** Doe", nested["name"])
+	assert.Equal(t, "DE", nested["country"])
+}
+
+func TestLoggerMaskFieldsReplacesDenyList(t *testing.T) {
+	l := New(INFO)
+	l.MaskFields([]string{"iban"})
+
+	fields := captured(t, l, Fields{"iban": "DE89370400440532016789", "source_account": "acct_0001234567"})
+	assert.Equal(t, "****6789", fields["iban"])
+	assert.Equal(t, "acct_0001234567", fields["source_account"])
+
+	l.MaskFields(nil)
+	fields = captured(t, l, Fields{"iban": "DE89370400440532016789"})
+	assert.Equal(t, "****6789", fields["iban"], "an empty list keeps the current one")
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Unit is a CloudWatch metric unit
+type Unit string
+
+const (
+	UnitCount        Unit = "Count"
+	UnitMilliseconds Unit = "Milliseconds"
+	UnitSeconds      Unit = "Seconds"
+	UnitNone         Unit = "None"
+)
+
+// Dimensions are the CloudWatch dimensions attached to a metric
+type Dimensions map[string]string
+
+// Recorder receives every metric the emitter writes, for backends other than CloudWatch
+type Recorder interface {
+	Record(name string, value float64, unit Unit, dims Dimensions)
+}
+
+// Emitter writes metrics as CloudWatch embedded metric format (EMF) log lines
+// CloudWatch Logs extracts the metrics asynchronously, so emitting never blocks on AWS calls.
+type Emitter struct {
+	mu        sync.Mutex
+	out       io.Writer
+	namespace string
+	defaults  Dimensions
+	recorders []Recorder
+}
+
+var defaultEmitter = New(os.Stdout, "CryptoConversion", nil)
+
+// New creates an emitter writing to out with the given namespace and default dimensions
+func New(out io.Writer, namespace string, defaults Dimensions) *Emitter {
+	return &Emitter{
+		out:       out,
+		namespace: namespace,
+		defaults:  defaults,
+	}
+}
+
+// SetDefault sets the default emitter
+func SetDefault(e *Emitter) {
+	defaultEmitter = e
+}
+
+// Default returns the default emitter
+func Default() *Emitter {
+	return defaultEmitter
+}
+
+// AddRecorder mirrors every subsequent metric to r
+func (e *Emitter) AddRecorder(r Recorder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.recorders = append(e.recorders, r)
+}
+
+// Emit writes a single metric value
+func (e *Emitter) Emit(name string, value float64, unit Unit, dims Dimensions) {
+	entry := make(map[string]interface{})
+	keys := make([]string, 0, len(e.defaults)+len(dims))
+
+	for k, v := range e.defaults {
+		entry[k] = v
+		keys = append(keys, k)
+	}
+	for k, v := range dims {
+		if _, ok := entry[k]; !ok {
+			keys = append(keys, k)
+		}
+		entry[k] = v
+	}
+	sort.Strings(keys)
+
+	entry[name] = value
+	entry["_aws"] = map[string]interface{}{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{
+			{
+				"Namespace":  e.namespace,
+				"Dimensions": [][]string{keys},
+				"Metrics": []map[string]string{
+					{"Name": name, "Unit": string(unit)},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.out.Write(append(data, '\n'))
+
+	if len(e.recorders) > 0 {
+		all := make(Dimensions, len(keys))
+		for _, k := range keys {
+			all[k] = entry[k].(string)
+		}
+		for _, r := range e.recorders {
+			r.Record(name, value, unit, all)
+		}
+	}
+}
+
+// Count emits a count of one
+func (e *Emitter) Count(name string, dims Dimensions) {
+	e.Emit(name, 1, UnitCount, dims)
+}
+
+// Duration emits a duration in milliseconds
+func (e *Emitter) Duration(name string, d time.Duration, dims Dimensions) {
+	e.Emit(name, float64(d.Milliseconds()), UnitMilliseconds, dims)
+}
+
+// Package-level convenience functions using the default emitter
+
+// Emit writes a single metric value using the default emitter
+func Emit(name string, value float64, unit Unit, dims Dimensions) {
+	defaultEmitter.Emit(name, value, unit, dims)
+}
+
+// Count emits a count of one using the default emitter
+func Count(name string, dims Dimensions) {
+	defaultEmitter.Count(name, dims)
+}
+
+// Duration emits a duration in milliseconds using the default emitter
+func Duration(name string, d time.Duration, dims Dimensions) {
+	defaultEmitter.Duration(name, d, dims)
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"crypto-conversion/internal/logger"
+)
+
+// PrometheusRecorder mirrors emitted metrics into a Prometheus registry
+// Count and None metrics become counters; durations become histograms in seconds.
+type PrometheusRecorder struct {
+	mu         sync.Mutex
+	registry   *prometheus.Registry
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// NewPrometheusRecorder creates a recorder with its own registry
+func NewPrometheusRecorder() *PrometheusRecorder {
+	return &PrometheusRecorder{
+		registry:   prometheus.NewRegistry(),
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Record implements Recorder
+func (p *PrometheusRecorder) Record(name string, value float64, unit Unit, dims Dimensions) {
+	labels := make(prometheus.Labels, len(dims))
+	for k, v := range dims {
+		labels[snakeCase(k)] = v
+	}
+
+	switch unit {
+	case UnitMilliseconds:
+		p.histogram(snakeCase(name)+"_seconds", labels).With(labels).Observe(value / 1000)
+	case UnitSeconds:
+		p.histogram(snakeCase(name)+"_seconds", labels).With(labels).Observe(value)
+	default:
+		p.counter(snakeCase(name)+"_total", labels).With(labels).Add(value)
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (p *PrometheusRecorder) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// counter returns the counter vector for name, registering it on first use
+func (p *PrometheusRecorder) counter(name string, labels prometheus.Labels) *prometheus.CounterVec {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if vec, ok := p.counters[name]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name}, labelNames(labels))
+	p.registry.MustRegister(vec)
+	p.counters[name] = vec
+	return vec
+}
+
+// histogram returns the histogram vector for name, registering it on first use
+func (p *PrometheusRecorder) histogram(name string, labels prometheus.Labels) *prometheus.HistogramVec {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if vec, ok := p.histograms[name]; ok {
+		return vec
+	}
+
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    name,
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10), // 10ms to ~43 minutes
+	}, labelNames(labels))
+	p.registry.MustRegister(vec)
+	p.histograms[name] = vec
+	return vec
+}
+
+// labelNames returns the sorted label names
+func labelNames(labels prometheus.Labels) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snakeCase converts a CloudWatch metric or dimension name (e.g. "AICallDuration") to "ai_call_duration"
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Break before an upper-case letter that starts a new word, keeping acronyms together
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ServePrometheus mirrors the default emitter into Prometheus and serves /metrics on addr
+// Intended for local, non-Lambda runs (e.g. docker-compose); the server runs until the process exits.
+func ServePrometheus(addr string) *http.Server {
+	recorder := NewPrometheusRecorder()
+	Default().AddRecorder(recorder)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", recorder.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Prometheus metrics server stopped", logger.Fields{
+				"addr":  addr,
+				"error": err.Error(),
+			})
+		}
+	}()
+	return server
+}
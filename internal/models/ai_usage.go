@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// AIUsage is the Claude spend attributed to a fee calculation, quote, or payment
+type AIUsage struct {
+	Model        string  `json:"model" dynamodbav:"model"`
+	Corridor     string  `json:"corridor" dynamodbav:"corridor"`                     // e.g. USD-EUR
+	Customer     string  `json:"customer,omitempty" dynamodbav:"customer,omitempty"` // API key that requested the calculation
+	Calls        int     `json:"calls" dynamodbav:"calls"`                           // Claude API calls, excluding cache hits
+	InputTokens  int     `json:"input_tokens" dynamodbav:"input_tokens"`
+	OutputTokens int     `json:"output_tokens" dynamodbav:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd" dynamodbav:"cost_usd"` // Estimated from published per-token pricing
+}
+
+// Add accumulates other into u, keeping u's model, corridor, and customer when set
+func (u *AIUsage) Add(other *AIUsage) {
+	if other == nil {
+		return
+	}
+	if u.Model == "" {
+		u.Model = other.Model
+	}
+	if u.Corridor == "" {
+		u.Corridor = other.Corridor
+	}
+	if u.Customer == "" {
+		u.Customer = other.Customer
+	}
+	u.Calls += other.Calls
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CostUSD += other.CostUSD
+}
+
+// AICostGroup is the AI spend for one corridor and customer in a cost report
+type AICostGroup struct {
+	Corridor     string  `json:"corridor"`
+	Customer     string  `json:"customer"`
+	Payments     int     `json:"payments"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// AICostReport aggregates AI spend on payments created in a time window
+type AICostReport struct {
+	Since        time.Time     `json:"since"`
+	Until        time.Time     `json:"until"`
+	Payments     int           `json:"payments"`
+	TotalCostUSD float64       `json:"total_cost_usd"`
+	Groups       []AICostGroup `json:"groups"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// AML monitoring rules
+const (
+	AMLRuleStructuring     = "structuring"       // Repeated payments just under a reporting threshold
+	AMLRuleVelocity        = "velocity"          // Too many payments, or too much value, in the monitoring window
+	AMLRuleHighRiskCountry = "high_risk_country" // Payout to a country scored high-risk
+)
+
+// AML alert severities
+const (
+	AMLSeverityMedium = "medium"
+	AMLSeverityHigh   = "high"
+)
+
+// AML alert statuses
+const (
+	AMLAlertStatusOpen   = "open"
+	AMLAlertStatusClosed = "closed"
+)
+
+// AMLAlert is a payment a monitoring rule flagged, held until a compliance officer reviews it
+// Alerts are identified as "<payment_id>:<rule>", so evaluating a payment twice never records one twice.
+// An alert doesn't stop the payment; it is a lead for review and, where warranted, a suspicious activity report.
+type AMLAlert struct {
+	AlertID    string     `json:"alert_id" dynamodbav:"alert_id"`
+	PaymentID  string     `json:"payment_id" dynamodbav:"payment_id"`
+	CustomerID string     `json:"customer_id,omitempty" dynamodbav:"customer_id,omitempty"`
+	Rule       string     `json:"rule" dynamodbav:"rule"`
+	Severity   string     `json:"severity" dynamodbav:"severity"`
+	Detail     string     `json:"detail" dynamodbav:"detail"`
+	Status     string     `json:"status" dynamodbav:"status"`
+	Resolution string     `json:"resolution,omitempty" dynamodbav:"resolution,omitempty"`
+	ResolvedBy string     `json:"resolved_by,omitempty" dynamodbav:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" dynamodbav:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" dynamodbav:"created_at"`
+}
+
+// AMLAlertID identifies the alert rule raised on a payment
+func AMLAlertID(paymentID, rule string) string {
+	return paymentID + ":" + rule
+}
+
+// AMLAlertCloseRequest is a compliance officer's disposition of an AML alert
+type AMLAlertCloseRequest struct {
+	Resolution string `json:"resolution"` // e.g. "payroll run, customer confirmed" or "SAR filed"
+}
+
+// Validate checks an alert close request
+func (r *AMLAlertCloseRequest) Validate() *errors.AppError {
+	if strings.TrimSpace(r.Resolution) == "" {
+		return errors.ErrValidation("resolution", "is required")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// APIKeySecretPrefix marks the secrets of keys the API issues, telling them apart from API Gateway keys
+const APIKeySecretPrefix = "sk_"
+
+// API key statuses
+const (
+	APIKeyStatusActive   = "active"
+	APIKeyStatusRotating = "rotating" // Replaced by a rotation; works until expires_at
+	APIKeyStatusExpired  = "expired"
+	APIKeyStatusRevoked  = "revoked"
+)
+
+// maxAPIKeyNameLength bounds an API key's label
+const maxAPIKeyNameLength = 100
+
+// APIKey is a key the API issued to a customer
+// Only the SHA-256 hash of its secret is stored; the secret itself is returned once, when the key is issued.
+// A request made with the key acts as the key's customer ID, as one made with an API Gateway key acts as
+// the gateway key's ID, so a customer's payments, records and limits carry over when its key is rotated.
+type APIKey struct {
+	KeyID      string     `json:"key_id" dynamodbav:"key_id"`
+	KeyHash    string     `json:"-" dynamodbav:"key_hash"`    // Hex SHA-256 of the secret
+	Prefix     string     `json:"prefix" dynamodbav:"prefix"` // The secret's first characters, to tell keys apart
+	CustomerID string     `json:"customer_id" dynamodbav:"customer_id"`
+	Name       string     `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" dynamodbav:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" dynamodbav:"expires_at,omitempty"` // Set when the key is rotated
+	RotatedTo  string     `json:"rotated_to,omitempty" dynamodbav:"rotated_to,omitempty"` // The replacement's key ID
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" dynamodbav:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" dynamodbav:"last_used_at,omitempty"` // Updated at most once a minute
+}
+
+// Status returns whether the key is active, rotating out, expired or revoked at the given time
+func (k *APIKey) Status(at time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return APIKeyStatusRevoked
+	case k.ExpiresAt != nil && !at.Before(*k.ExpiresAt):
+		return APIKeyStatusExpired
+	case k.ExpiresAt != nil:
+		return APIKeyStatusRotating
+	}
+	return APIKeyStatusActive
+}
+
+// Usable reports whether requests may be made with the key at the given time
+func (k *APIKey) Usable(at time.Time) bool {
+	status := k.Status(at)
+	return status == APIKeyStatusActive || status == APIKeyStatusRotating
+}
+
+// APIKeyView is an API key as its customer sees it, with its status
+type APIKeyView struct {
+	*APIKey
+	Status string `json:"status"`
+}
+
+// IssuedAPIKey is a newly issued key with its secret, which is never shown again
+type IssuedAPIKey struct {
+	*APIKey
+	Status string `json:"status"`
+	Secret string `json:"key"`
+}
+
+// APIKeyRequest issues an API key
+type APIKeyRequest struct {
+	Name string `json:"name,omitempty"` // A label such as "production server"
+}
+
+// Validate checks an API key request
+func (r *APIKeyRequest) Validate() *errors.AppError {
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > maxAPIKeyNameLength {
+		return errors.ErrValidationFields([]errors.FieldError{{
+			Field:  "name",
+			Code:   errors.FieldOutOfRange,
+			Reason: "must be at most 100 characters",
+		}})
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AuditEntry is one record in the append-only audit log
+// Each entry carries the hash of its predecessor, so editing or deleting any entry breaks the chain.
+type AuditEntry struct {
+	Sequence     int64             `json:"sequence" dynamodbav:"sequence"`
+	EntryID      string            `json:"entry_id" dynamodbav:"entry_id"`
+	Timestamp    time.Time         `json:"timestamp" dynamodbav:"timestamp"`
+	Actor        string            `json:"actor" dynamodbav:"actor"`                 // Who: API key, client IP, or service name
+	Action       string            `json:"action" dynamodbav:"action"`               // What: e.g. payment.created, admin.force_transition
+	ResourceType string            `json:"resource_type" dynamodbav:"resource_type"` // payment, config, ...
+	ResourceID   string            `json:"resource_id" dynamodbav:"resource_id"`
+	Details      map[string]string `json:"details,omitempty" dynamodbav:"details,omitempty"`
+	PrevHash     string            `json:"prev_hash" dynamodbav:"prev_hash"` // Empty for the first entry
+	Hash         string            `json:"hash" dynamodbav:"hash"`
+}
+
+// AuditExportResponse is returned by GET /audit
+type AuditExportResponse struct {
+	Entries  []*AuditEntry `json:"entries"`
+	Verified bool          `json:"verified"`             // Every entry's hash and link checked out
+	Error    string        `json:"error,omitempty"`      // First broken link when not verified
+	Next     int64         `json:"next_after,omitempty"` // Pass as ?after= to fetch the next page
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Batch statuses
+const (
+	BatchStatusAccepted = "accepted" // Every payment was created
+	BatchStatusRejected = "rejected" // No payment was created; see the per-item errors
+)
+
+// BatchPaymentRequest is the body of POST /payments/batch
+type BatchPaymentRequest struct {
+	Payments []PaymentRequest `json:"payments"`
+}
+
+// BatchPaymentResponse reports the outcome of each payment in a batch, in request order
+type BatchPaymentResponse struct {
+	BatchID string               `json:"batch_id,omitempty"`
+	Status  string               `json:"status"`
+	Results []BatchPaymentResult `json:"results"`
+}
+
+// BatchPaymentResult is the outcome of one payment in a batch: the created payment or why it was refused
+// In a rejected batch, items without an error were valid but weren't created either.
+type BatchPaymentResult struct {
+	Index     int                 `json:"index"`
+	PaymentID string              `json:"payment_id,omitempty"`
+	Status    PaymentStatus       `json:"status,omitempty"`
+	Error     *errors.ErrorDetail `json:"error,omitempty"`
+}
+
+// BatchIdempotencyKey is the idempotency key of the payment at index in the batch with the given key
+// The batch's key covers all of its payments, so a retried batch finds them under the same keys.
+func BatchIdempotencyKey(batchKey string, index int) string {
+	return fmt.Sprintf("%s#%d", batchKey, index)
+}
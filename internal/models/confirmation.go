@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ChainConfirmation is how far one of a payment's on-chain transactions has confirmed
+type ChainConfirmation struct {
+	Chain         string    `json:"chain" dynamodbav:"chain"`
+	TxHash        string    `json:"tx_hash" dynamodbav:"tx_hash"`
+	BlockNumber   uint64    `json:"block_number,omitempty" dynamodbav:"block_number,omitempty"` // Slot on Solana; 0 until included
+	Confirmations int       `json:"confirmations" dynamodbav:"confirmations"`
+	Reverted      bool      `json:"reverted,omitempty" dynamodbav:"reverted,omitempty"`
+	Final         bool      `json:"final" dynamodbav:"final"` // Meets the chain's finality rule
+	CheckedAt     time.Time `json:"checked_at" dynamodbav:"checked_at"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/money"
+)
+
+// CurrencyCatalog is what GET /currencies returns: the currencies and corridors payments can use
+type CurrencyCatalog struct {
+	SourceCurrencies      []*CurrencyInfo       `json:"source_currencies"`
+	DestinationCurrencies []*CurrencyInfo       `json:"destination_currencies"`
+	Corridors             []*CorridorCapability `json:"corridors"`
+}
+
+// CurrencyInfo is one currency and the currencies it pairs with, ordered by code
+type CurrencyInfo struct {
+	Code         string   `json:"code"`
+	MinorUnits   int      `json:"minor_units"`            // Decimal places amounts in this currency are given in
+	Destinations []string `json:"destinations,omitempty"` // Set on source currencies
+	Sources      []string `json:"sources,omitempty"`      // Set on destination currencies
+}
+
+// CorridorCapability describes a supported currency pair: its limits and fees
+type CorridorCapability struct {
+	CorridorID          string              `json:"corridor_id"`
+	SourceCurrency      string              `json:"source_currency"`
+	DestinationCurrency string              `json:"destination_currency"`
+	DestinationCountry  string              `json:"destination_country,omitempty"`
+	PayoutRail          string              `json:"payout_rail"`
+	MinAmount           int64               `json:"min_amount"`           // Source minor units
+	MaxAmount           int64               `json:"max_amount,omitempty"` // Source minor units; omitted when unlimited
+	Fees                *FeeScheduleSummary `json:"fees"`
+}
+
+// FeeScheduleSummary is the platform fee schedule in effect on a corridor, before negotiated pricing or discounts
+type FeeScheduleSummary struct {
+	ScheduleID      string                `json:"schedule_id,omitempty"`      // Stored schedule in effect; empty for the corridor's own
+	ScheduleVersion int64                 `json:"schedule_version,omitempty"` // Version of ScheduleID in effect
+	Currency        string                `json:"currency"`                   // Currency of the bounds and fixed fees: the source currency
+	Tiers           corridors.FeeSchedule `json:"tiers"`
+	MinFee          int64                 `json:"min_fee,omitempty"`
+	MaxFee          int64                 `json:"max_fee,omitempty"`
+	Surcharges      []corridors.Surcharge `json:"surcharges,omitempty"`
+}
+
+// NewCorridorCapability describes a corridor with the fees in effect on it
+func NewCorridorCapability(corridor corridors.Corridor, fees *FeeScheduleSummary) *CorridorCapability {
+	return &CorridorCapability{
+		CorridorID:          corridor.ID,
+		SourceCurrency:      corridor.SourceCurrency,
+		DestinationCurrency: corridor.DestinationCurrency,
+		DestinationCountry:  corridor.DestinationCountry,
+		PayoutRail:          corridor.PayoutRail,
+		MinAmount:           corridor.MinAmount,
+		MaxAmount:           corridor.MaxAmount,
+		Fees:                fees,
+	}
+}
+
+// NewCurrencyCatalog lists the source and destination currencies the corridors connect
+func NewCurrencyCatalog(capabilities []*CorridorCapability) *CurrencyCatalog {
+	sources := make(map[string]*CurrencyInfo)
+	destinations := make(map[string]*CurrencyInfo)
+	for _, c := range capabilities {
+		source, ok := sources[c.SourceCurrency]
+		if !ok {
+			source = &CurrencyInfo{Code: c.SourceCurrency, MinorUnits: money.MinorUnits(c.SourceCurrency)}
+			sources[c.SourceCurrency] = source
+		}
+		source.Destinations = append(source.Destinations, c.DestinationCurrency)
+
+		destination, ok := destinations[c.DestinationCurrency]
+		if !ok {
+			destination = &CurrencyInfo{Code: c.DestinationCurrency, MinorUnits: money.MinorUnits(c.DestinationCurrency)}
+			destinations[c.DestinationCurrency] = destination
+		}
+		destination.Sources = append(destination.Sources, c.SourceCurrency)
+	}
+
+	return &CurrencyCatalog{
+		SourceCurrencies:      sortedCurrencies(sources),
+		DestinationCurrencies: sortedCurrencies(destinations),
+		Corridors:             capabilities,
+	}
+}
+
+// sortedCurrencies orders currencies, and the currencies each pairs with, by code
+func sortedCurrencies(currencies map[string]*CurrencyInfo) []*CurrencyInfo {
+	list := make([]*CurrencyInfo, 0, len(currencies))
+	for _, currency := range currencies {
+		sort.Strings(currency.Destinations)
+		sort.Strings(currency.Sources)
+		list = append(list, currency)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Customer tiers, which set quote validity and the AI fee engine's pricing
+const (
+	CustomerTierStandard   = "standard"
+	CustomerTierBusiness   = "business"
+	CustomerTierPremium    = "premium"
+	CustomerTierEnterprise = "enterprise"
+)
+
+// CustomerTiers are the tiers a customer can be placed in
+var CustomerTiers = map[string]bool{
+	CustomerTierStandard:   true,
+	CustomerTierBusiness:   true,
+	CustomerTierPremium:    true,
+	CustomerTierEnterprise: true,
+}
+
+// Customer is a business calling the API, with the pricing tier, limits and accounts it is held to
+// The customer ID is the API Gateway key ID the customer calls with, the same ID volumes and invoices are kept under.
+type Customer struct {
+	CustomerID     string            `json:"customer_id" dynamodbav:"customer_id"`
+	Name           string            `json:"name" dynamodbav:"name"`
+	Tier           string            `json:"tier" dynamodbav:"tier"`
+	Limits         CustomerLimits    `json:"limits" dynamodbav:"limits"`
+	WebhookSecrets []string          `json:"webhook_secrets,omitempty" dynamodbav:"webhook_secrets,omitempty"` // Newest first; webhooks are signed with the first
+	Accounts       []CustomerAccount `json:"accounts,omitempty" dynamodbav:"accounts,omitempty"`
+	KYC            CustomerKYC       `json:"kyc" dynamodbav:"kyc"`
+	AllowedIPs     []string          `json:"allowed_ips,omitempty" dynamodbav:"allowed_ips,omitempty"` // CIDR ranges the API key may call from; empty allows any
+	Sandbox        bool              `json:"sandbox,omitempty" dynamodbav:"sandbox,omitempty"`         // Payments run against the deterministic sandbox providers
+	CreatedAt      time.Time         `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// CustomerLimits caps what a customer's payments may move
+type CustomerLimits struct {
+	MaxPaymentAmount int64 `json:"max_payment_amount,omitempty" dynamodbav:"max_payment_amount,omitempty"` // Largest single payment in the funding currency's minor units; 0 = no limit
+}
+
+// KYC verification statuses
+const (
+	KYCStatusUnverified = "unverified" // No verification has been started
+	KYCStatusPending    = "pending"    // Started; the provider hasn't decided yet
+	KYCStatusVerified   = "verified"
+	KYCStatusRejected   = "rejected"
+)
+
+// CustomerKYC is where a customer's identity verification stands with the KYC provider
+type CustomerKYC struct {
+	Status     string     `json:"status" dynamodbav:"status"` // Empty on records created before KYC was enabled, which counts as unverified
+	Provider   string     `json:"provider,omitempty" dynamodbav:"provider,omitempty"`
+	InquiryID  string     `json:"inquiry_id,omitempty" dynamodbav:"inquiry_id,omitempty"` // The provider's ID for the verification
+	CheckedAt  *time.Time `json:"checked_at,omitempty" dynamodbav:"checked_at,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty" dynamodbav:"verified_at,omitempty"`
+}
+
+// Verified reports whether the customer has passed KYC
+func (k CustomerKYC) Verified() bool {
+	return k.Status == KYCStatusVerified
+}
+
+// CustomerAccount is a source account linked to a customer
+// Once a customer links any account, its payments may only be funded from linked accounts.
+type CustomerAccount struct {
+	AccountID string    `json:"account_id" dynamodbav:"account_id"` // The source_account payments are funded from
+	Label     string    `json:"label,omitempty" dynamodbav:"label,omitempty"`
+	LinkedAt  time.Time `json:"linked_at" dynamodbav:"linked_at"`
+}
+
+// Account returns the customer's linked account with ID accountID, or nil
+func (c *Customer) Account(accountID string) *CustomerAccount {
+	for i := range c.Accounts {
+		if c.Accounts[i].AccountID == accountID {
+			return &c.Accounts[i]
+		}
+	}
+	return nil
+}
+
+// AllowsIP reports whether the customer's API key may call from ip
+// A customer without an allowlist allows every address; one with an allowlist refuses an address it can't parse.
+func (c *Customer) AllowsIP(ip string) bool {
+	if len(c.AllowedIPs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, cidr := range c.AllowedIPs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxAllowedIPs caps how many CIDR ranges one customer's allowlist holds
+const MaxAllowedIPs = 50
+
+// CustomerIPAllowlistRequest replaces the CIDR ranges a customer's API key may call from
+type CustomerIPAllowlistRequest struct {
+	AllowedIPs []string `json:"allowed_ips"` // CIDR ranges or single addresses; empty removes the restriction
+}
+
+// Validate checks an allowlist request, normalizing each entry to its network's CIDR and dropping duplicates
+// A single address becomes a /32, or a /128 for IPv6.
+func (r *CustomerIPAllowlistRequest) Validate() *errors.AppError {
+	if len(r.AllowedIPs) > MaxAllowedIPs {
+		return errors.ErrValidationFields([]errors.FieldError{{
+			Field:  "allowed_ips",
+			Code:   errors.FieldOutOfRange,
+			Reason: fmt.Sprintf("must have at most %d entries", MaxAllowedIPs),
+		}})
+	}
+
+	var fields []errors.FieldError
+	seen := map[string]bool{}
+	allowed := make([]string, 0, len(r.AllowedIPs))
+	for i, entry := range r.AllowedIPs {
+		cidr, ok := normalizeCIDR(strings.TrimSpace(entry))
+		if !ok {
+			fields = append(fields, errors.FieldError{
+				Field:  fmt.Sprintf("allowed_ips[%d]", i),
+				Code:   errors.FieldInvalidFormat,
+				Reason: "must be an IP address or CIDR range, e.g. 203.0.113.0/24",
+			})
+			continue
+		}
+		if !seen[cidr] {
+			seen[cidr] = true
+			allowed = append(allowed, cidr)
+		}
+	}
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
+	r.AllowedIPs = allowed
+	return nil
+}
+
+// normalizeCIDR returns the network of a CIDR range or single address, e.g. 10.1.2.3/8 as 10.0.0.0/8
+func normalizeCIDR(entry string) (string, bool) {
+	if !strings.Contains(entry, "/") {
+		addr := net.ParseIP(entry)
+		if addr == nil {
+			return "", false
+		}
+		if v4 := addr.To4(); v4 != nil {
+			return v4.String() + "/32", true
+		}
+		return addr.String() + "/128", true
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return "", false
+	}
+	return network.String(), true
+}
+
+// CustomerRequest creates or updates a customer
+type CustomerRequest struct {
+	CustomerID string         `json:"customer_id,omitempty"` // Required on create; taken from the path on update
+	Name       string         `json:"name"`
+	Tier       string         `json:"tier,omitempty"` // Defaults to standard
+	Limits     CustomerLimits `json:"limits"`
+	Sandbox    bool           `json:"sandbox,omitempty"`
+}
+
+// Validate checks a customer request, applying the default tier
+func (r *CustomerRequest) Validate() *errors.AppError {
+	var fields []errors.FieldError
+	invalid := func(field, code, reason string) {
+		fields = append(fields, errors.FieldError{Field: field, Code: code, Reason: reason})
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		invalid("name", errors.FieldRequired, "is required")
+	}
+	r.Tier = strings.ToLower(strings.TrimSpace(r.Tier))
+	if r.Tier == "" {
+		r.Tier = CustomerTierStandard
+	}
+	if !CustomerTiers[r.Tier] {
+		invalid("tier", errors.FieldUnsupported, "must be standard, business, premium or enterprise")
+	}
+	if r.Limits.MaxPaymentAmount < 0 {
+		invalid("limits.max_payment_amount", errors.FieldOutOfRange, "must not be negative")
+	}
+
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
+	return nil
+}
+
+// CustomerAccountRequest links a source account to a customer
+type CustomerAccountRequest struct {
+	AccountID string `json:"account_id"`
+	Label     string `json:"label,omitempty"`
+}
+
+// Validate checks an account link request
+func (r *CustomerAccountRequest) Validate() *errors.AppError {
+	r.AccountID = strings.TrimSpace(r.AccountID)
+	if r.AccountID == "" {
+		return errors.ErrValidation("account_id", "is required")
+	}
+	return nil
+}
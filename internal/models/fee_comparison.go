@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// FeeComparison is one shadow-mode sample: the static fee charged on a payment and what the AI engine would have charged
+type FeeComparison struct {
+	PaymentID      string    `json:"payment_id" dynamodbav:"payment_id"`
+	Amount         int64     `json:"amount" dynamodbav:"amount"`
+	SourceCurrency string    `json:"source_currency" dynamodbav:"source_currency"`
+	Currency       string    `json:"currency" dynamodbav:"currency"` // Payout currency
+	StaticFee      int64     `json:"static_fee" dynamodbav:"static_fee"`
+	AIFee          int64     `json:"ai_fee" dynamodbav:"ai_fee"`               // 0 when AIError is set
+	Delta          int64     `json:"delta" dynamodbav:"delta"`                 // AIFee - StaticFee
+	DeltaPercent   float64   `json:"delta_percent" dynamodbav:"delta_percent"` // Delta as a percentage of StaticFee
+	AIChain        string    `json:"ai_chain,omitempty" dynamodbav:"ai_chain,omitempty"`
+	AIConfidence   float64   `json:"ai_confidence" dynamodbav:"ai_confidence"`
+	AIFallback     bool      `json:"ai_fallback" dynamodbav:"ai_fallback"` // The AI engine returned its fallback fees
+	AIError        string    `json:"ai_error,omitempty" dynamodbav:"ai_error,omitempty"`
+	AILatencyMs    int64     `json:"ai_latency_ms" dynamodbav:"ai_latency_ms"`
+	PromptVersion  string    `json:"prompt_version,omitempty" dynamodbav:"prompt_version,omitempty"`
+	CreatedAt      time.Time `json:"created_at" dynamodbav:"created_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"crypto-conversion/internal/corridors"
+)
+
+// DefaultFeeScheduleID is the schedule applied to payments, and to quotes on corridors without their own
+const DefaultFeeScheduleID = "default"
+
+// FeeSchedule is one effective-dated version of a platform fee schedule
+// Versions are never edited: a pricing change adds the next version with the date it takes effect.
+type FeeSchedule struct {
+	ScheduleID    string                `json:"schedule_id" dynamodbav:"schedule_id"` // DefaultFeeScheduleID or a corridor ID, e.g. USD-EUR
+	Version       int64                 `json:"version" dynamodbav:"version"`
+	Tiers         corridors.FeeSchedule `json:"tiers" dynamodbav:"tiers"` // Two-decimal minor units for the default schedule, corridor source minor units otherwise
+	EffectiveFrom time.Time             `json:"effective_from" dynamodbav:"effective_from"`
+	CreatedBy     string                `json:"created_by" dynamodbav:"created_by"`
+	CreatedAt     time.Time             `json:"created_at" dynamodbav:"created_at"`
+}
+
+// ActiveFeeSchedule returns the version in effect at t, or nil if none has taken effect yet
+// The latest effective_from wins; on a tie the higher version does.
+func ActiveFeeSchedule(versions []*FeeSchedule, t time.Time) *FeeSchedule {
+	var active *FeeSchedule
+	for _, v := range versions {
+		if v.EffectiveFrom.After(t) {
+			continue
+		}
+		if active == nil || v.EffectiveFrom.After(active.EffectiveFrom) ||
+			(v.EffectiveFrom.Equal(active.EffectiveFrom) && v.Version > active.Version) {
+			active = v
+		}
+	}
+	return active
+}
+
+// FeeScheduleRequest is the body of POST /fee-schedules/{schedule_id}
+type FeeScheduleRequest struct {
+	Tiers         corridors.FeeSchedule `json:"tiers"`
+	EffectiveFrom *time.Time            `json:"effective_from,omitempty"` // Defaults to now
+}
+
+// FeeScheduleListResponse is the body of GET /fee-schedules/{schedule_id}
+type FeeScheduleListResponse struct {
+	ScheduleID string         `json:"schedule_id"`
+	Active     *FeeSchedule   `json:"active"` // Null while the built-in schedule applies
+	Versions   []*FeeSchedule `json:"versions"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// GasHistoryRetention is how long gas price snapshots are kept for trend analysis
+const GasHistoryRetention = 7 * 24 * time.Hour
+
+// GasSnapshot is one gas price reading for a settlement chain
+// Snapshots are keyed by chain and second, so readings taken in the same second collapse into one.
+type GasSnapshot struct {
+	Chain      string    `json:"chain" dynamodbav:"chain"`
+	RecordedAt time.Time `json:"recorded_at" dynamodbav:"recorded_at,unixtime"`
+	GasPrice   float64   `json:"gas_price" dynamodbav:"gas_price"` // Gwei on EVM chains, SOL on Solana
+	CostUSD    float64   `json:"cost_usd" dynamodbav:"cost_usd"`   // Estimated cost of one USDC transfer
+	TTL        int64     `json:"-" dynamodbav:"ttl"`               // DynamoDB TTL attribute, once the snapshot leaves retention
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Triggers that hold a payment for manual approval
+const (
+	HoldTriggerAmount    = "amount"        // Amount at or above the hold threshold
+	HoldTriggerRiskScore = "risk_score"    // Destination country risk score at or above the hold score
+	HoldTriggerSanctions = "sanctions_hit" // Counterparty matched a sanctions list that compliance released
+)
+
+// Manual approval decisions for payments held in ON_HOLD
+const (
+	HoldDecisionApprove = "approve" // Resume processing from where the payment was held
+	HoldDecisionReject  = "reject"  // Fail the payment, reversing it if funds were already on-ramped
+)
+
+// Hold records why a payment was held for manual approval and the decision on it
+type Hold struct {
+	Triggers   []string      `json:"triggers" dynamodbav:"triggers"`
+	Detail     string        `json:"detail" dynamodbav:"detail"`
+	HeldAt     time.Time     `json:"held_at" dynamodbav:"held_at"`
+	HeldFrom   PaymentStatus `json:"held_from" dynamodbav:"held_from"` // The status the payment resumes from if approved
+	Decision   string        `json:"decision,omitempty" dynamodbav:"decision,omitempty"`
+	ReviewedBy string        `json:"reviewed_by,omitempty" dynamodbav:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time    `json:"reviewed_at,omitempty" dynamodbav:"reviewed_at,omitempty"`
+	Reason     string        `json:"reason,omitempty" dynamodbav:"reason,omitempty"`
+}
+
+// Approved reports whether an operator approved the payment after a hold
+func (h *Hold) Approved() bool {
+	return h != nil && h.Decision == HoldDecisionApprove
+}
+
+// HoldDecisionRequest is the body of POST /internal/payments/{payment_id}/approve and /reject
+type HoldDecisionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
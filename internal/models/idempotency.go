@@ -0,0 +1,45 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// IdempotentResponse is the response a request was first answered with, kept on the payment it created
+// so a retry under the same idempotency key is answered the same way instead of being refused.
+type IdempotentResponse struct {
+	RequestHash string `json:"request_hash" dynamodbav:"request_hash"` // HashRequestBody of the original request
+	StatusCode  int    `json:"status_code" dynamodbav:"status_code"`
+	Body        string `json:"body" dynamodbav:"body"`
+}
+
+// NewIdempotentResponse records the response to a request body
+func NewIdempotentResponse(requestBody string, statusCode int, responseBody []byte) *IdempotentResponse {
+	return &IdempotentResponse{
+		RequestHash: HashRequestBody(requestBody),
+		StatusCode:  statusCode,
+		Body:        string(responseBody),
+	}
+}
+
+// Matches reports whether a replayed request body is the one the response answered
+// A payment created before responses were kept has none, and matches nothing.
+func (r *IdempotentResponse) Matches(requestBody string) bool {
+	return r != nil && r.RequestHash == HashRequestBody(requestBody)
+}
+
+// HashRequestBody returns the SHA-256 of a request body, hex encoded
+// JSON bodies are hashed in a canonical form, so a client re-serializing the same request with other
+// whitespace or key order still matches.
+func HashRequestBody(body string) string {
+	data := []byte(body)
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err == nil {
+		if canonical, err := json.Marshal(generic); err == nil {
+			data = canonical
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// FeeInvoice is the immutable record of the fees on a completed payment, for customers to reconcile
+// It's issued once, when the payment completes, from the fees recorded when the payment was accepted.
+// Amounts are in minor units of Currency, the payment's funding currency.
+type FeeInvoice struct {
+	PaymentID          string    `json:"payment_id" dynamodbav:"payment_id"`
+	CustomerID         string    `json:"customer_id,omitempty" dynamodbav:"customer_id,omitempty"`
+	QuoteID            string    `json:"quote_id,omitempty" dynamodbav:"quote_id,omitempty"`
+	Amount             int64     `json:"amount" dynamodbav:"amount"`
+	Currency           string    `json:"currency" dynamodbav:"currency"`
+	PayoutCurrency     string    `json:"payout_currency" dynamodbav:"payout_currency"`
+	PlatformFee        int64     `json:"platform_fee" dynamodbav:"platform_fee"`     // After negotiated pricing, volume discounts, fee limits and promos
+	RegulatoryFee      int64     `json:"regulatory_fee" dynamodbav:"regulatory_fee"` // Corridor surcharges
+	OnrampFee          int64     `json:"onramp_fee" dynamodbav:"onramp_fee"`
+	OfframpFee         int64     `json:"offramp_fee" dynamodbav:"offramp_fee"`
+	GasFee             int64     `json:"gas_fee" dynamodbav:"gas_fee"` // Settlement network fees passed on; absorbed by the platform today
+	TotalFees          int64     `json:"total_fees" dynamodbav:"total_fees"`
+	NegotiatedDiscount int64     `json:"negotiated_discount" dynamodbav:"negotiated_discount"` // Already taken off PlatformFee
+	PromoCode          string    `json:"promo_code,omitempty" dynamodbav:"promo_code,omitempty"`
+	PromoDiscount      int64     `json:"promo_discount" dynamodbav:"promo_discount"` // Already taken off PlatformFee
+	PricingCustomer    string    `json:"pricing_customer,omitempty" dynamodbav:"pricing_customer,omitempty"`
+	FeeLimit           string    `json:"fee_limit,omitempty" dynamodbav:"fee_limit,omitempty"`
+	FeeScheduleID      string    `json:"fee_schedule_id,omitempty" dynamodbav:"fee_schedule_id,omitempty"` // Empty for the built-in schedule
+	FeeScheduleVersion int64     `json:"fee_schedule_version,omitempty" dynamodbav:"fee_schedule_version,omitempty"`
+	CompletedAt        time.Time `json:"completed_at" dynamodbav:"completed_at"`
+	IssuedAt           time.Time `json:"issued_at" dynamodbav:"issued_at"`
+}
+
+// NewFeeInvoice builds the fee invoice for a completed payment
+func NewFeeInvoice(payment *Payment, issuedAt time.Time) *FeeInvoice {
+	completedAt := issuedAt
+	if payment.ProcessedAt != nil {
+		completedAt = *payment.ProcessedAt
+	}
+
+	invoice := &FeeInvoice{
+		PaymentID:          payment.PaymentID,
+		CustomerID:         payment.CustomerID,
+		QuoteID:            payment.QuoteID,
+		Amount:             payment.Amount,
+		Currency:           payment.FundingCurrency(),
+		PayoutCurrency:     payment.Currency,
+		PlatformFee:        payment.FeeAmount - payment.RegulatoryFee,
+		RegulatoryFee:      payment.RegulatoryFee,
+		OnrampFee:          payment.OnrampFee,
+		OfframpFee:         payment.OfframpFee,
+		NegotiatedDiscount: payment.FeeDiscount,
+		PromoCode:          payment.PromoCode,
+		PromoDiscount:      payment.PromoDiscount,
+		PricingCustomer:    payment.PricingCustomer,
+		FeeLimit:           payment.FeeLimit,
+		FeeScheduleID:      payment.FeeScheduleID,
+		FeeScheduleVersion: payment.FeeScheduleVersion,
+		CompletedAt:        completedAt,
+		IssuedAt:           issuedAt,
+	}
+	invoice.TotalFees = invoice.PlatformFee + invoice.RegulatoryFee + invoice.OnrampFee + invoice.OfframpFee + invoice.GasFee
+	return invoice
+}
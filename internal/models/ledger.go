@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Ledger account types; accounts held per chain are qualified, e.g. "usdc_float:base"
+const (
+	LedgerCustomerFunds    = "customer_funds"     // Liability: funds received for a payment and not yet paid out or returned
+	LedgerUSDCFloat        = "usdc_float"         // Asset: USDC in our treasury wallet on a chain
+	LedgerPayoutsInTransit = "payouts_in_transit" // Asset: USDC sent to the off-ramp or a wallet, not yet settled
+	LedgerFeeRevenue       = "fee_revenue"        // Income: fees earned on completed payments
+	LedgerGasExpense       = "gas_expense"        // Expense: network fees we absorb on the transactions we send
+)
+
+// Sides of a ledger entry
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
+
+// LedgerAccount names an account of the given type, qualified by chain for per-chain accounts
+func LedgerAccount(accountType, chain string) string {
+	if chain == "" {
+		return accountType
+	}
+	return accountType + ":" + strings.ToLower(chain)
+}
+
+// LedgerEntry is one debit or credit of a ledger transaction, in minor units
+type LedgerEntry struct {
+	Account  string `json:"account" dynamodbav:"account"`
+	Side     string `json:"side" dynamodbav:"side"`
+	Amount   int64  `json:"amount" dynamodbav:"amount"` // Always positive; Side gives the direction
+	Currency string `json:"currency" dynamodbav:"currency"`
+}
+
+// AccountType returns the entry's account without its chain qualifier
+func (e LedgerEntry) AccountType() string {
+	accountType, _, _ := strings.Cut(e.Account, ":")
+	return accountType
+}
+
+// LedgerTransaction is the balanced set of entries recording one leg of a payment's money movement
+// Transactions are identified as "<payment_id>:<leg>" and written once, so a retried step never posts twice.
+type LedgerTransaction struct {
+	TransactionID string        `json:"transaction_id" dynamodbav:"transaction_id"`
+	PaymentID     string        `json:"payment_id" dynamodbav:"payment_id"`
+	Leg           string        `json:"leg" dynamodbav:"leg"`
+	FromStatus    PaymentStatus `json:"from_status,omitempty" dynamodbav:"from_status,omitempty"` // Transition the leg was posted for
+	ToStatus      PaymentStatus `json:"to_status,omitempty" dynamodbav:"to_status,omitempty"`
+	Entries       []LedgerEntry `json:"entries" dynamodbav:"entries"`
+	CreatedAt     time.Time     `json:"created_at" dynamodbav:"created_at"`
+}
+
+// Payment legs recorded in the ledger
+const (
+	LedgerLegOnramp         = "onramp"          // USDC minted and held for the customer
+	LedgerLegBridge         = "bridge"          // USDC moved to the chain the off-ramp redeems on
+	LedgerLegOfframp        = "offramp"         // Payout, net of fees, deposited with the off-ramp
+	LedgerLegWallet         = "wallet"          // Payout, net of fees, sent to a wallet destination
+	LedgerLegCompleted      = "completed"       // Payout settled and fees earned
+	LedgerLegPayoutReleased = "payout_released" // Failed payout returned to our wallet
+	LedgerLegReversal       = "reversal"        // Funds returned to the source account
+)
+
+// LedgerBalance is an account's net position across a set of transactions
+type LedgerBalance struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+	Balance  int64  `json:"balance"` // On the account's normal side: debits less credits for assets and expenses, the reverse otherwise
+}
+
+// PaymentLedger is the response of GET /internal/payments/{payment_id}/ledger
+type PaymentLedger struct {
+	PaymentID    string               `json:"payment_id"`
+	Status       PaymentStatus        `json:"status"`
+	Transactions []*LedgerTransaction `json:"transactions"`
+	Balances     []LedgerBalance      `json:"balances"`
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"crypto-conversion/internal/money"
+)
 
 // PaymentStatus represents the current state of a payment
 type PaymentStatus string
@@ -9,36 +14,191 @@ const (
 	StatusPending         PaymentStatus = "PENDING"
 	StatusOnrampPending   PaymentStatus = "ONRAMP_PENDING"
 	StatusOnrampComplete  PaymentStatus = "ONRAMP_COMPLETE"
+	StatusBridgePending   PaymentStatus = "BRIDGE_PENDING" // Bridging USDC over CCTP to the chain the off-ramp redeems on
+	StatusBridgeComplete  PaymentStatus = "BRIDGE_COMPLETE"
 	StatusOfframpPending  PaymentStatus = "OFFRAMP_PENDING"
+	StatusWalletPending   PaymentStatus = "WALLET_TRANSFER_PENDING" // Sending USDC straight to a wallet destination instead of off-ramping
+	StatusReversing       PaymentStatus = "REVERSING" // Off-ramp failed, returning USDC to source as USD
+	StatusRequiresReview  PaymentStatus = "REQUIRES_REVIEW" // Execution rate slipped past the limit; held until an operator decides
+	StatusComplianceHold  PaymentStatus = "COMPLIANCE_HOLD" // Counterparty matched a sanctions list; held until compliance decides
+	StatusOnHold          PaymentStatus = "ON_HOLD" // Large or risky payment; held until an operator approves or rejects it
 	StatusCompleted       PaymentStatus = "COMPLETED"
 	StatusFailed          PaymentStatus = "FAILED"
+	StatusTimedOut        PaymentStatus = "TIMED_OUT"
 
 	// Legacy statuses for backwards compatibility
 	StatusProcessing      PaymentStatus = "PROCESSING"
 )
 
+// Payout types: where the payment delivers funds
+const (
+	PayoutTypeBank   = "bank"   // Off-ramped to fiat in a bank account (the default)
+	PayoutTypeWallet = "wallet" // USDC sent straight to a blockchain address, skipping the off-ramp
+)
+
+// WalletPayoutCurrency is the payout currency of wallet payouts
+const WalletPayoutCurrency = "USDC"
+
+// IsTerminal reports whether no further processing will occur for the status
+func (s PaymentStatus) IsTerminal() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusTimedOut
+}
+
+// paymentStatuses are the statuses a payment can be in; legacy PROCESSING is no longer entered
+var paymentStatuses = map[PaymentStatus]bool{
+	StatusPending:        true,
+	StatusOnrampPending:  true,
+	StatusOnrampComplete: true,
+	StatusBridgePending:  true,
+	StatusBridgeComplete: true,
+	StatusOfframpPending: true,
+	StatusWalletPending:  true,
+	StatusReversing:      true,
+	StatusRequiresReview: true,
+	StatusComplianceHold: true,
+	StatusOnHold:         true,
+	StatusCompleted:      true,
+	StatusFailed:         true,
+	StatusTimedOut:       true,
+}
+
+// IsValid reports whether the status is one a payment can be in
+func (s PaymentStatus) IsValid() bool {
+	return paymentStatuses[s]
+}
+
+// IsHeld reports whether the payment waits on an operator's decision before it can continue
+func (s PaymentStatus) IsHeld() bool {
+	return s == StatusRequiresReview || s == StatusComplianceHold || s == StatusOnHold
+}
+
 // Payment represents a payment record in the system
 type Payment struct {
 	PaymentID              string              `json:"payment_id" dynamodbav:"payment_id"`
 	IdempotencyKey         string              `json:"idempotency_key" dynamodbav:"idempotency_key"`
+	IdempotencyScope       string              `json:"-" dynamodbav:"idempotency_scope,omitempty"`         // API key the idempotency key is unique to; empty for unauthenticated callers
+	BatchID                string              `json:"batch_id,omitempty" dynamodbav:"batch_id,omitempty"` // Set when created through POST /payments/batch
 	Amount                 int64               `json:"amount" dynamodbav:"amount"`
-	Currency               string              `json:"currency" dynamodbav:"currency"`
+	Currency               string              `json:"currency" dynamodbav:"currency"`                                   // Payout currency
+	SourceCurrency         string              `json:"source_currency,omitempty" dynamodbav:"source_currency,omitempty"` // Funding currency; empty means USD
 	SourceAccount          string              `json:"source_account" dynamodbav:"source_account"`
+	CustomerID             string              `json:"customer_id,omitempty" dynamodbav:"customer_id,omitempty"` // Caller's API key ID, else SourceAccount; keys volume discounts
 	DestinationAccount     string              `json:"destination_account" dynamodbav:"destination_account"`
+	Beneficiary            *Beneficiary        `json:"beneficiary,omitempty" dynamodbav:"beneficiary,omitempty"`
+	Screening              *Screening          `json:"screening,omitempty" dynamodbav:"screening,omitempty"` // Set once the counterparty matched a sanctions list
+	Hold                   *Hold               `json:"hold,omitempty" dynamodbav:"hold,omitempty"`           // Set once the payment was held for manual approval
+	CallbackURL            string              `json:"callback_url,omitempty" dynamodbav:"callback_url,omitempty"` // Webhooks are also delivered here
 	Status                 PaymentStatus       `json:"status" dynamodbav:"status"`
 	FeeAmount              int64               `json:"fee_amount" dynamodbav:"fee_amount"`
 	FeeCurrency            string              `json:"fee_currency" dynamodbav:"fee_currency"`
+	FeeScheduleID          string              `json:"fee_schedule_id,omitempty" dynamodbav:"fee_schedule_id,omitempty"` // Stored schedule that priced FeeAmount; empty for the built-in one
+	FeeScheduleVersion     int64               `json:"fee_schedule_version,omitempty" dynamodbav:"fee_schedule_version,omitempty"`
+	PricingCustomer        string              `json:"pricing_customer,omitempty" dynamodbav:"pricing_customer,omitempty"` // Customer whose negotiated pricing set FeeAmount
+	FeeDiscount            int64               `json:"fee_discount,omitempty" dynamodbav:"fee_discount,omitempty"`         // Negotiated discount already taken off FeeAmount
+	PromoCode              string              `json:"promo_code,omitempty" dynamodbav:"promo_code,omitempty"`
+	PromoDiscount          int64               `json:"promo_discount,omitempty" dynamodbav:"promo_discount,omitempty"` // Promo discount already taken off FeeAmount
+	FeeLimit               string              `json:"fee_limit,omitempty" dynamodbav:"fee_limit,omitempty"`           // "minimum" or "maximum" when the corridor's fee limit set FeeAmount
+	RegulatoryFee          int64               `json:"regulatory_fee,omitempty" dynamodbav:"regulatory_fee,omitempty"` // Corridor surcharges included in FeeAmount
+	OnrampFee              int64               `json:"onramp_fee,omitempty" dynamodbav:"onramp_fee,omitempty"`         // Provider fees from the quote, else estimated at acceptance
+	OfframpFee             int64               `json:"offramp_fee,omitempty" dynamodbav:"offramp_fee,omitempty"`
 	QuoteID                string              `json:"quote_id,omitempty" dynamodbav:"quote_id,omitempty"`
 	GuaranteedPayoutAmount int64               `json:"guaranteed_payout_amount,omitempty" dynamodbav:"guaranteed_payout_amount,omitempty"`
+	ExpectedRate           float64             `json:"expected_rate,omitempty" dynamodbav:"expected_rate,omitempty"`   // Quoted rate, or the indicative rate when accepted without a quote
+	ExecutionRate          float64             `json:"execution_rate,omitempty" dynamodbav:"execution_rate,omitempty"` // Executable rate checked before the off-ramp
+	SlippageApproved       bool                `json:"slippage_approved,omitempty" dynamodbav:"slippage_approved,omitempty"`
+	FloatReserved          bool                `json:"float_reserved,omitempty" dynamodbav:"float_reserved,omitempty"` // Treasury float for the payout is held until it settles or is released
+	AIUsage                *AIUsage            `json:"ai_usage,omitempty" dynamodbav:"ai_usage,omitempty"` // Claude spend on the quote this payment used
+	PayoutType             string              `json:"payout_type,omitempty" dynamodbav:"payout_type,omitempty"` // Empty for bank payouts
+	Sandbox                bool                `json:"sandbox,omitempty" dynamodbav:"sandbox,omitempty"`         // Runs against the deterministic sandbox providers; no funds move
+	Chain                  string              `json:"chain,omitempty" dynamodbav:"chain,omitempty"`                   // Chain USDC is minted on
+	OffRampChain           string              `json:"off_ramp_chain,omitempty" dynamodbav:"off_ramp_chain,omitempty"` // Chain the off-ramp redeems on when it differs from Chain
 	OnRampTxID             string              `json:"on_ramp_tx_id,omitempty" dynamodbav:"on_ramp_tx_id,omitempty"`
 	OnRampPollCount        int                 `json:"on_ramp_poll_count,omitempty" dynamodbav:"on_ramp_poll_count,omitempty"`
 	OffRampTxID            string              `json:"off_ramp_tx_id,omitempty" dynamodbav:"off_ramp_tx_id,omitempty"`
 	OffRampPollCount       int                 `json:"off_ramp_poll_count,omitempty" dynamodbav:"off_ramp_poll_count,omitempty"`
+	OnRampConfirmation     *ChainConfirmation  `json:"on_ramp_confirmation,omitempty" dynamodbav:"on_ramp_confirmation,omitempty"`   // Finality of the USDC mint, when chain watching is enabled
+	OffRampConfirmation    *ChainConfirmation  `json:"off_ramp_confirmation,omitempty" dynamodbav:"off_ramp_confirmation,omitempty"` // Finality of our USDC deposit to the off-ramp
+	PollDelaySeconds       int                 `json:"poll_delay_seconds,omitempty" dynamodbav:"poll_delay_seconds,omitempty"`
+	ReversalTxID           string              `json:"reversal_tx_id,omitempty" dynamodbav:"reversal_tx_id,omitempty"`
+	ReversalPollCount      int                 `json:"reversal_poll_count,omitempty" dynamodbav:"reversal_poll_count,omitempty"`
+	BridgeTxID             string              `json:"bridge_tx_id,omitempty" dynamodbav:"bridge_tx_id,omitempty"` // CCTP burn on Chain
+	BridgePollCount        int                 `json:"bridge_poll_count,omitempty" dynamodbav:"bridge_poll_count,omitempty"`
+	WalletTxID             string              `json:"wallet_tx_id,omitempty" dynamodbav:"wallet_tx_id,omitempty"` // USDC transfer to a wallet destination
+	WalletPollCount        int                 `json:"wallet_poll_count,omitempty" dynamodbav:"wallet_poll_count,omitempty"`
+	WalletConfirmation     *ChainConfirmation  `json:"wallet_confirmation,omitempty" dynamodbav:"wallet_confirmation,omitempty"`
+	LockOwner              string              `json:"-" dynamodbav:"lock_owner,omitempty"`          // Worker currently running a step
+	LockExpiresAt          int64               `json:"-" dynamodbav:"lock_expires_at,omitempty"`     // Unix seconds; stale locks can be taken over
+	IdempotentResponse     *IdempotentResponse `json:"-" dynamodbav:"idempotent_response,omitempty"` // Returned to retries under the same idempotency key
 	StateHistory           []StateTransition   `json:"state_history,omitempty" dynamodbav:"state_history,omitempty"`
+	Timeline               *SettlementTimeline `json:"timeline,omitempty" dynamodbav:"timeline,omitempty"`
+	SettlementSLASeconds   int                 `json:"settlement_sla_seconds,omitempty" dynamodbav:"settlement_sla_seconds,omitempty"` // Settlement time promised when accepted
+	SettlementSeconds      int                 `json:"settlement_seconds,omitempty" dynamodbav:"settlement_seconds,omitempty"`         // On-ramp initiation to payout settlement, once completed
+	SLABreached            bool                `json:"sla_breached,omitempty" dynamodbav:"sla_breached,omitempty"`
+	SweepCount             int                 `json:"sweep_count,omitempty" dynamodbav:"sweep_count,omitempty"` // Times the stale-payment sweeper re-enqueued it
+	WebhookStatus          PaymentStatus       `json:"webhook_status,omitempty" dynamodbav:"webhook_status,omitempty"` // Last status whose webhook was queued
 	ErrorMessage           string              `json:"error_message,omitempty" dynamodbav:"error_message,omitempty"`
+	ErrorCode              string              `json:"error_code,omitempty" dynamodbav:"error_code,omitempty"` // Error catalog code of why the payment failed, reversed or timed out
 	CreatedAt              time.Time           `json:"created_at" dynamodbav:"created_at"`
 	UpdatedAt              time.Time           `json:"updated_at" dynamodbav:"updated_at"`
 	ProcessedAt            *time.Time          `json:"processed_at,omitempty" dynamodbav:"processed_at,omitempty"`
+	ArchivedAt             *time.Time          `json:"archived_at,omitempty" dynamodbav:"archived_at,omitempty"`
+	TTL                    int64               `json:"-" dynamodbav:"ttl,omitempty"` // DynamoDB TTL attribute (unix timestamp), set once terminal
+}
+
+// IsWalletPayout reports whether the payment pays USDC to a blockchain address instead of off-ramping
+func (p *Payment) IsWalletPayout() bool {
+	return p.PayoutType == PayoutTypeWallet
+}
+
+// RedeemChain returns the chain the off-ramp redeems USDC on
+func (p *Payment) RedeemChain() string {
+	if p.OffRampChain != "" {
+		return p.OffRampChain
+	}
+	return p.Chain
+}
+
+// NeedsBridge reports whether minted USDC must be bridged before the off-ramp can redeem it
+func (p *Payment) NeedsBridge() bool {
+	return p.OffRampChain != "" && p.OffRampChain != p.Chain
+}
+
+// DefaultSourceCurrency funds payments that don't specify a source currency
+const DefaultSourceCurrency = "USD"
+
+// FundingCurrency returns the currency the payment is funded (on-ramped) in
+func (p *Payment) FundingCurrency() string {
+	if p.SourceCurrency == "" {
+		return DefaultSourceCurrency
+	}
+	return p.SourceCurrency
+}
+
+// PayoutAmount is what the off-ramp pays out, in the payout currency: the guaranteed payout if a quote
+// was used, otherwise the amount net of fees converted at the rate expected at acceptance, as a quote would
+func (p *Payment) PayoutAmount() int64 {
+	if p.GuaranteedPayoutAmount != 0 {
+		return p.GuaranteedPayoutAmount
+	}
+
+	net := p.Amount - p.FeeAmount - p.OnrampFee - p.OfframpFee
+	if p.FundingCurrency() == p.Currency || p.ExpectedRate <= 0 {
+		// Cross-currency bank payouts are only accepted with a rate, so no conversion is needed here
+		return net
+	}
+	return money.Convert(net, p.FundingCurrency(), p.Currency, p.ExpectedRate)
+}
+
+// VolumeUSD returns the payment's amount in USD cents for volume discounts
+// Non-USD funding converts at the expected rate, which is only known when the payout is USD.
+func (p *Payment) VolumeUSD() (int64, bool) {
+	switch {
+	case p.FundingCurrency() == "USD":
+		return p.Amount, true
+	case p.Currency == "USD" && p.ExpectedRate > 0:
+		return money.Convert(p.Amount, p.FundingCurrency(), "USD", p.ExpectedRate), true
+	}
+	return 0, false
 }
 
 // StateTransition represents a state change in the payment lifecycle
@@ -52,10 +212,16 @@ type StateTransition struct {
 // PaymentRequest represents the incoming API request
 type PaymentRequest struct {
 	Amount             int64  `json:"amount"`
-	Currency           string `json:"currency"`
+	Currency           string `json:"currency"`                  // Payout currency
+	SourceCurrency     string `json:"source_currency,omitempty"` // Optional: funding currency (USD or EUR, default USD)
 	SourceAccount      string `json:"source_account"`
 	DestinationAccount string `json:"destination_account"`
 	QuoteID            string `json:"quote_id,omitempty"` // Optional: use quote for guaranteed rate
+	PromoCode          string `json:"promo_code,omitempty"` // Optional: defaults to the quote's promo code
+	Chain              string `json:"chain,omitempty"`    // Optional: settlement chain (affects polling cadence); required for wallet payouts
+	PayoutType         string `json:"payout_type,omitempty"` // Optional: "bank" (default) or "wallet", paying USDC to destination_account on chain
+	Beneficiary        *Beneficiary `json:"beneficiary,omitempty"` // Optional: who is paid, screened against sanctions lists
+	CallbackURL        string `json:"callback_url,omitempty"` // Optional: HTTPS URL this payment's webhooks are also delivered to
 }
 
 // PaymentResponse represents the API response
@@ -65,27 +231,85 @@ type PaymentResponse struct {
 	Message   string        `json:"message"`
 }
 
+// Current schema versions of the messages passed between Lambdas; see internal/schema
+// Version 1 is the payload written before messages carried a schema_version.
+const (
+	PaymentJobSchemaVersion   = 2
+	WebhookEventSchemaVersion = 2
+)
+
 // PaymentJob represents a message in the SQS queue
 type PaymentJob struct {
-	PaymentID          string `json:"payment_id"`
-	Amount             int64  `json:"amount"`
-	Currency           string `json:"currency"`
-	SourceAccount      string `json:"source_account"`
-	DestinationAccount string `json:"destination_account"`
+	SchemaVersion      int           `json:"schema_version,omitempty"`
+	PaymentID          string        `json:"payment_id"`
+	Amount             int64         `json:"amount"`
+	Currency           string        `json:"currency"`
+	SourceAccount      string        `json:"source_account"`
+	DestinationAccount string        `json:"destination_account"`
+	ExpectedStatus     PaymentStatus `json:"expected_status,omitempty"` // Status the job was enqueued for; stale redeliveries are skipped
+}
+
+// NewPaymentJob creates the job that runs a payment's next step from its current status
+func NewPaymentJob(p *Payment) *PaymentJob {
+	return &PaymentJob{
+		SchemaVersion:      PaymentJobSchemaVersion,
+		PaymentID:          p.PaymentID,
+		Amount:             p.Amount,
+		Currency:           p.Currency,
+		SourceAccount:      p.SourceAccount,
+		DestinationAccount: p.DestinationAccount,
+		ExpectedStatus:     p.Status,
+	}
+}
+
+// Review decisions for payments held in REQUIRES_REVIEW
+const (
+	ReviewDecisionApprove = "approve" // Proceed to the off-ramp at the current rate
+	ReviewDecisionReject  = "reject"  // Reverse the payment, returning funds to the source account
+)
+
+// ReviewRequest is an operator's decision on a payment held for review
+type ReviewRequest struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 // WebhookEvent represents a webhook notification payload
 type WebhookEvent struct {
-	EventType   string         `json:"event_type"`
-	PaymentID   string         `json:"payment_id"`
-	Status      PaymentStatus  `json:"status"`
-	Amount      int64          `json:"amount"`
-	Currency    string         `json:"currency"`
-	Fees        *FeeBreakdown  `json:"fees,omitempty"`
-	OnRampTxID  string         `json:"on_ramp_tx_id,omitempty"`
-	OffRampTxID string         `json:"off_ramp_tx_id,omitempty"`
-	Error       string         `json:"error,omitempty"`
-	Timestamp   time.Time      `json:"timestamp"`
+	SchemaVersion int           `json:"schema_version,omitempty"`
+	EventType     string        `json:"event_type"`
+	PaymentID     string        `json:"payment_id,omitempty"`
+	QuoteID       string        `json:"quote_id,omitempty"` // Set on quote.* events
+	Status        PaymentStatus `json:"status,omitempty"`
+	Amount        int64         `json:"amount"`
+	Currency      string        `json:"currency"`
+	Fees          *FeeBreakdown `json:"fees,omitempty"`
+	OnRampTxID    string        `json:"on_ramp_tx_id,omitempty"`
+	OffRampTxID   string        `json:"off_ramp_tx_id,omitempty"`
+	ReversalTxID  string        `json:"reversal_tx_id,omitempty"`
+	BridgeTxID    string        `json:"bridge_tx_id,omitempty"`
+	WalletTxID    string        `json:"wallet_tx_id,omitempty"`
+	Sandbox       bool          `json:"sandbox,omitempty"` // A sandbox payment's event; no funds moved
+	Error         string        `json:"error,omitempty"`
+	ErrorCode     string        `json:"error_code,omitempty"`   // Error catalog code on payment.failed, payment.cancelled and payment.timed_out
+	ErrorDocID    string        `json:"error_doc_id,omitempty"` // The code's error catalog ID
+	Retryable     *bool         `json:"retryable,omitempty"`    // Set with ErrorCode: whether a new payment for the same transfer may succeed
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"` // Quote expiry on quote.* events
+	Timestamp     time.Time     `json:"timestamp"`
+	CallbackURL   string        `json:"callback_url,omitempty"` // The payment's own callback URL; not part of the delivered payload
+	CustomerID    string        `json:"customer_id,omitempty"`  // Whose webhook subscriptions receive the event; not part of the delivered payload
+}
+
+// PaymentStateChangedEvent is the detail of a payment.state_changed lifecycle event
+type PaymentStateChangedEvent struct {
+	PaymentID  string        `json:"payment_id"`
+	FromStatus PaymentStatus `json:"from_status"`
+	ToStatus   PaymentStatus `json:"to_status"`
+	Message    string        `json:"message,omitempty"`
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+	Chain      string        `json:"chain,omitempty"`
+	Timestamp  time.Time     `json:"timestamp"`
 }
 
 // FeeBreakdown represents fee information in webhooks and responses
@@ -93,3 +317,39 @@ type FeeBreakdown struct {
 	Amount   int64  `json:"amount"`
 	Currency string `json:"currency"`
 }
+
+// Outbox message kinds
+const (
+	OutboxKindPaymentJob      = "payment_job"
+	OutboxKindPaymentJobBatch = "payment_job_batch" // JSON array of the jobs of a payment batch
+	OutboxKindWebhookEvent    = "webhook_event"
+)
+
+// OutboxMessage is a queue message persisted atomically with the record change that produced it
+// The outbox relay delivers it and then deletes it.
+type OutboxMessage struct {
+	MessageID string    `json:"message_id" dynamodbav:"message_id"`
+	Kind      string    `json:"kind" dynamodbav:"kind"`
+	PaymentID string    `json:"payment_id" dynamodbav:"payment_id"`
+	Payload   string    `json:"payload" dynamodbav:"payload"` // JSON-encoded message body
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+
+	// TraceHeader is the X-Ray trace of the request that wrote the message, continued by the relay's delivery
+	TraceHeader string `json:"trace_header,omitempty" dynamodbav:"trace_header,omitempty"`
+}
+
+// NewOutboxMessage builds an outbox message with a JSON-encoded body
+func NewOutboxMessage(messageID, kind, paymentID string, body interface{}) (*OutboxMessage, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxMessage{
+		MessageID: messageID,
+		Kind:      kind,
+		PaymentID: paymentID,
+		Payload:   string(payload),
+		CreatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// StatusUnderReview is how a payment held for any review appears to customers
+// Which review holds it, and why, stays internal.
+const StatusUnderReview PaymentStatus = "UNDER_REVIEW"
+
+// statusDescriptions are the customer-facing descriptions of the statuses shown in a payment's events
+var statusDescriptions = map[PaymentStatus]string{
+	StatusPending:        "Payment received",
+	StatusOnrampPending:  "Collecting funds and converting them to USDC",
+	StatusOnrampComplete: "Funds converted to USDC",
+	StatusBridgePending:  "Moving USDC to the payout network",
+	StatusBridgeComplete: "USDC arrived on the payout network",
+	StatusOfframpPending: "Paying out to the destination account",
+	StatusWalletPending:  "Sending USDC to the destination wallet",
+	StatusReversing:      "Payout failed; returning funds to the source account",
+	StatusUnderReview:    "Payment is under review",
+	StatusCompleted:      "Payment completed",
+	StatusFailed:         "Payment failed",
+	StatusTimedOut:       "Payment is delayed; our team is resolving it",
+}
+
+// PaymentEvents is the body of GET /payments/{payment_id}/events
+type PaymentEvents struct {
+	PaymentID string         `json:"payment_id"`
+	Status    PaymentStatus  `json:"status"`
+	Events    []PaymentEvent `json:"events"`
+}
+
+// PaymentEvent is one step of a payment's customer-facing timeline, oldest first
+type PaymentEvent struct {
+	Status    PaymentStatus `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Message   string        `json:"message"`
+}
+
+// PublicStatus returns the status as customers see it: every held status is UNDER_REVIEW
+func (s PaymentStatus) PublicStatus() PaymentStatus {
+	if s.IsHeld() {
+		return StatusUnderReview
+	}
+	return s
+}
+
+// NewPaymentEvents builds a payment's customer-facing timeline from its state history
+// Internal messages (provider errors, operator names, review reasons) are replaced by a description
+// of each status, and consecutive steps customers can't tell apart are shown once.
+func NewPaymentEvents(p *Payment) *PaymentEvents {
+	initial := p.Status
+	if len(p.StateHistory) > 0 {
+		initial = p.StateHistory[0].FromStatus
+	}
+
+	events := []PaymentEvent{newPaymentEvent(initial, p.CreatedAt)}
+	for _, transition := range p.StateHistory {
+		event := newPaymentEvent(transition.ToStatus, transition.Timestamp)
+		if event.Status == events[len(events)-1].Status {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return &PaymentEvents{
+		PaymentID: p.PaymentID,
+		Status:    p.Status.PublicStatus(),
+		Events:    events,
+	}
+}
+
+// PublicEvent describes the status a transition entered as a step of the customer-facing timeline
+func (t StateTransition) PublicEvent() PaymentEvent {
+	return newPaymentEvent(t.ToStatus, t.Timestamp)
+}
+
+// newPaymentEvent describes a payment entering status at the given time
+func newPaymentEvent(status PaymentStatus, at time.Time) PaymentEvent {
+	status = status.PublicStatus()
+	message, ok := statusDescriptions[status]
+	if !ok {
+		message = "Payment is being processed"
+	}
+	return PaymentEvent{Status: status, Timestamp: at.UTC(), Message: message}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Promo is a promotional discount on the platform fee
+// Codes are looked up case-insensitively; quotes check availability and payments redeem a use.
+type Promo struct {
+	Code       string    `json:"code" dynamodbav:"code"`                                   // Stored upper case
+	PercentOff float64   `json:"percent_off,omitempty" dynamodbav:"percent_off,omitempty"` // Share of the platform fee, e.g. 0.5
+	AmountOff  int64     `json:"amount_off,omitempty" dynamodbav:"amount_off,omitempty"`   // Minor units of the source currency, after PercentOff
+	ValidFrom  time.Time `json:"valid_from" dynamodbav:"valid_from"`
+	ValidUntil time.Time `json:"valid_until,omitempty" dynamodbav:"valid_until,omitempty"` // Zero = no end
+	MaxUses    int64     `json:"max_uses" dynamodbav:"max_uses"`                           // 0 = unlimited
+	Uses       int64     `json:"uses" dynamodbav:"uses"`
+	CreatedAt  time.Time `json:"created_at" dynamodbav:"created_at"`
+}
+
+// NormalizePromoCode returns the stored form of a client-supplied code
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Unavailable returns why the promo can't be used at t, or "" if it can
+func (p *Promo) Unavailable(t time.Time) string {
+	switch {
+	case t.Before(p.ValidFrom):
+		return "promo code is not active yet"
+	case !p.ValidUntil.IsZero() && !t.Before(p.ValidUntil):
+		return "promo code has expired"
+	case p.MaxUses > 0 && p.Uses >= p.MaxUses:
+		return "promo code has no uses left"
+	}
+	return ""
+}
+
+// Discount returns the promo's discount on fee, never more than the fee itself
+func (p *Promo) Discount(fee int64) int64 {
+	discount := int64(math.Round(float64(fee)*p.PercentOff)) + p.AmountOff
+	if discount > fee {
+		return fee
+	}
+	return discount
+}
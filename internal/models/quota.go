@@ -0,0 +1,122 @@
+package models
+
+import "time"
+
+// Quota periods
+const (
+	QuotaPeriodDaily   = "daily"   // The UTC day
+	QuotaPeriodMonthly = "monthly" // The UTC calendar month
+)
+
+// QuotaLimits caps the payments a customer tier may make; a zero limit is unlimited
+// Volume is in USD cents, as volume discounts count it.
+type QuotaLimits struct {
+	DailyCount    int64 `json:"daily_count,omitempty"`
+	DailyVolume   int64 `json:"daily_volume,omitempty"`
+	MonthlyCount  int64 `json:"monthly_count,omitempty"`
+	MonthlyVolume int64 `json:"monthly_volume,omitempty"`
+}
+
+// QuotaWindow is one period's counter that a payment is reserved against, with the tier's limits on it
+type QuotaWindow struct {
+	Period    string    // QuotaPeriodDaily or QuotaPeriodMonthly
+	Key       string    // e.g. daily#2026-10-16, the counter's key within the customer's
+	MaxCount  int64     // 0 for no limit
+	MaxVolume int64     // 0 for no limit
+	ResetsAt  time.Time // When the next window starts
+}
+
+// QuotaWindows returns the daily and monthly windows containing at, with the limits on each
+func QuotaWindows(limits QuotaLimits, at time.Time) []QuotaWindow {
+	at = at.UTC()
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return []QuotaWindow{
+		{
+			Period:    QuotaPeriodDaily,
+			Key:       QuotaPeriodDaily + "#" + day.Format(RevenueReportDateFormat),
+			MaxCount:  limits.DailyCount,
+			MaxVolume: limits.DailyVolume,
+			ResetsAt:  day.AddDate(0, 0, 1),
+		},
+		{
+			Period:    QuotaPeriodMonthly,
+			Key:       QuotaPeriodMonthly + "#" + month.Format(UsagePeriodFormat),
+			MaxCount:  limits.MonthlyCount,
+			MaxVolume: limits.MonthlyVolume,
+			ResetsAt:  month.AddDate(0, 1, 0),
+		},
+	}
+}
+
+// ExpiresAt is when the window's counter may be deleted: a day after the window resets
+func (w QuotaWindow) ExpiresAt() time.Time {
+	return w.ResetsAt.Add(24 * time.Hour)
+}
+
+// Exceeds reports whether one more payment of volume would take the counter past the window's limits
+func (w QuotaWindow) Exceeds(counter *QuotaCounter, volume int64) bool {
+	var payments, used int64
+	if counter != nil {
+		payments, used = counter.Payments, counter.Volume
+	}
+	return (w.MaxCount > 0 && payments+1 > w.MaxCount) || (w.MaxVolume > 0 && used+volume > w.MaxVolume)
+}
+
+// QuotaCounter is what a customer has used of one quota window
+type QuotaCounter struct {
+	CustomerID string `json:"customer_id" dynamodbav:"customer_id"`
+	WindowKey  string `json:"window_key" dynamodbav:"window_key"`
+	Payments   int64  `json:"payments" dynamodbav:"payments"`
+	Volume     int64  `json:"volume" dynamodbav:"volume"` // USD cents
+	TTL        int64  `json:"-" dynamodbav:"ttl"`         // DynamoDB TTL attribute, a day after the window resets
+}
+
+// QuotaStatus is a customer's quota headroom, as GET /quotas returns it
+type QuotaStatus struct {
+	CustomerID string         `json:"customer_id"`
+	Tier       string         `json:"tier"`
+	Daily      *QuotaHeadroom `json:"daily"`
+	Monthly    *QuotaHeadroom `json:"monthly"`
+}
+
+// QuotaHeadroom is what is used and left of one quota window
+type QuotaHeadroom struct {
+	ResetsAt time.Time   `json:"resets_at"`
+	Payments *QuotaUsage `json:"payments"`
+	Volume   *QuotaUsage `json:"volume"` // USD cents
+}
+
+// QuotaUsage is what is used and left of one limit; Limit and Remaining are omitted when unlimited
+type QuotaUsage struct {
+	Limit     *int64 `json:"limit,omitempty"`
+	Used      int64  `json:"used"`
+	Remaining *int64 `json:"remaining,omitempty"`
+}
+
+// NewQuotaHeadroom reports a window's counter against its limits
+func NewQuotaHeadroom(window QuotaWindow, counter *QuotaCounter) *QuotaHeadroom {
+	var payments, volume int64
+	if counter != nil {
+		payments, volume = counter.Payments, counter.Volume
+	}
+	return &QuotaHeadroom{
+		ResetsAt: window.ResetsAt,
+		Payments: newQuotaUsage(window.MaxCount, payments),
+		Volume:   newQuotaUsage(window.MaxVolume, volume),
+	}
+}
+
+// newQuotaUsage reports a used amount against a limit, 0 meaning none
+func newQuotaUsage(limit, used int64) *QuotaUsage {
+	usage := &QuotaUsage{Used: used}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Ramp legs a provider statement reports
+const (
+	RampLegOnramp  = "onramp"  // Fiat received and minted into USDC
+	RampLegOfframp = "offramp" // USDC redeemed and paid out as fiat
+)
+
+// Statement transaction statuses, normalized from the provider's
+const (
+	StatementStatusPending  = "pending"
+	StatementStatusComplete = "complete"
+	StatementStatusFailed   = "failed"
+)
+
+// StatementTransaction is one transfer on a ramp provider's transaction report
+type StatementTransaction struct {
+	Provider  string    `json:"provider"`
+	Leg       string    `json:"leg"`
+	TxID      string    `json:"tx_id"` // The provider's transfer ID, as returned when we initiated it
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RampTransfer is a transfer we asked a ramp provider to make for a payment, as our records have it
+type RampTransfer struct {
+	PaymentID string        `json:"payment_id"`
+	Leg       string        `json:"leg"`
+	TxID      string        `json:"tx_id"`
+	Amount    int64         `json:"amount"`
+	Currency  string        `json:"currency"`
+	Status    PaymentStatus `json:"status"`               // The payment's current status
+	SettledAt *time.Time    `json:"settled_at,omitempty"` // When the payment saw the transfer settle; nil while it hasn't
+}
+
+// RampTransfersFromPayment returns the on-ramp and off-ramp transfers a payment initiated
+// The on-ramp takes the funding amount; the off-ramp pays out the payout amount.
+func RampTransfersFromPayment(p *Payment) []*RampTransfer {
+	var transfers []*RampTransfer
+	if p.OnRampTxID != "" {
+		transfers = append(transfers, &RampTransfer{
+			PaymentID: p.PaymentID,
+			Leg:       RampLegOnramp,
+			TxID:      p.OnRampTxID,
+			Amount:    p.Amount,
+			Currency:  p.FundingCurrency(),
+			Status:    p.Status,
+			SettledAt: p.reachedAt(StatusOnrampComplete),
+		})
+	}
+	if p.OffRampTxID != "" {
+		transfers = append(transfers, &RampTransfer{
+			PaymentID: p.PaymentID,
+			Leg:       RampLegOfframp,
+			TxID:      p.OffRampTxID,
+			Amount:    p.PayoutAmount(),
+			Currency:  strings.ToUpper(p.Currency),
+			Status:    p.Status,
+			SettledAt: p.reachedAt(StatusCompleted),
+		})
+	}
+	return transfers
+}
+
+// reachedAt returns when the payment first moved into status, or nil if it never has
+func (p *Payment) reachedAt(status PaymentStatus) *time.Time {
+	for _, transition := range p.StateHistory {
+		if transition.ToStatus == status {
+			at := transition.Timestamp
+			return &at
+		}
+	}
+	return nil
+}
+
+// Kinds of reconciliation break
+const (
+	BreakMissingFromStatement = "missing_from_statement" // We saw a transfer settle that the provider doesn't report
+	BreakUnknownTransfer      = "unknown_transfer"       // The provider reports a transfer no payment of ours made
+	BreakAmountMismatch       = "amount_mismatch"        // The provider moved a different amount or currency than we expected
+	BreakStatusMismatch       = "status_mismatch"        // The provider and the payment disagree on whether the transfer settled
+)
+
+// Reconciliation break statuses
+const (
+	BreakStatusOpen     = "open"
+	BreakStatusResolved = "resolved"
+)
+
+// ReconciliationBreak is a disagreement between a provider statement and our payments, held until an operator resolves it
+// Breaks are identified as "<provider>:<tx_id>:<kind>", so re-running reconciliation never records one twice.
+type ReconciliationBreak struct {
+	BreakID        string     `json:"break_id" dynamodbav:"break_id"`
+	Kind           string     `json:"kind" dynamodbav:"kind"`
+	Provider       string     `json:"provider" dynamodbav:"provider"`
+	Leg            string     `json:"leg" dynamodbav:"leg"`
+	TxID           string     `json:"tx_id" dynamodbav:"tx_id"`
+	PaymentID      string     `json:"payment_id,omitempty" dynamodbav:"payment_id,omitempty"` // Empty for unknown transfers
+	ExpectedAmount int64      `json:"expected_amount,omitempty" dynamodbav:"expected_amount,omitempty"`
+	ActualAmount   int64      `json:"actual_amount,omitempty" dynamodbav:"actual_amount,omitempty"`
+	Currency       string     `json:"currency,omitempty" dynamodbav:"currency,omitempty"`
+	Detail         string     `json:"detail" dynamodbav:"detail"`
+	Status         string     `json:"status" dynamodbav:"status"`
+	Resolution     string     `json:"resolution,omitempty" dynamodbav:"resolution,omitempty"`
+	ResolvedBy     string     `json:"resolved_by,omitempty" dynamodbav:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" dynamodbav:"resolved_at,omitempty"`
+	DetectedAt     time.Time  `json:"detected_at" dynamodbav:"detected_at"`
+}
+
+// ReconciliationBreakID identifies the break of kind found for a provider transfer
+func ReconciliationBreakID(provider, txID, kind string) string {
+	return strings.ToLower(provider) + ":" + txID + ":" + kind
+}
+
+// BreakResolutionRequest is an operator's resolution of a reconciliation break
+type BreakResolutionRequest struct {
+	Resolution string `json:"resolution"` // What was done about it, e.g. "provider corrected the payout"
+}
+
+// Validate checks a break resolution request
+func (r *BreakResolutionRequest) Validate() *errors.AppError {
+	if strings.TrimSpace(r.Resolution) == "" {
+		return errors.ErrValidation("resolution", "is required")
+	}
+	return nil
+}
+
+// ReconciliationReport summarizes one reconciliation run
+type ReconciliationReport struct {
+	Provider     string                 `json:"provider"`
+	From         time.Time              `json:"from"`
+	Until        time.Time              `json:"until"`
+	Statement    int                    `json:"statement_transactions"`
+	Transfers    int                    `json:"transfers"`
+	Matched      int                    `json:"matched"`
+	Breaks       []*ReconciliationBreak `json:"breaks"`
+	NewBreaks    int                    `json:"new_breaks"` // Breaks not recorded by an earlier run
+	ReconciledAt time.Time              `json:"reconciled_at"`
+}
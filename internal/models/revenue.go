@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RevenueReport is one row of the daily revenue report: a customer's fee revenue and costs on a corridor
+// for one UTC day, built from the fee invoices of payments completed that day.
+// Amounts are in minor units of Currency, the corridor's funding currency.
+type RevenueReport struct {
+	ReportDate      string    `json:"report_date" dynamodbav:"report_date"` // YYYY-MM-DD, UTC
+	Corridor        string    `json:"corridor" dynamodbav:"corridor"`       // e.g. "USD-EUR"
+	CustomerID      string    `json:"customer_id" dynamodbav:"customer_id"`
+	Currency        string    `json:"currency" dynamodbav:"currency"`
+	Payments        int       `json:"payments" dynamodbav:"payments"`
+	Volume          int64     `json:"volume" dynamodbav:"volume"`
+	PlatformRevenue int64     `json:"platform_revenue" dynamodbav:"platform_revenue"` // Platform fees kept, after all discounts
+	RegulatoryFees  int64     `json:"regulatory_fees" dynamodbav:"regulatory_fees"`   // Corridor surcharges collected on behalf of regulators
+	ProviderCosts   int64     `json:"provider_costs" dynamodbav:"provider_costs"`     // On-ramp and off-ramp fees paid to providers
+	GasSpend        int64     `json:"gas_spend" dynamodbav:"gas_spend"`               // Network fees we absorbed, in USDC minor units
+	GeneratedAt     time.Time `json:"generated_at" dynamodbav:"generated_at"`
+}
+
+// RevenueReportDateFormat is the layout of RevenueReport.ReportDate
+const RevenueReportDateFormat = "2006-01-02"
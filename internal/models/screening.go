@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Beneficiary is the party a payment pays out to, as the caller describes it
+type Beneficiary struct {
+	Name    string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Country string `json:"country,omitempty" dynamodbav:"country,omitempty"` // ISO 3166-1 alpha-2
+}
+
+// Stages at which a payment is screened against sanctions lists
+const (
+	ScreeningStageCreation = "creation" // Before the payment is accepted
+	ScreeningStageOfframp  = "offramp"  // Before funds leave for the beneficiary
+)
+
+// Fields of a payment a sanctions match can be found on
+const (
+	ScreeningFieldName    = "name"
+	ScreeningFieldCountry = "country"
+	ScreeningFieldAddress = "address" // The destination address of a wallet payout
+)
+
+// ScreeningSubject is what a sanctions screener checks: the payment's counterparty
+type ScreeningSubject struct {
+	Name          string
+	Country       string
+	WalletAddress string // The destination of a wallet payout
+}
+
+// ScreeningSubjectFor returns the counterparty of a payment to screen
+func ScreeningSubjectFor(p *Payment) ScreeningSubject {
+	var subject ScreeningSubject
+	if p.Beneficiary != nil {
+		subject.Name = p.Beneficiary.Name
+		subject.Country = p.Beneficiary.Country
+	}
+	if p.IsWalletPayout() {
+		subject.WalletAddress = p.DestinationAccount
+	}
+	return subject
+}
+
+// ScreeningMatch is one sanctions list entry a payment's counterparty matched
+type ScreeningMatch struct {
+	List  string `json:"list" dynamodbav:"list"`   // e.g. "ofac_sdn"
+	Field string `json:"field" dynamodbav:"field"` // Which of the counterparty's details matched
+	Value string `json:"value" dynamodbav:"value"` // The counterparty's value that matched
+	Entry string `json:"entry" dynamodbav:"entry"` // The list entry it matched
+}
+
+// Compliance review decisions for payments held in COMPLIANCE_HOLD
+const (
+	ComplianceDecisionRelease = "release" // A false positive; the payment proceeds and isn't screened again
+	ComplianceDecisionReject  = "reject"  // Refuse the payment, reversing it if funds were already on-ramped
+)
+
+// Screening records a sanctions hit on a payment and the compliance decision on it
+type Screening struct {
+	Stage      string           `json:"stage" dynamodbav:"stage"`
+	Matches    []ScreeningMatch `json:"matches" dynamodbav:"matches"`
+	ScreenedAt time.Time        `json:"screened_at" dynamodbav:"screened_at"`
+	HeldFrom   PaymentStatus    `json:"held_from" dynamodbav:"held_from"` // The status the payment resumes from if released
+	Decision   string           `json:"decision,omitempty" dynamodbav:"decision,omitempty"`
+	ReviewedBy string           `json:"reviewed_by,omitempty" dynamodbav:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time       `json:"reviewed_at,omitempty" dynamodbav:"reviewed_at,omitempty"`
+	Reason     string           `json:"reason,omitempty" dynamodbav:"reason,omitempty"`
+}
+
+// Released reports whether compliance cleared the payment after a hit
+func (s *Screening) Released() bool {
+	return s != nil && s.Decision == ComplianceDecisionRelease
+}
+
+// ComplianceReviewRequest is a compliance officer's decision on a held payment
+type ComplianceReviewRequest struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+// Validate checks a compliance review request
+func (r *ComplianceReviewRequest) Validate() *errors.AppError {
+	var fields []errors.FieldError
+	if r.Decision != ComplianceDecisionRelease && r.Decision != ComplianceDecisionReject {
+		fields = append(fields, errors.FieldError{Field: "decision", Code: errors.FieldUnsupported, Reason: "must be release or reject"})
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		fields = append(fields, errors.FieldError{Field: "reason", Code: errors.FieldRequired, Reason: "is required"})
+	}
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Settlement is the outcome of one of our payments over its settlement chain
+// Derived from terminal payments that recorded a chain; feeds chain health scoring.
+type Settlement struct {
+	PaymentID  string        `json:"payment_id"`
+	Chain      string        `json:"chain"`
+	Status     PaymentStatus `json:"status"` // COMPLETED or TIMED_OUT
+	CreatedAt  time.Time     `json:"created_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+}
+
+// Latency returns how long the payment took from creation to its terminal status
+func (s *Settlement) Latency() time.Duration {
+	return s.FinishedAt.Sub(s.CreatedAt)
+}
+
+// SettlementFromPayment returns the settlement of a terminal payment with a chain
+// Returns false for payments still in flight, without a chain, or that failed before settling.
+func SettlementFromPayment(p *Payment) (*Settlement, bool) {
+	if p.Chain == "" || p.ProcessedAt == nil {
+		return nil, false
+	}
+	if p.Status != StatusCompleted && p.Status != StatusTimedOut {
+		return nil, false
+	}
+	return &Settlement{
+		PaymentID:  p.PaymentID,
+		Chain:      p.Chain,
+		Status:     p.Status,
+		CreatedAt:  p.CreatedAt,
+		FinishedAt: *p.ProcessedAt,
+	}, true
+}
+
+// SettlementReport is one row of the daily settlement report: the payments on a corridor and chain that
+// completed on one UTC day. GrossVolume and Fees are in minor units of Currency, the funding currency;
+// NetPayouts are in minor units of PayoutCurrency.
+type SettlementReport struct {
+	ReportDate           string    `json:"report_date"` // YYYY-MM-DD, UTC
+	Corridor             string    `json:"corridor"`    // e.g. "USD-EUR"
+	Chain                string    `json:"chain"`       // Empty for payments that didn't choose one
+	Currency             string    `json:"currency"`
+	PayoutCurrency       string    `json:"payout_currency"`
+	Payments             int       `json:"payments"`
+	GrossVolume          int64     `json:"gross_volume"`
+	Fees                 int64     `json:"fees"`          // Fees charged to the customer
+	ProviderFees         int64     `json:"provider_fees"` // On-ramp and off-ramp fees paid to providers
+	NetPayouts           int64     `json:"net_payouts"`
+	AvgSettlementSeconds float64   `json:"avg_settlement_seconds"` // Mean time from creation to completion
+	GeneratedAt          time.Time `json:"generated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SettlementTimeline records when each settlement stage of a payment started and finished
+// The payout leg is the off-ramp, or for a wallet payout the USDC transfer to the address.
+type SettlementTimeline struct {
+	OnrampInitiatedAt  *time.Time `json:"onramp_initiated_at,omitempty" dynamodbav:"onramp_initiated_at,omitempty"`
+	OnrampSettledAt    *time.Time `json:"onramp_settled_at,omitempty" dynamodbav:"onramp_settled_at,omitempty"`
+	OfframpInitiatedAt *time.Time `json:"offramp_initiated_at,omitempty" dynamodbav:"offramp_initiated_at,omitempty"`
+	OfframpSettledAt   *time.Time `json:"offramp_settled_at,omitempty" dynamodbav:"offramp_settled_at,omitempty"`
+}
+
+// SettlementTime returns how long the payment took from on-ramp initiation to payout settlement
+// It returns false until both are recorded.
+func (t *SettlementTimeline) SettlementTime() (time.Duration, bool) {
+	if t == nil || t.OnrampInitiatedAt == nil || t.OfframpSettledAt == nil {
+		return 0, false
+	}
+	return t.OfframpSettledAt.Sub(*t.OnrampInitiatedAt), true
+}
+
+// SLABreachedEvent is the detail of an sla.breached lifecycle event
+type SLABreachedEvent struct {
+	PaymentID         string              `json:"payment_id"`
+	Chain             string              `json:"chain,omitempty"`
+	OffRampChain      string              `json:"off_ramp_chain,omitempty"`
+	QuoteID           string              `json:"quote_id,omitempty"`
+	SLASeconds        int                 `json:"sla_seconds"`
+	SettlementSeconds int                 `json:"settlement_seconds"`
+	Timeline          *SettlementTimeline `json:"timeline"`
+	Timestamp         time.Time           `json:"timestamp"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// SocketConnectionLifetime is the longest API Gateway keeps a WebSocket connection open
+// A subscription outliving its connection is never pushed to again, so it expires with it.
+const SocketConnectionLifetime = 2 * time.Hour
+
+// Socket message types
+const (
+	SocketMessageSubscribed   = "subscribed"   // A subscription was stored; carries the payment's current status
+	SocketMessageUnsubscribed = "unsubscribed" // A subscription was removed
+	SocketMessageStatus       = "status"       // The payment entered a status
+	SocketMessageError        = "error"        // A client message was refused
+)
+
+// SocketSubscription is a WebSocket connection's subscription to one payment's status transitions
+// Subscriptions are keyed by payment and connection, so the worker reads a payment's subscribers with one query.
+type SocketSubscription struct {
+	PaymentID    string    `json:"payment_id" dynamodbav:"payment_id"`
+	ConnectionID string    `json:"connection_id" dynamodbav:"connection_id"`
+	CreatedAt    time.Time `json:"created_at" dynamodbav:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" dynamodbav:"expires_at"`
+	TTL          int64     `json:"-" dynamodbav:"ttl"` // DynamoDB TTL attribute, once the connection has closed
+}
+
+// NewSocketSubscription subscribes a connection to a payment
+func NewSocketSubscription(connectionID, paymentID string) *SocketSubscription {
+	now := time.Now().UTC()
+	expires := now.Add(SocketConnectionLifetime)
+	return &SocketSubscription{
+		PaymentID:    paymentID,
+		ConnectionID: connectionID,
+		CreatedAt:    now,
+		ExpiresAt:    expires,
+		TTL:          expires.Unix(),
+	}
+}
+
+// SocketRequest is a message a WebSocket client sends, routed on its action
+type SocketRequest struct {
+	Action    string `json:"action"` // "subscribe" or "unsubscribe"
+	PaymentID string `json:"payment_id"`
+}
+
+// SocketMessage is a message pushed to a WebSocket client
+type SocketMessage struct {
+	Type      string        `json:"type"`
+	PaymentID string        `json:"payment_id,omitempty"`
+	Event     *PaymentEvent `json:"event,omitempty"` // The status entered, for subscribed and status messages
+	Error     string        `json:"error,omitempty"`
+}
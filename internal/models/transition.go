@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+
+	"crypto-conversion/internal/errors"
+)
+
+// TransitionRequest is the body of POST /internal/payments/{payment_id}/transition: an operator moving a stuck payment
+type TransitionRequest struct {
+	Status  PaymentStatus `json:"status"`
+	Reason  string        `json:"reason"`
+	Enqueue bool          `json:"enqueue,omitempty"` // Queue a job so the worker runs the new status's step
+}
+
+// Validate checks a transition request
+func (r *TransitionRequest) Validate() *errors.AppError {
+	var fields []errors.FieldError
+	if !r.Status.IsValid() {
+		fields = append(fields, errors.FieldError{Field: "status", Code: errors.FieldUnsupported, Reason: "must be a payment status"})
+	} else if r.Enqueue && (r.Status.IsTerminal() || r.Status.IsHeld()) {
+		fields = append(fields, errors.FieldError{Field: "enqueue", Code: errors.FieldConflict, Reason: "only applies to a status the worker processes"})
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		fields = append(fields, errors.FieldError{Field: "reason", Code: errors.FieldRequired, Reason: "is required"})
+	}
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
+	return nil
+}
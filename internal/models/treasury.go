@@ -0,0 +1,160 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// Treasury account kinds
+const (
+	TreasuryKindChain    = "chain"    // USDC in our treasury wallet on a settlement chain
+	TreasuryKindProvider = "provider" // Fiat float prefunded with a ramp provider
+)
+
+// TreasuryOfframpProvider holds the fiat float bank payouts are paid from
+const TreasuryOfframpProvider = "offramp"
+
+// TreasuryUSDC is the currency of chain balances
+const TreasuryUSDC = "USDC"
+
+// TreasuryAccount names the balance of currency held on a chain or with a provider, e.g. "chain:base:USDC"
+func TreasuryAccount(kind, location, currency string) string {
+	return kind + ":" + strings.ToLower(location) + ":" + strings.ToUpper(currency)
+}
+
+// TreasuryThresholds normalizes configured low-balance thresholds to account names
+// Keys that aren't "kind:location:currency" are dropped.
+func TreasuryThresholds(raw map[string]int) map[string]int64 {
+	thresholds := make(map[string]int64, len(raw))
+	for key, threshold := range raw {
+		parts := strings.Split(key, ":")
+		if len(parts) != 3 {
+			continue
+		}
+		thresholds[TreasuryAccount(strings.ToLower(parts[0]), parts[1], parts[2])] = int64(threshold)
+	}
+	return thresholds
+}
+
+// TreasuryBalance is our balance in one treasury account, in minor units
+type TreasuryBalance struct {
+	Account             string    `json:"account" dynamodbav:"account"`
+	Kind                string    `json:"kind" dynamodbav:"kind"`
+	Location            string    `json:"location" dynamodbav:"location"` // Chain or provider
+	Currency            string    `json:"currency" dynamodbav:"currency"`
+	Balance             int64     `json:"balance" dynamodbav:"balance"`
+	LowBalanceThreshold int64     `json:"low_balance_threshold,omitempty" dynamodbav:"-"` // Configured alert threshold; not stored
+	Low                 bool      `json:"low" dynamodbav:"-"`
+	UpdatedAt           time.Time `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// SetThreshold records the balance's low-balance threshold and whether it is below it
+func (b *TreasuryBalance) SetThreshold(threshold int64) {
+	b.LowBalanceThreshold = threshold
+	b.Low = threshold > 0 && b.Balance < threshold
+}
+
+// TreasuryEntry is a signed change to one treasury account
+type TreasuryEntry struct {
+	Kind     string `json:"kind" dynamodbav:"kind"`
+	Location string `json:"location" dynamodbav:"location"`
+	Currency string `json:"currency" dynamodbav:"currency"`
+	Amount   int64  `json:"amount" dynamodbav:"amount"` // Negative for debits
+}
+
+// Account returns the treasury account the entry changes
+func (e TreasuryEntry) Account() string {
+	return TreasuryAccount(e.Kind, e.Location, e.Currency)
+}
+
+// USDCEntry changes our USDC balance on chain
+func USDCEntry(chain string, amount int64) TreasuryEntry {
+	return TreasuryEntry{Kind: TreasuryKindChain, Location: strings.ToLower(chain), Currency: TreasuryUSDC, Amount: amount}
+}
+
+// FloatEntry changes our fiat float with provider
+func FloatEntry(provider, currency string, amount int64) TreasuryEntry {
+	return TreasuryEntry{Kind: TreasuryKindProvider, Location: strings.ToLower(provider), Currency: strings.ToUpper(currency), Amount: amount}
+}
+
+// TreasuryMovement is a set of treasury entries applied together, at most once
+// Payment legs are identified as "<payment_id>:<leg>", so retrying a step never moves funds twice.
+type TreasuryMovement struct {
+	MovementID string          `json:"movement_id" dynamodbav:"movement_id"`
+	PaymentID  string          `json:"payment_id,omitempty" dynamodbav:"payment_id,omitempty"` // Empty for manual adjustments
+	Leg        string          `json:"leg" dynamodbav:"leg"`
+	Entries    []TreasuryEntry `json:"entries" dynamodbav:"entries"`
+	CreatedBy  string          `json:"created_by,omitempty" dynamodbav:"created_by,omitempty"`
+	CreatedAt  time.Time       `json:"created_at" dynamodbav:"created_at"`
+}
+
+// Payment legs that move treasury funds
+const (
+	TreasuryLegOnramp          = "onramp"           // USDC minted on the payment's chain
+	TreasuryLegBridge          = "bridge"           // USDC moved to the chain the off-ramp redeems on
+	TreasuryLegOfframpReserved = "offramp_reserved" // Payout reserved from our float before the off-ramp starts
+	TreasuryLegOfframp         = "offramp"          // USDC deposited with the off-ramp
+	TreasuryLegOfframpSettled  = "offramp_settled"  // Deposited USDC redeemed back into the float
+	TreasuryLegOfframpReleased = "offramp_released" // Failed off-ramp returned the deposit and the reserved payout
+	TreasuryLegWallet          = "wallet"           // USDC reserved for and sent to a wallet destination
+	TreasuryLegWalletReleased  = "wallet_released"  // Failed wallet transfer left the USDC with us
+	TreasuryLegReversal        = "reversal"         // USDC redeemed back to the source account
+	TreasuryLegAdjustment      = "adjustment"       // Manual top-up or withdrawal
+)
+
+// PaymentMovement builds the movement for one leg of a payment
+func PaymentMovement(paymentID, leg string, entries ...TreasuryEntry) *TreasuryMovement {
+	return &TreasuryMovement{
+		MovementID: paymentID + ":" + leg,
+		PaymentID:  paymentID,
+		Leg:        leg,
+		Entries:    entries,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// TreasuryAdjustmentRequest is an operator's top-up or withdrawal of a treasury account
+type TreasuryAdjustmentRequest struct {
+	Kind      string `json:"kind"`
+	Location  string `json:"location"`
+	Currency  string `json:"currency"`
+	Amount    int64  `json:"amount"`    // Negative for withdrawals
+	Reference string `json:"reference"` // Bank or transaction reference; an adjustment is applied once per reference
+}
+
+// Validate checks an adjustment request
+func (r *TreasuryAdjustmentRequest) Validate() *errors.AppError {
+	switch {
+	case r.Kind != TreasuryKindChain && r.Kind != TreasuryKindProvider:
+		return errors.ErrValidation("kind", "must be "+TreasuryKindChain+" or "+TreasuryKindProvider)
+	case r.Location == "":
+		return errors.ErrValidation("location", "is required")
+	case r.Currency == "":
+		return errors.ErrValidation("currency", "is required")
+	case r.Kind == TreasuryKindChain && !strings.EqualFold(r.Currency, TreasuryUSDC):
+		return errors.ErrValidation("currency", "must be "+TreasuryUSDC+" for chain balances")
+	case r.Amount == 0:
+		return errors.ErrValidation("amount", "must not be zero")
+	case r.Reference == "":
+		return errors.ErrValidation("reference", "is required")
+	}
+	return nil
+}
+
+// Entry returns the treasury entry the adjustment applies
+func (r *TreasuryAdjustmentRequest) Entry() TreasuryEntry {
+	return TreasuryEntry{
+		Kind:     r.Kind,
+		Location: strings.ToLower(r.Location),
+		Currency: strings.ToUpper(r.Currency),
+		Amount:   r.Amount,
+	}
+}
+
+// TreasuryReport is the response of GET /internal/treasury
+type TreasuryReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Balances    []*TreasuryBalance `json:"balances"`
+}
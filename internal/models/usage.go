@@ -0,0 +1,105 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// UsagePeriodFormat is the layout of UsageRecord.Period: usage is billed by UTC calendar month
+const UsagePeriodFormat = "2006-01"
+
+// Usage metrics
+const (
+	UsageMetricAPICalls          = "api_calls"           // Authenticated requests routed to an endpoint
+	UsageMetricPayments          = "payments"            // Payments accepted, alone or in a batch
+	UsageMetricPaymentVolume     = "payment_volume"      // Accepted payment amounts, in minor units of the funding currency
+	UsageMetricAIFeeCalculations = "ai_fee_calculations" // Successful POST /fees/calculate calls
+)
+
+// UsageRecord is one of a customer's usage counters for a month
+// Payment volume is counted per funding currency; the other metrics have no currency.
+type UsageRecord struct {
+	CustomerID string    `json:"customer_id" dynamodbav:"customer_id"`
+	UsageKey   string    `json:"-" dynamodbav:"usage_key"` // Period, metric and currency, e.g. 2026-10#payment_volume#USD
+	Period     string    `json:"period" dynamodbav:"period"`
+	Metric     string    `json:"metric" dynamodbav:"metric"`
+	Currency   string    `json:"currency,omitempty" dynamodbav:"currency,omitempty"`
+	Quantity   int64     `json:"quantity" dynamodbav:"quantity"`
+	UpdatedAt  time.Time `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// NewUsageRecord creates a counter increment for the month containing at
+func NewUsageRecord(customerID, metric, currency string, quantity int64, at time.Time) *UsageRecord {
+	period := UsagePeriod(at)
+	currency = strings.ToUpper(currency)
+	return &UsageRecord{
+		CustomerID: customerID,
+		UsageKey:   UsageKey(period, metric, currency),
+		Period:     period,
+		Metric:     metric,
+		Currency:   currency,
+		Quantity:   quantity,
+		UpdatedAt:  at.UTC(),
+	}
+}
+
+// UsagePeriod returns the billing period containing t
+func UsagePeriod(t time.Time) string {
+	return t.UTC().Format(UsagePeriodFormat)
+}
+
+// UsageKey identifies a counter within a customer's usage; a period's keys share the "<period>#" prefix
+func UsageKey(period, metric, currency string) string {
+	key := period + "#" + metric
+	if currency != "" {
+		key += "#" + currency
+	}
+	return key
+}
+
+// UsageSummary is a customer's usage for one month, as GET /usage returns it
+type UsageSummary struct {
+	CustomerID        string           `json:"customer_id"`
+	Period            string           `json:"period"`
+	APICalls          int64            `json:"api_calls"`
+	Payments          int64            `json:"payments"`
+	PaymentVolume     map[string]int64 `json:"payment_volume"` // Funding currency -> minor units
+	AIFeeCalculations int64            `json:"ai_fee_calculations"`
+}
+
+// NewUsageSummary totals a customer's counters for a period
+func NewUsageSummary(customerID, period string, records []*UsageRecord) *UsageSummary {
+	summary := &UsageSummary{
+		CustomerID:    customerID,
+		Period:        period,
+		PaymentVolume: map[string]int64{},
+	}
+	for _, record := range records {
+		switch record.Metric {
+		case UsageMetricAPICalls:
+			summary.APICalls += record.Quantity
+		case UsageMetricPayments:
+			summary.Payments += record.Quantity
+		case UsageMetricPaymentVolume:
+			summary.PaymentVolume[record.Currency] += record.Quantity
+		case UsageMetricAIFeeCalculations:
+			summary.AIFeeCalculations += record.Quantity
+		}
+	}
+	return summary
+}
+
+// SortUsageRecords orders counters by customer, metric and currency, as the billing export lists them
+func SortUsageRecords(records []*UsageRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.CustomerID != b.CustomerID {
+			return a.CustomerID < b.CustomerID
+		}
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		return a.Currency < b.Currency
+	})
+}
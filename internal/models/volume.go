@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// VolumeWindow is how far back a customer's payment volume counts toward volume discounts
+const VolumeWindow = 30 * 24 * time.Hour
+
+// VolumeEntry is one completed payment counted toward its customer's rolling volume
+// Entries are keyed by payment, so recording the same completion twice counts it once.
+type VolumeEntry struct {
+	CustomerID  string    `json:"customer_id" dynamodbav:"customer_id"`
+	PaymentID   string    `json:"payment_id" dynamodbav:"payment_id"`
+	AmountUSD   int64     `json:"amount_usd" dynamodbav:"amount_usd"` // USD cents
+	CompletedAt time.Time `json:"completed_at" dynamodbav:"completed_at,unixtime"`
+	TTL         int64     `json:"-" dynamodbav:"ttl"` // DynamoDB TTL attribute, once the entry leaves the window
+}
+
+// CustomerID identifies the customer for volume tracking: the API key if present, otherwise the source account
+func CustomerID(apiKeyID, sourceAccount string) string {
+	if apiKeyID != "" {
+		return apiKeyID
+	}
+	return sourceAccount
+}
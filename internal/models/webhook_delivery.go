@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// WebhookDeliveryRetention is how long delivery attempts are kept for endpoint statistics
+const WebhookDeliveryRetention = 7 * 24 * time.Hour
+
+// WebhookDelivery is one attempt to deliver a webhook to a subscription's endpoint
+// Attempts are keyed by subscription and delivery time in nanoseconds, so a subscription's recent
+// attempts are read with one query.
+type WebhookDelivery struct {
+	SubscriptionID string    `json:"subscription_id" dynamodbav:"subscription_id"`
+	DeliveredNanos int64     `json:"-" dynamodbav:"delivered_ns"`
+	DeliveredAt    time.Time `json:"delivered_at" dynamodbav:"delivered_at"`
+	EventType      string    `json:"event_type" dynamodbav:"event_type"`
+	PaymentID      string    `json:"payment_id,omitempty" dynamodbav:"payment_id,omitempty"`
+	Success        bool      `json:"success" dynamodbav:"success"`
+	StatusCode     int       `json:"status_code,omitempty" dynamodbav:"status_code,omitempty"` // 0 when the endpoint never answered
+	LatencyMs      int64     `json:"latency_ms" dynamodbav:"latency_ms"`
+	Error          string    `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	TTL            int64     `json:"-" dynamodbav:"ttl"` // DynamoDB TTL attribute, once the attempt leaves retention
+}
+
+// NewWebhookDelivery records an attempt that finished at deliveredAt
+func NewWebhookDelivery(subscriptionID string, deliveredAt time.Time, latency time.Duration) *WebhookDelivery {
+	deliveredAt = deliveredAt.UTC()
+	return &WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		DeliveredNanos: deliveredAt.UnixNano(),
+		DeliveredAt:    deliveredAt,
+		LatencyMs:      latency.Milliseconds(),
+		TTL:            deliveredAt.Add(WebhookDeliveryRetention).Unix(),
+	}
+}
+
+// WebhookDeliveryStats summarizes a subscription's delivery attempts since a point in time
+type WebhookDeliveryStats struct {
+	SubscriptionID string             `json:"subscription_id"`
+	Since          time.Time          `json:"since"`
+	Deliveries     int                `json:"deliveries"`
+	Succeeded      int                `json:"succeeded"`
+	Failed         int                `json:"failed"`
+	SuccessRate    float64            `json:"success_rate"`    // Fraction of attempts that succeeded; 0 without attempts
+	P95LatencyMs   int64              `json:"p95_latency_ms"`  // Nearest-rank 95th percentile over every attempt
+	RecentFailures []*WebhookDelivery `json:"recent_failures"` // Newest first
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/errors"
+)
+
+// WebhookSubscription is an endpoint a customer registered to receive its payments' webhooks
+type WebhookSubscription struct {
+	SubscriptionID          string       `json:"subscription_id" dynamodbav:"subscription_id"`
+	CustomerID              string       `json:"customer_id" dynamodbav:"customer_id"` // API key ID whose payments are delivered
+	URL                     string       `json:"url" dynamodbav:"url"`
+	EventTypes              []string     `json:"event_types,omitempty" dynamodbav:"event_types,omitempty"` // Empty delivers every event
+	MTLS                    *WebhookMTLS `json:"mtls,omitempty" dynamodbav:"mtls,omitempty"`
+	SigningSecrets          []string     `json:"signing_secrets,omitempty" dynamodbav:"signing_secrets,omitempty"` // Current first, then the one it replaced
+	PreviousSecretExpiresAt *time.Time   `json:"previous_secret_expires_at,omitempty" dynamodbav:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time    `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt               time.Time    `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// ActiveSecrets returns the secrets deliveries are signed with at the given time
+// The previous secret stays active until it expires, so receivers can switch to the new one without
+// rejecting a delivery in between.
+func (s *WebhookSubscription) ActiveSecrets(at time.Time) []string {
+	switch {
+	case len(s.SigningSecrets) == 0:
+		return nil
+	case len(s.SigningSecrets) == 1, s.PreviousSecretExpiresAt != nil && !at.Before(*s.PreviousSecretExpiresAt):
+		return s.SigningSecrets[:1]
+	}
+	return s.SigningSecrets[:2]
+}
+
+// WebhookMTLS is the client certificate presented to a receiver that requires mutual TLS
+// The certificate and key never leave Secrets Manager; only the secret's name or ARN is stored.
+type WebhookMTLS struct {
+	SecretID string `json:"secret_id" dynamodbav:"secret_id"` // JSON secret with PEM "certificate" and "private_key"
+}
+
+// Wants reports whether the subscription receives events of the given type
+func (s *WebhookSubscription) Wants(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscriptionRequest registers a webhook endpoint
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// Validate checks a subscription request; the URL itself is checked by the validator package
+func (r *WebhookSubscriptionRequest) Validate() *errors.AppError {
+	r.URL = strings.TrimSpace(r.URL)
+	if r.URL == "" {
+		return errors.ErrValidation("url", "is required")
+	}
+	for _, t := range r.EventTypes {
+		if !strings.HasPrefix(t, "payment.") {
+			return errors.ErrValidation("event_types", "must name payment.* events")
+		}
+	}
+	return nil
+}
+
+// WebhookMTLSRequest sets or clears the client certificate of a subscription
+type WebhookMTLSRequest struct {
+	SecretID string `json:"secret_id"` // Empty turns mutual TLS off
+}
@@ -0,0 +1,121 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultMinorUnits is the exponent for currencies not listed in minorUnits
+const defaultMinorUnits = 2
+
+// minorUnits is the ISO 4217 exponent for currencies that don't use two decimal places
+var minorUnits = map[string]int{
+	// Zero-decimal currencies
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"UGX": 0,
+	// Three-decimal currencies
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+	"IQD": 3,
+	"LYD": 3,
+}
+
+// currencySymbols are used when formatting amounts for display; other currencies use their code
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// MinorUnits returns the number of decimal places in the currency's minor unit
+func MinorUnits(currency string) int {
+	if units, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return units
+	}
+	return defaultMinorUnits
+}
+
+// scale returns how many minor units make one major unit (100 for USD, 1 for JPY)
+func scale(currency string) float64 {
+	return math.Pow10(MinorUnits(currency))
+}
+
+// ToMajor converts an amount in minor units to major units, e.g. 12345 USD cents to 123.45
+func ToMajor(amount int64, currency string) float64 {
+	return float64(amount) / scale(currency)
+}
+
+// FromMajor converts an amount in major units to minor units, rounding to the nearest minor unit
+func FromMajor(amount float64, currency string) int64 {
+	return int64(math.Round(amount * scale(currency)))
+}
+
+// Convert converts an amount in from's minor units to to's minor units at rate (units of to per unit of from)
+// The result is rounded half to even, so conversions don't drift in either party's favour and
+// float error like 999.9999 cents still lands on the intended 1000.
+func Convert(amount int64, from, to string, rate float64) int64 {
+	return int64(math.RoundToEven(float64(amount) * rate * Rescale(from, to)))
+}
+
+// Rescale returns the factor that turns a minor-unit amount in from into the same number of
+// major units expressed in to's minor units (1 for USD->EUR, 0.01 for USD->JPY, 10 for USD->BHD)
+func Rescale(from, to string) float64 {
+	return math.Pow10(MinorUnits(to) - MinorUnits(from))
+}
+
+// Format renders an amount in minor units for display, e.g. "$1,234.56", "¥1,235", "1.235 BHD"
+func Format(amount int64, currency string) string {
+	currency = strings.ToUpper(currency)
+	units := MinorUnits(currency)
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	major := amount
+	for i := 0; i < units; i++ {
+		major /= 10
+	}
+	text := groupThousands(major)
+	if units > 0 {
+		minor := amount - major*int64(scale(currency))
+		text = fmt.Sprintf("%s.%0*d", text, units, minor)
+	}
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + text
+	}
+	return sign + text + " " + currency
+}
+
+// groupThousands formats a non-negative integer with comma separators
+func groupThousands(n int64) string {
+	digits := fmt.Sprintf("%d", n)
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
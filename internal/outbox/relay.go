@@ -0,0 +1,188 @@
+// Package outbox relays outbox messages written alongside payment changes to where they are delivered:
+// payment jobs to the payment backend and webhook events to the webhook queue.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/schema"
+	"crypto-conversion/internal/tracing"
+)
+
+// sweepBatchSize caps how many outbox messages a scheduled sweep delivers
+const sweepBatchSize = 100
+
+// errUndeliverable marks a message that can never be delivered, so retrying it would block the outbox
+var errUndeliverable = errors.New("undeliverable outbox message")
+
+// Handler manages the Outbox Relay Lambda dependencies
+type Handler struct {
+	db      database.PaymentRepository
+	backend payment.Backend
+	queue   *queue.Client
+	cfg     *config.Config
+}
+
+// NewHandler creates a new outbox relay handler
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	// Initialize payment storage for the configured backend
+	db, _, err := database.NewRepositories(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize queue client
+	q, err := queue.NewClient(cfg.AWS.Region, cfg.Queue.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payments start on SQS by default, or as a Step Functions execution
+	var backend payment.Backend = payment.NewQueueBackend(queue.NewQueueAdapter(q, cfg.Queue.PaymentQueueURL))
+	if cfg.Orchestration.UseStepFunctions() {
+		backend, err = payment.NewStepFunctionsOrchestrator(cfg.AWS.Region, cfg.Orchestration.StateMachineARN, db, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Handler{
+		db:      db,
+		backend: backend,
+		queue:   q,
+		cfg:     cfg,
+	}, nil
+}
+
+// HandleStream delivers outbox messages as they are inserted (DynamoDB Streams trigger)
+// A failed delivery is reported as a batch item failure, so Lambda retries from that record
+// rather than redelivering the whole batch (requires ReportBatchItemFailures on the mapping).
+func (h *Handler) HandleStream(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	var response events.DynamoDBEventResponse
+	for _, record := range event.Records {
+		// Deletions of delivered messages show up on the stream too
+		if record.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+
+		image := record.Change.NewImage
+		msg := &models.OutboxMessage{
+			MessageID: image["message_id"].String(),
+			Kind:      image["kind"].String(),
+			PaymentID: image["payment_id"].String(),
+			Payload:   image["payload"].String(),
+		}
+		// Messages written before tracing was enabled carry no trace
+		if header, ok := image["trace_header"]; ok {
+			msg.TraceHeader = header.String()
+		}
+
+		// Later records are retried along with this one, so stop here
+		if err := h.relay(ctx, msg); err != nil {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+				ItemIdentifier: record.Change.SequenceNumber,
+			})
+			return response, nil
+		}
+	}
+
+	return response, nil
+}
+
+// HandleSweep delivers any pending outbox messages (scheduled trigger for backends without streams)
+func (h *Handler) HandleSweep(ctx context.Context, event events.CloudWatchEvent) error {
+	messages, err := h.db.ListOutboxMessages(ctx, sweepBatchSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Sweeping outbox", logger.Fields{
+		"count": len(messages),
+	})
+
+	for _, msg := range messages {
+		if err := h.relay(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relay delivers a single outbox message and removes it once delivered
+// Delivery is at-least-once; the worker's processing guards absorb duplicate jobs
+// and webhook consumers should dedupe on payment ID and event type.
+// Undeliverable messages are dropped with an alert instead of being retried forever.
+func (h *Handler) relay(ctx context.Context, msg *models.OutboxMessage) error {
+	// Continue the trace of the request that wrote the message
+	err := h.deliver(tracing.WithTraceHeader(ctx, msg.TraceHeader), msg)
+	if errors.Is(err, errUndeliverable) {
+		logger.Error("ALERT: dropping undeliverable outbox message", logger.Fields{
+			"alert":      "outbox_undeliverable",
+			"error":      err.Error(),
+			"message_id": msg.MessageID,
+			"kind":       msg.Kind,
+			"payment_id": msg.PaymentID,
+			"payload":    msg.Payload,
+		})
+		if msg.MessageID == "" {
+			return nil
+		}
+		return h.db.DeleteOutboxMessage(ctx, msg.MessageID)
+	}
+	if err != nil {
+		logger.Error("Failed to relay outbox message", logger.Fields{
+			"error":      err.Error(),
+			"message_id": msg.MessageID,
+			"kind":       msg.Kind,
+			"payment_id": msg.PaymentID,
+		})
+		return err
+	}
+
+	if err := h.db.DeleteOutboxMessage(ctx, msg.MessageID); err != nil {
+		return err
+	}
+
+	logger.Info("Outbox message relayed", logger.Fields{
+		"message_id": msg.MessageID,
+		"kind":       msg.Kind,
+		"payment_id": msg.PaymentID,
+	})
+	return nil
+}
+
+// deliver publishes an outbox message to its destination
+func (h *Handler) deliver(ctx context.Context, msg *models.OutboxMessage) error {
+	switch msg.Kind {
+	case models.OutboxKindPaymentJob:
+		job, err := schema.DecodePaymentJob([]byte(msg.Payload))
+		if err != nil {
+			return fmt.Errorf("%w: failed to unmarshal payment job: %v", errUndeliverable, err)
+		}
+		return h.backend.StartPayment(ctx, job)
+	case models.OutboxKindPaymentJobBatch:
+		jobs, err := schema.DecodePaymentJobs([]byte(msg.Payload))
+		if err != nil {
+			return fmt.Errorf("%w: failed to unmarshal payment job batch: %v", errUndeliverable, err)
+		}
+		return payment.StartPayments(ctx, h.backend, jobs)
+	case models.OutboxKindWebhookEvent:
+		event, err := schema.DecodeWebhookEvent([]byte(msg.Payload))
+		if err != nil {
+			return fmt.Errorf("%w: failed to unmarshal webhook event: %v", errUndeliverable, err)
+		}
+		return h.queue.SendWebhookEvent(ctx, h.cfg.Queue.WebhookQueueURL, event)
+	default:
+		return fmt.Errorf("%w: unknown kind %q", errUndeliverable, msg.Kind)
+	}
+}
@@ -0,0 +1,172 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/tracing"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend records started payments and the trace each was started in, failing those listed in fail
+type fakeBackend struct {
+	fail    map[string]bool
+	started []string
+	traces  []string
+}
+
+func (b *fakeBackend) StartPayment(ctx context.Context, job *models.PaymentJob) error {
+	if b.fail[job.PaymentID] {
+		return fmt.Errorf("backend unavailable")
+	}
+	b.started = append(b.started, job.PaymentID)
+	b.traces = append(b.traces, tracing.TraceHeader(ctx))
+	return nil
+}
+
+func streamRecord(seq int, eventName, kind, paymentID, payload string) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventName: eventName,
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: strconv.Itoa(seq),
+			NewImage: map[string]events.DynamoDBAttributeValue{
+				"message_id": events.NewStringAttribute(fmt.Sprintf("msg_%d", seq)),
+				"kind":       events.NewStringAttribute(kind),
+				"payment_id": events.NewStringAttribute(paymentID),
+				"payload":    events.NewStringAttribute(payload),
+			},
+		},
+	}
+}
+
+func jobPayload(paymentID string) string {
+	return fmt.Sprintf(`{"payment_id":%q}`, paymentID)
+}
+
+func TestHandleStreamReportsFailedRecord(t *testing.T) {
+	backend := &fakeBackend{fail: map[string]bool{"pay_down": true}}
+	h := &Handler{db: database.NewMemoryPaymentRepository(), backend: backend}
+
+	insert := string(events.DynamoDBOperationTypeInsert)
+	response, err := h.HandleStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord(1, string(events.DynamoDBOperationTypeRemove), models.OutboxKindPaymentJob, "pay_deleted", jobPayload("pay_deleted")),
+		streamRecord(2, insert, models.OutboxKindPaymentJob, "pay_1", jobPayload("pay_1")),
+		streamRecord(3, insert, "carrier_pigeon", "pay_unroutable", jobPayload("pay_unroutable")),
+		streamRecord(4, insert, models.OutboxKindPaymentJob, "pay_malformed", "{not json"),
+		streamRecord(5, insert, models.OutboxKindPaymentJob, "pay_down", jobPayload("pay_down")),
+		streamRecord(6, insert, models.OutboxKindPaymentJob, "pay_after", jobPayload("pay_after")),
+	}})
+	require.NoError(t, err)
+
+	// Undeliverable records are dropped rather than blocking the stream; the transient failure
+	// is reported so Lambda retries from it, along with the records after it
+	assert.Equal(t, []string{"pay_1"}, backend.started)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "5"}}, response.BatchItemFailures)
+
+	backend.fail = nil
+	response, err = h.HandleStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord(5, insert, models.OutboxKindPaymentJob, "pay_down", jobPayload("pay_down")),
+		streamRecord(6, insert, models.OutboxKindPaymentJob, "pay_after", jobPayload("pay_after")),
+	}})
+	require.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, []string{"pay_1", "pay_down", "pay_after"}, backend.started)
+}
+
+func TestHandleSweepDropsUndeliverableMessages(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemoryPaymentRepository()
+	for _, msg := range []*models.OutboxMessage{
+		{MessageID: "msg_bad", Kind: "carrier_pigeon", PaymentID: "pay_bad", Payload: jobPayload("pay_bad")},
+		{MessageID: "msg_good", Kind: models.OutboxKindPaymentJob, PaymentID: "pay_good", Payload: jobPayload("pay_good")},
+	} {
+		require.NoError(t, db.CreatePaymentWithOutbox(ctx, &models.Payment{PaymentID: msg.PaymentID, IdempotencyKey: msg.PaymentID}, msg))
+	}
+
+	backend := &fakeBackend{}
+	h := &Handler{db: db, backend: backend}
+	require.NoError(t, h.HandleSweep(ctx, events.CloudWatchEvent{}))
+	assert.Equal(t, []string{"pay_good"}, backend.started)
+
+	remaining, err := db.ListOutboxMessages(ctx, sweepBatchSize)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+// fakeQueue records jobs enqueued one at a time and in batches
+type fakeQueue struct {
+	single  []string
+	batches [][]string
+}
+
+func (q *fakeQueue) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
+	q.single = append(q.single, job.PaymentID)
+	return nil
+}
+
+func (q *fakeQueue) EnqueuePayments(ctx context.Context, jobs []*models.PaymentJob) error {
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.PaymentID)
+	}
+	q.batches = append(q.batches, ids)
+	return nil
+}
+
+func TestHandleStreamStartsPaymentBatch(t *testing.T) {
+	insert := string(events.DynamoDBOperationTypeInsert)
+	batch := streamRecord(1, insert, models.OutboxKindPaymentJobBatch, "batch_1",
+		`[{"payment_id":"pay_1"},{"payment_id":"pay_2"},{"payment_id":"pay_3"}]`)
+
+	// The queue backend sends the batch's jobs together
+	q := &fakeQueue{}
+	h := &Handler{db: database.NewMemoryPaymentRepository(), backend: payment.NewQueueBackend(q)}
+	response, err := h.HandleStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{batch}})
+	require.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, [][]string{{"pay_1", "pay_2", "pay_3"}}, q.batches)
+	assert.Empty(t, q.single)
+
+	// Backends without batch support start each payment
+	backend := &fakeBackend{}
+	h.backend = backend
+	response, err = h.HandleStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{batch}})
+	require.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, []string{"pay_1", "pay_2", "pay_3"}, backend.started)
+}
+
+func TestRelayContinuesWritersTrace(t *testing.T) {
+	tracing.Configure(true)
+	t.Cleanup(func() { tracing.Configure(false) })
+
+	// The API records its trace on the message when it writes it
+	writerTrace := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	ctx := tracing.WithTraceHeader(context.Background(), writerTrace)
+	db := database.NewMemoryPaymentRepository()
+	msg := &models.OutboxMessage{MessageID: "msg_1", Kind: models.OutboxKindPaymentJob, PaymentID: "pay_1", Payload: jobPayload("pay_1")}
+	require.NoError(t, db.CreatePaymentWithOutbox(ctx, &models.Payment{PaymentID: "pay_1", IdempotencyKey: "pay_1"}, msg))
+
+	backend := &fakeBackend{}
+	h := &Handler{db: db, backend: backend}
+	require.NoError(t, h.HandleSweep(context.Background(), events.CloudWatchEvent{}))
+
+	// Stream records carry it as an attribute, absent on messages written untraced
+	insert := string(events.DynamoDBOperationTypeInsert)
+	traced := streamRecord(2, insert, models.OutboxKindPaymentJob, "pay_2", jobPayload("pay_2"))
+	traced.Change.NewImage["trace_header"] = events.NewStringAttribute(writerTrace)
+	untraced := streamRecord(3, insert, models.OutboxKindPaymentJob, "pay_3", jobPayload("pay_3"))
+	response, err := h.HandleStream(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{traced, untraced}})
+	require.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+
+	assert.Equal(t, []string{"pay_1", "pay_2", "pay_3"}, backend.started)
+	assert.Equal(t, []string{writerTrace, writerTrace, ""}, backend.traces)
+}
@@ -0,0 +1,65 @@
+package payment
+
+import (
+	"context"
+
+	"crypto-conversion/internal/models"
+)
+
+// Backend starts asynchronous processing for a newly accepted payment
+type Backend interface {
+	StartPayment(ctx context.Context, job *models.PaymentJob) error
+}
+
+// BatchBackend starts processing for many newly accepted payments at once
+type BatchBackend interface {
+	StartPayments(ctx context.Context, jobs []*models.PaymentJob) error
+}
+
+// BatchQueueClient is a queue client that can enqueue many jobs per call
+type BatchQueueClient interface {
+	EnqueuePayments(ctx context.Context, jobs []*models.PaymentJob) error
+}
+
+// StartPayments starts each payment on the backend, in one call if the backend supports batches
+func StartPayments(ctx context.Context, backend Backend, jobs []*models.PaymentJob) error {
+	if batch, ok := backend.(BatchBackend); ok {
+		return batch.StartPayments(ctx, jobs)
+	}
+	for _, job := range jobs {
+		if err := backend.StartPayment(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueBackend drives payments through SQS delay re-enqueue (the default mode)
+type QueueBackend struct {
+	queueClient QueueClient
+}
+
+// NewQueueBackend creates an SQS-driven orchestration backend
+func NewQueueBackend(queue QueueClient) *QueueBackend {
+	return &QueueBackend{
+		queueClient: queue,
+	}
+}
+
+// StartPayment enqueues the first state machine step with no delay
+func (b *QueueBackend) StartPayment(ctx context.Context, job *models.PaymentJob) error {
+	return b.queueClient.EnqueuePaymentWithDelay(ctx, job, 0)
+}
+
+// StartPayments enqueues the first step of each payment with no delay, batching the sends where the queue can
+func (b *QueueBackend) StartPayments(ctx context.Context, jobs []*models.PaymentJob) error {
+	if batch, ok := b.queueClient.(BatchQueueClient); ok {
+		return batch.EnqueuePayments(ctx, jobs)
+	}
+	for _, job := range jobs {
+		if err := b.StartPayment(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/logger"
+)
+
+// StatefulBridgeClient is a mock of Circle's CCTP that simulates async cross-chain transfers
+// A transfer burns USDC on the source chain, waits for Circle's attestation of the burn once
+// the source chain finalizes it, then mints on the destination chain. It settles once minted.
+type StatefulBridgeClient struct {
+	transfers map[string]*Transfer
+	behaviour *mockBehaviour
+	mu        sync.RWMutex
+}
+
+// NewStatefulBridgeClient creates a new stateful CCTP bridge client
+// By default no burn fails; opts seed the attestation timing or script failures.
+func NewStatefulBridgeClient(opts ...MockOption) *StatefulBridgeClient {
+	return &StatefulBridgeClient{
+		transfers: make(map[string]*Transfer),
+		behaviour: newMockBehaviour(0, 0, opts),
+	}
+}
+
+// InitiateTransfer burns USDC on sourceChain for minting on destinationChain (returns immediately)
+func (c *StatefulBridgeClient) InitiateTransfer(ctx context.Context, stablecoinAmount int64, sourceChain, destinationChain string) (string, error) {
+	sourceDomain, ok := chains.CCTPDomain(sourceChain)
+	if !ok {
+		return "", fmt.Errorf("CCTP does not support %s", sourceChain)
+	}
+	destinationDomain, ok := chains.CCTPDomain(destinationChain)
+	if !ok {
+		return "", fmt.Errorf("CCTP does not support %s", destinationChain)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Generate transaction ID
+	txID := fmt.Sprintf("cctp_%d_%d_%d", sourceDomain, destinationDomain, time.Now().UnixNano())
+
+	scenario, scripted := c.behaviour.scenario(stablecoinAmount, destinationChain)
+	if c.behaviour.declines(scenario, scripted) {
+		return "", fmt.Errorf("mock CCTP burn failed")
+	}
+
+	// Attestation waits on source chain finality: 3-5 polls from Ethereum, 1-2 elsewhere
+	minPolls, spread := 1, 2
+	if sourceChain == "ethereum" {
+		minPolls, spread = 3, 3
+	}
+	settlesAfter := c.behaviour.settlesAfter(minPolls, spread, scenario, scripted)
+
+	transfer := &Transfer{
+		TxID:             txID,
+		Status:           TransferStatusPending,
+		Amount:           stablecoinAmount,
+		Currency:         "USDC",
+		Route:            sourceChain + "->" + destinationChain,
+		StablecoinAmount: stablecoinAmount,
+		CreatedAt:        time.Now(),
+		PollCount:        0,
+		SettlesAfterPoll: settlesAfter,
+	}
+
+	c.transfers[txID] = transfer
+	if scripted {
+		c.behaviour.script(txID, scenario)
+	}
+
+	logger.Info("CCTP bridge transfer initiated", logger.Fields{
+		"tx_id":              txID,
+		"stablecoin_amount":  stablecoinAmount,
+		"route":              transfer.Route,
+		"settles_after_poll": settlesAfter,
+	})
+
+	return txID, nil
+}
+
+// GetTransferStatus polls the status of a transfer
+// Burns that were submitted only wait on attestation unless failed by a failure rate or scenario.
+func (c *StatefulBridgeClient) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	transfer, exists := c.transfers[txID]
+	if !exists {
+		return nil, fmt.Errorf("transfer not found: %s", txID)
+	}
+
+	// Increment poll count
+	transfer.PollCount++
+
+	// Mint once the burn is attested
+	if transfer.Status == TransferStatusPending && transfer.PollCount >= transfer.SettlesAfterPoll {
+		switch c.behaviour.outcome(txID) {
+		case TransferStatusFailed:
+			transfer.Status = TransferStatusFailed
+			logger.Warn("CCTP bridge transfer failed", logger.Fields{
+				"tx_id":      txID,
+				"route":      transfer.Route,
+				"poll_count": transfer.PollCount,
+			})
+		case TransferStatusSettled:
+			transfer.Status = TransferStatusSettled
+			now := time.Now()
+			transfer.SettledAt = &now
+			logger.Info("CCTP bridge transfer minted", logger.Fields{
+				"tx_id":      txID,
+				"route":      transfer.Route,
+				"poll_count": transfer.PollCount,
+			})
+		}
+	}
+
+	logger.Info("CCTP bridge status polled", logger.Fields{
+		"tx_id":      txID,
+		"status":     transfer.Status,
+		"poll_count": transfer.PollCount,
+	})
+
+	// Return a copy
+	copied := *transfer
+	return &copied, nil
+}
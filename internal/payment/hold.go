@@ -0,0 +1,151 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// CountryRisks scores destination countries; fees.MockDataProvider is one
+type CountryRisks interface {
+	GetCountryRisk(country string) fees.CountryRisk
+}
+
+// HoldPolicy decides which payments are held for manual approval before they go ahead
+// Each trigger is off at its zero value.
+type HoldPolicy struct {
+	AmountThreshold int64   // Hold payments of at least this amount, in minor units of the funding currency
+	RiskScore       float64 // Hold payouts to countries whose risk score reaches this
+	SanctionsHit    bool    // Hold payments whose sanctions hit compliance released, for a second approval
+	Risks           CountryRisks
+	Corridors       *corridors.Registry
+}
+
+// Triggers returns the triggers the payment trips, with a description of each
+// A payment an operator already approved isn't held again for its amount or destination.
+func (p *HoldPolicy) Triggers(payment *models.Payment) ([]string, []string) {
+	if p == nil {
+		return nil, nil
+	}
+
+	var triggers, details []string
+	if !payment.Hold.Approved() {
+		if p.AmountThreshold > 0 && payment.Amount >= p.AmountThreshold {
+			triggers = append(triggers, models.HoldTriggerAmount)
+			details = append(details, fmt.Sprintf("amount %d %s reaches the hold threshold %d",
+				payment.Amount, payment.FundingCurrency(), p.AmountThreshold))
+		}
+		if country := p.destination(payment); p.RiskScore > 0 && p.Risks != nil && country != "" {
+			if risk := p.Risks.GetCountryRisk(country); risk.RiskScore >= p.RiskScore {
+				triggers = append(triggers, models.HoldTriggerRiskScore)
+				details = append(details, fmt.Sprintf("payout to %s has risk score %.1f (%s)", country, risk.RiskScore, risk.Tier))
+			}
+		}
+	}
+	if p.SanctionsHit && payment.Screening.Released() {
+		triggers = append(triggers, models.HoldTriggerSanctions)
+		details = append(details, fmt.Sprintf("sanctions hit released by %s", payment.Screening.ReviewedBy))
+	}
+	return triggers, details
+}
+
+// destination returns the country the payment pays out in, or "" if it can't be told
+func (p *HoldPolicy) destination(payment *models.Payment) string {
+	if payment.Beneficiary != nil && payment.Beneficiary.Country != "" {
+		return payment.Beneficiary.Country
+	}
+	if p.Corridors == nil || payment.IsWalletPayout() {
+		return ""
+	}
+	corridor, err := p.Corridors.Lookup(payment.FundingCurrency(), payment.Currency)
+	if err != nil {
+		return ""
+	}
+	return corridor.DestinationCountry
+}
+
+// HoldIfTriggered moves a payment tripping the policy to ON_HOLD, reporting whether it did
+// Nothing moves until an operator decides; the caller persists the payment and calls AlertHold.
+func (p *HoldPolicy) HoldIfTriggered(payment *models.Payment) bool {
+	triggers, details := p.Triggers(payment)
+	if len(triggers) == 0 {
+		return false
+	}
+
+	detail := strings.Join(details, "; ")
+	payment.Hold = &models.Hold{
+		Triggers: triggers,
+		Detail:   detail,
+		HeldAt:   time.Now().UTC(),
+		HeldFrom: payment.Status,
+	}
+	transitionState(payment, models.StatusOnHold, "Held for manual approval: "+detail)
+	return true
+}
+
+// AlertHold logs the alert operators are paged on for a payment held for approval
+func AlertHold(payment *models.Payment) {
+	for _, trigger := range payment.Hold.Triggers {
+		metrics.Count("PaymentHolds", metrics.Dimensions{"Trigger": trigger})
+	}
+
+	// Operators alarm on this log line via a CloudWatch metric filter
+	logger.Error("ALERT: payment held for manual approval", logger.Fields{
+		"alert":      "payment_hold",
+		"payment_id": payment.PaymentID,
+		"triggers":   strings.Join(payment.Hold.Triggers, ","),
+		"detail":     payment.Hold.Detail,
+		"held_from":  payment.Hold.HeldFrom,
+	})
+}
+
+// ResolveHold applies an operator's decision to a payment held in ON_HOLD, recording it in the state history
+// Approval returns the payment to the status it was held from. Rejection fails a payment held before
+// any funds moved, and reverses one held after its on-ramp.
+// The caller persists the payment and enqueues its next step or terminal webhook.
+func ResolveHold(payment *models.Payment, decision, actor, reason string) error {
+	if payment.Status != models.StatusOnHold || payment.Hold == nil {
+		return fmt.Errorf("payment is %s, not %s", payment.Status, models.StatusOnHold)
+	}
+
+	now := time.Now().UTC()
+	var message string
+	switch decision {
+	case models.HoldDecisionApprove:
+		message = fmt.Sprintf("Hold approved by %s", actor)
+	case models.HoldDecisionReject:
+		message = fmt.Sprintf("Hold rejected by %s", actor)
+	default:
+		return fmt.Errorf("unknown hold decision: %s", decision)
+	}
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+
+	payment.Hold.Decision = decision
+	payment.Hold.ReviewedBy = actor
+	payment.Hold.ReviewedAt = &now
+	payment.Hold.Reason = reason
+
+	if decision == models.HoldDecisionApprove {
+		transitionState(payment, payment.Hold.HeldFrom, message)
+		return nil
+	}
+
+	payment.ErrorMessage = message
+	payment.ErrorCode = errors.PaymentReviewRejected
+	if payment.Hold.HeldFrom == models.StatusPending {
+		payment.ProcessedAt = &now
+		transitionState(payment, models.StatusFailed, message)
+		return nil
+	}
+	transitionState(payment, models.StatusReversing, message)
+	return nil
+}
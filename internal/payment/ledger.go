@@ -0,0 +1,91 @@
+package payment
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// LedgerPoster interface for recording each leg of a payment in the double-entry ledger
+// Post reports false for a transaction that was already posted, and wraps ledger.ErrInvariant
+// when the transaction would break the ledger's invariants.
+type LedgerPoster interface {
+	Post(ctx context.Context, txn *models.LedgerTransaction) (bool, error)
+}
+
+// EnableLedger records the debits and credits of every state transition that moves money
+// gasCosts is what each transaction we send costs on a chain, in USDC minor units; chains
+// without one record no gas expense.
+func (sm *StateMachine) EnableLedger(poster LedgerPoster, gasCosts map[string]int64) {
+	sm.ledger = poster
+	sm.gasCosts = gasCosts
+}
+
+// ledgerAmounts is what the payment moves through the ledger: the USDC minted for it and the fees it earns
+func ledgerAmounts(payment *models.Payment) ledger.Amounts {
+	funds := mintedUSDC(payment)
+	fee := toStablecoin(payment.FeeAmount, payment.FundingCurrency())
+	if fee > funds {
+		fee = funds
+	}
+	if fee < 0 {
+		fee = 0
+	}
+	return ledger.Amounts{Funds: funds, Fee: fee}
+}
+
+// postLedger records one leg of a payment against the transition that caused it
+// Like treasury postings, failures are logged rather than returned: the funds have already moved.
+// Sandbox payments are kept out of the books.
+func (sm *StateMachine) postLedger(ctx context.Context, payment *models.Payment, leg string, entries []models.LedgerEntry) {
+	if sm.ledger == nil || payment.Sandbox {
+		return
+	}
+
+	txn := &models.LedgerTransaction{
+		TransactionID: payment.PaymentID + ":" + leg,
+		PaymentID:     payment.PaymentID,
+		Leg:           leg,
+		Entries:       entries,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if n := len(payment.StateHistory); n > 0 {
+		txn.FromStatus = payment.StateHistory[n-1].FromStatus
+		txn.ToStatus = payment.StateHistory[n-1].ToStatus
+	}
+
+	if _, err := sm.ledger.Post(ctx, txn); err != nil {
+		alert := "ledger_posting_failed"
+		if stderrors.Is(err, ledger.ErrInvariant) {
+			alert = "ledger_invariant_violated"
+		}
+		metrics.Count("LedgerPostingFailures", metrics.Dimensions{"Leg": leg})
+		logger.Error("ALERT: ledger transaction not recorded", logger.Fields{
+			"alert":          alert,
+			"payment_id":     payment.PaymentID,
+			"transaction_id": txn.TransactionID,
+			"error":          err.Error(),
+		})
+	}
+}
+
+// fundsChain is the chain the payment's USDC is held on: the off-ramp's chain once bridged there
+func fundsChain(payment *models.Payment) string {
+	if payment.NeedsBridge() && reached(payment, models.StatusBridgeComplete) {
+		return payment.OffRampChain
+	}
+	return payment.Chain
+}
+
+// payoutChain is the chain a payment's payout leaves our wallet on
+func payoutChain(payment *models.Payment) string {
+	if payment.IsWalletPayout() {
+		return payment.Chain
+	}
+	return payment.RedeemChain()
+}
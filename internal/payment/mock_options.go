@@ -0,0 +1,137 @@
+package payment
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"time"
+)
+
+// MockScenario scripts what a mock provider does with a transfer, in place of its random failures and timing
+type MockScenario struct {
+	Declined    bool           // InitiateTransfer refuses the transfer
+	Outcome     TransferStatus // Status the transfer ends in; TransferStatusPending never settles. Empty settles.
+	SettleAfter int            // Poll the transfer reaches Outcome on; 0 keeps the provider's usual timing
+}
+
+// MockOption configures a stateful mock provider
+type MockOption func(*mockBehaviour)
+
+// WithSeed seeds the provider's random source, so the same calls give the same failures, timing and hashes
+func WithSeed(seed int64) MockOption {
+	return func(b *mockBehaviour) {
+		b.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithFailureRates sets the chance a transfer is refused on initiation and the chance it fails on settlement
+func WithFailureRates(initiation, settlement float64) MockOption {
+	return func(b *mockBehaviour) {
+		b.initiationFailureRate = initiation
+		b.settlementFailureRate = settlement
+	}
+}
+
+// WithSettleAfter makes every transfer settle or fail on its polls-th poll instead of a random one
+func WithSettleAfter(polls int) MockOption {
+	return func(b *mockBehaviour) {
+		b.settleAfter = polls
+	}
+}
+
+// WithAmountScenarios scripts transfers by amount: the funding amount for on-ramps,
+// the stablecoin amount for off-ramps, bridges and wallet sends
+func WithAmountScenarios(scenarios map[int64]MockScenario) MockOption {
+	return func(b *mockBehaviour) {
+		b.amountScenarios = scenarios
+	}
+}
+
+// WithDestinationScenarios scripts transfers by where they go: the off-ramp's payout currency,
+// the bridge's destination chain or the wallet's payout address (the on-ramp's funding currency)
+// A scenario for the transfer's amount takes precedence.
+func WithDestinationScenarios(scenarios map[string]MockScenario) MockOption {
+	return func(b *mockBehaviour) {
+		b.destinationScenarios = scenarios
+	}
+}
+
+// mockBehaviour is how a stateful mock provider picks failures and timing
+// Its random source isn't safe for concurrent use; providers only use it holding their lock.
+type mockBehaviour struct {
+	rng                   *rand.Rand
+	initiationFailureRate float64
+	settlementFailureRate float64
+	settleAfter           int // 0 settles within the provider's usual range
+	amountScenarios       map[int64]MockScenario
+	destinationScenarios  map[string]MockScenario
+	outcomes              map[string]TransferStatus // Scripted outcome of each scenario transfer
+}
+
+// newMockBehaviour applies opts over a provider's failure rates
+func newMockBehaviour(initiationFailureRate, settlementFailureRate float64, opts []MockOption) *mockBehaviour {
+	b := &mockBehaviour{
+		rng:                   rand.New(rand.NewSource(time.Now().UnixNano())),
+		initiationFailureRate: initiationFailureRate,
+		settlementFailureRate: settlementFailureRate,
+		outcomes:              make(map[string]TransferStatus),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// scenario returns the scenario scripted for a transfer of amount to destination, if any
+func (b *mockBehaviour) scenario(amount int64, destination string) (MockScenario, bool) {
+	if scenario, ok := b.amountScenarios[amount]; ok {
+		return scenario, true
+	}
+	scenario, ok := b.destinationScenarios[destination]
+	return scenario, ok
+}
+
+// declines reports whether a transfer is refused on initiation
+func (b *mockBehaviour) declines(scenario MockScenario, scripted bool) bool {
+	if scripted {
+		return scenario.Declined
+	}
+	return b.rng.Float64() < b.initiationFailureRate
+}
+
+// settlesAfter returns the poll a transfer settles on, minPolls plus up to spread-1 unless scripted or fixed
+func (b *mockBehaviour) settlesAfter(minPolls, spread int, scenario MockScenario, scripted bool) int {
+	switch {
+	case scripted && scenario.SettleAfter > 0:
+		return scenario.SettleAfter
+	case b.settleAfter > 0:
+		return b.settleAfter
+	}
+	return minPolls + b.rng.Intn(spread)
+}
+
+// script records the outcome a scenario transfer ends in
+func (b *mockBehaviour) script(txID string, scenario MockScenario) {
+	outcome := scenario.Outcome
+	if outcome == "" {
+		outcome = TransferStatusSettled
+	}
+	b.outcomes[txID] = outcome
+}
+
+// outcome returns the status a transfer reaching its settlement poll ends in
+func (b *mockBehaviour) outcome(txID string) TransferStatus {
+	if outcome, ok := b.outcomes[txID]; ok {
+		return outcome
+	}
+	if b.rng.Float64() < b.settlementFailureRate {
+		return TransferStatusFailed
+	}
+	return TransferStatusSettled
+}
+
+// txHash returns a random transaction hash for a mock on-chain leg
+func (b *mockBehaviour) txHash() string {
+	buf := make([]byte, 32)
+	b.rng.Read(buf)
+	return "0x" + hex.EncodeToString(buf)
+}
@@ -3,7 +3,7 @@ package payment
 import (
 	"context"
 	"fmt"
-	"math/rand"
+	"math"
 	"sync"
 	"time"
 
@@ -25,6 +25,9 @@ type Transfer struct {
 	Status           TransferStatus
 	Amount           int64
 	Currency         string
+	Rail             PayoutRail // Off-ramp transfers only
+	Route            string     // Bridge transfers only, e.g. "base->polygon"
+	TxHash           string     // On-chain transaction: the USDC mint for on-ramps, our USDC deposit for off-ramps
 	StablecoinAmount int64
 	CreatedAt        time.Time
 	SettledAt        *time.Time
@@ -32,16 +35,35 @@ type Transfer struct {
 	SettlesAfterPoll int // Settles after this many poll attempts
 }
 
+// mockUSDCRates are the mock units of USDC minted per unit of funding currency
+// EUR is minted through Circle's EUR on-ramp at roughly the mid-market EUR/USD rate.
+var mockUSDCRates = map[string]float64{
+	"USD": 1.0,
+	"EUR": 1.0875,
+}
+
+// toStablecoin converts a funding amount to the USDC it mints, to the nearest minor unit (1:1 for unknown currencies)
+func toStablecoin(amount int64, currency string) int64 {
+	rate, ok := mockUSDCRates[currency]
+	if !ok {
+		return amount
+	}
+	return int64(math.Round(float64(amount) * rate))
+}
+
 // StatefulOnRampClient is a mock that simulates async settlement
 type StatefulOnRampClient struct {
 	transfers map[string]*Transfer
+	behaviour *mockBehaviour
 	mu        sync.RWMutex
 }
 
 // NewStatefulOnRampClient creates a new stateful on-ramp client
-func NewStatefulOnRampClient() *StatefulOnRampClient {
+// By default 2% of transfers are refused and 5% fail on settlement; opts seed or script them.
+func NewStatefulOnRampClient(opts ...MockOption) *StatefulOnRampClient {
 	return &StatefulOnRampClient{
 		transfers: make(map[string]*Transfer),
+		behaviour: newMockBehaviour(0.02, 0.05, opts),
 	}
 }
 
@@ -53,27 +75,31 @@ func (c *StatefulOnRampClient) InitiateTransfer(ctx context.Context, amount int6
 	// Generate transaction ID
 	txID := fmt.Sprintf("onramp_%s_%d", currency, time.Now().UnixNano())
 
-	// Simulate 2% immediate failure rate
-	if rand.Float32() < 0.02 {
+	// Simulate immediate failures
+	scenario, scripted := c.behaviour.scenario(amount, currency)
+	if c.behaviour.declines(scenario, scripted) {
 		return "", fmt.Errorf("mock on-ramp initiation failed")
 	}
 
 	// Create pending transfer
 	// Settles after 2-4 poll attempts (simulating 4-8 minutes at 2-min polling)
-	settlesAfter := 2 + rand.Intn(3)
+	settlesAfter := c.behaviour.settlesAfter(2, 3, scenario, scripted)
 
 	transfer := &Transfer{
 		TxID:             txID,
 		Status:           TransferStatusPending,
 		Amount:           amount,
 		Currency:         currency,
-		StablecoinAmount: amount, // 1:1 for simplicity
+		StablecoinAmount: toStablecoin(amount, currency),
 		CreatedAt:        time.Now(),
 		PollCount:        0,
 		SettlesAfterPoll: settlesAfter,
 	}
 
 	c.transfers[txID] = transfer
+	if scripted {
+		c.behaviour.script(txID, scenario)
+	}
 
 	logger.Info("On-ramp transfer initiated", logger.Fields{
 		"tx_id":              txID,
@@ -100,15 +126,17 @@ func (c *StatefulOnRampClient) GetTransferStatus(ctx context.Context, txID strin
 
 	// Check if it should settle now
 	if transfer.Status == TransferStatusPending && transfer.PollCount >= transfer.SettlesAfterPoll {
-		// Simulate 5% failure rate on settlement
-		if rand.Float32() < 0.05 {
+		// Simulate failures on settlement
+		switch c.behaviour.outcome(txID) {
+		case TransferStatusFailed:
 			transfer.Status = TransferStatusFailed
 			logger.Warn("On-ramp transfer failed", logger.Fields{
 				"tx_id":      txID,
 				"poll_count": transfer.PollCount,
 			})
-		} else {
+		case TransferStatusSettled:
 			transfer.Status = TransferStatusSettled
+			transfer.TxHash = c.behaviour.txHash()
 			now := time.Now()
 			transfer.SettledAt = &now
 			logger.Info("On-ramp transfer settled", logger.Fields{
@@ -132,6 +160,7 @@ func (c *StatefulOnRampClient) GetTransferStatus(ctx context.Context, txID strin
 		Amount:           transfer.Amount,
 		Currency:         transfer.Currency,
 		StablecoinAmount: transfer.StablecoinAmount,
+		TxHash:           transfer.TxHash,
 		CreatedAt:        transfer.CreatedAt,
 		SettledAt:        transfer.SettledAt,
 		PollCount:        transfer.PollCount,
@@ -139,16 +168,55 @@ func (c *StatefulOnRampClient) GetTransferStatus(ctx context.Context, txID strin
 	}, nil
 }
 
+// InitiateReversal redeems already-minted USDC back to fiat for the source account
+// Used to compensate a payment whose off-ramp failed after on-ramp settled
+func (c *StatefulOnRampClient) InitiateReversal(ctx context.Context, stablecoinAmount int64, currency, sourceAccount string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Generate transaction ID
+	txID := fmt.Sprintf("reversal_%s_%d", currency, time.Now().UnixNano())
+
+	// Redemptions settle after 1-3 poll attempts
+	settlesAfter := c.behaviour.settlesAfter(1, 3, MockScenario{}, false)
+
+	transfer := &Transfer{
+		TxID:             txID,
+		Status:           TransferStatusPending,
+		Amount:           stablecoinAmount, // 1:1 for simplicity
+		Currency:         currency,
+		StablecoinAmount: stablecoinAmount,
+		CreatedAt:        time.Now(),
+		PollCount:        0,
+		SettlesAfterPoll: settlesAfter,
+	}
+
+	c.transfers[txID] = transfer
+
+	logger.Info("On-ramp reversal initiated", logger.Fields{
+		"tx_id":              txID,
+		"stablecoin_amount":  stablecoinAmount,
+		"currency":           currency,
+		"source_account":     sourceAccount,
+		"settles_after_poll": settlesAfter,
+	})
+
+	return txID, nil
+}
+
 // StatefulOffRampClient is a mock that simulates async settlement
 type StatefulOffRampClient struct {
 	transfers map[string]*Transfer
+	behaviour *mockBehaviour
 	mu        sync.RWMutex
 }
 
 // NewStatefulOffRampClient creates a new stateful off-ramp client
-func NewStatefulOffRampClient() *StatefulOffRampClient {
+// By default 2% of transfers are refused and 5% fail on settlement; opts seed or script them.
+func NewStatefulOffRampClient(opts ...MockOption) *StatefulOffRampClient {
 	return &StatefulOffRampClient{
 		transfers: make(map[string]*Transfer),
+		behaviour: newMockBehaviour(0.02, 0.05, opts),
 	}
 }
 
@@ -157,17 +225,21 @@ func (c *StatefulOffRampClient) InitiateTransfer(ctx context.Context, stablecoin
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	rail := PayoutRailFor(currency)
+
 	// Generate transaction ID
 	txID := fmt.Sprintf("offramp_%s_%d", currency, time.Now().UnixNano())
 
-	// Simulate 2% immediate failure rate
-	if rand.Float32() < 0.02 {
+	// Simulate immediate failures
+	scenario, scripted := c.behaviour.scenario(stablecoinAmount, currency)
+	if c.behaviour.declines(scenario, scripted) {
 		return "", fmt.Errorf("mock off-ramp initiation failed")
 	}
 
 	// Create pending transfer
-	// Settles after 2-4 poll attempts
-	settlesAfter := 2 + rand.Intn(3)
+	// Settles after 1-2 poll attempts on Faster Payments, 2-4 elsewhere
+	minPolls, spread := rail.settlementPolls()
+	settlesAfter := c.behaviour.settlesAfter(minPolls, spread, scenario, scripted)
 
 	transfer := &Transfer{
 		TxID:             txID,
@@ -175,17 +247,23 @@ func (c *StatefulOffRampClient) InitiateTransfer(ctx context.Context, stablecoin
 		StablecoinAmount: stablecoinAmount,
 		Amount:           stablecoinAmount, // 1:1 for simplicity
 		Currency:         currency,
+		Rail:             rail,
+		TxHash:           c.behaviour.txHash(),
 		CreatedAt:        time.Now(),
 		PollCount:        0,
 		SettlesAfterPoll: settlesAfter,
 	}
 
 	c.transfers[txID] = transfer
+	if scripted {
+		c.behaviour.script(txID, scenario)
+	}
 
 	logger.Info("Off-ramp transfer initiated", logger.Fields{
 		"tx_id":              txID,
 		"stablecoin_amount":  stablecoinAmount,
 		"currency":           currency,
+		"rail":               rail,
 		"settles_after_poll": settlesAfter,
 	})
 
@@ -207,14 +285,15 @@ func (c *StatefulOffRampClient) GetTransferStatus(ctx context.Context, txID stri
 
 	// Check if it should settle now
 	if transfer.Status == TransferStatusPending && transfer.PollCount >= transfer.SettlesAfterPoll {
-		// Simulate 5% failure rate on settlement
-		if rand.Float32() < 0.05 {
+		// Simulate failures on settlement
+		switch c.behaviour.outcome(txID) {
+		case TransferStatusFailed:
 			transfer.Status = TransferStatusFailed
 			logger.Warn("Off-ramp transfer failed", logger.Fields{
 				"tx_id":      txID,
 				"poll_count": transfer.PollCount,
 			})
-		} else {
+		case TransferStatusSettled:
 			transfer.Status = TransferStatusSettled
 			now := time.Now()
 			transfer.SettledAt = &now
@@ -238,7 +317,9 @@ func (c *StatefulOffRampClient) GetTransferStatus(ctx context.Context, txID stri
 		Status:           transfer.Status,
 		Amount:           transfer.Amount,
 		Currency:         transfer.Currency,
+		Rail:             transfer.Rail,
 		StablecoinAmount: transfer.StablecoinAmount,
+		TxHash:           transfer.TxHash,
 		CreatedAt:        transfer.CreatedAt,
 		SettledAt:        transfer.SettledAt,
 		PollCount:        transfer.PollCount,
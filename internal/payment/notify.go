@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"context"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// StatusNotifier pushes the statuses a payment enters to the clients watching it
+type StatusNotifier interface {
+	NotifyStatus(ctx context.Context, paymentID string, event models.PaymentEvent) error
+}
+
+// EnableStatusNotifications pushes each status a payment enters to the WebSocket clients subscribed to it
+func (sm *StateMachine) EnableStatusNotifications(notifier StatusNotifier) {
+	sm.notifier = notifier
+}
+
+// notifyTransitions pushes each status entered during the step, as customers see it
+// Pushing is best-effort: the state change is already persisted, and clients can read it back.
+func (sm *StateMachine) notifyTransitions(ctx context.Context, payment *models.Payment, historyLen int) {
+	if sm.notifier == nil {
+		return
+	}
+
+	for _, transition := range payment.StateHistory[historyLen:] {
+		event := transition.PublicEvent()
+		// Moving between holds looks like no change to customers
+		if transition.FromStatus.PublicStatus() == event.Status {
+			continue
+		}
+
+		if err := sm.notifier.NotifyStatus(ctx, payment.PaymentID, event); err != nil {
+			logger.Warn("Failed to push status notification", logger.Fields{
+				"payment_id": payment.PaymentID,
+				"to":         transition.ToStatus,
+				"error":      err.Error(),
+			})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/models"
+)
+
+// PollingConfig controls how long the state machine waits between settlement polls
+type PollingConfig struct {
+	InitialDelaySeconds int            // Delay before the first poll of a stage
+	MaxDelaySeconds     int            // Upper bound for any single delay (SQS caps at 900)
+	Multiplier          float64        // Growth factor applied after each pending poll
+	ChainDelaySeconds   map[string]int // Per-chain initial delay overrides
+	MaxPollAttempts     int            // Polls allowed per stage before timing out (0 = unlimited)
+	MaxStageDuration    time.Duration  // Wall-clock budget per stage before timing out (0 = unlimited)
+}
+
+// DefaultPollingConfig returns the default exponential backoff settings
+//
+// Fast-finality chains start polling sooner; Ethereum L1 starts later so
+// we don't hammer providers while waiting for confirmations.
+func DefaultPollingConfig() PollingConfig {
+	return PollingConfig{
+		InitialDelaySeconds: 15,
+		MaxDelaySeconds:     300,
+		Multiplier:          2.0,
+		MaxPollAttempts:     20,
+		MaxStageDuration:    time.Hour,
+		ChainDelaySeconds: map[string]int{
+			"solana":    5,
+			"avalanche": 5,
+			"base":      10,
+			"arbitrum":  10,
+			"optimism":  10,
+			"polygon":   15,
+			"ethereum":  60,
+		},
+	}
+}
+
+// initialDelay returns the delay before the first poll of a stage
+func (p PollingConfig) initialDelay(chain string) int {
+	delay := p.InitialDelaySeconds
+	if chainDelay, ok := p.ChainDelaySeconds[strings.ToLower(chain)]; ok {
+		delay = chainDelay
+	}
+	return p.clamp(delay)
+}
+
+// nextDelay returns the backoff delay following the given one
+func (p PollingConfig) nextDelay(current int, chain string) int {
+	if current <= 0 {
+		return p.initialDelay(chain)
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	return p.clamp(int(float64(current) * multiplier))
+}
+
+// clamp keeps a delay within [1, MaxDelaySeconds]
+func (p PollingConfig) clamp(delay int) int {
+	if delay < 1 {
+		delay = 1
+	}
+	if p.MaxDelaySeconds > 0 && delay > p.MaxDelaySeconds {
+		delay = p.MaxDelaySeconds
+	}
+	return delay
+}
+
+// resetPollDelay starts a fresh backoff sequence for a new stage
+func (p PollingConfig) resetPollDelay(payment *models.Payment) int {
+	payment.PollDelaySeconds = p.initialDelay(payment.Chain)
+	return payment.PollDelaySeconds
+}
+
+// advancePollDelay grows the stored delay for another pending poll
+func (p PollingConfig) advancePollDelay(payment *models.Payment) int {
+	payment.PollDelaySeconds = p.nextDelay(payment.PollDelaySeconds, payment.Chain)
+	return payment.PollDelaySeconds
+}
+
+// stageTimeout reports whether a pending stage has exceeded its poll budget,
+// returning a human-readable reason when it has
+func (p PollingConfig) stageTimeout(payment *models.Payment, pollCount int) (bool, string) {
+	if p.MaxPollAttempts > 0 && pollCount >= p.MaxPollAttempts {
+		return true, fmt.Sprintf("%s exceeded %d poll attempts", payment.Status, p.MaxPollAttempts)
+	}
+
+	if p.MaxStageDuration > 0 {
+		elapsed := time.Since(stageStartedAt(payment))
+		if elapsed >= p.MaxStageDuration {
+			return true, fmt.Sprintf("%s exceeded %s (elapsed %s)", payment.Status, p.MaxStageDuration, elapsed.Round(time.Second))
+		}
+	}
+
+	return false, ""
+}
+
+// stageStartedAt returns when the payment entered its current status
+func stageStartedAt(payment *models.Payment) time.Time {
+	for i := len(payment.StateHistory) - 1; i >= 0; i-- {
+		if payment.StateHistory[i].ToStatus == payment.Status {
+			return payment.StateHistory[i].Timestamp
+		}
+	}
+	return payment.CreatedAt
+}
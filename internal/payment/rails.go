@@ -0,0 +1,37 @@
+package payment
+
+// PayoutRail is the local bank transfer scheme an off-ramp pays out over
+type PayoutRail string
+
+const (
+	RailSEPA           PayoutRail = "SEPA"            // EUR payouts (SEPA Instant where the bank supports it)
+	RailFasterPayments PayoutRail = "FASTER_PAYMENTS" // GBP payouts, typically credited within seconds
+	RailWire           PayoutRail = "WIRE"            // USD payouts from Circle USDC redemption
+	RailPIX            PayoutRail = "PIX"             // BRL payouts, Brazil's instant payment scheme
+	RailSWIFT          PayoutRail = "SWIFT"           // Every other currency
+)
+
+// payoutRails maps each payout currency to the rail that delivers it
+var payoutRails = map[string]PayoutRail{
+	"EUR": RailSEPA,
+	"GBP": RailFasterPayments,
+	"USD": RailWire,
+	"BRL": RailPIX,
+}
+
+// PayoutRailFor returns the rail used to pay out the given currency
+func PayoutRailFor(currency string) PayoutRail {
+	if rail, ok := payoutRails[currency]; ok {
+		return rail
+	}
+	return RailSWIFT
+}
+
+// settlementPolls returns the range of poll attempts a mock transfer on this rail takes to settle
+// Faster Payments and PIX settle near-instantly, so GBP and BRL payouts usually clear on the first poll.
+func (r PayoutRail) settlementPolls() (minPolls, spread int) {
+	if r == RailFasterPayments || r == RailPIX {
+		return 1, 2
+	}
+	return 2, 3
+}
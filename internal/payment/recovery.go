@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+)
+
+// ForceTransition moves a stuck payment to the status an operator chose, recording who and why in its state history
+// It is for operational recovery, so any status may be chosen; an archived payment is refused, as its record
+// is already set to expire. The caller holds the payment's processing lock, persists it, and queues its
+// terminal webhook or, if asked, its next job.
+func ForceTransition(payment *models.Payment, status models.PaymentStatus, actor, reason string) error {
+	if payment.ArchivedAt != nil {
+		return fmt.Errorf("payment was archived and can no longer be changed")
+	}
+	if payment.Status == status {
+		return fmt.Errorf("payment is already %s", status)
+	}
+
+	message := fmt.Sprintf("Forced from %s by %s: %s", payment.Status, actor, reason)
+	transitionState(payment, status, message)
+
+	switch {
+	case status.IsTerminal():
+		now := time.Now().UTC()
+		payment.ProcessedAt = &now
+		if status != models.StatusCompleted {
+			payment.ErrorMessage = message
+			payment.ErrorCode = errors.PaymentForced
+		}
+	default:
+		// Back in flight: the outcome of its last run no longer stands, and the sweeper may re-enqueue it afresh
+		payment.ProcessedAt = nil
+		payment.ErrorMessage = ""
+		payment.ErrorCode = ""
+		payment.SweepCount = 0
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// SandboxScenario is the path a sandbox payment is made to take
+type SandboxScenario string
+
+const (
+	SandboxSuccess         SandboxScenario = "success"          // Every leg settles and the payment completes
+	SandboxOnrampDeclined  SandboxScenario = "onramp_declined"  // The on-ramp refuses the transfer; the payment fails
+	SandboxOnrampFailed    SandboxScenario = "onramp_failed"    // The on-ramp transfer fails to settle; the payment fails
+	SandboxOfframpDeclined SandboxScenario = "offramp_declined" // The off-ramp refuses the transfer; the payment is reversed
+	SandboxOfframpFailed   SandboxScenario = "offramp_failed"   // The off-ramp transfer fails to settle; the payment is reversed
+	SandboxOnrampStuck     SandboxScenario = "onramp_stuck"     // The on-ramp never settles; the payment times out
+	SandboxSlippage        SandboxScenario = "slippage"         // The execution rate slips past the limit before the off-ramp
+)
+
+// SandboxSettlementPolls is the poll on which every sandbox transfer settles or fails,
+// so a sandbox payment's legs each take exactly the initial poll delay
+const SandboxSettlementPolls = 1
+
+// sandboxMagicAmounts picks a sandbox payment's scenario by the last two digits of its amount in minor units,
+// e.g. 100091 ($1,000.91) has its on-ramp declined; any other amount completes
+var sandboxMagicAmounts = map[int64]SandboxScenario{
+	91: SandboxOnrampDeclined,
+	92: SandboxOnrampFailed,
+	93: SandboxOfframpDeclined,
+	94: SandboxOfframpFailed,
+	95: SandboxOnrampStuck,
+	96: SandboxSlippage,
+}
+
+// SandboxScenarioFor returns the scenario a sandbox payment of amount takes
+func SandboxScenarioFor(amount int64) SandboxScenario {
+	if scenario, ok := sandboxMagicAmounts[amount%100]; ok {
+		return scenario
+	}
+	return SandboxSuccess
+}
+
+// sandboxSlippage is how far past the slippage limit a SandboxSlippage payment's execution rate falls
+const sandboxSlippage = 0.01
+
+// sandboxExecutionRate is the execution rate a sandbox payment gets: its expected rate,
+// or one slipped past maxSlippage for SandboxSlippage payments
+func sandboxExecutionRate(p *models.Payment, maxSlippage float64) float64 {
+	if SandboxScenarioFor(p.Amount) == SandboxSlippage {
+		return p.ExpectedRate * (1 - maxSlippage - sandboxSlippage)
+	}
+	return p.ExpectedRate
+}
+
+// SandboxProviders supplies the providers sandbox payments' legs run against
+// Sandbox implements it; wrappers such as chaos fault injection decorate the providers it returns.
+type SandboxProviders interface {
+	OnRamp(p *models.Payment) OnRampProvider
+	OffRamp(p *models.Payment) OffRampProvider
+	Bridge() BridgeProvider
+	Wallet() WalletProvider
+}
+
+// Sandbox simulates the on- and off-ramp for sandbox payments, deterministically: no transfer fails at random,
+// each settles or fails on its SandboxSettlementPolls poll, and the payment's amount picks which one fails
+type Sandbox struct {
+	transfers map[string]*Transfer
+	outcomes  map[string]TransferStatus // Status each pending transfer ends in
+	behaviour *mockBehaviour            // Source of transaction hashes
+	seq       int
+	mu        sync.Mutex
+}
+
+// NewSandbox creates the sandbox providers
+// Outcomes are fixed by each payment's amount; WithSeed also fixes the transaction hashes.
+func NewSandbox(opts ...MockOption) *Sandbox {
+	return &Sandbox{
+		transfers: make(map[string]*Transfer),
+		outcomes:  make(map[string]TransferStatus),
+		behaviour: newMockBehaviour(0, 0, opts),
+	}
+}
+
+// OnRamp returns the sandbox on-ramp for a payment
+func (s *Sandbox) OnRamp(p *models.Payment) OnRampProvider {
+	return &sandboxOnRamp{sandbox: s, scenario: SandboxScenarioFor(p.Amount)}
+}
+
+// OffRamp returns the sandbox off-ramp for a payment
+func (s *Sandbox) OffRamp(p *models.Payment) OffRampProvider {
+	return &sandboxOffRamp{sandbox: s, scenario: SandboxScenarioFor(p.Amount)}
+}
+
+// Bridge returns the sandbox CCTP bridge, whose transfers always settle
+func (s *Sandbox) Bridge() BridgeProvider {
+	return &sandboxBridge{sandbox: s}
+}
+
+// Wallet returns the sandbox treasury wallet, whose transfers always settle
+func (s *Sandbox) Wallet() WalletProvider {
+	return &sandboxWallet{sandbox: s}
+}
+
+// start records a new pending transfer that ends in outcome
+func (s *Sandbox) start(prefix string, transfer *Transfer, outcome TransferStatus) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	transfer.TxID = fmt.Sprintf("sandbox_%s_%d_%d", prefix, time.Now().UnixNano(), s.seq)
+	transfer.Status = TransferStatusPending
+	transfer.CreatedAt = time.Now()
+	transfer.SettlesAfterPoll = SandboxSettlementPolls
+	s.transfers[transfer.TxID] = transfer
+	s.outcomes[transfer.TxID] = outcome
+
+	logger.Info("Sandbox transfer initiated", logger.Fields{
+		"tx_id":   transfer.TxID,
+		"amount":  transfer.Amount,
+		"outcome": outcome,
+	})
+	return transfer.TxID
+}
+
+// txHash returns the next sandbox transaction hash
+func (s *Sandbox) txHash() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.behaviour.txHash()
+}
+
+// poll advances a transfer by one poll, settling or failing it once it reaches SandboxSettlementPolls
+func (s *Sandbox) poll(txID string) (*Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, exists := s.transfers[txID]
+	if !exists {
+		return nil, fmt.Errorf("transfer not found: %s", txID)
+	}
+
+	transfer.PollCount++
+	if transfer.Status == TransferStatusPending && transfer.PollCount >= transfer.SettlesAfterPoll {
+		switch s.outcomes[txID] {
+		case TransferStatusSettled:
+			transfer.Status = TransferStatusSettled
+			now := time.Now()
+			transfer.SettledAt = &now
+		case TransferStatusFailed:
+			transfer.Status = TransferStatusFailed
+		}
+	}
+
+	copied := *transfer
+	return &copied, nil
+}
+
+// sandboxOnRamp is the sandbox on-ramp for one payment
+type sandboxOnRamp struct {
+	sandbox  *Sandbox
+	scenario SandboxScenario
+}
+
+// InitiateTransfer starts a sandbox on-ramp transfer, refused for SandboxOnrampDeclined payments
+func (c *sandboxOnRamp) InitiateTransfer(ctx context.Context, amount int64, currency string) (string, error) {
+	if c.scenario == SandboxOnrampDeclined {
+		return "", fmt.Errorf("sandbox on-ramp declined the transfer")
+	}
+	outcome := TransferStatusSettled
+	switch c.scenario {
+	case SandboxOnrampFailed:
+		outcome = TransferStatusFailed
+	case SandboxOnrampStuck:
+		outcome = TransferStatusPending
+	}
+	return c.sandbox.start("onramp", &Transfer{
+		Amount:           amount,
+		Currency:         currency,
+		StablecoinAmount: toStablecoin(amount, currency),
+		TxHash:           c.sandbox.txHash(),
+	}, outcome), nil
+}
+
+// GetTransferStatus polls a sandbox on-ramp transfer or reversal
+func (c *sandboxOnRamp) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	return c.sandbox.poll(txID)
+}
+
+// InitiateReversal starts a sandbox reversal, which always settles
+func (c *sandboxOnRamp) InitiateReversal(ctx context.Context, stablecoinAmount int64, currency, sourceAccount string) (string, error) {
+	return c.sandbox.start("reversal", &Transfer{
+		Amount:           stablecoinAmount,
+		Currency:         currency,
+		StablecoinAmount: stablecoinAmount,
+	}, TransferStatusSettled), nil
+}
+
+// sandboxOffRamp is the sandbox off-ramp for one payment
+type sandboxOffRamp struct {
+	sandbox  *Sandbox
+	scenario SandboxScenario
+}
+
+// InitiateTransfer starts a sandbox off-ramp transfer, refused for SandboxOfframpDeclined payments
+func (c *sandboxOffRamp) InitiateTransfer(ctx context.Context, stablecoinAmount int64, currency string) (string, error) {
+	if c.scenario == SandboxOfframpDeclined {
+		return "", fmt.Errorf("sandbox off-ramp declined the transfer")
+	}
+	outcome := TransferStatusSettled
+	if c.scenario == SandboxOfframpFailed {
+		outcome = TransferStatusFailed
+	}
+	return c.sandbox.start("offramp", &Transfer{
+		Amount:           stablecoinAmount,
+		Currency:         currency,
+		StablecoinAmount: stablecoinAmount,
+		Rail:             PayoutRailFor(currency),
+		TxHash:           c.sandbox.txHash(),
+	}, outcome), nil
+}
+
+// GetTransferStatus polls a sandbox off-ramp transfer
+func (c *sandboxOffRamp) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	return c.sandbox.poll(txID)
+}
+
+// sandboxBridge is the sandbox CCTP bridge
+type sandboxBridge struct {
+	sandbox *Sandbox
+}
+
+// InitiateTransfer starts a sandbox bridge transfer
+func (c *sandboxBridge) InitiateTransfer(ctx context.Context, stablecoinAmount int64, sourceChain, destinationChain string) (string, error) {
+	return c.sandbox.start("bridge", &Transfer{
+		Amount:           stablecoinAmount,
+		Currency:         "USDC",
+		StablecoinAmount: stablecoinAmount,
+		TxHash:           c.sandbox.txHash(),
+	}, TransferStatusSettled), nil
+}
+
+// GetTransferStatus polls a sandbox bridge transfer
+func (c *sandboxBridge) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	return c.sandbox.poll(txID)
+}
+
+// sandboxWallet is the sandbox treasury wallet
+type sandboxWallet struct {
+	sandbox *Sandbox
+}
+
+// InitiateTransfer starts a sandbox wallet transfer
+func (c *sandboxWallet) InitiateTransfer(ctx context.Context, stablecoinAmount int64, chain, address string) (string, error) {
+	return c.sandbox.start("wallet", &Transfer{
+		Amount:           stablecoinAmount,
+		Currency:         "USDC",
+		StablecoinAmount: stablecoinAmount,
+		TxHash:           c.sandbox.txHash(),
+	}, TransferStatusSettled), nil
+}
+
+// GetTransferStatus polls a sandbox wallet transfer
+func (c *sandboxWallet) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	return c.sandbox.poll(txID)
+}
@@ -0,0 +1,123 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// Screener checks a payment's counterparty against sanctions lists
+type Screener interface {
+	Screen(ctx context.Context, subject models.ScreeningSubject) ([]models.ScreeningMatch, error)
+}
+
+// EnableScreening screens each payment's counterparty again before funds leave for it,
+// holding payments that match a sanctions list for compliance review
+func (sm *StateMachine) EnableScreening(screener Screener) {
+	sm.screener = screener
+}
+
+// screenBeforePayout reports whether the payment was held because its counterparty matched a sanctions list
+// Lists change between acceptance and payout, so payments are screened again here unless compliance released them.
+func (sm *StateMachine) screenBeforePayout(ctx context.Context, payment *models.Payment) (bool, error) {
+	if sm.screener == nil || payment.Screening.Released() {
+		return false, nil
+	}
+
+	matches, err := sm.screener.Screen(ctx, models.ScreeningSubjectFor(payment))
+	if err != nil {
+		// Funds don't leave unscreened; the job is retried
+		return false, fmt.Errorf("failed to screen payment: %w", err)
+	}
+	if len(matches) == 0 {
+		return false, nil
+	}
+
+	HoldForCompliance(payment, models.ScreeningStageOfframp, matches)
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return false, fmt.Errorf("failed to update payment: %w", err)
+	}
+	AlertComplianceHold(payment)
+	return true, nil
+}
+
+// HoldForCompliance moves a payment whose counterparty matched a sanctions list to COMPLIANCE_HOLD
+// Nothing is re-enqueued until compliance decides; the caller persists the payment.
+func HoldForCompliance(payment *models.Payment, stage string, matches []models.ScreeningMatch) {
+	payment.Screening = &models.Screening{
+		Stage:      stage,
+		Matches:    matches,
+		ScreenedAt: time.Now().UTC(),
+		HeldFrom:   payment.Status,
+	}
+	reason := fmt.Sprintf("Counterparty matched %d sanctions list entries", len(matches))
+	payment.ErrorMessage = reason
+	transitionState(payment, models.StatusComplianceHold, reason)
+}
+
+// AlertComplianceHold logs the alert compliance officers are paged on for a held payment
+func AlertComplianceHold(payment *models.Payment) {
+	metrics.Count("SanctionsHits", metrics.Dimensions{"Stage": payment.Screening.Stage})
+
+	// Compliance alarms on this log line via a CloudWatch metric filter
+	fields := logger.Fields{
+		"alert":      "sanctions_hit",
+		"payment_id": payment.PaymentID,
+		"stage":      payment.Screening.Stage,
+		"held_from":  payment.Screening.HeldFrom,
+	}
+	for i, match := range payment.Screening.Matches {
+		fields[fmt.Sprintf("match_%d", i)] = match.List + ":" + match.Field + ":" + match.Entry
+	}
+	logger.Error("ALERT: payment held on sanctions hit", fields)
+}
+
+// ResolveComplianceHold applies a compliance officer's decision to a payment held in COMPLIANCE_HOLD
+// Release returns the payment to the status it was held from and exempts it from further screening.
+// Rejection fails a payment held before any funds moved, and reverses one held before its payout.
+// The caller persists the payment and enqueues its next step or terminal webhook.
+func ResolveComplianceHold(payment *models.Payment, decision, actor, reason string) error {
+	if payment.Status != models.StatusComplianceHold || payment.Screening == nil {
+		return fmt.Errorf("payment is %s, not %s", payment.Status, models.StatusComplianceHold)
+	}
+
+	now := time.Now().UTC()
+	var message string
+	switch decision {
+	case models.ComplianceDecisionRelease:
+		message = fmt.Sprintf("Sanctions hit released by %s", actor)
+	case models.ComplianceDecisionReject:
+		message = fmt.Sprintf("Sanctions hit rejected by %s", actor)
+	default:
+		return fmt.Errorf("unknown compliance decision: %s", decision)
+	}
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+
+	payment.Screening.Decision = decision
+	payment.Screening.ReviewedBy = actor
+	payment.Screening.ReviewedAt = &now
+	payment.Screening.Reason = reason
+
+	if decision == models.ComplianceDecisionRelease {
+		payment.ErrorMessage = ""
+		transitionState(payment, payment.Screening.HeldFrom, message)
+		return nil
+	}
+
+	payment.ErrorMessage = message
+	payment.ErrorCode = errors.PaymentComplianceRejected
+	if payment.Screening.HeldFrom == models.StatusPending {
+		payment.ProcessedAt = &now
+		transitionState(payment, models.StatusFailed, message)
+		return nil
+	}
+	transitionState(payment, models.StatusReversing, message)
+	return nil
+}
@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/eventbus"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// recordStageTime stamps the settlement stage a transition starts or finishes on the payment's timeline
+// A completed payment also gets its total settlement time, checked against the SLA it was promised.
+func recordStageTime(payment *models.Payment, from, to models.PaymentStatus, at time.Time) {
+	switch to {
+	case models.StatusOnrampPending, models.StatusOnrampComplete, models.StatusOfframpPending,
+		models.StatusWalletPending, models.StatusCompleted:
+	default:
+		return
+	}
+	if payment.Timeline == nil {
+		payment.Timeline = &models.SettlementTimeline{}
+	}
+	timeline := payment.Timeline
+
+	switch {
+	case to == models.StatusOnrampPending && timeline.OnrampInitiatedAt == nil:
+		timeline.OnrampInitiatedAt = &at
+	case to == models.StatusOnrampComplete && from == models.StatusOnrampPending:
+		timeline.OnrampSettledAt = &at
+	case to == models.StatusOfframpPending || to == models.StatusWalletPending:
+		timeline.OfframpInitiatedAt = &at
+	case to == models.StatusCompleted:
+		timeline.OfframpSettledAt = &at
+		if elapsed, ok := timeline.SettlementTime(); ok {
+			payment.SettlementSeconds = int(elapsed / time.Second)
+			payment.SLABreached = payment.SettlementSLASeconds > 0 && payment.SettlementSeconds > payment.SettlementSLASeconds
+		}
+	}
+}
+
+// reportSettlement records a payment's settlement time once it completes, emitting sla.breached if it overran
+// Publishing is best-effort: the payment has settled either way. Sandbox settlement times aren't reported.
+func (sm *StateMachine) reportSettlement(ctx context.Context, payment *models.Payment, fromStatus models.PaymentStatus) {
+	if payment.Status != models.StatusCompleted || fromStatus == models.StatusCompleted || payment.Sandbox {
+		return
+	}
+	elapsed, ok := payment.Timeline.SettlementTime()
+	if !ok {
+		return
+	}
+
+	metrics.Duration("SettlementTime", elapsed, metrics.Dimensions{"Chain": payment.Chain})
+	if !payment.SLABreached {
+		return
+	}
+
+	metrics.Count("SLABreaches", metrics.Dimensions{"Chain": payment.Chain})
+	logger.Warn("Payment settled outside its SLA", logger.Fields{
+		"payment_id":         payment.PaymentID,
+		"chain":              payment.Chain,
+		"sla_seconds":        payment.SettlementSLASeconds,
+		"settlement_seconds": payment.SettlementSeconds,
+	})
+	if sm.events == nil {
+		return
+	}
+
+	event := &models.SLABreachedEvent{
+		PaymentID:         payment.PaymentID,
+		Chain:             payment.Chain,
+		OffRampChain:      payment.OffRampChain,
+		QuoteID:           payment.QuoteID,
+		SLASeconds:        payment.SettlementSLASeconds,
+		SettlementSeconds: payment.SettlementSeconds,
+		Timeline:          payment.Timeline,
+		Timestamp:         time.Now().UTC(),
+	}
+	if err := sm.events.Publish(ctx, eventbus.DetailTypeSLABreached, event); err != nil {
+		logger.Warn("Failed to publish SLA breach event", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"error":      err.Error(),
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// SlippageAction is what happens to a payment whose execution rate slipped past the limit
+type SlippageAction string
+
+const (
+	SlippageActionFail   SlippageAction = "fail"   // Reverse the payment, returning funds to the source account
+	SlippageActionReview SlippageAction = "review" // Hold the payment in REQUIRES_REVIEW for an operator
+)
+
+// reviewPollSeconds is how often a Step Functions execution re-checks a payment held for review
+const reviewPollSeconds = 300
+
+// RateSource returns the rate a payment would execute at right now
+type RateSource interface {
+	ExecutableRate(ctx context.Context, from, to string, amount int64) (float64, error)
+}
+
+// SlippageConfig controls the execution-time rate check
+type SlippageConfig struct {
+	MaxSlippage float64 // Largest tolerated shortfall versus the expected rate, as a fraction
+	Action      SlippageAction
+}
+
+// DefaultSlippageConfig tolerates 1% and holds anything worse for review
+func DefaultSlippageConfig() SlippageConfig {
+	return SlippageConfig{
+		MaxSlippage: 0.01,
+		Action:      SlippageActionReview,
+	}
+}
+
+// Slippage returns how far the executable rate falls short of the expected rate, as a fraction
+// Negative values mean the rate improved.
+func Slippage(expected, executable float64) float64 {
+	if expected <= 0 {
+		return 0
+	}
+	return (expected - executable) / expected
+}
+
+// checkSlippage fetches the executable rate and reports whether the payment may proceed to the off-ramp
+// Payments without an expected rate, or already approved by an operator, are not checked.
+func (sm *StateMachine) checkSlippage(ctx context.Context, payment *models.Payment) (bool, string, error) {
+	if sm.rates == nil || payment.ExpectedRate <= 0 || payment.SlippageApproved {
+		return true, "", nil
+	}
+
+	// Sandbox payments get a fixed rate, so only their magic amount slips
+	var rate float64
+	if payment.Sandbox && sm.sandbox != nil {
+		rate = sandboxExecutionRate(payment, sm.slippage.MaxSlippage)
+	} else {
+		var err error
+		rate, err = sm.rates.ExecutableRate(ctx, payment.FundingCurrency(), payment.Currency, payment.Amount)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to fetch execution rate: %w", err)
+		}
+	}
+	payment.ExecutionRate = rate
+
+	slippage := Slippage(payment.ExpectedRate, rate)
+	if slippage <= sm.slippage.MaxSlippage {
+		return true, "", nil
+	}
+
+	metrics.Count("SlippageExceeded", metrics.Dimensions{"Action": string(sm.slippage.Action)})
+	reason := fmt.Sprintf("Execution rate %.6f is %.2f%% below the expected %.6f (limit %.2f%%)",
+		rate, slippage*100, payment.ExpectedRate, sm.slippage.MaxSlippage*100)
+
+	logger.Warn("Execution rate slipped past limit", logger.Fields{
+		"payment_id":    payment.PaymentID,
+		"expected_rate": payment.ExpectedRate,
+		"rate":          rate,
+		"slippage":      slippage,
+		"action":        sm.slippage.Action,
+	})
+	return false, reason, nil
+}
+
+// holdForReview parks a payment in REQUIRES_REVIEW; nothing is re-enqueued until an operator decides
+func (sm *StateMachine) holdForReview(ctx context.Context, payment *models.Payment, reason string) error {
+	sm.transitionState(payment, models.StatusRequiresReview, reason)
+	payment.ErrorMessage = reason
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Operators alarm on this log line via a CloudWatch metric filter
+	logger.Error("ALERT: payment held for slippage review", logger.Fields{
+		"alert":          "slippage_review",
+		"payment_id":     payment.PaymentID,
+		"on_ramp_tx_id":  payment.OnRampTxID,
+		"expected_rate":  payment.ExpectedRate,
+		"execution_rate": payment.ExecutionRate,
+		"reason":         reason,
+	})
+	return nil
+}
+
+// ResolveReview applies an operator's decision to a payment held in REQUIRES_REVIEW
+// Approval sends the payment back to ONRAMP_COMPLETE with the slippage check waived; rejection
+// starts a reversal. The caller persists the payment and enqueues its next step.
+func ResolveReview(payment *models.Payment, decision, actor, reason string) error {
+	if payment.Status != models.StatusRequiresReview {
+		return fmt.Errorf("payment is %s, not %s", payment.Status, models.StatusRequiresReview)
+	}
+
+	var outcome string
+	switch decision {
+	case models.ReviewDecisionApprove:
+		outcome = "approved"
+	case models.ReviewDecisionReject:
+		outcome = "rejected"
+	default:
+		return fmt.Errorf("unknown review decision: %s", decision)
+	}
+
+	message := fmt.Sprintf("Slippage %s by %s", outcome, actor)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+
+	if decision == models.ReviewDecisionApprove {
+		payment.SlippageApproved = true
+		payment.ErrorMessage = ""
+		transitionState(payment, models.StatusOnrampComplete, message)
+		return nil
+	}
+
+	payment.ErrorMessage = message
+	payment.ErrorCode = errors.PaymentReviewRejected
+	transitionState(payment, models.StatusReversing, message)
+	return nil
+}
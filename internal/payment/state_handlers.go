@@ -2,25 +2,81 @@ package payment
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"crypto-conversion/internal/audit"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/eventbus"
+	"crypto-conversion/internal/ledger"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
 	"crypto-conversion/internal/models"
 )
 
 // StateMachine represents the payment state machine orchestrator
 type StateMachine struct {
-	onRampClient  *StatefulOnRampClient
-	offRampClient *StatefulOffRampClient
+	onRampClient  OnRampProvider
+	offRampClient OffRampProvider
 	dbClient      DatabaseClient
 	queueClient   QueueClient
+	polling       PollingConfig
+	events        EventPublisher
+	audit         AuditRecorder
+	rates         RateSource
+	slippage      SlippageConfig
+	volumes       VolumeRecorder      // nil unless volume discounts are enabled
+	invoices      InvoiceRecorder     // nil unless fee invoices are enabled
+	bridgeClient  BridgeProvider      // nil unless CCTP bridging is enabled
+	chainWatch    ConfirmationWatcher // nil unless on-chain finality checks are enabled
+	walletClient  WalletProvider      // nil unless wallet payouts are enabled
+	treasury      TreasuryLedger      // nil unless treasury tracking is enabled
+	ledger        LedgerPoster        // nil unless the double-entry ledger is enabled
+	screener      Screener            // nil unless sanctions screening is enabled
+	notifier      StatusNotifier      // nil unless WebSocket status notifications are enabled
+	sandbox       SandboxProviders    // nil unless sandbox payments are enabled
+
+	treasuryThresholds map[string]int64 // Low-balance alert threshold per treasury account
+	gasCosts           map[string]int64 // Ledger gas expense per transaction sent, by chain
 }
 
+// processingLockTTL bounds how long a crashed worker can hold a payment
+const processingLockTTL = 5 * time.Minute
+
 // DatabaseClient interface for payment database operations
 type DatabaseClient interface {
 	UpdatePayment(ctx context.Context, payment *models.Payment) error
+	UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error
 	GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error)
+	AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error)
+	ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error
+}
+
+// OnRampProvider interface for the asynchronous on-ramp transfers and reversals the state machine polls
+type OnRampProvider interface {
+	InitiateTransfer(ctx context.Context, amount int64, currency string) (string, error)
+	GetTransferStatus(ctx context.Context, txID string) (*Transfer, error)
+	InitiateReversal(ctx context.Context, stablecoinAmount int64, currency, sourceAccount string) (string, error)
+}
+
+// OffRampProvider interface for the asynchronous off-ramp transfers the state machine polls
+type OffRampProvider interface {
+	InitiateTransfer(ctx context.Context, stablecoinAmount int64, currency string) (string, error)
+	GetTransferStatus(ctx context.Context, txID string) (*Transfer, error)
+}
+
+// BridgeProvider interface for the asynchronous CCTP transfers the state machine polls
+type BridgeProvider interface {
+	InitiateTransfer(ctx context.Context, stablecoinAmount int64, sourceChain, destinationChain string) (string, error)
+	GetTransferStatus(ctx context.Context, txID string) (*Transfer, error)
+}
+
+// WalletProvider interface for the asynchronous treasury wallet sends the state machine polls
+type WalletProvider interface {
+	InitiateTransfer(ctx context.Context, stablecoinAmount int64, chain, address string) (string, error)
+	GetTransferStatus(ctx context.Context, txID string) (*Transfer, error)
 }
 
 // QueueClient interface for re-enqueuing jobs
@@ -28,16 +84,116 @@ type QueueClient interface {
 	EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error
 }
 
+// EventPublisher interface for emitting lifecycle events to downstream subscribers
+type EventPublisher interface {
+	Publish(ctx context.Context, detailType string, detail interface{}) error
+}
+
+// AuditRecorder interface for appending state transitions to the audit log
+type AuditRecorder interface {
+	Record(ctx context.Context, event audit.Event) (*models.AuditEntry, error)
+}
+
+// VolumeRecorder interface for counting completed payments toward customer volume discounts
+type VolumeRecorder interface {
+	RecordVolume(ctx context.Context, entry *models.VolumeEntry) error
+}
+
+// InvoiceRecorder interface for issuing the fee invoice of a completed payment
+type InvoiceRecorder interface {
+	CreateFeeInvoice(ctx context.Context, invoice *models.FeeInvoice) error
+}
+
+// ConfirmationWatcher interface for checking on-chain finality of a payment's transactions
+// Check returns nil without error for chains it doesn't watch.
+type ConfirmationWatcher interface {
+	Check(ctx context.Context, chain, txHash string) (*models.ChainConfirmation, error)
+}
+
 // NewStateMachine creates a new state machine orchestrator
-func NewStateMachine(onRamp *StatefulOnRampClient, offRamp *StatefulOffRampClient, db DatabaseClient, queue QueueClient) *StateMachine {
+// events and auditLog may be nil to disable lifecycle event publishing and audit logging;
+// rates may be nil to skip the execution-time slippage check
+func NewStateMachine(onRamp OnRampProvider, offRamp OffRampProvider, db DatabaseClient, queue QueueClient, polling PollingConfig, events EventPublisher, auditLog AuditRecorder, rates RateSource, slippage SlippageConfig) *StateMachine {
 	return &StateMachine{
 		onRampClient:  onRamp,
 		offRampClient: offRamp,
 		dbClient:      db,
 		queueClient:   queue,
+		polling:       polling,
+		events:        events,
+		audit:         auditLog,
+		rates:         rates,
+		slippage:      slippage,
 	}
 }
 
+// EnableVolumeTracking counts completed payments toward their customer's rolling volume
+func (sm *StateMachine) EnableVolumeTracking(volumes VolumeRecorder) {
+	sm.volumes = volumes
+}
+
+// EnableFeeInvoices issues an immutable fee invoice for every payment that completes
+func (sm *StateMachine) EnableFeeInvoices(invoices InvoiceRecorder) {
+	sm.invoices = invoices
+}
+
+// EnableBridging bridges USDC over CCTP when a payment's off-ramp redeems on a different chain than it was minted on
+// Without it such payments are reversed once the on-ramp settles.
+func (sm *StateMachine) EnableBridging(bridge BridgeProvider) {
+	sm.bridgeClient = bridge
+}
+
+// EnableWalletPayouts sends USDC straight to the destination address of wallet payouts instead of off-ramping
+// Without it wallet payouts are reversed once the on-ramp settles.
+func (sm *StateMachine) EnableWalletPayouts(wallet WalletProvider) {
+	sm.walletClient = wallet
+}
+
+// EnableChainWatch holds each stage until its on-chain transaction meets the chain's finality rule,
+// even once the provider reports it settled
+func (sm *StateMachine) EnableChainWatch(watcher ConfirmationWatcher) {
+	sm.chainWatch = watcher
+}
+
+// EnableSandbox runs sandbox payments' on-ramp, off-ramp, bridge and wallet legs against the deterministic
+// sandbox providers. Without it sandbox payments use the regular providers.
+func (sm *StateMachine) EnableSandbox(sandbox SandboxProviders) {
+	sm.sandbox = sandbox
+}
+
+// onRamp returns the on-ramp a payment's transfers go through
+func (sm *StateMachine) onRamp(payment *models.Payment) OnRampProvider {
+	if payment.Sandbox && sm.sandbox != nil {
+		return sm.sandbox.OnRamp(payment)
+	}
+	return sm.onRampClient
+}
+
+// offRamp returns the off-ramp a payment's transfers go through
+func (sm *StateMachine) offRamp(payment *models.Payment) OffRampProvider {
+	if payment.Sandbox && sm.sandbox != nil {
+		return sm.sandbox.OffRamp(payment)
+	}
+	return sm.offRampClient
+}
+
+// bridge returns the CCTP bridge a payment's transfers go through, nil when bridging is not enabled
+// Sandbox payments bridge only where regular payments can, so they take the same paths.
+func (sm *StateMachine) bridge(payment *models.Payment) BridgeProvider {
+	if sm.bridgeClient != nil && payment.Sandbox && sm.sandbox != nil {
+		return sm.sandbox.Bridge()
+	}
+	return sm.bridgeClient
+}
+
+// wallet returns the treasury wallet a payment's payout is sent from, nil when wallet payouts are not enabled
+func (sm *StateMachine) wallet(payment *models.Payment) WalletProvider {
+	if sm.walletClient != nil && payment.Sandbox && sm.sandbox != nil {
+		return sm.sandbox.Wallet()
+	}
+	return sm.walletClient
+}
+
 // ProcessPayment processes a payment based on its current state
 func (sm *StateMachine) ProcessPayment(ctx context.Context, job *models.PaymentJob) error {
 	// Fetch current payment state
@@ -51,21 +207,94 @@ func (sm *StateMachine) ProcessPayment(ctx context.Context, job *models.PaymentJ
 		"status":     payment.Status,
 	})
 
-	// Route to appropriate handler based on current state
+	if payment.Status.IsTerminal() {
+		logger.Info("Payment already in terminal state", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"status":     payment.Status,
+		})
+		return nil
+	}
+
+	// A redelivered job whose step already ran no longer matches the stored status
+	if job.ExpectedStatus != "" && job.ExpectedStatus != payment.Status {
+		logger.Warn("Skipping stale payment job", logger.Fields{
+			"payment_id":      payment.PaymentID,
+			"expected_status": job.ExpectedStatus,
+			"status":          payment.Status,
+		})
+		return nil
+	}
+
+	// Only one worker may run a step; concurrent duplicate deliveries become no-ops
+	owner := uuid.New().String()
+	acquired, err := sm.dbClient.AcquireProcessingLock(ctx, payment.PaymentID, payment.Status, owner, processingLockTTL)
+	if err != nil {
+		return fmt.Errorf("failed to acquire processing lock: %w", err)
+	}
+	if !acquired {
+		logger.Warn("Payment is locked or has moved on, skipping duplicate delivery", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"status":     payment.Status,
+		})
+		return nil
+	}
+	payment.LockOwner = owner
+	payment.LockExpiresAt = time.Now().Add(processingLockTTL).Unix()
+
+	defer func() {
+		if err := sm.dbClient.ReleaseProcessingLock(ctx, payment.PaymentID, owner); err != nil {
+			logger.Warn("Failed to release processing lock", logger.Fields{
+				"payment_id": payment.PaymentID,
+				"error":      err.Error(),
+			})
+		}
+	}()
+
+	fromStatus := payment.Status
+	historyLen := len(payment.StateHistory)
+
+	err = sm.dispatch(ctx, job, payment)
+
+	// Failure paths persist their FAILED transition before returning an error, so record regardless
+	recordTransitions(payment, historyLen)
+	sm.auditTransitions(ctx, payment, historyLen)
+	sm.notifyTransitions(ctx, payment, historyLen)
+	if err != nil {
+		return err
+	}
+
+	sm.publishTransitions(ctx, payment, fromStatus, historyLen)
+	sm.reportSettlement(ctx, payment, fromStatus)
+	return nil
+}
+
+// dispatch routes the payment to the handler for its current state
+func (sm *StateMachine) dispatch(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
 	switch payment.Status {
 	case models.StatusPending:
 		return sm.handlePending(ctx, job, payment)
 	case models.StatusOnrampPending:
 		return sm.handleOnrampPending(ctx, job, payment)
-	case models.StatusOnrampComplete:
+	case models.StatusOnrampComplete, models.StatusBridgeComplete:
 		return sm.handleOnrampComplete(ctx, job, payment)
+	case models.StatusBridgePending:
+		return sm.handleBridgePending(ctx, job, payment)
 	case models.StatusOfframpPending:
 		return sm.handleOfframpPending(ctx, job, payment)
-	case models.StatusCompleted, models.StatusFailed:
-		logger.Info("Payment already in terminal state", logger.Fields{
+	case models.StatusWalletPending:
+		return sm.handleWalletPending(ctx, job, payment)
+	case models.StatusReversing:
+		return sm.handleReversing(ctx, job, payment)
+	case models.StatusRequiresReview, models.StatusComplianceHold, models.StatusOnHold:
+		// Waiting on an operator; their decision re-enqueues the payment. A payment held when it was
+		// created hasn't had its payment.on_hold webhook queued yet.
+		logger.Info("Payment held for review, nothing to do", logger.Fields{
 			"payment_id": payment.PaymentID,
 			"status":     payment.Status,
 		})
+		if payment.WebhookStatus != payment.Status {
+			return sm.savePayment(ctx, payment)
+		}
 		return nil
 	default:
 		return fmt.Errorf("unexpected payment status: %s", payment.Status)
@@ -79,32 +308,37 @@ func (sm *StateMachine) handlePending(ctx context.Context, job *models.PaymentJo
 	})
 
 	// Initiate onramp transfer
-	txID, err := sm.onRampClient.InitiateTransfer(ctx, payment.Amount, payment.Currency)
+	txID, err := sm.onRamp(payment).InitiateTransfer(ctx, payment.Amount, payment.FundingCurrency())
 	if err != nil {
 		// Mark as failed
 		sm.transitionState(payment, models.StatusFailed, fmt.Sprintf("Onramp initiation failed: %s", err.Error()))
 		payment.ErrorMessage = err.Error()
-		sm.dbClient.UpdatePayment(ctx, payment)
+		payment.ErrorCode = errors.PaymentOnrampFailed
+		// If the FAILED status isn't saved, its webhook isn't queued either; the redelivered job retries the step
+		if saveErr := sm.savePayment(ctx, payment); saveErr != nil {
+			return fmt.Errorf("failed to update payment after onramp initiation failed: %w", saveErr)
+		}
 		return fmt.Errorf("onramp initiation failed: %w", err)
 	}
 
 	// Update payment state
 	payment.OnRampTxID = txID
 	sm.transitionState(payment, models.StatusOnrampPending, "Onramp transfer initiated")
+	delay := sm.polling.resetPollDelay(payment)
 
-	if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+	if err := sm.savePayment(ctx, payment); err != nil {
 		return fmt.Errorf("failed to update payment: %w", err)
 	}
 
-	// Re-enqueue with 30-second delay to poll onramp status
-	if err := sm.queueClient.EnqueuePaymentWithDelay(ctx, job, 30); err != nil {
+	// Re-enqueue with initial backoff delay to poll onramp status
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
 		return fmt.Errorf("failed to re-enqueue payment: %w", err)
 	}
 
 	logger.Info("Onramp initiated, re-enqueued for polling", logger.Fields{
 		"payment_id":    payment.PaymentID,
 		"on_ramp_tx_id": txID,
-		"delay_seconds": 30,
+		"delay_seconds": delay,
 	})
 
 	return nil
@@ -119,7 +353,7 @@ func (sm *StateMachine) handleOnrampPending(ctx context.Context, job *models.Pay
 	})
 
 	// Poll onramp status
-	transfer, err := sm.onRampClient.GetTransferStatus(ctx, payment.OnRampTxID)
+	transfer, err := sm.onRamp(payment).GetTransferStatus(ctx, payment.OnRampTxID)
 	if err != nil {
 		return fmt.Errorf("failed to poll onramp status: %w", err)
 	}
@@ -128,15 +362,22 @@ func (sm *StateMachine) handleOnrampPending(ctx context.Context, job *models.Pay
 
 	switch transfer.Status {
 	case TransferStatusSettled:
+		// The minted USDC must be final on-chain before it's bridged or off-ramped
+		if final, err := sm.awaitFinality(ctx, job, payment, &payment.OnRampConfirmation, payment.Chain, transfer.TxHash, payment.OnRampPollCount); !final {
+			return err
+		}
+
 		// Onramp complete, move to next stage
+		sm.postTreasury(ctx, payment, models.TreasuryLegOnramp, models.USDCEntry(payment.Chain, mintedUSDC(payment)))
 		sm.transitionState(payment, models.StatusOnrampComplete, "Onramp settled, USDC received")
+		sm.postLedger(ctx, payment, models.LedgerLegOnramp, ledger.Onramp(payment.Chain, ledgerAmounts(payment)))
 
-		if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+		if err := sm.savePayment(ctx, payment); err != nil {
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
 		// Immediately process offramp (no delay)
-		if err := sm.queueClient.EnqueuePaymentWithDelay(ctx, job, 0); err != nil {
+		if err := sm.enqueue(ctx, job, payment, 0); err != nil {
 			return fmt.Errorf("failed to re-enqueue payment: %w", err)
 		}
 
@@ -149,7 +390,10 @@ func (sm *StateMachine) handleOnrampPending(ctx context.Context, job *models.Pay
 		// Mark payment as failed
 		sm.transitionState(payment, models.StatusFailed, "Onramp transfer failed")
 		payment.ErrorMessage = "Onramp settlement failed"
-		sm.dbClient.UpdatePayment(ctx, payment)
+		payment.ErrorCode = errors.PaymentOnrampFailed
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
 
 		logger.Error("Onramp transfer failed", logger.Fields{
 			"payment_id": payment.PaymentID,
@@ -157,19 +401,25 @@ func (sm *StateMachine) handleOnrampPending(ctx context.Context, job *models.Pay
 		})
 
 	case TransferStatusPending:
-		// Still pending, check again in 30 seconds
-		if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+		// Give up once the stage has exhausted its poll budget
+		if timedOut, reason := sm.polling.stageTimeout(payment, payment.OnRampPollCount); timedOut {
+			return sm.handleStageTimeout(ctx, payment, reason)
+		}
+
+		// Still pending, back off before checking again
+		delay := sm.polling.advancePollDelay(payment)
+		if err := sm.savePayment(ctx, payment); err != nil {
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
-		if err := sm.queueClient.EnqueuePaymentWithDelay(ctx, job, 30); err != nil {
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
 			return fmt.Errorf("failed to re-enqueue payment: %w", err)
 		}
 
 		logger.Info("Onramp still pending, will poll again", logger.Fields{
-			"payment_id":   payment.PaymentID,
-			"poll_count":   payment.OnRampPollCount,
-			"delay_seconds": 30,
+			"payment_id":    payment.PaymentID,
+			"poll_count":    payment.OnRampPollCount,
+			"delay_seconds": delay,
 		})
 	}
 
@@ -177,45 +427,78 @@ func (sm *StateMachine) handleOnrampPending(ctx context.Context, job *models.Pay
 }
 
 // handleOnrampComplete initiates the offramp transfer
+// USDC minted on a chain the off-ramp doesn't redeem on is bridged there first; the payment
+// comes back here as BRIDGE_COMPLETE once it has been minted on the off-ramp's chain.
 func (sm *StateMachine) handleOnrampComplete(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
-	logger.Info("Handling ONRAMP_COMPLETE state - initiating offramp", logger.Fields{
+	logger.Info("Handling "+string(payment.Status)+" state - initiating offramp", logger.Fields{
 		"payment_id": payment.PaymentID,
 	})
 
+	if payment.NeedsBridge() && payment.BridgeTxID == "" {
+		return sm.startBridge(ctx, job, payment)
+	}
+
+	// Funds don't leave for a sanctioned counterparty
+	if held, err := sm.screenBeforePayout(ctx, payment); held || err != nil {
+		return err
+	}
+
+	// Don't silently under-pay if the rate moved since the payout was promised
+	proceed, reason, err := sm.checkSlippage(ctx, payment)
+	if err != nil {
+		return err
+	}
+	if !proceed {
+		if sm.slippage.Action == SlippageActionFail {
+			// USDC is already minted - return it to the source account
+			return sm.startReversal(ctx, job, payment, errors.PaymentSlippageExceeded, reason)
+		}
+		return sm.holdForReview(ctx, payment, reason)
+	}
+
+	// Wallet payouts are already in USDC, so skip the off-ramp
+	if payment.IsWalletPayout() {
+		return sm.startWalletTransfer(ctx, job, payment)
+	}
+
 	// Determine amount to send to offramp
 	// Use guaranteed payout if quote was used, otherwise use payment amount
-	amountToConvert := payment.GuaranteedPayoutAmount
-	if amountToConvert == 0 {
-		amountToConvert = payment.Amount
+	amountToConvert := payment.PayoutAmount()
+
+	// The payout is paid from our float with the off-ramp, so reserve it before starting one
+	float := models.FloatEntry(models.TreasuryOfframpProvider, payment.Currency, -amountToConvert)
+	if ok, err := sm.reserveFloat(ctx, job, payment, models.TreasuryLegOfframpReserved, float); !ok {
+		return err
 	}
 
 	// Initiate offramp transfer
-	txID, err := sm.offRampClient.InitiateTransfer(ctx, amountToConvert, payment.Currency)
+	txID, err := sm.offRamp(payment).InitiateTransfer(ctx, amountToConvert, payment.Currency)
 	if err != nil {
-		// Mark as failed
-		sm.transitionState(payment, models.StatusFailed, fmt.Sprintf("Offramp initiation failed: %s", err.Error()))
-		payment.ErrorMessage = err.Error()
-		sm.dbClient.UpdatePayment(ctx, payment)
-		return fmt.Errorf("offramp initiation failed: %w", err)
+		// USDC is already minted - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, fmt.Sprintf("Offramp initiation failed: %s", err.Error()))
 	}
+	sm.postTreasury(ctx, payment, models.TreasuryLegOfframp, models.USDCEntry(payment.RedeemChain(), -mintedUSDC(payment)))
 
 	// Update payment state
 	payment.OffRampTxID = txID
 	sm.transitionState(payment, models.StatusOfframpPending, "Offramp transfer initiated")
+	sm.postLedger(ctx, payment, models.LedgerLegOfframp,
+		ledger.Payout(payment.RedeemChain(), ledgerAmounts(payment), sm.gasCosts[payment.RedeemChain()]))
+	delay := sm.polling.resetPollDelay(payment)
 
-	if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+	if err := sm.savePayment(ctx, payment); err != nil {
 		return fmt.Errorf("failed to update payment: %w", err)
 	}
 
-	// Re-enqueue with 30-second delay to poll offramp status
-	if err := sm.queueClient.EnqueuePaymentWithDelay(ctx, job, 30); err != nil {
+	// Re-enqueue with initial backoff delay to poll offramp status
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
 		return fmt.Errorf("failed to re-enqueue payment: %w", err)
 	}
 
 	logger.Info("Offramp initiated, re-enqueued for polling", logger.Fields{
 		"payment_id":     payment.PaymentID,
 		"off_ramp_tx_id": txID,
-		"delay_seconds":  30,
+		"delay_seconds":  delay,
 	})
 
 	return nil
@@ -230,7 +513,7 @@ func (sm *StateMachine) handleOfframpPending(ctx context.Context, job *models.Pa
 	})
 
 	// Poll offramp status
-	transfer, err := sm.offRampClient.GetTransferStatus(ctx, payment.OffRampTxID)
+	transfer, err := sm.offRamp(payment).GetTransferStatus(ctx, payment.OffRampTxID)
 	if err != nil {
 		return fmt.Errorf("failed to poll offramp status: %w", err)
 	}
@@ -239,55 +522,756 @@ func (sm *StateMachine) handleOfframpPending(ctx context.Context, job *models.Pa
 
 	switch transfer.Status {
 	case TransferStatusSettled:
+		// Our USDC deposit must be final on-chain before the payment completes
+		if final, err := sm.awaitFinality(ctx, job, payment, &payment.OffRampConfirmation, payment.RedeemChain(), transfer.TxHash, payment.OffRampPollCount); !final {
+			return err
+		}
+
+		// The deposited USDC is redeemed back into our float
+		sm.postTreasury(ctx, payment, models.TreasuryLegOfframpSettled,
+			models.FloatEntry(models.TreasuryOfframpProvider, payment.Currency, payment.PayoutAmount()))
+
 		// Payment complete!
-		sm.transitionState(payment, models.StatusCompleted, "Offramp settled, funds delivered")
+		return sm.completePayment(ctx, payment, "Offramp settled, funds delivered")
+
+	case TransferStatusFailed:
+		logger.Error("Offramp transfer failed", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"tx_id":      payment.OffRampTxID,
+		})
+
+		// USDC is already minted - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, "Offramp settlement failed")
+
+	case TransferStatusPending:
+		// Give up once the stage has exhausted its poll budget
+		if timedOut, reason := sm.polling.stageTimeout(payment, payment.OffRampPollCount); timedOut {
+			return sm.handleStageTimeout(ctx, payment, reason)
+		}
+
+		// Still pending, back off before checking again
+		delay := sm.polling.advancePollDelay(payment)
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+			return fmt.Errorf("failed to re-enqueue payment: %w", err)
+		}
+
+		logger.Info("Offramp still pending, will poll again", logger.Fields{
+			"payment_id":    payment.PaymentID,
+			"poll_count":    payment.OffRampPollCount,
+			"delay_seconds": delay,
+		})
+	}
+
+	return nil
+}
+
+// completePayment moves a payment whose funds were delivered to COMPLETED
+func (sm *StateMachine) completePayment(ctx context.Context, payment *models.Payment, message string) error {
+	sm.transitionState(payment, models.StatusCompleted, message)
+	sm.postLedger(ctx, payment, models.LedgerLegCompleted, ledger.Completed(ledgerAmounts(payment)))
+	now := time.Now()
+	payment.ProcessedAt = &now
+
+	// Issued before the payment is saved as completed, so a failure retries the step
+	if err := sm.issueFeeInvoice(ctx, payment); err != nil {
+		return fmt.Errorf("failed to issue fee invoice: %w", err)
+	}
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	logger.Info("Payment completed successfully", logger.Fields{
+		"payment_id":         payment.PaymentID,
+		"onramp_poll_count":  payment.OnRampPollCount,
+		"offramp_poll_count": payment.OffRampPollCount,
+		"wallet_poll_count":  payment.WalletPollCount,
+		"total_time":         time.Since(payment.CreatedAt).String(),
+	})
+	sm.recordVolume(ctx, payment)
+
+	return nil
+}
+
+// startWalletTransfer sends the minted USDC straight to the payout address of a wallet payout
+func (sm *StateMachine) startWalletTransfer(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
+	wallet := sm.wallet(payment)
+	if wallet == nil {
+		// USDC is already minted - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutUnsupported, "Wallet payouts are not enabled")
+	}
+
+	// The payout is the USDC the on-ramp minted
+	stablecoinAmount := mintedUSDC(payment)
+
+	// Sent from our treasury wallet, so reserve the USDC before starting the transfer
+	debit := models.USDCEntry(payment.Chain, -stablecoinAmount)
+	if ok, err := sm.reserveFloat(ctx, job, payment, models.TreasuryLegWallet, debit); !ok {
+		return err
+	}
+
+	txID, err := wallet.InitiateTransfer(ctx, stablecoinAmount, payment.Chain, payment.DestinationAccount)
+	if err != nil {
+		// USDC is already minted - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, fmt.Sprintf("Wallet transfer initiation failed: %s", err.Error()))
+	}
+
+	// Update payment state
+	payment.WalletTxID = txID
+	sm.transitionState(payment, models.StatusWalletPending, fmt.Sprintf("USDC transfer to %s wallet initiated", payment.Chain))
+	sm.postLedger(ctx, payment, models.LedgerLegWallet,
+		ledger.Payout(payment.Chain, ledgerAmounts(payment), sm.gasCosts[payment.Chain]))
+	delay := sm.polling.resetPollDelay(payment)
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Re-enqueue with initial backoff delay to poll the transfer
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+		return fmt.Errorf("failed to re-enqueue payment: %w", err)
+	}
+
+	logger.Info("Wallet transfer initiated, re-enqueued for polling", logger.Fields{
+		"payment_id":        payment.PaymentID,
+		"wallet_tx_id":      txID,
+		"stablecoin_amount": stablecoinAmount,
+		"delay_seconds":     delay,
+	})
+
+	return nil
+}
+
+// handleWalletPending polls the USDC transfer of a wallet payout
+func (sm *StateMachine) handleWalletPending(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
+	logger.Info("Handling WALLET_TRANSFER_PENDING state - polling status", logger.Fields{
+		"payment_id":   payment.PaymentID,
+		"wallet_tx_id": payment.WalletTxID,
+		"poll_count":   payment.WalletPollCount,
+	})
+
+	wallet := sm.wallet(payment)
+	if wallet == nil {
+		return fmt.Errorf("payment %s is paying out to a wallet but wallet payouts are not enabled", payment.PaymentID)
+	}
+
+	// Poll transfer status
+	transfer, err := wallet.GetTransferStatus(ctx, payment.WalletTxID)
+	if err != nil {
+		return fmt.Errorf("failed to poll wallet transfer status: %w", err)
+	}
+
+	payment.WalletPollCount = transfer.PollCount
+
+	switch transfer.Status {
+	case TransferStatusSettled:
+		// The transfer must be final on-chain before the payment completes
+		if final, err := sm.awaitFinality(ctx, job, payment, &payment.WalletConfirmation, payment.Chain, transfer.TxHash, payment.WalletPollCount); !final {
+			return err
+		}
+
+		return sm.completePayment(ctx, payment, "Wallet transfer settled, USDC delivered")
+
+	case TransferStatusFailed:
+		logger.Error("Wallet transfer failed", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"tx_id":      payment.WalletTxID,
+		})
+
+		// The USDC never left our wallet - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, "Wallet transfer failed")
+
+	case TransferStatusPending:
+		// Give up once the stage has exhausted its poll budget
+		if timedOut, reason := sm.polling.stageTimeout(payment, payment.WalletPollCount); timedOut {
+			return sm.handleStageTimeout(ctx, payment, reason)
+		}
+
+		// Still pending, back off before checking again
+		delay := sm.polling.advancePollDelay(payment)
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+			return fmt.Errorf("failed to re-enqueue payment: %w", err)
+		}
+
+		logger.Info("Wallet transfer still pending, will poll again", logger.Fields{
+			"payment_id":    payment.PaymentID,
+			"poll_count":    payment.WalletPollCount,
+			"delay_seconds": delay,
+		})
+	}
+
+	return nil
+}
+
+// startBridge burns the minted USDC over CCTP for minting on the chain the off-ramp redeems on
+func (sm *StateMachine) startBridge(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
+	bridge := sm.bridge(payment)
+	if bridge == nil {
+		// USDC is still on the minting chain - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutUnsupported, fmt.Sprintf("Off-ramp redeems on %s but bridging from %s is not enabled", payment.OffRampChain, payment.Chain))
+	}
+
+	// What's bridged is the USDC the on-ramp minted, not the funding amount
+	txID, err := bridge.InitiateTransfer(ctx, mintedUSDC(payment), payment.Chain, payment.OffRampChain)
+	if err != nil {
+		// USDC is still on the minting chain - return it to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, fmt.Sprintf("Bridge initiation failed: %s", err.Error()))
+	}
+
+	// Update payment state
+	payment.BridgeTxID = txID
+	sm.transitionState(payment, models.StatusBridgePending, fmt.Sprintf("CCTP bridge initiated from %s to %s", payment.Chain, payment.OffRampChain))
+	delay := sm.polling.resetPollDelay(payment)
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Re-enqueue with initial backoff delay to poll for the mint
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+		return fmt.Errorf("failed to re-enqueue payment: %w", err)
+	}
+
+	logger.Info("Bridge initiated, re-enqueued for polling", logger.Fields{
+		"payment_id":     payment.PaymentID,
+		"bridge_tx_id":   txID,
+		"chain":          payment.Chain,
+		"off_ramp_chain": payment.OffRampChain,
+		"delay_seconds":  delay,
+	})
+
+	return nil
+}
+
+// handleBridgePending polls the CCTP transfer until USDC is minted on the off-ramp's chain
+func (sm *StateMachine) handleBridgePending(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
+	logger.Info("Handling BRIDGE_PENDING state - polling status", logger.Fields{
+		"payment_id":   payment.PaymentID,
+		"bridge_tx_id": payment.BridgeTxID,
+		"poll_count":   payment.BridgePollCount,
+	})
+
+	bridge := sm.bridge(payment)
+	if bridge == nil {
+		return fmt.Errorf("payment %s is bridging but bridging is not enabled", payment.PaymentID)
+	}
+
+	// Poll bridge status
+	transfer, err := bridge.GetTransferStatus(ctx, payment.BridgeTxID)
+	if err != nil {
+		return fmt.Errorf("failed to poll bridge status: %w", err)
+	}
+
+	payment.BridgePollCount = transfer.PollCount
+
+	switch transfer.Status {
+	case TransferStatusSettled:
+		// Minted on the off-ramp's chain, move on to the off-ramp
+		sm.postTreasury(ctx, payment, models.TreasuryLegBridge,
+			models.USDCEntry(payment.Chain, -mintedUSDC(payment)), models.USDCEntry(payment.OffRampChain, mintedUSDC(payment)))
+		sm.transitionState(payment, models.StatusBridgeComplete, fmt.Sprintf("Bridge settled, USDC minted on %s", payment.OffRampChain))
+		sm.postLedger(ctx, payment, models.LedgerLegBridge,
+			ledger.Bridge(payment.Chain, payment.OffRampChain, ledgerAmounts(payment), sm.gasCosts[payment.Chain]))
+
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		// Immediately process offramp (no delay)
+		if err := sm.enqueue(ctx, job, payment, 0); err != nil {
+			return fmt.Errorf("failed to re-enqueue payment: %w", err)
+		}
+
+		logger.Info("Bridge settled, proceeding to offramp", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"poll_count": payment.BridgePollCount,
+		})
+
+	case TransferStatusFailed:
+		logger.Error("Bridge transfer failed", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"tx_id":      payment.BridgeTxID,
+		})
+
+		// Nothing was minted on the off-ramp's chain - return the USDC to the source account
+		return sm.startReversal(ctx, job, payment, errors.PaymentPayoutFailed, "Bridge transfer failed")
+
+	case TransferStatusPending:
+		// Give up once the stage has exhausted its poll budget
+		if timedOut, reason := sm.polling.stageTimeout(payment, payment.BridgePollCount); timedOut {
+			return sm.handleStageTimeout(ctx, payment, reason)
+		}
+
+		// Still waiting on attestation, back off before checking again
+		delay := sm.polling.advancePollDelay(payment)
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+			return fmt.Errorf("failed to re-enqueue payment: %w", err)
+		}
+
+		logger.Info("Bridge still pending, will poll again", logger.Fields{
+			"payment_id":    payment.PaymentID,
+			"poll_count":    payment.BridgePollCount,
+			"delay_seconds": delay,
+		})
+	}
+
+	return nil
+}
+
+// awaitFinality reports whether a settled stage's transaction is final on-chain, recording its confirmations
+// When it isn't, the payment is re-enqueued to check again (or timed out), and false is returned with the
+// result of that. Stages without a transaction hash, on chains that aren't watched, or of sandbox payments
+// (whose hashes aren't on any chain) are final at once.
+func (sm *StateMachine) awaitFinality(ctx context.Context, job *models.PaymentJob, payment *models.Payment, confirmation **models.ChainConfirmation, chain, txHash string, pollCount int) (bool, error) {
+	if sm.chainWatch == nil || chain == "" || txHash == "" || payment.Sandbox {
+		return true, nil
+	}
+
+	checked, err := sm.chainWatch.Check(ctx, chain, txHash)
+	switch {
+	case err != nil:
+		// A node outage holds the stage rather than failing the payment; the stage timeout still applies
+		logger.Warn("Failed to check on-chain confirmations", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"chain":      chain,
+			"tx_hash":    txHash,
+			"error":      err.Error(),
+		})
+	case checked == nil:
+		return true, nil
+	default:
+		*confirmation = checked
+		if checked.Final {
+			return true, nil
+		}
+		if checked.Reverted {
+			// The provider reported settlement of a transaction the chain reverted
+			logger.Error("ALERT: settled transaction reverted on-chain", logger.Fields{
+				"alert":      "tx_reverted",
+				"payment_id": payment.PaymentID,
+				"stage":      payment.Status,
+				"chain":      chain,
+				"tx_hash":    txHash,
+			})
+		}
+	}
+
+	if timedOut, reason := sm.polling.stageTimeout(payment, pollCount); timedOut {
+		return false, sm.handleStageTimeout(ctx, payment, reason+" awaiting on-chain finality")
+	}
+
+	delay := sm.polling.advancePollDelay(payment)
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return false, fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+		return false, fmt.Errorf("failed to re-enqueue payment: %w", err)
+	}
+
+	fields := logger.Fields{
+		"payment_id":    payment.PaymentID,
+		"chain":         chain,
+		"tx_hash":       txHash,
+		"delay_seconds": delay,
+	}
+	if checked != nil {
+		fields["block_number"] = checked.BlockNumber
+		fields["confirmations"] = checked.Confirmations
+	}
+	logger.Info("Settled, awaiting on-chain finality", fields)
+
+	return false, nil
+}
+
+// startReversal moves a payment into the compensation stage after an off-ramp failure or rejected rate
+func (sm *StateMachine) startReversal(ctx context.Context, job *models.PaymentJob, payment *models.Payment, code, reason string) error {
+	sm.transitionState(payment, models.StatusReversing, reason)
+	payment.ErrorMessage = reason
+	payment.ErrorCode = code
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Immediately initiate the reversal (no delay)
+	if err := sm.enqueue(ctx, job, payment, 0); err != nil {
+		return fmt.Errorf("failed to re-enqueue payment: %w", err)
+	}
+
+	logger.Warn("Reversing payment after onramp settled", logger.Fields{
+		"payment_id":    payment.PaymentID,
+		"on_ramp_tx_id": payment.OnRampTxID,
+		"reason":        reason,
+	})
+
+	return nil
+}
+
+// handleReversing redeems minted USDC back to the source account, then fails the payment
+func (sm *StateMachine) handleReversing(ctx context.Context, job *models.PaymentJob, payment *models.Payment) error {
+	logger.Info("Handling REVERSING state", logger.Fields{
+		"payment_id":     payment.PaymentID,
+		"reversal_tx_id": payment.ReversalTxID,
+		"poll_count":     payment.ReversalPollCount,
+	})
+
+	// Step 1: initiate the redemption if we haven't yet
+	// What's redeemed is the USDC the on-ramp minted, matching the treasury's reversal leg
+	if payment.ReversalTxID == "" {
+		txID, err := sm.onRamp(payment).InitiateReversal(ctx, mintedUSDC(payment), payment.FundingCurrency(), payment.SourceAccount)
+		if err != nil {
+			return fmt.Errorf("reversal initiation failed: %w", err)
+		}
+
+		payment.ReversalTxID = txID
+		sm.postReversal(ctx, payment)
+		if payment.OffRampTxID != "" || payment.WalletTxID != "" {
+			sm.postLedger(ctx, payment, models.LedgerLegPayoutReleased, ledger.PayoutReleased(payoutChain(payment), ledgerAmounts(payment)))
+		}
+		delay := sm.polling.resetPollDelay(payment)
+
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+			return fmt.Errorf("failed to re-enqueue payment: %w", err)
+		}
+
+		logger.Info("Reversal initiated, re-enqueued for polling", logger.Fields{
+			"payment_id":     payment.PaymentID,
+			"reversal_tx_id": txID,
+			"delay_seconds":  delay,
+		})
+		return nil
+	}
+
+	// Step 2: poll the redemption
+	transfer, err := sm.onRamp(payment).GetTransferStatus(ctx, payment.ReversalTxID)
+	if err != nil {
+		return fmt.Errorf("failed to poll reversal status: %w", err)
+	}
+
+	payment.ReversalPollCount = transfer.PollCount
+
+	switch transfer.Status {
+	case TransferStatusSettled:
+		sm.transitionState(payment, models.StatusFailed, "Reversal settled, funds returned to source account")
+		sm.postLedger(ctx, payment, models.LedgerLegReversal, ledger.Reversal(fundsChain(payment), ledgerAmounts(payment)))
 		now := time.Now()
 		payment.ProcessedAt = &now
 
-		if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+		// The funds went back to the customer, so no fees are invoiced
+		if err := sm.savePayment(ctx, payment); err != nil {
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
-		logger.Info("Payment completed successfully", logger.Fields{
-			"payment_id":         payment.PaymentID,
-			"onramp_poll_count":  payment.OnRampPollCount,
-			"offramp_poll_count": payment.OffRampPollCount,
-			"total_time":         time.Since(payment.CreatedAt).String(),
+		logger.Info("Payment reversed", logger.Fields{
+			"payment_id":     payment.PaymentID,
+			"reversal_tx_id": payment.ReversalTxID,
 		})
 
 	case TransferStatusFailed:
-		// Mark payment as failed
-		sm.transitionState(payment, models.StatusFailed, "Offramp transfer failed")
-		payment.ErrorMessage = "Offramp settlement failed"
-		sm.dbClient.UpdatePayment(ctx, payment)
+		sm.transitionState(payment, models.StatusFailed, "Reversal failed, funds require manual recovery")
+		payment.ErrorMessage = fmt.Sprintf("%s; reversal failed", payment.ErrorMessage)
+		payment.ErrorCode = errors.PaymentReversalFailed
 
-		logger.Error("Offramp transfer failed", logger.Fields{
-			"payment_id": payment.PaymentID,
-			"tx_id":      payment.OffRampTxID,
+		if err := sm.savePayment(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		logger.Error("ALERT: payment reversal failed, USDC stranded", logger.Fields{
+			"alert":          "reversal_failed",
+			"payment_id":     payment.PaymentID,
+			"on_ramp_tx_id":  payment.OnRampTxID,
+			"reversal_tx_id": payment.ReversalTxID,
+			"amount":         payment.Amount,
 		})
 
 	case TransferStatusPending:
-		// Still pending, check again in 30 seconds
-		if err := sm.dbClient.UpdatePayment(ctx, payment); err != nil {
+		if timedOut, reason := sm.polling.stageTimeout(payment, payment.ReversalPollCount); timedOut {
+			return sm.handleStageTimeout(ctx, payment, reason)
+		}
+
+		delay := sm.polling.advancePollDelay(payment)
+		if err := sm.savePayment(ctx, payment); err != nil {
 			return fmt.Errorf("failed to update payment: %w", err)
 		}
 
-		if err := sm.queueClient.EnqueuePaymentWithDelay(ctx, job, 30); err != nil {
+		if err := sm.enqueue(ctx, job, payment, delay); err != nil {
 			return fmt.Errorf("failed to re-enqueue payment: %w", err)
 		}
 
-		logger.Info("Offramp still pending, will poll again", logger.Fields{
+		logger.Info("Reversal still pending, will poll again", logger.Fields{
 			"payment_id":    payment.PaymentID,
-			"poll_count":    payment.OffRampPollCount,
-			"delay_seconds": 30,
+			"poll_count":    payment.ReversalPollCount,
+			"delay_seconds": delay,
 		})
 	}
 
 	return nil
 }
 
+// handleStageTimeout moves a stuck payment to TIMED_OUT and alerts operators
+func (sm *StateMachine) handleStageTimeout(ctx context.Context, payment *models.Payment, reason string) error {
+	stage := payment.Status
+
+	sm.transitionState(payment, models.StatusTimedOut, reason)
+	payment.ErrorMessage = reason
+	payment.ErrorCode = errors.PaymentTimedOut
+	now := time.Now()
+	payment.ProcessedAt = &now
+
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	// Operators alarm on this log line via a CloudWatch metric filter
+	logger.Error("ALERT: payment timed out waiting for settlement", logger.Fields{
+		"alert":          "payment_timeout",
+		"payment_id":     payment.PaymentID,
+		"stage":          stage,
+		"reason":         reason,
+		"on_ramp_tx_id":  payment.OnRampTxID,
+		"bridge_tx_id":   payment.BridgeTxID,
+		"off_ramp_tx_id": payment.OffRampTxID,
+		"wallet_tx_id":   payment.WalletTxID,
+	})
+
+	return nil
+}
+
+// publishTransitions emits a payment.state_changed event for each transition made during the step
+// Publishing is best-effort: the state change is already persisted
+func (sm *StateMachine) publishTransitions(ctx context.Context, payment *models.Payment, fromStatus models.PaymentStatus, historyLen int) {
+	if sm.events == nil || payment.Status == fromStatus {
+		return
+	}
+
+	for _, transition := range payment.StateHistory[historyLen:] {
+		event := &models.PaymentStateChangedEvent{
+			PaymentID:  payment.PaymentID,
+			FromStatus: transition.FromStatus,
+			ToStatus:   transition.ToStatus,
+			Message:    transition.Message,
+			Amount:     payment.Amount,
+			Currency:   payment.Currency,
+			Chain:      payment.Chain,
+			Timestamp:  transition.Timestamp,
+		}
+
+		if err := sm.events.Publish(ctx, eventbus.DetailTypePaymentStateChanged, event); err != nil {
+			logger.Warn("Failed to publish state change event", logger.Fields{
+				"payment_id": payment.PaymentID,
+				"to":         transition.ToStatus,
+				"error":      err.Error(),
+			})
+		}
+	}
+}
+
+// recordTransitions emits status count and time-in-state metrics for each transition made during the step
+func recordTransitions(payment *models.Payment, historyLen int) {
+	for i := historyLen; i < len(payment.StateHistory); i++ {
+		transition := payment.StateHistory[i]
+
+		enteredAt := payment.CreatedAt
+		if i > 0 {
+			enteredAt = payment.StateHistory[i-1].Timestamp
+		}
+
+		metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(transition.ToStatus)})
+		metrics.Duration("StateDuration", transition.Timestamp.Sub(enteredAt), metrics.Dimensions{"Status": string(transition.FromStatus)})
+	}
+}
+
+// auditTransitions appends each transition made during the step to the audit log
+// Like event publishing this is best-effort; a failed append is logged for operators.
+func (sm *StateMachine) auditTransitions(ctx context.Context, payment *models.Payment, historyLen int) {
+	if sm.audit == nil {
+		return
+	}
+
+	for _, transition := range payment.StateHistory[historyLen:] {
+		_, err := sm.audit.Record(ctx, audit.Event{
+			Actor:        "worker-handler",
+			Action:       audit.ActionPaymentStateChanged,
+			ResourceType: audit.ResourcePayment,
+			ResourceID:   payment.PaymentID,
+			Details: map[string]string{
+				"from":    string(transition.FromStatus),
+				"to":      string(transition.ToStatus),
+				"message": transition.Message,
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to write audit entry", logger.Fields{
+				"payment_id": payment.PaymentID,
+				"to":         transition.ToStatus,
+				"error":      err.Error(),
+			})
+		}
+	}
+}
+
+// savePayment persists the payment, atomically queueing a webhook the first time it's saved in a status that emits one
+func (sm *StateMachine) savePayment(ctx context.Context, payment *models.Payment) error {
+	if webhookEventType(payment) == "" || payment.WebhookStatus == payment.Status {
+		return sm.dbClient.UpdatePayment(ctx, payment)
+	}
+
+	msg, err := NewWebhookOutboxMessage(payment)
+	if err != nil {
+		return err
+	}
+
+	return sm.dbClient.UpdatePaymentWithOutbox(ctx, payment, msg)
+}
+
+// NewWebhookOutboxMessage builds the outbox message that delivers the webhook of a payment's current status
+// It records the status as notified on the payment, so saving it again doesn't queue the webhook twice.
+func NewWebhookOutboxMessage(payment *models.Payment) (*models.OutboxMessage, error) {
+	msg, err := models.NewOutboxMessage(uuid.New().String(), models.OutboxKindWebhookEvent, payment.PaymentID, webhookEventFor(payment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build webhook outbox message: %w", err)
+	}
+	payment.WebhookStatus = payment.Status
+	return msg, nil
+}
+
+// webhookEventFor builds the webhook of a payment's current status
+// Holds are reported as UNDER_REVIEW, without saying which review holds the payment.
+func webhookEventFor(payment *models.Payment) *models.WebhookEvent {
+	event := &models.WebhookEvent{
+		SchemaVersion: models.WebhookEventSchemaVersion,
+		EventType:     webhookEventType(payment),
+		PaymentID:     payment.PaymentID,
+		Status:        payment.Status.PublicStatus(),
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		OnRampTxID:    payment.OnRampTxID,
+		OffRampTxID:   payment.OffRampTxID,
+		ReversalTxID:  payment.ReversalTxID,
+		BridgeTxID:    payment.BridgeTxID,
+		WalletTxID:    payment.WalletTxID,
+		Sandbox:       payment.Sandbox,
+		Error:         payment.ErrorMessage,
+		Timestamp:     time.Now(),
+		CallbackURL:   payment.CallbackURL,
+		CustomerID:    payment.CustomerID,
+	}
+
+	// Include fee information if available
+	if payment.FeeAmount > 0 {
+		event.Fees = &models.FeeBreakdown{
+			Amount:   payment.FeeAmount,
+			Currency: payment.FeeCurrency,
+		}
+	}
+
+	// Failures carry their catalog code, so integrators know whether a new payment may succeed
+	if payment.Status.IsTerminal() && payment.ErrorCode != "" {
+		entry := errors.Lookup(payment.ErrorCode, 0)
+		event.ErrorCode = entry.Code
+		event.ErrorDocID = entry.DocID
+		event.Retryable = &entry.Retryable
+	}
+
+	return event
+}
+
+// enqueue schedules the next step, tagging the job with the status it expects to find
+func (sm *StateMachine) enqueue(ctx context.Context, job *models.PaymentJob, payment *models.Payment, delaySeconds int) error {
+	job.ExpectedStatus = payment.Status
+
+	dims := metrics.Dimensions{"Status": string(payment.Status)}
+	metrics.Count("Reenqueues", dims)
+	metrics.Emit("ReenqueueDelay", float64(delaySeconds), metrics.UnitSeconds, dims)
+
+	return sm.queueClient.EnqueuePaymentWithDelay(ctx, job, delaySeconds)
+}
+
 // transitionState records a state transition
 func (sm *StateMachine) transitionState(payment *models.Payment, newStatus models.PaymentStatus, message string) {
+	transitionState(payment, newStatus, message)
+}
+
+// recordVolume counts a completed payment toward its customer's volume
+// Failures are logged rather than returned: the payment itself has settled. Sandbox payments don't count.
+func (sm *StateMachine) recordVolume(ctx context.Context, payment *models.Payment) {
+	if sm.volumes == nil || payment.CustomerID == "" || payment.Sandbox {
+		return
+	}
+
+	amount, ok := payment.VolumeUSD()
+	if !ok {
+		logger.Warn("No USD volume for payment", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"currency":   payment.Currency,
+		})
+		return
+	}
+
+	completedAt := time.Now()
+	if payment.ProcessedAt != nil {
+		completedAt = *payment.ProcessedAt
+	}
+	entry := &models.VolumeEntry{
+		CustomerID:  payment.CustomerID,
+		PaymentID:   payment.PaymentID,
+		AmountUSD:   amount,
+		CompletedAt: completedAt,
+		TTL:         completedAt.Add(models.VolumeWindow).Unix(),
+	}
+	if err := sm.volumes.RecordVolume(ctx, entry); err != nil {
+		logger.Error("Failed to record customer volume", logger.Fields{
+			"payment_id": payment.PaymentID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// issueFeeInvoice records the fees on a completed payment
+// An invoice from an earlier attempt at the step is kept as issued. Sandbox payments aren't invoiced.
+func (sm *StateMachine) issueFeeInvoice(ctx context.Context, payment *models.Payment) error {
+	if sm.invoices == nil || payment.Sandbox {
+		return nil
+	}
+
+	invoice := models.NewFeeInvoice(payment, time.Now())
+	if err := sm.invoices.CreateFeeInvoice(ctx, invoice); err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && appErr.Code == "CONFLICT" {
+			logger.Info("Fee invoice already issued", logger.Fields{"payment_id": payment.PaymentID})
+			return nil
+		}
+		metrics.Count("FeeInvoiceFailures", metrics.Dimensions{})
+		return err
+	}
+
+	logger.Info("Fee invoice issued", logger.Fields{
+		"payment_id": payment.PaymentID,
+		"total_fees": invoice.TotalFees,
+		"currency":   invoice.Currency,
+	})
+	return nil
+}
+
+// transitionState appends a transition to the payment's history and moves it to newStatus
+func transitionState(payment *models.Payment, newStatus models.PaymentStatus, message string) {
 	transition := models.StateTransition{
 		FromStatus: payment.Status,
 		ToStatus:   newStatus,
@@ -299,6 +1283,7 @@ func (sm *StateMachine) transitionState(payment *models.Payment, newStatus model
 		payment.StateHistory = []models.StateTransition{}
 	}
 	payment.StateHistory = append(payment.StateHistory, transition)
+	recordStageTime(payment, transition.FromStatus, newStatus, transition.Timestamp)
 	payment.Status = newStatus
 	payment.UpdatedAt = time.Now()
 
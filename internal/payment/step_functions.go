@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// StepFunctionsOrchestrator drives payment progression with an AWS Step Functions
+// execution instead of SQS delay re-enqueue
+//
+// Each execution loops over an "Advance" task that runs one StateMachine step.
+// Rather than re-enqueueing, the requested delay is returned to the execution,
+// which waits that long before advancing again (see infrastructure/stepfunctions).
+type StepFunctionsOrchestrator struct {
+	svc             *sfn.SFN
+	stateMachineARN string
+	dbClient        DatabaseClient
+	newStateMachine func(queue QueueClient) *StateMachine
+}
+
+// lockRetrySeconds is how long an execution waits when its step was skipped
+const lockRetrySeconds = 5
+
+// StepInput is the payload passed between Step Functions states
+type StepInput struct {
+	Action string             `json:"action,omitempty"`
+	Job    *models.PaymentJob `json:"job"`
+}
+
+// StepOutput is returned from each Advance task
+type StepOutput struct {
+	Job         *models.PaymentJob   `json:"job"`
+	Status      models.PaymentStatus `json:"status"`
+	Done        bool                 `json:"done"`
+	WaitSeconds int                  `json:"wait_seconds"`
+}
+
+// StepActionAdvance is the task action that runs one state machine step
+const StepActionAdvance = "advance"
+
+
+// NewStepFunctionsOrchestrator creates a Step Functions orchestration backend
+//
+// newStateMachine builds a StateMachine around the supplied queue client so each
+// step's re-enqueue request can be captured instead of sent to SQS. It may be nil
+// when the orchestrator is only used to start executions (e.g. from the API).
+func NewStepFunctionsOrchestrator(region, stateMachineARN string, db DatabaseClient, newStateMachine func(queue QueueClient) *StateMachine) (*StepFunctionsOrchestrator, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &StepFunctionsOrchestrator{
+		svc:             sfn.New(tracing.AWSSession(sess)),
+		stateMachineARN: stateMachineARN,
+		dbClient:        db,
+		newStateMachine: newStateMachine,
+	}, nil
+}
+
+// StartPayment starts a Step Functions execution for the payment
+// The execution name is the payment ID, so duplicate starts are ignored
+func (o *StepFunctionsOrchestrator) StartPayment(ctx context.Context, job *models.PaymentJob) error {
+	input, err := json.Marshal(StepInput{Job: job})
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution input: %w", err)
+	}
+
+	result, err := o.svc.StartExecutionWithContext(ctx, &sfn.StartExecutionInput{
+		StateMachineArn: aws.String(o.stateMachineARN),
+		Name:            aws.String(job.PaymentID),
+		Input:           aws.String(string(input)),
+	})
+	if err != nil {
+		// A redelivered start for a payment that is already running is a no-op
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sfn.ErrCodeExecutionAlreadyExists {
+			logger.Warn("Step Functions execution already exists", logger.Fields{
+				"payment_id": job.PaymentID,
+			})
+			return nil
+		}
+		return fmt.Errorf("failed to start execution: %w", err)
+	}
+
+	logger.Info("Step Functions execution started", logger.Fields{
+		"payment_id":    job.PaymentID,
+		"execution_arn": aws.StringValue(result.ExecutionArn),
+	})
+	return nil
+}
+
+// HandleTask dispatches a Step Functions task invocation
+func (o *StepFunctionsOrchestrator) HandleTask(ctx context.Context, input *StepInput) (*StepOutput, error) {
+	if input.Job == nil || input.Job.PaymentID == "" {
+		return nil, fmt.Errorf("step input missing payment job")
+	}
+
+	switch input.Action {
+	case "", StepActionAdvance:
+		return o.Advance(ctx, input.Job)
+	default:
+		return nil, fmt.Errorf("unknown step action: %s", input.Action)
+	}
+}
+
+// Advance runs a single state machine step and reports how the execution should proceed
+func (o *StepFunctionsOrchestrator) Advance(ctx context.Context, job *models.PaymentJob) (*StepOutput, error) {
+	recorder := &stepRecorder{}
+	if err := o.newStateMachine(recorder).ProcessPayment(ctx, job); err != nil {
+		return nil, err
+	}
+
+	payment, err := o.dbClient.GetPaymentByID(ctx, job.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch payment: %w", err)
+	}
+
+	output := &StepOutput{
+		Job:         job,
+		Status:      payment.Status,
+		Done:        !recorder.enqueued && payment.Status.IsTerminal(),
+		WaitSeconds: recorder.delaySeconds,
+	}
+
+	// The step was skipped because another invocation holds the payment; check back shortly.
+	// A payment held for review waits on an operator, so check back less often.
+	if !recorder.enqueued && !output.Done {
+		output.WaitSeconds = lockRetrySeconds
+		if payment.Status.IsHeld() {
+			output.WaitSeconds = reviewPollSeconds
+		}
+	}
+
+	logger.Info("Step Functions step advanced", logger.Fields{
+		"payment_id":   job.PaymentID,
+		"status":       output.Status,
+		"done":         output.Done,
+		"wait_seconds": output.WaitSeconds,
+	})
+
+	return output, nil
+}
+
+// stepRecorder captures re-enqueue requests so Step Functions can schedule the next step
+type stepRecorder struct {
+	enqueued     bool
+	delaySeconds int
+}
+
+// EnqueuePaymentWithDelay records the requested delay instead of sending to SQS
+func (r *stepRecorder) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
+	r.enqueued = true
+	r.delaySeconds = delaySeconds
+	return nil
+}
+
@@ -0,0 +1,212 @@
+package payment
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"github.com/google/uuid"
+)
+
+// SweeperConfig decides when a payment is stale and what the sweeper does about it
+type SweeperConfig struct {
+	SLA         time.Duration                          // How long a payment may go without an update
+	StatusSLAs  map[models.PaymentStatus]time.Duration // Per-status overrides of SLA
+	MaxRequeues int                                    // Re-enqueues of a payment before it is expired instead
+}
+
+// slaFor returns how long a payment may sit in status without an update
+func (c SweeperConfig) slaFor(status models.PaymentStatus) time.Duration {
+	if sla, ok := c.StatusSLAs[status]; ok {
+		return sla
+	}
+	return c.SLA
+}
+
+// sweptStatuses are the statuses a payment only leaves through a worker step
+// Held payments wait on an operator instead, and terminal ones are done.
+var sweptStatuses = []models.PaymentStatus{
+	models.StatusPending,
+	models.StatusOnrampPending,
+	models.StatusOnrampComplete,
+	models.StatusBridgePending,
+	models.StatusBridgeComplete,
+	models.StatusOfframpPending,
+	models.StatusWalletPending,
+	models.StatusReversing,
+}
+
+// errPaymentBusy means a stale payment was locked by a worker or moved on before it could be swept
+var errPaymentBusy = stderrors.New("payment is locked or has changed status")
+
+// SweeperRepository is the payment storage the sweeper needs
+type SweeperRepository interface {
+	GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error)
+	ListPaymentsByStatus(ctx context.Context, status models.PaymentStatus) ([]*models.Payment, error)
+	UpdatePaymentWithOutbox(ctx context.Context, payment *models.Payment, msg *models.OutboxMessage) error
+	AcquireProcessingLock(ctx context.Context, paymentID string, expectedStatus models.PaymentStatus, owner string, ttl time.Duration) (bool, error)
+	ReleaseProcessingLock(ctx context.Context, paymentID, owner string) error
+}
+
+// Sweeper finds payments stuck in a non-terminal status past their SLA, such as one whose job was never
+// enqueued, and restarts them with a fresh job. A payment that stays stuck after MaxRequeues is expired:
+// one still PENDING never moved funds and fails, and one further along times out for operators to settle.
+type Sweeper struct {
+	db  SweeperRepository
+	cfg SweeperConfig
+}
+
+// NewSweeper creates a stale-payment sweeper
+func NewSweeper(db SweeperRepository, cfg SweeperConfig) *Sweeper {
+	return &Sweeper{db: db, cfg: cfg}
+}
+
+// SweepResult counts what a sweep did
+type SweepResult struct {
+	Requeued int `json:"requeued"`
+	Expired  int `json:"expired"`
+	Skipped  int `json:"skipped"` // Stale payments a worker picked up during the sweep
+	Failed   int `json:"failed"`  // Stale payments that couldn't be handled this run
+}
+
+// Sweep handles every stale payment once
+// A payment that can't be handled is logged and counted, and the sweep carries on with the rest.
+func (s *Sweeper) Sweep(ctx context.Context) (*SweepResult, error) {
+	result := &SweepResult{}
+	now := time.Now()
+
+	for _, status := range sweptStatuses {
+		payments, err := s.db.ListPaymentsByStatus(ctx, status)
+		if err != nil {
+			return result, err
+		}
+
+		sla := s.cfg.slaFor(status)
+		for _, p := range payments {
+			if now.Sub(p.UpdatedAt) < sla {
+				continue
+			}
+
+			requeued, err := s.sweep(ctx, p.PaymentID, status, sla)
+			switch {
+			case stderrors.Is(err, errPaymentBusy):
+				result.Skipped++
+			case err != nil:
+				logger.Error("Failed to sweep stale payment", logger.Fields{
+					"payment_id": p.PaymentID,
+					"status":     status,
+					"error":      err.Error(),
+				})
+				result.Failed++
+			case requeued:
+				result.Requeued++
+			default:
+				result.Expired++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// sweep re-enqueues or expires one stale payment, reporting whether it was re-enqueued
+// It holds the payment's processing lock throughout, so it can't overwrite a step a worker is running.
+func (s *Sweeper) sweep(ctx context.Context, paymentID string, status models.PaymentStatus, sla time.Duration) (bool, error) {
+	owner := uuid.New().String()
+	acquired, err := s.db.AcquireProcessingLock(ctx, paymentID, status, owner, processingLockTTL)
+	if err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, errPaymentBusy
+	}
+	defer func() {
+		if err := s.db.ReleaseProcessingLock(ctx, paymentID, owner); err != nil {
+			logger.Warn("Failed to release processing lock", logger.Fields{
+				"payment_id": paymentID,
+				"error":      err.Error(),
+			})
+		}
+	}()
+
+	// Re-read under the lock in case a step ran since the listing
+	p, err := s.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		return false, err
+	}
+	p.LockOwner = owner
+	stale := time.Since(p.UpdatedAt).Round(time.Second)
+
+	if p.SweepCount < s.cfg.MaxRequeues {
+		return true, s.requeue(ctx, p, stale)
+	}
+	return false, s.expire(ctx, p, stale, sla)
+}
+
+// requeue saves a fresh job for a stale payment's current step
+func (s *Sweeper) requeue(ctx context.Context, p *models.Payment, stale time.Duration) error {
+	p.SweepCount++
+	msg, err := models.NewOutboxMessage(uuid.New().String(), models.OutboxKindPaymentJob, p.PaymentID, models.NewPaymentJob(p))
+	if err != nil {
+		return err
+	}
+	if err := s.db.UpdatePaymentWithOutbox(ctx, p, msg); err != nil {
+		return err
+	}
+
+	metrics.Count("StalePayments", metrics.Dimensions{"Status": string(p.Status), "Action": "requeued"})
+	logger.Warn("Re-enqueued stale payment", logger.Fields{
+		"payment_id":  p.PaymentID,
+		"status":      p.Status,
+		"stale_for":   stale.String(),
+		"sweep_count": p.SweepCount,
+	})
+	return nil
+}
+
+// expire ends a payment re-enqueuing hasn't moved, queueing its webhook
+func (s *Sweeper) expire(ctx context.Context, p *models.Payment, stale, sla time.Duration) error {
+	stage := p.Status
+	reason := fmt.Sprintf("%s made no progress for %s (SLA %s) after %d re-enqueues", stage, stale, sla, p.SweepCount)
+
+	// Nothing has moved before the on-ramp starts, so the payment can simply fail
+	outcome, code := models.StatusTimedOut, errors.PaymentTimedOut
+	if stage == models.StatusPending {
+		outcome, code = models.StatusFailed, errors.PaymentExpired
+	}
+	transitionState(p, outcome, reason)
+	p.ErrorMessage = reason
+	p.ErrorCode = code
+	now := time.Now()
+	p.ProcessedAt = &now
+
+	msg, err := NewWebhookOutboxMessage(p)
+	if err != nil {
+		return err
+	}
+	if err := s.db.UpdatePaymentWithOutbox(ctx, p, msg); err != nil {
+		return err
+	}
+
+	metrics.Count("StalePayments", metrics.Dimensions{"Status": string(stage), "Action": "expired"})
+	metrics.Count("PaymentTransitions", metrics.Dimensions{"Status": string(outcome)})
+
+	// Operators alarm on this log line via a CloudWatch metric filter
+	logger.Error("ALERT: stale payment expired", logger.Fields{
+		"alert":          "payment_stale",
+		"payment_id":     p.PaymentID,
+		"stage":          stage,
+		"status":         outcome,
+		"reason":         reason,
+		"on_ramp_tx_id":  p.OnRampTxID,
+		"bridge_tx_id":   p.BridgeTxID,
+		"off_ramp_tx_id": p.OffRampTxID,
+		"wallet_tx_id":   p.WalletTxID,
+	})
+	return nil
+}
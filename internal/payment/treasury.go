@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// TreasuryLedger interface for tracking our USDC and fiat float balances as payments move funds
+// ApplyTreasuryMovement reports false for a movement that was already applied; ReserveTreasuryMovement
+// applies a movement only if its debits are covered, reporting false when they aren't; GetTreasuryBalance
+// returns nil for accounts nothing has moved through.
+type TreasuryLedger interface {
+	ApplyTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error)
+	ReserveTreasuryMovement(ctx context.Context, movement *models.TreasuryMovement) (bool, error)
+	GetTreasuryBalance(ctx context.Context, account string) (*models.TreasuryBalance, error)
+}
+
+// EnableTreasury tracks treasury balances through the payment lifecycle
+// Off-ramps and wallet transfers reserve their float before they're initiated, waiting until there's enough, and balances
+// falling below their threshold (keyed by account, e.g. "provider:offramp:EUR") raise an alert.
+func (sm *StateMachine) EnableTreasury(ledger TreasuryLedger, thresholds map[string]int64) {
+	sm.treasury = ledger
+	sm.treasuryThresholds = thresholds
+}
+
+// mintedUSDC is the USDC the payment's on-ramp mints
+func mintedUSDC(payment *models.Payment) int64 {
+	return toStablecoin(payment.Amount, payment.FundingCurrency())
+}
+
+// postTreasury applies one leg of a payment to the treasury balances, then checks the debited accounts
+// Failures are logged rather than returned: the funds have already moved, and retrying the step
+// would move them again. Sandbox payments move no real funds, so they're never posted.
+func (sm *StateMachine) postTreasury(ctx context.Context, payment *models.Payment, leg string, entries ...models.TreasuryEntry) {
+	if sm.treasury == nil || payment.Sandbox {
+		return
+	}
+
+	movement := models.PaymentMovement(payment.PaymentID, leg, entries...)
+	applied, err := sm.treasury.ApplyTreasuryMovement(ctx, movement)
+	if err != nil {
+		metrics.Count("TreasuryPostingFailures", metrics.Dimensions{"Leg": leg})
+		logger.Error("ALERT: treasury balances not updated", logger.Fields{
+			"alert":       "treasury_posting_failed",
+			"payment_id":  payment.PaymentID,
+			"movement_id": movement.MovementID,
+			"error":       err.Error(),
+		})
+		return
+	}
+	if !applied {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Amount < 0 {
+			sm.checkLowBalance(ctx, entry.Account())
+		}
+	}
+}
+
+// checkLowBalance alerts when an account has fallen below its configured threshold
+func (sm *StateMachine) checkLowBalance(ctx context.Context, account string) {
+	threshold, ok := sm.treasuryThresholds[account]
+	if !ok {
+		return
+	}
+
+	balance, err := sm.treasury.GetTreasuryBalance(ctx, account)
+	if err != nil || balance == nil {
+		return
+	}
+	if balance.Balance < threshold {
+		// Operators alarm on this log line via a CloudWatch metric filter
+		logger.Error("ALERT: treasury balance low", logger.Fields{
+			"alert":     "treasury_low_balance",
+			"account":   account,
+			"balance":   balance.Balance,
+			"threshold": threshold,
+		})
+	}
+}
+
+// reserveFloat takes entry's debit from the treasury for the payment's payout, reporting whether it could
+// The debit is applied atomically with the balance check, so payments racing for the same float can't
+// both pass it. When the float falls short, the payment is re-enqueued to try again on the polling
+// backoff, and reversed once the stage runs out of time; false is returned with the result of doing so.
+// Sandbox payments don't draw on the float.
+func (sm *StateMachine) reserveFloat(ctx context.Context, job *models.PaymentJob, payment *models.Payment, leg string, entry models.TreasuryEntry) (bool, error) {
+	if sm.treasury == nil || payment.Sandbox {
+		return true, nil
+	}
+
+	account := entry.Account()
+	reserved, err := sm.treasury.ReserveTreasuryMovement(ctx, models.PaymentMovement(payment.PaymentID, leg, entry))
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve treasury float: %w", err)
+	}
+	if reserved {
+		payment.FloatReserved = true
+		sm.checkLowBalance(ctx, account)
+		return true, nil
+	}
+
+	var available int64
+	if balance, err := sm.treasury.GetTreasuryBalance(ctx, account); err == nil && balance != nil {
+		available = balance.Balance
+	}
+	metrics.Count("InsufficientFloat", metrics.Dimensions{"Account": account})
+	logger.Error("ALERT: insufficient treasury float, holding payment", logger.Fields{
+		"alert":      "insufficient_float",
+		"payment_id": payment.PaymentID,
+		"account":    account,
+		"available":  available,
+		"required":   -entry.Amount,
+	})
+
+	// Nothing has been initiated yet, so the minted USDC can still be returned
+	if timedOut, reason := sm.polling.stageTimeout(payment, 0); timedOut {
+		return false, sm.startReversal(ctx, job, payment, errors.PaymentTreasuryInsufficient, fmt.Sprintf("Insufficient treasury float in %s: %s", account, reason))
+	}
+
+	delay := sm.polling.advancePollDelay(payment)
+	if err := sm.savePayment(ctx, payment); err != nil {
+		return false, fmt.Errorf("failed to update payment: %w", err)
+	}
+	if err := sm.enqueue(ctx, job, payment, delay); err != nil {
+		return false, fmt.Errorf("failed to re-enqueue payment: %w", err)
+	}
+	return false, nil
+}
+
+// postReversal releases whatever a failed off-ramp or wallet transfer took from the treasury,
+// then debits the USDC redeemed back to the source account
+// Reserved float is released whether or not the transfer it was reserved for was initiated; payments
+// initiated before reservations were recorded always took it.
+func (sm *StateMachine) postReversal(ctx context.Context, payment *models.Payment) {
+	if sm.treasury == nil || payment.Sandbox {
+		return
+	}
+
+	usdc := mintedUSDC(payment)
+	if payment.IsWalletPayout() {
+		if payment.FloatReserved || payment.WalletTxID != "" {
+			sm.postTreasury(ctx, payment, models.TreasuryLegWalletReleased, models.USDCEntry(payment.Chain, usdc))
+		}
+	} else {
+		var released []models.TreasuryEntry
+		if payment.OffRampTxID != "" {
+			released = append(released, models.USDCEntry(payment.RedeemChain(), usdc))
+		}
+		if payment.FloatReserved || payment.OffRampTxID != "" {
+			released = append(released, models.FloatEntry(models.TreasuryOfframpProvider, payment.Currency, payment.PayoutAmount()))
+		}
+		if len(released) > 0 {
+			sm.postTreasury(ctx, payment, models.TreasuryLegOfframpReleased, released...)
+		}
+	}
+
+	// The USDC is redeemed from wherever it was when the payment failed
+	sm.postTreasury(ctx, payment, models.TreasuryLegReversal, models.USDCEntry(fundsChain(payment), -usdc))
+}
+
+// reached reports whether the payment has ever been in status
+func reached(payment *models.Payment, status models.PaymentStatus) bool {
+	for _, transition := range payment.StateHistory {
+		if transition.ToStatus == status {
+			return true
+		}
+	}
+	return false
+}
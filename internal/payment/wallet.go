@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/logger"
+)
+
+// StatefulWalletClient is a mock of our treasury wallet sending USDC straight to a payout address
+// Used for wallet payouts in place of the off-ramp.
+type StatefulWalletClient struct {
+	transfers map[string]*Transfer
+	behaviour *mockBehaviour
+	mu        sync.RWMutex
+}
+
+// NewStatefulWalletClient creates a new stateful treasury wallet client
+// By default no send fails; opts seed the inclusion timing or script failures.
+func NewStatefulWalletClient(opts ...MockOption) *StatefulWalletClient {
+	return &StatefulWalletClient{
+		transfers: make(map[string]*Transfer),
+		behaviour: newMockBehaviour(0, 0, opts),
+	}
+}
+
+// InitiateTransfer broadcasts a USDC transfer to address on chain (returns immediately)
+func (c *StatefulWalletClient) InitiateTransfer(ctx context.Context, stablecoinAmount int64, chain, address string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Generate transaction ID
+	txID := fmt.Sprintf("wallet_%s_%d", chain, time.Now().UnixNano())
+
+	scenario, scripted := c.behaviour.scenario(stablecoinAmount, address)
+	if c.behaviour.declines(scenario, scripted) {
+		return "", fmt.Errorf("mock wallet transfer failed")
+	}
+
+	// Included after 1-2 poll attempts
+	settlesAfter := c.behaviour.settlesAfter(1, 2, scenario, scripted)
+
+	transfer := &Transfer{
+		TxID:             txID,
+		Status:           TransferStatusPending,
+		Amount:           stablecoinAmount,
+		Currency:         "USDC",
+		StablecoinAmount: stablecoinAmount,
+		TxHash:           c.behaviour.txHash(),
+		CreatedAt:        time.Now(),
+		PollCount:        0,
+		SettlesAfterPoll: settlesAfter,
+	}
+
+	c.transfers[txID] = transfer
+	if scripted {
+		c.behaviour.script(txID, scenario)
+	}
+
+	logger.Info("Wallet transfer initiated", logger.Fields{
+		"tx_id":              txID,
+		"stablecoin_amount":  stablecoinAmount,
+		"chain":              chain,
+		"address":            address,
+		"settles_after_poll": settlesAfter,
+	})
+
+	return txID, nil
+}
+
+// GetTransferStatus polls the status of a transfer
+// Transfers only fail by a failure rate or scenario; an address validated up front accepts USDC.
+func (c *StatefulWalletClient) GetTransferStatus(ctx context.Context, txID string) (*Transfer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	transfer, exists := c.transfers[txID]
+	if !exists {
+		return nil, fmt.Errorf("transfer not found: %s", txID)
+	}
+
+	// Increment poll count
+	transfer.PollCount++
+
+	if transfer.Status == TransferStatusPending && transfer.PollCount >= transfer.SettlesAfterPoll {
+		switch c.behaviour.outcome(txID) {
+		case TransferStatusFailed:
+			transfer.Status = TransferStatusFailed
+			logger.Warn("Wallet transfer failed", logger.Fields{
+				"tx_id":      txID,
+				"poll_count": transfer.PollCount,
+			})
+		case TransferStatusSettled:
+			transfer.Status = TransferStatusSettled
+			now := time.Now()
+			transfer.SettledAt = &now
+			logger.Info("Wallet transfer settled", logger.Fields{
+				"tx_id":      txID,
+				"poll_count": transfer.PollCount,
+			})
+		}
+	}
+
+	logger.Info("Wallet transfer status polled", logger.Fields{
+		"tx_id":      txID,
+		"status":     transfer.Status,
+		"poll_count": transfer.PollCount,
+	})
+
+	// Return a copy
+	copied := *transfer
+	return &copied, nil
+}
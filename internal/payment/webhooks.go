@@ -0,0 +1,48 @@
+package payment
+
+import "crypto-conversion/internal/models"
+
+// Webhook event types emitted as a payment progresses
+const (
+	WebhookPaymentProcessing       = "payment.processing"        // The on-ramp started collecting funds
+	WebhookPaymentOnrampCompleted  = "payment.onramp_completed"  // Funds were converted to USDC
+	WebhookPaymentOfframpInitiated = "payment.offramp_initiated" // The payout to the bank account started
+	WebhookPaymentOnHold           = "payment.on_hold"           // The payment waits on a review
+	WebhookPaymentCompleted        = "payment.completed"
+	WebhookPaymentFailed           = "payment.failed"
+	WebhookPaymentCancelled        = "payment.cancelled" // Rejected by an operator before any funds moved
+	WebhookPaymentTimedOut         = "payment.timed_out"
+)
+
+// webhookEventType returns the webhook a payment emits on entering its current status, or "" if none
+func webhookEventType(payment *models.Payment) string {
+	switch {
+	case payment.Status == models.StatusOnrampPending:
+		return WebhookPaymentProcessing
+	case payment.Status == models.StatusOnrampComplete:
+		return WebhookPaymentOnrampCompleted
+	case payment.Status == models.StatusOfframpPending:
+		return WebhookPaymentOfframpInitiated
+	case payment.Status.IsHeld():
+		return WebhookPaymentOnHold
+	case payment.Status == models.StatusCompleted:
+		return WebhookPaymentCompleted
+	case payment.Status == models.StatusFailed && cancelled(payment):
+		return WebhookPaymentCancelled
+	case payment.Status == models.StatusFailed:
+		return WebhookPaymentFailed
+	case payment.Status == models.StatusTimedOut:
+		return WebhookPaymentTimedOut
+	}
+	return ""
+}
+
+// cancelled reports whether a failed payment was rejected by an operator before its on-ramp started
+func cancelled(payment *models.Payment) bool {
+	if payment.OnRampTxID != "" {
+		return false
+	}
+	rejectedHold := payment.Hold != nil && payment.Hold.Decision == models.HoldDecisionReject
+	rejectedScreening := payment.Screening != nil && payment.Screening.Decision == models.ComplianceDecisionReject
+	return rejectedHold || rejectedScreening
+}
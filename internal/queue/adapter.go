@@ -24,3 +24,8 @@ func NewQueueAdapter(client *Client, queueURL string) *QueueAdapter {
 func (qa *QueueAdapter) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
 	return qa.client.SendPaymentJobWithDelay(ctx, qa.queueURL, job, delaySeconds)
 }
+
+// EnqueuePayments sends payment jobs with no delay, batching the SQS calls
+func (qa *QueueAdapter) EnqueuePayments(ctx context.Context, jobs []*models.PaymentJob) error {
+	return qa.client.SendPaymentJobs(ctx, qa.queueURL, jobs)
+}
@@ -3,6 +3,9 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -10,6 +13,7 @@ import (
 	"crypto-conversion/internal/errors"
 	"crypto-conversion/internal/logger"
 	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
 )
 
 // Client represents an SQS client
@@ -26,7 +30,7 @@ func NewClient(region, endpoint string) (*Client, error) {
 		return nil, err
 	}
 
-	svc := sqs.New(sess)
+	svc := sqs.New(tracing.AWSSession(sess))
 
 	// Override endpoint for local testing
 	if endpoint != "" {
@@ -38,6 +42,15 @@ func NewClient(region, endpoint string) (*Client, error) {
 	}, nil
 }
 
+// CheckQueue verifies the queue exists and can be reached
+func (c *Client) CheckQueue(ctx context.Context, queueURL string) error {
+	_, err := c.svc.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+	})
+	return err
+}
+
 // SendPaymentJob sends a payment job to the queue
 func (c *Client) SendPaymentJob(ctx context.Context, queueURL string, job *models.PaymentJob) error {
 	return c.SendPaymentJobWithDelay(ctx, queueURL, job, 0)
@@ -54,16 +67,8 @@ func (c *Client) SendPaymentJobWithDelay(ctx context.Context, queueURL string, j
 	input := &sqs.SendMessageInput{
 		QueueUrl:    aws.String(queueURL),
 		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]*sqs.MessageAttributeValue{
-			"PaymentID": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String(job.PaymentID),
-			},
-			"Currency": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String(job.Currency),
-			},
-		},
+		MessageAttributes: paymentJobAttributes(job),
+		MessageSystemAttributes: traceAttributes(ctx),
 	}
 
 	// Add delay if specified (max 900 seconds = 15 minutes for standard SQS)
@@ -92,6 +97,93 @@ func (c *Client) SendPaymentJobWithDelay(ctx context.Context, queueURL string, j
 	return nil
 }
 
+// paymentJobAttributes are the message attributes a payment job is sent with
+func paymentJobAttributes(job *models.PaymentJob) map[string]*sqs.MessageAttributeValue {
+	return map[string]*sqs.MessageAttributeValue{
+		"PaymentID": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(job.PaymentID),
+		},
+		"Currency": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(job.Currency),
+		},
+	}
+}
+
+// traceAttributes carries the caller's trace header on a message, so the consumer's segment joins the trace
+// It's nil when the caller isn't traced.
+func traceAttributes(ctx context.Context) map[string]*sqs.MessageSystemAttributeValue {
+	header := tracing.TraceHeader(ctx)
+	if header == "" {
+		return nil
+	}
+	return map[string]*sqs.MessageSystemAttributeValue{
+		sqs.MessageSystemAttributeNameForSendsAwstraceHeader: {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(header),
+		},
+	}
+}
+
+// maxSendBatchEntries is the most messages SQS accepts in one SendMessageBatch call
+const maxSendBatchEntries = 10
+
+// SendPaymentJobs sends payment jobs to the queue with no delay, up to ten per SendMessageBatch call
+// A job SQS refuses fails the call after the rest have been sent; resending the whole set is safe,
+// since the worker's processing guards absorb duplicate jobs.
+func (c *Client) SendPaymentJobs(ctx context.Context, queueURL string, jobs []*models.PaymentJob) error {
+	var failed []string
+	for start := 0; start < len(jobs); start += maxSendBatchEntries {
+		end := start + maxSendBatchEntries
+		if end > len(jobs) {
+			end = len(jobs)
+		}
+
+		input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(queueURL)}
+		for i, job := range jobs[start:end] {
+			body, err := json.Marshal(job)
+			if err != nil {
+				logger.Error("Failed to marshal payment job", logger.Fields{"error": err.Error()})
+				return errors.ErrQueueOperation("marshal", err)
+			}
+			input.Entries = append(input.Entries, &sqs.SendMessageBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(start + i)), // Index into jobs
+				MessageBody:       aws.String(string(body)),
+				MessageAttributes: paymentJobAttributes(job),
+				MessageSystemAttributes: traceAttributes(ctx),
+			})
+		}
+
+		result, err := c.svc.SendMessageBatchWithContext(ctx, input)
+		if err != nil {
+			logger.Error("Failed to send payment job batch", logger.Fields{
+				"error": err.Error(),
+				"count": len(input.Entries),
+			})
+			return errors.ErrQueueOperation("send_batch", err)
+		}
+		for _, entry := range result.Failed {
+			index, _ := strconv.Atoi(aws.StringValue(entry.Id))
+			logger.Error("Payment job refused by queue", logger.Fields{
+				"payment_id": jobs[index].PaymentID,
+				"code":       aws.StringValue(entry.Code),
+				"message":    aws.StringValue(entry.Message),
+			})
+			failed = append(failed, jobs[index].PaymentID)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.ErrQueueOperation("send_batch", fmt.Errorf("%d of %d payment jobs not sent: %s", len(failed), len(jobs), strings.Join(failed, ", ")))
+	}
+
+	logger.Info("Payment jobs sent to queue", logger.Fields{
+		"count": len(jobs),
+	})
+	return nil
+}
+
 // EnqueuePaymentWithDelay is an alias for compatibility with state machine interface
 func (c *Client) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
 	// This will be set by the worker handler which knows the queue URL
@@ -107,19 +199,26 @@ func (c *Client) SendWebhookEvent(ctx context.Context, queueURL string, event *m
 		return errors.ErrQueueOperation("marshal", err)
 	}
 
-	input := &sqs.SendMessageInput{
-		QueueUrl:    aws.String(queueURL),
-		MessageBody: aws.String(string(body)),
-		MessageAttributes: map[string]*sqs.MessageAttributeValue{
-			"PaymentID": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String(event.PaymentID),
-			},
-			"Status": {
+	// SQS rejects empty attribute values, and quote events carry no payment status
+	attributes := map[string]*sqs.MessageAttributeValue{}
+	for name, value := range map[string]string{
+		"PaymentID": event.PaymentID,
+		"QuoteID":   event.QuoteID,
+		"Status":    string(event.Status),
+	} {
+		if value != "" {
+			attributes[name] = &sqs.MessageAttributeValue{
 				DataType:    aws.String("String"),
-				StringValue: aws.String(string(event.Status)),
-			},
-		},
+				StringValue: aws.String(value),
+			}
+		}
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueURL),
+		MessageBody:       aws.String(string(body)),
+		MessageAttributes: attributes,
+		MessageSystemAttributes: traceAttributes(ctx),
 	}
 
 	result, err := c.svc.SendMessageWithContext(ctx, input)
@@ -132,7 +231,9 @@ func (c *Client) SendWebhookEvent(ctx context.Context, queueURL string, event *m
 	}
 
 	logger.Info("Webhook event sent to queue", logger.Fields{
+		"event_type": event.EventType,
 		"payment_id": event.PaymentID,
+		"quote_id":   event.QuoteID,
 		"message_id": *result.MessageId,
 	})
 	return nil
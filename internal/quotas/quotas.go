@@ -0,0 +1,103 @@
+// Package quotas enforces hard daily and monthly payment quotas per customer tier
+// A payment is reserved against its customer's quota windows before it is saved, and released again
+// if it isn't, so the counters only hold payments that were accepted.
+package quotas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+)
+
+// Tiers maps a customer tier to its quota limits
+type Tiers map[string]models.QuotaLimits
+
+// ParseTiers parses the QUOTA_TIERS JSON object
+func ParseTiers(data []byte) (Tiers, error) {
+	var tiers Tiers
+	if err := json.Unmarshal(data, &tiers); err != nil {
+		return nil, fmt.Errorf("invalid quota tiers: %w", err)
+	}
+	for tier, limits := range tiers {
+		if limits.DailyCount < 0 || limits.DailyVolume < 0 || limits.MonthlyCount < 0 || limits.MonthlyVolume < 0 {
+			return nil, fmt.Errorf("quota tier %s: limits must not be negative", tier)
+		}
+	}
+	return tiers, nil
+}
+
+// Enforcer reserves payments against their customer's quotas
+type Enforcer struct {
+	store database.QuotaRepository
+	tiers Tiers
+}
+
+// New creates a quota enforcer backed by store; a tier missing from tiers has no quotas
+func New(store database.QuotaRepository, tiers Tiers) *Enforcer {
+	return &Enforcer{store: store, tiers: tiers}
+}
+
+// Limits returns a tier's quota limits
+func (e *Enforcer) Limits(tier string) models.QuotaLimits {
+	return e.tiers[tier]
+}
+
+// Reserve counts a payment against its customer's daily and monthly quotas,
+// failing with QUOTA_EXCEEDED if it would take either past its tier's limits
+func (e *Enforcer) Reserve(ctx context.Context, customerID, tier string, p *models.Payment) error {
+	windows := models.QuotaWindows(e.Limits(tier), p.CreatedAt)
+	volume := paymentVolume(p)
+
+	// A payment larger than a whole window's volume can never fit; an empty counter wouldn't be checked against it
+	for _, window := range windows {
+		if window.Exceeds(nil, volume) {
+			return errors.ErrQuotaExceeded(window.Period, window.ResetsAt)
+		}
+	}
+	return e.store.ReserveQuota(ctx, customerID, windows, volume)
+}
+
+// Release gives back a payment reserved with Reserve that failed to be created
+func (e *Enforcer) Release(ctx context.Context, customerID string, p *models.Payment) error {
+	return e.store.ReleaseQuota(ctx, customerID, models.QuotaWindows(models.QuotaLimits{}, p.CreatedAt), paymentVolume(p))
+}
+
+// Status reports what a customer has used and has left of its tier's quotas for the windows containing at
+func (e *Enforcer) Status(ctx context.Context, customerID, tier string, at time.Time) (*models.QuotaStatus, error) {
+	windows := models.QuotaWindows(e.Limits(tier), at)
+	keys := make([]string, len(windows))
+	for i, window := range windows {
+		keys[i] = window.Key
+	}
+
+	counters, err := e.store.GetQuotaCounters(ctx, customerID, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &models.QuotaStatus{
+		CustomerID: customerID,
+		Tier:       tier,
+		Daily:      models.NewQuotaHeadroom(windows[0], counters[windows[0].Key]),
+		Monthly:    models.NewQuotaHeadroom(windows[1], counters[windows[1].Key]),
+	}, nil
+}
+
+// paymentVolume returns the USD volume a payment counts for
+// A payment whose USD value isn't known counts toward the payment quotas only.
+func paymentVolume(p *models.Payment) int64 {
+	volume, ok := p.VolumeUSD()
+	if !ok {
+		logger.Warn("Payment volume unknown for quotas", logger.Fields{
+			"payment_id": p.PaymentID,
+			"currency":   p.FundingCurrency(),
+		})
+		return 0
+	}
+	return volume
+}
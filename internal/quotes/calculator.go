@@ -1,87 +1,168 @@
 package quotes
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/errors"
 	"crypto-conversion/internal/fees"
 	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/money"
 )
 
 // Calculator handles quote generation and exchange rate fetching
 type Calculator struct {
-	feeCalc *fees.Calculator
+	feeCalc   *fees.Calculator
+	ttlPolicy TTLPolicy
+	corridors *corridors.Registry
+	providers []RateProvider // nil uses mock rates for every corridor provider
+	advisor   ChainAdvisor   // Optional; picks the settlement chain by health
+	midRates  MidRateSource  // Optional; live mid-rates for indicative rates
+	sla       chains.SLAPolicy
+}
+
+// ChainAdvisor scores the health of the chains a corridor settles on
+// Satisfied by fees.AIFeeCalculator.
+type ChainAdvisor interface {
+	ChainHealth(ctx context.Context, from, to string) (map[string]*chains.Health, error)
 }
 
 // NewCalculator creates a new quote calculator
-func NewCalculator(feeCalc *fees.Calculator) *Calculator {
+// providers are the live rate providers; a corridor is quoted by those named in its rate_providers.
+// With no providers, rates are simulated around each corridor's mid-market rate.
+func NewCalculator(feeCalc *fees.Calculator, ttlPolicy TTLPolicy, registry *corridors.Registry, providers []RateProvider) *Calculator {
 	return &Calculator{
-		feeCalc: feeCalc,
+		feeCalc:   feeCalc,
+		ttlPolicy: ttlPolicy,
+		corridors: registry,
+		providers: providers,
 	}
 }
 
-// GenerateQuote creates a new quote with locked-in rates and fees
-func (c *Calculator) GenerateQuote(req *QuoteRequest) (*Quote, error) {
-	// Validate currencies (MVP: only USD -> EUR)
-	if req.FromCurrency != "USD" {
-		return nil, fmt.Errorf("only USD source currency supported in MVP")
+// SetChainAdvisor makes quotes name the healthiest corridor chain to settle on
+func (c *Calculator) SetChainAdvisor(advisor ChainAdvisor) {
+	c.advisor = advisor
+}
+
+// SetSLAPolicy overrides the settlement times quotes promise per chain
+func (c *Calculator) SetSLAPolicy(policy chains.SLAPolicy) {
+	c.sla = policy
+}
+
+// SettlementSLA returns the settlement time promised for USDC minted on chain, bridged to offRampChain if set
+// A nil calculator promises the built-in SLAs.
+func (c *Calculator) SettlementSLA(chain, offRampChain string) time.Duration {
+	if c == nil {
+		return chains.SLAPolicy{}.SettlementSLA(chain, offRampChain)
 	}
-	if req.ToCurrency != "EUR" {
-		return nil, fmt.Errorf("only EUR destination currency supported in MVP")
+	return c.sla.SettlementSLA(chain, offRampChain)
+}
+
+// GenerateQuote creates a new quote with locked-in rates and fees
+func (c *Calculator) GenerateQuote(ctx context.Context, req *QuoteRequest) (*Quote, error) {
+	// Validate the currency pair and amount against the corridor registry
+	corridor, err := c.corridors.Lookup(req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		return nil, err
 	}
-	if req.Amount <= 0 {
-		return nil, fmt.Errorf("amount must be positive")
+	if err := corridor.ValidateAmount(req.Amount); err != nil {
+		return nil, err
 	}
+	req.FromCurrency = corridor.SourceCurrency
+	req.ToCurrency = corridor.DestinationCurrency
 
 	// Generate quote ID
 	quoteID := fmt.Sprintf("quote_%s", uuid.New().String())
 
-	// Fetch exchange rate (mock - simulates checking multiple providers)
-	exchangeRate, providerName := c.fetchBestExchangeRate(req.FromCurrency, req.ToCurrency, req.Amount)
+	// Fetch the best executable rate across the corridor's providers
+	best, rateSpread, err := c.fetchBestExchangeRate(ctx, corridor, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	exchangeRate := best.Rate
+	providerName := best.Provider
 
 	// Calculate platform fee
-	feeResult := c.feeCalc.CalculateFee(req.Amount, req.ToCurrency)
-	platformFee := feeResult.FeeAmount
+	feeResult := c.feeCalc.CalculateCorridorFee(ctx, corridor, req.Amount, req.Customer)
+	platformFee := feeResult.PlatformFee()
+	regulatoryFee := feeResult.Surcharge
 
-	// Estimate onramp fee (mock - would come from provider APIs)
-	onrampFee := c.estimateOnrampFee(req.Amount)
+	// Promo discounts come off the platform fee only; provider fees are passed through
+	var promoCode string
+	var promoDiscount int64
+	if req.Promo != nil {
+		promoCode = req.Promo.Code
+		promoDiscount = req.Promo.Discount(platformFee)
+		platformFee -= promoDiscount
+	}
 
-	// Estimate offramp fee (mock - would come from provider APIs)
-	offrampFee := c.estimateOfframpFee(req.Amount)
+	// Provider fees, estimated when the winning provider didn't price them
+	onrampFee := c.estimateOnrampFee(req.Amount)
+	offrampFee := corridor.OfframpFee(req.Amount)
+	if best.FeesQuoted {
+		onrampFee = best.OnrampFee
+		offrampFee = best.OfframpFee
+	}
 
 	// Calculate total fees
-	totalFees := platformFee + onrampFee + offrampFee
+	totalFees := platformFee + regulatoryFee + onrampFee + offrampFee
 
 	// Calculate guaranteed payout
 	// Amount after fees, converted at locked rate
 	amountAfterFees := req.Amount - totalFees
-	guaranteedPayout := int64(float64(amountAfterFees) * exchangeRate)
+	guaranteedPayout := money.Convert(amountAfterFees, req.FromCurrency, req.ToCurrency, exchangeRate)
 
-	// Quote valid for 60 seconds
-	validForSeconds := 60
+	// Validity window depends on corridor, customer tier and current FX volatility
+	ttl, policy := c.ttlPolicy.Resolve(corridor.ID, req.CustomerTier, rateSpread)
+	validForSeconds := int(ttl / time.Second)
 	createdAt := time.Now()
-	expiresAt := createdAt.Add(time.Duration(validForSeconds) * time.Second)
+	expiresAt := createdAt.Add(ttl)
+	if !best.ExpiresAt.IsZero() && best.ExpiresAt.Before(expiresAt) {
+		// The guarantee can't outlive the provider's executable quote
+		expiresAt = best.ExpiresAt
+		validForSeconds = int(expiresAt.Sub(createdAt) / time.Second)
+		policy.Rule = "provider:" + best.Provider
+	}
 
 	quote := &Quote{
-		QuoteID:          quoteID,
-		FromCurrency:     req.FromCurrency,
-		ToCurrency:       req.ToCurrency,
-		Amount:           req.Amount,
-		ExchangeRate:     exchangeRate,
-		PlatformFee:      platformFee,
-		OnrampFee:        onrampFee,
-		OfframpFee:       offrampFee,
-		TotalFees:        totalFees,
-		GuaranteedPayout: guaranteedPayout,
-		PayoutCurrency:   req.ToCurrency,
-		CreatedAt:        createdAt,
-		ExpiresAt:        expiresAt,
-		ValidForSeconds:  validForSeconds,
-		ProviderRate:     providerName,
-		TTL:              expiresAt.Unix(), // DynamoDB will auto-delete after expiration
+		QuoteID:              quoteID,
+		FromCurrency:         req.FromCurrency,
+		ToCurrency:           req.ToCurrency,
+		Amount:               req.Amount,
+		ExchangeRate:         exchangeRate,
+		PlatformFee:          platformFee,
+		OnrampFee:            onrampFee,
+		OfframpFee:           offrampFee,
+		TotalFees:            totalFees,
+		GuaranteedPayout:     guaranteedPayout,
+		PayoutCurrency:       req.ToCurrency,
+		CreatedAt:            createdAt,
+		ExpiresAt:            expiresAt,
+		ValidForSeconds:      validForSeconds,
+		ProviderRate:         providerName,
+		ProviderQuoteID:      best.QuoteID,
+		TTLPolicy:            policy,
+		FeeScheduleID:        feeResult.ScheduleID,
+		FeeScheduleVersion:   feeResult.ScheduleVersion,
+		PricingCustomer:      feeResult.PricingCustomer,
+		FeeDiscount:          feeResult.Discount,
+		PromoCode:            promoCode,
+		PromoDiscount:        promoDiscount,
+		FeeLimit:             feeResult.FeeLimit,
+		UnclampedPlatformFee: feeResult.UnclampedFee,
+		RegulatoryFee:        regulatoryFee,
+		Surcharges:           feeResult.Surcharges,
+		TTL:                  expiresAt.Unix(), // DynamoDB will auto-delete after expiration
 	}
+	c.selectSettlementChain(ctx, corridor, quote)
+	quote.SettlementSLASeconds = int(c.SettlementSLA(quote.SettlementChain, quote.OffRampChain) / time.Second)
 
 	logger.Info("Quote generated", logger.Fields{
 		"quote_id":          quoteID,
@@ -91,40 +172,155 @@ func (c *Calculator) GenerateQuote(req *QuoteRequest) (*Quote, error) {
 		"guaranteed_payout": guaranteedPayout,
 		"provider":          providerName,
 		"expires_at":        expiresAt.Format(time.RFC3339),
+		"ttl_rule":          policy.Rule,
+		"volatility_capped": policy.VolatilityCapped,
+		"settlement_chain":  quote.SettlementChain,
 	})
 
 	return quote, nil
 }
 
-// fetchBestExchangeRate simulates fetching rates from multiple providers
-// In production, this would query Circle, Bridge, Coinbase APIs
-func (c *Calculator) fetchBestExchangeRate(from, to string, amount int64) (float64, string) {
-	// Mock: Simulate checking 3 providers
-	providers := []struct {
-		name string
-		rate float64
-	}{
-		{"Circle", 0.9200 + (rand.Float64()-0.5)*0.005},  // 0.9175 - 0.9225
-		{"Bridge", 0.9195 + (rand.Float64()-0.5)*0.005},  // 0.9170 - 0.9220
-		{"Coinbase", 0.9190 + (rand.Float64()-0.5)*0.005}, // 0.9165 - 0.9215
-	}
-
-	// Find best rate (highest for USD -> EUR)
-	bestProvider := providers[0]
-	for _, p := range providers {
-		if p.rate > bestProvider.rate {
-			bestProvider = p
+// selectSettlementChain sets the quote's settlement chain to the healthiest corridor chain
+// If the off-ramp doesn't redeem on it, the quote also names the healthiest chain it does redeem on.
+// Without an advisor, or if health can't be scored, the quote leaves the chain to the payment.
+func (c *Calculator) selectSettlementChain(ctx context.Context, corridor corridors.Corridor, quote *Quote) {
+	if c.advisor == nil || len(corridor.Chains) == 0 {
+		return
+	}
+	healths, err := c.advisor.ChainHealth(ctx, corridor.SourceCurrency, corridor.DestinationCurrency)
+	if err != nil {
+		logger.Warn("Chain health unavailable for quote", logger.Fields{
+			"quote_id": quote.QuoteID,
+			"corridor": corridor.ID,
+			"error":    err.Error(),
+		})
+		return
+	}
+
+	quote.SettlementChain = chains.Best(healths, corridor.Chains)
+	if health, ok := healths[quote.SettlementChain]; ok {
+		quote.ChainHealthScore = health.Score
+		quote.ChainHealthLevel = health.Level
+	}
+	if corridor.OfframpChainFor(quote.SettlementChain) != "" {
+		quote.OffRampChain = chains.Best(healths, corridor.OfframpChains)
+	}
+}
+
+// ExecutableRate returns the best rate the corridor's providers would execute at right now
+// It's used to check payments for slippage at execution time.
+func (c *Calculator) ExecutableRate(ctx context.Context, from, to string, amount int64) (float64, error) {
+	corridor, err := c.corridors.Lookup(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	best, _, err := c.fetchBestExchangeRate(ctx, corridor, amount)
+	if err != nil {
+		return 0, err
+	}
+	return best.Rate, nil
+}
+
+// EstimateProviderFees estimates the on-ramp and off-ramp fees for a payment accepted without a quote
+func (c *Calculator) EstimateProviderFees(from, to string, amount int64) (onrampFee, offrampFee int64, err error) {
+	corridor, err := c.corridors.Lookup(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return c.estimateOnrampFee(amount), corridor.OfframpFee(amount), nil
+}
+
+// fetchBestExchangeRate queries the corridor's rate providers concurrently and returns the best quote
+// The returned spread (best minus worst rate, as a fraction of the best) is used as a volatility signal.
+// Providers that fail or time out are skipped; the quote fails only if none respond.
+func (c *Calculator) fetchBestExchangeRate(ctx context.Context, corridor corridors.Corridor, amount int64) (*ProviderQuote, float64, error) {
+	providers := c.corridorProviders(corridor)
+	if len(providers) == 0 {
+		return nil, 0, errors.ErrQuoteUnavailable(corridor.ID, fmt.Errorf("no configured rate provider serves this corridor"))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, providerQuoteTimeout)
+	defer cancel()
+
+	results := make([]*ProviderQuote, len(providers))
+	failures := make([]string, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider RateProvider) {
+			defer wg.Done()
+			quote, err := provider.Quote(ctx, corridor, amount)
+			if err != nil {
+				failures[i] = fmt.Sprintf("%s: %s", provider.Name(), err.Error())
+				metrics.Count("ProviderQuoteFailures", metrics.Dimensions{"Provider": provider.Name()})
+				return
+			}
+			results[i] = quote
+		}(i, provider)
+	}
+	wg.Wait()
+
+	// Find best rate (highest units of payout currency per unit of source currency)
+	var best *ProviderQuote
+	var worstRate float64
+	for _, quote := range results {
+		if quote == nil {
+			continue
+		}
+		if best == nil || quote.Rate > best.Rate {
+			best = quote
+		}
+		if worstRate == 0 || quote.Rate < worstRate {
+			worstRate = quote.Rate
+		}
+	}
+
+	var failed []string
+	for _, f := range failures {
+		if f != "" {
+			failed = append(failed, f)
 		}
 	}
+	if best == nil {
+		return nil, 0, errors.ErrQuoteUnavailable(corridor.ID, fmt.Errorf("%s", strings.Join(failed, "; ")))
+	}
+	if len(failed) > 0 {
+		logger.Warn("Some rate providers failed to quote", logger.Fields{
+			"corridor": corridor.ID,
+			"errors":   strings.Join(failed, "; "),
+		})
+	}
+	spread := (best.Rate - worstRate) / best.Rate
 
 	logger.Info("Exchange rate fetched", logger.Fields{
-		"from":     from,
-		"to":       to,
-		"rate":     bestProvider.rate,
-		"provider": bestProvider.name,
+		"from":     corridor.SourceCurrency,
+		"to":       corridor.DestinationCurrency,
+		"rate":     best.Rate,
+		"provider": best.Provider,
+		"spread":   spread,
 	})
 
-	return bestProvider.rate, bestProvider.name
+	return best, spread, nil
+}
+
+// corridorProviders returns the providers to query for a corridor, in the corridor's order
+// Names without a configured live provider are skipped, so a real quote is never mixed with a simulated one.
+func (c *Calculator) corridorProviders(corridor corridors.Corridor) []RateProvider {
+	var providers []RateProvider
+	for i, name := range corridor.RateProviders {
+		if c.providers == nil {
+			providers = append(providers, NewMockRateProvider(name, i))
+			continue
+		}
+		for _, p := range c.providers {
+			if strings.EqualFold(p.Name(), name) {
+				providers = append(providers, p)
+				break
+			}
+		}
+	}
+	return providers
 }
 
 // estimateOnrampFee calculates estimated onramp provider fee
@@ -136,15 +332,6 @@ func (c *Calculator) estimateOnrampFee(amount int64) int64 {
 	return percentageFee + fixedFee
 }
 
-// estimateOfframpFee calculates estimated offramp provider fee
-// In production, would call provider quote APIs
-func (c *Calculator) estimateOfframpFee(amount int64) int64 {
-	// Mock: Offramp typically charges ~1.5% + fixed fee
-	percentageFee := int64(float64(amount) * 0.015) // 1.5%
-	fixedFee := int64(75)                           // $0.75
-	return percentageFee + fixedFee
-}
-
 // ToResponse converts a Quote to a QuoteResponse for API
 func (q *Quote) ToResponse() *QuoteResponse {
 	return &QuoteResponse{
@@ -153,15 +340,26 @@ func (q *Quote) ToResponse() *QuoteResponse {
 		Currency:     q.FromCurrency,
 		ExchangeRate: q.ExchangeRate,
 		Fees: FeeDetail{
-			PlatformFee: q.PlatformFee,
-			OnrampFee:   q.OnrampFee,
-			OfframpFee:  q.OfframpFee,
-			TotalFees:   q.TotalFees,
-			Currency:    "USD", // MVP: all fees in USD
+			PlatformFee:   q.PlatformFee,
+			OnrampFee:     q.OnrampFee,
+			OfframpFee:    q.OfframpFee,
+			TotalFees:     q.TotalFees,
+			Currency:      q.FromCurrency, // Fees are charged in the source currency
+			PromoCode:     q.PromoCode,
+			PromoDiscount: q.PromoDiscount,
+			FeeLimit:      q.FeeLimit,
+			UnclampedFee:  q.UnclampedPlatformFee,
+			RegulatoryFee: q.RegulatoryFee,
+			Surcharges:    q.Surcharges,
 		},
-		GuaranteedPayout: q.GuaranteedPayout,
-		PayoutCurrency:   q.PayoutCurrency,
-		ExpiresAt:        q.ExpiresAt,
-		ValidForSeconds:  q.ValidForSeconds,
+		GuaranteedPayout:     q.GuaranteedPayout,
+		PayoutCurrency:       q.PayoutCurrency,
+		ExpiresAt:            q.ExpiresAt,
+		ValidForSeconds:      q.ValidForSeconds,
+		TTLPolicy:            q.TTLPolicy,
+		SettlementChain:      q.SettlementChain,
+		ChainHealth:          q.ChainHealthLevel,
+		OffRampChain:         q.OffRampChain,
+		SettlementSLASeconds: q.SettlementSLASeconds,
 	}
 }
@@ -0,0 +1,113 @@
+package quotes
+
+import (
+	"strings"
+
+	"crypto-conversion/internal/fees"
+)
+
+// Cheaper values of a QuoteDelta
+const (
+	CheaperStatic = "static"
+	CheaperAI     = "ai"
+	CheaperEqual  = "equal"
+)
+
+// QuoteComparison sets the deterministic quote beside the AI engine's routing for the same payment,
+// returned by POST /quotes/compare. Neither side is binding: the static quote isn't stored.
+type QuoteComparison struct {
+	FromCurrency string      `json:"from_currency"`
+	ToCurrency   string      `json:"to_currency"`
+	Amount       int64       `json:"amount"`       // Source minor units
+	FeeCurrency  string      `json:"fee_currency"` // Both sides' fees are in the source currency
+	Static       StaticRoute `json:"static"`
+	AI           AIRoute     `json:"ai"`
+	Delta        QuoteDelta  `json:"delta"`
+}
+
+// StaticRoute is the deterministic side of a comparison: what POST /quotes would quote
+type StaticRoute struct {
+	PlatformFee          int64   `json:"platform_fee"`
+	OnrampFee            int64   `json:"onramp_fee"`
+	OfframpFee           int64   `json:"offramp_fee"`
+	RegulatoryFee        int64   `json:"regulatory_fee,omitempty"`
+	TotalFees            int64   `json:"total_fees"`
+	ExchangeRate         float64 `json:"exchange_rate"`
+	GuaranteedPayout     int64   `json:"guaranteed_payout"`
+	SettlementChain      string  `json:"settlement_chain,omitempty"`
+	OffRampChain         string  `json:"off_ramp_chain,omitempty"`
+	SettlementSLASeconds int     `json:"settlement_sla_seconds,omitempty"`
+}
+
+// AIRoute is the AI engine's side of a comparison: what POST /fees/calculate would recommend
+type AIRoute struct {
+	FeeBreakdown            fees.FeeBreakdown `json:"fee_breakdown"`
+	TotalFee                int64             `json:"total_fee"`
+	Onramp                  string            `json:"onramp"`
+	Offramp                 string            `json:"offramp"`
+	SettlementChain         string            `json:"settlement_chain"`
+	EstimatedSettlementTime string            `json:"estimated_settlement_time"`
+	ConfidenceScore         float64           `json:"confidence_score"`
+	Reasoning               string            `json:"reasoning,omitempty"`
+	Fallback                bool              `json:"fallback"` // The engine's static fallback fees rather than a model recommendation
+	PromptVersion           string            `json:"prompt_version,omitempty"`
+}
+
+// QuoteDelta summarises how the AI routing differs from the static quote
+type QuoteDelta struct {
+	Fee          int64   `json:"fee"`           // AI total fee minus static total fees
+	FeePercent   float64 `json:"fee_percent"`   // Fee as a percentage of the static total fees
+	ChainDiffers bool    `json:"chain_differs"` // The AI settles on a different chain than the static quote
+	Cheaper      string  `json:"cheaper"`       // CheaperStatic, CheaperAI or CheaperEqual
+}
+
+// Compare builds the comparison of a quote against the AI engine's fees for the same payment
+func Compare(quote *Quote, ai *fees.AIFeeResponse) *QuoteComparison {
+	comparison := &QuoteComparison{
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Amount:       quote.Amount,
+		FeeCurrency:  quote.FromCurrency,
+		Static: StaticRoute{
+			PlatformFee:          quote.PlatformFee,
+			OnrampFee:            quote.OnrampFee,
+			OfframpFee:           quote.OfframpFee,
+			RegulatoryFee:        quote.RegulatoryFee,
+			TotalFees:            quote.TotalFees,
+			ExchangeRate:         quote.ExchangeRate,
+			GuaranteedPayout:     quote.GuaranteedPayout,
+			SettlementChain:      quote.SettlementChain,
+			OffRampChain:         quote.OffRampChain,
+			SettlementSLASeconds: quote.SettlementSLASeconds,
+		},
+		AI: AIRoute{
+			FeeBreakdown:            ai.FeeBreakdown,
+			TotalFee:                ai.TotalFee,
+			Onramp:                  ai.Provider.Onramp,
+			Offramp:                 ai.Provider.Offramp,
+			SettlementChain:         ai.Provider.Chain,
+			EstimatedSettlementTime: ai.EstimatedSettlementTime,
+			ConfidenceScore:         ai.ConfidenceScore,
+			Reasoning:               ai.Provider.Reasoning,
+			Fallback:                ai.Fallback,
+			PromptVersion:           ai.PromptVersion,
+		},
+	}
+
+	delta := &comparison.Delta
+	delta.Fee = ai.TotalFee - quote.TotalFees
+	if quote.TotalFees != 0 {
+		delta.FeePercent = float64(delta.Fee) / float64(quote.TotalFees) * 100
+	}
+	// The model names chains for display ("Base"); corridors name them in lower case ("base")
+	delta.ChainDiffers = quote.SettlementChain != "" && ai.Provider.Chain != "" && !strings.EqualFold(quote.SettlementChain, ai.Provider.Chain)
+	switch {
+	case delta.Fee < 0:
+		delta.Cheaper = CheaperAI
+	case delta.Fee > 0:
+		delta.Cheaper = CheaperStatic
+	default:
+		delta.Cheaper = CheaperEqual
+	}
+	return comparison
+}
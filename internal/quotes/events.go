@@ -0,0 +1,93 @@
+package quotes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"crypto-conversion/internal/models"
+)
+
+// Webhook event types emitted from the quotes table stream
+const (
+	WebhookQuoteExpired  = "quote.expired"
+	WebhookQuoteConsumed = "quote.consumed"
+)
+
+// ttlPrincipal is the stream user identity DynamoDB records on items removed by TTL
+const ttlPrincipal = "dynamodb.amazonaws.com"
+
+// StreamWebhookEvent maps a quotes table stream record to the webhook it should emit, or nil
+// TTL removals of quotes no payment used become quote.expired; the update that first sets
+// consumed_at becomes quote.consumed. Inserts, manual deletes, and other updates emit nothing.
+// The stream must use NEW_AND_OLD_IMAGES.
+func StreamWebhookEvent(record events.DynamoDBEventRecord) *models.WebhookEvent {
+	change := record.Change
+	timestamp := change.ApproximateCreationDateTime.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	switch record.EventName {
+	case string(events.DynamoDBOperationTypeRemove):
+		if !isTTLDelete(record) || hasAttribute(change.OldImage, "consumed_at") {
+			return nil
+		}
+		return quoteWebhookEvent(WebhookQuoteExpired, change.OldImage, timestamp)
+	case string(events.DynamoDBOperationTypeModify):
+		if hasAttribute(change.OldImage, "consumed_at") || !hasAttribute(change.NewImage, "consumed_at") {
+			return nil
+		}
+		return quoteWebhookEvent(WebhookQuoteConsumed, change.NewImage, timestamp)
+	default:
+		return nil
+	}
+}
+
+// isTTLDelete reports whether DynamoDB's TTL process, rather than a caller, removed the item
+func isTTLDelete(record events.DynamoDBEventRecord) bool {
+	return record.UserIdentity != nil &&
+		record.UserIdentity.Type == "Service" &&
+		record.UserIdentity.PrincipalID == ttlPrincipal
+}
+
+// hasAttribute reports whether a stream image carries a non-null attribute
+func hasAttribute(image map[string]events.DynamoDBAttributeValue, name string) bool {
+	value, ok := image[name]
+	return ok && !value.IsNull()
+}
+
+// quoteWebhookEvent builds a quote webhook from a stream image
+func quoteWebhookEvent(eventType string, image map[string]events.DynamoDBAttributeValue, timestamp time.Time) *models.WebhookEvent {
+	event := &models.WebhookEvent{
+		SchemaVersion: models.WebhookEventSchemaVersion,
+		EventType:     eventType,
+		QuoteID:       stringAttribute(image, "quote_id"),
+		PaymentID:     stringAttribute(image, "payment_id"),
+		Currency:      stringAttribute(image, "from_currency"),
+		Timestamp:     timestamp,
+	}
+	event.Amount, _ = strconv.ParseInt(numberAttribute(image, "amount"), 10, 64)
+	if expiresAt, err := time.Parse(time.RFC3339Nano, stringAttribute(image, "expires_at")); err == nil {
+		event.ExpiresAt = &expiresAt
+	}
+	return event
+}
+
+// stringAttribute returns a string attribute from a stream image, or "" if absent
+func stringAttribute(image map[string]events.DynamoDBAttributeValue, name string) string {
+	value, ok := image[name]
+	if !ok || value.DataType() != events.DataTypeString {
+		return ""
+	}
+	return value.String()
+}
+
+// numberAttribute returns a number attribute from a stream image, or "" if absent
+func numberAttribute(image map[string]events.DynamoDBAttributeValue, name string) string {
+	value, ok := image[name]
+	if !ok || value.DataType() != events.DataTypeNumber {
+		return ""
+	}
+	return value.Number()
+}
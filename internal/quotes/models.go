@@ -1,6 +1,11 @@
 package quotes
 
-import "time"
+import (
+	"time"
+
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
 
 // Quote represents a locked-in exchange rate and fee quote
 type Quote struct {
@@ -19,6 +24,26 @@ type Quote struct {
 	ExpiresAt            time.Time `json:"expires_at" dynamodbav:"expires_at"`
 	ValidForSeconds      int       `json:"valid_for_seconds" dynamodbav:"valid_for_seconds"`
 	ProviderRate         string    `json:"provider_rate,omitempty" dynamodbav:"provider_rate,omitempty"` // Which provider gave best rate
+	ProviderQuoteID      string    `json:"provider_quote_id,omitempty" dynamodbav:"provider_quote_id,omitempty"` // Provider's executable quote reference
+	TTLPolicy            QuotePolicy `json:"ttl_policy" dynamodbav:"ttl_policy"` // Which rule set the validity window
+	ConsumedAt           *time.Time `json:"consumed_at,omitempty" dynamodbav:"consumed_at,omitempty"` // When a payment used the quote
+	PaymentID            string    `json:"payment_id,omitempty" dynamodbav:"payment_id,omitempty"` // Payment that consumed the quote
+	FeeScheduleID        string    `json:"fee_schedule_id,omitempty" dynamodbav:"fee_schedule_id,omitempty"` // Stored schedule that priced PlatformFee; empty for the built-in one
+	FeeScheduleVersion   int64     `json:"fee_schedule_version,omitempty" dynamodbav:"fee_schedule_version,omitempty"`
+	PricingCustomer      string    `json:"pricing_customer,omitempty" dynamodbav:"pricing_customer,omitempty"` // Customer whose negotiated pricing set PlatformFee
+	FeeDiscount          int64     `json:"fee_discount,omitempty" dynamodbav:"fee_discount,omitempty"`         // Negotiated discount already taken off PlatformFee
+	PromoCode            string    `json:"promo_code,omitempty" dynamodbav:"promo_code,omitempty"`
+	PromoDiscount        int64     `json:"promo_discount,omitempty" dynamodbav:"promo_discount,omitempty"` // Promo discount already taken off PlatformFee
+	FeeLimit             string    `json:"fee_limit,omitempty" dynamodbav:"fee_limit,omitempty"`           // "minimum" or "maximum" when the corridor's fee limit set PlatformFee
+	UnclampedPlatformFee int64     `json:"unclamped_platform_fee,omitempty" dynamodbav:"unclamped_platform_fee,omitempty"`
+	RegulatoryFee        int64     `json:"regulatory_fee,omitempty" dynamodbav:"regulatory_fee,omitempty"` // Corridor surcharges, on top of PlatformFee
+	Surcharges           []fees.SurchargeFee `json:"surcharges,omitempty" dynamodbav:"surcharges,omitempty"`
+	AIUsage              *models.AIUsage `json:"ai_usage,omitempty" dynamodbav:"ai_usage,omitempty"` // Claude spend on fee calculations for this quote
+	SettlementChain      string    `json:"settlement_chain,omitempty" dynamodbav:"settlement_chain,omitempty"` // Healthiest corridor chain when quoted; payments default to it
+	ChainHealthScore     float64   `json:"chain_health_score,omitempty" dynamodbav:"chain_health_score,omitempty"`
+	ChainHealthLevel     string    `json:"chain_health_level,omitempty" dynamodbav:"chain_health_level,omitempty"`
+	OffRampChain         string    `json:"off_ramp_chain,omitempty" dynamodbav:"off_ramp_chain,omitempty"` // Healthiest chain the off-ramp redeems on, when SettlementChain isn't one; bridged over CCTP
+	SettlementSLASeconds int       `json:"settlement_sla_seconds,omitempty" dynamodbav:"settlement_sla_seconds,omitempty"` // Settlement time promised on the quoted chains
 	TTL                  int64     `json:"-" dynamodbav:"ttl"` // DynamoDB TTL attribute (unix timestamp)
 }
 
@@ -27,6 +52,10 @@ type QuoteRequest struct {
 	FromCurrency string `json:"from_currency"`
 	ToCurrency   string `json:"to_currency"`
 	Amount       int64  `json:"amount"` // Amount in cents
+	CustomerTier string `json:"-"`      // Resolved from the caller's API key, never client-supplied
+	Customer     string `json:"-"`      // Caller's API key ID, for negotiated pricing
+	PromoCode    string `json:"promo_code,omitempty"`
+	Promo        *models.Promo `json:"-"` // PromoCode, looked up and checked by the caller
 }
 
 // QuoteResponse represents the API response for a quote
@@ -40,13 +69,24 @@ type QuoteResponse struct {
 	PayoutCurrency   string    `json:"payout_currency"`
 	ExpiresAt        time.Time `json:"expires_at"`
 	ValidForSeconds  int       `json:"valid_for_seconds"`
+	TTLPolicy        QuotePolicy `json:"ttl_policy"`
+	SettlementChain  string    `json:"settlement_chain,omitempty"`
+	ChainHealth      string    `json:"chain_health,omitempty"` // Settlement chain's health level
+	OffRampChain     string    `json:"off_ramp_chain,omitempty"` // Set when USDC is bridged to another chain for the off-ramp
+	SettlementSLASeconds int   `json:"settlement_sla_seconds,omitempty"` // Promised time from on-ramp initiation to payout settlement
 }
 
 // FeeDetail breaks down the fee structure
 type FeeDetail struct {
-	PlatformFee int64  `json:"platform_fee"`
-	OnrampFee   int64  `json:"onramp_fee"`
-	OfframpFee  int64  `json:"offramp_fee"`
-	TotalFees   int64  `json:"total_fees"`
-	Currency    string `json:"currency"` // USD for MVP
+	PlatformFee   int64               `json:"platform_fee"`
+	OnrampFee     int64               `json:"onramp_fee"`
+	OfframpFee    int64               `json:"offramp_fee"`
+	TotalFees     int64               `json:"total_fees"`
+	Currency      string              `json:"currency"` // Source currency
+	PromoCode     string              `json:"promo_code,omitempty"`
+	PromoDiscount int64               `json:"promo_discount,omitempty"` // Already taken off PlatformFee
+	FeeLimit      string              `json:"fee_limit,omitempty"`      // "minimum" or "maximum" when the corridor's limit set PlatformFee
+	UnclampedFee  int64               `json:"unclamped_fee,omitempty"`  // PlatformFee before the limit, and before any promo
+	RegulatoryFee int64               `json:"regulatory_fee,omitempty"` // Corridor surcharges, included in TotalFees
+	Surcharges    []fees.SurchargeFee `json:"surcharges,omitempty"`
 }
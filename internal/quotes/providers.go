@@ -0,0 +1,244 @@
+package quotes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/money"
+	"crypto-conversion/internal/tracing"
+)
+
+// Rate provider names, used in QUOTE_RATE_PROVIDERS and matched against a corridor's rate_providers
+const (
+	ProviderCircle   = "circle"
+	ProviderCoinbase = "coinbase"
+)
+
+// providerQuoteTimeout bounds each provider call so one slow provider can't stall quote creation
+const providerQuoteTimeout = 3 * time.Second
+
+// ProviderQuote is a rate offered by one provider for a corridor and amount
+type ProviderQuote struct {
+	Provider   string
+	QuoteID    string    // Provider's reference for an executable quote, if it issues one
+	Rate       float64   // Units of destination currency per unit of source currency
+	OnrampFee  int64     // In source minor units; only meaningful when FeesQuoted
+	OfframpFee int64     // In source minor units; only meaningful when FeesQuoted
+	FeesQuoted bool      // The provider priced its fees; otherwise the calculator estimates them
+	ExpiresAt  time.Time // When the provider stops honouring the rate (zero if not stated)
+}
+
+// RateProvider fetches executable exchange rates from a liquidity provider
+type RateProvider interface {
+	Name() string
+	Quote(ctx context.Context, corridor corridors.Corridor, amount int64) (*ProviderQuote, error)
+}
+
+// NewRateProviders builds rate providers from comma-separated names, e.g. "circle,coinbase"
+// An empty list returns nil, which makes the calculator use mock rates.
+func NewRateProviders(names, circleAPIURL, circleAPIKey string) ([]RateProvider, error) {
+	var providers []RateProvider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case ProviderCircle:
+			if circleAPIKey == "" {
+				return nil, fmt.Errorf("rate provider %s requires CIRCLE_API_KEY", ProviderCircle)
+			}
+			providers = append(providers, NewCircleRateProvider(circleAPIURL, circleAPIKey))
+		case ProviderCoinbase:
+			providers = append(providers, NewCoinbaseRateProvider())
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown rate provider: %s", name)
+		}
+	}
+	return providers, nil
+}
+
+// MockRateProvider simulates a provider quoting around the corridor's mid-market rate
+// Earlier providers in a corridor's list quote slightly better, mirroring typical liquidity.
+type MockRateProvider struct {
+	name string
+	rank int
+}
+
+// NewMockRateProvider creates a mock provider at the given position in a corridor's provider list
+func NewMockRateProvider(name string, rank int) *MockRateProvider {
+	return &MockRateProvider{name: name, rank: rank}
+}
+
+// Name implements RateProvider
+func (p *MockRateProvider) Name() string { return p.name }
+
+// Quote implements RateProvider
+func (p *MockRateProvider) Quote(ctx context.Context, corridor corridors.Corridor, amount int64) (*ProviderQuote, error) {
+	offset := 0.0005 - 0.0005*float64(p.rank)
+	return &ProviderQuote{
+		Provider: p.name,
+		Rate:     corridor.MidMarketRate + offset + (rand.Float64()-0.5)*0.005,
+	}, nil
+}
+
+// doJSON sends req and decodes a successful JSON response into dest
+func doJSON(ctx context.Context, client *http.Client, req *http.Request, dest interface{}) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+// CircleRateProvider requests tradable quotes from Circle's exchange API
+// Tradable quotes are executable until their expiry, so the quoted rate is what the payment settles at.
+type CircleRateProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewCircleRateProvider creates a Circle quote client
+func NewCircleRateProvider(baseURL, apiKey string) *CircleRateProvider {
+	return &CircleRateProvider{
+		client:  tracing.HTTPClient(&http.Client{Timeout: providerQuoteTimeout}),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Name implements RateProvider
+func (p *CircleRateProvider) Name() string { return "Circle" }
+
+// circleAmount is a currency amount in Circle's decimal-string format
+type circleAmount struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount,omitempty"`
+}
+
+// Quote implements RateProvider
+func (p *CircleRateProvider) Quote(ctx context.Context, corridor corridors.Corridor, amount int64) (*ProviderQuote, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"idempotencyKey": uuid.New().String(),
+		"type":           "tradable",
+		"from": circleAmount{
+			Currency: corridor.SourceCurrency,
+			Amount:   strconv.FormatFloat(money.ToMajor(amount, corridor.SourceCurrency), 'f', money.MinorUnits(corridor.SourceCurrency), 64),
+		},
+		"to": circleAmount{Currency: corridor.DestinationCurrency},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+"/v1/exchange/quotes", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	var response struct {
+		Data struct {
+			ID     string       `json:"id"`
+			Rate   float64      `json:"rate"`
+			From   circleAmount `json:"from"`
+			To     circleAmount `json:"to"`
+			Expiry time.Time    `json:"expiry"`
+		} `json:"data"`
+	}
+	if err := doJSON(ctx, p.client, req, &response); err != nil {
+		return nil, err
+	}
+	if response.Data.Rate <= 0 {
+		return nil, fmt.Errorf("invalid response: rate %v", response.Data.Rate)
+	}
+
+	return &ProviderQuote{
+		Provider:  p.Name(),
+		QuoteID:   response.Data.ID,
+		Rate:      response.Data.Rate,
+		ExpiresAt: response.Data.Expiry,
+	}, nil
+}
+
+// CoinbaseRateProvider prices a corridor from Coinbase's USDC buy and sell prices
+// The rate is what USDC sells for in the destination currency per unit of source currency
+// spent buying it, i.e. the executable round trip through USDC. Coinbase issues no quote ID.
+type CoinbaseRateProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewCoinbaseRateProvider creates a Coinbase price client
+func NewCoinbaseRateProvider() *CoinbaseRateProvider {
+	return &CoinbaseRateProvider{
+		client:  tracing.HTTPClient(&http.Client{Timeout: providerQuoteTimeout}),
+		baseURL: "https://api.coinbase.com",
+	}
+}
+
+// Name implements RateProvider
+func (p *CoinbaseRateProvider) Name() string { return "Coinbase" }
+
+// Quote implements RateProvider
+func (p *CoinbaseRateProvider) Quote(ctx context.Context, corridor corridors.Corridor, amount int64) (*ProviderQuote, error) {
+	buy, err := p.price(ctx, corridor.SourceCurrency, "buy")
+	if err != nil {
+		return nil, err
+	}
+	sell, err := p.price(ctx, corridor.DestinationCurrency, "sell")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProviderQuote{
+		Provider: p.Name(),
+		Rate:     sell / buy,
+	}, nil
+}
+
+// price returns the USDC buy or sell price in currency
+func (p *CoinbaseRateProvider) price(ctx context.Context, currency, side string) (float64, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/prices/USDC-%s/%s", p.baseURL, currency, side), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var response struct {
+		Data struct {
+			Amount string `json:"amount"`
+		} `json:"data"`
+	}
+	if err := doJSON(ctx, p.client, req, &response); err != nil {
+		return 0, err
+	}
+
+	price, err := strconv.ParseFloat(response.Data.Amount, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("invalid USDC-%s %s price %q", currency, side, response.Data.Amount)
+	}
+	return price, nil
+}
@@ -0,0 +1,98 @@
+package quotes
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/fx"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/money"
+)
+
+// RateSourceCorridor names the corridor's configured mid-market rate, used when no live FX rates are available
+const RateSourceCorridor = "corridor"
+
+// indicativeAmount is the amount, in major units of the source currency, rates are indicated for by default
+const indicativeAmount = 1000
+
+// MidRateSource supplies cached FX mid-market rates
+// Satisfied by fees.AIFeeCalculator.
+type MidRateSource interface {
+	FXRates(ctx context.Context) (*fx.Rates, error)
+}
+
+// IndicativeRate is the rate a quote would get now, against the market mid-rate, returned by GET /rates
+// It isn't binding: a quote locks a rate in.
+type IndicativeRate struct {
+	CorridorID    string    `json:"corridor_id"`
+	FromCurrency  string    `json:"from_currency"`
+	ToCurrency    string    `json:"to_currency"`
+	MidRate       float64   `json:"mid_rate"`        // Units of ToCurrency per unit of FromCurrency
+	MidRateSource string    `json:"mid_rate_source"` // The FX source, or RateSourceCorridor
+	MidRateAsOf   time.Time `json:"mid_rate_as_of"`  // When the mid-rate was fetched
+	Rate          float64   `json:"rate"`            // The best rate the corridor's providers offer
+	Provider      string    `json:"provider"`
+	Spread        float64   `json:"spread"` // How far Rate is below MidRate, as a fraction of MidRate
+	Amount        int64     `json:"amount"` // Source minor units the rate was indicated for
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// SetMidRateSource makes indicative rates compare against live FX mid-rates instead of the corridor's configured rate
+func (c *Calculator) SetMidRateSource(source MidRateSource) {
+	c.midRates = source
+}
+
+// IndicativeRate returns the rate from one currency to another against the mid-market rate
+// amount is in source minor units; 0 indicates the rate for 1,000 units of the source currency.
+func (c *Calculator) IndicativeRate(ctx context.Context, from, to string, amount int64) (*IndicativeRate, error) {
+	corridor, err := c.corridors.Lookup(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		amount = money.FromMajor(indicativeAmount, corridor.SourceCurrency)
+	}
+	if err := corridor.ValidateAmount(amount); err != nil {
+		return nil, err
+	}
+
+	best, _, err := c.fetchBestExchangeRate(ctx, corridor, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rate := &IndicativeRate{
+		CorridorID:    corridor.ID,
+		FromCurrency:  corridor.SourceCurrency,
+		ToCurrency:    corridor.DestinationCurrency,
+		MidRate:       corridor.MidMarketRate,
+		MidRateSource: RateSourceCorridor,
+		MidRateAsOf:   now,
+		Rate:          best.Rate,
+		Provider:      best.Provider,
+		Amount:        amount,
+		Timestamp:     now,
+	}
+	if c.midRates != nil {
+		// A failed fetch falls back to the configured rate rather than failing the request
+		rates, err := c.midRates.FXRates(ctx)
+		if err == nil {
+			var mid float64
+			if mid, err = rates.Rate(corridor.SourceCurrency, corridor.DestinationCurrency); err == nil {
+				rate.MidRate = mid
+				rate.MidRateSource = rates.Source
+				rate.MidRateAsOf = rates.FetchedAt
+			}
+		}
+		if err != nil {
+			logger.Warn("Live mid-rate unavailable, using corridor rate", logger.Fields{
+				"corridor": corridor.ID,
+				"error":    err.Error(),
+			})
+		}
+	}
+	rate.Spread = (rate.MidRate - rate.Rate) / rate.MidRate
+
+	return rate, nil
+}
@@ -0,0 +1,61 @@
+package quotes
+
+import "time"
+
+// DefaultQuoteTTL is how long a quote is honoured when no policy rule applies
+const DefaultQuoteTTL = 60 * time.Second
+
+// TTLPolicy decides how long a quote's locked rate is honoured
+// Tier rules take precedence over corridor rules; high FX volatility then caps the result.
+type TTLPolicy struct {
+	Default   time.Duration
+	Corridors map[string]time.Duration // Keyed by corridor ID, e.g. "USD-EUR"
+	Tiers     map[string]time.Duration // Keyed by customer tier, e.g. "enterprise"
+
+	// When the spread between provider rates exceeds VolatilityThreshold (as a fraction of the
+	// best rate), the TTL is capped at VolatileTTL to limit FX exposure. Zero disables the cap.
+	VolatilityThreshold float64
+	VolatileTTL         time.Duration
+}
+
+// QuotePolicy records which TTL rule produced a quote's validity window
+type QuotePolicy struct {
+	Rule             string  `json:"rule" dynamodbav:"rule"` // "default", "corridor:USD-EUR", "tier:enterprise", or "provider:Circle"
+	CustomerTier     string  `json:"customer_tier,omitempty" dynamodbav:"customer_tier,omitempty"`
+	VolatilityCapped bool    `json:"volatility_capped" dynamodbav:"volatility_capped"`
+	RateSpread       float64 `json:"rate_spread" dynamodbav:"rate_spread"` // Provider rate spread as a fraction of the best rate
+}
+
+// DefaultTTLPolicy returns the policy used when nothing is configured: a flat 60 seconds
+func DefaultTTLPolicy() TTLPolicy {
+	return TTLPolicy{Default: DefaultQuoteTTL}
+}
+
+// Resolve returns the TTL for a quote and the policy decision that produced it
+func (p TTLPolicy) Resolve(corridor, tier string, rateSpread float64) (time.Duration, QuotePolicy) {
+	ttl := p.Default
+	if ttl <= 0 {
+		ttl = DefaultQuoteTTL
+	}
+	policy := QuotePolicy{
+		Rule:         "default",
+		CustomerTier: tier,
+		RateSpread:   rateSpread,
+	}
+
+	if corridorTTL, ok := p.Corridors[corridor]; ok {
+		ttl = corridorTTL
+		policy.Rule = "corridor:" + corridor
+	}
+	if tierTTL, ok := p.Tiers[tier]; ok {
+		ttl = tierTTL
+		policy.Rule = "tier:" + tier
+	}
+
+	if p.VolatilityThreshold > 0 && p.VolatileTTL > 0 && rateSpread > p.VolatilityThreshold && ttl > p.VolatileTTL {
+		ttl = p.VolatileTTL
+		policy.VolatilityCapped = true
+	}
+
+	return ttl, policy
+}
@@ -0,0 +1,155 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/money"
+	"crypto-conversion/internal/tracing"
+)
+
+// circleProvider is the provider name recorded on Circle statement transactions and breaks
+const circleProvider = "circle"
+
+// circlePageSize is the largest page Circle's report endpoints return
+const circlePageSize = 50
+
+// CircleStatement reads Circle's business account deposit and payout reports
+// Deposits are the fiat our on-ramp transfers received; payouts are the fiat our off-ramp transfers sent.
+type CircleStatement struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewCircleStatement creates a Circle statement client
+func NewCircleStatement(baseURL, apiKey string) *CircleStatement {
+	return &CircleStatement{
+		client:  tracing.HTTPClient(&http.Client{Timeout: 30 * time.Second}),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Name implements StatementSource
+func (c *CircleStatement) Name() string { return circleProvider }
+
+// circleTransfer is one deposit or payout on a Circle report
+type circleTransfer struct {
+	ID     string `json:"id"`
+	Amount struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	} `json:"amount"`
+	Status     string    `json:"status"`
+	CreateDate time.Time `json:"createDate"`
+}
+
+// ListTransactions implements StatementSource
+func (c *CircleStatement) ListTransactions(ctx context.Context, from, until time.Time) ([]*models.StatementTransaction, error) {
+	deposits, err := c.list(ctx, "/v1/businessAccount/deposits", models.RampLegOnramp, from, until)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := c.list(ctx, "/v1/businessAccount/payouts", models.RampLegOfframp, from, until)
+	if err != nil {
+		return nil, err
+	}
+	return append(deposits, payouts...), nil
+}
+
+// list pages through one Circle report for the window
+func (c *CircleStatement) list(ctx context.Context, path, leg string, from, until time.Time) ([]*models.StatementTransaction, error) {
+	var txns []*models.StatementTransaction
+	pageAfter := ""
+	for {
+		query := url.Values{}
+		query.Set("from", from.UTC().Format(time.RFC3339))
+		query.Set("to", until.UTC().Format(time.RFC3339))
+		query.Set("pageSize", strconv.Itoa(circlePageSize))
+		if pageAfter != "" {
+			query.Set("pageAfter", pageAfter)
+		}
+
+		var response struct {
+			Data []circleTransfer `json:"data"`
+		}
+		if err := c.get(ctx, path+"?"+query.Encode(), &response); err != nil {
+			return nil, err
+		}
+
+		for _, transfer := range response.Data {
+			// Circle's to bound is inclusive; the window's isn't
+			if !transfer.CreateDate.Before(until) {
+				continue
+			}
+			amount, err := strconv.ParseFloat(transfer.Amount.Amount, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid amount %q on Circle transfer %s: %w", transfer.Amount.Amount, transfer.ID, err)
+			}
+			currency := strings.ToUpper(transfer.Amount.Currency)
+			txns = append(txns, &models.StatementTransaction{
+				Provider:  circleProvider,
+				Leg:       leg,
+				TxID:      transfer.ID,
+				Amount:    money.FromMajor(amount, currency),
+				Currency:  currency,
+				Status:    circleStatus(transfer.Status),
+				CreatedAt: transfer.CreateDate,
+			})
+		}
+
+		if len(response.Data) < circlePageSize {
+			return txns, nil
+		}
+		pageAfter = response.Data[len(response.Data)-1].ID
+	}
+}
+
+// get sends an authenticated GET and decodes the JSON response into dest
+func (c *CircleStatement) get(ctx context.Context, path string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+// circleStatus normalizes a Circle transfer status
+func circleStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "complete", "paid":
+		return models.StatementStatusComplete
+	case "failed", "returned":
+		return models.StatementStatusFailed
+	default:
+		return models.StatementStatusPending
+	}
+}
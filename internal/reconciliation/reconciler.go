@@ -0,0 +1,202 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/money"
+)
+
+// StatementSource lists the transfers a ramp provider reports for a period
+type StatementSource interface {
+	Name() string
+	ListTransactions(ctx context.Context, from, until time.Time) ([]*models.StatementTransaction, error)
+}
+
+// Reconciler matches a provider's statement against the ramp transfers our payments made and records the breaks
+type Reconciler struct {
+	source   StatementSource
+	payments database.PaymentRepository
+	breaks   database.ReconciliationRepository
+	lookback time.Duration
+}
+
+// NewReconciler creates a reconciler
+// Payments created up to lookback before a statement window are matched, since a transfer can settle days after
+// its payment was created.
+func NewReconciler(source StatementSource, payments database.PaymentRepository, breaks database.ReconciliationRepository, lookback time.Duration) *Reconciler {
+	return &Reconciler{
+		source:   source,
+		payments: payments,
+		breaks:   breaks,
+		lookback: lookback,
+	}
+}
+
+// Run reconciles the provider's statement for [from, until)
+// Breaks are keyed by provider, transfer and kind, so re-running a window records only what is new and never
+// reopens a break an operator resolved.
+func (r *Reconciler) Run(ctx context.Context, from, until time.Time) (*models.ReconciliationReport, error) {
+	statement, err := r.source.ListTransactions(ctx, from, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s statement: %w", r.source.Name(), err)
+	}
+
+	transfers, err := r.payments.ListRampTransfers(ctx, from.Add(-r.lookback), until)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	report := &models.ReconciliationReport{
+		Provider:     r.source.Name(),
+		From:         from,
+		Until:        until,
+		Statement:    len(statement),
+		Transfers:    len(transfers),
+		ReconciledAt: now,
+	}
+	report.Breaks = Match(r.source.Name(), statement, transfers, from, until, now)
+	report.Matched = countMatched(statement, transfers)
+
+	for _, brk := range report.Breaks {
+		recorded, err := r.breaks.RecordBreak(ctx, brk)
+		if err != nil {
+			return nil, err
+		}
+		if !recorded {
+			continue
+		}
+		report.NewBreaks++
+		metrics.Count("ReconciliationBreaks", metrics.Dimensions{"Kind": brk.Kind})
+		logger.Warn("Reconciliation break", logger.Fields{
+			"break_id":   brk.BreakID,
+			"kind":       brk.Kind,
+			"payment_id": brk.PaymentID,
+			"detail":     brk.Detail,
+		})
+	}
+
+	if report.NewBreaks > 0 {
+		// Operators alarm on this log line via a CloudWatch metric filter
+		logger.Error("ALERT: reconciliation found new breaks", logger.Fields{
+			"alert":      "reconciliation_breaks",
+			"provider":   report.Provider,
+			"from":       from,
+			"until":      until,
+			"new_breaks": report.NewBreaks,
+		})
+	}
+	logger.Info("Reconciliation complete", logger.Fields{
+		"provider":   report.Provider,
+		"from":       from,
+		"until":      until,
+		"statement":  report.Statement,
+		"transfers":  report.Transfers,
+		"matched":    report.Matched,
+		"breaks":     len(report.Breaks),
+		"new_breaks": report.NewBreaks,
+	})
+
+	return report, nil
+}
+
+// Match compares a provider statement for [from, until) with our transfers and returns the breaks between them
+// Pending statement transactions are only used to tell that the provider knows a transfer; whether it settled
+// is checked once they complete or fail.
+func Match(provider string, statement []*models.StatementTransaction, transfers []*models.RampTransfer, from, until, detectedAt time.Time) []*models.ReconciliationBreak {
+	ours := make(map[string]*models.RampTransfer, len(transfers))
+	for _, transfer := range transfers {
+		ours[transfer.TxID] = transfer
+	}
+
+	var breaks []*models.ReconciliationBreak
+	reported := make(map[string]bool, len(statement))
+	for _, txn := range statement {
+		reported[txn.TxID] = true
+		transfer, ok := ours[txn.TxID]
+		if !ok {
+			if txn.Status == models.StatementStatusComplete {
+				breaks = append(breaks, newBreak(provider, models.BreakUnknownTransfer, txn.Leg, txn.TxID, nil, txn, detectedAt,
+					fmt.Sprintf("%s reports a %s of %s that no payment made", provider, txn.Leg, money.Format(txn.Amount, txn.Currency))))
+			}
+			continue
+		}
+		if txn.Status == models.StatementStatusPending {
+			continue
+		}
+
+		if txn.Amount != transfer.Amount || !strings.EqualFold(txn.Currency, transfer.Currency) {
+			breaks = append(breaks, newBreak(provider, models.BreakAmountMismatch, transfer.Leg, txn.TxID, transfer, txn, detectedAt,
+				fmt.Sprintf("%s moved %s, the payment expected %s", provider,
+					money.Format(txn.Amount, txn.Currency), money.Format(transfer.Amount, transfer.Currency))))
+		}
+
+		switch {
+		case txn.Status == models.StatementStatusFailed && transfer.SettledAt != nil:
+			breaks = append(breaks, newBreak(provider, models.BreakStatusMismatch, transfer.Leg, txn.TxID, transfer, txn, detectedAt,
+				fmt.Sprintf("%s reports the %s failed, the payment saw it settle", provider, transfer.Leg)))
+		case txn.Status == models.StatementStatusComplete && transfer.SettledAt == nil && transfer.Status.IsTerminal():
+			breaks = append(breaks, newBreak(provider, models.BreakStatusMismatch, transfer.Leg, txn.TxID, transfer, txn, detectedAt,
+				fmt.Sprintf("%s reports the %s complete, the payment ended %s without it settling", provider, transfer.Leg, transfer.Status)))
+		}
+	}
+
+	for _, transfer := range transfers {
+		if reported[transfer.TxID] || transfer.SettledAt == nil {
+			continue
+		}
+		if transfer.SettledAt.Before(from) || !transfer.SettledAt.Before(until) {
+			continue
+		}
+		breaks = append(breaks, newBreak(provider, models.BreakMissingFromStatement, transfer.Leg, transfer.TxID, transfer, nil, detectedAt,
+			fmt.Sprintf("The payment saw the %s settle, %s doesn't report it", transfer.Leg, provider)))
+	}
+
+	return breaks
+}
+
+// countMatched counts the statement transactions made by one of our transfers
+func countMatched(statement []*models.StatementTransaction, transfers []*models.RampTransfer) int {
+	ours := make(map[string]bool, len(transfers))
+	for _, transfer := range transfers {
+		ours[transfer.TxID] = true
+	}
+	matched := 0
+	for _, txn := range statement {
+		if ours[txn.TxID] {
+			matched++
+		}
+	}
+	return matched
+}
+
+// newBreak builds an open break; transfer is nil for unknown transfers and txn is nil for missing ones
+func newBreak(provider, kind, leg, txID string, transfer *models.RampTransfer, txn *models.StatementTransaction, detectedAt time.Time, detail string) *models.ReconciliationBreak {
+	brk := &models.ReconciliationBreak{
+		BreakID:    models.ReconciliationBreakID(provider, txID, kind),
+		Kind:       kind,
+		Provider:   provider,
+		Leg:        leg,
+		TxID:       txID,
+		Detail:     detail,
+		Status:     models.BreakStatusOpen,
+		DetectedAt: detectedAt,
+	}
+	if transfer != nil {
+		brk.PaymentID = transfer.PaymentID
+		brk.ExpectedAmount = transfer.Amount
+		brk.Currency = transfer.Currency
+	}
+	if txn != nil {
+		brk.ActualAmount = txn.Amount
+		brk.Currency = strings.ToUpper(txn.Currency)
+	}
+	return brk
+}
@@ -0,0 +1,173 @@
+package reporting
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// Exporter publishes a day's revenue report for finance
+type Exporter interface {
+	ExportRevenueReport(ctx context.Context, reportDate string, reports []*models.RevenueReport) error
+}
+
+// Reporter builds the daily revenue report from fee invoices, stores it and exports it
+type Reporter struct {
+	invoices database.FeeInvoiceRepository
+	payments database.PaymentRepository
+	ledger   database.LedgerRepository // Source of gas spend; nil reports none
+	reports  database.RevenueReportRepository
+	exporter Exporter
+}
+
+// NewReporter creates a revenue reporter
+// Invoices are checked against their payments, so only completed payments count as revenue.
+func NewReporter(invoices database.FeeInvoiceRepository, payments database.PaymentRepository, reports database.RevenueReportRepository, exporter Exporter) *Reporter {
+	return &Reporter{
+		invoices: invoices,
+		payments: payments,
+		reports:  reports,
+		exporter: exporter,
+	}
+}
+
+// EnableLedger reports each payment's gas spend from its ledger postings
+func (r *Reporter) EnableLedger(ledger database.LedgerRepository) {
+	r.ledger = ledger
+}
+
+// Run reports the UTC day containing day
+// Re-running a day rebuilds its rows from the invoices and overwrites both the stored rows and the export,
+// so a day can be backfilled after late invoices.
+func (r *Reporter) Run(ctx context.Context, day time.Time) ([]*models.RevenueReport, error) {
+	since := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	until := since.AddDate(0, 0, 1)
+	reportDate := since.Format(models.RevenueReportDateFormat)
+
+	issued, err := r.invoices.ListFeeInvoices(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := r.completedInvoices(ctx, issued)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := r.gasSpend(ctx, invoices)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := BuildRevenueReport(reportDate, invoices, gas, time.Now().UTC())
+
+	if err := r.reports.PutRevenueReports(ctx, reports); err != nil {
+		return nil, err
+	}
+	if err := r.exporter.ExportRevenueReport(ctx, reportDate, reports); err != nil {
+		return nil, err
+	}
+
+	metrics.Emit("RevenueReportRows", float64(len(reports)), metrics.UnitCount, metrics.Dimensions{})
+	logger.Info("Revenue report complete", logger.Fields{
+		"report_date": reportDate,
+		"invoices":    len(invoices),
+		"excluded":    len(issued) - len(invoices),
+		"rows":        len(reports),
+	})
+
+	return reports, nil
+}
+
+// completedInvoices drops invoices whose payment didn't complete or was a sandbox payment
+// Reversed payments were invoiced before their funds were known to be returned; they earned nothing.
+func (r *Reporter) completedInvoices(ctx context.Context, invoices []*models.FeeInvoice) ([]*models.FeeInvoice, error) {
+	completed := make([]*models.FeeInvoice, 0, len(invoices))
+	for _, invoice := range invoices {
+		payment, err := r.payments.GetPaymentByID(ctx, invoice.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		if payment.Sandbox {
+			continue
+		}
+		if payment.Status != models.StatusCompleted {
+			logger.Warn("Fee invoice excluded from revenue", logger.Fields{
+				"payment_id": invoice.PaymentID,
+				"status":     payment.Status,
+			})
+			continue
+		}
+		completed = append(completed, invoice)
+	}
+	return completed, nil
+}
+
+// gasSpend returns each invoiced payment's gas expense posted to the ledger, in USDC minor units
+func (r *Reporter) gasSpend(ctx context.Context, invoices []*models.FeeInvoice) (map[string]int64, error) {
+	gas := make(map[string]int64)
+	if r.ledger == nil {
+		return gas, nil
+	}
+
+	for _, invoice := range invoices {
+		txns, err := r.ledger.ListLedgerTransactions(ctx, invoice.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, txn := range txns {
+			for _, entry := range txn.Entries {
+				if entry.AccountType() == models.LedgerGasExpense && entry.Side == models.LedgerDebit {
+					gas[invoice.PaymentID] += entry.Amount
+				}
+			}
+		}
+	}
+	return gas, nil
+}
+
+// BuildRevenueReport aggregates fee invoices into one row per corridor and customer, ordered by corridor then customer
+// gas is each payment's gas spend, keyed by payment ID.
+func BuildRevenueReport(reportDate string, invoices []*models.FeeInvoice, gas map[string]int64, generatedAt time.Time) []*models.RevenueReport {
+	rows := make(map[string]*models.RevenueReport)
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		corridor := corridors.Key(invoice.Currency, invoice.PayoutCurrency)
+		key := corridor + "|" + invoice.CustomerID
+		row, ok := rows[key]
+		if !ok {
+			row = &models.RevenueReport{
+				ReportDate:  reportDate,
+				Corridor:    corridor,
+				CustomerID:  invoice.CustomerID,
+				Currency:    invoice.Currency,
+				GeneratedAt: generatedAt,
+			}
+			rows[key] = row
+		}
+		row.Payments++
+		row.Volume += invoice.Amount
+		row.PlatformRevenue += invoice.PlatformFee
+		row.RegulatoryFees += invoice.RegulatoryFee
+		row.ProviderCosts += invoice.OnrampFee + invoice.OfframpFee
+		row.GasSpend += gas[invoice.PaymentID]
+	}
+
+	reports := make([]*models.RevenueReport, 0, len(rows))
+	for _, row := range rows {
+		reports = append(reports, row)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Corridor != reports[j].Corridor {
+			return reports[i].Corridor < reports[j].Corridor
+		}
+		return reports[i].CustomerID < reports[j].CustomerID
+	})
+	return reports
+}
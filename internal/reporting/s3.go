@@ -0,0 +1,205 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// csvHeader is the column order of the revenue report export
+var csvHeader = []string{
+	"report_date", "corridor", "customer_id", "currency", "payments", "volume",
+	"platform_revenue", "regulatory_fees", "provider_costs", "gas_spend", "generated_at",
+}
+
+// S3Exporter writes each day's revenue report and each month's usage to S3 as CSV objects, and keeps each day's settlement report as JSON
+type S3Exporter struct {
+	svc    *s3.S3
+	bucket string
+	prefix string
+}
+
+// NewS3Exporter creates a new S3 revenue report exporter
+func NewS3Exporter(region, bucket, prefix string) (*S3Exporter, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Exporter{
+		svc:    s3.New(tracing.AWSSession(sess)),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// key returns the object key for a day's report
+// One object per day, so re-running a day replaces its export
+func (e *S3Exporter) key(reportDate string) string {
+	return fmt.Sprintf("%srevenue/%s.csv", e.prefix, reportDate)
+}
+
+// ExportRevenueReport writes a day's report rows to S3
+func (e *S3Exporter) ExportRevenueReport(ctx context.Context, reportDate string, reports []*models.RevenueReport) error {
+	body, err := EncodeRevenueCSV(reports)
+	if err != nil {
+		return errors.ErrDatabaseOperation("report_encode", err)
+	}
+
+	_, err = e.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(e.bucket),
+		Key:         aws.String(e.key(reportDate)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("text/csv"),
+	})
+	if err != nil {
+		logger.Error("Failed to export revenue report", logger.Fields{
+			"error":       err.Error(),
+			"report_date": reportDate,
+		})
+		return errors.ErrDatabaseOperation("report_put", err)
+	}
+
+	return nil
+}
+
+// usageKey returns the object key for a month's usage export
+func (e *S3Exporter) usageKey(period string) string {
+	return fmt.Sprintf("%susage/%s.csv", e.prefix, period)
+}
+
+// ExportUsageReport writes a month's usage counters to S3, replacing any earlier run
+func (e *S3Exporter) ExportUsageReport(ctx context.Context, period string, records []*models.UsageRecord) error {
+	body, err := EncodeUsageCSV(records)
+	if err != nil {
+		return errors.ErrDatabaseOperation("report_encode", err)
+	}
+
+	_, err = e.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(e.bucket),
+		Key:         aws.String(e.usageKey(period)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("text/csv"),
+	})
+	if err != nil {
+		logger.Error("Failed to export usage report", logger.Fields{
+			"error":  err.Error(),
+			"period": period,
+		})
+		return errors.ErrDatabaseOperation("report_put", err)
+	}
+
+	return nil
+}
+
+// settlementKey returns the object key for a day's settlement report
+func (e *S3Exporter) settlementKey(reportDate string) string {
+	return fmt.Sprintf("%ssettlements/%s.json", e.prefix, reportDate)
+}
+
+// PutSettlementReport writes a day's settlement report rows to S3, replacing any earlier run
+func (e *S3Exporter) PutSettlementReport(ctx context.Context, reportDate string, reports []*models.SettlementReport) error {
+	body, err := json.Marshal(reports)
+	if err != nil {
+		return errors.ErrDatabaseOperation("report_encode", err)
+	}
+
+	_, err = e.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(e.bucket),
+		Key:         aws.String(e.settlementKey(reportDate)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		logger.Error("Failed to store settlement report", logger.Fields{
+			"error":       err.Error(),
+			"report_date": reportDate,
+		})
+		return errors.ErrDatabaseOperation("report_put", err)
+	}
+
+	return nil
+}
+
+// GetSettlementReport reads a day's settlement report rows from S3
+// Returns nil, nil if the day hasn't been reported
+func (e *S3Exporter) GetSettlementReport(ctx context.Context, reportDate string) ([]*models.SettlementReport, error) {
+	result, err := e.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(e.bucket),
+		Key:    aws.String(e.settlementKey(reportDate)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, nil
+		}
+		logger.Error("Failed to read settlement report", logger.Fields{
+			"error":       err.Error(),
+			"report_date": reportDate,
+		})
+		return nil, errors.ErrDatabaseOperation("report_get", err)
+	}
+	defer result.Body.Close()
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, errors.ErrDatabaseOperation("report_read", err)
+	}
+
+	reports := []*models.SettlementReport{}
+	if err := json.Unmarshal(body, &reports); err != nil {
+		return nil, errors.ErrDatabaseOperation("report_unmarshal", err)
+	}
+
+	return reports, nil
+}
+
+// EncodeRevenueCSV renders report rows as CSV with a header row
+// Amounts stay in minor units, as stored
+func EncodeRevenueCSV(reports []*models.RevenueReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		record := []string{
+			report.ReportDate,
+			report.Corridor,
+			report.CustomerID,
+			report.Currency,
+			strconv.Itoa(report.Payments),
+			strconv.FormatInt(report.Volume, 10),
+			strconv.FormatInt(report.PlatformRevenue, 10),
+			strconv.FormatInt(report.RegulatoryFees, 10),
+			strconv.FormatInt(report.ProviderCosts, 10),
+			strconv.FormatInt(report.GasSpend, 10),
+			report.GeneratedAt.UTC().Format(time.RFC3339),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package reporting
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// SettlementReportStore keeps each day's settlement report for the internal API to serve
+type SettlementReportStore interface {
+	PutSettlementReport(ctx context.Context, reportDate string, reports []*models.SettlementReport) error
+	GetSettlementReport(ctx context.Context, reportDate string) ([]*models.SettlementReport, error)
+}
+
+// SettlementReporter builds the daily settlement report from completed payments and stores it
+type SettlementReporter struct {
+	payments database.PaymentRepository
+	store    SettlementReportStore
+}
+
+// NewSettlementReporter creates a settlement reporter
+func NewSettlementReporter(payments database.PaymentRepository, store SettlementReportStore) *SettlementReporter {
+	return &SettlementReporter{
+		payments: payments,
+		store:    store,
+	}
+}
+
+// Run reports the UTC day containing day
+// Re-running a day rebuilds its rows from the payments and replaces the stored report.
+func (r *SettlementReporter) Run(ctx context.Context, day time.Time) ([]*models.SettlementReport, error) {
+	since := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	until := since.AddDate(0, 0, 1)
+	reportDate := since.Format(models.RevenueReportDateFormat)
+
+	payments, err := r.payments.ListCompletedPayments(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := BuildSettlementReport(reportDate, payments, time.Now().UTC())
+
+	if err := r.store.PutSettlementReport(ctx, reportDate, reports); err != nil {
+		return nil, err
+	}
+
+	metrics.Emit("SettlementReportRows", float64(len(reports)), metrics.UnitCount, metrics.Dimensions{})
+	logger.Info("Settlement report complete", logger.Fields{
+		"report_date": reportDate,
+		"payments":    len(payments),
+		"rows":        len(reports),
+	})
+
+	return reports, nil
+}
+
+// BuildSettlementReport aggregates completed payments into one row per corridor and chain, ordered by corridor then chain
+// Sandbox payments moved no funds and are left out.
+func BuildSettlementReport(reportDate string, payments []*models.Payment, generatedAt time.Time) []*models.SettlementReport {
+	rows := make(map[string]*models.SettlementReport)
+	settlementTime := make(map[string]time.Duration)
+	for _, payment := range payments {
+		if payment == nil || payment.ProcessedAt == nil || payment.Sandbox {
+			continue
+		}
+		corridor := corridors.Key(payment.FundingCurrency(), payment.Currency)
+		key := corridor + "|" + payment.Chain
+		row, ok := rows[key]
+		if !ok {
+			row = &models.SettlementReport{
+				ReportDate:     reportDate,
+				Corridor:       corridor,
+				Chain:          payment.Chain,
+				Currency:       strings.ToUpper(payment.FundingCurrency()),
+				PayoutCurrency: strings.ToUpper(payment.Currency),
+				GeneratedAt:    generatedAt,
+			}
+			rows[key] = row
+		}
+		row.Payments++
+		row.GrossVolume += payment.Amount
+		row.Fees += payment.FeeAmount
+		row.ProviderFees += payment.OnrampFee + payment.OfframpFee
+		row.NetPayouts += payment.PayoutAmount()
+		settlementTime[key] += payment.ProcessedAt.Sub(payment.CreatedAt)
+	}
+
+	reports := make([]*models.SettlementReport, 0, len(rows))
+	for key, row := range rows {
+		row.AvgSettlementSeconds = settlementTime[key].Seconds() / float64(row.Payments)
+		reports = append(reports, row)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Corridor != reports[j].Corridor {
+			return reports[i].Corridor < reports[j].Corridor
+		}
+		return reports[i].Chain < reports[j].Chain
+	})
+	return reports
+}
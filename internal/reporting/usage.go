@@ -0,0 +1,92 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// usageCSVHeader is the column order of the usage export
+var usageCSVHeader = []string{"period", "customer_id", "metric", "currency", "quantity"}
+
+// UsageExporter publishes a month's usage for billing
+type UsageExporter interface {
+	ExportUsageReport(ctx context.Context, period string, records []*models.UsageRecord) error
+}
+
+// UsageReporter exports each month's metered usage per customer
+type UsageReporter struct {
+	usage    database.UsageRepository
+	exporter UsageExporter
+}
+
+// NewUsageReporter creates a usage reporter
+func NewUsageReporter(usage database.UsageRepository, exporter UsageExporter) *UsageReporter {
+	return &UsageReporter{
+		usage:    usage,
+		exporter: exporter,
+	}
+}
+
+// Run exports the UTC month containing month
+// Re-running a month replaces its export, so a month still being metered can be exported again once it closes.
+func (r *UsageReporter) Run(ctx context.Context, month time.Time) ([]*models.UsageRecord, error) {
+	period := models.UsagePeriod(month)
+
+	records, err := r.usage.ListPeriodUsage(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.exporter.ExportUsageReport(ctx, period, records); err != nil {
+		return nil, err
+	}
+
+	customers := make(map[string]bool)
+	for _, record := range records {
+		customers[record.CustomerID] = true
+	}
+	metrics.Emit("UsageReportRows", float64(len(records)), metrics.UnitCount, metrics.Dimensions{})
+	logger.Info("Usage report complete", logger.Fields{
+		"period":    period,
+		"customers": len(customers),
+		"rows":      len(records),
+	})
+
+	return records, nil
+}
+
+// EncodeUsageCSV renders usage counters as CSV with a header row
+// Payment volume stays in minor units of its currency, as metered
+func EncodeUsageCSV(records []*models.UsageRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(usageCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		row := []string{
+			record.Period,
+			record.CustomerID,
+			record.Metric,
+			record.Currency,
+			strconv.FormatInt(record.Quantity, 10),
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
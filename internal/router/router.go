@@ -0,0 +1,195 @@
+// Package router dispatches API Gateway requests by method and path pattern
+// Patterns are paths whose {name} segments match any one segment and are passed to the handler
+// as path parameters, e.g. /payments/{payment_id}/events. Routes are registered on an API version,
+// served under its /vN prefix; the default version also serves unprefixed paths, as the API did
+// before it was versioned. A version based on another serves every route it doesn't replace,
+// so a breaking change ships under /v2 by registering only the changed routes.
+package router
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// HandlerFunc handles a routed request; its PathParameters hold the pattern's {name} segments
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Route is a registered method and pattern
+type Route struct {
+	Method  string
+	Pattern string // e.g. /payments/{payment_id}
+	Version string // The version the route was registered on
+	handler HandlerFunc
+	parts   []string
+}
+
+// Match is the route a request resolved to
+type Match struct {
+	Route   *Route
+	Version string            // The version the request asked for, e.g. v1 for /v1/payments and unprefixed paths
+	Params  map[string]string // Values of the pattern's {name} segments
+}
+
+// Handler returns the matched route's handler
+func (m *Match) Handler() HandlerFunc {
+	return m.Route.handler
+}
+
+// Version is one API version's routes
+type Version struct {
+	name   string
+	base   *Version
+	routes []*Route
+}
+
+// Handle registers the handler of method on pattern
+func (v *Version) Handle(method, pattern string, handler HandlerFunc) {
+	v.routes = append(v.routes, &Route{
+		Method:  method,
+		Pattern: pattern,
+		Version: v.name,
+		handler: handler,
+		parts:   split(pattern),
+	})
+}
+
+// Router matches requests to the routes of their API version
+type Router struct {
+	versions map[string]*Version
+	fallback *Version
+}
+
+// New creates a router whose unprefixed paths are served by the default version
+func New(defaultVersion string) *Router {
+	v := &Version{name: defaultVersion}
+	return &Router{versions: map[string]*Version{defaultVersion: v}, fallback: v}
+}
+
+// Version returns the routes of the default version or of one added with AddVersion
+func (r *Router) Version(name string) *Version {
+	return r.versions[name]
+}
+
+// AddVersion adds a version that serves base's routes unless it registers its own for a method and pattern
+func (r *Router) AddVersion(name string, base *Version) *Version {
+	v := &Version{name: name, base: base}
+	r.versions[name] = v
+	return v
+}
+
+// Match resolves a request's method and path
+// A path that matches no route with any method returns found false; one that matches only
+// with other methods returns them as allowed, sorted, for a 405's Allow header.
+func (r *Router) Match(method, path string) (match *Match, allowed []string, found bool) {
+	parts := split(path)
+	version := r.fallback
+	if len(parts) > 0 {
+		if v, ok := r.versions[parts[0]]; ok {
+			version, parts = v, parts[1:]
+		}
+	}
+
+	methods := map[string]bool{}
+	for v := version; v != nil; v = v.base {
+		var best *Route
+		var bestParams map[string]string
+		for _, route := range v.routes {
+			params, ok := route.match(parts)
+			if !ok {
+				continue
+			}
+			if route.Method != method {
+				methods[route.Method] = true
+				continue
+			}
+			if best == nil || route.literals() > best.literals() {
+				best, bestParams = route, params
+			}
+		}
+		if best != nil {
+			return &Match{Route: best, Version: version.name, Params: bestParams}, nil, true
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, false
+	}
+	for m := range methods {
+		allowed = append(allowed, m)
+	}
+	sort.Strings(allowed)
+	return nil, allowed, true
+}
+
+// Routes lists every route of every version, for documentation and tests
+func (r *Router) Routes() []*Route {
+	var routes []*Route
+	for _, v := range r.versions {
+		routes = append(routes, v.routes...)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Version != routes[j].Version {
+			return routes[i].Version < routes[j].Version
+		}
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// match reports whether the route's pattern matches the path's segments, returning its parameters
+func (route *Route) match(parts []string) (map[string]string, bool) {
+	if len(parts) != len(route.parts) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, part := range route.parts {
+		if name, ok := param(part); ok {
+			if parts[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[name] = parts[i]
+			continue
+		}
+		if part != parts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// literals counts the pattern's fixed segments; when patterns overlap the most specific wins
+func (route *Route) literals() int {
+	n := 0
+	for _, part := range route.parts {
+		if _, ok := param(part); !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// param returns the name of a {name} segment
+func param(part string) (string, bool) {
+	if len(part) > 2 && strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+		return part[1 : len(part)-1], true
+	}
+	return "", false
+}
+
+// split returns a path's segments, ignoring leading and trailing slashes
+func split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,135 @@
+// Package schema decodes the messages Lambdas pass each other through SQS and the outbox
+// A deployment rolls Lambdas one at a time, so a consumer can receive a message written by a producer
+// a version behind or ahead of it. Older messages are upgraded to the current schema before they are
+// decoded; newer ones are decoded as far as this version understands them rather than dropped.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// LegacyVersion is the version of a message written before messages carried a schema_version
+const LegacyVersion = 1
+
+// versionField is the field a message's schema version is written in
+const versionField = "schema_version"
+
+// upgrade rewrites a message's fields from one schema version to the next
+type upgrade func(fields map[string]json.RawMessage) error
+
+// message describes one kind of message: its current version and how each older version upgrades
+type message struct {
+	name     string
+	current  int
+	upgrades map[int]upgrade // Version -> upgrade to the version after it; a version with no field changes has none
+}
+
+var (
+	paymentJob = message{
+		name:    "PaymentJob",
+		current: models.PaymentJobSchemaVersion,
+	}
+	webhookEvent = message{
+		name:    "WebhookEvent",
+		current: models.WebhookEventSchemaVersion,
+	}
+)
+
+// DecodePaymentJob decodes a payment job of any schema version
+func DecodePaymentJob(data []byte) (*models.PaymentJob, error) {
+	var job models.PaymentJob
+	if err := paymentJob.decode(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// DecodePaymentJobs decodes a batch of payment jobs, each of any schema version
+func DecodePaymentJobs(data []byte) ([]*models.PaymentJob, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	jobs := make([]*models.PaymentJob, len(raw))
+	for i, item := range raw {
+		job, err := DecodePaymentJob(item)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", i, err)
+		}
+		jobs[i] = job
+	}
+	return jobs, nil
+}
+
+// DecodeWebhookEvent decodes a webhook event of any schema version
+func DecodeWebhookEvent(data []byte) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	if err := webhookEvent.decode(data, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// decode upgrades a message to the current version and unmarshals it into v
+func (m message) decode(data []byte, v interface{}) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	version, err := m.version(fields)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version == m.current:
+		return json.Unmarshal(data, v)
+	case version > m.current:
+		// Fields this version doesn't know are ignored; the rest mean what they always have
+		metrics.Count("SchemaVersionAhead", metrics.Dimensions{"Message": m.name})
+		logger.Warn("Decoding message from a newer schema version", logger.Fields{
+			"message":         m.name,
+			"schema_version":  version,
+			"current_version": m.current,
+		})
+		return json.Unmarshal(data, v)
+	}
+
+	for ; version < m.current; version++ {
+		if step := m.upgrades[version]; step != nil {
+			if err := step(fields); err != nil {
+				return fmt.Errorf("upgrade %s from schema version %d: %w", m.name, version, err)
+			}
+		}
+	}
+	fields[versionField] = json.RawMessage(fmt.Sprint(m.current))
+	metrics.Count("SchemaUpgrades", metrics.Dimensions{"Message": m.name})
+
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// version returns the schema version a message was written with
+func (m message) version(fields map[string]json.RawMessage) (int, error) {
+	raw, ok := fields[versionField]
+	if !ok || string(raw) == "null" {
+		return LegacyVersion, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return 0, fmt.Errorf("invalid %s schema version %s", m.name, raw)
+	}
+	if version < LegacyVersion {
+		return LegacyVersion, nil
+	}
+	return version, nil
+}
@@ -0,0 +1,254 @@
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// Routes of the WebSocket API; client messages are routed on their action
+const (
+	RouteConnect     = "$connect"
+	RouteDisconnect  = "$disconnect"
+	RouteSubscribe   = "subscribe"
+	RouteUnsubscribe = "unsubscribe"
+)
+
+// PaymentReader is the payment storage subscriptions are checked against
+type PaymentReader interface {
+	GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error)
+}
+
+// KeyAuthenticator identifies the caller using an API key from an address, as apikeys.Callers does
+// Authenticate refuses keys that aren't valid, and callers outside their IP allowlist, with an *errors.AppError.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, apiKey, sourceIP string) (*apikeys.Caller, error)
+	Active(ctx context.Context, caller *apikeys.Caller) (bool, error)
+}
+
+// Authorizer context keys, which API Gateway passes on every later message of the connection
+const (
+	contextCallerID = "caller_id"
+	contextKeyHash  = "key_hash"
+)
+
+// Connections handles the WebSocket API's connection lifecycle and subscription messages
+// It is also the $connect route's authorizer, so every later message is from a known caller, and a
+// connection can only subscribe to its caller's payments.
+type Connections struct {
+	payments PaymentReader
+	subs     Subscriptions
+	poster   Poster
+	keys     KeyAuthenticator // nil accepts every connection, as a caller without an API key
+}
+
+// NewConnections creates a WebSocket connection handler
+func NewConnections(payments PaymentReader, subs Subscriptions, poster Poster, keys KeyAuthenticator) *Connections {
+	return &Connections{payments: payments, subs: subs, poster: poster, keys: keys}
+}
+
+// Handle handles one invocation: an authorizer request for $connect, or a route of the WebSocket API
+func (c *Connections) Handle(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	var invocation struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &invocation); err != nil {
+		return nil, err
+	}
+
+	if invocation.Type == "REQUEST" {
+		var request events.APIGatewayCustomAuthorizerRequestTypeRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return c.Authorize(ctx, request)
+	}
+
+	var request events.APIGatewayWebsocketProxyRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+	return c.HandleRequest(ctx, request)
+}
+
+// Authorize authenticates a $connect request's X-Api-Key as the REST API does, allowing the connection as its caller
+// Keys that aren't valid get a 401, and callers outside their IP allowlist a 403.
+func (c *Connections) Authorize(ctx context.Context, request events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+	caller := &apikeys.Caller{}
+	if c.keys != nil {
+		authenticated, err := c.keys.Authenticate(ctx, header(request.Headers, "X-Api-Key"), request.RequestContext.Identity.SourceIP)
+		if err != nil {
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) {
+				logger.Error("Failed to check API key", logger.Fields{"error": err.Error()})
+				return events.APIGatewayCustomAuthorizerResponse{}, err
+			}
+			metrics.Count("SocketConnections", metrics.Dimensions{"Event": "refused"})
+			if appErr.StatusCode == http.StatusUnauthorized {
+				// API Gateway answers 401 for this error message
+				return events.APIGatewayCustomAuthorizerResponse{}, stderrors.New("Unauthorized")
+			}
+			return policy("", "Deny", request.MethodArn, nil), nil
+		}
+		caller = authenticated
+	}
+
+	return policy(caller.ID, "Allow", request.MethodArn, map[string]interface{}{
+		contextCallerID: caller.ID,
+		contextKeyHash:  caller.KeyHash,
+	}), nil
+}
+
+// policy builds an authorizer response allowing or denying a connection
+func policy(principalID, effect, methodArn string, context map[string]interface{}) events.APIGatewayCustomAuthorizerResponse {
+	if principalID == "" {
+		// API Gateway requires a principal even for anonymous connections
+		principalID = "anonymous"
+	}
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: principalID,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{
+				{Action: []string{"execute-api:Invoke"}, Effect: effect, Resource: []string{methodArn}},
+			},
+		},
+		Context: context,
+	}
+}
+
+// header returns a header however the client cased it
+func header(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// caller returns the caller the $connect authorizer allowed the request's connection as
+func caller(request events.APIGatewayWebsocketProxyRequest) *apikeys.Caller {
+	context, _ := request.RequestContext.Authorizer.(map[string]interface{})
+	id, _ := context[contextCallerID].(string)
+	keyHash, _ := context[contextKeyHash].(string)
+	return &apikeys.Caller{ID: id, KeyHash: keyHash}
+}
+
+// HandleRequest handles one WebSocket API route
+// Replies to client messages are posted to the connection, since API Gateway discards route responses
+// unless a route response is configured.
+func (c *Connections) HandleRequest(ctx context.Context, request events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	connectionID := request.RequestContext.ConnectionID
+
+	switch request.RequestContext.RouteKey {
+	case RouteConnect:
+		metrics.Count("SocketConnections", metrics.Dimensions{"Event": "connect"})
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+
+	case RouteDisconnect:
+		metrics.Count("SocketConnections", metrics.Dimensions{"Event": "disconnect"})
+		if err := c.subs.DeleteConnectionSubscriptions(ctx, connectionID); err != nil {
+			// Left behind, the subscriptions expire with the connection or on the next push
+			logger.Warn("Failed to delete closed connection's subscriptions", logger.Fields{
+				"connection_id": connectionID,
+				"error":         err.Error(),
+			})
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	var msg models.SocketRequest
+	if err := json.Unmarshal([]byte(request.Body), &msg); err != nil {
+		return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageError, Error: "Message must be JSON"})
+	}
+	if msg.PaymentID == "" && (msg.Action == RouteSubscribe || msg.Action == RouteUnsubscribe) {
+		return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageError, Error: "payment_id is required"})
+	}
+
+	switch msg.Action {
+	case RouteSubscribe:
+		return c.subscribe(ctx, connectionID, caller(request), msg.PaymentID)
+
+	case RouteUnsubscribe:
+		if err := c.subs.UnsubscribeConnection(ctx, connectionID, msg.PaymentID); err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageUnsubscribed, PaymentID: msg.PaymentID})
+
+	default:
+		return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageError, Error: "action must be subscribe or unsubscribe"})
+	}
+}
+
+// subscribe subscribes a connection to one of its caller's payments and replies with its current status
+// The caller's key is checked again first, so a revoked key can't subscribe on a connection opened before it was
+// revoked, and the payment's owner is checked before anything is stored, so another caller's payment is never pushed.
+// The subscription is stored before the status is read, so a transition in between is pushed rather than missed.
+func (c *Connections) subscribe(ctx context.Context, connectionID string, caller *apikeys.Caller, paymentID string) (events.APIGatewayProxyResponse, error) {
+	if c.keys != nil {
+		active, err := c.keys.Active(ctx, caller)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+		}
+		if !active {
+			return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageError, PaymentID: paymentID, Error: "The API key is invalid, expired or revoked"})
+		}
+	}
+
+	payment, err := c.payments.GetPaymentByID(ctx, paymentID)
+	if err == nil && payment.IdempotencyScope != caller.ID {
+		// Payments are only visible to the API key that created them
+		err = errors.ErrPaymentNotFound(paymentID)
+	}
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageError, PaymentID: paymentID, Error: appErr.Message})
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	if err := c.subs.SubscribeConnection(ctx, models.NewSocketSubscription(connectionID, paymentID)); err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	payment, err = c.payments.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if err := c.subs.UnsubscribeConnection(ctx, connectionID, paymentID); err != nil {
+			logger.Warn("Failed to delete subscription to unreadable payment", logger.Fields{
+				"payment_id": paymentID,
+				"error":      err.Error(),
+			})
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+
+	timeline := models.NewPaymentEvents(payment).Events
+	current := timeline[len(timeline)-1]
+	return c.reply(ctx, connectionID, models.SocketMessage{Type: models.SocketMessageSubscribed, PaymentID: paymentID, Event: &current})
+}
+
+// reply posts a message to the connection a request came from
+func (c *Connections) reply(ctx context.Context, connectionID string, msg models.SocketMessage) (events.APIGatewayProxyResponse, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, err
+	}
+	if err := c.poster.Post(ctx, connectionID, data); err != nil {
+		logger.Warn("Failed to reply to connection", logger.Fields{
+			"connection_id": connectionID,
+			"type":          msg.Type,
+			"error":         err.Error(),
+		})
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+}
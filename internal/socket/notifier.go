@@ -0,0 +1,125 @@
+// Package socket pushes payment status transitions to clients connected to the API Gateway WebSocket API
+// Clients subscribe a connection to the payments they watch; the worker pushes each status those payments
+// enter to every subscribed connection, so real-time dashboards don't have to poll.
+package socket
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// ErrGone means the connection a message was posted to has closed
+var ErrGone = stderrors.New("connection is gone")
+
+// Poster sends a message to one WebSocket connection
+type Poster interface {
+	Post(ctx context.Context, connectionID string, data []byte) error
+}
+
+// Subscriptions stores which connections are subscribed to which payments
+type Subscriptions interface {
+	SubscribeConnection(ctx context.Context, sub *models.SocketSubscription) error
+	UnsubscribeConnection(ctx context.Context, connectionID, paymentID string) error
+	ListPaymentSubscriptions(ctx context.Context, paymentID string) ([]*models.SocketSubscription, error)
+	DeleteConnectionSubscriptions(ctx context.Context, connectionID string) error
+}
+
+// GatewayPoster posts to connections through a WebSocket API's connection management endpoint
+type GatewayPoster struct {
+	svc *apigatewaymanagementapi.ApiGatewayManagementApi
+}
+
+// NewGatewayPoster creates a poster for the WebSocket API at endpoint (https://{api-id}.execute-api.{region}.amazonaws.com/{stage})
+func NewGatewayPoster(region, endpoint string) (*GatewayPoster, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayPoster{
+		svc: apigatewaymanagementapi.New(tracing.AWSSession(sess), aws.NewConfig().WithEndpoint(endpoint)),
+	}, nil
+}
+
+// Post sends a message to a connection, returning ErrGone if it has closed
+func (p *GatewayPoster) Post(ctx context.Context, connectionID string, data []byte) error {
+	_, err := p.svc.PostToConnectionWithContext(ctx, &apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(connectionID),
+		Data:         data,
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == apigatewaymanagementapi.ErrCodeGoneException {
+		return ErrGone
+	}
+	return err
+}
+
+// Notifier pushes payment status transitions to the connections subscribed to each payment
+type Notifier struct {
+	subs   Subscriptions
+	poster Poster
+}
+
+// NewNotifier creates a status notifier
+func NewNotifier(subs Subscriptions, poster Poster) *Notifier {
+	return &Notifier{subs: subs, poster: poster}
+}
+
+// NotifyStatus pushes a status the payment entered to every connection subscribed to it
+// Connections that have closed lose their subscriptions; a failed push doesn't stop the rest.
+func (n *Notifier) NotifyStatus(ctx context.Context, paymentID string, event models.PaymentEvent) error {
+	subs, err := n.subs.ListPaymentSubscriptions(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(models.SocketMessage{Type: models.SocketMessageStatus, PaymentID: paymentID, Event: &event})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, sub := range subs {
+		err := n.poster.Post(ctx, sub.ConnectionID, data)
+		switch {
+		case stderrors.Is(err, ErrGone):
+			metrics.Count("SocketPushes", metrics.Dimensions{"Result": "gone"})
+			if err := n.subs.DeleteConnectionSubscriptions(ctx, sub.ConnectionID); err != nil {
+				logger.Warn("Failed to delete closed connection's subscriptions", logger.Fields{
+					"connection_id": sub.ConnectionID,
+					"error":         err.Error(),
+				})
+			}
+		case err != nil:
+			metrics.Count("SocketPushes", metrics.Dimensions{"Result": "failed"})
+			logger.Warn("Failed to push payment status", logger.Fields{
+				"payment_id":    paymentID,
+				"connection_id": sub.ConnectionID,
+				"error":         err.Error(),
+			})
+			failed++
+		default:
+			metrics.Count("SocketPushes", metrics.Dimensions{"Result": "delivered"})
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to push to %d of %d connections", failed, len(subs))
+	}
+	return nil
+}
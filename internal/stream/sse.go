@@ -0,0 +1,271 @@
+// Package stream serves GET /payments/{payment_id}/stream, pushing a payment's status transitions to the
+// client as server-sent events while they happen, so it doesn't have to poll GET /payments/{payment_id}.
+// Events are the payment's customer-facing timeline (see models.NewPaymentEvents), numbered from 0, so a
+// client that reconnects with Last-Event-ID picks up after the last event it saw.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdaurl"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/metrics"
+	"crypto-conversion/internal/models"
+)
+
+// DefaultKeepAlive is how often an idle stream sends a comment, so proxies don't close it as dead
+const DefaultKeepAlive = 15 * time.Second
+
+// Event names
+const (
+	EventStatus = "status" // The payment entered a status; data is a StatusEvent
+	EventDone   = "done"   // The payment reached a terminal status and the stream is closing
+)
+
+// PaymentReader is the payment storage a stream reads
+type PaymentReader interface {
+	GetPaymentByID(ctx context.Context, paymentID string) (*models.Payment, error)
+}
+
+// KeyAuthenticator identifies the caller using an API key from an address, as apikeys.Callers does
+// Authenticate refuses keys that aren't valid, and callers outside their IP allowlist, with an *errors.AppError.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, apiKey, sourceIP string) (*apikeys.Caller, error)
+	Active(ctx context.Context, caller *apikeys.Caller) (bool, error)
+}
+
+// Config decides how often a stream checks its payment and how long it stays open
+type Config struct {
+	PollInterval time.Duration
+	MaxDuration  time.Duration // The client reconnects with Last-Event-ID after this
+	KeepAlive    time.Duration // DefaultKeepAlive when zero
+}
+
+// StatusEvent is the data of a status event
+type StatusEvent struct {
+	PaymentID string `json:"payment_id"`
+	models.PaymentEvent
+}
+
+// Handler streams payment status transitions
+type Handler struct {
+	db   PaymentReader
+	keys KeyAuthenticator // nil serves streams without an API key
+	cfg  Config
+}
+
+// NewHandler creates a payment status stream handler
+func NewHandler(db PaymentReader, keys KeyAuthenticator, cfg Config) *Handler {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	return &Handler{db: db, keys: keys, cfg: cfg}
+}
+
+// ServeHTTP handles GET /payments/{payment_id}/stream
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		writeCORSHeaders(w)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	paymentID, ok := paymentIDFromPath(r.URL.Path)
+	if !ok || r.Method != http.MethodGet {
+		writeError(w, errors.New("NOT_FOUND", "Endpoint not found", http.StatusNotFound, nil))
+		return
+	}
+
+	ctx := r.Context()
+	var caller *apikeys.Caller
+	var callerID string
+	if h.keys != nil {
+		apiKey := r.Header.Get("X-Api-Key")
+		if apiKey == "" {
+			writeError(w, errors.New("UNAUTHORIZED", "An API key is required", http.StatusUnauthorized, nil))
+			return
+		}
+		authenticated, err := h.keys.Authenticate(ctx, apiKey, sourceIP(r))
+		if err != nil {
+			if appErr, ok := err.(*errors.AppError); ok {
+				writeError(w, appErr)
+				return
+			}
+			logger.Error("Failed to check API key", logger.Fields{"error": err.Error()})
+			writeError(w, errors.New("INTERNAL_ERROR", "Failed to check API key", http.StatusInternalServerError, nil))
+			return
+		}
+		caller, callerID = authenticated, authenticated.ID
+	}
+
+	payment, err := h.db.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.StatusCode == http.StatusNotFound {
+			writeError(w, appErr)
+			return
+		}
+		logger.Error("Failed to fetch payment", logger.Fields{"payment_id": paymentID, "error": err.Error()})
+		writeError(w, errors.New("INTERNAL_ERROR", "Failed to fetch payment", http.StatusInternalServerError, nil))
+		return
+	}
+	// Payments are only visible to the API key that created them
+	if payment.IdempotencyScope != callerID {
+		writeError(w, errors.ErrPaymentNotFound(paymentID))
+		return
+	}
+
+	writeCORSHeaders(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	result := h.stream(ctx, w, payment, caller, lastEventID(r)+1)
+	metrics.Count("PaymentStreams", metrics.Dimensions{"Result": result})
+}
+
+// stream writes the payment's events from index next on, then each new one as the payment moves,
+// until it reaches a terminal status, the client goes away, the caller's key is revoked or the stream has been
+// open MaxDuration. The key is checked again on each keep-alive. It returns why the stream ended.
+func (h *Handler) stream(ctx context.Context, w http.ResponseWriter, payment *models.Payment, caller *apikeys.Caller, next int) string {
+	deadline := time.NewTimer(h.cfg.MaxDuration)
+	defer deadline.Stop()
+	poll := time.NewTicker(h.cfg.PollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(h.cfg.KeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		events := models.NewPaymentEvents(payment).Events
+		for ; next < len(events); next++ {
+			if err := writeEvent(w, next, EventStatus, StatusEvent{PaymentID: payment.PaymentID, PaymentEvent: events[next]}); err != nil {
+				return "disconnected"
+			}
+		}
+		if payment.Status.IsTerminal() {
+			writeEvent(w, next, EventDone, StatusEvent{PaymentID: payment.PaymentID, PaymentEvent: events[len(events)-1]})
+			return "completed"
+		}
+		flush(w)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return "disconnected"
+			case <-deadline.C:
+				return "expired"
+			case <-keepAlive.C:
+				if !h.callerActive(ctx, caller) {
+					return "revoked"
+				}
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return "disconnected"
+				}
+				flush(w)
+			case <-poll.C:
+				break wait
+			}
+		}
+
+		updated, err := h.db.GetPaymentByID(ctx, payment.PaymentID)
+		if err != nil {
+			// The client reconnects and resumes from the last event it saw
+			logger.Warn("Failed to refresh streamed payment", logger.Fields{
+				"payment_id": payment.PaymentID,
+				"error":      err.Error(),
+			})
+			return "failed"
+		}
+		payment = updated
+	}
+}
+
+// callerActive reports whether the stream's caller may keep streaming
+// A failed check keeps the stream open; the key was valid when it opened, and MaxDuration still bounds it.
+func (h *Handler) callerActive(ctx context.Context, caller *apikeys.Caller) bool {
+	if caller == nil {
+		return true
+	}
+	active, err := h.keys.Active(ctx, caller)
+	if err != nil {
+		logger.Warn("Failed to check streaming API key", logger.Fields{"caller_id": caller.ID, "error": err.Error()})
+		return true
+	}
+	return active
+}
+
+// sourceIP returns the address a stream request came from: the function URL's source IP, or the
+// connection's peer when served outside Lambda
+func sourceIP(r *http.Request) string {
+	if request, ok := lambdaurl.RequestFromContext(r.Context()); ok {
+		return request.RequestContext.HTTP.SourceIP
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeEvent writes one server-sent event
+func writeEvent(w http.ResponseWriter, id int, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, body)
+	return err
+}
+
+// flush sends what has been written so far, when the writer buffers
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// paymentIDFromPath returns the payment ID in a /payments/{payment_id}/stream path
+func paymentIDFromPath(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/payments/") || !strings.HasSuffix(path, "/stream") {
+		return "", false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(path, "/payments/"), "/stream")
+	if id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// lastEventID returns the ID of the last event a reconnecting client saw, or -1 for a new stream
+func lastEventID(r *http.Request) int {
+	id, err := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if err != nil || id < 0 {
+		return -1
+	}
+	return id
+}
+
+// writeCORSHeaders allows browsers on other origins to open a stream
+func writeCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET,OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type,X-Api-Key,Last-Event-ID")
+}
+
+// writeError writes an error response in the API's error format
+func writeError(w http.ResponseWriter, appErr *errors.AppError) {
+	body, _ := json.Marshal(errors.ToErrorResponse(appErr))
+	writeCORSHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErr.StatusCode)
+	w.Write(body)
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// enabled gates all instrumentation so local runs and tests never need an X-Ray daemon
+var enabled bool
+
+// Configure turns X-Ray instrumentation on or off
+// Call before constructing clients: sessions and HTTP clients are instrumented at creation.
+func Configure(isEnabled bool) {
+	enabled = isEnabled
+}
+
+// Enabled reports whether X-Ray instrumentation is on
+func Enabled() bool {
+	return enabled
+}
+
+// AWSSession instruments every client created from the session with X-Ray subsegments
+// SQS sends from a traced session carry the trace header, linking the async hop to the consumer.
+func AWSSession(sess *session.Session) *session.Session {
+	if !enabled {
+		return sess
+	}
+	return xray.AWSSession(sess)
+}
+
+// HTTPClient wraps an HTTP client so outbound requests are recorded as subsegments
+func HTTPClient(client *http.Client) *http.Client {
+	if !enabled {
+		return client
+	}
+	return xray.Client(client)
+}
+
+// Capture runs fn inside a named subsegment, recording any returned error
+func Capture(ctx context.Context, name string, fn func(context.Context) error) error {
+	if !enabled {
+		return fn(ctx)
+	}
+	return xray.Capture(ctx, name, fn)
+}
+
+// Annotate adds an indexed annotation to the current subsegment so traces can be searched by it
+func Annotate(ctx context.Context, key, value string) {
+	if !enabled {
+		return
+	}
+	xray.AddAnnotation(ctx, key, value)
+}
+
+// traceHeaderKey carries a trace header recorded by an earlier hop, such as the request that wrote an outbox message
+type traceHeaderKey struct{}
+
+// WithTraceHeader attaches a recorded trace header so later sends continue that trace
+// An empty header leaves ctx unchanged.
+func WithTraceHeader(ctx context.Context, header string) context.Context {
+	if header == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceHeaderKey{}, header)
+}
+
+// TraceHeader returns the X-Ray trace header to propagate from ctx, or "" when there's no trace
+// A header attached with WithTraceHeader wins over the current segment.
+func TraceHeader(ctx context.Context) string {
+	if !enabled {
+		return ""
+	}
+	if header, ok := ctx.Value(traceHeaderKey{}).(string); ok {
+		return header
+	}
+	seg := xray.GetSegment(ctx)
+	if seg == nil {
+		return ""
+	}
+	return seg.DownstreamHeader().String()
+}
@@ -0,0 +1,120 @@
+package validator
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+	"crypto-conversion/internal/errors"
+)
+
+// evmAddressPattern matches a 20-byte hex address
+var evmAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// solanaAddressLength is the byte length of a Solana public key
+const solanaAddressLength = 32
+
+// base58Alphabet is the Bitcoin base58 alphabet Solana addresses are encoded in
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// burnAddresses are well-known addresses nobody holds the key to; funds sent there are lost
+// EVM entries are lowercase, Solana entries are exact base58.
+var burnAddresses = map[string]bool{
+	"0x0000000000000000000000000000000000000000":  true, // Zero address
+	"0x000000000000000000000000000000000000dead":  true,
+	"0xdead000000000000000042069420694206942069":  true,
+	"11111111111111111111111111111111":            true, // System program
+	"1nc1nerator11111111111111111111111111111111": true, // Incinerator
+}
+
+// ValidateAddress checks that address is a valid USDC destination on chain
+// EVM addresses in mixed case must carry a valid EIP-55 checksum; all-lowercase or all-uppercase
+// addresses carry none. Solana addresses must decode from base58 to a 32-byte public key.
+// Known burn addresses are rejected on every chain.
+func ValidateAddress(chain, address string) error {
+	chain = strings.ToLower(chain)
+	if !supportedChains[chain] {
+		return fieldError("chain", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", chain))
+	}
+
+	if chain == "solana" {
+		decoded, ok := decodeBase58(address)
+		if !ok {
+			return fieldError("destination_account", errors.FieldInvalidFormat, "is not a valid Solana address: must be base58 encoded")
+		}
+		if len(decoded) != solanaAddressLength {
+			return fieldError("destination_account", errors.FieldInvalidFormat, fmt.Sprintf("is not a valid Solana address: decodes to %d bytes, expected %d",
+				len(decoded), solanaAddressLength))
+		}
+		if burnAddresses[address] {
+			return fieldError("destination_account", errors.FieldInvalidFormat, "is a burn address")
+		}
+		return nil
+	}
+
+	if !evmAddressPattern.MatchString(address) {
+		reason := fmt.Sprintf("is not a valid %s address", chain)
+		if strings.HasPrefix(address, "0x") && len(address) != 42 {
+			reason += fmt.Sprintf(": has %d hex digits, expected 40", len(address)-2)
+		}
+		return fieldError("destination_account", errors.FieldInvalidFormat, reason)
+	}
+	if burnAddresses[strings.ToLower(address)] {
+		return fieldError("destination_account", errors.FieldInvalidFormat, "is a burn address")
+	}
+	digits := address[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if address != checksumAddress(address) {
+		return fieldError("destination_account", errors.FieldInvalidFormat, "has an invalid EIP-55 checksum")
+	}
+	return nil
+}
+
+// fieldError creates a validation error that carries its field and code in the response's error list
+func fieldError(field, code, reason string) error {
+	return errors.ErrValidationFields([]errors.FieldError{{Field: field, Code: code, Reason: reason}})
+}
+
+// checksumAddress returns the EIP-55 mixed-case form of an EVM address
+// A hex letter is uppercased when the matching nibble of the Keccak-256 hash of the lowercase address is 8 or more.
+func checksumAddress(address string) string {
+	lower := strings.ToLower(address[2:])
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		if c >= 'a' && c <= 'f' && digest[i] >= '8' {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed)
+}
+
+// decodeBase58 decodes a base58 string, reporting false on characters outside the alphabet
+func decodeBase58(s string) ([]byte, bool) {
+	if s == "" {
+		return nil, false
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	leadingZeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, leadingZeros), n.Bytes()...), true
+}
@@ -0,0 +1,47 @@
+package validator
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"crypto-conversion/internal/errors"
+)
+
+// maxWebhookURLLength bounds the callback and subscription URLs we store
+const maxWebhookURLLength = 2048
+
+// ValidateCallbackURL checks that a payment's callback URL is an absolute HTTPS URL we can deliver webhooks to
+func ValidateCallbackURL(raw string) error {
+	return ValidateWebhookURL("callback_url", raw)
+}
+
+// ValidateWebhookURL checks that a URL in field is an absolute HTTPS URL we can deliver webhooks to
+// Hosts that resolve inside our own network by name or address (localhost, private, loopback and link-local
+// addresses) are rejected so webhooks can't be aimed at internal services.
+func ValidateWebhookURL(field, raw string) error {
+	if len(raw) > maxWebhookURLLength {
+		return fieldError(field, errors.FieldOutOfRange, "must be at most 2048 characters")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fieldError(field, errors.FieldInvalidFormat, "must be an absolute URL")
+	}
+	if u.Scheme != "https" {
+		return fieldError(field, errors.FieldInvalidFormat, "must use https")
+	}
+	if u.User != nil {
+		return fieldError(field, errors.FieldInvalid, "must not contain credentials")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return fieldError(field, errors.FieldInvalid, "must be publicly reachable")
+	}
+	if ip := net.ParseIP(host); ip != nil &&
+		(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast()) {
+		return fieldError(field, errors.FieldInvalid, "must be publicly reachable")
+	}
+	return nil
+}
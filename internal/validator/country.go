@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"strings"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
+
+// CountryPolicy restricts the countries payments may pay out in
+// A non-empty allow list admits only its countries; a denied country is refused either way.
+type CountryPolicy struct {
+	allowed map[string]bool
+	denied  map[string]bool
+}
+
+// NewCountryPolicy creates a policy from ISO 3166-1 alpha-2 allow and deny lists
+func NewCountryPolicy(allowed, denied []string) *CountryPolicy {
+	return &CountryPolicy{allowed: countrySet(allowed), denied: countrySet(denied)}
+}
+
+// countrySet returns the uppercased codes as a set
+func countrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
+
+// Check refuses a destination country the policy doesn't permit
+// An unknown ("") destination passes; a nil policy permits every country.
+func (p *CountryPolicy) Check(country string) error {
+	if p == nil || country == "" {
+		return nil
+	}
+	country = strings.ToUpper(country)
+	if p.denied[country] || (len(p.allowed) > 0 && !p.allowed[country]) {
+		return errors.ErrDestinationRestricted(country)
+	}
+	return nil
+}
+
+// PaymentDestination returns the country a payment request pays out in: the beneficiary's, else its corridor's
+// It returns "" when neither is known, as for wallet payouts without a beneficiary.
+func PaymentDestination(req *models.PaymentRequest, registry *corridors.Registry) string {
+	if req.Beneficiary != nil && req.Beneficiary.Country != "" {
+		return strings.ToUpper(req.Beneficiary.Country)
+	}
+	source := req.SourceCurrency
+	if source == "" {
+		source = models.DefaultSourceCurrency
+	}
+	return corridorCountry(registry, source, req.Currency)
+}
+
+// FeeDestination returns the country a fee request prices a payout to: the requested one, else its corridor's
+func FeeDestination(req *fees.AIFeeRequest, registry *corridors.Registry) string {
+	if req.DestinationCountry != "" {
+		return strings.ToUpper(req.DestinationCountry)
+	}
+	return corridorCountry(registry, req.FromCurrency, req.ToCurrency)
+}
+
+// corridorCountry returns the payout country of the corridor, or "" if it isn't supported
+func corridorCountry(registry *corridors.Registry, source, destination string) string {
+	if registry == nil {
+		return ""
+	}
+	corridor, err := registry.Lookup(source, destination)
+	if err != nil {
+		return ""
+	}
+	return corridor.DestinationCountry
+}
@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"crypto-conversion/internal/errors"
+)
+
+// ibanPattern matches a compact IBAN: country code, check digits, then the domestic account number
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`)
+
+// ibanPrefixPattern matches destinations that start like an IBAN
+var ibanPrefixPattern = regexp.MustCompile(`^[A-Za-z]{2}[0-9]{2}`)
+
+// ibanLengths are the IBAN lengths of the SEPA countries EUR payouts can reach
+var ibanLengths = map[string]int{
+	"AD": 24, "AT": 20, "BE": 16, "BG": 22, "CH": 21, "CY": 28, "CZ": 24, "DE": 22,
+	"DK": 18, "EE": 20, "ES": 24, "FI": 18, "FR": 27, "GB": 22, "GI": 23, "GR": 27,
+	"HR": 21, "HU": 28, "IE": 22, "IS": 26, "IT": 27, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "MC": 27, "MT": 31, "NL": 18, "NO": 15, "PL": 28, "PT": 25, "RO": 24,
+	"SE": 24, "SI": 19, "SK": 24, "SM": 27, "VA": 22,
+}
+
+// looksLikeIBAN reports whether a destination is meant as an IBAN rather than a provider account reference
+func looksLikeIBAN(account string) bool {
+	return ibanPrefixPattern.MatchString(strings.TrimSpace(account))
+}
+
+// ValidateIBAN checks an IBAN's country, length and ISO 7064 mod-97 check digits
+// Spaces are ignored so the grouped print format is accepted.
+func ValidateIBAN(iban string) error {
+	compact := strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if !ibanPattern.MatchString(compact) {
+		return fieldError("destination_account", errors.FieldInvalidFormat, "is not a valid IBAN")
+	}
+
+	country := compact[:2]
+	length, ok := ibanLengths[country]
+	if !ok {
+		return fieldError("destination_account", errors.FieldUnsupported, fmt.Sprintf("IBAN country '%s' is not a SEPA country", country))
+	}
+	if len(compact) != length {
+		return fieldError("destination_account", errors.FieldInvalidFormat, fmt.Sprintf("%s IBANs have %d characters, got %d", country, length, len(compact)))
+	}
+
+	// Move the country code and check digits to the end and read letters as 10-35
+	var digits strings.Builder
+	for _, c := range compact[4:] + compact[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return fieldError("destination_account", errors.FieldInvalidFormat, "has invalid IBAN check digits")
+	}
+	return nil
+}
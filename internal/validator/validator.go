@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"crypto-conversion/internal/corridors"
 	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
 	"crypto-conversion/internal/models"
 )
 
@@ -16,56 +18,158 @@ var supportedCurrencies = map[string]bool{
 	"JPY": true,
 	"AUD": true,
 	"CAD": true,
+	"BRL": true,
+}
+
+// Supported funding currencies (USD is on-ramped 1:1, EUR through Circle's EUR on-ramp)
+var supportedSourceCurrencies = map[string]bool{
+	"USD": true,
+	"EUR": true,
+}
+
+// Supported settlement chains
+var supportedChains = map[string]bool{
+	"base":      true,
+	"polygon":   true,
+	"arbitrum":  true,
+	"optimism":  true,
+	"solana":    true,
+	"avalanche": true,
+	"ethereum":  true,
 }
 
 // ValidatePaymentRequest validates a payment request
+// Every invalid field is reported at once, so a client can render complete form feedback in one round trip.
+// Checks that depend on another field (e.g. the destination's format on the payout type) are skipped
+// while that field is itself invalid.
 func ValidatePaymentRequest(req *models.PaymentRequest) error {
-	// Validate amount
+	var fields []errors.FieldError
+	invalid := func(field, code, reason string) {
+		fields = append(fields, errors.FieldError{Field: field, Code: code, Reason: reason})
+	}
+	merge := func(err error) {
+		if appErr, ok := err.(*errors.AppError); ok && len(appErr.Fields) > 0 {
+			fields = append(fields, appErr.Fields...)
+		} else if err != nil {
+			invalid("", errors.FieldInvalid, err.Error())
+		}
+	}
+
+	// Validate amount (maximum is e.g. 1 million in the smallest unit)
 	if req.Amount <= 0 {
-		return errors.ErrValidation("amount", "must be greater than 0")
+		invalid("amount", errors.FieldOutOfRange, "must be greater than 0")
+	} else if req.Amount > 1000000000 {
+		invalid("amount", errors.FieldOutOfRange, "exceeds maximum allowed amount")
 	}
 
-	// Maximum amount check (e.g., 1 million in smallest unit)
-	if req.Amount > 1000000000 {
-		return errors.ErrValidation("amount", "exceeds maximum allowed amount")
+	// Wallet payouts deliver USDC itself; bank payouts off-ramp to a fiat currency
+	wallet, payoutTypeValid := false, true
+	switch req.PayoutType {
+	case "", models.PayoutTypeBank:
+	case models.PayoutTypeWallet:
+		wallet = true
+	default:
+		payoutTypeValid = false
+		invalid("payout_type", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.PayoutType))
 	}
 
 	// Validate currency
-	if req.Currency == "" {
-		return errors.ErrValidation("currency", "is required")
+	currency := strings.ToUpper(req.Currency)
+	switch {
+	case req.Currency == "":
+		invalid("currency", errors.FieldRequired, "is required")
+	case !payoutTypeValid:
+		// Which currencies are allowed depends on the payout type
+	case wallet && currency != models.WalletPayoutCurrency:
+		invalid("currency", errors.FieldUnsupported, "must be USDC for wallet payouts")
+	case !wallet && !supportedCurrencies[currency]:
+		invalid("currency", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.Currency))
 	}
 
-	currency := strings.ToUpper(req.Currency)
-	if !supportedCurrencies[currency] {
-		return errors.ErrValidation("currency", fmt.Sprintf("'%s' is not supported", req.Currency))
+	// Validate optional source currency; funding and payout currencies must differ
+	if req.SourceCurrency != "" {
+		source := strings.ToUpper(req.SourceCurrency)
+		if !supportedSourceCurrencies[source] {
+			invalid("source_currency", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.SourceCurrency))
+		} else if source == currency {
+			invalid("source_currency", errors.FieldConflict, "must be different from currency")
+		}
 	}
 
 	// Validate source account
 	if req.SourceAccount == "" {
-		return errors.ErrValidation("source_account", "is required")
+		invalid("source_account", errors.FieldRequired, "is required")
+	} else if len(req.SourceAccount) < 3 || len(req.SourceAccount) > 100 {
+		invalid("source_account", errors.FieldOutOfRange, "must be between 3 and 100 characters")
 	}
 
-	if len(req.SourceAccount) < 3 || len(req.SourceAccount) > 100 {
-		return errors.ErrValidation("source_account", "must be between 3 and 100 characters")
+	// Validate optional settlement chain
+	chainValid := req.Chain == "" || supportedChains[strings.ToLower(req.Chain)]
+	if !chainValid {
+		invalid("chain", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.Chain))
 	}
 
 	// Validate destination account
-	if req.DestinationAccount == "" {
-		return errors.ErrValidation("destination_account", "is required")
+	switch {
+	case req.DestinationAccount == "":
+		invalid("destination_account", errors.FieldRequired, "is required")
+	case !payoutTypeValid:
+		// The destination's format depends on the payout type
+	case wallet:
+		// The address is only meaningful on the chain it's paid out on
+		if req.Chain == "" {
+			invalid("chain", errors.FieldRequired, "is required for wallet payouts")
+		} else if chainValid {
+			merge(ValidateAddress(req.Chain, req.DestinationAccount))
+		}
+	case len(req.DestinationAccount) < 3 || len(req.DestinationAccount) > 100:
+		invalid("destination_account", errors.FieldOutOfRange, "must be between 3 and 100 characters")
+	case currency == "EUR" && looksLikeIBAN(req.DestinationAccount):
+		// EUR bank destinations given as IBANs are checked before the SEPA payout can bounce;
+		// other destinations are provider account references
+		merge(ValidateIBAN(req.DestinationAccount))
 	}
 
-	if len(req.DestinationAccount) < 3 || len(req.DestinationAccount) > 100 {
-		return errors.ErrValidation("destination_account", "must be between 3 and 100 characters")
+	// Ensure source and destination are different
+	if req.SourceAccount != "" && req.SourceAccount == req.DestinationAccount {
+		invalid("destination_account", errors.FieldConflict, "must be different from source_account")
 	}
 
-	// Ensure source and destination are different
-	if req.SourceAccount == req.DestinationAccount {
-		return errors.ErrValidation("destination_account", "must be different from source_account")
+	// Validate optional beneficiary details, which are screened against sanctions lists
+	if b := req.Beneficiary; b != nil {
+		if len(b.Name) > 200 {
+			invalid("beneficiary.name", errors.FieldOutOfRange, "must be at most 200 characters")
+		}
+		if b.Country != "" && !isCountryCode(b.Country) {
+			invalid("beneficiary.country", errors.FieldInvalidFormat, "must be an ISO 3166-1 alpha-2 country code")
+		}
+		b.Country = strings.ToUpper(b.Country)
 	}
 
+	// Validate the optional callback URL webhooks are also delivered to
+	if req.CallbackURL != "" {
+		merge(ValidateCallbackURL(req.CallbackURL))
+	}
+
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
 	return nil
 }
 
+// isCountryCode reports whether s has the shape of an ISO 3166-1 alpha-2 code
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, c := range s {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateIdempotencyKey validates an idempotency key
 func ValidateIdempotencyKey(key string) error {
 	if key == "" {
@@ -99,3 +203,59 @@ func GetSupportedCurrencies() []string {
 	}
 	return currencies
 }
+
+// Fee calculation priorities the AI fee engine is prompted with
+var supportedFeePriorities = map[string]bool{
+	"standard": true,
+	"express":  true,
+}
+
+
+// ValidateFeeRequest validates a fee calculation request against the supported corridors
+// Every invalid field is reported at once, so a client can fix the request in one round trip
+// instead of spending AI tokens per attempt. Defaults must be applied before calling it.
+func ValidateFeeRequest(req *fees.AIFeeRequest, registry *corridors.Registry) error {
+	var fields []errors.FieldError
+	invalid := func(field, code, reason string) {
+		fields = append(fields, errors.FieldError{Field: field, Code: code, Reason: reason})
+	}
+
+	if req.Amount <= 0 {
+		invalid("amount", errors.FieldOutOfRange, "must be greater than 0")
+	} else if req.Amount > 1000000000 {
+		invalid("amount", errors.FieldOutOfRange, "exceeds maximum allowed amount")
+	}
+
+	if req.FromCurrency == "" {
+		invalid("from_currency", errors.FieldRequired, "is required")
+	}
+	if req.ToCurrency == "" {
+		invalid("to_currency", errors.FieldRequired, "is required")
+	}
+	if req.FromCurrency != "" && req.ToCurrency != "" {
+		corridor, err := registry.Lookup(req.FromCurrency, req.ToCurrency)
+		switch {
+		case err != nil:
+			invalid("to_currency", errors.FieldUnsupported, fmt.Sprintf("%s to %s is not a supported corridor",
+				strings.ToUpper(req.FromCurrency), strings.ToUpper(req.ToCurrency)))
+		case len(fields) > 0 && fields[0].Field == "amount":
+			// Already rejected by the global limits
+		case req.Amount < corridor.MinAmount:
+			invalid("amount", errors.FieldOutOfRange, fmt.Sprintf("is below the %s minimum of %d", corridor.ID, corridor.MinAmount))
+		case corridor.MaxAmount > 0 && req.Amount > corridor.MaxAmount:
+			invalid("amount", errors.FieldOutOfRange, fmt.Sprintf("exceeds the %s maximum of %d", corridor.ID, corridor.MaxAmount))
+		}
+	}
+
+	if !supportedFeePriorities[strings.ToLower(req.Priority)] {
+		invalid("priority", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.Priority))
+	}
+	if !models.CustomerTiers[strings.ToLower(req.CustomerTier)] {
+		invalid("customer_tier", errors.FieldUnsupported, fmt.Sprintf("'%s' is not supported", req.CustomerTier))
+	}
+
+	if len(fields) > 0 {
+		return errors.ErrValidationFields(fields)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package version reports which build of the code is running
+// GitSHA and BuildTime are set at link time by make build:
+//
+//	go build -ldflags "-X crypto-conversion/internal/version.GitSHA=$(git rev-parse HEAD) -X crypto-conversion/internal/version.BuildTime=..."
+//
+// Builds without them fall back to the VCS revision the Go toolchain stamps into binaries built from a checkout.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags -X at build time
+var (
+	GitSHA    = ""
+	BuildTime = "" // RFC 3339
+)
+
+// unknown is reported for build details that weren't stamped into the binary
+const unknown = "unknown"
+
+// Info describes the running build
+type Info struct {
+	GitSHA    string            `json:"git_sha"`
+	BuildTime string            `json:"build_time"`
+	GoVersion string            `json:"go_version"`
+	Modified  bool              `json:"modified,omitempty"` // Built from a checkout with uncommitted changes
+	Schemas   map[string]string `json:"schemas,omitempty"`  // Versions of the message and storage schemas the build reads and writes
+}
+
+var (
+	once  sync.Once
+	build Info
+)
+
+// Get returns the running build's details
+func Get() Info {
+	once.Do(func() {
+		build = Info{GitSHA: GitSHA, BuildTime: BuildTime, GoVersion: runtime.Version()}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					if build.GitSHA == "" {
+						build.GitSHA = s.Value
+					}
+				case "vcs.time":
+					if build.BuildTime == "" {
+						build.BuildTime = s.Value
+					}
+				case "vcs.modified":
+					build.Modified = s.Value == "true"
+				}
+			}
+		}
+		if build.GitSHA == "" {
+			build.GitSHA = unknown
+		}
+		if build.BuildTime == "" {
+			build.BuildTime = unknown
+		}
+	})
+	return build
+}
+
+// Short returns the first 12 characters of the build's git SHA, as added to every log line
+func Short() string {
+	sha := Get().GitSHA
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
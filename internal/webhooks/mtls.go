@@ -0,0 +1,128 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/tracing"
+)
+
+// DeliveryTimeout bounds one webhook delivery attempt
+const DeliveryTimeout = 10 * time.Second
+
+// SecretFetcher returns the string value of a Secrets Manager secret
+type SecretFetcher func(ctx context.Context, secretID string) (string, error)
+
+// clientCertificateSecret is the JSON a subscription's mTLS secret holds, both fields PEM-encoded
+type clientCertificateSecret struct {
+	Certificate string `json:"certificate"` // Leaf first, then any intermediates
+	PrivateKey  string `json:"private_key"`
+}
+
+// ClientCache builds the HTTP clients that present a subscription's client certificate
+// Clients are reused per secret for the TTL, then rebuilt so a rotated certificate is picked up.
+type ClientCache struct {
+	fetch SecretFetcher
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*cachedClient
+}
+
+// cachedClient is an mTLS client and when its certificate was loaded
+type cachedClient struct {
+	client   *http.Client
+	loadedAt time.Time
+}
+
+// NewClientCache creates a client cache that reads certificates from Secrets Manager in region
+func NewClientCache(region string, ttl time.Duration) *ClientCache {
+	return NewClientCacheWithFetcher(func(ctx context.Context, secretID string) (string, error) {
+		return config.GetSecretValue(ctx, secretID, region)
+	}, ttl)
+}
+
+// NewClientCacheWithFetcher creates a client cache that reads certificates with fetch
+func NewClientCacheWithFetcher(fetch SecretFetcher, ttl time.Duration) *ClientCache {
+	return &ClientCache{
+		fetch:   fetch,
+		ttl:     ttl,
+		clients: make(map[string]*cachedClient),
+	}
+}
+
+// Client returns the HTTP client presenting the certificate in the mTLS secret
+func (c *ClientCache) Client(ctx context.Context, mtls *models.WebhookMTLS) (*http.Client, error) {
+	c.mu.Lock()
+	cached, ok := c.clients[mtls.SecretID]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.client, nil
+	}
+
+	cert, err := c.Certificate(ctx, mtls)
+	if err != nil {
+		return nil, err
+	}
+	client := newMTLSClient(cert)
+
+	c.mu.Lock()
+	c.clients[mtls.SecretID] = &cachedClient{client: client, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return client, nil
+}
+
+// Certificate loads and checks the client certificate in the mTLS secret
+func (c *ClientCache) Certificate(ctx context.Context, mtls *models.WebhookMTLS) (tls.Certificate, error) {
+	value, err := c.fetch(ctx, mtls.SecretID)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("load client certificate %s: %w", mtls.SecretID, err)
+	}
+	return ParseClientCertificate(value)
+}
+
+// ParseClientCertificate parses an mTLS secret, rejecting a certificate that has expired
+func ParseClientCertificate(secret string) (tls.Certificate, error) {
+	var pem clientCertificateSecret
+	if err := json.Unmarshal([]byte(secret), &pem); err != nil {
+		return tls.Certificate{}, fmt.Errorf("client certificate secret is not JSON: %w", err)
+	}
+	if pem.Certificate == "" || pem.PrivateKey == "" {
+		return tls.Certificate{}, fmt.Errorf("client certificate secret needs certificate and private_key")
+	}
+
+	cert, err := tls.X509KeyPair([]byte(pem.Certificate), []byte(pem.PrivateKey))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return tls.Certificate{}, fmt.Errorf("client certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	cert.Leaf = leaf
+	return cert, nil
+}
+
+// newMTLSClient creates a delivery client that presents cert when the receiver asks for one
+func newMTLSClient(cert tls.Certificate) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return tracing.HTTPClient(&http.Client{
+		Timeout:   DeliveryTimeout,
+		Transport: transport,
+	})
+}
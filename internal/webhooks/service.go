@@ -0,0 +1,160 @@
+// Package webhooks manages the endpoints customers register for their payments' webhooks
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/validator"
+	"github.com/google/uuid"
+)
+
+// subscriptionIDPrefix marks webhook subscription IDs
+const subscriptionIDPrefix = "whsub_"
+
+// Service manages webhook subscriptions, each owned by the API key that registered it
+type Service struct {
+	store      database.WebhookSubscriptionRepository
+	deliveries database.WebhookDeliveryRepository
+	clients    *ClientCache // Checks mTLS certificates before they are attached; nil skips the check
+}
+
+// New creates a webhook subscription service backed by store, tracking delivery attempts in deliveries
+func New(store database.WebhookSubscriptionRepository, deliveries database.WebhookDeliveryRepository, clients *ClientCache) *Service {
+	return &Service{store: store, deliveries: deliveries, clients: clients}
+}
+
+// Create registers an endpoint for a customer's webhooks, with a freshly generated signing secret
+func (s *Service) Create(ctx context.Context, customerID string, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+	if err := validator.ValidateWebhookURL("url", req.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSigningSecret()
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to generate signing secret", err)
+	}
+
+	now := time.Now().UTC()
+	sub := &models.WebhookSubscription{
+		SubscriptionID: subscriptionIDPrefix + uuid.New().String(),
+		CustomerID:     customerID,
+		URL:            req.URL,
+		EventTypes:     req.EventTypes,
+		SigningSecrets: []string{secret},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.store.CreateWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// List returns a customer's subscriptions, oldest first
+func (s *Service) List(ctx context.Context, customerID string) ([]*models.WebhookSubscription, error) {
+	return s.store.ListWebhookSubscriptions(ctx, customerID)
+}
+
+// Get retrieves one of a customer's subscriptions
+// Another customer's subscription is reported as not found, so IDs can't be probed.
+func (s *Service) Get(ctx context.Context, customerID, subscriptionID string) (*models.WebhookSubscription, error) {
+	sub, err := s.store.GetWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.CustomerID != customerID {
+		return nil, errors.ErrWebhookSubscriptionNotFound(subscriptionID)
+	}
+	return sub, nil
+}
+
+// Delete removes one of a customer's subscriptions
+func (s *Service) Delete(ctx context.Context, customerID, subscriptionID string) error {
+	if _, err := s.Get(ctx, customerID, subscriptionID); err != nil {
+		return err
+	}
+	return s.store.DeleteWebhookSubscription(ctx, subscriptionID)
+}
+
+// RotateSecret replaces one of a customer's subscription's signing secrets with a new one
+// Deliveries are signed with both for PreviousSecretTTL, so the customer can move its receiver to the new
+// secret in that time without rejecting any. Rotating again drops the secret the last rotation replaced,
+// even if it hadn't expired.
+func (s *Service) RotateSecret(ctx context.Context, customerID, subscriptionID string) (*models.WebhookSubscription, error) {
+	sub, err := s.Get(ctx, customerID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSigningSecret()
+	if err != nil {
+		return nil, errors.ErrInternalServer("Failed to generate signing secret", err)
+	}
+
+	now := time.Now().UTC()
+	secrets := []string{secret}
+	sub.PreviousSecretExpiresAt = nil
+	if len(sub.SigningSecrets) > 0 {
+		secrets = append(secrets, sub.SigningSecrets[0])
+		expires := now.Add(PreviousSecretTTL)
+		sub.PreviousSecretExpiresAt = &expires
+	}
+	sub.SigningSecrets = secrets
+	sub.UpdatedAt = now
+	if err := s.store.UpdateWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SetMTLS attaches the client certificate in a Secrets Manager secret to a subscription, or removes it
+// when the secret ID is empty. The certificate is loaded first, so a bad secret is refused here rather
+// than failing every delivery.
+func (s *Service) SetMTLS(ctx context.Context, subscriptionID string, req *models.WebhookMTLSRequest) (*models.WebhookSubscription, error) {
+	sub, err := s.store.GetWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.MTLS = nil
+	if req.SecretID != "" {
+		mtls := &models.WebhookMTLS{SecretID: req.SecretID}
+		if s.clients != nil {
+			if _, err := s.clients.Certificate(ctx, mtls); err != nil {
+				return nil, errors.ErrValidation("secret_id", err.Error())
+			}
+		}
+		sub.MTLS = mtls
+	}
+	sub.UpdatedAt = time.Now().UTC()
+	if err := s.store.UpdateWebhookSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Deliveries returns the customer's subscriptions that receive events of the given type
+func (s *Service) Deliveries(ctx context.Context, customerID, eventType string) ([]*models.WebhookSubscription, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+	subs, err := s.store.ListWebhookSubscriptions(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := subs[:0]
+	for _, sub := range subs {
+		if sub.Wants(eventType) {
+			wanted = append(wanted, sub)
+		}
+	}
+	return wanted, nil
+}
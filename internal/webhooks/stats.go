@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"crypto-conversion/internal/models"
+)
+
+// maxRecentFailures bounds the failed attempts returned with delivery statistics
+const maxRecentFailures = 10
+
+// RecordDelivery stores an attempt to deliver a webhook to a subscription's endpoint
+func (s *Service) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return s.deliveries.RecordWebhookDelivery(ctx, delivery)
+}
+
+// Stats summarizes the delivery attempts to one of a customer's subscriptions since the given time
+func (s *Service) Stats(ctx context.Context, customerID, subscriptionID string, since time.Time) (*models.WebhookDeliveryStats, error) {
+	if _, err := s.Get(ctx, customerID, subscriptionID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.deliveries.ListWebhookDeliveries(ctx, subscriptionID, since)
+	if err != nil {
+		return nil, err
+	}
+	return DeliveryStats(subscriptionID, since, deliveries), nil
+}
+
+// DeliveryStats summarizes delivery attempts listed oldest first
+func DeliveryStats(subscriptionID string, since time.Time, deliveries []*models.WebhookDelivery) *models.WebhookDeliveryStats {
+	stats := &models.WebhookDeliveryStats{
+		SubscriptionID: subscriptionID,
+		Since:          since.UTC(),
+		Deliveries:     len(deliveries),
+		RecentFailures: []*models.WebhookDelivery{},
+	}
+	if len(deliveries) == 0 {
+		return stats
+	}
+
+	latencies := make([]int64, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		delivery := deliveries[i]
+		latencies = append(latencies, delivery.LatencyMs)
+		if delivery.Success {
+			stats.Succeeded++
+			continue
+		}
+		stats.Failed++
+		if len(stats.RecentFailures) < maxRecentFailures {
+			stats.RecentFailures = append(stats.RecentFailures, delivery)
+		}
+	}
+	stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Deliveries)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.P95LatencyMs = latencies[int(math.Ceil(0.95*float64(len(latencies))))-1]
+	return stats
+}
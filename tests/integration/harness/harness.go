@@ -0,0 +1,172 @@
+// Package harness stands up the payment pipeline against LocalStack for end-to-end tests:
+// it creates DynamoDB tables and SQS queues shaped like the deployed ones, wires the API handler,
+// outbox relay, repositories, queue and state machine to them, creates payments through the API
+// handler, relays the outbox with the relay's sweep, and works the payment queue as the worker would.
+// PostgresSchema gives Postgres repository tests a schema of their own in TEST_DATABASE_URL.
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"crypto-conversion/internal/api"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/outbox"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/queue"
+)
+
+// DefaultEndpoint is where LocalStack is reached unless LOCALSTACK_ENDPOINT is set
+const DefaultEndpoint = "http://localhost:4566"
+
+// region is the region resources are created in; LocalStack accepts any
+const region = "us-east-1"
+
+// APIKeyID is the API Gateway key payments are created under
+const APIKeyID = "it-api-key"
+
+// Options configures an environment; the zero value runs payments that always settle
+type Options struct {
+	OnRamp  []payment.MockOption // Applied after the harness defaults, e.g. scenarios that fail transfers
+	OffRamp []payment.MockOption
+	Polling payment.PollingConfig // Zero polls every second, up to 5 times per stage
+}
+
+// Env is a payment pipeline running against LocalStack
+// Its tables and queues are unique to the test and deleted when it ends.
+type Env struct {
+	Config       *config.Config
+	API          *api.Handler    // Serves POST /payments as the API Lambda does
+	Relay        *outbox.Handler // Delivers the outbox as the outbox relay Lambda does
+	Payments     database.PaymentRepository
+	Quotes       database.QuoteRepository
+	Queue        *queue.Client
+	StateMachine *payment.StateMachine
+
+	dynamo *dynamodb.DynamoDB
+	sqs    *sqs.SQS
+}
+
+// New creates the tables and queues for a test and wires the pipeline to them
+// The test is skipped when LocalStack isn't reachable.
+func New(t testing.TB, opts Options) *Env {
+	t.Helper()
+
+	endpoint := os.Getenv("LOCALSTACK_ENDPOINT")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if err := ping(endpoint); err != nil {
+		t.Skipf("LocalStack isn't reachable at %s: %v", endpoint, err)
+	}
+
+	// The repositories and queue client take credentials from the environment; LocalStack accepts any
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		t.Setenv("AWS_ACCESS_KEY_ID", "test")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:   aws.String(region),
+		Endpoint: aws.String(endpoint),
+	})
+	if err != nil {
+		t.Fatalf("failed to create AWS session: %v", err)
+	}
+	env := &Env{
+		dynamo: dynamodb.New(sess),
+		sqs:    sqs.New(sess),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	prefix := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	env.Config = &config.Config{
+		AWS:     config.AWSConfig{Region: region},
+		Storage: config.StorageConfig{Backend: config.StorageDynamoDB},
+		Database: config.DatabaseConfig{
+			TableName:            prefix + "-payments",
+			QuoteTableName:       prefix + "-quotes",
+			OutboxTableName:      prefix + "-outbox",
+			IdempotencyTableName: prefix + "-idempotency-keys",
+			Endpoint:             endpoint,
+		},
+		Queue: config.QueueConfig{
+			Endpoint: endpoint,
+		},
+	}
+	if err := env.createTables(ctx, t); err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	env.Config.Queue.PaymentQueueURL, err = env.createQueue(ctx, t, prefix+"-payment-queue", prefix+"-payment-dlq")
+	if err != nil {
+		t.Fatalf("failed to create payment queue: %v", err)
+	}
+	env.Config.Queue.WebhookQueueURL, err = env.createQueue(ctx, t, prefix+"-webhook-queue", prefix+"-webhook-dlq")
+	if err != nil {
+		t.Fatalf("failed to create webhook queue: %v", err)
+	}
+
+	// Wired as the worker wires them, over mock providers that settle on their first poll
+	env.Payments, env.Quotes, err = database.NewRepositories(ctx, env.Config)
+	if err != nil {
+		t.Fatalf("failed to create repositories: %v", err)
+	}
+	env.Queue, err = queue.NewClient(region, endpoint)
+	if err != nil {
+		t.Fatalf("failed to create queue client: %v", err)
+	}
+	env.API, err = api.NewHandler(env.Config)
+	if err != nil {
+		t.Fatalf("failed to create API handler: %v", err)
+	}
+	env.Relay, err = outbox.NewHandler(env.Config)
+	if err != nil {
+		t.Fatalf("failed to create outbox relay: %v", err)
+	}
+
+	onRamp := payment.NewStatefulOnRampClient(mockOptions(opts.OnRamp)...)
+	offRamp := payment.NewStatefulOffRampClient(mockOptions(opts.OffRamp)...)
+
+	polling := opts.Polling
+	if polling.InitialDelaySeconds == 0 {
+		polling = payment.PollingConfig{InitialDelaySeconds: 1, MaxDelaySeconds: 1, Multiplier: 1, MaxPollAttempts: 5}
+	}
+
+	env.StateMachine = payment.NewStateMachine(onRamp, offRamp, env.Payments, env.queueAdapter(), polling, nil, nil, nil, payment.SlippageConfig{})
+	env.StateMachine.EnableBridging(payment.NewStatefulBridgeClient(mockOptions(nil)...))
+	env.StateMachine.EnableWalletPayouts(payment.NewStatefulWalletClient(mockOptions(nil)...))
+	env.StateMachine.EnableSandbox(payment.NewSandbox(payment.WithSeed(1)))
+
+	return env
+}
+
+// mockOptions makes a mock provider reproducible and settle on its first poll, then applies opts
+func mockOptions(opts []payment.MockOption) []payment.MockOption {
+	defaults := []payment.MockOption{payment.WithSeed(1), payment.WithFailureRates(0, 0), payment.WithSettleAfter(1)}
+	return append(defaults, opts...)
+}
+
+// ping checks LocalStack is up
+func ping(endpoint string) error {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(endpoint + "/_localstack/health")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// visibilityTimeoutSeconds is how soon a job that failed is redelivered
+const visibilityTimeoutSeconds = 2
+
+// maxReceiveCount is how many deliveries a job gets before it's moved to the dead-letter queue
+const maxReceiveCount = 3
+
+// table describes a DynamoDB table as infrastructure/terraform/main.tf defines it
+type table struct {
+	name     string
+	hashKey  string
+	indexKey string // Hash key of a GSI projecting every attribute, if any
+	index    string
+	ttl      bool // Items expire on their "ttl" attribute
+}
+
+// tables returns the tables the payment and quote repositories write to
+func (e *Env) tables() []table {
+	db := e.Config.Database
+	return []table{
+		{name: db.TableName, hashKey: "payment_id", index: "idempotency-key-index", indexKey: "idempotency_key", ttl: true},
+		{name: db.QuoteTableName, hashKey: "quote_id", ttl: true},
+		{name: db.OutboxTableName, hashKey: "message_id"},
+		{name: db.IdempotencyTableName, hashKey: "scoped_key"},
+	}
+}
+
+// createTables creates the tables, deleting them when the test ends
+func (e *Env) createTables(ctx context.Context, t testing.TB) error {
+	for _, tbl := range e.tables() {
+		input := &dynamodb.CreateTableInput{
+			TableName:   aws.String(tbl.name),
+			BillingMode: aws.String(dynamodb.BillingModePayPerRequest),
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{
+				{AttributeName: aws.String(tbl.hashKey), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+			},
+			KeySchema: []*dynamodb.KeySchemaElement{
+				{AttributeName: aws.String(tbl.hashKey), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			},
+		}
+		if tbl.index != "" {
+			input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+				AttributeName: aws.String(tbl.indexKey),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			})
+			input.GlobalSecondaryIndexes = []*dynamodb.GlobalSecondaryIndex{{
+				IndexName: aws.String(tbl.index),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String(tbl.indexKey), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)},
+			}}
+		}
+
+		if _, err := e.dynamo.CreateTableWithContext(ctx, input); err != nil {
+			return err
+		}
+		name := tbl.name
+		t.Cleanup(func() {
+			e.dynamo.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(name)})
+		})
+
+		if err := e.dynamo.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tbl.name)}); err != nil {
+			return err
+		}
+		if tbl.ttl {
+			_, err := e.dynamo.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+				TableName: aws.String(tbl.name),
+				TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+					AttributeName: aws.String("ttl"),
+					Enabled:       aws.Bool(true),
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createQueue creates a queue redriving to its own dead-letter queue, deleting both when the test ends
+func (e *Env) createQueue(ctx context.Context, t testing.TB, name, dlqName string) (string, error) {
+	dlq, err := e.sqs.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{QueueName: aws.String(dlqName)})
+	if err != nil {
+		return "", err
+	}
+	t.Cleanup(func() {
+		e.sqs.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: dlq.QueueUrl})
+	})
+
+	attrs, err := e.sqs.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       dlq.QueueUrl,
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+	})
+	if err != nil {
+		return "", err
+	}
+	redrive, err := json.Marshal(map[string]interface{}{
+		"deadLetterTargetArn": aws.StringValue(attrs.Attributes[sqs.QueueAttributeNameQueueArn]),
+		"maxReceiveCount":     maxReceiveCount,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	q, err := e.sqs.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNameVisibilityTimeout: aws.String(strconv.Itoa(visibilityTimeoutSeconds)),
+			sqs.QueueAttributeNameRedrivePolicy:     aws.String(string(redrive)),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	t.Cleanup(func() {
+		e.sqs.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: q.QueueUrl})
+	})
+	return aws.StringValue(q.QueueUrl), nil
+}
@@ -0,0 +1,152 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/google/uuid"
+	"crypto-conversion/internal/logger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/schema"
+)
+
+// CreatePayment posts a payment request to the API handler, as an API Gateway caller with APIKeyID would
+// It returns the payment the handler accepted.
+func (e *Env) CreatePayment(ctx context.Context, req *models.PaymentRequest) (*models.Payment, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.API.HandleRequest(ctx, events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/payments",
+		Resource:   "/payments",
+		Headers: map[string]string{
+			"Content-Type":    "application/json",
+			"Idempotency-Key": uuid.New().String(),
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{APIKeyID: APIKeyID},
+		},
+		Body: string(body),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("POST /payments returned %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	var accepted models.PaymentResponse
+	if err := json.Unmarshal([]byte(resp.Body), &accepted); err != nil {
+		return nil, err
+	}
+	return e.Payments.GetPaymentByID(ctx, accepted.PaymentID)
+}
+
+// Run works the outbox and the payment queue until the payment ends or is held, returning it
+// Jobs whose step fails are left for redelivery, as the worker leaves them.
+func (e *Env) Run(ctx context.Context, paymentID string) (*models.Payment, error) {
+	for {
+		if err := e.RelayOutbox(ctx); err != nil {
+			return nil, err
+		}
+		if err := e.ProcessJobs(ctx); err != nil {
+			return nil, err
+		}
+
+		p, err := e.Payments.GetPaymentByID(ctx, paymentID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Status.IsTerminal() || p.Status.IsHeld() {
+			// The final status's webhook was written to the outbox with it
+			return p, e.RelayOutbox(ctx)
+		}
+		if err := ctx.Err(); err != nil {
+			return p, fmt.Errorf("payment %s still %s: %w", paymentID, p.Status, err)
+		}
+	}
+}
+
+// RelayOutbox delivers pending outbox messages with the outbox relay's scheduled sweep
+func (e *Env) RelayOutbox(ctx context.Context) error {
+	return e.Relay.HandleSweep(ctx, events.CloudWatchEvent{})
+}
+
+// ProcessJobs runs the payment jobs delivered within a second through the state machine, as the worker does
+func (e *Env) ProcessJobs(ctx context.Context) error {
+	messages, err := e.receive(ctx, e.Config.Queue.PaymentQueueURL)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		job, err := schema.DecodePaymentJob([]byte(aws.StringValue(message.Body)))
+		if err != nil {
+			return err
+		}
+		if err := e.StateMachine.ProcessPayment(ctx, job); err != nil {
+			logger.Warn("Payment job failed, leaving it for redelivery", logger.Fields{
+				"payment_id": job.PaymentID,
+				"error":      err.Error(),
+			})
+			continue
+		}
+		if err := e.Queue.DeleteMessage(ctx, e.Config.Queue.PaymentQueueURL, aws.StringValue(message.ReceiptHandle)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookEvents takes the webhook events queued so far off the webhook queue
+func (e *Env) WebhookEvents(ctx context.Context) ([]*models.WebhookEvent, error) {
+	var events []*models.WebhookEvent
+	for {
+		messages, err := e.receive(ctx, e.Config.Queue.WebhookQueueURL)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) == 0 {
+			return events, nil
+		}
+
+		for _, message := range messages {
+			event, err := schema.DecodeWebhookEvent([]byte(aws.StringValue(message.Body)))
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			if err := e.Queue.DeleteMessage(ctx, e.Config.Queue.WebhookQueueURL, aws.StringValue(message.ReceiptHandle)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// receive long-polls a queue for up to a second
+func (e *Env) receive(ctx context.Context, queueURL string) ([]*sqs.Message, error) {
+	result, err := e.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: aws.Int64(10),
+		WaitTimeSeconds:     aws.Int64(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Messages, nil
+}
+
+// queueAdapter returns the payment queue as the state machine and queue backend enqueue to it
+func (e *Env) queueAdapter() payment.QueueClient {
+	return queue.NewQueueAdapter(e.Queue, e.Config.Queue.PaymentQueueURL)
+}
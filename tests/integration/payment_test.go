@@ -0,0 +1,110 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/tests/integration/harness"
+)
+
+func newPayment() *models.PaymentRequest {
+	return &models.PaymentRequest{
+		Amount:             100000,
+		Currency:           "EUR",
+		SourceAccount:      "acct_source",
+		DestinationAccount: "acct_dest",
+		Chain:              "base",
+	}
+}
+
+// runPayment creates a payment and works it until it ends, returning it with the webhooks it sent
+func runPayment(t *testing.T, env *harness.Env, req *models.PaymentRequest) (*models.Payment, []*models.WebhookEvent) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	created, err := env.CreatePayment(ctx, req)
+	if err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	final, err := env.Run(ctx, created.PaymentID)
+	if err != nil {
+		t.Fatalf("payment didn't finish: %v", err)
+	}
+	events, err := env.WebhookEvents(ctx)
+	if err != nil {
+		t.Fatalf("failed to read webhooks: %v", err)
+	}
+	return final, events
+}
+
+func hasWebhook(events []*models.WebhookEvent, eventType string) bool {
+	for _, event := range events {
+		if event.EventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPaymentCompletes(t *testing.T) {
+	env := harness.New(t, harness.Options{})
+
+	p, events := runPayment(t, env, newPayment())
+
+	if p.Status != models.StatusCompleted {
+		t.Fatalf("Expected status %s, got %s", models.StatusCompleted, p.Status)
+	}
+	if p.OnRampTxID == "" || p.OffRampTxID == "" {
+		t.Errorf("Expected on-ramp and off-ramp transactions, got %q and %q", p.OnRampTxID, p.OffRampTxID)
+	}
+	if !hasWebhook(events, payment.WebhookPaymentCompleted) {
+		t.Errorf("Expected a %s webhook", payment.WebhookPaymentCompleted)
+	}
+}
+
+func TestPaymentFailsWhenOnRampFails(t *testing.T) {
+	env := harness.New(t, harness.Options{
+		OnRamp: []payment.MockOption{payment.WithAmountScenarios(map[int64]payment.MockScenario{
+			100000: {Outcome: payment.TransferStatusFailed},
+		})},
+	})
+
+	p, events := runPayment(t, env, newPayment())
+
+	if p.Status != models.StatusFailed {
+		t.Fatalf("Expected status %s, got %s", models.StatusFailed, p.Status)
+	}
+	if p.OffRampTxID != "" {
+		t.Errorf("Expected no off-ramp transfer, got %s", p.OffRampTxID)
+	}
+	if !hasWebhook(events, payment.WebhookPaymentFailed) {
+		t.Errorf("Expected a %s webhook", payment.WebhookPaymentFailed)
+	}
+}
+
+func TestPaymentReversedWhenOffRampDeclines(t *testing.T) {
+	env := harness.New(t, harness.Options{
+		OffRamp: []payment.MockOption{payment.WithDestinationScenarios(map[string]payment.MockScenario{
+			"EUR": {Declined: true},
+		})},
+	})
+
+	p, events := runPayment(t, env, newPayment())
+
+	if p.Status != models.StatusFailed {
+		t.Fatalf("Expected status %s, got %s", models.StatusFailed, p.Status)
+	}
+	if p.ReversalTxID == "" {
+		t.Error("Expected the minted USDC to be reversed")
+	}
+	if !hasWebhook(events, payment.WebhookPaymentFailed) {
+		t.Errorf("Expected a %s webhook", payment.WebhookPaymentFailed)
+	}
+}
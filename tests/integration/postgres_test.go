@@ -0,0 +1,354 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/tests/integration/harness"
+	"github.com/jackc/pgx/v5"
+)
+
+// newPostgresRepo migrates a fresh schema and returns a payment repository on it, with a connection for inspecting rows
+func newPostgresRepo(t *testing.T) (*database.PostgresPaymentRepository, *pgx.Conn) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	databaseURL := harness.PostgresSchema(t)
+	client, err := database.NewPostgresClient(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(context.Background()) })
+
+	return database.NewPostgresPaymentRepository(client), conn
+}
+
+func storedPayment(id, scope, idempotencyKey string) *models.Payment {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &models.Payment{
+		PaymentID:          id,
+		IdempotencyKey:     idempotencyKey,
+		IdempotencyScope:   scope,
+		Status:             models.StatusPending,
+		Amount:             100000,
+		Currency:           "EUR",
+		SourceAccount:      "acct_source",
+		DestinationAccount: "acct_dest",
+		Chain:              "base",
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
+
+func appErrorCode(err error) string {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+func TestPostgresCreateAndGetPayment(t *testing.T) {
+	repo, _ := newPostgresRepo(t)
+	ctx := context.Background()
+
+	p := storedPayment("pay_1", "key_a", "idem_1")
+	p.IdempotentResponse = &models.IdempotentResponse{RequestHash: "hash", StatusCode: 201, Body: `{"payment_id":"pay_1"}`}
+	if err := repo.CreatePayment(ctx, p); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+
+	got, err := repo.GetPaymentByID(ctx, "pay_1")
+	if err != nil {
+		t.Fatalf("failed to get payment: %v", err)
+	}
+	if got.Status != models.StatusPending || got.Amount != 100000 || got.Currency != "EUR" || got.Chain != "base" {
+		t.Errorf("payment didn't round-trip: %+v", got)
+	}
+	if got.SourceAccount != "acct_source" || got.DestinationAccount != "acct_dest" {
+		t.Errorf("accounts didn't round-trip: %q, %q", got.SourceAccount, got.DestinationAccount)
+	}
+	if got.IdempotencyScope != "key_a" {
+		t.Errorf("scope = %q, want key_a", got.IdempotencyScope)
+	}
+	if got.IdempotentResponse == nil || got.IdempotentResponse.StatusCode != 201 || got.IdempotentResponse.RequestHash != "hash" {
+		t.Errorf("idempotent response = %+v, want the stored one", got.IdempotentResponse)
+	}
+
+	if _, err := repo.GetPaymentByID(ctx, "pay_missing"); appErrorCode(err) != "PAYMENT_NOT_FOUND" {
+		t.Errorf("missing payment returned %v, want PAYMENT_NOT_FOUND", err)
+	}
+}
+
+func TestPostgresIdempotencyKeysAreScopedToTheirAPIKey(t *testing.T) {
+	repo, _ := newPostgresRepo(t)
+	ctx := context.Background()
+
+	if err := repo.CreatePayment(ctx, storedPayment("pay_a", "key_a", "idem")); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	// The same idempotency key under another API key is a different request
+	if err := repo.CreatePayment(ctx, storedPayment("pay_b", "key_b", "idem")); err != nil {
+		t.Fatalf("same key in another scope was rejected: %v", err)
+	}
+	// Reusing it within a scope is a duplicate
+	if err := repo.CreatePayment(ctx, storedPayment("pay_c", "key_a", "idem")); appErrorCode(err) != "DUPLICATE_REQUEST" {
+		t.Fatalf("duplicate key returned %v, want DUPLICATE_REQUEST", err)
+	}
+
+	for scope, want := range map[string]string{"key_a": "pay_a", "key_b": "pay_b"} {
+		got, err := repo.GetPaymentByIdempotencyKey(ctx, scope, "idem")
+		if err != nil {
+			t.Fatalf("failed to look up %s: %v", scope, err)
+		}
+		if got == nil || got.PaymentID != want {
+			t.Errorf("scope %s found %+v, want %s", scope, got, want)
+		}
+	}
+
+	got, err := repo.GetPaymentByIdempotencyKey(ctx, "key_c", "idem")
+	if err != nil || got != nil {
+		t.Errorf("unknown scope returned %+v, %v; want nothing", got, err)
+	}
+}
+
+func TestPostgresProcessingLocks(t *testing.T) {
+	repo, _ := newPostgresRepo(t)
+	ctx := context.Background()
+
+	if err := repo.CreatePayment(ctx, storedPayment("pay_1", "", "idem_1")); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+
+	// Only a payment still in the expected status can be claimed
+	ok, err := repo.AcquireProcessingLock(ctx, "pay_1", models.StatusOnrampPending, "worker-1", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("lock on the wrong status = %v, %v; want refused", ok, err)
+	}
+
+	ok, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-1", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("first lock = %v, %v; want acquired", ok, err)
+	}
+	ok, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-2", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("second worker's lock = %v, %v; want refused", ok, err)
+	}
+
+	// Another worker can't write over a held lock
+	p, err := repo.GetPaymentByID(ctx, "pay_1")
+	if err != nil {
+		t.Fatalf("failed to get payment: %v", err)
+	}
+	p.LockOwner = "worker-2"
+	if err := repo.UpdatePayment(ctx, p); appErrorCode(err) != "CONFLICT" {
+		t.Errorf("update by another worker returned %v, want CONFLICT", err)
+	}
+
+	// Releasing as someone else leaves the lock in place
+	if err := repo.ReleaseProcessingLock(ctx, "pay_1", "worker-2"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	ok, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-2", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("lock after a foreign release = %v, %v; want refused", ok, err)
+	}
+
+	if err := repo.ReleaseProcessingLock(ctx, "pay_1", "worker-1"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	ok, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-2", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("lock after release = %v, %v; want acquired", ok, err)
+	}
+}
+
+func TestPostgresExpiredProcessingLocksCanBeTakenOver(t *testing.T) {
+	repo, _ := newPostgresRepo(t)
+	ctx := context.Background()
+
+	if err := repo.CreatePayment(ctx, storedPayment("pay_1", "", "idem_1")); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	if ok, err := repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-1", -time.Minute); err != nil || !ok {
+		t.Fatalf("first lock = %v, %v; want acquired", ok, err)
+	}
+	if ok, err := repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker-2", time.Minute); err != nil || !ok {
+		t.Fatalf("lock over an expired one = %v, %v; want acquired", ok, err)
+	}
+}
+
+func TestPostgresOutboxIsWrittenWithThePayment(t *testing.T) {
+	repo, conn := newPostgresRepo(t)
+	ctx := context.Background()
+
+	msg, err := models.NewOutboxMessage("msg_1", models.OutboxKindPaymentJob, "pay_1", map[string]string{"payment_id": "pay_1"})
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+	if err := repo.CreatePaymentWithOutbox(ctx, storedPayment("pay_1", "", "idem_1"), msg); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+
+	// A duplicate rolls back its outbox message along with the payment
+	dup, err := models.NewOutboxMessage("msg_2", models.OutboxKindPaymentJob, "pay_2", map[string]string{"payment_id": "pay_2"})
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+	if err := repo.CreatePaymentWithOutbox(ctx, storedPayment("pay_2", "", "idem_1"), dup); appErrorCode(err) != "DUPLICATE_REQUEST" {
+		t.Fatalf("duplicate returned %v, want DUPLICATE_REQUEST", err)
+	}
+
+	messages, err := repo.ListOutboxMessages(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to list outbox: %v", err)
+	}
+	if len(messages) != 1 || messages[0].MessageID != "msg_1" || messages[0].PaymentID != "pay_1" {
+		t.Fatalf("outbox = %+v, want only msg_1", messages)
+	}
+	if messages[0].Payload != `{"payment_id":"pay_1"}` {
+		t.Errorf("payload = %s", messages[0].Payload)
+	}
+
+	p, err := repo.GetPaymentByID(ctx, "pay_1")
+	if err != nil {
+		t.Fatalf("failed to get payment: %v", err)
+	}
+	p.Status = models.StatusOnrampPending
+	update, err := models.NewOutboxMessage("msg_3", models.OutboxKindPaymentJob, "pay_1", map[string]string{"payment_id": "pay_1"})
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+	if err := repo.UpdatePaymentWithOutbox(ctx, p, update); err != nil {
+		t.Fatalf("failed to update payment: %v", err)
+	}
+
+	if err := repo.DeleteOutboxMessage(ctx, "msg_1"); err != nil {
+		t.Fatalf("failed to delete message: %v", err)
+	}
+	messages, err = repo.ListOutboxMessages(ctx, 10)
+	if err != nil {
+		t.Fatalf("failed to list outbox: %v", err)
+	}
+	if len(messages) != 1 || messages[0].MessageID != "msg_3" {
+		t.Fatalf("outbox = %+v, want only msg_3", messages)
+	}
+
+	var count int
+	if err := conn.QueryRow(ctx, `SELECT count(*) FROM payments`).Scan(&count); err != nil {
+		t.Fatalf("failed to count payments: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d payments stored, want 1", count)
+	}
+}
+
+func TestPostgresMigrationsApplyOnceUnderConcurrentStarts(t *testing.T) {
+	databaseURL := harness.PostgresSchema(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Cold-starting Lambdas migrate the same fresh database at once
+	const starts = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, starts)
+	for i := 0; i < starts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := database.NewPostgresClient(ctx, databaseURL)
+			if err != nil {
+				errs <- err
+				return
+			}
+			client.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent migration failed: %v", err)
+	}
+
+	entries, err := os.ReadDir("../../internal/database/migrations")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	var applied int
+	if err := conn.QueryRow(ctx, `SELECT count(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("failed to count migrations: %v", err)
+	}
+	if applied != len(entries) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(entries))
+	}
+
+}
+
+// fakeArchive serves payments exported before their rows were purged
+type fakeArchive map[string]*models.Payment
+
+func (a fakeArchive) GetArchivedPayment(ctx context.Context, paymentID string) (*models.Payment, error) {
+	return a[paymentID], nil
+}
+
+func TestPostgresReadsPurgedPaymentsFromTheArchive(t *testing.T) {
+	repo, conn := newPostgresRepo(t)
+	ctx := context.Background()
+
+	archived := storedPayment("pay_1", "", "idem_1")
+	archived.Status = models.StatusCompleted
+	repo.EnableRetention(30*24*time.Hour, fakeArchive{"pay_1": archived})
+
+	if err := repo.CreatePayment(ctx, storedPayment("pay_1", "", "idem_1")); err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	if err := repo.MarkPaymentArchived(ctx, "pay_1", time.Now()); err != nil {
+		t.Fatalf("failed to mark archived: %v", err)
+	}
+	var ttl int64
+	if err := conn.QueryRow(ctx, `SELECT ttl FROM payments WHERE payment_id = 'pay_1'`).Scan(&ttl); err != nil {
+		t.Fatalf("failed to read ttl: %v", err)
+	}
+	if ttl <= time.Now().Unix() {
+		t.Errorf("ttl = %d, want the retention period after archiving", ttl)
+	}
+
+	if _, err := conn.Exec(ctx, `DELETE FROM payments WHERE payment_id = 'pay_1'`); err != nil {
+		t.Fatalf("failed to purge payment: %v", err)
+	}
+	got, err := repo.GetPaymentByID(ctx, "pay_1")
+	if err != nil {
+		t.Fatalf("purged payment wasn't read from the archive: %v", err)
+	}
+	if got.Status != models.StatusCompleted {
+		t.Errorf("status = %s, want the archived %s", got.Status, models.StatusCompleted)
+	}
+
+	if _, err := repo.GetPaymentByID(ctx, "pay_2"); appErrorCode(err) != "PAYMENT_NOT_FOUND" {
+		t.Errorf("payment in neither returned %v, want PAYMENT_NOT_FOUND", err)
+	}
+}
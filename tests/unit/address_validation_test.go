@@ -0,0 +1,75 @@
+package unit
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/validator"
+)
+
+func TestValidateAddressRejectsBurnAddresses(t *testing.T) {
+	burns := map[string]string{
+		"0x0000000000000000000000000000000000000000":  "base",
+		"0x000000000000000000000000000000000000dEaD":  "ethereum",
+		"0x000000000000000000000000000000000000DEAD":  "polygon",
+		"11111111111111111111111111111111":            "solana",
+		"1nc1nerator11111111111111111111111111111111": "solana",
+	}
+	for address, chain := range burns {
+		assert.ErrorContains(t, validator.ValidateAddress(chain, address), "burn address", address)
+	}
+}
+
+func TestValidateAddressFieldErrors(t *testing.T) {
+	err := validator.ValidateAddress("base", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA")
+	var appErr *errors.AppError
+	require.True(t, stderrors.As(err, &appErr))
+	require.Len(t, appErr.Fields, 1)
+	assert.Equal(t, "destination_account", appErr.Fields[0].Field)
+	assert.Contains(t, appErr.Fields[0].Reason, "has 38 hex digits, expected 40")
+
+	err = validator.ValidateAddress("solana", "EPjFWdd5AufqSSqeM2qN1xzy")
+	require.True(t, stderrors.As(err, &appErr))
+	assert.Contains(t, appErr.Fields[0].Reason, "expected 32")
+}
+
+func TestValidateIBAN(t *testing.T) {
+	for _, iban := range []string{
+		"DE89370400440532013000",
+		"DE89 3704 0044 0532 0130 00",
+		"fr1420041010050500013m02606",
+		"NL91ABNA0417164300",
+		"GB82WEST12345698765432",
+	} {
+		assert.NoError(t, validator.ValidateIBAN(iban), iban)
+	}
+
+	invalid := map[string]string{
+		"DE88370400440532013000": "check digits",
+		"DE8937040044053201300":  "DE IBANs have 22 characters, got 21",
+		"US64SVBKUS6S3300958879": "not a SEPA country",
+		"DE89-3704-0044":         "not a valid IBAN",
+	}
+	for iban, reason := range invalid {
+		assert.ErrorContains(t, validator.ValidateIBAN(iban), reason, iban)
+	}
+}
+
+func TestValidateEURBankDestination(t *testing.T) {
+	bank := func(destination string) error {
+		return validator.ValidatePaymentRequest(&models.PaymentRequest{
+			Amount:             100000,
+			Currency:           "EUR",
+			SourceAccount:      "user123",
+			DestinationAccount: destination,
+		})
+	}
+
+	assert.NoError(t, bank("DE89370400440532013000"))
+	assert.NoError(t, bank("merchant456"), "provider account references aren't IBANs")
+	assert.ErrorContains(t, bank("DE88370400440532013000"), "check digits")
+}
@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
+
+func TestAICostReport(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	now := time.Now()
+
+	quoteUsage := &models.AIUsage{Model: "claude-sonnet-4-20250514", Corridor: "USD-EUR", Customer: "key_a", Calls: 1, InputTokens: 1000, OutputTokens: 200, CostUSD: 0.006}
+	quoteUsage.Add(&models.AIUsage{Calls: 1, InputTokens: 1000, OutputTokens: 200, CostUSD: 0.006})
+	assert.Equal(t, 2, quoteUsage.Calls)
+	assert.Equal(t, "USD-EUR", quoteUsage.Corridor)
+
+	payments := []*models.Payment{
+		{PaymentID: "pay_1", IdempotencyKey: "k1", CreatedAt: now.Add(-time.Hour), AIUsage: quoteUsage},
+		{PaymentID: "pay_2", IdempotencyKey: "k2", CreatedAt: now.Add(-2 * time.Hour), AIUsage: &models.AIUsage{Corridor: "USD-EUR", Customer: "key_a", Calls: 1, CostUSD: 0.004}},
+		{PaymentID: "pay_3", IdempotencyKey: "k3", CreatedAt: now.Add(-time.Hour), AIUsage: &models.AIUsage{Corridor: "USD-MXN", Customer: "key_b", Calls: 1, CostUSD: 0.02}},
+		{PaymentID: "pay_4", IdempotencyKey: "k4", CreatedAt: now.Add(-time.Hour)},
+		{PaymentID: "pay_5", IdempotencyKey: "k5", CreatedAt: now.Add(-48 * time.Hour), AIUsage: &models.AIUsage{Corridor: "USD-EUR", Customer: "key_a", Calls: 1, CostUSD: 1}},
+	}
+	for _, payment := range payments {
+		require.NoError(t, repo.CreatePayment(ctx, payment))
+	}
+
+	since := now.Add(-24 * time.Hour)
+	usages, err := repo.ListPaymentAIUsage(ctx, since, now)
+	require.NoError(t, err)
+	assert.Len(t, usages, 3, "payments without usage or outside the window are excluded")
+
+	report := fees.BuildAICostReport(usages, since, now)
+	assert.Equal(t, 3, report.Payments)
+	assert.InDelta(t, 0.036, report.TotalCostUSD, 1e-9)
+	require.Len(t, report.Groups, 2)
+
+	assert.Equal(t, "USD-MXN", report.Groups[0].Corridor, "most expensive group first")
+	assert.Equal(t, "USD-EUR", report.Groups[1].Corridor)
+	assert.Equal(t, "key_a", report.Groups[1].Customer)
+	assert.Equal(t, 2, report.Groups[1].Payments)
+	assert.Equal(t, 3, report.Groups[1].Calls)
+	assert.InDelta(t, 0.016, report.Groups[1].CostUSD, 1e-9)
+}
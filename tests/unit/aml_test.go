@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/compliance/rules"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func amlPayment(id, customerID string, amount int64, createdAt time.Time) *models.Payment {
+	return &models.Payment{
+		PaymentID:      id,
+		IdempotencyKey: "key_" + id,
+		Amount:         amount,
+		Currency:       "EUR",
+		CustomerID:     customerID,
+		Status:         models.StatusPending,
+		CreatedAt:      createdAt,
+	}
+}
+
+func TestStructuringRule(t *testing.T) {
+	rule := rules.Structuring{Threshold: 1000000, Margin: 0.1, Count: 3}
+	now := time.Now()
+	near := []*models.Payment{
+		amlPayment("pay_1", "cust_1", 950000, now),
+		amlPayment("pay_2", "cust_1", 990000, now),
+	}
+
+	finding := rule.Evaluate(amlPayment("pay_3", "cust_1", 900000, now), near)
+	require.NotNil(t, finding, "three payments just under the threshold")
+	assert.Equal(t, models.AMLSeverityHigh, finding.Severity)
+	assert.Contains(t, finding.Detail, "3 payments")
+
+	assert.Nil(t, rule.Evaluate(amlPayment("pay_3", "cust_1", 899999, now), near), "below the margin isn't near the threshold")
+	assert.Nil(t, rule.Evaluate(amlPayment("pay_3", "cust_1", 1000000, now), near), "at the threshold is reported anyway")
+	assert.Nil(t, rule.Evaluate(amlPayment("pay_3", "cust_1", 950000, now), near[:1]), "two near-threshold payments are under the count")
+}
+
+func TestVelocityRule(t *testing.T) {
+	now := time.Now()
+	history := []*models.Payment{
+		amlPayment("pay_1", "cust_1", 100000, now),
+		amlPayment("pay_2", "cust_1", 100000, now),
+	}
+
+	finding := rules.Velocity{MaxCount: 2}.Evaluate(amlPayment("pay_3", "cust_1", 100000, now), history)
+	require.NotNil(t, finding)
+	assert.Contains(t, finding.Detail, "3 payments")
+	assert.Nil(t, rules.Velocity{MaxCount: 3}.Evaluate(amlPayment("pay_3", "cust_1", 100000, now), history))
+
+	finding = rules.Velocity{MaxAmount: 250000}.Evaluate(amlPayment("pay_3", "cust_1", 100000, now), history)
+	require.NotNil(t, finding, "the window's total is over the amount limit")
+	assert.Equal(t, models.AMLSeverityMedium, finding.Severity)
+	assert.Nil(t, rules.Velocity{}.Evaluate(amlPayment("pay_3", "cust_1", 100000, now), history), "no limits set")
+}
+
+func TestHighRiskCountryRule(t *testing.T) {
+	rule := rules.HighRiskCountry{Risks: fees.NewMockDataProvider(), Corridors: corridors.Default(), MinScore: 6.0}
+
+	p := amlPayment("pay_1", "cust_1", 100000, time.Now())
+	p.Beneficiary = &models.Beneficiary{Name: "Ada Obi", Country: "NG"}
+	finding := rule.Evaluate(p, nil)
+	require.NotNil(t, finding)
+	assert.Contains(t, finding.Detail, "NG")
+
+	p.Beneficiary = nil
+	assert.Nil(t, rule.Evaluate(p, nil), "the EUR corridor pays out in Germany")
+
+	rule.MinScore = 1.0
+	finding = rule.Evaluate(p, nil)
+	require.NotNil(t, finding, "the corridor's country is scored without a beneficiary")
+	assert.Contains(t, finding.Detail, "DE")
+}
+
+func TestEngineRecordsEachAlertOnce(t *testing.T) {
+	ctx := context.Background()
+	payments := database.NewMemoryPaymentRepository()
+	store := database.NewMemoryAMLAlertRepository()
+	engine := rules.NewEngine(payments, store, 24*time.Hour, rules.Velocity{MaxCount: 2})
+
+	now := time.Now()
+	require.NoError(t, payments.CreatePayment(ctx, amlPayment("pay_old", "cust_1", 100000, now.Add(-48*time.Hour))))
+	require.NoError(t, payments.CreatePayment(ctx, amlPayment("pay_other", "cust_2", 100000, now)))
+	var last *models.Payment
+	for i := 1; i <= 3; i++ {
+		last = amlPayment(fmt.Sprintf("pay_%d", i), "cust_1", 100000, now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, payments.CreatePayment(ctx, last))
+	}
+
+	raised, err := engine.Evaluate(ctx, last)
+	require.NoError(t, err)
+	require.Len(t, raised, 1, "payments outside the window or of other customers don't count")
+	assert.Equal(t, "pay_3:velocity", raised[0].AlertID)
+	assert.Equal(t, "cust_1", raised[0].CustomerID)
+	assert.Equal(t, models.AMLAlertStatusOpen, raised[0].Status)
+
+	raised, err = engine.Evaluate(ctx, last)
+	require.NoError(t, err)
+	assert.Empty(t, raised, "re-evaluating records nothing twice")
+
+	require.NoError(t, store.CloseAlert(ctx, "pay_3:velocity", "payroll run", "officer", time.Now()))
+	err = store.CloseAlert(ctx, "pay_3:velocity", "again", "officer", time.Now())
+	assert.Error(t, err, "an alert closes once")
+
+	closed, err := store.ListAlerts(ctx, models.AMLAlertStatusClosed)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, "payroll run", closed[0].Resolution)
+	assert.Equal(t, "officer", closed[0].ResolvedBy)
+}
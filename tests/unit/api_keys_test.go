@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryAPIKeyRepository()
+	service := apikeys.New(store, time.Hour)
+
+	issued, err := service.Issue(ctx, "cust_acme", &models.APIKeyRequest{Name: " production "})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Secret, models.APIKeySecretPrefix))
+	assert.True(t, strings.HasPrefix(issued.Secret, issued.Prefix))
+	assert.Equal(t, "production", issued.Name)
+	assert.Equal(t, models.APIKeyStatusActive, issued.Status)
+
+	// Keys are stored, and found, by the hash of their secret
+	stored, err := store.GetAPIKey(ctx, apikeys.Hash(issued.Secret))
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, issued.KeyID, stored.KeyID)
+
+	key, err := service.Authenticate(ctx, issued.Secret)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, "cust_acme", key.CustomerID)
+	require.NotNil(t, key.LastUsedAt, "use is recorded")
+
+	key, err = service.Authenticate(ctx, issued.Secret+"x")
+	require.NoError(t, err)
+	assert.Nil(t, key, "unknown secrets authenticate no one")
+
+	// A rotated key keeps working through the grace period alongside its replacement
+	replacement, err := service.Rotate(ctx, "cust_acme", issued.KeyID)
+	require.NoError(t, err)
+	assert.Equal(t, "production", replacement.Name)
+	assert.NotEqual(t, issued.Secret, replacement.Secret)
+	for _, secret := range []string{issued.Secret, replacement.Secret} {
+		key, err = service.Authenticate(ctx, secret)
+		require.NoError(t, err)
+		assert.NotNil(t, key)
+	}
+
+	keys, err := service.List(ctx, "cust_acme")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, models.APIKeyStatusRotating, keys[0].Status)
+	assert.Equal(t, replacement.KeyID, keys[0].RotatedTo)
+
+	_, err = service.Rotate(ctx, "cust_acme", issued.KeyID)
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Code, "a key is rotated once")
+
+	revoked, err := service.Revoke(ctx, "cust_acme", replacement.KeyID)
+	require.NoError(t, err)
+	assert.Equal(t, models.APIKeyStatusRevoked, revoked.Status)
+	key, err = service.Authenticate(ctx, replacement.Secret)
+	require.NoError(t, err)
+	assert.Nil(t, key, "revoked keys are refused")
+
+	// Another customer's keys are hidden
+	_, err = service.Revoke(ctx, "cust_other", issued.KeyID)
+	appErr, ok = err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "API_KEY_NOT_FOUND", appErr.Code)
+}
+
+func TestAPIKeyStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.Equal(t, models.APIKeyStatusActive, (&models.APIKey{}).Status(now))
+	assert.Equal(t, models.APIKeyStatusRotating, (&models.APIKey{ExpiresAt: &future}).Status(now))
+	assert.Equal(t, models.APIKeyStatusExpired, (&models.APIKey{ExpiresAt: &past}).Status(now))
+	assert.Equal(t, models.APIKeyStatusRevoked, (&models.APIKey{ExpiresAt: &future, RevokedAt: &past}).Status(now))
+	assert.False(t, (&models.APIKey{ExpiresAt: &past}).Usable(now))
+}
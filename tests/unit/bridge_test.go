@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBridgeStateMachine builds a state machine over a single payment minted on chain for an off-ramp on offRampChain
+func newBridgeStateMachine(t *testing.T, status models.PaymentStatus, chain, offRampChain string, bridging bool) (*payment.StateMachine, database.PaymentRepository, *recordingQueue) {
+	var bridgeTxID string
+	if status == models.StatusBridgeComplete {
+		bridgeTxID = "cctp_done"
+	}
+
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(context.Background(), &models.Payment{
+		PaymentID:      "pay_bridge",
+		IdempotencyKey: "key_bridge",
+		Amount:         100000,
+		Currency:       "BRL",
+		Status:         status,
+		ExpectedRate:   5.0,
+		Chain:          chain,
+		OffRampChain:   offRampChain,
+		BridgeTxID:     bridgeTxID,
+	}))
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, fixedRateSource{rate: 4.5},
+		payment.SlippageConfig{MaxSlippage: 0.01, Action: payment.SlippageActionReview})
+	if bridging {
+		sm.EnableBridging(payment.NewStatefulBridgeClient())
+	}
+	return sm, repo, queue
+}
+
+func TestPaymentNeedsBridge(t *testing.T) {
+	assert.True(t, (&models.Payment{Chain: "base", OffRampChain: "polygon"}).NeedsBridge())
+	assert.False(t, (&models.Payment{Chain: "base", OffRampChain: "base"}).NeedsBridge())
+	assert.False(t, (&models.Payment{Chain: "base"}).NeedsBridge(), "no off-ramp chain means the off-ramp redeems on Chain")
+}
+
+func TestCorridorOfframpChainFor(t *testing.T) {
+	corridor := corridors.Corridor{Chains: []string{"base", "polygon", "solana"}, OfframpChains: []string{"solana", "polygon"}}
+
+	assert.Equal(t, "", corridor.OfframpChainFor("polygon"), "accepted chains need no bridge")
+	assert.Equal(t, "solana", corridor.OfframpChainFor("base"), "bridges to the preferred off-ramp chain")
+	assert.Equal(t, "", corridor.OfframpChainFor(""))
+	assert.Equal(t, "", corridors.Corridor{Chains: []string{"base"}}.OfframpChainFor("base"), "no off-ramp chains accepts any chain")
+}
+
+func TestOnrampCompleteBridgesToOfframpChain(t *testing.T) {
+	ctx := context.Background()
+	sm, repo, queue := newBridgeStateMachine(t, models.StatusOnrampComplete, "base", "polygon", true)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_bridge"}))
+
+	stored, err := repo.GetPaymentByID(ctx, "pay_bridge")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusBridgePending, stored.Status)
+	assert.Contains(t, stored.BridgeTxID, "cctp_6_7_", "burned on Base's CCTP domain for Polygon's")
+	assert.Empty(t, stored.OffRampTxID, "off-ramp waits for the mint")
+	require.Len(t, queue.jobs, 1)
+
+	// Base attests within two polls
+	for i := 0; i < 2 && stored.Status == models.StatusBridgePending; i++ {
+		require.NoError(t, sm.ProcessPayment(ctx, queue.jobs[len(queue.jobs)-1]))
+		stored, err = repo.GetPaymentByID(ctx, "pay_bridge")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.StatusBridgeComplete, stored.Status)
+	assert.Positive(t, stored.BridgePollCount)
+}
+
+func TestBridgeCompleteProceedsToOfframpChecks(t *testing.T) {
+	ctx := context.Background()
+	sm, repo, queue := newBridgeStateMachine(t, models.StatusBridgeComplete, "base", "polygon", true)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_bridge"}))
+
+	// The rate slipped 10%, so the payment is held rather than bridged again
+	stored, err := repo.GetPaymentByID(ctx, "pay_bridge")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusRequiresReview, stored.Status)
+	assert.Equal(t, "cctp_done", stored.BridgeTxID)
+	assert.Empty(t, queue.jobs)
+}
+
+func TestBridgeReversesWhenItCannotStart(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		offRampChain string
+		bridging     bool
+		reason       string
+	}{
+		"bridging disabled": {offRampChain: "polygon", bridging: false, reason: "bridging from base is not enabled"},
+		"unsupported chain": {offRampChain: "tron", bridging: true, reason: "CCTP does not support tron"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sm, repo, queue := newBridgeStateMachine(t, models.StatusOnrampComplete, "base", tc.offRampChain, tc.bridging)
+
+			require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_bridge"}))
+
+			stored, err := repo.GetPaymentByID(ctx, "pay_bridge")
+			require.NoError(t, err)
+			assert.Equal(t, models.StatusReversing, stored.Status)
+			assert.Contains(t, stored.ErrorMessage, tc.reason)
+			assert.Empty(t, stored.BridgeTxID)
+			assert.Len(t, queue.jobs, 1)
+		})
+	}
+}
+
+// recordingBridge records what each transfer bridged
+type recordingBridge struct {
+	*payment.StatefulBridgeClient
+	amounts []int64
+}
+
+func (b *recordingBridge) InitiateTransfer(ctx context.Context, stablecoinAmount int64, sourceChain, destinationChain string) (string, error) {
+	b.amounts = append(b.amounts, stablecoinAmount)
+	return b.StatefulBridgeClient.InitiateTransfer(ctx, stablecoinAmount, sourceChain, destinationChain)
+}
+
+func TestBridgeMovesMintedUSDCForEURFunding(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_bridge_eur",
+		IdempotencyKey: "key_bridge_eur",
+		Amount:         100000,
+		SourceCurrency: "EUR",
+		Currency:       "BRL",
+		Status:         models.StatusOnrampComplete,
+		Chain:          "base",
+		OffRampChain:   "polygon",
+	}))
+
+	bridge := &recordingBridge{StatefulBridgeClient: payment.NewStatefulBridgeClient()}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableBridging(bridge)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_bridge_eur"}))
+
+	stored, err := repo.GetPaymentByID(ctx, "pay_bridge_eur")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusBridgePending, stored.Status)
+	assert.Equal(t, []int64{108750}, bridge.amounts, "€1,000.00 mints $1,087.50 of USDC at the mock EUR rate")
+}
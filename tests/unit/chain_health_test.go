@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/chains"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+)
+
+func settlement(chain string, status models.PaymentStatus, latency time.Duration) *models.Settlement {
+	finished := time.Now().Add(-10 * time.Minute)
+	return &models.Settlement{
+		PaymentID:  "pay_" + chain,
+		Chain:      chain,
+		Status:     status,
+		CreatedAt:  finished.Add(-latency),
+		FinishedAt: finished,
+	}
+}
+
+func TestChainHealthScoresHealthyChain(t *testing.T) {
+	health := chains.Score("base", chains.Signals{
+		Gas:            &chains.GasSignal{Standard: 0.01, Fast: 0.011, Baseline: 0.01},
+		ProviderStatus: chains.ProviderOperational,
+	}, []*models.Settlement{
+		settlement("base", models.StatusCompleted, 3*time.Minute),
+		settlement("base", models.StatusCompleted, 4*time.Minute),
+		settlement("base", models.StatusCompleted, 4*time.Minute),
+	})
+
+	assert.Equal(t, chains.LevelHealthy, health.Level)
+	assert.Equal(t, 100.0, health.Score)
+	assert.Equal(t, 3, health.Settlements)
+	assert.Equal(t, "4m0s", health.MedianSettlement)
+	assert.Empty(t, health.Reasons)
+}
+
+func TestChainHealthPenalizesGasSlowSettlementsAndTimeouts(t *testing.T) {
+	health := chains.Score("ethereum", chains.Signals{
+		Gas:            &chains.GasSignal{Standard: 90, Baseline: 30}, // 3x normal
+		ProviderStatus: chains.ProviderOperational,
+	}, []*models.Settlement{
+		settlement("ethereum", models.StatusCompleted, 20*time.Minute),
+		settlement("ethereum", models.StatusCompleted, 24*time.Minute),
+		settlement("ethereum", models.StatusTimedOut, 30*time.Minute),
+		settlement("ethereum", models.StatusCompleted, 20*time.Minute),
+	})
+
+	assert.Equal(t, chains.LevelDegraded, health.Level)
+	assert.Equal(t, 0.0, health.Components["gas"])
+	assert.Equal(t, 18.75, health.Components["settlement"]) // 20m median at 2.5x expected, a quarter timed out
+	assert.Equal(t, 0.25, health.TimeoutRate)
+	assert.Len(t, health.Reasons, 3)
+}
+
+func TestChainHealthUsesFastSpreadWithoutHistory(t *testing.T) {
+	health := chains.Score("polygon", chains.Signals{
+		Gas: &chains.GasSignal{Standard: 100, Fast: 212.5}, // Spread at the midpoint of floor and ceiling
+	}, nil)
+
+	assert.Equal(t, 50.0, health.Components["gas"])
+	assert.Equal(t, chains.LevelCongested, health.Level)
+	assert.Zero(t, health.Settlements, "too few settlements to score")
+}
+
+func TestChainHealthOutageIsDegraded(t *testing.T) {
+	health := chains.Score("solana", chains.Signals{
+		Gas:            &chains.GasSignal{Standard: 0.000005, Baseline: 0.000005},
+		ProviderStatus: chains.ProviderOutage,
+	}, nil)
+
+	assert.Equal(t, chains.LevelDegraded, health.Level)
+	assert.Contains(t, health.Reasons, "provider outage")
+}
+
+func TestChainHealthUnknownWithoutSignals(t *testing.T) {
+	health := chains.Score("arbitrum", chains.Signals{}, nil)
+	assert.Equal(t, chains.LevelUnknown, health.Level)
+	assert.Zero(t, health.Score)
+}
+
+func TestBestChainPrefersHealthyOverDegradedAndUnknown(t *testing.T) {
+	healths := map[string]*chains.Health{
+		"base":     {Chain: "base", Score: 30, Level: chains.LevelDegraded},
+		"polygon":  {Chain: "polygon", Score: 60, Level: chains.LevelCongested},
+		"arbitrum": {Chain: "arbitrum", Level: chains.LevelUnknown},
+	}
+
+	assert.Equal(t, "polygon", chains.Best(healths, []string{"base", "polygon", "arbitrum"}))
+	assert.Equal(t, "arbitrum", chains.Best(healths, []string{"base", "arbitrum"}))
+	assert.Equal(t, "base", chains.Best(healths, []string{"base"}), "a degraded chain is still returned when it's the only one")
+	assert.Equal(t, "", chains.Best(healths, nil))
+}
+
+func TestChainMonitorScoresRecentSettlements(t *testing.T) {
+	repo := database.NewMemoryPaymentRepository()
+	ctx := context.Background()
+	now := time.Now()
+	for i, age := range []time.Duration{5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 3 * time.Hour} {
+		processed := now.Add(-age)
+		id := "pay_" + string(rune('a'+i))
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+			PaymentID:      id,
+			IdempotencyKey: id,
+			Status:         models.StatusCompleted,
+			Chain:          "base",
+			CreatedAt:      processed.Add(-20 * time.Minute),
+			ProcessedAt:    &processed,
+		}))
+	}
+	// Still in flight, so not a settlement
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_pending",
+		IdempotencyKey: "pay_pending",
+		Status:         models.StatusProcessing,
+		Chain:          "base",
+		CreatedAt:      now,
+	}))
+
+	settlements, err := repo.ListSettlements(ctx, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, settlements, 3)
+
+	healths := chains.NewMonitor(repo).Health(ctx, map[string]chains.Signals{
+		"base":    {ProviderStatus: chains.ProviderOperational},
+		"polygon": {},
+	})
+	require.Len(t, healths, 2)
+	assert.Equal(t, 3, healths["base"].Settlements)
+	assert.Equal(t, 0.0, healths["base"].Components["settlement"], "20m settlements are 5x the expected time")
+	assert.Equal(t, chains.LevelUnknown, healths["polygon"].Level)
+}
+
+func TestSettlementFromPaymentRequiresTerminalPaymentWithChain(t *testing.T) {
+	processed := time.Now()
+	payment := &models.Payment{
+		PaymentID:   "pay_1",
+		Status:      models.StatusTimedOut,
+		Chain:       "solana",
+		CreatedAt:   processed.Add(-time.Minute),
+		ProcessedAt: &processed,
+	}
+
+	s, ok := models.SettlementFromPayment(payment)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, s.Latency())
+
+	payment.Status = models.StatusFailed
+	_, ok = models.SettlementFromPayment(payment)
+	assert.False(t, ok)
+
+	payment.Status = models.StatusCompleted
+	payment.Chain = ""
+	_, ok = models.SettlementFromPayment(payment)
+	assert.False(t, ok)
+}
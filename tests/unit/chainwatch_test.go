@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/chainwatch"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+)
+
+// rpcServer answers JSON-RPC requests from a method -> result map
+func rpcServer(t *testing.T, results map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result, ok := results[req.Method]
+		if !ok {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%s}`, result)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChainWatchEVMConfirmations(t *testing.T) {
+	server := rpcServer(t, map[string]string{
+		"eth_getTransactionReceipt": `{"blockNumber": "0x64", "status": "0x1"}`,
+		"eth_blockNumber":           `"0x6d"`,
+		"eth_getBlockByNumber":      `{"number": "0x63"}`,
+	})
+	watcher := chainwatch.New(map[string]chainwatch.Client{
+		"base":     chainwatch.NewEVMClient("base", server.URL),
+		"ethereum": chainwatch.NewEVMClient("ethereum", server.URL),
+	}, nil)
+	ctx := context.Background()
+
+	base, err := watcher.Check(ctx, "base", "0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), base.BlockNumber)
+	assert.Equal(t, 10, base.Confirmations)
+	assert.True(t, base.Final, "base needs 10 confirmations")
+
+	ethereum, err := watcher.Check(ctx, "ethereum", "0xabc")
+	require.NoError(t, err)
+	assert.False(t, ethereum.Final, "ethereum waits for the finalized block to pass the transaction")
+
+	unwatched, err := watcher.Check(ctx, "polygon", "0xabc")
+	require.NoError(t, err)
+	assert.Nil(t, unwatched)
+}
+
+func TestChainWatchEVMPendingAndReverted(t *testing.T) {
+	ctx := context.Background()
+
+	pending := chainwatch.New(map[string]chainwatch.Client{
+		"avalanche": chainwatch.NewEVMClient("avalanche", rpcServer(t, map[string]string{"eth_getTransactionReceipt": `null`}).URL),
+	}, nil)
+	confirmation, err := pending.Check(ctx, "avalanche", "0xabc")
+	require.NoError(t, err)
+	assert.Zero(t, confirmation.BlockNumber)
+	assert.False(t, confirmation.Final, "not mined yet")
+
+	reverted := chainwatch.New(map[string]chainwatch.Client{
+		"avalanche": chainwatch.NewEVMClient("avalanche", rpcServer(t, map[string]string{
+			"eth_getTransactionReceipt": `{"blockNumber": "0x10", "status": "0x0"}`,
+			"eth_blockNumber":           `"0x20"`,
+		}).URL),
+	}, nil)
+	confirmation, err = reverted.Check(ctx, "avalanche", "0xabc")
+	require.NoError(t, err)
+	assert.True(t, confirmation.Reverted)
+	assert.False(t, confirmation.Final, "reverted transactions are never final")
+}
+
+func TestChainWatchSolanaFinalized(t *testing.T) {
+	server := rpcServer(t, map[string]string{
+		"getSignatureStatuses": `{"context": {"slot": 250}, "value": [{"slot": 200, "confirmations": null, "err": null, "confirmationStatus": "finalized"}]}`,
+	})
+	watcher := chainwatch.NewFromConfig(map[string]string{"solana": server.URL}, nil)
+
+	confirmation, err := watcher.Check(context.Background(), "solana", "5sig")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(200), confirmation.BlockNumber)
+	assert.Equal(t, 32, confirmation.Confirmations)
+	assert.True(t, confirmation.Final)
+	assert.False(t, watcher.Watches("base"), "only chains with an RPC URL are watched")
+}
+
+func TestChainWatchConfirmationOverride(t *testing.T) {
+	watcher := chainwatch.NewFromConfig(nil, map[string]int{"Ethereum": 32})
+	assert.Equal(t, chainwatch.Rule{Confirmations: 32}, watcher.Rule("ethereum"))
+	assert.Equal(t, chainwatch.DefaultRules["base"], watcher.Rule("base"))
+	assert.True(t, watcher.Watches("solana"), "every default chain is simulated without RPC URLs")
+}
+
+func TestOnrampWaitsForFinality(t *testing.T) {
+	ctx := context.Background()
+	onRamp := payment.NewStatefulOnRampClient()
+
+	// The mock on-ramp fails a few percent of transfers at random, so retry until one settles
+	for attempt := 0; attempt < 20; attempt++ {
+		txID, err := onRamp.InitiateTransfer(ctx, 100000, "USD")
+		if err != nil {
+			continue
+		}
+
+		repo := database.NewMemoryPaymentRepository()
+		id := fmt.Sprintf("pay_final_%d", attempt)
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+			PaymentID:      id,
+			IdempotencyKey: id,
+			Amount:         100000,
+			Currency:       "EUR",
+			Status:         models.StatusOnrampPending,
+			Chain:          "base",
+			OnRampTxID:     txID,
+			CreatedAt:      time.Now(),
+		}))
+
+		queue := &recordingQueue{}
+		sm := payment.NewStateMachine(onRamp, payment.NewStatefulOffRampClient(), repo, queue,
+			payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+		sm.EnableChainWatch(chainwatch.New(map[string]chainwatch.Client{"base": chainwatch.NewSimulatedClient()}, nil))
+
+		var stored *models.Payment
+		awaited := false
+		for i := 0; i < 10; i++ {
+			require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: id}))
+			stored, err = repo.GetPaymentByID(ctx, id)
+			require.NoError(t, err)
+			if stored.Status != models.StatusOnrampPending {
+				break
+			}
+			if stored.OnRampConfirmation != nil && !stored.OnRampConfirmation.Final {
+				awaited = true
+			}
+		}
+		if stored.Status == models.StatusFailed {
+			continue
+		}
+
+		assert.Equal(t, models.StatusOnrampComplete, stored.Status)
+		assert.True(t, awaited, "settled on-ramp held until the mint was final")
+		require.NotNil(t, stored.OnRampConfirmation)
+		assert.True(t, stored.OnRampConfirmation.Final)
+		assert.Positive(t, stored.OnRampConfirmation.BlockNumber)
+		assert.Contains(t, stored.OnRampConfirmation.TxHash, "0x")
+		return
+	}
+	t.Fatal("no on-ramp transfer settled")
+}
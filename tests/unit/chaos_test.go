@@ -0,0 +1,199 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/chaos"
+	"crypto-conversion/internal/config"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosInjectsFailures(t *testing.T) {
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointDatabase: {FailureRate: 1}}, nil, 1)
+	ctx := context.Background()
+
+	err := injector.Inject(ctx, chaos.PointDatabase, "UpdatePayment", "pay_1")
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+
+	// Points without a fault pass through
+	assert.NoError(t, injector.Inject(ctx, chaos.PointQueue, "EnqueuePaymentWithDelay", "pay_1"))
+}
+
+func TestChaosTargetsPayments(t *testing.T) {
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointProvider: {FailureRate: 1}}, []string{"pay_target"}, 1)
+	ctx := context.Background()
+
+	assert.Error(t, injector.Inject(ctx, chaos.PointProvider, "OnRamp.InitiateTransfer", "pay_target"))
+	assert.NoError(t, injector.Inject(ctx, chaos.PointProvider, "OnRamp.InitiateTransfer", "pay_other"))
+
+	// Calls that don't name their payment are matched on the payment ctx is tagged with
+	assert.Error(t, injector.Inject(chaos.WithPaymentID(ctx, "pay_target"), chaos.PointProvider, "OnRamp.InitiateTransfer", ""))
+	assert.NoError(t, injector.Inject(ctx, chaos.PointProvider, "OnRamp.InitiateTransfer", ""))
+}
+
+func TestChaosSameSeedSameFaults(t *testing.T) {
+	faults := map[chaos.Point]chaos.Fault{chaos.PointQueue: {FailureRate: 0.5}}
+	run := func() []bool {
+		injector := chaos.New(faults, nil, 99)
+		var failed []bool
+		for i := 0; i < 40; i++ {
+			failed = append(failed, injector.Inject(context.Background(), chaos.PointQueue, "EnqueuePaymentWithDelay", "pay_1") != nil)
+		}
+		return failed
+	}
+
+	first := run()
+	assert.Equal(t, first, run())
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
+
+func TestChaosInjectsDelays(t *testing.T) {
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointDataSource: {DelayRate: 1, Delay: 20 * time.Millisecond}}, nil, 1)
+
+	start := time.Now()
+	require.NoError(t, injector.Inject(context.Background(), chaos.PointDataSource, "ExecutableRate", ""))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// A delay ends early with the caller's context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, injector.Inject(ctx, chaos.PointDataSource, "ExecutableRate", ""), context.Canceled)
+}
+
+func TestChaosFromConfig(t *testing.T) {
+	injector := chaos.NewFromConfig(config.ChaosConfig{
+		Enabled:      true,
+		FailureRates: map[string]float64{"database": 1},
+		Payments:     []string{"pay_target"},
+		Seed:         1,
+	})
+	ctx := context.Background()
+
+	assert.Error(t, injector.Inject(ctx, chaos.PointDatabase, "GetPaymentByID", "pay_target"))
+	assert.NoError(t, injector.Inject(ctx, chaos.PointProvider, "OnRamp.GetTransferStatus", "pay_target"))
+}
+
+func TestChaosDatabaseFailureLeavesPaymentToRedelivery(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID: "pay_chaos",
+		Amount:    100000,
+		Currency:  "EUR",
+		Status:    models.StatusPending,
+	}, payment.DefaultPollingConfig())
+
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointDatabase: {FailureRate: 1}}, nil, 1)
+	sm := payment.NewStateMachine(f.onRamp, f.offRamp, chaos.Database(f.repo, injector), f.queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+
+	err := sm.ProcessPayment(context.Background(), &models.PaymentJob{PaymentID: "pay_chaos"})
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.Empty(t, f.onRamp.transfers)
+	assert.Empty(t, f.queue.jobs)
+
+	// The redelivered job runs once the database recovers
+	require.NoError(t, f.step(t, "pay_chaos"))
+	stored, err := f.repo.GetPaymentByID(context.Background(), "pay_chaos")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampPending, stored.Status)
+}
+
+func TestChaosProviderPollFailureDoesNotFailPayment(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:  "pay_chaos",
+		Amount:     100000,
+		Currency:   "EUR",
+		Status:     models.StatusOnrampPending,
+		OnRampTxID: "tx_1",
+	}, payment.DefaultPollingConfig())
+	f.onRamp.status = payment.TransferStatusSettled
+
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointProvider: {FailureRate: 1}}, []string{"pay_chaos"}, 1)
+	sm := payment.NewStateMachine(chaos.OnRamp(f.onRamp, injector), f.offRamp, f.repo, f.queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+
+	ctx := chaos.WithPaymentID(context.Background(), "pay_chaos")
+	err := sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_chaos"})
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.Zero(t, f.onRamp.polls)
+
+	stored, err := f.repo.GetPaymentByID(context.Background(), "pay_chaos")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampPending, stored.Status)
+
+	// The redelivered job polls again once the provider recovers
+	require.NoError(t, f.step(t, "pay_chaos"))
+	stored, err = f.repo.GetPaymentByID(context.Background(), "pay_chaos")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampComplete, stored.Status)
+}
+
+func TestChaosReachesSandboxPayments(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:  "pay_chaos",
+		Amount:     100000,
+		Currency:   "EUR",
+		Status:     models.StatusOnrampPending,
+		OnRampTxID: "tx_1",
+		Sandbox:    true,
+	}, payment.DefaultPollingConfig())
+
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointProvider: {FailureRate: 1}}, []string{"pay_chaos"}, 1)
+	sm := payment.NewStateMachine(chaos.OnRamp(f.onRamp, injector), f.offRamp, f.repo, f.queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableSandbox(chaos.Sandbox(payment.NewSandbox(), injector))
+
+	// The sandbox on-ramp is faulted like the live one, which the payment never reaches
+	ctx := chaos.WithPaymentID(context.Background(), "pay_chaos")
+	err := sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_chaos"})
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.Zero(t, f.onRamp.polls)
+
+	stored, err := f.repo.GetPaymentByID(context.Background(), "pay_chaos")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampPending, stored.Status)
+}
+
+func TestChaosSandboxWrapsEveryProvider(t *testing.T) {
+	injector := chaos.New(map[chaos.Point]chaos.Fault{chaos.PointProvider: {FailureRate: 1}}, nil, 1)
+	sandbox := chaos.Sandbox(payment.NewSandbox(), injector)
+	p := &models.Payment{PaymentID: "pay_1", Amount: 100000}
+	ctx := context.Background()
+
+	_, err := sandbox.OnRamp(p).InitiateTransfer(ctx, 100000, "USD")
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+	_, err = sandbox.OffRamp(p).InitiateTransfer(ctx, 100000, "EUR")
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+	_, err = sandbox.Bridge().InitiateTransfer(ctx, 100000, "base", "ethereum")
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+	_, err = sandbox.Wallet().InitiateTransfer(ctx, 100000, "base", "0xabc")
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+}
+
+func TestConfigRejectsMalformedChaosRates(t *testing.T) {
+	t.Setenv("PAYMENT_QUEUE_URL", "https://sqs.us-east-1.amazonaws.com/123/payments")
+
+	t.Setenv("CHAOS_FAILURE_RATES", "database=0.05, provider=1")
+	t.Setenv("CHAOS_DELAY_RATES", "queue=0")
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"database": 0.05, "provider": 1}, cfg.Chaos.FailureRates)
+	assert.Empty(t, cfg.Chaos.DelayRates)
+
+	for _, rates := range []string{"provder=0.2", "provider=0,2", "provider=abc", "provider=1.5", "provider=-0.1", "provider"} {
+		t.Setenv("CHAOS_FAILURE_RATES", rates)
+		_, err := config.Load()
+		assert.Error(t, err, rates)
+	}
+
+	t.Setenv("CHAOS_FAILURE_RATES", "")
+	t.Setenv("CHAOS_DELAY_RATES", "datasource=half")
+	_, err = config.Load()
+	assert.ErrorContains(t, err, "CHAOS_DELAY_RATES")
+}
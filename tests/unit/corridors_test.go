@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/corridors"
+)
+
+func TestDefaultRegistryLookup(t *testing.T) {
+	registry := corridors.Default()
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{"USD to EUR", "USD", "EUR", false},
+		{"USD to GBP", "USD", "GBP", false},
+		{"EUR to USD", "EUR", "USD", false},
+		{"lowercase gbp", "usd", "gbp", false},
+		{"unsupported pair", "EUR", "GBP", true},
+		{"unsupported payout", "USD", "JPY", true},
+		{"empty payout", "USD", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Lookup(tt.from, tt.to)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseJSONRegistry(t *testing.T) {
+	registry, err := corridors.ParseJSON([]byte(`[
+		{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "EUR", "enabled": true,
+		 "rate_providers": ["Circle"], "mid_market_rate": 0.92, "min_amount": 1000, "max_amount": 500000,
+		 "fee_schedule": [{"up_to": 0, "rate": 0.01, "fixed_fee": 10}]},
+		{"corridor_id": "USD-GBP", "source_currency": "USD", "destination_currency": "GBP", "enabled": false,
+		 "rate_providers": ["Circle"], "mid_market_rate": 0.79}
+	]`))
+	require.NoError(t, err)
+
+	corridor, err := registry.Lookup("USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, 0.01, corridor.Fees().Tier(100000).Rate)
+
+	assert.Error(t, corridor.ValidateAmount(999), "below minimum")
+	assert.NoError(t, corridor.ValidateAmount(1000))
+	assert.Error(t, corridor.ValidateAmount(500001), "above maximum")
+
+	_, err = registry.Lookup("USD", "GBP")
+	assert.Error(t, err, "disabled corridors are not served")
+	assert.Len(t, registry.List(), 1)
+}
+
+func TestParseJSONRejectsInvalidCorridors(t *testing.T) {
+	_, err := corridors.ParseJSON([]byte(`[{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "GBP",
+		"rate_providers": ["Circle"], "mid_market_rate": 0.79}]`))
+	assert.Error(t, err, "ID must match the currency pair")
+
+	_, err = corridors.ParseJSON([]byte(`[{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "EUR",
+		"rate_providers": ["Circle"]}]`))
+	assert.Error(t, err, "mid-market rate is required")
+
+	_, err = corridors.ParseJSON([]byte(`[{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "EUR",
+		"rate_providers": ["Circle"], "mid_market_rate": 0.92, "min_fee": 500, "max_fee": 100}]`))
+	assert.Error(t, err, "max fee must not be below min fee")
+
+	_, err = corridors.ParseJSON([]byte(`[{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "EUR",
+		"rate_providers": ["Circle"], "mid_market_rate": 0.92, "fee_schedule": [{"up_to": 10000, "rate": 0.01}]}]`))
+	assert.Error(t, err, "fee schedule must end with an unbounded tier")
+
+	_, err = corridors.ParseJSON([]byte(`[{"corridor_id": "USD-EUR", "source_currency": "USD", "destination_currency": "EUR",
+		"rate_providers": ["Circle"], "mid_market_rate": 0.92, "surcharges": [{"rate": 0.01}]}]`))
+	assert.Error(t, err, "surcharges need a name")
+}
+
+func TestDefaultFeeScheduleTiers(t *testing.T) {
+	schedule := corridors.DefaultFeeSchedule
+
+	assert.Equal(t, int64(30), schedule.Tier(9999).FixedFee)
+	assert.Equal(t, int64(50), schedule.Tier(10000).FixedFee)
+	assert.Equal(t, int64(100), schedule.Tier(100000).FixedFee)
+}
+
+func TestDefaultCorridorFeeStructures(t *testing.T) {
+	registry := corridors.Default()
+
+	usdEUR, err := registry.Lookup("USD", "EUR")
+	require.NoError(t, err)
+	usdGBP, err := registry.Lookup("USD", "GBP")
+	require.NoError(t, err)
+	usdBRL, err := registry.Lookup("USD", "BRL")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.020, usdEUR.Fees().Tier(100000).Rate)
+	assert.Equal(t, 0.018, usdGBP.Fees().Tier(100000).Rate)
+	assert.Equal(t, 0.025, usdBRL.Fees().Tier(100000).Rate)
+
+	assert.Empty(t, usdEUR.Surcharges)
+	require.Len(t, usdBRL.Surcharges, 1)
+	assert.Equal(t, "IOF", usdBRL.Surcharges[0].Name)
+	assert.Equal(t, int64(380), usdBRL.Surcharges[0].Amount(100000))
+}
@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	service := customers.New(database.NewMemoryCustomerRepository())
+
+	_, err := service.Create(ctx, &models.CustomerRequest{Name: "Acme"})
+	require.Error(t, err, "a customer needs an API key ID")
+
+	created, err := service.Create(ctx, &models.CustomerRequest{CustomerID: "key_acme", Name: " Acme "})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", created.Name)
+	assert.Equal(t, models.CustomerTierStandard, created.Tier, "the tier defaults to standard")
+	require.Len(t, created.WebhookSecrets, 1)
+	assert.Contains(t, created.WebhookSecrets[0], "whsec_")
+
+	updated, err := service.Update(ctx, "key_acme", &models.CustomerRequest{Name: "Acme", Tier: "premium"})
+	require.NoError(t, err)
+	assert.Equal(t, models.CustomerTierPremium, updated.Tier)
+	assert.Equal(t, created.WebhookSecrets, updated.WebhookSecrets, "updates keep the webhook secret")
+
+	_, err = service.LinkAccount(ctx, "key_acme", &models.CustomerAccountRequest{AccountID: "acct_1"})
+	require.NoError(t, err)
+	_, err = service.LinkAccount(ctx, "key_acme", &models.CustomerAccountRequest{AccountID: "acct_1"})
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Code)
+
+	unlinked, err := service.UnlinkAccount(ctx, "key_acme", "acct_1")
+	require.NoError(t, err)
+	assert.Empty(t, unlinked.Accounts)
+	_, err = service.UnlinkAccount(ctx, "key_acme", "acct_1")
+	assert.Error(t, err)
+
+	customer, err := service.Lookup(ctx, "key_unknown")
+	require.NoError(t, err)
+	assert.Nil(t, customer, "callers without a record have no customer")
+
+	require.NoError(t, service.Delete(ctx, "key_acme"))
+	_, err = service.Get(ctx, "key_acme")
+	appErr, ok = err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "CUSTOMER_NOT_FOUND", appErr.Code)
+}
+
+func TestCheckPaymentAgainstCustomer(t *testing.T) {
+	customer := &models.Customer{
+		CustomerID: "key_acme",
+		Limits:     models.CustomerLimits{MaxPaymentAmount: 100000},
+	}
+	payment := &models.PaymentRequest{Amount: 100000, SourceAccount: "acct_any"}
+	assert.Nil(t, customers.CheckPayment(customer, payment), "any account may fund payments until one is linked")
+
+	payment.Amount = 100001
+	appErr := customers.CheckPayment(customer, payment)
+	require.NotNil(t, appErr)
+	assert.Equal(t, "LIMIT_EXCEEDED", appErr.Code)
+
+	customer.Accounts = []models.CustomerAccount{{AccountID: "acct_linked"}}
+	payment.Amount = 5000
+	appErr = customers.CheckPayment(customer, payment)
+	require.NotNil(t, appErr)
+	assert.Equal(t, "ACCOUNT_NOT_LINKED", appErr.Code)
+
+	payment.SourceAccount = "acct_linked"
+	assert.Nil(t, customers.CheckPayment(customer, payment))
+}
+
+func TestIPAllowlistRequestNormalizesRanges(t *testing.T) {
+	req := &models.CustomerIPAllowlistRequest{AllowedIPs: []string{"203.0.113.7", " 10.1.2.3/8 ", "10.0.0.0/8", "2001:db8::1"}}
+	require.Nil(t, req.Validate())
+	assert.Equal(t, []string{"203.0.113.7/32", "10.0.0.0/8", "2001:db8::1/128"}, req.AllowedIPs)
+
+	req = &models.CustomerIPAllowlistRequest{AllowedIPs: []string{"10.0.0.0/8", "not-an-ip", "10.0.0.0/33"}}
+	appErr := req.Validate()
+	require.NotNil(t, appErr)
+	require.Len(t, appErr.Fields, 2)
+	assert.Equal(t, "allowed_ips[1]", appErr.Fields[0].Field)
+	assert.Equal(t, errors.FieldInvalidFormat, appErr.Fields[0].Code)
+	assert.Equal(t, "allowed_ips[2]", appErr.Fields[1].Field)
+}
+
+func TestCheckSourceIPAgainstAllowlist(t *testing.T) {
+	customer := &models.Customer{CustomerID: "key_acme"}
+	assert.Nil(t, customers.CheckSourceIP(customer, "198.51.100.1"), "no allowlist allows every address")
+
+	customer.AllowedIPs = []string{"203.0.113.0/24", "2001:db8::/32"}
+	assert.Nil(t, customers.CheckSourceIP(customer, "203.0.113.200"))
+	assert.Nil(t, customers.CheckSourceIP(customer, "2001:db8::42"))
+
+	appErr := customers.CheckSourceIP(customer, "198.51.100.1")
+	require.NotNil(t, appErr)
+	assert.Equal(t, "IP_NOT_ALLOWED", appErr.Code)
+	assert.Equal(t, 403, appErr.StatusCode)
+	assert.Equal(t, "E2007", appErr.DocID)
+
+	assert.NotNil(t, customers.CheckSourceIP(customer, "garbage"), "an unparseable address is refused")
+}
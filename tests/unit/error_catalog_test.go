@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCatalogDocIDsAreUnique(t *testing.T) {
+	seen := map[string]string{}
+	for _, entry := range errors.Catalog() {
+		assert.Regexp(t, `^E[1-6][0-9]{3}$`, entry.DocID, entry.Code)
+		assert.NotEmpty(t, entry.Summary, entry.Code)
+		if other, ok := seen[entry.DocID]; ok {
+			t.Errorf("%s and %s share doc ID %s", other, entry.Code, entry.DocID)
+		}
+		seen[entry.DocID] = entry.Code
+	}
+}
+
+func TestConstructorsAreClassified(t *testing.T) {
+	for _, appErr := range []*errors.AppError{
+		errors.ErrInvalidRequest("bad", nil),
+		errors.ErrDuplicateRequest("key_1"),
+		errors.ErrPaymentNotFound("pay_1"),
+		errors.ErrQuoteExpired("quote_1"),
+		errors.ErrQuoteConsumed("quote_1"),
+		errors.ErrLimitExceeded("over"),
+		errors.ErrValidation("amount", "must be greater than 0"),
+		errors.ErrMissingHeader("Idempotency-Key"),
+		errors.ErrInternalServer("boom", nil),
+		errors.ErrDatabaseOperation("put", nil),
+		errors.ErrQueueOperation("send", nil),
+		errors.ErrQuoteUnavailable("USD-EUR", nil),
+	} {
+		assert.NotEmpty(t, appErr.DocID, appErr.Code)
+		assert.Equal(t, appErr.StatusCode >= http.StatusInternalServerError, appErr.Retryable, appErr.Code)
+	}
+}
+
+func TestUncatalogedCodesAreRetryableByStatus(t *testing.T) {
+	throttled := errors.New("RATE_LIMITED", "Slow down", http.StatusTooManyRequests, nil)
+	assert.True(t, throttled.Retryable)
+	assert.Empty(t, throttled.DocID)
+
+	assert.False(t, errors.New("GONE", "Gone", http.StatusGone, nil).Retryable)
+	assert.True(t, errors.New("UPSTREAM_DOWN", "Upstream down", http.StatusBadGateway, nil).Retryable)
+}
+
+func TestErrorBodyCarriesRetryableAndDocID(t *testing.T) {
+	body, err := json.Marshal(errors.ToErrorResponse(errors.ErrQuoteUnavailable("USD-EUR", nil)))
+	require.NoError(t, err)
+
+	var resp struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(body, &resp))
+	assert.Equal(t, "QUOTE_UNAVAILABLE", resp.Error["code"])
+	assert.Equal(t, "E5007", resp.Error["doc_id"])
+	assert.Equal(t, true, resp.Error["retryable"])
+
+	// Terminal errors say so explicitly rather than leaving retryable out
+	body, err = json.Marshal(errors.ToErrorResponse(errors.ErrPaymentNotFound("pay_1")))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"retryable":false`)
+}
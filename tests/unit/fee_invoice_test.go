@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+)
+
+func TestNewFeeInvoice(t *testing.T) {
+	completedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	payment := &models.Payment{
+		PaymentID:          "pay_1",
+		CustomerID:         "key_1",
+		Amount:             100000,
+		Currency:           "BRL",
+		FeeAmount:          2030, // $16.50 platform fee + $3.80 IOF
+		RegulatoryFee:      380,
+		OnrampFee:          1050,
+		OfframpFee:         1250,
+		FeeDiscount:        500,
+		PromoCode:          "WELCOME",
+		PromoDiscount:      1000,
+		FeeScheduleID:      "USD-BRL",
+		FeeScheduleVersion: 3,
+		ProcessedAt:        &completedAt,
+	}
+
+	invoice := models.NewFeeInvoice(payment, completedAt.Add(time.Second))
+	assert.Equal(t, "USD", invoice.Currency)
+	assert.Equal(t, "BRL", invoice.PayoutCurrency)
+	assert.Equal(t, int64(1650), invoice.PlatformFee)
+	assert.Equal(t, int64(380), invoice.RegulatoryFee)
+	assert.Equal(t, int64(1650+380+1050+1250), invoice.TotalFees)
+	assert.Equal(t, int64(500), invoice.NegotiatedDiscount)
+	assert.Equal(t, int64(1000), invoice.PromoDiscount)
+	assert.Equal(t, int64(3), invoice.FeeScheduleVersion)
+	assert.Equal(t, completedAt, invoice.CompletedAt)
+}
+
+func TestMemoryFeeInvoiceRepositoryIsImmutable(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryFeeInvoiceRepository()
+
+	_, err := repo.GetFeeInvoice(ctx, "pay_1")
+	require.Error(t, err)
+	assert.Equal(t, "FEE_INVOICE_NOT_FOUND", err.(*errors.AppError).Code)
+
+	require.NoError(t, repo.CreateFeeInvoice(ctx, &models.FeeInvoice{PaymentID: "pay_1", PlatformFee: 2100, TotalFees: 2100}))
+	err = repo.CreateFeeInvoice(ctx, &models.FeeInvoice{PaymentID: "pay_1", PlatformFee: 0})
+	require.Error(t, err)
+	assert.Equal(t, "CONFLICT", err.(*errors.AppError).Code)
+
+	invoice, err := repo.GetFeeInvoice(ctx, "pay_1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2100), invoice.PlatformFee)
+}
@@ -0,0 +1,298 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
+
+var flatSchedule = corridors.FeeSchedule{{Rate: 0.01, FixedFee: 10}}
+
+func TestActiveFeeSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	v1 := &models.FeeSchedule{Version: 1, EffectiveFrom: now.Add(-48 * time.Hour)}
+	v2 := &models.FeeSchedule{Version: 2, EffectiveFrom: now.Add(-time.Hour)}
+	v3 := &models.FeeSchedule{Version: 3, EffectiveFrom: now.Add(time.Hour)} // Not yet in effect
+	v4 := &models.FeeSchedule{Version: 4, EffectiveFrom: now.Add(-time.Hour)}
+
+	assert.Nil(t, models.ActiveFeeSchedule(nil, now))
+	assert.Nil(t, models.ActiveFeeSchedule([]*models.FeeSchedule{v3}, now))
+	assert.Equal(t, v2, models.ActiveFeeSchedule([]*models.FeeSchedule{v1, v2, v3}, now))
+	assert.Equal(t, v4, models.ActiveFeeSchedule([]*models.FeeSchedule{v1, v2, v3, v4}, now))
+	assert.Equal(t, v3, models.ActiveFeeSchedule([]*models.FeeSchedule{v1, v2, v3}, now.Add(2*time.Hour)))
+}
+
+func TestFeeScheduleValidate(t *testing.T) {
+	assert.NoError(t, corridors.DefaultFeeSchedule.Validate())
+	assert.NoError(t, flatSchedule.Validate())
+
+	invalid := map[string]corridors.FeeSchedule{
+		"empty":           {},
+		"bounded last":    {{UpTo: 10000, Rate: 0.01}},
+		"unbounded first": {{Rate: 0.01}, {Rate: 0.02}},
+		"descending":      {{UpTo: 10000, Rate: 0.02}, {UpTo: 5000, Rate: 0.01}, {Rate: 0.01}},
+		"negative rate":   {{Rate: -0.01}},
+		"rate of one":     {{Rate: 1}},
+		"negative fixed":  {{Rate: 0.01, FixedFee: -1}},
+	}
+	for name, schedule := range invalid {
+		assert.Error(t, schedule.Validate(), name)
+	}
+}
+
+func TestMemoryFeeScheduleRepositoryRejectsDuplicateVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryFeeScheduleRepository()
+
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "default", Version: 2, Tiers: flatSchedule}))
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "default", Version: 1, Tiers: flatSchedule}))
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "USD-EUR", Version: 1, Tiers: flatSchedule}))
+	assert.Error(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "default", Version: 2, Tiers: flatSchedule}))
+
+	versions, err := repo.ListFeeSchedules(ctx, "default")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, int64(1), versions[0].Version)
+	assert.Equal(t, int64(2), versions[1].Version)
+}
+
+func TestCalculatorUsesStoredSchedule(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryFeeScheduleRepository()
+	calc := fees.NewCalculator()
+	calc.EnableSchedules(repo, time.Minute)
+
+	// No stored version yet: the built-in 2.0% + $1.00 tier applies
+	result := calc.CalculateFeeForCurrency(ctx, 100000, "EUR")
+	assert.Equal(t, int64(2100), result.FeeAmount)
+	assert.Empty(t, result.ScheduleID)
+
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{
+		ScheduleID:    models.DefaultFeeScheduleID,
+		Version:       1,
+		Tiers:         flatSchedule,
+		EffectiveFrom: time.Now().Add(-time.Minute),
+	}))
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{
+		ScheduleID:    models.DefaultFeeScheduleID,
+		Version:       2,
+		Tiers:         corridors.FeeSchedule{{Rate: 0.05}},
+		EffectiveFrom: time.Now().Add(time.Hour),
+	}))
+
+	// Cached until invalidated
+	assert.Empty(t, calc.CalculateFeeForCurrency(ctx, 100000, "EUR").ScheduleID)
+	calc.InvalidateSchedule(models.DefaultFeeScheduleID)
+
+	result = calc.CalculateFeeForCurrency(ctx, 100000, "EUR")
+	assert.Equal(t, int64(1010), result.FeeAmount)
+	assert.Equal(t, models.DefaultFeeScheduleID, result.ScheduleID)
+	assert.Equal(t, int64(1), result.ScheduleVersion)
+}
+
+func TestCalculateCorridorFeeResolution(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryFeeScheduleRepository()
+	calc := fees.NewCalculator()
+	calc.EnableSchedules(repo, 0)
+
+	usdEUR, err := corridors.Default().Lookup("USD", "EUR")
+	require.NoError(t, err)
+	custom := usdEUR
+	custom.FeeSchedule = corridors.FeeSchedule{{Rate: 0.02}}
+
+	// Built-in schedules until something is stored
+	assert.Equal(t, int64(2100), calc.CalculateCorridorFee(ctx, usdEUR, 100000).FeeAmount)
+	assert.Equal(t, int64(2000), calc.CalculateCorridorFee(ctx, custom, 100000).FeeAmount)
+
+	// The stored default replaces the built-in default but not a corridor's own schedule
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: models.DefaultFeeScheduleID, Version: 1, Tiers: flatSchedule}))
+	result := calc.CalculateCorridorFee(ctx, usdEUR, 100000)
+	assert.Equal(t, int64(1010), result.FeeAmount)
+	assert.Equal(t, int64(1), result.ScheduleVersion)
+	assert.Equal(t, int64(2000), calc.CalculateCorridorFee(ctx, custom, 100000).FeeAmount)
+
+	// A stored corridor schedule wins over both
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "USD-EUR", Version: 1, Tiers: corridors.FeeSchedule{{Rate: 0.03}}}))
+	result = calc.CalculateCorridorFee(ctx, custom, 100000)
+	assert.Equal(t, int64(3000), result.FeeAmount)
+	assert.Equal(t, "USD-EUR", result.ScheduleID)
+}
+
+func TestCorridorFeeIsInSourceCurrency(t *testing.T) {
+	ctx := context.Background()
+	calc := fees.NewCalculator()
+
+	eurUSD, err := corridors.Default().Lookup("EUR", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", calc.CalculateCorridorFee(ctx, eurUSD, 100000).FeeCurrency)
+	assert.Equal(t, "USD", calc.CalculateFeeForCurrency(ctx, 100000, "EUR").FeeCurrency)
+}
+
+func TestCorridorFeeLimits(t *testing.T) {
+	ctx := context.Background()
+	calc := fees.NewCalculator()
+
+	usdEUR, err := corridors.Default().Lookup("USD", "EUR")
+	require.NoError(t, err)
+	usdEUR.MinFee = 500
+	usdEUR.MaxFee = 5000
+
+	// 2.9% + $0.30 on $10 is raised to the floor
+	result := calc.CalculateCorridorFee(ctx, usdEUR, 1000)
+	assert.Equal(t, int64(500), result.FeeAmount)
+	assert.Equal(t, int64(1500), result.TotalAmount)
+	assert.Equal(t, fees.FeeLimitMinimum, result.FeeLimit)
+	assert.Equal(t, int64(59), result.UnclampedFee)
+
+	// 2.0% + $1.00 on $10,000 is capped
+	result = calc.CalculateCorridorFee(ctx, usdEUR, 1000000)
+	assert.Equal(t, int64(5000), result.FeeAmount)
+	assert.Equal(t, fees.FeeLimitMaximum, result.FeeLimit)
+	assert.Equal(t, int64(20100), result.UnclampedFee)
+
+	result = calc.CalculateCorridorFee(ctx, usdEUR, 100000)
+	assert.Equal(t, int64(2100), result.FeeAmount)
+	assert.Empty(t, result.FeeLimit)
+	assert.Zero(t, result.UnclampedFee)
+
+	// Negotiated pricing can't go below the floor
+	rate := 0.0
+	calc.SetCustomerPricing(map[string]fees.CustomerPricing{"key_free": {Rate: &rate}})
+	result = calc.CalculateCorridorFee(ctx, usdEUR, 100000, "key_free")
+	assert.Equal(t, int64(500), result.FeeAmount)
+	assert.Equal(t, fees.FeeLimitMinimum, result.FeeLimit)
+}
+
+func TestCalculateFeeForCurrencyUsesCorridor(t *testing.T) {
+	ctx := context.Background()
+	calc := fees.NewCalculator()
+	calc.SetCorridors(corridors.Default())
+
+	assert.Equal(t, int64(2100), calc.CalculateFeeForCurrency(ctx, 100000, "EUR").FeeAmount)
+	assert.Equal(t, int64(9100), calc.CalculateFeeForCurrency(ctx, 500000, "GBP").FeeAmount)
+
+	// $25.00 + $1.50 platform fee, plus 0.38% IOF
+	result := calc.CalculateFeeForCurrency(ctx, 100000, "BRL")
+	assert.Equal(t, int64(2650+380), result.FeeAmount)
+	assert.Equal(t, int64(2650), result.PlatformFee())
+	assert.Equal(t, int64(380), result.Surcharge)
+	assert.Equal(t, []fees.SurchargeFee{{Name: "IOF", Amount: 380}}, result.Surcharges)
+	assert.Equal(t, int64(100000+3030), result.TotalAmount)
+
+	// Negotiated pricing reprices the platform fee only
+	rate := 0.01
+	calc.SetCustomerPricing(map[string]fees.CustomerPricing{"key_negotiated": {Rate: &rate}})
+	result = calc.CalculateFeeForCurrency(ctx, 100000, "BRL", "key_negotiated")
+	assert.Equal(t, int64(1150+380), result.FeeAmount)
+
+	// Currencies without a USD corridor use the default schedule
+	assert.Equal(t, int64(2100), calc.CalculateFeeForCurrency(ctx, 100000, "JPY").FeeAmount)
+}
+
+// flakyScheduleStore serves a stored repository until failing is set
+type flakyScheduleStore struct {
+	*database.MemoryFeeScheduleRepository
+	failing bool
+}
+
+func (s *flakyScheduleStore) ListFeeSchedules(ctx context.Context, scheduleID string) ([]*models.FeeSchedule, error) {
+	if s.failing {
+		return nil, fmt.Errorf("table unavailable")
+	}
+	return s.MemoryFeeScheduleRepository.ListFeeSchedules(ctx, scheduleID)
+}
+
+func TestCalculatorKeepsLastScheduleWhenStoreFails(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyScheduleStore{MemoryFeeScheduleRepository: database.NewMemoryFeeScheduleRepository()}
+	require.NoError(t, store.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: models.DefaultFeeScheduleID, Version: 1, Tiers: flatSchedule}))
+
+	calc := fees.NewCalculator()
+	calc.EnableSchedules(store, 0)
+	assert.Equal(t, int64(1010), calc.CalculateFeeForCurrency(ctx, 100000, "EUR").FeeAmount)
+
+	store.failing = true
+	result := calc.CalculateFeeForCurrency(ctx, 100000, "EUR")
+	assert.Equal(t, int64(1010), result.FeeAmount)
+	assert.Equal(t, int64(1), result.ScheduleVersion)
+
+	// Nothing read yet: the built-in schedule applies
+	cold := fees.NewCalculator()
+	cold.EnableSchedules(store, 0)
+	assert.Equal(t, int64(2100), cold.CalculateFeeForCurrency(ctx, 100000, "EUR").FeeAmount)
+}
+
+func TestCustomerPricingOverridesSchedule(t *testing.T) {
+	ctx := context.Background()
+	pricing, err := fees.ParseCustomerPricing([]byte(`{
+		"key_negotiated": {"rate": 0.01, "fixed_fee": 25},
+		"acct_discount": {"discount": 0.2}
+	}`))
+	require.NoError(t, err)
+
+	calc := fees.NewCalculator()
+	calc.SetCustomerPricing(pricing)
+
+	result := calc.CalculateFee(100000, "EUR", "key_negotiated")
+	assert.Equal(t, int64(1025), result.FeeAmount)
+	assert.Equal(t, int64(101025), result.TotalAmount)
+	assert.Equal(t, "key_negotiated", result.PricingCustomer)
+
+	// The API key has no pricing, so the account's discount applies to the 2.0% + $1.00 tier
+	result = calc.CalculateFeeForCurrency(ctx, 100000, "EUR", "key_other", "acct_discount")
+	assert.Equal(t, int64(1680), result.FeeAmount)
+	assert.Equal(t, int64(420), result.Discount)
+	assert.Equal(t, "acct_discount", result.PricingCustomer)
+
+	usdEUR, err := corridors.Default().Lookup("USD", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1025), calc.CalculateCorridorFee(ctx, usdEUR, 100000, "key_negotiated").FeeAmount)
+
+	result = calc.CalculateFee(100000, "EUR", "key_other")
+	assert.Equal(t, int64(2100), result.FeeAmount)
+	assert.Empty(t, result.PricingCustomer)
+}
+
+func TestParseCustomerPricingRejectsInvalid(t *testing.T) {
+	for _, profiles := range []string{
+		`{"key": {}}`,
+		`{"key": {"rate": 1.5}}`,
+		`{"key": {"fixed_fee": -1}}`,
+		`{"key": {"discount": 2}}`,
+		`[]`,
+	} {
+		_, err := fees.ParseCustomerPricing([]byte(profiles))
+		assert.Error(t, err, profiles)
+	}
+}
+
+func TestCorridorFeeSummaryFollowsResolution(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryFeeScheduleRepository()
+	calc := fees.NewCalculator()
+	calc.EnableSchedules(repo, 0)
+
+	usdBRL, err := corridors.Default().Lookup("USD", "BRL")
+	require.NoError(t, err)
+	summary := calc.CorridorFeeSummary(ctx, usdBRL)
+	assert.Equal(t, "USD", summary.Currency)
+	assert.Equal(t, usdBRL.FeeSchedule, summary.Tiers)
+	assert.Empty(t, summary.ScheduleID, "the corridor's own schedule")
+	require.Len(t, summary.Surcharges, 1)
+	assert.Equal(t, "IOF", summary.Surcharges[0].Name)
+
+	require.NoError(t, repo.CreateFeeSchedule(ctx, &models.FeeSchedule{ScheduleID: "USD-BRL", Version: 2, Tiers: flatSchedule}))
+	summary = calc.CorridorFeeSummary(ctx, usdBRL)
+	assert.Equal(t, flatSchedule, summary.Tiers)
+	assert.Equal(t, "USD-BRL", summary.ScheduleID)
+	assert.Equal(t, int64(2), summary.ScheduleVersion)
+}
@@ -0,0 +1,42 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+)
+
+func TestFeeShadowRecordsComparison(t *testing.T) {
+	store := database.NewMemoryFeeComparisonRepository()
+	// Without a model client the AI engine returns its fallback fees, so no network is needed
+	ai := fees.NewAIFeeCalculator(nil, nil, nil, nil, fees.DefaultLLMConfig())
+	shadow := fees.NewShadow(ai, store, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	shadow.Compare(ctx, "pay_1", &fees.AIFeeRequest{
+		Amount:       100000,
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		Priority:     "standard",
+		CustomerTier: "standard",
+	}, 3000)
+	cancel() // The request finishing must not abort the comparison
+	shadow.Wait()
+
+	comparison := store.GetFeeComparison("pay_1")
+	require.NotNil(t, comparison)
+	assert.Equal(t, int64(3000), comparison.StaticFee)
+	assert.Equal(t, int64(3200), comparison.AIFee)
+	assert.Equal(t, int64(200), comparison.Delta)
+	assert.InDelta(t, 6.67, comparison.DeltaPercent, 0.01)
+	assert.True(t, comparison.AIFallback)
+	assert.Equal(t, "Base", comparison.AIChain)
+	assert.Empty(t, comparison.AIError)
+
+	assert.Nil(t, store.GetFeeComparison("pay_missing"))
+}
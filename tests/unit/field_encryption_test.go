@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKMS issues random data keys and "wraps" them by remembering which blob is which key
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	keys      map[string][]byte
+	generated int
+	decrypted int
+}
+
+func newFakeKMS() *fakeKMS {
+	return &fakeKMS{keys: make(map[string][]byte)}
+}
+
+func (k *fakeKMS) GenerateDataKeyWithContext(ctx aws.Context, in *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	k.generated++
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	blob := fmt.Sprintf("%s/%d", aws.StringValue(in.KeyId), k.generated)
+	k.keys[blob] = plaintext
+	return &kms.GenerateDataKeyOutput{CiphertextBlob: []byte(blob), Plaintext: plaintext, KeyId: in.KeyId}, nil
+}
+
+func (k *fakeKMS) DecryptWithContext(ctx aws.Context, in *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	k.decrypted++
+	plaintext, ok := k.keys[string(in.CiphertextBlob)]
+	if !ok {
+		return nil, fmt.Errorf("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{Plaintext: plaintext}, nil
+}
+
+func TestFieldCipherRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fields := database.NewFieldCipherWithClient(newFakeKMS(), "alias/payment-fields", time.Hour)
+
+	encrypted, err := fields.Encrypt(ctx, "pay_1", "source_account", "DE89370400440532013000")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v2:"))
+	assert.NotContains(t, encrypted, "DE89370400440532013000")
+
+	decrypted, err := fields.Decrypt(ctx, "pay_1", "source_account", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "DE89370400440532013000", decrypted)
+
+	_, err = fields.Decrypt(ctx, "pay_1", "destination_account", encrypted)
+	assert.Error(t, err, "a value moved to another field doesn't decrypt")
+	_, err = fields.Decrypt(ctx, "pay_2", "source_account", encrypted)
+	assert.Error(t, err, "a value moved to another payment doesn't decrypt")
+
+	plaintext, err := fields.Decrypt(ctx, "pay_1", "source_account", "acct_written_before_encryption")
+	require.NoError(t, err)
+	assert.Equal(t, "acct_written_before_encryption", plaintext, "plaintext records stay readable")
+
+	empty, err := fields.Encrypt(ctx, "pay_1", "source_account", "")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestFieldCipherKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	keys := newFakeKMS()
+
+	// A zero max age generates a new data key for every value
+	rotating := database.NewFieldCipherWithClient(keys, "alias/old-key", 0)
+	first, err := rotating.Encrypt(ctx, "pay_1", "source_account", "acct_1")
+	require.NoError(t, err)
+	second, err := rotating.Encrypt(ctx, "pay_1", "source_account", "acct_1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys.generated)
+	assert.NotEqual(t, first, second)
+
+	// An instance configured with a new KMS key still reads values the old key wrapped
+	replacement := database.NewFieldCipherWithClient(keys, "alias/new-key", time.Hour)
+	for _, value := range []string{first, second} {
+		decrypted, err := replacement.Decrypt(ctx, "pay_1", "source_account", value)
+		require.NoError(t, err)
+		assert.Equal(t, "acct_1", decrypted)
+	}
+	assert.Equal(t, 2, keys.decrypted)
+
+	_, err = replacement.Decrypt(ctx, "pay_1", "source_account", first)
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys.decrypted, "unwrapped data keys are cached")
+
+	reencrypted, err := replacement.Encrypt(ctx, "pay_1", "source_account", "acct_1")
+	require.NoError(t, err)
+	assert.Contains(t, reencrypted, "enc:v2:")
+	assert.Equal(t, 3, keys.generated, "new writes use the new key")
+}
+
+func TestFieldCipherReadsLegacyValues(t *testing.T) {
+	ctx := context.Background()
+	keys := newFakeKMS()
+
+	// Values written before payment binding were authenticated with only their field name
+	key, err := keys.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{KeyId: aws.String("alias/payment-fields")})
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key.Plaintext)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nonce, nonce, []byte("acct_legacy"), []byte("source_account"))
+	legacy := "enc:v1:" + base64.StdEncoding.EncodeToString(key.CiphertextBlob) + ":" + base64.StdEncoding.EncodeToString(sealed)
+
+	fields := database.NewFieldCipherWithClient(keys, "alias/payment-fields", time.Hour)
+	decrypted, err := fields.Decrypt(ctx, "pay_1", "source_account", legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "acct_legacy", decrypted)
+
+	_, err = fields.Decrypt(ctx, "pay_1", "destination_account", legacy)
+	assert.Error(t, err, "legacy values are still bound to their field")
+}
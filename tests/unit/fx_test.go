@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/fx"
+)
+
+// fakeFXSource returns fixed rates or a fixed error and counts calls
+type fakeFXSource struct {
+	name  string
+	rates map[string]float64
+	err   error
+	calls int
+}
+
+func (f *fakeFXSource) Name() string { return f.name }
+
+func (f *fakeFXSource) Fetch(ctx context.Context) (*fx.Rates, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fx.Rates{Source: f.name, Rates: f.rates, FetchedAt: time.Now()}, nil
+}
+
+func TestFXChainFailsOverInPriorityOrder(t *testing.T) {
+	primary := &fakeFXSource{name: "primary", err: fmt.Errorf("rate limited")}
+	secondary := &fakeFXSource{name: "secondary", rates: map[string]float64{"EUR": 0.92}}
+	chain := fx.NewChain(primary, secondary)
+
+	rates, err := chain.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", rates.Source)
+	assert.Equal(t, 1, primary.calls)
+}
+
+func TestFXChainBenchesFailingSource(t *testing.T) {
+	primary := &fakeFXSource{name: "primary", err: fmt.Errorf("down")}
+	secondary := &fakeFXSource{name: "secondary", rates: map[string]float64{"EUR": 0.92}}
+	chain := fx.NewChain(primary, secondary)
+	chain.FailureThreshold = 2
+
+	for i := 0; i < 3; i++ {
+		_, err := chain.Rates(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, primary.calls, "benched source is skipped during its cooldown")
+	health := chain.Health()
+	assert.False(t, health[0].Healthy)
+	assert.Equal(t, "down", health[0].LastError)
+	assert.True(t, health[1].Healthy)
+}
+
+func TestFXChainRetriesBenchedSourcesWhenAllAreDown(t *testing.T) {
+	only := &fakeFXSource{name: "only", err: fmt.Errorf("down")}
+	chain := fx.NewChain(only)
+	chain.FailureThreshold = 1
+
+	_, err := chain.Rates(context.Background())
+	assert.Error(t, err)
+	_, err = chain.Rates(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 2, only.calls)
+
+	only.err = nil
+	only.rates = map[string]float64{"EUR": 0.92}
+	rates, err := chain.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "only", rates.Source)
+	assert.True(t, chain.Health()[0].Healthy)
+}
+
+func TestFXChainFlagsDivergence(t *testing.T) {
+	primary := &fakeFXSource{name: "primary", rates: map[string]float64{"EUR": 0.92, "GBP": 0.79}}
+	secondary := &fakeFXSource{name: "secondary", rates: map[string]float64{"EUR": 0.92, "GBP": 0.75}}
+	chain := fx.NewChain(primary, secondary)
+	chain.Verify = true
+
+	rates, err := chain.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "primary", rates.Source)
+	assert.True(t, rates.Diverged)
+
+	divergence, currency := fx.Divergence(
+		&fx.Rates{Rates: primary.rates},
+		&fx.Rates{Rates: secondary.rates},
+	)
+	assert.Equal(t, "GBP", currency)
+	assert.InDelta(t, 0.0506, divergence, 0.001)
+}
+
+func TestRatesCrossThroughUSD(t *testing.T) {
+	rates := &fx.Rates{Source: "test", Rates: map[string]float64{"EUR": 0.92, "GBP": 0.80}}
+
+	rate, err := rates.Rate("USD", "EUR")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.92, rate, 1e-9)
+
+	rate, err = rates.Rate("EUR", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1/0.92, rate, 1e-9)
+
+	rate, err = rates.Rate("EUR", "GBP")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.80/0.92, rate, 1e-9)
+
+	_, err = rates.Rate("USD", "JPY")
+	assert.Error(t, err)
+}
+
+func TestFXChainFromNamesUsesConfiguredEndpoints(t *testing.T) {
+	var appID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appID = r.URL.Query().Get("app_id")
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.91}}`))
+	}))
+	defer server.Close()
+
+	chain, err := fx.NewChainFromNames("openexchangerates", "", map[string]fx.Endpoint{
+		fx.SourceOpenExchangeRates: {URL: server.URL, Timeout: time.Second, APIKey: "app-123"},
+	})
+	require.NoError(t, err)
+
+	rates, err := chain.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, fx.SourceOpenExchangeRates, rates.Source)
+	assert.Equal(t, "app-123", appID)
+	assert.InDelta(t, 0.91, rates.Rates["EUR"], 1e-9)
+}
+
+func TestFXChainFromNamesSkipsOpenExchangeRatesWithoutAppID(t *testing.T) {
+	_, err := fx.NewChainFromNames("openexchangerates", "", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
+
+func recordGas(t *testing.T, repo *database.MemoryGasPriceRepository, chain string, age time.Duration, gasPrice, costUSD float64) {
+	t.Helper()
+	require.NoError(t, repo.RecordGasPrice(context.Background(), &models.GasSnapshot{
+		Chain:      chain,
+		RecordedAt: time.Now().Add(-age),
+		GasPrice:   gasPrice,
+		CostUSD:    costUSD,
+	}))
+}
+
+func TestGasTrendAveragesAndDirection(t *testing.T) {
+	repo := database.NewMemoryGasPriceRepository()
+	recordGas(t, repo, "ethereum", 20*time.Hour, 20, 2.0)
+	recordGas(t, repo, "ethereum", 10*time.Hour, 20, 2.0)
+	recordGas(t, repo, "ethereum", 30*time.Minute, 40, 4.0)
+	recordGas(t, repo, "ethereum", 25*time.Hour, 500, 50.0) // Outside the day
+	recordGas(t, repo, "polygon", 10*time.Minute, 30, 0.01)
+
+	provider := fees.NewRealDataProvider(nil, nil)
+	provider.SetGasHistory(repo)
+
+	trend, err := provider.GasTrend(context.Background(), "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, 3, trend.Samples24h)
+	assert.Equal(t, 1, trend.Samples1h)
+	assert.InDelta(t, 40, trend.AvgGasPrice1h, 0.0001)
+	assert.InDelta(t, 80.0/3, trend.AvgGasPrice24h, 0.0001)
+	assert.InDelta(t, 4.0, trend.AvgCostUSD1h, 0.0001)
+	assert.Equal(t, fees.GasTrendRising, trend.Direction)
+
+	trend, err = provider.GasTrend(context.Background(), "polygon")
+	require.NoError(t, err)
+	assert.Equal(t, fees.GasTrendStable, trend.Direction)
+}
+
+func TestGasTrendUnknownWithoutRecentReadings(t *testing.T) {
+	repo := database.NewMemoryGasPriceRepository()
+	recordGas(t, repo, "base", 3*time.Hour, 0.5, 0.001)
+
+	provider := fees.NewRealDataProvider(nil, nil)
+	provider.SetGasHistory(repo)
+
+	trends, err := provider.GasTrends(context.Background())
+	require.NoError(t, err)
+	require.Len(t, trends, 7)
+	assert.Equal(t, "arbitrum", trends[0].Chain)
+	assert.Equal(t, fees.GasTrendUnknown, trends[0].Direction)
+	assert.Equal(t, "base", trends[2].Chain)
+	assert.Equal(t, 1, trends[2].Samples24h)
+	assert.Equal(t, fees.GasTrendUnknown, trends[2].Direction)
+}
+
+func TestGasTrendsWithoutHistory(t *testing.T) {
+	trends, err := fees.NewRealDataProvider(nil, nil).GasTrends(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, trends)
+}
+
+func TestMemoryGasPriceRepositoryOrdersAndDeduplicates(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryGasPriceRepository()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, repo.RecordGasPrice(ctx, &models.GasSnapshot{Chain: "base", RecordedAt: now, GasPrice: 2}))
+	require.NoError(t, repo.RecordGasPrice(ctx, &models.GasSnapshot{Chain: "base", RecordedAt: now.Add(-time.Minute), GasPrice: 1}))
+	require.NoError(t, repo.RecordGasPrice(ctx, &models.GasSnapshot{Chain: "base", RecordedAt: now.Add(100 * time.Millisecond), GasPrice: 3}))
+
+	snapshots, err := repo.ListGasPrices(ctx, "base", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, float64(1), snapshots[0].GasPrice)
+	assert.Equal(t, float64(3), snapshots[1].GasPrice) // Same second replaces the earlier reading
+}
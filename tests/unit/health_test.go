@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/health"
+)
+
+func healthyProbe(ctx context.Context) error { return nil }
+
+func failingProbe(ctx context.Context) error { return fmt.Errorf("connection refused") }
+
+func TestHealthCheckerStatuses(t *testing.T) {
+	ctx := context.Background()
+
+	checker := health.NewChecker(time.Second)
+	checker.Add("payments_store", true, database.NewMemoryPaymentRepository().CheckHealth)
+	checker.Add("payment_queue", true, healthyProbe)
+	report := checker.Run(ctx)
+	assert.Equal(t, health.StatusOK, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "payments_store", report.Checks[0].Name)
+	assert.Equal(t, health.StatusOK, report.Checks[1].Status)
+
+	// A non-critical dependency that is down degrades the service but leaves it ready
+	checker.Add("data_source:gas-base", false, failingProbe)
+	report = checker.Run(ctx)
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.True(t, report.Ready())
+	assert.Equal(t, health.StatusDown, report.Checks[2].Status)
+	assert.Equal(t, "connection refused", report.Checks[2].Error)
+
+	// A critical one makes it unready
+	checker.Add("quotes_store", true, failingProbe)
+	report = checker.Run(ctx)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.False(t, report.Ready())
+}
+
+func TestHealthCheckerTimesOutSlowDependencies(t *testing.T) {
+	checker := health.NewChecker(20 * time.Millisecond)
+	checker.Add("payment_queue", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Run(context.Background())
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Add("payments_store", true, failingProbe)
+	server := httptest.NewServer(checker.Handler())
+	defer server.Close()
+
+	get := func(path string) (int, health.Report) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var report health.Report
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		return resp.StatusCode, report
+	}
+
+	// Liveness reports the outage without failing, so the instance isn't replaced for it
+	status, report := get("/health")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+
+	status, report = get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, health.StatusDown, report.Checks[0].Status)
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"testing"
+
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/jsonschema"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedSchemasFollowJSONTags(t *testing.T) {
+	schema := jsonschema.Generate(quotes.QuoteRequest{})
+	assert.Equal(t, jsonschema.Draft, schema.Schema)
+	assert.Equal(t, "QuoteRequest", schema.Title)
+	assert.Equal(t, jsonschema.TypeInteger, schema.Properties["amount"].Type)
+	assert.Equal(t, jsonschema.TypeString, schema.Properties["promo_code"].Type)
+	assert.NotContains(t, schema.Properties, "CustomerTier", "json:\"-\" fields aren't client-supplied")
+	assert.NotContains(t, schema.Properties, "Promo")
+
+	batch := jsonschema.Generate(&models.BatchPaymentRequest{})
+	require.Equal(t, jsonschema.TypeArray, batch.Properties["payments"].Type)
+	assert.Equal(t, jsonschema.TypeObject, batch.Properties["payments"].Items.Properties["beneficiary"].Type)
+}
+
+func TestSchemaValidation(t *testing.T) {
+	schema := jsonschema.Generate(fees.AIFeeRequest{})
+
+	tests := []struct {
+		name   string
+		body   string
+		fields []errors.FieldError
+	}{
+		{
+			name: "valid",
+			body: `{"amount": 100000, "from_currency": "USD", "to_currency": "EUR", "priority": "express"}`,
+		},
+		{
+			name: "unknown fields and nulls are accepted",
+			body: `{"amount": null, "from_currency": "USD", "note": {"anything": [1, 2]}}`,
+		},
+		{
+			name: "keys match case-insensitively, as json.Unmarshal matches them",
+			body: `{"Amount": "100"}`,
+			fields: []errors.FieldError{
+				{Field: "Amount", Code: errors.FieldInvalidType, Reason: "must be an integer"},
+			},
+		},
+		{
+			name: "every mistyped field is reported, in key order",
+			body: `{"to_currency": 978, "amount": 100.5, "priority": true}`,
+			fields: []errors.FieldError{
+				{Field: "amount", Code: errors.FieldInvalidType, Reason: "must be an integer"},
+				{Field: "priority", Code: errors.FieldInvalidType, Reason: "must be a string"},
+				{Field: "to_currency", Code: errors.FieldInvalidType, Reason: "must be a string"},
+			},
+		},
+		{
+			name: "integers outside int64",
+			body: `{"amount": 99999999999999999999}`,
+			fields: []errors.FieldError{
+				{Field: "amount", Code: errors.FieldOutOfRange, Reason: "must be an integer from -9223372036854775808 to 9223372036854775807"},
+			},
+		},
+		{
+			name: "a body that isn't an object",
+			body: `[{"amount": 100}]`,
+			fields: []errors.FieldError{
+				{Field: "body", Code: errors.FieldInvalidType, Reason: "must be an object"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := schema.Validate([]byte(tt.body))
+			require.NoError(t, err)
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestSchemaValidationRejectsMalformedJSON(t *testing.T) {
+	schema := jsonschema.Generate(models.PaymentRequest{})
+
+	for _, body := range []string{``, `{"amount": 1`, `{"amount": 1} {}`} {
+		_, err := schema.Validate([]byte(body))
+		assert.Error(t, err, body)
+	}
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"crypto-conversion/internal/compliance/kyc"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKYCGate(t *testing.T) {
+	gate := kyc.NewGate(100000)
+	unverified := &models.Customer{CustomerID: "key_acme", KYC: models.CustomerKYC{Status: models.KYCStatusPending}}
+	verified := &models.Customer{CustomerID: "key_acme", KYC: models.CustomerKYC{Status: models.KYCStatusVerified}}
+
+	assert.Nil(t, gate.Check(unverified, 100000), "payments up to the threshold don't need KYC")
+	assert.Nil(t, gate.Check(nil, 100000))
+
+	appErr := gate.Check(unverified, 100001)
+	require.NotNil(t, appErr)
+	assert.Equal(t, "KYC_REQUIRED", appErr.Code)
+	assert.NotNil(t, gate.Check(nil, 100001), "callers without a record were never verified")
+	assert.Nil(t, gate.Check(verified, 100001))
+
+	assert.NotNil(t, kyc.NewGate(0).Check(unverified, 1), "a zero threshold gates every payment")
+}
+
+func TestPersonaVerification(t *testing.T) {
+	status := "pending"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer persona_key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/inquiries":
+			var body struct {
+				Data struct {
+					Attributes map[string]string `json:"attributes"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "itmpl_1", body.Data.Attributes["inquiry-template-id"])
+			assert.Equal(t, "key_acme", body.Data.Attributes["reference-id"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data": {"id": "inq_1", "attributes": {"status": "created"}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/inquiries/inq_1":
+			w.Write([]byte(`{"data": {"id": "inq_1", "attributes": {"status": "` + status + `"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store := database.NewMemoryCustomerRepository()
+	_, err := customers.New(store).Create(ctx, &models.CustomerRequest{CustomerID: "key_acme", Name: "Acme"})
+	require.NoError(t, err)
+	verifier := kyc.NewVerifier(kyc.NewPersona(server.URL, "persona_key", "itmpl_1"), store)
+
+	customer, err := verifier.Start(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.Equal(t, models.KYCStatusPending, customer.KYC.Status)
+	assert.Equal(t, "persona", customer.KYC.Provider)
+	assert.Equal(t, "inq_1", customer.KYC.InquiryID)
+
+	_, err = verifier.Start(ctx, "key_acme")
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "CONFLICT", appErr.Code, "a pending verification isn't restarted")
+
+	customer, err = verifier.Sync(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.Equal(t, models.KYCStatusPending, customer.KYC.Status)
+
+	status = "approved"
+	customer, err = verifier.Sync(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.True(t, customer.KYC.Verified())
+	assert.NotNil(t, customer.KYC.VerifiedAt)
+
+	stored, err := store.GetCustomer(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.Equal(t, models.KYCStatusVerified, stored.KYC.Status)
+}
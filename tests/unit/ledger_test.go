@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failOnceRepository fails the first save that would move a payment into failStatus, like a throttled write
+type failOnceRepository struct {
+	*database.MemoryPaymentRepository
+	failStatus models.PaymentStatus
+	failed     bool
+}
+
+func (r *failOnceRepository) UpdatePayment(ctx context.Context, p *models.Payment) error {
+	if !r.failed && p.Status == r.failStatus {
+		r.failed = true
+		return fmt.Errorf("simulated write failure")
+	}
+	return r.MemoryPaymentRepository.UpdatePayment(ctx, p)
+}
+
+// drivePayment runs queued jobs until the payment stops re-enqueueing, delivering every job twice
+// and redelivering jobs whose step failed, as SQS would
+func drivePayment(t *testing.T, sm *payment.StateMachine, queue *recordingQueue, job *models.PaymentJob) {
+	ctx := context.Background()
+	pending := []*models.PaymentJob{job}
+	for steps := 0; len(pending) > 0; steps++ {
+		require.Less(t, steps, 200, "payment never settled")
+
+		next := *pending[0]
+		pending = pending[1:]
+		duplicate := next
+
+		queue.jobs = nil
+		if err := sm.ProcessPayment(ctx, &next); err != nil {
+			pending = append(pending, &duplicate)
+			continue
+		}
+		require.NoError(t, sm.ProcessPayment(ctx, &duplicate))
+		pending = append(pending, queue.jobs...)
+	}
+}
+
+func TestLedgerPostsEachLegOnceAcrossRetries(t *testing.T) {
+	ctx := context.Background()
+
+	// The mock providers fail a few percent of transfers at random, so retry until one completes
+	for attempt := 0; attempt < 20; attempt++ {
+		id := fmt.Sprintf("pay_ledger_%d", attempt)
+		repo := &failOnceRepository{MemoryPaymentRepository: database.NewMemoryPaymentRepository(), failStatus: models.StatusOfframpPending}
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+			PaymentID:      id,
+			IdempotencyKey: id,
+			Amount:         100000,
+			Currency:       "EUR",
+			Chain:          "base",
+			FeeAmount:      1000,
+			Status:         models.StatusPending,
+			CreatedAt:      time.Now(),
+		}))
+
+		store := database.NewMemoryLedgerRepository()
+		queue := &recordingQueue{}
+		sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+			payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+		sm.EnableLedger(ledger.New(store), map[string]int64{"base": 2})
+
+		drivePayment(t, sm, queue, &models.PaymentJob{PaymentID: id})
+
+		stored, err := repo.GetPaymentByID(ctx, id)
+		require.NoError(t, err)
+		if stored.Status != models.StatusCompleted {
+			continue
+		}
+		require.True(t, repo.failed, "the off-ramp step was retried after a failed save")
+
+		txns, err := store.ListLedgerTransactions(ctx, id)
+		require.NoError(t, err)
+		var legs []string
+		for _, txn := range txns {
+			legs = append(legs, txn.Leg)
+			assert.NoError(t, ledger.Check(txn))
+		}
+		assert.Equal(t, []string{models.LedgerLegOnramp, models.LedgerLegOfframp, models.LedgerLegCompleted}, legs)
+
+		balances := make(map[string]int64)
+		for _, balance := range ledger.Balances(txns) {
+			balances[balance.Account] = balance.Balance
+		}
+		assert.Zero(t, balances[models.LedgerCustomerFunds], "nothing left owed to the customer")
+		assert.Zero(t, balances[models.LedgerPayoutsInTransit], "the payout settled")
+		assert.Equal(t, int64(1000), balances[models.LedgerFeeRevenue])
+		assert.Equal(t, int64(2), balances[models.LedgerGasExpense])
+		return
+	}
+	t.Fatal("no payment completed")
+}
+
+func TestLedgerRecordsReversal(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_ledger_reverse",
+		IdempotencyKey: "key_ledger_reverse",
+		Amount:         100000,
+		Currency:       "EUR",
+		Chain:          "base",
+		OffRampTxID:    "offramp_failed",
+		Status:         models.StatusReversing,
+		CreatedAt:      time.Now(),
+	}))
+
+	// What the on-ramp minted and the failed off-ramp took
+	store := database.NewMemoryLedgerRepository()
+	l := ledger.New(store)
+	amounts := ledger.Amounts{Funds: 100000}
+	for _, txn := range []*models.LedgerTransaction{
+		{Leg: models.LedgerLegOnramp, Entries: ledger.Onramp("base", amounts)},
+		{Leg: models.LedgerLegOfframp, Entries: ledger.Payout("base", amounts, 0)},
+	} {
+		txn.PaymentID = "pay_ledger_reverse"
+		txn.TransactionID = txn.PaymentID + ":" + txn.Leg
+		txn.CreatedAt = time.Now()
+		_, err := l.Post(ctx, txn)
+		require.NoError(t, err)
+	}
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableLedger(l, nil)
+
+	drivePayment(t, sm, queue, &models.PaymentJob{PaymentID: "pay_ledger_reverse"})
+	stored, err := repo.GetPaymentByID(ctx, "pay_ledger_reverse")
+	require.NoError(t, err)
+	require.Equal(t, models.StatusFailed, stored.Status)
+
+	txns, err := store.ListLedgerTransactions(ctx, "pay_ledger_reverse")
+	require.NoError(t, err)
+	if len(txns) < 4 {
+		t.Skip("the mock reversal failed")
+	}
+	for _, balance := range ledger.Balances(txns) {
+		assert.Zero(t, balance.Balance, "%s is back to zero", balance.Account)
+	}
+}
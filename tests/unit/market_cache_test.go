@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+)
+
+func TestMemoryMarketCacheRoundTrip(t *testing.T) {
+	var cache fees.SharedCache = database.NewMemoryMarketCache()
+	ctx := context.Background()
+
+	stored := fees.CachedTokenPrices{
+		Prices:    fees.TokenPrices{ETH: 3125.5, SOL: 142.25, MATIC: 0.41},
+		FetchedAt: time.Now().UTC(),
+	}
+	require.NoError(t, cache.Set(ctx, "token:prices", stored, 2*time.Minute))
+
+	var loaded fees.CachedTokenPrices
+	found, err := cache.Get(ctx, "token:prices", &loaded)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, stored.Prices, loaded.Prices)
+	assert.True(t, stored.FetchedAt.Equal(loaded.FetchedAt))
+}
+
+func TestMemoryMarketCacheMissesExpiredAndUnknownKeys(t *testing.T) {
+	cache := database.NewMemoryMarketCache()
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, "gas:base", fees.CachedTokenPrices{Prices: fees.TokenPrices{ETH: 1}}, -time.Second))
+
+	var loaded fees.CachedTokenPrices
+	found, err := cache.Get(ctx, "gas:base", &loaded)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = cache.Get(ctx, "gas:polygon", &loaded)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
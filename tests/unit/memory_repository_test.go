@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+)
+
+func TestMemoryPaymentRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+
+	payment := &models.Payment{
+		PaymentID:      "pay_1",
+		IdempotencyKey: "key_1",
+		Amount:         10000,
+		Currency:       "EUR",
+		Status:         models.StatusPending,
+	}
+	require.NoError(t, repo.CreatePayment(ctx, payment))
+
+	t.Run("duplicate idempotency key", func(t *testing.T) {
+		err := repo.CreatePayment(ctx, &models.Payment{PaymentID: "pay_2", IdempotencyKey: "key_1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("lookup by idempotency key", func(t *testing.T) {
+		found, err := repo.GetPaymentByIdempotencyKey(ctx, "", "key_1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "pay_1", found.PaymentID)
+
+		missing, err := repo.GetPaymentByIdempotencyKey(ctx, "", "key_missing")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("idempotency keys are scoped to their API key", func(t *testing.T) {
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{PaymentID: "pay_3", IdempotencyKey: "key_1", IdempotencyScope: "api_key_b"}))
+		assert.Error(t, repo.CreatePayment(ctx, &models.Payment{PaymentID: "pay_4", IdempotencyKey: "key_1", IdempotencyScope: "api_key_b"}))
+
+		found, err := repo.GetPaymentByIdempotencyKey(ctx, "api_key_b", "key_1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "pay_3", found.PaymentID)
+
+		missing, err := repo.GetPaymentByIdempotencyKey(ctx, "api_key_c", "key_1")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("processing lock", func(t *testing.T) {
+		acquired, err := repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker_a", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, acquired)
+
+		// Duplicate delivery cannot take the lock or write
+		acquired, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusPending, "worker_b", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+
+		stale, err := repo.GetPaymentByID(ctx, "pay_1")
+		require.NoError(t, err)
+		stale.LockOwner = "worker_b"
+		assert.Error(t, repo.UpdatePayment(ctx, stale))
+
+		require.NoError(t, repo.ReleaseProcessingLock(ctx, "pay_1", "worker_a"))
+
+		// Wrong expected status is rejected even when unlocked
+		acquired, err = repo.AcquireProcessingLock(ctx, "pay_1", models.StatusOnrampPending, "worker_b", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+	})
+
+	t.Run("stored records are copies", func(t *testing.T) {
+		fetched, err := repo.GetPaymentByID(ctx, "pay_1")
+		require.NoError(t, err)
+		fetched.Status = models.StatusCompleted
+
+		again, err := repo.GetPaymentByID(ctx, "pay_1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPending, again.Status)
+	})
+}
+
+func TestScanUnarchivedPaymentsSelectsTerminalPayments(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+
+	for id, status := range map[string]models.PaymentStatus{
+		"pay_pending":   models.StatusOfframpPending,
+		"pay_completed": models.StatusCompleted,
+		"pay_failed":    models.StatusFailed,
+		"pay_archived":  models.StatusTimedOut,
+	} {
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{PaymentID: id, IdempotencyKey: id, Status: status, CreatedAt: time.Now()}))
+	}
+	require.NoError(t, repo.MarkPaymentArchived(ctx, "pay_archived", time.Now()))
+
+	// Payments become archivable as soon as they're terminal, whether or not a TTL is set
+	payments, err := repo.ScanUnarchivedPayments(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, p := range payments {
+		ids = append(ids, p.PaymentID)
+	}
+	assert.ElementsMatch(t, []string{"pay_completed", "pay_failed"}, ids)
+}
@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/metrics"
+)
+
+func TestEmitterWritesEMF(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := metrics.New(&buf, "TestNamespace", metrics.Dimensions{"Service": "worker-handler"})
+
+	emitter.Count("PaymentTransitions", metrics.Dimensions{"Status": "COMPLETED"})
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	assert.Equal(t, float64(1), entry["PaymentTransitions"])
+	assert.Equal(t, "worker-handler", entry["Service"])
+	assert.Equal(t, "COMPLETED", entry["Status"])
+
+	aws := entry["_aws"].(map[string]interface{})
+	directive := aws["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "TestNamespace", directive["Namespace"])
+	assert.Equal(t, []interface{}{[]interface{}{"Service", "Status"}}, directive["Dimensions"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Name": "PaymentTransitions", "Unit": "Count"}}, directive["Metrics"])
+}
+
+func TestPrometheusRecorderMirrorsEmitter(t *testing.T) {
+	recorder := metrics.NewPrometheusRecorder()
+	emitter := metrics.New(io.Discard, "TestNamespace", metrics.Dimensions{"Service": "worker-handler"})
+	emitter.AddRecorder(recorder)
+
+	emitter.Count("PaymentTransitions", metrics.Dimensions{"Status": "COMPLETED"})
+	emitter.Count("PaymentTransitions", metrics.Dimensions{"Status": "COMPLETED"})
+	emitter.Duration("AICallDuration", 1500*time.Millisecond, metrics.Dimensions{"Outcome": "success"})
+
+	rec := httptest.NewRecorder()
+	recorder.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, `payment_transitions_total{service="worker-handler",status="COMPLETED"} 2`)
+	assert.Contains(t, body, `ai_call_duration_seconds_sum{outcome="success",service="worker-handler"} 1.5`)
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onRampRun initiates amounts on an on-ramp and polls each transfer until it leaves pending,
+// returning each transfer's outcome or "DECLINED", and the poll it ended on
+func onRampRun(t *testing.T, client *payment.StatefulOnRampClient, amounts []int64) ([]string, []int) {
+	ctx := context.Background()
+	var outcomes []string
+	var polls []int
+	for _, amount := range amounts {
+		txID, err := client.InitiateTransfer(ctx, amount, "USD")
+		if err != nil {
+			outcomes = append(outcomes, "DECLINED")
+			polls = append(polls, 0)
+			continue
+		}
+		var transfer *payment.Transfer
+		for i := 0; i < 10; i++ {
+			transfer, err = client.GetTransferStatus(ctx, txID)
+			require.NoError(t, err)
+			if transfer.Status != payment.TransferStatusPending {
+				break
+			}
+		}
+		outcomes = append(outcomes, string(transfer.Status))
+		polls = append(polls, transfer.PollCount)
+	}
+	return outcomes, polls
+}
+
+func TestStatefulMocksSameSeedSameSequence(t *testing.T) {
+	amounts := make([]int64, 50)
+	for i := range amounts {
+		amounts[i] = int64(10000 + i)
+	}
+	// High failure rates so the sequence mixes declines, failures and settlements
+	opts := []payment.MockOption{payment.WithSeed(42), payment.WithFailureRates(0.2, 0.3)}
+
+	first, firstPolls := onRampRun(t, payment.NewStatefulOnRampClient(opts...), amounts)
+	second, secondPolls := onRampRun(t, payment.NewStatefulOnRampClient(opts...), amounts)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstPolls, secondPolls)
+	assert.Contains(t, first, "DECLINED")
+	assert.Contains(t, first, string(payment.TransferStatusFailed))
+	assert.Contains(t, first, string(payment.TransferStatusSettled))
+}
+
+func TestStatefulMocksSeedFixesTxHashes(t *testing.T) {
+	ctx := context.Background()
+	hash := func() string {
+		client := payment.NewStatefulOffRampClient(payment.WithSeed(7), payment.WithFailureRates(0, 0))
+		txID, err := client.InitiateTransfer(ctx, 100000, "EUR")
+		require.NoError(t, err)
+		transfer, err := client.GetTransferStatus(ctx, txID)
+		require.NoError(t, err)
+		return transfer.TxHash
+	}
+
+	assert.Equal(t, hash(), hash())
+}
+
+func TestStatefulMocksSettleAfter(t *testing.T) {
+	client := payment.NewStatefulOnRampClient(payment.WithFailureRates(0, 0), payment.WithSettleAfter(3))
+
+	outcomes, polls := onRampRun(t, client, []int64{10000, 20000, 30000})
+
+	assert.Equal(t, []string{"SETTLED", "SETTLED", "SETTLED"}, outcomes)
+	assert.Equal(t, []int{3, 3, 3}, polls)
+}
+
+func TestStatefulMocksAmountScenarios(t *testing.T) {
+	client := payment.NewStatefulOnRampClient(
+		payment.WithFailureRates(0, 0),
+		payment.WithAmountScenarios(map[int64]payment.MockScenario{
+			10001: {Declined: true},
+			10002: {Outcome: payment.TransferStatusFailed, SettleAfter: 1},
+			10003: {Outcome: payment.TransferStatusPending},
+			10004: {SettleAfter: 5},
+		}),
+	)
+
+	outcomes, polls := onRampRun(t, client, []int64{10001, 10002, 10003, 10004})
+
+	assert.Equal(t, []string{"DECLINED", "FAILED", "PENDING", "SETTLED"}, outcomes)
+	assert.Equal(t, 1, polls[1])
+	assert.Equal(t, 10, polls[2]) // Never settled
+	assert.Equal(t, 5, polls[3])
+}
+
+func TestStatefulMocksScenariosOverrideFailureRates(t *testing.T) {
+	// Every unscripted transfer is refused; the scripted one settles
+	client := payment.NewStatefulOnRampClient(
+		payment.WithFailureRates(1, 1),
+		payment.WithAmountScenarios(map[int64]payment.MockScenario{10000: {SettleAfter: 1}}),
+	)
+
+	outcomes, _ := onRampRun(t, client, []int64{10000, 20000})
+
+	assert.Equal(t, []string{"SETTLED", "DECLINED"}, outcomes)
+}
+
+func TestStatefulMocksDestinationScenarios(t *testing.T) {
+	ctx := context.Background()
+
+	offRamp := payment.NewStatefulOffRampClient(
+		payment.WithFailureRates(0, 0),
+		payment.WithDestinationScenarios(map[string]payment.MockScenario{"MXN": {Declined: true}}),
+	)
+	_, err := offRamp.InitiateTransfer(ctx, 100000, "MXN")
+	assert.Error(t, err)
+	_, err = offRamp.InitiateTransfer(ctx, 100000, "EUR")
+	assert.NoError(t, err)
+
+	bridge := payment.NewStatefulBridgeClient(
+		payment.WithDestinationScenarios(map[string]payment.MockScenario{"polygon": {Outcome: payment.TransferStatusFailed, SettleAfter: 1}}),
+	)
+	txID, err := bridge.InitiateTransfer(ctx, 100000, "base", "polygon")
+	require.NoError(t, err)
+	transfer, err := bridge.GetTransferStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TransferStatusFailed, transfer.Status)
+
+	wallet := payment.NewStatefulWalletClient(
+		payment.WithDestinationScenarios(map[string]payment.MockScenario{"0xblocked": {Declined: true}}),
+		// The amount's scenario wins over the address's
+		payment.WithAmountScenarios(map[int64]payment.MockScenario{50000: {SettleAfter: 1}}),
+	)
+	_, err = wallet.InitiateTransfer(ctx, 100000, "base", "0xblocked")
+	assert.Error(t, err)
+	txID, err = wallet.InitiateTransfer(ctx, 50000, "base", "0xblocked")
+	require.NoError(t, err)
+	transfer, err = wallet.GetTransferStatus(ctx, txID)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TransferStatusSettled, transfer.Status)
+}
@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"testing"
+
+	"crypto-conversion/internal/corridors"
+	"crypto-conversion/internal/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinorUnits(t *testing.T) {
+	assert.Equal(t, 2, money.MinorUnits("USD"))
+	assert.Equal(t, 2, money.MinorUnits("eur"))
+	assert.Equal(t, 0, money.MinorUnits("JPY"))
+	assert.Equal(t, 3, money.MinorUnits("BHD"))
+	assert.Equal(t, 2, money.MinorUnits("XXX"), "unknown currencies default to two decimals")
+}
+
+func TestConvertAcrossMinorUnits(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		from   string
+		to     string
+		rate   float64
+		want   int64
+	}{
+		{"USD cents to EUR cents", 100000, "USD", "EUR", 0.92, 92000},
+		{"USD cents to JPY", 100000, "USD", "JPY", 150.0, 150000},
+		{"JPY to USD cents", 150000, "JPY", "USD", 1.0 / 150.0, 100000},
+		{"USD cents to BHD fils", 100000, "USD", "BHD", 0.376, 376000},
+		{"rounds to nearest", 1001, "EUR", "USD", 1.0875, 1089},
+		{"half rounds down to even", 5, "USD", "EUR", 0.5, 2},
+		{"half rounds up to even", 7, "USD", "EUR", 0.5, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, money.Convert(tt.amount, tt.from, tt.to, tt.rate))
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "$1,234.56", money.Format(123456, "USD"))
+	assert.Equal(t, "€0.05", money.Format(5, "EUR"))
+	assert.Equal(t, "¥1,235", money.Format(1235, "JPY"))
+	assert.Equal(t, "1.235 BHD", money.Format(1235, "BHD"))
+	assert.Equal(t, "-$10.00", money.Format(-1000, "USD"))
+}
+
+func TestFromMajorRounds(t *testing.T) {
+	assert.Equal(t, int64(1999), money.FromMajor(19.99, "USD"))
+	assert.Equal(t, int64(20), money.FromMajor(19.99, "JPY"))
+}
+
+func TestFeeScheduleForZeroDecimalCurrency(t *testing.T) {
+	schedule := corridors.DefaultFeeSchedule.ForCurrency("JPY")
+
+	assert.Equal(t, int64(100), schedule[0].UpTo)
+	assert.Equal(t, int64(1), schedule[2].FixedFee)
+	assert.Equal(t, corridors.DefaultFeeSchedule, corridors.DefaultFeeSchedule.ForCurrency("EUR"))
+}
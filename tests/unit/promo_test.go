@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+)
+
+func TestPromoAvailability(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	promo := &models.Promo{
+		Code:       "SPRING",
+		ValidFrom:  now.Add(-time.Hour),
+		ValidUntil: now.Add(time.Hour),
+		MaxUses:    2,
+		Uses:       1,
+	}
+
+	assert.Empty(t, promo.Unavailable(now))
+	assert.Equal(t, "promo code is not active yet", promo.Unavailable(now.Add(-2*time.Hour)))
+	assert.Equal(t, "promo code has expired", promo.Unavailable(now.Add(time.Hour)))
+
+	promo.Uses = 2
+	assert.Equal(t, "promo code has no uses left", promo.Unavailable(now))
+
+	// No end date and no cap
+	open := &models.Promo{Code: "OPEN", ValidFrom: now, Uses: 1000}
+	assert.Empty(t, open.Unavailable(now.AddDate(5, 0, 0)))
+}
+
+func TestPromoDiscount(t *testing.T) {
+	assert.Equal(t, int64(1050), (&models.Promo{PercentOff: 0.5}).Discount(2100))
+	assert.Equal(t, int64(550), (&models.Promo{PercentOff: 0.25, AmountOff: 25}).Discount(2100))
+	assert.Equal(t, int64(2100), (&models.Promo{AmountOff: 5000}).Discount(2100)) // Never more than the fee
+	assert.Equal(t, "SUMMER10", models.NormalizePromoCode(" summer10 "))
+}
+
+func TestMemoryPromoRepositoryEnforcesUsageCap(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPromoRepository()
+	require.NoError(t, repo.CreatePromo(ctx, &models.Promo{Code: "ONCE", PercentOff: 0.1, MaxUses: 1}))
+	assert.Error(t, repo.CreatePromo(ctx, &models.Promo{Code: "ONCE"}))
+
+	require.NoError(t, repo.RedeemPromo(ctx, "ONCE"))
+	assert.Error(t, repo.RedeemPromo(ctx, "ONCE"))
+	assert.Error(t, repo.RedeemPromo(ctx, "MISSING"))
+
+	promo, err := repo.GetPromo(ctx, "ONCE")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), promo.Uses)
+
+	_, err = repo.GetPromo(ctx, "MISSING")
+	assert.Error(t, err)
+}
+
+func TestQuoteAppliesPromoToPlatformFee(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Circle", quote: quotes.ProviderQuote{Rate: 0.92}})
+
+	full, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.NoError(t, err)
+
+	promo := &models.Promo{Code: "HALF", PercentOff: 0.5}
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000, Promo: promo})
+	require.NoError(t, err)
+
+	assert.Equal(t, "HALF", quote.PromoCode)
+	assert.Equal(t, full.PlatformFee/2, quote.PromoDiscount)
+	assert.Equal(t, full.PlatformFee-quote.PromoDiscount, quote.PlatformFee)
+	assert.Equal(t, full.OnrampFee, quote.OnrampFee)
+	assert.Equal(t, full.TotalFees-quote.PromoDiscount, quote.TotalFees)
+	assert.Greater(t, quote.GuaranteedPayout, full.GuaranteedPayout)
+	assert.Equal(t, quote.PromoDiscount, quote.ToResponse().Fees.PromoDiscount)
+}
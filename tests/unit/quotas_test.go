@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quotaPayment is a USD payment made at a given time
+func quotaPayment(id string, amount int64, at time.Time) *models.Payment {
+	return &models.Payment{PaymentID: id, Amount: amount, Currency: "USD", CreatedAt: at}
+}
+
+func TestQuotaWindowsRollOverAtUTCBoundaries(t *testing.T) {
+	limits := models.QuotaLimits{DailyCount: 5, MonthlyVolume: 1000}
+	windows := models.QuotaWindows(limits, time.Date(2026, 10, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)))
+
+	require.Len(t, windows, 2)
+	assert.Equal(t, "daily#2026-11-01", windows[0].Key, "the window is the UTC day")
+	assert.Equal(t, int64(5), windows[0].MaxCount)
+	assert.Zero(t, windows[0].MaxVolume)
+	assert.Equal(t, time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC), windows[0].ResetsAt)
+	assert.Equal(t, "monthly#2026-11", windows[1].Key)
+	assert.Equal(t, int64(1000), windows[1].MaxVolume)
+	assert.Equal(t, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), windows[1].ResetsAt)
+}
+
+func TestParseQuotaTiers(t *testing.T) {
+	tiers, err := quotas.ParseTiers([]byte(`{"standard": {"daily_count": 2, "monthly_volume": 50000}}`))
+	require.NoError(t, err)
+	assert.Equal(t, models.QuotaLimits{DailyCount: 2, MonthlyVolume: 50000}, tiers["standard"])
+
+	_, err = quotas.ParseTiers([]byte(`{"standard": {"daily_count": -1}}`))
+	assert.Error(t, err)
+	_, err = quotas.ParseTiers([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestQuotaEnforcerReservesUntilLimit(t *testing.T) {
+	ctx := context.Background()
+	enforcer := quotas.New(database.NewMemoryQuotaRepository(), quotas.Tiers{
+		"standard": {DailyCount: 2, MonthlyVolume: 50000},
+	})
+	day := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_1", 10000, day)))
+	require.NoError(t, enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_2", 10000, day)))
+
+	err := enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_3", 10000, day))
+	require.Error(t, err)
+	appErr := err.(*errors.AppError)
+	assert.Equal(t, "QUOTA_EXCEEDED", appErr.Code)
+	assert.Equal(t, 429, appErr.StatusCode)
+	assert.Contains(t, appErr.Message, "daily")
+	assert.Contains(t, appErr.Message, "2026-10-17T00:00:00Z")
+
+	// Other customers and unlisted tiers are unaffected
+	require.NoError(t, enforcer.Reserve(ctx, "key_b", "standard", quotaPayment("pay_4", 10000, day)))
+	require.NoError(t, enforcer.Reserve(ctx, "key_c", "enterprise", quotaPayment("pay_5", 10000, day)))
+
+	// The next day has a fresh daily window, but the month's volume is nearly used
+	tomorrow := day.AddDate(0, 0, 1)
+	require.NoError(t, enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_6", 20000, tomorrow)))
+	err = enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_7", 20000, tomorrow))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "monthly")
+
+	status, err := enforcer.Status(ctx, "key_a", "standard", tomorrow)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), status.Daily.Payments.Used)
+	assert.Equal(t, int64(1), *status.Daily.Payments.Remaining)
+	assert.Nil(t, status.Daily.Volume.Limit, "no daily volume limit")
+	assert.Equal(t, int64(40000), status.Monthly.Volume.Used, "the refused payment isn't counted")
+	assert.Equal(t, int64(10000), *status.Monthly.Volume.Remaining)
+}
+
+func TestQuotaEnforcerRefusesOversizedPaymentAndReleases(t *testing.T) {
+	ctx := context.Background()
+	enforcer := quotas.New(database.NewMemoryQuotaRepository(), quotas.Tiers{
+		"standard": {DailyCount: 1, DailyVolume: 10000},
+	})
+	day := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	err := enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_1", 10001, day))
+	require.Error(t, err, "a payment larger than the window never fits")
+	assert.Contains(t, err.Error(), "QUOTA_EXCEEDED")
+
+	payment := quotaPayment("pay_2", 10000, day)
+	require.NoError(t, enforcer.Reserve(ctx, "key_a", "standard", payment))
+	require.Error(t, enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_3", 1, day)))
+
+	require.NoError(t, enforcer.Release(ctx, "key_a", payment))
+	require.NoError(t, enforcer.Reserve(ctx, "key_a", "standard", quotaPayment("pay_4", 10000, day)))
+}
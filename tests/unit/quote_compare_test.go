@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/quotes"
+)
+
+func TestCompareQuoteDelta(t *testing.T) {
+	quote := &quotes.Quote{
+		FromCurrency:    "USD",
+		ToCurrency:      "EUR",
+		Amount:          100000,
+		TotalFees:       3000,
+		SettlementChain: "base",
+	}
+
+	comparison := quotes.Compare(quote, &fees.AIFeeResponse{
+		TotalFee: 2700,
+		Provider: fees.ProviderRecommendation{Chain: "Base"},
+	})
+	assert.Equal(t, "USD", comparison.FeeCurrency)
+	assert.Equal(t, int64(-300), comparison.Delta.Fee)
+	assert.InDelta(t, -10.0, comparison.Delta.FeePercent, 1e-9)
+	assert.False(t, comparison.Delta.ChainDiffers, "chain names compare case-insensitively")
+	assert.Equal(t, quotes.CheaperAI, comparison.Delta.Cheaper)
+
+	comparison = quotes.Compare(quote, &fees.AIFeeResponse{
+		TotalFee: 3300,
+		Provider: fees.ProviderRecommendation{Chain: "Polygon"},
+	})
+	assert.Equal(t, int64(300), comparison.Delta.Fee)
+	assert.True(t, comparison.Delta.ChainDiffers)
+	assert.Equal(t, quotes.CheaperStatic, comparison.Delta.Cheaper)
+
+	comparison = quotes.Compare(quote, &fees.AIFeeResponse{TotalFee: 3000})
+	assert.False(t, comparison.Delta.ChainDiffers, "a chain only one side names can't differ")
+	assert.Equal(t, quotes.CheaperEqual, comparison.Delta.Cheaper)
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/quotes"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quoteImage builds a quotes table stream image, optionally marked consumed
+func quoteImage(consumed bool) map[string]events.DynamoDBAttributeValue {
+	image := map[string]events.DynamoDBAttributeValue{
+		"quote_id":      events.NewStringAttribute("quote_1"),
+		"from_currency": events.NewStringAttribute("USD"),
+		"amount":        events.NewNumberAttribute("10000"),
+		"expires_at":    events.NewStringAttribute("2026-01-02T15:04:05Z"),
+	}
+	if consumed {
+		image["consumed_at"] = events.NewStringAttribute("2026-01-02T15:04:00Z")
+		image["payment_id"] = events.NewStringAttribute("pay_1")
+	}
+	return image
+}
+
+func ttlIdentity() *events.DynamoDBUserIdentity {
+	return &events.DynamoDBUserIdentity{Type: "Service", PrincipalID: "dynamodb.amazonaws.com"}
+}
+
+func TestStreamWebhookEvent(t *testing.T) {
+	t.Run("TTL delete of unused quote expires it", func(t *testing.T) {
+		event := quotes.StreamWebhookEvent(events.DynamoDBEventRecord{
+			EventName:    "REMOVE",
+			UserIdentity: ttlIdentity(),
+			Change:       events.DynamoDBStreamRecord{OldImage: quoteImage(false)},
+		})
+		require.NotNil(t, event)
+		assert.Equal(t, quotes.WebhookQuoteExpired, event.EventType)
+		assert.Equal(t, "quote_1", event.QuoteID)
+		assert.Equal(t, int64(10000), event.Amount)
+		assert.Equal(t, "USD", event.Currency)
+		require.NotNil(t, event.ExpiresAt)
+		assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), event.ExpiresAt.UTC())
+	})
+
+	t.Run("TTL delete of consumed quote is ignored", func(t *testing.T) {
+		event := quotes.StreamWebhookEvent(events.DynamoDBEventRecord{
+			EventName:    "REMOVE",
+			UserIdentity: ttlIdentity(),
+			Change:       events.DynamoDBStreamRecord{OldImage: quoteImage(true)},
+		})
+		assert.Nil(t, event)
+	})
+
+	t.Run("manual delete is ignored", func(t *testing.T) {
+		event := quotes.StreamWebhookEvent(events.DynamoDBEventRecord{
+			EventName: "REMOVE",
+			Change:    events.DynamoDBStreamRecord{OldImage: quoteImage(false)},
+		})
+		assert.Nil(t, event)
+	})
+
+	t.Run("consumption update emits consumed", func(t *testing.T) {
+		event := quotes.StreamWebhookEvent(events.DynamoDBEventRecord{
+			EventName: "MODIFY",
+			Change:    events.DynamoDBStreamRecord{OldImage: quoteImage(false), NewImage: quoteImage(true)},
+		})
+		require.NotNil(t, event)
+		assert.Equal(t, quotes.WebhookQuoteConsumed, event.EventType)
+		assert.Equal(t, "pay_1", event.PaymentID)
+	})
+
+	t.Run("insert is ignored", func(t *testing.T) {
+		event := quotes.StreamWebhookEvent(events.DynamoDBEventRecord{
+			EventName: "INSERT",
+			Change:    events.DynamoDBStreamRecord{NewImage: quoteImage(false)},
+		})
+		assert.Nil(t, event)
+	})
+}
+
+func TestMemoryQuoteRepositoryMarkConsumed(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryQuoteRepository()
+	require.NoError(t, repo.CreateQuote(ctx, &quotes.Quote{QuoteID: "quote_1", Amount: 10000}))
+
+	now := time.Now()
+	require.NoError(t, repo.MarkQuoteConsumed(ctx, "quote_1", "pay_1", now))
+
+	quote, err := repo.GetQuote(ctx, "quote_1")
+	require.NoError(t, err)
+	require.NotNil(t, quote.ConsumedAt)
+	assert.Equal(t, "pay_1", quote.PaymentID)
+
+	assert.Error(t, repo.MarkQuoteConsumed(ctx, "quote_1", "pay_2", now), "a quote can only be consumed once")
+	assert.Error(t, repo.MarkQuoteConsumed(ctx, "quote_missing", "pay_3", now))
+}
+
+func TestCreatingPaymentConsumesQuote(t *testing.T) {
+	ctx := context.Background()
+	quoteRepo := database.NewMemoryQuoteRepository()
+	require.NoError(t, quoteRepo.CreateQuote(ctx, &quotes.Quote{QuoteID: "quote_1", Amount: 10000}))
+	payments := database.NewMemoryPaymentRepository()
+	payments.EnableQuoteConsumption(quoteRepo)
+
+	create := func(paymentID string) error {
+		msg, err := models.NewOutboxMessage("msg_"+paymentID, models.OutboxKindPaymentJob, paymentID, &models.PaymentJob{PaymentID: paymentID})
+		require.NoError(t, err)
+		return payments.CreatePaymentWithOutbox(ctx, &models.Payment{
+			PaymentID:      paymentID,
+			IdempotencyKey: "key_" + paymentID,
+			Amount:         10000,
+			QuoteID:        "quote_1",
+			CreatedAt:      time.Now(),
+		}, msg)
+	}
+
+	require.NoError(t, create("pay_1"))
+	quote, err := quoteRepo.GetQuote(ctx, "quote_1")
+	require.NoError(t, err)
+	require.NotNil(t, quote.ConsumedAt)
+	assert.Equal(t, "pay_1", quote.PaymentID)
+
+	// A second payment racing for the quote is refused, and nothing of it is written
+	err = create("pay_2")
+	require.Error(t, err)
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "QUOTE_CONSUMED", appErr.Code)
+
+	_, err = payments.GetPaymentByID(ctx, "pay_2")
+	assert.Error(t, err)
+	outbox, err := payments.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, outbox, 1)
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/corridors"
+	apperrors "crypto-conversion/internal/errors"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/fx"
+	"crypto-conversion/internal/quotes"
+)
+
+// fakeRateProvider returns a fixed quote or error
+type fakeRateProvider struct {
+	name  string
+	quote quotes.ProviderQuote
+	err   error
+}
+
+func (f *fakeRateProvider) Name() string { return f.name }
+
+func (f *fakeRateProvider) Quote(ctx context.Context, corridor corridors.Corridor, amount int64) (*quotes.ProviderQuote, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	q := f.quote
+	q.Provider = f.name
+	return &q, nil
+}
+
+func newProviderCalculator(providers ...quotes.RateProvider) *quotes.Calculator {
+	return quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), corridors.Default(), providers)
+}
+
+func TestQuoteUsesBestProviderRate(t *testing.T) {
+	calc := newProviderCalculator(
+		&fakeRateProvider{name: "Circle", quote: quotes.ProviderQuote{Rate: 0.91, QuoteID: "circle-q1"}},
+		&fakeRateProvider{name: "Coinbase", quote: quotes.ProviderQuote{Rate: 0.90}},
+	)
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.NoError(t, err)
+	assert.Equal(t, "Circle", quote.ProviderRate)
+	assert.Equal(t, "circle-q1", quote.ProviderQuoteID)
+	assert.Equal(t, 0.91, quote.ExchangeRate)
+}
+
+func TestQuoteBreakdownShowsFeeLimit(t *testing.T) {
+	list := append([]corridors.Corridor(nil), corridors.Defaults...)
+	for i := range list {
+		if list[i].ID == "USD-EUR" {
+			list[i].MaxFee = 5000
+		}
+	}
+	registry, err := corridors.NewRegistry(list)
+	require.NoError(t, err)
+	calc := quotes.NewCalculator(fees.NewCalculator(), quotes.DefaultTTLPolicy(), registry, nil)
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 1000000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), quote.PlatformFee)
+
+	breakdown := quote.ToResponse().Fees
+	assert.Equal(t, fees.FeeLimitMaximum, breakdown.FeeLimit)
+	assert.Equal(t, int64(20100), breakdown.UnclampedFee)
+	assert.Equal(t, breakdown.PlatformFee+breakdown.OnrampFee+breakdown.OfframpFee, breakdown.TotalFees)
+}
+
+func TestQuoteBreakdownShowsSurcharges(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Bridge", quote: quotes.ProviderQuote{Rate: 5.0}})
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "BRL", Amount: 100000})
+	require.NoError(t, err)
+
+	breakdown := quote.ToResponse().Fees
+	assert.Equal(t, int64(2650), breakdown.PlatformFee)
+	assert.Equal(t, int64(380), breakdown.RegulatoryFee)
+	assert.Equal(t, []fees.SurchargeFee{{Name: "IOF", Amount: 380}}, breakdown.Surcharges)
+	assert.Equal(t, breakdown.PlatformFee+breakdown.RegulatoryFee+breakdown.OnrampFee+breakdown.OfframpFee, breakdown.TotalFees)
+}
+
+func TestQuoteSkipsFailedProviders(t *testing.T) {
+	calc := newProviderCalculator(
+		&fakeRateProvider{name: "Circle", err: fmt.Errorf("timeout")},
+		&fakeRateProvider{name: "Coinbase", quote: quotes.ProviderQuote{Rate: 0.90}},
+	)
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.NoError(t, err)
+	assert.Equal(t, "Coinbase", quote.ProviderRate)
+}
+
+func TestQuoteUnavailableWhenAllProvidersFail(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Circle", err: fmt.Errorf("timeout")})
+
+	_, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.Error(t, err)
+	appErr, ok := err.(*apperrors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "QUOTE_UNAVAILABLE", appErr.Code)
+}
+
+func TestQuoteUnavailableWhenNoProviderServesCorridor(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Kraken", quote: quotes.ProviderQuote{Rate: 0.95}})
+
+	_, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	assert.Error(t, err, "providers not listed on the corridor are never used")
+}
+
+func TestQuoteUsesProviderFeesAndExpiry(t *testing.T) {
+	providerExpiry := time.Now().Add(20 * time.Second)
+	calc := newProviderCalculator(&fakeRateProvider{name: "Circle", quote: quotes.ProviderQuote{
+		Rate:       0.92,
+		OnrampFee:  100,
+		OfframpFee: 200,
+		FeesQuoted: true,
+		ExpiresAt:  providerExpiry,
+	}})
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "EUR", Amount: 100000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), quote.OnrampFee)
+	assert.Equal(t, int64(200), quote.OfframpFee)
+	assert.True(t, quote.ExpiresAt.Equal(providerExpiry), "quote can't outlive the provider's")
+	assert.Equal(t, "provider:Circle", quote.TTLPolicy.Rule)
+}
+
+func TestQuoteSimulatesRatesWithoutProviders(t *testing.T) {
+	calc := newProviderCalculator()
+
+	quote, err := calc.GenerateQuote(context.Background(), &quotes.QuoteRequest{FromCurrency: "USD", ToCurrency: "GBP", Amount: 100000})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.79, quote.ExchangeRate, 0.01)
+}
+
+// fakeMidRates returns fixed FX rates or an error
+type fakeMidRates struct {
+	rates *fx.Rates
+	err   error
+}
+
+func (f *fakeMidRates) FXRates(ctx context.Context) (*fx.Rates, error) {
+	return f.rates, f.err
+}
+
+func TestIndicativeRateAgainstLiveMidRate(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Circle", quote: quotes.ProviderQuote{Rate: 0.918}})
+	fetchedAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	calc.SetMidRateSource(&fakeMidRates{rates: &fx.Rates{Source: "ecb", Rates: map[string]float64{"EUR": 0.92}, FetchedAt: fetchedAt}})
+
+	rate, err := calc.IndicativeRate(context.Background(), "usd", "eur", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "USD-EUR", rate.CorridorID)
+	assert.Equal(t, 0.92, rate.MidRate)
+	assert.Equal(t, "ecb", rate.MidRateSource)
+	assert.Equal(t, fetchedAt, rate.MidRateAsOf)
+	assert.Equal(t, 0.918, rate.Rate)
+	assert.Equal(t, "Circle", rate.Provider)
+	assert.InDelta(t, 0.002/0.92, rate.Spread, 1e-9)
+	assert.Equal(t, int64(100000), rate.Amount, "$1,000 by default")
+}
+
+func TestIndicativeRateFallsBackToCorridorRate(t *testing.T) {
+	calc := newProviderCalculator(&fakeRateProvider{name: "Circle", quote: quotes.ProviderQuote{Rate: 0.918}})
+	calc.SetMidRateSource(&fakeMidRates{err: fmt.Errorf("ecb unavailable")})
+	usdEUR, err := corridors.Default().Lookup("USD", "EUR")
+	require.NoError(t, err)
+
+	rate, err := calc.IndicativeRate(context.Background(), "USD", "EUR", 500000)
+	require.NoError(t, err)
+	assert.Equal(t, usdEUR.MidMarketRate, rate.MidRate)
+	assert.Equal(t, quotes.RateSourceCorridor, rate.MidRateSource)
+	assert.Equal(t, int64(500000), rate.Amount)
+
+	_, err = calc.IndicativeRate(context.Background(), "USD", "JPY", 0)
+	assert.Error(t, err)
+	_, err = calc.IndicativeRate(context.Background(), "USD", "EUR", 50)
+	assert.Error(t, err, "below the corridor minimum")
+}
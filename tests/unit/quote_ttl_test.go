@@ -0,0 +1,51 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"crypto-conversion/internal/quotes"
+)
+
+func TestTTLPolicyResolve(t *testing.T) {
+	policy := quotes.TTLPolicy{
+		Default:             60 * time.Second,
+		Corridors:           map[string]time.Duration{"USD-EUR": 45 * time.Second},
+		Tiers:               map[string]time.Duration{"enterprise": 300 * time.Second},
+		VolatilityThreshold: 0.005,
+		VolatileTTL:         20 * time.Second,
+	}
+
+	tests := []struct {
+		name     string
+		corridor string
+		tier     string
+		spread   float64
+		wantTTL  time.Duration
+		wantRule string
+		capped   bool
+	}{
+		{"default", "USD-GBP", "", 0.001, 60 * time.Second, "default", false},
+		{"corridor", "USD-EUR", "", 0.001, 45 * time.Second, "corridor:USD-EUR", false},
+		{"tier beats corridor", "USD-EUR", "enterprise", 0.001, 300 * time.Second, "tier:enterprise", false},
+		{"unknown tier", "USD-GBP", "startup", 0.001, 60 * time.Second, "default", false},
+		{"volatility caps tier", "USD-EUR", "enterprise", 0.01, 20 * time.Second, "tier:enterprise", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, decision := policy.Resolve(tt.corridor, tt.tier, tt.spread)
+			assert.Equal(t, tt.wantTTL, ttl)
+			assert.Equal(t, tt.wantRule, decision.Rule)
+			assert.Equal(t, tt.capped, decision.VolatilityCapped)
+		})
+	}
+}
+
+func TestTTLPolicyZeroValueFallsBackToDefault(t *testing.T) {
+	ttl, decision := quotes.TTLPolicy{}.Resolve("USD-EUR", "", 0.5)
+	assert.Equal(t, quotes.DefaultQuoteTTL, ttl)
+	assert.Equal(t, "default", decision.Rule)
+	assert.False(t, decision.VolatilityCapped)
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reconciliation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStatement serves a fixed provider statement
+type fakeStatement struct {
+	txns []*models.StatementTransaction
+}
+
+func (s *fakeStatement) Name() string { return "circle" }
+
+func (s *fakeStatement) ListTransactions(ctx context.Context, from, until time.Time) ([]*models.StatementTransaction, error) {
+	return s.txns, nil
+}
+
+// reconciledPayment builds a payment whose on-ramp settled at settled and whose off-ramp completed at completed
+func reconciledPayment(id string, status models.PaymentStatus, created time.Time, settled, completed *time.Time) *models.Payment {
+	p := &models.Payment{
+		PaymentID:      id,
+		IdempotencyKey: id,
+		Amount:         100000,
+		Currency:       "USD",
+		OnRampTxID:     "onramp_" + id,
+		OffRampTxID:    "offramp_" + id,
+		Status:         status,
+		CreatedAt:      created,
+	}
+	if settled != nil {
+		p.StateHistory = append(p.StateHistory, models.StateTransition{ToStatus: models.StatusOnrampComplete, Timestamp: *settled})
+	}
+	if completed != nil {
+		p.StateHistory = append(p.StateHistory, models.StateTransition{ToStatus: models.StatusCompleted, Timestamp: *completed})
+	}
+	return p
+}
+
+func TestReconcilerRecordsBreaks(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, 1)
+	at := from.Add(6 * time.Hour)
+
+	payments := database.NewMemoryPaymentRepository()
+	for _, p := range []*models.Payment{
+		reconciledPayment("pay_ok", models.StatusCompleted, from.Add(time.Hour), &at, &at),
+		reconciledPayment("pay_short", models.StatusCompleted, from.Add(time.Hour), &at, &at),
+		reconciledPayment("pay_failed", models.StatusCompleted, from.Add(-24*time.Hour), &at, &at),
+		reconciledPayment("pay_missing", models.StatusCompleted, from.Add(time.Hour), &at, &at),
+	} {
+		require.NoError(t, payments.CreatePayment(ctx, p))
+	}
+
+	complete := func(leg, txID string, amount int64) *models.StatementTransaction {
+		return &models.StatementTransaction{Provider: "circle", Leg: leg, TxID: txID, Amount: amount, Currency: "USD", Status: models.StatementStatusComplete}
+	}
+	statement := &fakeStatement{txns: []*models.StatementTransaction{
+		complete(models.RampLegOnramp, "onramp_pay_ok", 100000),
+		complete(models.RampLegOfframp, "offramp_pay_ok", 100000),
+		complete(models.RampLegOnramp, "onramp_pay_short", 100000),
+		complete(models.RampLegOfframp, "offramp_pay_short", 99000),
+		complete(models.RampLegOnramp, "onramp_pay_failed", 100000),
+		{Provider: "circle", Leg: models.RampLegOfframp, TxID: "offramp_pay_failed", Amount: 100000, Currency: "USD", Status: models.StatementStatusFailed},
+		complete(models.RampLegOnramp, "onramp_pay_missing", 100000),
+		complete(models.RampLegOfframp, "offramp_stranger", 5000),
+	}}
+
+	breaks := database.NewMemoryReconciliationRepository()
+	reconciler := reconciliation.NewReconciler(statement, payments, breaks, 3*24*time.Hour)
+
+	report, err := reconciler.Run(ctx, from, until)
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Statement)
+	assert.Equal(t, 7, report.Matched)
+	assert.Equal(t, 4, report.NewBreaks)
+
+	stored, err := breaks.ListBreaks(ctx, models.BreakStatusOpen)
+	require.NoError(t, err)
+	kinds := make(map[string]*models.ReconciliationBreak)
+	for _, brk := range stored {
+		kinds[brk.BreakID] = brk
+	}
+	require.Len(t, kinds, 4)
+
+	short := kinds["circle:offramp_pay_short:amount_mismatch"]
+	require.NotNil(t, short)
+	assert.Equal(t, "pay_short", short.PaymentID)
+	assert.Equal(t, int64(100000), short.ExpectedAmount)
+	assert.Equal(t, int64(99000), short.ActualAmount)
+
+	assert.Contains(t, kinds, "circle:offramp_pay_failed:status_mismatch", "a payment from the lookback period is matched")
+	assert.Contains(t, kinds, "circle:offramp_pay_missing:missing_from_statement")
+	assert.Contains(t, kinds, "circle:offramp_stranger:unknown_transfer")
+
+	// Re-running the day records nothing new
+	report, err = reconciler.Run(ctx, from, until)
+	require.NoError(t, err)
+	assert.Len(t, report.Breaks, 4)
+	assert.Zero(t, report.NewBreaks)
+}
+
+func TestReconcilerIgnoresPendingAndUnsettledTransfers(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, 1)
+	settled := from.Add(time.Hour)
+	later := until.Add(time.Hour)
+
+	transfers := models.RampTransfersFromPayment(reconciledPayment("pay_pending", models.StatusOfframpPending, from, &settled, nil))
+	transfers = append(transfers, models.RampTransfersFromPayment(reconciledPayment("pay_tomorrow", models.StatusCompleted, from, &settled, &later))...)
+
+	statement := []*models.StatementTransaction{
+		{Leg: models.RampLegOnramp, TxID: "onramp_pay_pending", Amount: 100000, Currency: "usd", Status: models.StatementStatusComplete},
+		{Leg: models.RampLegOfframp, TxID: "offramp_pay_pending", Amount: 1, Currency: "USD", Status: models.StatementStatusPending},
+		{Leg: models.RampLegOnramp, TxID: "onramp_pay_tomorrow", Amount: 100000, Currency: "USD", Status: models.StatementStatusComplete},
+		{Leg: models.RampLegOfframp, TxID: "offramp_unknown", Amount: 1, Currency: "USD", Status: models.StatementStatusPending},
+	}
+
+	breaks := reconciliation.Match("circle", statement, transfers, from, until, time.Now())
+	assert.Empty(t, breaks)
+}
+
+func TestResolveBreakOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryReconciliationRepository()
+	recorded, err := repo.RecordBreak(ctx, &models.ReconciliationBreak{
+		BreakID:    "circle:offramp_1:amount_mismatch",
+		Kind:       models.BreakAmountMismatch,
+		Status:     models.BreakStatusOpen,
+		DetectedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.True(t, recorded)
+
+	require.NoError(t, repo.ResolveBreak(ctx, "circle:offramp_1:amount_mismatch", "Circle refunded the difference", "ops", time.Now()))
+
+	err = repo.ResolveBreak(ctx, "circle:offramp_1:amount_mismatch", "again", "ops", time.Now())
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusConflict, appErr.StatusCode)
+
+	err = repo.ResolveBreak(ctx, "circle:missing:amount_mismatch", "nothing", "ops", time.Now())
+	appErr, ok = err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "BREAK_NOT_FOUND", appErr.Code)
+
+	brk, err := repo.GetBreak(ctx, "circle:offramp_1:amount_mismatch")
+	require.NoError(t, err)
+	assert.Equal(t, models.BreakStatusResolved, brk.Status)
+	assert.Equal(t, "ops", brk.ResolvedBy)
+}
@@ -0,0 +1,177 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reporting"
+)
+
+// recordingExporter captures exported reports instead of writing to S3
+type recordingExporter struct {
+	exports map[string][]*models.RevenueReport
+}
+
+func (e *recordingExporter) ExportRevenueReport(ctx context.Context, reportDate string, reports []*models.RevenueReport) error {
+	e.exports[reportDate] = reports
+	return nil
+}
+
+func TestBuildRevenueReportGroupsByCorridorAndCustomer(t *testing.T) {
+	invoices := []*models.FeeInvoice{
+		{PaymentID: "pay_1", CustomerID: "key_b", Amount: 500000, Currency: "USD", PayoutCurrency: "EUR", PlatformFee: 7500, OnrampFee: 2500, OfframpFee: 3000},
+		{PaymentID: "pay_2", CustomerID: "key_b", Amount: 200000, Currency: "USD", PayoutCurrency: "EUR", PlatformFee: 4000, OnrampFee: 1000, OfframpFee: 1200},
+		{PaymentID: "pay_3", CustomerID: "key_a", Amount: 100000, Currency: "USD", PayoutCurrency: "BRL", PlatformFee: 2500, RegulatoryFee: 380},
+		{PaymentID: "pay_4", CustomerID: "key_a", Amount: 300000, Currency: "USD", PayoutCurrency: "EUR", PlatformFee: 5100},
+	}
+
+	generatedAt := time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)
+	reports := reporting.BuildRevenueReport("2026-03-01", invoices, map[string]int64{"pay_3": 40}, generatedAt)
+	require.Len(t, reports, 3)
+
+	assert.Equal(t, "USD-BRL", reports[0].Corridor)
+	assert.Equal(t, int64(380), reports[0].RegulatoryFees)
+	assert.Equal(t, int64(40), reports[0].GasSpend)
+
+	assert.Equal(t, "USD-EUR", reports[1].Corridor)
+	assert.Equal(t, "key_a", reports[1].CustomerID)
+
+	eur := reports[2]
+	assert.Equal(t, "key_b", eur.CustomerID)
+	assert.Equal(t, "2026-03-01", eur.ReportDate)
+	assert.Equal(t, "USD", eur.Currency)
+	assert.Equal(t, 2, eur.Payments)
+	assert.Equal(t, int64(700000), eur.Volume)
+	assert.Equal(t, int64(11500), eur.PlatformRevenue)
+	assert.Equal(t, int64(7700), eur.ProviderCosts)
+	assert.Equal(t, generatedAt, eur.GeneratedAt)
+}
+
+func TestReporterRunReportsOneUTCDay(t *testing.T) {
+	ctx := context.Background()
+	invoices := database.NewMemoryFeeInvoiceRepository()
+	payments := database.NewMemoryPaymentRepository()
+	store := database.NewMemoryRevenueReportRepository()
+	exporter := &recordingExporter{exports: make(map[string][]*models.RevenueReport)}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for id, completedAt := range map[string]time.Time{
+		"pay_before": day.Add(-time.Nanosecond),
+		"pay_start":  day,
+		"pay_end":    day.Add(24*time.Hour - time.Nanosecond),
+		"pay_after":  day.Add(24 * time.Hour),
+	} {
+		require.NoError(t, invoices.CreateFeeInvoice(ctx, &models.FeeInvoice{
+			PaymentID:      id,
+			CustomerID:     "key_1",
+			Amount:         100000,
+			Currency:       "USD",
+			PayoutCurrency: "EUR",
+			PlatformFee:    1500,
+			CompletedAt:    completedAt,
+		}))
+		createReportedPayment(t, payments, id, models.StatusCompleted)
+	}
+
+	reports, err := reporting.NewReporter(invoices, payments, store, exporter).Run(ctx, day.Add(15*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 2, reports[0].Payments)
+	assert.Equal(t, int64(3000), reports[0].PlatformRevenue)
+
+	stored, err := store.ListRevenueReports(ctx, "2026-03-01")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 2, stored[0].Payments)
+	assert.Len(t, exporter.exports["2026-03-01"], 1)
+
+	// A late invoice is picked up when the day is re-run, replacing the stored row
+	require.NoError(t, invoices.CreateFeeInvoice(ctx, &models.FeeInvoice{
+		PaymentID: "pay_late", CustomerID: "key_1", Amount: 100000, Currency: "USD", PayoutCurrency: "EUR",
+		PlatformFee: 1500, CompletedAt: day.Add(12 * time.Hour),
+	}))
+	createReportedPayment(t, payments, "pay_late", models.StatusCompleted)
+	_, err = reporting.NewReporter(invoices, payments, store, exporter).Run(ctx, day)
+	require.NoError(t, err)
+
+	stored, err = store.ListRevenueReports(ctx, "2026-03-01")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 3, stored[0].Payments)
+}
+
+// createReportedPayment stores an invoiced payment in status
+func createReportedPayment(t *testing.T, payments database.PaymentRepository, paymentID string, status models.PaymentStatus) {
+	require.NoError(t, payments.CreatePayment(context.Background(), &models.Payment{
+		PaymentID:      paymentID,
+		IdempotencyKey: "key_" + paymentID,
+		Amount:         100000,
+		Currency:       "EUR",
+		Status:         status,
+	}))
+}
+
+func TestReporterCountsCompletedPaymentsAndLedgerGas(t *testing.T) {
+	ctx := context.Background()
+	invoices := database.NewMemoryFeeInvoiceRepository()
+	payments := database.NewMemoryPaymentRepository()
+	ledgerRepo := database.NewMemoryLedgerRepository()
+	store := database.NewMemoryRevenueReportRepository()
+	exporter := &recordingExporter{exports: make(map[string][]*models.RevenueReport)}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"pay_completed", "pay_reversed"} {
+		require.NoError(t, invoices.CreateFeeInvoice(ctx, &models.FeeInvoice{
+			PaymentID: id, CustomerID: "key_1", Amount: 100000, Currency: "USD", PayoutCurrency: "EUR",
+			PlatformFee: 1500, CompletedAt: day.Add(time.Hour),
+		}))
+	}
+	createReportedPayment(t, payments, "pay_completed", models.StatusCompleted)
+	createReportedPayment(t, payments, "pay_reversed", models.StatusFailed)
+
+	// Gas on the payout and the bridge burn
+	for leg, entries := range map[string][]models.LedgerEntry{
+		models.LedgerLegOfframp: ledger.Payout("base", ledger.Amounts{Funds: 100000, Fee: 1500}, 3),
+		models.LedgerLegBridge:  ledger.Bridge("base", "polygon", ledger.Amounts{Funds: 100000, Fee: 1500}, 2),
+	} {
+		require.NoError(t, ledgerRepo.CreateLedgerTransaction(ctx, &models.LedgerTransaction{
+			TransactionID: "pay_completed:" + leg, PaymentID: "pay_completed", Leg: leg, Entries: entries,
+		}))
+	}
+
+	reporter := reporting.NewReporter(invoices, payments, store, exporter)
+	reporter.EnableLedger(ledgerRepo)
+	reports, err := reporter.Run(ctx, day)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 1, reports[0].Payments, "the reversed payment earned nothing")
+	assert.Equal(t, int64(1500), reports[0].PlatformRevenue)
+	assert.Equal(t, int64(5), reports[0].GasSpend)
+}
+
+func TestEncodeRevenueCSV(t *testing.T) {
+	body, err := reporting.EncodeRevenueCSV([]*models.RevenueReport{{
+		ReportDate:      "2026-03-01",
+		Corridor:        "USD-EUR",
+		CustomerID:      "key_1",
+		Currency:        "USD",
+		Payments:        2,
+		Volume:          700000,
+		PlatformRevenue: 11500,
+		ProviderCosts:   7700,
+		GeneratedAt:     time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC),
+	}})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "report_date,corridor,customer_id,currency,payments,volume,platform_revenue,regulatory_fees,provider_costs,gas_spend,generated_at", lines[0])
+	assert.Equal(t, "2026-03-01,USD-EUR,key_1,USD,2,700000,11500,0,7700,0,2026-03-02T01:00:00Z", lines[1])
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"crypto-conversion/internal/router"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// named returns a handler that answers with its name, so tests can tell which route matched
+func named(name string) router.HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: name}, nil
+	}
+}
+
+// dispatch matches a request and runs its handler, returning the handler's name
+func dispatch(t *testing.T, r *router.Router, method, path string) (string, *router.Match) {
+	match, _, found := r.Match(method, path)
+	require.True(t, found, path)
+	require.NotNil(t, match, path)
+	resp, err := match.Handler()(context.Background(), events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	return resp.Body, match
+}
+
+func TestRouterMatchesPatternsAndVersionPrefixes(t *testing.T) {
+	r := router.New("v1")
+	v1 := r.Version("v1")
+	v1.Handle(http.MethodPost, "/payments", named("create"))
+	v1.Handle(http.MethodPost, "/payments/batch", named("batch"))
+	v1.Handle(http.MethodGet, "/payments/{payment_id}", named("get"))
+	v1.Handle(http.MethodPost, "/payments/{payment_id}/review", named("review"))
+	v1.Handle(http.MethodDelete, "/customers/{customer_id}/accounts/{account_id}", named("unlink"))
+
+	name, match := dispatch(t, r, http.MethodGet, "/payments/pay_1")
+	assert.Equal(t, "get", name)
+	assert.Equal(t, "v1", match.Version)
+	assert.Equal(t, map[string]string{"payment_id": "pay_1"}, match.Params)
+
+	// Unprefixed paths are the default version's
+	name, match = dispatch(t, r, http.MethodPost, "/v1/payments/pay_1/review/")
+	assert.Equal(t, "review", name)
+	assert.Equal(t, "/payments/{payment_id}/review", match.Route.Pattern)
+
+	name, match = dispatch(t, r, http.MethodDelete, "/v1/customers/cus_1/accounts/acct_1")
+	assert.Equal(t, "unlink", name)
+	assert.Equal(t, map[string]string{"customer_id": "cus_1", "account_id": "acct_1"}, match.Params)
+
+	// A literal segment beats a parameter
+	v1.Handle(http.MethodGet, "/payments/batch", named("get_batch"))
+	name, _ = dispatch(t, r, http.MethodGet, "/payments/batch")
+	assert.Equal(t, "get_batch", name)
+	name, _ = dispatch(t, r, http.MethodPost, "/payments/batch")
+	assert.Equal(t, "batch", name)
+}
+
+func TestRouterReportsUnknownPathsAndMethods(t *testing.T) {
+	r := router.New("v1")
+	r.Version("v1").Handle(http.MethodGet, "/webhooks", named("list"))
+	r.Version("v1").Handle(http.MethodPost, "/webhooks", named("create"))
+
+	_, _, found := r.Match(http.MethodGet, "/webhooks/sub_1/unknown")
+	assert.False(t, found)
+	_, _, found = r.Match(http.MethodGet, "/v3/webhooks")
+	assert.False(t, found, "an unknown version is just an unknown path")
+
+	match, allowed, found := r.Match(http.MethodDelete, "/webhooks")
+	assert.True(t, found)
+	assert.Nil(t, match)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPost}, allowed)
+}
+
+func TestNewVersionInheritsRoutesItDoesNotReplace(t *testing.T) {
+	r := router.New("v1")
+	v1 := r.Version("v1")
+	v1.Handle(http.MethodPost, "/payments", named("create_v1"))
+	v1.Handle(http.MethodGet, "/payments/{payment_id}", named("get_v1"))
+	v2 := r.AddVersion("v2", v1)
+	v2.Handle(http.MethodPost, "/payments", named("create_v2"))
+
+	name, match := dispatch(t, r, http.MethodPost, "/v2/payments")
+	assert.Equal(t, "create_v2", name)
+	assert.Equal(t, "v2", match.Version)
+
+	name, match = dispatch(t, r, http.MethodGet, "/v2/payments/pay_1")
+	assert.Equal(t, "get_v1", name)
+	assert.Equal(t, "v2", match.Version, "the version asked for, not the one that registered the route")
+	assert.Equal(t, "v1", match.Route.Version)
+
+	// Unprefixed and /v1 paths keep the old behavior
+	name, _ = dispatch(t, r, http.MethodPost, "/payments")
+	assert.Equal(t, "create_v1", name)
+	name, _ = dispatch(t, r, http.MethodPost, "/v1/payments")
+	assert.Equal(t, "create_v1", name)
+
+	assert.Len(t, r.Routes(), 3)
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"crypto-conversion/internal/compliance/sanctions"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sdnSample = `36,"AEROCARIBBEAN AIRLINES",-0- ,"CUBA",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- 
+9640,"KIM, Jong Un","individual","DPRK2",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,"DOB 08 Jan 1984."
+30001,"DOE, Sanctioned","individual","CYBER2",-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,-0- ,"Digital Currency Address - ETH 0xABCDEF0123456789abcdef0123456789ABCDEF01; Digital Currency Address - XBT 1BoatSLRHtKNngkdXEeobR76b53LETtpyT; alt. Email Address doe@example.com."
+` + "\x1a"
+
+func TestParseOFAC(t *testing.T) {
+	list, err := sanctions.ParseOFAC(strings.NewReader(sdnSample))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	matches, err := list.Screen(ctx, models.ScreeningSubject{Name: "Jong-Un Kim"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1, "names match regardless of order and punctuation")
+	assert.Equal(t, models.ScreeningFieldName, matches[0].Field)
+	assert.Equal(t, "KIM, Jong Un", matches[0].Entry)
+	assert.Equal(t, sanctions.OFACListName, matches[0].List)
+
+	matches, err = list.Screen(ctx, models.ScreeningSubject{WalletAddress: "0xabcdef0123456789ABCDEF0123456789abcdef01"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1, "wallet addresses from the remarks are listed")
+	assert.Equal(t, "DOE, Sanctioned", matches[0].Entry)
+
+	matches, err = list.Screen(ctx, models.ScreeningSubject{Name: "Jong Kim", Country: "DE"})
+	require.NoError(t, err)
+	assert.Empty(t, matches, "a partial name isn't a match")
+}
+
+func TestSanctionedCountries(t *testing.T) {
+	list, err := sanctions.Load(context.Background(), "", []string{"CU", "ir"})
+	require.NoError(t, err)
+
+	matches, err := list.Screen(context.Background(), models.ScreeningSubject{Name: "Ali", Country: "IR"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, models.ScreeningFieldCountry, matches[0].Field)
+}
+
+func TestSanctionsHitBeforePayoutHoldsPayment(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_sanctions",
+		IdempotencyKey: "key_sanctions",
+		Amount:         100000,
+		Currency:       "EUR",
+		Beneficiary:    &models.Beneficiary{Name: "Jong Un Kim", Country: "KP"},
+		Status:         models.StatusOnrampComplete,
+	}))
+
+	list, err := sanctions.ParseOFAC(strings.NewReader(sdnSample))
+	require.NoError(t, err)
+	list.AddCountry("KP")
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableScreening(list)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_sanctions"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_sanctions")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusComplianceHold, stored.Status)
+	assert.Empty(t, stored.OffRampTxID, "funds don't leave for a sanctioned counterparty")
+	assert.Empty(t, queue.jobs, "held payments wait for compliance")
+	require.NotNil(t, stored.Screening)
+	assert.Equal(t, models.ScreeningStageOfframp, stored.Screening.Stage)
+	assert.Len(t, stored.Screening.Matches, 2)
+	assert.Equal(t, models.StatusOnrampComplete, stored.Screening.HeldFrom)
+
+	// Releasing a false positive sends the payment on to the off-ramp without screening it again
+	require.NoError(t, payment.ResolveComplianceHold(stored, models.ComplianceDecisionRelease, "compliance@example.com", "Different person"))
+	assert.Equal(t, models.StatusOnrampComplete, stored.Status)
+	require.NoError(t, repo.UpdatePayment(ctx, stored))
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_sanctions"}))
+	stored, err = repo.GetPaymentByID(ctx, "pay_sanctions")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOfframpPending, stored.Status)
+}
+
+func TestRejectingComplianceHold(t *testing.T) {
+	held := func(from models.PaymentStatus) *models.Payment {
+		p := &models.Payment{PaymentID: "pay_held", Status: from}
+		payment.HoldForCompliance(p, models.ScreeningStageCreation, []models.ScreeningMatch{{Field: models.ScreeningFieldCountry, Entry: "IR"}})
+		return p
+	}
+
+	p := held(models.StatusPending)
+	require.NoError(t, payment.ResolveComplianceHold(p, models.ComplianceDecisionReject, "compliance@example.com", "Confirmed match"))
+	assert.Equal(t, models.StatusFailed, p.Status, "nothing moved, so the payment just fails")
+	assert.NotNil(t, p.ProcessedAt)
+
+	p = held(models.StatusBridgeComplete)
+	require.NoError(t, payment.ResolveComplianceHold(p, models.ComplianceDecisionReject, "compliance@example.com", "Confirmed match"))
+	assert.Equal(t, models.StatusReversing, p.Status, "on-ramped funds are returned")
+
+	assert.Error(t, payment.ResolveComplianceHold(p, models.ComplianceDecisionRelease, "compliance@example.com", ""), "only held payments can be resolved")
+}
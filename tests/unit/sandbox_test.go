@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/ledger"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runSandboxPayment steps a sandbox payment of amount until it ends or is held, returning it and the steps taken
+func runSandboxPayment(t *testing.T, amount int64) (*models.Payment, int) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_sandbox",
+		IdempotencyKey: "key_sandbox",
+		Amount:         amount,
+		Currency:       "EUR",
+		Status:         models.StatusPending,
+		ExpectedRate:   0.92,
+		Sandbox:        true,
+	}))
+
+	// The live rate would slip far past the limit; sandbox payments don't fetch it
+	queue := &recordingQueue{}
+	polling := payment.PollingConfig{InitialDelaySeconds: 5, MaxDelaySeconds: 60, Multiplier: 2, MaxPollAttempts: 3}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		polling, nil, nil, fixedRateSource{rate: 0.80}, payment.SlippageConfig{MaxSlippage: 0.01, Action: payment.SlippageActionReview})
+	sm.EnableSandbox(payment.NewSandbox())
+
+	for steps := 1; steps <= 20; steps++ {
+		job := &models.PaymentJob{PaymentID: "pay_sandbox"}
+		if n := len(queue.jobs); n > 0 {
+			job = queue.jobs[n-1]
+		}
+		err := sm.ProcessPayment(ctx, job)
+
+		stored, getErr := repo.GetPaymentByID(ctx, "pay_sandbox")
+		require.NoError(t, getErr)
+		if stored.Status.IsTerminal() || stored.Status.IsHeld() {
+			return stored, steps
+		}
+		require.NoError(t, err)
+	}
+	t.Fatal("sandbox payment didn't finish")
+	return nil, 0
+}
+
+func TestSandboxScenarioFor(t *testing.T) {
+	assert.Equal(t, payment.SandboxSuccess, payment.SandboxScenarioFor(100000))
+	assert.Equal(t, payment.SandboxOnrampDeclined, payment.SandboxScenarioFor(100091))
+	assert.Equal(t, payment.SandboxOfframpFailed, payment.SandboxScenarioFor(594))
+	assert.Equal(t, payment.SandboxSuccess, payment.SandboxScenarioFor(100097))
+}
+
+func TestSandboxPaymentCompletes(t *testing.T) {
+	stored, steps := runSandboxPayment(t, 100000)
+	assert.Equal(t, models.StatusCompleted, stored.Status)
+	assert.True(t, stored.Sandbox)
+	assert.Equal(t, 1, stored.OnRampPollCount)
+	assert.Equal(t, 1, stored.OffRampPollCount)
+	assert.Equal(t, 0.92, stored.ExecutionRate)
+	assert.Equal(t, 4, steps, "each ramp is initiated, then settles on its first poll")
+}
+
+func TestSandboxMagicAmounts(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		status    models.PaymentStatus
+		errorCode string
+		reversed  bool
+	}{
+		{100091, models.StatusFailed, errors.PaymentOnrampFailed, false},
+		{100092, models.StatusFailed, errors.PaymentOnrampFailed, false},
+		{100093, models.StatusFailed, errors.PaymentPayoutFailed, true},
+		{100094, models.StatusFailed, errors.PaymentPayoutFailed, true},
+		{100095, models.StatusTimedOut, "", false},
+		{100096, models.StatusRequiresReview, "", false},
+	}
+	for _, tt := range tests {
+		stored, _ := runSandboxPayment(t, tt.amount)
+		assert.Equal(t, tt.status, stored.Status, "amount %d", tt.amount)
+		if tt.errorCode != "" {
+			assert.Equal(t, tt.errorCode, stored.ErrorCode, "amount %d", tt.amount)
+		}
+		assert.Equal(t, tt.reversed, stored.ReversalTxID != "", "amount %d", tt.amount)
+	}
+}
+
+// recordingWallet records the wallet transfers initiated through it
+type recordingWallet struct {
+	*payment.StatefulWalletClient
+	amounts []int64
+}
+
+func (w *recordingWallet) InitiateTransfer(ctx context.Context, stablecoinAmount int64, chain, address string) (string, error) {
+	w.amounts = append(w.amounts, stablecoinAmount)
+	return w.StatefulWalletClient.InitiateTransfer(ctx, stablecoinAmount, chain, address)
+}
+
+func TestSandboxPaymentsStayOffTheBooks(t *testing.T) {
+	ctx := context.Background()
+	payments := []*models.Payment{
+		{PaymentID: "pay_sandbox_bridged", Currency: "BRL", Chain: "base", OffRampChain: "polygon"},
+		{PaymentID: "pay_sandbox_wallet", Currency: "USDC", Chain: "base", PayoutType: models.PayoutTypeWallet,
+			DestinationAccount: "0x1111111111111111111111111111111111111111"},
+	}
+
+	for _, p := range payments {
+		repo := database.NewMemoryPaymentRepository()
+		p.IdempotencyKey = p.PaymentID
+		p.Amount = 100000
+		p.SourceCurrency = "USD"
+		p.FeeAmount = 1500
+		p.ExpectedRate = 1
+		p.Status = models.StatusPending
+		p.Sandbox = true
+		require.NoError(t, repo.CreatePayment(ctx, p))
+
+		treasury := database.NewMemoryTreasuryRepository()
+		ledgerStore := database.NewMemoryLedgerRepository()
+		invoices := database.NewMemoryFeeInvoiceRepository()
+		bridge := &recordingBridge{StatefulBridgeClient: payment.NewStatefulBridgeClient()}
+		wallet := &recordingWallet{StatefulWalletClient: payment.NewStatefulWalletClient()}
+
+		queue := &recordingQueue{}
+		sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+			payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+		sm.EnableSandbox(payment.NewSandbox())
+		sm.EnableBridging(bridge)
+		sm.EnableWalletPayouts(wallet)
+		sm.EnableTreasury(treasury, nil)
+		sm.EnableLedger(ledger.New(ledgerStore), nil)
+		sm.EnableFeeInvoices(invoices)
+
+		drivePayment(t, sm, queue, &models.PaymentJob{PaymentID: p.PaymentID})
+
+		stored, err := repo.GetPaymentByID(ctx, p.PaymentID)
+		require.NoError(t, err)
+		require.Equal(t, models.StatusCompleted, stored.Status, p.PaymentID)
+		assert.Empty(t, bridge.amounts, "%s: the live bridge isn't used", p.PaymentID)
+		assert.Empty(t, wallet.amounts, "%s: the live wallet isn't used", p.PaymentID)
+
+		// Even without float, the payout isn't held; nothing moves through the treasury
+		balances, err := treasury.ListTreasuryBalances(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, balances, p.PaymentID)
+		txns, err := ledgerStore.ListLedgerTransactions(ctx, p.PaymentID)
+		require.NoError(t, err)
+		assert.Empty(t, txns, p.PaymentID)
+		_, err = invoices.GetFeeInvoice(ctx, p.PaymentID)
+		assert.Error(t, err, "%s isn't invoiced", p.PaymentID)
+	}
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePaymentJobUpgradesUnversionedJobs(t *testing.T) {
+	// Written by a worker deployed before jobs carried a schema_version
+	legacy := `{"payment_id":"pay_1","amount":1000,"currency":"EUR","source_account":"src","destination_account":"dst","expected_status":"ONRAMP_PENDING"}`
+
+	job, err := schema.DecodePaymentJob([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentJobSchemaVersion, job.SchemaVersion)
+	assert.Equal(t, "pay_1", job.PaymentID)
+	assert.Equal(t, int64(1000), job.Amount)
+	assert.Equal(t, models.StatusOnrampPending, job.ExpectedStatus)
+}
+
+func TestDecodePaymentJobsRoundTripsABatch(t *testing.T) {
+	jobs := []*models.PaymentJob{
+		models.NewPaymentJob(&models.Payment{PaymentID: "pay_1", Amount: 100, Currency: "EUR", Status: models.StatusPending}),
+		models.NewPaymentJob(&models.Payment{PaymentID: "pay_2", Amount: 200, Currency: "GBP", Status: models.StatusPending}),
+	}
+	data, err := json.Marshal(jobs)
+	require.NoError(t, err)
+
+	decoded, err := schema.DecodePaymentJobs(data)
+	require.NoError(t, err)
+	assert.Equal(t, jobs, decoded)
+}
+
+func TestDecodeWebhookEventKeepsMessagesFromANewerVersion(t *testing.T) {
+	// Written by a producer a version ahead, with a field this version doesn't know
+	newer := `{"schema_version":99,"event_type":"payment.completed","payment_id":"pay_1","status":"COMPLETED","amount":500,"currency":"EUR","timestamp":"2024-01-01T00:00:00Z","settled_on":"2024-01-01"}`
+
+	event, err := schema.DecodeWebhookEvent([]byte(newer))
+	require.NoError(t, err)
+	assert.Equal(t, 99, event.SchemaVersion)
+	assert.Equal(t, "payment.completed", event.EventType)
+	assert.Equal(t, models.StatusCompleted, event.Status)
+	assert.Equal(t, int64(500), event.Amount)
+}
+
+func TestDecodeWebhookEventRejectsAMalformedVersion(t *testing.T) {
+	_, err := schema.DecodeWebhookEvent([]byte(`{"schema_version":"two","event_type":"payment.completed"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reporting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSettlementStore keeps settlement reports in memory instead of S3
+type recordingSettlementStore struct {
+	reports map[string][]*models.SettlementReport
+}
+
+func (s *recordingSettlementStore) PutSettlementReport(ctx context.Context, reportDate string, reports []*models.SettlementReport) error {
+	s.reports[reportDate] = reports
+	return nil
+}
+
+func (s *recordingSettlementStore) GetSettlementReport(ctx context.Context, reportDate string) ([]*models.SettlementReport, error) {
+	return s.reports[reportDate], nil
+}
+
+// settledPayment builds a completed payment that took settleIn to complete at completedAt
+func settledPayment(id, chain string, amount int64, completedAt time.Time, settleIn time.Duration) *models.Payment {
+	return &models.Payment{
+		PaymentID:      id,
+		IdempotencyKey: id,
+		Amount:         amount,
+		Currency:       "EUR",
+		FeeAmount:      1000,
+		OnrampFee:      200,
+		OfframpFee:     300,
+		ExpectedRate:   0.9,
+		Chain:          chain,
+		Status:         models.StatusCompleted,
+		CreatedAt:      completedAt.Add(-settleIn),
+		ProcessedAt:    &completedAt,
+	}
+}
+
+func TestBuildSettlementReportGroupsByCorridorAndChain(t *testing.T) {
+	completedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	payments := []*models.Payment{
+		settledPayment("pay_1", "base", 100000, completedAt, 2*time.Minute),
+		settledPayment("pay_2", "base", 200000, completedAt, 4*time.Minute),
+		settledPayment("pay_3", "solana", 100000, completedAt, time.Minute),
+		settledPayment("pay_4", "", 100000, completedAt, 10*time.Minute),
+	}
+	sandbox := settledPayment("pay_sandbox", "base", 500000, completedAt, time.Minute)
+	sandbox.Sandbox = true
+	payments = append(payments, sandbox)
+
+	generatedAt := time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)
+	reports := reporting.BuildSettlementReport("2026-03-01", payments, generatedAt)
+	require.Len(t, reports, 3)
+
+	assert.Equal(t, "", reports[0].Chain)
+	assert.Equal(t, "solana", reports[2].Chain)
+
+	base := reports[1]
+	assert.Equal(t, "USD-EUR", base.Corridor)
+	assert.Equal(t, "base", base.Chain)
+	assert.Equal(t, "USD", base.Currency)
+	assert.Equal(t, "EUR", base.PayoutCurrency)
+	assert.Equal(t, 2, base.Payments)
+	assert.Equal(t, int64(300000), base.GrossVolume)
+	assert.Equal(t, int64(2000), base.Fees)
+	assert.Equal(t, int64(1000), base.ProviderFees)
+	assert.Equal(t, int64(88650+178650), base.NetPayouts, "net of fees at the expected rate")
+	assert.Equal(t, float64(180), base.AvgSettlementSeconds)
+	assert.Equal(t, generatedAt, base.GeneratedAt)
+}
+
+func TestSettlementReporterRunReportsOneUTCDay(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	store := &recordingSettlementStore{reports: make(map[string][]*models.SettlementReport)}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for id, completedAt := range map[string]time.Time{
+		"pay_before": day.Add(-time.Nanosecond),
+		"pay_start":  day,
+		"pay_end":    day.Add(24*time.Hour - time.Nanosecond),
+		"pay_after":  day.Add(24 * time.Hour),
+	} {
+		require.NoError(t, repo.CreatePayment(ctx, settledPayment(id, "base", 100000, completedAt, time.Minute)))
+	}
+	failedAt := day.Add(time.Hour)
+	failed := settledPayment("pay_failed", "base", 100000, failedAt, time.Minute)
+	failed.Status = models.StatusFailed
+	require.NoError(t, repo.CreatePayment(ctx, failed))
+
+	reports, err := reporting.NewSettlementReporter(repo, store).Run(ctx, day.Add(15*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 2, reports[0].Payments)
+	assert.Len(t, store.reports["2026-03-01"], 1)
+
+	// A day without completions stores an empty report rather than none
+	reports, err = reporting.NewSettlementReporter(repo, store).Run(ctx, day.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	assert.NotNil(t, reports)
+	assert.Empty(t, reports)
+}
@@ -0,0 +1,33 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/chains"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementSLAPerChain(t *testing.T) {
+	policy := chains.SLAPolicy{}
+	assert.Equal(t, 10*time.Minute, policy.SettlementSLA("solana", ""))
+	assert.Equal(t, 30*time.Minute, policy.SettlementSLA("Ethereum", ""))
+	assert.Equal(t, chains.DefaultSettlementSLA, policy.SettlementSLA("unknown", ""))
+	assert.Equal(t, chains.DefaultSettlementSLA, policy.SettlementSLA("", ""))
+}
+
+func TestSettlementSLAAddsBridgedChain(t *testing.T) {
+	policy := chains.SLAPolicy{}
+	assert.Equal(t, 25*time.Minute, policy.SettlementSLA("solana", "base"))
+	assert.Equal(t, 10*time.Minute, policy.SettlementSLA("solana", "solana"))
+}
+
+func TestSettlementSLAOverrides(t *testing.T) {
+	policy := chains.SLAPolicy{
+		Default: time.Hour,
+		Chains:  map[string]time.Duration{"solana": 5 * time.Minute},
+	}
+	assert.Equal(t, 5*time.Minute, policy.SettlementSLA("solana", ""))
+	assert.Equal(t, 15*time.Minute, policy.SettlementSLA("base", ""))
+	assert.Equal(t, time.Hour, policy.SettlementSLA("unknown", ""))
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+)
+
+// fixedRateSource returns the same executable rate for every corridor
+type fixedRateSource struct {
+	rate float64
+}
+
+func (f fixedRateSource) ExecutableRate(ctx context.Context, from, to string, amount int64) (float64, error) {
+	return f.rate, nil
+}
+
+// recordingQueue counts re-enqueued jobs
+type recordingQueue struct {
+	jobs []*models.PaymentJob
+}
+
+func (q *recordingQueue) EnqueuePaymentWithDelay(ctx context.Context, job *models.PaymentJob, delaySeconds int) error {
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+// runOnrampComplete processes one ONRAMP_COMPLETE step for a payment quoted at 0.92
+func runOnrampComplete(t *testing.T, executableRate float64, action payment.SlippageAction) (*models.Payment, *recordingQueue) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_slip",
+		IdempotencyKey: "key_slip",
+		Amount:         100000,
+		Currency:       "EUR",
+		Status:         models.StatusOnrampComplete,
+		ExpectedRate:   0.92,
+	}))
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, fixedRateSource{rate: executableRate},
+		payment.SlippageConfig{MaxSlippage: 0.01, Action: action})
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_slip"}))
+
+	stored, err := repo.GetPaymentByID(ctx, "pay_slip")
+	require.NoError(t, err)
+	return stored, queue
+}
+
+func TestSlippage(t *testing.T) {
+	assert.InDelta(t, 0.02, payment.Slippage(1.0, 0.98), 1e-9)
+	assert.InDelta(t, -0.01, payment.Slippage(1.0, 1.01), 1e-9, "improved rates are negative")
+	assert.Equal(t, 0.0, payment.Slippage(0, 0.98), "no expected rate means no check")
+}
+
+func TestSlippageBeyondLimitHoldsForReview(t *testing.T) {
+	stored, queue := runOnrampComplete(t, 0.90, payment.SlippageActionReview)
+
+	assert.Equal(t, models.StatusRequiresReview, stored.Status)
+	assert.Equal(t, 0.90, stored.ExecutionRate)
+	assert.Empty(t, stored.OffRampTxID, "off-ramp is not initiated")
+	assert.Empty(t, queue.jobs, "held payments wait for an operator")
+}
+
+func TestSlippageBeyondLimitFailsWhenConfigured(t *testing.T) {
+	stored, queue := runOnrampComplete(t, 0.90, payment.SlippageActionFail)
+
+	assert.Equal(t, models.StatusReversing, stored.Status)
+	assert.Contains(t, stored.ErrorMessage, "below the expected")
+	assert.Len(t, queue.jobs, 1)
+}
+
+func TestResolveReview(t *testing.T) {
+	held := &models.Payment{PaymentID: "pay_1", Status: models.StatusRequiresReview, ErrorMessage: "slipped"}
+	require.NoError(t, payment.ResolveReview(held, models.ReviewDecisionApprove, "iam:ops", ""))
+	assert.Equal(t, models.StatusOnrampComplete, held.Status)
+	assert.True(t, held.SlippageApproved)
+	assert.Empty(t, held.ErrorMessage)
+
+	rejected := &models.Payment{PaymentID: "pay_2", Status: models.StatusRequiresReview}
+	require.NoError(t, payment.ResolveReview(rejected, models.ReviewDecisionReject, "iam:ops", "rate too far off"))
+	assert.Equal(t, models.StatusReversing, rejected.Status)
+	assert.Equal(t, "Slippage rejected by iam:ops: rate too far off", rejected.ErrorMessage)
+
+	assert.Error(t, payment.ResolveReview(&models.Payment{Status: models.StatusCompleted}, models.ReviewDecisionApprove, "iam:ops", ""),
+		"only held payments can be reviewed")
+	assert.Error(t, payment.ResolveReview(&models.Payment{Status: models.StatusRequiresReview}, "maybe", "iam:ops", ""))
+}
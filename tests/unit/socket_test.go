@@ -0,0 +1,220 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/socket"
+)
+
+// recordingPoster records the messages posted to each connection; connections in gone have closed
+type recordingPoster struct {
+	posted map[string][]models.SocketMessage
+	gone   map[string]bool
+	err    error
+}
+
+func newRecordingPoster() *recordingPoster {
+	return &recordingPoster{posted: make(map[string][]models.SocketMessage), gone: make(map[string]bool)}
+}
+
+func (p *recordingPoster) Post(ctx context.Context, connectionID string, data []byte) error {
+	if p.gone[connectionID] {
+		return socket.ErrGone
+	}
+	if p.err != nil {
+		return p.err
+	}
+	var msg models.SocketMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	p.posted[connectionID] = append(p.posted[connectionID], msg)
+	return nil
+}
+
+// socketRequest is a WebSocket API request on a route from a connection
+func socketRequest(route, connectionID, body string) events.APIGatewayWebsocketProxyRequest {
+	return events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{RouteKey: route, ConnectionID: connectionID},
+		Body:           body,
+	}
+}
+
+func TestSocketSubscribeRepliesWithCurrentStatus(t *testing.T) {
+	ctx := context.Background()
+	payments := database.NewMemoryPaymentRepository()
+	streamPayment(t, payments, "pay_socket")
+	subs := database.NewMemorySocketSubscriptionRepository()
+	poster := newRecordingPoster()
+	conns := socket.NewConnections(payments, subs, poster, nil)
+
+	resp, err := conns.HandleRequest(ctx, socketRequest(socket.RouteSubscribe, "conn-1", `{"action":"subscribe","payment_id":"pay_socket"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	require.Len(t, poster.posted["conn-1"], 1)
+	reply := poster.posted["conn-1"][0]
+	assert.Equal(t, models.SocketMessageSubscribed, reply.Type)
+	require.NotNil(t, reply.Event)
+	assert.Equal(t, models.StatusOnrampPending, reply.Event.Status)
+
+	stored, err := subs.ListPaymentSubscriptions(ctx, "pay_socket")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "conn-1", stored[0].ConnectionID)
+
+	// Unknown payments are refused and leave no subscription behind
+	_, err = conns.HandleRequest(ctx, socketRequest(socket.RouteSubscribe, "conn-1", `{"action":"subscribe","payment_id":"pay_missing"}`))
+	require.NoError(t, err)
+	assert.Equal(t, models.SocketMessageError, poster.posted["conn-1"][1].Type)
+	stored, err = subs.ListPaymentSubscriptions(ctx, "pay_missing")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+
+	// Malformed messages get an error reply
+	_, err = conns.HandleRequest(ctx, socketRequest("$default", "conn-1", `{"action":"subscribe"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "payment_id is required", poster.posted["conn-1"][2].Error)
+
+	// Disconnecting drops the connection's subscriptions
+	_, err = conns.HandleRequest(ctx, socketRequest(socket.RouteDisconnect, "conn-1", ""))
+	require.NoError(t, err)
+	stored, err = subs.ListPaymentSubscriptions(ctx, "pay_socket")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestSocketNotifierPushesToSubscribers(t *testing.T) {
+	ctx := context.Background()
+	subs := database.NewMemorySocketSubscriptionRepository()
+	require.NoError(t, subs.SubscribeConnection(ctx, models.NewSocketSubscription("conn-open", "pay_push")))
+	require.NoError(t, subs.SubscribeConnection(ctx, models.NewSocketSubscription("conn-closed", "pay_push")))
+	require.NoError(t, subs.SubscribeConnection(ctx, models.NewSocketSubscription("conn-other", "pay_other")))
+	expired := models.NewSocketSubscription("conn-expired", "pay_push")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, subs.SubscribeConnection(ctx, expired))
+
+	poster := newRecordingPoster()
+	poster.gone["conn-closed"] = true
+	notifier := socket.NewNotifier(subs, poster)
+
+	event := models.StateTransition{ToStatus: models.StatusCompleted, Timestamp: time.Now()}.PublicEvent()
+	require.NoError(t, notifier.NotifyStatus(ctx, "pay_push", event))
+
+	require.Len(t, poster.posted["conn-open"], 1)
+	assert.Equal(t, models.SocketMessageStatus, poster.posted["conn-open"][0].Type)
+	assert.Equal(t, models.StatusCompleted, poster.posted["conn-open"][0].Event.Status)
+	assert.Empty(t, poster.posted["conn-other"])
+	assert.Empty(t, poster.posted["conn-expired"])
+
+	// The closed connection's subscription is gone, so it isn't pushed to again
+	stored, err := subs.ListPaymentSubscriptions(ctx, "pay_push")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "conn-open", stored[0].ConnectionID)
+
+	poster.err = fmt.Errorf("throttled")
+	assert.Error(t, notifier.NotifyStatus(ctx, "pay_push", event))
+}
+
+func TestWorkerPushesTransitionsToSubscribers(t *testing.T) {
+	ctx := context.Background()
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_live", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	subs := database.NewMemorySocketSubscriptionRepository()
+	require.NoError(t, subs.SubscribeConnection(ctx, models.NewSocketSubscription("conn-1", "pay_live")))
+	poster := newRecordingPoster()
+	f.sm.EnableStatusNotifications(socket.NewNotifier(subs, poster))
+
+	require.NoError(t, f.step(t, "pay_live"))
+	require.Len(t, poster.posted["conn-1"], 1)
+	assert.Equal(t, models.StatusOnrampPending, poster.posted["conn-1"][0].Event.Status)
+
+	// A pending poll changes nothing, so nothing is pushed
+	require.NoError(t, f.step(t, "pay_live"))
+	assert.Len(t, poster.posted["conn-1"], 1)
+
+	// Pushing is best-effort: a failing push doesn't fail the step
+	poster.err = fmt.Errorf("throttled")
+	f.onRamp.status = payment.TransferStatusSettled
+	require.NoError(t, f.step(t, "pay_live"))
+	assert.Equal(t, models.StatusOnrampComplete, f.payment(t, "pay_live").Status)
+}
+
+func TestSocketConnectionsAreAuthorizedAndScopedToTheirCaller(t *testing.T) {
+	ctx := context.Background()
+	payments := database.NewMemoryPaymentRepository()
+	own := streamPayment(t, payments, "pay_own")
+	own.IdempotencyScope = "cust_acme"
+	require.NoError(t, payments.UpdatePayment(ctx, own))
+	other := streamPayment(t, payments, "pay_other")
+	other.IdempotencyScope = "cust_other"
+	require.NoError(t, payments.UpdatePayment(ctx, other))
+
+	keys := apikeys.New(database.NewMemoryAPIKeyRepository(), time.Hour)
+	issued, err := keys.Issue(ctx, "cust_acme", &models.APIKeyRequest{Name: "dashboard"})
+	require.NoError(t, err)
+	subs := database.NewMemorySocketSubscriptionRepository()
+	poster := newRecordingPoster()
+	conns := socket.NewConnections(payments, subs, poster, apikeys.NewCallers(keys, fakeGatewayKeys{key: "gateway"}, nil))
+
+	authorize := func(key string) (events.APIGatewayCustomAuthorizerResponse, error) {
+		payload := fmt.Sprintf(`{"type":"REQUEST","methodArn":"arn:aws:execute-api:us-east-1:123456789012:abc/dev/$connect",`+
+			`"headers":{"x-api-key":%q},"requestContext":{"routeKey":"$connect","identity":{"sourceIp":"203.0.113.7"}}}`, key)
+		resp, err := conns.Handle(ctx, json.RawMessage(payload))
+		if err != nil {
+			return events.APIGatewayCustomAuthorizerResponse{}, err
+		}
+		return resp.(events.APIGatewayCustomAuthorizerResponse), nil
+	}
+
+	_, err = authorize("sk_unknown")
+	assert.EqualError(t, err, "Unauthorized")
+	denied, err := authorize("wrong")
+	require.NoError(t, err)
+	assert.Equal(t, "Deny", denied.PolicyDocument.Statement[0].Effect)
+
+	allowed, err := authorize(issued.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, "Allow", allowed.PolicyDocument.Statement[0].Effect)
+	assert.Equal(t, "cust_acme", allowed.PrincipalID)
+
+	// API Gateway passes the authorizer's context on every later message of the connection
+	authorizerContext, err := json.Marshal(allowed.Context)
+	require.NoError(t, err)
+	subscribe := func(paymentID string) models.SocketMessage {
+		payload := fmt.Sprintf(`{"requestContext":{"routeKey":"subscribe","connectionId":"conn-1","authorizer":%s},`+
+			`"body":"{\"action\":\"subscribe\",\"payment_id\":\"%s\"}"}`, authorizerContext, paymentID)
+		_, err := conns.Handle(ctx, json.RawMessage(payload))
+		require.NoError(t, err)
+		posted := poster.posted["conn-1"]
+		return posted[len(posted)-1]
+	}
+
+	assert.Equal(t, models.SocketMessageSubscribed, subscribe("pay_own").Type)
+	reply := subscribe("pay_other")
+	assert.Equal(t, models.SocketMessageError, reply.Type)
+	assert.Contains(t, reply.Error, "not found")
+	stored, err := subs.ListPaymentSubscriptions(ctx, "pay_other")
+	require.NoError(t, err)
+	assert.Empty(t, stored, "another caller's payment is never subscribed to")
+
+	// A key revoked after the connection opened can't subscribe on it
+	require.NoError(t, subs.DeleteConnectionSubscriptions(ctx, "conn-1"))
+	_, err = keys.Revoke(ctx, "cust_acme", issued.KeyID)
+	require.NoError(t, err)
+	reply = subscribe("pay_own")
+	assert.Equal(t, models.SocketMessageError, reply.Type)
+	stored, err = subs.ListPaymentSubscriptions(ctx, "pay_own")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
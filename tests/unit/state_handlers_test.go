@@ -0,0 +1,539 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/eventbus"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRamp is a scripted on-ramp or off-ramp: every poll reports status until it's changed
+type fakeRamp struct {
+	initErr   error
+	status    payment.TransferStatus
+	polls     int
+	transfers []int64 // Amounts passed to InitiateTransfer
+	reversals []int64 // Amounts passed to InitiateReversal
+}
+
+func (f *fakeRamp) InitiateTransfer(ctx context.Context, amount int64, currency string) (string, error) {
+	if f.initErr != nil {
+		return "", f.initErr
+	}
+	f.transfers = append(f.transfers, amount)
+	return fmt.Sprintf("tx_%d", len(f.transfers)), nil
+}
+
+func (f *fakeRamp) GetTransferStatus(ctx context.Context, txID string) (*payment.Transfer, error) {
+	f.polls++
+	return &payment.Transfer{TxID: txID, Status: f.status, PollCount: f.polls}, nil
+}
+
+func (f *fakeRamp) InitiateReversal(ctx context.Context, stablecoinAmount int64, currency, sourceAccount string) (string, error) {
+	f.reversals = append(f.reversals, stablecoinAmount)
+	return fmt.Sprintf("reversal_%d", len(f.reversals)), nil
+}
+
+// saveFailingRepository fails every save until failures is exhausted
+type saveFailingRepository struct {
+	*database.MemoryPaymentRepository
+	failures int
+}
+
+func (r *saveFailingRepository) UpdatePayment(ctx context.Context, p *models.Payment) error {
+	if r.failures > 0 {
+		r.failures--
+		return fmt.Errorf("simulated write failure")
+	}
+	return r.MemoryPaymentRepository.UpdatePayment(ctx, p)
+}
+
+func (r *saveFailingRepository) UpdatePaymentWithOutbox(ctx context.Context, p *models.Payment, msg *models.OutboxMessage) error {
+	if r.failures > 0 {
+		r.failures--
+		return fmt.Errorf("simulated write failure")
+	}
+	return r.MemoryPaymentRepository.UpdatePaymentWithOutbox(ctx, p, msg)
+}
+
+// stateMachineFixture is a state machine over fake ramps and an in-memory repository
+type stateMachineFixture struct {
+	repo    *saveFailingRepository
+	onRamp  *fakeRamp
+	offRamp *fakeRamp
+	queue   *recordingQueue
+	sm      *payment.StateMachine
+}
+
+func newStateMachineFixture(t *testing.T, p *models.Payment, polling payment.PollingConfig) *stateMachineFixture {
+	f := &stateMachineFixture{
+		repo:    &saveFailingRepository{MemoryPaymentRepository: database.NewMemoryPaymentRepository()},
+		onRamp:  &fakeRamp{status: payment.TransferStatusPending},
+		offRamp: &fakeRamp{status: payment.TransferStatusPending},
+		queue:   &recordingQueue{},
+	}
+	if p.IdempotencyKey == "" {
+		p.IdempotencyKey = "key_" + p.PaymentID
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	require.NoError(t, f.repo.CreatePayment(context.Background(), p))
+
+	f.sm = payment.NewStateMachine(f.onRamp, f.offRamp, f.repo, f.queue, polling, nil, nil, nil, payment.SlippageConfig{})
+	return f
+}
+
+// step runs the next queued job, or a fresh job for the payment when nothing is queued
+func (f *stateMachineFixture) step(t *testing.T, paymentID string) error {
+	job := &models.PaymentJob{PaymentID: paymentID}
+	if n := len(f.queue.jobs); n > 0 {
+		job = f.queue.jobs[n-1]
+	}
+	return f.sm.ProcessPayment(context.Background(), job)
+}
+
+func (f *stateMachineFixture) payment(t *testing.T, paymentID string) *models.Payment {
+	stored, err := f.repo.GetPaymentByID(context.Background(), paymentID)
+	require.NoError(t, err)
+	return stored
+}
+
+func TestPollDelaysBackOffAndResetPerStage(t *testing.T) {
+	polling := payment.PollingConfig{InitialDelaySeconds: 10, MaxDelaySeconds: 60, Multiplier: 2}
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_backoff", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, polling)
+
+	// Initiating the on-ramp polls after the initial delay, then each pending poll doubles it up to the cap
+	require.NoError(t, f.step(t, "pay_backoff"))
+	assert.Equal(t, models.StatusOnrampPending, f.payment(t, "pay_backoff").Status)
+	for _, want := range []int{10, 20, 40, 60, 60} {
+		assert.Equal(t, want, f.payment(t, "pay_backoff").PollDelaySeconds)
+		require.NoError(t, f.step(t, "pay_backoff"))
+	}
+
+	// The next stage starts again from the initial delay
+	f.onRamp.status = payment.TransferStatusSettled
+	require.NoError(t, f.step(t, "pay_backoff"))
+	require.NoError(t, f.step(t, "pay_backoff"))
+	stored := f.payment(t, "pay_backoff")
+	assert.Equal(t, models.StatusOfframpPending, stored.Status)
+	assert.Equal(t, 10, stored.PollDelaySeconds)
+}
+
+func TestStageTimesOutAfterPollBudget(t *testing.T) {
+	polling := payment.PollingConfig{InitialDelaySeconds: 1, MaxDelaySeconds: 1, Multiplier: 1, MaxPollAttempts: 3}
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_polls", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, polling)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, f.step(t, "pay_polls"))
+	}
+	stored := f.payment(t, "pay_polls")
+	assert.Equal(t, models.StatusTimedOut, stored.Status)
+	assert.Contains(t, stored.ErrorMessage, "exceeded 3 poll attempts")
+	assert.NotNil(t, stored.ProcessedAt)
+	assert.Equal(t, 3, f.onRamp.polls)
+
+	// A terminal payment is left alone
+	require.NoError(t, f.step(t, "pay_polls"))
+	assert.Equal(t, 3, f.onRamp.polls)
+}
+
+func TestStageTimesOutAfterMaxDuration(t *testing.T) {
+	polling := payment.PollingConfig{InitialDelaySeconds: 1, MaxStageDuration: time.Hour}
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:  "pay_slow",
+		Amount:     100000,
+		Currency:   "EUR",
+		Status:     models.StatusOfframpPending,
+		OnRampTxID: "tx_onramp",
+		CreatedAt:  time.Now().Add(-2 * time.Hour),
+	}, polling)
+
+	require.NoError(t, f.step(t, "pay_slow"))
+	stored := f.payment(t, "pay_slow")
+	assert.Equal(t, models.StatusTimedOut, stored.Status)
+	assert.Contains(t, stored.ErrorMessage, "OFFRAMP_PENDING exceeded 1h0m0s")
+}
+
+func TestOfframpFailureReversesMintedUSDC(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:      "pay_reversal",
+		Amount:         200000,
+		SourceCurrency: "EUR",
+		Currency:       "USD",
+		FeeAmount:      1500,
+		Status:         models.StatusOfframpPending,
+		OnRampTxID:     "tx_onramp",
+		OffRampTxID:    "tx_offramp",
+	}, payment.DefaultPollingConfig())
+	f.offRamp.status = payment.TransferStatusFailed
+
+	require.NoError(t, f.step(t, "pay_reversal"))
+	assert.Equal(t, models.StatusReversing, f.payment(t, "pay_reversal").Status)
+
+	// The redemption is the USDC the EUR on-ramp minted, not the gross EUR amount
+	require.NoError(t, f.step(t, "pay_reversal"))
+	assert.Equal(t, []int64{217500}, f.onRamp.reversals)
+
+	f.onRamp.status = payment.TransferStatusSettled
+	require.NoError(t, f.step(t, "pay_reversal"))
+	stored := f.payment(t, "pay_reversal")
+	assert.Equal(t, models.StatusFailed, stored.Status)
+	assert.Equal(t, "reversal_1", stored.ReversalTxID)
+	assert.Equal(t, "Offramp settlement failed", stored.ErrorMessage)
+	assert.Equal(t, errors.PaymentPayoutFailed, stored.ErrorCode)
+}
+
+func TestOnlyCompletedPaymentsAreInvoiced(t *testing.T) {
+	ctx := context.Background()
+	invoices := database.NewMemoryFeeInvoiceRepository()
+
+	completed := newStateMachineFixture(t, &models.Payment{
+		PaymentID:   "pay_invoiced",
+		Amount:      100000,
+		Currency:    "EUR",
+		FeeAmount:   1500,
+		Status:      models.StatusOfframpPending,
+		OnRampTxID:  "tx_onramp",
+		OffRampTxID: "tx_offramp",
+	}, payment.DefaultPollingConfig())
+	completed.sm.EnableFeeInvoices(invoices)
+	completed.offRamp.status = payment.TransferStatusSettled
+	require.NoError(t, completed.step(t, "pay_invoiced"))
+	require.Equal(t, models.StatusCompleted, completed.payment(t, "pay_invoiced").Status)
+	invoice, err := invoices.GetFeeInvoice(ctx, "pay_invoiced")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), invoice.TotalFees)
+
+	// A reversed payment's funds went back to the customer, so it isn't charged
+	reversed := newStateMachineFixture(t, &models.Payment{
+		PaymentID:    "pay_reversed",
+		Amount:       100000,
+		Currency:     "EUR",
+		FeeAmount:    1500,
+		Status:       models.StatusReversing,
+		OnRampTxID:   "tx_onramp",
+		ReversalTxID: "reversal_1",
+	}, payment.DefaultPollingConfig())
+	reversed.sm.EnableFeeInvoices(invoices)
+	reversed.onRamp.status = payment.TransferStatusSettled
+	require.NoError(t, reversed.step(t, "pay_reversed"))
+	require.Equal(t, models.StatusFailed, reversed.payment(t, "pay_reversed").Status)
+	_, err = invoices.GetFeeInvoice(ctx, "pay_reversed")
+	require.Error(t, err)
+	assert.Equal(t, "FEE_INVOICE_NOT_FOUND", err.(*errors.AppError).Code)
+}
+
+func TestDuplicateDeliveryIsSkippedWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_locked", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+
+	acquired, err := f.repo.AcquireProcessingLock(ctx, "pay_locked", models.StatusPending, "other-worker", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// Another worker holds the payment, so the duplicate delivery does nothing
+	require.NoError(t, f.step(t, "pay_locked"))
+	assert.Empty(t, f.onRamp.transfers)
+	assert.Empty(t, f.queue.jobs)
+	assert.Equal(t, models.StatusPending, f.payment(t, "pay_locked").Status)
+
+	// Once released, a job for a status the payment has already left is stale
+	require.NoError(t, f.repo.ReleaseProcessingLock(ctx, "pay_locked", "other-worker"))
+	require.NoError(t, f.step(t, "pay_locked"))
+	require.Len(t, f.onRamp.transfers, 1)
+
+	stale := &models.PaymentJob{PaymentID: "pay_locked", ExpectedStatus: models.StatusPending}
+	require.NoError(t, f.sm.ProcessPayment(ctx, stale))
+	assert.Len(t, f.onRamp.transfers, 1, "the on-ramp is initiated once")
+	assert.Equal(t, models.StatusOnrampPending, f.payment(t, "pay_locked").Status)
+}
+
+// recordingPublisher captures published events, failing every publish when err is set
+type recordingPublisher struct {
+	err      error
+	events   []*models.PaymentStateChangedEvent
+	breaches []*models.SLABreachedEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, detailType string, detail interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	if event, ok := detail.(*models.PaymentStateChangedEvent); ok && detailType == eventbus.DetailTypePaymentStateChanged {
+		p.events = append(p.events, event)
+	}
+	if event, ok := detail.(*models.SLABreachedEvent); ok && detailType == eventbus.DetailTypeSLABreached {
+		p.breaches = append(p.breaches, event)
+	}
+	return nil
+}
+
+func TestTransitionsPublishStateChangedEvents(t *testing.T) {
+	polling := payment.DefaultPollingConfig()
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_events", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, polling)
+	publisher := &recordingPublisher{}
+	f.sm = payment.NewStateMachine(f.onRamp, f.offRamp, f.repo, f.queue, polling, publisher, nil, nil, payment.SlippageConfig{})
+
+	require.NoError(t, f.step(t, "pay_events"))
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, models.StatusPending, publisher.events[0].FromStatus)
+	assert.Equal(t, models.StatusOnrampPending, publisher.events[0].ToStatus)
+	assert.Equal(t, int64(100000), publisher.events[0].Amount)
+
+	// A pending poll changes nothing, so nothing is published
+	require.NoError(t, f.step(t, "pay_events"))
+	assert.Len(t, publisher.events, 1)
+
+	// Publishing is best-effort: a failing bus doesn't fail the step
+	publisher.err = fmt.Errorf("bus unavailable")
+	f.onRamp.status = payment.TransferStatusSettled
+	require.NoError(t, f.step(t, "pay_events"))
+	assert.Equal(t, models.StatusOnrampComplete, f.payment(t, "pay_events").Status)
+}
+
+func TestTerminalSaveQueuesWebhookAndRetriesAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:   "pay_outbox",
+		Amount:      100000,
+		Currency:    "EUR",
+		Status:      models.StatusOfframpPending,
+		OnRampTxID:  "tx_onramp",
+		OffRampTxID: "tx_offramp",
+	}, payment.DefaultPollingConfig())
+	f.offRamp.status = payment.TransferStatusSettled
+
+	// The completing write fails, so neither the status nor its webhook is persisted
+	f.repo.failures = 1
+	require.Error(t, f.step(t, "pay_outbox"))
+	assert.Equal(t, models.StatusOfframpPending, f.payment(t, "pay_outbox").Status)
+	messages, err := f.repo.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	// The redelivered job completes the payment and queues its webhook with the same write
+	require.NoError(t, f.step(t, "pay_outbox"))
+	assert.Equal(t, models.StatusCompleted, f.payment(t, "pay_outbox").Status)
+
+	messages, err = f.repo.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, models.OutboxKindWebhookEvent, messages[0].Kind)
+	assert.Equal(t, "pay_outbox", messages[0].PaymentID)
+	assert.Contains(t, messages[0].Payload, `"event_type":"payment.completed"`)
+}
+
+func TestSettlementStagesAreTimestamped(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_timeline", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	f.onRamp.status = payment.TransferStatusSettled
+	f.offRamp.status = payment.TransferStatusSettled
+
+	for i := 0; i < 6 && f.payment(t, "pay_timeline").Status != models.StatusCompleted; i++ {
+		require.NoError(t, f.step(t, "pay_timeline"))
+	}
+
+	stored := f.payment(t, "pay_timeline")
+	require.Equal(t, models.StatusCompleted, stored.Status)
+	require.NotNil(t, stored.Timeline)
+	assert.NotNil(t, stored.Timeline.OnrampInitiatedAt)
+	assert.NotNil(t, stored.Timeline.OnrampSettledAt)
+	assert.NotNil(t, stored.Timeline.OfframpInitiatedAt)
+	assert.NotNil(t, stored.Timeline.OfframpSettledAt)
+	assert.False(t, stored.Timeline.OfframpSettledAt.Before(*stored.Timeline.OnrampInitiatedAt))
+	assert.False(t, stored.SLABreached)
+}
+
+func TestSlowSettlementPublishesSLABreach(t *testing.T) {
+	polling := payment.DefaultPollingConfig()
+	initiated := time.Now().Add(-20 * time.Minute)
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:            "pay_sla",
+		Amount:               100000,
+		Currency:             "EUR",
+		Chain:                "solana",
+		Status:               models.StatusOfframpPending,
+		OnRampTxID:           "tx_onramp",
+		OffRampTxID:          "tx_offramp",
+		SettlementSLASeconds: 600,
+		Timeline:             &models.SettlementTimeline{OnrampInitiatedAt: &initiated},
+	}, polling)
+	publisher := &recordingPublisher{}
+	f.sm = payment.NewStateMachine(f.onRamp, f.offRamp, f.repo, f.queue, polling, publisher, nil, nil, payment.SlippageConfig{})
+	f.offRamp.status = payment.TransferStatusSettled
+
+	require.NoError(t, f.step(t, "pay_sla"))
+	stored := f.payment(t, "pay_sla")
+	assert.Equal(t, models.StatusCompleted, stored.Status)
+	assert.True(t, stored.SLABreached)
+	assert.GreaterOrEqual(t, stored.SettlementSeconds, 1200)
+
+	require.Len(t, publisher.breaches, 1)
+	assert.Equal(t, "pay_sla", publisher.breaches[0].PaymentID)
+	assert.Equal(t, "solana", publisher.breaches[0].Chain)
+	assert.Equal(t, 600, publisher.breaches[0].SLASeconds)
+	assert.Equal(t, stored.SettlementSeconds, publisher.breaches[0].SettlementSeconds)
+}
+
+func TestSettlementWithinSLAPublishesNoBreach(t *testing.T) {
+	polling := payment.DefaultPollingConfig()
+	initiated := time.Now().Add(-5 * time.Minute)
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:            "pay_sla_ok",
+		Amount:               100000,
+		Currency:             "EUR",
+		Status:               models.StatusOfframpPending,
+		OnRampTxID:           "tx_onramp",
+		OffRampTxID:          "tx_offramp",
+		SettlementSLASeconds: 600,
+		Timeline:             &models.SettlementTimeline{OnrampInitiatedAt: &initiated},
+	}, polling)
+	publisher := &recordingPublisher{}
+	f.sm = payment.NewStateMachine(f.onRamp, f.offRamp, f.repo, f.queue, polling, publisher, nil, nil, payment.SlippageConfig{})
+	f.offRamp.status = payment.TransferStatusSettled
+
+	require.NoError(t, f.step(t, "pay_sla_ok"))
+	stored := f.payment(t, "pay_sla_ok")
+	assert.Equal(t, models.StatusCompleted, stored.Status)
+	assert.False(t, stored.SLABreached)
+	assert.Empty(t, publisher.breaches)
+}
+
+// webhookEventTypes returns the event types of the webhooks queued in the outbox, oldest first
+func webhookEventTypes(t *testing.T, repo *saveFailingRepository) []string {
+	messages, err := repo.ListOutboxMessages(context.Background(), 100)
+	require.NoError(t, err)
+
+	var types []string
+	for _, msg := range messages {
+		if msg.Kind != models.OutboxKindWebhookEvent {
+			continue
+		}
+		var event models.WebhookEvent
+		require.NoError(t, json.Unmarshal([]byte(msg.Payload), &event))
+		types = append(types, event.EventType)
+	}
+	return types
+}
+
+func TestProgressWebhooksAreQueuedOncePerStatus(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_progress", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+
+	require.NoError(t, f.step(t, "pay_progress"))
+	// Pending polls save the payment again without repeating payment.processing
+	require.NoError(t, f.step(t, "pay_progress"))
+	require.NoError(t, f.step(t, "pay_progress"))
+	assert.Equal(t, []string{payment.WebhookPaymentProcessing}, webhookEventTypes(t, f.repo))
+
+	f.onRamp.status = payment.TransferStatusSettled
+	f.offRamp.status = payment.TransferStatusSettled
+	for i := 0; i < 4 && f.payment(t, "pay_progress").Status != models.StatusCompleted; i++ {
+		require.NoError(t, f.step(t, "pay_progress"))
+	}
+
+	assert.Equal(t, []string{
+		payment.WebhookPaymentProcessing,
+		payment.WebhookPaymentOnrampCompleted,
+		payment.WebhookPaymentOfframpInitiated,
+		payment.WebhookPaymentCompleted,
+	}, webhookEventTypes(t, f.repo))
+}
+
+func TestPaymentHeldAtCreationQueuesOnHoldWebhook(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_held", Amount: 100000, Currency: "EUR", Status: models.StatusComplianceHold}, payment.DefaultPollingConfig())
+
+	require.NoError(t, f.step(t, "pay_held"))
+	require.NoError(t, f.step(t, "pay_held"))
+	assert.Equal(t, []string{payment.WebhookPaymentOnHold}, webhookEventTypes(t, f.repo))
+
+	// The webhook doesn't say which review holds the payment
+	messages, err := f.repo.ListOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0].Payload, `"status":"UNDER_REVIEW"`)
+	assert.Equal(t, models.StatusComplianceHold, f.payment(t, "pay_held").Status)
+}
+
+func TestRejectedHoldQueuesCancelledWebhook(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	p := &models.Payment{PaymentID: "pay_cancel", IdempotencyKey: "key_cancel", Amount: 100000, Currency: "EUR", Status: models.StatusPending}
+	(&payment.HoldPolicy{AmountThreshold: 50000}).HoldIfTriggered(p)
+	require.NoError(t, repo.CreatePayment(ctx, p))
+
+	require.NoError(t, payment.ResolveHold(p, models.HoldDecisionReject, "iam:ops", "Customer asked to cancel"))
+	msg, err := payment.NewWebhookOutboxMessage(p)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Payload, `"event_type":"payment.cancelled"`)
+	assert.Equal(t, models.StatusFailed, p.WebhookStatus)
+
+	var event models.WebhookEvent
+	require.NoError(t, json.Unmarshal([]byte(msg.Payload), &event))
+	assert.Equal(t, errors.PaymentReviewRejected, event.ErrorCode)
+	assert.Equal(t, "E6006", event.ErrorDocID)
+	require.NotNil(t, event.Retryable)
+	assert.False(t, *event.Retryable)
+}
+
+func TestFailedWebhookSaysWhetherToRetry(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_onramp_fail", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	f.onRamp.initErr = fmt.Errorf("provider unavailable")
+
+	require.Error(t, f.step(t, "pay_onramp_fail"))
+	assert.Equal(t, errors.PaymentOnrampFailed, f.payment(t, "pay_onramp_fail").ErrorCode)
+
+	messages, err := f.repo.ListOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	var event models.WebhookEvent
+	require.NoError(t, json.Unmarshal([]byte(messages[0].Payload), &event))
+	assert.Equal(t, payment.WebhookPaymentFailed, event.EventType)
+	assert.Equal(t, errors.PaymentOnrampFailed, event.ErrorCode)
+	assert.Equal(t, "E6001", event.ErrorDocID)
+	require.NotNil(t, event.Retryable)
+	assert.True(t, *event.Retryable, "nothing moved, so a new payment may succeed")
+}
+
+func TestFailedTransitionSaveIsReturned(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_save_fail", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	f.onRamp.initErr = fmt.Errorf("provider rejected transfer")
+
+	// The FAILED status couldn't be written, so the error goes back to SQS for redelivery
+	f.repo.failures = 1
+	assert.ErrorContains(t, f.step(t, "pay_save_fail"), "failed to update payment")
+	assert.Equal(t, models.StatusPending, f.payment(t, "pay_save_fail").Status)
+
+	assert.ErrorContains(t, f.step(t, "pay_save_fail"), "onramp initiation failed")
+	stored := f.payment(t, "pay_save_fail")
+	assert.Equal(t, models.StatusFailed, stored.Status)
+	assert.Contains(t, stored.ErrorMessage, "provider rejected transfer")
+}
+
+func TestUnquotedCrossCurrencyPayoutIsConverted(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{
+		PaymentID:      "pay_unquoted",
+		Amount:         100000,
+		SourceCurrency: "EUR",
+		Currency:       "USD",
+		FeeAmount:      1000,
+		OnrampFee:      500,
+		OfframpFee:     300,
+		ExpectedRate:   1.0875,
+		Status:         models.StatusOnrampComplete,
+		OnRampTxID:     "tx_onramp",
+	}, payment.DefaultPollingConfig())
+
+	// The off-ramp pays the EUR amount net of fees in USD, not the EUR amount itself
+	require.NoError(t, f.step(t, "pay_unquoted"))
+	assert.Equal(t, models.StatusOfframpPending, f.payment(t, "pay_unquoted").Status)
+	assert.Equal(t, []int64{106792}, f.offRamp.transfers)
+}
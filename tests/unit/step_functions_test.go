@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOrchestrator runs the fixture's payment through Step Functions tasks instead of SQS
+func newOrchestrator(t *testing.T, f *stateMachineFixture, polling payment.PollingConfig) *payment.StepFunctionsOrchestrator {
+	o, err := payment.NewStepFunctionsOrchestrator("us-east-1", "arn:aws:states:us-east-1:123456789012:stateMachine:payments", f.repo,
+		func(queue payment.QueueClient) *payment.StateMachine {
+			return payment.NewStateMachine(f.onRamp, f.offRamp, f.repo, queue, polling, nil, nil, nil, payment.SlippageConfig{})
+		})
+	require.NoError(t, err)
+	return o
+}
+
+func TestStepFunctionsAdvanceWaitsForRequestedDelay(t *testing.T) {
+	polling := payment.PollingConfig{InitialDelaySeconds: 10, MaxDelaySeconds: 60, Multiplier: 2}
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_sfn", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, polling)
+	o := newOrchestrator(t, f, polling)
+
+	// The re-enqueue delay becomes the execution's Wait state
+	output, err := o.HandleTask(context.Background(), &payment.StepInput{Action: payment.StepActionAdvance, Job: &models.PaymentJob{PaymentID: "pay_sfn"}})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampPending, output.Status)
+	assert.False(t, output.Done)
+	assert.Equal(t, 10, output.WaitSeconds)
+	assert.Empty(t, f.queue.jobs, "nothing is sent to SQS")
+
+	output, err = o.HandleTask(context.Background(), &payment.StepInput{Job: output.Job})
+	require.NoError(t, err)
+	assert.Equal(t, 20, output.WaitSeconds)
+	assert.Equal(t, 1, f.onRamp.polls)
+}
+
+func TestStepFunctionsAdvanceRetriesLockedPayment(t *testing.T) {
+	ctx := context.Background()
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_sfn_locked", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	o := newOrchestrator(t, f, payment.DefaultPollingConfig())
+
+	acquired, err := f.repo.AcquireProcessingLock(ctx, "pay_sfn_locked", models.StatusPending, "other-worker", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// Another invocation holds the payment, so the execution checks back shortly
+	output, err := o.Advance(ctx, &models.PaymentJob{PaymentID: "pay_sfn_locked"})
+	require.NoError(t, err)
+	assert.False(t, output.Done)
+	assert.Equal(t, 5, output.WaitSeconds)
+	assert.Empty(t, f.onRamp.transfers)
+}
+
+func TestStepFunctionsAdvanceFinishesTerminalPayment(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_sfn_done", Amount: 100000, Currency: "EUR", Status: models.StatusCompleted}, payment.DefaultPollingConfig())
+	o := newOrchestrator(t, f, payment.DefaultPollingConfig())
+
+	output, err := o.Advance(context.Background(), &models.PaymentJob{PaymentID: "pay_sfn_done"})
+	require.NoError(t, err)
+	assert.True(t, output.Done)
+	assert.Equal(t, models.StatusCompleted, output.Status)
+}
+
+func TestStepFunctionsHandleTaskRejectsBadInput(t *testing.T) {
+	f := newStateMachineFixture(t, &models.Payment{PaymentID: "pay_sfn_bad", Amount: 100000, Currency: "EUR", Status: models.StatusPending}, payment.DefaultPollingConfig())
+	o := newOrchestrator(t, f, payment.DefaultPollingConfig())
+
+	_, err := o.HandleTask(context.Background(), &payment.StepInput{Action: payment.StepActionAdvance})
+	assert.ErrorContains(t, err, "missing payment job")
+
+	_, err = o.HandleTask(context.Background(), &payment.StepInput{Action: "signal_settlement", Job: &models.PaymentJob{PaymentID: "pay_sfn_bad"}})
+	assert.ErrorContains(t, err, "unknown step action")
+}
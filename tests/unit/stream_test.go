@@ -0,0 +1,258 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/apikeys"
+	"crypto-conversion/internal/customers"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/stream"
+)
+
+// sseEvent is one server-sent event read from a stream
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  stream.StatusEvent
+}
+
+// readEvents reads a stream's events until it closes, skipping comments
+func readEvents(t *testing.T, resp *http.Response) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.Event != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.Data))
+		}
+	}
+	return events
+}
+
+// streamPayment stores a payment that has moved from PENDING to ONRAMP_PENDING
+func streamPayment(t *testing.T, repo *database.MemoryPaymentRepository, id string) *models.Payment {
+	t.Helper()
+	now := time.Now().UTC()
+	p := &models.Payment{
+		PaymentID:      id,
+		IdempotencyKey: "key_" + id,
+		Amount:         10000,
+		Currency:       "EUR",
+		Status:         models.StatusOnrampPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		StateHistory: []models.StateTransition{
+			{FromStatus: models.StatusPending, ToStatus: models.StatusOnrampPending, Timestamp: now},
+		},
+	}
+	require.NoError(t, repo.CreatePayment(context.Background(), p))
+	return p
+}
+
+// fakeGatewayKeys accepts one API Gateway key
+type fakeGatewayKeys struct{ key string }
+
+func (k fakeGatewayKeys) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	if apiKey != k.key {
+		return "", fmt.Errorf("unknown API key")
+	}
+	return "key-id", nil
+}
+
+func TestStreamPushesTransitionsUntilTerminal(t *testing.T) {
+	repo := database.NewMemoryPaymentRepository()
+	p := streamPayment(t, repo, "pay_stream")
+
+	server := httptest.NewServer(stream.NewHandler(repo, nil, stream.Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxDuration:  5 * time.Second,
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/payments/pay_stream/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Move the payment on while the stream is open
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p.StateHistory = append(p.StateHistory, models.StateTransition{
+			FromStatus: models.StatusOnrampPending, ToStatus: models.StatusFailed, Timestamp: time.Now().UTC(),
+			Message: "provider rejected the transfer",
+		})
+		p.Status = models.StatusFailed
+		repo.UpdatePayment(context.Background(), p)
+	}()
+
+	events := readEvents(t, resp)
+	require.Len(t, events, 4)
+	assert.Equal(t, []string{"0", "1", "2", "3"}, []string{events[0].ID, events[1].ID, events[2].ID, events[3].ID})
+	assert.Equal(t, models.StatusPending, events[0].Data.Status)
+	assert.Equal(t, models.StatusOnrampPending, events[1].Data.Status)
+	assert.Equal(t, stream.EventStatus, events[2].Event)
+	assert.Equal(t, models.StatusFailed, events[2].Data.Status)
+	assert.Equal(t, "Payment failed", events[2].Data.Message)
+	assert.Equal(t, stream.EventDone, events[3].Event)
+	assert.Equal(t, "pay_stream", events[3].Data.PaymentID)
+}
+
+func TestStreamResumesAfterLastEventID(t *testing.T) {
+	repo := database.NewMemoryPaymentRepository()
+	p := streamPayment(t, repo, "pay_resume")
+	p.Status = models.StatusCompleted
+	p.StateHistory = append(p.StateHistory, models.StateTransition{
+		FromStatus: models.StatusOnrampPending, ToStatus: models.StatusCompleted, Timestamp: time.Now().UTC(),
+	})
+	require.NoError(t, repo.UpdatePayment(context.Background(), p))
+
+	server := httptest.NewServer(stream.NewHandler(repo, nil, stream.Config{PollInterval: time.Second, MaxDuration: time.Second}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/payments/pay_resume/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	events := readEvents(t, resp)
+	require.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].ID)
+	assert.Equal(t, models.StatusCompleted, events[0].Data.Status)
+	assert.Equal(t, stream.EventDone, events[1].Event)
+}
+
+func TestStreamClosesAfterMaxDuration(t *testing.T) {
+	repo := database.NewMemoryPaymentRepository()
+	streamPayment(t, repo, "pay_open")
+
+	server := httptest.NewServer(stream.NewHandler(repo, nil, stream.Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxDuration:  50 * time.Millisecond,
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/payments/pay_open/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	events := readEvents(t, resp)
+	require.Len(t, events, 2)
+	assert.Equal(t, stream.EventStatus, events[1].Event)
+}
+
+func TestStreamRejectsUnknownPaymentsAndKeys(t *testing.T) {
+	repo := database.NewMemoryPaymentRepository()
+	keyed := streamPayment(t, repo, "pay_keyed")
+	keyed.IdempotencyScope = "key-id"
+	require.NoError(t, repo.UpdatePayment(context.Background(), keyed))
+	other := streamPayment(t, repo, "pay_other_key")
+	other.IdempotencyScope = "other-key-id"
+	require.NoError(t, repo.UpdatePayment(context.Background(), other))
+
+	server := httptest.NewServer(stream.NewHandler(repo, apikeys.NewCallers(nil, fakeGatewayKeys{key: "secret"}, nil), stream.Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxDuration:  10 * time.Millisecond,
+	}))
+	defer server.Close()
+
+	get := func(path, key string) int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("/payments/pay_keyed/stream", ""))
+	assert.Equal(t, http.StatusForbidden, get("/payments/pay_keyed/stream", "wrong"))
+	assert.Equal(t, http.StatusNotFound, get("/payments/pay_missing/stream", "secret"))
+	assert.Equal(t, http.StatusNotFound, get("/payments/pay_other_key/stream", "secret"), "another key's payment")
+	assert.Equal(t, http.StatusNotFound, get("/payments/pay_keyed/other", "secret"))
+	assert.Equal(t, http.StatusOK, get("/payments/pay_keyed/stream", "secret"))
+}
+
+func TestStreamAuthenticatesIssuedKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	p := streamPayment(t, repo, "pay_issued")
+	p.IdempotencyScope = "cust_acme"
+	require.NoError(t, repo.UpdatePayment(ctx, p))
+
+	keys := apikeys.New(database.NewMemoryAPIKeyRepository(), time.Hour)
+	issued, err := keys.Issue(ctx, "cust_acme", &models.APIKeyRequest{Name: "dashboard"})
+	require.NoError(t, err)
+	customerStore := database.NewMemoryCustomerRepository()
+	customer := &models.Customer{CustomerID: "cust_acme", AllowedIPs: []string{"10.0.0.0/8"}}
+	require.NoError(t, customerStore.CreateCustomer(ctx, customer))
+
+	callers := apikeys.NewCallers(keys, fakeGatewayKeys{key: "gateway"}, customers.New(customerStore))
+	server := httptest.NewServer(stream.NewHandler(repo, callers, stream.Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxDuration:  5 * time.Second,
+		KeepAlive:    20 * time.Millisecond,
+	}))
+	defer server.Close()
+
+	open := func(key string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/payments/pay_issued/stream", nil)
+		req.Header.Set("X-Api-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// The test server's clients call from 127.0.0.1, outside the customer's allowlist
+	resp := open(issued.Secret)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	customer.AllowedIPs = []string{"127.0.0.0/8"}
+	require.NoError(t, customerStore.UpdateCustomer(ctx, customer))
+	resp = open("gateway")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "a gateway key's caller isn't the payment's customer")
+
+	resp = open(issued.Secret)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Revoking the key closes the open stream at its next keep-alive, and refuses new ones
+	started := time.Now()
+	_, err = keys.Revoke(ctx, "cust_acme", issued.KeyID)
+	require.NoError(t, err)
+	events := readEvents(t, resp)
+	assert.Len(t, events, 2, "no done event")
+	assert.Less(t, time.Since(started), 2*time.Second)
+
+	resp = open(issued.Secret)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
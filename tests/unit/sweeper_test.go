@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+)
+
+// createStalePayment stores a payment last updated age ago
+func createStalePayment(t *testing.T, repo *database.MemoryPaymentRepository, id string, status models.PaymentStatus, age time.Duration) {
+	t.Helper()
+	require.NoError(t, repo.CreatePayment(context.Background(), &models.Payment{
+		PaymentID:      id,
+		IdempotencyKey: "key_" + id,
+		Amount:         10000,
+		Currency:       "EUR",
+		Status:         status,
+		CreatedAt:      time.Now().Add(-age),
+		UpdatedAt:      time.Now().Add(-age),
+	}))
+}
+
+func TestSweeperRequeuesStalePayments(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	createStalePayment(t, repo, "pay_stale", models.StatusPending, time.Hour)
+	createStalePayment(t, repo, "pay_fresh", models.StatusPending, time.Minute)
+	createStalePayment(t, repo, "pay_held", models.StatusRequiresReview, time.Hour)
+	createStalePayment(t, repo, "pay_done", models.StatusCompleted, time.Hour)
+
+	sweeper := payment.NewSweeper(repo, payment.SweeperConfig{SLA: 30 * time.Minute, MaxRequeues: 1})
+	result, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &payment.SweepResult{Requeued: 1}, result)
+
+	p, err := repo.GetPaymentByID(ctx, "pay_stale")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, p.Status)
+	assert.Equal(t, 1, p.SweepCount)
+	assert.Empty(t, p.LockOwner)
+
+	msgs, err := repo.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, models.OutboxKindPaymentJob, msgs[0].Kind)
+	var job models.PaymentJob
+	require.NoError(t, json.Unmarshal([]byte(msgs[0].Payload), &job))
+	assert.Equal(t, "pay_stale", job.PaymentID)
+	assert.Equal(t, models.StatusPending, job.ExpectedStatus)
+
+	// The re-enqueue restarts the SLA, so an immediate second sweep leaves it alone
+	result, err = sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &payment.SweepResult{}, result)
+}
+
+func TestSweeperExpiresPaymentsAfterMaxRequeues(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	createStalePayment(t, repo, "pay_pending", models.StatusPending, time.Hour)
+	createStalePayment(t, repo, "pay_bridge", models.StatusBridgePending, 3*time.Hour)
+
+	sweeper := payment.NewSweeper(repo, payment.SweeperConfig{
+		SLA:        30 * time.Minute,
+		StatusSLAs: map[models.PaymentStatus]time.Duration{models.StatusBridgePending: 2 * time.Hour},
+	})
+	result, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &payment.SweepResult{Expired: 2}, result)
+
+	// A payment that never started has moved no funds and fails
+	pending, err := repo.GetPaymentByID(ctx, "pay_pending")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, pending.Status)
+	assert.NotEmpty(t, pending.ErrorMessage)
+	assert.NotNil(t, pending.ProcessedAt)
+
+	// One mid-flight times out for operators to settle
+	bridge, err := repo.GetPaymentByID(ctx, "pay_bridge")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusTimedOut, bridge.Status)
+	require.NotEmpty(t, bridge.StateHistory)
+	assert.Equal(t, models.StatusBridgePending, bridge.StateHistory[len(bridge.StateHistory)-1].FromStatus)
+
+	msgs, err := repo.ListOutboxMessages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	for _, msg := range msgs {
+		assert.Equal(t, models.OutboxKindWebhookEvent, msg.Kind)
+	}
+}
+
+func TestSweeperSkipsLockedPayments(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	createStalePayment(t, repo, "pay_locked", models.StatusOnrampPending, time.Hour)
+
+	acquired, err := repo.AcquireProcessingLock(ctx, "pay_locked", models.StatusOnrampPending, "worker_a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	sweeper := payment.NewSweeper(repo, payment.SweeperConfig{SLA: 30 * time.Minute})
+	result, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &payment.SweepResult{Skipped: 1}, result)
+
+	p, err := repo.GetPaymentByID(ctx, "pay_locked")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusOnrampPending, p.Status)
+	assert.Equal(t, "worker_a", p.LockOwner)
+}
@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-xray-sdk-go/header"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/queue"
+	"crypto-conversion/internal/tracing"
+)
+
+// discardEmitter keeps closed segments away from the X-Ray daemon
+type discardEmitter struct{}
+
+func (discardEmitter) Emit(seg *xray.Segment)                     {}
+func (discardEmitter) RefreshEmitterWithAddress(raddr *net.UDPAddr) {}
+
+// enableTracing turns tracing on for one test, emitting nowhere
+func enableTracing(t *testing.T) {
+	t.Helper()
+	require.NoError(t, xray.Configure(xray.Config{Emitter: discardEmitter{}}))
+	tracing.Configure(true)
+	t.Cleanup(func() { tracing.Configure(false) })
+}
+
+// beginSampledSegment starts a segment that's always sampled, as Lambda's facade segment would be
+func beginSampledSegment(t *testing.T, name string) (context.Context, *xray.Segment) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+	ctx, seg := xray.BeginSegmentWithSampling(context.Background(), name, req, &header.Header{SamplingDecision: header.Sampled})
+	t.Cleanup(func() { seg.Close(nil) })
+	return ctx, seg
+}
+
+func TestCaptureRecordsSubsegment(t *testing.T) {
+	enableTracing(t)
+	ctx, seg := beginSampledSegment(t, "api-handler")
+
+	var sub *xray.Segment
+	err := tracing.Capture(ctx, "dynamodb.CreatePayment", func(ctx context.Context) error {
+		sub = xray.GetSegment(ctx)
+		tracing.Annotate(ctx, "payment_id", "pay_1")
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotNil(t, sub)
+	assert.Equal(t, "dynamodb.CreatePayment", sub.Name)
+	assert.Same(t, seg, sub.ParentSegment)
+	assert.Equal(t, "pay_1", sub.Annotations["payment_id"])
+	assert.False(t, sub.Fault)
+
+	failure := errors.New("claude unavailable")
+	err = tracing.Capture(ctx, "claude.CalculateFee", func(ctx context.Context) error {
+		sub = xray.GetSegment(ctx)
+		return failure
+	})
+	assert.Same(t, failure, err)
+	assert.Equal(t, "claude.CalculateFee", sub.Name)
+	assert.True(t, sub.Fault)
+}
+
+func TestCaptureWithoutTracingRunsPlainly(t *testing.T) {
+	var ran bool
+	failure := errors.New("claude unavailable")
+	err := tracing.Capture(context.Background(), "claude.CalculateFee", func(ctx context.Context) error {
+		ran = true
+		assert.Nil(t, xray.GetSegment(ctx))
+		tracing.Annotate(ctx, "payment_id", "pay_1")
+		return failure
+	})
+
+	assert.True(t, ran)
+	assert.Same(t, failure, err)
+	assert.Empty(t, tracing.TraceHeader(tracing.WithTraceHeader(context.Background(), "Root=1-5759e988-bd862e3fe1be46a994272793")))
+}
+
+func TestTraceHeaderFollowsSegmentOrRecordedHeader(t *testing.T) {
+	enableTracing(t)
+	assert.Empty(t, tracing.TraceHeader(context.Background()))
+
+	ctx, seg := beginSampledSegment(t, "api-handler")
+	current := tracing.TraceHeader(ctx)
+	assert.Contains(t, current, header.RootPrefix+seg.TraceID)
+	assert.Contains(t, current, header.ParentPrefix+seg.ID)
+
+	// A header recorded by an earlier hop wins, so deliveries join the writer's trace
+	recorded := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	assert.Equal(t, recorded, tracing.TraceHeader(tracing.WithTraceHeader(ctx, recorded)))
+	assert.Equal(t, current, tracing.TraceHeader(tracing.WithTraceHeader(ctx, "")))
+}
+
+// fakeSQS answers SendMessage and SendMessageBatch calls, recording the trace header each message was sent with
+type fakeSQS struct {
+	mu     sync.Mutex
+	traces []string
+}
+
+// sqsSystemAttributes is the part of a sent message that carries the trace header
+type sqsSystemAttributes struct {
+	MessageBody             string
+	MessageSystemAttributes map[string]struct{ StringValue string }
+}
+
+func (f *fakeSQS) record(msg sqsSystemAttributes) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.traces = append(f.traces, msg.MessageSystemAttributes["AWSTraceHeader"].StringValue)
+
+	sum := md5.Sum([]byte(msg.MessageBody))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.") {
+	case "SendMessage":
+		var msg sqsSystemAttributes
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"MessageId": "msg_1", "MD5OfMessageBody": f.record(msg)})
+	case "SendMessageBatch":
+		var batch struct {
+			Entries []struct {
+				Id string
+				sqsSystemAttributes
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var successful []map[string]string
+		for _, entry := range batch.Entries {
+			successful = append(successful, map[string]string{
+				"Id": entry.Id, "MessageId": "msg_" + entry.Id, "MD5OfMessageBody": f.record(entry.sqsSystemAttributes),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Successful": successful, "Failed": []interface{}{}})
+	default:
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+	}
+}
+
+func TestQueueSendsCarryTraceHeader(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	enableTracing(t)
+
+	sqsServer := &fakeSQS{}
+	server := httptest.NewServer(sqsServer)
+	defer server.Close()
+
+	client, err := queue.NewClient("us-east-1", server.URL)
+	require.NoError(t, err)
+
+	ctx, seg := beginSampledSegment(t, "worker-handler")
+	queueURL := server.URL + "/queue/payments"
+	require.NoError(t, client.SendPaymentJob(ctx, queueURL, &models.PaymentJob{PaymentID: "pay_1", Currency: "USD"}))
+	require.NoError(t, client.SendPaymentJobs(ctx, queueURL, []*models.PaymentJob{{PaymentID: "pay_2"}, {PaymentID: "pay_3"}}))
+	require.NoError(t, client.SendWebhookEvent(ctx, queueURL, &models.WebhookEvent{PaymentID: "pay_1", EventType: "payment.completed"}))
+
+	// A recorded header, as the outbox relay attaches, replaces the sender's own trace
+	recorded := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+	require.NoError(t, client.SendPaymentJob(tracing.WithTraceHeader(ctx, recorded), queueURL, &models.PaymentJob{PaymentID: "pay_4"}))
+
+	require.Len(t, sqsServer.traces, 5)
+	for _, trace := range sqsServer.traces[:4] {
+		assert.Contains(t, trace, header.RootPrefix+seg.TraceID)
+	}
+	assert.Equal(t, recorded, sqsServer.traces[4])
+}
+
+func TestOutboxMessagesRecordWritersTrace(t *testing.T) {
+	enableTracing(t)
+	repo := database.NewMemoryPaymentRepository()
+
+	// Untraced writes leave the message without a trace
+	untraced := &models.Payment{PaymentID: "pay_untraced", IdempotencyKey: "key_untraced", Status: models.StatusPending}
+	msg, err := models.NewOutboxMessage("msg_untraced", models.OutboxKindPaymentJob, untraced.PaymentID, models.NewPaymentJob(untraced))
+	require.NoError(t, err)
+	require.NoError(t, repo.CreatePaymentWithOutbox(context.Background(), untraced, msg))
+
+	ctx, seg := beginSampledSegment(t, "api-handler")
+	p := &models.Payment{PaymentID: "pay_1", IdempotencyKey: "key_1", Status: models.StatusPending}
+	msg, err = models.NewOutboxMessage("msg_1", models.OutboxKindPaymentJob, p.PaymentID, models.NewPaymentJob(p))
+	require.NoError(t, err)
+	require.NoError(t, repo.CreatePaymentWithOutbox(ctx, p, msg))
+
+	p.Status = models.StatusCompleted
+	event, err := models.NewOutboxMessage("msg_2", models.OutboxKindWebhookEvent, p.PaymentID, &models.WebhookEvent{PaymentID: p.PaymentID})
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdatePaymentWithOutbox(ctx, p, event))
+
+	messages, err := repo.ListOutboxMessages(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Empty(t, messages[0].TraceHeader)
+	assert.Contains(t, messages[1].TraceHeader, header.RootPrefix+seg.TraceID)
+	assert.Contains(t, messages[2].TraceHeader, header.RootPrefix+seg.TraceID)
+	assert.Empty(t, msg.TraceHeader, "the caller's message is left as it was")
+}
@@ -0,0 +1,325 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fundTreasury tops up a treasury account the way POST /internal/treasury does
+func fundTreasury(t *testing.T, repo database.TreasuryRepository, entry models.TreasuryEntry, reference string) {
+	_, err := repo.ApplyTreasuryMovement(context.Background(), &models.TreasuryMovement{
+		MovementID: models.TreasuryLegAdjustment + ":" + reference,
+		Leg:        models.TreasuryLegAdjustment,
+		Entries:    []models.TreasuryEntry{entry},
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+}
+
+// treasuryBalance returns an account's balance, zero when untracked
+func treasuryBalance(t *testing.T, repo database.TreasuryRepository, account string) int64 {
+	balance, err := repo.GetTreasuryBalance(context.Background(), account)
+	require.NoError(t, err)
+	if balance == nil {
+		return 0
+	}
+	return balance.Balance
+}
+
+func TestTreasuryMovementsApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryTreasuryRepository()
+
+	movement := models.PaymentMovement("pay_1", models.TreasuryLegOfframp,
+		models.USDCEntry("Base", -1000), models.FloatEntry("offramp", "eur", -900))
+	applied, err := repo.ApplyTreasuryMovement(ctx, movement)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = repo.ApplyTreasuryMovement(ctx, movement)
+	require.NoError(t, err)
+	assert.False(t, applied, "a retried step doesn't move funds twice")
+
+	balances, err := repo.ListTreasuryBalances(ctx)
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	assert.Equal(t, "chain:base:USDC", balances[0].Account)
+	assert.Equal(t, int64(-1000), balances[0].Balance)
+	assert.Equal(t, "provider:offramp:EUR", balances[1].Account)
+	assert.Equal(t, int64(-900), balances[1].Balance)
+
+	missing, err := repo.GetTreasuryBalance(ctx, "chain:solana:USDC")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestReserveTreasuryMovementRequiresCover(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryTreasuryRepository()
+	fundTreasury(t, repo, models.FloatEntry("offramp", "EUR", 150000), "seed")
+
+	first := models.PaymentMovement("pay_1", models.TreasuryLegOfframpReserved, models.FloatEntry("offramp", "EUR", -100000))
+	reserved, err := repo.ReserveTreasuryMovement(ctx, first)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	second := models.PaymentMovement("pay_2", models.TreasuryLegOfframpReserved, models.FloatEntry("offramp", "EUR", -100000))
+	reserved, err = repo.ReserveTreasuryMovement(ctx, second)
+	require.NoError(t, err)
+	assert.False(t, reserved, "the float only covers one payout")
+
+	reserved, err = repo.ReserveTreasuryMovement(ctx, first)
+	require.NoError(t, err)
+	assert.True(t, reserved, "a retried reservation is still held")
+	assert.Equal(t, int64(50000), treasuryBalance(t, repo, "provider:offramp:EUR"))
+
+	untracked := models.PaymentMovement("pay_3", models.TreasuryLegWallet, models.USDCEntry("solana", -1))
+	reserved, err = repo.ReserveTreasuryMovement(ctx, untracked)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Zero(t, treasuryBalance(t, repo, "chain:solana:USDC"), "refused reservations don't move funds")
+}
+
+func TestTreasuryThresholds(t *testing.T) {
+	thresholds := models.TreasuryThresholds(map[string]int{"chain:Base:usdc": 500, "provider:offramp:EUR": 100, "bogus": 1})
+	assert.Equal(t, map[string]int64{"chain:base:USDC": 500, "provider:offramp:EUR": 100}, thresholds)
+
+	balance := &models.TreasuryBalance{Balance: 499}
+	balance.SetThreshold(500)
+	assert.True(t, balance.Low)
+	balance.SetThreshold(0)
+	assert.False(t, balance.Low, "accounts without a threshold are never low")
+}
+
+func TestTreasuryAdjustmentValidation(t *testing.T) {
+	valid := models.TreasuryAdjustmentRequest{Kind: "provider", Location: "Offramp", Currency: "eur", Amount: 100, Reference: "wire-1"}
+	assert.Nil(t, valid.Validate())
+	assert.Equal(t, "provider:offramp:EUR", valid.Entry().Account())
+
+	chainEUR := valid
+	chainEUR.Kind = models.TreasuryKindChain
+	assert.ErrorContains(t, chainEUR.Validate(), "USDC")
+
+	noReference := valid
+	noReference.Reference = ""
+	assert.ErrorContains(t, noReference.Validate(), "reference")
+}
+
+func TestOfframpWaitsForFloat(t *testing.T) {
+	ctx := context.Background()
+
+	// The mock off-ramp fails a few percent of initiations at random, so retry until one starts
+	for attempt := 0; attempt < 20; attempt++ {
+		repo := database.NewMemoryPaymentRepository()
+		treasury := database.NewMemoryTreasuryRepository()
+		id := fmt.Sprintf("pay_float_%d", attempt)
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+			PaymentID:      id,
+			IdempotencyKey: id,
+			Amount:         100000,
+			Currency:       "EUR",
+			Chain:          "base",
+			Status:         models.StatusOnrampComplete,
+			CreatedAt:      time.Now(),
+		}))
+
+		queue := &recordingQueue{}
+		sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+			payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+		sm.EnableTreasury(treasury, nil)
+
+		// No float with the off-ramp yet: the payment is held
+		require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: id}))
+		stored, err := repo.GetPaymentByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusOnrampComplete, stored.Status)
+		assert.Empty(t, stored.OffRampTxID)
+		require.Len(t, queue.jobs, 1, "re-enqueued to check the float again")
+
+		fundTreasury(t, treasury, models.FloatEntry(models.TreasuryOfframpProvider, "EUR", 250000), id)
+		require.NoError(t, sm.ProcessPayment(ctx, queue.jobs[0]))
+		stored, err = repo.GetPaymentByID(ctx, id)
+		require.NoError(t, err)
+		if stored.Status == models.StatusReversing {
+			continue
+		}
+
+		assert.Equal(t, models.StatusOfframpPending, stored.Status)
+		assert.Equal(t, int64(150000), treasuryBalance(t, treasury, "provider:offramp:EUR"), "payout paid from the float")
+		assert.Equal(t, int64(-100000), treasuryBalance(t, treasury, "chain:base:USDC"), "USDC deposited with the off-ramp")
+		return
+	}
+	t.Fatal("no off-ramp transfer started")
+}
+
+func TestOfframpsCannotOverdrawSharedFloat(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	treasury := database.NewMemoryTreasuryRepository()
+	fundTreasury(t, treasury, models.FloatEntry(models.TreasuryOfframpProvider, "EUR", 150000), "seed")
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableTreasury(treasury, nil)
+
+	// Both payments passed the float check under the old read-then-debit; only one may reserve it now
+	for _, id := range []string{"pay_float_a", "pay_float_b"} {
+		require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+			PaymentID:      id,
+			IdempotencyKey: id,
+			Amount:         100000,
+			Currency:       "EUR",
+			Chain:          "base",
+			Status:         models.StatusOnrampComplete,
+			CreatedAt:      time.Now(),
+		}))
+		require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: id}))
+	}
+
+	first, err := repo.GetPaymentByID(ctx, "pay_float_a")
+	require.NoError(t, err)
+	assert.True(t, first.FloatReserved)
+
+	second, err := repo.GetPaymentByID(ctx, "pay_float_b")
+	require.NoError(t, err)
+	assert.False(t, second.FloatReserved)
+	assert.Equal(t, models.StatusOnrampComplete, second.Status, "held until the float is topped up")
+	assert.Empty(t, second.OffRampTxID)
+	assert.Equal(t, int64(50000), treasuryBalance(t, treasury, "provider:offramp:EUR"), "never overdrawn")
+}
+
+func TestReversalReleasesFloatReservedBeforeInitiation(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	treasury := database.NewMemoryTreasuryRepository()
+	fundTreasury(t, treasury, models.FloatEntry(models.TreasuryOfframpProvider, "EUR", 100000), "seed")
+
+	// The float was reserved but the off-ramp refused the transfer
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_unreserve",
+		IdempotencyKey: "key_unreserve",
+		Amount:         100000,
+		Currency:       "EUR",
+		Chain:          "base",
+		FloatReserved:  true,
+		Status:         models.StatusReversing,
+		CreatedAt:      time.Now(),
+	}))
+	for _, movement := range []*models.TreasuryMovement{
+		models.PaymentMovement("pay_unreserve", models.TreasuryLegOnramp, models.USDCEntry("base", 100000)),
+		models.PaymentMovement("pay_unreserve", models.TreasuryLegOfframpReserved, models.FloatEntry(models.TreasuryOfframpProvider, "EUR", -100000)),
+	} {
+		_, err := treasury.ApplyTreasuryMovement(ctx, movement)
+		require.NoError(t, err)
+	}
+
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableTreasury(treasury, nil)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_unreserve"}))
+	assert.Equal(t, int64(100000), treasuryBalance(t, treasury, "provider:offramp:EUR"), "the reservation is back in the float")
+	assert.Zero(t, treasuryBalance(t, treasury, "chain:base:USDC"), "the minted USDC was redeemed to the source")
+}
+
+func TestInsufficientFloatReversesAfterStageTimeout(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	treasury := database.NewMemoryTreasuryRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_float",
+		IdempotencyKey: "key_float",
+		Amount:         100000,
+		Currency:       "GBP",
+		Chain:          "base",
+		Status:         models.StatusOnrampComplete,
+		CreatedAt:      time.Now().Add(-2 * time.Hour),
+	}))
+
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableTreasury(treasury, nil)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_float"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_float")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusReversing, stored.Status)
+	assert.Contains(t, stored.ErrorMessage, "provider:offramp:GBP")
+	assert.Empty(t, stored.OffRampTxID)
+}
+
+func TestWalletPayoutDebitsChainBalance(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	treasury := database.NewMemoryTreasuryRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:          "pay_wallet",
+		IdempotencyKey:     "key_wallet",
+		Amount:             100000,
+		Currency:           "USDC",
+		DestinationAccount: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		PayoutType:         models.PayoutTypeWallet,
+		Chain:              "base",
+		Status:             models.StatusOnrampComplete,
+		CreatedAt:          time.Now(),
+	}))
+	fundTreasury(t, treasury, models.USDCEntry("base", 120000), "seed")
+
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableWalletPayouts(payment.NewStatefulWalletClient())
+	sm.EnableTreasury(treasury, map[string]int64{"chain:base:USDC": 50000})
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_wallet"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_wallet")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusWalletPending, stored.Status)
+	assert.Equal(t, int64(20000), treasuryBalance(t, treasury, "chain:base:USDC"))
+}
+
+func TestReversalReleasesOfframpFloat(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	treasury := database.NewMemoryTreasuryRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_reverse",
+		IdempotencyKey: "key_reverse",
+		Amount:         100000,
+		Currency:       "EUR",
+		Chain:          "base",
+		OffRampTxID:    "offramp_failed",
+		FloatReserved:  true,
+		Status:         models.StatusReversing,
+		CreatedAt:      time.Now(),
+	}))
+
+	// What the on-ramp minted and the failed off-ramp took
+	for _, movement := range []*models.TreasuryMovement{
+		models.PaymentMovement("pay_reverse", models.TreasuryLegOnramp, models.USDCEntry("base", 100000)),
+		models.PaymentMovement("pay_reverse", models.TreasuryLegOfframpReserved, models.FloatEntry(models.TreasuryOfframpProvider, "EUR", -100000)),
+		models.PaymentMovement("pay_reverse", models.TreasuryLegOfframp, models.USDCEntry("base", -100000)),
+	} {
+		_, err := treasury.ApplyTreasuryMovement(ctx, movement)
+		require.NoError(t, err)
+	}
+
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableTreasury(treasury, nil)
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_reverse"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_reverse")
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.ReversalTxID)
+	assert.Zero(t, treasuryBalance(t, treasury, "provider:offramp:EUR"), "the failed payout is back in the float")
+	assert.Zero(t, treasuryBalance(t, treasury, "chain:base:USDC"), "the minted USDC was redeemed to the source")
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/reporting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingUsageExporter captures exported usage instead of writing to S3
+type recordingUsageExporter struct {
+	exports map[string][]*models.UsageRecord
+}
+
+func (e *recordingUsageExporter) ExportUsageReport(ctx context.Context, period string, records []*models.UsageRecord) error {
+	e.exports[period] = records
+	return nil
+}
+
+func TestUsageCountersAccumulatePerMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryUsageRepository()
+	october := time.Date(2026, 10, 31, 23, 59, 0, 0, time.UTC)
+	november := october.Add(2 * time.Minute)
+
+	for _, record := range []*models.UsageRecord{
+		models.NewUsageRecord("key_a", models.UsageMetricAPICalls, "", 1, october),
+		models.NewUsageRecord("key_a", models.UsageMetricAPICalls, "", 1, october),
+		models.NewUsageRecord("key_a", models.UsageMetricPaymentVolume, "usd", 100000, october),
+		models.NewUsageRecord("key_a", models.UsageMetricPaymentVolume, "EUR", 5000, october),
+		models.NewUsageRecord("key_a", models.UsageMetricPaymentVolume, "USD", 25000, october),
+		models.NewUsageRecord("key_a", models.UsageMetricAPICalls, "", 1, november),
+		models.NewUsageRecord("key_b", models.UsageMetricAIFeeCalculations, "", 1, october),
+	} {
+		require.NoError(t, repo.AddUsage(ctx, record))
+	}
+
+	records, err := repo.ListUsage(ctx, "key_a", "2026-10")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	summary := models.NewUsageSummary("key_a", "2026-10", records)
+	assert.Equal(t, int64(2), summary.APICalls)
+	assert.Equal(t, map[string]int64{"USD": 125000, "EUR": 5000}, summary.PaymentVolume)
+	assert.Zero(t, summary.AIFeeCalculations)
+
+	records, err = repo.ListUsage(ctx, "key_a", "2026-11")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "2026-11#api_calls", records[0].UsageKey)
+
+	records, err = repo.ListPeriodUsage(ctx, "2026-10")
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "key_a", records[0].CustomerID)
+	assert.Equal(t, models.UsageMetricAPICalls, records[0].Metric)
+	assert.Equal(t, "EUR", records[1].Currency)
+	assert.Equal(t, "key_b", records[3].CustomerID)
+}
+
+func TestUsageReporterExportsOneMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryUsageRepository()
+	exporter := &recordingUsageExporter{exports: make(map[string][]*models.UsageRecord)}
+	september := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.AddUsage(ctx, models.NewUsageRecord("key_a", models.UsageMetricPayments, "", 3, september)))
+	require.NoError(t, repo.AddUsage(ctx, models.NewUsageRecord("key_a", models.UsageMetricPayments, "", 1, september.AddDate(0, 1, 0))))
+
+	records, err := reporting.NewUsageReporter(repo, exporter).Run(ctx, september)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, int64(3), records[0].Quantity)
+	assert.Equal(t, records, exporter.exports["2026-09"])
+
+	body, err := reporting.EncodeUsageCSV(records)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "period,customer_id,metric,currency,quantity", lines[0])
+	assert.Equal(t, "2026-09,key_a,payments,,3", lines[1])
+}
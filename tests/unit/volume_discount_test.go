@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/fees"
+	"crypto-conversion/internal/models"
+)
+
+func TestParseVolumeTiers(t *testing.T) {
+	tiers, err := fees.ParseVolumeTiers("100000000=0.018, 10000000=0.022")
+	require.NoError(t, err)
+	assert.Equal(t, []fees.VolumeTier{{MinVolume: 10000000, Rate: 0.022}, {MinVolume: 100000000, Rate: 0.018}}, tiers)
+
+	for _, spec := range []string{"100000000", "abc=0.01", "0=0.01", "100=1.5"} {
+		_, err := fees.ParseVolumeTiers(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMemoryVolumeRepositoryRollingWindow(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryVolumeRepository()
+	now := time.Now()
+
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_1", PaymentID: "pay_1", AmountUSD: 5000, CompletedAt: now}))
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_1", PaymentID: "pay_1", AmountUSD: 5000, CompletedAt: now})) // Retried completion
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_1", PaymentID: "pay_old", AmountUSD: 9000, CompletedAt: now.Add(-40 * 24 * time.Hour)}))
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_2", PaymentID: "pay_2", AmountUSD: 7000, CompletedAt: now}))
+
+	volume, err := repo.RollingVolume(ctx, "key_1", now.Add(-models.VolumeWindow))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), volume)
+}
+
+func TestVolumeDiscountLowersRate(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryVolumeRepository()
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_big", PaymentID: "pay_1", AmountUSD: 150000000, CompletedAt: time.Now()}))
+
+	tiers, err := fees.ParseVolumeTiers("10000000=0.022,100000000=0.018")
+	require.NoError(t, err)
+	calc := fees.NewCalculator()
+	calc.EnableVolumeDiscounts(repo, tiers)
+
+	// Over $1M: 1.8% keeps the tier's $1.00 fixed fee
+	result := calc.CalculateFeeForCurrency(ctx, 100000, "EUR", "key_big")
+	assert.Equal(t, int64(1900), result.FeeAmount)
+	require.NotNil(t, result.VolumeTier)
+	assert.Equal(t, 0.018, result.VolumeTier.Rate)
+
+	// No volume: the schedule applies
+	result = calc.CalculateFeeForCurrency(ctx, 200000, "EUR", "", "acct_new")
+	assert.Equal(t, int64(4100), result.FeeAmount)
+	assert.Nil(t, result.VolumeTier)
+
+	// Negotiated pricing wins over volume tiers
+	rate := 0.025
+	calc.SetCustomerPricing(map[string]fees.CustomerPricing{"key_big": {Rate: &rate}})
+	result = calc.CalculateFeeForCurrency(ctx, 200000, "EUR", "key_big")
+	assert.Equal(t, int64(5100), result.FeeAmount)
+	assert.Nil(t, result.VolumeTier)
+}
+
+func TestPricingStatus(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryVolumeRepository()
+	require.NoError(t, repo.RecordVolume(ctx, &models.VolumeEntry{CustomerID: "key_mid", PaymentID: "pay_1", AmountUSD: 20000000, CompletedAt: time.Now()}))
+
+	tiers, err := fees.ParseVolumeTiers("10000000=0.022,100000000=0.018")
+	require.NoError(t, err)
+	calc := fees.NewCalculator()
+	calc.EnableVolumeDiscounts(repo, tiers)
+
+	status, err := calc.PricingStatus(ctx, "key_mid")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20000000), status.Volume)
+	assert.Equal(t, 30, status.WindowDays)
+	require.NotNil(t, status.CurrentTier)
+	assert.Equal(t, 0.022, status.CurrentTier.Rate)
+	require.NotNil(t, status.NextTier)
+	assert.Equal(t, int64(100000000), status.NextTier.MinVolume)
+
+	status, err = calc.PricingStatus(ctx, "key_none")
+	require.NoError(t, err)
+	assert.Nil(t, status.CurrentTier)
+	assert.Equal(t, int64(10000000), status.NextTier.MinVolume)
+}
+
+func TestPaymentVolumeUSD(t *testing.T) {
+	volume, ok := (&models.Payment{Amount: 100000, Currency: "EUR"}).VolumeUSD()
+	assert.True(t, ok)
+	assert.Equal(t, int64(100000), volume)
+
+	volume, ok = (&models.Payment{Amount: 100000, SourceCurrency: "EUR", Currency: "USD", ExpectedRate: 1.1}).VolumeUSD()
+	assert.True(t, ok)
+	assert.InDelta(t, 110000, volume, 1)
+
+	_, ok = (&models.Payment{Amount: 100000, SourceCurrency: "EUR", Currency: "GBP"}).VolumeUSD()
+	assert.False(t, ok)
+
+	assert.Equal(t, "key_1", models.CustomerID("key_1", "acct_1"))
+	assert.Equal(t, "acct_1", models.CustomerID("", "acct_1"))
+}
@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/payment"
+	"crypto-conversion/internal/validator"
+)
+
+func TestValidateAddress(t *testing.T) {
+	valid := map[string]string{
+		"checksummed EVM": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"lowercase EVM":   "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"uppercase EVM":   "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+	}
+	for name, address := range valid {
+		assert.NoError(t, validator.ValidateAddress("base", address), name)
+	}
+	assert.NoError(t, validator.ValidateAddress("Solana", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"))
+
+	invalid := map[string]struct{ chain, address, reason string }{
+		"bad checksum":        {"ethereum", "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "checksum"},
+		"short EVM":           {"polygon", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "valid polygon address"},
+		"Solana on EVM chain": {"arbitrum", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "valid arbitrum address"},
+		"EVM on Solana":       {"solana", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "valid Solana address"},
+		"not base58":          {"solana", "0PjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "valid Solana address"},
+		"short Solana":        {"solana", "EPjFWdd5AufqSSqeM2qN1xzy", "valid Solana address"},
+		"unsupported chain":   {"tron", "TLa2f6VPqDgRE67v1736s7bJ8Ray5wYjU7", "not supported"},
+	}
+	for name, tc := range invalid {
+		err := validator.ValidateAddress(tc.chain, tc.address)
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.reason, name)
+		}
+	}
+}
+
+func TestValidateWalletPaymentRequest(t *testing.T) {
+	wallet := func(mutate func(*models.PaymentRequest)) error {
+		req := &models.PaymentRequest{
+			Amount:             100000,
+			Currency:           "USDC",
+			SourceAccount:      "user123",
+			DestinationAccount: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			Chain:              "base",
+			PayoutType:         models.PayoutTypeWallet,
+		}
+		mutate(req)
+		return validator.ValidatePaymentRequest(req)
+	}
+
+	assert.NoError(t, wallet(func(r *models.PaymentRequest) {}))
+	assert.ErrorContains(t, wallet(func(r *models.PaymentRequest) { r.Currency = "EUR" }), "USDC")
+	assert.ErrorContains(t, wallet(func(r *models.PaymentRequest) { r.Chain = "" }), "required for wallet payouts")
+	assert.ErrorContains(t, wallet(func(r *models.PaymentRequest) { r.Chain = "solana" }), "Solana address")
+	assert.ErrorContains(t, wallet(func(r *models.PaymentRequest) { r.PayoutType = "card" }), "payout_type")
+	assert.ErrorContains(t, wallet(func(r *models.PaymentRequest) { r.PayoutType = models.PayoutTypeBank }), "currency",
+		"bank payouts can't pay out USDC")
+}
+
+func TestWalletPayoutSkipsOfframp(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:          "pay_wallet",
+		IdempotencyKey:     "key_wallet",
+		Amount:             100000,
+		Currency:           "USDC",
+		SourceCurrency:     "EUR",
+		DestinationAccount: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		PayoutType:         models.PayoutTypeWallet,
+		Chain:              "base",
+		Status:             models.StatusOnrampComplete,
+		CreatedAt:          time.Now(),
+	}))
+
+	queue := &recordingQueue{}
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, queue,
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+	sm.EnableWalletPayouts(payment.NewStatefulWalletClient())
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_wallet"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_wallet")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusWalletPending, stored.Status)
+	assert.Contains(t, stored.WalletTxID, "wallet_base_")
+	assert.Empty(t, stored.OffRampTxID)
+
+	// The transfer is included within two polls
+	for i := 0; i < 2 && stored.Status == models.StatusWalletPending; i++ {
+		require.NoError(t, sm.ProcessPayment(ctx, queue.jobs[len(queue.jobs)-1]))
+		stored, err = repo.GetPaymentByID(ctx, "pay_wallet")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.StatusCompleted, stored.Status)
+	assert.NotNil(t, stored.ProcessedAt)
+	assert.Empty(t, stored.OffRampTxID, "the off-ramp is never used")
+}
+
+func TestWalletPayoutReversesWhenDisabled(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewMemoryPaymentRepository()
+	require.NoError(t, repo.CreatePayment(ctx, &models.Payment{
+		PaymentID:      "pay_wallet",
+		IdempotencyKey: "key_wallet",
+		Amount:         100000,
+		Currency:       "USDC",
+		PayoutType:     models.PayoutTypeWallet,
+		Chain:          "base",
+		Status:         models.StatusOnrampComplete,
+	}))
+
+	sm := payment.NewStateMachine(payment.NewStatefulOnRampClient(), payment.NewStatefulOffRampClient(), repo, &recordingQueue{},
+		payment.DefaultPollingConfig(), nil, nil, nil, payment.SlippageConfig{})
+
+	require.NoError(t, sm.ProcessPayment(ctx, &models.PaymentJob{PaymentID: "pay_wallet"}))
+	stored, err := repo.GetPaymentByID(ctx, "pay_wallet")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusReversing, stored.Status)
+	assert.Empty(t, stored.OffRampTxID, "wallet payouts never fall back to the off-ramp")
+}
@@ -0,0 +1,194 @@
+package unit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"crypto-conversion/internal/database"
+	"crypto-conversion/internal/errors"
+	"crypto-conversion/internal/models"
+	"crypto-conversion/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientCertificateSecret returns an mTLS secret holding a self-signed certificate valid until notAfter
+func clientCertificateSecret(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "payments-client"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	secret, err := json.Marshal(map[string]string{
+		"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	})
+	require.NoError(t, err)
+	return string(secret)
+}
+
+func TestWebhookSubscriptionsAreScopedToTheirCustomer(t *testing.T) {
+	ctx := context.Background()
+	service := webhooks.New(database.NewMemoryWebhookSubscriptionRepository(), database.NewMemoryWebhookDeliveryRepository(), nil)
+
+	_, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "http://acme.example.com/hooks"})
+	require.Error(t, err, "endpoints must use https")
+	_, err = service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "https://10.0.0.5/hooks"})
+	require.Error(t, err, "endpoints on internal networks are refused")
+
+	all, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "https://acme.example.com/hooks"})
+	require.NoError(t, err)
+	assert.Contains(t, all.SubscriptionID, "whsub_")
+	completed, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{
+		URL:        "https://acme.example.com/completed",
+		EventTypes: []string{"payment.completed"},
+	})
+	require.NoError(t, err)
+
+	deliveries, err := service.Deliveries(ctx, "key_acme", "payment.processing")
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, all.SubscriptionID, deliveries[0].SubscriptionID)
+	deliveries, err = service.Deliveries(ctx, "key_acme", "payment.completed")
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	deliveries, err = service.Deliveries(ctx, "key_other", "payment.completed")
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	err = service.Delete(ctx, "key_other", completed.SubscriptionID)
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, "WEBHOOK_SUBSCRIPTION_NOT_FOUND", appErr.Code, "another customer's subscription looks missing")
+
+	require.NoError(t, service.Delete(ctx, "key_acme", completed.SubscriptionID))
+	list, err := service.List(ctx, "key_acme")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestWebhookMTLSRefusesABadCertificate(t *testing.T) {
+	ctx := context.Background()
+	secrets := map[string]string{
+		"crypto-conversion/webhooks/valid":   clientCertificateSecret(t, time.Now().Add(30*24*time.Hour)),
+		"crypto-conversion/webhooks/expired": clientCertificateSecret(t, time.Now().Add(-time.Hour)),
+		"crypto-conversion/webhooks/garbled": `{"certificate": "not a pem", "private_key": "nor this"}`,
+	}
+	clients := webhooks.NewClientCacheWithFetcher(func(ctx context.Context, secretID string) (string, error) {
+		return secrets[secretID], nil
+	}, time.Minute)
+	service := webhooks.New(database.NewMemoryWebhookSubscriptionRepository(), database.NewMemoryWebhookDeliveryRepository(), clients)
+
+	sub, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "https://acme.example.com/hooks"})
+	require.NoError(t, err)
+
+	for _, secretID := range []string{"crypto-conversion/webhooks/expired", "crypto-conversion/webhooks/garbled", "crypto-conversion/webhooks/missing"} {
+		_, err := service.SetMTLS(ctx, sub.SubscriptionID, &models.WebhookMTLSRequest{SecretID: secretID})
+		require.Error(t, err, secretID)
+	}
+
+	updated, err := service.SetMTLS(ctx, sub.SubscriptionID, &models.WebhookMTLSRequest{SecretID: "crypto-conversion/webhooks/valid"})
+	require.NoError(t, err)
+	require.NotNil(t, updated.MTLS)
+	assert.Equal(t, "crypto-conversion/webhooks/valid", updated.MTLS.SecretID)
+
+	cleared, err := service.SetMTLS(ctx, sub.SubscriptionID, &models.WebhookMTLSRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, cleared.MTLS, "an empty secret turns mTLS off")
+}
+
+func TestWebhookMTLSClientPresentsTheCertificateAndIsCached(t *testing.T) {
+	ctx := context.Background()
+	secret := clientCertificateSecret(t, time.Now().Add(30*24*time.Hour))
+	fetches := 0
+	clients := webhooks.NewClientCacheWithFetcher(func(ctx context.Context, secretID string) (string, error) {
+		fetches++
+		return secret, nil
+	}, time.Minute)
+	mtls := &models.WebhookMTLS{SecretID: "crypto-conversion/webhooks/acme"}
+
+	client, err := clients.Client(ctx, mtls)
+	require.NoError(t, err)
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Len(t, transport.TLSClientConfig.Certificates, 1)
+	assert.Equal(t, "payments-client", transport.TLSClientConfig.Certificates[0].Leaf.Subject.CommonName)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+
+	again, err := clients.Client(ctx, mtls)
+	require.NoError(t, err)
+	assert.Same(t, client, again)
+	assert.Equal(t, 1, fetches, "the certificate is fetched once per TTL")
+}
+
+func TestWebhookDeliveryStatsWithoutDeliveries(t *testing.T) {
+	since := time.Now().Add(-time.Hour)
+	stats := webhooks.DeliveryStats("whsub_quiet", since, nil)
+	assert.Zero(t, stats.Deliveries)
+	assert.Zero(t, stats.SuccessRate)
+	assert.Zero(t, stats.P95LatencyMs)
+	assert.NotNil(t, stats.RecentFailures, "an empty list, not null, is returned")
+}
+
+func TestWebhookSecretRotationKeepsThePreviousSecretActive(t *testing.T) {
+	ctx := context.Background()
+	service := webhooks.New(database.NewMemoryWebhookSubscriptionRepository(), database.NewMemoryWebhookDeliveryRepository(), nil)
+
+	sub, err := service.Create(ctx, "key_acme", &models.WebhookSubscriptionRequest{URL: "https://acme.example.com/hooks"})
+	require.NoError(t, err)
+	require.Len(t, sub.SigningSecrets, 1)
+	original := sub.SigningSecrets[0]
+	assert.Contains(t, original, "whsec_")
+
+	_, err = service.RotateSecret(ctx, "key_other", sub.SubscriptionID)
+	require.Error(t, err, "only the owner can rotate")
+
+	rotated, err := service.RotateSecret(ctx, "key_acme", sub.SubscriptionID)
+	require.NoError(t, err)
+	require.Len(t, rotated.SigningSecrets, 2)
+	assert.NotEqual(t, original, rotated.SigningSecrets[0])
+	assert.Equal(t, original, rotated.SigningSecrets[1])
+	require.NotNil(t, rotated.PreviousSecretExpiresAt)
+
+	now := time.Now()
+	assert.Equal(t, rotated.SigningSecrets, rotated.ActiveSecrets(now), "both secrets sign during the overlap")
+	assert.Equal(t, rotated.SigningSecrets[:1], rotated.ActiveSecrets(now.Add(webhooks.PreviousSecretTTL)), "the previous secret expires")
+
+	again, err := service.RotateSecret(ctx, "key_acme", sub.SubscriptionID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{again.SigningSecrets[0], rotated.SigningSecrets[0]}, again.SigningSecrets, "only one previous secret is kept")
+}
+
+func TestWebhookSignatureVerifiesWithEitherActiveSecret(t *testing.T) {
+	payload := []byte(`{"event_type":"payment.completed","payment_id":"pay_1"}`)
+	now := time.Now()
+	header := webhooks.Sign([]string{"whsec_new", "whsec_old"}, now, payload)
+
+	assert.NoError(t, webhooks.Verify(header, "whsec_new", payload, 5*time.Minute, now))
+	assert.NoError(t, webhooks.Verify(header, "whsec_old", payload, 5*time.Minute, now), "receivers still on the old secret verify")
+	assert.Error(t, webhooks.Verify(header, "whsec_other", payload, 5*time.Minute, now))
+	assert.Error(t, webhooks.Verify(header, "whsec_new", []byte(`{"tampered":true}`), 5*time.Minute, now))
+	assert.Error(t, webhooks.Verify(header, "whsec_new", payload, 5*time.Minute, now.Add(time.Hour)), "old deliveries can't be replayed")
+}